@@ -807,6 +807,9 @@ type StorageConfig struct {
 	// through to C CCL code to set up encryption-at-rest.  Must be set if and
 	// only if encryption is enabled, otherwise left empty.
 	EncryptionOptions []byte
+	// NonVoting is true if the store is in non-voting mode, see
+	// StoreSpec.NonVoting.
+	NonVoting bool
 }
 
 // IsEncrypted returns whether the StorageConfig has encryption enabled.
@@ -272,6 +272,9 @@ type StoreSpec struct {
 	EncryptionOptions []byte
 	// ProvisionedRateSpec is optional.
 	ProvisionedRateSpec ProvisionedRateSpec
+	// NonVoting is true if the store is in non-voting mode: it only holds
+	// non-voting replicas and no leases, see StoreProperties.NonVoting.
+	NonVoting bool
 }
 
 // String returns a fully parsable version of the store spec.
@@ -324,6 +327,9 @@ func (ss StoreSpec) String() string {
 			fmt.Fprintf(&buffer, ",")
 		}
 	}
+	if ss.NonVoting {
+		fmt.Fprint(&buffer, "mode=non-voting,")
+	}
 	// Trim the extra comma from the end if it exists.
 	if l := buffer.Len(); l > 0 {
 		buffer.Truncate(l - 1)
@@ -370,6 +376,9 @@ var fractionRegex = regexp.MustCompile(`^([-]?([0-9]+\.[0-9]*|[0-9]*\.[0-9]+|[0-
 //     provisioned-rate can be used for admission control for operations on the
 //     store. The bandwidth is optional, and if unspecified, a cluster setting
 //     (kvadmission.store.provisioned_bandwidth) will be used.
+//   - mode=non-voting The optional mode of the store. A store in non-voting mode
+//     only holds non-voting replicas, which serve follower reads, and never
+//     holds leases. The default mode is "default".
 //
 // Note that commas are forbidden within any field name or value.
 func NewStoreSpec(value string) (StoreSpec, error) {
@@ -499,6 +508,14 @@ func NewStoreSpec(value string) (StoreSpec, error) {
 				return StoreSpec{}, err
 			}
 			ss.ProvisionedRateSpec = rateSpec
+		case "mode":
+			switch value {
+			case "default":
+			case "non-voting":
+				ss.NonVoting = true
+			default:
+				return StoreSpec{}, fmt.Errorf("%s is not a valid store mode", value)
+			}
 
 		default:
 			return StoreSpec{}, fmt.Errorf("%s is not a valid store field", field)
@@ -158,6 +158,11 @@ target_file_size=2097152`
 			Path: "/mnt/hda1", ProvisionedRateSpec: base.ProvisionedRateSpec{
 				DiskName: "sdb", ProvisionedBandwidth: 0}}},
 
+		// mode
+		{"path=/mnt/hda1,mode=non-voting", "", StoreSpec{Path: "/mnt/hda1", NonVoting: true}},
+		{"path=/mnt/hda1,mode=default", "", StoreSpec{Path: "/mnt/hda1"}},
+		{"path=/mnt/hda1,mode=archival", "archival is not a valid store mode", StoreSpec{}},
+
 		// RocksDB
 		{"path=/,rocksdb=key1=val1;key2=val2", "", StoreSpec{Path: "/", RocksDBOptions: "key1=val1;key2=val2"}},
 
@@ -319,6 +319,13 @@ func TestPebbleEncryption(t *testing.T) {
 	require.LessOrEqual(t, uint64(5), stats.ActiveKeyFiles)
 	require.Equal(t, stats.TotalBytes, stats.ActiveKeyBytes)
 
+	// All the data is in the sstable encrypted with the active key.
+	spanStats, err := db.GetSpanEncryptionStats(roachpb.KeyMin, roachpb.KeyMax)
+	require.NoError(t, err)
+	require.NotEqual(t, "plain", spanStats.ActiveKeyID)
+	require.Greater(t, spanStats.TotalBytes, uint64(0))
+	require.Equal(t, spanStats.TotalBytes, spanStats.ActiveKeyBytes)
+
 	db.Close()
 }
 
@@ -420,6 +427,91 @@ func TestPebbleEncryption2(t *testing.T) {
 	addKeyAndValidate("d", "d", "plain", "16v2.key")
 }
 
+// TestPebbleSpanEncryptionStatsKeyRotation verifies that the encryption stats
+// of a span report the data written before a rotation of the data key under
+// the old key, until a compaction of the span re-encrypts it.
+func TestPebbleSpanEncryptionStatsKeyRotation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	memFS := vfs.NewMem()
+	writeToFile(t, memFS, "16v1.key", []byte("111111111111111111111111111111111234567890123456"))
+	writeToFile(t, memFS, "16v2.key", []byte("111111111111111111111111111111198765432198765432"))
+
+	open := func(encKeyFile, oldEncKeyFile string) storage.Engine {
+		encOptions := baseccl.EncryptionOptions{
+			KeySource: baseccl.EncryptionKeySource_KeyFiles,
+			KeyFiles: &baseccl.EncryptionKeyFiles{
+				CurrentKey: encKeyFile,
+				OldKey:     oldEncKeyFile,
+			},
+			DataKeyRotationPeriod: 1000,
+		}
+		encOptionsBytes, err := protoutil.Marshal(&encOptions)
+		require.NoError(t, err)
+
+		opts := storage.DefaultPebbleOptions()
+		opts.FS = memFS
+		opts.Cache = pebble.NewCache(1 << 20)
+		defer opts.Cache.Unref()
+
+		db, err := storage.NewPebble(
+			context.Background(),
+			storage.PebbleConfig{
+				StorageConfig: base.StorageConfig{
+					Settings:          cluster.MakeTestingClusterSettings(),
+					Attrs:             roachpb.Attributes{},
+					MaxSize:           512 << 20,
+					UseFileRegistry:   true,
+					EncryptionOptions: encOptionsBytes,
+				},
+				Opts: opts,
+			})
+		require.NoError(t, err)
+		return db
+	}
+	put := func(db storage.Engine, key string) {
+		batch := db.NewWriteBatch()
+		defer batch.Close()
+		require.NoError(t, batch.PutUnversioned(roachpb.Key(key), []byte(key)))
+		require.NoError(t, batch.Commit(true))
+		require.NoError(t, db.Flush())
+	}
+
+	db := open("16v1.key", "plain")
+	put(db, "a")
+	before, err := db.GetSpanEncryptionStats(roachpb.KeyMin, roachpb.KeyMax)
+	require.NoError(t, err)
+	oldKeyID := before.ActiveKeyID
+	require.NotEqual(t, "plain", oldKeyID)
+	require.Greater(t, before.TotalBytes, uint64(0))
+	require.Equal(t, before.TotalBytes, before.ActiveKeyBytes)
+	require.Equal(t, map[string]uint64{oldKeyID: before.TotalBytes}, before.BytesByKeyID)
+	db.Close()
+
+	// Rotating the store key rotates the data key. The existing sstable stays
+	// encrypted with the old data key, while the new one uses the new key.
+	db = open("16v2.key", "16v1.key")
+	defer db.Close()
+	put(db, "b")
+	rotated, err := db.GetSpanEncryptionStats(roachpb.KeyMin, roachpb.KeyMax)
+	require.NoError(t, err)
+	newKeyID := rotated.ActiveKeyID
+	require.NotEqual(t, oldKeyID, newKeyID)
+	require.Len(t, rotated.BytesByKeyID, 2)
+	require.Equal(t, before.TotalBytes, rotated.BytesByKeyID[oldKeyID])
+	require.Equal(t, rotated.BytesByKeyID[newKeyID], rotated.ActiveKeyBytes)
+	require.Less(t, rotated.ActiveKeyBytes, rotated.TotalBytes)
+
+	// Compacting the span re-encrypts all of its data with the active key.
+	require.NoError(t, db.CompactRange(roachpb.KeyMin, roachpb.KeyMax))
+	compacted, err := db.GetSpanEncryptionStats(roachpb.KeyMin, roachpb.KeyMax)
+	require.NoError(t, err)
+	require.Equal(t, newKeyID, compacted.ActiveKeyID)
+	require.Greater(t, compacted.TotalBytes, uint64(0))
+	require.Equal(t, compacted.TotalBytes, compacted.ActiveKeyBytes)
+	require.Equal(t, map[string]uint64{newKeyID: compacted.TotalBytes}, compacted.BytesByKeyID)
+}
+
 func TestCanRegistryElide(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -1009,6 +1009,16 @@ For example:
   --store=provisioned-rate=disk-name=nvme1n1
   --store=provisioned-rate=disk-name=sdb:bandwidth=250MiB/s
 
+</PRE>
+Optionally, the "mode" field can be set to "non-voting" for a store that only
+holds non-voting replicas, which serve follower reads and historical reads,
+but that is excluded from the voting quorums and the leaseholdership of
+ranges. This is useful to scale reads or to archive data on cheaper hardware.
+For example:
+<PRE>
+
+  --store=path=/mnt/hdd01,mode=non-voting
+
 </PRE>
 Commas are forbidden in all values, since they are used to separate fields.
 Also, if you use equal signs in the file path to a store, you must use the
@@ -1355,6 +1355,8 @@ var debugCmds = []*cobra.Command{
 	debugListFilesCmd,
 	debugResetQuorumCmd,
 	debugSendKVBatchCmd,
+	debugPauseQueueCmd,
+	debugResumeQueueCmd,
 	debugRecoverCmd,
 }
 
@@ -1556,6 +1558,18 @@ func init() {
 		"whether to keep the CollectedSpans field on the response, to learn about how traces work")
 	f.StringVar(&debugSendKVBatchContext.traceFile, "trace-output", debugSendKVBatchContext.traceFile,
 		"the output file to use for the trace. If left empty, output to stderr.")
+
+	for _, cmd := range []*cobra.Command{debugPauseQueueCmd, debugResumeQueueCmd} {
+		f := cmd.Flags()
+		f.IntVar(&debugQueuePauseContext.nodeID, "node", debugQueuePauseContext.nodeID,
+			"the node on which to pause or resume the queue (0 for all nodes)")
+		f.IntVar(&debugQueuePauseContext.storeID, "store", debugQueuePauseContext.storeID,
+			"the store on which to pause or resume the queue (0 for all stores); requires --node")
+		f.IntVar(&debugQueuePauseContext.rangeID, "range", debugQueuePauseContext.rangeID,
+			"the range to pause or resume (0 for all ranges)")
+		f.StringVar(&debugQueuePauseContext.reason, "reason", debugQueuePauseContext.reason,
+			"the reason for the pause, recorded in the event log")
+	}
 }
 
 func initPebbleCmds(cmd *cobra.Command, pebbleTool *tool.T) {
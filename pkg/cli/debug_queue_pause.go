@@ -0,0 +1,127 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/cli/clierrorplus"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/errors"
+	"github.com/spf13/cobra"
+)
+
+// debugQueuePauseContext captures the command-line parameters of the `debug
+// pause-queue` and `debug resume-queue` commands.
+var debugQueuePauseContext = struct {
+	// nodeID is the node on which to pause or resume the queue, or 0 for all
+	// nodes.
+	nodeID int
+	// storeID is the store on which to pause or resume the queue, or 0 for all
+	// stores of the targeted node(s).
+	storeID int
+	// rangeID is the range to pause or resume, or 0 for all ranges.
+	rangeID int
+	// reason is the reason for the pause, recorded in the event log.
+	reason string
+}{}
+
+func setDebugQueuePauseContextDefaults() {
+	debugQueuePauseContext.nodeID = 0
+	debugQueuePauseContext.storeID = 0
+	debugQueuePauseContext.rangeID = 0
+	debugQueuePauseContext.reason = ""
+}
+
+var debugPauseQueueCmd = &cobra.Command{
+	Use:   "pause-queue <queue>",
+	Short: "pause a replica queue on one or more stores",
+	Long: `
+Pauses the named replica queue (e.g. split, merge, raftlog, replicate or
+mvccGC) on all stores of the cluster, or on the stores selected with --node
+and --store. The queue stops processing all ranges, or only the range selected
+with --range, until it is resumed with 'debug resume-queue'.
+
+Pauses are held in memory, and are lost when a node restarts. Every pause is
+recorded in the structured event log along with the given --reason. Requires
+the admin role.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: clierrorplus.MaybeDecorateError(func(cmd *cobra.Command, args []string) error {
+		return runDebugSetQueuePaused(args[0], true /* paused */)
+	}),
+}
+
+var debugResumeQueueCmd = &cobra.Command{
+	Use:   "resume-queue <queue>",
+	Short: "resume a replica queue paused with 'debug pause-queue'",
+	Long: `
+Resumes the named replica queue on all stores of the cluster, or on the stores
+selected with --node and --store, for all ranges, or only for the range
+selected with --range. Resuming a queue for all ranges does not resume the
+ranges which were paused individually.
+
+Every resumption is recorded in the structured event log. Requires the admin
+role.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: clierrorplus.MaybeDecorateError(func(cmd *cobra.Command, args []string) error {
+		return runDebugSetQueuePaused(args[0], false /* paused */)
+	}),
+}
+
+func runDebugSetQueuePaused(queue string, paused bool) error {
+	if paused && debugQueuePauseContext.reason == "" {
+		return errors.New("a --reason must be given when pausing a queue")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn, finish, err := getClientGRPCConn(ctx, serverCfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to the node")
+	}
+	defer finish()
+
+	resp, err := serverpb.NewAdminClient(conn).SetQueuePaused(ctx, &serverpb.SetQueuePausedRequest{
+		NodeID:  roachpb.NodeID(debugQueuePauseContext.nodeID),
+		StoreID: roachpb.StoreID(debugQueuePauseContext.storeID),
+		Queue:   queue,
+		RangeID: roachpb.RangeID(debugQueuePauseContext.rangeID),
+		Paused:  paused,
+		Reason:  debugQueuePauseContext.reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 2, 1, 2, ' ', 0)
+	fmt.Fprintln(w, "node\tstore\tchanged\tpaused ranges\tsince\treason\terror")
+	for _, d := range resp.Details {
+		if len(d.Pauses) == 0 {
+			fmt.Fprintf(w, "n%d\ts%d\t%t\t\t\t\t%s\n", d.NodeID, d.StoreID, d.Changed, d.Error)
+		}
+		for _, p := range d.Pauses {
+			ranges := "all"
+			if p.RangeID != 0 {
+				ranges = fmt.Sprintf("r%d", p.RangeID)
+			}
+			fmt.Fprintf(w, "n%d\ts%d\t%t\t%s\t%s\t%s\t%s\n", d.NodeID, d.StoreID, d.Changed,
+				ranges, p.Since.Format(time.RFC3339), p.Reason, d.Error)
+		}
+	}
+	return w.Flush()
+}
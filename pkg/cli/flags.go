@@ -627,6 +627,8 @@ func init() {
 		debugZipCmd,
 		debugListFilesCmd,
 		debugSendKVBatchCmd,
+		debugPauseQueueCmd,
+		debugResumeQueueCmd,
 		doctorExamineClusterCmd,
 		doctorExamineFallbackClusterCmd,
 		doctorRecreateClusterCmd,
@@ -300,6 +300,48 @@ const (
 	// progress columns from system.jobs table.
 	V24_1_DropPayloadAndProgressFromSystemJobsTable
 
+	// V24_1_WriteFences enables installing range-level write fences through
+	// the WriteFence request.
+	V24_1_WriteFences
+
+	// V24_1_ReplicaPins enables pinning the voters of ranges to stores through
+	// the PinReplicas request.
+	V24_1_ReplicaPins
+
+	// V24_1_StoreLiveness enables the per-store liveness epochs, which allow a
+	// node to keep heartbeating its liveness record when the disk of one of its
+	// stores fails.
+	V24_1_StoreLiveness
+
+	// V24_1_ColdCompaction enables the per-range cold compaction policies set
+	// through the ColdCompaction request.
+	V24_1_ColdCompaction
+
+	// V24_1_RelocateToLocality enables relocating ranges onto the stores of a
+	// locality through the AdminRelocateToLocality request.
+	V24_1_RelocateToLocality
+
+	// V24_1_CheckAndMutate enables the CheckAndMutate request, which atomically
+	// applies a set of conditional puts and deletes to keys of a single range.
+	V24_1_CheckAndMutate
+
+	// V24_1_MVCCValueExpiration enables writes with an expiration, which is
+	// stored in the MVCC value header and enforced by reads and GC.
+	V24_1_MVCCValueExpiration
+
+	// V24_1_CommitTimestampFloor enables transactions with a commit timestamp
+	// floor sourced from an external HLC, which ranges validate against their
+	// closed timestamps.
+	V24_1_CommitTimestampFloor
+
+	// V24_1_HotStandby enables designating hot standby replicas through the
+	// HotStandby request.
+	V24_1_HotStandby
+
+	// V24_1_HashShardedSpans enables the hash_shards zone config field, which
+	// spreads the keys of a table or index over virtual shards.
+	V24_1_HashShardedSpans
+
 	numKeys
 )
 
@@ -368,6 +410,16 @@ var versionTable = [numKeys]roachpb.Version{
 	// *************************************************
 
 	V24_1_DropPayloadAndProgressFromSystemJobsTable: {Major: 23, Minor: 2, Internal: 4},
+	V24_1_WriteFences:          {Major: 23, Minor: 2, Internal: 6},
+	V24_1_ReplicaPins:          {Major: 23, Minor: 2, Internal: 8},
+	V24_1_StoreLiveness:        {Major: 23, Minor: 2, Internal: 10},
+	V24_1_ColdCompaction:       {Major: 23, Minor: 2, Internal: 12},
+	V24_1_RelocateToLocality:   {Major: 23, Minor: 2, Internal: 14},
+	V24_1_CheckAndMutate:       {Major: 23, Minor: 2, Internal: 16},
+	V24_1_MVCCValueExpiration:  {Major: 23, Minor: 2, Internal: 18},
+	V24_1_CommitTimestampFloor: {Major: 23, Minor: 2, Internal: 20},
+	V24_1_HotStandby:           {Major: 23, Minor: 2, Internal: 22},
+	V24_1_HashShardedSpans:     {Major: 23, Minor: 2, Internal: 24},
 }
 
 // Latest is always the highest version key. This is the maximum logical cluster
@@ -35,6 +35,8 @@ const (
 	Constraints            // constraints
 	VoterConstraints       // voter_constraints
 	LeasePreferences       // lease_preferences
+	GCMaxVersions          // gc.max_versions
+	HashShards             // hash_shards
 
 	// NumFields is the number of fields in the config.
 	NumFields int = iota - 1
@@ -17,6 +17,8 @@ func _() {
 	_ = x[Constraints-7]
 	_ = x[VoterConstraints-8]
 	_ = x[LeasePreferences-9]
+	_ = x[GCMaxVersions-10]
+	_ = x[HashShards-11]
 }
 
 func (i Field) String() string {
@@ -39,6 +41,10 @@ func (i Field) String() string {
 		return "voter_constraints"
 	case LeasePreferences:
 		return "lease_preferences"
+	case GCMaxVersions:
+		return "gc.max_versions"
+	case HashShards:
+		return "hash_shards"
 	default:
 		return "Field(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
@@ -413,6 +413,14 @@ func (z *ZoneConfig) Validate() error {
 	if z.GC != nil && z.GC.TTLSeconds < 1 {
 		return fmt.Errorf("GC.TTLSeconds %d less than minimum allowed 1", z.GC.TTLSeconds)
 	}
+	if z.GC != nil && z.GC.MaxVersions < 0 {
+		return fmt.Errorf("GC.MaxVersions %d less than minimum allowed 0", z.GC.MaxVersions)
+	}
+	if z.HashShards != nil && *z.HashShards != 0 &&
+		(*z.HashShards < 2 || *z.HashShards > roachpb.MaxHashShards) {
+		return fmt.Errorf("HashShards %d must be 0 or between 2 and %d",
+			*z.HashShards, roachpb.MaxHashShards)
+	}
 
 	for _, constraints := range z.Constraints {
 		for _, constraint := range constraints.Constraints {
@@ -594,6 +602,8 @@ func (z *ZoneConfig) InheritFromParent(parent *ZoneConfig) {
 		z.LeasePreferences = parent.LeasePreferences
 		z.InheritedLeasePreferences = false
 	}
+	// HashShards isn't inherited: it determines the layout of the data of the
+	// span of the zone itself.
 }
 
 // CopyFromZone copies over the specified fields from the other zone.
@@ -625,12 +635,17 @@ func (z *ZoneConfig) CopyFromZone(other ZoneConfig, fieldList []tree.Name) {
 			if other.GlobalReads != nil {
 				z.GlobalReads = proto.Bool(*other.GlobalReads)
 			}
-		case "gc.ttlseconds":
+		case "gc.ttlseconds", "gc.max_versions":
 			z.GC = nil
 			if other.GC != nil {
 				tempGC := *other.GC
 				z.GC = &tempGC
 			}
+		case "hash_shards":
+			z.HashShards = nil
+			if other.HashShards != nil {
+				z.HashShards = proto.Int32(*other.HashShards)
+			}
 		case "constraints":
 			z.Constraints = other.Constraints
 			z.InheritedConstraints = other.InheritedConstraints
@@ -725,6 +740,18 @@ func (z *ZoneConfig) DiffWithZone(
 					Actual:   int32ToString(z.NumVoters),
 				}, nil
 			}
+		case "hash_shards":
+			if other.HashShards == nil && z.HashShards == nil {
+				continue
+			}
+			if z.HashShards == nil || other.HashShards == nil ||
+				*z.HashShards != *other.HashShards {
+				return false, DiffWithZoneMismatch{
+					Field:    "hash_shards",
+					Expected: int32ToString(other.HashShards),
+					Actual:   int32ToString(z.HashShards),
+				}, nil
+			}
 		case "range_min_bytes":
 			if other.RangeMinBytes == nil && z.RangeMinBytes == nil {
 				continue
@@ -765,13 +792,24 @@ func (z *ZoneConfig) DiffWithZone(
 			if other.GC == nil && z.GC == nil {
 				continue
 			}
-			if z.GC == nil || other.GC == nil || *z.GC != *other.GC {
+			if z.GC == nil || other.GC == nil || z.GC.TTLSeconds != other.GC.TTLSeconds {
 				return false, DiffWithZoneMismatch{
 					Field:    "gc.ttlseconds",
 					Expected: int32ToString(&other.GC.TTLSeconds),
 					Actual:   int32ToString(&z.GC.TTLSeconds),
 				}, nil
 			}
+		case "gc.max_versions":
+			if other.GC == nil && z.GC == nil {
+				continue
+			}
+			if z.GC == nil || other.GC == nil || z.GC.MaxVersions != other.GC.MaxVersions {
+				return false, DiffWithZoneMismatch{
+					Field:    "gc.max_versions",
+					Expected: int32ToString(&other.GC.MaxVersions),
+					Actual:   int32ToString(&z.GC.MaxVersions),
+				}, nil
+			}
 		case "constraints":
 			if other.Constraints == nil && z.Constraints == nil {
 				continue
@@ -1239,6 +1277,7 @@ func (z *ZoneConfig) toSpanConfig() (roachpb.SpanConfig, error) {
 	sc.RangeMinBytes = *z.RangeMinBytes
 	sc.RangeMaxBytes = *z.RangeMaxBytes
 	sc.GCPolicy.TTLSeconds = z.GC.TTLSeconds
+	sc.GCPolicy.MaxVersions = z.GC.MaxVersions
 
 	// GlobalReads is false by default.
 	if z.GlobalReads != nil {
@@ -1248,6 +1287,9 @@ func (z *ZoneConfig) toSpanConfig() (roachpb.SpanConfig, error) {
 	if z.NumVoters != nil {
 		sc.NumVoters = *z.NumVoters
 	}
+	if z.HashShards != nil {
+		sc.HashShards = *z.HashShards
+	}
 
 	toSpanConfigConstraints := func(src []Constraint) ([]roachpb.Constraint, error) {
 		spanConfigConstraints := make([]roachpb.Constraint, len(src))
@@ -16,17 +16,28 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/inspectz/inspectzpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvflowcontrol"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvflowcontrol/kvflowinspectpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
 )
 
 // URLPrefix is the prefix for all inspectz endpoints hosted by the server.
 const URLPrefix = "/inspectz/"
 
+// TopRangesInspector ranks the replicas on a node's stores by their recent
+// activity. It's implemented by *kvserver.Stores.
+type TopRangesInspector interface {
+	InspectTopRanges(
+		context.Context, *kvserverpb.TopRangesRequest,
+	) (*kvserverpb.TopRangesResponse, error)
+}
+
 // Server is a concrete implementation of the InspectzServer interface,
 // organizing everything under /inspectz/*. It's the top-level component that
 // houses parsing logic for common inspectz URL parameters and maintains routing
@@ -37,6 +48,7 @@ type Server struct {
 	mux              *http.ServeMux
 	handles          kvflowcontrol.Handles
 	kvflowController kvflowcontrol.Controller
+	topRanges        TopRangesInspector
 }
 
 var _ inspectzpb.InspectzServer = &Server{}
@@ -46,6 +58,7 @@ func NewServer(
 	ambient log.AmbientContext,
 	handles kvflowcontrol.Handles,
 	kvflowController kvflowcontrol.Controller,
+	topRanges TopRangesInspector,
 ) *Server {
 	mux := http.NewServeMux()
 	server := &Server{
@@ -54,6 +67,7 @@ func NewServer(
 		mux:              mux,
 		handles:          handles,
 		kvflowController: kvflowController,
+		topRanges:        topRanges,
 	}
 	mux.Handle("/inspectz/kvflowhandles", http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
@@ -86,6 +100,24 @@ func NewServer(
 			respond(ctx, w, http.StatusOK, resp)
 		},
 	))
+	mux.Handle("/inspectz/storetopranges", http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			ctx := server.AnnotateCtx(context.Background())
+
+			req, err := parseTopRangesRequest(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			resp, err := server.StoreTopRanges(ctx, req)
+			if err != nil {
+				log.ErrorfDepth(ctx, 1, "%s", err)
+				http.Error(w, "internal error: check logs for details", http.StatusInternalServerError)
+				return
+			}
+			respond(ctx, w, http.StatusOK, resp)
+		},
+	))
 
 	return server
 }
@@ -117,6 +149,13 @@ func (s *Server) KVFlowHandles(
 	return resp, nil
 }
 
+// StoreTopRanges implements the InspectzServer interface.
+func (s *Server) StoreTopRanges(
+	ctx context.Context, request *kvserverpb.TopRangesRequest,
+) (*kvserverpb.TopRangesResponse, error) {
+	return s.topRanges.InspectTopRanges(ctx, request)
+}
+
 // ServeHTTP serves various tools under the /debug endpoint.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.mux.ServeHTTP(w, r)
@@ -149,3 +188,40 @@ func parseRangeIDs(input string, w http.ResponseWriter) (ranges []roachpb.RangeI
 	}
 	return ranges, true
 }
+
+// parseTopRangesRequest parses the store, order, window and limit URL
+// parameters of /inspectz/storetopranges, e.g.
+// ?store=1&order=mutations&window=5m&limit=10.
+func parseTopRangesRequest(r *http.Request) (*kvserverpb.TopRangesRequest, error) {
+	query := r.URL.Query()
+	req := &kvserverpb.TopRangesRequest{}
+	if store := query.Get("store"); store != "" {
+		storeID, err := strconv.ParseInt(store, 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid store id")
+		}
+		req.StoreID = roachpb.StoreID(storeID)
+	}
+	if order := query.Get("order"); order != "" {
+		v, ok := kvserverpb.TopRangesOrder_value[strings.ToUpper(order)]
+		if !ok {
+			return nil, errors.Newf("invalid order %q", order)
+		}
+		req.OrderBy = kvserverpb.TopRangesOrder(v)
+	}
+	if window := query.Get("window"); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil {
+			return nil, errors.Newf("invalid window %q", window)
+		}
+		req.Window = d
+	}
+	if limit := query.Get("limit"); limit != "" {
+		l, err := strconv.ParseInt(limit, 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid limit")
+		}
+		req.Limit = int32(l)
+	}
+	return req, nil
+}
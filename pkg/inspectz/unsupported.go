@@ -15,6 +15,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/inspectz/inspectzpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvflowcontrol/kvflowinspectpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
 	"github.com/cockroachdb/cockroach/pkg/util/errorutil"
 )
 
@@ -37,3 +38,10 @@ func (u Unsupported) KVFlowHandles(
 ) (*kvflowinspectpb.HandlesResponse, error) {
 	return nil, errorutil.UnsupportedUnderClusterVirtualization(errorutil.FeatureNotAvailableToNonSystemTenantsIssue)
 }
+
+// StoreTopRanges is part of the inspectzpb.InspectzServer interface.
+func (u Unsupported) StoreTopRanges(
+	ctx context.Context, request *kvserverpb.TopRangesRequest,
+) (*kvserverpb.TopRangesResponse, error) {
+	return nil, errorutil.UnsupportedUnderClusterVirtualization(errorutil.FeatureNotAvailableToNonSystemTenantsIssue)
+}
@@ -85,6 +85,12 @@ var (
 	// LocalRangeAppliedStateSuffix is the suffix for the range applied state
 	// key.
 	LocalRangeAppliedStateSuffix = []byte("rask")
+	// LocalRangeColdCompactionSuffix is the suffix for the range's cold
+	// compaction policy.
+	LocalRangeColdCompactionSuffix = []byte("rcld")
+	// LocalRangeHotStandbySuffix is the suffix for the range's hot standby
+	// designation.
+	LocalRangeHotStandbySuffix = []byte("rhsb")
 	// This was previously used for the replicated RaftTruncatedState. It is no
 	// longer used and this key has been removed via a migration. See
 	// LocalRaftTruncatedStateSuffix for the corresponding unreplicated
@@ -97,8 +103,12 @@ var (
 	// LocalRangePriorReadSummarySuffix is the suffix for a range's prior read
 	// summary.
 	LocalRangePriorReadSummarySuffix = []byte("rprs")
+	// LocalRangeReplicaPinSuffix is the suffix for the range's replica pin.
+	LocalRangeReplicaPinSuffix = []byte("rpin")
 	// LocalRangeVersionSuffix is the suffix for the range version.
 	LocalRangeVersionSuffix = []byte("rver")
+	// LocalRangeWriteFencesSuffix is the suffix for the range's write fences.
+	LocalRangeWriteFencesSuffix = []byte("rwfn")
 	// LocalRangeStatsLegacySuffix is the suffix for range statistics.
 	LocalRangeStatsLegacySuffix = []byte("stat")
 	// localTxnSpanGCThresholdSuffix is DEPRECATED and remains to prevent reuse.
@@ -129,6 +139,13 @@ var (
 	// LocalRaftTruncatedStateSuffix is the suffix for the unreplicated
 	// RaftTruncatedState.
 	LocalRaftTruncatedStateSuffix = []byte("rftt")
+	// LocalRangeEventLogSuffix is the suffix for the keys of the replica's log
+	// of range events, which are suffixed by the raft index at which the event
+	// applied.
+	LocalRangeEventLogSuffix = []byte("rlev")
+	// LocalRangeLoadStatsSuffix is the suffix for the summary of the load of
+	// the replica, which is periodically persisted to be restored on restart.
+	LocalRangeLoadStatsSuffix = []byte("rlls")
 
 	// LocalRangeLastReplicaGCTimestampSuffix is the suffix for a range's last
 	// replica GC timestamp (for GC of old replicas).
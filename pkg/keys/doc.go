@@ -198,6 +198,8 @@ var _ = [...]interface{}{
 	RaftLogKey,                     // "rftl"
 	RaftReplicaIDKey,               // "rftr"
 	RaftTruncatedStateKey,          // "rftt"
+	RangeEventLogKey,               // "rlev"
+	RangeLoadStatsKey,              // "rlls"
 	RangeLastReplicaGCTimestampKey, // "rlrt"
 
 	//   3. Range local keys: These also store metadata that pertains to a range
@@ -312,6 +312,31 @@ func RangeGCHintKey(rangeID roachpb.RangeID) roachpb.Key {
 	return MakeRangeIDPrefixBuf(rangeID).RangeGCHintKey()
 }
 
+// RangeWriteFencesKey returns a system-local key for the write fences
+// installed on the range. Writes to a fenced span at or below the fence
+// timestamp will not be served.
+func RangeWriteFencesKey(rangeID roachpb.RangeID) roachpb.Key {
+	return MakeRangeIDPrefixBuf(rangeID).RangeWriteFencesKey()
+}
+
+// RangeReplicaPinKey returns a system-local key for the replica pin of the
+// range, which dictates the stores of its voters.
+func RangeReplicaPinKey(rangeID roachpb.RangeID) roachpb.Key {
+	return MakeRangeIDPrefixBuf(rangeID).RangeReplicaPinKey()
+}
+
+// RangeColdCompactionKey returns a system-local key for the cold compaction
+// policy of the range.
+func RangeColdCompactionKey(rangeID roachpb.RangeID) roachpb.Key {
+	return MakeRangeIDPrefixBuf(rangeID).RangeColdCompactionKey()
+}
+
+// RangeHotStandbyKey returns a system-local key for the hot standby
+// designation of the range.
+func RangeHotStandbyKey(rangeID roachpb.RangeID) roachpb.Key {
+	return MakeRangeIDPrefixBuf(rangeID).RangeHotStandbyKey()
+}
+
 // MVCCRangeKeyGCKey returns a range local key protecting range
 // tombstone mvcc stats calculations during range tombstone GC.
 func MVCCRangeKeyGCKey(rangeID roachpb.RangeID) roachpb.Key {
@@ -388,6 +413,31 @@ func RaftReplicaIDKey(rangeID roachpb.RangeID) roachpb.Key {
 	return MakeRangeIDPrefixBuf(rangeID).RaftReplicaIDKey()
 }
 
+// RangeEventLogPrefix returns the system-local prefix shared by all the
+// entries of a replica's range event log.
+func RangeEventLogPrefix(rangeID roachpb.RangeID) roachpb.Key {
+	return MakeRangeIDPrefixBuf(rangeID).RangeEventLogPrefix()
+}
+
+// RangeEventLogKey returns a system-local key for the entry of a replica's
+// range event log recording the event applied at the given raft index.
+func RangeEventLogKey(rangeID roachpb.RangeID, index kvpb.RaftIndex) roachpb.Key {
+	return MakeRangeIDPrefixBuf(rangeID).RangeEventLogKey(index)
+}
+
+// DecodeRangeEventLogKeyFromSuffix parses the suffix of a system-local key for
+// an entry of a range event log and returns the entry's raft index.
+func DecodeRangeEventLogKeyFromSuffix(suffix []byte) (kvpb.RaftIndex, error) {
+	_, index, err := encoding.DecodeUint64Ascending(suffix)
+	return kvpb.RaftIndex(index), err
+}
+
+// RangeLoadStatsKey returns a system-local key for the persisted summary of
+// the load of the replica.
+func RangeLoadStatsKey(rangeID roachpb.RangeID) roachpb.Key {
+	return MakeRangeIDPrefixBuf(rangeID).RangeLoadStatsKey()
+}
+
 // RangeLastReplicaGCTimestampKey returns a range-local key for
 // the range's last replica GC timestamp.
 func RangeLastReplicaGCTimestampKey(rangeID roachpb.RangeID) roachpb.Key {
@@ -1112,6 +1162,28 @@ func (b RangeIDPrefixBuf) RangeGCHintKey() roachpb.Key {
 	return append(b.replicatedPrefix(), LocalRangeGCHintSuffix...)
 }
 
+// RangeWriteFencesKey returns a range-local key for the write fences.
+func (b RangeIDPrefixBuf) RangeWriteFencesKey() roachpb.Key {
+	return append(b.replicatedPrefix(), LocalRangeWriteFencesSuffix...)
+}
+
+// RangeReplicaPinKey returns a range-local key for the replica pin.
+func (b RangeIDPrefixBuf) RangeReplicaPinKey() roachpb.Key {
+	return append(b.replicatedPrefix(), LocalRangeReplicaPinSuffix...)
+}
+
+// RangeColdCompactionKey returns a range-local key for the cold compaction
+// policy.
+func (b RangeIDPrefixBuf) RangeColdCompactionKey() roachpb.Key {
+	return append(b.replicatedPrefix(), LocalRangeColdCompactionSuffix...)
+}
+
+// RangeHotStandbyKey returns a range-local key for the hot standby
+// designation.
+func (b RangeIDPrefixBuf) RangeHotStandbyKey() roachpb.Key {
+	return append(b.replicatedPrefix(), LocalRangeHotStandbySuffix...)
+}
+
 // RangeVersionKey returns a system-local key for the range version.
 func (b RangeIDPrefixBuf) RangeVersionKey() roachpb.Key {
 	return append(b.replicatedPrefix(), LocalRangeVersionSuffix...)
@@ -1148,6 +1220,24 @@ func (b RangeIDPrefixBuf) RaftReplicaIDKey() roachpb.Key {
 	return append(b.unreplicatedPrefix(), LocalRaftReplicaIDSuffix...)
 }
 
+// RangeEventLogPrefix returns the system-local prefix shared by all the
+// entries of a replica's range event log.
+func (b RangeIDPrefixBuf) RangeEventLogPrefix() roachpb.Key {
+	return append(b.unreplicatedPrefix(), LocalRangeEventLogSuffix...)
+}
+
+// RangeEventLogKey returns a system-local key for the entry of a replica's
+// range event log recording the event applied at the given raft index.
+func (b RangeIDPrefixBuf) RangeEventLogKey(index kvpb.RaftIndex) roachpb.Key {
+	return encoding.EncodeUint64Ascending(b.RangeEventLogPrefix(), uint64(index))
+}
+
+// RangeLoadStatsKey returns a system-local key for the persisted summary of
+// the load of the replica.
+func (b RangeIDPrefixBuf) RangeLoadStatsKey() roachpb.Key {
+	return append(b.unreplicatedPrefix(), LocalRangeLoadStatsSuffix...)
+}
+
 // RangeLastReplicaGCTimestampKey returns a range-local key for
 // the range's last replica GC timestamp.
 func (b RangeIDPrefixBuf) RangeLastReplicaGCTimestampKey() roachpb.Key {
@@ -119,6 +119,10 @@ var (
 			psFunc: raftLogKeyParse,
 		},
 		{name: "RaftTruncatedState", suffix: LocalRaftTruncatedStateSuffix},
+		{name: "RangeEventLog", suffix: LocalRangeEventLogSuffix,
+			ppFunc: raftLogKeyPrint,
+		},
+		{name: "RangeLoadStats", suffix: LocalRangeLoadStatsSuffix},
 		{name: "RangeLastReplicaGCTimestamp", suffix: LocalRangeLastReplicaGCTimestampSuffix},
 		{name: "RangeLease", suffix: LocalRangeLeaseSuffix},
 		{name: "RangePriorReadSummary", suffix: LocalRangePriorReadSummarySuffix},
@@ -126,6 +130,10 @@ var (
 		{name: "RangeGCThreshold", suffix: LocalRangeGCThresholdSuffix},
 		{name: "RangeVersion", suffix: LocalRangeVersionSuffix},
 		{name: "RangeGCHint", suffix: LocalRangeGCHintSuffix},
+		{name: "RangeWriteFences", suffix: LocalRangeWriteFencesSuffix},
+		{name: "RangeReplicaPin", suffix: LocalRangeReplicaPinSuffix},
+		{name: "RangeColdCompaction", suffix: LocalRangeColdCompactionSuffix},
+		{name: "RangeHotStandby", suffix: LocalRangeHotStandbySuffix},
 	}
 
 	rangeSuffixDict = []struct {
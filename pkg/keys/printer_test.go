@@ -250,10 +250,16 @@ func TestPrettyPrint(t *testing.T) {
 		{keys.RangeGCThresholdKey(roachpb.RangeID(1000001)), "/Local/RangeID/1000001/r/RangeGCThreshold", revertSupportUnknown},
 		{keys.RangeVersionKey(roachpb.RangeID(1000001)), "/Local/RangeID/1000001/r/RangeVersion", revertSupportUnknown},
 		{keys.RangeGCHintKey(roachpb.RangeID(1000001)), "/Local/RangeID/1000001/r/RangeGCHint", revertSupportUnknown},
+		{keys.RangeWriteFencesKey(roachpb.RangeID(1000001)), "/Local/RangeID/1000001/r/RangeWriteFences", revertSupportUnknown},
+		{keys.RangeReplicaPinKey(roachpb.RangeID(1000001)), "/Local/RangeID/1000001/r/RangeReplicaPin", revertSupportUnknown},
+		{keys.RangeColdCompactionKey(roachpb.RangeID(1000001)), "/Local/RangeID/1000001/r/RangeColdCompaction", revertSupportUnknown},
+		{keys.RangeHotStandbyKey(roachpb.RangeID(1000001)), "/Local/RangeID/1000001/r/RangeHotStandby", revertSupportUnknown},
 
 		{keys.RaftHardStateKey(roachpb.RangeID(1000001)), "/Local/RangeID/1000001/u/RaftHardState", revertSupportUnknown},
 		{keys.RangeTombstoneKey(roachpb.RangeID(1000001)), "/Local/RangeID/1000001/u/RangeTombstone", revertSupportUnknown},
 		{keys.RaftLogKey(roachpb.RangeID(1000001), kvpb.RaftIndex(200001)), "/Local/RangeID/1000001/u/RaftLog/logIndex:200001", revertSupportUnknown},
+		{keys.RangeEventLogKey(roachpb.RangeID(1000001), kvpb.RaftIndex(200001)), "/Local/RangeID/1000001/u/RangeEventLog/logIndex:200001", revertSupportUnknown},
+		{keys.RangeLoadStatsKey(roachpb.RangeID(1000001)), "/Local/RangeID/1000001/u/RangeLoadStats", revertSupportUnknown},
 		{keys.RangeLastReplicaGCTimestampKey(roachpb.RangeID(1000001)), "/Local/RangeID/1000001/u/RangeLastReplicaGCTimestamp", revertSupportUnknown},
 
 		{keys.MakeRangeKeyPrefix(roachpb.RKey(tenSysCodec.TablePrefix(42))), `/Local/Range/Table/42`, revertSupportUnknown},
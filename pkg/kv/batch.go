@@ -52,7 +52,12 @@ type Batch struct {
 	// The AdmissionHeader which will be used when sending the resulting
 	// BatchRequest. To be modified directly.
 	AdmissionHeader kvpb.AdmissionHeader
-	reqs            []kvpb.RequestUnion
+	// DisableCommitSplitOnRetry, if set, keeps the EndTxn request added by
+	// Txn.CommitInBatch in the batch of the other requests when the transaction
+	// has restarted, instead of sending it on its own. See
+	// kvpb.EndTxnRequest.DisableSplitOnRetry.
+	DisableCommitSplitOnRetry bool
+	reqs                      []kvpb.RequestUnion
 
 	// approxMutationReqBytes tracks the approximate size of keys and values in
 	// mutations added to this batch via Put, CPut, InitPut, Del, etc.
@@ -247,12 +252,19 @@ func (b *Batch) fillResults(ctx context.Context) {
 			case *kvpb.ScanRequest:
 				if result.Err == nil {
 					t := reply.(*kvpb.ScanResponse)
-					result.Rows = make([]KeyValue, len(t.Rows))
+					result.Rows = make([]KeyValue, 0, len(t.Rows))
+					var filter *kvpb.ScanFilterMatcher
+					if req.Filter != nil {
+						// Replicas predating scan filters return the rows
+						// unfiltered, so the filter is applied again.
+						filter, result.Err = req.Filter.Compile()
+					}
 					for j := range t.Rows {
 						src := &t.Rows[j]
-						dst := &result.Rows[j]
-						dst.Key = src.Key
-						dst.Value = &src.Value
+						if filter != nil && !filter.Matches(src.Key, src.Value.RawBytes) {
+							continue
+						}
+						result.Rows = append(result.Rows, KeyValue{Key: src.Key, Value: &src.Value})
 					}
 				}
 			case *kvpb.ReverseScanRequest:
@@ -305,6 +317,12 @@ func (b *Batch) fillResults(ctx context.Context) {
 			case *kvpb.MigrateRequest:
 			case *kvpb.QueryResolvedTimestampRequest:
 			case *kvpb.BarrierRequest:
+			case *kvpb.WriteFenceRequest:
+			case *kvpb.PinReplicasRequest:
+			case *kvpb.ColdCompactionRequest:
+			case *kvpb.HotStandbyRequest:
+			case *kvpb.AdminRelocateToLocalityRequest:
+			case *kvpb.CheckAndMutateRequest:
 			default:
 				if result.Err == nil {
 					result.Err = errors.Errorf("unsupported reply: %T for %T",
@@ -470,6 +488,37 @@ func (b *Batch) Put(key, value interface{}) {
 	b.put(key, value, false)
 }
 
+// PutWithExpiration sets the value for a key, which expires at the given
+// timestamp. Reads at or above the expiration see the key as deleted, and the
+// value is garbage collected once the GC threshold of its range reaches the
+// expiration, without requiring an explicit deletion.
+//
+// A new result will be appended to the batch which will contain a single row
+// and Result.Err will indicate success or failure.
+//
+// key can be either a byte slice or a string. value can be any key type, a
+// protoutil.Message or any Go primitive type (bool, int, etc).
+func (b *Batch) PutWithExpiration(key, value interface{}, expiration hlc.Timestamp) {
+	if value == nil {
+		panic("can't Put an empty Value; did you mean to Del() instead?")
+	}
+	k, err := marshalKey(key)
+	if err != nil {
+		b.initResult(0, 1, notRaw, err)
+		return
+	}
+	v, err := marshalValue(value)
+	if err != nil {
+		b.initResult(0, 1, notRaw, err)
+		return
+	}
+	req := kvpb.NewPut(k, v).(*kvpb.PutRequest)
+	req.Expiration = expiration
+	b.appendReqs(req)
+	b.approxMutationReqBytes += len(k) + len(v.RawBytes)
+	b.initResult(1, 1, notRaw, nil)
+}
+
 // PutBytes allows an arbitrary number of PutRequests to be added to the batch.
 func (b *Batch) PutBytes(bs BulkSource[[]byte]) {
 	numKeys := bs.Len()
@@ -598,6 +647,26 @@ func (b *Batch) cputInternal(
 	b.initResult(1, 1, notRaw, nil)
 }
 
+// CheckAndMutate atomically applies the given conditional puts and deletes,
+// whose keys must belong to a single range. The mutations are applied only if
+// all of their conditions hold, and none of them is applied otherwise. See
+// CheckAndMutateRequest for details.
+//
+// A new result will be appended to the batch which will contain no rows. The
+// mutations whose conditions failed are reported in the corresponding
+// CheckAndMutateResponse of the batch's RawResponse.
+func (b *Batch) CheckAndMutate(mutations ...kvpb.CheckAndMutateRequest_Mutation) {
+	if len(mutations) == 0 {
+		b.initResult(0, 0, notRaw, errors.New("CheckAndMutate requires at least one mutation"))
+		return
+	}
+	b.appendReqs(kvpb.NewCheckAndMutate(mutations))
+	for i := range mutations {
+		b.approxMutationReqBytes += len(mutations[i].Key) + len(mutations[i].Value.RawBytes)
+	}
+	b.initResult(1, 0, notRaw, nil)
+}
+
 // CPutTuplesEmpty allows multiple CPut tuple requests to be added to the batch
 // as tuples using the BulkSource interface. The values for these keys are
 // expected to be empty.
@@ -784,6 +853,23 @@ func (b *Batch) Scan(s, e interface{}) {
 	b.scan(s, e, false /* isReverse */, kvpb.NonLocking, kvpb.Invalid)
 }
 
+// ScanWithFilter retrieves the key/values between begin (inclusive) and end
+// (exclusive) in ascending order which match the filter. The filter is
+// evaluated by the replicas, so the key/values filtered out are neither
+// returned over the network nor counted against the limits of the batch.
+//
+// A new result will be appended to the batch which will contain "rows" (each
+// row is a key/value pair) and Result.Err will indicate success or failure.
+//
+// key can be either a byte slice or a string.
+func (b *Batch) ScanWithFilter(s, e interface{}, filter *kvpb.ScanFilter) {
+	n := len(b.reqs)
+	b.scan(s, e, false /* isReverse */, kvpb.NonLocking, kvpb.Invalid)
+	if len(b.reqs) > n {
+		b.reqs[n].GetScan().Filter = filter
+	}
+}
+
 // ScanForUpdate retrieves the rows between begin (inclusive) and end
 // (exclusive) in ascending order. Exclusive locks with the supplied durability
 // are acquired on each of the returned keys.
@@ -1116,7 +1202,7 @@ func (b *Batch) queryResolvedTimestamp(s, e interface{}) {
 	b.initResult(1, 0, notRaw, nil)
 }
 
-func (b *Batch) barrier(s, e interface{}) {
+func (b *Batch) barrier(s, e interface{}, waitForAllVoters bool) {
 	begin, err := marshalKey(s)
 	if err != nil {
 		b.initResult(0, 0, notRaw, err)
@@ -1132,6 +1218,101 @@ func (b *Batch) barrier(s, e interface{}) {
 			Key:    begin,
 			EndKey: end,
 		},
+		WaitForAllVoters: waitForAllVoters,
+	}
+	b.appendReqs(req)
+	b.initResult(1, 0, notRaw, nil)
+}
+
+func (b *Batch) writeFence(
+	s, e interface{}, action kvpb.WriteFenceRequest_Action, fenceTS hlc.Timestamp,
+) {
+	begin, err := marshalKey(s)
+	if err != nil {
+		b.initResult(0, 0, notRaw, err)
+		return
+	}
+	end, err := marshalKey(e)
+	if err != nil {
+		b.initResult(0, 0, notRaw, err)
+		return
+	}
+	req := &kvpb.WriteFenceRequest{
+		RequestHeader: kvpb.RequestHeader{
+			Key:    begin,
+			EndKey: end,
+		},
+		Action:         action,
+		FenceTimestamp: fenceTS,
+	}
+	b.appendReqs(req)
+	b.initResult(1, 0, notRaw, nil)
+}
+
+func (b *Batch) pinReplicas(key interface{}, voterStoreIDs []roachpb.StoreID, unpin bool) {
+	k, err := marshalKey(key)
+	if err != nil {
+		b.initResult(0, 0, notRaw, err)
+		return
+	}
+	req := &kvpb.PinReplicasRequest{
+		RequestHeader: kvpb.RequestHeader{
+			Key: k,
+		},
+		VoterStoreIDs: voterStoreIDs,
+		Unpin:         unpin,
+	}
+	b.appendReqs(req)
+	b.initResult(1, 0, notRaw, nil)
+}
+
+func (b *Batch) coldCompaction(
+	key interface{}, action kvpb.ColdCompactionRequest_Action, threshold hlc.Timestamp,
+) {
+	k, err := marshalKey(key)
+	if err != nil {
+		b.initResult(0, 0, notRaw, err)
+		return
+	}
+	req := &kvpb.ColdCompactionRequest{
+		RequestHeader: kvpb.RequestHeader{
+			Key: k,
+		},
+		Action:    action,
+		Threshold: threshold,
+	}
+	b.appendReqs(req)
+	b.initResult(1, 0, notRaw, nil)
+}
+
+func (b *Batch) hotStandby(key interface{}, storeID roachpb.StoreID) {
+	k, err := marshalKey(key)
+	if err != nil {
+		b.initResult(0, 0, notRaw, err)
+		return
+	}
+	req := &kvpb.HotStandbyRequest{
+		RequestHeader: kvpb.RequestHeader{
+			Key: k,
+		},
+		StoreID: storeID,
+	}
+	b.appendReqs(req)
+	b.initResult(1, 0, notRaw, nil)
+}
+
+// adminRelocateToLocality is only exported on DB.
+func (b *Batch) adminRelocateToLocality(key interface{}, locality roachpb.Locality) {
+	k, err := marshalKey(key)
+	if err != nil {
+		b.initResult(0, 0, notRaw, err)
+		return
+	}
+	req := &kvpb.AdminRelocateToLocalityRequest{
+		RequestHeader: kvpb.RequestHeader{
+			Key: k,
+		},
+		Locality: locality,
 	}
 	b.appendReqs(req)
 	b.initResult(1, 0, notRaw, nil)
@@ -414,6 +414,16 @@ func (db *DB) Put(ctx context.Context, key, value interface{}) error {
 	return getOneErr(db.Run(ctx, b), b)
 }
 
+// PutWithExpiration sets the value for a key, which expires at the given
+// timestamp. See Batch.PutWithExpiration.
+func (db *DB) PutWithExpiration(
+	ctx context.Context, key, value interface{}, expiration hlc.Timestamp,
+) error {
+	b := &Batch{}
+	b.PutWithExpiration(key, value, expiration)
+	return getOneErr(db.Run(ctx, b), b)
+}
+
 // PutInline sets the value for a key, but does not maintain
 // multi-version values. The most recent value is always overwritten.
 // Inline values cannot be mutated transactionally and should be used
@@ -888,22 +898,190 @@ func (db *DB) QueryResolvedTimestamp(
 // Barrier is a command that waits for conflicting operations such as earlier
 // writes on the specified key range to finish.
 func (db *DB) Barrier(ctx context.Context, begin, end interface{}) (hlc.Timestamp, error) {
+	resp, err := db.barrier(ctx, begin, end, false /* waitForAllVoters */)
+	if err != nil {
+		return hlc.Timestamp{}, err
+	}
+	return resp.Timestamp, nil
+}
+
+// BarrierOnAllVoters is like Barrier, but additionally waits until the
+// operations it waited for have been applied on all voters of the ranges of
+// the specified key range, not just on their leaseholders. It returns the
+// lease applied index that the voters of each range reached.
+func (db *DB) BarrierOnAllVoters(
+	ctx context.Context, begin, end interface{},
+) (hlc.Timestamp, []kvpb.BarrierResponse_AppliedIndex, error) {
+	resp, err := db.barrier(ctx, begin, end, true /* waitForAllVoters */)
+	if err != nil {
+		return hlc.Timestamp{}, nil, err
+	}
+	if len(resp.AppliedIndexes) == 0 {
+		return hlc.Timestamp{}, nil, errors.Errorf("Barrier did not wait for all voters")
+	}
+	return resp.Timestamp, resp.AppliedIndexes, nil
+}
+
+func (db *DB) barrier(
+	ctx context.Context, begin, end interface{}, waitForAllVoters bool,
+) (*kvpb.BarrierResponse, error) {
 	b := &Batch{}
-	b.barrier(begin, end)
+	b.barrier(begin, end, waitForAllVoters)
 	err := getOneErr(db.Run(ctx, b), b)
 	if err != nil {
-		return hlc.Timestamp{}, err
+		return nil, err
 	}
 	responses := b.response.Responses
 	if len(responses) == 0 {
-		return hlc.Timestamp{}, errors.Errorf("unexpected empty response for Barrier")
+		return nil, errors.Errorf("unexpected empty response for Barrier")
 	}
 	resp, ok := responses[0].GetInner().(*kvpb.BarrierResponse)
 	if !ok {
-		return hlc.Timestamp{}, errors.Errorf("unexpected response of type %T for Barrier",
+		return nil, errors.Errorf("unexpected response of type %T for Barrier",
 			responses[0].GetInner())
 	}
-	return resp.Timestamp, nil
+	return resp, nil
+}
+
+// InstallWriteFence installs a write fence on the specified key range, which
+// rejects all writes to the range at or below the fence timestamp with a
+// WriteFenceError. If a fence on the same key range already exists, its
+// timestamp is forwarded. See WriteFenceRequest for details.
+func (db *DB) InstallWriteFence(
+	ctx context.Context, begin, end interface{}, fenceTS hlc.Timestamp,
+) error {
+	b := &Batch{}
+	b.writeFence(begin, end, kvpb.WriteFenceRequest_INSTALL, fenceTS)
+	return getOneErr(db.Run(ctx, b), b)
+}
+
+// RemoveWriteFences removes all write fences contained in the specified key
+// range.
+func (db *DB) RemoveWriteFences(ctx context.Context, begin, end interface{}) error {
+	b := &Batch{}
+	b.writeFence(begin, end, kvpb.WriteFenceRequest_REMOVE, hlc.Timestamp{})
+	return getOneErr(db.Run(ctx, b), b)
+}
+
+// ListWriteFences returns the write fences overlapping the specified key
+// range. Fences spanning multiple ranges are returned once per range.
+func (db *DB) ListWriteFences(
+	ctx context.Context, begin, end interface{},
+) ([]roachpb.WriteFence, error) {
+	b := &Batch{}
+	b.writeFence(begin, end, kvpb.WriteFenceRequest_LIST, hlc.Timestamp{})
+	if err := getOneErr(db.Run(ctx, b), b); err != nil {
+		return nil, err
+	}
+	return b.RawResponse().Responses[0].GetWriteFence().Fences, nil
+}
+
+// CheckAndMutate atomically applies the given conditional puts and deletes,
+// whose keys must belong to a single range. It returns the mutations whose
+// conditions didn't hold, in which case none of the mutations was applied.
+// See CheckAndMutateRequest for details.
+func (db *DB) CheckAndMutate(
+	ctx context.Context, mutations ...kvpb.CheckAndMutateRequest_Mutation,
+) ([]kvpb.CheckAndMutateResponse_Failure, error) {
+	b := &Batch{}
+	b.CheckAndMutate(mutations...)
+	if err := getOneErr(db.Run(ctx, b), b); err != nil {
+		return nil, err
+	}
+	return b.RawResponse().Responses[0].GetCheckAndMutate().Failures, nil
+}
+
+// PinReplicas pins the voters of the range containing the key to the given
+// stores. The replicate queue relocates the voters of the range onto these
+// stores, and doesn't move them elsewhere until the range is unpinned or one of
+// the stores dies. See PinReplicasRequest for details.
+func (db *DB) PinReplicas(
+	ctx context.Context, key interface{}, voterStoreIDs []roachpb.StoreID,
+) error {
+	if len(voterStoreIDs) == 0 {
+		return errors.New("no stores to pin the voters to")
+	}
+	b := &Batch{}
+	b.pinReplicas(key, voterStoreIDs, false /* unpin */)
+	return getOneErr(db.Run(ctx, b), b)
+}
+
+// UnpinReplicas removes the pin of the range containing the key, if any.
+func (db *DB) UnpinReplicas(ctx context.Context, key interface{}) error {
+	b := &Batch{}
+	b.pinReplicas(key, nil /* voterStoreIDs */, true /* unpin */)
+	return getOneErr(db.Run(ctx, b), b)
+}
+
+// SetColdCompactionPolicy sets the cold compaction policy of the range
+// containing the key: the data of the range is compacted into the bottom levels
+// of the engines of its replicas once none of it has been written at or after
+// the threshold. See ColdCompactionRequest for details.
+func (db *DB) SetColdCompactionPolicy(
+	ctx context.Context, key interface{}, threshold hlc.Timestamp,
+) error {
+	if threshold.IsEmpty() {
+		return errors.New("empty cold compaction threshold")
+	}
+	b := &Batch{}
+	b.coldCompaction(key, kvpb.ColdCompactionRequest_SET, threshold)
+	return getOneErr(db.Run(ctx, b), b)
+}
+
+// RemoveColdCompactionPolicy removes the cold compaction policy of the range
+// containing the key, if any.
+func (db *DB) RemoveColdCompactionPolicy(ctx context.Context, key interface{}) error {
+	b := &Batch{}
+	b.coldCompaction(key, kvpb.ColdCompactionRequest_REMOVE, hlc.Timestamp{})
+	return getOneErr(db.Run(ctx, b), b)
+}
+
+// CompactColdData compacts the data of the range containing the key into the
+// bottom levels of the engines of its replicas. The range must have a cold
+// compaction policy.
+func (db *DB) CompactColdData(ctx context.Context, key interface{}) error {
+	b := &Batch{}
+	b.coldCompaction(key, kvpb.ColdCompactionRequest_COMPACT, hlc.Timestamp{})
+	return getOneErr(db.Run(ctx, b), b)
+}
+
+// SetHotStandby designates the voter on the given store as the hot standby of
+// the range containing the key, which is kept ready to take over the lease of
+// the range on failover. See HotStandbyRequest for details.
+func (db *DB) SetHotStandby(ctx context.Context, key interface{}, storeID roachpb.StoreID) error {
+	if storeID == 0 {
+		return errors.New("no store to designate as hot standby")
+	}
+	b := &Batch{}
+	b.hotStandby(key, storeID)
+	return getOneErr(db.Run(ctx, b), b)
+}
+
+// RemoveHotStandby removes the hot standby designation of the range containing
+// the key, if any.
+func (db *DB) RemoveHotStandby(ctx context.Context, key interface{}) error {
+	b := &Batch{}
+	b.hotStandby(key, 0 /* storeID */)
+	return getOneErr(db.Run(ctx, b), b)
+}
+
+// AdminRelocateToLocality moves the replicas of the range containing the key
+// onto stores matching the locality, and blocks until the data of the range is
+// verified to be present on them. The response carries a report of the
+// verification, signed by the node which produced it. See
+// AdminRelocateToLocalityRequest for details.
+func (db *DB) AdminRelocateToLocality(
+	ctx context.Context, key interface{}, locality roachpb.Locality,
+) (*kvpb.AdminRelocateToLocalityResponse, error) {
+	if locality.Empty() {
+		return nil, errors.New("no locality to relocate the range to")
+	}
+	b := &Batch{}
+	b.adminRelocateToLocality(key, locality)
+	if err := getOneErr(db.Run(ctx, b), b); err != nil {
+		return nil, err
+	}
+	return b.RawResponse().Responses[0].GetAdminRelocateToLocality(), nil
 }
 
 // sendAndFill is a helper which sends the given batch and fills its results,
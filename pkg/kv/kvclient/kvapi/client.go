@@ -0,0 +1,248 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvcoord"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/rpc"
+	"github.com/cockroachdb/cockroach/pkg/rpc/nodedialer"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+)
+
+// Config configures the Client created by Dial.
+type Config struct {
+	// Addr is the address of a node of the cluster, through which the client
+	// discovers the other nodes and the ranges.
+	Addr string
+	// Insecure must be set if the cluster runs in insecure mode.
+	Insecure bool
+	// CertsDir is the directory containing the CA certificate and the client
+	// certificate and key of the root user. Unused if Insecure is set.
+	CertsDir string
+	// ClusterName, if set, is verified to be the name of the cluster.
+	ClusterName string
+	// MaxOffset is the maximum clock offset of the cluster, 500ms if zero. The
+	// client assigns the timestamps of its transactions, so its clock must be
+	// synchronized with the clocks of the nodes as tightly as their own.
+	MaxOffset time.Duration
+	// Retry configures the backoff between the retries of requests which failed
+	// on network errors.
+	Retry RetryOptions
+}
+
+// RetryOptions configures the backoff between retries. The zero values of the
+// fields are replaced by defaults.
+type RetryOptions struct {
+	// InitialBackoff is the backoff before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum backoff between retries.
+	MaxBackoff time.Duration
+	// Multiplier is the factor by which the backoff grows after each retry.
+	Multiplier float64
+	// MaxRetries is the maximum number of retries. Zero means that requests
+	// are retried until their context is canceled.
+	MaxRetries int
+}
+
+func (o RetryOptions) options() retry.Options {
+	opts := base.DefaultRetryOptions()
+	if o.InitialBackoff != 0 {
+		opts.InitialBackoff = o.InitialBackoff
+	}
+	if o.MaxBackoff != 0 {
+		opts.MaxBackoff = o.MaxBackoff
+	}
+	if o.Multiplier != 0 {
+		opts.Multiplier = o.Multiplier
+	}
+	opts.MaxRetries = o.MaxRetries
+	return opts
+}
+
+// KeyValue is a key and its value.
+type KeyValue struct {
+	Key   []byte
+	Value []byte
+}
+
+// Range describes a range of the cluster.
+type Range struct {
+	RangeID int64
+	// StartKey and EndKey delimit the keys of the range, [StartKey, EndKey).
+	StartKey []byte
+	EndKey   []byte
+	// Replicas are the replicas of the range.
+	Replicas []Replica
+}
+
+// Replica describes a replica of a range.
+type Replica struct {
+	NodeID  int32
+	StoreID int32
+}
+
+// Client reads and writes KV data. It's safe for concurrent use.
+//
+// The keys are the raw keys of the cluster, and the values are byte strings.
+// Only the values written through a Client, or as byte strings through the
+// internal KV API, can be read.
+type Client struct {
+	db *kv.DB
+	// stopper is only set if the client owns the KV client stack.
+	stopper *stop.Stopper
+}
+
+// Dial connects a client to the cluster. The client must be closed once
+// unused.
+func Dial(ctx context.Context, cfg Config) (_ *Client, retErr error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("no address to dial")
+	}
+	maxOffset := cfg.MaxOffset
+	if maxOffset == 0 {
+		maxOffset = base.DefaultMaxClockOffset
+	}
+	st := cluster.MakeClusterSettings()
+	tracer := tracing.NewTracer()
+	clock := hlc.NewClockWithSystemTimeSource(maxOffset, maxOffset*4/5 /* toleratedOffset */)
+
+	var baseCfg base.Config
+	baseCfg.InitDefaults()
+	connCfg := rpc.MakeClientConnConfigFromBaseConfig(
+		baseCfg, username.RootUserName(), tracer, st, clock, rpc.ContextTestingKnobs{})
+	connCfg.ServerAddr = cfg.Addr
+	connCfg.Insecure = cfg.Insecure
+	connCfg.SSLCertsDir = cfg.CertsDir
+	connCfg.ClusterName = cfg.ClusterName
+	rpcCtx, stopper := rpc.NewClientContext(ctx, connCfg)
+	defer func() {
+		if retErr != nil {
+			stopper.Stop(ctx)
+		}
+	}()
+
+	ambientCtx := log.AmbientContext{Tracer: tracer}
+	retryOpts := cfg.Retry.options()
+	resolver, err := startResolver(ctx, ambientCtx, rpcCtx, cfg.Addr, retryOpts)
+	if err != nil {
+		return nil, err
+	}
+	ds := kvcoord.NewDistSender(kvcoord.DistSenderConfig{
+		AmbientCtx:        ambientCtx,
+		Settings:          st,
+		Stopper:           stopper,
+		Clock:             clock,
+		NodeDescs:         resolver,
+		RPCRetryOptions:   &retryOpts,
+		TransportFactory:  kvcoord.GRPCTransportFactory(nodedialer.New(rpcCtx, resolver.addr)),
+		RangeDescriptorDB: resolver,
+		LatencyFunc:       rpcCtx.RemoteClocks.Latency,
+	})
+	tcf := kvcoord.NewTxnCoordSenderFactory(kvcoord.TxnCoordSenderFactoryConfig{
+		AmbientCtx: ambientCtx,
+		Settings:   st,
+		Clock:      clock,
+		Stopper:    stopper,
+		Metrics:    kvcoord.MakeTxnMetrics(baseCfg.HistogramWindowInterval()),
+	}, ds)
+	return &Client{
+		db:      kv.NewDB(ambientCtx, tcf, clock, stopper),
+		stopper: stopper,
+	}, nil
+}
+
+// NewClientFromDB returns a client running its requests on the given
+// *kv.DB, for tests which run a server in process. Closing the client doesn't
+// affect the DB.
+func NewClientFromDB(db *kv.DB) *Client {
+	return &Client{db: db}
+}
+
+// Close releases the resources of the client, and cancels its in-flight
+// requests.
+func (c *Client) Close() {
+	if c.stopper != nil {
+		c.stopper.Stop(context.Background())
+	}
+}
+
+// Get returns the value of the key, and false if the key doesn't exist.
+func (c *Client) Get(ctx context.Context, key []byte) (value []byte, ok bool, _ error) {
+	return get(ctx, c.db, key)
+}
+
+// Put sets the value of the key.
+func (c *Client) Put(ctx context.Context, key, value []byte) error {
+	return c.db.Put(ctx, key, value)
+}
+
+// Scan returns the keys and values in [start, end), in key order. If maxRows
+// is positive, at most maxRows keys are returned.
+func (c *Client) Scan(ctx context.Context, start, end []byte, maxRows int64) ([]KeyValue, error) {
+	return scan(ctx, c.db, start, end, maxRows)
+}
+
+// Del deletes the keys.
+func (c *Client) Del(ctx context.Context, keys ...[]byte) error {
+	return del(ctx, c.db, keys)
+}
+
+// Txn runs fn in a serializable transaction, which is committed once fn
+// returns and rolled back if it returns an error. fn is retried if the
+// transaction has to restart, so it must not have side effects besides its
+// reads and writes in the transaction.
+func (c *Client) Txn(ctx context.Context, fn func(context.Context, *Txn) error) error {
+	return c.db.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
+		return fn(ctx, &Txn{txn: txn})
+	})
+}
+
+// RangeLookup returns the range containing the key.
+func (c *Client) RangeLookup(ctx context.Context, key []byte) (Range, error) {
+	descs, _, err := kv.RangeLookup(ctx, c.db.NonTransactionalSender(), key,
+		kvpb.CONSISTENT, 0 /* prefetchNum */, false /* prefetchReverse */)
+	if err != nil {
+		return Range{}, err
+	}
+	if len(descs) == 0 {
+		return Range{}, errors.Errorf("no range found for key %q", key)
+	}
+	return makeRange(&descs[0]), nil
+}
+
+func makeRange(desc *roachpb.RangeDescriptor) Range {
+	r := Range{
+		RangeID:  int64(desc.RangeID),
+		StartKey: desc.StartKey.AsRawKey(),
+		EndKey:   desc.EndKey.AsRawKey(),
+	}
+	for _, repl := range desc.Replicas().Descriptors() {
+		r.Replicas = append(r.Replicas, Replica{
+			NodeID:  int32(repl.NodeID),
+			StoreID: int32(repl.StoreID),
+		})
+	}
+	return r
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvapi_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvapi"
+	"github.com/cockroachdb/cockroach/pkg/security/certnames"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s := serverutils.StartServerOnly(t, base.TestServerArgs{
+		DefaultTestTenant: base.TestIsSpecificToStorageLayerAndNeedsASystemTenant,
+	})
+	defer s.Stopper().Stop(ctx)
+
+	scratch, err := s.ScratchRange()
+	require.NoError(t, err)
+	key := func(s string) []byte {
+		return append(scratch[:len(scratch):len(scratch)], s...)
+	}
+
+	dialed, err := kvapi.Dial(ctx, kvapi.Config{
+		Addr:     s.AdvRPCAddr(),
+		CertsDir: certnames.EmbeddedCertsDir,
+	})
+	require.NoError(t, err)
+	defer dialed.Close()
+
+	for name, c := range map[string]*kvapi.Client{
+		"dialed":  dialed,
+		"from-db": kvapi.NewClientFromDB(s.DB()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			a, b, c1 := key(name+"/a"), key(name+"/b"), key(name+"/c")
+
+			_, ok, err := c.Get(ctx, a)
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			require.NoError(t, c.Put(ctx, a, []byte("1")))
+			require.NoError(t, c.Put(ctx, b, []byte("2")))
+			value, ok, err := c.Get(ctx, a)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, []byte("1"), value)
+
+			kvs, err := c.Scan(ctx, a, c1, 0 /* maxRows */)
+			require.NoError(t, err)
+			require.Equal(t, []kvapi.KeyValue{
+				{Key: a, Value: []byte("1")},
+				{Key: b, Value: []byte("2")},
+			}, kvs)
+			kvs, err = c.Scan(ctx, a, c1, 1 /* maxRows */)
+			require.NoError(t, err)
+			require.Len(t, kvs, 1)
+
+			// A transaction's writes are only visible once it committed.
+			require.NoError(t, c.Txn(ctx, func(ctx context.Context, txn *kvapi.Txn) error {
+				if err := txn.Del(ctx, a); err != nil {
+					return err
+				}
+				if err := txn.Put(ctx, c1, []byte("3")); err != nil {
+					return err
+				}
+				_, ok, err := txn.Get(ctx, a)
+				require.False(t, ok)
+				return err
+			}))
+			kvs, err = c.Scan(ctx, a, key(name+"/d"), 0 /* maxRows */)
+			require.NoError(t, err)
+			require.Equal(t, []kvapi.KeyValue{
+				{Key: b, Value: []byte("2")},
+				{Key: c1, Value: []byte("3")},
+			}, kvs)
+
+			// A transaction whose function fails is rolled back.
+			errBoom := errors.New("boom")
+			require.ErrorIs(t, c.Txn(ctx, func(ctx context.Context, txn *kvapi.Txn) error {
+				if err := txn.Put(ctx, a, []byte("4")); err != nil {
+					return err
+				}
+				return errBoom
+			}), errBoom)
+			_, ok, err = c.Get(ctx, a)
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			require.NoError(t, c.Del(ctx, b, c1))
+			kvs, err = c.Scan(ctx, a, key(name+"/d"), 0 /* maxRows */)
+			require.NoError(t, err)
+			require.Empty(t, kvs)
+
+			r, err := c.RangeLookup(ctx, a)
+			require.NoError(t, err)
+			require.Equal(t, []byte(scratch), r.StartKey)
+			require.NotZero(t, r.RangeID)
+			require.Equal(t, []kvapi.Replica{{
+				NodeID:  int32(s.NodeID()),
+				StoreID: int32(s.GetFirstStoreID()),
+			}}, r.Replicas)
+		})
+	}
+}
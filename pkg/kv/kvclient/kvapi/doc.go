@@ -0,0 +1,31 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package kvapi is a minimal Go client of the KV layer, for the tools and
+// tests which read and write KV data without going through SQL.
+//
+// A Client is connected to a cluster with Dial, and runs its requests on the
+// same client stack as the SQL layer: requests are routed to the leaseholders
+// of the ranges with a range descriptor cache, retried on routing and network
+// errors, and transactions are run with serializable isolation and retried on
+// conflicts.
+//
+// # Compatibility
+//
+// Unlike the other packages of the KV layer, the exported API of this package
+// is supported: it is versioned following semantic versioning (see Version)
+// and only changes in backwards incompatible ways with a new major version.
+// To let the packages it builds upon evolve freely, the API only uses standard
+// library types; NewClientFromDB is the only exception, as it's meant for tests
+// which already run a server in process.
+package kvapi
+
+// Version is the semantic version of the API of this package.
+const Version = "1.0.0"
@@ -0,0 +1,234 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvapi
+
+import (
+	"context"
+	"io"
+	"net"
+
+	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvcoord"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/rangecache"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/rpc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	nodeDescPattern  = gossip.MakePrefixPattern(gossip.KeyNodeDescPrefix)
+	storeDescPattern = gossip.MakePrefixPattern(gossip.KeyStoreDescPrefix)
+)
+
+// resolver looks up the descriptors of the nodes, stores and ranges of the
+// cluster through the node dialed by the client, like the connector of the
+// SQL servers of tenants does through the nodes it dials.
+type resolver struct {
+	log.AmbientContext
+	rpcCtx    *rpc.Context
+	dialAddr  string
+	retryOpts retry.Options
+
+	mu struct {
+		syncutil.RWMutex
+		nodeDescs  map[roachpb.NodeID]*roachpb.NodeDescriptor
+		storeDescs map[roachpb.StoreID]*roachpb.StoreDescriptor
+	}
+}
+
+var _ kvcoord.NodeDescStore = (*resolver)(nil)
+var _ rangecache.RangeDescriptorDB = (*resolver)(nil)
+
+// startResolver starts the gossip subscription of a resolver, and waits until
+// it received the ID of the cluster.
+func startResolver(
+	ctx context.Context,
+	ambientCtx log.AmbientContext,
+	rpcCtx *rpc.Context,
+	dialAddr string,
+	retryOpts retry.Options,
+) (*resolver, error) {
+	r := &resolver{
+		AmbientContext: ambientCtx,
+		rpcCtx:         rpcCtx,
+		dialAddr:       dialAddr,
+		retryOpts:      retryOpts,
+	}
+	r.mu.nodeDescs = make(map[roachpb.NodeID]*roachpb.NodeDescriptor)
+	r.mu.storeDescs = make(map[roachpb.StoreID]*roachpb.StoreDescriptor)
+
+	startupCh := make(chan struct{})
+	bgCtx := r.AnnotateCtx(context.Background())
+	if err := rpcCtx.Stopper.RunAsyncTask(bgCtx, "kvapi-gossip-subscription",
+		func(ctx context.Context) {
+			ctx, cancel := rpcCtx.Stopper.WithCancelOnQuiesce(ctx)
+			defer cancel()
+			r.runGossipSubscription(ctx, startupCh)
+		},
+	); err != nil {
+		return nil, err
+	}
+	select {
+	case <-startupCh:
+		return r, nil
+	case <-ctx.Done():
+		return nil, errors.Wrapf(ctx.Err(), "connecting to %s", dialAddr)
+	}
+}
+
+func (r *resolver) dial(ctx context.Context) (kvpb.InternalClient, error) {
+	conn, err := r.rpcCtx.GRPCUnvalidatedDial(r.dialAddr).Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return kvpb.NewInternalClient(conn), nil
+}
+
+// runGossipSubscription keeps the descriptors of the nodes and stores up to
+// date. It closes the given channel once the ID of the cluster was received.
+func (r *resolver) runGossipSubscription(ctx context.Context, startupCh chan struct{}) {
+	for re := retry.StartWithCtx(ctx, r.retryOpts); re.Next(); {
+		client, err := r.dial(ctx)
+		if err != nil {
+			log.Warningf(ctx, "error dialing %s: %v", r.dialAddr, err)
+			continue
+		}
+		stream, err := client.GossipSubscription(ctx, &kvpb.GossipSubscriptionRequest{
+			Patterns: []string{gossip.KeyClusterID, nodeDescPattern, storeDescPattern},
+		})
+		if err != nil {
+			log.Warningf(ctx, "error issuing GossipSubscription RPC: %v", err)
+			continue
+		}
+		for {
+			e, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					log.Warningf(ctx, "error consuming GossipSubscription RPC: %v", err)
+				}
+				break
+			}
+			if e.Error != nil {
+				// Hard logical error. We expect io.EOF next.
+				log.Errorf(ctx, "error consuming GossipSubscription RPC: %v", e.Error)
+				continue
+			}
+			re.Reset()
+			r.handleGossipEvent(ctx, e)
+			if startupCh != nil && e.PatternMatched == gossip.KeyClusterID {
+				close(startupCh)
+				startupCh = nil
+			}
+		}
+	}
+}
+
+func (r *resolver) handleGossipEvent(ctx context.Context, e *kvpb.GossipSubscriptionEvent) {
+	switch e.PatternMatched {
+	case gossip.KeyClusterID:
+		bytes, err := e.Content.GetBytes()
+		if err == nil {
+			var clusterID uuid.UUID
+			if clusterID, err = uuid.FromBytes(bytes); err == nil {
+				r.rpcCtx.StorageClusterID.Set(ctx, clusterID)
+				return
+			}
+		}
+		log.Errorf(ctx, "invalid ClusterID value: %v", e.Content.RawBytes)
+	case nodeDescPattern:
+		desc := new(roachpb.NodeDescriptor)
+		if err := e.Content.GetProto(desc); err != nil {
+			log.Errorf(ctx, "could not unmarshal node descriptor: %v", err)
+			return
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.mu.nodeDescs[desc.NodeID] = desc
+	case storeDescPattern:
+		desc := new(roachpb.StoreDescriptor)
+		if err := e.Content.GetProto(desc); err != nil {
+			log.Errorf(ctx, "could not unmarshal store descriptor: %v", err)
+			return
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.mu.storeDescs[desc.StoreID] = desc
+	}
+}
+
+// GetNodeDescriptor implements the kvcoord.NodeDescStore interface.
+func (r *resolver) GetNodeDescriptor(nodeID roachpb.NodeID) (*roachpb.NodeDescriptor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	desc, ok := r.mu.nodeDescs[nodeID]
+	if !ok {
+		return nil, kvpb.NewNodeDescNotFoundError(nodeID)
+	}
+	return desc, nil
+}
+
+// GetNodeDescriptorCount implements the kvcoord.NodeDescStore interface.
+func (r *resolver) GetNodeDescriptorCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.mu.nodeDescs)
+}
+
+// GetStoreDescriptor implements the kvcoord.NodeDescStore interface.
+func (r *resolver) GetStoreDescriptor(storeID roachpb.StoreID) (*roachpb.StoreDescriptor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	desc, ok := r.mu.storeDescs[storeID]
+	if !ok {
+		return nil, kvpb.NewStoreDescNotFoundError(storeID)
+	}
+	return desc, nil
+}
+
+// addr implements the nodedialer.AddressResolver interface.
+func (r *resolver) addr(nodeID roachpb.NodeID) (net.Addr, error) {
+	desc, err := r.GetNodeDescriptor(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return &desc.Address, nil
+}
+
+// RangeLookup implements the rangecache.RangeDescriptorDB interface.
+func (r *resolver) RangeLookup(
+	ctx context.Context, key roachpb.RKey, rc rangecache.RangeLookupConsistency, useReverseScan bool,
+) ([]roachpb.RangeDescriptor, []roachpb.RangeDescriptor, error) {
+	client, err := r.dial(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := client.RangeLookup(ctx, &kvpb.RangeLookupRequest{
+		Key: key,
+		// See the comment on (*kvcoord.DistSender).RangeLookup or kv.RangeLookup
+		// for more discussion on the choice of ReadConsistency and its
+		// implications.
+		ReadConsistency: rc,
+		PrefetchNum:     kvcoord.RangeLookupPrefetchCount,
+		PrefetchReverse: useReverseScan,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.Error != nil {
+		return nil, nil, resp.Error.GoError()
+	}
+	return resp.Descriptors, resp.PrefetchedDescriptors, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvapi
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+)
+
+// Txn is a transaction run by Client.Txn. Its methods must not be called
+// concurrently, nor once the function it was passed to has returned.
+type Txn struct {
+	txn *kv.Txn
+}
+
+// Get returns the value of the key, and false if the key doesn't exist.
+func (t *Txn) Get(ctx context.Context, key []byte) (value []byte, ok bool, _ error) {
+	return get(ctx, t.txn, key)
+}
+
+// Put sets the value of the key.
+func (t *Txn) Put(ctx context.Context, key, value []byte) error {
+	return t.txn.Put(ctx, key, value)
+}
+
+// Scan returns the keys and values in [start, end), in key order. If maxRows
+// is positive, at most maxRows keys are returned.
+func (t *Txn) Scan(ctx context.Context, start, end []byte, maxRows int64) ([]KeyValue, error) {
+	return scan(ctx, t.txn, start, end, maxRows)
+}
+
+// Del deletes the keys.
+func (t *Txn) Del(ctx context.Context, keys ...[]byte) error {
+	return del(ctx, t.txn, keys)
+}
+
+// ops is the subset of the methods of *kv.DB and *kv.Txn used by the API.
+type ops interface {
+	Get(ctx context.Context, key interface{}) (kv.KeyValue, error)
+	Scan(ctx context.Context, begin, end interface{}, maxRows int64) ([]kv.KeyValue, error)
+	Del(ctx context.Context, keys ...interface{}) ([]roachpb.Key, error)
+}
+
+var _ ops = (*kv.DB)(nil)
+var _ ops = (*kv.Txn)(nil)
+
+func get(ctx context.Context, o ops, key []byte) ([]byte, bool, error) {
+	res, err := o.Get(ctx, key)
+	if err != nil || !res.Exists() {
+		return nil, false, err
+	}
+	value, err := res.Value.GetBytes()
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func scan(ctx context.Context, o ops, start, end []byte, maxRows int64) ([]KeyValue, error) {
+	kvs, err := o.Scan(ctx, start, end, maxRows)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]KeyValue, len(kvs))
+	for i := range kvs {
+		value, err := kvs[i].Value.GetBytes()
+		if err != nil {
+			return nil, err
+		}
+		res[i] = KeyValue{Key: kvs[i].Key, Value: value}
+	}
+	return res, nil
+}
+
+func del(ctx context.Context, o ops, keys [][]byte) error {
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		args[i] = roachpb.Key(key)
+	}
+	_, err := o.Del(ctx, args...)
+	return err
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/rangecache"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
 	"github.com/cockroachdb/cockroach/pkg/multitenant"
 	"github.com/cockroachdb/cockroach/pkg/multitenant/tenantcostmodel"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -38,6 +39,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
 	"github.com/cockroachdb/cockroach/pkg/util/retry"
 	"github.com/cockroachdb/cockroach/pkg/util/startup"
@@ -121,6 +123,24 @@ var (
 		Measurement: "Partial Batches",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaDistSenderAsyncAdaptiveThrottledCount = metric.Metadata{
+		Name:        "distsender.batches.async.adaptive_throttled",
+		Help:        "Number of partial batches not sent asynchronously due to the adaptive concurrency limits",
+		Measurement: "Partial Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaDistSenderAsyncSystemLaneSentCount = metric.Metadata{
+		Name:        "distsender.batches.async.system_lane.sent",
+		Help:        "Number of partial batches of system-critical traffic sent asynchronously in the system lane",
+		Measurement: "Partial Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaDistSenderAsyncSystemLaneThrottledCount = metric.Metadata{
+		Name:        "distsender.batches.async.system_lane.throttled",
+		Help:        "Number of partial batches of system-critical traffic not sent asynchronously due to the system lane's concurrency limit",
+		Measurement: "Partial Batches",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaTransportSentCount = metric.Metadata{
 		Name:        "distsender.rpc.sent",
 		Help:        "Number of replica-addressed RPCs sent",
@@ -260,6 +280,46 @@ var rangeDescriptorCacheSize = settings.RegisterIntSetting(
 	settings.IntWithMinimum(64),
 )
 
+// rangeDescriptorCacheAutotuneEnabled controls whether the size of the range
+// descriptor cache is tuned after the workload, between
+// kv.range_descriptor_cache.autotune.min_size and
+// kv.range_descriptor_cache.size, instead of being the latter.
+var rangeDescriptorCacheAutotuneEnabled = settings.RegisterBoolSetting(
+	settings.ApplicationLevel,
+	"kv.range_descriptor_cache.autotune.enabled",
+	"if enabled, the range descriptor cache grows while its hit rate is under "+
+		"kv.range_descriptor_cache.autotune.target_hit_rate, up to "+
+		"kv.range_descriptor_cache.size entries, and shrinks under memory pressure",
+	false,
+)
+
+var rangeDescriptorCacheAutotuneMinSize = settings.RegisterIntSetting(
+	settings.ApplicationLevel,
+	"kv.range_descriptor_cache.autotune.min_size",
+	"minimum number of entries in the range descriptor cache when "+
+		"kv.range_descriptor_cache.autotune.enabled is set",
+	1e4,
+	settings.IntWithMinimum(64),
+)
+
+var rangeDescriptorCacheAutotuneTargetHitRate = settings.RegisterFloatSetting(
+	settings.ApplicationLevel,
+	"kv.range_descriptor_cache.autotune.target_hit_rate",
+	"hit rate of the range descriptor cache under which it is grown when "+
+		"kv.range_descriptor_cache.autotune.enabled is set",
+	0.99,
+	settings.FractionUpperExclusive,
+)
+
+// rangeCacheAutotuneInterval is the interval at which the size of the range
+// descriptor cache is tuned.
+const rangeCacheAutotuneInterval = 10 * time.Second
+
+// rangeCacheAutotuneMemoryPressure is the fraction of the memory monitor's
+// limit above which the node is considered under memory pressure by the range
+// descriptor cache autotuner.
+const rangeCacheAutotuneMemoryPressure = 0.8
+
 // senderConcurrencyLimit controls the maximum number of asynchronous send
 // requests.
 var senderConcurrencyLimit = settings.RegisterIntSetting(
@@ -270,6 +330,16 @@ var senderConcurrencyLimit = settings.RegisterIntSetting(
 	settings.NonNegativeInt,
 )
 
+// useDedicatedBulkConnectionClass controls whether bulk requests are sent over
+// dedicated connections, separate from the foreground traffic.
+var useDedicatedBulkConnectionClass = settings.RegisterBoolSetting(
+	settings.ApplicationLevel,
+	"kv.dist_sender.use_dedicated_bulk_connection_class.enabled",
+	"uses dedicated connections when sending bulk requests, such as AddSSTable and Export",
+	util.ConstantWithMetamorphicTestBool(
+		"kv.dist_sender.use_dedicated_bulk_connection_class.enabled", false),
+)
+
 // FollowerReadsUnhealthy controls whether we will send follower reads to nodes
 // that are not considered healthy. By default, we will sort these nodes behind
 // healthy nodes.
@@ -291,6 +361,18 @@ var sortByLocalityFirst = settings.RegisterBoolSetting(
 	true,
 )
 
+// parallelCommitRecordFirst controls whether the requests of a parallel commit
+// batch which address the range of the transaction record are sent ahead of,
+// and separately from, the rest of the batch when it spans ranges.
+var parallelCommitRecordFirst = settings.RegisterBoolSetting(
+	settings.ApplicationLevel,
+	"kv.dist_sender.parallel_commit_record_first.enabled",
+	"if enabled, a parallel commit batch which spans ranges sends its EndTxn, along "+
+		"with the other requests to the range of the transaction record, ahead of the "+
+		"requests to the other ranges",
+	false,
+)
+
 // DistSenderMetrics is the set of metrics for a given distributed sender.
 type DistSenderMetrics struct {
 	BatchCount                         *metric.Counter
@@ -303,6 +385,14 @@ type DistSenderMetrics struct {
 	CrossZoneBatchResponseBytes        *metric.Counter
 	AsyncSentCount                     *metric.Counter
 	AsyncThrottledCount                *metric.Counter
+	AsyncAdaptiveThrottledCount        *metric.Counter
+	AsyncSystemLaneSentCount           *metric.Counter
+	AsyncSystemLaneThrottledCount      *metric.Counter
+	RangeCacheAutotune                 rangecache.SizeAutotunerMetrics
+	KeySerialization                   KeySerializationMetrics
+	HashSharding                       HashShardingMetrics
+	ObservedTimestampHints             ObservedTimestampHintMetrics
+	AdaptiveTargetBytes                AdaptiveTargetBytesMetrics
 	SentCount                          *metric.Counter
 	LocalSentCount                     *metric.Counter
 	NextReplicaErrCount                *metric.Counter
@@ -329,6 +419,14 @@ func makeDistSenderMetrics() DistSenderMetrics {
 		PartialBatchCount:                  metric.NewCounter(metaDistSenderPartialBatchCount),
 		AsyncSentCount:                     metric.NewCounter(metaDistSenderAsyncSentCount),
 		AsyncThrottledCount:                metric.NewCounter(metaDistSenderAsyncThrottledCount),
+		AsyncAdaptiveThrottledCount:        metric.NewCounter(metaDistSenderAsyncAdaptiveThrottledCount),
+		AsyncSystemLaneSentCount:           metric.NewCounter(metaDistSenderAsyncSystemLaneSentCount),
+		AsyncSystemLaneThrottledCount:      metric.NewCounter(metaDistSenderAsyncSystemLaneThrottledCount),
+		RangeCacheAutotune:                 rangecache.MakeSizeAutotunerMetrics(),
+		KeySerialization:                   makeKeySerializationMetrics(),
+		HashSharding:                       makeHashShardingMetrics(),
+		ObservedTimestampHints:             makeObservedTimestampHintMetrics(),
+		AdaptiveTargetBytes:                makeAdaptiveTargetBytesMetrics(),
 		SentCount:                          metric.NewCounter(metaTransportSentCount),
 		LocalSentCount:                     metric.NewCounter(metaTransportLocalSentCount),
 		ReplicaAddressedBatchRequestBytes:  metric.NewCounter(metaDistSenderReplicaAddressedBatchRequestBytes),
@@ -525,12 +623,34 @@ type DistSender struct {
 	metrics DistSenderMetrics
 	// rangeCache caches replica metadata for key ranges.
 	rangeCache *rangecache.RangeCache
+	// rangeCacheAutotuner sizes the rangeCache when
+	// kv.range_descriptor_cache.autotune.enabled is set.
+	rangeCacheAutotuner *rangecache.SizeAutotuner
+	// memMonitor, if set, is the memory monitor whose usage is the memory
+	// pressure feedback of the rangeCacheAutotuner. See SetMemoryMonitor.
+	memMonitor atomic.Pointer[mon.BytesMonitor]
 	// firstRangeProvider provides the range descriptor for range one.
 	// This is not required if a RangeDescriptorDB is supplied.
 	firstRangeProvider FirstRangeProvider
 	transportFactory   TransportFactory
 	rpcRetryOptions    retry.Options
 	asyncSenderSem     *quotapool.IntPool
+	// asyncSystemSenderSem limits the partial batches of system-critical
+	// traffic sent asynchronously, instead of asyncSenderSem. See
+	// isSystemCriticalSpan.
+	asyncSystemSenderSem *quotapool.IntPool
+	// adaptiveConcurrency further limits the partial batches sent
+	// asynchronously, per batch and per node.
+	adaptiveConcurrency *adaptiveConcurrency
+	// keySerializer serializes the batches on the contended keys of the tables
+	// hinted with kv.dist_sender.key_serialization.tables.
+	keySerializer *keySerializer
+	// hashSharder translates the batches addressing the hash-sharded spans
+	// between logical and physical keys.
+	hashSharder *hashSharder
+	// observedTimestampHints holds the observed timestamps of the nodes which
+	// returned uncertainty errors, to hand them to other transactions.
+	observedTimestampHints *observedTimestampHints
 
 	// batchInterceptor is set for tenants; when set, information about all
 	// BatchRequests and BatchResponses are passed through this interceptor, which
@@ -617,6 +737,10 @@ type DistSenderConfig struct {
 	// can potentially throttle requests.
 	KVInterceptor multitenant.TenantSideKVInterceptor
 
+	// HashShardedSpans, if set, provides the hash-sharded spans whose batches
+	// the DistSender translates between logical and physical keys.
+	HashShardedSpans *kvserverbase.HashShardedSpansWatcher
+
 	TestingKnobs ClientTestingKnobs
 
 	HealthFunc HealthFunc
@@ -672,7 +796,23 @@ func NewDistSender(cfg DistSenderConfig) *DistSender {
 	if rdb == nil {
 		panic("DistSenderConfig must contain either FirstRangeProvider or RangeDescriptorDB")
 	}
+	ds.rangeCacheAutotuner = rangecache.NewSizeAutotuner(rangecache.SizeAutotunerConfig{
+		MinSize: func() int64 {
+			return rangeDescriptorCacheAutotuneMinSize.Get(&ds.st.SV)
+		},
+		MaxSize: func() int64 {
+			return rangeDescriptorCacheSize.Get(&ds.st.SV)
+		},
+		TargetHitRate: func() float64 {
+			return rangeDescriptorCacheAutotuneTargetHitRate.Get(&ds.st.SV)
+		},
+		MemoryPressure: ds.underMemoryPressure,
+		Metrics:        &ds.metrics.RangeCacheAutotune,
+	})
 	getRangeDescCacheSize := func() int64 {
+		if rangeDescriptorCacheAutotuneEnabled.Get(&ds.st.SV) {
+			return ds.rangeCacheAutotuner.Size()
+		}
 		return rangeDescriptorCacheSize.Get(&ds.st.SV)
 	}
 	ds.rangeCache = rangecache.NewRangeCache(ds.st, rdb, getRangeDescCacheSize, cfg.Stopper)
@@ -700,6 +840,16 @@ func NewDistSender(cfg DistSenderConfig) *DistSender {
 		ds.asyncSenderSem.UpdateCapacity(uint64(senderConcurrencyLimit.Get(&ds.st.SV)))
 	})
 	cfg.Stopper.AddCloser(ds.asyncSenderSem.Closer("stopper"))
+	ds.asyncSystemSenderSem = quotapool.NewIntPool("DistSender async system concurrency",
+		uint64(systemLaneConcurrencyLimit.Get(&ds.st.SV)))
+	systemLaneConcurrencyLimit.SetOnChange(&ds.st.SV, func(ctx context.Context) {
+		ds.asyncSystemSenderSem.UpdateCapacity(uint64(systemLaneConcurrencyLimit.Get(&ds.st.SV)))
+	})
+	cfg.Stopper.AddCloser(ds.asyncSystemSenderSem.Closer("stopper"))
+	ds.adaptiveConcurrency = newAdaptiveConcurrency(ds.st, timeutil.Now)
+	ds.keySerializer = newKeySerializer(ds.st, &ds.metrics.KeySerialization)
+	ds.hashSharder = newHashSharder(cfg.HashShardedSpans)
+	ds.observedTimestampHints = newObservedTimestampHints(ds.metrics.ObservedTimestampHints)
 
 	if ds.firstRangeProvider != nil {
 		ctx := ds.AnnotateCtx(context.Background())
@@ -733,9 +883,49 @@ func NewDistSender(cfg DistSenderConfig) *DistSender {
 		}
 	}
 
+	ctx := ds.AnnotateCtx(context.Background())
+	_ = cfg.Stopper.RunAsyncTask(ctx, "kv.DistSender: range cache autotuner", ds.runRangeCacheAutotuner)
+
 	return ds
 }
 
+// SetMemoryMonitor sets the memory monitor whose usage is the memory pressure
+// feedback of the range descriptor cache autotuner. It is set after the
+// DistSender is created, since the node's memory monitors are created later.
+func (ds *DistSender) SetMemoryMonitor(m *mon.BytesMonitor) {
+	ds.memMonitor.Store(m)
+}
+
+// underMemoryPressure returns whether the usage of the memory monitor is close
+// to its limit.
+func (ds *DistSender) underMemoryPressure() bool {
+	m := ds.memMonitor.Load()
+	if m == nil {
+		return false
+	}
+	limit := m.Limit()
+	return limit > 0 && float64(m.AllocBytes()) > rangeCacheAutotuneMemoryPressure*float64(limit)
+}
+
+// runRangeCacheAutotuner periodically tunes the size of the range descriptor
+// cache, while kv.range_descriptor_cache.autotune.enabled is set.
+func (ds *DistSender) runRangeCacheAutotuner(ctx context.Context) {
+	var timer timeutil.Timer
+	defer timer.Stop()
+	for {
+		timer.Reset(rangeCacheAutotuneInterval)
+		select {
+		case <-timer.C:
+			timer.Read = true
+			if rangeDescriptorCacheAutotuneEnabled.Get(&ds.st.SV) {
+				ds.rangeCacheAutotuner.Tune(ctx, ds.rangeCache)
+			}
+		case <-ds.stopper.ShouldQuiesce():
+			return
+		}
+	}
+}
+
 // LatencyFunc returns the LatencyFunc of the DistSender.
 func (ds *DistSender) LatencyFunc() LatencyFunc {
 	return ds.latencyFunc
@@ -1036,16 +1226,37 @@ func (ds *DistSender) Send(
 	ctx, sp := tracing.EnsureChildSpan(ctx, ds.AmbientContext.Tracer, "dist sender send")
 	defer sp.Finish()
 
+	release := ds.keySerializer.acquire(ctx, ba)
+	var br *kvpb.BatchResponse
+	var pErr *kvpb.Error
+	if spans := ds.hashSharder.spansFor(ba); len(spans) > 0 {
+		br, pErr = ds.sendHashSharded(ctx, ba, spans)
+	} else {
+		br, pErr = ds.sendVerifiedBatch(ctx, ba)
+	}
+	if release != nil {
+		release(pErr)
+	}
+	return br, pErr
+}
+
+// sendVerifiedBatch is the part of Send which subdivides the initialized and
+// verified batch, sends each part and recombines the response.
+func (ds *DistSender) sendVerifiedBatch(
+	ctx context.Context, ba *kvpb.BatchRequest,
+) (*kvpb.BatchResponse, *kvpb.Error) {
 	splitET := false
-	var require1PC bool
+	var require1PC, disableSplitOnRetry bool
 	lastReq := ba.Requests[len(ba.Requests)-1].GetInner()
-	if et, ok := lastReq.(*kvpb.EndTxnRequest); ok && et.Require1PC {
-		require1PC = true
+	if et, ok := lastReq.(*kvpb.EndTxnRequest); ok {
+		require1PC = et.Require1PC
+		disableSplitOnRetry = et.DisableSplitOnRetry
 	}
 	// To ensure that we lay down intents to prevent starvation, always
 	// split the end transaction request into its own batch on retries.
-	// Txns requiring 1PC are an exception and should never be split.
-	if ba.Txn != nil && ba.Txn.Epoch > 0 && !require1PC {
+	// Txns requiring 1PC are an exception and should never be split, as
+	// are the ones which explicitly opted out.
+	if ba.Txn != nil && ba.Txn.Epoch > 0 && !require1PC && !disableSplitOnRetry {
 		splitET = true
 	}
 	parts := splitBatchAndCheckForRefreshSpans(ba, splitET)
@@ -1198,7 +1409,7 @@ func (ds *DistSender) divideAndSendParallelCommit(
 	}
 	if swapIdx == -1 {
 		// No pre-commit QueryIntents. Nothing to split.
-		return ds.divideAndSendBatchToRanges(ctx, ba, rs, isReverse, true /* withCommit */, batchIdx)
+		return ds.divideAndSendCommitRecordFirst(ctx, ba, rs, isReverse, batchIdx)
 	}
 
 	// Swap the EndTxn request and the first pre-commit QueryIntent. This
@@ -1228,6 +1439,9 @@ func (ds *DistSender) divideAndSendParallelCommit(
 	qiIsReverse := false // QueryIntentRequests do not carry the isReverse flag
 	qiBatchIdx := batchIdx + 1
 	qiResponseCh := make(chan response, 1)
+	// qiDuration is the time it took to prove the in-flight writes. It is set
+	// before the response is sent on qiResponseCh.
+	var qiDuration time.Duration
 
 	runTask := ds.stopper.RunAsyncTask
 	if ds.disableParallelBatches {
@@ -1254,7 +1468,9 @@ func (ds *DistSender) divideAndSendParallelCommit(
 
 		// Send the batch with withCommit=true since it will be inflight
 		// concurrently with the EndTxn batch below.
+		start := timeutil.Now()
 		reply, pErr := ds.divideAndSendBatchToRanges(ctx, qiBa, qiRS, qiIsReverse, true /* withCommit */, qiBatchIdx)
+		qiDuration = timeutil.Since(start)
 		qiResponseCh <- response{reply: reply, positions: positions, pErr: pErr}
 	}); err != nil {
 		return nil, kvpb.NewError(err)
@@ -1272,7 +1488,7 @@ func (ds *DistSender) divideAndSendParallelCommit(
 	// Note that we don't need to recompute isReverse for the updated batch
 	// since we only separated out QueryIntentRequests which don't carry the
 	// isReverse flag.
-	br, pErr = ds.divideAndSendBatchToRanges(ctx, ba, rs, isReverse, true /* withCommit */, batchIdx)
+	br, pErr = ds.divideAndSendCommitRecordFirst(ctx, ba, rs, isReverse, batchIdx)
 
 	// Wait for the QueryIntent-only batch to complete and stitch
 	// the responses together.
@@ -1322,9 +1538,154 @@ func (ds *DistSender) divideAndSendParallelCommit(
 	if err := br.Combine(ctx, qiReply.reply, qiReply.positions, ba); err != nil {
 		return nil, kvpb.NewError(err)
 	}
+	if ba.ReturnCommitLatencyBreakdown {
+		br.EnsureCommitLatencyBreakdown().PipelineProving = qiDuration
+	}
 	return br, nil
 }
 
+// divideAndSendCommitRecordFirst sends a parallel-committing batch which spans
+// ranges in two legs: the record leg, made of the EndTxn request and the other
+// requests addressing the range of the transaction record, and the rest of the
+// batch. The record leg is dispatched first, in its own task, and the rest of
+// the batch is pipelined behind it.
+//
+// Without this, the partial batch carrying the EndTxn request is dispatched in
+// key order along with the others, and is sent after them once the limit of
+// asynchronous partial batches is reached. Under WAN latencies, this delays the
+// staging of the transaction record, on which the commit of the transaction
+// hinges. Splitting the batch is safe, since a parallel commit only requires
+// that the writes in its in-flight set eventually succeed, regardless of the
+// batch that carries them.
+//
+// The batch is sent as is if the optimization is disabled, if it is contained
+// in the range of the transaction record, or if one of its requests straddles
+// the boundary of that range. The latter keeps a ranged request and the
+// requests ordered around it in the same partial batches.
+func (ds *DistSender) divideAndSendCommitRecordFirst(
+	ctx context.Context, ba *kvpb.BatchRequest, rs roachpb.RSpan, isReverse bool, batchIdx int,
+) (*kvpb.BatchResponse, *kvpb.Error) {
+	recordPositions, restPositions, ok := ds.splitCommitRecordLeg(ctx, ba, rs)
+	if !ok {
+		return ds.divideAndSendBatchToRanges(ctx, ba, rs, isReverse, true /* withCommit */, batchIdx)
+	}
+	makeLeg := func(positions []int) (*kvpb.BatchRequest, roachpb.RSpan, error) {
+		legBa := ba.ShallowCopy()
+		legBa.Requests = make([]kvpb.RequestUnion, len(positions))
+		for i, pos := range positions {
+			legBa.Requests[i] = ba.Requests[pos]
+		}
+		legRS, err := keys.Range(legBa.Requests)
+		return legBa, legRS, err
+	}
+	recordBa, recordRS, err := makeLeg(recordPositions)
+	if err != nil {
+		return nil, kvpb.NewError(err)
+	}
+	restBa, restRS, err := makeLeg(restPositions)
+	if err != nil {
+		return nil, kvpb.NewError(err)
+	}
+
+	recordResponseCh := make(chan response, 1)
+	runTask := ds.stopper.RunAsyncTask
+	if ds.disableParallelBatches {
+		runTask = ds.stopper.RunTask
+	}
+	if err := runTask(ctx, "kv.DistSender: sending commit record leg", func(ctx context.Context) {
+		reply, pErr := ds.divideAndSendBatchToRanges(ctx, recordBa, recordRS, isReverse, true /* withCommit */, batchIdx)
+		recordResponseCh <- response{reply: reply, positions: recordPositions, pErr: pErr}
+	}); err != nil {
+		return nil, kvpb.NewError(err)
+	}
+	restReply, restPErr := ds.divideAndSendBatchToRanges(ctx, restBa, restRS, isReverse, true /* withCommit */, batchIdx+1)
+	resps := [2]response{
+		<-recordResponseCh,
+		{reply: restReply, positions: restPositions, pErr: restPErr},
+	}
+
+	var pErr *kvpb.Error
+	for _, resp := range resps {
+		if resp.pErr == nil {
+			continue
+		}
+		// Re-map the error index within the leg back to its position in the
+		// batch.
+		if resp.pErr.Index != nil && resp.pErr.Index.Index != -1 {
+			resp.pErr.Index.Index = int32(resp.positions[resp.pErr.Index.Index])
+		}
+		if pErr == nil {
+			pErr = resp.pErr
+		} else {
+			pErr = mergeErrors(pErr, resp.pErr)
+		}
+	}
+	if pErr != nil {
+		for _, resp := range resps {
+			if resp.reply != nil {
+				pErr.UpdateTxn(resp.reply.Txn)
+			}
+		}
+		return nil, pErr
+	}
+
+	br := &kvpb.BatchResponse{
+		Responses: make([]kvpb.ResponseUnion, len(ba.Requests)),
+	}
+	for _, resp := range resps {
+		if err := br.Combine(ctx, resp.reply, resp.positions, ba); err != nil {
+			return nil, kvpb.NewError(err)
+		}
+	}
+	return br, nil
+}
+
+// splitCommitRecordLeg returns the positions of the requests of the record
+// leg of the parallel-committing batch, ending with its EndTxn request, and
+// the positions of the rest of its requests. It returns false if the batch
+// should not be split, see divideAndSendCommitRecordFirst.
+func (ds *DistSender) splitCommitRecordLeg(
+	ctx context.Context, ba *kvpb.BatchRequest, rs roachpb.RSpan,
+) (recordPositions, restPositions []int, ok bool) {
+	if !parallelCommitRecordFirst.Get(&ds.st.SV) {
+		return nil, nil, false
+	}
+	lastIdx := len(ba.Requests) - 1
+	et, isET := ba.Requests[lastIdx].GetInner().(*kvpb.EndTxnRequest)
+	if !isET || !et.IsParallelCommit() {
+		return nil, nil, false
+	}
+	recordKey, err := keys.Addr(et.Key)
+	if err != nil {
+		return nil, nil, false
+	}
+	ri := MakeRangeIterator(ds)
+	ri.Seek(ctx, recordKey, Ascending)
+	if !ri.Valid() {
+		return nil, nil, false
+	}
+	recordRS := ri.Desc().RSpan()
+	if recordRS.ContainsKeyRange(rs.Key, rs.EndKey) {
+		return nil, nil, false
+	}
+	for i := range ba.Requests[:lastIdx] {
+		reqRS, err := keys.Range(ba.Requests[i : i+1])
+		if err != nil {
+			return nil, nil, false
+		}
+		switch {
+		case recordRS.ContainsKeyRange(reqRS.Key, reqRS.EndKey):
+			recordPositions = append(recordPositions, i)
+		case reqRS.Key.Less(recordRS.EndKey) && recordRS.Key.Less(reqRS.EndKey):
+			return nil, nil, false
+		default:
+			restPositions = append(restPositions, i)
+		}
+	}
+	recordPositions = append(recordPositions, lastIdx)
+	return recordPositions, restPositions, len(restPositions) > 0
+}
+
 // detectIntentMissingDueToIntentResolution attempts to detect whether a missing
 // intent error thrown by a pre-commit QueryIntent request was due to intent
 // resolution after the transaction was already finalized instead of due to a
@@ -1549,6 +1910,12 @@ func (ds *DistSender) divideAndSendBatchToRanges(
 	// the ResumeSpan is necessary. This reason is common to all individual
 	// responses that carry a ResumeSpan.
 	var resumeReason kvpb.ResumeReason
+	// If the batch sizes its range responses adaptively, adaptive holds the
+	// response size budget of the next range.
+	adaptive := makeAdaptiveTargetBytes(ba)
+	if adaptive.enabled() {
+		ds.metrics.AdaptiveTargetBytes.BatchCount.Inc(1)
+	}
 	defer func() {
 		if r := recover(); r != nil {
 			// If we're in the middle of a panic, don't wait on responseChs.
@@ -1598,6 +1965,9 @@ func (ds *DistSender) divideAndSendBatchToRanges(
 		if pErr == nil && couldHaveSkippedResponses {
 			fillSkippedResponses(ba, br, seekKey, resumeReason, isReverse)
 		}
+		if pErr == nil && adaptive.enabled() {
+			br.AdaptiveTargetBytesHint = adaptive.budget
+		}
 	}()
 
 	canParallelize := ba.Header.MaxSpanRequestKeys == 0 && ba.Header.TargetBytes == 0 &&
@@ -1642,6 +2012,9 @@ func (ds *DistSender) divideAndSendBatchToRanges(
 	// imply no parallelism), ba.MaxSpanRequestKeys and ba.TargetBytes are
 	// adjusted with the responses for the sub-batch. If a limit is exhausted, the
 	// loop breaks.
+	// batchConc holds the adaptive concurrency limit of the partial batches of
+	// the batch sent asynchronously.
+	var batchConc batchConcurrency
 	for ; ri.Valid(); ri.Seek(ctx, seekKey, scanDir) {
 		responseCh := make(chan response, 1)
 		responseChs = append(responseChs, responseCh)
@@ -1663,6 +2036,12 @@ func (ds *DistSender) divideAndSendBatchToRanges(
 			responseCh <- response{pErr: kvpb.NewError(err)}
 			return
 		}
+		if adaptive.enabled() {
+			// The range gets the current budget, and the batch it's sent in is
+			// no longer sized adaptively.
+			curRangeBatch.TargetBytes = adaptive.rangeTargetBytes(ba.TargetBytes)
+			curRangeBatch.AdaptiveTargetBytes = 0
+		}
 		nextRS := rs
 		if scanDir == Ascending {
 			nextRS.Key = seekKey
@@ -1675,7 +2054,7 @@ func (ds *DistSender) divideAndSendBatchToRanges(
 		// If we can reserve one of the limited goroutines available for parallel
 		// batch RPCs, send asynchronously.
 		if canParallelize && !lastRange && !ds.disableParallelBatches &&
-			ds.sendPartialBatchAsync(ctx, curRangeBatch, curRangeRS, isReverse, withCommit, batchIdx, ri.Token(), responseCh, positions) {
+			ds.sendPartialBatchAsync(ctx, curRangeBatch, curRangeRS, isReverse, withCommit, batchIdx, ri.Token(), responseCh, positions, &batchConc) {
 			// Sent the batch asynchronously.
 		} else {
 			resp := ds.sendPartialBatch(
@@ -1692,6 +2071,12 @@ func (ds *DistSender) divideAndSendBatchToRanges(
 			if !lastRange {
 				ba.UpdateTxn(resp.reply.Txn)
 			}
+			// If the range stopped at its adaptive budget, the batch returns
+			// early below with the range's resume spans.
+			if adaptive.enabled() &&
+				adaptive.observe(resp.reply, curRangeBatch.TargetBytes, ba.TargetBytes) {
+				ds.metrics.AdaptiveTargetBytes.EarlyReturnCount.Inc(1)
+			}
 
 			mightStopEarly := ba.MaxSpanRequestKeys > 0 || ba.TargetBytes > 0 || ba.ReturnOnRangeBoundary || ba.ReturnElasticCPUResumeSpans
 			// Check whether we've received enough responses to exit query loop.
@@ -1757,10 +2142,41 @@ func (ds *DistSender) divideAndSendBatchToRanges(
 	return
 }
 
+// batchConnectionClass returns the class of the connections the batch,
+// addressed to the given range, is sent over.
+func batchConnectionClass(
+	sv *settings.Values, ba *kvpb.BatchRequest, desc *roachpb.RangeDescriptor,
+) rpc.ConnectionClass {
+	class := rpc.ConnectionClassForKey(desc.RSpan().Key)
+	if class == rpc.DefaultClass && useDedicatedBulkConnectionClass.Get(sv) && isBulkBatch(ba) {
+		return rpc.BulkClass
+	}
+	return class
+}
+
+// isBulkBatch returns whether the batch only contains bulk requests.
+func isBulkBatch(ba *kvpb.BatchRequest) bool {
+	if len(ba.Requests) == 0 {
+		return false
+	}
+	for _, ru := range ba.Requests {
+		switch ru.GetInner().(type) {
+		case *kvpb.AddSSTableRequest, *kvpb.ExportRequest:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // sendPartialBatchAsync sends the partial batch asynchronously if
 // there aren't currently more than the allowed number of concurrent
-// async requests outstanding. Returns whether the partial batch was
-// sent.
+// async requests outstanding, overall and, under adaptive concurrency,
+// for the batch and the leaseholder's node. batchConc is the adaptive
+// concurrency of the batch. Returns whether the partial batch was sent.
+//
+// The partial batches of system-critical traffic are instead only limited
+// by the concurrency of the system lane, see isSystemCriticalSpan.
 func (ds *DistSender) sendPartialBatchAsync(
 	ctx context.Context,
 	ba *kvpb.BatchRequest,
@@ -1771,22 +2187,52 @@ func (ds *DistSender) sendPartialBatchAsync(
 	routing rangecache.EvictionToken,
 	responseCh chan response,
 	positions []int,
+	batchConc *batchConcurrency,
 ) bool {
+	systemLane := systemLaneEnabled.Get(&ds.st.SV) && isSystemCriticalSpan(rs)
+	sem := ds.asyncSenderSem
+	var permit *adaptiveConcurrencyPermit
+	if systemLane {
+		sem = ds.asyncSystemSenderSem
+	} else {
+		var nodeID roachpb.NodeID
+		if lh := routing.Leaseholder(); lh != nil {
+			nodeID = lh.NodeID
+		}
+		var ok bool
+		permit, ok = ds.adaptiveConcurrency.tryAcquire(nodeID, batchConc)
+		if !ok {
+			ds.metrics.AsyncAdaptiveThrottledCount.Inc(1)
+			return false
+		}
+	}
 	if err := ds.stopper.RunAsyncTaskEx(
 		ctx,
 		stop.TaskOpts{
 			TaskName:   "kv.DistSender: sending partial batch",
 			SpanOpt:    stop.ChildSpan,
-			Sem:        ds.asyncSenderSem,
+			Sem:        sem,
 			WaitForSem: false,
 		},
 		func(ctx context.Context) {
+			if systemLane {
+				ds.metrics.AsyncSystemLaneSentCount.Inc(1)
+			}
 			ds.metrics.AsyncSentCount.Inc(1)
 			resp := ds.sendPartialBatch(ctx, ba, rs, isReverse, withCommit, batchIdx, routing)
+			if permit != nil {
+				permit.release(resp.pErr)
+			}
 			resp.positions = positions
 			responseCh <- resp
 		},
 	); err != nil {
+		if permit != nil {
+			permit.cancel()
+		}
+		if systemLane {
+			ds.metrics.AsyncSystemLaneThrottledCount.Inc(1)
+		}
 		ds.metrics.AsyncThrottledCount.Inc(1)
 		return false
 	}
@@ -2288,7 +2734,7 @@ func (ds *DistSender) sendToReplicas(
 	}
 
 	opts := SendOptions{
-		class:                  rpc.ConnectionClassForKey(desc.RSpan().Key),
+		class:                  batchConnectionClass(&ds.st.SV, ba, desc),
 		metrics:                &ds.metrics,
 		dontConsiderConnHealth: ds.dontConsiderConnHealth,
 	}
@@ -2354,6 +2800,16 @@ func (ds *DistSender) sendToReplicas(
 		ba.Replica = curReplica
 		ba.RangeID = desc.RangeID
 
+		// Hand the transaction the observed timestamp hint of the node, if
+		// possible. Otherwise, note when the batch is sent in case the observed
+		// timestamp the node records becomes a hint.
+		var hintSentAt hlc.Timestamp
+		if ba.Txn != nil && observedTimestampHintsEnabled.Get(&ds.st.SV) {
+			if ds.observedTimestampHints.maybeApply(ba, ds.nodeIDGetter()) {
+				hintSentAt = ds.clock.Now()
+			}
+		}
+
 		// When a sub-batch from a batch containing a commit experiences an
 		// ambiguous error, it is critical to ensure subsequent replay attempts
 		// do not permit changing the write timestamp, as the transaction may
@@ -2498,6 +2954,9 @@ func (ds *DistSender) sendToReplicas(
 				if !br.Error.Now.IsEmpty() {
 					ds.clock.Update(br.Error.Now)
 				}
+				if !hintSentAt.IsEmpty() {
+					ds.observedTimestampHints.maybeRecord(curReplica.NodeID, hintSentAt, br.Error)
+				}
 			} else if !br.Now.IsEmpty() {
 				ds.clock.Update(br.Now)
 			}
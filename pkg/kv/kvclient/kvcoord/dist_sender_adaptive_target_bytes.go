@@ -0,0 +1,115 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+)
+
+var (
+	metaAdaptiveTargetBytesBatches = metric.Metadata{
+		Name:        "distsender.adaptive_target_bytes.batches",
+		Help:        "Number of batches whose range responses were sized adaptively",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaAdaptiveTargetBytesEarlyReturns = metric.Metadata{
+		Name:        "distsender.adaptive_target_bytes.early_returns",
+		Help:        "Number of adaptively sized batches returned before exhausting their TargetBytes",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// AdaptiveTargetBytesMetrics are the metrics of the batches whose range
+// responses are sized adaptively, see kvpb.Header.AdaptiveTargetBytes.
+type AdaptiveTargetBytesMetrics struct {
+	BatchCount       *metric.Counter
+	EarlyReturnCount *metric.Counter
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (AdaptiveTargetBytesMetrics) MetricStruct() {}
+
+func makeAdaptiveTargetBytesMetrics() AdaptiveTargetBytesMetrics {
+	return AdaptiveTargetBytesMetrics{
+		BatchCount:       metric.NewCounter(metaAdaptiveTargetBytesBatches),
+		EarlyReturnCount: metric.NewCounter(metaAdaptiveTargetBytesEarlyReturns),
+	}
+}
+
+// adaptiveTargetBytesMinRows is the minimum number of rows, of the average size
+// returned so far, which the budget of the next range fits.
+const adaptiveTargetBytesMinRows = 16
+
+// adaptiveTargetBytes sizes the responses of the ranges a batch is sent to,
+// starting from the batch's AdaptiveTargetBytes and growing with the rows
+// returned, up to the batch's TargetBytes.
+type adaptiveTargetBytes struct {
+	// budget is the response size budget of the next range. Zero if the batch
+	// doesn't size its range responses adaptively.
+	budget int64
+	// limit is the TargetBytes of the batch, which caps budget.
+	limit int64
+}
+
+func makeAdaptiveTargetBytes(ba *kvpb.BatchRequest) adaptiveTargetBytes {
+	if ba.AdaptiveTargetBytes <= 0 || ba.TargetBytes <= 0 {
+		return adaptiveTargetBytes{}
+	}
+	return adaptiveTargetBytes{
+		budget: min(ba.AdaptiveTargetBytes, ba.TargetBytes),
+		limit:  ba.TargetBytes,
+	}
+}
+
+func (a *adaptiveTargetBytes) enabled() bool {
+	return a.budget > 0
+}
+
+// rangeTargetBytes returns the TargetBytes of the next range, given the
+// remaining TargetBytes of the batch.
+func (a *adaptiveTargetBytes) rangeTargetBytes(remaining int64) int64 {
+	return min(a.budget, remaining)
+}
+
+// observe grows the budget with the response of a range sent with the given
+// TargetBytes, while the batch had the given remaining TargetBytes. Returns
+// whether the range stopped at its budget before the batch's TargetBytes were
+// exhausted.
+func (a *adaptiveTargetBytes) observe(
+	br *kvpb.BatchResponse, rangeTargetBytes, remaining int64,
+) (stoppedEarly bool) {
+	var numKeys, numBytes int64
+	for _, r := range br.Responses {
+		h := r.GetInner().Header()
+		numKeys += h.NumKeys
+		numBytes += h.NumBytes
+		if h.ResumeReason == kvpb.RESUME_BYTE_LIMIT && rangeTargetBytes < remaining {
+			stoppedEarly = true
+		}
+	}
+	a.budget = growAdaptiveTargetBytes(a.budget, numKeys, numBytes, a.limit)
+	return stoppedEarly
+}
+
+// growAdaptiveTargetBytes returns the budget following the given one, after a
+// range returned numKeys keys totaling numBytes. The budget at least doubles,
+// and fits at least adaptiveTargetBytesMinRows rows of the average size
+// returned, up to limit.
+func growAdaptiveTargetBytes(budget, numKeys, numBytes, limit int64) int64 {
+	next := 2 * budget
+	if numKeys > 0 {
+		next = max(next, adaptiveTargetBytesMinRows*(numBytes/numKeys))
+	}
+	return min(next, limit)
+}
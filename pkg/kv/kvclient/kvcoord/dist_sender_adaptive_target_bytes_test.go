@@ -0,0 +1,92 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrowAdaptiveTargetBytes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	for _, tc := range []struct {
+		budget, numKeys, numBytes, limit int64
+		expected                         int64
+	}{
+		// The budget doubles with small rows, or without rows.
+		{budget: 1 << 10, numKeys: 10, numBytes: 100, limit: 1 << 20, expected: 2 << 10},
+		{budget: 1 << 10, numKeys: 0, numBytes: 0, limit: 1 << 20, expected: 2 << 10},
+		// The budget fits adaptiveTargetBytesMinRows rows of the average size.
+		{budget: 1 << 10, numKeys: 2, numBytes: 2 << 10, limit: 1 << 20, expected: 16 << 10},
+		// The budget is capped by the limit.
+		{budget: 1 << 10, numKeys: 2, numBytes: 2 << 10, limit: 4 << 10, expected: 4 << 10},
+		{budget: 4 << 10, numKeys: 0, numBytes: 0, limit: 4 << 10, expected: 4 << 10},
+	} {
+		require.Equal(t, tc.expected,
+			growAdaptiveTargetBytes(tc.budget, tc.numKeys, tc.numBytes, tc.limit), "%+v", tc)
+	}
+}
+
+func TestAdaptiveTargetBytes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ba := &kvpb.BatchRequest{}
+	ba.TargetBytes = 10 << 10
+	require.False(t, makeAdaptiveTargetBytes(ba).enabled())
+	ba.AdaptiveTargetBytes = 1 << 10
+	a := makeAdaptiveTargetBytes(ba)
+	require.True(t, a.enabled())
+
+	reply := func(numKeys, numBytes int64, resumeReason kvpb.ResumeReason) *kvpb.BatchResponse {
+		br := &kvpb.BatchResponse{}
+		resp := &kvpb.ScanResponse{}
+		resp.NumKeys, resp.NumBytes = numKeys, numBytes
+		if resumeReason != 0 {
+			resp.ResumeSpan = &roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")}
+			resp.ResumeReason = resumeReason
+		}
+		br.Add(resp)
+		return br
+	}
+
+	// The first range gets the initial budget, and finishes within it.
+	remaining := ba.TargetBytes
+	rangeTargetBytes := a.rangeTargetBytes(remaining)
+	require.Equal(t, int64(1<<10), rangeTargetBytes)
+	require.False(t, a.observe(reply(5, 500, 0), rangeTargetBytes, remaining))
+	remaining -= 500
+
+	// The second range gets a doubled budget, and stops at it before the
+	// batch's TargetBytes are exhausted.
+	rangeTargetBytes = a.rangeTargetBytes(remaining)
+	require.Equal(t, int64(2<<10), rangeTargetBytes)
+	require.True(t, a.observe(reply(20, 2<<10, kvpb.RESUME_BYTE_LIMIT), rangeTargetBytes, remaining))
+	remaining -= 2 << 10
+
+	// A range stopping at the batch's remaining TargetBytes doesn't stop early.
+	require.Equal(t, int64(4<<10), a.budget)
+	remaining = 3 << 10
+	rangeTargetBytes = a.rangeTargetBytes(remaining)
+	require.Equal(t, remaining, rangeTargetBytes)
+	require.False(t, a.observe(reply(30, 3<<10, kvpb.RESUME_BYTE_LIMIT), rangeTargetBytes, remaining))
+
+	// The budget never exceeds the batch's TargetBytes.
+	require.False(t, a.observe(reply(1, 9<<10, 0), 8<<10, 8<<10))
+	require.Equal(t, ba.TargetBytes, a.budget)
+}
@@ -0,0 +1,270 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+)
+
+// adaptiveConcurrencyEnabled controls whether the parallelism of the partial
+// batches sent asynchronously by the DistSender adapts to the errors and the
+// latency they observe.
+var adaptiveConcurrencyEnabled = settings.RegisterBoolSetting(
+	settings.ApplicationLevel,
+	"kv.dist_sender.adaptive_concurrency.enabled",
+	"if enabled, the number of partial batches of a batch, and to a node, sent "+
+		"concurrently adapts to the errors and latency of the partial batches",
+	false,
+)
+
+var adaptiveConcurrencyPerBatchMin = settings.RegisterIntSetting(
+	settings.ApplicationLevel,
+	"kv.dist_sender.adaptive_concurrency.per_batch.min",
+	"minimum number of partial batches of a batch sent concurrently when "+
+		"kv.dist_sender.adaptive_concurrency.enabled is set",
+	4,
+	settings.PositiveInt,
+)
+
+var adaptiveConcurrencyPerBatchMax = settings.RegisterIntSetting(
+	settings.ApplicationLevel,
+	"kv.dist_sender.adaptive_concurrency.per_batch.max",
+	"maximum number of partial batches of a batch sent concurrently when "+
+		"kv.dist_sender.adaptive_concurrency.enabled is set",
+	256,
+	settings.PositiveInt,
+)
+
+var adaptiveConcurrencyPerNodeMin = settings.RegisterIntSetting(
+	settings.ApplicationLevel,
+	"kv.dist_sender.adaptive_concurrency.per_node.min",
+	"minimum number of partial batches sent concurrently to a node when "+
+		"kv.dist_sender.adaptive_concurrency.enabled is set",
+	2,
+	settings.PositiveInt,
+)
+
+var adaptiveConcurrencyPerNodeMax = settings.RegisterIntSetting(
+	settings.ApplicationLevel,
+	"kv.dist_sender.adaptive_concurrency.per_node.max",
+	"maximum number of partial batches sent concurrently to a node when "+
+		"kv.dist_sender.adaptive_concurrency.enabled is set",
+	64,
+	settings.PositiveInt,
+)
+
+var adaptiveConcurrencyLatencyTarget = settings.RegisterDurationSetting(
+	settings.ApplicationLevel,
+	"kv.dist_sender.adaptive_concurrency.latency_target",
+	"latency of a partial batch above which the DistSender reduces its "+
+		"concurrency when kv.dist_sender.adaptive_concurrency.enabled is set",
+	time.Second,
+	settings.PositiveDuration,
+)
+
+// aimdLimit is a concurrency limit adjusted with additive increase,
+// multiplicative decrease (AIMD): every successful partial batch increases the
+// limit by 1/limit, i.e. by 1 once as many partial batches as the limit have
+// succeeded, and a failed or slow one halves it.
+type aimdLimit struct {
+	// limit is the current limit. It is zero until the first use, which
+	// initializes it to the ceiling: the controller starts with the full
+	// parallelism and backs off once it observes trouble.
+	limit float64
+	// lastDecrease is the time of the last decrease. The limit is decreased at
+	// most once per latency target, so that a burst of partial batches failing
+	// together counts as a single signal.
+	lastDecrease time.Time
+}
+
+// get returns the limit, clamped to the given bounds.
+func (l *aimdLimit) get(floor, ceiling int64) int64 {
+	if ceiling < floor {
+		ceiling = floor
+	}
+	if l.limit == 0 {
+		l.limit = float64(ceiling)
+	}
+	if l.limit < float64(floor) {
+		l.limit = float64(floor)
+	} else if l.limit > float64(ceiling) {
+		l.limit = float64(ceiling)
+	}
+	return int64(l.limit)
+}
+
+// record adjusts the limit to the outcome of a partial batch.
+func (l *aimdLimit) record(
+	now time.Time, overloaded bool, target time.Duration, floor, ceiling int64,
+) {
+	l.get(floor, ceiling)
+	if overloaded {
+		if now.Sub(l.lastDecrease) < target {
+			return
+		}
+		l.lastDecrease = now
+		l.limit /= 2
+	} else {
+		l.limit += 1 / l.limit
+	}
+	l.get(floor, ceiling)
+}
+
+// nodeConcurrency is the adaptive concurrency of the partial batches sent to a
+// node.
+type nodeConcurrency struct {
+	aimdLimit
+	inFlight int64
+}
+
+// batchConcurrency is the adaptive concurrency of the partial batches of a
+// single batch. It lives for the duration of the batch: each batch adapts its
+// own limit to the outcome of its partial batches, so that the errors or the
+// latency observed by a batch don't throttle the unrelated batches sent
+// concurrently. Only the limits per node are shared, by all batches (see
+// adaptiveConcurrency).
+type batchConcurrency struct {
+	mu struct {
+		syncutil.Mutex
+		aimdLimit
+		// inFlight is the number of partial batches of the batch sent
+		// asynchronously and in flight.
+		inFlight int64
+	}
+}
+
+// adaptiveConcurrency controls the number of partial batches the DistSender
+// sends concurrently, both per batch and per node, when
+// kv.dist_sender.adaptive_concurrency.enabled is set. It complements
+// kv.dist_sender.concurrency_limit, which bounds the number of partial batches
+// sent concurrently by the DistSender as a whole: huge scans on small clusters
+// back off as the nodes they fan out to slow down or fail, while large
+// clusters keep the full parallelism as long as they keep up.
+//
+// The limits per node are held by the adaptiveConcurrency, while the limit of
+// each batch is held by its batchConcurrency.
+type adaptiveConcurrency struct {
+	st  *cluster.Settings
+	now func() time.Time
+	mu  struct {
+		syncutil.Mutex
+		nodes map[roachpb.NodeID]*nodeConcurrency
+	}
+}
+
+func newAdaptiveConcurrency(st *cluster.Settings, now func() time.Time) *adaptiveConcurrency {
+	c := &adaptiveConcurrency{st: st, now: now}
+	c.mu.nodes = map[roachpb.NodeID]*nodeConcurrency{}
+	return c
+}
+
+// adaptiveConcurrencyPermit is held by a partial batch sent asynchronously
+// under the adaptive concurrency limits.
+type adaptiveConcurrencyPermit struct {
+	c     *adaptiveConcurrency
+	start time.Time
+	batch *batchConcurrency
+	node  *nodeConcurrency
+}
+
+// tryAcquire returns a permit to send a partial batch of the given batch
+// asynchronously to the given node, zero if unknown. The permit must be
+// released once the partial batch is done.
+//
+// ok is false if the batch or the node already have as many partial batches in
+// flight as allowed, in which case the partial batch is to be sent
+// synchronously. The permit is nil if adaptive concurrency is disabled.
+func (c *adaptiveConcurrency) tryAcquire(
+	nodeID roachpb.NodeID, batch *batchConcurrency,
+) (_ *adaptiveConcurrencyPermit, ok bool) {
+	sv := &c.st.SV
+	if !adaptiveConcurrencyEnabled.Get(sv) {
+		return nil, true
+	}
+	batch.mu.Lock()
+	defer batch.mu.Unlock()
+	batchLimit := batch.mu.get(
+		adaptiveConcurrencyPerBatchMin.Get(sv), adaptiveConcurrencyPerBatchMax.Get(sv))
+	if batch.mu.inFlight >= batchLimit {
+		return nil, false
+	}
+	var node *nodeConcurrency
+	if nodeID != 0 {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		node = c.mu.nodes[nodeID]
+		if node == nil {
+			node = &nodeConcurrency{}
+			c.mu.nodes[nodeID] = node
+		}
+		nodeLimit := node.get(
+			adaptiveConcurrencyPerNodeMin.Get(sv), adaptiveConcurrencyPerNodeMax.Get(sv))
+		if node.inFlight >= nodeLimit {
+			return nil, false
+		}
+		node.inFlight++
+	}
+	batch.mu.inFlight++
+	return &adaptiveConcurrencyPermit{c: c, start: c.now(), batch: batch, node: node}, true
+}
+
+// release releases the permit, adjusting the limits to the outcome of the
+// partial batch.
+func (p *adaptiveConcurrencyPermit) release(pErr *kvpb.Error) {
+	c := p.c
+	sv := &c.st.SV
+	now := c.now()
+	target := adaptiveConcurrencyLatencyTarget.Get(sv)
+	overloaded := now.Sub(p.start) > target || (pErr != nil && isOverloadError(pErr.GoError()))
+
+	p.batch.mu.Lock()
+	p.batch.mu.inFlight--
+	p.batch.mu.record(now, overloaded, target,
+		adaptiveConcurrencyPerBatchMin.Get(sv), adaptiveConcurrencyPerBatchMax.Get(sv))
+	p.batch.mu.Unlock()
+	if p.node != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		p.node.inFlight--
+		p.node.record(now, overloaded, target,
+			adaptiveConcurrencyPerNodeMin.Get(sv), adaptiveConcurrencyPerNodeMax.Get(sv))
+	}
+}
+
+// cancel releases the permit of a partial batch which wasn't sent, without
+// adjusting the limits.
+func (p *adaptiveConcurrencyPermit) cancel() {
+	p.batch.mu.Lock()
+	p.batch.mu.inFlight--
+	p.batch.mu.Unlock()
+	if p.node != nil {
+		p.c.mu.Lock()
+		defer p.c.mu.Unlock()
+		p.node.inFlight--
+	}
+}
+
+// isOverloadError returns whether the error of a partial batch indicates that
+// the ranges or nodes it was sent to are overloaded or unavailable, as opposed
+// to a logical error of the request.
+func isOverloadError(err error) bool {
+	return IsSendError(err) ||
+		errors.HasType(err, (*kvpb.ReplicaUnavailableError)(nil)) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptiveConcurrency(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	now := time.Unix(1000, 0)
+	c := newAdaptiveConcurrency(st, func() time.Time { return now })
+
+	// Adaptive concurrency is disabled by default.
+	var batch batchConcurrency
+	permit, ok := c.tryAcquire(1, &batch)
+	require.True(t, ok)
+	require.Nil(t, permit)
+
+	adaptiveConcurrencyEnabled.Override(ctx, &st.SV, true)
+	adaptiveConcurrencyPerBatchMin.Override(ctx, &st.SV, 1)
+	adaptiveConcurrencyPerBatchMax.Override(ctx, &st.SV, 8)
+	adaptiveConcurrencyPerNodeMin.Override(ctx, &st.SV, 1)
+	adaptiveConcurrencyPerNodeMax.Override(ctx, &st.SV, 4)
+	adaptiveConcurrencyLatencyTarget.Override(ctx, &st.SV, time.Second)
+
+	acquireFor := func(
+		batch *batchConcurrency, n int, nodeID roachpb.NodeID,
+	) []*adaptiveConcurrencyPermit {
+		var permits []*adaptiveConcurrencyPermit
+		for i := 0; i < n; i++ {
+			permit, ok := c.tryAcquire(nodeID, batch)
+			if !ok {
+				break
+			}
+			permits = append(permits, permit)
+		}
+		return permits
+	}
+	acquire := func(n int, nodeID roachpb.NodeID) []*adaptiveConcurrencyPermit {
+		return acquireFor(&batch, n, nodeID)
+	}
+	inFlight := func() int64 {
+		batch.mu.Lock()
+		defer batch.mu.Unlock()
+		return batch.mu.inFlight
+	}
+	cancel := func(permits []*adaptiveConcurrencyPermit) {
+		for _, p := range permits {
+			p.cancel()
+		}
+	}
+
+	// The limits start at the ceilings: 4 partial batches to node 1, and 8
+	// overall for the batch.
+	n1 := acquire(10, 1)
+	require.Len(t, n1, 4)
+	n2 := acquire(10, 2)
+	require.Len(t, n2, 4)
+	require.EqualValues(t, 8, inFlight())
+	_, ok = c.tryAcquire(3, &batch)
+	require.False(t, ok)
+
+	// A slow partial batch to node 1 halves the limits.
+	now = now.Add(2 * time.Second)
+	n1[0].release(nil /* pErr */)
+	// A burst of overload errors counts as a single signal.
+	sendErr := kvpb.NewError(newSendError(errors.New("boom")))
+	for _, p := range n1[1:] {
+		p.release(sendErr)
+	}
+	cancel(n2)
+	require.Zero(t, inFlight())
+	permits := acquire(10, 1)
+	require.Len(t, permits, 2)
+	cancel(permits)
+
+	// Other batches are only held back by the limit of the node, not by the
+	// limit of the batch which observed the slowness.
+	var other batchConcurrency
+	permits = acquireFor(&other, 10, 1)
+	require.Len(t, permits, 2)
+	permits = append(permits, acquireFor(&other, 10, 2)...)
+	require.Len(t, permits, 6)
+	cancel(permits)
+
+	// Logical errors don't decrease the limits, and successes increase them
+	// additively: the node limit grows from 2 to 4 after 6 partial batches.
+	for i := 0; i < 6; i++ {
+		permits := acquire(1, 1)
+		require.Len(t, permits, 1)
+		permits[0].release(kvpb.NewError(&kvpb.ConditionFailedError{}))
+	}
+	permits = acquire(10, 1)
+	require.Len(t, permits, 4)
+	cancel(permits)
+
+	// The limits never go below the floors.
+	adaptiveConcurrencyPerBatchMin.Override(ctx, &st.SV, 4)
+	adaptiveConcurrencyPerNodeMin.Override(ctx, &st.SV, 3)
+	for i := 0; i < 5; i++ {
+		now = now.Add(2 * time.Second)
+		permits := acquire(1, 1)
+		require.Len(t, permits, 1)
+		permits[0].release(sendErr)
+	}
+	require.Len(t, acquire(10, 1), 3)
+	require.Len(t, acquire(10, 2), 1)
+}
@@ -0,0 +1,825 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"context"
+	"encoding/binary"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	metaHashShardingBatches = metric.Metadata{
+		Name:        "distsender.hash_sharding.batches",
+		Help:        "Number of batches translated to the physical keys of hash-sharded spans",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaHashShardingFanOuts = metric.Metadata{
+		Name:        "distsender.hash_sharding.fan_outs",
+		Help:        "Number of range requests fanned out to the shards of hash-sharded spans",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// HashShardingMetrics are the metrics of the translation of the batches
+// addressing hash-sharded spans.
+type HashShardingMetrics struct {
+	BatchCount  *metric.Counter
+	FanOutCount *metric.Counter
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (HashShardingMetrics) MetricStruct() {}
+
+func makeHashShardingMetrics() HashShardingMetrics {
+	return HashShardingMetrics{
+		BatchCount:  metric.NewCounter(metaHashShardingBatches),
+		FanOutCount: metric.NewCounter(metaHashShardingFanOuts),
+	}
+}
+
+// hashSharder finds the hash-sharded spans addressed by the batches, see
+// kvserverbase.HashShardedSpan.
+type hashSharder struct {
+	watcher *kvserverbase.HashShardedSpansWatcher
+}
+
+func newHashSharder(watcher *kvserverbase.HashShardedSpansWatcher) *hashSharder {
+	return &hashSharder{watcher: watcher}
+}
+
+// spansFor returns the hash-sharded spans, if the batch addresses any of them
+// with logical keys.
+func (hs *hashSharder) spansFor(ba *kvpb.BatchRequest) kvserverbase.HashShardedSpans {
+	if ba.HashShardedKeysTranslated {
+		return nil
+	}
+	spans := hs.watcher.Get()
+	if len(spans) == 0 {
+		return nil
+	}
+	for _, ru := range ba.Requests {
+		switch t := ru.GetInner().(type) {
+		case *kvpb.EndTxnRequest:
+			for _, sp := range t.LockSpans {
+				if overlapsHashShardedSpans(spans, sp) {
+					return spans
+				}
+			}
+			for _, w := range t.InFlightWrites {
+				if _, ok := spans.Find(w.Key); ok {
+					return spans
+				}
+			}
+		default:
+			if overlapsHashShardedSpans(spans, t.Header().Span()) {
+				return spans
+			}
+		}
+	}
+	return nil
+}
+
+func overlapsHashShardedSpans(spans kvserverbase.HashShardedSpans, sp roachpb.Span) bool {
+	if len(sp.EndKey) == 0 {
+		_, ok := spans.Find(sp.Key)
+		return ok
+	}
+	return len(spans.Overlapping(sp)) > 0
+}
+
+// hashShardedPart is a physical span holding part of the keys of a logical
+// span, along with the hash-sharded span whose shard it is in, if any.
+type hashShardedPart struct {
+	span   roachpb.Span
+	shards *kvserverbase.HashShardedSpan
+	// logical is the part of the logical span whose keys the physical span
+	// holds. The parts of a same segment hold the keys of the same part of the
+	// logical span in different shards, while the parts of consecutive
+	// segments hold the keys of consecutive parts of the logical span.
+	logical roachpb.Span
+	segment int
+}
+
+// splitHashSharded splits the given logical span into the physical spans
+// holding its keys, in order.
+func splitHashSharded(spans kvserverbase.HashShardedSpans, sp roachpb.Span) []hashShardedPart {
+	var parts []hashShardedPart
+	segment := 0
+	cursor := sp.Key
+	overlapping := spans.Overlapping(sp)
+	for i := range overlapping {
+		s := &overlapping[i]
+		if cursor.Compare(s.Prefix) < 0 {
+			unsharded := roachpb.Span{Key: cursor, EndKey: s.Prefix}
+			parts = append(parts, hashShardedPart{
+				span: unsharded, logical: unsharded, segment: segment,
+			})
+			segment++
+		}
+		logical := s.Span().Intersect(sp)
+		for shard := 0; shard < s.NumShards; shard++ {
+			parts = append(parts, hashShardedPart{
+				span: s.PhysicalSpan(shard, sp), shards: s, logical: logical, segment: segment,
+			})
+		}
+		segment++
+		cursor = s.Span().EndKey
+	}
+	if cursor.Compare(sp.EndKey) < 0 {
+		unsharded := roachpb.Span{Key: cursor, EndKey: sp.EndKey}
+		parts = append(parts, hashShardedPart{
+			span: unsharded, logical: unsharded, segment: segment,
+		})
+	}
+	return parts
+}
+
+// logicalKey returns the logical key of a physical key in the part.
+func (p *hashShardedPart) logicalKey(key roachpb.Key) (roachpb.Key, error) {
+	switch {
+	case p.shards == nil:
+		return key, nil
+	case key.Equal(p.span.Key):
+		return p.logical.Key, nil
+	case key.Equal(p.span.EndKey):
+		return p.logical.EndKey, nil
+	default:
+		return p.shards.LogicalKey(key)
+	}
+}
+
+// physicalSpans translates the given logical spans, e.g. the lock spans of a
+// transaction, to the physical spans holding their keys.
+func physicalSpans(
+	spans kvserverbase.HashShardedSpans, logical []roachpb.Span,
+) []roachpb.Span {
+	res := make([]roachpb.Span, 0, len(logical))
+	for _, sp := range logical {
+		if len(sp.EndKey) == 0 {
+			if s, ok := spans.Find(sp.Key); ok {
+				sp.Key = s.PhysicalKey(sp.Key)
+			}
+			res = append(res, sp)
+			continue
+		}
+		for _, part := range splitHashSharded(spans, sp) {
+			res = append(res, part.span)
+		}
+	}
+	return res
+}
+
+// hashShardedPiece is a physical request sent for a logical request.
+type hashShardedPiece struct {
+	req kvpb.Request
+	hashShardedPart
+}
+
+// translateHashSharded returns the physical requests to send for the given
+// logical request. A range request is fanned out to all the shards of the
+// hash-sharded spans it overlaps with.
+func translateHashSharded(
+	spans kvserverbase.HashShardedSpans, req kvpb.Request,
+) ([]hashShardedPiece, error) {
+	h := req.Header()
+	switch t := req.(type) {
+	case *kvpb.EndTxnRequest:
+		et := t.ShallowCopy().(*kvpb.EndTxnRequest)
+		et.LockSpans = physicalSpans(spans, t.LockSpans)
+		et.InFlightWrites = make([]roachpb.SequencedWrite, len(t.InFlightWrites))
+		for i, w := range t.InFlightWrites {
+			if s, ok := spans.Find(w.Key); ok {
+				w.Key = s.PhysicalKey(w.Key)
+			}
+			et.InFlightWrites[i] = w
+		}
+		return []hashShardedPiece{{req: et}}, nil
+
+	case *kvpb.GetRequest, *kvpb.PutRequest, *kvpb.ConditionalPutRequest, *kvpb.InitPutRequest,
+		*kvpb.IncrementRequest, *kvpb.DeleteRequest, *kvpb.QueryIntentRequest,
+		*kvpb.ResolveIntentRequest, *kvpb.RefreshRequest:
+		s, ok := spans.Find(h.Key)
+		if !ok {
+			return []hashShardedPiece{{req: req}}, nil
+		}
+		r := req.ShallowCopy()
+		h.Key = s.PhysicalKey(h.Key)
+		r.SetHeader(h)
+		return []hashShardedPiece{{req: r}}, nil
+
+	case *kvpb.ScanRequest, *kvpb.ReverseScanRequest, *kvpb.DeleteRangeRequest,
+		*kvpb.RefreshRangeRequest, *kvpb.ResolveIntentRangeRequest:
+		if hashShardedScanFormat(req) == kvpb.COL_BATCH_RESPONSE {
+			return nil, errors.Newf("%s with the COL_BATCH_RESPONSE format is not supported "+
+				"in hash-sharded spans", req.Method())
+		}
+		if scan, ok := req.(*kvpb.ScanRequest); ok && scan.Filter != nil {
+			// The filter would be evaluated against the physical keys.
+			return nil, errors.Newf("filtered scans are not supported in hash-sharded spans")
+		}
+		parts := splitHashSharded(spans, h.Span())
+		pieces := make([]hashShardedPiece, len(parts))
+		for i, part := range parts {
+			r := req.ShallowCopy()
+			h.Key, h.EndKey = part.span.Key, part.span.EndKey
+			r.SetHeader(h)
+			pieces[i] = hashShardedPiece{req: r, hashShardedPart: part}
+		}
+		return pieces, nil
+
+	default:
+		// The other requests are either addressed to a key without reading or
+		// writing it, such as the requests addressed to the record of a
+		// transaction anchored at a logical key, or must cover entire
+		// hash-sharded spans, in which case they address all their physical keys
+		// as well.
+		if !kvpb.IsRange(req) {
+			return []hashShardedPiece{{req: req}}, nil
+		}
+		for _, s := range spans.Overlapping(h.Span()) {
+			if !h.Span().Contains(s.Span()) {
+				return nil, errors.Newf("%s partially overlapping with hash-sharded span %s "+
+					"is not supported", req.Method(), s.Prefix)
+			}
+		}
+		return []hashShardedPiece{{req: req}}, nil
+	}
+}
+
+func hashShardedScanFormat(req kvpb.Request) kvpb.ScanFormat {
+	switch t := req.(type) {
+	case *kvpb.ScanRequest:
+		return t.ScanFormat
+	case *kvpb.ReverseScanRequest:
+		return t.ScanFormat
+	default:
+		return kvpb.KEY_VALUES
+	}
+}
+
+// sendHashSharded sends a batch addressing hash-sharded spans. The batch is
+// translated to the physical keys of the spans, and the responses translated
+// back to their logical keys.
+func (ds *DistSender) sendHashSharded(
+	ctx context.Context, ba *kvpb.BatchRequest, spans kvserverbase.HashShardedSpans,
+) (*kvpb.BatchResponse, *kvpb.Error) {
+	ds.metrics.HashSharding.BatchCount.Inc(1)
+	pieces := make([][]hashShardedPiece, len(ba.Requests))
+	var numPieces int
+	for i, ru := range ba.Requests {
+		p, err := translateHashSharded(spans, ru.GetInner())
+		if err != nil {
+			return nil, kvpb.NewError(err)
+		}
+		if len(p) > 1 {
+			ds.metrics.HashSharding.FanOutCount.Inc(1)
+		}
+		pieces[i] = p
+		numPieces += len(p)
+	}
+	if ba.MaxSpanRequestKeys != 0 || ba.TargetBytes != 0 {
+		return ds.sendHashShardedWithLimits(ctx, ba, pieces)
+	}
+
+	// Without limits, all the pieces are sent in a single physical batch, and
+	// the complete responses to the pieces of each logical request merged.
+	phys := ba.ShallowCopy()
+	phys.HashShardedKeysTranslated = true
+	phys.Requests = make([]kvpb.RequestUnion, 0, numPieces)
+	logicalIdx := make([]int32, 0, numPieces)
+	for i, p := range pieces {
+		for _, piece := range p {
+			var ru kvpb.RequestUnion
+			ru.MustSetInner(piece.req)
+			phys.Requests = append(phys.Requests, ru)
+			logicalIdx = append(logicalIdx, int32(i))
+		}
+	}
+	br, pErr := ds.sendVerifiedBatch(ctx, phys)
+	if pErr != nil {
+		if pErr.Index != nil && pErr.Index.Index >= 0 && int(pErr.Index.Index) < len(logicalIdx) {
+			pErr.Index.Index = logicalIdx[pErr.Index.Index]
+		}
+		return nil, pErr
+	}
+	resps := br.Responses
+	br.Responses = make([]kvpb.ResponseUnion, len(ba.Requests))
+	for i, p := range pieces {
+		pieceResps := make([]kvpb.Response, len(p))
+		for j := range p {
+			pieceResps[j] = resps[0].GetInner()
+			resps = resps[1:]
+			if len(p) > 1 && pieceResps[j].Header().ResumeSpan != nil {
+				return nil, kvpb.NewError(errors.AssertionFailedf(
+					"unexpected resume span in response to %s", p[j].req))
+			}
+		}
+		resp, err := mergeHashSharded(ctx, ba, ba.Requests[i].GetInner(), p, pieceResps)
+		if err != nil {
+			return nil, kvpb.NewError(err)
+		}
+		br.Responses[i].MustSetInner(resp)
+	}
+	return br, nil
+}
+
+// sendHashShardedWithLimits sends a batch with limits addressing hash-sharded
+// spans. The logical requests are sent one after the other, each with the
+// remaining limits. The pieces of a logical scan fanned out to the shards of a
+// hash-sharded span are each sent with the limits of the scan: as a shard may
+// stop short of the others, the results of the scan are cut at the first key
+// not returned by a shard, from which the scan resumes. The pieces are sent
+// one after the other.
+func (ds *DistSender) sendHashShardedWithLimits(
+	ctx context.Context, ba *kvpb.BatchRequest, pieces [][]hashShardedPiece,
+) (*kvpb.BatchResponse, *kvpb.Error) {
+	for i, ru := range ba.Requests {
+		switch ru.GetInner().(type) {
+		case *kvpb.ScanRequest, *kvpb.ReverseScanRequest:
+		case *kvpb.EndTxnRequest:
+			return nil, kvpb.NewErrorf("batch with limit addressing hash-sharded spans "+
+				"contains %s request", ru.GetInner().Method())
+		default:
+			if len(pieces[i]) > 1 {
+				return nil, kvpb.NewErrorf("batch with limit addressing hash-sharded spans "+
+					"contains %s request", ru.GetInner().Method())
+			}
+		}
+	}
+
+	// The logical requests are sent in separate batches, which must all read at
+	// the same timestamp.
+	base := ba.ShallowCopy()
+	base.CanForwardReadTimestamp = false
+	base.HashShardedKeysTranslated = true
+	br := ba.CreateReply()
+	lim := hashShardedLimits{maxKeys: ba.MaxSpanRequestKeys, targetBytes: ba.TargetBytes}
+	send := func(req kvpb.Request) (kvpb.Response, *kvpb.Error) {
+		sub := base.ShallowCopy()
+		sub.Requests = make([]kvpb.RequestUnion, 1)
+		sub.Requests[0].MustSetInner(req)
+		sub.MaxSpanRequestKeys, sub.TargetBytes = lim.maxKeys, lim.targetBytes
+		subBR, pErr := ds.sendVerifiedBatch(ctx, sub)
+		if pErr != nil {
+			return nil, pErr
+		}
+		base.UpdateTxn(subBR.Txn)
+		collected := br.CollectedSpans
+		br.BatchResponse_Header = subBR.BatchResponse_Header
+		br.CollectedSpans = append(collected, subBR.CollectedSpans...)
+		return subBR.Responses[0].GetInner(), nil
+	}
+
+	var resumeReason kvpb.ResumeReason
+	for i, ru := range ba.Requests {
+		req := ru.GetInner()
+		if resumeReason != kvpb.RESUME_UNKNOWN {
+			// The limits are exhausted.
+			resp := br.Responses[i].GetInner()
+			h := resp.Header()
+			h.ResumeSpan = &roachpb.Span{Key: req.Header().Key, EndKey: req.Header().EndKey}
+			h.ResumeReason = resumeReason
+			resp.SetHeader(h)
+			continue
+		}
+
+		var resp kvpb.Response
+		var pErr *kvpb.Error
+		if len(pieces[i]) == 1 {
+			piece := pieces[i][0]
+			if resp, pErr = send(piece.req); pErr == nil {
+				if h := resp.Header(); h.ResumeSpan != nil && piece.req != req {
+					// The resume span of a translated request holds physical keys.
+					h.ResumeSpan = &roachpb.Span{Key: req.Header().Key, EndKey: req.Header().EndKey}
+					resp.SetHeader(h)
+				}
+			}
+		} else {
+			resp, pErr = sendHashShardedScanWithLimits(ba, req, pieces[i], send, &lim)
+		}
+		if pErr != nil {
+			pErr.SetErrorIndex(int32(i))
+			return nil, pErr
+		}
+		br.Responses[i].MustSetInner(resp)
+
+		h := resp.Header()
+		if len(pieces[i]) == 1 {
+			// The limits are deducted piece by piece for the fanned out scans.
+			lim.consume(ba, h.NumKeys, h.NumBytes)
+		}
+		if h.ResumeSpan != nil {
+			resumeReason = h.ResumeReason
+		} else {
+			resumeReason = lim.exhausted(ba)
+		}
+	}
+	return br, nil
+}
+
+// hashShardedLimits are the limits remaining to a batch.
+type hashShardedLimits struct {
+	maxKeys, targetBytes int64
+}
+
+// consume deducts the given keys and bytes from the limits.
+func (l *hashShardedLimits) consume(ba *kvpb.BatchRequest, numKeys, numBytes int64) {
+	if ba.MaxSpanRequestKeys > 0 {
+		l.maxKeys -= numKeys
+	}
+	if ba.TargetBytes > 0 {
+		l.targetBytes -= numBytes
+	}
+}
+
+// exhausted returns the reason to stop if the limits are exhausted.
+func (l *hashShardedLimits) exhausted(ba *kvpb.BatchRequest) kvpb.ResumeReason {
+	if ba.MaxSpanRequestKeys > 0 && l.maxKeys <= 0 {
+		return kvpb.RESUME_KEY_LIMIT
+	}
+	if ba.TargetBytes > 0 && l.targetBytes <= 0 {
+		return kvpb.RESUME_BYTE_LIMIT
+	}
+	return kvpb.RESUME_UNKNOWN
+}
+
+// hashShardedEntry is an entry returned by a logical scan.
+type hashShardedEntry struct {
+	// key is the logical key of the entry.
+	key roachpb.Key
+	// kv is the entry, in the KEY_VALUES format.
+	kv roachpb.KeyValue
+	// raw is the entry, in the BATCH_RESPONSE format.
+	raw []byte
+}
+
+func (e *hashShardedEntry) size() int64 {
+	if e.raw != nil {
+		return int64(len(e.raw))
+	}
+	return int64(len(e.kv.Key) + len(e.kv.Value.RawBytes))
+}
+
+// sendHashShardedScanWithLimits sends the pieces of a logical scan, segment by
+// segment in the order of the scan, until the limits are exhausted.
+func sendHashShardedScanWithLimits(
+	ba *kvpb.BatchRequest,
+	req kvpb.Request,
+	pieces []hashShardedPiece,
+	send func(kvpb.Request) (kvpb.Response, *kvpb.Error),
+	lim *hashShardedLimits,
+) (kvpb.Response, *kvpb.Error) {
+	isReverse := req.Method() == kvpb.ReverseScan
+	segments := hashShardedSegments(pieces, isReverse)
+	var entries, intents []hashShardedEntry
+	var resume roachpb.Key
+	var resumeReason kvpb.ResumeReason
+	for s, seg := range segments {
+		var segEntries []hashShardedEntry
+		// cutoff is the first logical key, in the order of the scan, not
+		// returned by a piece stopped short by the limits. For reverse scans,
+		// it is the exclusive end of the keys not returned.
+		var cutoff roachpb.Key
+		for _, idx := range seg {
+			piece := &pieces[idx]
+			resp, pErr := send(piece.req)
+			if pErr != nil {
+				return nil, pErr
+			}
+			pieceEntries, pieceIntents, err := hashShardedScanEntries(resp, piece)
+			if err != nil {
+				return nil, kvpb.NewError(err)
+			}
+			segEntries = append(segEntries, pieceEntries...)
+			intents = append(intents, pieceIntents...)
+			if rs := resp.Header().ResumeSpan; rs != nil {
+				next := rs.Key
+				if isReverse {
+					next = rs.EndKey
+				}
+				if next, err = piece.logicalKey(next); err != nil {
+					return nil, kvpb.NewError(err)
+				}
+				if cutoff == nil || hashShardedBefore(next, cutoff, isReverse) {
+					cutoff = next
+					resumeReason = resp.Header().ResumeReason
+				}
+			}
+		}
+		sortHashShardedEntries(segEntries, isReverse)
+		if cutoff != nil {
+			segEntries = segEntries[:sort.Search(len(segEntries), func(i int) bool {
+				if isReverse {
+					return segEntries[i].key.Compare(cutoff) < 0
+				}
+				return segEntries[i].key.Compare(cutoff) >= 0
+			})]
+		}
+
+		// Cap the entries to the limits.
+		n := 0
+		var size int64
+		for ; n < len(segEntries); n++ {
+			if ba.MaxSpanRequestKeys > 0 && int64(n) >= lim.maxKeys {
+				resumeReason = kvpb.RESUME_KEY_LIMIT
+				break
+			}
+			if ba.TargetBytes > 0 && (size >= lim.targetBytes ||
+				(ba.AllowEmpty && size+segEntries[n].size() > lim.targetBytes)) {
+				resumeReason = kvpb.RESUME_BYTE_LIMIT
+				break
+			}
+			size += segEntries[n].size()
+		}
+		if n < len(segEntries) {
+			n = wholeHashShardedRows(ba, segEntries, n)
+			size = 0
+			for _, e := range segEntries[:n] {
+				size += e.size()
+			}
+			cutoff = segEntries[n].key
+			if isReverse {
+				cutoff = cutoff.Next()
+			}
+		}
+		entries = append(entries, segEntries[:n]...)
+		lim.consume(ba, int64(n), size)
+		exhausted := lim.exhausted(ba)
+
+		if cutoff != nil {
+			resume = cutoff
+			break
+		}
+		if exhausted != kvpb.RESUME_UNKNOWN && s < len(segments)-1 {
+			// The next segments are left to resume from.
+			resume = pieces[segments[s+1][0]].logical.Key
+			if isReverse {
+				resume = pieces[segments[s+1][0]].logical.EndKey
+			}
+			resumeReason = exhausted
+			break
+		}
+	}
+
+	resp := makeHashShardedScanResponse(req, entries, intents)
+	if resume != nil {
+		rs := req.Header().Span()
+		if isReverse {
+			rs.EndKey = resume
+		} else {
+			rs.Key = resume
+		}
+		h := resp.Header()
+		h.ResumeSpan = &rs
+		h.ResumeReason = resumeReason
+		resp.SetHeader(h)
+	}
+	return resp, nil
+}
+
+// wholeHashShardedRows moves the cut of the entries before the n-th one back
+// to the start of its row, if the batch only accepts whole rows. The cut is
+// left in the middle of the row if it's the first one.
+func wholeHashShardedRows(ba *kvpb.BatchRequest, entries []hashShardedEntry, n int) int {
+	if ba.WholeRowsOfSize <= 1 || n == 0 {
+		return n
+	}
+	rowPrefix := func(key roachpb.Key) roachpb.Key {
+		if l, err := keys.GetRowPrefixLength(key); err == nil {
+			return key[:l]
+		}
+		return key
+	}
+	row := rowPrefix(entries[n].key)
+	m := n
+	for m > 0 && rowPrefix(entries[m-1].key).Equal(row) {
+		m--
+	}
+	if m == 0 {
+		return n
+	}
+	return m
+}
+
+// hashShardedSegments groups the indexes of the pieces of a logical request by
+// segment, in the order of the scan.
+func hashShardedSegments(pieces []hashShardedPiece, isReverse bool) [][]int {
+	var segments [][]int
+	for i, piece := range pieces {
+		if i == 0 || piece.segment != pieces[i-1].segment {
+			segments = append(segments, nil)
+		}
+		segments[len(segments)-1] = append(segments[len(segments)-1], i)
+	}
+	if isReverse {
+		for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+			segments[i], segments[j] = segments[j], segments[i]
+		}
+	}
+	return segments
+}
+
+// hashShardedBefore returns whether a comes before b in the order of the scan.
+func hashShardedBefore(a, b roachpb.Key, isReverse bool) bool {
+	if isReverse {
+		return a.Compare(b) > 0
+	}
+	return a.Compare(b) < 0
+}
+
+func sortHashShardedEntries(entries []hashShardedEntry, isReverse bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return hashShardedBefore(entries[i].key, entries[j].key, isReverse)
+	})
+}
+
+// mergeHashSharded returns the response to a logical request from the
+// complete responses to its physical pieces.
+func mergeHashSharded(
+	ctx context.Context,
+	ba *kvpb.BatchRequest,
+	req kvpb.Request,
+	pieces []hashShardedPiece,
+	resps []kvpb.Response,
+) (kvpb.Response, error) {
+	if len(pieces) == 1 {
+		// Point requests don't return keys.
+		return resps[0], nil
+	}
+	switch req.(type) {
+	case *kvpb.ScanRequest, *kvpb.ReverseScanRequest:
+		isReverse := req.Method() == kvpb.ReverseScan
+		var entries, intents []hashShardedEntry
+		for _, seg := range hashShardedSegments(pieces, isReverse) {
+			var segEntries []hashShardedEntry
+			for _, idx := range seg {
+				pieceEntries, pieceIntents, err := hashShardedScanEntries(resps[idx], &pieces[idx])
+				if err != nil {
+					return nil, err
+				}
+				segEntries = append(segEntries, pieceEntries...)
+				intents = append(intents, pieceIntents...)
+			}
+			sortHashShardedEntries(segEntries, isReverse)
+			entries = append(entries, segEntries...)
+		}
+		return makeHashShardedScanResponse(req, entries, intents), nil
+
+	case *kvpb.DeleteRangeRequest:
+		resp := &kvpb.DeleteRangeResponse{}
+		var deleted []hashShardedEntry
+		for i := range pieces {
+			dr := resps[i].(*kvpb.DeleteRangeResponse)
+			for _, k := range dr.Keys {
+				logical, err := pieces[i].logicalKey(k)
+				if err != nil {
+					return nil, err
+				}
+				deleted = append(deleted, hashShardedEntry{key: logical})
+			}
+			resp.NumKeys += dr.NumKeys
+			resp.NumBytes += dr.NumBytes
+		}
+		sortHashShardedEntries(deleted, false /* isReverse */)
+		for _, e := range deleted {
+			resp.Keys = append(resp.Keys, e.key)
+		}
+		return resp, nil
+
+	default:
+		resp := resps[0]
+		for _, other := range resps[1:] {
+			if err := kvpb.CombineResponses(ctx, resp, other, ba); err != nil {
+				return nil, err
+			}
+		}
+		return resp, nil
+	}
+}
+
+// hashShardedScanEntries returns the entries and intents returned by a piece
+// of a logical scan, with their logical keys.
+func hashShardedScanEntries(
+	resp kvpb.Response, piece *hashShardedPiece,
+) (entries, intents []hashShardedEntry, _ error) {
+	var rows, intentRows []roachpb.KeyValue
+	var batchResponses [][]byte
+	switch t := resp.(type) {
+	case *kvpb.ScanResponse:
+		rows, intentRows, batchResponses = t.Rows, t.IntentRows, t.BatchResponses
+	case *kvpb.ReverseScanResponse:
+		rows, intentRows, batchResponses = t.Rows, t.IntentRows, t.BatchResponses
+	default:
+		return nil, nil, errors.AssertionFailedf("unexpected response %T", resp)
+	}
+	translate := func(kvs []roachpb.KeyValue) ([]hashShardedEntry, error) {
+		res := make([]hashShardedEntry, len(kvs))
+		for i, kv := range kvs {
+			key, err := piece.logicalKey(kv.Key)
+			if err != nil {
+				return nil, err
+			}
+			kv.Key = key
+			res[i] = hashShardedEntry{key: key, kv: kv}
+		}
+		return res, nil
+	}
+	var err error
+	if entries, err = translate(rows); err != nil {
+		return nil, nil, err
+	}
+	if intents, err = translate(intentRows); err != nil {
+		return nil, nil, err
+	}
+	// The entries in the BATCH_RESPONSE format are length-prefixed encoded MVCC
+	// keys followed by their value, see enginepb.ScanDecodeKeyValue.
+	for _, data := range batchResponses {
+		for len(data) > 0 {
+			if len(data) < 8 {
+				return nil, nil, errors.Errorf("unexpected batch EOF")
+			}
+			valSize := binary.LittleEndian.Uint32(data)
+			keyEnd := binary.LittleEndian.Uint32(data[4:8]) + 8
+			if int(keyEnd+valSize) > len(data) {
+				return nil, nil, errors.Errorf("expected %d bytes, but only %d remaining",
+					keyEnd+valSize, len(data))
+			}
+			rawKey, value := data[8:keyEnd], data[keyEnd:keyEnd+valSize]
+			data = data[keyEnd+valSize:]
+			key, _, ok := enginepb.SplitMVCCKey(rawKey)
+			if !ok {
+				return nil, nil, errors.Errorf("invalid encoded mvcc key: %x", rawKey)
+			}
+			logical, err := piece.logicalKey(key)
+			if err != nil {
+				return nil, nil, err
+			}
+			// Swap the physical key for the logical one, keeping the encoded
+			// timestamp suffix.
+			suffix := rawKey[len(key):]
+			raw := make([]byte, 8, 8+len(logical)+len(suffix)+len(value))
+			binary.LittleEndian.PutUint32(raw, valSize)
+			binary.LittleEndian.PutUint32(raw[4:], uint32(len(logical)+len(suffix)))
+			raw = append(append(append(raw, logical...), suffix...), value...)
+			entries = append(entries, hashShardedEntry{key: logical, raw: raw})
+		}
+	}
+	return entries, intents, nil
+}
+
+// makeHashShardedScanResponse returns the response to a logical scan holding
+// the given entries and intents, in the format of the scan.
+func makeHashShardedScanResponse(
+	req kvpb.Request, entries, intents []hashShardedEntry,
+) kvpb.Response {
+	var rows, intentRows []roachpb.KeyValue
+	var batchResponses [][]byte
+	var numBytes int64
+	if hashShardedScanFormat(req) == kvpb.BATCH_RESPONSE {
+		var data []byte
+		for _, e := range entries {
+			data = append(data, e.raw...)
+			numBytes += e.size()
+		}
+		if data != nil {
+			batchResponses = [][]byte{data}
+		}
+	} else {
+		rows = make([]roachpb.KeyValue, 0, len(entries))
+		for _, e := range entries {
+			rows = append(rows, e.kv)
+			numBytes += e.size()
+		}
+	}
+	for _, e := range intents {
+		intentRows = append(intentRows, e.kv)
+	}
+	h := kvpb.ResponseHeader{NumKeys: int64(len(entries)), NumBytes: numBytes}
+	if req.Method() == kvpb.ReverseScan {
+		return &kvpb.ReverseScanResponse{
+			ResponseHeader: h, Rows: rows, IntentRows: intentRows, BatchResponses: batchResponses,
+		}
+	}
+	return &kvpb.ScanResponse{
+		ResponseHeader: h, Rows: rows, IntentRows: intentRows, BatchResponses: batchResponses,
+	}
+}
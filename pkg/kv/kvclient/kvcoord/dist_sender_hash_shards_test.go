@@ -0,0 +1,296 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// hashShardedTestEngine evaluates the physical requests sent for the logical
+// requests addressing hash-sharded spans.
+type hashShardedTestEngine struct {
+	kvs []roachpb.KeyValue
+}
+
+func (e *hashShardedTestEngine) eval(req kvpb.Request, maxKeys int64) kvpb.Response {
+	h := req.Header()
+	switch t := req.(type) {
+	case *kvpb.PutRequest:
+		i := sort.Search(len(e.kvs), func(i int) bool { return e.kvs[i].Key.Compare(h.Key) >= 0 })
+		e.kvs = append(e.kvs, roachpb.KeyValue{})
+		copy(e.kvs[i+1:], e.kvs[i:])
+		e.kvs[i] = roachpb.KeyValue{Key: h.Key, Value: t.Value}
+		return &kvpb.PutResponse{}
+	case *kvpb.ScanRequest:
+		resp := &kvpb.ScanResponse{}
+		for _, kv := range e.kvs {
+			if !h.Span().ContainsKey(kv.Key) {
+				continue
+			}
+			if maxKeys > 0 && int64(len(resp.Rows)) == maxKeys {
+				resp.ResumeSpan = &roachpb.Span{Key: kv.Key, EndKey: h.EndKey}
+				resp.ResumeReason = kvpb.RESUME_KEY_LIMIT
+				break
+			}
+			resp.Rows = append(resp.Rows, kv)
+		}
+		resp.NumKeys = int64(len(resp.Rows))
+		return resp
+	case *kvpb.ReverseScanRequest:
+		resp := &kvpb.ReverseScanResponse{}
+		for i := len(e.kvs) - 1; i >= 0; i-- {
+			kv := e.kvs[i]
+			if !h.Span().ContainsKey(kv.Key) {
+				continue
+			}
+			if maxKeys > 0 && int64(len(resp.Rows)) == maxKeys {
+				resp.ResumeSpan = &roachpb.Span{Key: h.Key, EndKey: kv.Key.Next()}
+				resp.ResumeReason = kvpb.RESUME_KEY_LIMIT
+				break
+			}
+			resp.Rows = append(resp.Rows, kv)
+		}
+		resp.NumKeys = int64(len(resp.Rows))
+		return resp
+	default:
+		panic(fmt.Sprintf("unexpected request %s", req))
+	}
+}
+
+// hashShardedTestSource is a span config source holding the given span
+// configs, which must be ordered and non-overlapping.
+type hashShardedTestSource struct {
+	entries []hashShardedTestEntry
+	handler func(ctx context.Context, updated roachpb.Span)
+}
+
+type hashShardedTestEntry struct {
+	span roachpb.Span
+	conf roachpb.SpanConfig
+}
+
+func (s *hashShardedTestSource) Subscribe(fn func(ctx context.Context, updated roachpb.Span)) {
+	s.handler = fn
+	fn(context.Background(), keys.EverythingSpan)
+}
+
+func (s *hashShardedTestSource) ComputeBoundaries(
+	_ context.Context, start, end roachpb.RKey, limit int,
+) ([]roachpb.RKey, error) {
+	var res []roachpb.RKey
+	add := func(k roachpb.Key) {
+		rk := roachpb.RKey(k)
+		if start.Less(rk) && rk.Less(end) && len(res) < limit &&
+			(len(res) == 0 || !res[len(res)-1].Equal(rk)) {
+			res = append(res, rk)
+		}
+	}
+	for _, e := range s.entries {
+		add(e.span.Key)
+		add(e.span.EndKey)
+	}
+	return res, nil
+}
+
+func (s *hashShardedTestSource) GetSpanConfigForKey(
+	_ context.Context, key roachpb.RKey,
+) (roachpb.SpanConfig, error) {
+	for _, e := range s.entries {
+		if e.span.ContainsKey(key.AsRawKey()) {
+			return e.conf, nil
+		}
+	}
+	return roachpb.SpanConfig{}, nil
+}
+
+func TestHashShardedSpansWatcher(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	prefix := keys.SystemSQLCodec.IndexPrefix(104, 1)
+	other := keys.SystemSQLCodec.IndexPrefix(104, 2)
+	src := &hashShardedTestSource{entries: []hashShardedTestEntry{
+		{span: roachpb.Span{Key: prefix, EndKey: prefix.PrefixEnd()},
+			conf: roachpb.SpanConfig{HashShards: 4}},
+		// Only the spans of a prefix are hash-sharded.
+		{span: roachpb.Span{Key: other, EndKey: keys.SystemSQLCodec.TablePrefix(105)},
+			conf: roachpb.SpanConfig{HashShards: 4}},
+	}}
+	w := kvserverbase.NewHashShardedSpansWatcher()
+	hs := newHashSharder(w)
+	put := &kvpb.PutRequest{RequestHeader: kvpb.RequestHeader{Key: append(prefix, 'a')}}
+	ba := &kvpb.BatchRequest{}
+	ba.Add(put)
+	require.Empty(t, hs.spansFor(ba))
+
+	w.Start(src)
+	spans := w.Get()
+	require.Equal(t, kvserverbase.HashShardedSpans{{Prefix: prefix, NumShards: 4}}, spans)
+	require.Len(t, hs.spansFor(ba), 1)
+
+	// The keys of a batch already translated to physical keys aren't translated
+	// again.
+	translated := ba.ShallowCopy()
+	translated.HashShardedKeysTranslated = true
+	require.Empty(t, hs.spansFor(translated))
+
+	// The ranges are split at the bounds of the span and its shards.
+	var splits []roachpb.RKey
+	start, end := roachpb.RKey(keys.SystemSQLCodec.TablePrefix(104)), roachpb.RKeyMax
+	for {
+		splitKey := spans.SplitKey(start, end)
+		if splitKey == nil {
+			break
+		}
+		splits = append(splits, splitKey)
+		start = splitKey
+	}
+	s := spans[0]
+	require.Equal(t, []roachpb.RKey{
+		roachpb.RKey(prefix), roachpb.RKey(s.ShardPrefix(1)), roachpb.RKey(s.ShardPrefix(2)),
+		roachpb.RKey(s.ShardPrefix(3)), roachpb.RKey(s.ShardPrefix(4)), roachpb.RKey(prefix.PrefixEnd()),
+	}, splits)
+
+	// An update of the span configs within the hash-sharded span recomputes it
+	// as a whole.
+	src.entries[0].span.EndKey = append(prefix[:len(prefix):len(prefix)], 'b')
+	src.handler(ctx, roachpb.Span{Key: append(prefix[:len(prefix):len(prefix)], 'a'), EndKey: prefix.PrefixEnd()})
+	require.Empty(t, w.Get())
+	require.Empty(t, hs.spansFor(ba))
+}
+
+func TestHashShardedTranslation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	prefix := keys.SystemSQLCodec.IndexPrefix(104, 1)
+	const numShards = 4
+	spans := kvserverbase.HashShardedSpans{{Prefix: prefix, NumShards: numShards}}
+	key := func(i int) roachpb.Key {
+		return encoding.EncodeUvarintAscending(prefix[:len(prefix):len(prefix)], uint64(i))
+	}
+
+	// Write sequential keys, along with a key after the hash-sharded span.
+	var e hashShardedTestEngine
+	var expected []roachpb.Key
+	for i := 0; i < 100; i++ {
+		expected = append(expected, key(i))
+	}
+	expected = append(expected, keys.SystemSQLCodec.IndexPrefix(104, 2))
+	for _, k := range expected {
+		put := &kvpb.PutRequest{RequestHeader: kvpb.RequestHeader{Key: k}, Value: roachpb.MakeValueFromString("v")}
+		pieces, err := translateHashSharded(spans, put)
+		require.NoError(t, err)
+		require.Len(t, pieces, 1)
+		e.eval(pieces[0].req, 0)
+	}
+	// The sequential keys are spread over the shards.
+	shards := map[uint64]int{}
+	for _, kv := range e.kvs[:100] {
+		rest, shard, err := encoding.DecodeUvarintAscending(kv.Key[len(prefix):])
+		require.NoError(t, err)
+		require.Equal(t, []byte(kv.Key[len(kv.Key)-len(rest):]), rest)
+		shards[shard]++
+	}
+	require.Len(t, shards, numShards)
+
+	span := roachpb.Span{Key: key(10), EndKey: keys.SystemSQLCodec.TablePrefix(105)}
+	expected = expected[10:]
+	scan := func(span roachpb.Span, isReverse bool) kvpb.Request {
+		if isReverse {
+			return &kvpb.ReverseScanRequest{RequestHeader: kvpb.RequestHeaderFromSpan(span)}
+		}
+		return &kvpb.ScanRequest{RequestHeader: kvpb.RequestHeaderFromSpan(span)}
+	}
+	rows := func(resp kvpb.Response) []roachpb.KeyValue {
+		if sr, ok := resp.(*kvpb.ScanResponse); ok {
+			return sr.Rows
+		}
+		return resp.(*kvpb.ReverseScanResponse).Rows
+	}
+	expectedInOrder := func(isReverse bool) []roachpb.Key {
+		res := append([]roachpb.Key(nil), expected...)
+		if isReverse {
+			for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+				res[i], res[j] = res[j], res[i]
+			}
+		}
+		return res
+	}
+
+	testutils.RunTrueAndFalse(t, "reverse", func(t *testing.T, isReverse bool) {
+		// Without limits, the scan is fanned out to all the shards and the
+		// results merged in order.
+		req := scan(span, isReverse)
+		pieces, err := translateHashSharded(spans, req)
+		require.NoError(t, err)
+		require.Len(t, pieces, numShards+1)
+		resps := make([]kvpb.Response, len(pieces))
+		for i, piece := range pieces {
+			resps[i] = e.eval(piece.req, 0)
+		}
+		resp, err := mergeHashSharded(ctx, &kvpb.BatchRequest{}, req, pieces, resps)
+		require.NoError(t, err)
+		var got []roachpb.Key
+		for _, kv := range rows(resp) {
+			got = append(got, kv.Key)
+		}
+		require.Equal(t, expectedInOrder(isReverse), got)
+
+		// With limits, the scan is resumed until it completes.
+		for _, limit := range []int64{1, 7, 50, 1000} {
+			t.Run(fmt.Sprintf("limit=%d", limit), func(t *testing.T) {
+				ba := &kvpb.BatchRequest{}
+				ba.MaxSpanRequestKeys = limit
+				var lim hashShardedLimits
+				send := func(req kvpb.Request) (kvpb.Response, *kvpb.Error) {
+					return e.eval(req, lim.maxKeys), nil
+				}
+				var got []roachpb.Key
+				remaining := span
+				for {
+					req := scan(remaining, isReverse)
+					pieces, err := translateHashSharded(spans, req)
+					require.NoError(t, err)
+					lim = hashShardedLimits{maxKeys: limit}
+					resp, pErr := sendHashShardedScanWithLimits(ba, req, pieces, send, &lim)
+					require.Nil(t, pErr)
+					require.LessOrEqual(t, int64(len(rows(resp))), limit)
+					require.Equal(t, int64(len(rows(resp))), resp.Header().NumKeys)
+					for _, kv := range rows(resp) {
+						got = append(got, kv.Key)
+					}
+					rs := resp.Header().ResumeSpan
+					if rs == nil {
+						break
+					}
+					remaining = *rs
+				}
+				require.Equal(t, expectedInOrder(isReverse), got)
+			})
+		}
+	})
+}
@@ -0,0 +1,341 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"bytes"
+	"context"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+)
+
+// keySerializationTables is the hint naming the tables whose contended keys
+// are serialized on the gateway.
+var keySerializationTables = settings.RegisterStringSetting(
+	settings.ApplicationLevel,
+	"kv.dist_sender.key_serialization.tables",
+	"comma-separated IDs of the tables whose single-key locking batches are sent one "+
+		"key at a time by each gateway, to reduce latch contention on the leaseholder",
+	"",
+	settings.WithValidateString(func(_ *settings.Values, s string) error {
+		_, err := parseKeySerializationTables(s)
+		return err
+	}),
+)
+
+// keySerializationMaxWait bounds the time a batch waits for the batches ahead
+// of it on its key. The serialization is best-effort: it must not deadlock
+// with a transaction whose intent on the key is waited on by the batch holding
+// the key, so a batch which waited for too long is sent regardless.
+var keySerializationMaxWait = settings.RegisterDurationSetting(
+	settings.ApplicationLevel,
+	"kv.dist_sender.key_serialization.max_wait",
+	"maximum time a batch waits for the batches ahead of it on its key, when "+
+		"its table is listed in kv.dist_sender.key_serialization.tables",
+	50*time.Millisecond,
+	settings.NonNegativeDuration,
+)
+
+// numKeySerializerShards is the number of shards the keys are hashed into.
+// Each shard has its own mutex, so that the serialization of unrelated keys
+// doesn't contend.
+const numKeySerializerShards = 64
+
+// maxKeySerializerHoldersPerShard bounds the number of keys whose lock holder
+// is remembered by each shard.
+const maxKeySerializerHoldersPerShard = 1024
+
+var (
+	metaKeySerializationSerialized = metric.Metadata{
+		Name:        "distsender.key_serialization.batches",
+		Help:        "Number of batches serialized on their key by the gateway",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKeySerializationWaited = metric.Metadata{
+		Name:        "distsender.key_serialization.waited",
+		Help:        "Number of serialized batches which waited for a batch ahead of them on their key",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKeySerializationWaitTimeouts = metric.Metadata{
+		Name:        "distsender.key_serialization.wait_timeouts",
+		Help:        "Number of serialized batches sent after waiting for kv.dist_sender.key_serialization.max_wait",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKeySerializationQueued = metric.Metadata{
+		Name:        "distsender.key_serialization.queued",
+		Help:        "Number of batches waiting for a batch ahead of them on their key",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// KeySerializationMetrics are the metrics of the gateway-local serialization
+// of batches on contended keys.
+type KeySerializationMetrics struct {
+	SerializedCount   *metric.Counter
+	WaitedCount       *metric.Counter
+	WaitTimeoutsCount *metric.Counter
+	Queued            *metric.Gauge
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (KeySerializationMetrics) MetricStruct() {}
+
+func makeKeySerializationMetrics() KeySerializationMetrics {
+	return KeySerializationMetrics{
+		SerializedCount:   metric.NewCounter(metaKeySerializationSerialized),
+		WaitedCount:       metric.NewCounter(metaKeySerializationWaited),
+		WaitTimeoutsCount: metric.NewCounter(metaKeySerializationWaitTimeouts),
+		Queued:            metric.NewGauge(metaKeySerializationQueued),
+	}
+}
+
+// parseKeySerializationTables parses the value of
+// kv.dist_sender.key_serialization.tables.
+func parseKeySerializationTables(s string) (map[uint32]struct{}, error) {
+	tables := map[uint32]struct{}{}
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(f, 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid table ID %q", f)
+		}
+		tables[uint32(id)] = struct{}{}
+	}
+	return tables, nil
+}
+
+// keySerializer serializes, on the gateway, the single-key locking batches
+// addressed to the keys of the tables hinted with
+// kv.dist_sender.key_serialization.tables: such batches on the same key are
+// sent one at a time, in order, as if by a single worker per key. Contended
+// keys then see a single request at a time from each gateway, instead of a
+// thundering herd of requests all thrashing the latches of the leaseholder.
+//
+// A transaction which already holds the lock on a key is not serialized: its
+// batches could otherwise queue behind a batch of another transaction which is
+// itself waiting on the leaseholder for the lock to be released, stalling both
+// until kv.dist_sender.key_serialization.max_wait. The gateway doesn't see the
+// locks, so it approximates their holder with the last transaction whose
+// locking batch on the key succeeded.
+//
+// Keys are hashed into shards, each holding the queues of its keys in flight
+// and the lock holders of its recently locked keys.
+type keySerializer struct {
+	st      *cluster.Settings
+	metrics *KeySerializationMetrics
+	tables  atomic.Pointer[map[uint32]struct{}]
+	shards  [numKeySerializerShards]keySerializerShard
+}
+
+type keySerializerShard struct {
+	syncutil.Mutex
+	// queues holds, for each key with a batch in flight, the channels of the
+	// batches waiting for it, in order.
+	queues map[string][]chan struct{}
+	// holders holds, for the keys recently locked, the ID of the transaction
+	// presumed to hold the lock. It is bounded by
+	// maxKeySerializerHoldersPerShard, evicting arbitrary keys: it only serves
+	// to let the holders skip the queues, so forgetting a holder merely delays
+	// its batches up to kv.dist_sender.key_serialization.max_wait.
+	holders map[string]uuid.UUID
+}
+
+func newKeySerializer(st *cluster.Settings, metrics *KeySerializationMetrics) *keySerializer {
+	ks := &keySerializer{st: st, metrics: metrics}
+	for i := range ks.shards {
+		ks.shards[i].queues = map[string][]chan struct{}{}
+		ks.shards[i].holders = map[string]uuid.UUID{}
+	}
+	update := func(ctx context.Context) {
+		tables, err := parseKeySerializationTables(keySerializationTables.Get(&st.SV))
+		if err != nil {
+			// The setting is validated.
+			log.Warningf(ctx, "%v", err)
+			return
+		}
+		ks.tables.Store(&tables)
+	}
+	keySerializationTables.SetOnChange(&st.SV, update)
+	update(context.Background())
+	return ks
+}
+
+// serializationKey returns the key the batch is serialized on, if any. Only
+// batches whose locking requests all address the same key of a hinted table
+// are serialized.
+func (ks *keySerializer) serializationKey(ba *kvpb.BatchRequest) (roachpb.Key, bool) {
+	tables := *ks.tables.Load()
+	if len(tables) == 0 || !ba.IsLocking() {
+		return nil, false
+	}
+	var key roachpb.Key
+	for _, ru := range ba.Requests {
+		req := ru.GetInner()
+		if !kvpb.IsLocking(req) {
+			continue
+		}
+		if kvpb.IsRange(req) {
+			return nil, false
+		}
+		if key == nil {
+			key = req.Header().Key
+		} else if !key.Equal(req.Header().Key) {
+			return nil, false
+		}
+	}
+	if key == nil {
+		return nil, false
+	}
+	rem, _, err := keys.DecodeTenantPrefix(key)
+	if err != nil || bytes.Compare(rem, keys.TableDataMin) < 0 {
+		return nil, false
+	}
+	_, tableID, err := encoding.DecodeUvarintAscending(rem)
+	if err != nil {
+		return nil, false
+	}
+	if _, ok := tables[uint32(tableID)]; !ok {
+		return nil, false
+	}
+	return key, true
+}
+
+// acquire waits until the batch is at the head of the queue of its key, if it
+// is serialized and its transaction doesn't hold the lock on the key. The
+// returned function, if not nil, must be called with the outcome of the batch
+// once it is done.
+func (ks *keySerializer) acquire(
+	ctx context.Context, ba *kvpb.BatchRequest,
+) (release func(*kvpb.Error)) {
+	key, ok := ks.serializationKey(ba)
+	if !ok {
+		return nil
+	}
+	var txnID uuid.UUID
+	if ba.Txn != nil {
+		txnID = ba.Txn.ID
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(key)
+	shard := &ks.shards[h.Sum32()%numKeySerializerShards]
+	k := string(key)
+
+	shard.Lock()
+	if txnID != (uuid.UUID{}) && shard.holders[k] == txnID {
+		shard.Unlock()
+		return nil
+	}
+	ks.metrics.SerializedCount.Inc(1)
+	queue, inFlight := shard.queues[k]
+	var ch chan struct{}
+	if inFlight {
+		ch = make(chan struct{})
+		shard.queues[k] = append(queue, ch)
+	} else {
+		shard.queues[k] = nil
+	}
+	shard.Unlock()
+	release = func(pErr *kvpb.Error) { ks.release(shard, k, txnID, pErr, true /* handOver */) }
+	if ch == nil {
+		return release
+	}
+
+	ks.metrics.WaitedCount.Inc(1)
+	ks.metrics.Queued.Inc(1)
+	defer ks.metrics.Queued.Dec(1)
+	var timer timeutil.Timer
+	defer timer.Stop()
+	timer.Reset(keySerializationMaxWait.Get(&ks.st.SV))
+	select {
+	case <-ch:
+		return release
+	case <-timer.C:
+		timer.Read = true
+		ks.metrics.WaitTimeoutsCount.Inc(1)
+		log.VEventf(ctx, 2, "sending batch after waiting for the batches ahead of it on %s", key)
+	case <-ctx.Done():
+	}
+	// Leave the queue, unless the batch was handed the key concurrently.
+	shard.Lock()
+	defer shard.Unlock()
+	select {
+	case <-ch:
+		return release
+	default:
+	}
+	queue = shard.queues[k]
+	for i := range queue {
+		if queue[i] == ch {
+			shard.queues[k] = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	// The batch is sent without holding the key, but may still take the lock.
+	return func(pErr *kvpb.Error) { ks.release(shard, k, txnID, pErr, false /* handOver */) }
+}
+
+// release records the outcome of a batch on the key and, if the batch held the
+// key, hands it over to the next batch waiting for it, if any.
+func (ks *keySerializer) release(
+	shard *keySerializerShard, k string, txnID uuid.UUID, pErr *kvpb.Error, handOver bool,
+) {
+	shard.Lock()
+	defer shard.Unlock()
+	if pErr == nil {
+		// The transaction of the batch now holds the lock on the key, or no one
+		// does if the batch was not transactional.
+		if txnID == (uuid.UUID{}) {
+			delete(shard.holders, k)
+		} else {
+			if _, ok := shard.holders[k]; !ok && len(shard.holders) >= maxKeySerializerHoldersPerShard {
+				for evict := range shard.holders {
+					delete(shard.holders, evict)
+					break
+				}
+			}
+			shard.holders[k] = txnID
+		}
+	}
+	if !handOver {
+		return
+	}
+	queue := shard.queues[k]
+	if len(queue) == 0 {
+		delete(shard.queues, k)
+		return
+	}
+	next := queue[0]
+	shard.queues[k] = queue[1:]
+	close(next)
+}
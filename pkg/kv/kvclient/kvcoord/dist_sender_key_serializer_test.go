@@ -0,0 +1,218 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/isolation"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySerializerSerializationKey(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	metrics := makeKeySerializationMetrics()
+	ks := newKeySerializer(st, &metrics)
+
+	tableKey := func(tableID uint32, suffix string) roachpb.Key {
+		return append(keys.SystemSQLCodec.TablePrefix(tableID), suffix...)
+	}
+	k1, k2 := tableKey(104, "a"), tableKey(104, "b")
+	batch := func(reqs ...kvpb.Request) *kvpb.BatchRequest {
+		ba := &kvpb.BatchRequest{}
+		ba.Add(reqs...)
+		return ba
+	}
+	put := func(k roachpb.Key) kvpb.Request {
+		return &kvpb.PutRequest{RequestHeader: kvpb.RequestHeader{Key: k}}
+	}
+	get := func(k roachpb.Key) kvpb.Request {
+		return &kvpb.GetRequest{RequestHeader: kvpb.RequestHeader{Key: k}}
+	}
+	delRange := func(k, ek roachpb.Key) kvpb.Request {
+		return &kvpb.DeleteRangeRequest{RequestHeader: kvpb.RequestHeader{Key: k, EndKey: ek}}
+	}
+
+	// No table is hinted by default.
+	_, ok := ks.serializationKey(batch(put(k1)))
+	require.False(t, ok)
+
+	require.Error(t, keySerializationTables.Validate(&st.SV, "104,foo"))
+	keySerializationTables.Override(ctx, &st.SV, "104, 105")
+
+	for _, tc := range []struct {
+		name string
+		ba   *kvpb.BatchRequest
+		exp  roachpb.Key
+	}{
+		{"put", batch(put(k1)), k1},
+		{"puts on the same key", batch(put(k1), put(k1)), k1},
+		{"non-locking requests are ignored", batch(get(k2), put(k1)), k1},
+		{"puts on different keys", batch(put(k1), put(k2)), nil},
+		{"read-only", batch(get(k1)), nil},
+		{"ranged", batch(delRange(k1, k2)), nil},
+		{"table not hinted", batch(put(tableKey(106, "a"))), nil},
+		{"system table", batch(put(tableKey(3, "a"))), nil},
+		{"non-table key", batch(put(roachpb.Key("a"))), nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			key, ok := ks.serializationKey(tc.ba)
+			require.Equal(t, tc.exp != nil, ok)
+			require.Equal(t, tc.exp, key)
+		})
+	}
+}
+
+func TestKeySerializerAcquire(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	metrics := makeKeySerializationMetrics()
+	ks := newKeySerializer(st, &metrics)
+	keySerializationTables.Override(ctx, &st.SV, "104")
+	keySerializationMaxWait.Override(ctx, &st.SV, time.Hour)
+
+	put := func(suffix string) *kvpb.BatchRequest {
+		ba := &kvpb.BatchRequest{}
+		ba.Add(&kvpb.PutRequest{RequestHeader: kvpb.RequestHeader{
+			Key: append(keys.SystemSQLCodec.TablePrefix(104), suffix...),
+		}})
+		return ba
+	}
+
+	// Batches on unrelated keys don't wait for each other.
+	releaseA := ks.acquire(ctx, put("a"))
+	require.NotNil(t, releaseA)
+	releaseB := ks.acquire(ctx, put("b"))
+	require.NotNil(t, releaseB)
+	releaseB(nil /* pErr */)
+
+	// Batches on the same key are handed the key in order.
+	order := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		i := i
+		go func() {
+			release := ks.acquire(ctx, put("a"))
+			order <- i
+			release(nil /* pErr */)
+		}()
+		// Wait for the batch to be queued, so that the order of the queue is
+		// deterministic.
+		require.Eventually(t, func() bool {
+			return metrics.Queued.Value() == int64(i+1)
+		}, 10*time.Second, time.Millisecond)
+	}
+	select {
+	case <-order:
+		t.Fatal("batch sent while the key is held")
+	default:
+	}
+	releaseA(nil /* pErr */)
+	for i := 0; i < 3; i++ {
+		require.Equal(t, i, <-order)
+	}
+	require.Zero(t, metrics.Queued.Value())
+	require.EqualValues(t, 3, metrics.WaitedCount.Count())
+
+	// A batch which waited for too long is sent without holding the key, so it
+	// doesn't hand the key over once done.
+	keySerializationMaxWait.Override(ctx, &st.SV, time.Millisecond)
+	releaseA = ks.acquire(ctx, put("a"))
+	require.NotNil(t, releaseA)
+	ks.acquire(ctx, put("a"))(nil /* pErr */)
+	require.EqualValues(t, 1, metrics.WaitTimeoutsCount.Count())
+	ks.acquire(ctx, put("a"))(nil /* pErr */)
+	require.EqualValues(t, 2, metrics.WaitTimeoutsCount.Count())
+	releaseA(nil /* pErr */)
+
+	// The key is free again once its holder released it.
+	releaseA = ks.acquire(ctx, put("a"))
+	require.NotNil(t, releaseA)
+	releaseA(nil /* pErr */)
+	require.EqualValues(t, 2, metrics.WaitTimeoutsCount.Count())
+	for i := range ks.shards {
+		require.Empty(t, ks.shards[i].queues)
+	}
+}
+
+// TestKeySerializerLockHolder verifies that the batches of the transaction
+// holding the lock on a key are not queued behind the batches of other
+// transactions, which may be waiting for that lock on the leaseholder.
+func TestKeySerializerLockHolder(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	metrics := makeKeySerializationMetrics()
+	ks := newKeySerializer(st, &metrics)
+	keySerializationTables.Override(ctx, &st.SV, "104")
+	keySerializationMaxWait.Override(ctx, &st.SV, time.Hour)
+
+	key := append(keys.SystemSQLCodec.TablePrefix(104), "a"...)
+	put := func(txn *roachpb.Transaction) *kvpb.BatchRequest {
+		ba := &kvpb.BatchRequest{}
+		ba.Txn = txn
+		ba.Add(&kvpb.PutRequest{RequestHeader: kvpb.RequestHeader{Key: key}})
+		return ba
+	}
+	now := hlc.Timestamp{WallTime: 1}
+	txn0 := roachpb.MakeTransaction("txn0", key, isolation.Serializable, 0, now, 0, 0, 0, false)
+	txn1 := roachpb.MakeTransaction("txn1", key, isolation.Serializable, 0, now, 0, 0, 0, false)
+
+	// The first transaction locks the key.
+	release0 := ks.acquire(ctx, put(&txn0))
+	require.NotNil(t, release0)
+	release0(nil /* pErr */)
+
+	// The second transaction is handed the key, and is then stuck on the
+	// leaseholder waiting for the lock of the first one.
+	release1 := ks.acquire(ctx, put(&txn1))
+	require.NotNil(t, release1)
+
+	// The first transaction's next batch on the key doesn't wait for it.
+	require.Nil(t, ks.acquire(ctx, put(&txn0)))
+	require.Zero(t, metrics.WaitedCount.Count())
+
+	// Once the second transaction took the lock, the first one is serialized
+	// again behind the batches holding the key.
+	release1(nil /* pErr */)
+	releaseOther := ks.acquire(ctx, put(nil /* txn */))
+	require.NotNil(t, releaseOther)
+	acquired := make(chan func(*kvpb.Error))
+	go func() { acquired <- ks.acquire(ctx, put(&txn0)) }()
+	require.Eventually(t, func() bool {
+		return metrics.Queued.Value() == 1
+	}, 10*time.Second, time.Millisecond)
+	// A failed batch doesn't take the lock.
+	releaseOther(kvpb.NewErrorf("boom"))
+	release0 = <-acquired
+	require.NotNil(t, release0)
+	release0(nil /* pErr */)
+	for i := range ks.shards {
+		require.Empty(t, ks.shards[i].queues)
+	}
+}
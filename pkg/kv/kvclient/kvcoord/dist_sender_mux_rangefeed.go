@@ -275,7 +275,8 @@ func (s *activeMuxRangeFeed) start(ctx context.Context, m *rangefeedMuxer) error
 
 		for !s.transport.IsExhausted() {
 			args := makeRangeFeedRequest(
-				s.Span, s.token.Desc().RangeID, m.cfg.overSystemTable, s.startAfter, m.cfg.withDiff, m.cfg.withFiltering)
+				s.Span, s.token.Desc().RangeID, m.cfg.overSystemTable, s.startAfter, m.cfg.withDiff, m.cfg.withFiltering,
+				m.cfg.resolvedTSInterval, m.cfg.overflowPolicy, m.cfg.mvccHistMutToken)
 			args.Replica = s.transport.NextReplica()
 			args.StreamID = streamID
 			s.ReplicaDescriptor = args.Replica
@@ -539,6 +540,7 @@ func (m *rangefeedMuxer) restartActiveRangeFeed(
 	}
 
 	if errInfo.evict {
+		errInfo.evictToken(ctx, &active.token)
 		active.resetRouting(ctx, rangecache.EvictionToken{})
 	}
 
@@ -0,0 +1,164 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// observedTimestampHintsEnabled controls whether the observed timestamps of
+// the nodes which returned uncertainty errors are shared with the other
+// transactions of the gateway, see observedTimestampHints.
+var observedTimestampHintsEnabled = settings.RegisterBoolSetting(
+	settings.ApplicationLevel,
+	"kv.dist_sender.observed_timestamp_hints.enabled",
+	"if enabled, the observed timestamps of the nodes which returned uncertainty errors are "+
+		"handed to the transactions of the gateway which started before they were observed",
+	true,
+)
+
+var (
+	metaObservedTimestampHintsRecorded = metric.Metadata{
+		Name: "distsender.observed_timestamp_hints.recorded",
+		Help: "Number of observed timestamps of the nodes which returned uncertainty errors " +
+			"recorded as hints",
+		Measurement: "Hints",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaObservedTimestampHintsApplied = metric.Metadata{
+		Name: "distsender.observed_timestamp_hints.applied",
+		Help: "Number of batches whose transaction was handed the observed timestamp hint " +
+			"of the node it was sent to",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// ObservedTimestampHintMetrics are the metrics of the observed timestamp
+// hints.
+type ObservedTimestampHintMetrics struct {
+	RecordedCount *metric.Counter
+	AppliedCount  *metric.Counter
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (ObservedTimestampHintMetrics) MetricStruct() {}
+
+func makeObservedTimestampHintMetrics() ObservedTimestampHintMetrics {
+	return ObservedTimestampHintMetrics{
+		RecordedCount: metric.NewCounter(metaObservedTimestampHintsRecorded),
+		AppliedCount:  metric.NewCounter(metaObservedTimestampHintsApplied),
+	}
+}
+
+// observedTimestampHint is an observed timestamp of a node, taken by a
+// transaction of the gateway.
+type observedTimestampHint struct {
+	// observed is the clock reading of the node.
+	observed hlc.ClockTimestamp
+	// sentAt is the clock reading of the gateway before it sent the request
+	// on which the node's clock was read.
+	sentAt hlc.Timestamp
+}
+
+// observedTimestampHints holds the observed timestamps of the nodes which
+// returned ReadWithinUncertaintyIntervalErrors to the transactions of the
+// gateway, to reduce the uncertainty restarts of the other transactions
+// reading from these nodes.
+//
+// An observed timestamp of a node bounds the uncertainty interval of a
+// transaction on that node because the values written on the node after it
+// was read can't causally precede the transaction. This holds for any
+// transaction which had started when the node's clock was read, not only the
+// one which read it, so a hint can be handed to the transactions which started
+// before it was sent, as if they had sent the request themselves. Since the
+// start of a transaction and the send of a hint are compared on the clock of
+// the gateway, hints are only handed to the transactions the gateway
+// coordinates: the transaction protos of leaf transactions were created on the
+// clocks of other nodes.
+//
+// A single hint is held per node: the most recently sent one, which can be
+// handed to the most transactions.
+type observedTimestampHints struct {
+	metrics ObservedTimestampHintMetrics
+
+	mu struct {
+		syncutil.RWMutex
+		hints map[roachpb.NodeID]observedTimestampHint
+	}
+}
+
+func newObservedTimestampHints(metrics ObservedTimestampHintMetrics) *observedTimestampHints {
+	h := &observedTimestampHints{metrics: metrics}
+	h.mu.hints = make(map[roachpb.NodeID]observedTimestampHint)
+	return h
+}
+
+// maybeApply hands the transaction of the batch the hint of the node the batch
+// is sent to, if the transaction doesn't have an observed timestamp of the
+// node yet, the gateway coordinates it, and the hint was sent after it
+// started. If the transaction still has no observed timestamp of the node,
+// maybeApply returns true: the observed timestamp the node will record can
+// become a hint if the batch fails with an uncertainty error.
+func (h *observedTimestampHints) maybeApply(
+	ba *kvpb.BatchRequest, localNodeID roachpb.NodeID,
+) (recordable bool) {
+	nodeID := ba.Replica.NodeID
+	if _, ok := ba.Txn.GetObservedTimestamp(nodeID); ok {
+		// NB: the existing observed timestamp can't become a hint since it's
+		// unknown when it was taken.
+		return false
+	}
+	if localNodeID != 0 && ba.Txn.CoordinatorNodeID == int32(localNodeID) {
+		h.mu.RLock()
+		hint, ok := h.mu.hints[nodeID]
+		h.mu.RUnlock()
+		if ok && ba.Txn.MinTimestamp.Less(hint.sentAt) {
+			txn := ba.Txn.Clone()
+			txn.UpdateObservedTimestamp(nodeID, hint.observed)
+			ba.Txn = txn
+			h.metrics.AppliedCount.Inc(1)
+			return false
+		}
+	}
+	return true
+}
+
+// maybeRecord records the observed timestamp of the node which returned the
+// error as its hint, if the error is a ReadWithinUncertaintyIntervalError and
+// sentAt is more recent than the current hint of the node.
+func (h *observedTimestampHints) maybeRecord(
+	nodeID roachpb.NodeID, sentAt hlc.Timestamp, pErr *kvpb.Error,
+) {
+	if _, ok := pErr.GetDetail().(*kvpb.ReadWithinUncertaintyIntervalError); !ok {
+		return
+	}
+	txn := pErr.GetTxn()
+	if txn == nil {
+		return
+	}
+	observed, ok := txn.GetObservedTimestamp(nodeID)
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if cur, ok := h.mu.hints[nodeID]; ok && sentAt.LessEq(cur.sentAt) {
+		return
+	}
+	h.mu.hints[nodeID] = observedTimestampHint{observed: observed, sentAt: sentAt}
+	h.metrics.RecordedCount.Inc(1)
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObservedTimestampHints(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const localNodeID, remoteNodeID = roachpb.NodeID(1), roachpb.NodeID(2)
+	metrics := makeObservedTimestampHintMetrics()
+	h := newObservedTimestampHints(metrics)
+	ts := func(wallTime int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wallTime} }
+	uncertaintyErr := func(observed hlc.ClockTimestamp) *kvpb.Error {
+		txn := makeTxnProto()
+		txn.UpdateObservedTimestamp(remoteNodeID, observed)
+		return kvpb.NewErrorWithTxn(&kvpb.ReadWithinUncertaintyIntervalError{}, &txn)
+	}
+	// makeBatch returns a batch to the remote node of a transaction
+	// coordinated by the given node, which started at the given time.
+	makeBatch := func(coordinator roachpb.NodeID, start hlc.Timestamp) *kvpb.BatchRequest {
+		txn := makeTxnProto()
+		txn.CoordinatorNodeID = int32(coordinator)
+		txn.MinTimestamp = start
+		ba := &kvpb.BatchRequest{}
+		ba.Txn = &txn
+		ba.Replica.NodeID = remoteNodeID
+		return ba
+	}
+
+	// Without hints, the observed timestamp of the node is recordable.
+	ba := makeBatch(localNodeID, ts(10))
+	require.True(t, h.maybeApply(ba, localNodeID))
+	_, ok := ba.Txn.GetObservedTimestamp(remoteNodeID)
+	require.False(t, ok)
+
+	// Only the observed timestamps returned with uncertainty errors are
+	// recorded.
+	txn := makeTxnProto()
+	txn.UpdateObservedTimestamp(remoteNodeID, hlc.ClockTimestamp{WallTime: 25})
+	h.maybeRecord(remoteNodeID, ts(20), kvpb.NewErrorWithTxn(kvpb.NewWriteTooOldError(
+		ts(1), ts(2), nil), &txn))
+	require.Zero(t, metrics.RecordedCount.Count())
+	h.maybeRecord(remoteNodeID, ts(20), uncertaintyErr(hlc.ClockTimestamp{WallTime: 25}))
+	require.Equal(t, int64(1), metrics.RecordedCount.Count())
+
+	// Older hints don't replace the current one.
+	h.maybeRecord(remoteNodeID, ts(15), uncertaintyErr(hlc.ClockTimestamp{WallTime: 17}))
+	require.Equal(t, int64(1), metrics.RecordedCount.Count())
+
+	// A transaction which started before the hint was sent is handed the hint.
+	ba = makeBatch(localNodeID, ts(10))
+	require.False(t, h.maybeApply(ba, localNodeID))
+	observed, ok := ba.Txn.GetObservedTimestamp(remoteNodeID)
+	require.True(t, ok)
+	require.Equal(t, hlc.ClockTimestamp{WallTime: 25}, observed)
+	require.Equal(t, int64(1), metrics.AppliedCount.Count())
+
+	// A transaction which started after the hint was sent isn't.
+	ba = makeBatch(localNodeID, ts(20))
+	require.True(t, h.maybeApply(ba, localNodeID))
+	_, ok = ba.Txn.GetObservedTimestamp(remoteNodeID)
+	require.False(t, ok)
+
+	// Nor is a transaction coordinated by another node, or by an unknown one.
+	ba = makeBatch(3, ts(10))
+	require.True(t, h.maybeApply(ba, localNodeID))
+	_, ok = ba.Txn.GetObservedTimestamp(remoteNodeID)
+	require.False(t, ok)
+	ba = makeBatch(0, ts(10))
+	require.True(t, h.maybeApply(ba, 0))
+	_, ok = ba.Txn.GetObservedTimestamp(remoteNodeID)
+	require.False(t, ok)
+
+	// A transaction which already observed the node keeps its observed
+	// timestamp, which isn't recordable.
+	ba = makeBatch(localNodeID, ts(10))
+	ba.Txn.UpdateObservedTimestamp(remoteNodeID, hlc.ClockTimestamp{WallTime: 12})
+	require.False(t, h.maybeApply(ba, localNodeID))
+	observed, _ = ba.Txn.GetObservedTimestamp(remoteNodeID)
+	require.Equal(t, hlc.ClockTimestamp{WallTime: 12}, observed)
+	require.Equal(t, int64(1), metrics.AppliedCount.Count())
+}
@@ -88,6 +88,9 @@ type rangeFeedConfig struct {
 	overSystemTable     bool
 	withDiff            bool
 	withFiltering       bool
+	resolvedTSInterval  time.Duration
+	overflowPolicy      kvpb.RangeFeedRequest_OverflowPolicy
+	mvccHistMutToken    []byte
 	rangeObserver       func(ForEachRangeFn)
 
 	knobs struct {
@@ -149,6 +152,36 @@ func WithFiltering() RangeFeedOption {
 	})
 }
 
+// WithResolvedTSInterval sets the minimum interval at which the rangefeed
+// server emits checkpoints for each range. Latency-sensitive consumers can use
+// a short interval to observe resolved timestamp advances promptly, while bulk
+// consumers can use a long one to reduce checkpoint overhead. If unset,
+// checkpoints are emitted whenever the resolved timestamp advances.
+func WithResolvedTSInterval(interval time.Duration) RangeFeedOption {
+	return optionFunc(func(c *rangeFeedConfig) {
+		c.resolvedTSInterval = interval
+	})
+}
+
+// WithOverflowPolicy sets the policy used by the rangefeed server when the
+// consumer can't keep up with the events on a range. See
+// kvpb.RangeFeedRequest_OverflowPolicy for details.
+func WithOverflowPolicy(policy kvpb.RangeFeedRequest_OverflowPolicy) RangeFeedOption {
+	return optionFunc(func(c *rangeFeedConfig) {
+		c.overflowPolicy = policy
+	})
+}
+
+// WithMVCCHistoryMutationToken sets a token identifying the rangefeed consumer.
+// MVCC history mutations carrying the same token are emitted as
+// RangeFeedMVCCHistoryMutation events instead of terminating the rangefeed
+// with an MVCCHistoryMutationError.
+func WithMVCCHistoryMutationToken(token []byte) RangeFeedOption {
+	return optionFunc(func(c *rangeFeedConfig) {
+		c.mvccHistMutToken = token
+	})
+}
+
 // WithRangeObserver is called when the rangefeed starts with a function that
 // can be used to iterate over all the ranges.
 func WithRangeObserver(observer func(ForEachRangeFn)) RangeFeedOption {
@@ -623,7 +656,7 @@ func (ds *DistSender) partialRangeFeed(
 			return err
 		}
 		if errInfo.evict {
-			token.Evict(ctx)
+			errInfo.evictToken(ctx, &token)
 			token = rangecache.EvictionToken{}
 		}
 
@@ -641,6 +674,27 @@ func (ds *DistSender) partialRangeFeed(
 type rangefeedErrorInfo struct {
 	resolveSpan bool // true if the span resolution needs to be performed, and rangefeed restarted.
 	evict       bool // true if routing info needs to be updated prior to retry.
+	// replacements are the up-to-date range infos returned by the server, which
+	// replace the evicted routing info.
+	replacements []roachpb.RangeInfo
+}
+
+// evictToken evicts the routing info of the given token, and inserts the range
+// infos returned by the server in the range cache, if any.
+func (e rangefeedErrorInfo) evictToken(ctx context.Context, token *rangecache.EvictionToken) {
+	if !token.Valid() {
+		return
+	}
+	replacements := make([]roachpb.RangeInfo, 0, len(e.replacements))
+	for _, ri := range e.replacements {
+		// Never put the evicted descriptor back in the cache, which would send
+		// the retry to the same range.
+		if token.Desc().RSpan().Equal(ri.Desc.RSpan()) {
+			continue
+		}
+		replacements = append(replacements, ri)
+	}
+	token.EvictAndReplace(ctx, replacements...)
 }
 
 // handleRangefeedError handles an error that occurred while running rangefeed.
@@ -687,8 +741,15 @@ func handleRangefeedError(
 		metrics.Errors.RangeNotFound.Inc(1)
 		return rangefeedErrorInfo{evict: true}, nil
 	case errors.HasType(err, (*kvpb.RangeKeyMismatchError)(nil)):
+		var t *kvpb.RangeKeyMismatchError
+		if ok := errors.As(err, &t); !ok {
+			return rangefeedErrorInfo{}, errors.AssertionFailedf("wrong error type: %T", err)
+		}
 		metrics.Errors.RangeKeyMismatch.Inc(1)
-		return rangefeedErrorInfo{evict: true, resolveSpan: true}, nil
+		// The server returns the descriptors and leases of the ranges overlapping
+		// the span, which repopulate the range cache for the whole span before it
+		// is resolved again.
+		return rangefeedErrorInfo{evict: true, resolveSpan: true, replacements: t.Ranges}, nil
 	case errors.HasType(err, (*kvpb.RangeFeedRetryError)(nil)):
 		var t *kvpb.RangeFeedRetryError
 		if ok := errors.As(err, &t); !ok {
@@ -759,7 +820,8 @@ func newTransportForRange(
 
 // makeRangeFeedRequest constructs kvpb.RangeFeedRequest for specified span and
 // rangeID. Request is constructed to watch event after specified timestamp, and
-// with optional diff.  If the request corresponds to a system range, request
+// with optional diff, checkpoint interval, overflow policy and MVCC history
+// mutation token.  If the request corresponds to a system range, request
 // receives higher admission priority.
 func makeRangeFeedRequest(
 	span roachpb.Span,
@@ -768,6 +830,9 @@ func makeRangeFeedRequest(
 	startAfter hlc.Timestamp,
 	withDiff bool,
 	withFiltering bool,
+	resolvedTSInterval time.Duration,
+	overflowPolicy kvpb.RangeFeedRequest_OverflowPolicy,
+	mvccHistMutToken []byte,
 ) kvpb.RangeFeedRequest {
 	admissionPri := admissionpb.BulkNormalPri
 	if isSystemRange {
@@ -779,8 +844,11 @@ func makeRangeFeedRequest(
 			Timestamp: startAfter,
 			RangeID:   rangeID,
 		},
-		WithDiff:      withDiff,
-		WithFiltering: withFiltering,
+		WithDiff:                 withDiff,
+		WithFiltering:            withFiltering,
+		ResolvedTSInterval:       resolvedTSInterval,
+		OverflowPolicy:           overflowPolicy,
+		MVCCHistoryMutationToken: mvccHistMutToken,
 		AdmissionHeader: kvpb.AdmissionHeader{
 			// NB: AdmissionHeader is used only at the start of the range feed
 			// stream since the initial catch-up scan is expensive.
@@ -838,7 +906,8 @@ func (ds *DistSender) singleRangeFeed(
 		cancelFeed()
 	}()
 
-	args := makeRangeFeedRequest(span, desc.RangeID, cfg.overSystemTable, startAfter, cfg.withDiff, cfg.withFiltering)
+	args := makeRangeFeedRequest(span, desc.RangeID, cfg.overSystemTable, startAfter, cfg.withDiff,
+		cfg.withFiltering, cfg.resolvedTSInterval, cfg.overflowPolicy, cfg.mvccHistMutToken)
 	transport, err := newTransportForRange(ctx, desc, ds)
 	if err != nil {
 		return args.Timestamp, err
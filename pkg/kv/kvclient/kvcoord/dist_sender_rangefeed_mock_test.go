@@ -18,6 +18,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/gossip"
 	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/rangecache"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/rangecache/rangecachemock"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb/kvpbmock"
@@ -167,3 +168,55 @@ func TestDistSenderRangeFeedRetryOnTransportErrors(t *testing.T) {
 		}
 	}
 }
+
+// TestRangefeedErrorRangeKeyMismatchReplacesCache tests that the range infos of
+// a RangeKeyMismatchError received by a rangefeed replace the evicted routing
+// info in the range cache.
+func TestRangefeedErrorRangeKeyMismatchReplacesCache(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	replicas := []roachpb.ReplicaDescriptor{{NodeID: 1, StoreID: 1, ReplicaID: 1}}
+	mkDesc := func(id roachpb.RangeID, start, end string, gen roachpb.RangeGeneration) roachpb.RangeDescriptor {
+		return roachpb.RangeDescriptor{
+			RangeID:          id,
+			StartKey:         roachpb.RKey(start),
+			EndKey:           roachpb.RKey(end),
+			InternalReplicas: replicas,
+			Generation:       gen,
+		}
+	}
+	staleDesc := mkDesc(1, "a", "c", 1)
+	lhsDesc := mkDesc(1, "a", "b", 2)
+	rhsDesc := mkDesc(2, "b", "c", 2)
+
+	rc := rangecache.NewRangeCache(cluster.MakeTestingClusterSettings(), nil, /* db */
+		func() int64 { return 1 << 20 }, stopper)
+	rc.Insert(ctx, roachpb.RangeInfo{Desc: staleDesc})
+	ent := rc.GetCached(ctx, roachpb.RKey("a"), false /* inverted */)
+	require.NotNil(t, ent)
+	token := rc.MakeEvictionToken(ent)
+
+	mismatch := kvpb.NewRangeKeyMismatchError(ctx, roachpb.Key("b"), roachpb.Key("c"), &lhsDesc, nil /* lease */)
+	mismatch.AppendRangeInfo(ctx, roachpb.RangeInfo{Desc: rhsDesc})
+	// The stale descriptor is never put back in the cache.
+	mismatch.AppendRangeInfo(ctx, roachpb.RangeInfo{Desc: staleDesc})
+
+	metrics := makeDistSenderRangeFeedMetrics()
+	errInfo, err := handleRangefeedError(ctx, &metrics, kvpb.NewError(mismatch).GoError())
+	require.NoError(t, err)
+	require.True(t, errInfo.evict)
+	require.True(t, errInfo.resolveSpan)
+	errInfo.evictToken(ctx, &token)
+	require.False(t, token.Valid())
+
+	var descs []roachpb.RangeDescriptor
+	for _, e := range rc.GetCachedOverlapping(ctx, roachpb.RSpan{Key: roachpb.RKey("a"), EndKey: roachpb.RKey("c")}) {
+		descs = append(descs, *e.Desc())
+	}
+	require.Equal(t, []roachpb.RangeDescriptor{lhsDesc, rhsDesc}, descs)
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+)
+
+// systemLaneEnabled controls whether the partial batches of system-critical
+// traffic are sent asynchronously in a lane of their own, see
+// isSystemCriticalSpan.
+var systemLaneEnabled = settings.RegisterBoolSetting(
+	settings.ApplicationLevel,
+	"kv.dist_sender.system_lane.enabled",
+	"if enabled, the partial batches to the node liveness, jobs and span configuration "+
+		"ranges are sent asynchronously with a concurrency limit of their own, ahead of "+
+		"the partial batches of user traffic",
+	true,
+)
+
+// systemLaneConcurrencyLimit controls the maximum number of asynchronous send
+// requests of system-critical traffic.
+var systemLaneConcurrencyLimit = settings.RegisterIntSetting(
+	settings.ApplicationLevel,
+	"kv.dist_sender.system_lane.concurrency_limit",
+	"maximum number of asynchronous send requests to the node liveness, jobs and span "+
+		"configuration ranges when kv.dist_sender.system_lane.enabled is set",
+	256,
+	settings.NonNegativeInt,
+)
+
+// isSystemCriticalSpan returns whether the span addresses the data the
+// cluster needs to make progress regardless of the user workload: the node
+// liveness records, and the jobs and span configurations system tables of
+// any tenant. The partial batches to these spans don't queue behind the
+// partial batches of user traffic, nor count against its concurrency limits.
+func isSystemCriticalSpan(rs roachpb.RSpan) bool {
+	key := rs.Key.AsRawKey()
+	if keys.NodeLivenessSpan.ContainsKey(key) {
+		return true
+	}
+	rest, err := keys.StripTenantPrefix(key)
+	if err != nil || encoding.PeekType(rest) != encoding.Int {
+		return false
+	}
+	_, tableID, err := encoding.DecodeUvarintAscending(rest)
+	if err != nil {
+		return false
+	}
+	switch tableID {
+	case keys.JobsTableID, keys.ScheduledJobsTableID, keys.SpanConfigurationsTableID:
+		return true
+	default:
+		return false
+	}
+}
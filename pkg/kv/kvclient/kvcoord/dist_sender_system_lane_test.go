@@ -0,0 +1,50 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSystemCriticalSpan(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	tenantCodec := keys.MakeSQLCodec(roachpb.MustMakeTenantID(10))
+	span := func(key roachpb.Key) roachpb.RSpan {
+		return roachpb.RSpan{Key: roachpb.RKey(key), EndKey: roachpb.RKey(key.PrefixEnd())}
+	}
+	for _, tc := range []struct {
+		name     string
+		key      roachpb.Key
+		expected bool
+	}{
+		{"liveness", keys.NodeLivenessKey(1), true},
+		{"jobs", keys.SystemSQLCodec.TablePrefix(keys.JobsTableID), true},
+		{"scheduled jobs", keys.SystemSQLCodec.TablePrefix(keys.ScheduledJobsTableID), true},
+		{"span configurations", keys.SystemSQLCodec.IndexPrefix(keys.SpanConfigurationsTableID, 1), true},
+		{"tenant jobs", tenantCodec.TablePrefix(keys.JobsTableID), true},
+		{"meta2", keys.RangeMetaKey(roachpb.RKey("a")).AsRawKey(), false},
+		{"descriptors", keys.SystemSQLCodec.TablePrefix(keys.DescriptorTableID), false},
+		{"user table", keys.SystemSQLCodec.TablePrefix(104), false},
+		{"tenant user table", tenantCodec.TablePrefix(104), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, isSystemCriticalSpan(span(tc.key)))
+		})
+	}
+}
@@ -3049,6 +3049,172 @@ func TestMultiRangeWithEndTxn(t *testing.T) {
 	}
 }
 
+// TestParallelCommitRecordFirst verifies that, with
+// kv.dist_sender.parallel_commit_record_first.enabled, a parallel-committing
+// batch which spans ranges sends the requests to the range of the transaction
+// record ahead of the others, and that the responses and errors of the two
+// legs are stitched back in the order of the original batch.
+func TestParallelCommitRecordFirst(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	clock := hlc.NewClockForTesting(nil)
+	rpcContext := rpc.NewInsecureTestingContext(ctx, clock, stopper)
+	g := makeGossip(t, stopper, rpcContext)
+	if err := g.SetNodeDescriptor(newNodeDesc(1)); err != nil {
+		t.Fatal(err)
+	}
+	nd := &roachpb.NodeDescriptor{
+		NodeID:  roachpb.NodeID(1),
+		Address: util.MakeUnresolvedAddr(testAddress.Network(), testAddress.String()),
+	}
+	require.NoError(t, g.AddInfoProto(gossip.MakeNodeIDKey(roachpb.NodeID(1)), nd, time.Hour))
+
+	// Split the keyspace at "b".
+	descDB := mockRangeDescriptorDBForDescs(
+		TestMetaRangeDescriptor,
+		roachpb.RangeDescriptor{
+			RangeID:          2,
+			StartKey:         testMetaEndKey,
+			EndKey:           roachpb.RKey("b"),
+			InternalReplicas: []roachpb.ReplicaDescriptor{{NodeID: 1, StoreID: 1}},
+		},
+		roachpb.RangeDescriptor{
+			RangeID:          3,
+			StartKey:         roachpb.RKey("b"),
+			EndKey:           roachpb.RKeyMax,
+			InternalReplicas: []roachpb.ReplicaDescriptor{{NodeID: 1, StoreID: 1}},
+		},
+	)
+
+	put := func(key string) kvpb.Request {
+		return kvpb.NewPut(roachpb.Key(key), roachpb.MakeValueFromString("val"))
+	}
+	qi := func(key string) kvpb.Request {
+		return &kvpb.QueryIntentRequest{RequestHeader: kvpb.RequestHeader{Key: roachpb.Key(key)}}
+	}
+	et := func(key string, parCommit bool) kvpb.Request {
+		et := &kvpb.EndTxnRequest{
+			RequestHeader: kvpb.RequestHeader{Key: roachpb.Key(key)},
+			Commit:        true,
+		}
+		if parCommit {
+			et.InFlightWrites = []roachpb.SequencedWrite{{Key: roachpb.Key(key), Sequence: 1}}
+		}
+		return et
+	}
+
+	// send sends a batch made of the given requests with the optimization
+	// enabled, and returns the methods of the batches received by the
+	// transport. errKey, if set, makes the requests to it fail.
+	send := func(
+		t *testing.T, reqs []kvpb.Request, errKey string,
+	) ([][]kvpb.Method, *kvpb.BatchResponse, *kvpb.Error) {
+		var act [][]kvpb.Method
+		testFn := func(ctx context.Context, ba *kvpb.BatchRequest) (*kvpb.BatchResponse, error) {
+			var cur []kvpb.Method
+			br := ba.CreateReply()
+			for i, union := range ba.Requests {
+				req := union.GetInner()
+				cur = append(cur, req.Method())
+				if errKey != "" && req.Header().Key.Equal(roachpb.Key(errKey)) && br.Error == nil {
+					br.Error = kvpb.NewError(&kvpb.ConditionFailedError{})
+					br.Error.SetErrorIndex(int32(i))
+				}
+			}
+			act = append(act, cur)
+			return br, nil
+		}
+		st := cluster.MakeTestingClusterSettings()
+		parallelCommitRecordFirst.Override(ctx, &st.SV, true)
+		ds := NewDistSender(DistSenderConfig{
+			AmbientCtx:        log.MakeTestingAmbientCtxWithNewTracer(),
+			Clock:             clock,
+			NodeDescs:         g,
+			Stopper:           stopper,
+			TransportFactory:  adaptSimpleTransport(testFn),
+			RangeDescriptorDB: descDB,
+			Settings:          st,
+		})
+		ds.DisableParallelBatches()
+
+		ba := &kvpb.BatchRequest{}
+		ba.Txn = &roachpb.Transaction{Name: "test"}
+		ba.Add(reqs...)
+		br, pErr := ds.Send(ctx, ba)
+		return act, br, pErr
+	}
+
+	testCases := []struct {
+		name string
+		reqs []kvpb.Request
+		exp  [][]kvpb.Method
+	}{
+		{
+			name: "single range",
+			reqs: []kvpb.Request{put("b1"), put("b2"), et("b3", true)},
+			exp:  [][]kvpb.Method{{kvpb.Put, kvpb.Put, kvpb.EndTxn}},
+		},
+		{
+			name: "only the EndTxn on the record range",
+			reqs: []kvpb.Request{put("a1"), put("a2"), et("b", true)},
+			exp:  [][]kvpb.Method{{kvpb.EndTxn}, {kvpb.Put, kvpb.Put}},
+		},
+		{
+			name: "writes on both ranges",
+			reqs: []kvpb.Request{put("a1"), put("b1"), put("a2"), et("b2", true)},
+			exp:  [][]kvpb.Method{{kvpb.Put, kvpb.EndTxn}, {kvpb.Put, kvpb.Put}},
+		},
+		{
+			name: "pre-commit query intents",
+			reqs: []kvpb.Request{put("a1"), put("b1"), qi("a2"), et("b2", true)},
+			exp: [][]kvpb.Method{
+				{kvpb.QueryIntent},
+				{kvpb.Put, kvpb.EndTxn},
+				{kvpb.Put},
+			},
+		},
+		{
+			name: "request straddling the record range",
+			reqs: []kvpb.Request{
+				kvpb.NewDeleteRange(roachpb.Key("a"), roachpb.Key("c"), false /* returnKeys */),
+				et("b", true),
+			},
+			exp: [][]kvpb.Method{{kvpb.DeleteRange}, {kvpb.DeleteRange, kvpb.EndTxn}},
+		},
+		{
+			name: "no parallel commit",
+			reqs: []kvpb.Request{put("a1"), put("a2"), et("b", false)},
+			exp:  [][]kvpb.Method{{kvpb.Put, kvpb.Put}, {kvpb.EndTxn}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			act, br, pErr := send(t, tc.reqs, "" /* errKey */)
+			require.NoError(t, pErr.GoError())
+			require.Equal(t, tc.exp, act)
+			require.Len(t, br.Responses, len(tc.reqs))
+			for i, req := range tc.reqs {
+				require.Equal(t, req.Method(), br.Responses[i].GetInner().Method(), "response %d", i)
+			}
+		})
+	}
+
+	// An error in either leg is returned with its index in the original batch.
+	t.Run("errors", func(t *testing.T) {
+		reqs := []kvpb.Request{put("a1"), put("b1"), put("a2"), et("b2", true)}
+		for errKey, expIdx := range map[string]int32{"a2": 2, "b1": 1} {
+			_, _, pErr := send(t, reqs, errKey)
+			require.IsType(t, &kvpb.ConditionFailedError{}, pErr.GetDetail())
+			require.NotNil(t, pErr.Index)
+			require.Equal(t, expIdx, pErr.Index.Index, "error on %s", errKey)
+		}
+	})
+}
+
 // TestParallelCommitSplitFromQueryIntents verifies that a parallel-committing
 // batch is split into sub-batches - one containing all pre-commit QueryIntent
 // requests and one containing everything else.
@@ -6235,3 +6401,51 @@ func (m *mockTenantSideCostController) GetCostConfig() *tenantcostmodel.Config {
 func (m *mockTenantSideCostController) Metrics() metric.Struct {
 	return nil
 }
+
+func TestBatchConnectionClass(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	useDedicatedBulkConnectionClass.Override(ctx, &st.SV, true)
+
+	userDesc := &roachpb.RangeDescriptor{
+		StartKey: roachpb.RKey("a"),
+		EndKey:   roachpb.RKey("b"),
+	}
+	metaDesc := &roachpb.RangeDescriptor{
+		StartKey: roachpb.RKeyMin,
+		EndKey:   roachpb.RKey(keys.Meta2Prefix),
+	}
+	batch := func(reqs ...kvpb.Request) *kvpb.BatchRequest {
+		ba := &kvpb.BatchRequest{}
+		ba.Add(reqs...)
+		return ba
+	}
+	span := kvpb.RequestHeader{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")}
+
+	for _, tc := range []struct {
+		name string
+		ba   *kvpb.BatchRequest
+		desc *roachpb.RangeDescriptor
+		exp  rpc.ConnectionClass
+	}{
+		{"get", batch(&kvpb.GetRequest{RequestHeader: span}), userDesc, rpc.DefaultClass},
+		{"add sstable", batch(&kvpb.AddSSTableRequest{RequestHeader: span}), userDesc, rpc.BulkClass},
+		{"export", batch(&kvpb.ExportRequest{RequestHeader: span}), userDesc, rpc.BulkClass},
+		{"mixed", batch(&kvpb.ExportRequest{RequestHeader: span}, &kvpb.ScanRequest{RequestHeader: span}),
+			userDesc, rpc.DefaultClass},
+		{"system", batch(&kvpb.ExportRequest{RequestHeader: span}), metaDesc, rpc.SystemClass},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, batchConnectionClass(&st.SV, tc.ba, tc.desc))
+		})
+	}
+
+	// Bulk requests share the connections of the foreground traffic if the
+	// dedicated connections are disabled.
+	useDedicatedBulkConnectionClass.Override(ctx, &st.SV, false)
+	require.Equal(t, rpc.DefaultClass,
+		batchConnectionClass(&st.SV, batch(&kvpb.ExportRequest{RequestHeader: span}), userDesc))
+}
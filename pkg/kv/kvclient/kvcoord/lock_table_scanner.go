@@ -0,0 +1,179 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+)
+
+// LockTableScannerOptions configures a LockTableScanner.
+type LockTableScannerOptions struct {
+	// IncludeUncontended is passed on to the QueryLocksRequests, see
+	// kvpb.QueryLocksRequest.
+	IncludeUncontended bool
+	// MaxLocksPerRequest and TargetBytesPerRequest bound the results of each
+	// QueryLocksRequest. The lock tables of ranges holding more locks are
+	// paginated through.
+	MaxLocksPerRequest    int64
+	TargetBytesPerRequest int64
+	// Concurrency is the number of ranges whose first page of locks is queried
+	// concurrently.
+	Concurrency int
+}
+
+// LockTableScanner collects the locks held in a set of spans from the lock
+// tables of the ranges they overlap, with bounded results per request.
+//
+// Sending a single QueryLocksRequest over spans covering many ranges either
+// fans out to all of them at once without limits, or visits them one after
+// the other with limits. Instead, the scanner queries the first page of locks
+// of up to Concurrency ranges at a time, and paginates through the rest of
+// the locks of each range in key order. Since most ranges hold few locks, this
+// collects the locks of large tables quickly, while holding at most
+// Concurrency pages of locks in memory.
+type LockTableScanner struct {
+	ds    *DistSender
+	opts  LockTableScannerOptions
+	spans roachpb.Spans
+
+	// ri iterates over the ranges of rs, the span being scanned, if inSpan is
+	// set.
+	ri     RangeIterator
+	rs     roachpb.RSpan
+	inSpan bool
+
+	// window holds the first page of locks of the ranges queried concurrently,
+	// which are yet to be returned.
+	window []*kvpb.QueryLocksResponse
+	// resumeSpan is the resume span of the last page returned.
+	resumeSpan *roachpb.Span
+}
+
+// NewLockTableScanner returns a LockTableScanner for the given spans, which
+// are scanned one after the other.
+func NewLockTableScanner(
+	ds *DistSender, spans roachpb.Spans, opts LockTableScannerOptions,
+) *LockTableScanner {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	return &LockTableScanner{
+		ds:    ds,
+		opts:  opts,
+		spans: spans,
+		ri:    MakeRangeIterator(ds),
+	}
+}
+
+// NextPage returns the next page of locks, in key order within each span.
+// Returns no locks once all the spans have been scanned.
+func (s *LockTableScanner) NextPage(ctx context.Context) ([]roachpb.LockStateInfo, error) {
+	for {
+		var resp *kvpb.QueryLocksResponse
+		switch {
+		case s.resumeSpan != nil:
+			var err error
+			if resp, err = s.queryLocks(ctx, *s.resumeSpan); err != nil {
+				return nil, err
+			}
+		case len(s.window) > 0:
+			resp, s.window = s.window[0], s.window[1:]
+		default:
+			if done, err := s.queryWindow(ctx); err != nil || done {
+				return nil, err
+			}
+			continue
+		}
+		s.resumeSpan = resp.ResumeSpan
+		if len(resp.Locks) > 0 {
+			return resp.Locks, nil
+		}
+	}
+}
+
+// queryWindow concurrently queries the first page of locks of the next ranges.
+// Returns whether there are no ranges left to query.
+func (s *LockTableScanner) queryWindow(ctx context.Context) (done bool, _ error) {
+	var spans []roachpb.Span
+	for len(spans) < s.opts.Concurrency {
+		if !s.inSpan {
+			if len(s.spans) == 0 {
+				break
+			}
+			rs, err := keys.SpanAddr(s.spans[0])
+			if err != nil {
+				return false, err
+			}
+			s.spans = s.spans[1:]
+			s.rs = rs
+			s.ri.Seek(ctx, rs.Key, Ascending)
+			s.inSpan = true
+		}
+		if !s.ri.Valid() {
+			return false, s.ri.Error()
+		}
+		curRangeRS, err := s.rs.Intersect(s.ri.Desc().RSpan())
+		if err != nil {
+			return false, err
+		}
+		spans = append(spans, curRangeRS.AsRawSpanWithNoLocals())
+		if s.ri.NeedAnother(s.rs) {
+			s.ri.Next(ctx)
+		} else {
+			s.inSpan = false
+		}
+	}
+	if len(spans) == 0 {
+		return true, nil
+	}
+
+	window := make([]*kvpb.QueryLocksResponse, len(spans))
+	g := ctxgroup.WithContext(ctx)
+	for i := range spans {
+		i := i
+		g.GoCtx(func(ctx context.Context) error {
+			var err error
+			window[i], err = s.queryLocks(ctx, spans[i])
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return false, err
+	}
+	s.window = window
+	return false, nil
+}
+
+// queryLocks queries a page of the locks in the span. The span usually
+// addresses a single range, but may address several if the range split since
+// its descriptor was looked up.
+func (s *LockTableScanner) queryLocks(
+	ctx context.Context, span roachpb.Span,
+) (*kvpb.QueryLocksResponse, error) {
+	ba := &kvpb.BatchRequest{}
+	ba.Timestamp = s.ds.clock.Now()
+	ba.MaxSpanRequestKeys = s.opts.MaxLocksPerRequest
+	ba.TargetBytes = s.opts.TargetBytesPerRequest
+	ba.Add(&kvpb.QueryLocksRequest{
+		RequestHeader:      kvpb.RequestHeaderFromSpan(span),
+		IncludeUncontended: s.opts.IncludeUncontended,
+	})
+	br, pErr := s.ds.Send(ctx, ba)
+	if pErr != nil {
+		return nil, pErr.GoError()
+	}
+	return br.Responses[0].GetQueryLocks(), nil
+}
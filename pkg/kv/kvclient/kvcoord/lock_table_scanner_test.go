@@ -0,0 +1,84 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvcoord"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLockTableScanner verifies that the LockTableScanner collects the locks
+// of spans over many ranges, whatever its limits and concurrency.
+func TestLockTableScanner(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db := startNoSplitMergeServer(t)
+	defer s.Stopper().Stop(ctx)
+	ds := s.DistSenderI().(*kvcoord.DistSender)
+
+	// Split "a"-"z" into ranges, some of which hold several locks and some
+	// none, and lock keys in both "a"-"m" and "n"-"z".
+	require.NoError(t, setupMultipleRanges(ctx, db, "b", "c", "d", "f", "h", "j", "o", "q", "s"))
+	var lockedKeys []roachpb.Key
+	for _, prefix := range []string{"a", "c", "d", "e", "i", "n", "o", "r"} {
+		for i := 0; i < 3; i++ {
+			lockedKeys = append(lockedKeys, roachpb.Key(fmt.Sprintf("%s%d", prefix, i)))
+		}
+	}
+	txn := db.NewTxn(ctx, "locker")
+	for _, k := range lockedKeys {
+		_, err := txn.GetForUpdate(ctx, k, kvpb.BestEffort)
+		require.NoError(t, err)
+	}
+	defer func() { require.NoError(t, txn.Rollback(ctx)) }()
+
+	spans := roachpb.Spans{
+		{Key: roachpb.Key("a"), EndKey: roachpb.Key("m")},
+		{Key: roachpb.Key("n"), EndKey: roachpb.Key("z")},
+	}
+	for _, maxLocks := range []int64{0, 1, 2, 100} {
+		for _, concurrency := range []int{0, 1, 3, 100} {
+			t.Run(fmt.Sprintf("maxLocks=%d,concurrency=%d", maxLocks, concurrency), func(t *testing.T) {
+				scanner := kvcoord.NewLockTableScanner(ds, spans, kvcoord.LockTableScannerOptions{
+					IncludeUncontended: true,
+					MaxLocksPerRequest: maxLocks,
+					Concurrency:        concurrency,
+				})
+				var got []roachpb.Key
+				for {
+					locks, err := scanner.NextPage(ctx)
+					require.NoError(t, err)
+					if len(locks) == 0 {
+						break
+					}
+					if maxLocks > 0 {
+						require.LessOrEqual(t, int64(len(locks)), maxLocks)
+					}
+					for _, l := range locks {
+						require.Equal(t, txn.ID(), l.LockHolder.ID)
+						got = append(got, l.Key)
+					}
+				}
+				require.Equal(t, lockedKeys, got)
+			})
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"math/rand"
 	"runtime/debug"
 
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/isolation"
@@ -146,6 +147,11 @@ type TxnCoordSender struct {
 		// caller of DeferCommitWait has assumed responsibility for performing
 		// the commit-wait.
 		commitWaitDeferred bool
+
+		// commitTimestampFloor, if set, is the timestamp below which the
+		// transaction must not commit. It is sent in the header of every batch
+		// for the ranges to validate it against their closed timestamps.
+		commitTimestampFloor hlc.Timestamp
 	}
 
 	// A pointer member to the creating factory provides access to
@@ -160,9 +166,10 @@ type TxnCoordSender struct {
 	// additional heap allocations necessary.
 	interceptorStack []txnInterceptor
 	interceptorAlloc struct {
-		arr [6]txnInterceptor
+		arr [7]txnInterceptor
 		txnHeartbeater
 		txnSeqNumAllocator
+		txnWriteBuffer
 		txnPipeliner
 		txnCommitter
 		txnSpanRefresher
@@ -257,12 +264,17 @@ func newRootTxnCoordSender(
 		stopper: tcs.stopper,
 		metrics: &tcs.metrics,
 		mu:      &tcs.mu.Mutex,
+		cleaner: tcf.deadlineAbortCleaner,
 	}
 	tcs.interceptorAlloc.txnMetricRecorder = txnMetricRecorder{
 		metrics:    &tcs.metrics,
 		timeSource: timeutil.DefaultTimeSource{},
 		txn:        &tcs.mu.txn,
 	}
+	tcs.interceptorAlloc.txnWriteBuffer = txnWriteBuffer{
+		st:      tcf.st,
+		metrics: &tcs.metrics,
+	}
 	tcs.initCommonInterceptors(tcf, txn, kv.RootTxn)
 
 	// Once the interceptors are initialized, piece them all together in the
@@ -272,6 +284,11 @@ func newRootTxnCoordSender(
 		// Various interceptors below rely on sequence number allocation,
 		// so the sequence number allocator is near the top of the stack.
 		&tcs.interceptorAlloc.txnSeqNumAllocator,
+		// The write buffer sits below the sequence number allocator, so that
+		// the buffered writes keep the sequence numbers they were issued at.
+		// It sits above the pipeliner, which tracks the buffered writes once
+		// they are flushed, like any other write.
+		&tcs.interceptorAlloc.txnWriteBuffer,
 		// The pipeliner sits above the span refresher because it will
 		// never generate transaction retry errors that could be avoided
 		// with a refresh.
@@ -492,7 +509,8 @@ func (tc *TxnCoordSender) Send(
 		return nil, pErr
 	}
 
-	if ba.IsSingleEndTxnRequest() && !tc.interceptorAlloc.txnPipeliner.hasAcquiredLocks() {
+	if ba.IsSingleEndTxnRequest() && !tc.interceptorAlloc.txnPipeliner.hasAcquiredLocks() &&
+		!tc.interceptorAlloc.txnWriteBuffer.hasBufferedWrites() {
 		return nil, tc.finalizeNonLockingTxnLocked(ctx, ba)
 	}
 
@@ -530,10 +548,17 @@ func (tc *TxnCoordSender) Send(
 	// Clone the Txn's Proto so that future modifications can be made without
 	// worrying about synchronization.
 	ba.Txn = tc.mu.txn.Clone()
+	ba.CommitTimestampFloor = tc.mu.commitTimestampFloor
 
 	// Send the command through the txnInterceptor stack.
 	br, pErr := tc.interceptorStack[0].SendLocked(ctx, ba)
 
+	// Remember whether the transaction ran past its deadline, in which case
+	// its intents are resolved eagerly if it is rolled back.
+	if pErr != nil && isDeadlineExceeded(ctx, pErr) {
+		tc.interceptorAlloc.txnCommitter.setDeadlineExceeded()
+	}
+
 	pErr = tc.updateStateLocked(ctx, ba, br, pErr)
 
 	// If we succeeded to commit, or we attempted to rollback, we move to
@@ -1203,6 +1228,42 @@ func (tc *TxnCoordSender) SetFixedTimestamp(ctx context.Context, ts hlc.Timestam
 	return nil
 }
 
+// SetCommitTimestampFloor is part of the kv.TxnSender interface.
+func (tc *TxnCoordSender) SetCommitTimestampFloor(ctx context.Context, ts hlc.Timestamp) error {
+	if !tc.st.Version.IsActive(ctx, clusterversion.V24_1_CommitTimestampFloor) {
+		return errors.Newf("commit timestamp floors require cluster version %s",
+			clusterversion.V24_1_CommitTimestampFloor)
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	// The transaction must not have already been used in this epoch.
+	if tc.hasPerformedReadsLocked() {
+		return errors.WithContextTags(errors.AssertionFailedf(
+			"cannot set commit timestamp floor, txn %s already performed reads", tc.mu.txn), ctx)
+	}
+	if tc.hasPerformedWritesLocked() {
+		return errors.WithContextTags(errors.AssertionFailedf(
+			"cannot set commit timestamp floor, txn %s already performed writes", tc.mu.txn), ctx)
+	}
+	if tc.mu.txn.ReadTimestampFixed {
+		return errors.WithContextTags(errors.AssertionFailedf(
+			"cannot set commit timestamp floor, txn %s has a fixed timestamp", tc.mu.txn), ctx)
+	}
+	// The floor comes from another HLC, which the local clock must not lag
+	// behind, as reads of the transaction's writes would otherwise observe
+	// values from the future. This also rejects floors too far ahead of the
+	// local wall time.
+	if err := tc.clock.UpdateAndCheckMaxOffset(ctx, ts.UnsafeToClockTimestamp()); err != nil {
+		return errors.Wrap(err, "invalid commit timestamp floor")
+	}
+
+	tc.mu.commitTimestampFloor = ts
+	tc.mu.txn.ReadTimestamp.Forward(ts)
+	tc.mu.txn.WriteTimestamp.Forward(ts)
+	tc.mu.txn.GlobalUncertaintyLimit.Forward(ts)
+	return nil
+}
+
 // RequiredFrontier is part of the kv.TxnSender interface.
 func (tc *TxnCoordSender) RequiredFrontier() hlc.Timestamp {
 	tc.mu.Lock()
@@ -1335,6 +1396,13 @@ func (tc *TxnCoordSender) GetLeafTxnInputState(
 		return nil, pErr.GoError()
 	}
 
+	// The reads of the leaf must observe the buffered writes.
+	if tc.interceptorAlloc.txnWriteBuffer.hasBufferedWrites() {
+		if pErr = tc.flushWriteBufferLocked(ctx); pErr != nil {
+			return nil, pErr.GoError()
+		}
+	}
+
 	// Copy mutable state so access is safe for the caller.
 	tis.Txn = tc.mu.txn
 	for _, reqInt := range tc.interceptorStack {
@@ -1350,6 +1418,15 @@ func (tc *TxnCoordSender) GetLeafTxnInputState(
 	return tis, nil
 }
 
+// flushWriteBufferLocked sends the writes buffered by the txnWriteBuffer in a
+// batch of their own.
+func (tc *TxnCoordSender) flushWriteBufferLocked(ctx context.Context) *kvpb.Error {
+	ba := &kvpb.BatchRequest{}
+	ba.Txn = tc.mu.txn.Clone()
+	br, pErr := tc.interceptorAlloc.txnWriteBuffer.flushOnlyLocked(ctx, ba)
+	return tc.updateStateLocked(ctx, ba, br, pErr)
+}
+
 // GetLeafTxnFinalState is part of the kv.TxnSender interface.
 func (tc *TxnCoordSender) GetLeafTxnFinalState(
 	ctx context.Context,
@@ -36,6 +36,10 @@ type TxnCoordSenderFactory struct {
 	metrics                TxnMetrics
 	condensedIntentsEveryN log.EveryN
 
+	// deadlineAbortCleaner resolves the intents of the transactions rolled back
+	// after exceeding their deadline.
+	deadlineAbortCleaner *deadlineAbortCleaner
+
 	testingKnobs ClientTestingKnobs
 }
 
@@ -84,6 +88,7 @@ func NewTxnCoordSenderFactory(
 	if tcf.metrics == (TxnMetrics{}) {
 		tcf.metrics = MakeTxnMetrics(metric.TestSampleInterval)
 	}
+	tcf.deadlineAbortCleaner = newDeadlineAbortCleaner(tcf)
 	return tcf
 }
 
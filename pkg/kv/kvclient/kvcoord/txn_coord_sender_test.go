@@ -2787,6 +2787,44 @@ func TestPutsInStagingTxn(t *testing.T) {
 	require.True(t, putInStagingSeen)
 }
 
+// TestTxnCoordSenderSetCommitTimestampFloor tests that a transaction with a
+// commit timestamp floor commits at or above it, and that its writes are
+// rejected by ranges which already closed the floor.
+func TestTxnCoordSenderSetCommitTimestampFloor(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	s := createTestDB(t)
+	defer s.Stop()
+
+	// The floor forwards the timestamps of the transaction.
+	floor := s.Clock.Now().Add(10, 0)
+	txn := kv.NewTxn(ctx, s.DB, 0 /* gatewayNodeID */)
+	require.NoError(t, txn.SetCommitTimestampFloor(ctx, floor))
+	require.False(t, txn.ReadTimestamp().Less(floor))
+	require.NoError(t, txn.Put(ctx, "a", "v"))
+	require.NoError(t, txn.Commit(ctx))
+	commitTS, err := txn.CommitTimestamp()
+	require.NoError(t, err)
+	require.False(t, commitTS.Less(floor))
+	require.False(t, s.Clock.Now().Less(floor))
+
+	// The floor can't be set once the transaction wrote.
+	txn = kv.NewTxn(ctx, s.DB, 0 /* gatewayNodeID */)
+	require.NoError(t, txn.Put(ctx, "b", "v"))
+	require.Regexp(t, "cannot set commit timestamp floor, .* already performed writes",
+		txn.SetCommitTimestampFloor(ctx, s.Clock.Now()))
+	require.NoError(t, txn.Rollback(ctx))
+
+	// A write to a range whose closed timestamp is above the floor is rejected.
+	// The previous writes closed timestamps close to the present.
+	txn = kv.NewTxn(ctx, s.DB, 0 /* gatewayNodeID */)
+	require.NoError(t, txn.SetCommitTimestampFloor(ctx, hlc.Timestamp{WallTime: 1}))
+	err = txn.Put(ctx, "c", "v")
+	require.True(t, kvpb.IsCommitTimestampFloorClosedError(err), "%+v", err)
+	require.NoError(t, txn.Rollback(ctx))
+}
+
 // TestTxnCoordSenderSetFixedTimestamp tests that SetFixedTimestamp cannot be
 // called after a transaction has already been used in the current epoch to read
 // or write.
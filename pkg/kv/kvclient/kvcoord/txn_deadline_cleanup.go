@@ -0,0 +1,211 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/multitenant"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// deadlineAbortCleanupEnabled controls whether the coordinator of a
+// transaction rolled back after exceeding its deadline resolves the
+// transaction's intents itself, see deadlineAbortCleaner.
+var deadlineAbortCleanupEnabled = settings.RegisterBoolSetting(
+	settings.ApplicationLevel,
+	"kv.transaction.deadline_abort_cleanup.enabled",
+	"if enabled, the intents of transactions rolled back after exceeding their deadline "+
+		"are resolved by their coordinator right away, in parallel across ranges, rather "+
+		"than left for conflicting requests to discover",
+	true,
+)
+
+// deadlineAbortCleanupConcurrency bounds the number of intent resolution
+// batches sent concurrently by the deadlineAbortCleaner of a node.
+var deadlineAbortCleanupConcurrency = settings.RegisterIntSetting(
+	settings.ApplicationLevel,
+	"kv.transaction.deadline_abort_cleanup.concurrency",
+	"maximum number of concurrent intent resolution batches sent by a node on behalf of "+
+		"transactions rolled back after exceeding their deadline; the intents of the batches "+
+		"over the limit are left to the asynchronous cleanup of the transaction record's range",
+	16,
+	settings.PositiveInt,
+)
+
+const (
+	// deadlineAbortCleanupMaxSpansPerBatch is the maximum number of lock spans
+	// resolved by each batch sent by the deadlineAbortCleaner. The DistSender
+	// splits each batch across ranges and sends the partial batches in
+	// parallel.
+	deadlineAbortCleanupMaxSpansPerBatch = 128
+	// deadlineAbortCleanupMaxKeysPerBatch bounds the number of intents
+	// resolved by each batch, which bounds the work spent on condensed lock
+	// spans covering a large keyspace. Their remaining intents are left to the
+	// asynchronous cleanup of the transaction record's range.
+	deadlineAbortCleanupMaxKeysPerBatch = 10000
+	// deadlineAbortCleanupTimeout is the timeout of each batch.
+	deadlineAbortCleanupTimeout = time.Minute
+)
+
+// deadlineAbortCleaner resolves the intents of transactions rolled back after
+// exceeding their deadline.
+//
+// The rollback of a transaction only resolves the intents on the range of its
+// transaction record synchronously; the others are resolved by an asynchronous
+// task of that range, which is rate limited and may fall behind. Meanwhile,
+// the requests which conflict with the intents have to push the aborted
+// transaction to discover that they can be removed. A transaction exceeding
+// its deadline is a sign of an overloaded workload whose retries are likely
+// to run into the intents, so the coordinator resolves them right away,
+// amplifying the contention less.
+type deadlineAbortCleaner struct {
+	log.AmbientContext
+	st      *cluster.Settings
+	stopper *stop.Stopper
+	wrapped kv.Sender
+	metrics *TxnMetrics
+	sem     *quotapool.IntPool
+}
+
+func newDeadlineAbortCleaner(tcf *TxnCoordSenderFactory) *deadlineAbortCleaner {
+	c := &deadlineAbortCleaner{
+		AmbientContext: tcf.AmbientContext,
+		st:             tcf.st,
+		stopper:        tcf.stopper,
+		wrapped:        tcf.wrapped,
+		metrics:        &tcf.metrics,
+	}
+	c.sem = quotapool.NewIntPool("deadline abort cleanup concurrency",
+		uint64(deadlineAbortCleanupConcurrency.Get(&c.st.SV)))
+	deadlineAbortCleanupConcurrency.SetOnChange(&c.st.SV, func(ctx context.Context) {
+		c.sem.UpdateCapacity(uint64(deadlineAbortCleanupConcurrency.Get(&c.st.SV)))
+	})
+	if c.stopper != nil {
+		c.stopper.AddCloser(c.sem.Closer("stopper"))
+	}
+	return c
+}
+
+// isDeadlineExceeded returns true if the error indicates that the transaction
+// ran past its deadline: either the deadline of the client's context, or the
+// commit deadline of the transaction.
+func isDeadlineExceeded(ctx context.Context, pErr *kvpb.Error) bool {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return true
+	}
+	if tErr, ok := pErr.GetDetail().(*kvpb.TransactionRetryError); ok &&
+		tErr.Reason == kvpb.RETRY_COMMIT_DEADLINE_EXCEEDED {
+		return true
+	}
+	return errors.Is(pErr.GoError(), context.DeadlineExceeded)
+}
+
+// resolveAbortedIntentsAsync resolves the intents in the given lock spans of
+// the given aborted transaction, asynchronously. The lock spans are split into
+// batches which are sent concurrently, as long as the node's concurrency limit
+// allows; the intents which aren't resolved are left to the asynchronous
+// cleanup of the transaction record's range.
+func (c *deadlineAbortCleaner) resolveAbortedIntentsAsync(
+	ctx context.Context, txn *roachpb.Transaction, lockSpans []roachpb.Span,
+) {
+	if c == nil || len(lockSpans) == 0 || !deadlineAbortCleanupEnabled.Get(&c.st.SV) {
+		return
+	}
+	// The intents can only be resolved once the transaction is known to be
+	// aborted, or else a concurrent recovery could find the transaction
+	// implicitly committed with some of its intents removed.
+	if txn == nil || txn.Status != roachpb.ABORTED {
+		return
+	}
+	c.metrics.DeadlineAbortCleanups.Inc(1)
+	log.VEventf(ctx, 2, "resolving %d lock spans of aborted txn %s", len(lockSpans), txn)
+
+	asyncCtx := c.AnnotateCtx(context.Background())
+	// If ctx is exempt from cost control, the cleanup ctx should be exempt as
+	// well.
+	if multitenant.HasTenantCostControlExemption(ctx) {
+		asyncCtx = multitenant.WithTenantCostControlExemption(asyncCtx)
+	}
+	for len(lockSpans) > 0 {
+		n := len(lockSpans)
+		if n > deadlineAbortCleanupMaxSpansPerBatch {
+			n = deadlineAbortCleanupMaxSpansPerBatch
+		}
+		ba := makeResolveAbortedIntentsBatch(txn, lockSpans[:n])
+		lockSpans = lockSpans[n:]
+
+		const taskName = "txnCommitter: resolving intents of aborted txn"
+		if err := c.stopper.RunAsyncTaskEx(asyncCtx, stop.TaskOpts{
+			TaskName:   taskName,
+			Sem:        c.sem,
+			WaitForSem: false,
+		}, func(ctx context.Context) {
+			if err := timeutil.RunWithTimeout(ctx, taskName, deadlineAbortCleanupTimeout,
+				func(ctx context.Context) error {
+					_, pErr := c.wrapped.Send(ctx, ba)
+					return pErr.GoError()
+				},
+			); err != nil {
+				log.VEventf(ctx, 1, "resolving intents of aborted txn %s failed: %v", txn, err)
+				c.metrics.DeadlineAbortCleanupsFailed.Inc(1)
+			}
+		}); err != nil {
+			// Either the node is shutting down, or the concurrency limit is
+			// reached; the remaining intents are left to the range of the
+			// transaction record.
+			log.VEventf(ctx, 2, "not resolving intents of aborted txn %s: %v", txn, err)
+			c.metrics.DeadlineAbortCleanupsFailed.Inc(1)
+			return
+		}
+	}
+}
+
+// makeResolveAbortedIntentsBatch returns a batch resolving the intents of the
+// given aborted transaction in the given lock spans.
+func makeResolveAbortedIntentsBatch(
+	txn *roachpb.Transaction, lockSpans []roachpb.Span,
+) *kvpb.BatchRequest {
+	ba := &kvpb.BatchRequest{}
+	ba.MaxSpanRequestKeys = deadlineAbortCleanupMaxKeysPerBatch
+	ba.AdmissionHeader = kv.AdmissionHeaderForLockUpdateForTxn(txn)
+	for _, sp := range lockSpans {
+		if len(sp.EndKey) == 0 {
+			ba.Add(&kvpb.ResolveIntentRequest{
+				RequestHeader:  kvpb.RequestHeaderFromSpan(sp),
+				IntentTxn:      txn.TxnMeta,
+				Status:         roachpb.ABORTED,
+				Poison:         true,
+				IgnoredSeqNums: txn.IgnoredSeqNums,
+			})
+		} else {
+			ba.Add(&kvpb.ResolveIntentRangeRequest{
+				RequestHeader:  kvpb.RequestHeaderFromSpan(sp),
+				IntentTxn:      txn.TxnMeta,
+				Status:         roachpb.ABORTED,
+				Poison:         true,
+				MinTimestamp:   txn.MinTimestamp,
+				IgnoredSeqNums: txn.IgnoredSeqNums,
+			})
+		}
+	}
+	return ba
+}
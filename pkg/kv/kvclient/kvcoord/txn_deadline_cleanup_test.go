@@ -0,0 +1,110 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsDeadlineExceeded(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	txn := makeTxnProto()
+	require.True(t, isDeadlineExceeded(ctx, kvpb.NewError(context.DeadlineExceeded)))
+	require.True(t, isDeadlineExceeded(ctx, kvpb.NewError(
+		errors.Wrap(context.DeadlineExceeded, "sending batch"))))
+	require.True(t, isDeadlineExceeded(ctx, generateTxnDeadlineExceededErr(&txn, txn.WriteTimestamp)))
+	require.False(t, isDeadlineExceeded(ctx, kvpb.NewError(context.Canceled)))
+	require.False(t, isDeadlineExceeded(ctx, kvpb.NewErrorf("boom")))
+
+	// An expired context is enough, regardless of the error.
+	expiredCtx, cancel := context.WithTimeout(ctx, 0)
+	defer cancel()
+	<-expiredCtx.Done()
+	require.True(t, isDeadlineExceeded(expiredCtx, kvpb.NewErrorf("boom")))
+}
+
+func TestDeadlineAbortCleaner(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	st := cluster.MakeTestingClusterSettings()
+
+	received := make(chan *kvpb.BatchRequest, 10)
+	tcf := NewTxnCoordSenderFactory(TxnCoordSenderFactoryConfig{
+		AmbientCtx: log.MakeTestingAmbientCtxWithNewTracer(),
+		Settings:   st,
+		Stopper:    stopper,
+	}, kv.SenderFunc(func(
+		_ context.Context, ba *kvpb.BatchRequest,
+	) (*kvpb.BatchResponse, *kvpb.Error) {
+		received <- ba
+		return ba.CreateReply(), nil
+	}))
+	c := tcf.deadlineAbortCleaner
+	metrics := tcf.Metrics()
+
+	txn := makeTxnProto()
+	lockSpans := []roachpb.Span{
+		{Key: roachpb.Key("a")},
+		{Key: roachpb.Key("b"), EndKey: roachpb.Key("d")},
+	}
+
+	// Nothing is resolved until the transaction is known to be aborted.
+	c.resolveAbortedIntentsAsync(ctx, &txn, lockSpans)
+	require.Zero(t, metrics.DeadlineAbortCleanups.Count())
+
+	txn.Status = roachpb.ABORTED
+	c.resolveAbortedIntentsAsync(ctx, &txn, lockSpans)
+	ba := <-received
+	require.Nil(t, ba.Txn)
+	require.Len(t, ba.Requests, 2)
+	ri := ba.Requests[0].GetResolveIntent()
+	require.Equal(t, roachpb.Key("a"), ri.Key)
+	require.Equal(t, roachpb.ABORTED, ri.Status)
+	require.Equal(t, txn.ID, ri.IntentTxn.ID)
+	require.True(t, ri.Poison)
+	rir := ba.Requests[1].GetResolveIntentRange()
+	require.Equal(t, lockSpans[1], rir.Span())
+	require.Equal(t, roachpb.ABORTED, rir.Status)
+	require.Equal(t, int64(1), metrics.DeadlineAbortCleanups.Count())
+
+	// The lock spans are split into bounded batches.
+	lockSpans = nil
+	for i := 0; i < deadlineAbortCleanupMaxSpansPerBatch+1; i++ {
+		lockSpans = append(lockSpans, roachpb.Span{Key: roachpb.Key([]byte{byte(i)})})
+	}
+	c.resolveAbortedIntentsAsync(ctx, &txn, lockSpans)
+	lens := []int{len((<-received).Requests), len((<-received).Requests)}
+	require.ElementsMatch(t, []int{deadlineAbortCleanupMaxSpansPerBatch, 1}, lens)
+
+	// Nothing is resolved while disabled.
+	deadlineAbortCleanupEnabled.Override(ctx, &st.SV, false)
+	c.resolveAbortedIntentsAsync(ctx, &txn, lockSpans)
+	require.Equal(t, int64(2), metrics.DeadlineAbortCleanups.Count())
+	require.Zero(t, metrics.DeadlineAbortCleanupsFailed.Count())
+}
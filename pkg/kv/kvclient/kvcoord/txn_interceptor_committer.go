@@ -136,6 +136,10 @@ type txnCommitter struct {
 	metrics    *TxnMetrics
 	mu         sync.Locker
 	disable1PC bool
+	cleaner    *deadlineAbortCleaner
+	// deadlineExceeded is set once the transaction ran past its deadline, see
+	// deadlineAbortCleaner.
+	deadlineExceeded bool
 }
 
 // SendLocked implements the lockedSender interface.
@@ -185,9 +189,14 @@ func (tc *txnCommitter) SendLocked(
 		et.InFlightWrites = nil
 	}
 
-	// If the EndTxn request is a rollback, pass it through.
+	// If the EndTxn request is a rollback, pass it through. If the transaction
+	// ran past its deadline, resolve its intents eagerly once it is aborted.
 	if !et.Commit {
-		return tc.wrapped.SendLocked(ctx, ba)
+		br, pErr := tc.wrapped.SendLocked(ctx, ba)
+		if pErr == nil && tc.deadlineExceeded {
+			tc.cleaner.resolveAbortedIntentsAsync(ctx, br.Txn, et.LockSpans)
+		}
+		return br, pErr
 	}
 
 	// Send the adjusted batch through the wrapped lockedSender. Unlocks while
@@ -369,10 +378,12 @@ func (tc *txnCommitter) canCommitInParallel(ba *kvpb.BatchRequest, et *kvpb.EndT
 		switch {
 		case kvpb.IsIntentWrite(req):
 			if kvpb.IsRange(req) {
-				// Similar to how we can't pipeline ranged writes, we also can't
-				// commit in parallel with them. The reason for this is that the
-				// status resolution process for STAGING transactions wouldn't
-				// know where to look for the corresponding intents.
+				// We can't commit in parallel with ranged writes. The reason for
+				// this is that the status resolution process for STAGING
+				// transactions wouldn't know where to look for the corresponding
+				// intents. Ranged writes pipelined in earlier batches don't have
+				// this problem: the txnPipeliner tracks the keys they wrote as
+				// in-flight writes, which are proven like point writes.
 				return false
 			}
 			// All other point writes are included in the EndTxn request's
@@ -520,6 +531,12 @@ func (tc *txnCommitter) makeTxnCommitExplicitAsync(
 	}
 }
 
+// setDeadlineExceeded records that the transaction ran past its deadline. If
+// it is later rolled back, its intents are resolved eagerly.
+func (tc *txnCommitter) setDeadlineExceeded() {
+	tc.deadlineExceeded = true
+}
+
 func makeTxnCommitExplicitLocked(
 	ctx context.Context, s lockedSender, txn *roachpb.Transaction, lockSpans []roachpb.Span,
 ) error {
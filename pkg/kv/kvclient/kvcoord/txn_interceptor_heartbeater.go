@@ -15,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvbase"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/txnwait"
@@ -190,12 +191,25 @@ func (h *txnHeartbeater) SendLocked(
 	if pErr != nil {
 		return nil, pErr
 	}
+	if hint := ba.TxnRecordKeyHint; len(hint) > 0 {
+		if keys.IsLocal(hint) {
+			return nil, kvpb.NewErrorf("transaction record key hint %s is not a global key", hint)
+		}
+		// The transaction record can't move once the transaction is anchored, so
+		// a late hint elsewhere is dropped, rather than rejected by the server.
+		if len(h.mu.txn.Key) > 0 && !hint.Equal(h.mu.txn.Key) {
+			ba.TxnRecordKeyHint = nil
+		}
+	}
 	if firstLockingIndex != -1 {
-		// Set txn key based on the key of the first transactional write if not
-		// already set. If it is already set, make sure we keep the anchor key
-		// the same.
+		// Set txn key based on the key of the first transactional write, or on
+		// the record key hint of the batch, if not already set. If it is already
+		// set, make sure we keep the anchor key the same.
 		if len(h.mu.txn.Key) == 0 {
 			anchor := ba.Requests[firstLockingIndex].GetInner().Header().Key
+			if len(ba.TxnRecordKeyHint) > 0 {
+				anchor = ba.TxnRecordKeyHint
+			}
 			h.mu.txn.Key = anchor
 			// Put the anchor also in the ba's copy of the txn, since this batch
 			// was prepared before we had an anchor.
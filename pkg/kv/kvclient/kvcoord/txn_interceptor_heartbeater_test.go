@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/txnwait"
@@ -143,6 +144,63 @@ func TestTxnHeartbeaterSetsTransactionKey(t *testing.T) {
 	require.Equal(t, keyB, roachpb.Key(txn.Key))
 }
 
+// TestTxnHeartbeaterHonorsTxnRecordKeyHint tests that the txnHeartbeater sets
+// the transaction key to the record key hint of the batch acquiring its first
+// lock, and drops the hints which don't match the key afterwards.
+func TestTxnHeartbeaterHonorsTxnRecordKeyHint(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	txn := makeTxnProto()
+	txn.Key = nil // reset
+	th, mockSender, _ := makeMockTxnHeartbeater(&txn)
+	defer th.stopper.Stop(ctx)
+
+	keyA, keyB, keyC := roachpb.Key("a"), roachpb.Key("b"), roachpb.Key("c")
+
+	// A local key can't anchor the transaction.
+	ba := &kvpb.BatchRequest{}
+	ba.Header = kvpb.Header{Txn: txn.Clone(), TxnRecordKeyHint: keys.RangeDescriptorKey(roachpb.RKey(keyA))}
+	ba.Add(&kvpb.PutRequest{RequestHeader: kvpb.RequestHeader{Key: keyA}})
+	_, pErr := th.SendLocked(ctx, ba)
+	require.NotNil(t, pErr)
+	require.Regexp(t, "not a global key", pErr.GoError())
+	require.Nil(t, txn.Key)
+
+	// The hint is set as the transaction key instead of the key of the first
+	// write.
+	ba.Header = kvpb.Header{Txn: txn.Clone(), TxnRecordKeyHint: keyB}
+	mockSender.MockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		require.Equal(t, keyB, roachpb.Key(ba.Txn.Key))
+		require.Equal(t, keyB, ba.TxnRecordKeyHint)
+
+		br := ba.CreateReply()
+		br.Txn = ba.Txn
+		return br, nil
+	})
+	br, pErr := th.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+	require.NotNil(t, br)
+	require.Equal(t, keyB, roachpb.Key(txn.Key))
+
+	// A subsequent hint doesn't move the transaction record, and is dropped.
+	ba.Requests = nil
+	ba.Header = kvpb.Header{Txn: txn.Clone(), TxnRecordKeyHint: keyC}
+	ba.Add(&kvpb.PutRequest{RequestHeader: kvpb.RequestHeader{Key: keyC}})
+	mockSender.MockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		require.Equal(t, keyB, roachpb.Key(ba.Txn.Key))
+		require.Nil(t, ba.TxnRecordKeyHint)
+
+		br := ba.CreateReply()
+		br.Txn = ba.Txn
+		return br, nil
+	})
+	br, pErr = th.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+	require.NotNil(t, br)
+	require.Equal(t, keyB, roachpb.Key(txn.Key))
+}
+
 // TestTxnHeartbeaterLoopStartedOnFirstLock tests that the txnHeartbeater
 // doesn't start its heartbeat loop until it observes the transaction issues
 // a request that will acquire locks.
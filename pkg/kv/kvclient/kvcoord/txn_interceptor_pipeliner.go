@@ -56,6 +56,18 @@ var pipelinedWritesMaxBatchSize = settings.RegisterIntSetting(
 	settings.WithName("kv.transaction.write_pipelining.max_batch_size"),
 )
 
+// pipelinedRangedWritesEnabled controls whether ranged writes are pipelined
+// along with point writes. Only DeleteRange requests which return the keys
+// they delete, in batches with a key limit, are pipelined: the deleted keys
+// are then tracked as in-flight writes and proven like point writes.
+var pipelinedRangedWritesEnabled = settings.RegisterBoolSetting(
+	settings.ApplicationLevel,
+	"kv.transaction.write_pipelining.ranged_writes.enabled",
+	"if enabled, transactional DeleteRange requests which return the keys they delete "+
+		"are pipelined through Raft consensus along with point writes",
+	true,
+)
+
 // TrackedWritesMaxSize is a byte threshold for the tracking of writes performed
 // a single transaction. This includes the tracking of lock spans and of
 // in-flight writes, both stored in the txnPipeliner.
@@ -461,9 +473,10 @@ func (tp *txnPipeliner) canUseAsyncConsensus(ctx context.Context, ba *kvpb.Batch
 
 		// Determine whether the current request prevents us from performing async
 		// consensus on the batch.
-		if !kvpb.IsIntentWrite(req) || kvpb.IsRange(req) {
+		if !kvpb.IsIntentWrite(req) || (kvpb.IsRange(req) && !tp.canPipelineRangedWrite(ba, req)) {
 			// Only allow batches consisting of solely transactional point
-			// writes to perform consensus asynchronously.
+			// writes, and ranged writes which return the keys they wrote, to
+			// perform consensus asynchronously.
 			// TODO(nvanbenschoten): We could allow batches with reads and point
 			// writes to perform async consensus, but this would be a bit
 			// tricky. Any read would need to chain on to any write that came
@@ -482,7 +495,14 @@ func (tp *txnPipeliner) canUseAsyncConsensus(ctx context.Context, ba *kvpb.Batch
 		// in that some writes may be proven by this batch and removed
 		// from the in-flight write set. The real accounting in
 		// inFlightWriteSet.{insert,remove} gets this right.
-		addedIFBytes += keySize(req.Header().Key)
+		if kvpb.IsRange(req) {
+			// We don't know how many keys a ranged write will write, so assume
+			// that it writes as many keys as the batch allows, of the size of its
+			// start key.
+			addedIFBytes += ba.MaxSpanRequestKeys * keySize(req.Header().Key)
+		} else {
+			addedIFBytes += keySize(req.Header().Key)
+		}
 		if (tp.ifWrites.byteSize() + addedIFBytes + tp.lockFootprint.bytes) > maxTrackingBytes {
 			log.VEventf(ctx, 2, "cannot perform async consensus because memory budget exceeded")
 			return false
@@ -491,6 +511,23 @@ func (tp *txnPipeliner) canUseAsyncConsensus(ctx context.Context, ba *kvpb.Batch
 	return true
 }
 
+// canPipelineRangedWrite returns whether the ranged write can be performed
+// using async consensus. The txnPipeliner must know every key that a write
+// performed asynchronously wrote an intent on to be able to prove it, so only
+// DeleteRange requests which return the keys they delete are eligible. The
+// batch must also bound the number of keys it touches, for the deleted keys to
+// be accounted for in the lock tracking budget up front.
+func (tp *txnPipeliner) canPipelineRangedWrite(ba *kvpb.BatchRequest, req kvpb.Request) bool {
+	if !pipelinedRangedWritesEnabled.Get(&tp.st.SV) {
+		return false
+	}
+	dr, ok := req.(*kvpb.DeleteRangeRequest)
+	if !ok || !dr.ReturnKeys || dr.Inline || dr.UseRangeTombstone {
+		return false
+	}
+	return ba.MaxSpanRequestKeys > 0
+}
+
 // chainToInFlightWrites ensures that we "chain" on to any in-flight writes that
 // overlap the keys we're trying to read/write. We do this by prepending
 // QueryIntent requests with the ErrorIfMissing option before each request that
@@ -730,11 +767,16 @@ func (tp *txnPipeliner) updateLockTrackingInner(
 				// Record any writes that were performed asynchronously. We'll
 				// need to prove that these succeeded sometime before we commit.
 				header := req.Header()
-				tp.ifWrites.insert(header.Key, header.Sequence)
-				// The request is not expected to be a ranged one, as we're only
-				// tracking one key in the ifWrites. Ranged requests do not admit
-				// ba.AsyncConsensus.
-				if kvpb.IsRange(req) {
+				if !kvpb.IsRange(req) {
+					tp.ifWrites.insert(header.Key, header.Sequence)
+				} else if drResp, ok := resp.(*kvpb.DeleteRangeResponse); ok {
+					// Ranged writes only admit ba.AsyncConsensus if they return
+					// the keys they wrote (see canPipelineRangedWrite). Each of
+					// them is an in-flight write.
+					for _, key := range drResp.Keys {
+						tp.ifWrites.insert(key, header.Sequence)
+					}
+				} else {
 					log.Fatalf(ctx, "unexpected range request with AsyncConsensus: %s", req)
 				}
 			} else {
@@ -523,8 +523,9 @@ func TestTxnPipelinerReads(t *testing.T) {
 }
 
 // TestTxnPipelinerRangedWrites tests that txnPipeliner will never perform
-// ranged write operations using async consensus. It also tests that ranged
-// writes will correctly chain on to existing in-flight writes.
+// ranged write operations which don't return the keys they wrote using async
+// consensus. It also tests that ranged writes will correctly chain on to
+// existing in-flight writes.
 func TestTxnPipelinerRangedWrites(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -607,6 +608,105 @@ func TestTxnPipelinerRangedWrites(t *testing.T) {
 	require.Equal(t, 2, tp.ifWrites.len())
 }
 
+// TestTxnPipelinerPipelinedRangedWrites tests that txnPipeliner performs
+// DeleteRange requests which return the keys they delete using async consensus,
+// tracks the deleted keys as in-flight writes, and proves them on commit.
+func TestTxnPipelinerPipelinedRangedWrites(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	tp, mockSender := makeMockTxnPipeliner(nil /* iter */)
+
+	txn := makeTxnProto()
+	keyA, keyB, keyC, keyD := roachpb.Key("a"), roachpb.Key("b"), roachpb.Key("c"), roachpb.Key("d")
+
+	delRange := func(returnKeys bool, maxKeys int64) *kvpb.BatchRequest {
+		ba := &kvpb.BatchRequest{}
+		ba.Header = kvpb.Header{Txn: &txn, MaxSpanRequestKeys: maxKeys}
+		delRngArgs := kvpb.DeleteRangeRequest{
+			RequestHeader: kvpb.RequestHeader{Key: keyA, EndKey: keyD},
+			ReturnKeys:    returnKeys,
+		}
+		delRngArgs.Sequence = 1
+		ba.Add(&delRngArgs)
+		return ba
+	}
+
+	// DeleteRange requests which don't return their keys, or in batches without
+	// a key limit, don't use async consensus.
+	for _, ba := range []*kvpb.BatchRequest{
+		delRange(false /* returnKeys */, 10 /* maxKeys */),
+		delRange(true /* returnKeys */, 0 /* maxKeys */),
+	} {
+		mockSender.MockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+			require.False(t, ba.AsyncConsensus)
+			br := ba.CreateReply()
+			br.Txn = ba.Txn
+			return br, nil
+		})
+		br, pErr := tp.SendLocked(ctx, ba)
+		require.Nil(t, pErr)
+		require.NotNil(t, br)
+		require.Equal(t, 0, tp.ifWrites.len())
+	}
+
+	// Neither do they when ranged write pipelining is disabled.
+	pipelinedRangedWritesEnabled.Override(ctx, &tp.st.SV, false)
+	br, pErr := tp.SendLocked(ctx, delRange(true /* returnKeys */, 10 /* maxKeys */))
+	require.Nil(t, pErr)
+	require.NotNil(t, br)
+	require.Equal(t, 0, tp.ifWrites.len())
+	pipelinedRangedWritesEnabled.Override(ctx, &tp.st.SV, true)
+
+	// Otherwise, the deleted keys are tracked as in-flight writes.
+	mockSender.MockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		require.Len(t, ba.Requests, 1)
+		require.True(t, ba.AsyncConsensus)
+
+		br := ba.CreateReply()
+		br.Txn = ba.Txn
+		br.Responses[0].GetDeleteRange().Keys = []roachpb.Key{keyB, keyC}
+		return br, nil
+	})
+	br, pErr = tp.SendLocked(ctx, delRange(true /* returnKeys */, 10 /* maxKeys */))
+	require.Nil(t, pErr)
+	require.NotNil(t, br)
+	require.Equal(t, 2, tp.ifWrites.len())
+
+	// The deleted keys are proven in parallel with the commit.
+	ba := &kvpb.BatchRequest{}
+	ba.Header = kvpb.Header{Txn: &txn}
+	ba.Add(&kvpb.EndTxnRequest{Commit: true})
+
+	mockSender.MockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		require.Len(t, ba.Requests, 3)
+		require.False(t, ba.AsyncConsensus)
+		require.IsType(t, &kvpb.QueryIntentRequest{}, ba.Requests[0].GetInner())
+		require.IsType(t, &kvpb.QueryIntentRequest{}, ba.Requests[1].GetInner())
+		require.IsType(t, &kvpb.EndTxnRequest{}, ba.Requests[2].GetInner())
+
+		require.Equal(t, keyB, ba.Requests[0].GetQueryIntent().Key)
+		require.Equal(t, keyC, ba.Requests[1].GetQueryIntent().Key)
+		expInFlight := []roachpb.SequencedWrite{
+			{Key: keyB, Sequence: 1},
+			{Key: keyC, Sequence: 1},
+		}
+		require.Equal(t, expInFlight, ba.Requests[2].GetEndTxn().InFlightWrites)
+		require.Equal(t, []roachpb.Span{{Key: keyA, EndKey: keyD}}, ba.Requests[2].GetEndTxn().LockSpans)
+
+		br := ba.CreateReply()
+		br.Txn = ba.Txn
+		br.Txn.Status = roachpb.COMMITTED
+		br.Responses[0].GetQueryIntent().FoundIntent = true
+		br.Responses[1].GetQueryIntent().FoundIntent = true
+		return br, nil
+	})
+	br, pErr = tp.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+	require.NotNil(t, br)
+	require.Equal(t, 0, tp.ifWrites.len())
+}
+
 // TestTxnPipelinerNonTransactionalRequests tests that non-transaction requests
 // cause the txnPipeliner to stall its entire pipeline.
 func TestTxnPipelinerNonTransactionalRequests(t *testing.T) {
@@ -0,0 +1,365 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"context"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// bufferedWritesEnabled is the kv.transaction.write_buffering.enabled cluster
+// setting.
+var bufferedWritesEnabled = settings.RegisterBoolSetting(
+	settings.ApplicationLevel,
+	"kv.transaction.write_buffering.enabled",
+	"if enabled, blind transactional writes are buffered on the client until they are "+
+		"read or the transaction commits, and then sent along with the read or the commit",
+	false,
+)
+
+// bufferedWritesMaxBufferSize is the
+// kv.transaction.write_buffering.max_buffer_size cluster setting.
+var bufferedWritesMaxBufferSize = settings.RegisterByteSizeSetting(
+	settings.ApplicationLevel,
+	"kv.transaction.write_buffering.max_buffer_size",
+	"maximum number of bytes of writes buffered by a transaction; a transaction exceeding "+
+		"it flushes its buffer and pipelines its writes for the rest of its lifetime",
+	1<<20, /* 1 MiB */
+	settings.NonNegativeInt,
+)
+
+// txnWriteBuffer is a txnInterceptor that buffers the blind writes of a
+// transaction on the client, instead of sending them to the leaseholders of
+// their ranges as they are issued. The buffered writes are flushed, i.e. sent
+// along with the batch that triggered the flush, when:
+//
+//  1. a request overlaps with a buffered write. The request must observe the
+//     write, which the transaction model ensures through sequence numbers
+//     once both are evaluated. Sending the buffered writes in the same batch
+//     as the request, and before it, gives this guarantee.
+//
+//  2. the transaction commits. Sending the buffered writes along with the
+//     EndTxn request lets the txnCommitter attempt a parallel commit, or even
+//     a one-phase commit if they all land in a single range, instead of first
+//     waiting for the writes to be pipelined.
+//
+//  3. the buffer exceeds kv.transaction.write_buffering.max_buffer_size. The
+//     transaction then falls back to pipelining its writes for the rest of its
+//     lifetime.
+//
+// A write-heavy transaction thus only pays for a single round trip at commit
+// time, instead of one round trip per batch of writes.
+//
+// Only blind Put requests are buffered, as their responses don't depend on
+// the state of the database. Other writes, like ConditionalPuts, must be
+// evaluated to produce their response, and are sent right away (along with
+// the buffered writes they overlap with, if any).
+//
+// Buffered writes keep the sequence number assigned by the
+// txnSeqNumAllocator, which sits above the txnWriteBuffer in the interceptor
+// stack. Rolling back to a savepoint drops the buffered writes performed
+// after it, and an epoch bump drops all of them. Writes are only removed from
+// the buffer once a batch carrying them succeeds: if it fails, they are sent
+// again by the next flush, which is harmless since writes at a sequence
+// number are idempotent.
+//
+// The txnWriteBuffer is not part of the interceptor stack of leaf
+// transactions, which never perform writes. However, the reads of the leaf
+// transactions must observe the writes of their root, so the buffer is
+// flushed before the input state of a leaf is created, see
+// TxnCoordSender.GetLeafTxnInputState.
+type txnWriteBuffer struct {
+	st      *cluster.Settings
+	metrics *TxnMetrics
+	wrapped lockedSender
+
+	// buffer holds the buffered writes, in the order in which they were
+	// issued.
+	buffer []*kvpb.PutRequest
+	// bufferKeys holds the keys of the buffered writes, in key order, to
+	// determine the requests that overlap with them.
+	bufferKeys []roachpb.Key
+	// bufferSize is the size of the keys and values of the buffered writes.
+	bufferSize int64
+	// overBudget is set once the buffer exceeded its budget, after which
+	// writes are no longer buffered.
+	overBudget bool
+}
+
+// SendLocked is part of the txnInterceptor interface.
+func (twb *txnWriteBuffer) SendLocked(
+	ctx context.Context, ba *kvpb.BatchRequest,
+) (*kvpb.BatchResponse, *kvpb.Error) {
+	if et, ok := ba.GetArg(kvpb.EndTxn); ok && !et.(*kvpb.EndTxnRequest).Commit {
+		// The buffered writes don't need to be written for a rollback.
+		twb.clearBuffer()
+		return twb.wrapped.SendLocked(ctx, ba)
+	}
+
+	if twb.canBuffer(ba) {
+		size := bufferedWritesSize(ba)
+		if twb.bufferSize+size <= bufferedWritesMaxBufferSize.Get(&twb.st.SV) {
+			return twb.bufferLocked(ba, size), nil
+		}
+		// The buffer is over budget: flush it along with this batch, and
+		// pipeline the writes for the rest of the transaction.
+		log.VEventf(ctx, 2, "write buffer of %d bytes over budget, flushing", twb.bufferSize+size)
+		twb.overBudget = true
+		twb.metrics.TxnsWithWriteBufferOverBudget.Inc(1)
+		return twb.flushLocked(ctx, ba)
+	}
+
+	if len(twb.buffer) == 0 || !twb.mustFlush(ba) {
+		return twb.wrapped.SendLocked(ctx, ba)
+	}
+	if !canCarryBufferedWrites(ba) {
+		// Flush the buffered writes in a batch of their own, before the batch
+		// that needs them.
+		flush := &kvpb.BatchRequest{}
+		flush.Txn = ba.Txn
+		flush.AdmissionHeader = ba.AdmissionHeader
+		flushBR, pErr := twb.flushOnlyLocked(ctx, flush)
+		if pErr != nil {
+			return nil, pErr
+		}
+		ba = ba.ShallowCopy()
+		ba.UpdateTxn(flushBR.Txn)
+		return twb.wrapped.SendLocked(ctx, ba)
+	}
+	return twb.flushLocked(ctx, ba)
+}
+
+// canBuffer returns whether all the requests of the batch can be buffered.
+func (twb *txnWriteBuffer) canBuffer(ba *kvpb.BatchRequest) bool {
+	if twb.overBudget || !bufferedWritesEnabled.Get(&twb.st.SV) {
+		return false
+	}
+	if ba.MaxSpanRequestKeys != 0 || ba.TargetBytes != 0 {
+		return false
+	}
+	for _, ru := range ba.Requests {
+		put, ok := ru.GetInner().(*kvpb.PutRequest)
+		if !ok || put.Inline {
+			return false
+		}
+	}
+	return true
+}
+
+// mustFlush returns whether the batch must be preceded by the buffered writes,
+// because it commits the transaction or overlaps with one of them.
+func (twb *txnWriteBuffer) mustFlush(ba *kvpb.BatchRequest) bool {
+	if _, ok := ba.GetArg(kvpb.EndTxn); ok {
+		return true
+	}
+	for _, ru := range ba.Requests {
+		if twb.overlapsBuffer(ru.GetInner().Header().Span()) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapsBuffer returns whether the span overlaps with a buffered write.
+func (twb *txnWriteBuffer) overlapsBuffer(span roachpb.Span) bool {
+	i := sort.Search(len(twb.bufferKeys), func(i int) bool {
+		return twb.bufferKeys[i].Compare(span.Key) >= 0
+	})
+	if i == len(twb.bufferKeys) {
+		return false
+	}
+	if len(span.EndKey) == 0 {
+		return twb.bufferKeys[i].Equal(span.Key)
+	}
+	return twb.bufferKeys[i].Compare(span.EndKey) < 0
+}
+
+// canCarryBufferedWrites returns whether the buffered writes can be added to
+// the batch. Batches with limits can only contain a restricted set of
+// requests, and reads skipping locked keys can't be combined with writes.
+func canCarryBufferedWrites(ba *kvpb.BatchRequest) bool {
+	if _, ok := ba.GetArg(kvpb.EndTxn); ok {
+		return true
+	}
+	return ba.MaxSpanRequestKeys == 0 && ba.TargetBytes == 0 &&
+		ba.WaitPolicy != lock.WaitPolicy_SkipLocked
+}
+
+// bufferedWritesSize returns the size of the writes of the batch, as
+// accounted by the buffer.
+func bufferedWritesSize(ba *kvpb.BatchRequest) int64 {
+	var size int64
+	for _, ru := range ba.Requests {
+		put := ru.GetPut()
+		size += int64(len(put.Key) + len(put.Value.RawBytes))
+	}
+	return size
+}
+
+// bufferLocked buffers the writes of the batch and returns its response.
+func (twb *txnWriteBuffer) bufferLocked(ba *kvpb.BatchRequest, size int64) *kvpb.BatchResponse {
+	for _, ru := range ba.Requests {
+		// The client may reuse the memory of its requests once they return, so
+		// the buffered writes are copied.
+		put := *ru.GetPut()
+		put.Key = append(roachpb.Key(nil), put.Key...)
+		put.Value.RawBytes = append([]byte(nil), put.Value.RawBytes...)
+		twb.buffer = append(twb.buffer, &put)
+		i := sort.Search(len(twb.bufferKeys), func(i int) bool {
+			return twb.bufferKeys[i].Compare(put.Key) >= 0
+		})
+		if i == len(twb.bufferKeys) || !twb.bufferKeys[i].Equal(put.Key) {
+			twb.bufferKeys = append(twb.bufferKeys, nil)
+			copy(twb.bufferKeys[i+1:], twb.bufferKeys[i:])
+			twb.bufferKeys[i] = put.Key
+		}
+	}
+	twb.bufferSize += size
+	twb.metrics.WritesBuffered.Inc(int64(len(ba.Requests)))
+
+	br := ba.CreateReply()
+	br.Txn = ba.Txn
+	return br
+}
+
+// flushLocked sends the buffered writes along with the batch, ahead of its
+// requests, and returns the response to the batch.
+func (twb *txnWriteBuffer) flushLocked(
+	ctx context.Context, ba *kvpb.BatchRequest,
+) (*kvpb.BatchResponse, *kvpb.Error) {
+	n := len(twb.buffer)
+	if n == 0 {
+		return twb.wrapped.SendLocked(ctx, ba)
+	}
+	log.VEventf(ctx, 2, "flushing %d buffered writes", n)
+	reqs := make([]kvpb.RequestUnion, 0, n+len(ba.Requests))
+	for _, put := range twb.buffer {
+		var ru kvpb.RequestUnion
+		ru.MustSetInner(put)
+		reqs = append(reqs, ru)
+	}
+	ba = ba.ShallowCopy()
+	ba.Requests = append(reqs, ba.Requests...)
+
+	br, pErr := twb.wrapped.SendLocked(ctx, ba)
+	if pErr != nil {
+		if pErr.Index != nil {
+			if pErr.Index.Index < int32(n) {
+				// The error is attributed to a write the client considers done.
+				pErr.Index = nil
+			} else {
+				pErr.Index.Index -= int32(n)
+			}
+		}
+		return nil, pErr
+	}
+	twb.clearBuffer()
+	br.Responses = br.Responses[n:]
+	return br, nil
+}
+
+// flushOnlyLocked sends the buffered writes in a batch of their own. The
+// given batch provides the header of this batch, and must not contain any
+// requests.
+func (twb *txnWriteBuffer) flushOnlyLocked(
+	ctx context.Context, ba *kvpb.BatchRequest,
+) (*kvpb.BatchResponse, *kvpb.Error) {
+	ba.Requests = make([]kvpb.RequestUnion, len(twb.buffer))
+	for i, put := range twb.buffer {
+		ba.Requests[i].MustSetInner(put)
+	}
+	log.VEventf(ctx, 2, "flushing %d buffered writes", len(twb.buffer))
+	br, pErr := twb.wrapped.SendLocked(ctx, ba)
+	if pErr != nil {
+		// The error is attributed to a write the client considers done.
+		pErr.Index = nil
+		return nil, pErr
+	}
+	twb.clearBuffer()
+	return br, nil
+}
+
+// hasBufferedWrites returns whether any writes are buffered.
+func (twb *txnWriteBuffer) hasBufferedWrites() bool {
+	return len(twb.buffer) > 0
+}
+
+func (twb *txnWriteBuffer) clearBuffer() {
+	twb.buffer = nil
+	twb.bufferKeys = nil
+	twb.bufferSize = 0
+}
+
+// setWrapped is part of the txnInterceptor interface.
+func (twb *txnWriteBuffer) setWrapped(wrapped lockedSender) { twb.wrapped = wrapped }
+
+// populateLeafInputState is part of the txnInterceptor interface.
+func (twb *txnWriteBuffer) populateLeafInputState(*roachpb.LeafTxnInputState) {}
+
+// populateLeafFinalState is part of the txnInterceptor interface.
+func (twb *txnWriteBuffer) populateLeafFinalState(*roachpb.LeafTxnFinalState) {}
+
+// importLeafFinalState is part of the txnInterceptor interface.
+func (twb *txnWriteBuffer) importLeafFinalState(context.Context, *roachpb.LeafTxnFinalState) error {
+	return nil
+}
+
+// epochBumpedLocked is part of the txnInterceptor interface.
+func (twb *txnWriteBuffer) epochBumpedLocked() {
+	// The writes of the previous epoch are discarded.
+	twb.clearBuffer()
+}
+
+// createSavepointLocked is part of the txnInterceptor interface.
+func (twb *txnWriteBuffer) createSavepointLocked(context.Context, *savepoint) {}
+
+// rollbackToSavepointLocked is part of the txnInterceptor interface.
+func (twb *txnWriteBuffer) rollbackToSavepointLocked(ctx context.Context, s savepoint) {
+	// Drop the writes performed after the savepoint. The writes are ordered by
+	// sequence number.
+	i := sort.Search(len(twb.buffer), func(i int) bool {
+		return twb.buffer[i].Sequence > s.seqNum
+	})
+	if i == len(twb.buffer) {
+		return
+	}
+	for _, put := range twb.buffer[i:] {
+		twb.bufferSize -= int64(len(put.Key) + len(put.Value.RawBytes))
+	}
+	twb.buffer = twb.buffer[:i:i]
+	twb.bufferKeys = twb.bufferKeys[:0]
+	for _, put := range twb.buffer {
+		twb.bufferKeys = append(twb.bufferKeys, put.Key)
+	}
+	sort.Slice(twb.bufferKeys, func(i, j int) bool {
+		return twb.bufferKeys[i].Compare(twb.bufferKeys[j]) < 0
+	})
+	// Deduplicate the keys written more than once.
+	keys := twb.bufferKeys[:0]
+	for i, k := range twb.bufferKeys {
+		if i == 0 || !k.Equal(keys[len(keys)-1]) {
+			keys = append(keys, k)
+		}
+	}
+	twb.bufferKeys = keys
+}
+
+// closeLocked is part of the txnInterceptor interface.
+func (twb *txnWriteBuffer) closeLocked() {
+	twb.clearBuffer()
+}
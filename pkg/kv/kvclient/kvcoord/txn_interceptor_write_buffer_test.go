@@ -0,0 +1,323 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func makeMockTxnWriteBuffer() (txnWriteBuffer, *mockLockedSender) {
+	mockSender := &mockLockedSender{}
+	metrics := MakeTxnMetrics(time.Hour)
+	st := cluster.MakeTestingClusterSettings()
+	bufferedWritesEnabled.Override(context.Background(), &st.SV, true)
+	return txnWriteBuffer{
+		st:      st,
+		metrics: &metrics,
+		wrapped: mockSender,
+	}, mockSender
+}
+
+func putArgs(key roachpb.Key, value string, seq enginepb.TxnSeq) *kvpb.PutRequest {
+	return &kvpb.PutRequest{
+		RequestHeader: kvpb.RequestHeader{Key: key, Sequence: seq},
+		Value:         roachpb.MakeValueFromString(value),
+	}
+}
+
+func noSend(t *testing.T) func(*kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+	return func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		t.Fatalf("unexpected batch %s", ba)
+		return nil, nil
+	}
+}
+
+// TestTxnWriteBufferFlushesOnOverlap tests that blind writes are buffered
+// until a request overlaps with them.
+func TestTxnWriteBufferFlushesOnOverlap(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	twb, mockSender := makeMockTxnWriteBuffer()
+
+	txn := makeTxnProto()
+	keyA, keyB, keyC, keyD := roachpb.Key("a"), roachpb.Key("b"), roachpb.Key("c"), roachpb.Key("d")
+
+	// Blind writes are buffered.
+	ba := &kvpb.BatchRequest{}
+	ba.Header = kvpb.Header{Txn: &txn}
+	ba.Add(putArgs(keyA, "a", 1), putArgs(keyC, "c", 2))
+	mockSender.MockSend(noSend(t))
+	br, pErr := twb.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+	require.Len(t, br.Responses, 2)
+	require.IsType(t, &kvpb.PutResponse{}, br.Responses[0].GetInner())
+	require.True(t, twb.hasBufferedWrites())
+	require.Equal(t, int64(2), twb.metrics.WritesBuffered.Count())
+
+	// Requests not overlapping with the buffered writes are sent on their own.
+	ba.Requests = nil
+	ba.Add(&kvpb.GetRequest{RequestHeader: kvpb.RequestHeader{Key: keyB}})
+	ba.Add(&kvpb.ConditionalPutRequest{RequestHeader: kvpb.RequestHeader{Key: keyD, Sequence: 3}})
+	mockSender.MockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		require.Len(t, ba.Requests, 2)
+		require.IsType(t, &kvpb.GetRequest{}, ba.Requests[0].GetInner())
+		require.IsType(t, &kvpb.ConditionalPutRequest{}, ba.Requests[1].GetInner())
+		br := ba.CreateReply()
+		br.Txn = ba.Txn
+		return br, nil
+	})
+	br, pErr = twb.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+	require.Len(t, br.Responses, 2)
+	require.True(t, twb.hasBufferedWrites())
+
+	// A request overlapping with a buffered write is preceded by all of them.
+	ba.Requests = nil
+	ba.Add(&kvpb.ScanRequest{RequestHeader: kvpb.RequestHeader{Key: keyB, EndKey: keyD}})
+	mockSender.MockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		require.Len(t, ba.Requests, 3)
+		require.Equal(t, keyA, ba.Requests[0].GetPut().Key)
+		require.Equal(t, enginepb.TxnSeq(1), ba.Requests[0].GetPut().Sequence)
+		require.Equal(t, keyC, ba.Requests[1].GetPut().Key)
+		require.IsType(t, &kvpb.ScanRequest{}, ba.Requests[2].GetInner())
+		br := ba.CreateReply()
+		br.Txn = ba.Txn
+		return br, nil
+	})
+	br, pErr = twb.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+	require.Len(t, br.Responses, 1)
+	require.IsType(t, &kvpb.ScanResponse{}, br.Responses[0].GetInner())
+	require.False(t, twb.hasBufferedWrites())
+
+	// Once flushed, the writes are no longer sent.
+	mockSender.MockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		require.Len(t, ba.Requests, 1)
+		br := ba.CreateReply()
+		br.Txn = ba.Txn
+		return br, nil
+	})
+	_, pErr = twb.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+}
+
+// TestTxnWriteBufferFlushesOnCommit tests that the buffered writes are sent
+// along with the commit of the transaction, and dropped by its rollback.
+func TestTxnWriteBufferFlushesOnCommit(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	for _, commit := range []bool{false, true} {
+		twb, mockSender := makeMockTxnWriteBuffer()
+		txn := makeTxnProto()
+		ba := &kvpb.BatchRequest{}
+		ba.Header = kvpb.Header{Txn: &txn}
+		ba.Add(putArgs(roachpb.Key("a"), "a", 1))
+		mockSender.MockSend(noSend(t))
+		_, pErr := twb.SendLocked(ctx, ba)
+		require.Nil(t, pErr)
+
+		ba.Requests = nil
+		ba.Add(&kvpb.EndTxnRequest{RequestHeader: kvpb.RequestHeader{Key: txn.Key, Sequence: 2}, Commit: commit})
+		mockSender.MockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+			if commit {
+				require.Len(t, ba.Requests, 2)
+				require.IsType(t, &kvpb.PutRequest{}, ba.Requests[0].GetInner())
+				require.IsType(t, &kvpb.EndTxnRequest{}, ba.Requests[1].GetInner())
+			} else {
+				require.Len(t, ba.Requests, 1)
+			}
+			br := ba.CreateReply()
+			br.Txn = ba.Txn
+			return br, nil
+		})
+		br, pErr := twb.SendLocked(ctx, ba)
+		require.Nil(t, pErr)
+		require.Len(t, br.Responses, 1)
+		require.IsType(t, &kvpb.EndTxnResponse{}, br.Responses[0].GetInner())
+		require.False(t, twb.hasBufferedWrites())
+	}
+}
+
+// TestTxnWriteBufferLimits tests that the buffered writes are flushed in a
+// batch of their own before a batch with limits.
+func TestTxnWriteBufferLimits(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	twb, mockSender := makeMockTxnWriteBuffer()
+
+	txn := makeTxnProto()
+	ba := &kvpb.BatchRequest{}
+	ba.Header = kvpb.Header{Txn: &txn}
+	ba.Add(putArgs(roachpb.Key("a"), "a", 1))
+	mockSender.MockSend(noSend(t))
+	_, pErr := twb.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+
+	ba.Requests = nil
+	ba.MaxSpanRequestKeys = 10
+	ba.Add(&kvpb.ScanRequest{RequestHeader: kvpb.RequestHeader{Key: roachpb.Key("a"), EndKey: roachpb.Key("c")}})
+	mockSender.ChainMockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		require.Len(t, ba.Requests, 1)
+		require.IsType(t, &kvpb.PutRequest{}, ba.Requests[0].GetInner())
+		require.Zero(t, ba.MaxSpanRequestKeys)
+		br := ba.CreateReply()
+		br.Txn = ba.Txn.Clone()
+		br.Txn.WriteTimestamp = br.Txn.WriteTimestamp.Add(1, 0)
+		return br, nil
+	}, func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		require.Len(t, ba.Requests, 1)
+		require.IsType(t, &kvpb.ScanRequest{}, ba.Requests[0].GetInner())
+		require.Equal(t, int64(10), ba.MaxSpanRequestKeys)
+		// The batch carries the transaction updated by the flush.
+		require.Equal(t, txn.WriteTimestamp.Add(1, 0), ba.Txn.WriteTimestamp)
+		br := ba.CreateReply()
+		br.Txn = ba.Txn
+		return br, nil
+	})
+	br, pErr := twb.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+	require.Len(t, br.Responses, 1)
+	require.False(t, twb.hasBufferedWrites())
+}
+
+// TestTxnWriteBufferOverBudget tests that a transaction exceeding its write
+// buffer budget flushes its buffer and stops buffering writes.
+func TestTxnWriteBufferOverBudget(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	twb, mockSender := makeMockTxnWriteBuffer()
+	bufferedWritesMaxBufferSize.Override(ctx, &twb.st.SV, 30)
+
+	txn := makeTxnProto()
+	ba := &kvpb.BatchRequest{}
+	ba.Header = kvpb.Header{Txn: &txn}
+	ba.Add(putArgs(roachpb.Key("a"), "a", 1))
+	mockSender.MockSend(noSend(t))
+	_, pErr := twb.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+
+	ba.Requests = nil
+	ba.Add(putArgs(roachpb.Key("b"), "a value over the budget", 2))
+	mockSender.MockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		require.Len(t, ba.Requests, 2)
+		br := ba.CreateReply()
+		br.Txn = ba.Txn
+		return br, nil
+	})
+	br, pErr := twb.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+	require.Len(t, br.Responses, 1)
+	require.Equal(t, int64(1), twb.metrics.TxnsWithWriteBufferOverBudget.Count())
+
+	// The following writes are no longer buffered.
+	ba.Requests = nil
+	ba.Add(putArgs(roachpb.Key("c"), "c", 3))
+	mockSender.MockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		require.Len(t, ba.Requests, 1)
+		br := ba.CreateReply()
+		br.Txn = ba.Txn
+		return br, nil
+	})
+	_, pErr = twb.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+	require.False(t, twb.hasBufferedWrites())
+}
+
+// TestTxnWriteBufferError tests that the buffered writes are kept when the
+// batch carrying them fails, and that the index of the error is adjusted.
+func TestTxnWriteBufferError(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	twb, mockSender := makeMockTxnWriteBuffer()
+
+	txn := makeTxnProto()
+	ba := &kvpb.BatchRequest{}
+	ba.Header = kvpb.Header{Txn: &txn}
+	ba.Add(putArgs(roachpb.Key("a"), "a", 1))
+	mockSender.MockSend(noSend(t))
+	_, pErr := twb.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+
+	ba.Requests = nil
+	ba.Add(&kvpb.ConditionalPutRequest{RequestHeader: kvpb.RequestHeader{Key: roachpb.Key("a"), Sequence: 2}})
+	for _, tc := range []struct {
+		index    int32
+		expIndex *kvpb.ErrPosition
+	}{
+		{index: 1, expIndex: &kvpb.ErrPosition{Index: 0}},
+		{index: 0, expIndex: nil},
+	} {
+		mockSender.MockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+			require.Len(t, ba.Requests, 2)
+			pErr := kvpb.NewErrorf("boom")
+			pErr.SetErrorIndex(tc.index)
+			return nil, pErr
+		})
+		_, pErr = twb.SendLocked(ctx, ba)
+		require.NotNil(t, pErr)
+		require.Equal(t, tc.expIndex, pErr.Index)
+		require.True(t, twb.hasBufferedWrites())
+	}
+}
+
+// TestTxnWriteBufferRollbackToSavepoint tests that rolling back to a savepoint
+// drops the writes buffered after it.
+func TestTxnWriteBufferRollbackToSavepoint(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	twb, mockSender := makeMockTxnWriteBuffer()
+
+	txn := makeTxnProto()
+	ba := &kvpb.BatchRequest{}
+	ba.Header = kvpb.Header{Txn: &txn}
+	ba.Add(putArgs(roachpb.Key("a"), "a", 1), putArgs(roachpb.Key("b"), "b", 2))
+	mockSender.MockSend(noSend(t))
+	_, pErr := twb.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+
+	twb.rollbackToSavepointLocked(ctx, savepoint{seqNum: 1})
+	require.Len(t, twb.buffer, 1)
+	require.Equal(t, []roachpb.Key{roachpb.Key("a")}, twb.bufferKeys)
+
+	// The dropped write no longer triggers a flush.
+	ba.Requests = nil
+	ba.Add(&kvpb.GetRequest{RequestHeader: kvpb.RequestHeader{Key: roachpb.Key("b")}})
+	mockSender.MockSend(func(ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		require.Len(t, ba.Requests, 1)
+		br := ba.CreateReply()
+		br.Txn = ba.Txn
+		return br, nil
+	})
+	_, pErr = twb.SendLocked(ctx, ba)
+	require.Nil(t, pErr)
+	require.True(t, twb.hasBufferedWrites())
+
+	// An epoch bump drops all the buffered writes.
+	twb.epochBumpedLocked()
+	require.False(t, twb.hasBufferedWrites())
+}
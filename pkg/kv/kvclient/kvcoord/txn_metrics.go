@@ -40,6 +40,9 @@ type TxnMetrics struct {
 	TxnsWithCondensedIntentsGauge *metric.Gauge
 	TxnsRejectedByLockSpanBudget  *metric.Counter
 
+	WritesBuffered                *metric.Counter
+	TxnsWithWriteBufferOverBudget *metric.Counter
+
 	// Restarts is the number of times we had to restart the transaction.
 	Restarts metric.IHistogram
 
@@ -57,6 +60,10 @@ type TxnMetrics struct {
 	// End transaction failure counters.
 	RollbacksFailed      *metric.Counter
 	AsyncRollbacksFailed *metric.Counter
+
+	// Deadline abort cleanup counters.
+	DeadlineAbortCleanups       *metric.Counter
+	DeadlineAbortCleanupsFailed *metric.Counter
 }
 
 var (
@@ -179,6 +186,20 @@ var (
 		Measurement: "KV Transactions",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaWritesBuffered = metric.Metadata{
+		Name: "txn.write_buffering.writes_buffered",
+		Help: "Number of transactional writes buffered on the client until they are read or " +
+			"committed (kv.transaction.write_buffering.enabled)",
+		Measurement: "Writes",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaTxnsWithWriteBufferOverBudget = metric.Metadata{
+		Name: "txn.write_buffering.over_budget",
+		Help: "KV transactions that exceeded their write buffer budget " +
+			"(kv.transaction.write_buffering.max_buffer_size) and fell back to pipelining their writes",
+		Measurement: "KV Transactions",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	metaRestartsHistogram = metric.Metadata{
 		Name:        "txn.restarts",
@@ -273,6 +294,20 @@ var (
 		Measurement: "KV Transactions",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaDeadlineAbortCleanups = metric.Metadata{
+		Name: "txn.deadline_abort_cleanups",
+		Help: "Number of KV transactions rolled back after exceeding their deadline " +
+			"whose intents were resolved eagerly by their coordinator",
+		Measurement: "KV Transactions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaDeadlineAbortCleanupsFailed = metric.Metadata{
+		Name: "txn.deadline_abort_cleanups.failed",
+		Help: "Number of batches resolving the intents of KV transactions rolled back after " +
+			"exceeding their deadline which failed or were skipped over the concurrency limit",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
 )
 
 // MakeTxnMetrics returns a TxnMetrics struct that contains metrics whose
@@ -301,6 +336,8 @@ func MakeTxnMetrics(histogramWindow time.Duration) TxnMetrics {
 		TxnsWithCondensedIntents:      metric.NewCounter(metaTxnsWithCondensedIntentSpans),
 		TxnsWithCondensedIntentsGauge: metric.NewGauge(metaTxnsWithCondensedIntentSpansGauge),
 		TxnsRejectedByLockSpanBudget:  metric.NewCounter(metaTxnsRejectedByLockSpanBudget),
+		WritesBuffered:                metric.NewCounter(metaWritesBuffered),
+		TxnsWithWriteBufferOverBudget: metric.NewCounter(metaTxnsWithWriteBufferOverBudget),
 		Restarts: metric.NewHistogram(metric.HistogramOptions{
 			Metadata:     metaRestartsHistogram,
 			Duration:     histogramWindow,
@@ -319,5 +356,7 @@ func MakeTxnMetrics(histogramWindow time.Duration) TxnMetrics {
 		RestartsUnknown:                telemetry.NewCounterWithMetric(metaRestartsUnknown),
 		RollbacksFailed:                metric.NewCounter(metaRollbacksFailed),
 		AsyncRollbacksFailed:           metric.NewCounter(metaAsyncRollbacksFailed),
+		DeadlineAbortCleanups:          metric.NewCounter(metaDeadlineAbortCleanups),
+		DeadlineAbortCleanupsFailed:    metric.NewCounter(metaDeadlineAbortCleanupsFailed),
 	}
 }
@@ -0,0 +1,179 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package nodedescs
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvcoord"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+)
+
+// RangefeedDescriptorsEnabled controls whether the FallbackStore serves node
+// and store descriptors from the rangefeed-backed Store, only consulting gossip
+// for descriptors which the rangefeed has not observed. When disabled, gossip
+// remains authoritative and the rangefeed-backed Store is only compared against
+// it.
+var RangefeedDescriptorsEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.dist_sender.rangefeed_node_descriptors.enabled",
+	"if enabled, the DistSender looks up node and store descriptors using a "+
+		"rangefeed over the node status records, falling back to gossip",
+	false,
+)
+
+var (
+	metaRangefeedHits = metric.Metadata{
+		Name:        "distsender.node_descs.rangefeed_hits",
+		Help:        "Number of node and store descriptor lookups served by the rangefeed-backed source",
+		Measurement: "Lookups",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaGossipFallbacks = metric.Metadata{
+		Name: "distsender.node_descs.gossip_fallbacks",
+		Help: "Number of node and store descriptor lookups which fell back to gossip " +
+			"because the rangefeed-backed source did not know the descriptor",
+		Measurement: "Lookups",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangefeedMisses = metric.Metadata{
+		Name: "distsender.node_descs.rangefeed_misses",
+		Help: "Number of node and store descriptor lookups for which gossip knew " +
+			"the descriptor but the rangefeed-backed source did not",
+		Measurement: "Lookups",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaMismatches = metric.Metadata{
+		Name: "distsender.node_descs.mismatches",
+		Help: "Number of node and store descriptor lookups for which gossip and " +
+			"the rangefeed-backed source disagreed on the node's address",
+		Measurement: "Lookups",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// Metrics tracks how the rangefeed-backed descriptor source compares to
+// gossip.
+type Metrics struct {
+	RangefeedHits   *metric.Counter
+	GossipFallbacks *metric.Counter
+	RangefeedMisses *metric.Counter
+	Mismatches      *metric.Counter
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (Metrics) MetricStruct() {}
+
+func makeMetrics() Metrics {
+	return Metrics{
+		RangefeedHits:   metric.NewCounter(metaRangefeedHits),
+		GossipFallbacks: metric.NewCounter(metaGossipFallbacks),
+		RangefeedMisses: metric.NewCounter(metaRangefeedMisses),
+		Mismatches:      metric.NewCounter(metaMismatches),
+	}
+}
+
+// FallbackStore is a kvcoord.NodeDescStore which combines a rangefeed-backed
+// Store with gossip. Every lookup consults both sources so that the metrics can
+// report how far apart they are; which source's answer is returned is dictated
+// by RangefeedDescriptorsEnabled.
+type FallbackStore struct {
+	st        *cluster.Settings
+	rangefeed *Store
+	gossip    kvcoord.NodeDescStore
+	metrics   Metrics
+}
+
+var _ kvcoord.NodeDescStore = (*FallbackStore)(nil)
+
+// NewFallbackStore constructs a FallbackStore. The rangefeed-backed Store is
+// expected to be started separately, once KV is available.
+func NewFallbackStore(
+	st *cluster.Settings, rangefeed *Store, gossip kvcoord.NodeDescStore,
+) *FallbackStore {
+	return &FallbackStore{
+		st:        st,
+		rangefeed: rangefeed,
+		gossip:    gossip,
+		metrics:   makeMetrics(),
+	}
+}
+
+// Metrics returns the FallbackStore's metrics.
+func (s *FallbackStore) Metrics() Metrics {
+	return s.metrics
+}
+
+// Rangefeed returns the rangefeed-backed Store.
+func (s *FallbackStore) Rangefeed() *Store {
+	return s.rangefeed
+}
+
+func (s *FallbackStore) enabled() bool {
+	return RangefeedDescriptorsEnabled.Get(&s.st.SV)
+}
+
+// GetNodeDescriptor implements the kvcoord.NodeDescStore interface.
+func (s *FallbackStore) GetNodeDescriptor(nodeID roachpb.NodeID) (*roachpb.NodeDescriptor, error) {
+	rfDesc, rfErr := s.rangefeed.GetNodeDescriptor(nodeID)
+	gDesc, gErr := s.gossip.GetNodeDescriptor(nodeID)
+	s.compare(rfErr == nil, gErr == nil,
+		rfErr == nil && gErr == nil && rfDesc.Address == gDesc.Address)
+	if s.enabled() {
+		if rfErr == nil {
+			s.metrics.RangefeedHits.Inc(1)
+			return rfDesc, nil
+		}
+		s.metrics.GossipFallbacks.Inc(1)
+	}
+	return gDesc, gErr
+}
+
+// GetNodeDescriptorCount implements the kvcoord.NodeDescStore interface.
+func (s *FallbackStore) GetNodeDescriptorCount() int {
+	if s.enabled() {
+		if n := s.rangefeed.GetNodeDescriptorCount(); n > 0 {
+			return n
+		}
+	}
+	return s.gossip.GetNodeDescriptorCount()
+}
+
+// GetStoreDescriptor implements the kvcoord.NodeDescStore interface.
+func (s *FallbackStore) GetStoreDescriptor(
+	storeID roachpb.StoreID,
+) (*roachpb.StoreDescriptor, error) {
+	rfDesc, rfErr := s.rangefeed.GetStoreDescriptor(storeID)
+	gDesc, gErr := s.gossip.GetStoreDescriptor(storeID)
+	s.compare(rfErr == nil, gErr == nil, rfErr == nil && gErr == nil &&
+		rfDesc.Node.NodeID == gDesc.Node.NodeID && rfDesc.Node.Address == gDesc.Node.Address)
+	if s.enabled() {
+		if rfErr == nil {
+			s.metrics.RangefeedHits.Inc(1)
+			return rfDesc, nil
+		}
+		s.metrics.GossipFallbacks.Inc(1)
+	}
+	return gDesc, gErr
+}
+
+// compare records how the two sources' answers to a lookup relate. Only
+// addressing information is compared: store capacities are refreshed on
+// different schedules by the two sources and are expected to differ.
+func (s *FallbackStore) compare(rfFound, gossipFound, agree bool) {
+	switch {
+	case gossipFound && !rfFound:
+		s.metrics.RangefeedMisses.Inc(1)
+	case gossipFound && rfFound && !agree:
+		s.metrics.Mismatches.Inc(1)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package nodedescs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/status/statuspb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func makeNodeStatus(
+	nodeID roachpb.NodeID, addr string, storeIDs ...roachpb.StoreID,
+) *statuspb.NodeStatus {
+	desc := roachpb.NodeDescriptor{NodeID: nodeID, Address: util.MakeUnresolvedAddr("tcp", addr)}
+	status := &statuspb.NodeStatus{Desc: desc}
+	for _, storeID := range storeIDs {
+		status.StoreStatuses = append(status.StoreStatuses, statuspb.StoreStatus{
+			Desc: roachpb.StoreDescriptor{StoreID: storeID, Node: desc},
+		})
+	}
+	return status
+}
+
+func TestStore(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	s := NewStore(nil /* clock */)
+	s.update(1, makeNodeStatus(1, "n1:26257", 1, 2))
+	s.update(2, makeNodeStatus(2, "n2:26257", 3))
+	require.Equal(t, 2, s.GetNodeDescriptorCount())
+
+	desc, err := s.GetStoreDescriptor(2)
+	require.NoError(t, err)
+	require.Equal(t, roachpb.NodeID(1), desc.Node.NodeID)
+
+	// An updated status replaces the node's stores.
+	s.update(1, makeNodeStatus(1, "n1:26257", 1))
+	_, err = s.GetStoreDescriptor(2)
+	require.Error(t, err)
+
+	// A deleted status removes the node and its stores.
+	s.update(2, nil)
+	_, err = s.GetNodeDescriptor(2)
+	require.Error(t, err)
+	_, err = s.GetStoreDescriptor(3)
+	require.Error(t, err)
+	require.Equal(t, 1, s.GetNodeDescriptorCount())
+
+	nodeID, err := decodeNodeStatusKey(keys.NodeStatusKey(7))
+	require.NoError(t, err)
+	require.Equal(t, roachpb.NodeID(7), nodeID)
+	_, err = decodeNodeStatusKey(keys.NodeLivenessKey(7))
+	require.Error(t, err)
+}
+
+func TestFallbackStore(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+
+	// Node 1 is known to both sources, node 2 only to gossip, and node 3 is
+	// known to both but with different addresses.
+	rf := NewStore(nil /* clock */)
+	rf.update(1, makeNodeStatus(1, "n1:26257", 1))
+	rf.update(3, makeNodeStatus(3, "n3-new:26257", 3))
+	gossip := NewStore(nil /* clock */)
+	gossip.update(1, makeNodeStatus(1, "n1:26257", 1))
+	gossip.update(2, makeNodeStatus(2, "n2:26257", 2))
+	gossip.update(3, makeNodeStatus(3, "n3-old:26257", 3))
+
+	s := NewFallbackStore(st, rf, gossip)
+	m := s.Metrics()
+
+	// While disabled, gossip is authoritative but the sources are compared.
+	desc, err := s.GetNodeDescriptor(3)
+	require.NoError(t, err)
+	require.Equal(t, "n3-old:26257", desc.Address.AddressField)
+	_, err = s.GetNodeDescriptor(2)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), m.Mismatches.Count())
+	require.Equal(t, int64(1), m.RangefeedMisses.Count())
+	require.Zero(t, m.RangefeedHits.Count())
+	require.Zero(t, m.GossipFallbacks.Count())
+	require.Equal(t, 3, s.GetNodeDescriptorCount())
+
+	// Once enabled, the rangefeed-backed source is preferred and gossip is
+	// only used for descriptors it does not know.
+	RangefeedDescriptorsEnabled.Override(ctx, &st.SV, true)
+	desc, err = s.GetNodeDescriptor(3)
+	require.NoError(t, err)
+	require.Equal(t, "n3-new:26257", desc.Address.AddressField)
+	storeDesc, err := s.GetStoreDescriptor(2)
+	require.NoError(t, err)
+	require.Equal(t, roachpb.NodeID(2), storeDesc.Node.NodeID)
+	_, err = s.GetNodeDescriptor(4)
+	require.Error(t, err)
+	require.Equal(t, int64(2), m.Mismatches.Count())
+	require.Equal(t, int64(2), m.RangefeedMisses.Count())
+	require.Equal(t, int64(1), m.RangefeedHits.Count())
+	require.Equal(t, int64(2), m.GossipFallbacks.Count())
+	require.Equal(t, 2, s.GetNodeDescriptorCount())
+}
@@ -0,0 +1,165 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package nodedescs provides sources of node and store descriptors for the
+// kvcoord package which do not depend on gossip.
+package nodedescs
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvcoord"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/rangefeed"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/status/statuspb"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+)
+
+// Store is a kvcoord.NodeDescStore backed by a rangefeed over the node status
+// records which every KV node periodically persists under
+// keys.StatusNodePrefix. Each record carries the node's descriptor along with
+// the descriptors of all of its stores.
+//
+// The Store is empty until it is started and its initial scan has completed;
+// callers which cannot tolerate that should wrap it in a FallbackStore.
+type Store struct {
+	clock *hlc.Clock
+
+	mu struct {
+		syncutil.RWMutex
+		nodes        map[roachpb.NodeID]*roachpb.NodeDescriptor
+		stores       map[roachpb.StoreID]*roachpb.StoreDescriptor
+		storesByNode map[roachpb.NodeID][]roachpb.StoreID
+	}
+}
+
+var _ kvcoord.NodeDescStore = (*Store)(nil)
+
+// NewStore constructs an empty Store. The Store is populated once Start is
+// called.
+func NewStore(clock *hlc.Clock) *Store {
+	s := &Store{clock: clock}
+	s.mu.nodes = make(map[roachpb.NodeID]*roachpb.NodeDescriptor)
+	s.mu.stores = make(map[roachpb.StoreID]*roachpb.StoreDescriptor)
+	s.mu.storesByNode = make(map[roachpb.NodeID][]roachpb.StoreID)
+	return s
+}
+
+// Start establishes the rangefeed over the node status records. It does not
+// wait for the initial scan to complete. The rangefeed is closed when the
+// stopper quiesces.
+func (s *Store) Start(ctx context.Context, stopper *stop.Stopper, f *rangefeed.Factory) error {
+	span := roachpb.Span{Key: keys.StatusNodePrefix, EndKey: keys.StatusNodePrefix.PrefixEnd()}
+	rf, err := f.RangeFeed(ctx,
+		"node-descriptors",
+		[]roachpb.Span{span},
+		s.clock.Now(),
+		s.onValue,
+		rangefeed.WithSystemTablePriority(),
+		rangefeed.WithInitialScan(func(ctx context.Context) {
+			log.VEventf(ctx, 1, "node descriptor rangefeed completed its initial scan")
+		}),
+	)
+	if err != nil {
+		return err
+	}
+	stopper.AddCloser(rf)
+	return nil
+}
+
+func (s *Store) onValue(ctx context.Context, kv *kvpb.RangeFeedValue) {
+	nodeID, err := decodeNodeStatusKey(kv.Key)
+	if err != nil {
+		log.Warningf(ctx, "failed to decode node status key %s: %v", kv.Key, err)
+		return
+	}
+	if !kv.Value.IsPresent() {
+		s.update(nodeID, nil)
+		return
+	}
+	var status statuspb.NodeStatus
+	if err := kv.Value.GetProto(&status); err != nil {
+		log.Warningf(ctx, "failed to decode node status for n%d: %v", nodeID, err)
+		return
+	}
+	s.update(nodeID, &status)
+}
+
+// update replaces the descriptors of the given node and its stores with those
+// in the provided status. A nil status removes them.
+func (s *Store) update(nodeID roachpb.NodeID, status *statuspb.NodeStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, storeID := range s.mu.storesByNode[nodeID] {
+		delete(s.mu.stores, storeID)
+	}
+	delete(s.mu.storesByNode, nodeID)
+	if status == nil {
+		delete(s.mu.nodes, nodeID)
+		return
+	}
+	s.mu.nodes[nodeID] = &status.Desc
+	storeIDs := make([]roachpb.StoreID, 0, len(status.StoreStatuses))
+	for i := range status.StoreStatuses {
+		desc := &status.StoreStatuses[i].Desc
+		s.mu.stores[desc.StoreID] = desc
+		storeIDs = append(storeIDs, desc.StoreID)
+	}
+	s.mu.storesByNode[nodeID] = storeIDs
+}
+
+// GetNodeDescriptor implements the kvcoord.NodeDescStore interface.
+func (s *Store) GetNodeDescriptor(nodeID roachpb.NodeID) (*roachpb.NodeDescriptor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	desc, ok := s.mu.nodes[nodeID]
+	if !ok {
+		return nil, kvpb.NewNodeDescNotFoundError(nodeID)
+	}
+	return desc, nil
+}
+
+// GetNodeDescriptorCount implements the kvcoord.NodeDescStore interface.
+func (s *Store) GetNodeDescriptorCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.mu.nodes)
+}
+
+// GetStoreDescriptor implements the kvcoord.NodeDescStore interface.
+func (s *Store) GetStoreDescriptor(storeID roachpb.StoreID) (*roachpb.StoreDescriptor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	desc, ok := s.mu.stores[storeID]
+	if !ok {
+		return nil, kvpb.NewStoreNotFoundError(storeID)
+	}
+	return desc, nil
+}
+
+// decodeNodeStatusKey is the inverse of keys.NodeStatusKey.
+func decodeNodeStatusKey(key roachpb.Key) (roachpb.NodeID, error) {
+	if !bytes.HasPrefix(key, keys.StatusNodePrefix) {
+		return 0, errors.Newf("key %s does not have prefix %s", key, keys.StatusNodePrefix)
+	}
+	_, nodeID, err := encoding.DecodeUvarintAscending(key[len(keys.StatusNodePrefix):])
+	if err != nil {
+		return 0, err
+	}
+	return roachpb.NodeID(nodeID), nil
+}
@@ -17,6 +17,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/biogo/store/llrb"
@@ -144,6 +145,10 @@ type RangeCache struct {
 	// for details on this inference.
 	lookupRequests *singleflight.Group
 
+	// hits and misses count the lookups served from the cache, and the ones
+	// which had to look the descriptor up in the database.
+	hits, misses atomic.Int64
+
 	// coalesced, if not nil, is sent on every time a request is coalesced onto
 	// another in-flight one. Used by tests to block until a lookup request is
 	// blocked on the single-flight querying the db.
@@ -759,9 +764,11 @@ func (rc *RangeCache) tryLookup(
 	rc.rangeCache.RLock()
 	if entry, _ := rc.getCachedRLocked(ctx, key, useReverseScan); entry != nil {
 		rc.rangeCache.RUnlock()
+		rc.hits.Add(1)
 		returnToken := rc.makeEvictionToken(entry, nil /* nextDesc */)
 		return returnToken, nil
 	}
+	rc.misses.Add(1)
 
 	log.VEventf(ctx, 2, "looking up range descriptor: key=%s", key)
 
@@ -1291,6 +1298,19 @@ func (rc *RangeCache) delEntryLocked(entry *cache.Entry) {
 	entry.Key.(*rangeCacheKey).release()
 }
 
+// HitsAndMisses returns the number of lookups served from the cache, and the
+// number of lookups which missed it.
+func (rc *RangeCache) HitsAndMisses() (hits, misses int64) {
+	return rc.hits.Load(), rc.misses.Load()
+}
+
+// Len returns the number of entries in the cache.
+func (rc *RangeCache) Len() int {
+	rc.rangeCache.RLock()
+	defer rc.rangeCache.RUnlock()
+	return rc.rangeCache.cache.Len()
+}
+
 // DB returns the descriptor database, for tests.
 func (rc *RangeCache) DB() RangeDescriptorDB {
 	return rc.db
@@ -0,0 +1,146 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package rangecache
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+)
+
+// autotuneMinLookups is the minimum number of lookups in an interval for its
+// hit rate to be acted upon.
+const autotuneMinLookups = 100
+
+var (
+	metaAutotuneSize = metric.Metadata{
+		Name:        "distsender.rangecache.autotune.size",
+		Help:        "Number of entries the range descriptor cache is sized for by the autotuner",
+		Measurement: "Entries",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaAutotuneGrowCount = metric.Metadata{
+		Name:        "distsender.rangecache.autotune.grow",
+		Help:        "Number of times the autotuner grew the range descriptor cache due to a low hit rate",
+		Measurement: "Resizes",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaAutotuneShrinkCount = metric.Metadata{
+		Name:        "distsender.rangecache.autotune.shrink",
+		Help:        "Number of times the autotuner shrank the range descriptor cache due to memory pressure",
+		Measurement: "Resizes",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// SizeAutotunerMetrics are the metrics of a SizeAutotuner.
+type SizeAutotunerMetrics struct {
+	Size        *metric.Gauge
+	GrowCount   *metric.Counter
+	ShrinkCount *metric.Counter
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (SizeAutotunerMetrics) MetricStruct() {}
+
+// MakeSizeAutotunerMetrics instantiates the metrics of a SizeAutotuner.
+func MakeSizeAutotunerMetrics() SizeAutotunerMetrics {
+	return SizeAutotunerMetrics{
+		Size:        metric.NewGauge(metaAutotuneSize),
+		GrowCount:   metric.NewCounter(metaAutotuneGrowCount),
+		ShrinkCount: metric.NewCounter(metaAutotuneShrinkCount),
+	}
+}
+
+// SizeAutotunerConfig configures a SizeAutotuner.
+type SizeAutotunerConfig struct {
+	// MinSize and MaxSize bound the size of the cache.
+	MinSize, MaxSize func() int64
+	// TargetHitRate is the hit rate under which the cache is grown, if it is
+	// full.
+	TargetHitRate func() float64
+	// MemoryPressure returns whether the node is under memory pressure, in
+	// which case the cache is shrunk.
+	MemoryPressure func() bool
+	Metrics        *SizeAutotunerMetrics
+}
+
+// SizeAutotuner sizes a RangeCache after the workload: it grows the cache
+// while its hit rate is under target and it is full, i.e. while the misses
+// are evictions which a larger cache would have avoided, and shrinks it under
+// memory pressure. Tune must be called periodically with the cache to adjust
+// its size, which is returned by Size.
+type SizeAutotuner struct {
+	cfg  SizeAutotunerConfig
+	size atomic.Int64
+	// lastHits and lastMisses are the hits and misses of the cache as of the
+	// previous call to Tune.
+	lastHits, lastMisses int64
+}
+
+// NewSizeAutotuner creates a SizeAutotuner. The cache starts at its minimum
+// size.
+func NewSizeAutotuner(cfg SizeAutotunerConfig) *SizeAutotuner {
+	a := &SizeAutotuner{cfg: cfg}
+	a.size.Store(cfg.MinSize())
+	a.cfg.Metrics.Size.Update(a.size.Load())
+	return a
+}
+
+// Size returns the size the cache is tuned for. It is clamped to the current
+// bounds.
+func (a *SizeAutotuner) Size() int64 {
+	return a.clamp(a.size.Load())
+}
+
+func (a *SizeAutotuner) clamp(size int64) int64 {
+	minSize, maxSize := a.cfg.MinSize(), a.cfg.MaxSize()
+	if size > maxSize {
+		size = maxSize
+	}
+	if size < minSize {
+		size = minSize
+	}
+	return size
+}
+
+// Tune adjusts the size of the cache to its hit rate since the last call, and
+// to the memory pressure. It must not be called concurrently.
+func (a *SizeAutotuner) Tune(ctx context.Context, rc *RangeCache) {
+	hits, misses := rc.HitsAndMisses()
+	intervalHits, intervalMisses := hits-a.lastHits, misses-a.lastMisses
+	a.lastHits, a.lastMisses = hits, misses
+
+	size := a.Size()
+	newSize := size
+	if a.cfg.MemoryPressure() {
+		newSize = a.clamp(size / 2)
+		if newSize < size {
+			a.cfg.Metrics.ShrinkCount.Inc(1)
+			log.VEventf(ctx, 1, "shrinking the range descriptor cache from %d to %d entries "+
+				"due to memory pressure", size, newSize)
+		}
+	} else if lookups := intervalHits + intervalMisses; lookups >= autotuneMinLookups {
+		hitRate := float64(intervalHits) / float64(lookups)
+		if hitRate < a.cfg.TargetHitRate() && int64(rc.Len()) >= size {
+			newSize = a.clamp(size * 2)
+			if newSize > size {
+				a.cfg.Metrics.GrowCount.Inc(1)
+				log.VEventf(ctx, 1, "growing the range descriptor cache from %d to %d entries "+
+					"due to its %.2f hit rate", size, newSize, hitRate)
+			}
+		}
+	}
+	a.size.Store(newSize)
+	a.cfg.Metrics.Size.Update(newSize)
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package rangecache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeAutotuner(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	st := cluster.MakeTestingClusterSettings()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	var pressure bool
+	maxSize := int64(64)
+	metrics := MakeSizeAutotunerMetrics()
+	a := NewSizeAutotuner(SizeAutotunerConfig{
+		MinSize:        func() int64 { return 8 },
+		MaxSize:        func() int64 { return maxSize },
+		TargetHitRate:  func() float64 { return 0.9 },
+		MemoryPressure: func() bool { return pressure },
+		Metrics:        &metrics,
+	})
+	rc := NewRangeCache(st, nil, a.Size, stopper)
+	require.EqualValues(t, 8, a.Size())
+
+	// fill inserts n non-overlapping descriptors in the cache.
+	fill := func(n int) {
+		for i := 0; i < n; i++ {
+			rc.Insert(ctx, roachpb.RangeInfo{Desc: roachpb.RangeDescriptor{
+				RangeID:  roachpb.RangeID(i + 1),
+				StartKey: roachpb.RKey(fmt.Sprintf("k%04d", i)),
+				EndKey:   roachpb.RKey(fmt.Sprintf("k%04d\x00", i)),
+			}})
+		}
+	}
+	lookups := func(hits, misses int64) {
+		rc.hits.Add(hits)
+		rc.misses.Add(misses)
+	}
+
+	// A low hit rate doesn't grow the cache until it is full.
+	lookups(50, 50)
+	a.Tune(ctx, rc)
+	require.EqualValues(t, 8, a.Size())
+
+	// Too few lookups aren't acted upon.
+	fill(100)
+	require.Equal(t, 8, rc.Len())
+	lookups(5, 5)
+	a.Tune(ctx, rc)
+	require.EqualValues(t, 8, a.Size())
+
+	// A full cache with a low hit rate grows, up to its maximum size.
+	for _, exp := range []int64{16, 32, 64, 64} {
+		lookups(50, 50)
+		a.Tune(ctx, rc)
+		require.EqualValues(t, exp, a.Size())
+		fill(100)
+	}
+	require.EqualValues(t, 3, metrics.GrowCount.Count())
+
+	// A high hit rate doesn't grow the cache.
+	maxSize = 128
+	lookups(95, 5)
+	a.Tune(ctx, rc)
+	require.EqualValues(t, 64, a.Size())
+
+	// Memory pressure shrinks the cache, down to its minimum size, regardless
+	// of the hit rate.
+	pressure = true
+	for _, exp := range []int64{32, 16, 8, 8} {
+		lookups(0, 100)
+		a.Tune(ctx, rc)
+		require.EqualValues(t, exp, a.Size())
+	}
+	require.EqualValues(t, 3, metrics.ShrinkCount.Count())
+	require.EqualValues(t, 8, metrics.Size.Value())
+	// The cache is trimmed to its new size on the next insertion.
+	rc.Insert(ctx, roachpb.RangeInfo{Desc: roachpb.RangeDescriptor{
+		RangeID: 1000, StartKey: roachpb.RKey("z"), EndKey: roachpb.RKeyMax,
+	}})
+	require.Equal(t, 8, rc.Len())
+}
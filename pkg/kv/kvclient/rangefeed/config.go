@@ -12,6 +12,7 @@ package rangefeed
 
 import (
 	"context"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -38,11 +39,15 @@ type config struct {
 	useRowTimestampInInitialScan bool
 
 	withDiff             bool
+	resolvedTSInterval   time.Duration
+	overflowPolicy       kvpb.RangeFeedRequest_OverflowPolicy
 	onUnrecoverableError OnUnrecoverableError
 	onCheckpoint         OnCheckpoint
 	onFrontierAdvance    OnFrontierAdvance
 	onSSTable            OnSSTable
 	onDeleteRange        OnDeleteRange
+	mvccHistMutToken     []byte
+	onMVCCHistMutation   OnMVCCHistoryMutation
 	extraPProfLabels     []string
 }
 
@@ -143,6 +148,28 @@ func WithDiff(withDiff bool) Option {
 	})
 }
 
+// WithResolvedTSInterval makes an option to set the minimum interval at which
+// checkpoints are emitted for each range in the rangefeed. The option defaults
+// to zero, in which case a checkpoint is emitted whenever a range's resolved
+// timestamp advances.
+func WithResolvedTSInterval(interval time.Duration) Option {
+	return optionFunc(func(c *config) {
+		c.resolvedTSInterval = interval
+	})
+}
+
+// WithOverflowPolicy makes an option to set the policy used when the rangefeed
+// can't keep up with the events on a range. The option defaults to
+// kvpb.RangeFeedRequest_DISCONNECT, in which case the range's feed is
+// restarted with a catch-up scan. With kvpb.RangeFeedRequest_CHECKPOINT_ONLY,
+// the checkpoints passed to OnCheckpoint report dropped events in
+// EventsDroppedSince, and the frontier advances past them.
+func WithOverflowPolicy(policy kvpb.RangeFeedRequest_OverflowPolicy) Option {
+	return optionFunc(func(c *config) {
+		c.overflowPolicy = policy
+	})
+}
+
 // WithRetry configures the retry options for the rangefeed.
 func WithRetry(options retry.Options) Option {
 	return optionFunc(func(c *config) {
@@ -210,6 +237,24 @@ func WithOnDeleteRange(f OnDeleteRange) Option {
 	})
 }
 
+// OnMVCCHistoryMutation is called when MVCC history is mutated by a request
+// (e.g. ClearRange, RevertRange, or AddSSTable below the request timestamp)
+// which carried the rangefeed's MVCC history mutation token. Values previously
+// emitted across the span may no longer be accurate, and it is up to the
+// callback to reconcile the consumer's state.
+type OnMVCCHistoryMutation func(ctx context.Context, mut *kvpb.RangeFeedMVCCHistoryMutation)
+
+// WithOnMVCCHistoryMutation sets up a callback that's invoked whenever MVCC
+// history is mutated by a request carrying the given token. Without this
+// option, or for mutations without a matching token, the rangefeed terminates
+// with an MVCCHistoryMutationError instead.
+func WithOnMVCCHistoryMutation(token []byte, f OnMVCCHistoryMutation) Option {
+	return optionFunc(func(c *config) {
+		c.mvccHistMutToken = token
+		c.onMVCCHistMutation = f
+	})
+}
+
 // OnFrontierAdvance is called when the rangefeed frontier is advanced with the
 // new frontier timestamp.
 type OnFrontierAdvance func(ctx context.Context, timestamp hlc.Timestamp)
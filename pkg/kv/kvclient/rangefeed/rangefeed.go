@@ -300,6 +300,15 @@ func (f *RangeFeed) run(ctx context.Context, frontier span.Frontier) {
 	if f.withDiff {
 		rangefeedOpts = append(rangefeedOpts, kvcoord.WithDiff())
 	}
+	if f.resolvedTSInterval > 0 {
+		rangefeedOpts = append(rangefeedOpts, kvcoord.WithResolvedTSInterval(f.resolvedTSInterval))
+	}
+	if f.overflowPolicy != kvpb.RangeFeedRequest_DISCONNECT {
+		rangefeedOpts = append(rangefeedOpts, kvcoord.WithOverflowPolicy(f.overflowPolicy))
+	}
+	if len(f.mvccHistMutToken) > 0 {
+		rangefeedOpts = append(rangefeedOpts, kvcoord.WithMVCCHistoryMutationToken(f.mvccHistMutToken))
+	}
 
 	for i := 0; r.Next(); i++ {
 		ts := frontier.Frontier()
@@ -387,6 +396,12 @@ func (f *RangeFeed) processEvents(
 						"received unexpected rangefeed DeleteRange event with no OnDeleteRange handler: %s", ev)
 				}
 				f.onDeleteRange(ctx, ev.DeleteRange)
+			case ev.MVCCHistoryMutation != nil:
+				if f.onMVCCHistMutation == nil {
+					return errors.AssertionFailedf(
+						"received unexpected rangefeed MVCCHistoryMutation event with no OnMVCCHistoryMutation handler: %s", ev)
+				}
+				f.onMVCCHistMutation(ctx, ev.MVCCHistoryMutation)
 			case ev.Error != nil:
 				// Intentionally do nothing, we'll get an error returned from the
 				// call to RangeFeed.
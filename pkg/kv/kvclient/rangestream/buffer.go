@@ -0,0 +1,74 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package rangestream
+
+import (
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+)
+
+// eventBuffer buffers the rangefeed events of the source span until the
+// rangefeed frontier passes them. Events are only ingested into the destination
+// once the frontier passes them, so that the destination never contains data
+// above the replicated time of the stream, except for the data of a flush that
+// failed half way.
+type eventBuffer struct {
+	points    []storage.MVCCKeyValue
+	rangeKeys []storage.MVCCRangeKeyValue
+}
+
+func (b *eventBuffer) addPoint(kv storage.MVCCKeyValue) {
+	b.points = append(b.points, kv)
+}
+
+func (b *eventBuffer) addRangeKey(rkv storage.MVCCRangeKeyValue) {
+	b.rangeKeys = append(b.rangeKeys, rkv)
+}
+
+// take removes the events at or below the frontier from the buffer and returns
+// them. The point keys are sorted in MVCC order, as required by the SST
+// batcher, and deduplicated, since rangefeeds may emit the same value more than
+// once.
+func (b *eventBuffer) take(
+	frontier hlc.Timestamp,
+) (points []storage.MVCCKeyValue, rangeKeys []storage.MVCCRangeKeyValue) {
+	var keptPoints []storage.MVCCKeyValue
+	for _, kv := range b.points {
+		if kv.Key.Timestamp.LessEq(frontier) {
+			points = append(points, kv)
+		} else {
+			keptPoints = append(keptPoints, kv)
+		}
+	}
+	var keptRangeKeys []storage.MVCCRangeKeyValue
+	for _, rkv := range b.rangeKeys {
+		if rkv.RangeKey.Timestamp.LessEq(frontier) {
+			rangeKeys = append(rangeKeys, rkv)
+		} else {
+			keptRangeKeys = append(keptRangeKeys, rkv)
+		}
+	}
+	b.points, b.rangeKeys = keptPoints, keptRangeKeys
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Key.Less(points[j].Key)
+	})
+	deduped := points[:0]
+	for _, kv := range points {
+		if len(deduped) > 0 && kv.Key.Equal(deduped[len(deduped)-1].Key) {
+			continue
+		}
+		deduped = append(deduped, kv)
+	}
+	return deduped, rangeKeys
+}
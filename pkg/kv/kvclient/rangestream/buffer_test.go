@@ -0,0 +1,74 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package rangestream
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBufferTake(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ts := func(wallTime int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wallTime} }
+	point := func(key string, wallTime int64) storage.MVCCKeyValue {
+		return storage.MVCCKeyValue{
+			Key:   storage.MVCCKey{Key: roachpb.Key(key), Timestamp: ts(wallTime)},
+			Value: []byte(key),
+		}
+	}
+	rangeKey := func(start, end string, wallTime int64) storage.MVCCRangeKeyValue {
+		return storage.MVCCRangeKeyValue{
+			RangeKey: storage.MVCCRangeKey{
+				StartKey:  roachpb.Key(start),
+				EndKey:    roachpb.Key(end),
+				Timestamp: ts(wallTime),
+			},
+		}
+	}
+
+	var b eventBuffer
+	b.addPoint(point("c", 3))
+	b.addPoint(point("a", 1))
+	b.addPoint(point("b", 5))
+	b.addPoint(point("a", 2))
+	b.addPoint(point("c", 3)) // duplicate
+	b.addRangeKey(rangeKey("a", "b", 2))
+	b.addRangeKey(rangeKey("b", "c", 4))
+
+	// Nothing is at or below the initial frontier.
+	points, rangeKeys := b.take(ts(0))
+	require.Empty(t, points)
+	require.Empty(t, rangeKeys)
+
+	// Points are returned in MVCC order, newest version first, without
+	// duplicates.
+	points, rangeKeys = b.take(ts(3))
+	require.Equal(t, []storage.MVCCKeyValue{
+		point("a", 2), point("a", 1), point("c", 3),
+	}, points)
+	require.Equal(t, []storage.MVCCRangeKeyValue{rangeKey("a", "b", 2)}, rangeKeys)
+
+	points, rangeKeys = b.take(ts(10))
+	require.Equal(t, []storage.MVCCKeyValue{point("b", 5)}, points)
+	require.Equal(t, []storage.MVCCRangeKeyValue{rangeKey("b", "c", 4)}, rangeKeys)
+
+	points, rangeKeys = b.take(ts(20))
+	require.Empty(t, points)
+	require.Empty(t, rangeKeys)
+}
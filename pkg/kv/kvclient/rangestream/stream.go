@@ -0,0 +1,443 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package rangestream replicates a span of a source cluster into the same span
+// of a destination cluster, i.e. physical replication scoped to a span.
+//
+// A stream first copies the committed data of the span as of an initial
+// timestamp, by exporting it from the source with ExportRequests and ingesting
+// the exported keys into the destination with AddSSTable. It then tails the
+// span with a rangefeed from the initial timestamp: the events are buffered
+// until the rangefeed frontier passes them, and are then ingested at their
+// original timestamps. The frontier of the ingested events is the replicated
+// time of the stream: the destination span contains all the data of the source
+// span up to that time.
+//
+// Streams are cut over to the destination with Stream.Cutover, which waits for
+// the replicated time to reach the cutover time, stops the stream, and reverts
+// the destination span to the cutover time. The destination span then contains
+// exactly the data of the source span as of the cutover time.
+package rangestream
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/bulk"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/rangefeed"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/limit"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+)
+
+// revertBatchSize is the maximum number of keys reverted by each RevertRange
+// request of a cutover.
+const revertBatchSize = 10000
+
+// Config configures a Stream.
+type Config struct {
+	// Span is the span replicated from the source cluster into the same span
+	// of the destination cluster. Nothing else must write to the destination
+	// span while the stream runs.
+	Span roachpb.Span
+	// Source is the DB of the source cluster, which serves the ExportRequests
+	// of the initial copy.
+	Source *kv.DB
+	// SourceFeeds creates the rangefeed on the source cluster.
+	SourceFeeds *rangefeed.Factory
+	// Destination is the DB of the destination cluster.
+	Destination *kv.DB
+	// Settings are the settings of the destination cluster.
+	Settings *cluster.Settings
+	// Mem accounts for the memory of the SSTs ingested into the destination.
+	// Optional.
+	Mem *mon.ConcurrentBoundAccount
+	// ResumeTime is the replicated time of a previous stream of the same span
+	// to resume from. The initial copy is skipped if it is set.
+	ResumeTime hlc.Timestamp
+}
+
+// Stream replicates a span of a source cluster into a destination cluster. See
+// the package documentation for details.
+type Stream struct {
+	cfg     Config
+	batcher *bulk.SSTBatcher
+	feed    *rangefeed.RangeFeed
+	cancel  context.CancelFunc
+
+	// buf is only accessed by the rangefeed callbacks, which run in a single
+	// goroutine.
+	buf eventBuffer
+
+	mu struct {
+		syncutil.Mutex
+		// replicatedTime is the time up to which the destination span contains
+		// all the data of the source span.
+		replicatedTime hlc.Timestamp
+		// advanced is closed and replaced whenever the replicated time advances
+		// or the stream fails.
+		advanced chan struct{}
+		err      error
+		closed   bool
+	}
+}
+
+// Start starts replicating the span of the config. Unless the stream resumes a
+// previous one, it first copies the data of the span as of the current time of
+// the source, and only returns once the copy is done. The stream then runs
+// until it is closed or cut over, or until the context is canceled.
+func Start(ctx context.Context, cfg Config) (*Stream, error) {
+	if !cfg.Span.Valid() {
+		return nil, errors.Errorf("invalid span %s", cfg.Span)
+	}
+	s := &Stream{cfg: cfg}
+	s.mu.advanced = make(chan struct{})
+	var err error
+	s.batcher, err = bulk.MakeStreamSSTBatcher(ctx, cfg.Destination, nil /* rc */, cfg.Settings,
+		cfg.Mem, limit.MakeConcurrentRequestLimiter("range-stream-ingest", 1), nil /* onFlush */)
+	if err != nil {
+		return nil, err
+	}
+
+	initialTime := cfg.ResumeTime
+	if initialTime.IsEmpty() {
+		initialTime = cfg.Source.Clock().Now()
+		if err := s.copySpan(ctx, initialTime); err != nil {
+			s.batcher.Close(ctx)
+			return nil, errors.Wrapf(err, "copying %s as of %s", cfg.Span, initialTime)
+		}
+	}
+	s.mu.replicatedTime = initialTime
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.feed, err = cfg.SourceFeeds.RangeFeed(ctx, "range-stream", []roachpb.Span{cfg.Span}, initialTime,
+		s.onValue,
+		rangefeed.WithOnDeleteRange(s.onDeleteRange),
+		rangefeed.WithOnSSTable(s.onSSTable),
+		rangefeed.WithOnFrontierAdvance(s.onFrontierAdvance),
+		rangefeed.WithOnInternalError(func(ctx context.Context, err error) {
+			s.fail(err)
+		}),
+	)
+	if err != nil {
+		s.cancel()
+		s.batcher.Close(ctx)
+		return nil, err
+	}
+	log.Infof(ctx, "started streaming %s from %s", cfg.Span, initialTime)
+	return s, nil
+}
+
+// copySpan copies the latest values of the span as of the given time from the
+// source into the destination.
+func (s *Stream) copySpan(ctx context.Context, asOf hlc.Timestamp) error {
+	span := s.cfg.Span
+	for {
+		header := kvpb.Header{
+			// The sentinel value of 1 makes the export paginate after each SST,
+			// which bounds the memory of the copy.
+			TargetBytes:                 1,
+			Timestamp:                   asOf,
+			ReturnElasticCPUResumeSpans: true,
+		}
+		req := &kvpb.ExportRequest{
+			RequestHeader: kvpb.RequestHeaderFromSpan(span),
+			MVCCFilter:    kvpb.MVCCFilter_Latest,
+		}
+		resp, pErr := kv.SendWrappedWith(ctx, s.cfg.Source.NonTransactionalSender(), header, req)
+		if pErr != nil {
+			return pErr.GoError()
+		}
+		exportResp := resp.(*kvpb.ExportResponse)
+		for _, file := range exportResp.Files {
+			if err := s.ingestExportedFile(ctx, file); err != nil {
+				return err
+			}
+		}
+		if exportResp.ResumeSpan == nil {
+			return s.batcher.Flush(ctx)
+		}
+		span.Key = exportResp.ResumeSpan.Key
+	}
+}
+
+// ingestExportedFile adds the keys of an exported SST to the batcher. The files
+// of an export are ordered, so the keys are added in order.
+func (s *Stream) ingestExportedFile(ctx context.Context, file kvpb.ExportResponse_File) error {
+	iter, err := storage.NewMemSSTIterator(file.SST, false /* verify */, storage.IterOptions{
+		KeyTypes:   storage.IterKeyTypePointsOnly,
+		LowerBound: file.Span.Key,
+		UpperBound: file.Span.EndKey,
+	})
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for iter.SeekGE(storage.MVCCKey{Key: file.Span.Key}); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			return err
+		} else if !ok {
+			return nil
+		}
+		v, err := iter.UnsafeValue()
+		if err != nil {
+			return err
+		}
+		if err := s.batcher.AddMVCCKey(ctx, iter.UnsafeKey().Clone(), v); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Stream) onValue(ctx context.Context, value *kvpb.RangeFeedValue) {
+	s.buf.addPoint(storage.MVCCKeyValue{
+		Key:   storage.MVCCKey{Key: value.Key, Timestamp: value.Value.Timestamp},
+		Value: value.Value.RawBytes,
+	})
+}
+
+func (s *Stream) onDeleteRange(ctx context.Context, value *kvpb.RangeFeedDeleteRange) {
+	span := value.Span.Intersect(s.cfg.Span)
+	if !span.Valid() {
+		return
+	}
+	s.buf.addRangeKey(storage.MVCCRangeKeyValue{
+		RangeKey: storage.MVCCRangeKey{
+			StartKey:  span.Key,
+			EndKey:    span.EndKey,
+			Timestamp: value.Timestamp,
+		},
+	})
+}
+
+// onSSTable buffers the keys of an SST ingested into the source span, which
+// are all written at the same timestamp.
+func (s *Stream) onSSTable(ctx context.Context, sst *kvpb.RangeFeedSSTable, _ roachpb.Span) {
+	span := sst.Span.Intersect(s.cfg.Span)
+	if !span.Valid() {
+		return
+	}
+	iter, err := storage.NewMemSSTIterator(sst.Data, false /* verify */, storage.IterOptions{
+		KeyTypes:   storage.IterKeyTypePointsAndRanges,
+		LowerBound: span.Key,
+		UpperBound: span.EndKey,
+	})
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	defer iter.Close()
+	for iter.SeekGE(storage.MVCCKey{Key: span.Key}); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			s.fail(err)
+			return
+		} else if !ok {
+			return
+		}
+		hasPoint, hasRange := iter.HasPointAndRange()
+		if hasRange && iter.RangeKeyChanged() {
+			for _, rkv := range iter.RangeKeys().Clone().AsRangeKeyValues() {
+				s.buf.addRangeKey(rkv)
+			}
+		}
+		if hasPoint {
+			v, err := iter.UnsafeValue()
+			if err != nil {
+				s.fail(err)
+				return
+			}
+			s.buf.addPoint(storage.MVCCKeyValue{
+				Key:   iter.UnsafeKey().Clone(),
+				Value: append([]byte(nil), v...),
+			})
+		}
+	}
+}
+
+// onFrontierAdvance ingests the buffered events at or below the new frontier
+// into the destination, and advances the replicated time.
+func (s *Stream) onFrontierAdvance(ctx context.Context, frontier hlc.Timestamp) {
+	points, rangeKeys := s.buf.take(frontier)
+	for _, kv := range points {
+		if err := s.batcher.AddMVCCKey(ctx, kv.Key, kv.Value); err != nil {
+			s.fail(err)
+			return
+		}
+	}
+	if err := s.batcher.Flush(ctx); err != nil {
+		s.fail(err)
+		return
+	}
+	for _, rkv := range rangeKeys {
+		if err := s.ingestRangeKey(ctx, rkv); err != nil {
+			s.fail(err)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mu.replicatedTime.Forward(frontier) {
+		close(s.mu.advanced)
+		s.mu.advanced = make(chan struct{})
+	}
+}
+
+// ingestRangeKey ingests an MVCC range key into the destination. The SST
+// containing it is split at the range boundaries of the destination, since
+// AddSSTable can't span multiple ranges.
+func (s *Stream) ingestRangeKey(ctx context.Context, rkv storage.MVCCRangeKeyValue) error {
+	rangeKey := rkv.RangeKey
+	for rangeKey.StartKey.Compare(rkv.RangeKey.EndKey) < 0 {
+		err := s.addRangeKeySST(ctx, rangeKey, rkv.Value)
+		if m := (*kvpb.RangeKeyMismatchError)(nil); errors.As(err, &m) {
+			mr, err := m.MismatchedRange()
+			if err != nil {
+				return err
+			}
+			split := mr.Desc.EndKey.AsRawKey()
+			if split.Compare(rangeKey.StartKey) <= 0 || split.Compare(rangeKey.EndKey) >= 0 {
+				return errors.Wrapf(m, "unable to split range key %s", rangeKey)
+			}
+			rangeKey.EndKey = split
+			continue
+		} else if err != nil {
+			return err
+		}
+		rangeKey.StartKey, rangeKey.EndKey = rangeKey.EndKey, rkv.RangeKey.EndKey
+	}
+	return nil
+}
+
+func (s *Stream) addRangeKeySST(
+	ctx context.Context, rangeKey storage.MVCCRangeKey, value []byte,
+) error {
+	sstFile := &storage.MemObject{}
+	sstWriter := storage.MakeIngestionSSTWriter(ctx, s.cfg.Settings, sstFile)
+	defer sstWriter.Close()
+	if err := sstWriter.PutRawMVCCRangeKey(rangeKey, value); err != nil {
+		return err
+	}
+	if err := sstWriter.Finish(); err != nil {
+		return err
+	}
+	_, _, err := s.cfg.Destination.AddSSTable(ctx, rangeKey.StartKey, rangeKey.EndKey, sstFile.Data(),
+		false /* disallowConflicts */, false /* disallowShadowing */, hlc.Timestamp{}, /* disallowShadowingBelow */
+		nil /* stats */, false /* ingestAsWrites */, s.cfg.Destination.Clock().Now())
+	return err
+}
+
+// fail stops the stream with the given error.
+func (s *Stream) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mu.err != nil || s.mu.closed {
+		return
+	}
+	s.mu.err = err
+	close(s.mu.advanced)
+	s.mu.advanced = make(chan struct{})
+	s.cancel()
+}
+
+// ReplicatedTime returns the time up to which the destination span contains
+// all the data of the source span, along with the error which stopped the
+// stream, if any.
+func (s *Stream) ReplicatedTime() (hlc.Timestamp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mu.replicatedTime, s.mu.err
+}
+
+// WaitForReplicatedTime waits until the replicated time of the stream reaches
+// the given time.
+func (s *Stream) WaitForReplicatedTime(ctx context.Context, ts hlc.Timestamp) error {
+	for {
+		s.mu.Lock()
+		replicatedTime, err, advanced := s.mu.replicatedTime, s.mu.err, s.mu.advanced
+		closed := s.mu.closed
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		if ts.LessEq(replicatedTime) {
+			return nil
+		}
+		if closed {
+			return errors.Errorf("stream closed at %s before reaching %s", replicatedTime, ts)
+		}
+		select {
+		case <-advanced:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Cutover cuts the destination span over to the given time of the source span.
+// It waits for the replicated time to reach the cutover time, stops the stream,
+// and reverts the destination span to the cutover time, at which point it
+// contains exactly the data of the source span as of the cutover time. The
+// caller is responsible for stopping the writes to the source span, if needed,
+// and for choosing a cutover time after the last of them.
+func (s *Stream) Cutover(ctx context.Context, cutoverTime hlc.Timestamp) error {
+	if cutoverTime.IsEmpty() {
+		return errors.New("empty cutover time")
+	}
+	if err := s.WaitForReplicatedTime(ctx, cutoverTime); err != nil {
+		return err
+	}
+	s.Close(ctx)
+
+	span := s.cfg.Span
+	for {
+		b := &kv.Batch{}
+		b.Header.MaxSpanRequestKeys = revertBatchSize
+		b.AddRawRequest(&kvpb.RevertRangeRequest{
+			RequestHeader: kvpb.RequestHeaderFromSpan(span),
+			TargetTime:    cutoverTime,
+		})
+		if err := s.cfg.Destination.Run(ctx, b); err != nil {
+			return errors.Wrapf(err, "reverting %s to %s", span, cutoverTime)
+		}
+		resp := b.RawResponse().Responses[0].GetRevertRange()
+		if resp.ResumeSpan == nil {
+			break
+		}
+		span = *resp.ResumeSpan
+	}
+	log.Infof(ctx, "cut over %s at %s", s.cfg.Span, cutoverTime)
+	return nil
+}
+
+// Close stops the stream. The replicated time of the stream can be used to
+// resume it later on.
+func (s *Stream) Close(ctx context.Context) {
+	s.mu.Lock()
+	if s.mu.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.mu.closed = true
+	close(s.mu.advanced)
+	s.mu.advanced = make(chan struct{})
+	s.mu.Unlock()
+
+	s.cancel()
+	s.feed.Close()
+	s.batcher.Close(ctx)
+}
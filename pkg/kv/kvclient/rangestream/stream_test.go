@@ -0,0 +1,115 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package rangestream_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/rangefeed"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/rangestream"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStreamCutover replicates a span between two clusters, and checks that
+// the destination contains exactly the data of the source as of the cutover
+// time after a cutover.
+func TestStreamCutover(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	args := base.TestServerArgs{
+		DefaultTestTenant: base.TestIsSpecificToStorageLayerAndNeedsASystemTenant,
+	}
+	srcSrv := serverutils.StartServerOnly(t, args)
+	defer srcSrv.Stopper().Stop(ctx)
+	dstSrv := serverutils.StartServerOnly(t, args)
+	defer dstSrv.Stopper().Stop(ctx)
+	kvserver.RangefeedEnabled.Override(ctx, &srcSrv.ClusterSettings().SV, true)
+
+	scratchKey, err := srcSrv.ScratchRange()
+	require.NoError(t, err)
+	_, err = dstSrv.ScratchRange()
+	require.NoError(t, err)
+	mkKey := func(k string) roachpb.Key {
+		return append(scratchKey[:len(scratchKey):len(scratchKey)], k...)
+	}
+	span := roachpb.Span{Key: scratchKey, EndKey: scratchKey.PrefixEnd()}
+	src, dst := srcSrv.DB(), dstSrv.DB()
+
+	// Data written before the stream starts is copied by the initial scan.
+	require.NoError(t, src.Put(ctx, mkKey("a"), "1"))
+	require.NoError(t, src.Put(ctx, mkKey("b"), "1"))
+
+	s, err := rangestream.Start(ctx, rangestream.Config{
+		Span:        span,
+		Source:      src,
+		SourceFeeds: srcSrv.RangeFeedFactory().(*rangefeed.Factory),
+		Destination: dst,
+		Settings:    dstSrv.ClusterSettings(),
+	})
+	require.NoError(t, err)
+	defer s.Close(ctx)
+
+	// Data written afterwards is streamed.
+	require.NoError(t, src.Put(ctx, mkKey("a"), "2"))
+	_, err = src.Del(ctx, mkKey("b"))
+	require.NoError(t, err)
+	require.NoError(t, src.Put(ctx, mkKey("c"), "2"))
+	cutoverTime := src.Clock().Now()
+	require.NoError(t, src.Put(ctx, mkKey("c"), "3"))
+	require.NoError(t, src.Put(ctx, mkKey("d"), "3"))
+
+	require.NoError(t, s.WaitForReplicatedTime(ctx, src.Clock().Now()))
+	replicatedTime, err := s.ReplicatedTime()
+	require.NoError(t, err)
+	require.True(t, cutoverTime.Less(replicatedTime))
+
+	read := func(db *kv.DB, asOf hlc.Timestamp) map[string]string {
+		kvs := make(map[string]string)
+		require.NoError(t, db.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
+			if !asOf.IsEmpty() {
+				if err := txn.SetFixedTimestamp(ctx, asOf); err != nil {
+					return err
+				}
+			}
+			rows, err := txn.Scan(ctx, span.Key, span.EndKey, 0 /* maxRows */)
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				v, err := row.Value.GetBytes()
+				if err != nil {
+					return err
+				}
+				kvs[string(row.Key[len(scratchKey):])] = string(v)
+			}
+			return nil
+		}))
+		return kvs
+	}
+	require.Equal(t, read(src, replicatedTime), read(dst, replicatedTime))
+	require.Equal(t, map[string]string{"a": "2", "c": "3", "d": "3"}, read(dst, hlc.Timestamp{}))
+
+	// After the cutover, the destination contains the data of the source as of
+	// the cutover time.
+	require.NoError(t, s.Cutover(ctx, cutoverTime))
+	require.Equal(t, map[string]string{"a": "2", "c": "2"}, read(dst, hlc.Timestamp{}))
+}
@@ -0,0 +1,178 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// RecomputeStatsReport reports the drift of the MVCC stats of the ranges
+// whose stats were recomputed.
+type RecomputeStatsReport struct {
+	// Ranges is the number of ranges whose stats were recomputed.
+	Ranges int64
+	// DriftedRanges is the number of ranges whose stats differed from their
+	// recomputation.
+	DriftedRanges int64
+	// Delta is the sum of the adjustments of the stats of the ranges, i.e. the
+	// drift of their stats. LastUpdateNanos, GCBytesAge and LockAge are unset:
+	// the adjustment of the ages mostly reflects the time elapsed since the
+	// stats were last updated, as they are only aged on updates.
+	Delta enginepb.MVCCStats
+}
+
+// add adds the adjustment of the stats of a range to the report.
+func (r *RecomputeStatsReport) add(delta enginepb.MVCCStats) {
+	delta.LastUpdateNanos, delta.GCBytesAge, delta.LockAge = 0, 0, 0
+	r.Ranges++
+	if delta != (enginepb.MVCCStats{}) {
+		r.DriftedRanges++
+	}
+	r.Delta.Add(delta)
+}
+
+// String implements the fmt.Stringer interface.
+func (r RecomputeStatsReport) String() string {
+	return fmt.Sprintf("recomputed the stats of %d ranges, %d of which had drifted: "+
+		"live bytes %+d, key bytes %+d, val bytes %+d, live count %+d, key count %+d, "+
+		"val count %+d, intent count %+d, sys bytes %+d, sys count %+d",
+		r.Ranges, r.DriftedRanges, r.Delta.LiveBytes, r.Delta.KeyBytes, r.Delta.ValBytes,
+		r.Delta.LiveCount, r.Delta.KeyCount, r.Delta.ValCount, r.Delta.IntentCount,
+		r.Delta.SysBytes, r.Delta.SysCount)
+}
+
+// RecomputeStatsProgress records the progress of RecomputeStats. It can be
+// passed to a subsequent invocation to resume an interrupted recomputation.
+type RecomputeStatsProgress struct {
+	// ResumeKey is the key from which the recomputation resumes: the stats of
+	// the ranges before it have been recomputed. It is nil once the stats of
+	// all the ranges have been recomputed.
+	ResumeKey roachpb.Key
+	// Report is the drift report of the ranges whose stats have been
+	// recomputed.
+	Report RecomputeStatsReport
+}
+
+// RecomputeStatsOptions are the options of RecomputeStats.
+type RecomputeStatsOptions struct {
+	// RangesPerSecond bounds the rate at which the stats of the ranges are
+	// recomputed, each recomputation reading all the data of the range on its
+	// leaseholder. It defaults to 10.
+	RangesPerSecond float64
+	// DryRun, if set, computes the drift of the stats without adjusting them.
+	DryRun bool
+	// Resume, if set, is the progress of a previous invocation over the same
+	// span. The ranges it covers are not recomputed again.
+	Resume *RecomputeStatsProgress
+	// OnProgress, if set, is invoked with the progress at most once every
+	// CheckpointInterval, and once all the ranges are done. It can be used to
+	// persist the progress.
+	OnProgress func(context.Context, RecomputeStatsProgress)
+	// CheckpointInterval is the minimum interval between two invocations of
+	// OnProgress. If zero, OnProgress is invoked after every range.
+	CheckpointInterval time.Duration
+}
+
+// RecomputeStats recomputes the MVCC stats of the ranges of the given span, in
+// order, through RecomputeStatsRequests paced by opts.RangesPerSecond. It
+// replaces looping over the ranges of a span, e.g. the span of a database, to
+// correct the drift of their stats. The returned progress holds the drift
+// report, and can be used to resume the recomputation on error.
+//
+// Ranges straddling the boundaries of the span are recomputed in full. The
+// first range of the keyspace can't be addressed by a RecomputeStatsRequest,
+// which requires the start key of the range, and is skipped.
+func RecomputeStats(
+	ctx context.Context, db *kv.DB, span roachpb.Span, opts RecomputeStatsOptions,
+) (RecomputeStatsProgress, error) {
+	if opts.RangesPerSecond <= 0 {
+		opts.RangesPerSecond = 10
+	}
+	progress := RecomputeStatsProgress{ResumeKey: span.Key}
+	if opts.Resume != nil {
+		progress = *opts.Resume
+		if progress.ResumeKey == nil {
+			return progress, nil
+		}
+		if !span.ContainsKey(progress.ResumeKey) {
+			return progress, errors.Errorf("resume key %s is outside of %s", progress.ResumeKey, span)
+		}
+	}
+	limiter := quotapool.NewRateLimiter("recompute-stats", quotapool.Limit(opts.RangesPerSecond), 1)
+	var lastCheckpoint time.Time
+	for progress.ResumeKey != nil {
+		if err := limiter.WaitN(ctx, 1); err != nil {
+			return progress, err
+		}
+		desc, err := lookupRange(ctx, db, progress.ResumeKey)
+		if err != nil {
+			return progress, errors.Wrapf(err, "looking up the range of %s", progress.ResumeKey)
+		}
+		if !desc.StartKey.Equal(roachpb.RKeyMin) {
+			delta, err := recomputeRangeStats(ctx, db, desc, opts.DryRun)
+			if err != nil {
+				return progress, errors.Wrapf(err, "recomputing the stats of r%d", desc.RangeID)
+			}
+			progress.Report.add(delta)
+			log.VEventf(ctx, 2, "recomputed the stats of r%d: %+v", desc.RangeID, delta)
+		}
+		progress.ResumeKey = desc.EndKey.AsRawKey()
+		if !progress.ResumeKey.Less(span.EndKey) {
+			progress.ResumeKey = nil
+		}
+		if opts.OnProgress != nil && (progress.ResumeKey == nil ||
+			timeutil.Since(lastCheckpoint) >= opts.CheckpointInterval) {
+			opts.OnProgress(ctx, progress)
+			lastCheckpoint = timeutil.Now()
+		}
+	}
+	return progress, nil
+}
+
+// lookupRange returns the descriptor of the range containing the given key.
+func lookupRange(ctx context.Context, db *kv.DB, key roachpb.Key) (roachpb.RangeDescriptor, error) {
+	var desc roachpb.RangeDescriptor
+	err := db.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
+		kvs, err := ScanMetaKVs(ctx, txn, roachpb.Span{Key: key, EndKey: key.Next()})
+		if err != nil {
+			return err
+		}
+		return kvs[0].ValueProto(&desc)
+	})
+	return desc, err
+}
+
+// recomputeRangeStats recomputes the stats of the given range, returning the
+// adjustment of its stats.
+func recomputeRangeStats(
+	ctx context.Context, db *kv.DB, desc roachpb.RangeDescriptor, dryRun bool,
+) (enginepb.MVCCStats, error) {
+	var b kv.Batch
+	b.AddRawRequest(&kvpb.RecomputeStatsRequest{
+		RequestHeader: kvpb.RequestHeader{Key: desc.StartKey.AsRawKey()},
+		DryRun:        dryRun,
+	})
+	if err := db.Run(ctx, &b); err != nil {
+		return enginepb.MVCCStats{}, err
+	}
+	return b.RawResponse().Responses[0].GetRecomputeStats().AddedDelta.ToStats(), nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecomputeStats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s := serverutils.StartServerOnly(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+	db := s.DB()
+
+	scratch, err := s.ScratchRange()
+	require.NoError(t, err)
+	key := func(suffix string) roachpb.Key {
+		return append(scratch[:len(scratch):len(scratch)], suffix...)
+	}
+	for _, k := range []string{"b", "c"} {
+		_, _, err := s.SplitRange(key(k))
+		require.NoError(t, err)
+	}
+	for _, k := range []string{"a", "b", "c"} {
+		require.NoError(t, db.Put(ctx, key(k), "value"))
+	}
+	span := roachpb.Span{Key: scratch, EndKey: key("d")}
+
+	// Resume a recomputation which already recomputed the first range. The
+	// last range straddles the end of the span.
+	progress, err := RecomputeStats(ctx, db, span, RecomputeStatsOptions{
+		DryRun: true,
+		Resume: &RecomputeStatsProgress{
+			ResumeKey: key("b"),
+			Report:    RecomputeStatsReport{Ranges: 1},
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, progress.ResumeKey)
+	require.Equal(t, int64(3), progress.Report.Ranges)
+
+	// The progress is checkpointed at most once per interval, and at the end.
+	var checkpoints []RecomputeStatsProgress
+	progress, err = RecomputeStats(ctx, db, span, RecomputeStatsOptions{
+		RangesPerSecond: 100,
+		OnProgress: func(_ context.Context, p RecomputeStatsProgress) {
+			checkpoints = append(checkpoints, p)
+		},
+		CheckpointInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 2)
+	require.Equal(t, key("b"), checkpoints[0].ResumeKey)
+	require.Equal(t, int64(1), checkpoints[0].Report.Ranges)
+	require.Equal(t, progress, checkpoints[1])
+	require.Equal(t, int64(3), progress.Report.Ranges)
+	t.Log(progress.Report)
+
+	// Once recomputed, the stats haven't drifted.
+	progress, err = RecomputeStats(ctx, db, span, RecomputeStatsOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), progress.Report.Ranges)
+	require.Zero(t, progress.Report.DriftedRanges)
+
+	// The resume key must be within the span.
+	_, err = RecomputeStats(ctx, db, span, RecomputeStatsOptions{
+		Resume: &RecomputeStatsProgress{ResumeKey: key("e")},
+	})
+	require.ErrorContains(t, err, "outside of")
+}
@@ -0,0 +1,226 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvclient
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// RelocateRangesTarget describes the desired replicas of the ranges of a span.
+type RelocateRangesTarget struct {
+	// Span is the span whose ranges are relocated. Ranges straddling the
+	// boundaries of the span are relocated in full; the span should be split at
+	// its boundaries beforehand if that is undesirable.
+	Span                      roachpb.Span
+	VoterTargets              []roachpb.ReplicationTarget
+	NonVoterTargets           []roachpb.ReplicationTarget
+	TransferLeaseToFirstVoter bool
+}
+
+// RelocateRangesProgress records the progress of AdminRelocateRanges. It can
+// be passed to a subsequent invocation to resume an interrupted relocation.
+type RelocateRangesProgress struct {
+	// Done are the spans of the ranges which have been relocated.
+	Done []roachpb.Span
+}
+
+// RelocateRangesOptions are the options of AdminRelocateRanges.
+type RelocateRangesOptions struct {
+	// MaxConcurrency bounds the number of ranges relocated concurrently. It
+	// defaults to 4.
+	MaxConcurrency int
+	// MaxConcurrentSnapshotsPerStore bounds the number of concurrent
+	// relocations which add a replica, and thus send a snapshot, to the same
+	// store. It defaults to 1, which matches the number of snapshots a store
+	// applies concurrently by default.
+	MaxConcurrentSnapshotsPerStore int
+	// Resume, if set, is the progress of a previous invocation with the same
+	// targets. The ranges it covers are not relocated again.
+	Resume *RelocateRangesProgress
+	// OnProgress, if set, is invoked with the progress every time a range has
+	// been relocated. It can be used to persist the progress.
+	OnProgress func(context.Context, RelocateRangesProgress)
+}
+
+// relocation is the relocation of a single range.
+type relocation struct {
+	desc   roachpb.RangeDescriptor
+	target *RelocateRangesTarget
+	// snapshotStores are the stores which receive a snapshot to add a replica.
+	snapshotStores []roachpb.StoreID
+}
+
+// AdminRelocateRanges relocates the replicas of the ranges of multiple spans
+// onto the specified stores. It replaces looping over the ranges and
+// relocating each of them with AdminRelocateRange.
+//
+// The relocations are planned together: ranges are relocated concurrently, as
+// long as the stores receiving a replica are not already receiving too many
+// snapshots, which would otherwise be queued by the stores. Each range is
+// relocated through AdminRelocateRange, which changes its replicas atomically.
+// On error, the relocation of the ranges in flight is awaited, and the
+// returned progress can be used to resume the relocation of the remaining
+// ranges.
+func AdminRelocateRanges(
+	ctx context.Context, db *kv.DB, targets []RelocateRangesTarget, opts RelocateRangesOptions,
+) (RelocateRangesProgress, error) {
+	if opts.MaxConcurrency <= 0 {
+		opts.MaxConcurrency = 4
+	}
+	if opts.MaxConcurrentSnapshotsPerStore <= 0 {
+		opts.MaxConcurrentSnapshotsPerStore = 1
+	}
+	var done roachpb.SpanGroup
+	if opts.Resume != nil {
+		done.Add(opts.Resume.Done...)
+	}
+	progress := func() RelocateRangesProgress {
+		return RelocateRangesProgress{Done: done.Slice()}
+	}
+
+	pending, err := planRelocations(ctx, db, targets, &done)
+	if err != nil {
+		return progress(), err
+	}
+
+	type result struct {
+		r   *relocation
+		err error
+	}
+	results := make(chan result, len(pending))
+	snapshots := map[roachpb.StoreID]int{}
+	fits := func(r *relocation) bool {
+		for _, s := range r.snapshotStores {
+			if snapshots[s] >= opts.MaxConcurrentSnapshotsPerStore {
+				return false
+			}
+		}
+		return true
+	}
+
+	g := ctxgroup.WithContext(ctx)
+	var retErr error
+	running := 0
+	for len(pending) > 0 || running > 0 {
+		// Start the relocation of as many ranges as the limits allow, in order.
+		for i := 0; i < len(pending) && running < opts.MaxConcurrency; {
+			r := pending[i]
+			if !fits(r) {
+				i++
+				continue
+			}
+			pending = append(pending[:i], pending[i+1:]...)
+			for _, s := range r.snapshotStores {
+				snapshots[s]++
+			}
+			running++
+			g.GoCtx(func(ctx context.Context) error {
+				err := db.AdminRelocateRange(ctx, r.desc.StartKey.AsRawKey(),
+					r.target.VoterTargets, r.target.NonVoterTargets, r.target.TransferLeaseToFirstVoter)
+				results <- result{r: r, err: err}
+				return nil
+			})
+		}
+
+		var res result
+		select {
+		case res = <-results:
+		case <-ctx.Done():
+			// Don't start new relocations, and wait for the ones in flight, which
+			// observe the cancellation too.
+			pending = nil
+			res = <-results
+		}
+		running--
+		for _, s := range res.r.snapshotStores {
+			snapshots[s]--
+		}
+		if res.err != nil {
+			if retErr == nil {
+				retErr = errors.Wrapf(res.err, "relocating r%d", res.r.desc.RangeID)
+			}
+			// Let the relocations in flight finish, but don't start new ones.
+			pending = nil
+			continue
+		}
+		done.Add(res.r.desc.RSpan().AsRawSpanWithNoLocals())
+		log.VEventf(ctx, 2, "relocated r%d", res.r.desc.RangeID)
+		if opts.OnProgress != nil {
+			opts.OnProgress(ctx, progress())
+		}
+	}
+	if err := g.Wait(); err != nil && retErr == nil {
+		retErr = err
+	}
+	if retErr == nil {
+		retErr = ctx.Err()
+	}
+	return progress(), retErr
+}
+
+// planRelocations returns the relocations of the ranges of the given targets,
+// in order, skipping the ranges which are already done.
+func planRelocations(
+	ctx context.Context, db *kv.DB, targets []RelocateRangesTarget, done *roachpb.SpanGroup,
+) ([]*relocation, error) {
+	var relocations []*relocation
+	seen := map[roachpb.RangeID]struct{}{}
+	for i := range targets {
+		target := &targets[i]
+		var descs []roachpb.RangeDescriptor
+		if err := db.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
+			descs = descs[:0]
+			kvs, err := ScanMetaKVs(ctx, txn, target.Span)
+			if err != nil {
+				return err
+			}
+			for _, metaKV := range kvs {
+				var desc roachpb.RangeDescriptor
+				if err := metaKV.ValueProto(&desc); err != nil {
+					return err
+				}
+				descs = append(descs, desc)
+			}
+			return nil
+		}); err != nil {
+			return nil, errors.Wrapf(err, "looking up the ranges of %s", target.Span)
+		}
+
+		for _, desc := range descs {
+			if _, ok := seen[desc.RangeID]; ok {
+				return nil, errors.Errorf(
+					"r%d is covered by multiple relocation targets", desc.RangeID)
+			}
+			seen[desc.RangeID] = struct{}{}
+			if done.Encloses(desc.RSpan().AsRawSpanWithNoLocals()) {
+				continue
+			}
+			r := &relocation{desc: desc, target: target}
+			for _, targets := range [][]roachpb.ReplicationTarget{
+				target.VoterTargets, target.NonVoterTargets,
+			} {
+				for _, t := range targets {
+					if _, ok := desc.GetReplicaDescriptor(t.StoreID); !ok {
+						r.snapshotStores = append(r.snapshotStores, t.StoreID)
+					}
+				}
+			}
+			relocations = append(relocations, r)
+		}
+	}
+	return relocations, nil
+}
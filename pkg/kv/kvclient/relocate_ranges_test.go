@@ -0,0 +1,71 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/testutils/testcluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminRelocateRanges(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	tc := testcluster.StartTestCluster(t, 3, base.TestClusterArgs{
+		ReplicationMode: base.ReplicationManual,
+	})
+	defer tc.Stopper().Stop(ctx)
+	db := tc.Server(0).DB()
+
+	scratch := tc.ScratchRange(t)
+	key := func(suffix string) roachpb.Key {
+		return append(scratch[:len(scratch):len(scratch)], suffix...)
+	}
+	tc.SplitRangeOrFatal(t, key("b"))
+	tc.SplitRangeOrFatal(t, key("c"))
+
+	targets := []RelocateRangesTarget{{
+		Span:         roachpb.Span{Key: scratch, EndKey: key("d")},
+		VoterTargets: tc.Targets(0, 1, 2),
+	}}
+	// Resume a relocation which already relocated the first range.
+	var progressCalls int
+	progress, err := AdminRelocateRanges(ctx, db, targets, RelocateRangesOptions{
+		Resume: &RelocateRangesProgress{Done: []roachpb.Span{{Key: scratch, EndKey: key("b")}}},
+		OnProgress: func(context.Context, RelocateRangesProgress) {
+			progressCalls++
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, progressCalls)
+	require.Len(t, progress.Done, 1)
+	require.Equal(t, scratch, progress.Done[0].Key)
+
+	require.Len(t, tc.LookupRangeOrFatal(t, scratch).Replicas().VoterDescriptors(), 1)
+	for _, k := range []roachpb.Key{key("b"), key("c")} {
+		require.Len(t, tc.LookupRangeOrFatal(t, k).Replicas().VoterDescriptors(), 3)
+	}
+
+	// Ranges can't be covered by multiple targets.
+	_, err = AdminRelocateRanges(ctx, db, []RelocateRangesTarget{
+		{Span: roachpb.Span{Key: scratch, EndKey: key("a")}, VoterTargets: tc.Targets(0)},
+		{Span: roachpb.Span{Key: key("a"), EndKey: key("b")}, VoterTargets: tc.Targets(1)},
+	}, RelocateRangesOptions{})
+	require.ErrorContains(t, err, "covered by multiple relocation targets")
+}
@@ -663,6 +663,7 @@ func (r *BarrierResponse) combine(_ context.Context, c combinable, _ *BatchReque
 			return err
 		}
 		r.Timestamp.Forward(otherR.Timestamp)
+		r.AppliedIndexes = append(r.AppliedIndexes, otherR.AppliedIndexes...)
 	}
 	return nil
 }
@@ -702,6 +703,34 @@ func (r *IsSpanEmptyResponse) combine(_ context.Context, c combinable, _ *BatchR
 
 var _ combinable = &IsSpanEmptyResponse{}
 
+// combine implements the combinable interface.
+func (r *WriteFenceResponse) combine(_ context.Context, c combinable, _ *BatchRequest) error {
+	otherR := c.(*WriteFenceResponse)
+	if r != nil {
+		if err := r.ResponseHeader.combine(otherR.Header()); err != nil {
+			return err
+		}
+		r.Fences = append(r.Fences, otherR.Fences...)
+	}
+	return nil
+}
+
+var _ combinable = &WriteFenceResponse{}
+
+// combine implements the combinable interface.
+func (r *CheckAndMutateResponse) combine(_ context.Context, c combinable, _ *BatchRequest) error {
+	otherR := c.(*CheckAndMutateResponse)
+	if r != nil {
+		if err := r.ResponseHeader.combine(otherR.Header()); err != nil {
+			return err
+		}
+		r.Failures = append(r.Failures, otherR.Failures...)
+	}
+	return nil
+}
+
+var _ combinable = &CheckAndMutateResponse{}
+
 // Header implements the Request interface.
 func (rh RequestHeader) Header() RequestHeader {
 	return rh
@@ -747,9 +776,33 @@ func (h *BatchResponse_Header) combine(o BatchResponse_Header) error {
 	h.Now.Forward(o.Now)
 	h.RangeInfos = append(h.RangeInfos, o.RangeInfos...)
 	h.CollectedSpans = append(h.CollectedSpans, o.CollectedSpans...)
+	if o.CommitLatencyBreakdown != nil {
+		h.EnsureCommitLatencyBreakdown().combine(*o.CommitLatencyBreakdown)
+	}
+	h.RangePlacements = append(h.RangePlacements, o.RangePlacements...)
+	h.TruncatedBelowGCThreshold.Forward(o.TruncatedBelowGCThreshold)
+	h.AdaptiveTargetBytesHint = max(h.AdaptiveTargetBytesHint, o.AdaptiveTargetBytesHint)
 	return nil
 }
 
+// EnsureCommitLatencyBreakdown returns the commit latency breakdown of the
+// response, allocating it if needed.
+func (h *BatchResponse_Header) EnsureCommitLatencyBreakdown() *CommitLatencyBreakdown {
+	if h.CommitLatencyBreakdown == nil {
+		h.CommitLatencyBreakdown = &CommitLatencyBreakdown{}
+	}
+	return h.CommitLatencyBreakdown
+}
+
+// combine merges the breakdown of a partial batch evaluated in parallel with
+// the one of b, keeping the maximum of each component.
+func (b *CommitLatencyBreakdown) combine(o CommitLatencyBreakdown) {
+	b.PipelineProving = max(b.PipelineProving, o.PipelineProving)
+	b.IntentResolution = max(b.IntentResolution, o.IntentResolution)
+	b.Replication = max(b.Replication, o.Replication)
+	b.LatchWait = max(b.LatchWait, o.LatchWait)
+}
+
 // SetHeader implements the Response interface.
 func (rh *ResponseHeader) SetHeader(other ResponseHeader) {
 	*rh = other
@@ -981,6 +1034,24 @@ func (*BarrierRequest) Method() Method { return Barrier }
 // Method implements the Request interface.
 func (*IsSpanEmptyRequest) Method() Method { return IsSpanEmpty }
 
+// Method implements the Request interface.
+func (*WriteFenceRequest) Method() Method { return WriteFence }
+
+// Method implements the Request interface.
+func (*PinReplicasRequest) Method() Method { return PinReplicas }
+
+// Method implements the Request interface.
+func (*ColdCompactionRequest) Method() Method { return ColdCompaction }
+
+// Method implements the Request interface.
+func (*AdminRelocateToLocalityRequest) Method() Method { return AdminRelocateToLocality }
+
+// Method implements the Request interface.
+func (*CheckAndMutateRequest) Method() Method { return CheckAndMutate }
+
+// Method implements the Request interface.
+func (*HotStandbyRequest) Method() Method { return HotStandby }
+
 // ShallowCopy implements the Request interface.
 func (gr *GetRequest) ShallowCopy() Request {
 	shallowCopy := *gr
@@ -1269,6 +1340,42 @@ func (r *IsSpanEmptyRequest) ShallowCopy() Request {
 	return &shallowCopy
 }
 
+// ShallowCopy implements the Request interface.
+func (r *WriteFenceRequest) ShallowCopy() Request {
+	shallowCopy := *r
+	return &shallowCopy
+}
+
+// ShallowCopy implements the Request interface.
+func (r *PinReplicasRequest) ShallowCopy() Request {
+	shallowCopy := *r
+	return &shallowCopy
+}
+
+// ShallowCopy implements the Request interface.
+func (r *ColdCompactionRequest) ShallowCopy() Request {
+	shallowCopy := *r
+	return &shallowCopy
+}
+
+// ShallowCopy implements the Request interface.
+func (r *AdminRelocateToLocalityRequest) ShallowCopy() Request {
+	shallowCopy := *r
+	return &shallowCopy
+}
+
+// ShallowCopy implements the Request interface.
+func (r *CheckAndMutateRequest) ShallowCopy() Request {
+	shallowCopy := *r
+	return &shallowCopy
+}
+
+// ShallowCopy implements the Request interface.
+func (r *HotStandbyRequest) ShallowCopy() Request {
+	shallowCopy := *r
+	return &shallowCopy
+}
+
 // NewLockingGet returns a Request initialized to get the value at key. A lock
 // corresponding to the supplied lock strength and durability is acquired on the
 // key, if it exists.
@@ -1371,6 +1478,29 @@ func NewConditionalPutInline(
 	}
 }
 
+// NewCheckAndMutate returns a Request initialized to atomically apply the
+// given mutations, addressed at the span covering their keys. The values of
+// the mutations are checksummed in place.
+func NewCheckAndMutate(mutations []CheckAndMutateRequest_Mutation) Request {
+	var span roachpb.Span
+	for i := range mutations {
+		m := &mutations[i]
+		if !m.Delete {
+			m.Value.InitChecksum(m.Key)
+		}
+		if span.Key == nil || m.Key.Compare(span.Key) < 0 {
+			span.Key = m.Key
+		}
+		if span.EndKey == nil || m.Key.Compare(span.EndKey) >= 0 {
+			span.EndKey = m.Key.Next()
+		}
+	}
+	return &CheckAndMutateRequest{
+		RequestHeader: RequestHeaderFromSpan(span),
+		Mutations:     mutations,
+	}
+}
+
 // NewInitPut returns a Request initialized to put the value at key, as long as
 // the key doesn't exist, returning a ConditionFailedError if the key exists and
 // the existing value is different from value. If failOnTombstones is set to
@@ -1770,6 +1900,25 @@ func (*QueryResolvedTimestampRequest) flags() flag {
 func (*BarrierRequest) flags() flag     { return isWrite | isRange }
 func (*IsSpanEmptyRequest) flags() flag { return isRead | isRange }
 
+// WriteFence requests are always evaluated as writes, even when listing fences,
+// so that they are serialized with concurrent changes to the range's fences.
+func (*WriteFenceRequest) flags() flag { return isWrite | isRange | isAlone }
+
+func (*PinReplicasRequest) flags() flag { return isWrite | isAlone }
+
+func (*ColdCompactionRequest) flags() flag { return isWrite | isAlone }
+
+func (*AdminRelocateToLocalityRequest) flags() flag { return isAdmin | isAlone }
+
+// CheckAndMutate, like DeleteRange, reads keys it may not write to when a
+// condition fails, so it updates the timestamp cache and needs a refresh.
+func (*CheckAndMutateRequest) flags() flag {
+	return isRead | isWrite | isTxn | isLocking | isIntentWrite | isRange |
+		appliesTSCache | updatesTSCache | needsRefresh | canBackpressure
+}
+
+func (*HotStandbyRequest) flags() flag { return isWrite | isAlone }
+
 // IsParallelCommit returns whether the EndTxn request is attempting to perform
 // a parallel commit. See txn_interceptor_committer.go for a discussion about
 // parallel commits.
@@ -1823,6 +1972,9 @@ func (e *RangeFeedEvent) ShallowCopy() *RangeFeedEvent {
 	case *RangeFeedDeleteRange:
 		cpyDelRange := *t
 		cpy.MustSetValue(&cpyDelRange)
+	case *RangeFeedMVCCHistoryMutation:
+		cpyHistMut := *t
+		cpy.MustSetValue(&cpyHistMut)
 	case *RangeFeedError:
 		cpyErr := *t
 		cpy.MustSetValue(&cpyErr)
@@ -878,6 +878,30 @@ func (ba *BatchRequest) ValidateForEvaluation() error {
 			return errors.AssertionFailedf("WriteTooOld set but no offset in timestamps. txn: %s", ba.Txn)
 		}
 	}
+	if hint := ba.TxnRecordKeyHint; len(hint) > 0 {
+		if ba.Txn == nil {
+			return errors.Errorf("transaction record key hint set on non-transactional batch")
+		}
+		if bytes.HasPrefix(hint, roachpb.LocalPrefix) {
+			return errors.Errorf("transaction record key hint %s is not a global key", hint)
+		}
+		// The TxnCoordSender drops the hint from batches whose transaction was
+		// anchored elsewhere, so a mismatch indicates a client bug.
+		if len(ba.Txn.Key) > 0 && !hint.Equal(ba.Txn.Key) {
+			return errors.AssertionFailedf("transaction record key hint %s does not match "+
+				"the anchor key %s of txn %s", hint, ba.Txn.Key, ba.Txn.Short())
+		}
+	}
+	if ba.AllowReadBelowGCThreshold {
+		// Reading below the GC threshold can miss MVCC versions, which would
+		// violate the isolation of transactions.
+		if ba.Txn != nil {
+			return errors.Errorf("reads below the GC threshold are not permitted in transactions")
+		}
+		if !ba.IsReadOnly() {
+			return errors.Errorf("reads below the GC threshold are only permitted in read-only batches")
+		}
+	}
 	return nil
 }
 
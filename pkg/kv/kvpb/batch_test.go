@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/isolation"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
@@ -384,6 +385,132 @@ func TestRefreshSpanIterateSkipLocked(t *testing.T) {
 	require.Equal(t, expReadSpans, readSpans)
 }
 
+// TestBatchResponseCombineCommitLatencyBreakdown tests that the commit latency
+// breakdowns of partial batches are combined by keeping the maximum of each
+// component.
+func TestBatchResponseCombineCommitLatencyBreakdown(t *testing.T) {
+	br := &BatchResponse{}
+	require.NoError(t, br.Combine(context.Background(), &BatchResponse{}, nil, &BatchRequest{}))
+	require.Nil(t, br.CommitLatencyBreakdown)
+
+	for _, b := range []CommitLatencyBreakdown{
+		{PipelineProving: 3 * time.Millisecond, LatchWait: time.Millisecond},
+		{Replication: 2 * time.Millisecond, LatchWait: 4 * time.Millisecond},
+		{IntentResolution: time.Millisecond, Replication: time.Millisecond},
+	} {
+		b := b
+		other := &BatchResponse{BatchResponse_Header: BatchResponse_Header{CommitLatencyBreakdown: &b}}
+		require.NoError(t, br.Combine(context.Background(), other, nil, &BatchRequest{}))
+	}
+	require.Equal(t, &CommitLatencyBreakdown{
+		PipelineProving:  3 * time.Millisecond,
+		IntentResolution: time.Millisecond,
+		Replication:      2 * time.Millisecond,
+		LatchWait:        4 * time.Millisecond,
+	}, br.CommitLatencyBreakdown)
+}
+
+// TestBatchResponseCombineRangePlacements tests that the range placements of
+// partial batches are accumulated.
+func TestBatchResponseCombineRangePlacements(t *testing.T) {
+	br := &BatchResponse{}
+	for _, rangeID := range []roachpb.RangeID{1, 2} {
+		other := &BatchResponse{BatchResponse_Header: BatchResponse_Header{
+			RangePlacements: []RangePlacement{{RangeID: rangeID, ReplicationFactor: 3}},
+		}}
+		require.NoError(t, br.Combine(context.Background(), other, nil, &BatchRequest{}))
+	}
+	require.Equal(t, []RangePlacement{
+		{RangeID: 1, ReplicationFactor: 3},
+		{RangeID: 2, ReplicationFactor: 3},
+	}, br.RangePlacements)
+}
+
+// TestBatchResponseCombineTruncatedBelowGCThreshold tests that the highest
+// GC threshold which partial batches read below is retained.
+func TestBatchResponseCombineTruncatedBelowGCThreshold(t *testing.T) {
+	br := &BatchResponse{}
+	for _, wallTime := range []int64{0, 20, 10} {
+		other := &BatchResponse{BatchResponse_Header: BatchResponse_Header{
+			TruncatedBelowGCThreshold: hlc.Timestamp{WallTime: wallTime},
+		}}
+		require.NoError(t, br.Combine(context.Background(), other, nil, &BatchRequest{}))
+	}
+	require.Equal(t, hlc.Timestamp{WallTime: 20}, br.TruncatedBelowGCThreshold)
+}
+
+// TestBatchRequestValidateTxnRecordKeyHint tests the validation of the
+// transaction record key hint of batches received for evaluation.
+func TestBatchRequestValidateTxnRecordKeyHint(t *testing.T) {
+	txn := roachpb.MakeTransaction(
+		"test", roachpb.Key("a"), isolation.Serializable, roachpb.NormalUserPriority,
+		hlc.Timestamp{WallTime: 123}, 0 /* maxOffsetNs */, 99 /* coordinatorNodeID */, 0, false, /* omitInRangefeeds */
+	)
+	unanchored := txn.Clone()
+	unanchored.Key = nil
+	for _, tc := range []struct {
+		name string
+		txn  *roachpb.Transaction
+		hint roachpb.Key
+		err  string
+	}{
+		{"no hint", &txn, nil, ""},
+		{"matching hint", &txn, roachpb.Key("a"), ""},
+		{"unanchored txn", unanchored, roachpb.Key("b"), ""},
+		{"non-transactional", nil, roachpb.Key("a"), "non-transactional batch"},
+		{"local key", unanchored, append(roachpb.LocalPrefix.Clone(), 'a'), "not a global key"},
+		{"mismatched hint", &txn, roachpb.Key("b"), "does not match the anchor key"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ba := &BatchRequest{}
+			ba.RangeID = 1
+			ba.Replica.StoreID = 1
+			ba.Txn = tc.txn
+			ba.TxnRecordKeyHint = tc.hint
+			err := ba.ValidateForEvaluation()
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+}
+
+// TestBatchRequestValidateAllowReadBelowGCThreshold tests that only
+// non-transactional read-only batches may read below the GC threshold.
+func TestBatchRequestValidateAllowReadBelowGCThreshold(t *testing.T) {
+	txn := roachpb.MakeTransaction(
+		"test", roachpb.Key("a"), isolation.Serializable, roachpb.NormalUserPriority,
+		hlc.Timestamp{WallTime: 123}, 0 /* maxOffsetNs */, 99 /* coordinatorNodeID */, 0, false, /* omitInRangefeeds */
+	)
+	for _, tc := range []struct {
+		name string
+		txn  *roachpb.Transaction
+		req  Request
+		err  string
+	}{
+		{"read", nil, &ScanRequest{}, ""},
+		{"transactional read", &txn, &ScanRequest{}, "not permitted in transactions"},
+		{"write", nil, &PutRequest{}, "only permitted in read-only batches"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ba := &BatchRequest{}
+			ba.RangeID = 1
+			ba.Replica.StoreID = 1
+			ba.Txn = tc.txn
+			ba.AllowReadBelowGCThreshold = true
+			ba.Add(tc.req)
+			err := ba.ValidateForEvaluation()
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+}
+
 func TestBatchResponseCombine(t *testing.T) {
 	br := &BatchResponse{}
 	{
@@ -0,0 +1,50 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvpb
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/redact"
+)
+
+// NewCommitTimestampFloorClosedError returns a new
+// CommitTimestampFloorClosedError for a write to the given range, whose closed
+// timestamp reached the given commit timestamp floor.
+func NewCommitTimestampFloorClosedError(
+	rangeID roachpb.RangeID, floor, closedTS hlc.Timestamp,
+) *CommitTimestampFloorClosedError {
+	return &CommitTimestampFloorClosedError{
+		RangeID:         rangeID,
+		Floor:           floor,
+		ClosedTimestamp: closedTS,
+	}
+}
+
+// SafeFormatError implements errors.SafeFormatter.
+func (e *CommitTimestampFloorClosedError) SafeFormatError(p errors.Printer) (next error) {
+	p.Printf("r%d: commit timestamp floor %s is at or below the closed timestamp %s",
+		e.RangeID, e.Floor, e.ClosedTimestamp)
+	return nil
+}
+
+func (e *CommitTimestampFloorClosedError) Error() string {
+	return redact.Sprint(e).StripMarkers()
+}
+
+var _ errors.SafeFormatter = (*CommitTimestampFloorClosedError)(nil)
+
+// IsCommitTimestampFloorClosedError returns true if the error is, or wraps, a
+// CommitTimestampFloorClosedError.
+func IsCommitTimestampFloorClosedError(err error) bool {
+	return errors.HasType(err, (*CommitTimestampFloorClosedError)(nil))
+}
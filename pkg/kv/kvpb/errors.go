@@ -298,6 +298,7 @@ const (
 	RefreshFailedErrType                    ErrorDetailType = 43
 	MVCCHistoryMutationErrType              ErrorDetailType = 44
 	LockConflictErrType                     ErrorDetailType = 45
+	WriteFenceErrType                       ErrorDetailType = 46
 	// When adding new error types, don't forget to update NumErrors below.
 
 	// CommunicationErrType indicates a gRPC error; this is not an ErrorDetail.
@@ -307,7 +308,7 @@ const (
 	// detail. The value 25 is chosen because it's reserved in the errors proto.
 	InternalErrType ErrorDetailType = 25
 
-	NumErrors int = 46
+	NumErrors int = 47
 )
 
 // Register the migration of all errors that used to be in the roachpb package
@@ -1385,6 +1386,33 @@ func (e *MVCCHistoryMutationError) Type() ErrorDetailType {
 
 var _ ErrorDetailInterface = &MVCCHistoryMutationError{}
 
+// NewWriteFenceError returns a new WriteFenceError for a write at the given
+// timestamp that was rejected by the given fence.
+func NewWriteFenceError(fence roachpb.WriteFence, writeTS hlc.Timestamp) *WriteFenceError {
+	return &WriteFenceError{
+		Span:           fence.Span,
+		FenceTimestamp: fence.Timestamp,
+		WriteTimestamp: writeTS,
+	}
+}
+
+func (e *WriteFenceError) Error() string {
+	return redact.Sprint(e).StripMarkers()
+}
+
+func (e *WriteFenceError) SafeFormatError(p errors.Printer) (next error) {
+	p.Printf("write at %s rejected by write fence on span %s at %s",
+		e.WriteTimestamp, e.Span, e.FenceTimestamp)
+	return nil
+}
+
+// Type is part of the ErrorDetailInterface.
+func (e *WriteFenceError) Type() ErrorDetailType {
+	return WriteFenceErrType
+}
+
+var _ ErrorDetailInterface = &WriteFenceError{}
+
 // NewIntentMissingError creates a new IntentMissingError.
 func NewIntentMissingError(key roachpb.Key, wrongIntent *roachpb.Intent) *IntentMissingError {
 	return &IntentMissingError{
@@ -1751,4 +1779,5 @@ var _ errors.SafeFormatter = &OptimisticEvalConflictsError{}
 var _ errors.SafeFormatter = &MinTimestampBoundUnsatisfiableError{}
 var _ errors.SafeFormatter = &RefreshFailedError{}
 var _ errors.SafeFormatter = &MVCCHistoryMutationError{}
+var _ errors.SafeFormatter = &WriteFenceError{}
 var _ errors.SafeFormatter = &UnhandledRetryableError{}
@@ -328,6 +328,26 @@ func TestErrorRedaction(t *testing.T) {
 			err:    &MVCCHistoryMutationError{},
 			expect: "unexpected MVCC history mutation in span ‹/Min›",
 		},
+		{
+			err:    &WriteFenceError{},
+			expect: "write at 0,0 rejected by write fence on span ‹/Min› at 0,0",
+		},
+		{
+			err:    &ReproposalBudgetExceededError{RangeID: 1, Budget: 2, Reproposals: 3},
+			expect: "r1: command rejected instead of being reproposed: 3 commands to repropose, in excess of the reproposal budget of 2",
+		},
+		{
+			err: &LeaseIndexReproposalsExhaustedError{RangeID: 1, Rejections: []LeaseIndexRejection{
+				{MaxLeaseIndex: 10, LeaseAppliedIndex: 12, ProposerLeaseSequence: 3, LeaseSequence: 3},
+				{MaxLeaseIndex: 14, LeaseAppliedIndex: 15, ProposerLeaseSequence: 3, LeaseSequence: 4},
+			}},
+			expect: "r1: command failed the lease applied index check 2 times: [max 10, applied 12, lease 3/3] [max 14, applied 15, lease 3/4]",
+		},
+		{
+			err: &CommitTimestampFloorClosedError{RangeID: 1,
+				Floor: hlc.Timestamp{WallTime: 2}, ClosedTimestamp: hlc.Timestamp{WallTime: 3}},
+			expect: "r1: commit timestamp floor 0.000000002,0 is at or below the closed timestamp 0.000000003,0",
+		},
 		{
 			err:    &UnhandledRetryableError{},
 			expect: "{<nil> 0 {<nil>} ‹<nil>› 0,0}",
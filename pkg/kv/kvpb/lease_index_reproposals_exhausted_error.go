@@ -0,0 +1,67 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvpb
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/redact"
+)
+
+// NewLeaseIndexReproposalsExhaustedError returns a new
+// LeaseIndexReproposalsExhaustedError for a command which the given range
+// stopped reproposing after the given rejections.
+func NewLeaseIndexReproposalsExhaustedError(
+	rangeID roachpb.RangeID, rejections []LeaseIndexRejection,
+) *LeaseIndexReproposalsExhaustedError {
+	return &LeaseIndexReproposalsExhaustedError{
+		RangeID:    rangeID,
+		Rejections: rejections,
+	}
+}
+
+// LeaseChanged returns true if the lease of the range changed while the
+// command was being reproposed, i.e. if any attempt was proposed or rejected
+// under another lease than the first one.
+func (e *LeaseIndexReproposalsExhaustedError) LeaseChanged() bool {
+	if len(e.Rejections) == 0 {
+		return false
+	}
+	seq := e.Rejections[0].ProposerLeaseSequence
+	for _, r := range e.Rejections {
+		if r.ProposerLeaseSequence != seq || r.LeaseSequence != seq {
+			return true
+		}
+	}
+	return false
+}
+
+// SafeFormatError implements errors.SafeFormatter.
+func (e *LeaseIndexReproposalsExhaustedError) SafeFormatError(p errors.Printer) (next error) {
+	p.Printf("r%d: command failed the lease applied index check %d times:", e.RangeID, len(e.Rejections))
+	for _, r := range e.Rejections {
+		p.Printf(" [max %d, applied %d, lease %d/%d]",
+			r.MaxLeaseIndex, r.LeaseAppliedIndex, r.ProposerLeaseSequence, r.LeaseSequence)
+	}
+	return nil
+}
+
+func (e *LeaseIndexReproposalsExhaustedError) Error() string {
+	return redact.Sprint(e).StripMarkers()
+}
+
+var _ errors.SafeFormatter = (*LeaseIndexReproposalsExhaustedError)(nil)
+
+// IsLeaseIndexReproposalsExhaustedError returns true if the error is, or
+// wraps, a LeaseIndexReproposalsExhaustedError.
+func IsLeaseIndexReproposalsExhaustedError(err error) bool {
+	return errors.HasType(err, (*LeaseIndexReproposalsExhaustedError)(nil))
+}
@@ -174,6 +174,24 @@ const (
 	// IsSpanEmpty is a non-transaction read request used to determine whether
 	// a span contains any keys whatsoever (garbage or otherwise).
 	IsSpanEmpty
+	// WriteFence installs, removes or lists write fences, which reject all
+	// writes to a span at or below a timestamp.
+	WriteFence
+	// PinReplicas pins the voters of a range to a set of stores, or removes
+	// the pin of a range.
+	PinReplicas
+	// ColdCompaction sets or removes the cold compaction policy of a range, or
+	// compacts the cold data of a range into the bottom levels of the engines.
+	ColdCompaction
+	// AdminRelocateToLocality moves the replicas of a range onto stores
+	// matching a locality, and verifies that its data is present on them.
+	AdminRelocateToLocality
+	// CheckAndMutate atomically applies a set of conditional puts and deletes
+	// to keys of a single range, if all of their conditions hold.
+	CheckAndMutate
+	// HotStandby designates the replica of a range on a store as its hot
+	// standby, or removes the designation.
+	HotStandby
 	// MaxMethod is the maximum method.
 	MaxMethod Method = iota - 1
 	// NumMethods represents the total number of API methods.
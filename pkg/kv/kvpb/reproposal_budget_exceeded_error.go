@@ -0,0 +1,51 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvpb
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/redact"
+)
+
+// NewReproposalBudgetExceededError returns a new ReproposalBudgetExceededError
+// for a command which the given range rejected, rather than reproposing it
+// along with the given number of other commands, in excess of its budget.
+func NewReproposalBudgetExceededError(
+	rangeID roachpb.RangeID, budget, reproposals int64,
+) *ReproposalBudgetExceededError {
+	return &ReproposalBudgetExceededError{
+		RangeID:     rangeID,
+		Budget:      budget,
+		Reproposals: reproposals,
+	}
+}
+
+// SafeFormatError implements errors.SafeFormatter.
+func (e *ReproposalBudgetExceededError) SafeFormatError(p errors.Printer) (next error) {
+	p.Printf("r%d: command rejected instead of being reproposed: %d commands "+
+		"to repropose, in excess of the reproposal budget of %d",
+		e.RangeID, e.Reproposals, e.Budget)
+	return nil
+}
+
+func (e *ReproposalBudgetExceededError) Error() string {
+	return redact.Sprint(e).StripMarkers()
+}
+
+var _ errors.SafeFormatter = (*ReproposalBudgetExceededError)(nil)
+
+// IsReproposalBudgetExceededError returns true if the error is, or wraps, a
+// ReproposalBudgetExceededError, including when it's the cause of an
+// AmbiguousResultError.
+func IsReproposalBudgetExceededError(err error) bool {
+	return errors.HasType(err, (*ReproposalBudgetExceededError)(nil))
+}
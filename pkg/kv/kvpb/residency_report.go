@@ -0,0 +1,79 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvpb
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/errors"
+)
+
+// SignResidencyReport signs a marshaled ResidencyReport with the private key
+// of a node certificate. Ed25519 keys sign the report itself, while RSA and
+// ECDSA keys sign its SHA-256 digest.
+func SignResidencyReport(key crypto.PrivateKey, report []byte) ([]byte, error) {
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return ed25519.Sign(k, report), nil
+	case *rsa.PrivateKey, *ecdsa.PrivateKey:
+		digest := sha256.Sum256(report)
+		return k.(crypto.Signer).Sign(rand.Reader, digest[:], crypto.SHA256)
+	default:
+		return nil, errors.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// VerifyResidencyReport checks the signature of the report of an
+// AdminRelocateToLocalityResponse against the certificate it carries, and
+// returns the unmarshaled report. The caller is responsible for checking that
+// the certificate is a node certificate issued by the CA of the cluster.
+func VerifyResidencyReport(
+	resp *AdminRelocateToLocalityResponse,
+) (*ResidencyReport, *x509.Certificate, error) {
+	if len(resp.Signature) == 0 {
+		return nil, nil, errors.New("residency report is not signed")
+	}
+	block, _ := pem.Decode(resp.Certificate)
+	if block == nil {
+		return nil, nil, errors.New("no PEM certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parsing the certificate of the residency report")
+	}
+	var algo x509.SignatureAlgorithm
+	switch cert.PublicKeyAlgorithm {
+	case x509.Ed25519:
+		algo = x509.PureEd25519
+	case x509.RSA:
+		algo = x509.SHA256WithRSA
+	case x509.ECDSA:
+		algo = x509.ECDSAWithSHA256
+	default:
+		return nil, nil, errors.Errorf("unsupported public key algorithm %s", cert.PublicKeyAlgorithm)
+	}
+	if err := cert.CheckSignature(algo, resp.Report, resp.Signature); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid signature of the residency report")
+	}
+	report := &ResidencyReport{}
+	if err := protoutil.Unmarshal(resp.Report, report); err != nil {
+		return nil, nil, err
+	}
+	return report, cert, nil
+}
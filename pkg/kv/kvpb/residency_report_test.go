@@ -0,0 +1,81 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvpb
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResidencyReportSignature(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	report := ResidencyReport{
+		Locality: roachpb.Locality{Tiers: []roachpb.Tier{{Key: "region", Value: "eu-west"}}},
+		Status:   CheckConsistencyResponse_RANGE_CONSISTENT,
+		NodeID:   1,
+	}
+	reportBytes, err := protoutil.Marshal(&report)
+	require.NoError(t, err)
+
+	for _, key := range []crypto.Signer{edKey, ecKey} {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "node"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		require.NoError(t, err)
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+		signature, err := SignResidencyReport(key, reportBytes)
+		require.NoError(t, err)
+		resp := &AdminRelocateToLocalityResponse{
+			Report:      reportBytes,
+			Signature:   signature,
+			Certificate: certPEM,
+		}
+		got, cert, err := VerifyResidencyReport(resp)
+		require.NoError(t, err)
+		require.Equal(t, "node", cert.Subject.CommonName)
+		require.Equal(t, report, *got)
+
+		// A tampered report doesn't verify.
+		tampered := *resp
+		tampered.Report = append([]byte(nil), reportBytes...)
+		tampered.Report[len(tampered.Report)-1]++
+		_, _, err = VerifyResidencyReport(&tampered)
+		require.Error(t, err)
+
+		// Neither does an unsigned one.
+		tampered = *resp
+		tampered.Signature = nil
+		_, _, err = VerifyResidencyReport(&tampered)
+		require.Error(t, err)
+	}
+}
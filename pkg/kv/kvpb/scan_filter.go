@@ -0,0 +1,77 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvpb
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/errors"
+)
+
+// ScanFilterMatcher is a compiled ScanFilter.
+type ScanFilterMatcher struct {
+	keyPrefix roachpb.Key
+	keyRegex  *regexp.Regexp
+	value     *ScanValuePredicate
+}
+
+// Compile validates the filter and returns its matcher.
+func (f *ScanFilter) Compile() (*ScanFilterMatcher, error) {
+	m := &ScanFilterMatcher{keyPrefix: f.KeyPrefix, value: f.Value}
+	if f.KeyRegex != "" {
+		var err error
+		if m.keyRegex, err = regexp.Compile(f.KeyRegex); err != nil {
+			return nil, errors.Wrap(err, "invalid key regex of scan filter")
+		}
+	}
+	if f.Value != nil {
+		switch f.Value.Op {
+		case ScanValuePredicate_EQUALS, ScanValuePredicate_PREFIX, ScanValuePredicate_CONTAINS:
+		default:
+			return nil, errors.Errorf("unknown scan filter value op %d", f.Value.Op)
+		}
+	}
+	return m, nil
+}
+
+// Matches returns whether the key-value pair matches the filter. The value is
+// the RawBytes of a roachpb.Value.
+func (m *ScanFilterMatcher) Matches(key roachpb.Key, rawValue []byte) bool {
+	if len(m.keyPrefix) > 0 && !bytes.HasPrefix(key, m.keyPrefix) {
+		return false
+	}
+	if m.keyRegex != nil && !m.keyRegex.Match(key) {
+		return false
+	}
+	if m.value == nil {
+		return true
+	}
+	v := roachpb.Value{RawBytes: rawValue}
+	if v.GetTag() != roachpb.ValueType_BYTES {
+		return false
+	}
+	data, err := v.GetBytes()
+	if err != nil {
+		return false
+	}
+	switch m.value.Op {
+	case ScanValuePredicate_EQUALS:
+		return bytes.Equal(data, m.value.Operand)
+	case ScanValuePredicate_PREFIX:
+		return bytes.HasPrefix(data, m.value.Operand)
+	case ScanValuePredicate_CONTAINS:
+		return bytes.Contains(data, m.value.Operand)
+	default:
+		return false
+	}
+}
@@ -2021,6 +2021,7 @@ func (a *Allocator) ValidLeaseTargets(
 	candidates, _ = storePool.LiveAndDeadReplicas(
 		candidates, false, /* includeSuspectAndDrainingStores */
 	)
+	candidates = excludeNonVotingStores(storePool, candidates)
 
 	if a.knobs == nil || !a.knobs.AllowLeaseTransfersToReplicasNeedingSnapshots {
 		// Only proceed with the lease transfer if we are also the raft leader (we
@@ -2164,6 +2165,31 @@ func (a *Allocator) leaseholderShouldMoveDueToIOOverload(
 	return false
 }
 
+// leaseholderShouldMoveDueToNonVotingStore returns true if the store of the
+// current leaseholder is in non-voting mode, which happens when the mode of a
+// store holding voters is changed.
+func leaseholderShouldMoveDueToNonVotingStore(
+	storePool storepool.AllocatorStorePool, leaseStoreID roachpb.StoreID,
+) bool {
+	store, ok := storePool.GetStoreDescriptor(leaseStoreID)
+	return ok && store.Properties.NonVoting
+}
+
+// excludeNonVotingStores filters out the replicas on stores in non-voting
+// mode, which never hold leases.
+func excludeNonVotingStores(
+	storePool storepool.AllocatorStorePool, replicas []roachpb.ReplicaDescriptor,
+) []roachpb.ReplicaDescriptor {
+	filtered := make([]roachpb.ReplicaDescriptor, 0, len(replicas))
+	for _, repl := range replicas {
+		if store, ok := storePool.GetStoreDescriptor(repl.StoreID); ok && store.Properties.NonVoting {
+			continue
+		}
+		filtered = append(filtered, repl)
+	}
+	return filtered
+}
+
 // leaseholderShouldMoveDueToPreferences returns true if the current leaseholder
 // is in violation of lease preferences _that can otherwise be satisfied_ by
 // some existing replica.
@@ -2235,9 +2261,28 @@ func (a *Allocator) IOOverloadOptions() IOOverloadOptions {
 		ReplicaIOOverloadThreshold:   ReplicaIOOverloadThreshold.Get(&a.st.SV),
 		LeaseIOOverloadThreshold:     LeaseIOOverloadThreshold.Get(&a.st.SV),
 		LeaseIOOverloadShedThreshold: LeaseIOOverloadShedThreshold.Get(&a.st.SV),
+		IOClassAware:                 IOClassAwareAllocation.Get(&a.st.SV),
 	}
 }
 
+// pickLeaseTarget picks a lease transfer target among the given candidates,
+// which are equally good: the one on the preferred store if any, or a random
+// one.
+func (a *Allocator) pickLeaseTarget(
+	candidates []roachpb.ReplicaDescriptor, preferred roachpb.StoreID,
+) roachpb.ReplicaDescriptor {
+	if preferred != 0 {
+		for _, c := range candidates {
+			if c.StoreID == preferred {
+				return c
+			}
+		}
+	}
+	a.randGen.Lock()
+	defer a.randGen.Unlock()
+	return candidates[a.randGen.Intn(len(candidates))]
+}
+
 // TransferLeaseTarget returns a suitable replica to transfer the range lease
 // to from the provided list. It includes the current lease holder replica
 // unless asked to do otherwise by the excludeLeaseRepl parameter.
@@ -2347,9 +2392,7 @@ func (a *Allocator) TransferLeaseTarget(
 		// If we want to ignore the existing lease counts on replicas, just do a
 		// random transfer.
 		if !opts.CheckCandidateFullness {
-			a.randGen.Lock()
-			defer a.randGen.Unlock()
-			return validTargets[a.randGen.Intn(len(validTargets))]
+			return a.pickLeaseTarget(validTargets, opts.PreferredTarget)
 		}
 
 		var bestOption roachpb.ReplicaDescriptor
@@ -2366,7 +2409,8 @@ func (a *Allocator) TransferLeaseTarget(
 			if float64(storeDesc.Capacity.LeaseCount) < candidateLeasesMean-0.5 {
 				candidates = append(candidates, repl)
 			}
-			if storeDesc.Capacity.LeaseCount < bestOptionLeaseCount {
+			if storeDesc.Capacity.LeaseCount < bestOptionLeaseCount ||
+				(storeDesc.Capacity.LeaseCount == bestOptionLeaseCount && repl.StoreID == opts.PreferredTarget) {
 				bestOption = repl
 				bestOptionLeaseCount = storeDesc.Capacity.LeaseCount
 			}
@@ -2380,9 +2424,7 @@ func (a *Allocator) TransferLeaseTarget(
 			}
 			return roachpb.ReplicaDescriptor{}
 		}
-		a.randGen.Lock()
-		defer a.randGen.Unlock()
-		return candidates[a.randGen.Intn(len(candidates))]
+		return a.pickLeaseTarget(candidates, opts.PreferredTarget)
 
 	case allocator.LoadConvergence:
 		leaseReplLoad := usageInfo.TransferImpact()
@@ -2548,6 +2590,10 @@ func (a *Allocator) ShouldTransferLease(
 		return true
 	}
 
+	if leaseholderShouldMoveDueToNonVotingStore(storePool, leaseRepl.StoreID()) {
+		return true
+	}
+
 	existing = a.ValidLeaseTargets(
 		ctx,
 		storePool,
@@ -0,0 +1,139 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package allocatorimpl
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator/storepool"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// IOClassWriteLoadMeanThreshold is the fraction above the mean class-relative
+// write load of comparable candidate stores after which a store is considered
+// overloaded for its IO class.
+const IOClassWriteLoadMeanThreshold = 1.25
+
+// IOClassAwareAllocation controls whether the allocator accounts for the IO
+// class of stores when choosing allocation and rebalance targets. It is off by
+// default: the classes are only known from what operators declare, and a
+// declaration which doesn't match the hardware would skew the placement of
+// write load rather than even it out.
+var IOClassAwareAllocation = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.allocator.io_class_aware.enabled",
+	"if enabled, stores declaring an IO class through their attributes "+
+		"(`iops-low`, `iops-standard` or `iops-high`) receive write load in "+
+		"proportion to their class, when the candidate stores are of mixed classes; "+
+		"the classes are not measured, so they should only be declared for stores "+
+		"whose disks are known to differ",
+	false,
+)
+
+// StoreIOClass is the class of IOPS and throughput a store's disk can sustain,
+// relative to the other stores of the cluster. It is declared through the
+// store attributes; the store descriptors don't carry any measurement of the
+// capacity of the disks which it could be derived from.
+type StoreIOClass int
+
+const (
+	// IOClassStandard is the class of stores which don't declare one.
+	IOClassStandard StoreIOClass = iota
+	// IOClassLow is the class of small or slow disks, declared with the
+	// `iops-low` store attribute.
+	IOClassLow
+	// IOClassHigh is the class of fast disks, declared with the `iops-high`
+	// store attribute.
+	IOClassHigh
+)
+
+var ioClassAttrs = map[string]StoreIOClass{
+	"iops-low":      IOClassLow,
+	"iops-standard": IOClassStandard,
+	"iops-high":     IOClassHigh,
+}
+
+// storeIOClass returns the IO class declared by the attributes of the store.
+func storeIOClass(store roachpb.StoreDescriptor) StoreIOClass {
+	for _, attr := range store.Attrs.Attrs {
+		if class, ok := ioClassAttrs[attr]; ok {
+			return class
+		}
+	}
+	return IOClassStandard
+}
+
+// weight returns the relative write capacity of the stores of the class.
+func (c StoreIOClass) weight() float64 {
+	switch c {
+	case IOClassLow:
+		return 0.5
+	case IOClassHigh:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// ioClassWriteLoad returns the write load of the store relative to the write
+// capacity of its IO class.
+func ioClassWriteLoad(store roachpb.StoreDescriptor) float64 {
+	return store.Capacity.WritesPerSecond / storeIOClass(store).weight()
+}
+
+// ioClassWriteLoads summarizes the class-relative write loads of a list of
+// candidate stores.
+type ioClassWriteLoads struct {
+	// mixed is set if the stores are of different IO classes. The write loads are
+	// only acted upon in that case: a fleet of a single class is balanced on its
+	// range counts or load alone, as it was before IO classes.
+	mixed bool
+	mean  float64
+}
+
+func makeIOClassWriteLoads(sl storepool.StoreList) ioClassWriteLoads {
+	var loads ioClassWriteLoads
+	if len(sl.Stores) == 0 {
+		return loads
+	}
+	first := storeIOClass(sl.Stores[0])
+	var total float64
+	for _, s := range sl.Stores {
+		if storeIOClass(s) != first {
+			loads.mixed = true
+		}
+		total += ioClassWriteLoad(s)
+	}
+	loads.mean = total / float64(len(sl.Stores))
+	return loads
+}
+
+// ioClassOverloadedCheck returns true if the store's write load, relative to
+// the write capacity of its IO class, is too far above the mean of the
+// comparable stores. This keeps slow stores of a mixed fleet from being handed
+// as much write load as the faster stores.
+func (o IOOverloadOptions) ioClassOverloadedCheck(
+	ctx context.Context, store roachpb.StoreDescriptor, loads ioClassWriteLoads,
+) bool {
+	if !o.IOClassAware || !loads.mixed {
+		return false
+	}
+	load := ioClassWriteLoad(store)
+	if load <= loads.mean*IOClassWriteLoadMeanThreshold {
+		return false
+	}
+	log.KvDistribution.VEventf(ctx, 3,
+		"s%d: io class write load %.2f exceeds the mean %.2f of comparable stores",
+		store.StoreID, load, loads.mean)
+	return true
+}
@@ -698,11 +698,14 @@ type candidate struct {
 	diversityScore  float64
 	ioOverloaded    bool
 	ioOverloadScore float64
-	convergesScore  int
-	balanceScore    balanceStatus
-	hasNonVoter     bool
-	rangeCount      int
-	details         string
+	// ioClassOverloaded is set if the store has too much write load for its IO
+	// class, relative to the other candidates. See ioClassOverloadedCheck.
+	ioClassOverloaded bool
+	convergesScore    int
+	balanceScore      balanceStatus
+	hasNonVoter       bool
+	rangeCount        int
+	details           string
 }
 
 func (c candidate) String() string {
@@ -713,10 +716,11 @@ func (c candidate) String() string {
 func (c candidate) SafeFormat(w redact.SafePrinter, _ rune) {
 	w.Printf("s%d, valid:%t, fulldisk:%t, necessary:%t, "+
 		"voterNecessary:%t, diversity:%.2f, ioOverloaded: %t, ioOverload: %.2f, "+
-		"converges:%d, balance:%d, hasNonVoter:%t, rangeCount:%d, queriesPerSecond:%.2f",
+		"ioClassOverloaded: %t, converges:%d, balance:%d, hasNonVoter:%t, rangeCount:%d, "+
+		"queriesPerSecond:%.2f",
 		c.store.StoreID, c.valid, c.fullDisk, c.necessary, c.voterNecessary,
-		c.diversityScore, c.ioOverloaded, c.ioOverloadScore, c.convergesScore,
-		c.balanceScore, c.hasNonVoter, c.rangeCount, c.store.Capacity.QueriesPerSecond)
+		c.diversityScore, c.ioOverloaded, c.ioOverloadScore, c.ioClassOverloaded,
+		c.convergesScore, c.balanceScore, c.hasNonVoter, c.rangeCount, c.store.Capacity.QueriesPerSecond)
 	if c.details != "" {
 		w.Printf(", details:(%s)", c.details)
 	}
@@ -748,6 +752,9 @@ func (c candidate) compactString() string {
 	if c.ioOverloadScore > 0 {
 		fmt.Fprintf(&buf, ", ioOverload:%.2fd", c.ioOverloadScore)
 	}
+	if c.ioClassOverloaded {
+		fmt.Fprintf(&buf, ", ioClassOverloaded:%t", c.ioClassOverloaded)
+	}
 	fmt.Fprintf(&buf, ", converges:%d, balance:%d, rangeCount:%d",
 		c.convergesScore, c.balanceScore, c.rangeCount)
 	if c.details != "" {
@@ -810,6 +817,12 @@ func (c candidate) compare(o candidate) float64 {
 	if o.ioOverloaded {
 		return 250
 	}
+	if c.ioClassOverloaded != o.ioClassOverloaded {
+		if o.ioClassOverloaded {
+			return 225
+		}
+		return -225
+	}
 
 	if c.convergesScore != o.convergesScore {
 		if c.convergesScore > o.convergesScore {
@@ -888,6 +901,7 @@ func (c byScoreAndID) Less(i, j int) bool {
 		c[i].voterNecessary == c[j].voterNecessary &&
 		c[i].fullDisk == c[j].fullDisk &&
 		c[i].ioOverloaded == c[j].ioOverloaded &&
+		c[i].ioClassOverloaded == c[j].ioClassOverloaded &&
 		c[i].valid == c[j].valid {
 		return c[i].store.StoreID < c[j].store.StoreID
 	}
@@ -918,6 +932,7 @@ func (cl candidateList) best() candidateList {
 		if cl[i].necessary == cl[0].necessary &&
 			cl[i].voterNecessary == cl[0].voterNecessary &&
 			scoresAlmostEqual(cl[i].diversityScore, cl[0].diversityScore) &&
+			cl[i].ioClassOverloaded == cl[0].ioClassOverloaded &&
 			cl[i].convergesScore == cl[0].convergesScore &&
 			cl[i].balanceScore == cl[0].balanceScore &&
 			cl[i].hasNonVoter == cl[0].hasNonVoter {
@@ -1135,6 +1150,7 @@ func rankedCandidateListForAllocation(
 	// Create a new store list, which will update the average for each stat to
 	// only be the average value of valid candidates.
 	validStoreList := storepool.MakeStoreList(validCandidateStores)
+	ioClassLoads := makeIOClassWriteLoads(validStoreList)
 
 	for _, s := range validStoreList.Stores {
 		// Disregard all the stores that already have replicas.
@@ -1176,9 +1192,11 @@ func rankedCandidateListForAllocation(
 			necessary:      necessary,
 			valid:          constraintsOK,
 			diversityScore: diversityScore,
-			balanceScore:   balanceScore,
-			hasNonVoter:    hasNonVoter,
-			rangeCount:     int(s.Capacity.RangeCount),
+			ioClassOverloaded: options.getIOOverloadOptions().ioClassOverloadedCheck(
+				ctx, s, ioClassLoads),
+			balanceScore: balanceScore,
+			hasNonVoter:  hasNonVoter,
+			rangeCount:   int(s.Capacity.RangeCount),
 		})
 	}
 	if options.deterministicForTesting() {
@@ -1753,6 +1771,10 @@ func rankedCandidateListForRebalancing(
 			existing.rangeCount = int(existing.store.Capacity.RangeCount)
 		}
 
+		// Like IO overload, the write load of the stores relative to their IO
+		// class only prevents rebalancing to a store, and is not accounted for
+		// the existing stores.
+		ioClassLoads := makeIOClassWriteLoads(comparable.candidateSL)
 		var candidates candidateList
 		for _, cand := range comparable.candidates {
 			// We handled the possible candidates for removal above. Don't process
@@ -1774,6 +1796,8 @@ func rankedCandidateListForRebalancing(
 				// comparable stores average and not the cluster.
 				comparable.candidateSL.CandidateIOOverloadScores.Mean,
 			)
+			cand.ioClassOverloaded = options.getIOOverloadOptions().ioClassOverloadedCheck(
+				ctx, s, ioClassLoads)
 			cand.balanceScore = options.balanceScore(comparable.candidateSL, s.Capacity)
 			cand.convergesScore = options.rebalanceToConvergesScore(comparable, s)
 			cand.rangeCount = int(s.Capacity.RangeCount)
@@ -1908,13 +1932,17 @@ type rebalanceConstraintsCheckFn func(toStore, fromStore roachpb.StoreDescriptor
 // necessary as per the `voter_constraints` and `constraints` on the range.
 //
 // NB: Potential voting replica candidates are "valid" only if they satisfy both
-// the `voter_constraints` as well as the overall `constraints`. Additionally,
+// the `voter_constraints` as well as the overall `constraints`, and if their
+// store isn't in non-voting mode (see StoreProperties.NonVoting). Additionally,
 // candidates are only marked "necessary" if they're required in order to
 // satisfy either the `voter_constraints` set or the `constraints` set.
 func voterConstraintsCheckerForAllocation(
 	overallConstraints, voterConstraints constraint.AnalyzedConstraints,
 ) constraintsCheckFn {
 	return func(s roachpb.StoreDescriptor) (valid, necessary bool) {
+		if s.Properties.NonVoting {
+			return false, false
+		}
 		overallConstraintsOK, necessaryOverall := allocateConstraintsCheck(s, overallConstraints)
 		voterConstraintsOK, necessaryForVoters := allocateConstraintsCheck(s, voterConstraints)
 
@@ -1948,6 +1976,11 @@ func voterConstraintsCheckerForRemoval(
 	overallConstraints, voterConstraints constraint.AnalyzedConstraints,
 ) constraintsCheckFn {
 	return func(s roachpb.StoreDescriptor) (valid, necessary bool) {
+		// A voter on a store in non-voting mode is invalid, which makes it the
+		// first candidate for removal.
+		if s.Properties.NonVoting {
+			return false, false
+		}
 		overallConstraintsOK, necessaryOverall := removeConstraintsCheck(s, overallConstraints)
 		voterConstraintsOK, necessaryForVoters := removeConstraintsCheck(s, voterConstraints)
 
@@ -1977,6 +2010,9 @@ func voterConstraintsCheckerForRebalance(
 	overallConstraints, voterConstraints constraint.AnalyzedConstraints,
 ) rebalanceConstraintsCheckFn {
 	return func(toStore, fromStore roachpb.StoreDescriptor) (valid, necessary, voterNecessary bool) {
+		if toStore.Properties.NonVoting {
+			return false, false, false
+		}
 		overallConstraintsOK, necessaryOverall := rebalanceFromConstraintsCheck(toStore, fromStore, overallConstraints)
 		voterConstraintsOK, necessaryForVoters := rebalanceFromConstraintsCheck(toStore, fromStore, voterConstraints)
 
@@ -2009,6 +2045,9 @@ func voterConstraintsCheckerForReplace(
 	existingStore roachpb.StoreDescriptor,
 ) constraintsCheckFn {
 	return func(s roachpb.StoreDescriptor) (valid, necessary bool) {
+		if s.Properties.NonVoting {
+			return false, false
+		}
 		overallConstraintsOK, necessaryOverall := replaceConstraintsCheck(s, existingStore, overallConstraints)
 		voterConstraintsOK, necessaryForVoters := replaceConstraintsCheck(s, existingStore, voterConstraints)
 
@@ -2395,6 +2434,10 @@ type IOOverloadOptions struct {
 	ReplicaIOOverloadThreshold   float64
 	LeaseIOOverloadThreshold     float64
 	LeaseIOOverloadShedThreshold float64
+
+	// IOClassAware is set if the IO class of stores is accounted for when
+	// choosing allocation and rebalance targets. See ioClassOverloadedCheck.
+	IOClassAware bool
 }
 
 func ioOverloadCheck(
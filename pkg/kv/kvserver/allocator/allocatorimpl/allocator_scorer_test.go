@@ -1664,6 +1664,72 @@ func TestMaxCapacity(t *testing.T) {
 	}
 }
 
+func TestIOClassOverloadedCheck(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	store := func(id int, writes float64, attrs ...string) roachpb.StoreDescriptor {
+		return roachpb.StoreDescriptor{
+			StoreID: roachpb.StoreID(id),
+			Node:    roachpb.NodeDescriptor{NodeID: roachpb.NodeID(id)},
+			Attrs:   roachpb.Attributes{Attrs: attrs},
+			Capacity: roachpb.StoreCapacity{
+				Capacity:        100,
+				Available:       50,
+				RangeCount:      10,
+				WritesPerSecond: writes,
+			},
+		}
+	}
+	rank := func(aware bool, stores ...roachpb.StoreDescriptor) map[roachpb.StoreID]bool {
+		options := &RangeCountScorerOptions{
+			IOOverloadOptions:   IOOverloadOptions{IOClassAware: aware},
+			DiskCapacityOptions: defaultDiskCapacityOptions(),
+			deterministic:       true,
+		}
+		candidates := rankedCandidateListForAllocation(
+			ctx,
+			storepool.MakeStoreList(stores),
+			func(roachpb.StoreDescriptor) (bool, bool) { return true, false },
+			nil, /* existingReplicas */
+			nil, /* nonVoterReplicas */
+			nil, /* existingStoreLocalities */
+			func(context.Context, roachpb.StoreID) bool { return true },
+			false, /* allowMultipleReplsPerNode */
+			options,
+			VoterTarget,
+		)
+		overloaded := map[roachpb.StoreID]bool{}
+		for _, c := range candidates {
+			overloaded[c.store.StoreID] = c.ioClassOverloaded
+		}
+		// Overloaded candidates are ranked last, and are not among the best.
+		for _, c := range candidates.best() {
+			require.False(t, c.ioClassOverloaded)
+		}
+		return overloaded
+	}
+
+	// The slow store receives less write load than the others, but more than
+	// its share given its class.
+	mixed := []roachpb.StoreDescriptor{
+		store(1, 300, "ssd", "iops-low"),
+		store(2, 400, "ssd"),
+		store(3, 500, "iops-high"),
+	}
+	require.Equal(t, map[roachpb.StoreID]bool{1: true, 2: false, 3: false}, rank(true, mixed...))
+	require.Equal(t, map[roachpb.StoreID]bool{1: false, 2: false, 3: false}, rank(false, mixed...))
+
+	// Stores of a single class are not compared on their write load.
+	uniform := []roachpb.StoreDescriptor{
+		store(1, 1000, "iops-low"),
+		store(2, 10, "iops-low"),
+		store(3, 10, "iops-low"),
+	}
+	require.Equal(t, map[roachpb.StoreID]bool{1: false, 2: false, 3: false}, rank(true, uniform...))
+}
+
 func TestCandidateListString(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -1691,8 +1757,8 @@ func TestCandidateListString(t *testing.T) {
 
 	require.Equal(t, "[]", candidateList{}.String())
 	require.Equal(t, "[\n"+
-		"s1, valid:false, fulldisk:false, necessary:false, voterNecessary:false, diversity:0.00, ioOverloaded: false, ioOverload: 0.00, converges:0, balance:0, hasNonVoter:false, rangeCount:1, queriesPerSecond:0.00, details:(mock detail 1)\n"+
-		"s2, valid:true, fulldisk:true, necessary:true, voterNecessary:true, diversity:0.00, ioOverloaded: true, ioOverload: 0.00, converges:1, balance:1, hasNonVoter:true, rangeCount:2, queriesPerSecond:0.00, details:(mock detail 2)\n"+
-		"s3, valid:false, fulldisk:false, necessary:false, voterNecessary:false, diversity:1.00, ioOverloaded: false, ioOverload: 1.00, converges:-1, balance:-1, hasNonVoter:false, rangeCount:3, queriesPerSecond:0.00, details:(mock detail 3)]",
+		"s1, valid:false, fulldisk:false, necessary:false, voterNecessary:false, diversity:0.00, ioOverloaded: false, ioOverload: 0.00, ioClassOverloaded: false, converges:0, balance:0, hasNonVoter:false, rangeCount:1, queriesPerSecond:0.00, details:(mock detail 1)\n"+
+		"s2, valid:true, fulldisk:true, necessary:true, voterNecessary:true, diversity:0.00, ioOverloaded: true, ioOverload: 0.00, ioClassOverloaded: false, converges:1, balance:1, hasNonVoter:true, rangeCount:2, queriesPerSecond:0.00, details:(mock detail 2)\n"+
+		"s3, valid:false, fulldisk:false, necessary:false, voterNecessary:false, diversity:1.00, ioOverloaded: false, ioOverload: 1.00, ioClassOverloaded: false, converges:-1, balance:-1, hasNonVoter:false, rangeCount:3, queriesPerSecond:0.00, details:(mock detail 3)]",
 		cl.String())
 }
@@ -595,6 +595,49 @@ func TestAllocatorNoAvailableDisks(t *testing.T) {
 	}
 }
 
+// TestAllocatorNonVotingStores verifies that stores in non-voting mode are
+// only allocated non-voting replicas, and never hold leases.
+func TestAllocatorNonVotingStores(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	stopper, g, sp, a, _ := CreateTestAllocator(ctx, 10, false /* deterministic */)
+	defer stopper.Stop(ctx)
+	// All the stores but s1 are in non-voting mode.
+	var stores []*roachpb.StoreDescriptor
+	for _, store := range sameDCStores {
+		store := *store
+		store.Properties.NonVoting = store.StoreID != 1
+		stores = append(stores, &store)
+	}
+	gossiputil.NewStoreGossiper(g).GossipStores(stores, t)
+
+	result, _, err := a.AllocateVoter(ctx, sp, emptySpanConfig(),
+		nil /* existingVoters */, nil /* existingNonVoters */, nil /* replacing */, Dead)
+	require.NoError(t, err)
+	require.Equal(t, roachpb.StoreID(1), result.StoreID)
+
+	voters := []roachpb.ReplicaDescriptor{{NodeID: 1, StoreID: 1, ReplicaID: 1}}
+	_, _, err = a.AllocateVoter(ctx, sp, emptySpanConfig(),
+		voters, nil /* existingNonVoters */, nil /* replacing */, Dead)
+	require.Error(t, err)
+	result, _, err = a.AllocateNonVoter(ctx, sp, emptySpanConfig(),
+		voters, nil /* existingNonVoters */, nil /* replacing */, Dead)
+	require.NoError(t, err)
+	require.NotEqual(t, roachpb.StoreID(1), result.StoreID)
+
+	// A voter on s2, whose mode was changed after it was added, is not a valid
+	// lease target, and its lease should be transferred away.
+	voters = append(voters, roachpb.ReplicaDescriptor{NodeID: 2, StoreID: 2, ReplicaID: 2})
+	desc := &roachpb.RangeDescriptor{InternalReplicas: voters}
+	targets := a.ValidLeaseTargets(ctx, sp, desc, emptySpanConfig(), voters,
+		&mockRepl{replicationFactor: 2, storeID: 1}, allocator.TransferLeaseOptions{})
+	require.Equal(t, voters[:1], targets)
+	require.True(t, a.ShouldTransferLease(ctx, sp, desc, emptySpanConfig(), voters,
+		&mockRepl{replicationFactor: 2, storeID: 2}, allocator.RangeUsageInfo{}))
+}
+
 func TestAllocatorAllocateVoterIOOverloadCheck(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -1987,6 +2030,69 @@ func TestAllocatorTransferLeaseTarget(t *testing.T) {
 	}
 }
 
+// TestAllocatorTransferLeaseTargetPreferredTarget verifies that the preferred
+// target only breaks the ties between equally good lease transfer targets.
+func TestAllocatorTransferLeaseTargetPreferredTarget(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	stopper, g, sp, a, _ := CreateTestAllocator(ctx, 10, true /* deterministic */)
+	defer stopper.Stop(ctx)
+
+	// 4 stores where stores 2 and 3 have the fewest leases.
+	var stores []*roachpb.StoreDescriptor
+	for i, leaseCount := range []int32{40, 10, 10, 20} {
+		stores = append(stores, &roachpb.StoreDescriptor{
+			StoreID:  roachpb.StoreID(i + 1),
+			Node:     roachpb.NodeDescriptor{NodeID: roachpb.NodeID(i + 1)},
+			Capacity: roachpb.StoreCapacity{LeaseCount: leaseCount},
+		})
+	}
+	sg := gossiputil.NewStoreGossiper(g)
+	sg.GossipStores(stores, t)
+
+	existing := []roachpb.ReplicaDescriptor{
+		{StoreID: 1, ReplicaID: 1},
+		{StoreID: 2, ReplicaID: 2},
+		{StoreID: 3, ReplicaID: 3},
+		{StoreID: 4, ReplicaID: 4},
+	}
+	testCases := []struct {
+		preferred roachpb.StoreID
+		expected  []roachpb.StoreID
+	}{
+		// The preferred store is picked among the stores with the fewest leases.
+		{preferred: 2, expected: []roachpb.StoreID{2}},
+		{preferred: 3, expected: []roachpb.StoreID{3}},
+		// Otherwise, it's ignored.
+		{preferred: 4, expected: []roachpb.StoreID{2, 3}},
+		{preferred: 5, expected: []roachpb.StoreID{2, 3}},
+	}
+	for _, c := range testCases {
+		t.Run(fmt.Sprintf("preferred=s%d", c.preferred), func(t *testing.T) {
+			target := a.TransferLeaseTarget(
+				ctx,
+				sp,
+				&roachpb.RangeDescriptor{},
+				emptySpanConfig(),
+				existing,
+				&mockRepl{
+					replicationFactor: 4,
+					storeID:           1,
+				},
+				allocator.RangeUsageInfo{}, /* stats */
+				false,                      /* forceDecisionWithoutStats */
+				allocator.TransferLeaseOptions{
+					ExcludeLeaseRepl:       true,
+					CheckCandidateFullness: true,
+					PreferredTarget:        c.preferred,
+				},
+			)
+			require.Contains(t, c.expected, target.StoreID)
+		})
+	}
+}
+
 func TestAllocatorTransferLeaseTargetIOOverloadCheck(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
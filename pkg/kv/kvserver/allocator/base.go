@@ -191,6 +191,10 @@ type TransferLeaseOptions struct {
 	// LoadDimensions declares the load dimensions to use when the Goal is
 	// LoadConvergence.
 	LoadDimensions []load.Dimension
+	// PreferredTarget, if set, is the store `TransferLeaseTarget` picks among
+	// the candidates it considers equally good, e.g. the store of the hot
+	// standby of the range. It doesn't make the store a candidate otherwise.
+	PreferredTarget roachpb.StoreID
 }
 
 // LeaseTransferOutcome represents the result of shedLease().
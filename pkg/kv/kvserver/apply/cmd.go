@@ -32,6 +32,10 @@ type Command interface {
 	// that were locally proposed typically have a client waiting on a
 	// response, so there is additional urgency to apply them quickly.
 	IsLocal() bool
+	// EntrySize returns the size of the payload of the corresponding raft
+	// entry. It counts towards the byte limit of the apply.Batch the command is
+	// applied in.
+	EntrySize() int
 	// Ctx returns the Context in which operations on this Command should be
 	// performed.
 	//
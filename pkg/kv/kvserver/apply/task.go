@@ -128,6 +128,9 @@ type Task struct {
 	anyLocal bool
 	// The maximum number of commands that can be applied in a batch.
 	batchSize int32
+	// The maximum total size of the entries of the commands that can be
+	// applied in a batch.
+	batchBytes int64
 }
 
 // MakeTask creates a new task with the provided state machine and decoder.
@@ -241,6 +244,13 @@ func (t *Task) SetMaxBatchSize(size int) {
 	t.batchSize = int32(size)
 }
 
+// SetMaxBatchBytes sets the maximum total size of the entries of the commands
+// applied in a batch. If 0, no limit will be placed on the size of a batch. A
+// batch always contains at least one command, regardless of its size.
+func (t *Task) SetMaxBatchBytes(bytes int64) {
+	t.batchBytes = bytes
+}
+
 // ApplyCommittedEntries applies raft entries that have been committed to the
 // raft log but have not yet been applied to the replicated state machine.
 func (t *Task) ApplyCommittedEntries(ctx context.Context) error {
@@ -275,9 +285,9 @@ func (t *Task) applyOneBatch(ctx context.Context, iter CommandIterator) error {
 	defer batch.Close()
 
 	// Consume a batch-worth of commands.
-	pol := trivialPolicy{maxCount: t.batchSize}
+	pol := trivialPolicy{maxCount: t.batchSize, maxBytes: t.batchBytes}
 	batchIter := takeWhileCmdIter(iter, func(cmd Command) bool {
-		return pol.maybeAdd(cmd.IsTrivial())
+		return pol.maybeAdd(cmd.IsTrivial(), cmd.EntrySize())
 	})
 
 	// Stage each command in the batch.
@@ -302,18 +312,22 @@ func (t *Task) applyOneBatch(ctx context.Context, iter CommandIterator) error {
 }
 
 // trivialPolicy encodes a batching policy that allows a batch to consist of
-// either one or more trivial commands or exactly one non-trivial command.
+// either one or more trivial commands or exactly one non-trivial command. The
+// number of trivial commands in a batch, and the total size of their entries,
+// can be limited.
 type trivialPolicy struct {
 	maxCount int32
+	maxBytes int64
 
 	trivialCount    int32
 	nonTrivialCount int32
+	bytes           int64
 }
 
-// maybeAdd returns whether a command with the specified triviality should be
-// added to a batch given the batching policy. If the method returns true, the
-// command is considered to have been added.
-func (p *trivialPolicy) maybeAdd(trivial bool) bool {
+// maybeAdd returns whether a command with the specified triviality and entry
+// size should be added to a batch given the batching policy. If the method
+// returns true, the command is considered to have been added.
+func (p *trivialPolicy) maybeAdd(trivial bool, size int) bool {
 	if !trivial {
 		if p.trivialCount+p.nonTrivialCount > 0 {
 			return false
@@ -327,7 +341,11 @@ func (p *trivialPolicy) maybeAdd(trivial bool) bool {
 	if p.maxCount > 0 && p.maxCount == p.trivialCount {
 		return false
 	}
+	if p.maxBytes > 0 && p.trivialCount > 0 && p.bytes+int64(size) > p.maxBytes {
+		return false
+	}
 	p.trivialCount++
+	p.bytes += int64(size)
 	return true
 }
 
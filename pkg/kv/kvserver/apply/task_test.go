@@ -39,6 +39,7 @@ type cmd struct {
 	nonLocal              bool
 	shouldReject          bool
 	shouldThrowErrRemoved bool
+	size                  int
 
 	acked    bool
 	finished bool
@@ -56,6 +57,7 @@ type appliedCmd struct {
 func (c *cmd) Index() kvpb.RaftIndex { return c.index }
 func (c *cmd) IsTrivial() bool       { return !c.nonTrivial }
 func (c *cmd) IsLocal() bool         { return !c.nonLocal }
+func (c *cmd) EntrySize() int        { return c.size }
 func (c *cmd) Ctx() context.Context  { return context.Background() }
 func (c *cmd) AckErrAndFinish(_ context.Context, err error) error {
 	c.acked = true
@@ -219,6 +221,7 @@ func (d *testDecoder) DecodeAndBind(_ context.Context, ents []raftpb.Entry) (boo
 			nonLocal:              d.nonLocal[idx],
 			shouldReject:          d.shouldReject[idx],
 			shouldThrowErrRemoved: d.shouldThrowErrRemoved[idx],
+			size:                  len(ent.Data),
 		}
 		d.cmds[i] = cmd
 		if logging {
@@ -302,6 +305,40 @@ func TestApplyCommittedEntriesWithBatchSize(t *testing.T) {
 	}
 }
 
+func TestApplyCommittedEntriesWithBatchBytes(t *testing.T) {
+	ctx := context.Background()
+	ents := makeEntries(7)
+	for i, size := range []int{3, 3, 5, 1, 2, 10, 1} {
+		ents[i].Data = make([]byte, size)
+	}
+
+	sm := getTestStateMachine()
+	dec := newTestDecoder()
+	dec.nonTrivial[4] = true
+
+	// Use an apply.Task to apply all commands with a batch size limit in
+	// bytes. Commands larger than the limit are applied in their own batch.
+	appT := apply.MakeTask(sm, dec)
+	appT.SetMaxBatchBytes(6)
+	defer appT.Close()
+	require.NoError(t, appT.Decode(ctx, ents))
+	require.NoError(t, appT.ApplyCommittedEntries(ctx))
+
+	// Assert that all commands were applied in the correct batches.
+	exp := testStateMachine{
+		batches:            [][]kvpb.RaftIndex{{1, 2}, {3}, {4}, {5}, {6}, {7}},
+		applied:            []kvpb.RaftIndex{1, 2, 3, 4, 5, 6, 7},
+		appliedSideEffects: []kvpb.RaftIndex{1, 2, 3, 4, 5, 6, 7},
+	}
+	require.Equal(t, exp, *sm)
+
+	// Assert that all commands were acknowledged and finished.
+	for _, cmd := range dec.cmds {
+		require.True(t, cmd.acked)
+		require.True(t, cmd.finished)
+	}
+}
+
 func TestAckCommittedEntriesBeforeApplication(t *testing.T) {
 	ctx := context.Background()
 	ents := makeEntries(9)
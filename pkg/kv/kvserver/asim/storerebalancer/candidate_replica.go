@@ -114,6 +114,12 @@ func (sr *simulatorReplica) AdminTransferLease(
 	return nil
 }
 
+// ReplicaPin returns the replica pin of the range. Ranges are never pinned in
+// the simulator.
+func (sr *simulatorReplica) ReplicaPin() *roachpb.ReplicaPin {
+	return nil
+}
+
 // Replica returns the underlying kvserver replica, however when called from
 // the simulator it only returns nil.
 func (sr *simulatorReplica) Repl() *kvserver.Replica {
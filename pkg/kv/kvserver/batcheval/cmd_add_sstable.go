@@ -12,6 +12,7 @@ package batcheval
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"time"
 
@@ -96,6 +97,17 @@ var AddSSTableRequireAtRequestTimestamp = settings.RegisterBoolSetting(
 	false,
 )
 
+// addSSTableDeduplicationEnabled controls whether AddSSTable requests that
+// write at the request timestamp are skipped if an identical sstable was
+// already ingested into the same span at the same timestamp.
+var addSSTableDeduplicationEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.bulk_io_write.sst_deduplication.enabled",
+	"skips addsstable requests which ingest an sstable identical to one the range recently "+
+		"ingested into the same span at the same timestamp, e.g. retries after ambiguous errors",
+	true,
+)
+
 // addSSTableCapacityRemainingLimit is the fraction of remaining store capacity
 // under which addsstable requests are rejected.
 var addSSTableCapacityRemainingLimit = settings.RegisterFloatSetting(
@@ -171,11 +183,49 @@ func EvalAddSSTable(
 				// a history mutation.
 				MVCCHistoryMutation: &kvserverpb.ReplicatedEvalResult_MVCCHistoryMutation{
 					Spans: []roachpb.Span{{Key: start.Key, EndKey: end.Key}},
+					Token: args.MVCCHistoryMutationToken,
 				},
 			},
 		}, nil
 	}
 
+	// If the same sstable was already ingested into the same span at the same
+	// timestamp, e.g. by an earlier attempt of this request that failed with an
+	// ambiguous error, ingesting it again would be a no-op apart from doubling
+	// the work and the estimated stats, so skip it. This only applies to
+	// sstables written at the request timestamp: their ingestion is idempotent
+	// as long as the range hasn't seen an MVCC history mutation since, which
+	// makes the range forget the sstables it ingested.
+	var contentHash []byte
+	if sstToReqTS.IsSet() && addSSTableDeduplicationEnabled.Get(&cArgs.EvalCtx.ClusterSettings().SV) {
+		sum := sha256.Sum256(sst)
+		contentHash = sum[:]
+		if cArgs.EvalCtx.HasIngestedSSTable(
+			roachpb.Span{Key: start.Key, EndKey: end.Key}, h.Timestamp, contentHash,
+		) {
+			log.VEventf(ctx, 2, "skipping SSTable [%s,%s) already ingested at %s",
+				start.Key, end.Key, h.Timestamp)
+			reply := resp.(*kvpb.AddSSTableResponse)
+			reply.Deduplicated = true
+			reply.RangeSpan = cArgs.EvalCtx.Desc().KeySpan().AsRawSpanWithNoLocals()
+			reply.AvailableBytes = cArgs.EvalCtx.GetMaxBytes(ctx) - cArgs.EvalCtx.GetMVCCStats().Total()
+			if args.ReturnFollowingLikelyNonEmptySpanStart {
+				if reply.FollowingLikelyNonEmptySpanStart, err = followingLikelyNonEmptySpanStart(
+					ctx, readWriter, end, reply.RangeSpan.EndKey,
+				); err != nil {
+					return result.Result{}, err
+				}
+			}
+			return result.Result{
+				Local: result.LocalResult{
+					Metrics: &result.Metrics{
+						AddSSTableDeduplicated: 1,
+					},
+				},
+			}, nil
+		}
+	}
+
 	if min := addSSTableCapacityRemainingLimit.Get(&cArgs.EvalCtx.ClusterSettings().SV); min > 0 {
 		cap, err := cArgs.EvalCtx.GetEngineCapacity()
 		if err != nil {
@@ -399,6 +449,7 @@ func EvalAddSSTable(
 	if sstToReqTS.IsEmpty() {
 		mvccHistoryMutation = &kvserverpb.ReplicatedEvalResult_MVCCHistoryMutation{
 			Spans: []roachpb.Span{{Key: start.Key, EndKey: end.Key}},
+			Token: args.MVCCHistoryMutationToken,
 		}
 	}
 
@@ -414,23 +465,10 @@ func EvalAddSSTable(
 	// addition, and instead just use this key-only iterator. If a caller actually
 	// needs to know what data is there, it must issue its own real Scan.
 	if args.ReturnFollowingLikelyNonEmptySpanStart {
-		existingIter, err := spanset.DisableReaderAssertions(readWriter).NewMVCCIterator(
-			ctx,
-			storage.MVCCKeyIterKind, // don't care if it is committed or not, just that it isn't empty.
-			storage.IterOptions{
-				KeyTypes:     storage.IterKeyTypePointsAndRanges,
-				UpperBound:   reply.RangeSpan.EndKey,
-				ReadCategory: storage.BatchEvalReadCategory,
-			})
-		if err != nil {
-			return result.Result{}, errors.Wrap(err, "error when creating iterator for non-empty span")
-		}
-		defer existingIter.Close()
-		existingIter.SeekGE(end)
-		if ok, err := existingIter.Valid(); err != nil {
-			return result.Result{}, errors.Wrap(err, "error while searching for non-empty span start")
-		} else if ok {
-			reply.FollowingLikelyNonEmptySpanStart = existingIter.UnsafeKey().Key.Clone()
+		if reply.FollowingLikelyNonEmptySpanStart, err = followingLikelyNonEmptySpanStart(
+			ctx, readWriter, end, reply.RangeSpan.EndKey,
+		); err != nil {
+			return result.Result{}, err
 		}
 	}
 
@@ -536,12 +574,39 @@ func EvalAddSSTable(
 				CRC32:            util.CRC32(sst),
 				Span:             roachpb.Span{Key: start.Key, EndKey: end.Key},
 				AtWriteTimestamp: sstToReqTS.IsSet(),
+				ContentHash:      contentHash,
 			},
 			MVCCHistoryMutation: mvccHistoryMutation,
 		},
 	}, nil
 }
 
+// followingLikelyNonEmptySpanStart returns the first key at or after end, and
+// before the end of the range, at which there is existing data, if any.
+func followingLikelyNonEmptySpanStart(
+	ctx context.Context, reader storage.Reader, end storage.MVCCKey, rangeEnd roachpb.Key,
+) (roachpb.Key, error) {
+	existingIter, err := spanset.DisableReaderAssertions(reader).NewMVCCIterator(
+		ctx,
+		storage.MVCCKeyIterKind, // don't care if it is committed or not, just that it isn't empty.
+		storage.IterOptions{
+			KeyTypes:     storage.IterKeyTypePointsAndRanges,
+			UpperBound:   rangeEnd,
+			ReadCategory: storage.BatchEvalReadCategory,
+		})
+	if err != nil {
+		return nil, errors.Wrap(err, "error when creating iterator for non-empty span")
+	}
+	defer existingIter.Close()
+	existingIter.SeekGE(end)
+	if ok, err := existingIter.Valid(); err != nil {
+		return nil, errors.Wrap(err, "error while searching for non-empty span start")
+	} else if ok {
+		return existingIter.UnsafeKey().Key.Clone(), nil
+	}
+	return nil, nil
+}
+
 // assertSSTContents checks that the SST contains expected inputs:
 //
 // * Only SST set operations (not explicitly verified).
@@ -48,12 +48,22 @@ func declareKeysBarrier(
 }
 
 // Barrier evaluation is a no-op, as all the latch waiting happens in
-// the latch manager.
+// the latch manager. If the barrier waits for all voters, it returns the
+// lease applied index which they must reach, which covers all conflicting
+// writes since they applied before releasing their latches. The replica then
+// waits for the voters to apply it.
 func Barrier(
 	_ context.Context, _ storage.ReadWriter, cArgs CommandArgs, response kvpb.Response,
 ) (result.Result, error) {
+	args := cArgs.Args.(*kvpb.BarrierRequest)
 	resp := response.(*kvpb.BarrierResponse)
 	resp.Timestamp = cArgs.EvalCtx.Clock().Now()
+	if args.WaitForAllVoters {
+		resp.AppliedIndexes = []kvpb.BarrierResponse_AppliedIndex{{
+			RangeID:           cArgs.EvalCtx.GetRangeID(),
+			LeaseAppliedIndex: cArgs.EvalCtx.GetLeaseAppliedIndex(),
+		}}
+	}
 
 	return result.Result{}, nil
 }
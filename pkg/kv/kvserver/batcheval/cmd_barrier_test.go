@@ -0,0 +1,57 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package batcheval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBarrierWaitForAllVoters tests that a Barrier which waits for all voters
+// returns the lease applied index that they must reach.
+func TestBarrierWaitForAllVoters(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	evalCtx := (&MockEvalCtx{
+		Clock:             hlc.NewClockForTesting(nil),
+		Desc:              &roachpb.RangeDescriptor{RangeID: 7},
+		LeaseAppliedIndex: 42,
+	}).EvalContext()
+
+	for _, waitForAllVoters := range []bool{false, true} {
+		var resp kvpb.BarrierResponse
+		_, err := Barrier(ctx, nil /* readWriter */, CommandArgs{
+			EvalCtx: evalCtx,
+			Args: &kvpb.BarrierRequest{
+				RequestHeader:    kvpb.RequestHeader{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")},
+				WaitForAllVoters: waitForAllVoters,
+			},
+		}, &resp)
+		require.NoError(t, err)
+		require.False(t, resp.Timestamp.IsEmpty())
+		if !waitForAllVoters {
+			require.Empty(t, resp.AppliedIndexes)
+			continue
+		}
+		require.Equal(t, []kvpb.BarrierResponse_AppliedIndex{
+			{RangeID: 7, LeaseAppliedIndex: 42},
+		}, resp.AppliedIndexes)
+	}
+}
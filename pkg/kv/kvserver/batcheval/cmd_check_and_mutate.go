@@ -0,0 +1,168 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package batcheval
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/batcheval/result"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/lockspanset"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/spanset"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/errors"
+)
+
+func init() {
+	RegisterReadWriteCommand(kvpb.CheckAndMutate, declareKeysCheckAndMutate, CheckAndMutate)
+}
+
+func declareKeysCheckAndMutate(
+	rs ImmutableRangeState,
+	header *kvpb.Header,
+	req kvpb.Request,
+	latchSpans *spanset.SpanSet,
+	lockSpans *lockspanset.LockSpanSet,
+	maxOffset time.Duration,
+) error {
+	// Only the keys of the mutations are read and written, so they are latched
+	// and locked individually instead of the request span, which may cover many
+	// more keys. The mutations outside of the request span are rejected during
+	// evaluation.
+	args := req.(*kvpb.CheckAndMutateRequest)
+	reqSpan := args.Span()
+	for i := range args.Mutations {
+		if key := args.Mutations[i].Key; reqSpan.ContainsKey(key) {
+			span := roachpb.Span{Key: key}
+			latchSpans.AddMVCC(spanset.SpanReadWrite, span, header.Timestamp)
+			lockSpans.Add(lock.Intent, span)
+		}
+	}
+	return nil
+}
+
+// CheckAndMutate applies the mutations of the request if the conditions of all
+// of them hold. Otherwise, it applies none of them, and returns the failed
+// mutations in the response.
+func CheckAndMutate(
+	ctx context.Context, readWriter storage.ReadWriter, cArgs CommandArgs, resp kvpb.Response,
+) (result.Result, error) {
+	args := cArgs.Args.(*kvpb.CheckAndMutateRequest)
+	reply := resp.(*kvpb.CheckAndMutateResponse)
+	h := cArgs.Header
+
+	if !cArgs.EvalCtx.ClusterSettings().Version.IsActive(ctx, clusterversion.V24_1_CheckAndMutate) {
+		return result.Result{}, errors.Newf(
+			"CheckAndMutate requires cluster version %s", clusterversion.V24_1_CheckAndMutate)
+	}
+	if err := validateCheckAndMutate(args); err != nil {
+		return result.Result{}, err
+	}
+
+	// Check all conditions before writing anything, so that no mutation is
+	// applied if any condition fails. A value written above the read timestamp
+	// results in a WriteTooOldError, with which the request is retried at a
+	// higher timestamp.
+	maxLockConflicts := storage.MaxConflictsPerLockConflictError.Get(&cArgs.EvalCtx.ClusterSettings().SV)
+	for i := range args.Mutations {
+		m := &args.Mutations[i]
+		res, err := storage.MVCCGet(ctx, readWriter, m.Key, h.Timestamp, storage.MVCCGetOptions{
+			Txn:              h.Txn,
+			FailOnMoreRecent: true,
+			ReadCategory:     storage.BatchEvalReadCategory,
+		})
+		if err != nil {
+			return result.Result{}, err
+		}
+		if !checkAndMutateConditionHolds(m, res.Value) {
+			reply.Failures = append(reply.Failures, kvpb.CheckAndMutateResponse_Failure{
+				Index:       int32(i),
+				Key:         m.Key,
+				ActualValue: res.Value,
+			})
+		}
+	}
+	if len(reply.Failures) > 0 {
+		return result.Result{}, nil
+	}
+
+	opts := storage.MVCCWriteOptions{
+		Txn:                            h.Txn,
+		LocalTimestamp:                 cArgs.Now,
+		Stats:                          cArgs.Stats,
+		ReplayWriteTimestampProtection: h.AmbiguousReplayProtection,
+		OmitInRangefeeds:               cArgs.OmitInRangefeeds,
+		MaxLockConflicts:               maxLockConflicts,
+		Category:                       storage.BatchEvalReadCategory,
+	}
+	acqs := make([]roachpb.LockAcquisition, 0, len(args.Mutations))
+	for i := range args.Mutations {
+		m := &args.Mutations[i]
+		var acq roachpb.LockAcquisition
+		var err error
+		if m.Delete {
+			_, acq, err = storage.MVCCDelete(ctx, readWriter, m.Key, h.Timestamp, opts)
+		} else {
+			acq, err = storage.MVCCPut(ctx, readWriter, m.Key, h.Timestamp, m.Value, opts)
+		}
+		if err != nil {
+			return result.Result{}, err
+		}
+		acqs = append(acqs, acq)
+	}
+	return result.WithAcquiredLocks(acqs...), nil
+}
+
+// validateCheckAndMutate returns an error if the request has no mutations, if
+// any of them is outside of the request span, which only covers the keys of a
+// single range, or if multiple mutations address the same key.
+func validateCheckAndMutate(args *kvpb.CheckAndMutateRequest) error {
+	if len(args.Mutations) == 0 {
+		return errors.New("CheckAndMutate requires at least one mutation")
+	}
+	reqSpan := args.Span()
+	keys := make(map[string]struct{}, len(args.Mutations))
+	for i := range args.Mutations {
+		key := args.Mutations[i].Key
+		if !reqSpan.ContainsKey(key) {
+			return errors.Errorf(
+				"CheckAndMutate mutation of key %s outside of request span %s; "+
+					"the mutations must address keys of a single range", key, reqSpan)
+		}
+		if _, ok := keys[string(key)]; ok {
+			return errors.Errorf("CheckAndMutate mutates key %s more than once", key)
+		}
+		keys[string(key)] = struct{}{}
+	}
+	return nil
+}
+
+// checkAndMutateConditionHolds returns whether the existing value of the key
+// of the mutation, nil if the key doesn't exist, satisfies its condition. The
+// condition has the semantics of ConditionalPut.
+func checkAndMutateConditionHolds(
+	m *kvpb.CheckAndMutateRequest_Mutation, existing *roachpb.Value,
+) bool {
+	expPresent, existPresent := len(m.ExpBytes) != 0, existing != nil
+	switch {
+	case expPresent && existPresent:
+		return bytes.Equal(m.ExpBytes, existing.TagAndDataBytes())
+	case expPresent:
+		return m.AllowIfDoesNotExist
+	default:
+		return !existPresent
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package batcheval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckAndMutate tests that CheckAndMutate applies all of its mutations
+// when their conditions hold, and none of them otherwise.
+func TestCheckAndMutate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	engine := storage.NewDefaultInMemForTesting()
+	defer engine.Close()
+
+	evalCtx := (&MockEvalCtx{ClusterSettings: cluster.MakeTestingClusterSettings()}).EvalContext()
+	value := func(s string) roachpb.Value {
+		v := roachpb.MakeValueFromString(s)
+		v.InitChecksum(nil)
+		return v
+	}
+	exp := func(s string) []byte {
+		v := value(s)
+		return v.TagAndDataBytes()
+	}
+	get := func(key string, ts int64) *roachpb.Value {
+		res, err := storage.MVCCGet(ctx, engine, roachpb.Key(key), hlc.Timestamp{WallTime: ts},
+			storage.MVCCGetOptions{})
+		require.NoError(t, err)
+		return res.Value
+	}
+	send := func(
+		ts int64, mutations ...kvpb.CheckAndMutateRequest_Mutation,
+	) (*kvpb.CheckAndMutateResponse, error) {
+		var resp kvpb.CheckAndMutateResponse
+		_, err := CheckAndMutate(ctx, engine, CommandArgs{
+			EvalCtx: evalCtx,
+			Header:  kvpb.Header{Timestamp: hlc.Timestamp{WallTime: ts}},
+			Args:    kvpb.NewCheckAndMutate(mutations),
+			Stats:   &enginepb.MVCCStats{},
+		}, &resp)
+		return &resp, err
+	}
+
+	for _, kv := range [][2]string{{"a", "1"}, {"b", "2"}} {
+		_, err := storage.MVCCPut(ctx, engine, roachpb.Key(kv[0]), hlc.Timestamp{WallTime: 1},
+			value(kv[1]), storage.MVCCWriteOptions{})
+		require.NoError(t, err)
+	}
+
+	// All conditions hold, so all mutations are applied.
+	resp, err := send(2,
+		kvpb.CheckAndMutateRequest_Mutation{Key: roachpb.Key("a"), Value: value("10"), ExpBytes: exp("1")},
+		kvpb.CheckAndMutateRequest_Mutation{Key: roachpb.Key("b"), Delete: true, ExpBytes: exp("2")},
+		kvpb.CheckAndMutateRequest_Mutation{Key: roachpb.Key("c"), Value: value("3")},
+	)
+	require.NoError(t, err)
+	require.Empty(t, resp.Failures)
+	v, err := get("a", 2).GetBytes()
+	require.NoError(t, err)
+	require.Equal(t, "10", string(v))
+	require.Nil(t, get("b", 2))
+	require.NotNil(t, get("c", 2))
+
+	// The conditions of the mutations of a and c fail, so no mutation is
+	// applied, not even the one of b whose condition holds.
+	resp, err = send(3,
+		kvpb.CheckAndMutateRequest_Mutation{Key: roachpb.Key("a"), Value: value("11"), ExpBytes: exp("1")},
+		kvpb.CheckAndMutateRequest_Mutation{Key: roachpb.Key("b"), Value: value("4")},
+		kvpb.CheckAndMutateRequest_Mutation{Key: roachpb.Key("c"), Delete: true},
+	)
+	require.NoError(t, err)
+	require.Len(t, resp.Failures, 2)
+	require.Equal(t, int32(0), resp.Failures[0].Index)
+	require.Equal(t, roachpb.Key("a"), resp.Failures[0].Key)
+	v, err = resp.Failures[0].ActualValue.GetBytes()
+	require.NoError(t, err)
+	require.Equal(t, "10", string(v))
+	require.Equal(t, int32(2), resp.Failures[1].Index)
+	require.Nil(t, get("b", 3))
+	require.NotNil(t, get("c", 3))
+
+	// A missing key satisfies the condition if allowed.
+	resp, err = send(4, kvpb.CheckAndMutateRequest_Mutation{
+		Key: roachpb.Key("b"), Value: value("5"), ExpBytes: exp("2"), AllowIfDoesNotExist: true,
+	})
+	require.NoError(t, err)
+	require.Empty(t, resp.Failures)
+	require.NotNil(t, get("b", 4))
+
+	// A write below an existing value results in a WriteTooOldError.
+	_, err = send(3, kvpb.CheckAndMutateRequest_Mutation{
+		Key: roachpb.Key("b"), Value: value("6"), ExpBytes: exp("5"),
+	})
+	require.ErrorAs(t, err, new(*kvpb.WriteTooOldError))
+
+	// Mutations must address distinct keys within the request span.
+	_, err = send(5,
+		kvpb.CheckAndMutateRequest_Mutation{Key: roachpb.Key("a"), Value: value("12")},
+		kvpb.CheckAndMutateRequest_Mutation{Key: roachpb.Key("a"), Delete: true},
+	)
+	require.ErrorContains(t, err, "more than once")
+	req := kvpb.NewCheckAndMutate([]kvpb.CheckAndMutateRequest_Mutation{
+		{Key: roachpb.Key("a"), Value: value("12")},
+		{Key: roachpb.Key("m"), Value: value("13")},
+	}).(*kvpb.CheckAndMutateRequest)
+	require.Equal(t, roachpb.Key("a"), req.Key)
+	require.Equal(t, roachpb.Key("m").Next(), req.EndKey)
+	req.EndKey = roachpb.Key("c")
+	require.ErrorContains(t, validateCheckAndMutate(req), "outside of request span")
+}
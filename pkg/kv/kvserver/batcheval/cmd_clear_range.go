@@ -107,6 +107,7 @@ func ClearRange(
 		Replicated: kvserverpb.ReplicatedEvalResult{
 			MVCCHistoryMutation: &kvserverpb.ReplicatedEvalResult_MVCCHistoryMutation{
 				Spans: []roachpb.Span{{Key: from, EndKey: to}},
+				Token: args.MVCCHistoryMutationToken,
 			},
 		},
 	}
@@ -0,0 +1,100 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package batcheval
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/batcheval/result"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/lockspanset"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/spanset"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/errors"
+)
+
+func init() {
+	RegisterReadWriteCommand(kvpb.ColdCompaction, declareKeysColdCompaction, ColdCompaction)
+}
+
+func declareKeysColdCompaction(
+	rs ImmutableRangeState,
+	_ *kvpb.Header,
+	_ kvpb.Request,
+	latchSpans *spanset.SpanSet,
+	_ *lockspanset.LockSpanSet,
+	_ time.Duration,
+) error {
+	latchSpans.AddNonMVCC(spanset.SpanReadWrite, roachpb.Span{
+		Key: keys.RangeColdCompactionKey(rs.GetRangeID()),
+	})
+	return nil
+}
+
+// ColdCompaction sets or removes the cold compaction policy of the range, or
+// records the compaction of its data. See the comment on ColdCompactionRequest
+// for details.
+func ColdCompaction(
+	ctx context.Context, readWriter storage.ReadWriter, cArgs CommandArgs, resp kvpb.Response,
+) (result.Result, error) {
+	args := cArgs.Args.(*kvpb.ColdCompactionRequest)
+	reply := resp.(*kvpb.ColdCompactionResponse)
+
+	sl := MakeStateLoader(cArgs.EvalCtx)
+	prev, err := sl.LoadColdCompaction(ctx, readWriter)
+	if err != nil {
+		return result.Result{}, err
+	}
+
+	cs := &roachpb.ColdCompaction{}
+	switch args.Action {
+	case kvpb.ColdCompactionRequest_SET, kvpb.ColdCompactionRequest_COMPACT:
+		if !cArgs.EvalCtx.ClusterSettings().Version.IsActive(ctx, clusterversion.V24_1_ColdCompaction) {
+			return result.Result{}, errors.Newf(
+				"cold compaction policies require cluster version %s", clusterversion.V24_1_ColdCompaction)
+		}
+		if args.Action == kvpb.ColdCompactionRequest_SET {
+			if args.Threshold.IsEmpty() {
+				return result.Result{}, errors.New("empty cold compaction threshold")
+			}
+			// Changing the threshold doesn't undo the previous compactions.
+			*cs = *prev
+			cs.Threshold = args.Threshold
+		} else {
+			if prev.Threshold.IsEmpty() {
+				return result.Result{}, errors.Errorf("r%d has no cold compaction policy", cArgs.EvalCtx.GetRangeID())
+			}
+			*cs = *prev
+			cs.CompactedAt = cArgs.Header.Timestamp
+		}
+	case kvpb.ColdCompactionRequest_REMOVE:
+	default:
+		return result.Result{}, errors.AssertionFailedf("unknown action %s", args.Action)
+	}
+
+	reply.ColdCompaction = *cs
+	if prev.Equal(cs) {
+		return result.Result{}, nil
+	}
+	if err := sl.SetColdCompaction(ctx, readWriter, cArgs.Stats, cs); err != nil {
+		return result.Result{}, err
+	}
+	var pd result.Result
+	pd.Replicated.State = &kvserverpb.ReplicaState{
+		ColdCompaction: cs,
+	}
+	return pd, nil
+}
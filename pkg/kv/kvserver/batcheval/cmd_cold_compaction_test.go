@@ -0,0 +1,111 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package batcheval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestColdCompaction tests that ColdCompaction requests set, compact and remove
+// the cold compaction policy of a range, and persist it in the range's replicated
+// state.
+func TestColdCompaction(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	engine := storage.NewDefaultInMemForTesting()
+	defer engine.Close()
+
+	desc := roachpb.RangeDescriptor{
+		RangeID:  1,
+		StartKey: roachpb.RKey("a"),
+		EndKey:   roachpb.RKey("z"),
+	}
+	evalCtx := (&MockEvalCtx{
+		ClusterSettings: cluster.MakeTestingClusterSettings(),
+		Desc:            &desc,
+	}).EvalContext()
+
+	send := func(
+		action kvpb.ColdCompactionRequest_Action, threshold, now hlc.Timestamp,
+	) (roachpb.ColdCompaction, bool, error) {
+		var resp kvpb.ColdCompactionResponse
+		res, err := ColdCompaction(ctx, engine, CommandArgs{
+			EvalCtx: evalCtx,
+			Header:  kvpb.Header{Timestamp: now},
+			Args: &kvpb.ColdCompactionRequest{
+				RequestHeader: kvpb.RequestHeader{Key: roachpb.Key("a")},
+				Action:        action,
+				Threshold:     threshold,
+			},
+			Stats: &enginepb.MVCCStats{},
+		}, &resp)
+		if err != nil {
+			return roachpb.ColdCompaction{}, false, err
+		}
+		// The in-memory state update must match the persisted state.
+		persisted, err := MakeStateLoader(evalCtx).LoadColdCompaction(ctx, engine)
+		require.NoError(t, err)
+		require.Equal(t, *persisted, resp.ColdCompaction)
+		if res.Replicated.State != nil {
+			require.Equal(t, persisted, res.Replicated.State.ColdCompaction)
+		}
+		return resp.ColdCompaction, res.Replicated.State != nil, nil
+	}
+	ts := func(wallTime int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wallTime} }
+
+	// A range without a policy can't be compacted.
+	_, _, err := send(kvpb.ColdCompactionRequest_COMPACT, hlc.Timestamp{}, ts(10))
+	require.ErrorContains(t, err, "r1 has no cold compaction policy")
+	_, _, err = send(kvpb.ColdCompactionRequest_SET, hlc.Timestamp{}, ts(10))
+	require.ErrorContains(t, err, "empty cold compaction threshold")
+
+	cs, changed, err := send(kvpb.ColdCompactionRequest_SET, ts(5), ts(10))
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, roachpb.ColdCompaction{Threshold: ts(5)}, cs)
+
+	// Setting the same policy again is a no-op.
+	_, changed, err = send(kvpb.ColdCompactionRequest_SET, ts(5), ts(20))
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// Compacting records the request timestamp, which survives threshold
+	// changes.
+	cs, changed, err = send(kvpb.ColdCompactionRequest_COMPACT, hlc.Timestamp{}, ts(30))
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, roachpb.ColdCompaction{Threshold: ts(5), CompactedAt: ts(30)}, cs)
+	cs, _, err = send(kvpb.ColdCompactionRequest_SET, ts(25), ts(40))
+	require.NoError(t, err)
+	require.Equal(t, roachpb.ColdCompaction{Threshold: ts(25), CompactedAt: ts(30)}, cs)
+
+	// Removing the policy clears the key.
+	cs, changed, err = send(kvpb.ColdCompactionRequest_REMOVE, hlc.Timestamp{}, ts(50))
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, roachpb.ColdCompaction{}, cs)
+	_, changed, err = send(kvpb.ColdCompactionRequest_REMOVE, hlc.Timestamp{}, ts(60))
+	require.NoError(t, err)
+	require.False(t, changed)
+}
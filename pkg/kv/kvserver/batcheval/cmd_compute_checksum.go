@@ -72,6 +72,7 @@ func ComputeChecksum(
 		Mode:       args.Mode,
 		Checkpoint: args.Checkpoint,
 		Terminate:  args.Terminate,
+		Snapshot:   args.Snapshot,
 	}
 	return pd, nil
 }
@@ -26,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/errors"
 )
 
@@ -38,10 +39,55 @@ var enableStickyGCHint = settings.RegisterBoolSetting(
 	false,
 )
 
+// ConvertFullRangeDeleteRange controls whether a non-transactional point
+// DeleteRange that covers an entire range is evaluated by writing a single MVCC
+// range tombstone across the range, with MVCC stats derived from the range's
+// stats, instead of writing a point tombstone for every live key. The range is
+// also marked in its GCHint, so that once the GC TTL has passed the MVCC GC
+// queue removes its data with an engine-level range deletion. Note that
+// rangefeeds over the range observe a single DeleteRange event rather than the
+// individual point deletions.
+//
+// The leaseholder decides whether to convert a request before declaring its
+// latches, see kvpb.DeleteRangeRequest.ConvertToRangeTombstone.
+var ConvertFullRangeDeleteRange = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.delete_range.full_range_conversion.enabled",
+	"if enabled, non-transactional DeleteRange requests that cover an entire "+
+		"range write an MVCC range tombstone instead of point tombstones",
+	false,
+)
+
+// ClearHistoryOnFullRangeDeleteRange controls whether a DeleteRange converted
+// per ConvertFullRangeDeleteRange also clears the MVCC history of the range
+// right away, using an engine-level range deletion, instead of leaving it to
+// the MVCC GC queue once the GC TTL has passed. The GC threshold of the range
+// is raised to just below the deletion, so that reads at older timestamps fail
+// instead of observing the missing history. The history is only cleared if no
+// protected timestamp record known to the leaseholder protects it.
+var ClearHistoryOnFullRangeDeleteRange = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.delete_range.full_range_conversion.clear_history.enabled",
+	"if enabled, DeleteRange requests converted to an MVCC range tombstone also "+
+		"clear the range's MVCC history immediately, disregarding the GC TTL",
+	false,
+)
+
 func init() {
 	RegisterReadWriteCommand(kvpb.DeleteRange, declareKeysDeleteRange, DeleteRange)
 }
 
+// MayConvertToRangeTombstone returns whether a point DeleteRange request is
+// eligible to be evaluated using an MVCC range tombstone, provided that it
+// covers the entire range and ConvertFullRangeDeleteRange is enabled. The
+// request must be non-transactional and must not depend on the individual keys
+// that it deletes.
+func MayConvertToRangeTombstone(header *kvpb.Header, args *kvpb.DeleteRangeRequest) bool {
+	return !args.UseRangeTombstone && !args.Inline && !args.ReturnKeys &&
+		args.Predicates == (kvpb.DeleteRangePredicates{}) && header.Txn == nil &&
+		header.MaxSpanRequestKeys == 0 && header.TargetBytes == 0
+}
+
 func declareKeysDeleteRange(
 	rs ImmutableRangeState,
 	header *kvpb.Header,
@@ -65,7 +111,11 @@ func declareKeysDeleteRange(
 	// When writing range tombstones, we must look for adjacent range tombstones
 	// that we merge with or fragment, to update MVCC stats accordingly. But we
 	// make sure to stay within the range bounds.
-	if args.UseRangeTombstone {
+	//
+	// Requests that the leaseholder marked for conversion to a range tombstone
+	// declare the same latches.
+	convert := args.ConvertToRangeTombstone && MayConvertToRangeTombstone(header, args)
+	if args.UseRangeTombstone || convert {
 		// NB: The range end key is not available, so this will pessimistically
 		// latch up to args.EndKey.Next(). If EndKey falls on the range end key, the
 		// span will be tightened during evaluation.
@@ -86,12 +136,20 @@ func declareKeysDeleteRange(
 			Key: keys.MVCCRangeKeyGCKey(rs.GetRangeID()),
 		})
 
-		if args.UpdateRangeDeleteGCHint {
+		if args.UpdateRangeDeleteGCHint || convert {
 			// If we are updating GC hint, add it to the latch span.
 			latchSpans.AddNonMVCC(spanset.SpanReadWrite, roachpb.Span{
 				Key: keys.RangeGCHintKey(rs.GetRangeID()),
 			})
 		}
+
+		if convert && !args.ClearHistoryAt.IsEmpty() {
+			// Clearing the history raises the GC threshold, serialize with GC
+			// requests like they do with each other.
+			latchSpans.AddNonMVCC(spanset.SpanReadWrite, roachpb.Span{
+				Key: keys.RangeGCThresholdKey(rs.GetRangeID()),
+			})
+		}
 	}
 	return nil
 }
@@ -140,37 +198,7 @@ func DeleteRange(
 			if !args.UpdateRangeDeleteGCHint {
 				return nil
 			}
-			sl := MakeStateLoader(cArgs.EvalCtx)
-			hint, err := sl.LoadGCHint(ctx, readWriter)
-			if err != nil {
-				return err
-			}
-
-			updated := false
-			// TODO(pavelkalinnikov): deprecate the cluster setting and call
-			// ScheduleGCFor unconditionally when min supported version is 23.2.
-			if cArgs.EvalCtx.ClusterSettings().Version.IsActive(ctx, clusterversion.V23_2) ||
-				enableStickyGCHint.Get(&cArgs.EvalCtx.ClusterSettings().SV) {
-				// Add the timestamp to GCHint to guarantee that GC eventually clears it.
-				updated = hint.ScheduleGCFor(h.Timestamp)
-			}
-			// If the range tombstone covers the whole Range key span, update the
-			// corresponding timestamp in GCHint to enable ClearRange optimization.
-			if args.Key.Equal(desc.StartKey.AsRawKey()) && args.EndKey.Equal(desc.EndKey.AsRawKey()) {
-				// NB: don't swap the order, we want to call the method unconditionally.
-				updated = hint.ForwardLatestRangeDeleteTimestamp(h.Timestamp) || updated
-			}
-			if !updated {
-				return nil
-			}
-
-			if err := sl.SetGCHint(ctx, readWriter, cArgs.Stats, hint); err != nil {
-				return err
-			}
-			res.Replicated.State = &kvserverpb.ReplicaState{
-				GCHint: hint,
-			}
-			return nil
+			return updateRangeDeleteGCHint(ctx, readWriter, cArgs, res)
 		}
 
 		leftPeekBound, rightPeekBound := rangeTombstonePeekBounds(
@@ -245,6 +273,12 @@ func DeleteRange(
 		return result.Result{}, nil
 	}
 
+	if args.ConvertToRangeTombstone && MayConvertToRangeTombstone(&h, args) {
+		if res, ok, err := deleteFullRangeUsingTombstone(ctx, readWriter, cArgs, reply); ok || err != nil {
+			return res, err
+		}
+	}
+
 	var timestamp hlc.Timestamp
 	if !args.Inline {
 		timestamp = h.Timestamp
@@ -291,3 +325,186 @@ func DeleteRange(
 
 	return result.WithAcquiredLocks(acqs...), nil
 }
+
+// updateRangeDeleteGCHint updates the GCHint of the range after an MVCC range
+// tombstone was written across the request's span at the request's timestamp,
+// and adds it to the result if it changed.
+func updateRangeDeleteGCHint(
+	ctx context.Context, readWriter storage.ReadWriter, cArgs CommandArgs, res *result.Result,
+) error {
+	h := cArgs.Header
+	span := cArgs.Args.Header().Span()
+	desc := cArgs.EvalCtx.Desc()
+	sl := MakeStateLoader(cArgs.EvalCtx)
+	hint, err := sl.LoadGCHint(ctx, readWriter)
+	if err != nil {
+		return err
+	}
+
+	updated := false
+	// TODO(pavelkalinnikov): deprecate the cluster setting and call
+	// ScheduleGCFor unconditionally when min supported version is 23.2.
+	if cArgs.EvalCtx.ClusterSettings().Version.IsActive(ctx, clusterversion.V23_2) ||
+		enableStickyGCHint.Get(&cArgs.EvalCtx.ClusterSettings().SV) {
+		// Add the timestamp to GCHint to guarantee that GC eventually clears it.
+		updated = hint.ScheduleGCFor(h.Timestamp)
+	}
+	// If the range tombstone covers the whole Range key span, update the
+	// corresponding timestamp in GCHint to enable ClearRange optimization.
+	if span.Key.Equal(desc.StartKey.AsRawKey()) && span.EndKey.Equal(desc.EndKey.AsRawKey()) {
+		// NB: don't swap the order, we want to call the method unconditionally.
+		updated = hint.ForwardLatestRangeDeleteTimestamp(h.Timestamp) || updated
+	}
+	if !updated {
+		return nil
+	}
+
+	if err := sl.SetGCHint(ctx, readWriter, cArgs.Stats, hint); err != nil {
+		return err
+	}
+	if res.Replicated.State == nil {
+		res.Replicated.State = &kvserverpb.ReplicaState{}
+	}
+	res.Replicated.State.GCHint = hint
+	return nil
+}
+
+// deleteFullRangeUsingTombstone evaluates a point DeleteRange request that
+// covers the entire range by writing a single MVCC range tombstone, using the
+// range's MVCC stats to compute the stats delta instead of scanning all point
+// keys. It also updates the range's GCHint so that the MVCC GC queue can clear
+// the range's data using an engine-level range deletion once the GC TTL has
+// passed, unless the request allows clearing the range's history right away.
+//
+// Returns ok=false (and no error) if the request does not cover the entire
+// range, or if it conflicts with newer writes, in which case the caller should
+// fall back to writing point tombstones.
+func deleteFullRangeUsingTombstone(
+	ctx context.Context, readWriter storage.ReadWriter, cArgs CommandArgs, reply *kvpb.DeleteRangeResponse,
+) (_ result.Result, ok bool, _ error) {
+	args := cArgs.Args.(*kvpb.DeleteRangeRequest)
+	h := cArgs.Header
+	desc := cArgs.EvalCtx.Desc()
+	if !args.Key.Equal(desc.StartKey.AsRawKey()) || !args.EndKey.Equal(desc.EndKey.AsRawKey()) {
+		return result.Result{}, false, nil
+	}
+
+	// NB: We take the fast path even if stats are estimates, like the
+	// UseRangeTombstone path does.
+	stats := cArgs.EvalCtx.GetMVCCStats()
+	statsCovered := stats
+	leftPeekBound, rightPeekBound := rangeTombstonePeekBounds(
+		args.Key, args.EndKey, desc.StartKey.AsRawKey(), desc.EndKey.AsRawKey())
+	maxLockConflicts := storage.MaxConflictsPerLockConflictError.Get(&cArgs.EvalCtx.ClusterSettings().SV)
+	var res result.Result
+
+	// The leaseholder only sets ClearHistoryAt after checking the protected
+	// timestamps of the range at this timestamp. If the request was pushed, the
+	// history is left to the MVCC GC queue.
+	if !args.ClearHistoryAt.IsEmpty() && args.ClearHistoryAt == h.Timestamp {
+		if err := clearFullRangeHistory(ctx, readWriter, cArgs, &res); err != nil {
+			if errors.HasType(err, (*kvpb.WriteTooOldError)(nil)) {
+				log.VEventf(ctx, 2, "not converting DeleteRange to range tombstone: %v", err)
+				return result.Result{}, false, nil
+			}
+			return result.Result{}, false, err
+		}
+		// The range is now empty, so the range tombstone covers no data.
+		statsCovered = enginepb.MVCCStats{}
+	}
+
+	// The conflict checks are performed before anything is written, so on a
+	// WriteTooOldError the batch is untouched and we can fall back to point
+	// tombstones, which handle newer writes the same way they always have.
+	if err := storage.MVCCDeleteRangeUsingTombstone(ctx, readWriter, cArgs.Stats,
+		args.Key, args.EndKey, h.Timestamp, cArgs.Now, leftPeekBound, rightPeekBound,
+		false /* idempotent */, maxLockConflicts, &statsCovered); err != nil {
+		if errors.HasType(err, (*kvpb.WriteTooOldError)(nil)) {
+			log.VEventf(ctx, 2, "not converting DeleteRange to range tombstone: %v", err)
+			return result.Result{}, false, nil
+		}
+		return result.Result{}, false, err
+	}
+	// The number of deleted keys is only known if the stats are accurate.
+	if stats.ContainsEstimates == 0 {
+		reply.NumKeys = stats.LiveCount
+	}
+
+	if err := updateRangeDeleteGCHint(ctx, readWriter, cArgs, &res); err != nil {
+		return result.Result{}, false, err
+	}
+	return res, true, nil
+}
+
+// clearFullRangeHistory clears all the MVCC data of the range, which the
+// request covers entirely, using an engine-level range deletion, and raises
+// the range's GC threshold to just below the request's timestamp so that reads
+// below it fail instead of observing the missing history. It returns a
+// LockConflictError if the range has locks, and a WriteTooOldError if it has
+// data at or above the request's timestamp, in both cases without writing.
+func clearFullRangeHistory(
+	ctx context.Context, readWriter storage.ReadWriter, cArgs CommandArgs, res *result.Result,
+) error {
+	h := cArgs.Header
+	from, to := cArgs.Args.Header().Key, cArgs.Args.Header().EndKey
+
+	maxLockConflicts := storage.MaxConflictsPerLockConflictError.Get(&cArgs.EvalCtx.ClusterSettings().SV)
+	locks, err := storage.ScanLocks(ctx, readWriter, from, to, maxLockConflicts, 0,
+		storage.BatchEvalReadCategory)
+	if err != nil {
+		return err
+	} else if len(locks) > 0 {
+		return &kvpb.LockConflictError{Locks: locks}
+	}
+	if err := func() error {
+		iter, err := storage.NewMVCCIncrementalIterator(ctx, readWriter, storage.MVCCIncrementalIterOptions{
+			KeyTypes:     storage.IterKeyTypePointsAndRanges,
+			StartKey:     from,
+			EndKey:       to,
+			StartTime:    h.Timestamp.Prev(), // make inclusive
+			ReadCategory: storage.BatchEvalReadCategory,
+		})
+		if err != nil {
+			return err
+		}
+		defer iter.Close()
+		iter.SeekGE(storage.MVCCKey{Key: from})
+		if ok, err := iter.Valid(); err != nil || !ok {
+			return err
+		}
+		if hasPoint, _ := iter.HasPointAndRange(); hasPoint {
+			key := iter.UnsafeKey()
+			return kvpb.NewWriteTooOldError(h.Timestamp, key.Timestamp.Next(), key.Key)
+		}
+		return kvpb.NewWriteTooOldError(h.Timestamp, iter.RangeKeys().Newest().Next(),
+			iter.RangeBounds().Key)
+	}(); err != nil {
+		return err
+	}
+
+	statsDelta, err := computeStatsDelta(ctx, readWriter, cArgs, from, to)
+	if err != nil {
+		return err
+	}
+	cArgs.Stats.Subtract(statsDelta)
+	const pointKeyThreshold, rangeKeyThreshold = 2, 2
+	if err := storage.ClearRangeWithHeuristic(
+		ctx, readWriter, readWriter, from, to, pointKeyThreshold, rangeKeyThreshold,
+	); err != nil {
+		return err
+	}
+
+	gcThreshold := cArgs.EvalCtx.GetGCThreshold()
+	if gcThreshold.Forward(h.Timestamp.Prev()) {
+		if err := MakeStateLoader(cArgs.EvalCtx).SetGCThreshold(
+			ctx, readWriter, cArgs.Stats, &gcThreshold,
+		); err != nil {
+			return err
+		}
+		if res.Replicated.State == nil {
+			res.Replicated.State = &kvserverpb.ReplicaState{}
+		}
+		res.Replicated.State.GCThreshold = &gcThreshold
+	}
+	return nil
+}
@@ -417,3 +417,135 @@ func computeStats(
 	require.NoError(t, err)
 	return ms
 }
+
+// TestDeleteRangeFullRangeConversion tests that a non-transactional point
+// DeleteRange covering the entire range is converted to an MVCC range
+// tombstone when the leaseholder marked it with ConvertToRangeTombstone, that
+// it also clears the range's history when marked with ClearHistoryAt, and that
+// it falls back to point tombstones otherwise.
+func TestDeleteRangeFullRangeConversion(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	storage.DisableMetamorphicSimpleValueEncoding(t)
+
+	now := hlc.ClockTimestamp{Logical: 9}
+	rangeStart, rangeEnd := roachpb.Key("a"), roachpb.Key("z")
+
+	testcases := map[string]struct {
+		start, end   string
+		ts           int64
+		convert      bool
+		clearHistory bool
+		expectRange  bool
+	}{
+		"full range converted": {
+			start: "a", end: "z", ts: 10e9, convert: true, expectRange: true,
+		},
+		"full range converted with history cleared": {
+			start: "a", end: "z", ts: 10e9, convert: true, clearHistory: true, expectRange: true,
+		},
+		"not marked": {
+			start: "a", end: "z", ts: 10e9, convert: false, expectRange: false,
+		},
+		"partial range not converted": {
+			start: "a", end: "d", ts: 10e9, convert: true, expectRange: false,
+		},
+		"below newer write falls back": {
+			start: "a", end: "z", ts: 3e9, convert: true, expectRange: false,
+		},
+		"below newer write falls back without clearing history": {
+			start: "a", end: "z", ts: 3e9, convert: true, clearHistory: true, expectRange: false,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			st := cluster.MakeTestingClusterSettings()
+			engine := storage.NewDefaultInMemForTesting()
+			defer engine.Close()
+
+			_, err := storage.MVCCPut(ctx, engine, roachpb.Key("b"), hlc.Timestamp{WallTime: 2e9}, roachpb.MakeValueFromString("b2"), storage.MVCCWriteOptions{})
+			require.NoError(t, err)
+			_, err = storage.MVCCPut(ctx, engine, roachpb.Key("c"), hlc.Timestamp{WallTime: 4e9}, roachpb.MakeValueFromString("c4"), storage.MVCCWriteOptions{})
+			require.NoError(t, err)
+
+			ts := hlc.Timestamp{WallTime: tc.ts}
+			evalCtx := &MockEvalCtx{
+				ClusterSettings: st,
+				Desc: &roachpb.RangeDescriptor{
+					RangeID:  1,
+					StartKey: roachpb.RKey(rangeStart),
+					EndKey:   roachpb.RKey(rangeEnd),
+				},
+				Stats: computeStats(t, engine, rangeStart, rangeEnd, ts.WallTime),
+			}
+			h := kvpb.Header{Timestamp: ts}
+			req := &kvpb.DeleteRangeRequest{
+				RequestHeader: kvpb.RequestHeader{
+					Key:    roachpb.Key(tc.start),
+					EndKey: roachpb.Key(tc.end),
+				},
+				ConvertToRangeTombstone: tc.convert,
+			}
+			if tc.clearHistory {
+				req.ClearHistoryAt = ts
+			}
+
+			var latchSpans spanset.SpanSet
+			var lockSpans lockspanset.LockSpanSet
+			require.NoError(t,
+				declareKeysDeleteRange(evalCtx.Desc, &h, req, &latchSpans, &lockSpans, 0),
+			)
+			// Only the requests marked for conversion declare the GC hint.
+			gcHintKey := roachpb.Span{Key: keys.RangeGCHintKey(evalCtx.Desc.RangeID)}
+			require.Equal(t, tc.convert,
+				latchSpans.CheckAllowed(spanset.SpanReadWrite, gcHintKey) == nil)
+			batch := spanset.NewBatchAt(engine.NewBatch(), &latchSpans, h.Timestamp)
+			defer batch.Close()
+
+			ms := evalCtx.Stats
+			resp := &kvpb.DeleteRangeResponse{}
+			res, err := DeleteRange(ctx, batch, CommandArgs{
+				EvalCtx: evalCtx.EvalContext(),
+				Stats:   &ms,
+				Now:     now,
+				Header:  h,
+				Args:    req,
+			}, resp)
+			if tc.ts < 4e9 {
+				// The point tombstone path rejects the write below c4.
+				require.True(t, errors.HasType(err, &kvpb.WriteTooOldError{}), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, batch.Commit(true))
+
+			rangeKey := storage.MVCCRangeKey{
+				StartKey:  roachpb.Key(tc.start),
+				EndKey:    roachpb.Key(tc.end),
+				Timestamp: ts,
+			}
+			checkDeleteRangeTombstone(t, engine, rangeKey, tc.expectRange, now)
+			require.EqualValues(t, 2, resp.NumKeys)
+			if tc.expectRange {
+				require.NotNil(t, res.Replicated.State)
+				require.Equal(t, ts, res.Replicated.State.GCHint.LatestRangeDeleteTimestamp)
+			}
+			if tc.clearHistory && tc.expectRange {
+				// The history was cleared, and reads below the deletion fail.
+				require.Equal(t, ts.Prev(), *res.Replicated.State.GCThreshold)
+				kvs, err := storage.Scan(ctx, engine, rangeStart, rangeEnd, 0)
+				require.NoError(t, err)
+				require.Empty(t, kvs)
+			} else if res.Replicated.State != nil {
+				require.Nil(t, res.Replicated.State.GCThreshold)
+			}
+
+			// The stats delta must match the stats computed from scratch, ignoring
+			// the range-local GC hint and GC threshold keys.
+			ms.SysBytes, ms.SysCount = 0, 0
+			require.Equal(t, computeStats(t, engine, rangeStart, rangeEnd, ts.WallTime), ms)
+		})
+	}
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/iterutil"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/redact"
 )
@@ -189,6 +190,26 @@ func declareKeysEndTxn(
 				latchSpans.AddNonMVCC(spanset.SpanReadWrite, roachpb.Span{
 					Key: keys.RangeGCHintKey(mt.LeftDesc.RangeID),
 				})
+				// Merge carries over the write fences of the RHS, so we need to get a
+				// write latch on the left side.
+				latchSpans.AddNonMVCC(spanset.SpanReadWrite, roachpb.Span{
+					Key: keys.RangeWriteFencesKey(mt.LeftDesc.RangeID),
+				})
+				// Merge may carry over the replica pin of the RHS, so we need to get a
+				// write latch on the left side.
+				latchSpans.AddNonMVCC(spanset.SpanReadWrite, roachpb.Span{
+					Key: keys.RangeReplicaPinKey(mt.LeftDesc.RangeID),
+				})
+				// Merge combines the cold compaction policies of both sides, so we need
+				// to get a write latch on the left side.
+				latchSpans.AddNonMVCC(spanset.SpanReadWrite, roachpb.Span{
+					Key: keys.RangeColdCompactionKey(mt.LeftDesc.RangeID),
+				})
+				// Merge may carry over the hot standby of the RHS, so we need to get a
+				// write latch on the left side.
+				latchSpans.AddNonMVCC(spanset.SpanReadWrite, roachpb.Span{
+					Key: keys.RangeHotStandbyKey(mt.LeftDesc.RangeID),
+				})
 
 				// Merges need to adjust MVCC stats for merged MVCC range tombstones
 				// that straddle the ranges, by peeking to the left and right of the RHS
@@ -438,11 +459,18 @@ func EndTxn(
 	// we position the transaction record next to the first write of a transaction.
 	// This avoids the need for the intentResolver to have to return to this range
 	// to resolve locks for this transaction in the future.
+	var resolveStart time.Time
+	if cArgs.CommitLatency != nil {
+		resolveStart = timeutil.Now()
+	}
 	resolvedLocks, releasedReplLocks, externalLocks, err := resolveLocalLocks(
 		ctx, readWriter, cArgs.EvalCtx, ms, args, reply.Txn)
 	if err != nil {
 		return result.Result{}, err
 	}
+	if cArgs.CommitLatency != nil && args.Commit {
+		cArgs.CommitLatency.IntentResolution = timeutil.Since(resolveStart)
+	}
 	if err := updateFinalizedTxn(
 		ctx, readWriter, cArgs.EvalCtx, ms, key, args, reply.Txn, recordAlreadyExisted, externalLocks,
 	); err != nil {
@@ -1237,6 +1265,69 @@ func splitTriggerHelper(
 		if err != nil {
 			return enginepb.MVCCStats{}, result.Result{}, errors.Wrap(err, "unable to write initial Replica state")
 		}
+
+		// Copy the write fences overlapping the RHS, so that they continue to be
+		// enforced on the new range. The LHS keeps all of its fences; those which
+		// no longer overlap it are inert.
+		fences, err := sl.LoadWriteFences(ctx, batch)
+		if err != nil {
+			return enginepb.MVCCStats{}, result.Result{}, errors.Wrap(err, "unable to load WriteFences")
+		}
+		var rightFences roachpb.WriteFences
+		rightSpan := split.RightDesc.RSpan().AsRawSpanWithNoLocals()
+		for _, fence := range fences.Fences {
+			if fence.Span.Overlaps(rightSpan) {
+				rightFences.Fences = append(rightFences.Fences, fence)
+			}
+		}
+		if !rightFences.IsEmpty() {
+			if err := stateloader.Make(split.RightDesc.RangeID).SetWriteFences(
+				ctx, batch, h.AbsPostSplitRight(), &rightFences,
+			); err != nil {
+				return enginepb.MVCCStats{}, result.Result{}, errors.Wrap(err, "unable to write WriteFences")
+			}
+		}
+
+		// Pin the voters of the RHS to the same stores as the LHS, so that pinned
+		// critical ranges remain pinned after a split.
+		pin, err := sl.LoadReplicaPin(ctx, batch)
+		if err != nil {
+			return enginepb.MVCCStats{}, result.Result{}, errors.Wrap(err, "unable to load ReplicaPin")
+		}
+		if len(pin.VoterStoreIDs) > 0 {
+			if err := stateloader.Make(split.RightDesc.RangeID).SetReplicaPin(
+				ctx, batch, h.AbsPostSplitRight(), pin,
+			); err != nil {
+				return enginepb.MVCCStats{}, result.Result{}, errors.Wrap(err, "unable to write ReplicaPin")
+			}
+		}
+
+		// The RHS inherits the cold compaction policy of the LHS, along with the
+		// compaction state of its data.
+		cs, err := sl.LoadColdCompaction(ctx, batch)
+		if err != nil {
+			return enginepb.MVCCStats{}, result.Result{}, errors.Wrap(err, "unable to load ColdCompaction")
+		}
+		if !cs.Threshold.IsEmpty() {
+			if err := stateloader.Make(split.RightDesc.RangeID).SetColdCompaction(
+				ctx, batch, h.AbsPostSplitRight(), cs,
+			); err != nil {
+				return enginepb.MVCCStats{}, result.Result{}, errors.Wrap(err, "unable to write ColdCompaction")
+			}
+		}
+
+		// The RHS inherits the hot standby of the LHS, whose replicas it shares.
+		hs, err := sl.LoadHotStandby(ctx, batch)
+		if err != nil {
+			return enginepb.MVCCStats{}, result.Result{}, errors.Wrap(err, "unable to load HotStandby")
+		}
+		if hs.StoreID != 0 {
+			if err := stateloader.Make(split.RightDesc.RangeID).SetHotStandby(
+				ctx, batch, h.AbsPostSplitRight(), hs,
+			); err != nil {
+				return enginepb.MVCCStats{}, result.Result{}, errors.Wrap(err, "unable to write HotStandby")
+			}
+		}
 	}
 
 	var pd result.Result
@@ -1369,6 +1460,110 @@ func mergeTrigger(
 			}
 		}
 	}
+
+	{
+		// Carry over the write fences of the RHS, so that they continue to be
+		// enforced on the merged range.
+		lhsLoader := MakeStateLoader(rec)
+		lhsFences, err := lhsLoader.LoadWriteFences(ctx, batch)
+		if err != nil {
+			return result.Result{}, err
+		}
+		rhsLoader := stateloader.Make(merge.RightDesc.RangeID)
+		rhsFences, err := rhsLoader.LoadWriteFences(ctx, batch)
+		if err != nil {
+			return result.Result{}, err
+		}
+		if lhsFences.Merge(rhsFences) {
+			if err := lhsLoader.SetWriteFences(ctx, batch, ms, lhsFences); err != nil {
+				return result.Result{}, err
+			}
+			if pd.Replicated.State == nil {
+				pd.Replicated.State = &kvserverpb.ReplicaState{}
+			}
+			pd.Replicated.State.WriteFences = lhsFences
+		}
+	}
+
+	{
+		// The merged range keeps the replica pin of the LHS. If the LHS is not
+		// pinned, it adopts the pin of the RHS instead, if any.
+		lhsLoader := MakeStateLoader(rec)
+		lhsPin, err := lhsLoader.LoadReplicaPin(ctx, batch)
+		if err != nil {
+			return result.Result{}, err
+		}
+		rhsPin, err := stateloader.Make(merge.RightDesc.RangeID).LoadReplicaPin(ctx, batch)
+		if err != nil {
+			return result.Result{}, err
+		}
+		if len(lhsPin.VoterStoreIDs) == 0 && len(rhsPin.VoterStoreIDs) > 0 {
+			if err := lhsLoader.SetReplicaPin(ctx, batch, ms, rhsPin); err != nil {
+				return result.Result{}, err
+			}
+			if pd.Replicated.State == nil {
+				pd.Replicated.State = &kvserverpb.ReplicaState{}
+			}
+			pd.Replicated.State.ReplicaPin = rhsPin
+		}
+	}
+
+	{
+		// The merged range keeps the cold compaction policy of the LHS, or adopts
+		// that of the RHS if the LHS has none. Its data is only compacted as of the
+		// earliest compaction of both sides: if one of them has no policy, its data
+		// was never compacted, and neither was the merged range.
+		lhsLoader := MakeStateLoader(rec)
+		lhsCS, err := lhsLoader.LoadColdCompaction(ctx, batch)
+		if err != nil {
+			return result.Result{}, err
+		}
+		rhsCS, err := stateloader.Make(merge.RightDesc.RangeID).LoadColdCompaction(ctx, batch)
+		if err != nil {
+			return result.Result{}, err
+		}
+		mergedCS := *lhsCS
+		if mergedCS.Threshold.IsEmpty() {
+			mergedCS = *rhsCS
+		}
+		if lhsCS.Threshold.IsEmpty() || rhsCS.Threshold.IsEmpty() {
+			mergedCS.CompactedAt = hlc.Timestamp{}
+		} else if rhsCS.CompactedAt.Less(mergedCS.CompactedAt) {
+			mergedCS.CompactedAt = rhsCS.CompactedAt
+		}
+		if !mergedCS.Equal(lhsCS) {
+			if err := lhsLoader.SetColdCompaction(ctx, batch, ms, &mergedCS); err != nil {
+				return result.Result{}, err
+			}
+			if pd.Replicated.State == nil {
+				pd.Replicated.State = &kvserverpb.ReplicaState{}
+			}
+			pd.Replicated.State.ColdCompaction = &mergedCS
+		}
+	}
+
+	{
+		// The merged range keeps the hot standby of the LHS. If the LHS has none,
+		// it adopts that of the RHS instead, if any.
+		lhsLoader := MakeStateLoader(rec)
+		lhsHS, err := lhsLoader.LoadHotStandby(ctx, batch)
+		if err != nil {
+			return result.Result{}, err
+		}
+		rhsHS, err := stateloader.Make(merge.RightDesc.RangeID).LoadHotStandby(ctx, batch)
+		if err != nil {
+			return result.Result{}, err
+		}
+		if lhsHS.StoreID == 0 && rhsHS.StoreID != 0 {
+			if err := lhsLoader.SetHotStandby(ctx, batch, ms, rhsHS); err != nil {
+				return result.Result{}, err
+			}
+			if pd.Replicated.State == nil {
+				pd.Replicated.State = &kvserverpb.ReplicaState{}
+			}
+			pd.Replicated.State.HotStandby = rhsHS
+		}
+	}
 	return pd, nil
 }
 
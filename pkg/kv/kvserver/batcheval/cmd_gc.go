@@ -202,7 +202,7 @@ func GC(
 	// Garbage collect the specified keys by expiration timestamps.
 	for _, gcKeys := range [][]kvpb.GCRequest_GCKey{localKeys, globalKeys} {
 		if err := storage.MVCCGarbageCollect(
-			ctx, readWriter, cArgs.Stats, gcKeys, h.Timestamp,
+			ctx, readWriter, cArgs.Stats, gcKeys, h.Timestamp, cArgs.EvalCtx.GetGCThreshold(),
 		); err != nil {
 			return result.Result{}, err
 		}
@@ -0,0 +1,88 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package batcheval
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/batcheval/result"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/lockspanset"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/spanset"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/errors"
+)
+
+func init() {
+	RegisterReadWriteCommand(kvpb.HotStandby, declareKeysHotStandby, HotStandby)
+}
+
+func declareKeysHotStandby(
+	rs ImmutableRangeState,
+	_ *kvpb.Header,
+	_ kvpb.Request,
+	latchSpans *spanset.SpanSet,
+	_ *lockspanset.LockSpanSet,
+	_ time.Duration,
+) error {
+	latchSpans.AddNonMVCC(spanset.SpanReadWrite, roachpb.Span{
+		Key: keys.RangeHotStandbyKey(rs.GetRangeID()),
+	})
+	return nil
+}
+
+// HotStandby designates the hot standby replica of the range, or removes the
+// designation. See the comment on HotStandbyRequest for details.
+func HotStandby(
+	ctx context.Context, readWriter storage.ReadWriter, cArgs CommandArgs, resp kvpb.Response,
+) (result.Result, error) {
+	args := cArgs.Args.(*kvpb.HotStandbyRequest)
+	reply := resp.(*kvpb.HotStandbyResponse)
+
+	hs := &roachpb.HotStandby{}
+	if args.StoreID != 0 {
+		if !cArgs.EvalCtx.ClusterSettings().Version.IsActive(ctx, clusterversion.V24_1_HotStandby) {
+			return result.Result{}, errors.Newf(
+				"hot standbys require cluster version %s", clusterversion.V24_1_HotStandby)
+		}
+		// The designation outlives the replica if it is later removed, in which
+		// case it is ignored, but it can't designate a replica which isn't a
+		// voter to begin with.
+		desc := cArgs.EvalCtx.Desc()
+		if repl, ok := desc.GetReplicaDescriptor(args.StoreID); !ok || !repl.IsVoterNewConfig() {
+			return result.Result{}, errors.Errorf("s%d has no voter of r%d", args.StoreID, desc.RangeID)
+		}
+		hs.StoreID = args.StoreID
+	}
+
+	sl := MakeStateLoader(cArgs.EvalCtx)
+	prev, err := sl.LoadHotStandby(ctx, readWriter)
+	if err != nil {
+		return result.Result{}, err
+	}
+	reply.HotStandby = *hs
+	if prev.Equal(hs) {
+		return result.Result{}, nil
+	}
+	if err := sl.SetHotStandby(ctx, readWriter, cArgs.Stats, hs); err != nil {
+		return result.Result{}, err
+	}
+	var pd result.Result
+	pd.Replicated.State = &kvserverpb.ReplicaState{
+		HotStandby: hs,
+	}
+	return pd, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package batcheval
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/batcheval/result"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/lockspanset"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/spanset"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/errors"
+)
+
+func init() {
+	RegisterReadWriteCommand(kvpb.PinReplicas, declareKeysPinReplicas, PinReplicas)
+}
+
+func declareKeysPinReplicas(
+	rs ImmutableRangeState,
+	_ *kvpb.Header,
+	_ kvpb.Request,
+	latchSpans *spanset.SpanSet,
+	_ *lockspanset.LockSpanSet,
+	_ time.Duration,
+) error {
+	latchSpans.AddNonMVCC(spanset.SpanReadWrite, roachpb.Span{
+		Key: keys.RangeReplicaPinKey(rs.GetRangeID()),
+	})
+	return nil
+}
+
+// PinReplicas pins the voters of the range to the requested stores, or removes
+// the pin of the range. See the comment on PinReplicasRequest for details.
+func PinReplicas(
+	ctx context.Context, readWriter storage.ReadWriter, cArgs CommandArgs, resp kvpb.Response,
+) (result.Result, error) {
+	args := cArgs.Args.(*kvpb.PinReplicasRequest)
+	reply := resp.(*kvpb.PinReplicasResponse)
+
+	pin := &roachpb.ReplicaPin{}
+	if !args.Unpin {
+		if !cArgs.EvalCtx.ClusterSettings().Version.IsActive(ctx, clusterversion.V24_1_ReplicaPins) {
+			return result.Result{}, errors.Newf(
+				"replica pins require cluster version %s", clusterversion.V24_1_ReplicaPins)
+		}
+		if len(args.VoterStoreIDs) == 0 {
+			return result.Result{}, errors.New("no stores to pin the voters to")
+		}
+		seen := make(map[roachpb.StoreID]struct{}, len(args.VoterStoreIDs))
+		for _, storeID := range args.VoterStoreIDs {
+			if storeID <= 0 {
+				return result.Result{}, errors.Errorf("invalid store s%d", storeID)
+			}
+			if _, ok := seen[storeID]; ok {
+				return result.Result{}, errors.Errorf("s%d is pinned more than once", storeID)
+			}
+			seen[storeID] = struct{}{}
+		}
+		pin.VoterStoreIDs = args.VoterStoreIDs
+	}
+
+	sl := MakeStateLoader(cArgs.EvalCtx)
+	prev, err := sl.LoadReplicaPin(ctx, readWriter)
+	if err != nil {
+		return result.Result{}, err
+	}
+	reply.Pin = *pin
+	if prev.Equal(pin) {
+		return result.Result{}, nil
+	}
+	if err := sl.SetReplicaPin(ctx, readWriter, cArgs.Stats, pin); err != nil {
+		return result.Result{}, err
+	}
+	var pd result.Result
+	pd.Replicated.State = &kvserverpb.ReplicaState{
+		ReplicaPin: pin,
+	}
+	return pd, nil
+}
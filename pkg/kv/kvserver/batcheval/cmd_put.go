@@ -14,13 +14,17 @@ import (
 	"context"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/batcheval/result"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/lockspanset"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/spanset"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/errors"
 )
 
 func init() {
@@ -38,9 +42,17 @@ func declareKeysPut(
 	args := req.(*kvpb.PutRequest)
 	if args.Inline {
 		return DefaultDeclareKeys(rs, header, req, latchSpans, lockSpans, maxOffset)
-	} else {
-		return DefaultDeclareIsolatedKeys(rs, header, req, latchSpans, lockSpans, maxOffset)
 	}
+	if err := DefaultDeclareIsolatedKeys(rs, header, req, latchSpans, lockSpans, maxOffset); err != nil {
+		return err
+	}
+	if !args.Expiration.IsEmpty() {
+		// The expiration is added to the GC hint of the range.
+		latchSpans.AddNonMVCC(spanset.SpanReadWrite, roachpb.Span{
+			Key: keys.RangeGCHintKey(rs.GetRangeID()),
+		})
+	}
+	return nil
 }
 
 // Put sets the value for a specified key.
@@ -54,6 +66,15 @@ func Put(
 	if !args.Inline {
 		ts = h.Timestamp
 	}
+	if !args.Expiration.IsEmpty() {
+		if !cArgs.EvalCtx.ClusterSettings().Version.IsActive(ctx, clusterversion.V24_1_MVCCValueExpiration) {
+			return result.Result{}, errors.Newf(
+				"put with expiration requires cluster version %s", clusterversion.V24_1_MVCCValueExpiration)
+		}
+		if args.Inline {
+			return result.Result{}, errors.New("inline put cannot have an expiration")
+		}
+	}
 
 	opts := storage.MVCCWriteOptions{
 		Txn:                            h.Txn,
@@ -63,6 +84,7 @@ func Put(
 		OmitInRangefeeds:               cArgs.OmitInRangefeeds,
 		MaxLockConflicts:               storage.MaxConflictsPerLockConflictError.Get(&cArgs.EvalCtx.ClusterSettings().SV),
 		Category:                       storage.BatchEvalReadCategory,
+		Expiration:                     args.Expiration,
 	}
 
 	var err error
@@ -75,5 +97,42 @@ func Put(
 	if err != nil {
 		return result.Result{}, err
 	}
-	return result.WithAcquiredLocks(acq), nil
+	res := result.WithAcquiredLocks(acq)
+	if !args.Expiration.IsEmpty() {
+		if err := scheduleGCForExpiration(ctx, readWriter, cArgs, args.Expiration, &res); err != nil {
+			return result.Result{}, err
+		}
+	}
+	return res, nil
+}
+
+// scheduleGCForExpiration schedules the GC of the range for the expiration of
+// a written value in its GCHint, and adds the hint to the result if it changed.
+// An expired value is accounted as live in the stats until it's garbage
+// collected, so they wouldn't otherwise make the MVCC GC queue process the
+// range; the hint makes it process the range once the GC threshold can reach
+// the expiration.
+func scheduleGCForExpiration(
+	ctx context.Context,
+	readWriter storage.ReadWriter,
+	cArgs CommandArgs,
+	expiration hlc.Timestamp,
+	res *result.Result,
+) error {
+	sl := MakeStateLoader(cArgs.EvalCtx)
+	hint, err := sl.LoadGCHint(ctx, readWriter)
+	if err != nil {
+		return err
+	}
+	if !hint.ScheduleGCFor(expiration) {
+		return nil
+	}
+	if err := sl.SetGCHint(ctx, readWriter, cArgs.Stats, hint); err != nil {
+		return err
+	}
+	if res.Replicated.State == nil {
+		res.Replicated.State = &kvserverpb.ReplicaState{}
+	}
+	res.Replicated.State.GCHint = hint
+	return nil
 }
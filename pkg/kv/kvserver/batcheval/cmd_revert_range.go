@@ -125,6 +125,7 @@ func RevertRange(
 		Replicated: kvserverpb.ReplicatedEvalResult{
 			MVCCHistoryMutation: &kvserverpb.ReplicatedEvalResult_MVCCHistoryMutation{
 				Spans: []roachpb.Span{{Key: args.Key, EndKey: args.EndKey}},
+				Token: args.MVCCHistoryMutationToken,
 			},
 		},
 	}
@@ -65,6 +65,13 @@ func Scan(
 		DontInterleaveIntents: cArgs.DontInterleaveIntents,
 		ReadCategory:          readCategory,
 	}
+	if args.Filter != nil {
+		filter, err := args.Filter.Compile()
+		if err != nil {
+			return result.Result{}, err
+		}
+		opts.Filter = filter.Matches
+	}
 
 	switch args.ScanFormat {
 	case kvpb.BATCH_RESPONSE:
@@ -245,3 +245,78 @@ func makeRowKey(t *testing.T, id int, columnFamily uint32) roachpb.Key {
 	require.NoError(t, err)
 	return keys.MakeFamilyKey(key, columnFamily)
 }
+
+func TestScanFilter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	ts := hlc.Timestamp{WallTime: 1}
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+
+	for k, v := range map[string]string{
+		"a1": "x-1", "a2": "y-2", "a3": "x-3", "a4": "x-4", "b1": "x-5", "b2": "y-6",
+	} {
+		_, err := storage.MVCCPut(
+			ctx, eng, roachpb.Key(k), ts, roachpb.MakeValueFromString(v), storage.MVCCWriteOptions{},
+		)
+		require.NoError(t, err)
+	}
+	_, err := storage.MVCCPut(
+		ctx, eng, roachpb.Key("a5"), ts, roachpb.MakeValueFromInt(1), storage.MVCCWriteOptions{},
+	)
+	require.NoError(t, err)
+
+	scan := func(filter *kvpb.ScanFilter, maxKeys int64) ([]string, *kvpb.ScanResponse, error) {
+		resp := &kvpb.ScanResponse{}
+		_, err := Scan(ctx, eng, CommandArgs{
+			Args: &kvpb.ScanRequest{
+				RequestHeader: kvpb.RequestHeader{Key: roachpb.Key("a"), EndKey: roachpb.Key("c")},
+				Filter:        filter,
+			},
+			Header: kvpb.Header{Timestamp: ts, MaxSpanRequestKeys: maxKeys},
+			EvalCtx: (&MockEvalCtx{
+				ClusterSettings: cluster.MakeTestingClusterSettings(),
+			}).EvalContext(),
+		}, resp)
+		var keys []string
+		for _, kv := range resp.Rows {
+			keys = append(keys, string(kv.Key))
+		}
+		return keys, resp, err
+	}
+
+	valueX := &kvpb.ScanValuePredicate{Op: kvpb.ScanValuePredicate_PREFIX, Operand: []byte("x")}
+	for i, tc := range []struct {
+		filter *kvpb.ScanFilter
+		exp    []string
+	}{
+		{filter: &kvpb.ScanFilter{KeyPrefix: roachpb.Key("a")}, exp: []string{"a1", "a2", "a3", "a4", "a5"}},
+		{filter: &kvpb.ScanFilter{KeyRegex: "^.[12]$"}, exp: []string{"a1", "a2", "b1", "b2"}},
+		{filter: &kvpb.ScanFilter{Value: valueX}, exp: []string{"a1", "a3", "a4", "b1"}},
+		{filter: &kvpb.ScanFilter{KeyPrefix: roachpb.Key("a"), Value: valueX}, exp: []string{"a1", "a3", "a4"}},
+		{filter: &kvpb.ScanFilter{Value: &kvpb.ScanValuePredicate{
+			Op: kvpb.ScanValuePredicate_EQUALS, Operand: []byte("y-6"),
+		}}, exp: []string{"b2"}},
+		{filter: &kvpb.ScanFilter{Value: &kvpb.ScanValuePredicate{
+			Op: kvpb.ScanValuePredicate_CONTAINS, Operand: []byte("-2"),
+		}}, exp: []string{"a2"}},
+	} {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			keys, _, err := scan(tc.filter, 0)
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, keys)
+		})
+	}
+
+	// The filtered out keys don't count against the limits.
+	keys, resp, err := scan(&kvpb.ScanFilter{Value: valueX}, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a1", "a3"}, keys)
+	require.EqualValues(t, 2, resp.NumKeys)
+	require.Equal(t, roachpb.Key("a4"), resp.ResumeSpan.Key)
+
+	_, _, err = scan(&kvpb.ScanFilter{KeyRegex: "("}, 0)
+	require.ErrorContains(t, err, "invalid key regex")
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package batcheval
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/batcheval/result"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/lockspanset"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/spanset"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/errors"
+)
+
+func init() {
+	RegisterReadWriteCommand(kvpb.WriteFence, declareKeysWriteFence, WriteFence)
+}
+
+func declareKeysWriteFence(
+	rs ImmutableRangeState,
+	header *kvpb.Header,
+	req kvpb.Request,
+	latchSpans *spanset.SpanSet,
+	lockSpans *lockspanset.LockSpanSet,
+	maxOffset time.Duration,
+) error {
+	// Write latches over the request span serialize the request with all
+	// in-flight writes to the span. Once a fence is installed, writes to the
+	// span are checked against it before evaluation.
+	err := DefaultDeclareIsolatedKeys(rs, header, req, latchSpans, lockSpans, maxOffset)
+	if err != nil {
+		return err
+	}
+	latchSpans.AddNonMVCC(spanset.SpanReadWrite, roachpb.Span{
+		Key: keys.RangeWriteFencesKey(rs.GetRangeID()),
+	})
+	return nil
+}
+
+// WriteFence installs, removes or lists the write fences on the request span.
+// See the comment on WriteFenceRequest for details.
+func WriteFence(
+	ctx context.Context, readWriter storage.ReadWriter, cArgs CommandArgs, resp kvpb.Response,
+) (result.Result, error) {
+	args := cArgs.Args.(*kvpb.WriteFenceRequest)
+	reply := resp.(*kvpb.WriteFenceResponse)
+	span := args.Span()
+	bounds := cArgs.EvalCtx.Desc().RSpan().AsRawSpanWithNoLocals()
+
+	sl := MakeStateLoader(cArgs.EvalCtx)
+	fences, err := sl.LoadWriteFences(ctx, readWriter)
+	if err != nil {
+		return result.Result{}, err
+	}
+
+	var updated bool
+	switch args.Action {
+	case kvpb.WriteFenceRequest_LIST:
+	case kvpb.WriteFenceRequest_INSTALL:
+		if !cArgs.EvalCtx.ClusterSettings().Version.IsActive(ctx, clusterversion.V24_1_WriteFences) {
+			return result.Result{}, errors.Newf(
+				"write fences require cluster version %s", clusterversion.V24_1_WriteFences)
+		}
+		if args.FenceTimestamp.IsEmpty() {
+			return result.Result{}, errors.AssertionFailedf("WriteFence INSTALL requires a fence timestamp")
+		}
+		// Check for any locks, and return them for the caller to resolve. An
+		// intent below the fence timestamp could otherwise still be committed
+		// after the fence has been installed.
+		maxLockConflicts := storage.MaxConflictsPerLockConflictError.Get(&cArgs.EvalCtx.ClusterSettings().SV)
+		locks, err := storage.ScanLocks(ctx, readWriter, span.Key, span.EndKey, maxLockConflicts, 0,
+			storage.BatchEvalReadCategory)
+		if err != nil {
+			return result.Result{}, err
+		} else if len(locks) > 0 {
+			return result.Result{}, &kvpb.LockConflictError{Locks: locks}
+		}
+		updated = fences.Install(span, args.FenceTimestamp)
+	case kvpb.WriteFenceRequest_REMOVE:
+		updated = fences.Remove(span, bounds)
+	default:
+		return result.Result{}, errors.AssertionFailedf("unknown WriteFence action %s", args.Action)
+	}
+	reply.Fences = fences.Clip(span)
+
+	if !updated {
+		return result.Result{}, nil
+	}
+	if err := sl.SetWriteFences(ctx, readWriter, cArgs.Stats, fences); err != nil {
+		return result.Result{}, err
+	}
+	var pd result.Result
+	pd.Replicated.State = &kvserverpb.ReplicaState{
+		WriteFences: fences,
+	}
+	return pd, nil
+}
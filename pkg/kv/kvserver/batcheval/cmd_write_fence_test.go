@@ -0,0 +1,111 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package batcheval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/isolation"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWriteFence tests that WriteFence requests install, list and remove
+// fences, persist them in the range's replicated state, and refuse to install
+// a fence over unresolved locks.
+func TestWriteFence(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	engine := storage.NewDefaultInMemForTesting()
+	defer engine.Close()
+
+	desc := roachpb.RangeDescriptor{
+		RangeID:  1,
+		StartKey: roachpb.RKey("a"),
+		EndKey:   roachpb.RKey("z"),
+	}
+	evalCtx := (&MockEvalCtx{
+		ClusterSettings: cluster.MakeTestingClusterSettings(),
+		Desc:            &desc,
+	}).EvalContext()
+	fenceTS := hlc.Timestamp{WallTime: 10}
+
+	send := func(
+		action kvpb.WriteFenceRequest_Action, start, end string,
+	) (*kvpb.WriteFenceResponse, error) {
+		var resp kvpb.WriteFenceResponse
+		res, err := WriteFence(ctx, engine, CommandArgs{
+			EvalCtx: evalCtx,
+			Header:  kvpb.Header{Timestamp: hlc.Timestamp{WallTime: 20}},
+			Args: &kvpb.WriteFenceRequest{
+				RequestHeader:  kvpb.RequestHeader{Key: roachpb.Key(start), EndKey: roachpb.Key(end)},
+				Action:         action,
+				FenceTimestamp: fenceTS,
+			},
+			Stats: &enginepb.MVCCStats{},
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		// The in-memory state update must match the persisted state.
+		persisted, err := MakeStateLoader(evalCtx).LoadWriteFences(ctx, engine)
+		require.NoError(t, err)
+		if res.Replicated.State != nil {
+			require.Equal(t, persisted.Fences, res.Replicated.State.WriteFences.Fences)
+		}
+		return &resp, nil
+	}
+
+	// Installing a fence over an intent fails with a LockConflictError.
+	txn := roachpb.MakeTransaction("test", nil /* baseKey */, isolation.Serializable, roachpb.NormalUserPriority, hlc.Timestamp{WallTime: 5}, 0, 0, 0, false /* omitInRangefeeds */)
+	_, err := storage.MVCCPut(ctx, engine, roachpb.Key("c"), txn.WriteTimestamp, roachpb.MakeValueFromString("c"), storage.MVCCWriteOptions{Txn: &txn})
+	require.NoError(t, err)
+	_, err = send(kvpb.WriteFenceRequest_INSTALL, "b", "d")
+	require.True(t, errors.HasType(err, (*kvpb.LockConflictError)(nil)), "%v", err)
+
+	// Installing a fence elsewhere succeeds.
+	resp, err := send(kvpb.WriteFenceRequest_INSTALL, "e", "g")
+	require.NoError(t, err)
+	require.Equal(t, []roachpb.WriteFence{
+		{Span: roachpb.Span{Key: roachpb.Key("e"), EndKey: roachpb.Key("g")}, Timestamp: fenceTS},
+	}, resp.Fences)
+
+	// Listing returns the fences restricted to the request span.
+	resp, err = send(kvpb.WriteFenceRequest_LIST, "f", "z")
+	require.NoError(t, err)
+	require.Equal(t, []roachpb.WriteFence{
+		{Span: roachpb.Span{Key: roachpb.Key("f"), EndKey: roachpb.Key("g")}, Timestamp: fenceTS},
+	}, resp.Fences)
+
+	// Removing requires the request span to contain the fence.
+	resp, err = send(kvpb.WriteFenceRequest_REMOVE, "f", "z")
+	require.NoError(t, err)
+	require.Len(t, resp.Fences, 1)
+	resp, err = send(kvpb.WriteFenceRequest_REMOVE, "a", "z")
+	require.NoError(t, err)
+	require.Empty(t, resp.Fences)
+
+	// Once all fences are removed, the state key is cleared.
+	fences, err := MakeStateLoader(evalCtx).LoadWriteFences(ctx, engine)
+	require.NoError(t, err)
+	require.True(t, fences.IsEmpty())
+}
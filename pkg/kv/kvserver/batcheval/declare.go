@@ -212,7 +212,10 @@ type CommandArgs struct {
 	Stats *enginepb.MVCCStats
 	// ScanStats should be mutated to reflect Get, Scan, ReverseScan,
 	// ExportRequest reads made by the command.
-	ScanStats             *kvpb.ScanStats
+	ScanStats *kvpb.ScanStats
+	// *CommitLatency, if not nil, should be mutated to reflect the time spent
+	// by a committing EndTxn resolving the intents of its transaction.
+	CommitLatency         *kvpb.CommitLatencyBreakdown
 	Concurrency           *concurrency.Guard
 	Uncertainty           uncertainty.Interval
 	DontInterleaveIntents bool
@@ -140,6 +140,11 @@ type EvalContext interface {
 	// in the specified key range.
 	GetApproximateDiskBytes(from, to roachpb.Key) (uint64, error)
 
+	// HasIngestedSSTable returns whether the range recently ingested an
+	// sstable with the given content hash into the given span at the given
+	// timestamp, and hasn't seen an MVCC history mutation of the span since.
+	HasIngestedSSTable(span roachpb.Span, ts hlc.Timestamp, contentHash []byte) bool
+
 	// GetCurrentClosedTimestamp returns the current closed timestamp on the
 	// range. It is expected that a caller will have performed some action (either
 	// calling RevokeLease or WatchForMerge) to freeze further progression of the
@@ -177,6 +182,7 @@ type MockEvalCtx struct {
 	GCThreshold          hlc.Timestamp
 	Term                 kvpb.RaftTerm
 	FirstIndex           kvpb.RaftIndex
+	LeaseAppliedIndex    kvpb.LeaseAppliedIndex
 	CanCreateTxnRecordFn func() (bool, kvpb.TransactionAbortedReason)
 	MinTxnCommitTSFn     func() hlc.Timestamp
 	Lease                roachpb.Lease
@@ -240,7 +246,7 @@ func (m *mockEvalCtxImpl) GetTerm(kvpb.RaftIndex) (kvpb.RaftTerm, error) {
 	return m.Term, nil
 }
 func (m *mockEvalCtxImpl) GetLeaseAppliedIndex() kvpb.LeaseAppliedIndex {
-	panic("unimplemented")
+	return m.LeaseAppliedIndex
 }
 func (m *mockEvalCtxImpl) Desc() *roachpb.RangeDescriptor {
 	return m.MockEvalCtx.Desc
@@ -320,6 +326,9 @@ func (m *mockEvalCtxImpl) GetEngineCapacity() (roachpb.StoreCapacity, error) {
 func (m *mockEvalCtxImpl) GetApproximateDiskBytes(from, to roachpb.Key) (uint64, error) {
 	return m.ApproxDiskBytes, nil
 }
+func (m *mockEvalCtxImpl) HasIngestedSSTable(roachpb.Span, hlc.Timestamp, []byte) bool {
+	return false
+}
 
 func (m *mockEvalCtxImpl) AdmissionHeader() kvpb.AdmissionHeader {
 	return kvpb.AdmissionHeader{}
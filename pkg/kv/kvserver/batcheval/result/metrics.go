@@ -13,14 +13,15 @@ package result
 // Metrics tracks various counters related to command applications and
 // their outcomes.
 type Metrics struct {
-	LeaseRequestSuccess  int // lease request evaluated successfully
-	LeaseRequestError    int // lease request error at evaluation time
-	LeaseTransferSuccess int // lease transfer evaluated successfully
-	LeaseTransferError   int // lease transfer error at evaluation time
-	ResolveCommit        int // intent commit evaluated successfully
-	ResolveAbort         int // non-poisoning intent abort evaluated successfully
-	ResolvePoison        int // poisoning intent abort evaluated successfully
-	AddSSTableAsWrites   int // AddSSTable requests with IngestAsWrites set
+	LeaseRequestSuccess    int // lease request evaluated successfully
+	LeaseRequestError      int // lease request error at evaluation time
+	LeaseTransferSuccess   int // lease transfer evaluated successfully
+	LeaseTransferError     int // lease transfer error at evaluation time
+	ResolveCommit          int // intent commit evaluated successfully
+	ResolveAbort           int // non-poisoning intent abort evaluated successfully
+	ResolvePoison          int // poisoning intent abort evaluated successfully
+	AddSSTableAsWrites     int // AddSSTable requests with IngestAsWrites set
+	AddSSTableDeduplicated int // AddSSTable requests skipped as already ingested
 }
 
 // Add absorbs the supplied Metrics into the receiver.
@@ -33,4 +34,5 @@ func (mt *Metrics) Add(o Metrics) {
 	mt.ResolveAbort += o.ResolveAbort
 	mt.ResolvePoison += o.ResolvePoison
 	mt.AddSSTableAsWrites += o.AddSSTableAsWrites
+	mt.AddSSTableDeduplicated += o.AddSSTableDeduplicated
 }
@@ -11,6 +11,7 @@
 package result
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 
@@ -240,13 +241,42 @@ func (p *Result) MergeAndDestroy(q Result) error {
 			q.Replicated.State.GCThreshold = nil
 		}
 
-		if p.Replicated.State.GCHint == nil {
+		if q.Replicated.State.GCHint != nil {
+			// The requests of a batch which update the GC hint, e.g. Puts with an
+			// expiration, load the hint written by the earlier ones, so the last
+			// one accounts for all of them.
 			p.Replicated.State.GCHint = q.Replicated.State.GCHint
-		} else if q.Replicated.State.GCHint != nil {
-			return errors.AssertionFailedf("conflicting GC hint")
 		}
 		q.Replicated.State.GCHint = nil
 
+		if p.Replicated.State.WriteFences == nil {
+			p.Replicated.State.WriteFences = q.Replicated.State.WriteFences
+		} else if q.Replicated.State.WriteFences != nil {
+			return errors.AssertionFailedf("conflicting WriteFences")
+		}
+		q.Replicated.State.WriteFences = nil
+
+		if p.Replicated.State.ReplicaPin == nil {
+			p.Replicated.State.ReplicaPin = q.Replicated.State.ReplicaPin
+		} else if q.Replicated.State.ReplicaPin != nil {
+			return errors.AssertionFailedf("conflicting ReplicaPin")
+		}
+		q.Replicated.State.ReplicaPin = nil
+
+		if p.Replicated.State.ColdCompaction == nil {
+			p.Replicated.State.ColdCompaction = q.Replicated.State.ColdCompaction
+		} else if q.Replicated.State.ColdCompaction != nil {
+			return errors.AssertionFailedf("conflicting ColdCompaction")
+		}
+		q.Replicated.State.ColdCompaction = nil
+
+		if p.Replicated.State.HotStandby == nil {
+			p.Replicated.State.HotStandby = q.Replicated.State.HotStandby
+		} else if q.Replicated.State.HotStandby != nil {
+			return errors.AssertionFailedf("conflicting HotStandby")
+		}
+		q.Replicated.State.HotStandby = nil
+
 		if p.Replicated.State.Version == nil {
 			p.Replicated.State.Version = q.Replicated.State.Version
 		} else if q.Replicated.State.Version != nil {
@@ -311,6 +341,11 @@ func (p *Result) MergeAndDestroy(q Result) error {
 	} else if q.Replicated.MVCCHistoryMutation != nil {
 		p.Replicated.MVCCHistoryMutation.Spans = append(p.Replicated.MVCCHistoryMutation.Spans,
 			q.Replicated.MVCCHistoryMutation.Spans...)
+		// The token applies to all spans of the mutation, so if the mutations were
+		// coordinated differently, conservatively treat them as uncoordinated.
+		if !bytes.Equal(p.Replicated.MVCCHistoryMutation.Token, q.Replicated.MVCCHistoryMutation.Token) {
+			p.Replicated.MVCCHistoryMutation.Token = nil
+		}
 	}
 	q.Replicated.MVCCHistoryMutation = nil
 
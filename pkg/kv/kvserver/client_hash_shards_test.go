@@ -0,0 +1,104 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHashShardedSpanIntentResolution verifies that the intents of a
+// transaction writing into a hash-sharded table spanning several ranges are
+// resolved, i.e. that the intent resolver doesn't translate their physical keys
+// again.
+func TestHashShardedSpanIntentResolution(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, sqlDB, _ := serverutils.StartServer(t, base.TestServerArgs{
+		DefaultTestTenant: base.TestIsSpecificToStorageLayerAndNeedsASystemTenant,
+		Knobs: base.TestingKnobs{
+			Store: &kvserver.StoreTestingKnobs{DisableMergeQueue: true},
+		},
+	})
+	defer s.Stopper().Stop(ctx)
+	store, err := s.GetStores().(*kvserver.Stores).GetStore(s.GetFirstStoreID())
+	require.NoError(t, err)
+
+	const numShards = 4
+	tdb := sqlutils.MakeSQLRunner(sqlDB)
+	tdb.Exec(t, `CREATE TABLE t (k INT PRIMARY KEY, v INT)`)
+	tdb.Exec(t, `ALTER TABLE t CONFIGURE ZONE USING hash_shards = 4`)
+	tdb.ExpectErr(t, "hash_shards cannot be changed once set",
+		`ALTER TABLE t CONFIGURE ZONE USING hash_shards = 8`)
+	prefix := s.Codec().TablePrefix(sqlutils.QueryTableID(t, sqlDB, "defaultdb", "public", "t"))
+
+	// Wait for the table to be hash-sharded, and split at its shards.
+	testutils.SucceedsSoon(t, func() error {
+		sharded, ok := store.GetStoreConfig().HashShardedSpans.Get().Find(prefix)
+		if !ok {
+			return errors.New("table not hash-sharded yet")
+		}
+		require.Equal(t, numShards, sharded.NumShards)
+		if err := store.ForceSplitScanAndProcess(); err != nil {
+			return err
+		}
+		for shard := 0; shard < numShards; shard++ {
+			shardPrefix := sharded.ShardPrefix(shard)
+			if desc := store.LookupReplica(roachpb.RKey(shardPrefix)).Desc(); !desc.StartKey.Equal(shardPrefix) {
+				return errors.Newf("shard %d not split yet: %s", shard, desc)
+			}
+		}
+		return nil
+	})
+
+	// Write the rows in a transaction whose intents span all the shards, so
+	// that most of them are resolved asynchronously after the commit.
+	tdb.Exec(t, `BEGIN; INSERT INTO t SELECT i, i FROM generate_series(1, 100) AS g(i); COMMIT`)
+
+	span := roachpb.Span{Key: prefix, EndKey: prefix.PrefixEnd()}
+	testutils.SucceedsSoon(t, func() error {
+		res, err := storage.MVCCScan(ctx, store.TODOEngine(), span.Key, span.EndKey,
+			hlc.MaxTimestamp, storage.MVCCScanOptions{Inconsistent: true})
+		if err != nil {
+			return err
+		}
+		if len(res.Intents) > 0 {
+			return errors.Newf("%d intents left, e.g. %s", len(res.Intents), res.Intents[0].Key)
+		}
+		// The rows are stored in the shards.
+		shards := map[uint64]struct{}{}
+		for _, kv := range res.KVs {
+			_, shard, err := encoding.DecodeUvarintAscending(kv.Key[len(prefix):])
+			require.NoError(t, err)
+			shards[shard] = struct{}{}
+		}
+		require.Len(t, res.KVs, 100)
+		require.Len(t, shards, numShards)
+		return nil
+	})
+	tdb.CheckQueryResults(t, `SELECT count(*), sum(v) FROM t`, [][]string{{"100", "5050"}})
+}
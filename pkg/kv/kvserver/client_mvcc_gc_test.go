@@ -87,6 +87,66 @@ func TestMVCCGCCorrectStats(t *testing.T) {
 	require.Equal(t, oldValBytes, newStats.ValBytes)
 }
 
+// TestMVCCGCQueueProcessesExpiredData verifies that the MVCC GC queue processes
+// a range which only contains expired values, once the GC TTL has passed since
+// their expiration, even though they are accounted as live in its stats.
+func TestMVCCGCQueueProcessesExpiredData(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	var args base.TestServerArgs
+	args.Knobs.Store = &kvserver.StoreTestingKnobs{DisableGCQueue: true}
+	args.Knobs.SpanConfig = &spanconfig.TestingKnobs{
+		OverrideFallbackConf: func(config roachpb.SpanConfig) roachpb.SpanConfig {
+			config.GCPolicy.TTLSeconds = 1
+			return config
+		},
+	}
+	s := serverutils.StartServerOnly(t, args)
+	defer s.Stopper().Stop(ctx)
+
+	key, err := s.ScratchRange()
+	require.NoError(t, err)
+	store, err := s.GetStores().(*kvserver.Stores).GetStore(s.GetFirstStoreID())
+	require.NoError(t, err)
+	repl := store.LookupReplica(roachpb.RKey(key))
+
+	const numKeys = 10
+	expiration := s.Clock().Now().Add(time.Second.Nanoseconds(), 0)
+	b := &kv.Batch{}
+	for i := 0; i < numKeys; i++ {
+		b.PutWithExpiration(key, "foo", expiration)
+		key = key.Next()
+	}
+	require.NoError(t, store.DB().Run(ctx, b))
+	require.Equal(t, expiration, repl.GetGCHint().GCTimestamp)
+
+	runGCQueue := func() {
+		_, processErr, enqueueErr := store.Enqueue(
+			ctx, "mvccGC", repl, false /* skipShouldQueue */, false /* async */)
+		require.NoError(t, enqueueErr)
+		require.NoError(t, processErr)
+	}
+
+	// The values aren't garbage collected before they expire, and stay accounted
+	// as live.
+	runGCQueue()
+	require.Equal(t, int64(numKeys), repl.GetMVCCStats().LiveCount)
+
+	// Once the GC TTL has passed since their expiration, the range is processed
+	// and the values are removed.
+	testutils.SucceedsSoon(t, func() error {
+		runGCQueue()
+		if ms := repl.GetMVCCStats(); ms.KeyCount != 0 || ms.LiveCount != 0 {
+			return errors.Newf("expired values not garbage collected yet: %d keys, %d live",
+				ms.KeyCount, ms.LiveCount)
+		}
+		return nil
+	})
+	require.True(t, repl.GetGCHint().GCTimestamp.IsEmpty())
+}
+
 // TestSystemSpanConfigProtectionPoliciesApplyAfterGC is a regression test for
 // https://github.com/cockroachdb/cockroach/issues/113867. This test attempts
 // to recreate the following observed timeline:
@@ -64,6 +64,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing/tracingpb"
@@ -5721,6 +5722,40 @@ func TestElectionAfterRestart(t *testing.T) {
 	}
 }
 
+// TestRaftSnapshotNotifiesRangeState verifies that the range-state listeners of
+// a store are notified of the state installed by a snapshot.
+func TestRaftSnapshotNotifiesRangeState(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	tc := testcluster.StartTestCluster(t, 2, base.TestClusterArgs{
+		ReplicationMode: base.ReplicationManual,
+	})
+	defer tc.Stopper().Stop(ctx)
+
+	var mu syncutil.Mutex
+	leases := map[roachpb.RangeID][]protoutil.Message{}
+	store := tc.GetFirstStoreFromServer(t, 1)
+	defer store.RegisterRangeStateListener(func(_ context.Context, c kvserver.RangeStateChange) {
+		mu.Lock()
+		defer mu.Unlock()
+		leases[c.RangeID] = append(leases[c.RangeID], c.After)
+	}, kvserver.RangeStateLease)()
+
+	key := tc.ScratchRange(t)
+	desc := tc.AddVotersOrFatal(t, key, tc.Target(1))
+	lease, _, err := tc.FindRangeLease(desc, nil /* hint */)
+	require.NoError(t, err)
+
+	// The replica on the second store was created by a snapshot, which carried
+	// the lease of the range.
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, leases[desc.RangeID])
+	require.Equal(t, &lease, leases[desc.RangeID][len(leases[desc.RangeID])-1])
+}
+
 // TestRaftSnapshotsWithMVCCRangeKeys tests that snapshots carry MVCC range keys
 // (i.e. MVCC range tombstones).
 func TestRaftSnapshotsWithMVCCRangeKeys(t *testing.T) {
@@ -699,3 +699,54 @@ func TestAdminRelocateRangeLaterallyAmongStores(t *testing.T) {
 		}, "node 1 already has a replica", /* errRegExp */
 	)
 }
+
+// TestAdminRelocateRangeLaterallyUsesLocalSnapshots tests that relocating a
+// replica between the stores of a node sends the snapshot through the local
+// fast path, unless it is disabled.
+func TestAdminRelocateRangeLaterallyUsesLocalSnapshots(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	args := base.TestClusterArgs{
+		ServerArgs: base.TestServerArgs{
+			StoreSpecs: []base.StoreSpec{
+				{InMemory: true},
+				{InMemory: true},
+			},
+		},
+		ReplicationMode: base.ReplicationManual,
+	}
+	tc := testcluster.StartTestCluster(t, 1, args)
+	defer tc.Stopper().Stop(ctx)
+	tc.WaitForNStores(t, 2, tc.Server(0).GossipI().(*gossip.Gossip))
+
+	stores := tc.Server(0).GetStores().(*kvserver.Stores)
+	s1, err := stores.GetStore(1)
+	require.NoError(t, err)
+	s2, err := stores.GetStore(2)
+	require.NoError(t, err)
+
+	scratchKey := keys.MustAddr(tc.ScratchRange(t))
+	require.NoError(t, tc.Server(0).DB().Put(ctx, scratchKey.AsRawKey(), "foo"))
+
+	// Move the replica from s1 to s2, which receives its snapshot locally.
+	relocateAndCheck(t, tc, scratchKey, []roachpb.ReplicationTarget{
+		{NodeID: 1, StoreID: 2},
+	}, nil /* nonVoterTargets */)
+	require.NotZero(t, s2.Metrics().RangeSnapshotsLocalRcvd.Count())
+	v, err := tc.Server(0).DB().Get(ctx, scratchKey.AsRawKey())
+	require.NoError(t, err)
+	require.Equal(t, "foo", string(v.ValueBytes()))
+
+	// Move it back with the local fast path disabled: the snapshot is streamed.
+	_, err = tc.ServerConn(0).Exec(`SET CLUSTER SETTING kv.snapshot.local_fast_path.enabled = false`)
+	require.NoError(t, err)
+	relocateAndCheck(t, tc, scratchKey, []roachpb.ReplicationTarget{
+		{NodeID: 1, StoreID: 1},
+	}, nil /* nonVoterTargets */)
+	require.Zero(t, s1.Metrics().RangeSnapshotsLocalRcvd.Count())
+	v, err = tc.Server(0).DB().Get(ctx, scratchKey.AsRawKey())
+	require.NoError(t, err)
+	require.Equal(t, "foo", string(v.ValueBytes()))
+}
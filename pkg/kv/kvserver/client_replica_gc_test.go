@@ -205,3 +205,53 @@ func TestReplicaGCQueueDropReplicaGCOnScan(t *testing.T) {
 		return nil
 	})
 }
+
+// TestReplicaGCQueueDropReplicaOnNotification verifies that a removed replica
+// which does not learn about its removal is garbage collected promptly, once the
+// range's leader reports the removal to its store.
+func TestReplicaGCQueueDropReplicaOnNotification(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	tc := testcluster.StartTestCluster(t, 3,
+		base.TestClusterArgs{
+			ReplicationMode: base.ReplicationManual,
+			ServerArgs: base.TestServerArgs{
+				Knobs: base.TestingKnobs{
+					Store: &kvserver.StoreTestingKnobs{
+						// The removed replica must not remove itself upon applying its
+						// removal or receiving a ReplicaTooOldError.
+						DisableEagerReplicaRemoval: true,
+					},
+				},
+			},
+		},
+	)
+	defer tc.Stopper().Stop(context.Background())
+
+	ts := tc.Servers[1]
+	store, pErr := ts.GetStores().(*kvserver.Stores).GetStore(ts.GetFirstStoreID())
+	if pErr != nil {
+		t.Fatal(pErr)
+	}
+	// Disable the scanner so that the replica can only be enqueued into the
+	// replica GC queue by the notification of the leader.
+	store.SetReplicaScannerActive(false)
+
+	// Create our scratch range and up-replicate it.
+	k := tc.ScratchRange(t)
+	tc.AddVotersOrFatal(t, k, tc.Target(1), tc.Target(2))
+	require.NoError(t, tc.WaitForVoters(k, tc.Target(1), tc.Target(2)))
+
+	desc := tc.RemoveVotersOrFatal(t, k, tc.Target(1))
+
+	// Make sure the range is removed from the store.
+	testutils.SucceedsSoon(t, func() error {
+		if _, err := store.GetReplica(desc.RangeID); !testutils.IsError(err, "r[0-9]+ was not found") {
+			// nolint:errwrap
+			return errors.Errorf("expected range removal: %v", err)
+		}
+		return nil
+	})
+	require.NotZero(t, store.ReplicaGCQueueNotifiedCount())
+}
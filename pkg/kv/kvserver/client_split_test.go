@@ -115,6 +115,46 @@ func TestStoreRangeSplitAtIllegalKeys(t *testing.T) {
 	}
 }
 
+// TestStoreSplitNotifiesRangeState verifies that the range-state listeners of a
+// store are notified of the initial state of the right-hand side of a split.
+func TestStoreSplitNotifiesRangeState(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	tc := testcluster.StartTestCluster(t, 1, base.TestClusterArgs{
+		ReplicationMode: base.ReplicationManual,
+	})
+	defer tc.Stopper().Stop(ctx)
+	store := tc.GetFirstStoreFromServer(t, 0)
+
+	var mu syncutil.Mutex
+	var changes []kvserver.RangeStateChange
+	defer store.RegisterRangeStateListener(func(_ context.Context, c kvserver.RangeStateChange) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, c)
+	}, kvserver.RangeStateLease)()
+
+	key := tc.ScratchRange(t)
+	_, rightDesc := tc.SplitRangeOrFatal(t, key.Next())
+	rightRepl := store.LookupReplica(rightDesc.StartKey)
+	require.NotNil(t, rightRepl)
+	lease, _ := rightRepl.GetLease()
+
+	mu.Lock()
+	defer mu.Unlock()
+	var found bool
+	for _, c := range changes {
+		if c.RangeID == rightDesc.RangeID {
+			require.Nil(t, c.Before)
+			require.Equal(t, &lease, c.After)
+			found = true
+		}
+	}
+	require.True(t, found, "no lease change notified for the RHS r%d", rightDesc.RangeID)
+}
+
 // Verify that on a split, only the non-expired abort span records are copied
 // into the right hand side of the split.
 func TestStoreSplitAbortSpan(t *testing.T) {
@@ -3970,3 +4010,76 @@ func TestLBSplitUnsafeKeys(t *testing.T) {
 		})
 	}
 }
+
+// TestStoreSplitMergeNotifiesApplyTriggers verifies that the applied split and
+// merge triggers are reported to the apply trigger registry, and update the
+// range cache of the node.
+func TestStoreSplitMergeNotifiesApplyTriggers(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s := serverutils.StartServerOnly(t, base.TestServerArgs{
+		Knobs: base.TestingKnobs{
+			Store: &kvserver.StoreTestingKnobs{
+				DisableMergeQueue: true,
+				DisableSplitQueue: true,
+			},
+		},
+	})
+	defer s.Stopper().Stop(ctx)
+	store, err := s.GetStores().(*kvserver.Stores).GetStore(s.GetFirstStoreID())
+	require.NoError(t, err)
+	scratch, err := s.ScratchRange()
+	require.NoError(t, err)
+
+	var mu struct {
+		syncutil.Mutex
+		events []kvserverbase.SplitMergeEvent
+	}
+	unregister := store.GetStoreConfig().ApplyTriggers.RegisterSplitMergeObserver(
+		func(_ context.Context, ev kvserverbase.SplitMergeEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			mu.events = append(mu.events, ev)
+		})
+	defer unregister()
+	waitForEvent := func() kvserverbase.SplitMergeEvent {
+		var ev kvserverbase.SplitMergeEvent
+		testutils.SucceedsSoon(t, func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(mu.events) == 0 {
+				return errors.New("no split or merge event")
+			}
+			ev, mu.events = mu.events[0], mu.events[1:]
+			return nil
+		})
+		return ev
+	}
+	rangeCache := s.DistSenderI().(*kvcoord.DistSender).RangeDescriptorCache()
+	requireCached := func(desc roachpb.RangeDescriptor) {
+		entry := rangeCache.GetCached(ctx, desc.StartKey, false /* inverted */)
+		require.NotNil(t, entry)
+		require.Equal(t, desc, *entry.Desc())
+	}
+
+	lhs, rhs, err := s.SplitRange(scratch.Next())
+	require.NoError(t, err)
+	ev := waitForEvent()
+	require.False(t, ev.Merge)
+	require.Len(t, ev.Ranges, 2)
+	require.Equal(t, lhs, ev.Ranges[0].Desc)
+	require.Equal(t, rhs, ev.Ranges[1].Desc)
+	requireCached(lhs)
+	requireCached(rhs)
+
+	merged, err := s.MergeRanges(scratch)
+	require.NoError(t, err)
+	ev = waitForEvent()
+	require.True(t, ev.Merge)
+	require.Len(t, ev.Ranges, 1)
+	require.Equal(t, merged, ev.Ranges[0].Desc)
+	require.Equal(t, rhs.RangeID, ev.SubsumedRangeID)
+	requireCached(merged)
+}
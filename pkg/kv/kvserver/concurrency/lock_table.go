@@ -4621,7 +4621,13 @@ func (t *lockTableImpl) QueryLockTableState(
 
 	now := t.clock.PhysicalTime()
 
-	lockTableState := make([]roachpb.LockStateInfo, 0, snap.Len())
+	// Don't size the result for the whole lock table if it's paginated, as
+	// ranges with many locks are queried with bounded results.
+	capacity := int64(snap.Len())
+	if opts.MaxLocks > 0 && opts.MaxLocks < capacity {
+		capacity = opts.MaxLocks
+	}
+	lockTableState := make([]roachpb.LockStateInfo, 0, capacity)
 	resumeState := QueryLockTableResumeState{}
 	var numLocks int64
 	var numBytes int64
@@ -12,6 +12,7 @@ package kvserver
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
@@ -69,6 +70,9 @@ type consistencyQueue struct {
 	*baseQueue
 	interval       func() time.Duration
 	replicaCountFn func() int
+	// pauseWindows are the parsed server.consistency_check.pause_windows.
+	pauseWindows atomic.Pointer[[]consistencyCheckPauseWindow]
+	priorities   consistencyCheckPriorities
 }
 
 var _ queueImpl = &consistencyQueue{}
@@ -94,6 +98,8 @@ func newConsistencyQueue(store *Store) *consistencyQueue {
 		"consistencyChecker", q, store,
 		queueConfig{
 			maxSize:              defaultQueueMaxSize,
+			maxConcurrency:       consistencyCheckMaxStoreConcurrency,
+			concurrencySetting:   consistencyCheckStoreConcurrency,
 			needsLease:           true,
 			needsSpanConfigs:     false,
 			acceptsUnsplitRanges: true,
@@ -102,17 +108,28 @@ func newConsistencyQueue(store *Store) *consistencyQueue {
 			storeFailures:        store.metrics.StoreFailures,
 			pending:              store.metrics.ConsistencyQueuePending,
 			processingNanos:      store.metrics.ConsistencyQueueProcessingNanos,
-			processTimeoutFunc:   makeRateLimitedTimeoutFunc(consistencyCheckRate),
+			processTimeoutFunc:   consistencyCheckTimeout,
 			disabledConfig:       kvserverbase.ConsistencyQueueEnabled,
+			// The checksum computations pace their own scans (see
+			// consistencyCheckRate), so only the CPU budget is charged.
+			priorityClass: queuePriorityClassMaintenance,
 		},
 	)
+	sv := &store.ClusterSettings().SV
+	q.updatePauseWindows(store.AnnotateCtx(context.Background()), sv)
+	consistencyCheckPauseWindows.SetOnChange(sv, func(ctx context.Context) {
+		q.updatePauseWindows(ctx, sv)
+	})
 	return q
 }
 
 func (q *consistencyQueue) shouldQueue(
 	ctx context.Context, now hlc.ClockTimestamp, repl *Replica, _ spanconfig.StoreReader,
 ) (bool, float64) {
-	return consistencyQueueShouldQueueImpl(ctx, now,
+	if q.paused(now.ToTimestamp().GoTime()) {
+		return false, 0
+	}
+	shouldQ, priority := consistencyQueueShouldQueueImpl(ctx, now,
 		consistencyShouldQueueData{
 			desc: repl.Desc(),
 			getQueueLastProcessed: func(ctx context.Context) (hlc.Timestamp, error) {
@@ -128,6 +145,11 @@ func (q *consistencyQueue) shouldQueue(
 			disableLastProcessedCheck: repl.store.cfg.TestingKnobs.DisableLastProcessedCheck,
 			interval:                  q.interval(),
 		})
+	if shouldQ {
+		// Ranges prioritized by operators are checked first.
+		priority += q.priorities.forRange(repl.Desc())
+	}
+	return shouldQ, priority
 }
 
 // ConsistencyQueueShouldQueueImpl is exposed for testability without having
@@ -165,6 +187,11 @@ func (q *consistencyQueue) process(
 	if q.interval() <= 0 {
 		return false, nil
 	}
+	// The replica may have been queued before a pause window started.
+	if q.paused(repl.store.Clock().PhysicalTime()) {
+		log.VEventf(ctx, 2, "consistency checks are paused")
+		return false, nil
+	}
 
 	// Call setQueueLastProcessed because the consistency checker targets a much
 	// longer cycle time than other queues. That it ignores errors is likely a
@@ -177,7 +204,9 @@ func (q *consistencyQueue) process(
 		// Tell CheckConsistency that the caller is the queue. This triggers
 		// code to handle inconsistencies by recomputing with a diff and
 		// instructing the nodes in the minority to terminate with a fatal
-		// error. It also triggers a stats readjustment if there is no
+		// error, or rebuilding the minority if
+		// server.consistency_check.rebuild_divergent_replicas.enabled is set.
+		// It also triggers a stats readjustment if there is no
 		// inconsistency but the persisted stats are found to disagree with
 		// those reflected in the data. All of this really ought to be lifted
 		// into the queue in the future.
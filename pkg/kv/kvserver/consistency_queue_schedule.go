@@ -0,0 +1,230 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/redact"
+)
+
+var consistencyCheckPauseWindows = settings.RegisterStringSetting(
+	settings.SystemOnly,
+	"server.consistency_check.pause_windows",
+	"comma-separated list of daily time windows, in the form HH:MM-HH:MM in UTC, during "+
+		"which the consistency checker doesn't check any range; a window ending before "+
+		"its start spans midnight",
+	"",
+	settings.WithValidateString(func(_ *settings.Values, s string) error {
+		_, err := parseConsistencyCheckPauseWindows(s)
+		return err
+	}),
+)
+
+// consistencyCheckMaxStoreConcurrency is the maximum value of
+// server.consistency_check.store_concurrency.
+const consistencyCheckMaxStoreConcurrency = 8
+
+var consistencyCheckStoreConcurrency = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"server.consistency_check.store_concurrency",
+	"the maximum number of ranges the consistency checker of a store checks concurrently; "+
+		"the checks share the rate limit of server.consistency_check.max_rate",
+	1,
+	settings.IntInRange(1, consistencyCheckMaxStoreConcurrency),
+)
+
+// rateLimitedConsistencyCheckTimeout is the timeout of a consistency check
+// running alone on a store.
+var rateLimitedConsistencyCheckTimeout = makeRateLimitedTimeoutFunc(consistencyCheckRate)
+
+// consistencyCheckTimeout returns the timeout of a consistency check. Since
+// the concurrent checks of a store share its rate limit, the timeout is scaled
+// by the concurrency.
+func consistencyCheckTimeout(cs *cluster.Settings, r replicaInQueue) time.Duration {
+	return rateLimitedConsistencyCheckTimeout(cs, r) *
+		time.Duration(consistencyCheckStoreConcurrency.Get(&cs.SV))
+}
+
+// consistencyCheckPauseWindow is a daily time window during which consistency
+// checks are paused. The start and end are offsets from midnight UTC.
+type consistencyCheckPauseWindow struct {
+	start, end time.Duration
+}
+
+// contains returns whether the window contains the given time.
+func (w consistencyCheckPauseWindow) contains(t time.Time) bool {
+	t = t.UTC()
+	offset := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+	if w.start < w.end {
+		return w.start <= offset && offset < w.end
+	}
+	// The window spans midnight.
+	return w.start <= offset || offset < w.end
+}
+
+// parseConsistencyCheckPauseWindows parses the value of the
+// server.consistency_check.pause_windows setting.
+func parseConsistencyCheckPauseWindows(s string) ([]consistencyCheckPauseWindow, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	parseOffset := func(s string) (time.Duration, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(s))
+		if err != nil {
+			return 0, errors.Newf("invalid time %q, expected HH:MM", s)
+		}
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+	}
+	var windows []consistencyCheckPauseWindow
+	for _, w := range strings.Split(s, ",") {
+		start, end, ok := strings.Cut(w, "-")
+		if !ok {
+			return nil, errors.Newf("invalid pause window %q, expected HH:MM-HH:MM", w)
+		}
+		var window consistencyCheckPauseWindow
+		var err error
+		if window.start, err = parseOffset(start); err != nil {
+			return nil, errors.Wrapf(err, "pause window %q", w)
+		}
+		if window.end, err = parseOffset(end); err != nil {
+			return nil, errors.Wrapf(err, "pause window %q", w)
+		}
+		if window.start == window.end {
+			return nil, errors.Newf("pause window %q is empty", w)
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// updatePauseWindows parses the pause windows from the cluster setting. The
+// setting is validated, so parsing only fails if the validation changed.
+func (q *consistencyQueue) updatePauseWindows(ctx context.Context, sv *settings.Values) {
+	windows, err := parseConsistencyCheckPauseWindows(consistencyCheckPauseWindows.Get(sv))
+	if err != nil {
+		log.Warningf(ctx, "ignoring consistency check pause windows: %v", err)
+		windows = nil
+	}
+	q.pauseWindows.Store(&windows)
+}
+
+// paused returns whether consistency checks are paused at the given time.
+func (q *consistencyQueue) paused(now time.Time) bool {
+	windows := q.pauseWindows.Load()
+	if windows == nil {
+		return false
+	}
+	for _, w := range *windows {
+		if w.contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConsistencyCheckPriority raises the priority with which the consistency
+// checker of a store checks the ranges overlapping some spans, typically those
+// of the tables of a database.
+type ConsistencyCheckPriority struct {
+	// Name identifies the priority, e.g. the name of a database.
+	Name string
+	// Spans are the spans of the prioritized ranges.
+	Spans roachpb.Spans
+	// Priority is added to the priority of the ranges when they're queued.
+	Priority float64
+}
+
+// consistencyCheckPriorities holds the priorities set by operators, keyed by
+// name. They aren't persisted, so they're lost when the node restarts.
+type consistencyCheckPriorities struct {
+	syncutil.RWMutex
+	m map[string]ConsistencyCheckPriority
+}
+
+// set sets the priority, or removes it if it's zero.
+func (p *consistencyCheckPriorities) set(priority ConsistencyCheckPriority) {
+	p.Lock()
+	defer p.Unlock()
+	if priority.Priority == 0 {
+		delete(p.m, priority.Name)
+		return
+	}
+	if p.m == nil {
+		p.m = map[string]ConsistencyCheckPriority{}
+	}
+	p.m[priority.Name] = priority
+}
+
+// list returns the priorities, sorted by name.
+func (p *consistencyCheckPriorities) list() []ConsistencyCheckPriority {
+	p.RLock()
+	defer p.RUnlock()
+	priorities := make([]ConsistencyCheckPriority, 0, len(p.m))
+	for _, priority := range p.m {
+		priorities = append(priorities, priority)
+	}
+	sort.Slice(priorities, func(i, j int) bool {
+		return priorities[i].Name < priorities[j].Name
+	})
+	return priorities
+}
+
+// forRange returns the highest priority whose spans overlap the range, or zero
+// if there is none.
+func (p *consistencyCheckPriorities) forRange(desc *roachpb.RangeDescriptor) float64 {
+	span := desc.KeySpan().AsRawSpanWithNoLocals()
+	p.RLock()
+	defer p.RUnlock()
+	var highest float64
+	for _, priority := range p.m {
+		if priority.Priority <= highest {
+			continue
+		}
+		for _, sp := range priority.Spans {
+			if sp.Overlaps(span) {
+				highest = priority.Priority
+				break
+			}
+		}
+	}
+	return highest
+}
+
+// SetConsistencyCheckPriority sets the priority with which the consistency
+// checker of the store checks the ranges overlapping the given spans, replacing
+// any priority with the same name. A zero priority removes it.
+func (s *Store) SetConsistencyCheckPriority(ctx context.Context, priority ConsistencyCheckPriority) {
+	if s.consistencyQueue == nil {
+		return
+	}
+	s.consistencyQueue.priorities.set(priority)
+	log.Infof(ctx, "set consistency check priority %q to %.2f",
+		priority.Name, redact.Safe(priority.Priority))
+}
+
+// ConsistencyCheckPriorities returns the priorities of the consistency checker
+// of the store, sorted by name.
+func (s *Store) ConsistencyCheckPriorities() []ConsistencyCheckPriority {
+	if s.consistencyQueue == nil {
+		return nil
+	}
+	return s.consistencyQueue.priorities.list()
+}
@@ -0,0 +1,170 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsistencyCheckPauseWindows(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	windows, err := parseConsistencyCheckPauseWindows(" 09:00-17:30, 22:00-02:00")
+	require.NoError(t, err)
+	require.Equal(t, []consistencyCheckPauseWindow{
+		{start: 9 * time.Hour, end: 17*time.Hour + 30*time.Minute},
+		{start: 22 * time.Hour, end: 2 * time.Hour},
+	}, windows)
+
+	paused := func(hour, minute int) bool {
+		now := time.Date(2023, 10, 1, hour, minute, 0, 0, time.UTC)
+		for _, w := range windows {
+			if w.contains(now) {
+				return true
+			}
+		}
+		return false
+	}
+	require.False(t, paused(8, 59))
+	require.True(t, paused(9, 0))
+	require.True(t, paused(17, 29))
+	require.False(t, paused(17, 30))
+	require.True(t, paused(23, 0))
+	require.True(t, paused(0, 0))
+	require.True(t, paused(1, 59))
+	require.False(t, paused(2, 0))
+
+	// Times in other time zones are converted to UTC.
+	require.True(t, windows[0].contains(
+		time.Date(2023, 10, 1, 11, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))))
+
+	windows, err = parseConsistencyCheckPauseWindows("")
+	require.NoError(t, err)
+	require.Empty(t, windows)
+	for _, s := range []string{"09:00", "9-17", "09:00-24:00", "10:00-10:00", "09:00-10:00,"} {
+		_, err := parseConsistencyCheckPauseWindows(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestConsistencyCheckPriorities(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	span := func(start, end string) roachpb.Span {
+		return roachpb.Span{Key: roachpb.Key(start), EndKey: roachpb.Key(end)}
+	}
+	desc := func(start, end string) *roachpb.RangeDescriptor {
+		return &roachpb.RangeDescriptor{StartKey: roachpb.RKey(start), EndKey: roachpb.RKey(end)}
+	}
+
+	var p consistencyCheckPriorities
+	p.set(ConsistencyCheckPriority{Name: "b", Spans: roachpb.Spans{span("a", "c"), span("x", "z")}, Priority: 5})
+	p.set(ConsistencyCheckPriority{Name: "a", Spans: roachpb.Spans{span("b", "d")}, Priority: 10})
+	require.Equal(t, 10.0, p.forRange(desc("c", "e")))
+	require.Equal(t, 10.0, p.forRange(desc("a", "z")))
+	require.Equal(t, 5.0, p.forRange(desc("y", "z")))
+	require.Equal(t, 0.0, p.forRange(desc("d", "x")))
+
+	priorities := p.list()
+	require.Len(t, priorities, 2)
+	require.Equal(t, "a", priorities[0].Name)
+
+	// A zero priority removes it.
+	p.set(ConsistencyCheckPriority{Name: "a"})
+	require.Equal(t, 5.0, p.forRange(desc("a", "z")))
+	require.Len(t, p.list(), 1)
+}
+
+func TestMakeConsistencyReport(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	result := func(replicaID roachpb.ReplicaID, checksum string, liveBytes int64) ConsistencyCheckResult {
+		return ConsistencyCheckResult{
+			Replica: roachpb.ReplicaDescriptor{ReplicaID: replicaID},
+			Response: CollectChecksumResponse{
+				Checksum:  []byte(checksum),
+				Persisted: enginepb.MVCCStats{LiveBytes: liveBytes},
+			},
+		}
+	}
+
+	// One replica diverged from the majority, and another one didn't respond.
+	failed := ConsistencyCheckResult{
+		Replica: roachpb.ReplicaDescriptor{ReplicaID: 4},
+		Err:     errors.New("boom"),
+	}
+	report := makeConsistencyReport(1, []ConsistencyCheckResult{
+		result(1, "good", 100), result(2, "bad", 130), result(3, "good", 100), failed,
+	})
+	require.Equal(t, roachpb.RangeID(1), report.RangeID)
+	require.Equal(t, kvpb.CheckConsistencyResponse_RANGE_INCONSISTENT, report.Status)
+	require.Len(t, report.Replicas, 4)
+	require.False(t, report.Replicas[0].Minority)
+	require.True(t, report.Replicas[1].Minority)
+	require.Equal(t, int64(30), report.Replicas[1].Diff.LiveBytes)
+	require.Equal(t, enginepb.MVCCStats{}, report.Replicas[0].Diff)
+	require.Equal(t, failed.Err, report.Replicas[3].Err)
+	require.Empty(t, report.Replicas[3].Checksum)
+
+	// With the KVs of the replicas, the report holds the KVs on which the
+	// minority differs from the majority.
+	kv := func(key string, wallTime int64, value string) ReplicaSnapshotData_KeyValue {
+		return ReplicaSnapshotData_KeyValue{
+			Key: roachpb.Key(key), Timestamp: hlc.Timestamp{WallTime: wallTime}, Value: []byte(value),
+		}
+	}
+	good := &ReplicaSnapshotData{KVs: []ReplicaSnapshotData_KeyValue{
+		kv("a", 2, "a2"), kv("a", 1, "a1"), kv("b", 1, "b1"),
+	}}
+	bad := &ReplicaSnapshotData{
+		KVs: []ReplicaSnapshotData_KeyValue{kv("a", 2, "a2"), kv("b", 1, "x"), kv("c", 1, "c1")},
+		RangeKVs: []ReplicaSnapshotData_RangeKeyValue{{
+			StartKey: roachpb.Key("d"), EndKey: roachpb.Key("e"), Timestamp: hlc.Timestamp{WallTime: 3},
+		}},
+	}
+	withSnapshot := func(r ConsistencyCheckResult, snap *ReplicaSnapshotData) ConsistencyCheckResult {
+		r.Response.Snapshot = snap
+		return r
+	}
+	report = makeConsistencyReport(1, []ConsistencyCheckResult{
+		withSnapshot(result(1, "good", 100), good), withSnapshot(result(2, "bad", 130), bad),
+		withSnapshot(result(3, "good", 100), good),
+	})
+	require.Empty(t, report.Replicas[0].KeyDiff)
+	require.Equal(t, []ReplicaSnapshotDiff{
+		{Majority: true, Key: roachpb.Key("a"), Timestamp: hlc.Timestamp{WallTime: 1}, Value: []byte("a1")},
+		{Majority: true, Key: roachpb.Key("b"), Timestamp: hlc.Timestamp{WallTime: 1}, Value: []byte("b1")},
+		{Key: roachpb.Key("b"), Timestamp: hlc.Timestamp{WallTime: 1}, Value: []byte("x")},
+		{Key: roachpb.Key("c"), Timestamp: hlc.Timestamp{WallTime: 1}, Value: []byte("c1")},
+		{Key: roachpb.Key("d"), EndKey: roachpb.Key("e"), Timestamp: hlc.Timestamp{WallTime: 3}},
+	}, report.Replicas[1].KeyDiff)
+
+	// Without the inconsistency, the failed replica makes the check
+	// indeterminate.
+	report = makeConsistencyReport(1, []ConsistencyCheckResult{
+		result(1, "good", 100), result(3, "good", 100), failed,
+	})
+	require.Equal(t, kvpb.CheckConsistencyResponse_RANGE_INDETERMINATE, report.Status)
+	require.False(t, report.Replicas[0].Minority)
+}
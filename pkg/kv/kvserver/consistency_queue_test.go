@@ -424,6 +424,220 @@ func TestCheckConsistencyInconsistent(t *testing.T) {
 	require.NotEmpty(t, b)
 }
 
+// TestCheckConsistencyRebuildDivergentReplica verifies that, if enabled, the
+// consistency checker rebuilds a divergent replica from a snapshot of the
+// majority, and falls back to terminating its node if the rebuild fails.
+func TestCheckConsistencyRebuildDivergentReplica(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testutils.RunTrueAndFalse(t, "rebuild-fails", func(t *testing.T, rebuildFails bool) {
+		ctx := context.Background()
+		rebuilt := make(chan error, 1)
+		notifyFatal := make(chan roachpb.StoreIdent, 1)
+		testKnobs := kvserver.StoreTestingKnobs{DisableConsistencyQueue: true}
+		testKnobs.ConsistencyTestingKnobs.OnBadChecksumFatal = func(s roachpb.StoreIdent) {
+			notifyFatal <- s
+		}
+		testKnobs.ConsistencyTestingKnobs.BeforeRebuildDivergentReplicas = func(
+			[]roachpb.ReplicaDescriptor,
+		) error {
+			if rebuildFails {
+				return errors.New("injected rebuild failure")
+			}
+			return nil
+		}
+		testKnobs.ConsistencyTestingKnobs.AfterRebuildDivergentReplicas = func(err error) {
+			rebuilt <- err
+		}
+
+		tc := testcluster.StartTestCluster(t, 3, base.TestClusterArgs{
+			ReplicationMode: base.ReplicationManual,
+			ServerArgs: base.TestServerArgs{
+				Knobs: base.TestingKnobs{Store: &testKnobs},
+			},
+		})
+		defer tc.Stopper().Stop(ctx)
+		_, err := tc.ServerConn(0).Exec(
+			`SET CLUSTER SETTING server.consistency_check.rebuild_divergent_replicas.enabled = true`)
+		require.NoError(t, err)
+
+		store := tc.GetFirstStoreFromServer(t, 0)
+		key := tc.ScratchRange(t)
+		_, pErr := kv.SendWrapped(ctx, store.DB().NonTransactionalSender(), putArgs(key, []byte("b")))
+		require.NoError(t, pErr.GoError())
+		desc := tc.AddVotersOrFatal(t, key, tc.Targets(1, 2)...)
+		s2 := tc.GetFirstStoreFromServer(t, 1)
+		divergent, ok := desc.GetReplicaDescriptor(s2.StoreID())
+		require.True(t, ok)
+
+		runConsistencyCheck := func(mode kvpb.ChecksumMode) kvpb.CheckConsistencyResponse_Status {
+			req := kvpb.CheckConsistencyRequest{
+				RequestHeader: kvpb.RequestHeader{Key: key, EndKey: key.Next()},
+				Mode:          mode,
+			}
+			resp, pErr := kv.SendWrapped(ctx, store.DB().NonTransactionalSender(), &req)
+			require.NoError(t, pErr.GoError())
+			return resp.(*kvpb.CheckConsistencyResponse).Result[0].Status
+		}
+
+		// Write a key only to the replica on s2.
+		var val roachpb.Value
+		val.SetInt(42)
+		_, err = storage.MVCCPut(ctx, s2.TODOEngine(), key.Next(), tc.Server(0).Clock().Now(), val,
+			storage.MVCCWriteOptions{})
+		require.NoError(t, err)
+
+		require.Equal(t, kvpb.CheckConsistencyResponse_RANGE_INCONSISTENT,
+			runConsistencyCheck(kvpb.ChecksumMode_CHECK_VIA_QUEUE))
+		select {
+		case err := <-rebuilt:
+			if rebuildFails {
+				require.ErrorContains(t, err, "injected rebuild failure")
+			} else {
+				require.NoError(t, err)
+			}
+		case <-time.After(30 * time.Second):
+			t.Fatal("divergent replica not rebuilt")
+		}
+
+		if rebuildFails {
+			// The node of the divergent replica is terminated instead.
+			select {
+			case s := <-notifyFatal:
+				require.Equal(t, *s2.Ident, s)
+			case <-time.After(30 * time.Second):
+				t.Fatal("node of the divergent replica not terminated")
+			}
+			return
+		}
+
+		// The replica on s2 was replaced by a new one, consistent with the others,
+		// and no node was terminated.
+		desc = tc.LookupRangeOrFatal(t, key)
+		rebuiltReplica, ok := desc.GetReplicaDescriptor(s2.StoreID())
+		require.True(t, ok)
+		require.NotEqual(t, divergent.ReplicaID, rebuiltReplica.ReplicaID)
+		require.Equal(t, kvpb.CheckConsistencyResponse_RANGE_CONSISTENT,
+			runConsistencyCheck(kvpb.ChecksumMode_CHECK_FULL))
+		select {
+		case s := <-notifyFatal:
+			t.Fatalf("unexpected termination of %s", s)
+		default:
+		}
+	})
+}
+
+// TestCheckConsistencyRebuildMultipleDivergentReplicas verifies that several
+// divergent replicas are all removed before any of them is re-added, so that
+// none of them can send the snapshot of a re-added replica as a delegate.
+func TestCheckConsistencyRebuildMultipleDivergentReplicas(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	rebuilt := make(chan error, 1)
+	// The scratch range, and the stores of its divergent replicas, which are
+	// preferred as snapshot delegates whenever they are part of the range.
+	var mu struct {
+		syncutil.Mutex
+		rangeID         roachpb.RangeID
+		divergentStores []roachpb.StoreID
+	}
+	testKnobs := kvserver.StoreTestingKnobs{DisableConsistencyQueue: true}
+	testKnobs.ConsistencyTestingKnobs.OnBadChecksumFatal = func(s roachpb.StoreIdent) {
+		t.Errorf("unexpected termination of %s", s)
+	}
+	testKnobs.ConsistencyTestingKnobs.AfterRebuildDivergentReplicas = func(err error) {
+		rebuilt <- err
+	}
+	testKnobs.SelectDelegateSnapshotSender = func(
+		desc *roachpb.RangeDescriptor,
+	) []roachpb.ReplicaDescriptor {
+		mu.Lock()
+		defer mu.Unlock()
+		if desc.RangeID != mu.rangeID {
+			return nil
+		}
+		var senders []roachpb.ReplicaDescriptor
+		for _, storeID := range mu.divergentStores {
+			if rd, ok := desc.GetReplicaDescriptor(storeID); ok && (rd.IsAnyVoter() || rd.IsNonVoter()) {
+				senders = append(senders, rd)
+			}
+		}
+		return senders
+	}
+
+	tc := testcluster.StartTestCluster(t, 5, base.TestClusterArgs{
+		ReplicationMode: base.ReplicationManual,
+		ServerArgs: base.TestServerArgs{
+			Knobs: base.TestingKnobs{Store: &testKnobs},
+		},
+	})
+	defer tc.Stopper().Stop(ctx)
+	_, err := tc.ServerConn(0).Exec(
+		`SET CLUSTER SETTING server.consistency_check.rebuild_divergent_replicas.enabled = true`)
+	require.NoError(t, err)
+
+	store := tc.GetFirstStoreFromServer(t, 0)
+	key := tc.ScratchRange(t)
+	_, pErr := kv.SendWrapped(ctx, store.DB().NonTransactionalSender(), putArgs(key, []byte("b")))
+	require.NoError(t, pErr.GoError())
+	desc := tc.AddVotersOrFatal(t, key, tc.Targets(1, 2, 3, 4)...)
+
+	// Write the same key only to the replicas on s2 and s3, which agree with
+	// each other but diverge from the majority.
+	divergentStores := []*kvserver.Store{
+		tc.GetFirstStoreFromServer(t, 1), tc.GetFirstStoreFromServer(t, 2),
+	}
+	var val roachpb.Value
+	val.SetInt(42)
+	ts := tc.Server(0).Clock().Now()
+	for _, s := range divergentStores {
+		_, err := storage.MVCCPut(ctx, s.TODOEngine(), key.Next(), ts, val, storage.MVCCWriteOptions{})
+		require.NoError(t, err)
+	}
+	func() {
+		mu.Lock()
+		defer mu.Unlock()
+		mu.rangeID = desc.RangeID
+		for _, s := range divergentStores {
+			mu.divergentStores = append(mu.divergentStores, s.StoreID())
+		}
+	}()
+
+	runConsistencyCheck := func(mode kvpb.ChecksumMode) kvpb.CheckConsistencyResponse_Status {
+		req := kvpb.CheckConsistencyRequest{
+			RequestHeader: kvpb.RequestHeader{Key: key, EndKey: key.Next()},
+			Mode:          mode,
+		}
+		resp, pErr := kv.SendWrapped(ctx, store.DB().NonTransactionalSender(), &req)
+		require.NoError(t, pErr.GoError())
+		return resp.(*kvpb.CheckConsistencyResponse).Result[0].Status
+	}
+	require.Equal(t, kvpb.CheckConsistencyResponse_RANGE_INCONSISTENT,
+		runConsistencyCheck(kvpb.ChecksumMode_CHECK_VIA_QUEUE))
+	select {
+	case err := <-rebuilt:
+		require.NoError(t, err)
+	case <-time.After(45 * time.Second):
+		t.Fatal("divergent replicas not rebuilt")
+	}
+
+	// Both divergent replicas were replaced by new ones, which received their
+	// snapshots from consistent replicas.
+	newDesc := tc.LookupRangeOrFatal(t, key)
+	for _, s := range divergentStores {
+		old, ok := desc.GetReplicaDescriptor(s.StoreID())
+		require.True(t, ok)
+		rebuiltReplica, ok := newDesc.GetReplicaDescriptor(s.StoreID())
+		require.True(t, ok)
+		require.NotEqual(t, old.ReplicaID, rebuiltReplica.ReplicaID)
+	}
+	require.Equal(t, kvpb.CheckConsistencyResponse_RANGE_CONSISTENT,
+		runConsistencyCheck(kvpb.ChecksumMode_CHECK_FULL))
+}
+
 // TestConsistencyQueueRecomputeStats is an end-to-end test of the mechanism CockroachDB
 // employs to adjust incorrect MVCCStats ("incorrect" meaning not an inconsistency of
 // these stats between replicas, but a delta between persisted stats and those one
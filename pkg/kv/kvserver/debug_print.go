@@ -437,6 +437,9 @@ func tryRangeIDKey(kv storage.MVCCKeyValue) (string, error) {
 	case bytes.Equal(suffix, keys.LocalRangeLastReplicaGCTimestampSuffix):
 		msg = &hlc.Timestamp{}
 
+	case bytes.Equal(suffix, keys.LocalRangeLoadStatsSuffix):
+		msg = &kvserverpb.RangeLoadStats{}
+
 	default:
 		return "", fmt.Errorf("unknown raft id key %s", suffix)
 	}
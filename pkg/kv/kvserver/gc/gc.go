@@ -156,6 +156,20 @@ var AdmissionPriority = settings.RegisterEnumSetting(
 	},
 )
 
+// MaxVersionsMinRetention is the minimum age of the versions removed to
+// enforce the max versions GC policy of a range, see
+// CalculateMaxVersionsThreshold.
+var MaxVersionsMinRetention = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.gc.max_versions.min_retention",
+	"the minimum age of the versions removed by gc to retain at most gc.max_versions versions "+
+		"of every key; since the gc threshold of the whole range is raised over the versions "+
+		"removed, it is also the shortest history retained for the other keys of the range; it "+
+		"also bounds how often ranges with such a policy are scanned for keys with too many versions",
+	10*time.Minute,
+	settings.DurationWithMinimum(time.Minute),
+)
+
 // CalculateThreshold calculates the GC threshold given the policy and the
 // current view of time.
 func CalculateThreshold(now hlc.Timestamp, gcttl time.Duration) (threshold hlc.Timestamp) {
@@ -170,6 +184,76 @@ func TimestampForThreshold(threshold hlc.Timestamp, gcttl time.Duration) (ts hlc
 	return threshold.Add(ttlNanos, 0)
 }
 
+// CalculateMaxVersionsThreshold returns the GC threshold required to retain at
+// most maxVersions versions of every key of the range, or the given threshold
+// if it's higher. The returned threshold is capped at limit.
+//
+// Versions are only removed below the GC threshold of the range, so that reads
+// at the timestamps they served fail instead of observing older versions. To
+// retain only the newest maxVersions versions of a key with more versions, the
+// threshold is raised to the timestamp of the oldest of them, which shortens
+// the history of the whole range as much as required by its most written key.
+// This trade-off is deliberate, see zonepb.GCPolicy. Provisional values of
+// intents aren't counted as versions.
+func CalculateMaxVersionsThreshold(
+	ctx context.Context,
+	desc *roachpb.RangeDescriptor,
+	snap storage.Reader,
+	maxVersions int32,
+	threshold, limit hlc.Timestamp,
+) (hlc.Timestamp, error) {
+	if maxVersions <= 0 || !threshold.Less(limit) {
+		return threshold, nil
+	}
+	span := desc.KeySpan().AsRawSpanWithNoLocals()
+	iter, err := snap.NewMVCCIterator(ctx, storage.MVCCKeyAndIntentsIterKind, storage.IterOptions{
+		LowerBound:   span.Key,
+		UpperBound:   span.EndKey,
+		KeyTypes:     storage.IterKeyTypePointsOnly,
+		ReadCategory: storage.MVCCGCReadCategory,
+	})
+	if err != nil {
+		return hlc.Timestamp{}, err
+	}
+	defer iter.Close()
+
+	// Versions are iterated from newest to oldest, after the metadata of the
+	// key's intent, if any.
+	var curKey roachpb.Key
+	var versions int32
+	var oldestRetained hlc.Timestamp
+	for iter.SeekGE(storage.MakeMVCCMetadataKey(span.Key)); ; {
+		if ok, err := iter.Valid(); err != nil {
+			return hlc.Timestamp{}, err
+		} else if !ok {
+			break
+		}
+		key := iter.UnsafeKey()
+		if !key.Key.Equal(curKey) {
+			curKey = append(curKey[:0], key.Key...)
+			versions = 0
+			if !key.IsValue() {
+				// Skip the metadata and, unless the key is inline, the provisional
+				// value of the intent which follows it.
+				versions = -1
+				iter.Next()
+				continue
+			}
+		}
+		if versions++; versions <= maxVersions {
+			oldestRetained = key.Timestamp
+			iter.Next()
+			continue
+		}
+		// The key has more than maxVersions versions.
+		if threshold.Forward(oldestRetained) && !threshold.Less(limit) {
+			return limit, nil
+		}
+		iter.NextKey()
+	}
+	return threshold, nil
+}
+
 // Thresholder is part of the GCer interface.
 type Thresholder interface {
 	SetGCThreshold(context.Context, Threshold) error
@@ -307,8 +391,16 @@ type RunOptions struct {
 	// to issuing point delete requests for the oldest batch to free up memory
 	// before resuming further iteration.
 	MaxPendingKeysSize int64
+	// OnScan, if set, is called with the number of bytes of replicated keys
+	// and values scanned since its last call, roughly every scanReportBytes.
+	// It allows the caller to pace the scan. An error aborts the GC run.
+	OnScan func(ctx context.Context, bytes int64) error
 }
 
+// scanReportBytes is the number of scanned bytes after which
+// RunOptions.OnScan is called.
+const scanReportBytes = 1 << 20 // 1 MiB
+
 // CleanupIntentsFunc synchronously resolves the supplied intents
 // (which may be PENDING, in which case they are first pushed) while
 // taking care of proper batching.
@@ -368,7 +460,7 @@ func Run(
 		return Info{}, err
 	}
 	fastPath, err := processReplicatedKeyRange(ctx, desc, snap, newThreshold,
-		populateBatcherOptions(options), gcer, &info)
+		populateBatcherOptions(options), gcer, options.OnScan, &info)
 	if err != nil {
 		if errors.Is(err, pebble.ErrSnapshotExcised) {
 			err = benignerror.NewStoreBenign(err)
@@ -439,6 +531,7 @@ func processReplicatedKeyRange(
 	threshold hlc.Timestamp,
 	batcherThresholds gcKeyBatcherThresholds,
 	gcer PureGCer,
+	onScan func(ctx context.Context, bytes int64) error,
 	info *Info,
 ) (bool, error) {
 	// Perform fast path check prior to performing GC. Fast path only collects
@@ -493,6 +586,15 @@ func processReplicatedKeyRange(
 				prevWasNewest:    true,
 			}
 
+			var scanned int64
+			reportScan := func() error {
+				if onScan == nil || scanned == 0 {
+					return nil
+				}
+				n := scanned
+				scanned = 0
+				return onScan(ctx, n)
+			}
 			for ; ; it.step() {
 				var err error
 
@@ -504,6 +606,13 @@ func processReplicatedKeyRange(
 					break
 				}
 
+				scanned += int64(s.cur.key.EncodedSize() + s.cur.mvccValueLen + len(s.cur.metaValue))
+				if scanned >= scanReportBytes {
+					if err := reportScan(); err != nil {
+						return err
+					}
+				}
+
 				switch {
 				case s.curIsNotValue():
 					// Skip over non mvcc data.
@@ -529,6 +638,9 @@ func processReplicatedKeyRange(
 					return err
 				}
 			}
+			if err := reportScan(); err != nil {
+				return err
+			}
 
 			return b.flushLastBatch(ctx)
 		})
@@ -1144,6 +1256,8 @@ func (b *intentBatcher) maybeFlushPendingIntents(ctx context.Context) error {
 // guaranteed as described above. However if this were the only rule, then if
 // the most recent write was a delete, it would never be removed. Thus, when a
 // deleted value is the most recent before expiration, it can be deleted.
+// A value which itself expired at or below the threshold is treated like a
+// delete, since reads at or above the threshold see it as deleted.
 func isGarbage(
 	threshold hlc.Timestamp,
 	cur, next *mvccKeyValue,
@@ -1163,7 +1277,7 @@ func isGarbage(
 		}
 		return true
 	}
-	isDelete := cur.mvccValueIsTombstone
+	isDelete := cur.mvccValueIsTombstone || cur.mvccValueExpired
 	if isNewestPoint && !isDelete {
 		return false
 	}
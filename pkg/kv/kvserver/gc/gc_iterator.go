@@ -143,7 +143,7 @@ func (it *gcIterator) fillTo(targetLen int) (ok bool) {
 			}
 			key := it.it.UnsafeKey()
 			var mvccValueLen int
-			var mvccValueIsTombstone bool
+			var mvccValueIsTombstone, mvccValueExpired bool
 			var metaValue []byte
 			if key.IsValue() {
 				var err error
@@ -152,6 +152,14 @@ func (it *gcIterator) fillTo(targetLen int) (ok bool) {
 					it.err = err
 					return false
 				}
+				// A value can only have expired at or below the threshold if it was
+				// itself written at or below the threshold.
+				if !mvccValueIsTombstone && key.Timestamp.LessEq(it.threshold) {
+					if mvccValueExpired, err = it.currentValueExpired(); err != nil {
+						it.err = err
+						return false
+					}
+				}
 			} else {
 				var err error
 				metaValue, err = it.it.UnsafeValue()
@@ -160,13 +168,27 @@ func (it *gcIterator) fillTo(targetLen int) (ok bool) {
 					return false
 				}
 			}
-			it.buf.pushBack(key, mvccValueLen, mvccValueIsTombstone, metaValue, ts)
+			it.buf.pushBack(key, mvccValueLen, mvccValueIsTombstone, mvccValueExpired, metaValue, ts)
 		}
 		it.it.Prev()
 	}
 	return true
 }
 
+// currentValueExpired returns whether the current value has an expiration at
+// or below the gc threshold, in which case it is garbage like a tombstone.
+func (it *gcIterator) currentValueExpired() (bool, error) {
+	raw, err := it.it.UnsafeValue()
+	if err != nil {
+		return false, err
+	}
+	v, err := storage.DecodeMVCCValue(raw)
+	if err != nil {
+		return false, err
+	}
+	return v.IsExpired(it.threshold), nil
+}
+
 // currentRangeTS returns timestamp of the first range tombstone at or below
 // gc threshold for current key. it also updates cached value to avoid
 // recomputation for every key and version by checking current range bounds
@@ -193,12 +215,15 @@ func (it *gcIterator) currentRangeTS() hlc.Timestamp {
 const gcIteratorRingBufSize = 3
 
 type mvccKeyValue struct {
-	// If key.IsValue(), mvccValueLen and mvccValueIsTombstone are populated,
-	// else, metaValue is populated.
+	// If key.IsValue(), mvccValueLen, mvccValueIsTombstone and mvccValueExpired
+	// are populated, else, metaValue is populated.
 	key                  storage.MVCCKey
 	mvccValueLen         int
 	mvccValueIsTombstone bool
-	metaValue            []byte
+	// mvccValueExpired is true if the value expired at or below the gc
+	// threshold.
+	mvccValueExpired bool
+	metaValue        []byte
 }
 
 type gcIteratorRingBuf struct {
@@ -234,6 +259,7 @@ func (b *gcIteratorRingBuf) pushBack(
 	k storage.MVCCKey,
 	mvccValueLen int,
 	mvccValueIsTombstone bool,
+	mvccValueExpired bool,
 	metaValue []byte,
 	rangeTS hlc.Timestamp,
 ) {
@@ -250,6 +276,7 @@ func (b *gcIteratorRingBuf) pushBack(
 		key:                  k,
 		mvccValueLen:         mvccValueLen,
 		mvccValueIsTombstone: mvccValueIsTombstone,
+		mvccValueExpired:     mvccValueExpired,
 		metaValue:            metaValue,
 	}
 	b.firstRangeTombstoneAtOrBelowGCTss[i] = rangeTS
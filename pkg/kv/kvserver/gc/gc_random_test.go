@@ -359,7 +359,7 @@ func TestNewVsInvariants(t *testing.T) {
 			// Handle GC + resolve intents.
 			var stats enginepb.MVCCStats
 			require.NoError(t,
-				storage.MVCCGarbageCollect(ctx, eng, &stats, gcer.pointKeys(), gcThreshold))
+				storage.MVCCGarbageCollect(ctx, eng, &stats, gcer.pointKeys(), gcThreshold, gcThreshold))
 			for _, i := range gcer.locks {
 				l := roachpb.LockUpdate{
 					Span:   roachpb.Span{Key: i.Key},
@@ -55,6 +55,66 @@ func TestCalculateThreshold(t *testing.T) {
 	}
 }
 
+func TestCalculateMaxVersionsThreshold(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+
+	ts := func(wallTime int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wallTime} }
+	value := roachpb.MakeValueFromString("v")
+	put := func(key string, wallTime int64, txn *roachpb.Transaction) {
+		_, err := storage.MVCCPut(ctx, eng, roachpb.Key(key), ts(wallTime), value,
+			storage.MVCCWriteOptions{Txn: txn})
+		require.NoError(t, err)
+	}
+	for i := int64(1); i <= 5; i++ {
+		put("a", i, nil)
+	}
+	put("b", 1, nil)
+	put("b", 2, nil)
+	// The provisional value of the intent isn't counted as a version.
+	put("c", 2, nil)
+	put("c", 3, nil)
+	put("c", 4, nil)
+	txn := roachpb.MakeTransaction("txn", roachpb.Key("c"), isolation.Serializable,
+		roachpb.NormalUserPriority, ts(10), 0, 0, 0, false /* omitInRangefeeds */)
+	put("c", 10, &txn)
+	put("d", 1, nil)
+
+	desc := &roachpb.RangeDescriptor{StartKey: roachpb.RKey("a"), EndKey: roachpb.RKey("e")}
+	for _, tc := range []struct {
+		maxVersions       int32
+		threshold, limit  hlc.Timestamp
+		expectedThreshold hlc.Timestamp
+	}{
+		// Retaining 2 versions of "a" requires the highest threshold.
+		{maxVersions: 2, threshold: ts(0), limit: ts(100), expectedThreshold: ts(4)},
+		{maxVersions: 3, threshold: ts(0), limit: ts(100), expectedThreshold: ts(3)},
+		// No key has more than 5 versions.
+		{maxVersions: 5, threshold: ts(0), limit: ts(100), expectedThreshold: ts(0)},
+		// The threshold is never lowered, and is capped at the limit.
+		{maxVersions: 2, threshold: ts(6), limit: ts(100), expectedThreshold: ts(6)},
+		{maxVersions: 2, threshold: ts(0), limit: ts(2), expectedThreshold: ts(2)},
+		// The policy is disabled.
+		{maxVersions: 0, threshold: ts(0), limit: ts(100), expectedThreshold: ts(0)},
+	} {
+		threshold, err := CalculateMaxVersionsThreshold(
+			ctx, desc, eng, tc.maxVersions, tc.threshold, tc.limit)
+		require.NoError(t, err)
+		require.Equal(t, tc.expectedThreshold, threshold, "%+v", tc)
+	}
+
+	// A single key is enough to require a threshold.
+	threshold, err := CalculateMaxVersionsThreshold(ctx,
+		&roachpb.RangeDescriptor{StartKey: roachpb.RKey("c"), EndKey: roachpb.RKey("d")},
+		eng, 2 /* maxVersions */, ts(0), ts(100))
+	require.NoError(t, err)
+	require.Equal(t, ts(3), threshold)
+}
+
 type collectingGCer struct {
 	keys [][]kvpb.GCRequest_GCKey
 }
@@ -196,6 +256,46 @@ func TestLockAgeThresholdSetting(t *testing.T) {
 	require.Equal(t, 8, len(gcer.locks))
 }
 
+// TestGCReportsScannedBytes verifies that a GC run reports the bytes it scans
+// to RunOptions.OnScan, and is aborted by an error returned from it.
+func TestGCReportsScannedBytes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+
+	const numKeys = 100
+	value := roachpb.MakeValueFromBytes(make([]byte, 100))
+	for i := 0; i < numKeys; i++ {
+		key := roachpb.Key(fmt.Sprintf("a%03d", i))
+		_, err := storage.MVCCPut(ctx, eng, key, hlc.Timestamp{WallTime: 1}, value,
+			storage.MVCCWriteOptions{})
+		require.NoError(t, err)
+	}
+	desc := roachpb.RangeDescriptor{StartKey: roachpb.RKey("a"), EndKey: roachpb.RKey("b")}
+	snap := eng.NewSnapshot()
+	defer snap.Close()
+	now := hlc.Timestamp{WallTime: 10}
+
+	var scanned int64
+	gcer := makeFakeGCer()
+	_, err := Run(ctx, &desc, snap, now, now, RunOptions{
+		OnScan: func(ctx context.Context, bytes int64) error {
+			scanned += bytes
+			return nil
+		},
+	}, time.Second, &gcer, gcer.resolveIntents, gcer.resolveIntentsAsync)
+	require.NoError(t, err)
+	require.Greater(t, scanned, int64(numKeys*len(value.RawBytes)))
+
+	scanErr := errors.New("boom")
+	_, err = Run(ctx, &desc, snap, now, now, RunOptions{
+		OnScan: func(ctx context.Context, bytes int64) error { return scanErr },
+	}, time.Second, &gcer, gcer.resolveIntents, gcer.resolveIntentsAsync)
+	require.ErrorIs(t, err, scanErr)
+}
+
 func TestIntentCleanupBatching(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -1126,7 +1226,7 @@ func runTest(t *testing.T, data testRunData, verify gcVerifier) {
 	require.NoError(t, err)
 	require.Empty(t, gcer.locks, "expecting no intents")
 	require.NoError(t,
-		storage.MVCCGarbageCollect(ctx, eng, &stats, gcer.pointKeys(), gcTS))
+		storage.MVCCGarbageCollect(ctx, eng, &stats, gcer.pointKeys(), gcTS, gcTS))
 
 	for _, r := range gcer.clearRanges() {
 		if r.StartKeyTimestamp.IsEmpty() {
@@ -166,6 +166,12 @@ func (s *Store) SetReplicaGCQueueActive(active bool) {
 	s.setReplicaGCQueueActive(active)
 }
 
+// ReplicaGCQueueNotifiedCount returns the number of replicas enqueued into the
+// replica GC queue after their removal was reported by the range's leader.
+func (s *Store) ReplicaGCQueueNotifiedCount() int64 {
+	return s.replicaGCQueue.metrics.NotifiedCount.Count()
+}
+
 // SetSplitQueueActive enables or disables the split queue.
 func (s *Store) SetSplitQueueActive(active bool) {
 	s.setSplitQueueActive(active)
@@ -456,7 +462,7 @@ func (r *Replica) SideloadedRaftMuLocked() logstore.SideloadStorage {
 func (r *Replica) LargestPreviousMaxRangeSizeBytes() int64 {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	return r.mu.largestPreviousMaxRangeSizeBytes
+	return r.mu.sizeTracker.largestPreviousMaxRangeSizeBytes
 }
 
 // LoadBasedSplitter returns the replica's split.Decider, which is used to
@@ -239,6 +239,7 @@ func New(c Config) *IntentResolver {
 	if c.TestingKnobs.MaxIntentResolutionBatchSize > 0 {
 		gcBatchSize = c.TestingKnobs.MaxGCBatchSize
 	}
+	sender := physicalKeysSender(c.DB.NonTransactionalSender())
 	ir.gcBatcher = requestbatcher.New(requestbatcher.Config{
 		AmbientCtx:      c.AmbientCtx,
 		Name:            "intent_resolver_gc_batcher",
@@ -250,7 +251,7 @@ func New(c Config) *IntentResolver {
 		// backpressure limit.
 		InFlightBackpressureLimit: func() int { return inFlightGCBackpressureLimit },
 		Stopper:                   c.Stopper,
-		Sender:                    c.DB.NonTransactionalSender(),
+		Sender:                    sender,
 	})
 	intentResolutionBatchSize := intentResolverBatchSize
 	intentResolutionRangeBatchSize := intentResolverRangeBatchSize
@@ -272,7 +273,7 @@ func New(c Config) *IntentResolver {
 		MaxTimeout:                intentResolutionSendBatchTimeout,
 		InFlightBackpressureLimit: inFlightLimit.limit,
 		Stopper:                   c.Stopper,
-		Sender:                    c.DB.NonTransactionalSender(),
+		Sender:                    sender,
 	})
 	ir.irRangeBatcher = requestbatcher.New(requestbatcher.Config{
 		AmbientCtx:                c.AmbientCtx,
@@ -285,11 +286,26 @@ func New(c Config) *IntentResolver {
 		MaxTimeout:                intentResolutionSendBatchTimeout,
 		InFlightBackpressureLimit: inFlightLimit.limit,
 		Stopper:                   c.Stopper,
-		Sender:                    c.DB.NonTransactionalSender(),
+		Sender:                    sender,
 	})
 	return ir
 }
 
+// physicalKeysSender wraps the sender of the batches resolving intents. The
+// keys of the intents were read back from the storage layer or from the lock
+// spans of transaction records, so they're already the physical keys of the
+// hash-sharded spans, which the DistSender must not translate again, see
+// kvserverbase.HashShardedSpan.
+func physicalKeysSender(wrapped kv.Sender) kv.Sender {
+	return kv.SenderFunc(func(
+		ctx context.Context, ba *kvpb.BatchRequest,
+	) (*kvpb.BatchResponse, *kvpb.Error) {
+		ba = ba.ShallowCopy()
+		ba.HashShardedKeysTranslated = true
+		return wrapped.Send(ctx, ba)
+	})
+}
+
 func getPusherTxn(h kvpb.Header) roachpb.Transaction {
 	// If the txn is nil, we communicate a priority by sending an empty
 	// txn with only the priority set. This is official usage of PushTxn.
@@ -1041,6 +1057,7 @@ func (ir *IntentResolver) resolveIntents(
 		// ... using a single batch.
 		b := &kv.Batch{}
 		b.AdmissionHeader = h
+		b.Header.HashShardedKeysTranslated = true
 		b.AddRawRequest(reqs...)
 		if err := ir.db.Run(ctx, b); err != nil {
 			return b.MustPErr()
@@ -96,6 +96,27 @@ var rangefeedCatchupScanElasticControlEnabled = settings.RegisterBoolSetting(
 	true,
 )
 
+// elasticCPUDurationPerQueueWorkUnit controls how many CPU tokens are allotted
+// for each unit of work done by budgeted store queues. Only takes effect if
+// kvadmission.queue_work_elastic_control.enabled is set.
+var elasticCPUDurationPerQueueWorkUnit = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kvadmission.elastic_cpu.duration_per_queue_work_unit",
+	"controls how many CPU tokens are allotted for each unit of work done by budgeted store queues",
+	admission.MaxElasticCPUDuration,
+	settings.DurationInRange(admission.MinElasticCPUDuration, admission.MaxElasticCPUDuration),
+)
+
+// queueWorkElasticControlEnabled determines whether the processing of budgeted
+// store queues integrates with elastic CPU control.
+var queueWorkElasticControlEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kvadmission.queue_work_elastic_control.enabled",
+	"determines whether the processing of budgeted store queues (e.g. mvcc gc and "+
+		"the consistency checker) integrates with the elastic CPU control",
+	true,
+)
+
 // ProvisionedBandwidth set a value of the provisioned
 // bandwidth for each store in the cluster.
 var ProvisionedBandwidth = settings.RegisterByteSizeSetting(
@@ -165,6 +186,11 @@ type Controller interface {
 	// catchup scans (typically CPU-intensive and affecting scheduling
 	// latencies).
 	AdmitRangefeedRequest(roachpb.TenantID, *kvpb.RangeFeedRequest) *admission.Pacer
+	// AdmitQueueWork must be called before a budgeted store queue processes a
+	// replica. If enabled, it returns a non-nil Pacer that's to be used while
+	// processing the replica, and closed once done, so that the CPU-intensive
+	// background work yields to foreground work.
+	AdmitQueueWork(admissionpb.WorkPriority) *admission.Pacer
 	// SetTenantWeightProvider is used to set the provider that will be
 	// periodically polled for weights. The stopper should be used to terminate
 	// the periodic polling.
@@ -489,6 +515,22 @@ func (n *controllerImpl) AdmitRangefeedRequest(
 		})
 }
 
+// AdmitQueueWork implements the Controller interface.
+func (n *controllerImpl) AdmitQueueWork(pri admissionpb.WorkPriority) *admission.Pacer {
+	if !queueWorkElasticControlEnabled.Get(&n.settings.SV) {
+		return nil
+	}
+
+	return n.elasticCPUGrantCoordinator.NewPacer(
+		elasticCPUDurationPerQueueWorkUnit.Get(&n.settings.SV),
+		admission.WorkInfo{
+			TenantID:        roachpb.SystemTenantID,
+			Priority:        pri,
+			CreateTime:      timeutil.Now().UnixNano(),
+			BypassAdmission: false,
+		})
+}
+
 // SetTenantWeightProvider implements the Controller interface.
 func (n *controllerImpl) SetTenantWeightProvider(
 	provider TenantWeightProvider, stopper *stop.Stopper,
@@ -0,0 +1,88 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserverbase
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// SplitMergeEvent describes a split or merge trigger applied by a replica of
+// the node.
+type SplitMergeEvent struct {
+	// Merge is set for merges, and unset for splits.
+	Merge bool
+	// Ranges are the ranges resulting from the trigger, as known to the node:
+	// the left-hand and right-hand sides of a split, or the merged range. The
+	// lease of the right-hand side of a split is unknown if the node doesn't
+	// hold a replica of it.
+	Ranges []roachpb.RangeInfo
+	// SubsumedRangeID is the ID of the right-hand side of a merge.
+	SubsumedRangeID roachpb.RangeID
+}
+
+// SplitMergeObserver is notified of the split and merge triggers applied by
+// the replicas of the node.
+//
+// Observers are called synchronously on the raft application path of the
+// replica, while it holds its raftMu: they must be cheap, and must not block
+// nor call back into the replica.
+type SplitMergeObserver func(ctx context.Context, ev SplitMergeEvent)
+
+// ApplyTriggerRegistry lets the components of the node observe the triggers
+// applied by its replicas, to update their node-local state right away rather
+// than on their next refresh. A nil registry has no observers.
+type ApplyTriggerRegistry struct {
+	mu struct {
+		syncutil.RWMutex
+		nextID    int
+		observers map[int]SplitMergeObserver
+	}
+}
+
+// NewApplyTriggerRegistry creates an ApplyTriggerRegistry.
+func NewApplyTriggerRegistry() *ApplyTriggerRegistry {
+	r := &ApplyTriggerRegistry{}
+	r.mu.observers = make(map[int]SplitMergeObserver)
+	return r
+}
+
+// RegisterSplitMergeObserver registers an observer of the split and merge
+// triggers. The returned function unregisters it.
+func (r *ApplyTriggerRegistry) RegisterSplitMergeObserver(
+	fn SplitMergeObserver,
+) (unregister func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := r.mu.nextID
+	r.mu.nextID++
+	r.mu.observers[id] = fn
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.mu.observers, id)
+	}
+}
+
+// NotifySplitMerge notifies the observers of the split and merge triggers of
+// the given event.
+func (r *ApplyTriggerRegistry) NotifySplitMerge(ctx context.Context, ev SplitMergeEvent) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, fn := range r.mu.observers {
+		fn(ctx, ev)
+	}
+}
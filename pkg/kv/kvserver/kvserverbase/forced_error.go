@@ -35,6 +35,27 @@ const (
 	ProposalRejectionIllegalLeaseIndex
 )
 
+// ForcedErrReason indicates why a command was rejected below raft.
+type ForcedErrReason int
+
+const (
+	// ForcedErrReasonNone indicates that the command was not rejected.
+	ForcedErrReasonNone ForcedErrReason = iota
+	// ForcedErrReasonNoop indicates that the command was an empty raft entry or
+	// a ProbeRequest, which are "rejected" as they have nothing to apply.
+	ForcedErrReasonNoop
+	// ForcedErrReasonLeaseMismatch indicates that the command was proposed
+	// under a lease other than the current one, or that it was a lease request
+	// for a replica which isn't part of the range.
+	ForcedErrReasonLeaseMismatch
+	// ForcedErrReasonLeaseIndex indicates that the command's lease index had
+	// already been consumed by another command.
+	ForcedErrReasonLeaseIndex
+	// ForcedErrReasonGCThreshold indicates that the command was writing at or
+	// below the range's GC threshold.
+	ForcedErrReasonGCThreshold
+)
+
 // noopOnEmptyRaftCommandErr is returned from CheckForcedErr when an empty raft
 // command is received. See the comment near its use.
 var noopOnEmptyRaftCommandErr = kvpb.NewErrorf("no-op on empty Raft entry")
@@ -48,6 +69,7 @@ type ForcedErrResult struct {
 	LeaseIndex  kvpb.LeaseAppliedIndex
 	Rejection   ProposalRejectionType
 	ForcedError *kvpb.Error
+	Reason      ForcedErrReason
 }
 
 // CheckForcedErr determines whether or not a command should be applied to the
@@ -86,6 +108,7 @@ func CheckForcedErr(
 		return ForcedErrResult{
 			Rejection:   ProposalRejectionPermanent,
 			ForcedError: NoopOnProbeCommandErr,
+			Reason:      ForcedErrReasonNoop,
 		}
 	}
 	leaseIndex := replicaState.LeaseAppliedIndex
@@ -104,6 +127,7 @@ func CheckForcedErr(
 			LeaseIndex:  leaseIndex,
 			Rejection:   ProposalRejectionPermanent,
 			ForcedError: noopOnEmptyRaftCommandErr,
+			Reason:      ForcedErrReasonNoop,
 		}
 	}
 
@@ -174,6 +198,7 @@ func CheckForcedErr(
 					Requested: requestedLease,
 					Message:   "proposed under invalid lease",
 				}),
+				Reason: ForcedErrReasonLeaseMismatch,
 			}
 		}
 		// We return a NotLeaseHolderError so that the DistSender retries.
@@ -188,6 +213,7 @@ func CheckForcedErr(
 			LeaseIndex:  leaseIndex,
 			Rejection:   ProposalRejectionPermanent,
 			ForcedError: kvpb.NewError(nlhe),
+			Reason:      ForcedErrReasonLeaseMismatch,
 		}
 	}
 
@@ -208,6 +234,7 @@ func CheckForcedErr(
 					Requested: requestedLease,
 					Message:   "replica not part of range",
 				}),
+				Reason: ForcedErrReasonLeaseMismatch,
 			}
 		}
 	} else if replicaState.LeaseAppliedIndex < raftCmd.MaxLeaseIndex {
@@ -240,7 +267,9 @@ func CheckForcedErr(
 			Rejection:  retry,
 			ForcedError: kvpb.NewErrorf(
 				"command observed at lease index %d, but required < %d", leaseIndex, raftCmd.MaxLeaseIndex,
-			)}
+			),
+			Reason: ForcedErrReasonLeaseIndex,
+		}
 	}
 
 	// Verify that command is not trying to write below the GC threshold. This is
@@ -261,6 +290,7 @@ func CheckForcedErr(
 				Timestamp: wts,
 				Threshold: *replicaState.GCThreshold,
 			}),
+			Reason: ForcedErrReasonGCThreshold,
 		}
 	}
 	return ForcedErrResult{
@@ -0,0 +1,258 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserverbase
+
+import (
+	"bytes"
+	"context"
+	"hash/fnv"
+	"sort"
+	"sync/atomic"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// HashShardedSpan is the span of the keys with a given prefix, spread over
+// virtual shards. The logical key P/k, where P is the prefix, is physically
+// stored at P/s/k, where s is the shard of the key, picked by the hash of its
+// row prefix, so that all the keys of a SQL row are in the same shard. The
+// range boundaries of a hash-sharded span are kept on the shard prefixes.
+//
+// The hash-sharded spans are the tables and indexes whose zone config sets
+// hash_shards, see HashShardedSpansWatcher. The translation between the
+// logical and physical keys is the job of the DistSender: all the layers above
+// it only see the logical keys, and all the layers below it only see the
+// physical keys.
+type HashShardedSpan struct {
+	Prefix    roachpb.Key
+	NumShards int
+}
+
+// Span returns the span of the keys with the prefix of the hash-sharded span.
+func (s HashShardedSpan) Span() roachpb.Span {
+	return roachpb.Span{Key: s.Prefix, EndKey: s.Prefix.PrefixEnd()}
+}
+
+// ShardPrefix returns the prefix of the physical keys of the given shard.
+func (s HashShardedSpan) ShardPrefix(shard int) roachpb.Key {
+	return encoding.EncodeUvarintAscending(s.Prefix[:len(s.Prefix):len(s.Prefix)], uint64(shard))
+}
+
+// Shard returns the shard of the given logical key, which must have the prefix
+// of the hash-sharded span.
+func (s HashShardedSpan) Shard(key roachpb.Key) int {
+	row := key
+	if n, err := keys.GetRowPrefixLength(key); err == nil && n >= len(s.Prefix) {
+		row = key[:n]
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(row[len(s.Prefix):])
+	return int(h.Sum32() % uint32(s.NumShards))
+}
+
+// PhysicalKey returns the physical key of the given logical key, which must
+// have the prefix of the hash-sharded span.
+func (s HashShardedSpan) PhysicalKey(key roachpb.Key) roachpb.Key {
+	return append(s.ShardPrefix(s.Shard(key)), key[len(s.Prefix):]...)
+}
+
+// LogicalKey returns the logical key of the given physical key, which must
+// have the prefix of the hash-sharded span.
+func (s HashShardedSpan) LogicalKey(key roachpb.Key) (roachpb.Key, error) {
+	if !bytes.HasPrefix(key, s.Prefix) {
+		return nil, errors.AssertionFailedf("key %s not in hash-sharded span %s", key, s.Prefix)
+	}
+	rest, _, err := encoding.DecodeUvarintAscending(key[len(s.Prefix):])
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding shard of key %s", key)
+	}
+	return append(s.Prefix[:len(s.Prefix):len(s.Prefix)], rest...), nil
+}
+
+// PhysicalSpan returns the part of the given shard holding the keys of the
+// given logical span, which must overlap with the hash-sharded span.
+func (s HashShardedSpan) PhysicalSpan(shard int, span roachpb.Span) roachpb.Span {
+	prefix := s.ShardPrefix(shard)
+	res := roachpb.Span{Key: prefix, EndKey: prefix.PrefixEnd()}
+	if bytes.Compare(span.Key, s.Prefix) > 0 {
+		res.Key = append(prefix[:len(prefix):len(prefix)], span.Key[len(s.Prefix):]...)
+	}
+	if span.EndKey.Compare(s.Span().EndKey) < 0 {
+		res.EndKey = append(prefix[:len(prefix):len(prefix)], span.EndKey[len(s.Prefix):]...)
+	}
+	return res
+}
+
+// HashShardedSpans is a set of non-overlapping hash-sharded spans, ordered by
+// their prefix.
+type HashShardedSpans []HashShardedSpan
+
+// Find returns the hash-sharded span containing the given key, if any.
+func (hs HashShardedSpans) Find(key roachpb.Key) (HashShardedSpan, bool) {
+	i := sort.Search(len(hs), func(i int) bool {
+		return hs[i].Prefix.Compare(key) > 0
+	})
+	if i > 0 && bytes.HasPrefix(key, hs[i-1].Prefix) {
+		return hs[i-1], true
+	}
+	return HashShardedSpan{}, false
+}
+
+// Overlapping returns the hash-sharded spans overlapping with the given span,
+// in order.
+func (hs HashShardedSpans) Overlapping(span roachpb.Span) HashShardedSpans {
+	var res HashShardedSpans
+	for _, s := range hs {
+		if s.Span().Overlaps(span) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// SplitKey returns the first range boundary required by the hash-sharded
+// spans strictly within the given span, if any. The prefixes of the
+// hash-sharded spans, their ends and the prefixes of their shards are all
+// range boundaries.
+func (hs HashShardedSpans) SplitKey(start, end roachpb.RKey) roachpb.RKey {
+	for _, s := range hs {
+		span := s.Span()
+		if roachpb.RKey(span.EndKey).Compare(start) <= 0 {
+			continue
+		}
+		if roachpb.RKey(span.Key).Compare(end) >= 0 {
+			return nil
+		}
+		// The shard prefixes are ordered, and the end of each shard is the
+		// prefix of the next one.
+		boundaries := make([]roachpb.Key, 0, s.NumShards+1)
+		boundaries = append(boundaries, span.Key)
+		for shard := 1; shard < s.NumShards; shard++ {
+			boundaries = append(boundaries, s.ShardPrefix(shard))
+		}
+		boundaries = append(boundaries, s.ShardPrefix(s.NumShards-1).PrefixEnd(), span.EndKey)
+		for _, b := range boundaries {
+			if rb := roachpb.RKey(b); start.Less(rb) && rb.Less(end) {
+				return rb
+			}
+		}
+	}
+	return nil
+}
+
+// HashShardedSpansSource is the part of spanconfig.KVSubscriber used by
+// HashShardedSpansWatcher.
+type HashShardedSpansSource interface {
+	Subscribe(func(ctx context.Context, updated roachpb.Span))
+	ComputeBoundaries(ctx context.Context, start, end roachpb.RKey, limit int) ([]roachpb.RKey, error)
+	GetSpanConfigForKey(ctx context.Context, key roachpb.RKey) (roachpb.SpanConfig, error)
+}
+
+// hashShardedSpansBoundaryBatchSize is the number of span config boundaries
+// fetched at once by HashShardedSpansWatcher.
+const hashShardedSpansBoundaryBatchSize = 1000
+
+// HashShardedSpansWatcher maintains the hash-sharded spans from the span
+// configs. A span config with HashShards set makes its span hash-sharded if the
+// span is the span of a prefix, i.e. of a table or an index; the zone config
+// validation only lets hash_shards be set on those. Since the span configs are
+// persisted, the layout of the data of a span doesn't depend on the settings
+// of the node reading it.
+type HashShardedSpansWatcher struct {
+	spans atomic.Pointer[HashShardedSpans]
+}
+
+// NewHashShardedSpansWatcher returns a watcher without any hash-sharded span,
+// until Start is called.
+func NewHashShardedSpansWatcher() *HashShardedSpansWatcher {
+	return &HashShardedSpansWatcher{}
+}
+
+// Start subscribes the watcher to the updates of the span configs of the given
+// source.
+func (w *HashShardedSpansWatcher) Start(src HashShardedSpansSource) {
+	src.Subscribe(func(ctx context.Context, updated roachpb.Span) {
+		if err := w.update(ctx, src, updated); err != nil {
+			log.Warningf(ctx, "updating the hash-sharded spans: %v", err)
+		}
+	})
+}
+
+// Get returns the current hash-sharded spans. A nil watcher has none.
+func (w *HashShardedSpansWatcher) Get() HashShardedSpans {
+	if w == nil {
+		return nil
+	}
+	if spans := w.spans.Load(); spans != nil {
+		return *spans
+	}
+	return nil
+}
+
+// update recomputes the hash-sharded spans overlapping with the given span,
+// whose span configs were updated.
+func (w *HashShardedSpansWatcher) update(
+	ctx context.Context, src HashShardedSpansSource, updated roachpb.Span,
+) error {
+	// Only the tables of the system tenant can be hash-sharded, as the
+	// DistSenders of the secondary tenants don't translate their keys.
+	span := updated.Intersect(roachpb.Span{Key: keys.TableDataMin, EndKey: keys.TableDataMax})
+	if len(span.EndKey) == 0 || !span.Valid() {
+		return nil
+	}
+	// A hash-sharded span partially covered by the update is recomputed as a
+	// whole.
+	old := w.Get()
+	for _, s := range old.Overlapping(span) {
+		span = span.Combine(s.Span())
+	}
+	var res HashShardedSpans
+	for _, s := range old {
+		if !s.Span().Overlaps(span) {
+			res = append(res, s)
+		}
+	}
+
+	end := roachpb.RKey(span.EndKey)
+	pieceStart := roachpb.RKey(span.Key)
+	for done := false; !done; {
+		boundaries, err := src.ComputeBoundaries(ctx, pieceStart, end, hashShardedSpansBoundaryBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(boundaries) < hashShardedSpansBoundaryBatchSize {
+			boundaries = append(boundaries, end)
+			done = true
+		}
+		for _, pieceEnd := range boundaries {
+			conf, err := src.GetSpanConfigForKey(ctx, pieceStart)
+			if err != nil {
+				return err
+			}
+			if conf.HashShards > 0 && pieceEnd.Equal(pieceStart.PrefixEnd()) {
+				res = append(res, HashShardedSpan{
+					Prefix:    pieceStart.AsRawKey(),
+					NumShards: int(conf.HashShards),
+				})
+			}
+			pieceStart = pieceEnd
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Prefix.Compare(res[j].Prefix) < 0
+	})
+	w.spans.Store(&res)
+	return nil
+}
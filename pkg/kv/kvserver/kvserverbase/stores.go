@@ -13,6 +13,7 @@ package kvserverbase
 import (
 	"context"
 
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/util/errorutil"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
@@ -40,6 +41,24 @@ type Store interface {
 	// SetQueueActive disables/enables the named queue.
 	SetQueueActive(active bool, queue string) error
 
+	// Health returns the composed health of the store, which lets admission
+	// control outside of KV shape load before the store's admission queues
+	// build up.
+	Health() roachpb.StoreHealth
+
+	// VisitHotKeys invokes the visitor with the hot keys sampled by each
+	// replica of the store, if the sampling of hot keys is enabled. Iteration
+	// stops on the first error, which is returned.
+	VisitHotKeys(visitor func(rangeID roachpb.RangeID, key roachpb.Key, count int64) error) error
+
+	// VisitKeySpaceUsage invokes the visitor with the size of the keys of each
+	// replica of the store per SQL index, if the accounting of the key space
+	// usage is enabled. The usage of the replicas is computed if needed.
+	// Iteration stops on the first error, which is returned.
+	VisitKeySpaceUsage(
+		ctx context.Context, visitor func(rangeID roachpb.RangeID, usage kvserverpb.KeySpaceUsage) error,
+	) error
+
 	// GetReplicaMutexForTesting returns the mutex of the replica with the given
 	// range ID, or nil if no replica was found. This is used for testing.
 	GetReplicaMutexForTesting(rangeID roachpb.RangeID) *syncutil.RWMutex
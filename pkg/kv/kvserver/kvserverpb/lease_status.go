@@ -26,13 +26,21 @@ func (st LeaseStatus) OwnedBy(storeID roachpb.StoreID) bool {
 	return st.Lease.OwnedBy(storeID)
 }
 
-// Expiration returns the expiration of the lease.
+// Expiration returns the expiration of the lease. An epoch-based lease expires
+// with the liveness record of its node, or earlier if the epoch of its store
+// was incremented since the lease was acquired.
 func (st LeaseStatus) Expiration() hlc.Timestamp {
 	switch st.Lease.Type() {
 	case roachpb.LeaseExpiration:
 		return st.Lease.GetExpiration()
 	case roachpb.LeaseEpoch:
-		return st.Liveness.Expiration.ToTimestamp()
+		expiration := st.Liveness.Expiration.ToTimestamp()
+		if storeExpiration, ok := st.Liveness.StoreEpochExpiration(
+			st.Lease.Replica.StoreID, st.Lease.StoreEpoch,
+		); ok {
+			expiration.Backward(storeExpiration)
+		}
+		return expiration
 	default:
 		panic("unexpected")
 	}
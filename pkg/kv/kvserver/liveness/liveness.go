@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/gossip"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
@@ -82,6 +83,18 @@ var TimeAfterNodeSuspect = settings.RegisterDurationSetting(
 	settings.DurationInRange(minTimeUntilNodeSuspect, maxTimeAfterNodeSuspect),
 )
 
+// StoreLivenessEnabled controls whether a node keeps heartbeating its liveness
+// record when the disks of some, but not all, of its stores fail. The epochs of
+// the failed stores are incremented instead, which invalidates the leases of
+// these stores without affecting the leases of the other stores of the node.
+var StoreLivenessEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.store_liveness.enabled",
+	"if enabled, a disk failure on a store of a node only invalidates the leases "+
+		"of the store, instead of failing the liveness heartbeats of the node",
+	false,
+)
+
 var (
 	// ErrMissingRecord is returned when asking for liveness information
 	// about a node for which nothing is known. This happens when attempting to
@@ -187,6 +200,12 @@ var (
 		Measurement: "Epochs",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaStoreEpochIncrements = metric.Metadata{
+		Name:        "liveness.storeepochincrements",
+		Help:        "Number of times this node has incremented the liveness epoch of one of its stores after a disk failure",
+		Measurement: "Epochs",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaHeartbeatLatency = metric.Metadata{
 		Name:        "liveness.heartbeatlatency",
 		Help:        "Node liveness heartbeat latency",
@@ -197,12 +216,13 @@ var (
 
 // Metrics holds metrics for use with node liveness activity.
 type Metrics struct {
-	LiveNodes          *metric.Gauge
-	HeartbeatsInFlight *metric.Gauge
-	HeartbeatSuccesses *metric.Counter
-	HeartbeatFailures  telemetry.CounterWithMetric
-	EpochIncrements    telemetry.CounterWithMetric
-	HeartbeatLatency   metric.IHistogram
+	LiveNodes            *metric.Gauge
+	HeartbeatsInFlight   *metric.Gauge
+	HeartbeatSuccesses   *metric.Counter
+	HeartbeatFailures    telemetry.CounterWithMetric
+	EpochIncrements      telemetry.CounterWithMetric
+	StoreEpochIncrements *metric.Counter
+	HeartbeatLatency     metric.IHistogram
 }
 
 // IsLiveCallback is invoked when a node's IsLive state changes to true.
@@ -339,11 +359,12 @@ func NewNodeLiveness(opts NodeLivenessOptions) *NodeLiveness {
 		cache:                 opts.Cache,
 	}
 	nl.metrics = Metrics{
-		LiveNodes:          metric.NewFunctionalGauge(metaLiveNodes, nl.numLiveNodes),
-		HeartbeatsInFlight: metric.NewGauge(metaHeartbeatsInFlight),
-		HeartbeatSuccesses: metric.NewCounter(metaHeartbeatSuccesses),
-		HeartbeatFailures:  telemetry.NewCounterWithMetric(metaHeartbeatFailures),
-		EpochIncrements:    telemetry.NewCounterWithMetric(metaEpochIncrements),
+		LiveNodes:            metric.NewFunctionalGauge(metaLiveNodes, nl.numLiveNodes),
+		HeartbeatsInFlight:   metric.NewGauge(metaHeartbeatsInFlight),
+		HeartbeatSuccesses:   metric.NewCounter(metaHeartbeatSuccesses),
+		HeartbeatFailures:    telemetry.NewCounterWithMetric(metaHeartbeatFailures),
+		EpochIncrements:      telemetry.NewCounterWithMetric(metaEpochIncrements),
+		StoreEpochIncrements: metric.NewCounter(metaStoreEpochIncrements),
 		HeartbeatLatency: metric.NewHistogram(metric.HistogramOptions{
 			Mode:         metric.HistogramModePreferHdrLatency,
 			Metadata:     metaHeartbeatLatency,
@@ -482,7 +503,7 @@ func (nl *NodeLiveness) setDrainingInternal(
 		<-sem
 	}()
 
-	if oldLivenessRec.Liveness == (livenesspb.Liveness{}) {
+	if oldLivenessRec.Liveness.Equal(livenesspb.Liveness{}) {
 		return errors.AssertionFailedf("invalid old liveness record; found to be empty")
 	}
 
@@ -795,7 +816,7 @@ func (nl *NodeLiveness) heartbeatInternal(
 		}
 	}
 
-	if oldLiveness == (livenesspb.Liveness{}) {
+	if oldLiveness.Equal(livenesspb.Liveness{}) {
 		return errors.AssertionFailedf("invalid old liveness record; found to be empty")
 	}
 
@@ -820,8 +841,9 @@ func (nl *NodeLiveness) heartbeatInternal(
 	}
 
 	update := LivenessUpdate{
-		oldLiveness: oldLiveness,
-		newLiveness: newLiveness,
+		oldLiveness:               oldLiveness,
+		newLiveness:               newLiveness,
+		tolerateStoreDiskFailures: true,
 	}
 	written, err := nl.updateLiveness(ctx, update, func(actual Record) error {
 		// Update liveness to actual value on mismatch.
@@ -1065,9 +1087,32 @@ func (nl *NodeLiveness) RegisterCallback(cb IsLiveCallback) {
 func (nl *NodeLiveness) updateLiveness(
 	ctx context.Context, update LivenessUpdate, handleCondFailed func(actual Record) error,
 ) (Record, error) {
-	if err := nl.verifyDiskHealth(ctx); err != nil {
+	failedStores, err := nl.verifyDiskHealth(ctx, update.tolerateStoreDiskFailures)
+	if err != nil {
 		return Record{}, err
 	}
+	now := nl.clock.Now()
+	for _, storeID := range failedStores {
+		// The heartbeat keeps the node live, but the leases of the stores whose
+		// disk failed are invalidated. They expire with oldLiveness, which is the
+		// latest liveness record that this node may have used to serve requests
+		// under them. Another node can only acquire the leases after that, just
+		// like it would have if this node had stopped heartbeating. This also
+		// holds for the requests admitted before the increment is written.
+		//
+		// The epochs are incremented again until the disks recover, to also
+		// invalidate the leases that the stores may have acquired in the
+		// meantime, e.g. through a lease transfer by a node which is unaware of
+		// the failure. To keep the expiration of the leases from moving forward
+		// with every heartbeat, the epoch of a store is only incremented again
+		// once the previous increment took effect on all nodes.
+		if !update.oldLiveness.CanIncrementStoreEpoch(storeID, now, nl.clock.MaxOffset()) {
+			continue
+		}
+		log.Warningf(ctx, "incrementing the liveness epoch of s%d after a disk failure", storeID)
+		update.newLiveness.IncrementStoreEpoch(storeID, update.oldLiveness.Expiration)
+		nl.metrics.StoreEpochIncrements.Inc(1)
+	}
 	retryOpts := base.DefaultRetryOptions()
 	retryOpts.Closer = nl.stopper.ShouldQuiesce()
 	for r := retry.StartWithCtx(ctx, retryOpts); r.Next(); {
@@ -1096,7 +1141,14 @@ func (nl *NodeLiveness) updateLiveness(
 // need to return a timely NLHE to the caller such that it will try a different
 // replica and nudge it into acquiring the lease. This can leak a goroutine in
 // the case of a stalled disk.
-func (nl *NodeLiveness) verifyDiskHealth(ctx context.Context) error {
+//
+// If tolerateStoreDiskFailures is set and store liveness is enabled, the
+// failure of some, but not all, of the disks doesn't fail the update: the
+// stores of the failed disks are returned instead, so that their epochs can be
+// incremented.
+func (nl *NodeLiveness) verifyDiskHealth(
+	ctx context.Context, tolerateStoreDiskFailures bool,
+) (failedStores []roachpb.StoreID, _ error) {
 	resultCs := make([]singleflight.Future, len(nl.engines))
 	for i, eng := range nl.engines {
 		eng := eng // pin the loop variable
@@ -1110,13 +1162,46 @@ func (nl *NodeLiveness) verifyDiskHealth(ctx context.Context) error {
 				return nil, diskStorage.WriteSyncNoop(eng)
 			})
 	}
-	for _, resultC := range resultCs {
+	tolerateStoreDiskFailures = tolerateStoreDiskFailures && nl.storeLivenessEnabled(ctx)
+	var firstErr error
+	for i, resultC := range resultCs {
 		r := resultC.WaitForResult(ctx)
-		if r.Err != nil {
-			return errors.Wrapf(r.Err, "disk write failed while updating node liveness")
+		if r.Err == nil {
+			continue
+		}
+		err := errors.Wrapf(r.Err, "disk write failed while updating node liveness")
+		if !tolerateStoreDiskFailures || ctx.Err() != nil {
+			// NB: a disk which stalls until the context of the caller expires
+			// fails the update, as it would fail the write of the liveness record
+			// anyway.
+			return nil, err
+		}
+		// The store ID of the engine is unknown if it failed before the store
+		// was initialized, in which case the node can't be kept live.
+		storeID, idErr := nl.engines[i].GetStoreID()
+		if idErr != nil || storeID == 0 {
+			return nil, err
+		}
+		if firstErr == nil {
+			firstErr = err
 		}
+		failedStores = append(failedStores, roachpb.StoreID(storeID))
 	}
-	return nil
+	if len(failedStores) > 0 && len(failedStores) == len(nl.engines) {
+		return nil, firstErr
+	}
+	return failedStores, nil
+}
+
+// storeLivenessEnabled returns whether the epochs of the stores can be
+// incremented independently of the epoch of the node.
+func (nl *NodeLiveness) storeLivenessEnabled(ctx context.Context) bool {
+	if nl.cache == nil || nl.cache.st == nil {
+		return false
+	}
+	st := nl.cache.st
+	return StoreLivenessEnabled.Get(&st.SV) &&
+		st.Version.IsActive(ctx, clusterversion.V24_1_StoreLiveness)
 }
 
 func (nl *NodeLiveness) updateLivenessAttempt(
@@ -1133,7 +1218,7 @@ func (nl *NodeLiveness) updateLivenessAttempt(
 			// ErrMissingRecord instead.
 			return Record{}, ErrRecordCacheMiss
 		}
-		if l.Liveness != update.oldLiveness {
+		if !l.Liveness.Equal(update.oldLiveness) {
 			return Record{}, handleCondFailed(l)
 		}
 		update.oldRaw = l.raw
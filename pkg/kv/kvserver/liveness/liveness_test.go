@@ -160,6 +160,55 @@ func TestShouldReplaceLiveness(t *testing.T) {
 	}
 }
 
+// TestStoreEpochIncrement verifies that the leases of a store expire with the
+// liveness record that an increment of its epoch replaced, and that the epoch
+// is only incremented again once the previous increment took effect.
+func TestStoreEpochIncrement(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const maxOffset = 100 * time.Nanosecond
+	exp1 := hlc.LegacyTimestamp{WallTime: 1000}
+	exp2 := hlc.LegacyTimestamp{WallTime: 3000}
+
+	var l livenesspb.Liveness
+	_, ok := l.StoreEpochExpiration(1, 0)
+	require.False(t, ok)
+	require.True(t, l.CanIncrementStoreEpoch(1, hlc.Timestamp{WallTime: 1}, maxOffset))
+
+	// The first increment caps the leases of store epoch zero at exp1.
+	prev := l
+	l.IncrementStoreEpoch(1, exp1)
+	require.Empty(t, prev.StoreEpochs)
+	require.Equal(t, int64(1), l.StoreEpoch(1))
+	require.Equal(t, int64(0), l.StoreEpoch(2))
+	exp, ok := l.StoreEpochExpiration(1, 0)
+	require.True(t, ok)
+	require.Equal(t, exp1.ToTimestamp(), exp)
+	_, ok = l.StoreEpochExpiration(1, 1)
+	require.False(t, ok)
+	_, ok = l.StoreEpochExpiration(2, 0)
+	require.False(t, ok)
+
+	// The epoch can't be incremented again until exp1 is more than the maximum
+	// clock offset in the past, and other stores are unaffected.
+	require.False(t, l.CanIncrementStoreEpoch(1, exp1.ToTimestamp(), maxOffset))
+	require.False(t, l.CanIncrementStoreEpoch(1, hlc.Timestamp{WallTime: 1099}, maxOffset))
+	require.True(t, l.CanIncrementStoreEpoch(1, hlc.Timestamp{WallTime: 1100}, maxOffset))
+	require.True(t, l.CanIncrementStoreEpoch(2, exp1.ToTimestamp(), maxOffset))
+
+	// The second increment caps the leases of store epoch one at exp2, and the
+	// leases of store epoch zero remain expired.
+	l.IncrementStoreEpoch(1, exp2)
+	require.Equal(t, int64(2), l.StoreEpoch(1))
+	exp, ok = l.StoreEpochExpiration(1, 1)
+	require.True(t, ok)
+	require.Equal(t, exp2.ToTimestamp(), exp)
+	exp, ok = l.StoreEpochExpiration(1, 0)
+	require.True(t, ok)
+	require.True(t, exp.LessEq(exp1.ToTimestamp()))
+}
+
 func TestNodeLivenessLivenessStatus(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -67,6 +67,78 @@ func (l Liveness) Compare(o Liveness) int {
 	return 0
 }
 
+// StoreEpoch returns the liveness epoch of the given store of the node.
+func (l Liveness) StoreEpoch(storeID roachpb.StoreID) int64 {
+	for _, se := range l.StoreEpochs {
+		if se.StoreID == storeID {
+			return se.Epoch
+		}
+	}
+	return 0
+}
+
+// StoreEpochExpiration returns the expiration of the epoch-based leases that
+// the given store acquired under the given store epoch, if the epoch of the
+// store has since been incremented. Leases from the previous store epoch
+// expire with the liveness record that was replaced by the increment. Leases
+// from older store epochs had expired by the time of the increment, see
+// CanIncrementStoreEpoch. Returns false if the given store epoch is current.
+func (l Liveness) StoreEpochExpiration(
+	storeID roachpb.StoreID, storeEpoch int64,
+) (hlc.Timestamp, bool) {
+	for _, se := range l.StoreEpochs {
+		if se.StoreID != storeID || se.Epoch <= storeEpoch {
+			continue
+		}
+		if se.Epoch-storeEpoch > 1 {
+			return hlc.Timestamp{}, true
+		}
+		return se.Expiration.ToTimestamp(), true
+	}
+	return hlc.Timestamp{}, false
+}
+
+// CanIncrementStoreEpoch returns whether the epoch of the given store can be
+// incremented at the given time. The epoch of a store is only incremented
+// again once all nodes consider the leases from the previous store epoch
+// expired, i.e. once the expiration of the previous increment is more than
+// the maximum clock offset in the past. This allows StoreEpochExpiration to
+// consider the leases more than one store epoch behind expired, and so
+// guarantees that a lease never becomes valid again after it was considered
+// expired.
+func (l Liveness) CanIncrementStoreEpoch(
+	storeID roachpb.StoreID, now hlc.Timestamp, maxOffset time.Duration,
+) bool {
+	for _, se := range l.StoreEpochs {
+		if se.StoreID == storeID {
+			return se.Expiration.ToTimestamp().Add(maxOffset.Nanoseconds(), 0).LessEq(now)
+		}
+	}
+	return true
+}
+
+// IncrementStoreEpoch increments the liveness epoch of the given store of the
+// node. The leases from the previous store epoch expire at the given
+// expiration, which must be the expiration of the liveness record that this
+// one replaces. The store epochs are copied, since they may be shared with the
+// liveness record this one was derived from.
+func (l *Liveness) IncrementStoreEpoch(storeID roachpb.StoreID, expiration hlc.LegacyTimestamp) {
+	storeEpochs := make([]StoreEpoch, 0, len(l.StoreEpochs)+1)
+	found := false
+	for _, se := range l.StoreEpochs {
+		if se.StoreID == storeID {
+			se.Epoch++
+			se.Expiration = expiration
+			found = true
+		}
+		storeEpochs = append(storeEpochs, se)
+	}
+	if !found {
+		storeEpochs = append(storeEpochs, StoreEpoch{StoreID: storeID, Epoch: 1, Expiration: expiration})
+	}
+	l.StoreEpochs = storeEpochs
+}
+
 func (l Liveness) String() string {
 	return redact.StringWithoutMarkers(l)
 }
@@ -77,6 +149,9 @@ func (l Liveness) SafeFormat(s redact.SafePrinter, _ rune) {
 	if l.Draining || l.Membership.Decommissioning() || l.Membership.Decommissioned() {
 		s.Printf(" drain:%t membership:%s", l.Draining, l.Membership)
 	}
+	for _, se := range l.StoreEpochs {
+		s.Printf(" s%d-epo:%d", se.StoreID, se.Epoch)
+	}
 	s.Printf(")")
 }
 
@@ -57,6 +57,11 @@ type LivenessUpdate struct {
 	// if unmarshalling/marshaling doesn't round-trip. Nil means that a liveness
 	// record for the respected node is not expected to exist in the database.
 	oldRaw []byte
+	// tolerateStoreDiskFailures allows the update to go through when the disks
+	// of some, but not all, of the stores of the node fail, in which case the
+	// epochs of these stores are incremented in newLiveness. Only set for the
+	// heartbeats of the node.
+	tolerateStoreDiskFailures bool
 }
 
 // Get returns a slice containing the liveness record of all nodes that have
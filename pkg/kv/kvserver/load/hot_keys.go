@@ -0,0 +1,145 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package load
+
+import (
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+const (
+	// hotKeySketchDepth and hotKeySketchWidth are the dimensions of the
+	// count-min sketch estimating the access counts of the keys. With these,
+	// the sketch takes 4 KiB, and overestimates the count of a key by at most
+	// ~1% of all accesses (e/width) with probability ~98% (1-e^-depth).
+	hotKeySketchDepth = 4
+	hotKeySketchWidth = 256
+	// HotKeysTopK is the number of hot keys retained by a HotKeySampler.
+	HotKeysTopK = 8
+	// hotKeyDecayInterval is the interval at which the counts of the sampler
+	// are halved, so that they reflect the recent accesses.
+	hotKeyDecayInterval = time.Minute
+)
+
+// HotKey is a key along with an estimate of the number of times it was
+// accessed, with the accesses older than a minute or so decayed.
+type HotKey struct {
+	Key   roachpb.Key
+	Count int64
+}
+
+// HotKeySampler estimates the most frequently accessed keys of a replica. The
+// access counts of all the keys are estimated in fixed memory with a count-min
+// sketch, and the HotKeysTopK keys with the highest estimates are retained.
+type HotKeySampler struct {
+	clock *hlc.Clock
+
+	mu struct {
+		syncutil.Mutex
+		sketch    [hotKeySketchDepth][hotKeySketchWidth]uint32
+		top       []HotKey
+		lastDecay time.Time
+	}
+}
+
+// NewHotKeySampler returns a new, empty HotKeySampler.
+func NewHotKeySampler(clock *hlc.Clock) *HotKeySampler {
+	s := &HotKeySampler{clock: clock}
+	s.mu.lastDecay = timeutil.Unix(0, clock.PhysicalNow())
+	return s
+}
+
+// Record records an access to the given key.
+func (s *HotKeySampler) Record(key roachpb.Key) {
+	h := fnv.New64a()
+	_, _ = h.Write(key)
+	sum := h.Sum64()
+	// Derive the hashes of the rows of the sketch from two halves of a single
+	// hash, see "Less Hashing, Same Performance" by Kirsch and Mitzenmacher.
+	h1, h2 := uint32(sum), uint32(sum>>32)
+
+	now := timeutil.Unix(0, s.clock.PhysicalNow())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maybeDecayLocked(now)
+
+	var estimate uint32
+	for i := range s.mu.sketch {
+		c := &s.mu.sketch[i][(h1+uint32(i)*h2)%hotKeySketchWidth]
+		if *c < ^uint32(0) {
+			*c++
+		}
+		if i == 0 || *c < estimate {
+			estimate = *c
+		}
+	}
+
+	minIdx := -1
+	for i := range s.mu.top {
+		if s.mu.top[i].Key.Equal(key) {
+			s.mu.top[i].Count = int64(estimate)
+			return
+		}
+		if minIdx == -1 || s.mu.top[i].Count < s.mu.top[minIdx].Count {
+			minIdx = i
+		}
+	}
+	if len(s.mu.top) < HotKeysTopK {
+		s.mu.top = append(s.mu.top, HotKey{Key: key.Clone(), Count: int64(estimate)})
+	} else if int64(estimate) > s.mu.top[minIdx].Count {
+		s.mu.top[minIdx] = HotKey{Key: key.Clone(), Count: int64(estimate)}
+	}
+}
+
+// maybeDecayLocked halves the counts of the sampler for each
+// hotKeyDecayInterval elapsed since they were last halved.
+func (s *HotKeySampler) maybeDecayLocked(now time.Time) {
+	elapsed := now.Sub(s.mu.lastDecay)
+	if elapsed < hotKeyDecayInterval {
+		return
+	}
+	shift := uint(elapsed / hotKeyDecayInterval)
+	s.mu.lastDecay = now
+	for i := range s.mu.sketch {
+		for j := range s.mu.sketch[i] {
+			s.mu.sketch[i][j] >>= shift
+		}
+	}
+	top := s.mu.top[:0]
+	for _, hk := range s.mu.top {
+		if hk.Count >>= shift; hk.Count > 0 {
+			top = append(top, hk)
+		}
+	}
+	s.mu.top = top
+}
+
+// HotKeys returns the hot keys retained by the sampler, from the most to the
+// least accessed.
+func (s *HotKeySampler) HotKeys() []HotKey {
+	now := timeutil.Unix(0, s.clock.PhysicalNow())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maybeDecayLocked(now)
+
+	res := make([]HotKey, len(s.mu.top))
+	copy(res, s.mu.top)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Count > res[j].Count
+	})
+	return res
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package load
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHotKeySampler(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	manual := timeutil.NewManualTime(timeutil.Unix(0, 123))
+	clock := hlc.NewClockForTesting(manual)
+	s := NewHotKeySampler(clock)
+	require.Empty(t, s.HotKeys())
+
+	// A few hot keys among many cold ones.
+	hot := []roachpb.Key{roachpb.Key("hot-1"), roachpb.Key("hot-2"), roachpb.Key("hot-3")}
+	for i := 0; i < 1000; i++ {
+		s.Record(roachpb.Key(fmt.Sprintf("cold-%d", i)))
+		for j, k := range hot {
+			// hot-1 is accessed 3 times as often as hot-3.
+			for n := 0; n < len(hot)-j; n++ {
+				s.Record(k)
+			}
+		}
+	}
+	hotKeys := s.HotKeys()
+	require.Len(t, hotKeys, HotKeysTopK)
+	for i, k := range hot {
+		require.Equal(t, k, hotKeys[i].Key)
+		// The sketch never underestimates, and overestimates by little.
+		expected := int64(1000 * (len(hot) - i))
+		require.GreaterOrEqual(t, hotKeys[i].Count, expected)
+		require.Less(t, hotKeys[i].Count, expected+200)
+	}
+
+	// The counts decay over time.
+	manual.Advance(2 * hotKeyDecayInterval)
+	hotKeys = s.HotKeys()
+	require.Equal(t, hot[0], hotKeys[0].Key)
+	require.Less(t, hotKeys[0].Count, int64(1000))
+
+	// Keys which aren't accessed anymore are eventually dropped.
+	manual.Advance(time.Hour)
+	require.Empty(t, s.HotKeys())
+	s.Record(hot[2])
+	require.Equal(t, []HotKey{{Key: hot[2], Count: 1}}, s.HotKeys())
+}
@@ -11,6 +11,8 @@
 package load
 
 import (
+	"time"
+
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/replicastats"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
@@ -204,6 +206,54 @@ func (rl *ReplicaLoad) Stats() ReplicaLoadStats {
 	}
 }
 
+// Snapshot returns a current stat summary of replica load, along with the
+// duration over which it was measured. The duration is zero if the load hasn't
+// been measured for at least replicastats.MinStatsDuration.
+func (rl *ReplicaLoad) Snapshot() (ReplicaLoadStats, time.Duration) {
+	stats := rl.Stats()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	_, dur := rl.mu.stats[Requests].AverageRatePerSecond(timeutil.Unix(0, rl.clock.PhysicalNow()))
+	if dur < replicastats.MinStatsDuration {
+		return ReplicaLoadStats{}, 0
+	}
+	return stats, dur
+}
+
+// Restore replaces the recorded history with the given stat summary, measured
+// over the given duration, e.g. to restore the load of the replica before a
+// restart. Per-locality counts aren't restored.
+func (rl *ReplicaLoad) Restore(stats ReplicaLoadStats, dur time.Duration) {
+	now := timeutil.Unix(0, rl.clock.PhysicalNow())
+	rates := [numLoadStats]float64{
+		Queries:      stats.QueriesPerSecond,
+		Requests:     stats.RequestsPerSecond,
+		WriteKeys:    stats.WriteKeysPerSecond,
+		ReadKeys:     stats.ReadKeysPerSecond,
+		WriteBytes:   stats.WriteBytesPerSecond,
+		ReadBytes:    stats.ReadBytesPerSecond,
+		RaftCPUNanos: stats.RaftCPUNanosPerSecond,
+		ReqCPUNanos:  stats.RequestCPUNanosPerSecond,
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for i := range rl.mu.stats {
+		rl.mu.stats[i].RestoreRate(now, rates[i], dur)
+	}
+}
+
+// SetDecayHalfLife sets the half-life of the decay of the per-locality request
+// rates. See replicastats.DecayHalfLife.
+func (rl *ReplicaLoad) SetDecayHalfLife(halfLife time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for i := range rl.mu.stats {
+		rl.mu.stats[i].SetDecayHalfLife(halfLife)
+	}
+}
+
 // RequestLocalityInfo returns the summary of client localities for requests
 // made to this replica.
 func (rl *ReplicaLoad) RequestLocalityInfo() *replicastats.RatedSummary {
@@ -25,6 +25,12 @@ import (
 
 var errSideloadedFileNotFound = errors.New("sideloaded file not found")
 
+// IsSideloadedFileNotFound returns whether the error was returned by a
+// SideloadStorage for a file which isn't present.
+func IsSideloadedFileNotFound(err error) bool {
+	return errors.Is(err, errSideloadedFileNotFound)
+}
+
 // SideloadStorage is the interface used for Raft SSTable sideloading.
 // Implementations do not need to be thread safe.
 type SideloadStorage interface {
@@ -62,6 +68,28 @@ type SideloadStorage interface {
 	// Returns an absolute path to the file that Get() would return the contents
 	// of. Does not check whether the file actually exists.
 	Filename(_ context.Context, index kvpb.RaftIndex, term kvpb.RaftTerm) (string, error)
+	// Reconcile removes the files which are not referenced by the raft log
+	// containing the entries in [first, last]. A file is referenced iff its
+	// index is in [first, last] and its term matches the term of the log entry
+	// at this index, as returned by the given function. Such orphaned files can
+	// be left behind by crashes or races with log truncation.
+	//
+	// Returns the statistics of the files removed and retained.
+	Reconcile(
+		_ context.Context, first, last kvpb.RaftIndex,
+		term func(kvpb.RaftIndex) (kvpb.RaftTerm, error),
+	) (SideloadReconcileStats, error)
+}
+
+// SideloadReconcileStats describes the result of SideloadStorage.Reconcile.
+type SideloadReconcileStats struct {
+	// Files and Bytes are the number and total size of the files retained.
+	Files int
+	Bytes int64
+	// OrphanedFiles and OrphanedBytes are the number and total size of the
+	// files removed.
+	OrphanedFiles int
+	OrphanedBytes int64
 }
 
 // MaybeSideloadEntries optimizes handling for AddSST requests. AddSST are
@@ -212,7 +212,7 @@ func (ss *DiskSideloadStorage) possiblyTruncateTo(
 			return true, nil
 		}
 		if index < from {
-			// TODO(pavelkalinnikov): these files may never be removed. Clean them up.
+			// These files are not referenced by the log, and are removed by Reconcile.
 			return true, nil
 		}
 		// index is in [from, to)
@@ -310,6 +310,67 @@ func (ss *DiskSideloadStorage) forEach(
 	return nil
 }
 
+// Reconcile implements SideloadStorage.
+func (ss *DiskSideloadStorage) Reconcile(
+	ctx context.Context,
+	first, last kvpb.RaftIndex,
+	term func(kvpb.RaftIndex) (kvpb.RaftTerm, error),
+) (SideloadReconcileStats, error) {
+	var stats SideloadReconcileStats
+	if err := ss.forEach(ctx, func(index kvpb.RaftIndex, filename string) (bool, error) {
+		referenced := index >= first && index <= last
+		if referenced {
+			fileTerm, ok := sideloadedFileTerm(filename)
+			if !ok {
+				// Leave files with unexpected names alone.
+				log.Infof(ctx, "unexpected file %s in sideloaded directory %s", filename, ss.dir)
+				return true, nil
+			}
+			t, err := term(index)
+			if err != nil {
+				return false, err
+			}
+			referenced = t == fileTerm
+		}
+		if referenced {
+			size, err := ss.fileSize(filename)
+			if err != nil {
+				return false, err
+			}
+			stats.Files++
+			stats.Bytes += size
+			return true, nil
+		}
+		size, err := ss.purgeFile(ctx, filename)
+		if errors.Is(err, errSideloadedFileNotFound) {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+		log.VEventf(ctx, 2, "removed orphaned sideloaded file %s (%d bytes)", filename, size)
+		stats.OrphanedFiles++
+		stats.OrphanedBytes += size
+		return true, nil
+	}); err != nil {
+		return SideloadReconcileStats{}, err
+	}
+	return stats, nil
+}
+
+// sideloadedFileTerm extracts the term from the name of a file created by
+// DiskSideloadStorage.Put, i.e. of the form "i<index>.t<term>".
+func sideloadedFileTerm(filename string) (kvpb.RaftTerm, bool) {
+	parts := strings.SplitN(filepath.Base(filename), ".", 2)
+	if len(parts) != 2 || len(parts[1]) < 1 || parts[1][0] != 't' {
+		return 0, false
+	}
+	term, err := strconv.ParseUint(parts[1][1:], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return kvpb.RaftTerm(term), true
+}
+
 // String lists the files in the storage without guaranteeing an ordering.
 func (ss *DiskSideloadStorage) String() string {
 	var buf strings.Builder
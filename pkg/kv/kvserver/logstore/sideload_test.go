@@ -651,6 +651,47 @@ func TestSideloadStorageSync(t *testing.T) {
 	})
 }
 
+func TestSideloadStorageReconcile(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+	ss := newTestingSideloadStorage(eng)
+
+	// The raft log contains entries [10, 12] at term 5.
+	put := func(index kvpb.RaftIndex, term kvpb.RaftTerm) {
+		require.NoError(t, ss.Put(ctx, index, term, []byte("payload")))
+	}
+	put(5, 4)  // below the first index, e.g. leaked by a truncation race
+	put(10, 5) // referenced
+	put(11, 4) // overwritten by an entry at a later term
+	put(12, 5) // referenced
+	put(15, 5) // above the last index, e.g. left behind by a crash
+	term := func(index kvpb.RaftIndex) (kvpb.RaftTerm, error) {
+		require.True(t, index >= 10 && index <= 12, "unexpected term lookup at %d", index)
+		return 5, nil
+	}
+
+	stats, err := ss.Reconcile(ctx, 10 /* first */, 12 /* last */, term)
+	require.NoError(t, err)
+	require.Equal(t, SideloadReconcileStats{
+		Files: 2, Bytes: 14, OrphanedFiles: 3, OrphanedBytes: 21,
+	}, stats)
+	for _, index := range []kvpb.RaftIndex{10, 12} {
+		_, err := ss.Get(ctx, index, 5)
+		require.NoError(t, err)
+	}
+	_, err = ss.Get(ctx, 11, 4)
+	require.ErrorIs(t, err, errSideloadedFileNotFound)
+
+	// Reconciling again finds no orphans.
+	stats, err = ss.Reconcile(ctx, 10 /* first */, 12 /* last */, term)
+	require.NoError(t, err)
+	require.Equal(t, SideloadReconcileStats{Files: 2, Bytes: 14}, stats)
+}
+
 func TestMkdirAllAndSyncParents(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
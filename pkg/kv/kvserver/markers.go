@@ -38,6 +38,7 @@ var errMarkCanRetryReplicationChangeWithUpdatedDesc = errors.New("should retry w
 func IsRetriableReplicationChangeError(err error) bool {
 	return errors.Is(err, errMarkCanRetryReplicationChangeWithUpdatedDesc) ||
 		IsLeaseTransferRejectedBecauseTargetMayNeedSnapshotError(err) ||
+		IsLeaseTransferRejectedBecauseTargetIsBehindError(err) ||
 		IsLeaseTransferRejectedBecauseTargetCannotReceiveLease(err) ||
 		isSnapshotError(err)
 }
@@ -108,6 +109,18 @@ func IsLeaseTransferRejectedBecauseTargetMayNeedSnapshotError(err error) bool {
 	return errors.Is(err, errMarkLeaseTransferRejectedBecauseTargetMayNeedSnapshot)
 }
 
+var errMarkLeaseTransferRejectedBecauseTargetIsBehind = errors.New(
+	"lease transfer rejected because the target is behind")
+
+// IsLeaseTransferRejectedBecauseTargetIsBehindError detects whether an error
+// (assumed to have been emitted by a lease transfer request) indicates that
+// the lease transfer failed because the lease transfer target was further
+// behind the current leaseholder's applied index than allowed by
+// kv.lease_transfer.max_target_lag.
+func IsLeaseTransferRejectedBecauseTargetIsBehindError(err error) bool {
+	return errors.Is(err, errMarkLeaseTransferRejectedBecauseTargetIsBehind)
+}
+
 // IsLeaseTransferRejectedBecauseTargetCannotReceiveLease returns true if err
 // (assumed to have been emitted by the current leaseholder when processing a
 // lease transfer request) indicates that the target replica is not qualified to
@@ -156,8 +156,14 @@ func (mq *mergeQueue) shouldQueue(
 		// with.
 		return false, 0
 	}
+	hashSharded := mq.store.cfg.HashShardedSpans.Get()
+	if hashSharded.SplitKey(desc.StartKey, desc.EndKey.Next()) != nil {
+		// The range ends at the boundary of a hash-sharded span or shard, which
+		// the split queue would split again.
+		return false, 0
+	}
 
-	sizeRatio := float64(repl.GetMVCCStats().Total()) / float64(repl.GetMinBytes(ctx))
+	sizeRatio := float64(repl.getSizeEstimate(ctx).Bytes) / float64(repl.GetMinBytes(ctx))
 	if math.IsNaN(sizeRatio) || sizeRatio >= 1 {
 		// This range is above the minimum size threshold. It does not need to be
 		// merged.
@@ -285,7 +291,7 @@ func (mq *mergeQueue) process(
 		}
 	}
 
-	shouldSplit, _ := shouldSplitRange(ctx, mergedDesc, mergedStats,
+	shouldSplit, _ := shouldSplitRange(ctx, mergedDesc, mergedStats.Total(),
 		lhsRepl.GetMaxBytes(ctx), lhsRepl.shouldBackpressureWrites(), confReader)
 	if shouldSplit {
 		log.VEventf(ctx, 2,
@@ -90,6 +90,12 @@ var (
 		Measurement: "Replicas",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaHibernatedCount = metric.Metadata{
+		Name:        "replicas.hibernated",
+		Help:        "Number of quiesced replicas which released their in-memory raft state",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaUninitializedCount = metric.Metadata{
 		Name:        "replicas.uninitialized",
 		Help:        "Number of uninitialized replicas, this does not include uninitialized replicas that can lie dormant in a persistent state.",
@@ -122,6 +128,18 @@ var (
 		Measurement: "Ranges",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaPinnedRangeCount = metric.Metadata{
+		Name:        "ranges.pinned",
+		Help:        "Number of ranges whose voters are pinned to stores",
+		Measurement: "Ranges",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaPinnedNonCompliantRangeCount = metric.Metadata{
+		Name:        "ranges.pinned.noncompliant",
+		Help:        "Number of ranges whose voters are not on the stores they are pinned to",
+		Measurement: "Ranges",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// Lease request metrics.
 	metaLeaseRequestSuccessCount = metric.Metadata{
@@ -142,6 +160,18 @@ var (
 		Measurement: "Latency",
 		Unit:        metric.Unit_NANOSECONDS,
 	}
+	metaLeaseAcquisitionsQueued = metric.Metadata{
+		Name:        "leases.acquisitions.queued",
+		Help:        "Number of lease acquisitions waiting for their turn under kv.lease.max_concurrent_acquisitions",
+		Measurement: "Lease Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLeaseAcquisitionsWaited = metric.Metadata{
+		Name:        "leases.acquisitions.waited",
+		Help:        "Number of lease acquisitions which waited for their turn under kv.lease.max_concurrent_acquisitions",
+		Measurement: "Lease Requests",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaLeaseTransferSuccessCount = metric.Metadata{
 		Name:        "leases.transfers.success",
 		Help:        "Number of successful lease transfers",
@@ -154,6 +184,18 @@ var (
 		Measurement: "Lease Transfers",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaLeaseTransferRejectedMayNeedSnapshotCount = metric.Metadata{
+		Name:        "leases.transfers.rejected.may_need_snapshot",
+		Help:        "Number of lease transfers rejected because the target may need a Raft snapshot",
+		Measurement: "Lease Transfers",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLeaseTransferRejectedTargetLagCount = metric.Metadata{
+		Name:        "leases.transfers.rejected.target_lag",
+		Help:        "Number of lease transfers rejected because the target trails the leaseholder's applied index by more than kv.lease_transfer.max_target_lag",
+		Measurement: "Lease Transfers",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaLeaseExpirationCount = metric.Metadata{
 		Name:        "leases.expiration",
 		Help:        "Number of replica leaseholders using expiration-based leases",
@@ -185,6 +227,13 @@ var (
 		Measurement: "Replicas",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaLeasePrewarmedBytes = metric.Metadata{
+		Name: "leases.prewarmed_bytes",
+		Help: "Number of bytes read from replicas which acquired the lease from another store " +
+			"to load their recently hot data into the block cache",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
 
 	// Storage metrics.
 	metaLiveBytes = metric.Metadata{
@@ -363,6 +412,27 @@ var (
 		Measurement: "Storage",
 		Unit:        metric.Unit_BYTES,
 	}
+	metaRaftApplyEntries = metric.Metadata{
+		Name: "raft.apply.entries",
+		Help: `Number of Raft entries applied to the state machine.
+
+The metric is labeled with the tenant owning the replicas, to audit the Raft
+application work done on behalf of each tenant.`,
+		Measurement: "Entries",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftApplyEntryBytes = metric.Metadata{
+		Name:        "raft.apply.entry_bytes",
+		Help:        "Total size of the Raft entries applied to the state machine, labeled by tenant",
+		Measurement: "Bytes",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaRaftApplyMutations = metric.Metadata{
+		Name:        "raft.apply.mutations",
+		Help:        "Number of keys mutated by the Raft entries applied to the state machine, labeled by tenant",
+		Measurement: "Keys",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// Metrics used by the rebalancing logic that aren't already captured elsewhere.
 	metaAverageQueriesPerSecond = metric.Metadata{
@@ -429,6 +499,13 @@ var (
 		Measurement: "Read Ops",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaFollowerReadsVerificationFailures = metric.Metadata{
+		Name: "follower_reads.verification_failures",
+		Help: "Number of follower reads evaluated on an engine state whose closed " +
+			"timestamp didn't cover the read",
+		Measurement: "Read Ops",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// Server-side transaction metrics.
 	metaCommitWaitBeforeCommitTriggerCount = metric.Metadata{
@@ -1011,6 +1088,12 @@ var (
 		Measurement: "Snapshots",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRangeSnapshotsLocalRcvd = metric.Metadata{
+		Name:        "range.snapshots.local-rcvd",
+		Help:        "Number of snapshots received from a store on the same node through the local fast path",
+		Measurement: "Snapshots",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaRangeSnapshotRcvdBytes = metric.Metadata{
 		Name:        "range.snapshots.rcvd-bytes",
 		Help:        "Number of snapshot bytes received",
@@ -1065,12 +1148,30 @@ var (
 		Measurement: "Snapshots",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRangeSnapshotRecvDrainingRejected = metric.Metadata{
+		Name:        "range.snapshots.recv-draining-rejected",
+		Help:        "Number of learner snapshots declined by the recipient because its store was draining",
+		Measurement: "Snapshots",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaRangeSnapshotRecvUnusable = metric.Metadata{
 		Name:        "range.snapshots.recv-unusable",
 		Help:        "Number of range snapshot that were fully transmitted but determined to be unnecessary or unusable",
 		Measurement: "Snapshots",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRangeSnapshotRecvAdvertisedRate = metric.Metadata{
+		Name:        "range.snapshots.recv-advertised-rate",
+		Help:        "Rate at which the store last asked the sender of a snapshot to send it, given the health of its LSM",
+		Measurement: "Bytes/Sec",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaRangeSnapshotRecvRateLimited = metric.Metadata{
+		Name:        "range.snapshots.recv-rate-limited",
+		Help:        "Number of snapshots the store asked to be sent below kv.snapshot_rebalance.max_rate due to the health of its LSM",
+		Measurement: "Snapshots",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaRangeSnapShotCrossRegionSentBytes = metric.Metadata{
 		Name:        "range.snapshots.cross-region.sent-bytes",
 		Help:        "Number of snapshot bytes sent cross region",
@@ -1289,6 +1390,26 @@ order, and hence can not be applied as is.`,
 		Measurement: "Commands",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRaftCommandsReevaluated = metric.Metadata{
+		Name: "raft.commands.reevaluated",
+		Help: `Number of Raft commands re-evaluated after being rejected by the closed timestamp.
+
+The number of local Raft commands which failed the lease applied index check,
+could not be re-proposed because the closed timestamp advanced past their write
+timestamp, and were evaluated again at a higher timestamp on the leaseholder
+instead of being redirected to the client.`,
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftCommandsDeduplicated = metric.Metadata{
+		Name: "raft.commands.deduplicated",
+		Help: `Number of Raft commands acknowledged as already applied.
+
+The number of local proposals rejected due to their LAI which were acknowledged
+instead of re-proposed, because another copy of the command had already applied.`,
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaRaftCommandsApplied = metric.Metadata{
 		Name: "raft.commandsapplied",
 		Help: `Number of Raft commands applied.
@@ -1302,6 +1423,78 @@ state machines on this node.`,
 		Measurement: "Commands",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRaftCommandsRejectedLeaseMismatch = metric.Metadata{
+		Name:        "raft.commands.rejected.lease-mismatch",
+		Help:        "Number of Raft commands rejected below raft because they were proposed under a different lease",
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftCommandsRejectedLeaseIndex = metric.Metadata{
+		Name:        "raft.commands.rejected.lease-index",
+		Help:        "Number of Raft commands rejected below raft because their lease applied index had already been consumed",
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftCommandsRejectedGCThreshold = metric.Metadata{
+		Name:        "raft.commands.rejected.gc-threshold",
+		Help:        "Number of Raft commands rejected below raft because they were writing at or below the GC threshold",
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftCommandsRejectedProposalRetry = metric.Metadata{
+		Name:        "raft.commands.rejected.proposal-retry",
+		Help:        "Number of Raft commands rejected below raft because of their lease applied index, which were proposed locally and are retried at a new lease applied index",
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftCommandsReproposalsDeferred = metric.Metadata{
+		Name:        "raft.commands.reproposed.deferred",
+		Help:        "Number of times the reproposal of a Raft command which failed to replicate was deferred, as it was backing off after its previous reproposals",
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftCommandsReproposalsRejected = metric.Metadata{
+		Name:        "raft.commands.reproposed.rejected",
+		Help:        "Number of Raft commands rejected with an ambiguous result instead of being reproposed, in excess of the reproposal budget of their replica",
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftApplyCheckFailuresAppliedIndex = metric.Metadata{
+		Name:        "raft.apply.check_failures.applied_index",
+		Help:        "Number of Raft commands whose index did not follow the applied index of their replica, see kv.raft.apply.sanity_checks",
+		Measurement: "Failures",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftApplyCheckFailuresCmdClosedTimestamp = metric.Metadata{
+		Name:        "raft.apply.check_failures.cmd_closed_timestamp",
+		Help:        "Number of Raft commands carrying a closed timestamp below the closed timestamp of their replica, see kv.raft.apply.sanity_checks",
+		Measurement: "Failures",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftApplyCheckFailuresBatchClosedTimestamp = metric.Metadata{
+		Name:        "raft.apply.check_failures.batch_closed_timestamp",
+		Help:        "Number of Raft application batches regressing the closed timestamp of their replica, see kv.raft.apply.sanity_checks",
+		Measurement: "Failures",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftApplyCheckFailuresWriteBelowClosedTimestamp = metric.Metadata{
+		Name:        "raft.apply.check_failures.write_below_closed_timestamp",
+		Help:        "Number of local Raft commands writing below the closed timestamp of their replica, see kv.raft.apply.sanity_checks",
+		Measurement: "Failures",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftApplyCheckFailuresLeaseAppliedIndex = metric.Metadata{
+		Name:        "raft.apply.check_failures.lease_applied_index",
+		Help:        "Number of Raft application batches regressing the lease applied index of their replica, see kv.raft.apply.sanity_checks",
+		Measurement: "Failures",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftApplyCheckFailuresStatsNonNegative = metric.Metadata{
+		Name:        "raft.apply.check_failures.stats_non_negative",
+		Help:        "Number of Raft application batches after which the MVCC stats of their replica were negative without being estimates, see kv.raft.apply.sanity_checks",
+		Measurement: "Failures",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaRaftLogCommitLatency = metric.Metadata{
 		Name: "raft.process.logcommit.latency",
 		Help: `Latency histogram for committing Raft log entries to stable storage
@@ -1375,6 +1568,29 @@ metric, which receives datapoints for each sub-batch processed in the process.`,
 		Measurement: "Latency",
 		Unit:        metric.Unit_NANOSECONDS,
 	}
+	metaRaftApplyBatchEntries = metric.Metadata{
+		Name: "raft.process.applybatch.entries",
+		Help: `Number of Raft entries applied in a batch to the state machine.
+
+The size of the batches is limited by the kv.raft.apply.max_batch_entries and
+kv.raft.apply.max_batch_bytes cluster settings, and by the adaptive shrinking of
+these limits under memory pressure.`,
+		Measurement: "Entries",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftApplyBatchBytes = metric.Metadata{
+		Name:        "raft.process.applybatch.bytes",
+		Help:        "Total size of the Raft entries applied in a batch to the state machine",
+		Measurement: "Bytes",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaRaftApplyBatchShrinkFactor = metric.Metadata{
+		Name: "raft.process.applybatch.shrink_factor",
+		Help: `Factor by which the limits on the size of Raft application batches are
+currently shrunk due to memory pressure (1 if they are not shrunk)`,
+		Measurement: "Factor",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaRaftReplicationLatency = metric.Metadata{
 		Name: "raft.replication.latency",
 		Help: `The duration elapsed between having evaluated a BatchRequest and it being
@@ -1401,6 +1617,19 @@ the actual replication latency, despite returning early to the client.`,
 		Measurement: "Latency",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRaftReplicationLagAlerts = metric.Metadata{
+		Name:        "raft.replication.latency_alerts",
+		Help:        "Number of times the proposal-to-apply latency of a range exceeded kv.replication.proposal_to_apply_latency.alert_threshold",
+		Measurement: "Events",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftApplyTimestampSkew = metric.Metadata{
+		Name: "raft.apply.timestamp_skew_exceeded",
+		Help: "Number of Raft commands applied with a write timestamp further ahead of the " +
+			"local clock than allowed by kv.raft.apply.timestamp_skew_check.tolerance",
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaRaftSchedulerLatency = metric.Metadata{
 		Name: "raft.scheduler.latency",
 		Help: `Queueing durations for ranges waiting to be processed by the Raft scheduler.
@@ -1418,6 +1647,18 @@ of processing.
 		Measurement: "Elections called after timeout",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRaftHibernationWakes = metric.Metadata{
+		Name:        "raft.hibernation.wakes",
+		Help:        "Number of hibernated replicas whose raft state was reconstructed",
+		Measurement: "Wakes",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftHibernationWakeLatency = metric.Metadata{
+		Name:        "raft.hibernation.wake.latency",
+		Help:        "Latency of reconstructing the raft state of a hibernated replica",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
 	metaRaftStorageReadBytes = metric.Metadata{
 		Name: "raft.storage.read_bytes",
 		Help: `Counter of raftpb.Entry.Size() read from pebble for raft log entries.
@@ -1639,6 +1880,167 @@ difficult to meaningfully interpret this metric.`,
 		Measurement: "Log Entries",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaCompactionHintsQueued = metric.Metadata{
+		Name: "kv.compaction_hints.queued",
+		Help: "Number of spans cleared by replica destruction or with fragmented MVCC range keys " +
+			"queued for a manual compaction",
+		Measurement: "Spans",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaCompactionHintsCompacted = metric.Metadata{
+		Name: "kv.compaction_hints.compacted",
+		Help: "Number of manual compactions of spans cleared by replica destruction or with " +
+			"fragmented MVCC range keys",
+		Measurement: "Compactions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaCompactionHintsFailed = metric.Metadata{
+		Name: "kv.compaction_hints.failed",
+		Help: "Number of failed manual compactions of spans cleared by replica destruction " +
+			"or with fragmented MVCC range keys",
+		Measurement: "Compactions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaCompactionHintsPendingBytes = metric.Metadata{
+		Name:        "kv.compaction_hints.pending_bytes",
+		Help:        "Logical size of the data cleared by replica destruction in spans pending a manual compaction",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaRangeKeyWritesApplied = metric.Metadata{
+		Name:        "kv.range_keys.writes_applied",
+		Help:        "Number of MVCC range key sets, unsets and deletions applied by replicas",
+		Measurement: "Range Keys",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeKeyOverlappingFragments = metric.Metadata{
+		Name: "kv.range_keys.overlapping_fragments",
+		Help: "Number of MVCC range key fragments overlapping the span of each applied " +
+			"MVCC range key write",
+		Measurement: "Fragments",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeKeyDefragmentationsQueued = metric.Metadata{
+		Name:        "kv.range_keys.defragmentations_queued",
+		Help:        "Number of replicas with fragmented MVCC range keys queued for a manual compaction",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaColdCompactions = metric.Metadata{
+		Name:        "kv.cold_compaction.compactions",
+		Help:        "Number of compactions of cold ranges into the bottom levels of the engine under their cold compaction policy",
+		Measurement: "Compactions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaColdCompactionsFailed = metric.Metadata{
+		Name:        "kv.cold_compaction.compactions.failed",
+		Help:        "Number of failed compactions of cold ranges into the bottom levels of the engine under their cold compaction policy",
+		Measurement: "Compactions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaColdCompactionSharedBytes = metric.Metadata{
+		Name:        "kv.cold_compaction.shared_bytes",
+		Help:        "Approximate on-disk size of the data of the ranges with a cold compaction policy that is on shared storage, as of the last scan",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaColdCompactionLocalBytes = metric.Metadata{
+		Name:        "kv.cold_compaction.local_bytes",
+		Help:        "Approximate on-disk size of the data of the ranges with a cold compaction policy that is on local storage, as of the last scan",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaHotStandbyReplicas = metric.Metadata{
+		Name:        "kv.hot_standby.replicas",
+		Help:        "Number of replicas on the store designated as the hot standby of their range",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaHotStandbyPrewarmedBytes = metric.Metadata{
+		Name:        "kv.hot_standby.prewarmed_bytes",
+		Help:        "Number of bytes read from hot standby replicas to load them into the block cache",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaHotStandbyFailovers = metric.Metadata{
+		Name: "kv.hot_standby.failovers",
+		Help: "Number of raft leadership transfers to the hot standby of a range " +
+			"after its lease expired, so that the standby acquires the lease",
+		Measurement: "Leadership Transfers",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaIntentResolutionCoalescedBatches = metric.Metadata{
+		Name:        "kv.intent_resolution.coalesced_batches",
+		Help:        "Number of intent resolution batches coalesced with others into a single raft proposal",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaStoreHealthScore = metric.Metadata{
+		Name:        "kv.store_health.score",
+		Help:        "Composed health score of the store, the maximum of its IO overload, apply backlog, raft scheduler queue depth and follower write rate, each normalized by its threshold (1 or more means overloaded)",
+		Measurement: "Score",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaStoreHealthApplyBacklog = metric.Metadata{
+		Name:        "kv.store_health.apply_backlog",
+		Help:        "Number of committed raft entries of the replicas of the store which are not yet applied, as of the last health computation",
+		Measurement: "Entries",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaStoreHealthRaftSchedulerQueueDepth = metric.Metadata{
+		Name:        "kv.store_health.raft_scheduler_queue_depth",
+		Help:        "Number of ranges waiting in the queues of the raft scheduler, as of the last health computation",
+		Measurement: "Ranges",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaStoreHealthFollowerWriteBytes = metric.Metadata{
+		Name:        "kv.store_health.follower_write_bytes_per_second",
+		Help:        "Rate at which the store writes the commands of the ranges it is a follower of, since the previous health computation",
+		Measurement: "Bytes/Sec",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaRaftLogSideloadedBytes = metric.Metadata{
+		Name:        "raftlog.sideloaded.bytes",
+		Help:        "Total size of the sideloaded Raft log payloads on this store, as of the last reconciliation pass",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaRaftLogSideloadedOrphanedFiles = metric.Metadata{
+		Name:        "raftlog.sideloaded.orphaned.files",
+		Help:        "Number of sideloaded Raft log payload files removed because they were not referenced by the Raft log",
+		Measurement: "Files",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftLogSideloadedOrphanedBytes = metric.Metadata{
+		Name:        "raftlog.sideloaded.orphaned.bytes",
+		Help:        "Total size of the sideloaded Raft log payload files removed because they were not referenced by the Raft log",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaRaftLogSideloadedVerifiedBytes = metric.Metadata{
+		Name:        "raftlog.sideloaded.verified.bytes",
+		Help:        "Total size of the sideloaded Raft log payloads whose checksums were verified in the background",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaRaftLogSideloadedCorruptFiles = metric.Metadata{
+		Name:        "raftlog.sideloaded.corrupt.files",
+		Help:        "Number of sideloaded Raft log payload files found corrupted by the background verification of their checksums",
+		Measurement: "Files",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeIDLocalOrphanedRanges = metric.Metadata{
+		Name:        "range_id_local.orphaned.ranges",
+		Help:        "Number of ranges without a replica on the store whose orphaned range-ID local data was removed",
+		Measurement: "Ranges",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeIDLocalOrphanedKeys = metric.Metadata{
+		Name:        "range_id_local.orphaned.keys",
+		Help:        "Number of orphaned range-ID local keys removed from ranges without a replica on the store",
+		Measurement: "Keys",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	metaRaftFollowerPaused = metric.Metadata{
 		Name: "admission.raft.paused_replicas",
@@ -1801,6 +2203,24 @@ The messages are dropped to help these replicas to recover from I/O overload.`,
 		Measurement: "Processing Time",
 		Unit:        metric.Unit_NANOSECONDS,
 	}
+	metaMaintenanceQueueBudgetCPUNanos = metric.Metadata{
+		Name:        "queue.maintenance_budget.cpu_nanos",
+		Help:        "CPU time charged to the processing budget of maintenance queues",
+		Measurement: "CPU Time",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaMaintenanceQueueBudgetScanBytes = metric.Metadata{
+		Name:        "queue.maintenance_budget.scan_bytes",
+		Help:        "Bytes of range data charged to the processing budget of maintenance queues",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaMaintenanceQueueBudgetWaitNanos = metric.Metadata{
+		Name:        "queue.maintenance_budget.wait_nanos",
+		Help:        "Time spent by maintenance queues waiting for their processing budget",
+		Measurement: "Wait Time",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
 	metaReplicaGCQueueSuccesses = metric.Metadata{
 		Name:        "queue.replicagc.process.success",
 		Help:        "Number of replicas successfully processed by the replica GC queue",
@@ -2031,6 +2451,13 @@ The messages are dropped to help these replicas to recover from I/O overload.`,
 		Measurement: "Replicas",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaGCMaxVersionsThresholdRaised = metric.Metadata{
+		Name: "queue.gc.info.maxversionsthresholdraised",
+		Help: "Number of GC runs which raised the GC threshold past the GC TTL to " +
+			"enforce gc.max_versions",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// Slow request metrics.
 	metaLatchRequests = metric.Metadata{
@@ -2118,6 +2545,16 @@ These AddSSTable requests do not count towards the addsstable metrics
 'proposals', 'applications', or 'copies', as they are not ingested as AddSSTable
 Raft commands, but rather normal write commands. However, if these requests get
 throttled they do count towards 'delay.total' and 'delay.enginebackpressure'.
+`,
+		Measurement: "Ingestions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaAddSSTableDeduplicated = metric.Metadata{
+		Name: "addsstable.deduplicated",
+		Help: `Number of AddSSTable requests skipped since an identical SSTable was already
+ingested into the same span at the same timestamp.
+
+These are typically retries of requests which failed with ambiguous errors.
 `,
 		Measurement: "Ingestions",
 		Unit:        metric.Unit_COUNT,
@@ -2321,13 +2758,16 @@ type StoreMetrics struct {
 	RaftLeaderInvalidLeaseCount   *metric.Gauge
 	LeaseHolderCount              *metric.Gauge
 	QuiescentCount                *metric.Gauge
+	HibernatedCount               *metric.Gauge
 	UninitializedCount            *metric.Gauge
 
 	// Range metrics.
-	RangeCount                *metric.Gauge
-	UnavailableRangeCount     *metric.Gauge
-	UnderReplicatedRangeCount *metric.Gauge
-	OverReplicatedRangeCount  *metric.Gauge
+	RangeCount                   *metric.Gauge
+	UnavailableRangeCount        *metric.Gauge
+	UnderReplicatedRangeCount    *metric.Gauge
+	OverReplicatedRangeCount     *metric.Gauge
+	PinnedRangeCount             *metric.Gauge
+	PinnedNonCompliantRangeCount *metric.Gauge
 
 	// Lease request metrics for successful and failed lease requests. These
 	// count proposals (i.e. it does not matter how many replicas apply the
@@ -2335,6 +2775,8 @@ type StoreMetrics struct {
 	LeaseRequestSuccessCount       *metric.Counter
 	LeaseRequestErrorCount         *metric.Counter
 	LeaseRequestLatency            metric.IHistogram
+	LeaseAcquisitionsQueued        *metric.Gauge
+	LeaseAcquisitionsWaited        *metric.Counter
 	LeaseTransferSuccessCount      *metric.Counter
 	LeaseTransferErrorCount        *metric.Counter
 	LeaseExpirationCount           *metric.Gauge
@@ -2342,6 +2784,11 @@ type StoreMetrics struct {
 	LeaseLivenessCount             *metric.Gauge
 	LeaseViolatingPreferencesCount *metric.Gauge
 	LeaseLessPreferredCount        *metric.Gauge
+	LeasePrewarmedBytes            *metric.Counter
+
+	// Lease transfers rejected by the current leaseholder, by reason.
+	LeaseTransferRejectedMayNeedSnapshotCount *metric.Counter
+	LeaseTransferRejectedTargetLagCount       *metric.Counter
 
 	// Storage metrics.
 	ResolveCommitCount *metric.Counter
@@ -2375,7 +2822,8 @@ type StoreMetrics struct {
 	}
 
 	// Follower read metrics.
-	FollowerReadsCount *metric.Counter
+	FollowerReadsCount                *metric.Counter
+	FollowerReadsVerificationFailures *metric.Counter
 
 	// Server-side transaction metrics.
 	CommitWaitsBeforeCommitTrigger                           *metric.Counter
@@ -2492,6 +2940,7 @@ type StoreMetrics struct {
 	RangeSnapshotsAppliedByVoters                *metric.Counter
 	RangeSnapshotsAppliedForInitialUpreplication *metric.Counter
 	RangeSnapshotsAppliedByNonVoters             *metric.Counter
+	RangeSnapshotsLocalRcvd                      *metric.Counter
 	RangeSnapshotRcvdBytes                       *metric.Counter
 	RangeSnapshotSentBytes                       *metric.Counter
 	RangeSnapshotUnknownRcvdBytes                *metric.Counter
@@ -2501,7 +2950,10 @@ type StoreMetrics struct {
 	RangeSnapshotRebalancingRcvdBytes            *metric.Counter
 	RangeSnapshotRebalancingSentBytes            *metric.Counter
 	RangeSnapshotRecvFailed                      *metric.Counter
+	RangeSnapshotRecvDrainingRejected            *metric.Counter
 	RangeSnapshotRecvUnusable                    *metric.Counter
+	RangeSnapshotRecvAdvertisedRate              *metric.Gauge
+	RangeSnapshotRecvRateLimited                 *metric.Counter
 	RangeSnapShotCrossRegionSentBytes            *metric.Counter
 	RangeSnapShotCrossRegionRcvdBytes            *metric.Counter
 	RangeSnapShotCrossZoneSentBytes              *metric.Counter
@@ -2533,19 +2985,43 @@ type StoreMetrics struct {
 	RaftCommandsProposed       *metric.Counter
 	RaftCommandsReproposed     *metric.Counter
 	RaftCommandsReproposedLAI  *metric.Counter
+	RaftCommandsReevaluated    *metric.Counter
+	RaftCommandsDeduplicated   *metric.Counter
 	RaftCommandsApplied        *metric.Counter
 	RaftLogCommitLatency       metric.IHistogram
 	RaftCommandCommitLatency   metric.IHistogram
 	RaftHandleReadyLatency     metric.IHistogram
 	RaftApplyCommittedLatency  metric.IHistogram
+	RaftApplyBatchEntries      metric.IHistogram
+	RaftApplyBatchBytes        metric.IHistogram
+	RaftApplyBatchShrinkFactor *metric.Gauge
 	RaftReplicationLatency     metric.IHistogram
+	RaftReplicationLagAlerts   *metric.Counter
+	RaftApplyTimestampSkew     *metric.Counter
 	RaftSchedulerLatency       metric.IHistogram
 	RaftTimeoutCampaign        *metric.Counter
+	RaftHibernationWakes       *metric.Counter
+	RaftHibernationWakeLatency metric.IHistogram
 	RaftStorageReadBytes       *metric.Counter
 	RaftStorageError           *metric.Counter
 	WALBytesWritten            *metric.Gauge
 	WALBytesIn                 *metric.Gauge
 
+	// Raft commands rejected below raft, by reason.
+	RaftCommandsRejectedLeaseMismatch *metric.Counter
+	RaftCommandsRejectedLeaseIndex    *metric.Counter
+	RaftCommandsRejectedGCThreshold   *metric.Counter
+	RaftCommandsRejectedProposalRetry *metric.Counter
+
+	// Reproposals of Raft commands deferred by their backoff, or rejected by
+	// the reproposal budget.
+	RaftCommandsReproposalsDeferred *metric.Counter
+	RaftCommandsReproposalsRejected *metric.Counter
+
+	// Failures of the sanity checks of the application of Raft commands, by
+	// applyCheck.
+	RaftApplyCheckFailures [numApplyChecks]*metric.Counter
+
 	// Raft message metrics.
 	//
 	// An array for conveniently finding the appropriate metric.
@@ -2565,6 +3041,45 @@ type StoreMetrics struct {
 	RaftLogFollowerBehindCount *metric.Gauge
 	RaftLogTruncated           *metric.Counter
 
+	RaftLogSideloadedBytes         *metric.Gauge
+	RaftLogSideloadedOrphanedFiles *metric.Counter
+	RaftLogSideloadedOrphanedBytes *metric.Counter
+	RaftLogSideloadedVerifiedBytes *metric.Counter
+	RaftLogSideloadedCorruptFiles  *metric.Counter
+
+	RangeIDLocalOrphanedRanges *metric.Counter
+	RangeIDLocalOrphanedKeys   *metric.Counter
+
+	// Compaction hint metrics.
+	CompactionHintsQueued       *metric.Counter
+	CompactionHintsCompacted    *metric.Counter
+	CompactionHintsFailed       *metric.Counter
+	CompactionHintsPendingBytes *metric.Gauge
+
+	// MVCC range key fragmentation metrics.
+	RangeKeyWritesApplied          *metric.Counter
+	RangeKeyOverlappingFragments   metric.IHistogram
+	RangeKeyDefragmentationsQueued *metric.Counter
+
+	// Cold compaction metrics.
+	ColdCompactions           *metric.Counter
+	ColdCompactionsFailed     *metric.Counter
+	ColdCompactionSharedBytes *metric.Gauge
+	ColdCompactionLocalBytes  *metric.Gauge
+
+	// Hot standby metrics.
+	HotStandbyReplicas       *metric.Gauge
+	HotStandbyPrewarmedBytes *metric.Counter
+	HotStandbyFailovers      *metric.Counter
+
+	IntentResolutionCoalescedBatches *metric.Counter
+
+	// Store health metrics.
+	StoreHealthScore                   *metric.GaugeFloat64
+	StoreHealthApplyBacklog            *metric.Gauge
+	StoreHealthRaftSchedulerQueueDepth *metric.Gauge
+	StoreHealthFollowerWriteBytes      *metric.Gauge
+
 	RaftPausedFollowerCount       *metric.Gauge
 	RaftPausedFollowerDroppedMsgs *metric.Counter
 	IOOverload                    *metric.GaugeFloat64
@@ -2594,6 +3109,9 @@ type StoreMetrics struct {
 	ConsistencyQueueFailures                  *metric.Counter
 	ConsistencyQueuePending                   *metric.Gauge
 	ConsistencyQueueProcessingNanos           *metric.Counter
+	MaintenanceQueueBudgetCPUNanos            *metric.Counter
+	MaintenanceQueueBudgetScanBytes           *metric.Counter
+	MaintenanceQueueBudgetWaitNanos           *metric.Counter
 	ReplicaGCQueueSuccesses                   *metric.Counter
 	ReplicaGCQueueFailures                    *metric.Counter
 	ReplicaGCQueuePending                     *metric.Gauge
@@ -2636,6 +3154,9 @@ type StoreMetrics struct {
 	GCUsedClearRange          *metric.Counter
 	GCFailedClearRange        *metric.Counter
 	GCEnqueueHighPriority     *metric.Counter
+	// GC runs which raised the GC threshold to retain at most gc.max_versions
+	// versions of every key.
+	GCMaxVersionsThresholdRaised *metric.Counter
 
 	// Slow request counts.
 	SlowLatchRequests *metric.Gauge
@@ -2651,6 +3172,7 @@ type StoreMetrics struct {
 	AddSSTableApplications        *metric.Counter
 	AddSSTableApplicationCopies   *metric.Counter
 	AddSSTableAsWrites            *metric.Counter
+	AddSSTableDeduplicated        *metric.Counter
 	AddSSTableProposalTotalDelay  *metric.Counter
 	AddSSTableProposalEngineDelay *metric.Counter
 
@@ -2747,6 +3269,11 @@ type TenantsStorageMetrics struct {
 	SysCount       *aggmetric.AggGauge
 	AbortSpanBytes *aggmetric.AggGauge
 
+	// Raft application metrics, attributed to the tenant owning the replica.
+	RaftApplyEntries    *aggmetric.AggCounter
+	RaftApplyEntryBytes *aggmetric.AggCounter
+	RaftApplyMutations  *aggmetric.AggCounter
+
 	// This struct is invisible to the metric package.
 	//
 	// NB: note that the int64 conversion in this map is lossless, so
@@ -2780,6 +3307,10 @@ func tenantsStorageMetricsSet() map[string]struct{} {
 		metaSysBytes.Name:       {},
 		metaSysCount.Name:       {},
 		metaAbortSpanBytes.Name: {},
+
+		metaRaftApplyEntries.Name:    {},
+		metaRaftApplyEntryBytes.Name: {},
+		metaRaftApplyMutations.Name:  {},
 	}
 }
 
@@ -2848,6 +3379,9 @@ func (sm *TenantsStorageMetrics) acquireTenant(tenantID roachpb.TenantID) *tenan
 			m.SysBytes = sm.SysBytes.AddChild(tenantIDStr)
 			m.SysCount = sm.SysCount.AddChild(tenantIDStr)
 			m.AbortSpanBytes = sm.AbortSpanBytes.AddChild(tenantIDStr)
+			m.RaftApplyEntries = sm.RaftApplyEntries.AddChild(tenantIDStr)
+			m.RaftApplyEntryBytes = sm.RaftApplyEntryBytes.AddChild(tenantIDStr)
+			m.RaftApplyMutations = sm.RaftApplyMutations.AddChild(tenantIDStr)
 			m.mu.Unlock()
 			return &tenantMetricsRef{
 				_tenantID: tenantID,
@@ -2907,6 +3441,15 @@ func (sm *TenantsStorageMetrics) releaseTenant(ctx context.Context, ref *tenantM
 		(*gptr).Unlink()
 		*gptr = nil
 	}
+	for _, cptr := range []**aggmetric.Counter{
+		&m.RaftApplyEntries,
+		&m.RaftApplyEntryBytes,
+		&m.RaftApplyMutations,
+	} {
+		// The counters remain accounted for in their parents.
+		(*cptr).Unlink()
+		*cptr = nil
+	}
 	sm.tenants.Delete(int64(ref._tenantID.ToUint64()))
 }
 
@@ -2950,6 +3493,10 @@ type tenantStorageMetrics struct {
 	SysBytes       *aggmetric.Gauge
 	SysCount       *aggmetric.Gauge
 	AbortSpanBytes *aggmetric.Gauge
+
+	RaftApplyEntries    *aggmetric.Counter
+	RaftApplyEntryBytes *aggmetric.Counter
+	RaftApplyMutations  *aggmetric.Counter
 }
 
 func newTenantsStorageMetrics() *TenantsStorageMetrics {
@@ -2975,6 +3522,10 @@ func newTenantsStorageMetrics() *TenantsStorageMetrics {
 		SysBytes:       b.Gauge(metaSysBytes),
 		SysCount:       b.Gauge(metaSysCount),
 		AbortSpanBytes: b.Gauge(metaAbortSpanBytes),
+
+		RaftApplyEntries:    b.Counter(metaRaftApplyEntries),
+		RaftApplyEntryBytes: b.Counter(metaRaftApplyEntryBytes),
+		RaftApplyMutations:  b.Counter(metaRaftApplyMutations),
 	}
 	return sm
 }
@@ -3001,13 +3552,16 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		RaftLeaderInvalidLeaseCount:   metric.NewGauge(metaRaftLeaderInvalidLeaseCount),
 		LeaseHolderCount:              metric.NewGauge(metaLeaseHolderCount),
 		QuiescentCount:                metric.NewGauge(metaQuiescentCount),
+		HibernatedCount:               metric.NewGauge(metaHibernatedCount),
 		UninitializedCount:            metric.NewGauge(metaUninitializedCount),
 
 		// Range metrics.
-		RangeCount:                metric.NewGauge(metaRangeCount),
-		UnavailableRangeCount:     metric.NewGauge(metaUnavailableRangeCount),
-		UnderReplicatedRangeCount: metric.NewGauge(metaUnderReplicatedRangeCount),
-		OverReplicatedRangeCount:  metric.NewGauge(metaOverReplicatedRangeCount),
+		RangeCount:                   metric.NewGauge(metaRangeCount),
+		UnavailableRangeCount:        metric.NewGauge(metaUnavailableRangeCount),
+		UnderReplicatedRangeCount:    metric.NewGauge(metaUnderReplicatedRangeCount),
+		OverReplicatedRangeCount:     metric.NewGauge(metaOverReplicatedRangeCount),
+		PinnedRangeCount:             metric.NewGauge(metaPinnedRangeCount),
+		PinnedNonCompliantRangeCount: metric.NewGauge(metaPinnedNonCompliantRangeCount),
 
 		// Lease request metrics.
 		LeaseRequestSuccessCount: metric.NewCounter(metaLeaseRequestSuccessCount),
@@ -3018,6 +3572,8 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 			Duration:     histogramWindow,
 			BucketConfig: metric.IOLatencyBuckets,
 		}),
+		LeaseAcquisitionsQueued:        metric.NewGauge(metaLeaseAcquisitionsQueued),
+		LeaseAcquisitionsWaited:        metric.NewCounter(metaLeaseAcquisitionsWaited),
 		LeaseTransferSuccessCount:      metric.NewCounter(metaLeaseTransferSuccessCount),
 		LeaseTransferErrorCount:        metric.NewCounter(metaLeaseTransferErrorCount),
 		LeaseExpirationCount:           metric.NewGauge(metaLeaseExpirationCount),
@@ -3025,6 +3581,10 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		LeaseLivenessCount:             metric.NewGauge(metaLeaseLivenessCount),
 		LeaseViolatingPreferencesCount: metric.NewGauge(metaLeaseViolatingPreferencesCount),
 		LeaseLessPreferredCount:        metric.NewGauge(metaLeaseLessPreferredCount),
+		LeasePrewarmedBytes:            metric.NewCounter(metaLeasePrewarmedBytes),
+
+		LeaseTransferRejectedMayNeedSnapshotCount: metric.NewCounter(metaLeaseTransferRejectedMayNeedSnapshotCount),
+		LeaseTransferRejectedTargetLagCount:       metric.NewCounter(metaLeaseTransferRejectedTargetLagCount),
 
 		// Intent resolution metrics.
 		ResolveCommitCount: metric.NewCounter(metaResolveCommit),
@@ -3058,7 +3618,8 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		),
 
 		// Follower reads metrics.
-		FollowerReadsCount: metric.NewCounter(metaFollowerReadsCount),
+		FollowerReadsCount:                metric.NewCounter(metaFollowerReadsCount),
+		FollowerReadsVerificationFailures: metric.NewCounter(metaFollowerReadsVerificationFailures),
 
 		// Server-side transaction metrics.
 		CommitWaitsBeforeCommitTrigger:                           metric.NewCounter(metaCommitWaitBeforeCommitTriggerCount),
@@ -3167,6 +3728,7 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		RangeSnapshotsAppliedByVoters: metric.NewCounter(metaRangeSnapshotsAppliedByVoters),
 		RangeSnapshotsAppliedForInitialUpreplication: metric.NewCounter(metaRangeSnapshotsAppliedForInitialUpreplication),
 		RangeSnapshotsAppliedByNonVoters:             metric.NewCounter(metaRangeSnapshotsAppliedByNonVoter),
+		RangeSnapshotsLocalRcvd:                      metric.NewCounter(metaRangeSnapshotsLocalRcvd),
 		RangeSnapshotRcvdBytes:                       metric.NewCounter(metaRangeSnapshotRcvdBytes),
 		RangeSnapshotSentBytes:                       metric.NewCounter(metaRangeSnapshotSentBytes),
 		RangeSnapshotUnknownRcvdBytes:                metric.NewCounter(metaRangeSnapshotUnknownRcvdBytes),
@@ -3176,7 +3738,10 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		RangeSnapshotRebalancingRcvdBytes:            metric.NewCounter(metaRangeSnapshotRebalancingRcvdBytes),
 		RangeSnapshotRebalancingSentBytes:            metric.NewCounter(metaRangeSnapshotRebalancingSentBytes),
 		RangeSnapshotRecvFailed:                      metric.NewCounter(metaRangeSnapshotRecvFailed),
+		RangeSnapshotRecvDrainingRejected:            metric.NewCounter(metaRangeSnapshotRecvDrainingRejected),
 		RangeSnapshotRecvUnusable:                    metric.NewCounter(metaRangeSnapshotRecvUnusable),
+		RangeSnapshotRecvAdvertisedRate:              metric.NewGauge(metaRangeSnapshotRecvAdvertisedRate),
+		RangeSnapshotRecvRateLimited:                 metric.NewCounter(metaRangeSnapshotRecvRateLimited),
 		RangeSnapShotCrossRegionSentBytes:            metric.NewCounter(metaRangeSnapShotCrossRegionSentBytes),
 		RangeSnapShotCrossRegionRcvdBytes:            metric.NewCounter(metaRangeSnapShotCrossRegionRcvdBytes),
 		RangeSnapShotCrossZoneSentBytes:              metric.NewCounter(metaRangeSnapShotCrossZoneSentBytes),
@@ -3213,6 +3778,8 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		RaftCommandsProposed:      metric.NewCounter(metaRaftCommandsProposed),
 		RaftCommandsReproposed:    metric.NewCounter(metaRaftCommandsReproposed),
 		RaftCommandsReproposedLAI: metric.NewCounter(metaRaftCommandsReproposedLAI),
+		RaftCommandsReevaluated:   metric.NewCounter(metaRaftCommandsReevaluated),
+		RaftCommandsDeduplicated:  metric.NewCounter(metaRaftCommandsDeduplicated),
 		RaftCommandsApplied:       metric.NewCounter(metaRaftCommandsApplied),
 		RaftLogCommitLatency: metric.NewHistogram(metric.HistogramOptions{
 			Mode:         metric.HistogramModePreferHdrLatency,
@@ -3238,12 +3805,25 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 			Duration:     histogramWindow,
 			BucketConfig: metric.IOLatencyBuckets,
 		}),
+		RaftApplyBatchEntries: metric.NewHistogram(metric.HistogramOptions{
+			Metadata:     metaRaftApplyBatchEntries,
+			Duration:     histogramWindow,
+			BucketConfig: metric.DataCount16MBuckets,
+		}),
+		RaftApplyBatchBytes: metric.NewHistogram(metric.HistogramOptions{
+			Metadata:     metaRaftApplyBatchBytes,
+			Duration:     histogramWindow,
+			BucketConfig: metric.DataSize16MBBuckets,
+		}),
+		RaftApplyBatchShrinkFactor: metric.NewGauge(metaRaftApplyBatchShrinkFactor),
 		RaftReplicationLatency: metric.NewHistogram(metric.HistogramOptions{
 			Mode:         metric.HistogramModePrometheus,
 			Metadata:     metaRaftReplicationLatency,
 			Duration:     histogramWindow,
 			BucketConfig: metric.IOLatencyBuckets,
 		}),
+		RaftReplicationLagAlerts: metric.NewCounter(metaRaftReplicationLagAlerts),
+		RaftApplyTimestampSkew:   metric.NewCounter(metaRaftApplyTimestampSkew),
 		RaftSchedulerLatency: metric.NewHistogram(metric.HistogramOptions{
 			Mode:         metric.HistogramModePreferHdrLatency,
 			Metadata:     metaRaftSchedulerLatency,
@@ -3251,9 +3831,33 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 			BucketConfig: metric.IOLatencyBuckets,
 		}),
 		RaftTimeoutCampaign:  metric.NewCounter(metaRaftTimeoutCampaign),
+		RaftHibernationWakes: metric.NewCounter(metaRaftHibernationWakes),
+		RaftHibernationWakeLatency: metric.NewHistogram(metric.HistogramOptions{
+			Mode:         metric.HistogramModePreferHdrLatency,
+			Metadata:     metaRaftHibernationWakeLatency,
+			Duration:     histogramWindow,
+			BucketConfig: metric.IOLatencyBuckets,
+		}),
 		RaftStorageReadBytes: metric.NewCounter(metaRaftStorageReadBytes),
 		RaftStorageError:     metric.NewCounter(metaRaftStorageError),
 
+		RaftCommandsRejectedLeaseMismatch: metric.NewCounter(metaRaftCommandsRejectedLeaseMismatch),
+		RaftCommandsRejectedLeaseIndex:    metric.NewCounter(metaRaftCommandsRejectedLeaseIndex),
+		RaftCommandsRejectedGCThreshold:   metric.NewCounter(metaRaftCommandsRejectedGCThreshold),
+		RaftCommandsRejectedProposalRetry: metric.NewCounter(metaRaftCommandsRejectedProposalRetry),
+
+		RaftCommandsReproposalsDeferred: metric.NewCounter(metaRaftCommandsReproposalsDeferred),
+		RaftCommandsReproposalsRejected: metric.NewCounter(metaRaftCommandsReproposalsRejected),
+
+		RaftApplyCheckFailures: [numApplyChecks]*metric.Counter{
+			applyCheckAppliedIndex:              metric.NewCounter(metaRaftApplyCheckFailuresAppliedIndex),
+			applyCheckCmdClosedTimestamp:        metric.NewCounter(metaRaftApplyCheckFailuresCmdClosedTimestamp),
+			applyCheckBatchClosedTimestamp:      metric.NewCounter(metaRaftApplyCheckFailuresBatchClosedTimestamp),
+			applyCheckWriteBelowClosedTimestamp: metric.NewCounter(metaRaftApplyCheckFailuresWriteBelowClosedTimestamp),
+			applyCheckLeaseAppliedIndex:         metric.NewCounter(metaRaftApplyCheckFailuresLeaseAppliedIndex),
+			applyCheckStatsNonNegative:          metric.NewCounter(metaRaftApplyCheckFailuresStatsNonNegative),
+		},
+
 		// Raft message metrics.
 		RaftRcvdMessages: [maxRaftMsgType + 1]*metric.Counter{
 			raftpb.MsgProp:           metric.NewCounter(metaRaftRcvdProp),
@@ -3284,6 +3888,46 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		RaftLogFollowerBehindCount: metric.NewGauge(metaRaftLogFollowerBehindCount),
 		RaftLogTruncated:           metric.NewCounter(metaRaftLogTruncated),
 
+		RaftLogSideloadedBytes:         metric.NewGauge(metaRaftLogSideloadedBytes),
+		RaftLogSideloadedOrphanedFiles: metric.NewCounter(metaRaftLogSideloadedOrphanedFiles),
+		RaftLogSideloadedOrphanedBytes: metric.NewCounter(metaRaftLogSideloadedOrphanedBytes),
+		RaftLogSideloadedVerifiedBytes: metric.NewCounter(metaRaftLogSideloadedVerifiedBytes),
+		RaftLogSideloadedCorruptFiles:  metric.NewCounter(metaRaftLogSideloadedCorruptFiles),
+
+		RangeIDLocalOrphanedRanges: metric.NewCounter(metaRangeIDLocalOrphanedRanges),
+		RangeIDLocalOrphanedKeys:   metric.NewCounter(metaRangeIDLocalOrphanedKeys),
+
+		CompactionHintsQueued:       metric.NewCounter(metaCompactionHintsQueued),
+		CompactionHintsCompacted:    metric.NewCounter(metaCompactionHintsCompacted),
+		CompactionHintsFailed:       metric.NewCounter(metaCompactionHintsFailed),
+		CompactionHintsPendingBytes: metric.NewGauge(metaCompactionHintsPendingBytes),
+
+		RangeKeyWritesApplied: metric.NewCounter(metaRangeKeyWritesApplied),
+		RangeKeyOverlappingFragments: metric.NewHistogram(metric.HistogramOptions{
+			Metadata:     metaRangeKeyOverlappingFragments,
+			Duration:     histogramWindow,
+			MaxVal:       maxRangeKeyFragmentsCounted,
+			SigFigs:      1,
+			BucketConfig: metric.Count1KBuckets,
+		}),
+		RangeKeyDefragmentationsQueued: metric.NewCounter(metaRangeKeyDefragmentationsQueued),
+
+		ColdCompactions:           metric.NewCounter(metaColdCompactions),
+		ColdCompactionsFailed:     metric.NewCounter(metaColdCompactionsFailed),
+		ColdCompactionSharedBytes: metric.NewGauge(metaColdCompactionSharedBytes),
+		ColdCompactionLocalBytes:  metric.NewGauge(metaColdCompactionLocalBytes),
+
+		HotStandbyReplicas:       metric.NewGauge(metaHotStandbyReplicas),
+		HotStandbyPrewarmedBytes: metric.NewCounter(metaHotStandbyPrewarmedBytes),
+		HotStandbyFailovers:      metric.NewCounter(metaHotStandbyFailovers),
+
+		IntentResolutionCoalescedBatches: metric.NewCounter(metaIntentResolutionCoalescedBatches),
+
+		StoreHealthScore:                   metric.NewGaugeFloat64(metaStoreHealthScore),
+		StoreHealthApplyBacklog:            metric.NewGauge(metaStoreHealthApplyBacklog),
+		StoreHealthRaftSchedulerQueueDepth: metric.NewGauge(metaStoreHealthRaftSchedulerQueueDepth),
+		StoreHealthFollowerWriteBytes:      metric.NewGauge(metaStoreHealthFollowerWriteBytes),
+
 		RaftPausedFollowerCount:       metric.NewGauge(metaRaftFollowerPaused),
 		RaftPausedFollowerDroppedMsgs: metric.NewCounter(metaRaftPausedFollowerDroppedMsgs),
 		IOOverload:                    metric.NewGaugeFloat64(metaIOOverload),
@@ -3315,6 +3959,9 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		ConsistencyQueueFailures:                  metric.NewCounter(metaConsistencyQueueFailures),
 		ConsistencyQueuePending:                   metric.NewGauge(metaConsistencyQueuePending),
 		ConsistencyQueueProcessingNanos:           metric.NewCounter(metaConsistencyQueueProcessingNanos),
+		MaintenanceQueueBudgetCPUNanos:            metric.NewCounter(metaMaintenanceQueueBudgetCPUNanos),
+		MaintenanceQueueBudgetScanBytes:           metric.NewCounter(metaMaintenanceQueueBudgetScanBytes),
+		MaintenanceQueueBudgetWaitNanos:           metric.NewCounter(metaMaintenanceQueueBudgetWaitNanos),
 		ReplicaGCQueueSuccesses:                   metric.NewCounter(metaReplicaGCQueueSuccesses),
 		ReplicaGCQueueFailures:                    metric.NewCounter(metaReplicaGCQueueFailures),
 		ReplicaGCQueuePending:                     metric.NewGauge(metaReplicaGCQueuePending),
@@ -3355,6 +4002,7 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		GCUsedClearRange:             metric.NewCounter(metaGCUsedClearRange),
 		GCFailedClearRange:           metric.NewCounter(metaGCFailedClearRange),
 		GCEnqueueHighPriority:        metric.NewCounter(metaGCEnqueueHighPriority),
+		GCMaxVersionsThresholdRaised: metric.NewCounter(metaGCMaxVersionsThresholdRaised),
 
 		// Wedge request counters.
 		SlowLatchRequests: metric.NewGauge(metaLatchRequests),
@@ -3368,6 +4016,7 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		AddSSTableProposals:           metric.NewCounter(metaAddSSTableProposals),
 		AddSSTableApplications:        metric.NewCounter(metaAddSSTableApplications),
 		AddSSTableAsWrites:            metric.NewCounter(metaAddSSTableAsWrites),
+		AddSSTableDeduplicated:        metric.NewCounter(metaAddSSTableDeduplicated),
 		AddSSTableApplicationCopies:   metric.NewCounter(metaAddSSTableApplicationCopies),
 		AddSSTableProposalTotalDelay:  metric.NewCounter(metaAddSSTableEvalTotalDelay),
 		AddSSTableProposalEngineDelay: metric.NewCounter(metaAddSSTableEvalEngineDelay),
@@ -3477,6 +4126,17 @@ func (sm *TenantsStorageMetrics) addMVCCStats(
 	sm.incMVCCGauges(ctx, ref, delta)
 }
 
+// recordRaftApply attributes the application of Raft entries to the tenant
+// owning the replica.
+func (sm *TenantsStorageMetrics) recordRaftApply(
+	ctx context.Context, ref *tenantMetricsRef, stats appBatchStats,
+) {
+	tm := sm.getTenant(ctx, ref)
+	tm.RaftApplyEntries.Inc(int64(stats.numEntriesProcessed))
+	tm.RaftApplyEntryBytes.Inc(stats.numEntriesProcessedBytes)
+	tm.RaftApplyMutations.Inc(int64(stats.numMutations))
+}
+
 func (sm *TenantsStorageMetrics) subtractMVCCStats(
 	ctx context.Context, ref *tenantMetricsRef, delta enginepb.MVCCStats,
 ) {
@@ -3665,6 +4325,8 @@ func (sm *StoreMetrics) handleMetricsResult(ctx context.Context, metric result.M
 
 	sm.AddSSTableAsWrites.Inc(int64(metric.AddSSTableAsWrites))
 	metric.AddSSTableAsWrites = 0
+	sm.AddSSTableDeduplicated.Inc(int64(metric.AddSSTableDeduplicated))
+	metric.AddSSTableDeduplicated = 0
 
 	if metric != (result.Metrics{}) {
 		log.Fatalf(ctx, "unhandled fields in metrics result: %+v", metric)
@@ -49,6 +49,32 @@ func TestTenantsStorageMetricsRelease(t *testing.T) {
 // TestTenantsStorageMetricsConcurrency exercises the concurrency logic of the
 // TenantsStorageMetrics and ensures that none of the assertions are hit.
 // The test doesn't meaningfully exercise the logic which is tested elsewhere.
+func TestTenantsStorageMetricsRaftApply(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	m := newTenantsStorageMetrics()
+	ref1 := m.acquireTenant(roachpb.SystemTenantID)
+	ref2 := m.acquireTenant(roachpb.MustMakeTenantID(2))
+
+	m.recordRaftApply(ctx, ref1, appBatchStats{
+		numEntriesProcessed: 3, numEntriesProcessedBytes: 300, numMutations: 5,
+	})
+	m.recordRaftApply(ctx, ref2, appBatchStats{
+		numEntriesProcessed: 1, numEntriesProcessedBytes: 10, numMutations: 2,
+	})
+	require.Equal(t, int64(3), m.getTenant(ctx, ref1).RaftApplyEntries.Value())
+	require.Equal(t, int64(10), m.getTenant(ctx, ref2).RaftApplyEntryBytes.Value())
+	require.Equal(t, int64(2), m.getTenant(ctx, ref2).RaftApplyMutations.Value())
+	require.Equal(t, int64(4), m.RaftApplyEntries.Count())
+	require.Equal(t, int64(310), m.RaftApplyEntryBytes.Count())
+	require.Equal(t, int64(7), m.RaftApplyMutations.Count())
+
+	// The counters of released tenants remain accounted for in the aggregates.
+	m.releaseTenant(ctx, ref2)
+	require.Equal(t, int64(4), m.RaftApplyEntries.Count())
+	m.releaseTenant(ctx, ref1)
+}
+
 func TestTenantsStorageMetricsConcurrency(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
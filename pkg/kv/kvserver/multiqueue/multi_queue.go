@@ -97,6 +97,7 @@ func (h *notifyHeap) tryRemove(task *Task) bool {
 // return the Permit to the queue so that the next Task can be started.
 type MultiQueue struct {
 	mu             syncutil.Mutex
+	maxConcurrency int
 	remainingRuns  int
 	mapping        map[int]int
 	lastQueueIndex int
@@ -107,8 +108,9 @@ type MultiQueue struct {
 // to be called on it first.
 func NewMultiQueue(maxConcurrency int) *MultiQueue {
 	queue := MultiQueue{
-		remainingRuns: maxConcurrency,
-		mapping:       make(map[int]int),
+		maxConcurrency: maxConcurrency,
+		remainingRuns:  maxConcurrency,
+		mapping:        make(map[int]int),
 	}
 	queue.lastQueueIndex = -1
 
@@ -232,6 +234,23 @@ func (m *MultiQueue) releaseLocked(permit *Permit) {
 	m.tryRunNextLocked()
 }
 
+// UpdateConcurrencyLimit updates the number of concurrent jobs the queue
+// allows to run. If the limit is decreased below the number of running jobs,
+// no new job is started until enough of them are released.
+func (m *MultiQueue) UpdateConcurrencyLimit(maxConcurrency int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remainingRuns += maxConcurrency - m.maxConcurrency
+	m.maxConcurrency = maxConcurrency
+	for runs := m.remainingRuns; runs > 0; runs = m.remainingRuns {
+		m.tryRunNextLocked()
+		if m.remainingRuns == runs {
+			// Nothing is queued.
+			return
+		}
+	}
+}
+
 // AvailableLen returns the number of additional tasks that can be added without
 // queueing. This will return 0 if there is anything queued.
 func (m *MultiQueue) AvailableLen() int {
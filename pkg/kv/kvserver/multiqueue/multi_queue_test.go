@@ -389,3 +389,36 @@ func verifyOrder(t *testing.T, queue *MultiQueue, tasks ...*Task) {
 		queue.Release(found)
 	}
 }
+
+// TestMultiQueueUpdateConcurrencyLimit checks that increasing the concurrency
+// limit starts the queued tasks, and that decreasing it holds the queued tasks
+// back until enough running ones are released.
+func TestMultiQueueUpdateConcurrencyLimit(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	queue := NewMultiQueue(1)
+	task1, _ := queue.Add(1, 1, -1)
+	task2, _ := queue.Add(1, 1, -1)
+	task3, _ := queue.Add(1, 1, -1)
+	require.Equal(t, 3, queue.QueueLen())
+
+	queue.UpdateConcurrencyLimit(3)
+	permit1 := <-task1.GetWaitChan()
+	permit2 := <-task2.GetWaitChan()
+	permit3 := <-task3.GetWaitChan()
+	require.Equal(t, 0, queue.AvailableLen())
+
+	queue.UpdateConcurrencyLimit(1)
+	task4, _ := queue.Add(1, 1, -1)
+	queue.Release(permit1)
+	queue.Release(permit2)
+	select {
+	case <-task4.GetWaitChan():
+		t.Fatal("task started above the concurrency limit")
+	default:
+	}
+	queue.Release(permit3)
+	queue.Release(<-task4.GetWaitChan())
+	require.Equal(t, 1, queue.AvailableLen())
+}
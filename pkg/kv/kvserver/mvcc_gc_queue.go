@@ -210,6 +210,7 @@ func newMVCCGCQueue(store *Store) *mvccGCQueue {
 			pending:         store.metrics.MVCCGCQueuePending,
 			processingNanos: store.metrics.MVCCGCQueueProcessingNanos,
 			disabledConfig:  kvserverbase.MVCCGCQueueEnabled,
+			priorityClass:   queuePriorityClassMaintenance,
 		},
 	)
 	return mgcq
@@ -224,6 +225,7 @@ type mvccGCQueueScore struct {
 	DeadFraction        float64
 	ValuesScalableScore float64
 	IntentScore         float64
+	OldIntentScore      float64
 	FuzzFactor          float64
 	FinalScore          float64
 	ShouldQueue         bool
@@ -245,10 +247,10 @@ func (r mvccGCQueueScore) String() string {
 	if r.LastGC != 0 {
 		lastGC = fmt.Sprintf("%s ago", r.LastGC)
 	}
-	s := fmt.Sprintf("queue=%t with %.2f/fuzz(%.2f)=%.2f=valScaleScore(%.2f)*deadFrac(%.2f)+intentScore(%.2f)\n"+
+	s := fmt.Sprintf("queue=%t with %.2f/fuzz(%.2f)=%.2f=valScaleScore(%.2f)*deadFrac(%.2f)+intentScore(%.2f)+oldIntentScore(%.2f)\n"+
 		"likely last GC: %s, %s non-live, curr. age %s*s, min exp. reduction: %s*s",
 		r.ShouldQueue, r.FinalScore, r.FuzzFactor, r.FinalScore/r.FuzzFactor, r.ValuesScalableScore,
-		r.DeadFraction, r.IntentScore, lastGC, humanizeutil.IBytes(r.GCBytes),
+		r.DeadFraction, r.IntentScore, r.OldIntentScore, lastGC, humanizeutil.IBytes(r.GCBytes),
 		humanizeutil.IBytes(r.GCByteAge), humanizeutil.IBytes(r.ExpMinGCByteAgeReduction))
 	if !r.Hint.IsEmpty() {
 		s += fmt.Sprintf("\nhint: %s", r.Hint)
@@ -261,7 +263,7 @@ func (r mvccGCQueueScore) String() string {
 // in the event that the cumulative ages of GC'able bytes or extant
 // intents exceed thresholds.
 func (mgcq *mvccGCQueue) shouldQueue(
-	ctx context.Context, _ hlc.ClockTimestamp, repl *Replica, _ spanconfig.StoreReader,
+	ctx context.Context, now hlc.ClockTimestamp, repl *Replica, _ spanconfig.StoreReader,
 ) (bool, float64) {
 	// Consult the protected timestamp state to determine whether we can GC and
 	// the timestamp which can be used to calculate the score.
@@ -289,10 +291,30 @@ func (mgcq *mvccGCQueue) shouldQueue(
 	}
 
 	r := makeMVCCGCQueueScore(ctx, repl, gcTimestamp, lastGC, conf.TTL(), canAdvanceGCThreshold)
+	if !r.ShouldQueue && mayExceedMaxVersions(conf.GCPolicy.MaxVersions, repl.GetMVCCStats(),
+		now.ToTimestamp(), lastGC, gc.MaxVersionsMinRetention.Get(&repl.ClusterSettings().SV)) {
+		log.VEventf(ctx, 2, "shouldQueue=true: may exceed gc.max_versions=%d: %s",
+			conf.GCPolicy.MaxVersions, r)
+		return true, mvccGCKeyScoreThreshold
+	}
 	log.VEventf(ctx, 2, "shouldQueue=%t: %s", r.ShouldQueue, r)
 	return r.ShouldQueue, r.FinalScore
 }
 
+// mayExceedMaxVersions returns true if the range may have keys with more
+// versions than allowed by its max versions GC policy, and wasn't processed in
+// the last minRetention, which bounds how often the range is scanned for such
+// keys. A key with more than maxVersions versions has at least maxVersions
+// versions which aren't its newest, so the check has no false negatives.
+func mayExceedMaxVersions(
+	maxVersions int32, ms enginepb.MVCCStats, now, lastGC hlc.Timestamp, minRetention time.Duration,
+) bool {
+	if maxVersions <= 0 || ms.ValCount-ms.KeyCount < int64(maxVersions) {
+		return false
+	}
+	return lastGC.Add(minRetention.Nanoseconds(), 0).Less(now)
+}
+
 func makeMVCCGCQueueScore(
 	ctx context.Context,
 	repl *Replica,
@@ -313,9 +335,10 @@ func makeMVCCGCQueueScore(
 	// Use desc.RangeID for fuzzing the final score, so that different ranges
 	// have slightly different priorities and even symmetrical workloads don't
 	// trigger GC at the same time.
+	sv := &repl.ClusterSettings().SV
 	r := makeMVCCGCQueueScoreImpl(
 		ctx, int64(repl.RangeID), now, ms, gcTTL, lastGC, canAdvanceGCThreshold,
-		hint, gc.TxnCleanupThreshold.Get(&repl.ClusterSettings().SV),
+		hint, gc.TxnCleanupThreshold.Get(sv), gc.LockAgeThreshold.Get(sv),
 	)
 	return r
 }
@@ -421,6 +444,7 @@ func makeMVCCGCQueueScoreImpl(
 	canAdvanceGCThreshold bool,
 	hint roachpb.GCHint,
 	txnCleanupThreshold time.Duration,
+	lockAgeThreshold time.Duration,
 ) mvccGCQueueScore {
 	ms.Forward(now.WallTime)
 	var r mvccGCQueueScore
@@ -482,6 +506,20 @@ func makeMVCCGCQueueScoreImpl(
 	// undergone a reality check yet.
 	r.IntentScore = ms.AvgLockAge(now.WallTime) / float64(intentAgeNormalization.Nanoseconds()/1e9)
 
+	// Old intent score. Locks older than the lock age threshold are resolved by
+	// the GC run, and the abandoned ones slow down every read which encounters
+	// them, while garbage only costs disk space. Ranges whose locks are on
+	// average older than the threshold are thus prioritized, by a score which
+	// grows with the age and the number of their locks. It doesn't make them
+	// eligible for GC on its own, which is the job of the intent score above.
+	// A zero threshold disables it.
+	if lockAgeThreshold > 0 && ms.LockCount > 0 {
+		avgAgeRatio := ms.AvgLockAge(now.WallTime) / lockAgeThreshold.Seconds()
+		if avgAgeRatio >= 1 {
+			r.OldIntentScore = avgAgeRatio * math.Log10(1+float64(ms.LockCount))
+		}
+	}
+
 	// Randomly skew the score down a bit to cause decoherence of replicas with
 	// similar load. Note that we'll only ever reduce the score, never increase
 	// it (for increasing it could lead to a fruitless run).
@@ -489,7 +527,7 @@ func makeMVCCGCQueueScoreImpl(
 
 	// Compute priority.
 	valScore := r.DeadFraction * r.ValuesScalableScore
-	r.FinalScore = r.FuzzFactor * (valScore + r.IntentScore)
+	r.FinalScore = r.FuzzFactor * (valScore + r.IntentScore + r.OldIntentScore)
 
 	// Check GC queueing eligibility using cooldown discounted by score.
 	isGCScoreMet := func(score float64, minThreshold, maxThreshold float64, cooldown time.Duration) bool {
@@ -712,16 +750,6 @@ func (mgcq *mvccGCQueue) process(
 	}
 	r := makeMVCCGCQueueScore(ctx, repl, gcTimestamp, lastGC, conf.TTL(), canAdvanceGCThreshold)
 	log.VEventf(ctx, 2, "processing replica %s with score %s", repl.String(), r)
-	// Synchronize the new GC threshold decision with concurrent
-	// AdminVerifyProtectedTimestamp requests.
-	if err := repl.markPendingGC(cacheTimestamp, newThreshold); err != nil {
-		log.VEventf(ctx, 1, "not gc'ing replica %v due to pending protection: %v", repl, err)
-		return false, nil
-	}
-	// Update the last processed timestamp.
-	if err := repl.setQueueLastProcessed(ctx, mgcq.name, repl.store.Clock().Now()); err != nil {
-		log.VErrEventf(ctx, 2, "failed to update last processed time: %v", err)
-	}
 
 	var snap storage.Reader
 	if repl.store.cfg.SharedStorageEnabled || storage.ShouldUseEFOS(&repl.ClusterSettings().SV) {
@@ -738,6 +766,38 @@ func (mgcq *mvccGCQueue) process(
 	}
 	defer snap.Close()
 
+	if maxVersions := conf.GCPolicy.MaxVersions; maxVersions > 0 {
+		limit := cacheTimestamp.Add(
+			-gc.MaxVersionsMinRetention.Get(&repl.store.ClusterSettings().SV).Nanoseconds(), 0)
+		if gcTimestamp.Less(cacheTimestamp) {
+			// GC is held back by a protected timestamp, which the policy doesn't
+			// override.
+			limit = newThreshold
+		}
+		versionsThreshold, err := gc.CalculateMaxVersionsThreshold(
+			ctx, desc, snap, maxVersions, newThreshold, limit)
+		if err != nil {
+			return false, err
+		}
+		if newThreshold.Less(versionsThreshold) {
+			log.VEventf(ctx, 2, "raising GC threshold from %s to %s to retain at most %d versions",
+				newThreshold, versionsThreshold, maxVersions)
+			mgcq.store.metrics.GCMaxVersionsThresholdRaised.Inc(1)
+			newThreshold = versionsThreshold
+		}
+	}
+
+	// Synchronize the new GC threshold decision with concurrent
+	// AdminVerifyProtectedTimestamp requests.
+	if err := repl.markPendingGC(cacheTimestamp, newThreshold); err != nil {
+		log.VEventf(ctx, 1, "not gc'ing replica %v due to pending protection: %v", repl, err)
+		return false, nil
+	}
+	// Update the last processed timestamp.
+	if err := repl.setQueueLastProcessed(ctx, mgcq.name, repl.store.Clock().Now()); err != nil {
+		log.VErrEventf(ctx, 2, "failed to update last processed time: %v", err)
+	}
+
 	lockAgeThreshold := gc.LockAgeThreshold.Get(&repl.store.ClusterSettings().SV)
 	maxLocksPerCleanupBatch := gc.MaxLocksPerCleanupBatch.Get(&repl.store.ClusterSettings().SV)
 	maxLocksKeyBytesPerCleanupBatch := gc.MaxLockKeyBytesPerCleanupBatch.Get(&repl.store.ClusterSettings().SV)
@@ -756,6 +816,7 @@ func (mgcq *mvccGCQueue) process(
 			MaxTxnsPerIntentCleanupBatch:         intentresolver.MaxTxnsPerIntentCleanupBatch,
 			IntentCleanupBatchTimeout:            mvccGCQueueIntentBatchTimeout,
 			ClearRangeMinKeys:                    clearRangeMinKeys,
+			OnScan:                               chargeQueueScan,
 		},
 		conf.TTL(),
 		&replicaGCer{
@@ -810,6 +871,9 @@ func (mgcq *mvccGCQueue) process(
 	log.VEventf(ctx, 2, "MVCC stats after GC: %+v", repl.GetMVCCStats())
 	log.VEventf(ctx, 2, "GC score after GC: %s", scoreAfter)
 	updateStoreMetricsWithGCInfo(mgcq.store.metrics, info)
+	repl.activity.record(timeutil.Now(), replicaActivityCounts{
+		gcBytes: info.AffectedVersionsKeyBytes + info.AffectedVersionsValBytes,
+	})
 	// If the score after running through the queue indicates that this
 	// replica should be re-queued for GC it most likely means that there
 	// is something wrong with the stats. One such known issue is
@@ -116,6 +116,7 @@ func TestMVCCGCQueueMakeGCScoreInvariantQuick(t *testing.T) {
 			ctx, int64(seed), now, ms, time.Duration(ttlSec)*time.Second, hlc.Timestamp{},
 			true,                        /* canAdvanceGCThreshold */
 			roachpb.GCHint{}, time.Hour, /* txnCleanupThreshold */
+			0, /* lockAgeThreshold */
 		)
 		wouldHaveToDeleteSomething := gcBytes*int64(ttlSec) < ms.GCByteAge(now.WallTime)
 		result := !r.ShouldQueue || wouldHaveToDeleteSomething
@@ -140,6 +141,7 @@ func TestMVCCGCQueueMakeGCScoreAnomalousStats(t *testing.T) {
 			KeyBytes:          int64(keyBytes),
 		}, 60*time.Second, hlc.Timestamp{}, true, /* canAdvanceGCThreshold */
 			roachpb.GCHint{}, time.Hour, /* txnCleanupThreshold */
+			0, /* lockAgeThreshold */
 		)
 		return r.DeadFraction >= 0 && r.DeadFraction <= 1
 	}, &quick.Config{MaxCount: 1000}); err != nil {
@@ -168,6 +170,7 @@ func TestMVCCGCQueueMakeGCScoreLargeAbortSpan(t *testing.T) {
 			true, /* canAdvanceGCThreshold */
 			roachpb.GCHint{},
 			time.Hour, /* txnCleanupThreshold */
+			0,         /* lockAgeThreshold */
 		)
 		require.True(t, r.ShouldQueue)
 		require.NotZero(t, r.FinalScore)
@@ -186,6 +189,7 @@ func TestMVCCGCQueueMakeGCScoreLargeAbortSpan(t *testing.T) {
 			true, /* canAdvanceGCThreshold */
 			roachpb.GCHint{},
 			time.Hour, /* txnCleanupThreshold */
+			0,         /* lockAgeThreshold */
 		)
 		require.True(t, r.ShouldQueue)
 		require.NotZero(t, r.FinalScore)
@@ -200,12 +204,31 @@ func TestMVCCGCQueueMakeGCScoreLargeAbortSpan(t *testing.T) {
 			true, /* canAdvanceGCThreshold */
 			roachpb.GCHint{},
 			time.Hour, /* txnCleanupThreshold */
+			0,         /* lockAgeThreshold */
 		)
 		require.False(t, r.ShouldQueue)
 		require.Zero(t, r.FinalScore)
 	}
 }
 
+func TestMVCCGCQueueMayExceedMaxVersions(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const minRetention = 10 * time.Minute
+	now := hlc.Timestamp{WallTime: time.Hour.Nanoseconds()}
+	recentGC := now.Add(-minRetention.Nanoseconds()/2, 0)
+	ms := enginepb.MVCCStats{KeyCount: 10, ValCount: 15}
+
+	// 5 versions aren't the newest of their key, so a key may have 6 versions.
+	require.True(t, mayExceedMaxVersions(5, ms, now, hlc.Timestamp{}, minRetention))
+	require.False(t, mayExceedMaxVersions(6, ms, now, hlc.Timestamp{}, minRetention))
+	// The policy is disabled.
+	require.False(t, mayExceedMaxVersions(0, ms, now, hlc.Timestamp{}, minRetention))
+	// The range was processed recently.
+	require.False(t, mayExceedMaxVersions(5, ms, now, recentGC, minRetention))
+}
+
 func TestMVCCGCQueueMakeGCScoreIntentCooldown(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -241,12 +264,66 @@ func TestMVCCGCQueueMakeGCScoreIntentCooldown(t *testing.T) {
 			r := makeMVCCGCQueueScoreImpl(
 				ctx, seed, now, ms, gcTTL, tc.lastGC, true, /* canAdvanceGCThreshold */
 				roachpb.GCHint{}, time.Hour, /* txnCleanupThreshold */
+				0, /* lockAgeThreshold */
 			)
 			require.Equal(t, tc.expectGC, r.ShouldQueue)
 		})
 	}
 }
 
+// TestMVCCGCQueueMakeGCScoreOldIntents tests that ranges with intents older
+// than the lock age threshold are prioritized, more so the more intents they
+// have, without affecting whether they are queued.
+func TestMVCCGCQueueMakeGCScoreOldIntents(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const seed = 1
+	ctx := context.Background()
+	now := hlc.Timestamp{WallTime: 1e6 * 1e9}
+	lockAgeThreshold := 2 * time.Hour
+
+	score := func(lockCount int64, avgAge time.Duration, lastGC hlc.Timestamp) mvccGCQueueScore {
+		ms := enginepb.MVCCStats{
+			LastUpdateNanos: now.WallTime,
+			LockCount:       lockCount,
+			LockAge:         lockCount * int64(avgAge.Seconds()),
+		}
+		return makeMVCCGCQueueScoreImpl(
+			ctx, seed, now, ms, time.Second, lastGC, true, /* canAdvanceGCThreshold */
+			roachpb.GCHint{}, time.Hour, /* txnCleanupThreshold */
+			lockAgeThreshold,
+		)
+	}
+
+	// Young intents don't get a priority boost.
+	young := score(1000, time.Hour, hlc.Timestamp{})
+	require.Zero(t, young.OldIntentScore)
+
+	// Old intents do, which grows with their age and count.
+	old := score(1000, 4*time.Hour, hlc.Timestamp{})
+	require.Greater(t, old.OldIntentScore, 0.0)
+	require.Greater(t, old.FinalScore, young.FinalScore)
+	older := score(1000, 8*time.Hour, hlc.Timestamp{})
+	require.Greater(t, older.OldIntentScore, old.OldIntentScore)
+	more := score(100000, 4*time.Hour, hlc.Timestamp{})
+	require.Greater(t, more.OldIntentScore, old.OldIntentScore)
+
+	// The boost doesn't bypass the intent cooldown.
+	justGCed := score(1000, 48*time.Hour, now.Add(-1, 0))
+	require.Greater(t, justGCed.OldIntentScore, 0.0)
+	require.False(t, justGCed.ShouldQueue)
+
+	// A zero threshold disables the boost.
+	ms := enginepb.MVCCStats{LastUpdateNanos: now.WallTime, LockCount: 1, LockAge: 1e6}
+	disabled := makeMVCCGCQueueScoreImpl(
+		ctx, seed, now, ms, time.Second, hlc.Timestamp{}, true, /* canAdvanceGCThreshold */
+		roachpb.GCHint{}, time.Hour, /* txnCleanupThreshold */
+		0, /* lockAgeThreshold */
+	)
+	require.Zero(t, disabled.OldIntentScore)
+}
+
 const cacheFirstLen = 3
 
 type gcTestCacheKey struct {
@@ -376,6 +453,7 @@ func (cws *cachedWriteSimulator) shouldQueue(
 	r := makeMVCCGCQueueScoreImpl(context.Background(), 0 /* seed */, ts, ms, ttl,
 		prevGCTs, true, /* canAdvanceGCThreshold */
 		roachpb.GCHint{}, time.Hour, /* txnCleanupThreshold */
+		0, /* lockAgeThreshold */
 	)
 	if fmt.Sprintf("%.2f", r.FinalScore) != fmt.Sprintf("%.2f", prio) || b != r.ShouldQueue {
 		cws.t.Errorf("expected queued=%t (is %t), prio=%.2f, got %.2f: after=%s, ttl=%s:\nms: %+v\nscore: %s",
@@ -553,7 +631,7 @@ func TestFullRangeDeleteHeuristic(t *testing.T) {
 	shouldQueueAfter := func(ms enginepb.MVCCStats, delay time.Duration, gcTTL time.Duration) bool {
 		now := deletionTime.Add(delay.Nanoseconds(), 0)
 		r := makeMVCCGCQueueScoreImpl(ctx, 0 /* seed */, now, ms, gcTTL, now.Add(-time.Hour.Nanoseconds(), 0), true,
-			roachpb.GCHint{}, time.Hour)
+			roachpb.GCHint{}, time.Hour, 0 /* lockAgeThreshold */)
 		return r.ShouldQueue
 	}
 
@@ -654,7 +732,7 @@ func TestGCScoreWithHint(t *testing.T) {
 				GCBytesAge:        int64((d.garbage + d.rangeKeys*2) * 10 * 100),
 			}
 			score := makeMVCCGCQueueScoreImpl(ctx, 1, now, ms, time.Minute, hlc.Timestamp{}, true,
-				roachpb.GCHint{LatestRangeDeleteTimestamp: d.hintTs}, time.Hour)
+				roachpb.GCHint{LatestRangeDeleteTimestamp: d.hintTs}, time.Hour, 0 /* lockAgeThreshold */)
 			require.Equal(t, d.shouldGC, score.ShouldQueue)
 			if d.shouldGC {
 				require.Equal(t, d.priority, score.FinalScore)
@@ -14,6 +14,7 @@ import (
 	"container/heap"
 	"context"
 	"fmt"
+	"sort"
 	"sync/atomic"
 	"time"
 
@@ -210,11 +211,12 @@ func (pq *priorityQueue) update(item *replicaItem, priority float64) {
 
 var (
 	errQueueDisabled = errors.New("queue disabled")
+	errQueuePaused   = errors.New("queue paused")
 	errQueueStopped  = errors.New("queue stopped")
 )
 
 func isExpectedQueueError(err error) bool {
-	return err == nil || errors.Is(err, errQueueDisabled)
+	return err == nil || errors.Is(err, errQueueDisabled) || errors.Is(err, errQueuePaused)
 }
 
 // shouldQueueAgain is a helper function to determine whether the
@@ -301,7 +303,11 @@ type queueConfig struct {
 	maxSize int
 	// maxConcurrency is the maximum number of replicas that can be processed
 	// concurrently. If not set, defaults to 1.
-	maxConcurrency       int
+	maxConcurrency int
+	// concurrencySetting, if set, further limits the number of replicas that
+	// can be processed concurrently to its value, capped at maxConcurrency. It
+	// allows operators to change the concurrency of a queue at runtime.
+	concurrencySetting   *settings.IntSetting
 	addOrMaybeAddSemSize int
 	// needsLease controls whether this queue requires the range lease to operate
 	// on a replica. If so, one will be acquired if necessary. Many queues set
@@ -330,6 +336,9 @@ type queueConfig struct {
 	processDestroyedReplicas bool
 	// processTimeout returns the timeout for processing a replica.
 	processTimeoutFunc queueProcessTimeoutFunc
+	// priorityClass is the priority class of the queue, which determines the
+	// processing budget it is subject to. See queueBudget.
+	priorityClass queuePriorityClass
 	// successes is a counter of replicas processed successfully.
 	successes *metric.Counter
 	// failures is a counter of replicas which failed processing.
@@ -432,6 +441,7 @@ type baseQueue struct {
 	queueConfig
 	incoming         chan struct{} // Channel signaled when a new replica is added to the queue.
 	processSem       chan struct{}
+	processLimit     *quotapool.IntPool // nil unless concurrencySetting is set
 	addOrMaybeAddSem *quotapool.IntPool // for {Maybe,}AddAsync
 	addLogN          log.EveryN         // avoid log spam when addSem, addOrMaybeAddSemSize are maxed out
 	processDur       int64              // accessed atomically
@@ -442,9 +452,25 @@ type baseQueue struct {
 		purgatory      map[roachpb.RangeID]PurgatoryError // Map of replicas to processing errors
 		stopped        bool
 		disabled       bool
+		// paused holds the pauses requested by operators, keyed by range ID.
+		// A pause of all ranges is keyed by range ID zero. See SetPaused.
+		paused map[roachpb.RangeID]QueuePause
 	}
 }
 
+// QueuePause describes a pause of a replica queue, requested by an operator to
+// temporarily stop the queue from processing some or all ranges on a store.
+type QueuePause struct {
+	// Queue is the name of the paused queue.
+	Queue string
+	// RangeID is the paused range, or zero if all ranges are paused.
+	RangeID roachpb.RangeID
+	// Since is the time at which the pause was requested.
+	Since time.Time
+	// Reason is the reason given by the operator.
+	Reason string
+}
+
 // newBaseQueue returns a new instance of baseQueue with the specified
 // shouldQueue function to determine which replicas to queue and maxSize to
 // limit the growth of the queue. Note that maxSize doesn't prevent new
@@ -498,10 +524,29 @@ func newBaseQueue(name string, impl queueImpl, store *Store, cfg queueConfig) *b
 	cfg.disabledConfig.SetOnChange(&store.cfg.Settings.SV, func(ctx context.Context) {
 		bq.SetDisabled(!cfg.disabledConfig.Get(&store.cfg.Settings.SV))
 	})
+	if cfg.concurrencySetting != nil {
+		bq.processLimit = quotapool.NewIntPool(name+"-process", bq.processConcurrency())
+		cfg.concurrencySetting.SetOnChange(&store.cfg.Settings.SV, func(ctx context.Context) {
+			bq.processLimit.UpdateCapacity(bq.processConcurrency())
+		})
+	}
 
 	return &bq
 }
 
+// processConcurrency returns the number of replicas the queue may process
+// concurrently, as configured by its concurrencySetting.
+func (bq *baseQueue) processConcurrency() uint64 {
+	n := bq.concurrencySetting.Get(&bq.store.cfg.Settings.SV)
+	if n < 1 {
+		n = 1
+	}
+	if n > int64(bq.maxConcurrency) {
+		n = int64(bq.maxConcurrency)
+	}
+	return uint64(n)
+}
+
 // Name returns the name of the queue.
 func (bq *baseQueue) Name() string {
 	return bq.name
@@ -538,6 +583,70 @@ func (bq *baseQueue) SetDisabled(disabled bool) {
 	bq.mu.Unlock()
 }
 
+// SetPaused pauses or resumes the processing of the given range by the queue,
+// or of all ranges if rangeID is zero. Unlike SetDisabled, which follows the
+// queue's cluster setting, pauses are requested by operators and can target
+// individual ranges. Pausing removes the paused replicas from the queue and
+// from purgatory, but does not interrupt replicas which are being processed.
+// Resuming all ranges does not resume the ranges which were paused
+// individually. Returns false if the pause was already in the requested state.
+func (bq *baseQueue) SetPaused(
+	rangeID roachpb.RangeID, paused bool, reason string, now time.Time,
+) bool {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	_, ok := bq.mu.paused[rangeID]
+	if !paused {
+		delete(bq.mu.paused, rangeID)
+		return ok
+	}
+	if ok {
+		return false
+	}
+	if bq.mu.paused == nil {
+		bq.mu.paused = map[roachpb.RangeID]QueuePause{}
+	}
+	bq.mu.paused[rangeID] = QueuePause{
+		Queue:   bq.name,
+		RangeID: rangeID,
+		Since:   now,
+		Reason:  reason,
+	}
+	if rangeID == 0 {
+		for _, item := range bq.mu.replicas {
+			bq.removeLocked(item)
+		}
+	} else if item, ok := bq.mu.replicas[rangeID]; ok {
+		bq.removeLocked(item)
+	}
+	return true
+}
+
+// Pauses returns the pauses of the queue, ordered by range ID.
+func (bq *baseQueue) Pauses() []QueuePause {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	pauses := make([]QueuePause, 0, len(bq.mu.paused))
+	for _, p := range bq.mu.paused {
+		pauses = append(pauses, p)
+	}
+	sort.Slice(pauses, func(i, j int) bool {
+		return pauses[i].RangeID < pauses[j].RangeID
+	})
+	return pauses
+}
+
+// pausedLocked returns whether the processing of the given range by the queue
+// is paused.
+func (bq *baseQueue) pausedLocked(rangeID roachpb.RangeID) bool {
+	if len(bq.mu.paused) == 0 {
+		return false
+	}
+	_, all := bq.mu.paused[0]
+	_, ok := bq.mu.paused[rangeID]
+	return all || ok
+}
+
 // lockProcessing locks all processing in the baseQueue. It returns
 // a function to unlock processing.
 func (bq *baseQueue) lockProcessing() func() {
@@ -649,9 +758,10 @@ func (bq *baseQueue) maybeAdd(ctx context.Context, repl replicaInQueue, now hlc.
 	bq.mu.Lock()
 	stopped := bq.mu.stopped
 	disabled := bq.mu.disabled
+	paused := bq.pausedLocked(repl.GetRangeID())
 	bq.mu.Unlock()
 
-	if stopped {
+	if stopped || paused {
 		return
 	}
 
@@ -719,6 +829,13 @@ func (bq *baseQueue) addInternal(
 		}
 	}
 
+	if bq.pausedLocked(desc.RangeID) {
+		if log.V(3) {
+			log.Infof(ctx, "queue paused")
+		}
+		return false, errQueuePaused
+	}
+
 	// If the replica is currently in purgatory, don't re-add it.
 	if _, ok := bq.mu.purgatory[desc.RangeID]; ok {
 		return false, nil
@@ -947,6 +1064,22 @@ func (bq *baseQueue) processReplica(ctx context.Context, repl replicaInQueue) er
 		return err
 	}
 
+	// Wait for the queue's concurrency limit, if any, before the processing
+	// timeout starts ticking.
+	if bq.processLimit != nil {
+		alloc, err := bq.processLimit.Acquire(ctx, 1)
+		if err != nil {
+			return err
+		}
+		defer alloc.Release()
+	}
+
+	ctx, work, err := bq.admitProcessing(ctx)
+	if err != nil {
+		return err
+	}
+	defer work.finish()
+
 	return timeutil.RunWithTimeout(ctx, fmt.Sprintf("%s queue process replica %d", bq.name, repl.GetRangeID()),
 		bq.processTimeoutFunc(bq.store.ClusterSettings(), repl), func(ctx context.Context) error {
 			log.VEventf(ctx, 3, "processing...")
@@ -1165,7 +1298,11 @@ func (bq *baseQueue) finishProcessingReplica(
 		// purgatory.
 		if purgErr, ok := IsPurgatoryError(err); ok {
 			bq.mu.Lock()
-			bq.addToPurgatoryLocked(ctx, stopper, repl, purgErr)
+			// Replicas which were paused while being processed are dropped
+			// instead, like the other replicas removed by the pause.
+			if !bq.pausedLocked(repl.GetRangeID()) {
+				bq.addToPurgatoryLocked(ctx, stopper, repl, purgErr)
+			}
 			bq.mu.Unlock()
 			return
 		}
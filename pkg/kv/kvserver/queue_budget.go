@@ -0,0 +1,215 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
+	"github.com/cockroachdb/cockroach/pkg/util/grunning"
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// queuePriorityClass groups the replica queues which share a processing
+// budget.
+type queuePriorityClass int8
+
+const (
+	// queuePriorityClassCritical is the class of the queues whose processing is
+	// needed for the availability and the performance of ranges, e.g. the
+	// replicate, split and raft log queues. Their processing is not budgeted.
+	queuePriorityClassCritical queuePriorityClass = iota
+	// queuePriorityClassMaintenance is the class of the queues doing background
+	// maintenance which can be deferred during peak hours, e.g. the MVCC GC
+	// queue and the consistency checker. Their processing is subject to the
+	// store's maintenance queue budget.
+	queuePriorityClassMaintenance
+)
+
+// maintenanceQueueCPUShare is the share of the node's CPU capacity that the
+// processing of maintenance queues on a store can consume.
+var maintenanceQueueCPUShare = settings.RegisterFloatSetting(
+	settings.SystemOnly,
+	"kv.queue.maintenance_budget.cpu_share",
+	"the share of the CPU capacity of the node that the processing of maintenance "+
+		"queues (e.g. mvcc gc and the consistency checker) on a store may use collectively "+
+		"(0 disables the limit)",
+	0.25,
+	settings.FloatInRange(0, 1),
+)
+
+// maintenanceQueueScanRate is the rate at which the processing of maintenance
+// queues on a store can scan range data.
+var maintenanceQueueScanRate = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.queue.maintenance_budget.scan_rate",
+	"the rate (in bytes per second) at which the processing of maintenance queues "+
+		"(e.g. mvcc gc and the consistency checker) on a store may scan range data "+
+		"collectively (0 disables the limit)",
+	256<<20, /* 256 MiB */
+)
+
+// maintenanceQueueAdmissionPriority is the admission priority of the
+// processing of maintenance queues.
+const maintenanceQueueAdmissionPriority = admissionpb.BulkNormalPri
+
+// queueBudget limits the CPU and IO consumed by the processing of the queues in
+// a priority class. Both are tracked by token buckets: the IO budget is charged
+// for the bytes that the queues scan, as they scan them (see chargeQueueScan),
+// and the CPU budget is charged for the CPU time spent processing a replica
+// after the fact, delaying the processing of the next replica if the budget is
+// exhausted.
+//
+// In addition to the budget, which caps the share of the resources of the node
+// used by the class, the processing is admitted through the elastic CPU work
+// queue of admission control, which yields to foreground work when the
+// scheduling latencies of the node increase.
+type queueBudget struct {
+	st *cluster.Settings
+	// cpu is in CPU nanoseconds.
+	cpu *quotapool.RateLimiter
+	// scan is in bytes.
+	scan *quotapool.RateLimiter
+}
+
+func newMaintenanceQueueBudget(st *cluster.Settings) *queueBudget {
+	b := &queueBudget{
+		st:   st,
+		cpu:  quotapool.NewRateLimiter("maintenance-queue-cpu", quotapool.Inf(), 0),
+		scan: quotapool.NewRateLimiter("maintenance-queue-scan", quotapool.Inf(), 0),
+	}
+	b.updateLimits()
+	maintenanceQueueCPUShare.SetOnChange(&st.SV, func(context.Context) { b.updateLimits() })
+	maintenanceQueueScanRate.SetOnChange(&st.SV, func(context.Context) { b.updateLimits() })
+	return b
+}
+
+// updateLimits updates the rates of the budget from the cluster settings. The
+// bursts allow for a second worth of processing.
+func (b *queueBudget) updateLimits() {
+	cpuRate := quotapool.Inf()
+	if share := maintenanceQueueCPUShare.Get(&b.st.SV); share > 0 {
+		cpuRate = quotapool.Limit(share * float64(runtime.GOMAXPROCS(0)) * float64(time.Second))
+	}
+	scanRate := quotapool.Inf()
+	if rate := maintenanceQueueScanRate.Get(&b.st.SV); rate > 0 {
+		scanRate = quotapool.Limit(rate)
+	}
+	b.cpu.UpdateLimit(cpuRate, burstOf(cpuRate))
+	b.scan.UpdateLimit(scanRate, burstOf(scanRate))
+}
+
+func burstOf(rate quotapool.Limit) int64 {
+	if math.IsInf(float64(rate), 1) {
+		return 0
+	}
+	return int64(rate)
+}
+
+// queueWork is the budgeted processing of a replica by a queue in the
+// maintenance priority class. It is carried by the context of the processing.
+type queueWork struct {
+	bq       *baseQueue
+	budget   *queueBudget
+	pacer    *admission.Pacer
+	cpuStart time.Duration
+}
+
+type queueWorkKey struct{}
+
+// admitProcessing waits for the CPU time charged for the replicas previously
+// processed by the queue's priority class to be paid off, and admits the
+// processing through admission control. The returned context carries the
+// budgeted work, which must be finished once the processing is done, from the
+// same goroutine.
+func (bq *baseQueue) admitProcessing(ctx context.Context) (context.Context, *queueWork, error) {
+	b := bq.store.maintenanceBudget
+	if bq.priorityClass != queuePriorityClassMaintenance || b == nil {
+		return ctx, nil, nil
+	}
+
+	// The CPU budget is charged asynchronously for the processing of previous
+	// replicas (see finish). The quota pool serves requests in order, so this
+	// waits for those charges.
+	if err := bq.waitForBudget(ctx, b.cpu, 1); err != nil {
+		return nil, nil, err
+	}
+	w := &queueWork{bq: bq, budget: b}
+	if ac := bq.store.cfg.KVAdmissionController; ac != nil {
+		w.pacer = ac.AdmitQueueWork(maintenanceQueueAdmissionPriority)
+		if err := w.pacer.Pace(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+	w.cpuStart = grunning.Time()
+	return context.WithValue(ctx, queueWorkKey{}, w), w, nil
+}
+
+// chargeQueueScan charges the budget of the queue processing a replica, if the
+// processing is budgeted, for the given number of bytes scanned, and yields to
+// foreground work through admission control. Queues which scan range data call
+// it periodically while scanning.
+func chargeQueueScan(ctx context.Context, bytes int64) error {
+	w, _ := ctx.Value(queueWorkKey{}).(*queueWork)
+	if w == nil {
+		return nil
+	}
+	if err := w.bq.waitForBudget(ctx, w.budget.scan, bytes); err != nil {
+		return err
+	}
+	w.bq.store.metrics.MaintenanceQueueBudgetScanBytes.Inc(bytes)
+	return w.pacer.Pace(ctx)
+}
+
+// finish finishes the budgeted processing of a replica, charging the CPU budget
+// for the CPU time spent. The charge is waited for asynchronously, so that the
+// processing slot of the queue is released without delay, while the next
+// processing is still held back by it (see admitProcessing). It is a no-op on
+// a nil queueWork.
+func (w *queueWork) finish() {
+	if w == nil {
+		return
+	}
+	w.pacer.Close()
+	cpuNanos := (grunning.Time() - w.cpuStart).Nanoseconds()
+	if cpuNanos <= 0 {
+		return
+	}
+	bq := w.bq
+	bq.store.metrics.MaintenanceQueueBudgetCPUNanos.Inc(cpuNanos)
+	ctx := bq.AnnotateCtx(context.Background())
+	// The error is only returned when the stopper quiesces, in which case there
+	// is no next replica to delay anyway.
+	_ = bq.store.stopper.RunAsyncTask(ctx, "queue-budget-cpu-charge", func(ctx context.Context) {
+		_ = bq.waitForBudget(ctx, w.budget.cpu, cpuNanos)
+	})
+}
+
+// waitForBudget acquires n tokens from the given budget, waiting for them if
+// needed. Acquisitions larger than the burst of the budget put it in debt once
+// it is full, which delays the next acquisition.
+func (bq *baseQueue) waitForBudget(
+	ctx context.Context, budget *quotapool.RateLimiter, n int64,
+) error {
+	ctx, cancel := bq.store.stopper.WithCancelOnQuiesce(ctx)
+	defer cancel()
+	start := timeutil.Now()
+	err := budget.WaitN(ctx, n)
+	bq.store.metrics.MaintenanceQueueBudgetWaitNanos.Inc(timeutil.Since(start).Nanoseconds())
+	return err
+}
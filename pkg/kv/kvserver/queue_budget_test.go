@@ -0,0 +1,103 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQueueBudgetPriorityClass verifies that only the processing of the queues
+// in the maintenance priority class is charged to the maintenance budget.
+func TestQueueBudgetPriorityClass(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	ctx := context.Background()
+	defer stopper.Stop(ctx)
+	tc.Start(ctx, t, stopper)
+
+	m := tc.store.metrics
+	const scanBytes = 100
+
+	critical := makeTestBaseQueue("critical", &testQueueImpl{}, tc.store, queueConfig{})
+	cctx, work, err := critical.admitProcessing(ctx)
+	require.NoError(t, err)
+	require.Nil(t, work)
+	require.NoError(t, chargeQueueScan(cctx, scanBytes))
+	work.finish()
+	require.Zero(t, m.MaintenanceQueueBudgetScanBytes.Count())
+
+	maintenance := makeTestBaseQueue("maintenance", &testQueueImpl{}, tc.store, queueConfig{
+		priorityClass: queuePriorityClassMaintenance,
+	})
+	for i := 1; i <= 2; i++ {
+		mctx, work, err := maintenance.admitProcessing(ctx)
+		require.NoError(t, err)
+		require.NoError(t, chargeQueueScan(mctx, scanBytes))
+		work.finish()
+		require.Equal(t, int64(i)*scanBytes, m.MaintenanceQueueBudgetScanBytes.Count())
+	}
+
+	// The limits can be lifted.
+	maintenanceQueueCPUShare.Override(ctx, &tc.store.ClusterSettings().SV, 0)
+	maintenanceQueueScanRate.Override(ctx, &tc.store.ClusterSettings().SV, 0)
+	mctx, work, err := maintenance.admitProcessing(ctx)
+	require.NoError(t, err)
+	require.NoError(t, chargeQueueScan(mctx, scanBytes))
+	work.finish()
+	require.Equal(t, 3*scanBytes, m.MaintenanceQueueBudgetScanBytes.Count())
+}
+
+// TestQueueBudgetCPUCharge verifies that the CPU time spent processing a
+// replica is charged without holding up the finished processing, but holds
+// back the next processing until it is paid off.
+func TestQueueBudgetCPUCharge(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	ctx := context.Background()
+	defer stopper.Stop(ctx)
+	tc.Start(ctx, t, stopper)
+
+	// Allow for a few CPU nanoseconds per second.
+	maintenanceQueueCPUShare.Override(ctx, &tc.store.ClusterSettings().SV, 1e-9)
+	q := makeTestBaseQueue("maintenance", &testQueueImpl{}, tc.store, queueConfig{
+		priorityClass: queuePriorityClassMaintenance,
+	})
+	_, work, err := q.admitProcessing(ctx)
+	require.NoError(t, err)
+	// Pretend that the processing took a second of CPU time. Finishing it
+	// doesn't wait for the budget.
+	work.cpuStart -= time.Second
+	work.finish()
+	require.GreaterOrEqual(t, tc.store.metrics.MaintenanceQueueBudgetCPUNanos.Count(),
+		time.Second.Nanoseconds())
+
+	testutils.SucceedsSoon(t, func() error {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		if _, _, err := q.admitProcessing(ctx); err == nil {
+			return errors.New("processing admitted despite CPU debt")
+		}
+		return nil
+	})
+}
@@ -1248,6 +1248,67 @@ func TestBaseQueueDisable(t *testing.T) {
 	}
 }
 
+// TestBaseQueuePause verifies that pausing a queue for a range, or for all
+// ranges, removes the paused replicas from the queue and prevents them from
+// being added until the queue is resumed.
+func TestBaseQueuePause(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	ctx := context.Background()
+	defer stopper.Stop(ctx)
+	tc.Start(ctx, t, stopper)
+
+	r, err := tc.store.GetReplica(1)
+	require.NoError(t, err)
+
+	testQueue := &testQueueImpl{
+		shouldQueueFn: func(now hlc.ClockTimestamp, r *Replica) (bool, float64) {
+			return true, 1.0
+		},
+	}
+	// The queue is not started, so that added replicas remain queued.
+	bq := makeTestBaseQueue("test", testQueue, tc.store, queueConfig{maxSize: 2})
+	now := timeutil.Unix(1000, 0)
+
+	_, err = bq.testingAdd(ctx, r, 1.0)
+	require.NoError(t, err)
+	require.Equal(t, 1, bq.Length())
+
+	// Pausing the range removes it from the queue.
+	require.True(t, bq.SetPaused(r.RangeID, true, "incident", now))
+	require.False(t, bq.SetPaused(r.RangeID, true, "again", now.Add(time.Second)))
+	require.Equal(t, 0, bq.Length())
+	_, err = bq.testingAdd(ctx, r, 1.0)
+	require.ErrorIs(t, err, errQueuePaused)
+	bq.maybeAdd(ctx, r, hlc.ClockTimestamp{})
+	require.Equal(t, 0, bq.Length())
+	require.Equal(t, []QueuePause{
+		{Queue: "test", RangeID: r.RangeID, Since: now, Reason: "incident"},
+	}, bq.Pauses())
+
+	require.True(t, bq.SetPaused(r.RangeID, false, "", now))
+	require.False(t, bq.SetPaused(r.RangeID, false, "", now))
+	bq.maybeAdd(ctx, r, hlc.ClockTimestamp{})
+	require.Equal(t, 1, bq.Length())
+
+	// Pausing all ranges removes all replicas from the queue. Resuming all
+	// ranges does not resume the ranges paused individually.
+	require.True(t, bq.SetPaused(0, true, "all", now))
+	require.True(t, bq.SetPaused(r.RangeID, true, "one", now))
+	require.Equal(t, 0, bq.Length())
+	require.Len(t, bq.Pauses(), 2)
+	require.Zero(t, bq.Pauses()[0].RangeID)
+	require.True(t, bq.SetPaused(0, false, "", now))
+	_, err = bq.testingAdd(ctx, r, 1.0)
+	require.ErrorIs(t, err, errQueuePaused)
+	require.True(t, bq.SetPaused(r.RangeID, false, "", now))
+	_, err = bq.testingAdd(ctx, r, 1.0)
+	require.NoError(t, err)
+	require.Empty(t, bq.Pauses())
+}
+
 // TestQueueDisable verifies that setting the set of queue.enabled cluster
 // settings actually disables the base queue. This test works alongside
 // TestBaseQueueDisable to verify the entire disable workflow.
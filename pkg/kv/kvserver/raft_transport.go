@@ -172,6 +172,7 @@ type RaftTransport struct {
 	tracer  *tracing.Tracer
 	stopper *stop.Stopper
 	metrics *RaftTransportMetrics
+	peers   raftTransportPeers
 
 	// Queues maintains a map[roachpb.NodeID]*raftSendQueue on a per rpc-class
 	// level. When new raftSendQueues are instantiated, or old ones deleted, we
@@ -183,6 +184,11 @@ type RaftTransport struct {
 	incomingMessageHandlers syncutil.IntMap // map[roachpb.StoreID]*IncomingRaftMessageHandler
 	outgoingMessageHandlers syncutil.IntMap // map[roachpb.StoreID]*OutgoingRaftMessageHandler
 
+	// snapshotSendBandwidth and snapshotRecvBandwidth coordinate the bandwidth
+	// of the snapshots sent and received by the stores of the node.
+	snapshotSendBandwidth *snapshotBandwidth
+	snapshotRecvBandwidth *snapshotBandwidth
+
 	kvflowControl struct {
 		// Everything nested under this struct is used to return flow tokens
 		// from the receiver (where work was admitted) up to the sender (where
@@ -322,6 +328,10 @@ func NewRaftTransport(
 	t.kvflowControl.mu.connectionTracker = newConnectionTrackerForFlowControl()
 
 	t.initMetrics()
+	t.snapshotSendBandwidth = newSnapshotBandwidth(
+		"snapshot-send-bandwidth", st, snapshotNodeSendRate, t.metrics.SnapshotSendBandwidthWaitNanos)
+	t.snapshotRecvBandwidth = newSnapshotBandwidth(
+		"snapshot-recv-bandwidth", st, snapshotNodeRecvRate, t.metrics.SnapshotRecvBandwidthWaitNanos)
 	if grpcServer != nil {
 		RegisterMultiRaftServer(grpcServer, t)
 	}
@@ -612,7 +622,12 @@ func (t *RaftTransport) RaftSnapshot(stream MultiRaft_RaftSnapshotServer) error
 			rmr.FromReplica, rmr.ToReplica)
 		return kvpb.NewStoreNotFoundError(rmr.ToReplica.StoreID)
 	}
-	return incomingMessageHandler.HandleSnapshot(ctx, req.Header, stream)
+	return incomingMessageHandler.HandleSnapshot(ctx, req.Header, &snapshotBandwidthRecvStream{
+		SnapshotResponseStream: stream,
+		ctx:                    ctx,
+		bandwidth:              t.snapshotRecvBandwidth,
+		storeID:                rmr.ToReplica.StoreID,
+	})
 }
 
 // ListenIncomingRaftMessages registers a IncomingRaftMessageHandler to receive proxied messages.
@@ -801,6 +816,9 @@ func (t *RaftTransport) processQueue(
 			}
 
 			maybeAnnotateWithStoreIDs(batch)
+			if err := t.maybeDelayOutgoingBatch(ctx, q.nodeID); err != nil {
+				return err
+			}
 			if err := stream.Send(batch); err != nil {
 				t.metrics.FlowTokenDispatchesDropped.Inc(int64(len(pendingDispatches)))
 				return err
@@ -835,6 +853,9 @@ func (t *RaftTransport) processQueue(
 			releaseRaftMessageRequest(req)
 
 			maybeAnnotateWithStoreIDs(batch)
+			if err := t.maybeDelayOutgoingBatch(ctx, q.nodeID); err != nil {
+				return err
+			}
 			if err := stream.Send(batch); err != nil {
 				t.metrics.FlowTokenDispatchesDropped.Inc(int64(len(pendingDispatches)))
 				return err
@@ -860,6 +881,29 @@ func (t *RaftTransport) processQueue(
 	}
 }
 
+// maybeDelayOutgoingBatch delays the sending of a batch of messages to the
+// given node by the latency injected with the OutgoingLatency testing knob.
+func (t *RaftTransport) maybeDelayOutgoingBatch(ctx context.Context, nodeID roachpb.NodeID) error {
+	fn := t.knobs.OutgoingLatency
+	if fn == nil {
+		return nil
+	}
+	latency := fn(nodeID)
+	if latency <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.stopper.ShouldQuiesce():
+		return stop.ErrUnavailable
+	}
+}
+
 // getQueue returns the queue for the specified node ID and a boolean
 // indicating whether the queue already exists (true) or was created (false).
 func (t *RaftTransport) getQueue(
@@ -926,11 +970,20 @@ func (t *RaftTransport) SendAsync(
 		// OutgoingRaftMessageHandler registered.
 		outgoingMessageHandler.HandleRaftRequestSent(context.Background(), req.FromReplica.NodeID, req.ToReplica.NodeID, size)
 	}
+	if t.shouldDropBulky(req, size, len(q.reqs)) {
+		t.peerMetrics(toNodeID).bulkyMessagesDropped.Inc(1)
+		if logRaftSendQueueFullEvery.ShouldLog() {
+			log.Warningf(t.AnnotateCtx(context.Background()),
+				"raft send queue to n%d is nearly full, dropping bulky messages", toNodeID)
+		}
+		return false
+	}
 	select {
 	case q.reqs <- req:
 		q.bytes.Add(size)
 		return true
 	default:
+		t.peerMetrics(toNodeID).sendQueueOverflows.Inc(1)
 		if logRaftSendQueueFullEvery.ShouldLog() {
 			log.Warningf(t.AnnotateCtx(context.Background()), "raft send queue to n%d is full", toNodeID)
 		}
@@ -1119,6 +1172,7 @@ func (t *RaftTransport) dropFlowTokensForDisconnectedNodes() {
 func (t *RaftTransport) SendSnapshot(
 	ctx context.Context,
 	clusterID uuid.UUID,
+	storeID roachpb.StoreID,
 	storePool *storepool.StorePool,
 	header kvserverpb.SnapshotRequest_Header,
 	snap *OutgoingSnapshot,
@@ -1126,8 +1180,17 @@ func (t *RaftTransport) SendSnapshot(
 	sent func(),
 	recordBytesSent snapshotRecordMetrics,
 ) (*kvserverpb.SnapshotResponse, error) {
-	nodeID := header.RaftMessageRequest.ToReplica.NodeID
+	if localSnapshotsEnabled.Get(&t.st.SV) && !header.SharedReplicate {
+		// The recipient is a store on the same node if it registered a handler
+		// with this transport.
+		if handler, ok := t.getIncomingRaftMessageHandler(header.RaftMessageRequest.ToReplica.StoreID); ok {
+			if recipient, ok := handler.(*Store); ok {
+				return recipient.receiveLocalSnapshot(ctx, &header, snap, sent, recordBytesSent)
+			}
+		}
+	}
 
+	nodeID := header.RaftMessageRequest.ToReplica.NodeID
 	conn, err := t.dialer.Dial(ctx, nodeID, rpc.DefaultClass)
 	if err != nil {
 		return nil, err
@@ -1143,7 +1206,13 @@ func (t *RaftTransport) SendSnapshot(
 			log.Warningf(ctx, "failed to close snapshot stream: %+v", err)
 		}
 	}()
-	return sendSnapshot(ctx, clusterID, t.st, t.tracer, stream, storePool, header, snap, newWriteBatch, sent, recordBytesSent)
+	limitedStream := &snapshotBandwidthSendStream{
+		outgoingSnapshotStream: stream,
+		ctx:                    ctx,
+		bandwidth:              t.snapshotSendBandwidth,
+		storeID:                storeID,
+	}
+	return sendSnapshot(ctx, clusterID, t.st, t.tracer, limitedStream, storePool, header, snap, newWriteBatch, sent, recordBytesSent)
 }
 
 // DelegateSnapshot sends a DelegateSnapshotRequest to a remote store
@@ -1233,6 +1302,13 @@ type RaftTransportTestingKnobs struct {
 	// DisablePiggyBackedFlowTokenDispatch disables the piggybacked mechanism
 	// when dispatching flow tokens.
 	DisablePiggyBackedFlowTokenDispatch func() bool
+	// OutgoingLatency, if set, returns the latency to inject in the delivery
+	// of the Raft messages sent to the given node. Each batch of messages is
+	// delayed by the returned duration before being sent. Since the batches to
+	// a node are sent one at a time, this also bounds the throughput of the
+	// connection, as a slow link would. Combined with different settings on
+	// each node of a TestCluster, it can simulate asymmetric network latencies.
+	OutgoingLatency func(toNodeID roachpb.NodeID) time.Duration
 }
 
 // ModuleTestingKnobs is part of the base.ModuleTestingKnobs interface.
@@ -10,7 +10,10 @@
 
 package kvserver
 
-import "github.com/cockroachdb/cockroach/pkg/util/metric"
+import (
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/metric/aggmetric"
+)
 
 // RaftTransportMetrics is the set of metrics for a given RaftTransport.
 type RaftTransportMetrics struct {
@@ -21,13 +24,21 @@ type RaftTransportMetrics struct {
 	MessagesSent    *metric.Counter
 	MessagesRcvd    *metric.Counter
 
+	// SendQueueOverflows and BulkyMessagesDropped are labeled by peer node.
+	SendQueueOverflows   *aggmetric.AggCounter
+	BulkyMessagesDropped *aggmetric.AggCounter
+
 	ReverseSent *metric.Counter
 	ReverseRcvd *metric.Counter
 
 	FlowTokenDispatchesDropped *metric.Counter
+
+	SnapshotSendBandwidthWaitNanos *metric.Counter
+	SnapshotRecvBandwidthWaitNanos *metric.Counter
 }
 
 func (t *RaftTransport) initMetrics() {
+	b := aggmetric.MakeBuilder("remote_node_id")
 	t.metrics = &RaftTransportMetrics{
 		SendQueueSize: metric.NewFunctionalGauge(metric.Metadata{
 			Name: "raft.transport.send-queue-size",
@@ -60,6 +71,27 @@ have a fuller picture.`,
 			Unit:        metric.Unit_COUNT,
 		}),
 
+		SendQueueOverflows: b.Counter(metric.Metadata{
+			Name: "raft.transport.send-queue-overflows",
+			Help: `Number of Raft messages dropped because the send queue to a peer was full.
+
+The metric is labeled with the peer node. Messages dropped because of an
+overflow are also counted in sends-dropped.`,
+			Measurement: "Messages",
+			Unit:        metric.Unit_COUNT,
+		}),
+
+		BulkyMessagesDropped: b.Counter(metric.Metadata{
+			Name: "raft.transport.bulky-sends-dropped",
+			Help: `Number of bulky Raft messages dropped to preserve room for heartbeats and small messages.
+
+Bulky messages are dropped when the send queue to a peer is nearly full, with
+the prefer_small kv.raft.transport.send_queue_overflow_policy. The metric is
+labeled with the peer node. These messages are also counted in sends-dropped.`,
+			Measurement: "Messages",
+			Unit:        metric.Unit_COUNT,
+		}),
+
 		MessagesSent: metric.NewCounter(metric.Metadata{
 			Name:        "raft.transport.sent",
 			Help:        "Number of Raft messages sent by the Raft Transport",
@@ -100,5 +132,19 @@ responses to Raft messages. Responses are received over another stream.`,
 			Measurement: "Dispatches",
 			Unit:        metric.Unit_COUNT,
 		}),
+
+		SnapshotSendBandwidthWaitNanos: metric.NewCounter(metric.Metadata{
+			Name:        "raft.transport.snapshot-send-bandwidth-wait-nanos",
+			Help:        "Total time snapshot batches waited for the node's kv.snapshot.node_send_rate",
+			Measurement: "Nanoseconds",
+			Unit:        metric.Unit_NANOSECONDS,
+		}),
+
+		SnapshotRecvBandwidthWaitNanos: metric.NewCounter(metric.Metadata{
+			Name:        "raft.transport.snapshot-recv-bandwidth-wait-nanos",
+			Help:        "Total time snapshot batches waited for the node's kv.snapshot.node_recv_rate",
+			Measurement: "Nanoseconds",
+			Unit:        metric.Unit_NANOSECONDS,
+		}),
 	}
 }
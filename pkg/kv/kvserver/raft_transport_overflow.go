@@ -0,0 +1,124 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/metric/aggmetric"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// raftSendQueueOverflowPolicy is the policy with which the raft transport drops
+// outgoing messages when the send queue to a peer fills up.
+type raftSendQueueOverflowPolicy int64
+
+const (
+	// raftSendQueueOverflowUniform drops every message which doesn't fit in
+	// the queue.
+	raftSendQueueOverflowUniform raftSendQueueOverflowPolicy = iota
+	// raftSendQueueOverflowPreferSmall starts dropping bulky messages before
+	// the queue is full, keeping the rest of the queue for heartbeats and small
+	// messages, such as small appends and their responses, which keep the raft
+	// groups and their leases alive.
+	raftSendQueueOverflowPreferSmall
+)
+
+// raftSendQueueBulkyLimit is the length of a send queue above which bulky
+// messages are dropped with the prefer_small overflow policy.
+const raftSendQueueBulkyLimit = raftSendBufferSize * 3 / 4
+
+var raftSendQueueOverflowPolicySetting = settings.RegisterEnumSetting(
+	settings.SystemOnly,
+	"kv.raft.transport.send_queue_overflow_policy",
+	"the policy with which raft messages are dropped when the send queue to a peer fills "+
+		"up; uniform drops all messages which don't fit in the queue, while prefer_small "+
+		"drops the messages larger than kv.raft.transport.bulky_message_size when the "+
+		"queue is 75% full, preserving room for heartbeats and small messages",
+	"uniform",
+	map[int64]string{
+		int64(raftSendQueueOverflowUniform):     "uniform",
+		int64(raftSendQueueOverflowPreferSmall): "prefer_small",
+	},
+)
+
+var raftBulkyMessageSize = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.raft.transport.bulky_message_size",
+	"the size above which raft messages other than heartbeats are dropped first when the "+
+		"send queue to a peer fills up, with the prefer_small overflow policy",
+	64<<10, // 64 KiB
+	settings.PositiveInt,
+)
+
+// isRaftHeartbeat returns whether the request carries heartbeats or heartbeat
+// responses, which are never considered bulky.
+func isRaftHeartbeat(req *kvserverpb.RaftMessageRequest) bool {
+	if len(req.Heartbeats) > 0 || len(req.HeartbeatResps) > 0 {
+		return true
+	}
+	switch req.Message.Type {
+	case raftpb.MsgHeartbeat, raftpb.MsgHeartbeatResp:
+		return true
+	}
+	return false
+}
+
+// shouldDropBulky returns whether a message of the given size, about to be
+// sent on a queue of the given length, should be dropped to preserve room in
+// the queue for heartbeats and small messages.
+func (t *RaftTransport) shouldDropBulky(
+	req *kvserverpb.RaftMessageRequest, size int64, queueLen int,
+) bool {
+	if raftSendQueueOverflowPolicy(raftSendQueueOverflowPolicySetting.Get(&t.st.SV)) !=
+		raftSendQueueOverflowPreferSmall {
+		return false
+	}
+	if queueLen < raftSendQueueBulkyLimit || isRaftHeartbeat(req) {
+		return false
+	}
+	return size > raftBulkyMessageSize.Get(&t.st.SV)
+}
+
+// raftTransportPeerMetrics are the metrics of the raft transport labeled by
+// peer node.
+type raftTransportPeerMetrics struct {
+	sendQueueOverflows   *aggmetric.Counter
+	bulkyMessagesDropped *aggmetric.Counter
+}
+
+// raftTransportPeers holds the per-peer metrics of the raft transport. They
+// are created when a message to the peer is first dropped, and never removed.
+type raftTransportPeers struct {
+	syncutil.Mutex
+	metrics map[roachpb.NodeID]*raftTransportPeerMetrics
+}
+
+// peerMetrics returns the metrics of the given peer.
+func (t *RaftTransport) peerMetrics(nodeID roachpb.NodeID) *raftTransportPeerMetrics {
+	t.peers.Lock()
+	defer t.peers.Unlock()
+	m, ok := t.peers.metrics[nodeID]
+	if !ok {
+		if t.peers.metrics == nil {
+			t.peers.metrics = map[roachpb.NodeID]*raftTransportPeerMetrics{}
+		}
+		label := nodeID.String()
+		m = &raftTransportPeerMetrics{
+			sendQueueOverflows:   t.metrics.SendQueueOverflows.AddChild(label),
+			bulkyMessagesDropped: t.metrics.BulkyMessagesDropped.AddChild(label),
+		}
+		t.peers.metrics[nodeID] = m
+	}
+	return m
+}
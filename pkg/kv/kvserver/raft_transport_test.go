@@ -35,6 +35,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/metric"
 	"github.com/cockroachdb/cockroach/pkg/util/netutil"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
@@ -437,6 +438,52 @@ func TestInOrderDelivery(t *testing.T) {
 	}
 }
 
+// TestRaftTransportOutgoingLatency verifies that the OutgoingLatency testing
+// knob delays the messages sent to the selected nodes only.
+func TestRaftTransportOutgoingLatency(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	rttc := newRaftTransportTestContext(t, cluster.MakeTestingClusterSettings())
+	defer rttc.Stop()
+
+	const latency = 200 * time.Millisecond
+	slowReplica := roachpb.ReplicaDescriptor{NodeID: 2, StoreID: 2, ReplicaID: 2}
+	fastReplica := roachpb.ReplicaDescriptor{NodeID: 3, StoreID: 3, ReplicaID: 3}
+	clientReplica := roachpb.ReplicaDescriptor{NodeID: 1, StoreID: 1, ReplicaID: 1}
+	rttc.AddNode(slowReplica.NodeID)
+	rttc.AddNode(fastReplica.NodeID)
+	slowChannel := rttc.ListenStore(slowReplica.NodeID, slowReplica.StoreID)
+	fastChannel := rttc.ListenStore(fastReplica.NodeID, fastReplica.StoreID)
+
+	_, addr := rttc.AddNodeWithoutGossip(
+		clientReplica.NodeID, util.TestAddr, rttc.stopper,
+		kvflowdispatch.NewDummyDispatch(), kvserver.NoopStoresFlowControlIntegration{},
+		kvserver.NoopRaftTransportDisconnectListener{},
+		&kvserver.RaftTransportTestingKnobs{
+			OutgoingLatency: func(toNodeID roachpb.NodeID) time.Duration {
+				if toNodeID == slowReplica.NodeID {
+					return latency
+				}
+				return 0
+			},
+		},
+	)
+	rttc.GossipNode(clientReplica.NodeID, addr)
+
+	for _, to := range []roachpb.ReplicaDescriptor{slowReplica, fastReplica} {
+		start := timeutil.Now()
+		require.True(t, rttc.Send(clientReplica, to, 1, raftpb.Message{Commit: 1}))
+		ch := slowChannel.ch
+		if to == fastReplica {
+			ch = fastChannel.ch
+		}
+		<-ch
+		if to == slowReplica {
+			require.GreaterOrEqual(t, timeutil.Since(start), latency)
+		}
+	}
+}
+
 // TestRaftTransportCircuitBreaker verifies that messages will be
 // dropped waiting for raft node connection to be established.
 func TestRaftTransportCircuitBreaker(t *testing.T) {
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvflowcontrol/kvflowdispatch"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/rpc"
 	"github.com/cockroachdb/cockroach/pkg/rpc/nodedialer"
@@ -28,9 +29,11 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/netutil"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
+	"go.etcd.io/raft/v3/raftpb"
 )
 
 func TestRaftTransportStartNewQueue(t *testing.T) {
@@ -114,3 +117,37 @@ func TestRaftTransportStartNewQueue(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestRaftTransportSendQueueOverflowPolicy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	st := cluster.MakeTestingClusterSettings()
+	tp := NewDummyRaftTransport(st, tracing.NewTracer())
+
+	bulky := &kvserverpb.RaftMessageRequest{
+		Message: raftpb.Message{Type: raftpb.MsgApp},
+	}
+	heartbeat := &kvserverpb.RaftMessageRequest{
+		Heartbeats: []kvserverpb.RaftHeartbeat{{RangeID: 1}},
+	}
+	const bulkySize = 1 << 20
+
+	// With the uniform policy, only messages overflowing the queue are dropped.
+	require.False(t, tp.shouldDropBulky(bulky, bulkySize, raftSendBufferSize-1))
+
+	raftSendQueueOverflowPolicySetting.Override(ctx, &st.SV, int64(raftSendQueueOverflowPreferSmall))
+	require.False(t, tp.shouldDropBulky(bulky, bulkySize, raftSendQueueBulkyLimit-1))
+	require.True(t, tp.shouldDropBulky(bulky, bulkySize, raftSendQueueBulkyLimit))
+	require.False(t, tp.shouldDropBulky(bulky, 1<<10, raftSendQueueBulkyLimit))
+	require.False(t, tp.shouldDropBulky(heartbeat, bulkySize, raftSendQueueBulkyLimit))
+
+	// The peer metrics are created once per peer, and aggregated.
+	tp.peerMetrics(1).bulkyMessagesDropped.Inc(1)
+	tp.peerMetrics(1).bulkyMessagesDropped.Inc(1)
+	tp.peerMetrics(2).sendQueueOverflows.Inc(3)
+	require.Equal(t, int64(2), tp.peerMetrics(1).bulkyMessagesDropped.Value())
+	require.Equal(t, int64(2), tp.Metrics().BulkyMessagesDropped.Count())
+	require.Equal(t, int64(3), tp.Metrics().SendQueueOverflows.Count())
+}
@@ -38,8 +38,17 @@ type Cache struct {
 	mu    syncutil.Mutex
 	lru   partitionList
 	parts map[roachpb.RangeID]*partition
+	// protected is the set of ranges which are guaranteed a minimum share of
+	// the cache. See SetProtected.
+	protected map[roachpb.RangeID]struct{}
 }
 
+// protectedShare is the share of the cache's capacity which is split evenly
+// between the protected ranges. The remainder of the capacity is always
+// available to other ranges, so that protecting many ranges cannot starve the
+// rest of the store.
+const protectedShare = 0.5
+
 // Design
 //
 // Cache is designed to be a shared store-wide object which incurs low
@@ -75,6 +84,15 @@ type Cache struct {
 // just made to the partition are no longer stored in the cache and thus the
 // Cache stats shall not change.
 //
+// Eviction is LRU at the granularity of partitions, with one exception: ranges
+// can be marked as protected (see SetProtected), which guarantees each of them
+// an equal split of protectedShare of the cache's capacity. A protected
+// partition which does not exceed its guaranteed size is skipped over when
+// looking for a partition to evict. This adapts the partitioning of the cache
+// to the needs of ranges with followers lagging behind, which read entries from
+// the cache to catch up the followers, and would otherwise be evicted by hot
+// ranges and fall back to reading their log from storage.
+//
 // This approach admits several undesirable conditions, fortunately they aren't
 // practical concerns.
 //
@@ -117,9 +135,10 @@ func NewCache(maxBytes uint64) *Cache {
 		maxBytes = math.MaxInt32
 	}
 	return &Cache{
-		maxBytes: int32(maxBytes),
-		metrics:  makeMetrics(),
-		parts:    map[roachpb.RangeID]*partition{},
+		maxBytes:  int32(maxBytes),
+		metrics:   makeMetrics(),
+		parts:     map[roachpb.RangeID]*partition{},
+		protected: map[roachpb.RangeID]struct{}{},
 	}
 }
 
@@ -132,12 +151,39 @@ func (c *Cache) Metrics() Metrics {
 func (c *Cache) Drop(id roachpb.RangeID) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.setProtectedLocked(id, false)
 	p := c.getPartLocked(id, false /* create */, false /* recordUse */)
 	if p != nil {
 		c.updateGauges(c.evictPartitionLocked(p))
 	}
 }
 
+// SetProtected marks the specified range as protected or not. Protected ranges
+// are guaranteed a minimum share of the cache, and are not evicted to make room
+// for entries of other ranges as long as their cached entries fit into this
+// share. The share adapts to the number of protected ranges, see
+// protectedShare.
+//
+// Ranges with followers lagging behind the leader are expected to be protected,
+// so that the followers can be caught up without reading from storage.
+func (c *Cache) SetProtected(id roachpb.RangeID, protected bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setProtectedLocked(id, protected)
+}
+
+func (c *Cache) setProtectedLocked(id roachpb.RangeID, protected bool) {
+	if _, ok := c.protected[id]; ok == protected {
+		return
+	}
+	if protected {
+		c.protected[id] = struct{}{}
+	} else {
+		delete(c.protected, id)
+	}
+	c.metrics.ProtectedRanges.Update(int64(len(c.protected)))
+}
+
 // Add inserts ents into the cache. If truncate is true, the method also removes
 // all entries with indices equal to or greater than the indices of the entries
 // provided. ents is expected to consist of entries with a contiguous sequence
@@ -157,7 +203,7 @@ func (c *Cache) Add(id roachpb.RangeID, ents []raftpb.Entry, truncate bool) {
 	p := c.getPartLocked(id, add /* create */, true /* recordUse */)
 	if bytesGuessed > 0 {
 		c.evictLocked(bytesGuessed)
-		if len(c.parts) == 0 { // Get p again if we evicted everything.
+		if c.parts[id] != p { // Get p again if we evicted it.
 			p = c.getPartLocked(id, true /* create */, false /* recordUse */)
 		}
 		// Use the atomic (load|set)Size partition methods to avoid a race condition
@@ -221,6 +267,7 @@ func (c *Cache) Get(id roachpb.RangeID, idx kvpb.RaftIndex) (e raftpb.Entry, ok
 	p := c.getPartLocked(id, false /* create */, true /* recordUse */)
 	c.mu.Unlock()
 	if p == nil {
+		c.metrics.Misses.Inc(1)
 		return e, false
 	}
 	p.mu.RLock()
@@ -229,6 +276,8 @@ func (c *Cache) Get(id roachpb.RangeID, idx kvpb.RaftIndex) (e raftpb.Entry, ok
 	if ok {
 		c.metrics.Hits.Inc(1)
 		c.metrics.ReadBytes.Inc(int64(e.Size()))
+	} else {
+		c.metrics.Misses.Inc(1)
 	}
 	return e, ok
 }
@@ -247,6 +296,7 @@ func (c *Cache) Scan(
 	p := c.getPartLocked(id, false /* create */, true /* recordUse */)
 	c.mu.Unlock()
 	if p == nil {
+		c.metrics.Misses.Inc(1)
 		return ents, 0, lo, false
 	}
 	p.mu.RLock()
@@ -259,6 +309,8 @@ func (c *Cache) Scan(
 	c.metrics.ReadBytes.Inc(int64(bytes))
 	if nextIdx == hi || exceededMaxBytes {
 		c.metrics.Hits.Inc(1)
+	} else {
+		c.metrics.Misses.Inc(1)
 	}
 	return ents, bytes, nextIdx, exceededMaxBytes
 }
@@ -282,8 +334,25 @@ func (c *Cache) getPartLocked(id roachpb.RangeID, create, recordUse bool) *parti
 func (c *Cache) evictLocked(toAdd int32) {
 	bytes := c.addBytes(toAdd)
 	for bytes > c.maxBytes && len(c.parts) > 0 {
-		bytes, _ = c.evictPartitionLocked(c.lru.back())
+		bytes, _ = c.evictPartitionLocked(c.evictionCandidateLocked())
+	}
+}
+
+// evictionCandidateLocked returns the least recently used partition which is
+// not protected, or exceeds its guaranteed share of the cache. If there is no
+// such partition, the least recently used partition is returned.
+func (c *Cache) evictionCandidateLocked() *partition {
+	back := c.lru.back()
+	if len(c.protected) == 0 {
+		return back
+	}
+	guaranteed := int32(float64(c.maxBytes) * protectedShare / float64(len(c.protected)))
+	for p := back; p != &c.lru.root; p = p.prev {
+		if _, ok := c.protected[p.id]; !ok || p.loadSize().bytes() > guaranteed {
+			return p
+		}
 	}
+	return back
 }
 
 func (c *Cache) evictPartitionLocked(p *partition) (updatedBytes, updatedEntries int32) {
@@ -564,6 +564,37 @@ func TestEntryCacheEviction(t *testing.T) {
 	}
 }
 
+func TestEntryCacheProtectedEviction(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	c := NewCache(3000 + 3*uint64(partitionSize))
+	c.SetProtected(1, true)
+	require.Equal(t, int64(1), c.Metrics().ProtectedRanges.Value())
+	for id := roachpb.RangeID(1); id <= 3; id++ {
+		c.Add(id, []raftpb.Entry{newEntry(1, 1000)}, true)
+	}
+	// Range 1 is the least recently used, but it is protected and fits into its
+	// guaranteed share of the cache, so range 2 is evicted instead.
+	c.Add(3, []raftpb.Entry{newEntry(2, 1000)}, false)
+	_, ok := c.Get(1, 1)
+	require.True(t, ok)
+	_, ok = c.Get(2, 1)
+	require.False(t, ok)
+	require.Equal(t, int64(1), c.Metrics().Misses.Count())
+
+	// Once unprotected, range 1 is evicted like any other range.
+	c.SetProtected(1, false)
+	require.Equal(t, int64(0), c.Metrics().ProtectedRanges.Value())
+	c.Add(3, []raftpb.Entry{newEntry(3, 1000)}, false)
+	_, ok = c.Get(1, 1)
+	require.False(t, ok)
+	require.Equal(t, int64(2), c.Metrics().Misses.Count())
+
+	// Dropping a range also drops its protection.
+	c.SetProtected(3, true)
+	c.Drop(3)
+	require.Equal(t, int64(0), c.Metrics().ProtectedRanges.Value())
+}
+
 // TestConcurrentUpdates ensures that concurrent updates to the same do not
 // race with each other.
 func TestConcurrentUpdates(t *testing.T) {
@@ -37,6 +37,18 @@ var (
 		Measurement: "Hits",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaEntryCacheMisses = metric.Metadata{
+		Name:        "raft.entrycache.misses",
+		Help:        "Number of unsuccessful cache lookups in the Raft entry cache",
+		Measurement: "Misses",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaEntryCacheProtectedRanges = metric.Metadata{
+		Name:        "raft.entrycache.protected_ranges",
+		Help:        "Number of ranges guaranteed a minimum share of the Raft entry cache due to lagging followers",
+		Measurement: "Ranges",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaEntryCacheReadBytes = metric.Metadata{
 		Name:        "raft.entrycache.read_bytes",
 		Help:        "Counter of bytes in entries returned from the Raft entry cache",
@@ -49,19 +61,23 @@ var (
 type Metrics struct {
 	// NB: the values in the gauges are updated asynchronously and may hold stale
 	// values in the face of concurrent updates.
-	Size      *metric.Gauge
-	Bytes     *metric.Gauge
-	Accesses  *metric.Counter
-	Hits      *metric.Counter
-	ReadBytes *metric.Counter
+	Size            *metric.Gauge
+	Bytes           *metric.Gauge
+	Accesses        *metric.Counter
+	Hits            *metric.Counter
+	Misses          *metric.Counter
+	ReadBytes       *metric.Counter
+	ProtectedRanges *metric.Gauge
 }
 
 func makeMetrics() Metrics {
 	return Metrics{
-		Size:      metric.NewGauge(metaEntryCacheSize),
-		Bytes:     metric.NewGauge(metaEntryCacheBytes),
-		Accesses:  metric.NewCounter(metaEntryCacheAccesses),
-		Hits:      metric.NewCounter(metaEntryCacheHits),
-		ReadBytes: metric.NewCounter(metaEntryCacheReadBytes),
+		Size:            metric.NewGauge(metaEntryCacheSize),
+		Bytes:           metric.NewGauge(metaEntryCacheBytes),
+		Accesses:        metric.NewCounter(metaEntryCacheAccesses),
+		Hits:            metric.NewCounter(metaEntryCacheHits),
+		Misses:          metric.NewCounter(metaEntryCacheMisses),
+		ReadBytes:       metric.NewCounter(metaEntryCacheReadBytes),
+		ProtectedRanges: metric.NewGauge(metaEntryCacheProtectedRanges),
 	}
 }
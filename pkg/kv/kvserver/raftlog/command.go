@@ -80,6 +80,12 @@ func (c *ReplicatedCmd) IsLocal() bool {
 	return false
 }
 
+// EntrySize implements apply.Command. It returns the size of the payload of
+// the log entry that this Command applies.
+func (c *ReplicatedCmd) EntrySize() int {
+	return len(c.Entry.Data)
+}
+
 // Ctx implements apply.Command. It returns context.Background().
 func (c *ReplicatedCmd) Ctx() context.Context {
 	return context.Background()
@@ -184,3 +184,25 @@ func ReplicaMayNeedSnapshot(
 	//    truncation operations using latching.
 	return NoSnapshotNeeded
 }
+
+// ReplicaLag returns the number of log entries up to the given index which the
+// given peer replica has yet to receive, according to the raft log. The peer
+// can't apply entries past its match index, so this bounds from below the
+// number of entries it has yet to apply to catch up with the index. ok is
+// false if the lag is unknown, i.e. if our local replica is not the raft
+// leader or isn't tracking the progress of the peer.
+func ReplicaLag(
+	st *raft.Status, index kvpb.RaftIndex, replicaID roachpb.ReplicaID,
+) (lag uint64, ok bool) {
+	if st == nil || st.RaftState != raft.StateLeader {
+		return 0, false
+	}
+	progress, ok := st.Progress[uint64(replicaID)]
+	if !ok {
+		return 0, false
+	}
+	if progress.Match >= uint64(index) {
+		return 0, true
+	}
+	return uint64(index) - progress.Match, true
+}
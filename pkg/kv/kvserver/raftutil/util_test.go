@@ -205,3 +205,66 @@ func TestReplicaMayNeedSnapshot(t *testing.T) {
 		})
 	}
 }
+
+func TestReplicaLag(t *testing.T) {
+	const replicaID = 3
+	const index = 100
+	makeStatus := func(f func(*raft.Status)) *raft.Status {
+		st := new(raft.Status)
+		st.Progress = make(map[uint64]tracker.Progress)
+		f(st)
+		return st
+	}
+
+	tests := []struct {
+		name      string
+		st        *raft.Status
+		expectLag uint64
+		expectOK  bool
+	}{
+		{
+			name: "local follower",
+			st: makeStatus(func(st *raft.Status) {
+				st.RaftState = raft.StateFollower
+			}),
+			expectOK: false,
+		},
+		{
+			name: "local leader, no progress for peer",
+			st: makeStatus(func(st *raft.Status) {
+				st.RaftState = raft.StateLeader
+			}),
+			expectOK: false,
+		},
+		{
+			name: "local leader, peer behind",
+			st: makeStatus(func(st *raft.Status) {
+				st.RaftState = raft.StateLeader
+				st.Progress[replicaID] = tracker.Progress{State: tracker.StateReplicate, Match: 60}
+			}),
+			expectLag: 40,
+			expectOK:  true,
+		},
+		{
+			name: "local leader, peer caught up",
+			st: makeStatus(func(st *raft.Status) {
+				st.RaftState = raft.StateLeader
+				st.Progress[replicaID] = tracker.Progress{State: tracker.StateReplicate, Match: 120}
+			}),
+			expectLag: 0,
+			expectOK:  true,
+		},
+		{
+			name:     "nil raft status",
+			st:       nil,
+			expectOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lag, ok := ReplicaLag(tt.st, index, replicaID)
+			require.Equal(t, tt.expectOK, ok)
+			require.Equal(t, tt.expectLag, lag)
+		})
+	}
+}
@@ -107,7 +107,9 @@ func runBenchmarkRangefeed(b *testing.B, opts benchmarkRangefeedOpts) {
 		streams[i] = &noopStream{ctx: ctx}
 		futures[i] = &future.ErrorFuture{}
 		ok, _ := p.Register(span, hlc.MinTimestamp, nil,
-			withDiff, withFiltering, streams[i], nil, futures[i])
+			withDiff, withFiltering, 0, /* resolvedTSInterval */
+			kvpb.RangeFeedRequest_DISCONNECT, nil, /* mvccHistoryMutationToken */
+			streams[i], nil, futures[i])
 		require.True(b, ok)
 	}
 
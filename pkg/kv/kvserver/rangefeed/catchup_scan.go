@@ -68,7 +68,11 @@ type CatchUpIterator struct {
 	span      roachpb.Span
 	startTime hlc.Timestamp // exclusive
 	pacer     *admission.Pacer
-	OnEmit    func(key, endKey roachpb.Key, ts hlc.Timestamp, vh enginepb.MVCCValueHeader)
+	// parts are the iterators over the sub-spans of a parallel catch-up scan,
+	// see NewParallelCatchUpIterator. The iterator has no iterator of its own
+	// if set.
+	parts  []*CatchUpIterator
+	OnEmit func(key, endKey roachpb.Key, ts hlc.Timestamp, vh enginepb.MVCCValueHeader)
 }
 
 // NewCatchUpIterator returns a CatchUpIterator for the given Reader over the
@@ -113,8 +117,14 @@ func NewCatchUpIterator(
 // Close closes the iterator and calls the instantiator-supplied close
 // callback.
 func (i *CatchUpIterator) Close() {
-	i.simpleCatchupIter.Close()
-	i.pacer.Close()
+	if i.parts != nil {
+		for _, part := range i.parts {
+			part.Close()
+		}
+	} else {
+		i.simpleCatchupIter.Close()
+		i.pacer.Close()
+	}
 	if i.close != nil {
 		i.close()
 	}
@@ -142,6 +152,9 @@ type outputEventFn func(e *kvpb.RangeFeedEvent) error
 func (i *CatchUpIterator) CatchUpScan(
 	ctx context.Context, outputFn outputEventFn, withDiff bool, withFiltering bool,
 ) error {
+	if i.parts != nil {
+		return i.parallelCatchUpScan(ctx, outputFn, withDiff, withFiltering)
+	}
 	var a bufalloc.ByteAllocator
 	// MVCCIterator will encounter historical values for each key in
 	// reverse-chronological order. To output in chronological order, store
@@ -0,0 +1,206 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package rangefeed
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+)
+
+// parallelCatchUpScanBufferSize is the number of events each sub-span of a
+// parallel catch-up scan buffers ahead of its emission.
+const parallelCatchUpScanBufferSize = 1024
+
+// NewParallelCatchUpIterator returns a CatchUpIterator which scans the
+// sub-spans of span delimited by splitKeys concurrently, each with its own
+// iterator and its own pacer forked from the given one. The events are still
+// emitted in key order: the events of each sub-span are buffered until the
+// events of the preceding sub-spans have been emitted, and the scan of a
+// sub-span blocks once its buffer is full.
+//
+// All the iterators are created by this call, so they observe the same
+// state of the reader. MVCC range tombstones which straddle split keys are
+// emitted in fragments, one per sub-span. The closer is called once all the
+// iterators are closed.
+func NewParallelCatchUpIterator(
+	ctx context.Context,
+	reader storage.Reader,
+	span roachpb.Span,
+	splitKeys []roachpb.Key,
+	startTime hlc.Timestamp,
+	closer func(),
+	pacer *admission.Pacer,
+) (*CatchUpIterator, error) {
+	if len(splitKeys) == 0 {
+		return NewCatchUpIterator(ctx, reader, span, startTime, closer, pacer)
+	}
+	i := &CatchUpIterator{
+		close:     closer,
+		span:      span,
+		startTime: startTime,
+	}
+	start := span.Key
+	for j := 0; j <= len(splitKeys); j++ {
+		end := span.EndKey
+		if j < len(splitKeys) {
+			end = splitKeys[j]
+		}
+		partPacer := pacer
+		if j > 0 {
+			partPacer = pacer.Fork()
+		}
+		part, err := NewCatchUpIterator(ctx, reader, roachpb.Span{Key: start, EndKey: end},
+			startTime, nil /* closer */, partPacer)
+		if err != nil {
+			if j > 0 {
+				partPacer.Close()
+			}
+			for _, part := range i.parts {
+				part.Close()
+			}
+			return nil, err
+		}
+		i.parts = append(i.parts, part)
+		start = end
+	}
+	return i, nil
+}
+
+// parallelCatchUpScan runs the catch-up scans of the parts of the iterator
+// concurrently, and emits their events in the order of the parts.
+func (i *CatchUpIterator) parallelCatchUpScan(
+	ctx context.Context, outputFn outputEventFn, withDiff bool, withFiltering bool,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	g := ctxgroup.WithContext(ctx)
+	eventChs := make([]chan *kvpb.RangeFeedEvent, len(i.parts))
+	// errs[j] is set before eventChs[j] is closed.
+	errs := make([]error, len(i.parts))
+	for j := range i.parts {
+		j, part := j, i.parts[j]
+		part.OnEmit = i.OnEmit
+		eventCh := make(chan *kvpb.RangeFeedEvent, parallelCatchUpScanBufferSize)
+		eventChs[j] = eventCh
+		g.GoCtx(func(ctx context.Context) error {
+			defer close(eventCh)
+			errs[j] = part.CatchUpScan(ctx, func(e *kvpb.RangeFeedEvent) error {
+				// The event may be reused by the caller once this returns, but
+				// the memory it references isn't.
+				ev := *e
+				select {
+				case eventCh <- &ev:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}, withDiff, withFiltering)
+			return errs[j]
+		})
+	}
+
+	for j, eventCh := range eventChs {
+		for e := range eventCh {
+			if err := outputFn(e); err != nil {
+				cancel()
+				_ = g.Wait()
+				return err
+			}
+		}
+		if errs[j] != nil {
+			// Don't emit the events of the following parts, which would skip
+			// over the keys that this part failed to scan.
+			cancel()
+			_ = g.Wait()
+			return errs[j]
+		}
+	}
+	return g.Wait()
+}
+
+// CatchUpScanSplitKeys returns the keys which split span into at most parts
+// sub-spans of roughly the same size on disk, as estimated by the given
+// function. The keys are found by bisection, so they don't necessarily exist.
+// It returns no keys if the span can't be split.
+func CatchUpScanSplitKeys(
+	span roachpb.Span, parts int, diskBytes func(from, to roachpb.Key) (uint64, error),
+) ([]roachpb.Key, error) {
+	if parts <= 1 {
+		return nil, nil
+	}
+	total, err := diskBytes(span.Key, span.EndKey)
+	if err != nil || total == 0 {
+		return nil, err
+	}
+	// maxBisections bounds the number of estimations for each split key.
+	const maxBisections = 32
+	var splitKeys []roachpb.Key
+	lo := span.Key
+	for k := 1; k < parts; k++ {
+		target := total / uint64(parts) * uint64(k)
+		hi := span.EndKey
+		for n := 0; n < maxBisections; n++ {
+			mid := midKey(lo, hi)
+			if mid.Compare(lo) <= 0 || mid.Compare(hi) >= 0 {
+				break
+			}
+			bytes, err := diskBytes(span.Key, mid)
+			if err != nil {
+				return nil, err
+			}
+			if bytes < target {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		if hi.Compare(span.EndKey) >= 0 {
+			break
+		}
+		if len(splitKeys) == 0 || splitKeys[len(splitKeys)-1].Compare(hi) < 0 {
+			splitKeys = append(splitKeys, hi)
+		}
+		lo = hi
+	}
+	return splitKeys, nil
+}
+
+// midKey returns the key halfway between a and b, when interpreting the keys
+// as fractions in [0, 1). The result is a itself if there is no room between
+// the keys at the precision of the longest one plus a byte.
+func midKey(a, b roachpb.Key) roachpb.Key {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	n++
+	pad := func(k roachpb.Key) *big.Int {
+		buf := make([]byte, n)
+		copy(buf, k)
+		return new(big.Int).SetBytes(buf)
+	}
+	sum := pad(a)
+	sum.Add(sum, pad(b))
+	sum.Rsh(sum, 1)
+	mid := roachpb.Key(sum.FillBytes(make([]byte, n)))
+	// Drop the trailing zeros which aren't needed to order the key after a.
+	for len(mid) > len(a) && mid[len(mid)-1] == 0 {
+		mid = mid[:len(mid)-1]
+	}
+	return mid
+}
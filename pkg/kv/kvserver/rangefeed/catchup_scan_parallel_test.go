@@ -0,0 +1,154 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package rangefeed
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParallelCatchUpScan tests that a parallel catch-up scan emits the same
+// events as a serial one, in the same order.
+func TestParallelCatchUpScan(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+	const numKeys = 100
+	for i := 0; i < numKeys; i++ {
+		key := roachpb.Key(fmt.Sprintf("k%03d", i))
+		for ts := int64(1); ts <= 3; ts++ {
+			_, err := storage.MVCCPut(ctx, eng, key, hlc.Timestamp{WallTime: ts},
+				roachpb.MakeValueFromString(fmt.Sprintf("v%d", ts)), storage.MVCCWriteOptions{})
+			require.NoError(t, err)
+		}
+	}
+	span := roachpb.Span{Key: roachpb.Key("k"), EndKey: roachpb.Key("l")}
+	startTime := hlc.Timestamp{WallTime: 1}
+
+	scan := func(splitKeys []roachpb.Key, withDiff bool) []*kvpb.RangeFeedEvent {
+		iter, err := NewParallelCatchUpIterator(ctx, eng, span, splitKeys, startTime, nil, nil)
+		require.NoError(t, err)
+		defer iter.Close()
+		var events []*kvpb.RangeFeedEvent
+		require.NoError(t, iter.CatchUpScan(ctx, func(e *kvpb.RangeFeedEvent) error {
+			ev := *e
+			events = append(events, &ev)
+			return nil
+		}, withDiff, false /* withFiltering */))
+		return events
+	}
+
+	testutils.RunTrueAndFalse(t, "withDiff", func(t *testing.T, withDiff bool) {
+		exp := scan(nil /* splitKeys */, withDiff)
+		require.Len(t, exp, numKeys*2)
+		for _, splitKeys := range [][]roachpb.Key{
+			{roachpb.Key("k050")},
+			{roachpb.Key("k010"), roachpb.Key("k0105"), roachpb.Key("k077")},
+			// Sub-spans without any key.
+			{roachpb.Key("k000"), roachpb.Key("k100")},
+		} {
+			require.Equal(t, exp, scan(splitKeys, withDiff), "split keys %v", splitKeys)
+		}
+	})
+
+	// An error of the output function stops all the sub-span scans.
+	iter, err := NewParallelCatchUpIterator(ctx, eng, span,
+		[]roachpb.Key{roachpb.Key("k025"), roachpb.Key("k050"), roachpb.Key("k075")},
+		startTime, nil, nil)
+	require.NoError(t, err)
+	defer iter.Close()
+	var emitted int
+	injected := errors.New("injected")
+	err = iter.CatchUpScan(ctx, func(e *kvpb.RangeFeedEvent) error {
+		if emitted++; emitted == 60 {
+			return injected
+		}
+		return nil
+	}, false /* withDiff */, false /* withFiltering */)
+	require.ErrorIs(t, err, injected)
+	require.Equal(t, 60, emitted)
+}
+
+func TestCatchUpScanSplitKeys(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// The data is spread uniformly over the keys with a first byte in [a, e),
+	// with 100 bytes per first byte.
+	diskBytes := func(from, to roachpb.Key) (uint64, error) {
+		pos := func(k roachpb.Key) uint64 {
+			if len(k) == 0 || k[0] < 'a' {
+				return 0
+			}
+			if k[0] >= 'e' {
+				return 400
+			}
+			frac := uint64(0)
+			if len(k) > 1 {
+				frac = uint64(k[1]) * 100 / 256
+			}
+			return uint64(k[0]-'a')*100 + frac
+		}
+		return pos(to) - pos(from), nil
+	}
+	span := roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("z")}
+
+	splitKeys, err := CatchUpScanSplitKeys(span, 1, diskBytes)
+	require.NoError(t, err)
+	require.Empty(t, splitKeys)
+
+	splitKeys, err = CatchUpScanSplitKeys(span, 4, diskBytes)
+	require.NoError(t, err)
+	require.Len(t, splitKeys, 3)
+	prev := span.Key
+	for i, k := range splitKeys {
+		require.True(t, prev.Compare(k) < 0, "split keys %v", splitKeys)
+		bytes, err := diskBytes(span.Key, k)
+		require.NoError(t, err)
+		require.InDelta(t, 100*(i+1), bytes, 2, "split key %s", k)
+		prev = k
+	}
+	require.True(t, prev.Compare(span.EndKey) < 0)
+
+	// An empty span isn't split.
+	splitKeys, err = CatchUpScanSplitKeys(
+		roachpb.Span{Key: roachpb.Key("x"), EndKey: roachpb.Key("y")}, 4, diskBytes)
+	require.NoError(t, err)
+	require.Empty(t, splitKeys)
+}
+
+func TestMidKey(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	for _, tc := range []struct {
+		a, b, exp roachpb.Key
+	}{
+		{roachpb.Key("a"), roachpb.Key("c"), roachpb.Key("b")},
+		{roachpb.Key("a"), roachpb.Key("b"), roachpb.Key("a\x80")},
+		{roachpb.Key(""), roachpb.Key("\x02"), roachpb.Key("\x01")},
+		{roachpb.Key("ab"), roachpb.Key("ab\x00"), roachpb.Key("ab")},
+	} {
+		require.Equal(t, tc.exp, midKey(tc.a, tc.b), "midKey(%q, %q)", tc.a, tc.b)
+	}
+}
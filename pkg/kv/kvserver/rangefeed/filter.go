@@ -40,6 +40,12 @@ func newFilterFromRegistry(reg *registry) *Filter {
 	return f
 }
 
+// NeedPrevVals returns whether any registration requires MVCCWriteValueOp and
+// MVCCCommitIntentOp operations to contain populated PrevValue fields.
+func (r *Filter) NeedPrevVals() bool {
+	return r.needPrevVals.Len() > 0
+}
+
 // NeedPrevVal returns whether the Processor requires MVCCWriteValueOp and
 // MVCCCommitIntentOp operations over the specified key span to contain
 // populated PrevValue fields.
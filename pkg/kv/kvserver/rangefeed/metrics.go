@@ -43,6 +43,36 @@ var (
 		Measurement: "Registrations",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRangeFeedOverflowBlocked = metric.Metadata{
+		Name:        "kv.rangefeed.overflow.blocked",
+		Help:        "Number of times a RangeFeed registration with the BLOCK overflow policy paused the processor on a full buffer",
+		Measurement: "Events",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeFeedOverflowDisconnected = metric.Metadata{
+		Name:        "kv.rangefeed.overflow.disconnected",
+		Help:        "Number of RangeFeed registrations disconnected because their buffer overflowed",
+		Measurement: "Registrations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeFeedOverflowDegraded = metric.Metadata{
+		Name:        "kv.rangefeed.overflow.degraded",
+		Help:        "Number of times a RangeFeed registration with the CHECKPOINT_ONLY overflow policy started dropping events because its buffer overflowed",
+		Measurement: "Events",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeFeedOverflowDroppedEvents = metric.Metadata{
+		Name:        "kv.rangefeed.overflow.dropped_events",
+		Help:        "Number of RangeFeed events dropped by registrations with the CHECKPOINT_ONLY overflow policy",
+		Measurement: "Events",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeFeedPrevValueReadBytes = metric.Metadata{
+		Name:        "kv.rangefeed.prev_value_read_bytes",
+		Help:        "Bytes of previous values read on the apply path for RangeFeed registrations requesting diffs",
+		Measurement: "Bytes",
+		Unit:        metric.Unit_BYTES,
+	}
 	metaRangeFeedProcessorsGO = metric.Metadata{
 		Name:        "kv.rangefeed.processors_goroutine",
 		Help:        "Number of active RangeFeed processors using goroutines",
@@ -71,10 +101,17 @@ var (
 
 // Metrics are for production monitoring of RangeFeeds.
 type Metrics struct {
-	RangeFeedCatchUpScanNanos        *metric.Counter
-	RangeFeedBudgetExhausted         *metric.Counter
-	RangeFeedBudgetBlocked           *metric.Counter
-	RangeFeedRegistrations           *metric.Gauge
+	RangeFeedCatchUpScanNanos *metric.Counter
+	RangeFeedBudgetExhausted  *metric.Counter
+	RangeFeedBudgetBlocked    *metric.Counter
+	RangeFeedRegistrations    *metric.Gauge
+	// Metrics counting the actions taken on registrations with full buffers,
+	// according to their overflow policy.
+	RangeFeedOverflowBlocked         *metric.Counter
+	RangeFeedOverflowDisconnected    *metric.Counter
+	RangeFeedOverflowDegraded        *metric.Counter
+	RangeFeedOverflowDroppedEvents   *metric.Counter
+	RangeFeedPrevValueReadBytes      *metric.Counter
 	RangeFeedSlowClosedTimestampLogN log.EveryN
 	// RangeFeedSlowClosedTimestampNudgeSem bounds the amount of work that can be
 	// spun up on behalf of the RangeFeed nudger. We don't expect to hit this
@@ -98,6 +135,11 @@ func NewMetrics() *Metrics {
 		RangeFeedBudgetExhausted:             metric.NewCounter(metaRangeFeedExhausted),
 		RangeFeedBudgetBlocked:               metric.NewCounter(metaRangeFeedBudgetBlocked),
 		RangeFeedRegistrations:               metric.NewGauge(metaRangeFeedRegistrations),
+		RangeFeedOverflowBlocked:             metric.NewCounter(metaRangeFeedOverflowBlocked),
+		RangeFeedOverflowDisconnected:        metric.NewCounter(metaRangeFeedOverflowDisconnected),
+		RangeFeedOverflowDegraded:            metric.NewCounter(metaRangeFeedOverflowDegraded),
+		RangeFeedOverflowDroppedEvents:       metric.NewCounter(metaRangeFeedOverflowDroppedEvents),
+		RangeFeedPrevValueReadBytes:          metric.NewCounter(metaRangeFeedPrevValueReadBytes),
 		RangeFeedSlowClosedTimestampLogN:     log.Every(5 * time.Second),
 		RangeFeedSlowClosedTimestampNudgeSem: make(chan struct{}, 1024),
 		RangeFeedProcessorsGO:                metric.NewGauge(metaRangeFeedProcessorsGO),
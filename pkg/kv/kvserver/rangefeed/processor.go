@@ -169,6 +169,13 @@ type Processor interface {
 	// updated operation filter that includes the operations required by the new
 	// registration.
 	//
+	// If resolvedTSInterval is non-zero, checkpoints are published to the
+	// registration at most once per interval, each carrying the latest resolved
+	// timestamp. The overflowPolicy determines how the registration is handled
+	// if its consumer can't keep up with the events published to it. If
+	// mvccHistoryMutationToken is non-empty, MVCC history mutations carrying the
+	// same token are published to the registration instead of disconnecting it.
+	//
 	// NB: startTS is exclusive; the first possible event will be at startTS.Next().
 	Register(
 		span roachpb.RSpan,
@@ -176,6 +183,9 @@ type Processor interface {
 		catchUpIter *CatchUpIterator,
 		withDiff bool,
 		withFiltering bool,
+		resolvedTSInterval time.Duration,
+		overflowPolicy kvpb.RangeFeedRequest_OverflowPolicy,
+		mvccHistoryMutationToken []byte,
 		stream Stream,
 		disconnectFn func(),
 		done *future.ErrorFuture,
@@ -183,6 +193,10 @@ type Processor interface {
 	// DisconnectSpanWithErr disconnects all rangefeed registrations that overlap
 	// the given span with the given error.
 	DisconnectSpanWithErr(span roachpb.Span, pErr *kvpb.Error)
+	// DisconnectUncoordinatedSpanWithErr is like DisconnectSpanWithErr, but
+	// doesn't disconnect registrations that coordinated an MVCC history mutation
+	// carrying the given token.
+	DisconnectUncoordinatedSpanWithErr(span roachpb.Span, token []byte, pErr *kvpb.Error)
 	// Filter returns a new operation filter based on the registrations attached to
 	// the processor. Returns nil if the processor has been stopped already.
 	Filter() *Filter
@@ -209,6 +223,14 @@ type Processor interface {
 	// EventChanTimeout configuration. If the method returns false, the processor
 	// will have been stopped, so calling Stop is not necessary.
 	ForwardClosedTS(ctx context.Context, closedTS hlc.Timestamp) bool
+	// ConsumeMVCCHistoryMutation informs the rangefeed processor of an MVCC
+	// history mutation across the given span, coordinated using the given
+	// token. Registrations that coordinated the mutation receive an MVCC history
+	// mutation event, all others are disconnected. It returns false if
+	// consuming the mutation hit a timeout, as specified by the EventChanTimeout
+	// configuration. If the method returns false, the processor will have been
+	// stopped, so calling Stop is not necessary.
+	ConsumeMVCCHistoryMutation(ctx context.Context, span roachpb.Span, token []byte) bool
 
 	// External notification integration.
 
@@ -252,6 +274,7 @@ type event struct {
 	ct      ctEvent
 	initRTS initRTSEvent
 	sst     *sstEvent
+	histMut *histMutEvent
 	sync    *syncEvent
 	// Budget allocated to process the event.
 	alloc *SharedBudgetAllocation
@@ -271,6 +294,11 @@ type sstEvent struct {
 	ts   hlc.Timestamp
 }
 
+type histMutEvent struct {
+	span  roachpb.Span
+	token []byte
+}
+
 type syncEvent struct {
 	c chan struct{}
 	// This setting is used in conjunction with c in tests in order to ensure that
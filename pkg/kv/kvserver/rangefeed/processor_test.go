@@ -263,6 +263,12 @@ func withEventTimeout(timeout time.Duration) option {
 	}
 }
 
+func withSettings(st *cluster.Settings) option {
+	return func(config *testConfig) {
+		config.Settings = st
+	}
+}
+
 func withSpan(span roachpb.RSpan) option {
 	return func(config *testConfig) {
 		config.Span = span
@@ -410,6 +416,9 @@ func TestProcessorBasic(t *testing.T) {
 		nil,   /* catchUpIter */
 		false, /* withDiff */
 		false, /* withFiltering */
+		0,     /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
 		r1Stream,
 		func() {},
 		&r1Done,
@@ -431,6 +440,7 @@ func TestProcessorBasic(t *testing.T) {
 	require.True(t, r1Filter.NeedVal(roachpb.Span{Key: roachpb.Key("a")}))
 	require.True(t, r1Filter.NeedVal(roachpb.Span{Key: roachpb.Key("d"), EndKey: roachpb.Key("r")}))
 	require.False(t, r1Filter.NeedVal(roachpb.Span{Key: roachpb.Key("z")}))
+	require.False(t, r1Filter.NeedPrevVals())
 	require.False(t, r1Filter.NeedPrevVal(roachpb.Span{Key: roachpb.Key("a")}))
 	require.False(t,
 		r1Filter.NeedPrevVal(roachpb.Span{Key: roachpb.Key("d"), EndKey: roachpb.Key("r")}))
@@ -544,6 +554,9 @@ func TestProcessorBasic(t *testing.T) {
 		nil,   /* catchUpIter */
 		true,  /* withDiff */
 		false, /* withFiltering */
+		0,     /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
 		r2Stream,
 		func() {},
 		&r2Done,
@@ -567,6 +580,7 @@ func TestProcessorBasic(t *testing.T) {
 	require.True(t,
 		r1And2Filter.NeedVal(roachpb.Span{Key: roachpb.Key("y"), EndKey: roachpb.Key("zzz")}))
 	require.False(t, r1And2Filter.NeedVal(roachpb.Span{Key: roachpb.Key("zzz")}))
+	require.True(t, r1And2Filter.NeedPrevVals())
 	require.False(t, r1And2Filter.NeedPrevVal(roachpb.Span{Key: roachpb.Key("a")}))
 	require.True(t, r1And2Filter.NeedPrevVal(roachpb.Span{Key: roachpb.Key("y")}))
 	require.True(t,
@@ -641,6 +655,9 @@ func TestProcessorBasic(t *testing.T) {
 		nil,   /* catchUpIter */
 		false, /* withDiff */
 		false, /* withFiltering */
+		0,     /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
 		r3Stream,
 		func() {},
 		&r3Done,
@@ -663,6 +680,9 @@ func TestProcessorSlowConsumer(t *testing.T) {
 		nil,   /* catchUpIter */
 		false, /* withDiff */
 		false, /* withFiltering */
+		0,     /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
 		r1Stream,
 		func() {},
 		&r1Done,
@@ -675,6 +695,9 @@ func TestProcessorSlowConsumer(t *testing.T) {
 		nil,   /* catchUpIter */
 		false, /* withDiff */
 		false, /* withFiltering */
+		0,     /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
 		r2Stream,
 		func() {},
 		&r2Done,
@@ -768,6 +791,9 @@ func TestProcessorMemoryBudgetExceeded(t *testing.T) {
 		nil,   /* catchUpIter */
 		false, /* withDiff */
 		false, /* withFiltering */
+		0,     /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
 		r1Stream,
 		func() {},
 		&r1Done,
@@ -821,6 +847,9 @@ func TestProcessorMemoryBudgetReleased(t *testing.T) {
 		nil,   /* catchUpIter */
 		false, /* withDiff */
 		false, /* withFiltering */
+		0,     /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
 		r1Stream,
 		func() {},
 		&r1Done,
@@ -901,6 +930,9 @@ func TestProcessorInitializeResolvedTimestamp(t *testing.T) {
 		nil,   /* catchUpIter */
 		false, /* withDiff */
 		false, /* withFiltering */
+		0,     /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
 		r1Stream,
 		func() {},
 		&r1Done,
@@ -951,6 +983,62 @@ func TestProcessorInitializeResolvedTimestamp(t *testing.T) {
 	require.Equal(t, chEvent, r1Stream.Events())
 }
 
+// TestProcessorPublishesWithheldCheckpoints tests that a checkpoint withheld
+// from a registration with a resolved timestamp interval is delivered once the
+// interval has elapsed, even if the resolved timestamp doesn't advance anymore
+// and transaction pushes are disabled.
+func TestProcessorPublishesWithheldCheckpoints(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	p, h, stopper := newTestProcessor(t)
+	ctx := context.Background()
+	defer stopper.Stop(ctx)
+
+	span := roachpb.RSpan{Key: roachpb.RKey("a"), EndKey: roachpb.RKey("m")}
+	stream := newTestStream()
+	var done future.ErrorFuture
+	ok, _ := p.Register(
+		span,
+		hlc.Timestamp{WallTime: 1},
+		nil,         /* catchUpIter */
+		false,       /* withDiff */
+		false,       /* withFiltering */
+		time.Second, /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
+		stream,
+		func() {},
+		&done,
+	)
+	require.True(t, ok)
+	h.syncEventAndRegistrations()
+	checkpoint := func(wall int64) *kvpb.RangeFeedEvent {
+		return rangeFeedCheckpoint(span.AsRawSpanWithNoLocals(), hlc.Timestamp{WallTime: wall})
+	}
+	require.Equal(t, []*kvpb.RangeFeedEvent{checkpoint(1)}, stream.Events())
+
+	// The first paced checkpoint is published immediately. Further advances of
+	// the closed timestamp within the interval are withheld.
+	p.ForwardClosedTS(ctx, hlc.Timestamp{WallTime: 3})
+	h.syncEventAndRegistrations()
+	require.Equal(t, []*kvpb.RangeFeedEvent{checkpoint(3)}, stream.Events())
+	p.ForwardClosedTS(ctx, hlc.Timestamp{WallTime: 5})
+	p.ForwardClosedTS(ctx, hlc.Timestamp{WallTime: 7})
+	h.syncEventAndRegistrations()
+	require.Empty(t, stream.Events())
+
+	// The latest resolved timestamp is published once the interval has elapsed,
+	// without any further events.
+	testutils.SucceedsSoon(t, func() error {
+		h.syncEventAndRegistrations()
+		if events := stream.Events(); len(events) == 0 {
+			return errors.New("withheld checkpoint not published yet")
+		} else {
+			require.Equal(t, []*kvpb.RangeFeedEvent{checkpoint(7)}, events)
+		}
+		return nil
+	})
+}
+
 func TestProcessorTxnPushAttempt(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -1145,7 +1233,8 @@ func TestProcessorConcurrentStop(t *testing.T) {
 			runtime.Gosched()
 			s := newTestStream()
 			var done future.ErrorFuture
-			p.Register(h.span, hlc.Timestamp{}, nil, false, false, s, func() {}, &done)
+			p.Register(h.span, hlc.Timestamp{}, nil, false, false, 0,
+				kvpb.RangeFeedRequest_DISCONNECT, nil, s, func() {}, &done)
 		}()
 		go func() {
 			defer wg.Done()
@@ -1214,7 +1303,8 @@ func TestProcessorRegistrationObservesOnlyNewEvents(t *testing.T) {
 			s := newTestStream()
 			regs[s] = firstIdx
 			var done future.ErrorFuture
-			p.Register(h.span, hlc.Timestamp{}, nil, false, false, s, func() {}, &done)
+			p.Register(h.span, hlc.Timestamp{}, nil, false, false, 0,
+				kvpb.RangeFeedRequest_DISCONNECT, nil, s, func() {}, &done)
 			regDone <- struct{}{}
 		}
 	}()
@@ -1274,6 +1364,9 @@ func TestBudgetReleaseOnProcessorStop(t *testing.T) {
 		nil,   /* catchUpIter */
 		false, /* withDiff */
 		false, /* withFiltering */
+		0,     /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
 		rStream,
 		func() {},
 		&done,
@@ -1354,6 +1447,9 @@ func TestBudgetReleaseOnLastStreamError(t *testing.T) {
 		nil,   /* catchUpIter */
 		false, /* withDiff */
 		false, /* withFiltering */
+		0,     /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
 		rStream,
 		func() {},
 		&done,
@@ -1423,6 +1519,9 @@ func TestBudgetReleaseOnOneStreamError(t *testing.T) {
 		nil,   /* catchUpIter */
 		false, /* withDiff */
 		false, /* withFiltering */
+		0,     /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
 		r1Stream,
 		func() {},
 		&r1Done,
@@ -1438,6 +1537,9 @@ func TestBudgetReleaseOnOneStreamError(t *testing.T) {
 		nil,   /* catchUpIter */
 		false, /* withDiff */
 		false, /* withFiltering */
+		0,     /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
 		r2Stream,
 		func() {},
 		&r2Done,
@@ -1588,7 +1690,9 @@ func TestProcessorBackpressure(t *testing.T) {
 	stream := newTestStream()
 	done := &future.ErrorFuture{}
 	ok, _ := p.Register(span, hlc.MinTimestamp, nil, /* catchUpIter */
-		false /* withDiff */, false /* withFiltering */, stream, nil, done)
+		false /* withDiff */, false /* withFiltering */, 0, /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT, nil, /* mvccHistoryMutationToken */
+		stream, nil, done)
 	require.True(t, ok)
 
 	// Wait for the initial checkpoint.
@@ -1637,3 +1741,62 @@ func TestProcessorBackpressure(t *testing.T) {
 		},
 	}, events[len(events)-1])
 }
+
+// TestProcessorOverflowBlock tests that a registration with the BLOCK overflow
+// policy pauses the processor instead of blocking its scheduler worker, pushing
+// back on the senders, and that it is disconnected once the block timeout has
+// elapsed, after which the processor resumes. The block timeout is capped by
+// the senders' timeout, so that they don't give up and stop the processor
+// first.
+func TestProcessorOverflowBlock(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testutils.RunTrueAndFalse(t, "capped", func(t *testing.T, capped bool) {
+		ctx := context.Background()
+		st := cluster.MakeTestingClusterSettings()
+		eventTimeout := time.Minute
+		if capped {
+			// The block timeout is capped at half the event timeout.
+			overflowBlockTimeout.Override(ctx, &st.SV, time.Hour)
+			eventTimeout = 200 * time.Millisecond
+		} else {
+			overflowBlockTimeout.Override(ctx, &st.SV, 100*time.Millisecond)
+		}
+		m := NewMetrics()
+		span := roachpb.RSpan{Key: roachpb.RKey("a"), EndKey: roachpb.RKey("z")}
+		p, h, stopper := newTestProcessor(t, withSpan(span), withBudget(newTestBudget(math.MaxInt64)),
+			withChanCap(4), withEventTimeout(eventTimeout), withMetrics(m), withSettings(st))
+		defer stopper.Stop(ctx)
+		defer p.Stop()
+
+		stream := newTestStream()
+		done := &future.ErrorFuture{}
+		ok, _ := p.Register(span, hlc.MinTimestamp, nil, /* catchUpIter */
+			false /* withDiff */, false /* withFiltering */, 0, /* resolvedTSInterval */
+			kvpb.RangeFeedRequest_BLOCK, nil, /* mvccHistoryMutationToken */
+			stream, nil, done)
+		require.True(t, ok)
+		h.syncEventAndRegistrations()
+		require.Len(t, stream.Events(), 1)
+
+		// Block the consumer, and post more events than the registration's buffer
+		// and the processor's event channel can hold. The sender is pushed back
+		// until the registration is disconnected, after which the processor
+		// resumes.
+		unblock := stream.BlockSend()
+		defer unblock()
+		const numEvents = 20
+		for i := 0; i < numEvents; i++ {
+			require.True(t, p.ForwardClosedTS(ctx, hlc.Timestamp{WallTime: int64(i + 1)}))
+		}
+		require.NotZero(t, m.RangeFeedOverflowBlocked.Count())
+		require.Equal(t, int64(1), m.RangeFeedOverflowDisconnected.Count())
+
+		// The registration is disconnected once its buffer has been emptied.
+		unblock()
+		err, _ := future.Wait(ctx, done)
+		require.Equal(t, newErrBufferCapacityExceeded().GoError(), err)
+		h.syncEventAndRegistrations()
+		require.Equal(t, hlc.Timestamp{WallTime: numEvents}, h.rts.Get())
+	})
+}
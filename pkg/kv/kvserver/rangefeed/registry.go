@@ -11,13 +11,16 @@
 package rangefeed
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/util/future"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/interval"
@@ -28,6 +31,21 @@ import (
 	"github.com/cockroachdb/errors"
 )
 
+// overflowBlockTimeout bounds how long a registration with the BLOCK overflow
+// policy may pause the processor on a full buffer, after which the registration
+// is disconnected. This prevents a stuck consumer from stalling all other
+// registrations on the range indefinitely. The pause is further capped by the
+// processor's EventChanTimeout, see ScheduledProcessor.blockTimeout.
+var overflowBlockTimeout = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.rangefeed.overflow_block_timeout",
+	"the maximum duration for which a rangefeed registration with the BLOCK overflow "+
+		"policy may pause the event processing of its range before it is disconnected; "+
+		"capped at half of the send timeout of rangefeed events",
+	25*time.Millisecond,
+	settings.PositiveDuration,
+)
+
 // Stream is a object capable of transmitting RangeFeedEvents.
 type Stream interface {
 	// Context returns the context for this stream.
@@ -63,6 +81,15 @@ func putPooledSharedEvent(e *sharedEvent) {
 	sharedEventSyncPool.Put(e)
 }
 
+// releaseSharedEvents releases the allocations of the given events and returns
+// them to the pool.
+func releaseSharedEvents(ctx context.Context, events []*sharedEvent) {
+	for _, e := range events {
+		e.alloc.Release(ctx)
+		putPooledSharedEvent(e)
+	}
+}
+
 // registration is an instance of a rangefeed subscriber who has
 // registered to receive updates for a specific range of keys.
 // Updates are delivered to its stream until one of the following
@@ -80,7 +107,15 @@ type registration struct {
 	catchUpTimestamp hlc.Timestamp // exclusive
 	withDiff         bool
 	withFiltering    bool
-	metrics          *Metrics
+	// resolvedTSInterval, if non-zero, is the minimum interval between two
+	// checkpoints published to the registration.
+	resolvedTSInterval time.Duration
+	// overflowPolicy determines what happens when buf is full.
+	overflowPolicy kvpb.RangeFeedRequest_OverflowPolicy
+	// mvccHistoryMutationToken, if set, identifies the consumer for the purpose
+	// of coordinated MVCC history mutations.
+	mvccHistoryMutationToken []byte
+	metrics                  *Metrics
 
 	// Output.
 	stream Stream
@@ -91,6 +126,20 @@ type registration struct {
 	keys          interval.Range
 	buf           chan *sharedEvent
 	blockWhenFull bool // if true, block when buf is full (for tests)
+	// unblocked, if set, is called by the output loop once all events parked
+	// under the BLOCK overflow policy have been moved into buf, so that the
+	// processor resumes consuming events.
+	unblocked func()
+	// blockedRegs, if set, is the registry's count of registrations with parked
+	// events, which the registration maintains as its events are parked and
+	// unparked.
+	blockedRegs *int32
+
+	// Checkpoint pacing state. Only accessed by the processor goroutine. If
+	// checkpointPending is set, a checkpoint was withheld from the registration
+	// because its resolvedTSInterval hadn't elapsed since lastCheckpoint.
+	lastCheckpoint    time.Time
+	checkpointPending bool
 
 	mu struct {
 		sync.Locker
@@ -98,6 +147,20 @@ type registration struct {
 		// This will cause the registration to exit with an error once the buffer
 		// has been emptied.
 		overflowed bool
+		// If set, this registration dropped an event other than a checkpoint
+		// because its buffer overflowed under the CHECKPOINT_ONLY overflow
+		// policy, and the next checkpoint delivered to the registration reports
+		// the drop with EventsDroppedSince set to this timestamp. It is the
+		// resolved timestamp of the last checkpoint buffered before the drop, all
+		// dropped events being above it.
+		eventsDroppedSince hlc.Timestamp
+		// The resolved timestamp of the last checkpoint written to the buffer, or
+		// the catch-up timestamp if none was.
+		lastCheckpointTS hlc.Timestamp
+		// Events published to a registration with the BLOCK overflow policy
+		// while its buffer was full. The processor pauses while any events are
+		// parked, and the output loop moves them into the buffer as it drains.
+		parked []*sharedEvent
 		// Boolean indicating if all events have been output to stream. Used only
 		// for testing.
 		caughtUp bool
@@ -119,6 +182,9 @@ func newRegistration(
 	catchUpIter *CatchUpIterator,
 	withDiff bool,
 	withFiltering bool,
+	resolvedTSInterval time.Duration,
+	overflowPolicy kvpb.RangeFeedRequest_OverflowPolicy,
+	mvccHistoryMutationToken []byte,
 	bufferSz int,
 	blockWhenFull bool,
 	metrics *Metrics,
@@ -127,65 +193,160 @@ func newRegistration(
 	done *future.ErrorFuture,
 ) registration {
 	r := registration{
-		span:             span,
-		catchUpTimestamp: startTS,
-		withDiff:         withDiff,
-		withFiltering:    withFiltering,
-		metrics:          metrics,
-		stream:           stream,
-		done:             done,
-		unreg:            unregisterFn,
-		buf:              make(chan *sharedEvent, bufferSz),
-		blockWhenFull:    blockWhenFull,
+		span:                     span,
+		catchUpTimestamp:         startTS,
+		withDiff:                 withDiff,
+		withFiltering:            withFiltering,
+		resolvedTSInterval:       resolvedTSInterval,
+		overflowPolicy:           overflowPolicy,
+		mvccHistoryMutationToken: mvccHistoryMutationToken,
+		metrics:                  metrics,
+		stream:                   stream,
+		done:                     done,
+		unreg:                    unregisterFn,
+		buf:                      make(chan *sharedEvent, bufferSz),
+		blockWhenFull:            blockWhenFull,
 	}
 	r.mu.Locker = &syncutil.Mutex{}
 	r.mu.caughtUp = true
 	r.mu.catchUpIter = catchUpIter
+	r.mu.lastCheckpointTS = startTS
 	return r
 }
 
 // publish attempts to send a single event to the output buffer for this
-// registration. If the output buffer is full, the overflowed flag is set,
-// indicating that live events were lost and a catch-up scan should be initiated.
-// If overflowed is already set, events are ignored and not written to the
-// buffer.
+// registration. If the output buffer is full, the registration's overflow
+// policy determines what happens:
+//   - DISCONNECT: the overflowed flag is set, indicating that live events were
+//     lost and a catch-up scan should be initiated. If overflowed is already
+//     set, events are ignored and not written to the buffer.
+//   - BLOCK: the event is parked, along with all subsequent events until the
+//     output loop has moved the parked events into the buffer. The processor
+//     doesn't consume further events in the meantime, see registry.blocked.
+//   - CHECKPOINT_ONLY: checkpoints are dropped, as they are superseded by later
+//     checkpoints. Any other event sets eventsDroppedSince, which the next
+//     checkpoint written to the buffer reports to the consumer. Resolved
+//     timestamps keep being emitted, and the registration stays connected.
 func (r *registration) publish(
 	ctx context.Context, event *kvpb.RangeFeedEvent, alloc *SharedBudgetAllocation,
 ) {
 	r.validateEvent(event)
-	e := getPooledSharedEvent(sharedEvent{event: r.maybeStripEvent(event), alloc: alloc})
+	event = r.maybeStripEvent(event)
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if r.mu.overflowed {
+	if r.mu.overflowed || r.mu.disconnected {
 		return
 	}
+	if event.Checkpoint != nil && !r.mu.eventsDroppedSince.IsEmpty() {
+		event = withEventsDroppedSince(event, r.mu.eventsDroppedSince)
+	}
+	e := getPooledSharedEvent(sharedEvent{event: event, alloc: alloc})
 	alloc.Use()
+	if len(r.mu.parked) > 0 {
+		// Preserve the order of events behind those already parked.
+		r.mu.parked = append(r.mu.parked, e)
+		return
+	}
 	select {
 	case r.buf <- e:
 		r.mu.caughtUp = false
+		r.onBufferedLocked(event)
 	default:
-		// If we're asked to block (in tests), do a blocking send after releasing
-		// the mutex -- otherwise, the output loop won't be able to consume from the
-		// channel. We optimistically attempt the non-blocking send above first,
-		// since we're already holding the mutex.
+		// If we're asked to block (in tests, or by the overflow policy), do a
+		// blocking send after releasing the mutex -- otherwise, the output loop
+		// won't be able to consume from the channel. We optimistically attempt
+		// the non-blocking send above first, since we're already holding the
+		// mutex.
 		if r.blockWhenFull {
 			r.mu.Unlock()
 			select {
 			case r.buf <- e:
 				r.mu.Lock()
 				r.mu.caughtUp = false
+				r.onBufferedLocked(event)
 			case <-ctx.Done():
 				r.mu.Lock()
 				alloc.Release(ctx)
 			}
 			return
 		}
+		if r.overflowPolicy == kvpb.RangeFeedRequest_BLOCK {
+			// Don't block the processor's goroutine, which is shared with other
+			// processors. Instead, park the event and let the processor pause
+			// until the output loop has made room for it.
+			r.metrics.RangeFeedOverflowBlocked.Inc(1)
+			r.parkLocked(e)
+			r.mu.caughtUp = false
+			return
+		}
+		alloc.Release(ctx)
+		putPooledSharedEvent(e)
+		if r.overflowPolicy == kvpb.RangeFeedRequest_CHECKPOINT_ONLY {
+			if event.Checkpoint == nil {
+				// Checkpoints published from now on would wrongly imply that the
+				// consumer has seen all prior events, so they report the drop
+				// until one of them makes it into the buffer.
+				if r.mu.eventsDroppedSince.IsEmpty() {
+					r.mu.eventsDroppedSince = r.mu.lastCheckpointTS
+					if r.mu.eventsDroppedSince.IsEmpty() {
+						r.mu.eventsDroppedSince = hlc.MinTimestamp
+					}
+					r.metrics.RangeFeedOverflowDegraded.Inc(1)
+				}
+				r.metrics.RangeFeedOverflowDroppedEvents.Inc(1)
+			}
+			return
+		}
 		// Buffer exceeded and we are dropping this event. Registration will need
 		// a catch-up scan.
 		r.mu.overflowed = true
-		alloc.Release(ctx)
+		r.metrics.RangeFeedOverflowDisconnected.Inc(1)
+	}
+}
+
+// onBufferedLocked is called once the given event has been written to the
+// buffer. A checkpoint written to the buffer reports any dropped events to the
+// consumer, so they no longer need to be reported.
+func (r *registration) onBufferedLocked(event *kvpb.RangeFeedEvent) {
+	if event.Checkpoint == nil {
+		return
 	}
+	r.mu.lastCheckpointTS = event.Checkpoint.ResolvedTS
+	r.mu.eventsDroppedSince = hlc.Timestamp{}
+}
+
+// withEventsDroppedSince returns a copy of the given checkpoint event which
+// reports that events above the given timestamp were dropped.
+func withEventsDroppedSince(
+	event *kvpb.RangeFeedEvent, since hlc.Timestamp,
+) *kvpb.RangeFeedEvent {
+	ret := event.ShallowCopy()
+	ret.Checkpoint.EventsDroppedSince = since
+	return ret
+}
+
+// maybePaceCheckpoint returns whether a checkpoint should be published to the
+// registration at the given time. If the registration's resolvedTSInterval has
+// not yet elapsed since its last checkpoint, the checkpoint is withheld and
+// marked as pending instead.
+func (r *registration) maybePaceCheckpoint(now time.Time) bool {
+	if r.resolvedTSInterval > 0 && now.Sub(r.lastCheckpoint) < r.resolvedTSInterval {
+		r.checkpointPending = true
+		return false
+	}
+	r.lastCheckpoint = now
+	r.checkpointPending = false
+	return true
+}
+
+// pendingCheckpointDue returns the time at which the checkpoint withheld from
+// the registration is due, or the zero time if none is pending.
+func (r *registration) pendingCheckpointDue() time.Time {
+	if !r.checkpointPending {
+		return time.Time{}
+	}
+	return r.lastCheckpoint.Add(r.resolvedTSInterval)
 }
 
 // validateEvent checks that the event contains enough information for the
@@ -223,6 +384,10 @@ func (r *registration) validateEvent(event *kvpb.RangeFeedEvent) {
 		if t.Timestamp.IsEmpty() {
 			panic(fmt.Sprintf("unexpected empty RangeFeedDeleteRange.Timestamp: %v", t))
 		}
+	case *kvpb.RangeFeedMVCCHistoryMutation:
+		if len(t.Span.Key) == 0 || len(t.Span.EndKey) == 0 {
+			panic(fmt.Sprintf("unexpected empty key in RangeFeedMVCCHistoryMutation.Span: %v", t))
+		}
 	default:
 		panic(fmt.Sprintf("unexpected RangeFeedEvent variant: %v", t))
 	}
@@ -275,6 +440,12 @@ func (r *registration) maybeStripEvent(event *kvpb.RangeFeedEvent) *kvpb.RangeFe
 			t = copyOnWrite().(*kvpb.RangeFeedDeleteRange)
 			t.Span = i.Clone()
 		}
+	case *kvpb.RangeFeedMVCCHistoryMutation:
+		// Truncate the mutated span to the registration bounds.
+		if i := t.Span.Intersect(r.span); !i.Equal(t.Span) {
+			t = copyOnWrite().(*kvpb.RangeFeedMVCCHistoryMutation)
+			t.Span = i.Clone()
+		}
 	case *kvpb.RangeFeedSSTable:
 		// SSTs are always sent in their entirety, it is up to the caller to
 		// filter out irrelevant entries.
@@ -284,6 +455,12 @@ func (r *registration) maybeStripEvent(event *kvpb.RangeFeedEvent) *kvpb.RangeFe
 	return ret
 }
 
+// coordinatesMVCCHistoryMutation returns true if an MVCC history mutation
+// carrying the given token was coordinated with the registration's consumer.
+func (r *registration) coordinatesMVCCHistoryMutation(token []byte) bool {
+	return len(token) > 0 && bytes.Equal(r.mvccHistoryMutationToken, token)
+}
+
 // disconnect cancels the output loop context for the registration and passes an
 // error to the output error stream for the registration.
 // Safe to run multiple times, but subsequent errors would be discarded.
@@ -300,6 +477,14 @@ func (r *registration) disconnect(pErr *kvpb.Error) {
 		}
 		r.mu.disconnected = true
 		r.done.Set(pErr.GoError())
+		// Don't pause the processor for the events parked by a disconnected
+		// registration.
+		if parked := r.clearParkedLocked(); len(parked) > 0 {
+			releaseSharedEvents(context.Background(), parked)
+			if r.unblocked != nil {
+				r.unblocked()
+			}
+		}
 	}
 }
 
@@ -325,13 +510,19 @@ func (r *registration) outputLoop(ctx context.Context) error {
 
 	// Normal buffered output loop.
 	for {
-		overflowed := false
+		overflowed, unblocked := false, false
 		r.mu.Lock()
+		if len(r.mu.parked) > 0 {
+			unblocked = r.unparkLocked()
+		}
 		if len(r.buf) == 0 {
 			overflowed = r.mu.overflowed
 			r.mu.caughtUp = true
 		}
 		r.mu.Unlock()
+		if unblocked && r.unblocked != nil {
+			r.unblocked()
+		}
 		if overflowed {
 			return newErrBufferCapacityExceeded().GoError()
 		}
@@ -352,6 +543,66 @@ func (r *registration) outputLoop(ctx context.Context) error {
 	}
 }
 
+// parkLocked parks the given event under the BLOCK overflow policy.
+func (r *registration) parkLocked(e *sharedEvent) {
+	if len(r.mu.parked) == 0 && r.blockedRegs != nil {
+		atomic.AddInt32(r.blockedRegs, 1)
+	}
+	r.mu.parked = append(r.mu.parked, e)
+}
+
+// clearParkedLocked forgets the events parked under the BLOCK overflow policy,
+// and returns them.
+func (r *registration) clearParkedLocked() []*sharedEvent {
+	parked := r.mu.parked
+	if len(parked) > 0 && r.blockedRegs != nil {
+		atomic.AddInt32(r.blockedRegs, -1)
+	}
+	r.mu.parked = nil
+	return parked
+}
+
+// unparkLocked moves as many events parked under the BLOCK overflow policy into
+// the buffer as fit, and returns whether all parked events have been moved.
+func (r *registration) unparkLocked() bool {
+	for i, e := range r.mu.parked {
+		select {
+		case r.buf <- e:
+			r.onBufferedLocked(e.event)
+			r.mu.parked[i] = nil
+		default:
+			r.mu.parked = r.mu.parked[i:]
+			return false
+		}
+	}
+	r.clearParkedLocked()
+	return true
+}
+
+// isBlocked returns whether the registration has events parked under the BLOCK
+// overflow policy.
+func (r *registration) isBlocked() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.mu.parked) > 0
+}
+
+// overflowParked drops the events parked under the BLOCK overflow policy, if
+// any, and marks the registration as overflowed. It is then disconnected once
+// its buffer has been emptied, as with the DISCONNECT policy.
+func (r *registration) overflowParked(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.mu.parked) == 0 {
+		return
+	}
+	releaseSharedEvents(ctx, r.clearParkedLocked())
+	if !r.mu.overflowed {
+		r.mu.overflowed = true
+		r.metrics.RangeFeedOverflowDisconnected.Inc(1)
+	}
+}
+
 func (r *registration) runOutputLoop(ctx context.Context, _forStacks roachpb.RangeID) {
 	r.mu.Lock()
 	if r.mu.disconnected {
@@ -368,6 +619,10 @@ func (r *registration) runOutputLoop(ctx context.Context, _forStacks roachpb.Ran
 // drainAllocations should be done after registration is disconnected from
 // processor to release all memory budget that its pending events hold.
 func (r *registration) drainAllocations(ctx context.Context) {
+	r.mu.Lock()
+	parked := r.clearParkedLocked()
+	r.mu.Unlock()
+	releaseSharedEvents(ctx, parked)
 	for {
 		select {
 		case e, ok := <-r.buf:
@@ -422,6 +677,11 @@ type registry struct {
 	metrics *Metrics
 	tree    interval.Tree // *registration items
 	idAlloc int64
+	// blockedRegs is the number of registrations with the BLOCK overflow policy
+	// which have events parked because their buffer is full. It is maintained
+	// by the registrations, whose output loops unpark events concurrently with
+	// the processor.
+	blockedRegs int32
 }
 
 func makeRegistry(metrics *Metrics) registry {
@@ -450,6 +710,9 @@ func (reg *registry) Register(r *registration) {
 	if err := reg.tree.Insert(r, false /* fast */); err != nil {
 		panic(err)
 	}
+	if r.overflowPolicy == kvpb.RangeFeedRequest_BLOCK {
+		r.blockedRegs = &reg.blockedRegs
+	}
 }
 
 func (reg *registry) nextID() int64 {
@@ -498,6 +761,74 @@ func (reg *registry) PublishToOverlapping(
 	})
 }
 
+// PublishCheckpoint publishes a checkpoint event to all registrations, pacing
+// it according to each registration's resolved timestamp interval.
+// Registrations which received a checkpoint less than their interval ago don't
+// receive the event, and are instead marked as having a checkpoint pending.
+// Returns the time at which the earliest pending checkpoint is due, or the zero
+// time if none is pending.
+func (reg *registry) PublishCheckpoint(
+	ctx context.Context, event *kvpb.RangeFeedEvent, now time.Time,
+) (nextDue time.Time) {
+	if _, ok := event.GetValue().(*kvpb.RangeFeedCheckpoint); !ok {
+		panic(fmt.Sprintf("unexpected RangeFeedEvent variant: %v", event))
+	}
+	reg.forOverlappingRegs(all, func(r *registration) (bool, *kvpb.Error) {
+		if r.maybePaceCheckpoint(now) {
+			r.publish(ctx, event, nil)
+		}
+		nextDue = earliestDue(nextDue, r.pendingCheckpointDue())
+		return false, nil
+	})
+	return nextDue
+}
+
+// PublishPendingCheckpoints publishes a checkpoint event to all registrations
+// which had a checkpoint withheld by PublishCheckpoint and whose resolved
+// timestamp interval has since elapsed. The event must carry the current
+// resolved timestamp, which supersedes any withheld checkpoints. Returns the
+// time at which the earliest checkpoint still pending is due, or the zero time
+// if none is.
+func (reg *registry) PublishPendingCheckpoints(
+	ctx context.Context, event *kvpb.RangeFeedEvent, now time.Time,
+) (nextDue time.Time) {
+	reg.forOverlappingRegs(all, func(r *registration) (bool, *kvpb.Error) {
+		if r.checkpointPending && r.maybePaceCheckpoint(now) {
+			r.publish(ctx, event, nil)
+		}
+		nextDue = earliestDue(nextDue, r.pendingCheckpointDue())
+		return false, nil
+	})
+	return nextDue
+}
+
+// earliestDue returns the earliest of two due times, ignoring zero times.
+func earliestDue(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+// Blocked returns whether any registration with the BLOCK overflow policy has
+// events parked because its buffer is full. The processor must not consume
+// further events until it is unblocked.
+func (reg *registry) Blocked() bool {
+	return atomic.LoadInt32(&reg.blockedRegs) > 0
+}
+
+// OverflowBlocked drops the events parked by all registrations with the BLOCK
+// overflow policy, which are then disconnected as with the DISCONNECT policy.
+func (reg *registry) OverflowBlocked(ctx context.Context) {
+	if !reg.Blocked() {
+		return
+	}
+	reg.forOverlappingRegs(all, func(r *registration) (bool, *kvpb.Error) {
+		r.overflowParked(ctx)
+		return false, nil
+	})
+}
+
 // Unregister removes a registration from the registry. It is assumed that the
 // registration has already been disconnected, this is intended only to clean
 // up the registry.
@@ -538,6 +869,35 @@ func (reg *registry) DisconnectWithErr(span roachpb.Span, pErr *kvpb.Error) {
 	})
 }
 
+// DisconnectUncoordinatedWithErr disconnects all registrations that overlap the
+// specified span with the provided error, except for those which coordinated
+// an MVCC history mutation carrying the given token.
+func (reg *registry) DisconnectUncoordinatedWithErr(
+	span roachpb.Span, token []byte, pErr *kvpb.Error,
+) {
+	reg.forOverlappingRegs(span, func(r *registration) (bool, *kvpb.Error) {
+		return !r.coordinatesMVCCHistoryMutation(token), pErr
+	})
+}
+
+// PublishMVCCHistoryMutation publishes an MVCC history mutation event to all
+// registrations overlapping the mutated span which coordinated the mutation
+// using the given token. Any other overlapping registrations are disconnected
+// with an MVCCHistoryMutationError, as they may otherwise have missed it.
+func (reg *registry) PublishMVCCHistoryMutation(
+	ctx context.Context, span roachpb.Span, token []byte,
+) {
+	var event kvpb.RangeFeedEvent
+	event.MustSetValue(&kvpb.RangeFeedMVCCHistoryMutation{Span: span})
+	reg.forOverlappingRegs(span, func(r *registration) (bool, *kvpb.Error) {
+		if r.coordinatesMVCCHistoryMutation(token) {
+			r.publish(ctx, &event, nil /* alloc */)
+			return false, nil
+		}
+		return true, kvpb.NewError(&kvpb.MVCCHistoryMutationError{Span: span})
+	})
+}
+
 // all is a span that overlaps with all registrations.
 var all = roachpb.Span{Key: roachpb.KeyMin, EndKey: roachpb.KeyMax}
 
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	_ "github.com/cockroachdb/cockroach/pkg/keys" // hook up pretty printer
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
@@ -26,7 +27,9 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -129,6 +132,9 @@ func newTestRegistration(
 		makeCatchUpIterator(catchup, span, ts),
 		withDiff,
 		withFiltering,
+		0, /* resolvedTSInterval */
+		kvpb.RangeFeedRequest_DISCONNECT,
+		nil, /* mvccHistoryMutationToken */
 		5,
 		false, /* blockWhenFull */
 		NewMetrics(),
@@ -452,6 +458,7 @@ func TestRegistryBasic(t *testing.T) {
 	require.True(t, f.NeedVal(roachpb.Span{Key: keyC}))
 	require.False(t, f.NeedVal(roachpb.Span{Key: keyX}))
 	// Testing NeedPrevVal.
+	require.True(t, f.NeedPrevVals())
 	require.False(t, f.NeedPrevVal(spAB))
 	require.True(t, f.NeedPrevVal(spBC))
 	require.True(t, f.NeedPrevVal(spCD))
@@ -603,6 +610,266 @@ func TestRegistryPublishBeneathStartTimestamp(t *testing.T) {
 	r.disconnect(nil)
 }
 
+func TestRegistrationOverflowPolicy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	val := roachpb.Value{RawBytes: []byte("val"), Timestamp: hlc.Timestamp{WallTime: 1}}
+	ev, cp := new(kvpb.RangeFeedEvent), new(kvpb.RangeFeedEvent)
+	ev.MustSetValue(&kvpb.RangeFeedValue{Key: keyA, Value: val})
+	cp.MustSetValue(&kvpb.RangeFeedCheckpoint{Span: spAB, ResolvedTS: hlc.Timestamp{WallTime: 2}})
+
+	newReg := func(policy kvpb.RangeFeedRequest_OverflowPolicy) *testRegistration {
+		r := newTestRegistration(spAB, hlc.Timestamp{}, nil, /* catchup */
+			false /* withDiff */, false /* withFiltering */)
+		r.overflowPolicy = policy
+		return r
+	}
+	fill := func(r *testRegistration) {
+		for i := 0; i < cap(r.buf); i++ {
+			r.publish(ctx, ev, nil /* alloc */)
+		}
+	}
+
+	t.Run("disconnect", func(t *testing.T) {
+		r := newReg(kvpb.RangeFeedRequest_DISCONNECT)
+		fill(r)
+		r.publish(ctx, ev, nil /* alloc */)
+		r.publish(ctx, cp, nil /* alloc */)
+		go r.runOutputLoop(ctx, 0)
+		require.Equal(t, newErrBufferCapacityExceeded().GoError(), r.Err())
+		require.Equal(t, cap(r.buf), len(r.Events()))
+		require.Equal(t, int64(1), r.metrics.RangeFeedOverflowDisconnected.Count())
+	})
+
+	t.Run("checkpoint-only", func(t *testing.T) {
+		checkpoint := func(wallTime int64) *kvpb.RangeFeedEvent {
+			cp := new(kvpb.RangeFeedEvent)
+			cp.MustSetValue(&kvpb.RangeFeedCheckpoint{
+				Span: spAB, ResolvedTS: hlc.Timestamp{WallTime: wallTime},
+			})
+			return cp
+		}
+		r := newReg(kvpb.RangeFeedRequest_CHECKPOINT_ONLY)
+		r.publish(ctx, checkpoint(2), nil /* alloc */)
+		for i := 1; i < cap(r.buf); i++ {
+			r.publish(ctx, ev, nil /* alloc */)
+		}
+		// Checkpoints which don't fit into the buffer are dropped, since they are
+		// superseded by later checkpoints.
+		r.publish(ctx, checkpoint(3), nil /* alloc */)
+		require.Zero(t, r.metrics.RangeFeedOverflowDegraded.Count())
+
+		// Once any other event is dropped, the next checkpoint which fits into
+		// the buffer reports the drop since the last buffered checkpoint.
+		for i := 0; i < 3; i++ {
+			r.publish(ctx, ev, nil /* alloc */)
+		}
+		r.publish(ctx, checkpoint(4), nil /* alloc */)
+		require.Equal(t, int64(1), r.metrics.RangeFeedOverflowDegraded.Count())
+		require.Equal(t, int64(3), r.metrics.RangeFeedOverflowDroppedEvents.Count())
+		go r.runOutputLoop(ctx, 0)
+		require.NoError(t, r.waitForCaughtUp())
+		r.publish(ctx, checkpoint(5), nil /* alloc */)
+		r.publish(ctx, checkpoint(6), nil /* alloc */)
+		require.NoError(t, r.waitForCaughtUp())
+
+		// The registration stays connected and keeps emitting resolved
+		// timestamps, and only the first checkpoint after the drop reports it.
+		events := r.Events()
+		require.Equal(t, cap(r.buf)+2, len(events))
+		dropped := checkpoint(5)
+		dropped.Checkpoint.EventsDroppedSince = hlc.Timestamp{WallTime: 2}
+		require.Equal(t, []*kvpb.RangeFeedEvent{dropped, checkpoint(6)}, events[cap(r.buf):])
+		require.NoError(t, r.TryErr())
+		require.Zero(t, r.metrics.RangeFeedOverflowDisconnected.Count())
+		r.disconnect(nil)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		r := newReg(kvpb.RangeFeedRequest_BLOCK)
+		unblocked := make(chan struct{})
+		r.unblocked = func() { close(unblocked) }
+		fill(r)
+		// Events which don't fit into the buffer are parked instead of blocking
+		// the publisher, until the output loop makes room for them.
+		r.publish(ctx, ev, nil /* alloc */)
+		r.publish(ctx, cp, nil /* alloc */)
+		require.True(t, r.isBlocked())
+		go r.runOutputLoop(ctx, 0)
+		<-unblocked
+		require.NoError(t, r.waitForCaughtUp())
+		require.False(t, r.isBlocked())
+		events := r.Events()
+		require.Equal(t, cap(r.buf)+2, len(events))
+		require.Equal(t, []*kvpb.RangeFeedEvent{ev, cp}, events[cap(r.buf):])
+		require.NoError(t, r.TryErr())
+		require.Equal(t, int64(1), r.metrics.RangeFeedOverflowBlocked.Count())
+		r.disconnect(nil)
+	})
+
+	t.Run("block-timeout", func(t *testing.T) {
+		reg := makeRegistry(NewMetrics())
+		r := newReg(kvpb.RangeFeedRequest_BLOCK)
+		reg.Register(&r.registration)
+		fill(r)
+		r.publish(ctx, ev, nil /* alloc */)
+		require.True(t, reg.Blocked())
+
+		// If the buffer doesn't drain in time, the parked events are dropped and
+		// the registration is disconnected.
+		reg.OverflowBlocked(ctx)
+		require.False(t, reg.Blocked())
+		go r.runOutputLoop(ctx, 0)
+		require.Equal(t, newErrBufferCapacityExceeded().GoError(), r.Err())
+		require.Equal(t, cap(r.buf), len(r.Events()))
+		require.Equal(t, int64(1), r.metrics.RangeFeedOverflowBlocked.Count())
+		require.Equal(t, int64(1), r.metrics.RangeFeedOverflowDisconnected.Count())
+		reg.Unregister(ctx, &r.registration)
+		require.Zero(t, reg.blockedRegs)
+	})
+
+	t.Run("block-disconnect", func(t *testing.T) {
+		reg := makeRegistry(NewMetrics())
+		r := newReg(kvpb.RangeFeedRequest_BLOCK)
+		reg.Register(&r.registration)
+		fill(r)
+		r.publish(ctx, ev, nil /* alloc */)
+		require.True(t, reg.Blocked())
+
+		// A disconnected registration doesn't pause the processor anymore, and
+		// doesn't park further events.
+		r.disconnect(nil)
+		require.False(t, reg.Blocked())
+		r.publish(ctx, ev, nil /* alloc */)
+		require.False(t, reg.Blocked())
+		reg.Unregister(ctx, &r.registration)
+		require.Zero(t, reg.blockedRegs)
+	})
+}
+
+func TestRegistryPublishCheckpointPacing(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	reg := makeRegistry(NewMetrics())
+
+	rUnpaced := newTestRegistration(spAB, hlc.Timestamp{}, nil, /* catchup */
+		false /* withDiff */, false /* withFiltering */)
+	rPaced := newTestRegistration(spAB, hlc.Timestamp{}, nil, /* catchup */
+		false /* withDiff */, false /* withFiltering */)
+	rPaced.resolvedTSInterval = time.Second
+	for _, r := range []*testRegistration{rUnpaced, rPaced} {
+		go r.runOutputLoop(ctx, 0)
+		reg.Register(&r.registration)
+	}
+
+	checkpoint := func(wall int64) *kvpb.RangeFeedEvent {
+		ev := new(kvpb.RangeFeedEvent)
+		ev.MustSetValue(&kvpb.RangeFeedCheckpoint{
+			Span: spAB, ResolvedTS: hlc.Timestamp{WallTime: wall},
+		})
+		return ev
+	}
+	resolvedTSs := func(r *testRegistration) []int64 {
+		var res []int64
+		for _, ev := range r.Events() {
+			res = append(res, ev.Checkpoint.ResolvedTS.WallTime)
+		}
+		return res
+	}
+
+	// The first checkpoint is published to both registrations, subsequent ones
+	// only to the unpaced registration until the interval elapses.
+	start := timeutil.Unix(100, 0)
+	reg.PublishCheckpoint(ctx, checkpoint(1), start)
+	reg.PublishCheckpoint(ctx, checkpoint(2), start.Add(100*time.Millisecond))
+	reg.PublishCheckpoint(ctx, checkpoint(3), start.Add(500*time.Millisecond))
+	require.NoError(t, reg.waitForCaughtUp(all))
+	require.Equal(t, []int64{1, 2, 3}, resolvedTSs(rUnpaced))
+	require.Equal(t, []int64{1}, resolvedTSs(rPaced))
+	require.True(t, rPaced.checkpointPending)
+
+	// Pending checkpoints aren't published before the interval elapses.
+	reg.PublishPendingCheckpoints(ctx, checkpoint(3), start.Add(900*time.Millisecond))
+	require.NoError(t, reg.waitForCaughtUp(all))
+	require.Equal(t, []int64{1}, resolvedTSs(rPaced))
+
+	// Once it has elapsed, only the registration with a pending checkpoint
+	// receives the latest resolved timestamp.
+	reg.PublishPendingCheckpoints(ctx, checkpoint(3), start.Add(time.Second))
+	require.NoError(t, reg.waitForCaughtUp(all))
+	require.Equal(t, []int64{1, 2, 3}, resolvedTSs(rUnpaced))
+	require.Equal(t, []int64{1, 3}, resolvedTSs(rPaced))
+	require.False(t, rPaced.checkpointPending)
+
+	// Nothing is pending anymore.
+	reg.PublishPendingCheckpoints(ctx, checkpoint(3), start.Add(3*time.Second))
+	require.NoError(t, reg.waitForCaughtUp(all))
+	require.Equal(t, []int64{1, 3}, resolvedTSs(rPaced))
+
+	// A checkpoint after the interval is published immediately.
+	reg.PublishCheckpoint(ctx, checkpoint(4), start.Add(3*time.Second))
+	require.NoError(t, reg.waitForCaughtUp(all))
+	require.Equal(t, []int64{1, 2, 3, 4}, resolvedTSs(rUnpaced))
+	require.Equal(t, []int64{1, 3, 4}, resolvedTSs(rPaced))
+
+	rUnpaced.disconnect(nil)
+	rPaced.disconnect(nil)
+}
+
+func TestRegistryMVCCHistoryMutation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	reg := makeRegistry(NewMetrics())
+	token, otherToken := []byte("token"), []byte("other")
+
+	newReg := func(span roachpb.Span, token []byte) *testRegistration {
+		r := newTestRegistration(span, hlc.Timestamp{}, nil, /* catchup */
+			false /* withDiff */, false /* withFiltering */)
+		r.mvccHistoryMutationToken = token
+		go r.runOutputLoop(ctx, 0)
+		reg.Register(&r.registration)
+		return r
+	}
+	rAC := newReg(spAC, token)
+	rBD := newReg(roachpb.Span{Key: keyB, EndKey: keyD}, token)
+	rABOther := newReg(spAB, otherToken)
+	rBCNone := newReg(spBC, nil)
+	rXY := newReg(spXY, nil)
+
+	// Uncoordinated registrations overlapping the mutation are disconnected,
+	// coordinated ones receive the event truncated to their span.
+	mutSpan := roachpb.Span{Key: keyA, EndKey: keyC}
+	reg.DisconnectUncoordinatedWithErr(mutSpan, token,
+		kvpb.NewError(&kvpb.MVCCHistoryMutationError{Span: mutSpan}))
+	reg.PublishMVCCHistoryMutation(ctx, mutSpan, token)
+	require.NoError(t, reg.waitForCaughtUp(all))
+
+	expEvent := func(span roachpb.Span) []*kvpb.RangeFeedEvent {
+		ev := new(kvpb.RangeFeedEvent)
+		ev.MustSetValue(&kvpb.RangeFeedMVCCHistoryMutation{Span: span})
+		return []*kvpb.RangeFeedEvent{ev}
+	}
+	require.Equal(t, expEvent(spAC), rAC.Events())
+	require.Equal(t, expEvent(spBC), rBD.Events())
+	require.NoError(t, rAC.TryErr())
+	require.NoError(t, rBD.TryErr())
+	require.Nil(t, rXY.Events())
+	require.NoError(t, rXY.TryErr())
+	for _, r := range []*testRegistration{rABOther, rBCNone} {
+		require.True(t, errors.HasType(r.Err(), (*kvpb.MVCCHistoryMutationError)(nil)))
+		require.Nil(t, r.Events())
+	}
+	require.Equal(t, 3, reg.Len())
+
+	// Mutations without a token disconnect all overlapping registrations.
+	reg.PublishMVCCHistoryMutation(ctx, mutSpan, nil /* token */)
+	require.True(t, errors.HasType(rAC.Err(), (*kvpb.MVCCHistoryMutationError)(nil)))
+	require.True(t, errors.HasType(rBD.Err(), (*kvpb.MVCCHistoryMutationError)(nil)))
+	require.Equal(t, 1, reg.Len())
+	rXY.disconnect(nil)
+}
+
 func TestRegistrationString(t *testing.T) {
 	testCases := []struct {
 		r   registration
@@ -23,6 +23,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
 )
 
@@ -61,6 +62,17 @@ type ScheduledProcessor struct {
 	// stopper passed by start that is used for firing up async work from scheduler.
 	stopper       *stop.Stopper
 	txnPushActive bool
+
+	// checkpointTimer publishes the checkpoints withheld from registrations with
+	// a resolved timestamp interval once they are due, at checkpointFlushAt.
+	checkpointTimer   *time.Timer
+	checkpointFlushAt time.Time
+
+	// blockTimer bounds how long the processor pauses for registrations with
+	// the BLOCK overflow policy, see blockTimeout. blockedSince is the
+	// time at which the current pause started, or zero if not paused.
+	blockTimer   *time.Timer
+	blockedSince time.Time
 }
 
 // NewScheduledProcessor creates a new scheduler based rangefeed Processor.
@@ -160,6 +172,9 @@ func (p *ScheduledProcessor) processRequests(ctx context.Context) {
 
 // Transform and route pending events.
 func (p *ScheduledProcessor) processEvents(ctx context.Context) {
+	if p.blocked() {
+		return
+	}
 	// Only process as much data as was present at the start of the processing
 	// run to avoid starving other processors.
 	for max := len(p.eventC); max > 0; max-- {
@@ -172,12 +187,73 @@ func (p *ScheduledProcessor) processEvents(ctx context.Context) {
 			}
 			e.alloc.Release(ctx)
 			putPooledEvent(e)
+			if p.blocked() {
+				return
+			}
 		default:
 			return
 		}
 	}
 }
 
+// blocked returns whether a registration with the BLOCK overflow policy has a
+// full buffer, in which case the processor pauses and leaves the remaining
+// events queued. This pushes back on their producers once the event queue is
+// full. The processor resumes once the registration's output loop has made
+// room, or once blockTimeout has elapsed and the registration has been
+// disconnected.
+func (p *ScheduledProcessor) blocked() bool {
+	if !p.reg.Blocked() {
+		p.blockedSince = time.Time{}
+		return false
+	}
+	if p.blockedSince.IsZero() {
+		p.blockedSince = timeutil.Now()
+		p.armBlockTimer(p.blockTimeout())
+	}
+	return true
+}
+
+// blockTimeout returns how long the processor may pause for registrations with
+// the BLOCK overflow policy. Producers wait at most EventChanTimeout for room
+// in the event queue, after which they stop the processor and disconnect all of
+// its registrations. The pause must end well before that, so that only the
+// registrations which caused it are disconnected.
+func (p *ScheduledProcessor) blockTimeout() time.Duration {
+	timeout := overflowBlockTimeout.Get(&p.Settings.SV)
+	if p.EventChanTimeout > 0 && timeout > p.EventChanTimeout/2 {
+		timeout = p.EventChanTimeout / 2
+	}
+	return timeout
+}
+
+// armBlockTimer arms the block timer to fire after the given duration. The
+// timer is reused across pauses.
+func (p *ScheduledProcessor) armBlockTimer(d time.Duration) {
+	if p.blockTimer == nil {
+		p.blockTimer = time.AfterFunc(d, func() {
+			p.enqueueRequest(p.checkBlockTimeout)
+		})
+		return
+	}
+	p.blockTimer.Reset(d)
+}
+
+// checkBlockTimeout disconnects the registrations the processor is paused for
+// once blockTimeout has elapsed, and resumes processing.
+func (p *ScheduledProcessor) checkBlockTimeout(ctx context.Context) {
+	if p.blockedSince.IsZero() {
+		return
+	}
+	if remaining := p.blockTimeout() - timeutil.Since(p.blockedSince); remaining > 0 {
+		p.armBlockTimer(remaining)
+		return
+	}
+	p.blockedSince = time.Time{}
+	p.reg.OverflowBlocked(ctx)
+	p.scheduler.Enqueue(EventQueued)
+}
+
 func (p *ScheduledProcessor) processPushTxn(ctx context.Context) {
 	// NB: Len() check avoids hlc.Clock.Now() mutex acquisition in the common
 	// case, which can be a significant source of contention.
@@ -228,6 +304,13 @@ func (p *ScheduledProcessor) cleanup() {
 	pErr := kvpb.NewError(&kvpb.NodeUnavailableError{})
 	p.reg.DisconnectAllOnShutdown(pErr)
 
+	if p.checkpointTimer != nil {
+		p.checkpointTimer.Stop()
+	}
+	if p.blockTimer != nil {
+		p.blockTimer.Stop()
+	}
+
 	// Unregister callback from scheduler
 	p.scheduler.Unregister()
 
@@ -264,6 +347,20 @@ func (p *ScheduledProcessor) DisconnectSpanWithErr(span roachpb.Span, pErr *kvpb
 	})
 }
 
+// DisconnectUncoordinatedSpanWithErr disconnects all rangefeed registrations
+// that overlap the given span with the given error, except for those that
+// coordinated an MVCC history mutation carrying the given token.
+func (p *ScheduledProcessor) DisconnectUncoordinatedSpanWithErr(
+	span roachpb.Span, token []byte, pErr *kvpb.Error,
+) {
+	if p == nil {
+		return
+	}
+	p.enqueueRequest(func(ctx context.Context) {
+		p.reg.DisconnectUncoordinatedWithErr(span, token, pErr)
+	})
+}
+
 func (p *ScheduledProcessor) sendStop(pErr *kvpb.Error) {
 	p.enqueueRequest(func(ctx context.Context) {
 		p.reg.DisconnectWithErr(all, pErr)
@@ -296,6 +393,9 @@ func (p *ScheduledProcessor) Register(
 	catchUpIter *CatchUpIterator,
 	withDiff bool,
 	withFiltering bool,
+	resolvedTSInterval time.Duration,
+	overflowPolicy kvpb.RangeFeedRequest_OverflowPolicy,
+	mvccHistoryMutationToken []byte,
 	stream Stream,
 	disconnectFn func(),
 	done *future.ErrorFuture,
@@ -308,8 +408,12 @@ func (p *ScheduledProcessor) Register(
 	blockWhenFull := p.Config.EventChanTimeout == 0 // for testing
 	r := newRegistration(
 		span.AsRawSpanWithNoLocals(), startTS, catchUpIter, withDiff, withFiltering,
+		resolvedTSInterval, overflowPolicy, mvccHistoryMutationToken,
 		p.Config.EventChanCap, blockWhenFull, p.Metrics, stream, disconnectFn, done,
 	)
+	// Resume processing once the output loop has made room for the events
+	// parked under the BLOCK overflow policy.
+	r.unblocked = func() { p.scheduler.Enqueue(EventQueued) }
 
 	filter := runRequest(p, func(ctx context.Context, p *ScheduledProcessor) *Filter {
 		if p.stopping {
@@ -396,6 +500,21 @@ func (p *ScheduledProcessor) ConsumeSSTable(
 	return p.sendEvent(ctx, event{sst: &sstEvent{sst, sstSpan, writeTS}}, p.EventChanTimeout)
 }
 
+// ConsumeMVCCHistoryMutation informs the rangefeed processor of an MVCC history
+// mutation across the given span, coordinated using the given token. It
+// returns false if consuming the mutation hit a timeout, as specified by the
+// EventChanTimeout configuration. If the method returns false, the processor
+// will have been stopped, so calling Stop is not necessary. Safe to call on nil
+// Processor.
+func (p *ScheduledProcessor) ConsumeMVCCHistoryMutation(
+	ctx context.Context, span roachpb.Span, token []byte,
+) bool {
+	if p == nil {
+		return true
+	}
+	return p.sendEvent(ctx, event{histMut: &histMutEvent{span, token}}, p.EventChanTimeout)
+}
+
 // ForwardClosedTS indicates that the closed timestamp that serves as the basis
 // for the rangefeed processor's resolved timestamp has advanced. It returns
 // false if forwarding the closed timestamp hit a timeout, as specified by the
@@ -631,6 +750,8 @@ func (p *ScheduledProcessor) consumeEvent(ctx context.Context, e *event) {
 		p.initResolvedTS(ctx)
 	case e.sst != nil:
 		p.consumeSSTable(ctx, e.sst.data, e.sst.span, e.sst.ts, e.alloc)
+	case e.histMut != nil:
+		p.reg.PublishMVCCHistoryMutation(ctx, e.histMut.span, e.histMut.token)
 	case e.sync != nil:
 		if e.sync.testRegCatchupSpan != nil {
 			if err := p.reg.waitForCaughtUp(*e.sync.testRegCatchupSpan); err != nil {
@@ -785,10 +906,42 @@ func (p *ScheduledProcessor) publishSSTable(
 
 func (p *ScheduledProcessor) publishCheckpoint(ctx context.Context) {
 	// TODO(nvanbenschoten): persist resolvedTimestamp. Give Processor a client.DB.
-	// TODO(nvanbenschoten): rate limit these? send them periodically?
 
 	event := p.newCheckpointEvent()
-	p.reg.PublishToOverlapping(ctx, all, event, false /* omitInRangefeeds */, nil)
+	p.maybeScheduleCheckpointFlush(p.reg.PublishCheckpoint(ctx, event, timeutil.Now()))
+}
+
+// publishPendingCheckpoints publishes the current resolved timestamp to
+// registrations that had checkpoints withheld due to their resolved timestamp
+// interval, once that interval has elapsed.
+func (p *ScheduledProcessor) publishPendingCheckpoints(ctx context.Context) {
+	if p.stopping || p.reg.Len() == 0 {
+		return
+	}
+	event := p.newCheckpointEvent()
+	p.maybeScheduleCheckpointFlush(p.reg.PublishPendingCheckpoints(ctx, event, timeutil.Now()))
+}
+
+// maybeScheduleCheckpointFlush arms the checkpoint timer to publish the
+// withheld checkpoints which are due at the given time, unless it is zero or
+// the timer already fires by then. Withheld checkpoints are thus delivered even
+// if the resolved timestamp doesn't advance anymore.
+func (p *ScheduledProcessor) maybeScheduleCheckpointFlush(due time.Time) {
+	if due.IsZero() || (!p.checkpointFlushAt.IsZero() && !due.Before(p.checkpointFlushAt)) {
+		return
+	}
+	if p.checkpointTimer != nil {
+		p.checkpointTimer.Stop()
+	}
+	p.checkpointFlushAt = due
+	p.checkpointTimer = time.AfterFunc(timeutil.Until(due), func() {
+		p.enqueueRequest(func(ctx context.Context) {
+			if p.checkpointFlushAt.Equal(due) {
+				p.checkpointFlushAt = time.Time{}
+			}
+			p.publishPendingCheckpoints(ctx)
+		})
+	})
 }
 
 func (p *ScheduledProcessor) newCheckpointEvent() *kvpb.RangeFeedEvent {
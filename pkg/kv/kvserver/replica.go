@@ -269,6 +269,48 @@ type Replica struct {
 	// inform load based lease and replica rebalancing decisions.
 	loadStats *load.ReplicaLoad
 
+	// activity tracks the work this replica caused its store to perform over
+	// the last few minutes, for ranking replicas by recent activity.
+	activity replicaActivity
+
+	// applyLatency tracks the latency from the proposal of the commands
+	// proposed by this replica to their application.
+	applyLatency replicaApplyLatency
+
+	// appliedStateHistory retains the last applied states of the replica, see
+	// BisectStatsDivergence.
+	appliedStateHistory replicaAppliedStateHistory
+
+	// applyWatchdog reports the commands which are slow to apply.
+	applyWatchdog applyWatchdog
+
+	// proposalQuotaWaiters tracks the proposals waiting for proposal quota.
+	proposalQuotaWaiters proposalQuotaWaiters
+
+	// rejectedCmds counts the commands rejected below raft by the replica.
+	rejectedCmds rejectedCommandCounts
+
+	// hotKeySampler samples the most frequently accessed keys of the replica
+	// while kv.replica_hot_keys.enabled is set. It is allocated on first use.
+	hotKeySampler atomic.Pointer[load.HotKeySampler]
+
+	// keySpaceUsageTracker tracks the size of the keys of the replica per SQL
+	// index while kv.replica_key_space_usage.enabled is set. It is allocated
+	// when the usage is first reported.
+	keySpaceUsageTracker atomic.Pointer[keySpaceUsageTracker]
+
+	// rangeKeyFragmentation tracks the fragmentation of the MVCC range keys of
+	// the replica, for their defragmentation by the store.
+	rangeKeyFragmentation rangeKeyFragmentation
+
+	// intentResolutionCoalescer coalesces the intent resolution batches
+	// received by the replica.
+	intentResolutionCoalescer intentResolutionCoalescer
+
+	// ingestedSSTs remembers the sstables recently ingested by the replica, to
+	// deduplicate retries of the AddSSTable requests which ingested them.
+	ingestedSSTs ingestedSSTables
+
 	// Held in read mode during read-only commands. Held in exclusive mode to
 	// prevent read-only commands from executing. Acquired before the embedded
 	// RWMutex.
@@ -287,6 +329,11 @@ type Replica struct {
 	// replica.mu lock. All updates to state.Desc should be duplicated here.
 	isInitialized syncutil.AtomicBool
 
+	// rebuildingDivergentReplicas is set while an async task rebuilds the
+	// replicas of the range found to diverge by the consistency checker, so
+	// that the next checks don't start a concurrent rebuild.
+	rebuildingDivergentReplicas syncutil.AtomicBool
+
 	// connectionClass controls the ConnectionClass used to send raft messages.
 	connectionClass atomicConnectionClass
 
@@ -323,6 +370,9 @@ type Replica struct {
 		stateMachine replicaStateMachine
 		// decoder is used to decode committed raft entries.
 		decoder replicaDecoder
+		// appliedCmds holds the seed IDs of the commands most recently applied to the
+		// replica.
+		appliedCmds appliedCmdCache
 	}
 
 	// localMsgs contains a collection of raftpb.Message that target the local
@@ -449,6 +499,13 @@ type Replica struct {
 		// allowed to unquiesce and be Tick()'d. See canUnquiesceRLocked for an
 		// explanation of these conditions.
 		quiescent bool
+		// quiescedAt is the time at which the replica last quiesced.
+		quiescedAt time.Time
+		// hibernated is true if the replica released its raft group after being
+		// quiesced for kv.raft.hibernation.idle_duration. The raft group is then
+		// nil, and is reconstructed under raftMu by
+		// maybeWakeRaftGroupRaftMuLockedReplicaMuLocked.
+		hibernated bool
 		// laggingFollowersOnQuiesce is the set of dead replicas that are not
 		// up-to-date with the rest of the quiescent Raft group. Nil if !quiescent.
 		laggingFollowersOnQuiesce laggingReplicaSet
@@ -728,7 +785,8 @@ type Replica struct {
 		// already held.
 		applyingEntries bool
 		// The replica's Raft group "node". Can be nil for destroyed replicas
-		// (destroyReasonRemoved) and in some tests, otherwise is never nil.
+		// (destroyReasonRemoved), hibernated replicas and in some tests,
+		// otherwise is never nil.
 		//
 		// TODO(erikgrinaker): make this never be nil.
 		internalRaftGroup *raft.RawNode
@@ -782,6 +840,15 @@ type Replica struct {
 		// replica because it looks like it's dead).
 		quotaReleaseQueue []*quotapool.IntAlloc
 
+		// proposalQuotaOverride, if set, replaces the capacity of the proposal
+		// quota pool of the range until its expiration. It is set through the
+		// SetProposalQuota RPC, and is only in effect while the replica is the
+		// raft leader.
+		proposalQuotaOverride struct {
+			capacity   uint64
+			expiration time.Time
+		}
+
 		// Counts calls to Replica.tick()
 		ticks int
 
@@ -805,14 +872,13 @@ type Replica struct {
 		// the request. See the comment on the struct for more details.
 		cachedProtectedTS cachedProtectedTimestampState
 
-		// largestPreviousMaxRangeSizeBytes tracks a previous conf.RangeMaxBytes
-		// which exceeded the current conf.RangeMaxBytes to help defeat the range
-		// backpressure mechanism in cases where a user reduces the configured range
-		// size. It is set when the span config changes to a smaller value and the
-		// current range size exceeds the new value. It is cleared after the range's
-		// size drops below its current conf.MaxRangeBytes or if the
-		// conf.MaxRangeBytes increases to surpass the current value.
-		largestPreviousMaxRangeSizeBytes int64
+		// sizeTracker maintains the estimate of the range's size which informs
+		// size-based splits and merges.
+		sizeTracker rangeSizeTracker
+
+		// closedTSHistory holds samples of the range's closed timestamp, taken
+		// periodically by the store. Nil until the first sample is taken.
+		closedTSHistory *closedTimestampHistory
 
 		// failureToGossipSystemConfig is set to true when the leaseholder of the
 		// range containing the system config span fails to gossip due to an
@@ -897,6 +963,10 @@ type Replica struct {
 	// semaphores.
 	splitQueueThrottle, mergeQueueThrottle util.EveryN
 
+	// timestampSkewReportThrottle throttles the structured events reporting the
+	// commands applied with a write timestamp too far ahead of the local clock.
+	timestampSkewReportThrottle util.EveryN
+
 	// loadBasedSplitter keeps information about load-based splitting.
 	loadBasedSplitter split.Decider
 
@@ -982,24 +1052,8 @@ func (r *Replica) SetSpanConfig(conf roachpb.SpanConfig) bool {
 	oldConf := r.mu.conf
 
 	if r.IsInitialized() && !r.mu.conf.IsEmpty() && !conf.IsEmpty() {
-		total := r.mu.state.Stats.Total()
-
-		// Set largestPreviousMaxRangeSizeBytes if the current range size is
-		// greater than the new limit, if the limit has decreased from what we
-		// last remember, and we don't already have a larger value.
-		if total > conf.RangeMaxBytes && conf.RangeMaxBytes < r.mu.conf.RangeMaxBytes &&
-			r.mu.largestPreviousMaxRangeSizeBytes < r.mu.conf.RangeMaxBytes &&
-			// We also want to make sure that we're replacing a real span config.
-			// If we didn't have this check, the default value would prevent
-			// backpressure until the range got larger than it.
-			r.mu.spanConfigExplicitlySet {
-			r.mu.largestPreviousMaxRangeSizeBytes = r.mu.conf.RangeMaxBytes
-		} else if r.mu.largestPreviousMaxRangeSizeBytes > 0 &&
-			r.mu.largestPreviousMaxRangeSizeBytes < conf.RangeMaxBytes {
-			// Reset it if the new limit is larger than the largest we were
-			// aware of.
-			r.mu.largestPreviousMaxRangeSizeBytes = 0
-		}
+		r.mu.sizeTracker.onSpanConfigChange(r.mu.state.Stats.Total(),
+			r.mu.conf.RangeMaxBytes, conf.RangeMaxBytes, r.mu.spanConfigExplicitlySet)
 	}
 	if knobs := r.store.TestingKnobs(); knobs != nil && knobs.SetSpanConfigInterceptor != nil {
 		conf = knobs.SetSpanConfigInterceptor(r.descRLocked(), conf)
@@ -1586,6 +1640,9 @@ func (r *Replica) State(ctx context.Context) kvserverpb.RangeInfo {
 	ri.NumDropped = uint64(r.mu.droppedMessages)
 	if r.mu.proposalQuota != nil {
 		ri.ApproximateProposalQuota = int64(r.mu.proposalQuota.ApproximateQuota())
+		ri.ProposalQuotaCapacity = int64(r.mu.proposalQuota.Capacity())
+		ri.ProposalQuotaOutstanding = ri.ProposalQuotaCapacity - ri.ApproximateProposalQuota
+		ri.ProposalQuotaLongestWait = r.proposalQuotaWaiters.longestWait(timeutil.Now())
 		ri.ProposalQuotaBaseIndex = int64(r.mu.proposalQuotaBaseIndex)
 		ri.ProposalQuotaReleaseQueue = make([]int64, len(r.mu.quotaReleaseQueue))
 		for i, a := range r.mu.quotaReleaseQueue {
@@ -1610,6 +1667,13 @@ func (r *Replica) State(ctx context.Context) kvserverpb.RangeInfo {
 	if err := r.breaker.Signal().Err(); err != nil {
 		ri.CircuitBreakerError = err.Error()
 	}
+	ri.RejectedCommands = r.rejectedCmds.get()
+	ri.HotKeys = r.hotKeys()
+	ri.KeySpaceUsage = r.keySpaceUsage()
+	if o := r.mu.proposalQuotaOverride; o.capacity != 0 && timeutil.Now().Before(o.expiration) {
+		ri.ProposalQuotaOverride = int64(o.capacity)
+		ri.ProposalQuotaOverrideExpiration = o.expiration
+	}
 	if m := r.mu.pausedFollowers; len(m) > 0 {
 		var sl []roachpb.ReplicaID
 		for id := range m {
@@ -1751,6 +1815,13 @@ func (r *Replica) checkExecutionCanProceedBeforeStorageSnapshot(
 		return kvserverpb.LeaseStatus{}, err
 	}
 
+	// Is the write rejected by a write fence?
+	if ba.IsWrite() {
+		if err := r.checkWriteFencesRLocked(ba); err != nil {
+			return kvserverpb.LeaseStatus{}, err
+		}
+	}
+
 	// Is there a merge in progress? We intentionally check this last to let requests error out
 	// for other reasons first, in case callers don't require this replica to service the request.
 	// Tests such as TestClosedTimestampFrozenAfterSubsumption also rely on this late-checking of
@@ -1927,6 +1998,37 @@ func (r *Replica) checkTSAboveGCThresholdRLocked(
 	}
 }
 
+// checkWriteFencesRLocked returns a WriteFenceError if any of the writes in
+// the batch overlaps with a write fence installed at or above the batch's
+// timestamp.
+//
+// The fences are only checked here, before evaluation, and not below raft.
+// WriteFence requests acquire write latches over the fenced span, so they
+// serialize with all in-flight writes to it, and latches are only released
+// once the command holding them applied, even if its success was acknowledged
+// earlier. So while a write holds its latches, no fence on its span is in
+// flight, and the fences in the applied state are the ones the write applies
+// under. Lease transfers don't open a gap either: the commands proposed under
+// the previous lease apply before the new lease does, or are rejected below
+// raft, so the new leaseholder sees the fences they installed before it
+// evaluates anything. TestReplicaWriteFenceLatching exercises both orderings.
+func (r *Replica) checkWriteFencesRLocked(ba *kvpb.BatchRequest) error {
+	fences := r.mu.state.WriteFences
+	if fences.IsEmpty() {
+		return nil
+	}
+	for _, ru := range ba.Requests {
+		req := ru.GetInner()
+		if !kvpb.IsIntentWrite(req) && req.Method() != kvpb.AddSSTable {
+			continue
+		}
+		if fence, ok := fences.Conflicting(req.Header().Span(), ba.Timestamp); ok {
+			return kvpb.NewWriteFenceError(fence, ba.Timestamp)
+		}
+	}
+	return nil
+}
+
 // shouldWaitForPendingMergeRLocked determines whether the given batch request
 // should wait for an on-going merge to conclude before being allowed to proceed.
 // If not, an error is returned to prevent the request from proceeding until the
@@ -0,0 +1,201 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+const (
+	// replicaActivityBucket is the granularity at which replica activity is
+	// tracked.
+	replicaActivityBucket = time.Minute
+	// replicaActivityBuckets is the number of buckets of replica activity which
+	// are retained, bounding the window over which it can be inspected.
+	replicaActivityBuckets = 30
+	// defaultTopRangesLimit is the number of replicas returned by TopRanges if
+	// the request does not specify a limit.
+	defaultTopRangesLimit = 20
+)
+
+// replicaActivityCounts is the work a replica caused its store to perform.
+type replicaActivityCounts struct {
+	appliedBytes  int64
+	mutations     int64
+	snapshotBytes int64
+	gcBytes       int64
+}
+
+func (c *replicaActivityCounts) add(o replicaActivityCounts) {
+	c.appliedBytes += o.appliedBytes
+	c.mutations += o.mutations
+	c.snapshotBytes += o.snapshotBytes
+	c.gcBytes += o.gcBytes
+}
+
+func (c replicaActivityCounts) get(order kvserverpb.TopRangesOrder) int64 {
+	switch order {
+	case kvserverpb.TopRangesOrder_MUTATIONS:
+		return c.mutations
+	case kvserverpb.TopRangesOrder_SNAPSHOT_BYTES:
+		return c.snapshotBytes
+	case kvserverpb.TopRangesOrder_GC_BYTES:
+		return c.gcBytes
+	default:
+		return c.appliedBytes
+	}
+}
+
+// replicaActivity tracks the work a replica caused its store to perform over
+// the last replicaActivityBuckets minutes, in per-minute buckets. It is used to
+// rank replicas for incident triage without having to scrape metrics for
+// individual ranges.
+type replicaActivity struct {
+	mu struct {
+		syncutil.Mutex
+		// buckets is a ring buffer; buckets[cur] accumulates activity for the
+		// bucket starting at curStart.
+		buckets  [replicaActivityBuckets]replicaActivityCounts
+		cur      int
+		curStart time.Time
+	}
+}
+
+// advanceLocked rotates the ring buffer so that the current bucket contains
+// now, clearing any buckets which have been skipped over.
+func (a *replicaActivity) advanceLocked(now time.Time) {
+	start := now.Truncate(replicaActivityBucket)
+	if a.mu.curStart.IsZero() {
+		a.mu.curStart = start
+		return
+	}
+	n := int(start.Sub(a.mu.curStart) / replicaActivityBucket)
+	if n <= 0 {
+		return
+	}
+	if n > replicaActivityBuckets {
+		n = replicaActivityBuckets
+	}
+	for i := 0; i < n; i++ {
+		a.mu.cur = (a.mu.cur + 1) % replicaActivityBuckets
+		a.mu.buckets[a.mu.cur] = replicaActivityCounts{}
+	}
+	a.mu.curStart = start
+}
+
+// record adds the given activity to the bucket containing now.
+func (a *replicaActivity) record(now time.Time, c replicaActivityCounts) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.advanceLocked(now)
+	a.mu.buckets[a.mu.cur].add(c)
+}
+
+// sum returns the activity recorded over the given number of most recent
+// buckets, including the current, partial one.
+func (a *replicaActivity) sum(now time.Time, buckets int) replicaActivityCounts {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.advanceLocked(now)
+	var c replicaActivityCounts
+	for i := 0; i < buckets; i++ {
+		c.add(a.mu.buckets[(a.mu.cur-i+replicaActivityBuckets)%replicaActivityBuckets])
+	}
+	return c
+}
+
+// topRangesBuckets returns the number of buckets covering the requested
+// window.
+func topRangesBuckets(window time.Duration) int {
+	if window <= 0 {
+		return replicaActivityBuckets
+	}
+	n := int((window + replicaActivityBucket - 1) / replicaActivityBucket)
+	if n > replicaActivityBuckets {
+		n = replicaActivityBuckets
+	}
+	return n
+}
+
+// TopRanges returns the replicas on the store with the most activity over the
+// requested window, in decreasing order.
+func (s *Store) TopRanges(req *kvserverpb.TopRangesRequest) []kvserverpb.TopRangesResponse_Range {
+	now := timeutil.Now()
+	buckets := topRangesBuckets(req.Window)
+	var ranges []kvserverpb.TopRangesResponse_Range
+	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+		c := r.activity.sum(now, buckets)
+		if c == (replicaActivityCounts{}) {
+			return true
+		}
+		ranges = append(ranges, kvserverpb.TopRangesResponse_Range{
+			StoreID:       s.StoreID(),
+			RangeID:       r.RangeID,
+			StartKey:      r.Desc().StartKey,
+			AppliedBytes:  c.appliedBytes,
+			Mutations:     c.mutations,
+			SnapshotBytes: c.snapshotBytes,
+			GCBytes:       c.gcBytes,
+		})
+		return true
+	})
+	return sortAndLimitTopRanges(ranges, req)
+}
+
+// InspectTopRanges ranks the replicas on all stores, or the requested store,
+// by their activity over the requested window.
+func (ls *Stores) InspectTopRanges(
+	_ context.Context, req *kvserverpb.TopRangesRequest,
+) (*kvserverpb.TopRangesResponse, error) {
+	var ranges []kvserverpb.TopRangesResponse_Range
+	if err := ls.VisitStores(func(s *Store) error {
+		if req.StoreID == 0 || req.StoreID == s.StoreID() {
+			ranges = append(ranges, s.TopRanges(req)...)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &kvserverpb.TopRangesResponse{
+		Ranges: sortAndLimitTopRanges(ranges, req),
+		Window: time.Duration(topRangesBuckets(req.Window)) * replicaActivityBucket,
+	}, nil
+}
+
+func sortAndLimitTopRanges(
+	ranges []kvserverpb.TopRangesResponse_Range, req *kvserverpb.TopRangesRequest,
+) []kvserverpb.TopRangesResponse_Range {
+	get := func(r kvserverpb.TopRangesResponse_Range) int64 {
+		return replicaActivityCounts{
+			appliedBytes:  r.AppliedBytes,
+			mutations:     r.Mutations,
+			snapshotBytes: r.SnapshotBytes,
+			gcBytes:       r.GCBytes,
+		}.get(req.OrderBy)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return get(ranges[i]) > get(ranges[j])
+	})
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultTopRangesLimit
+	}
+	if len(ranges) > limit {
+		ranges = ranges[:limit]
+	}
+	return ranges
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaActivity(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	start := time.Unix(1000*60, 0)
+	var a replicaActivity
+	a.record(start, replicaActivityCounts{appliedBytes: 10, mutations: 1})
+	a.record(start.Add(30*time.Second), replicaActivityCounts{appliedBytes: 5, mutations: 1})
+	a.record(start.Add(time.Minute), replicaActivityCounts{snapshotBytes: 100})
+	a.record(start.Add(2*time.Minute), replicaActivityCounts{gcBytes: 7})
+
+	now := start.Add(2 * time.Minute)
+	require.Equal(t, replicaActivityCounts{gcBytes: 7}, a.sum(now, 1))
+	require.Equal(t, replicaActivityCounts{snapshotBytes: 100, gcBytes: 7}, a.sum(now, 2))
+	require.Equal(t, replicaActivityCounts{
+		appliedBytes: 15, mutations: 2, snapshotBytes: 100, gcBytes: 7,
+	}, a.sum(now, replicaActivityBuckets))
+
+	// Activity ages out of the window once its bucket is reused.
+	now = start.Add((replicaActivityBuckets + 1) * time.Minute)
+	require.Equal(t, replicaActivityCounts{gcBytes: 7}, a.sum(now, replicaActivityBuckets))
+	now = now.Add(time.Hour)
+	require.Equal(t, replicaActivityCounts{}, a.sum(now, replicaActivityBuckets))
+
+	require.Equal(t, replicaActivityBuckets, topRangesBuckets(0))
+	require.Equal(t, 1, topRangesBuckets(time.Second))
+	require.Equal(t, 5, topRangesBuckets(5*time.Minute))
+	require.Equal(t, replicaActivityBuckets, topRangesBuckets(24*time.Hour))
+}
+
+func TestSortAndLimitTopRanges(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ranges := []kvserverpb.TopRangesResponse_Range{
+		{RangeID: 1, AppliedBytes: 10, Mutations: 3},
+		{RangeID: 2, AppliedBytes: 30, Mutations: 1},
+		{RangeID: 3, AppliedBytes: 20, Mutations: 2},
+	}
+	rangeIDs := func(ranges []kvserverpb.TopRangesResponse_Range) (ids []int64) {
+		for _, r := range ranges {
+			ids = append(ids, int64(r.RangeID))
+		}
+		return ids
+	}
+	req := &kvserverpb.TopRangesRequest{}
+	require.Equal(t, []int64{2, 3, 1}, rangeIDs(sortAndLimitTopRanges(ranges, req)))
+	req = &kvserverpb.TopRangesRequest{OrderBy: kvserverpb.TopRangesOrder_MUTATIONS, Limit: 2}
+	require.Equal(t, []int64{1, 3}, rangeIDs(sortAndLimitTopRanges(ranges, req)))
+}
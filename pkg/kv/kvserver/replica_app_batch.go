@@ -23,7 +23,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
-	"github.com/cockroachdb/cockroach/pkg/util/log/logcrash"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/redact"
@@ -67,6 +66,18 @@ type replicaAppBatch struct {
 
 	start                   time.Time // time at NewBatch()
 	followerStoreWriteBytes kvadmission.FollowerStoreWriteBytes
+	// proposedAt holds the proposal times of the local commands in the batch,
+	// to record their proposal-to-apply latency once the batch is committed.
+	proposedAt []time.Time
+	// cmds holds the commands staged in the batch, to be reported by the
+	// replica's apply watchdog while the batch is committed. It is only
+	// populated while the watchdog is enabled.
+	cmds []*replicatedCmd
+	// tracedCmds holds the local commands staged in the batch whose proposer is
+	// tracing them, to record the commit of the batch in their traces.
+	tracedCmds []*replicatedCmd
+	// checkLevel is the level of the sanity checks performed on the batch.
+	checkLevel applyCheckLevel
 
 	// Reused by addAppliedStateKeyToBatch to avoid heap allocations.
 	asAlloc kvserverpb.RangeAppliedState
@@ -98,6 +109,15 @@ func (b *replicaAppBatch) Stage(
 	ctx context.Context, cmdI apply.Command,
 ) (apply.CheckedCommand, error) {
 	cmd := cmdI.(*replicatedCmd)
+	b.r.applyWatchdog.start(applyPhaseStage, cmd)
+	defer b.r.applyWatchdog.stop()
+	if slowApplyThreshold.Get(&b.r.ClusterSettings().SV) != 0 {
+		b.cmds = append(b.cmds, cmd)
+	}
+	if cmd.IsLocal() && cmd.sp != nil {
+		b.tracedCmds = append(b.tracedCmds, cmd)
+	}
+	log.Eventf(ctx, "staging command at raft index %d", cmd.Index())
 
 	// We'll follow the steps outlined in appBatch's comment here, and will call
 	// into appBatch at appropriate times.
@@ -105,6 +125,7 @@ func (b *replicaAppBatch) Stage(
 
 	fr, err := ab.assertAndCheckCommand(ctx, &cmd.ReplicatedCmd, &b.state, cmd.IsLocal())
 	if err != nil {
+		b.r.store.metrics.RaftApplyCheckFailures[applyCheckAppliedIndex].Inc(1)
 		return nil, err
 	}
 
@@ -116,11 +137,17 @@ func (b *replicaAppBatch) Stage(
 	// Now update cmd. We'll either put the lease index in it or zero out
 	// the cmd in case there's a forced error.
 	ab.toCheckedCmd(ctx, &cmd.ReplicatedCmd, fr)
+	b.r.recordRejectedCommand(fr)
 
 	// TODO(tbg): these assertions should be pushed into
 	// (*appBatch).assertAndCheckCommand.
-	b.assertNoCmdClosedTimestampRegression(ctx, cmd)
-	b.assertNoWriteBelowClosedTimestamp(ctx, cmd)
+	if b.shouldCheck(applyCheckCmdClosedTimestamp) {
+		b.assertNoCmdClosedTimestampRegression(ctx, cmd)
+	}
+	if b.shouldCheck(applyCheckWriteBelowClosedTimestamp) {
+		b.assertNoWriteBelowClosedTimestamp(ctx, cmd)
+	}
+	b.checkTimestampSkew(ctx, cmd)
 
 	// Run any triggers that should occur before the batch is applied
 	// and before the write batch is staged in the batch.
@@ -163,12 +190,19 @@ func (b *replicaAppBatch) Stage(
 	// non-trivial ReplicatedState updates until later (without ever staging
 	// them in the batch) is sufficient.
 	b.stageTrivialReplicatedEvalResult(ctx, cmd)
+	if !cmd.Rejected() && cmd.ID != "" {
+		b.r.raftMu.appliedCmds.add(cmd.seedID())
+	}
+	if !cmd.Rejected() && !cmd.proposedAt.IsZero() {
+		b.proposedAt = append(b.proposedAt, cmd.proposedAt)
+	}
 	b.ab.numEntriesProcessed++
 	size := len(cmd.Data)
 	b.ab.numEntriesProcessedBytes += int64(size)
 	if size == 0 {
 		b.ab.numEmptyEntries++
 	}
+	log.Event(ctx, "ran triggers and staged command in application batch")
 
 	// The command was checked by shouldApplyCommand, so it can be returned
 	// as an apply.CheckedCommand.
@@ -199,11 +233,17 @@ func (b *replicaAppBatch) runPreAddTriggersReplicaOnly(
 		// application there are no listening rangefeeds. So we do this only
 		// in Replica application.
 		if p, filter := b.r.getRangefeedProcessorAndFilter(); p != nil {
-			if err := populatePrevValsInLogicalOpLog(ctx, filter, ops, b.batch); err != nil {
+			if err := populatePrevValsInLogicalOpLog(
+				ctx, filter, ops, b.batch, b.r.store.metrics.RangeFeedMetrics,
+			); err != nil {
 				b.r.disconnectRangefeedWithErr(p, kvpb.NewError(err))
 			}
 		}
 	}
+	// Account for the writes of the command in the key space usage of the
+	// replica, which requires reading the versions they overwrite before the
+	// write batch is staged.
+	b.r.recordKeySpaceUsage(ctx, b.batch, cmd)
 	return nil
 }
 
@@ -263,29 +303,42 @@ func (b *replicaAppBatch) runPostAddTriggersReplicaOnly(
 		b.followerStoreWriteBytes.IngestedBytes += ingestedBytes
 	}
 
+	// Sample the keys written by the command, to estimate the hot keys of the
+	// replica.
+	if wb := cmd.Cmd.WriteBatch; wb != nil {
+		b.r.recordHotWriteKeys(ctx, wb.Data)
+		// Account for the fragmentation of the MVCC range keys written by the
+		// command, which is now staged in the batch.
+		b.r.recordRangeKeyFragmentation(ctx, b.batch, wb.Data)
+	}
+
 	// MVCC history mutations violate the closed timestamp, modifying data that
 	// has already been emitted and checkpointed via a rangefeed. Callers are
 	// expected to ensure that no rangefeeds are currently active across such
-	// spans, but as a safeguard we disconnect the overlapping rangefeeds
-	// with a non-retriable error anyway.
+	// spans, unless they coordinated the mutation with the rangefeed consumer via
+	// an MVCC history mutation token. As a safeguard we disconnect all other
+	// overlapping rangefeeds with a non-retriable error anyway.
 	//
 	// The are no rangefeeds in standalone mode, so we don't have to do anything
 	// for this on appBatch.
 	if res.MVCCHistoryMutation != nil {
 		for _, span := range res.MVCCHistoryMutation.Spans {
-			b.r.disconnectRangefeedSpanWithErr(span, kvpb.NewError(&kvpb.MVCCHistoryMutationError{
-				Span: span,
-			}))
+			b.r.handleMVCCHistoryMutationRaftMuLocked(ctx, span, res.MVCCHistoryMutation.Token)
+			b.r.ingestedSSTs.forget(span)
 		}
 	}
 
 	if res.AddSSTable != nil {
 		// We've ingested the SST already (via the appBatch), so all that's left
-		// to do here is notify the rangefeed, if appropriate.
+		// to do here is notify the rangefeed, if appropriate, and remember the
+		// SST to deduplicate retries of its ingestion.
 		if res.AddSSTable.AtWriteTimestamp {
 			b.r.handleSSTableRaftMuLocked(
 				ctx, res.AddSSTable.Data, res.AddSSTable.Span, res.WriteTimestamp)
 		}
+		if res.AddSSTable.ContentHash != nil {
+			b.r.ingestedSSTs.record(res.AddSSTable.Span, res.WriteTimestamp, res.AddSSTable.ContentHash)
+		}
 		res.AddSSTable = nil
 	}
 
@@ -468,6 +521,15 @@ func (b *replicaAppBatch) runPostAddTriggersReplicaOnly(
 		}
 	}
 
+	// Record the splits, merges, replication and lease changes in the range
+	// event log of the replica. The log is not part of the replicated state, so
+	// this is not done in standalone log application.
+	if ev, ok := makeRangeEvent(cmd.Index(), res, b.state.Lease); ok {
+		if err := writeRangeEvent(ctx, b.batch, b.r.RangeID, &ev); err != nil {
+			return err
+		}
+	}
+
 	// Detect if this command will remove us from the range.
 	// If so we stage the removal of all of our range data into this batch.
 	// We'll complete the removal when it commits. Later logic detects the
@@ -562,6 +624,9 @@ func (b *replicaAppBatch) stageTrivialReplicatedEvalResult(
 // engine. This encompasses the persistent state transition portion of entry
 // application.
 func (b *replicaAppBatch) ApplyToStateMachine(ctx context.Context) error {
+	b.r.applyWatchdog.start(applyPhaseApply, b.cmds...)
+	defer b.r.applyWatchdog.stop()
+
 	if log.V(4) {
 		log.Infof(ctx, "flushing batch %v of %d entries", b.state, b.ab.numEntriesProcessed)
 	}
@@ -601,15 +666,26 @@ func (b *replicaAppBatch) ApplyToStateMachine(ctx context.Context) error {
 	// application of this command. I.e. the loosely coupled truncation migration
 	// mentioned above likely needs to be done first.
 	sync := b.changeRemovesReplica || b.changeTruncatesSideloadedFiles
+	commitStart := timeutil.Now()
 	if err := b.batch.Commit(sync); err != nil {
 		return errors.Wrapf(err, "unable to commit Raft entry batch")
 	}
 	b.batch.Close()
 	b.batch = nil
+	// The batch is committed on behalf of all its commands, record the commit
+	// in the traces of the local ones.
+	if len(b.tracedCmds) > 0 {
+		commitDur := timeutil.Since(commitStart)
+		for _, cmd := range b.tracedCmds {
+			log.Eventf(cmd.ctx, "committed application batch of %d entries (%d bytes, sync=%t) in %s",
+				b.ab.numEntriesProcessed, b.ab.numEntriesProcessedBytes, sync, commitDur)
+		}
+	}
 
 	// Update the replica's applied indexes, mvcc stats and closed timestamp.
 	r := b.r
 	r.mu.Lock()
+	prevLeaseAppliedIndex := r.mu.state.LeaseAppliedIndex
 	r.mu.state.RaftAppliedIndex = b.state.RaftAppliedIndex
 	r.mu.state.RaftAppliedIndexTerm = b.state.RaftAppliedIndexTerm
 	r.mu.state.LeaseAppliedIndex = b.state.LeaseAppliedIndex
@@ -617,11 +693,12 @@ func (b *replicaAppBatch) ApplyToStateMachine(ctx context.Context) error {
 	// Sanity check that the RaftClosedTimestamp doesn't go backwards.
 	existingClosed := r.mu.state.RaftClosedTimestamp
 	newClosed := b.state.RaftClosedTimestamp
-	if !newClosed.IsEmpty() && newClosed.Less(existingClosed) {
+	if b.shouldCheck(applyCheckBatchClosedTimestamp) &&
+		!newClosed.IsEmpty() && newClosed.Less(existingClosed) {
 		err := errors.AssertionFailedf(
 			"raft closed timestamp regression; replica has: %s, new batch has: %s.",
 			existingClosed.String(), newClosed.String())
-		logcrash.ReportOrPanic(ctx, &b.r.ClusterSettings().SV, "%v", err)
+		b.checkFailed(ctx, applyCheckBatchClosedTimestamp, err)
 	}
 	r.mu.closedTimestampSetter = b.closedTimestampSetter
 
@@ -629,11 +706,7 @@ func (b *replicaAppBatch) ApplyToStateMachine(ctx context.Context) error {
 	prevStats := *r.mu.state.Stats
 	*r.mu.state.Stats = *b.state.Stats
 
-	// If the range is now less than its RangeMaxBytes, clear the history of its
-	// largest previous max bytes.
-	if r.mu.largestPreviousMaxRangeSizeBytes > 0 && b.state.Stats.Total() < r.mu.conf.RangeMaxBytes {
-		r.mu.largestPreviousMaxRangeSizeBytes = 0
-	}
+	r.mu.sizeTracker.onSizeChange(r.mu.state.Stats.Total(), r.mu.conf.RangeMaxBytes)
 
 	// Check the queuing conditions while holding the lock.
 	needsSplitBySize := r.needsSplitBySizeRLocked()
@@ -649,10 +722,16 @@ func (b *replicaAppBatch) ApplyToStateMachine(ctx context.Context) error {
 	deltaStats.Subtract(prevStats)
 	r.store.metrics.addMVCCStats(ctx, r.tenantMetricsRef, deltaStats)
 
+	b.checkReplicaStateInvariants(ctx, prevLeaseAppliedIndex)
+
 	// Record the number of keys written to the replica.
 	b.r.loadStats.RecordWriteKeys(float64(b.ab.numMutations))
 
 	now := timeutil.Now()
+	b.r.activity.record(now, replicaActivityCounts{
+		appliedBytes: b.ab.numEntriesProcessedBytes,
+		mutations:    int64(b.ab.numMutations),
+	})
 	if needsSplitBySize && r.splitQueueThrottle.ShouldProcess(now) {
 		r.store.splitQueue.MaybeAddAsync(ctx, r, r.store.Clock().NowAsClockTimestamp())
 	}
@@ -663,7 +742,7 @@ func (b *replicaAppBatch) ApplyToStateMachine(ctx context.Context) error {
 		r.store.raftLogQueue.MaybeAddAsync(ctx, r, r.store.Clock().NowAsClockTimestamp())
 	}
 
-	b.recordStatsOnCommit()
+	b.recordStatsOnCommit(ctx)
 	return nil
 }
 
@@ -680,9 +759,12 @@ func (b *replicaAppBatch) addAppliedStateKeyToBatch(ctx context.Context) error {
 	)
 }
 
-func (b *replicaAppBatch) recordStatsOnCommit() {
+func (b *replicaAppBatch) recordStatsOnCommit(ctx context.Context) {
 	b.applyStats.appBatchStats.merge(b.ab.appBatchStats)
 	b.applyStats.numBatchesProcessed++
+	b.r.store.metrics.RaftApplyBatchEntries.RecordValue(int64(b.ab.numEntriesProcessed))
+	b.r.store.metrics.RaftApplyBatchBytes.RecordValue(b.ab.numEntriesProcessedBytes)
+	b.r.store.metrics.recordRaftApply(ctx, b.r.tenantMetricsRef, b.ab.appBatchStats)
 	b.applyStats.followerStoreWriteBytes.Merge(b.followerStoreWriteBytes)
 
 	if n := b.ab.numAddSST; n > 0 {
@@ -692,8 +774,14 @@ func (b *replicaAppBatch) recordStatsOnCommit() {
 		b.r.store.metrics.AddSSTableApplicationCopies.Inc(int64(n))
 	}
 
-	elapsed := timeutil.Since(b.start)
+	now := timeutil.Now()
+	elapsed := now.Sub(b.start)
 	b.r.store.metrics.RaftCommandCommitLatency.RecordValue(elapsed.Nanoseconds())
+
+	if len(b.proposedAt) > 0 {
+		b.r.recordProposalToApplyLatencies(ctx, now, b.proposedAt)
+	}
+	b.recordAppliedState()
 }
 
 // Close implements the apply.Batch interface.
@@ -734,7 +822,7 @@ func (b *replicaAppBatch) assertNoWriteBelowClosedTimestamp(
 			cmd.ID, wts,
 			b.state.RaftClosedTimestamp, cmd.Cmd.ClosedTimestamp,
 			req, b.state.Lease)
-		logcrash.ReportOrPanic(ctx, &b.r.ClusterSettings().SV, "%v", err)
+		b.checkFailed(ctx, applyCheckWriteBelowClosedTimestamp, err)
 	}
 }
 
@@ -775,7 +863,7 @@ func (b *replicaAppBatch) assertNoCmdClosedTimestampRegression(
 			cmd.ID, cmd.Term, cmd.Index(), existingClosed, newClosed, b.state.Lease, req, cmd.LeaseIndex,
 			prevReq, b.closedTimestampSetter.lease, b.closedTimestampSetter.leaseIdx, b.ab.numEntriesProcessed,
 			logTail)
-		logcrash.ReportOrPanic(ctx, &b.r.ClusterSettings().SV, "%v", err)
+		b.checkFailed(ctx, applyCheckCmdClosedTimestamp, err)
 	}
 }
 
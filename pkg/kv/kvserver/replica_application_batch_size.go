@@ -0,0 +1,137 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// maxApplicationBatchEntries is the maximum number of raft entries applied to
+// the state machine of a replica in a single batch.
+var maxApplicationBatchEntries = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"kv.raft.apply.max_batch_entries",
+	"the maximum number of raft entries applied to the state machine of a range in a "+
+		"single batch (0 for no limit); smaller batches lower the latency of the commands "+
+		"at the front of a batch, at the expense of the application throughput",
+	0,
+	settings.NonNegativeInt,
+)
+
+// maxApplicationBatchBytes is the maximum total size of the raft entries
+// applied to the state machine of a replica in a single batch.
+var maxApplicationBatchBytes = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.raft.apply.max_batch_bytes",
+	"the maximum total size of the raft entries applied to the state machine of a range "+
+		"in a single batch (0 for no limit); a batch always contains at least one entry",
+	0,
+	settings.NonNegativeInt,
+)
+
+const (
+	// applicationBatchMemoryPressure is the fraction of the limit of the KV
+	// memory monitor above which the limits of application batches are shrunk.
+	applicationBatchMemoryPressure = 0.8
+	// applicationBatchMaxShrinkShift bounds the shrinking of the limits of
+	// application batches to 1/16th of their configured value.
+	applicationBatchMaxShrinkShift = 4
+	// applicationBatchResizeInterval is the minimum interval between two
+	// adjustments of the shrinking of the limits of application batches.
+	applicationBatchResizeInterval = time.Second
+	// applicationBatchBytesUnderMemoryPressure is the byte limit of application
+	// batches which is shrunk under memory pressure when
+	// kv.raft.apply.max_batch_bytes doesn't set one.
+	applicationBatchBytesUnderMemoryPressure = 64 << 20 // 64 MiB
+)
+
+// applicationBatchSizer adapts the limits of the raft application batches of
+// a store to its memory pressure. While the store is under memory pressure,
+// the limits are halved at every adjustment, down to 1/16th of their
+// configured value, and they are doubled back at every adjustment once the
+// pressure is gone. Smaller batches lower the amount of memory held by the
+// application of the commands of a range at any point in time.
+type applicationBatchSizer struct {
+	// shift is the number of times the configured limits are halved.
+	shift atomic.Int32
+	// lastResize is the time, in unix nanos, of the last adjustment of shift.
+	lastResize atomic.Int64
+}
+
+// maybeResize adjusts the shrinking of the limits to the memory pressure, if
+// the last adjustment is at least applicationBatchResizeInterval old. It
+// returns the current shift of the limits.
+func (b *applicationBatchSizer) maybeResize(now time.Time, underPressure func() bool) int32 {
+	last := b.lastResize.Load()
+	if now.UnixNano()-last < applicationBatchResizeInterval.Nanoseconds() ||
+		!b.lastResize.CompareAndSwap(last, now.UnixNano()) {
+		// Not yet due, or adjusted concurrently.
+		return b.shift.Load()
+	}
+	shift := b.shift.Load()
+	if underPressure() {
+		if shift < applicationBatchMaxShrinkShift {
+			shift++
+		}
+	} else if shift > 0 {
+		shift--
+	}
+	b.shift.Store(shift)
+	return shift
+}
+
+// limits returns the limits of application batches, given the configured
+// ones. A limit of 0 means no limit.
+func (b *applicationBatchSizer) limits(maxEntries, maxBytes int64) (int64, int64) {
+	shift := b.shift.Load()
+	if shift == 0 {
+		return maxEntries, maxBytes
+	}
+	if maxEntries > 0 {
+		maxEntries = max(maxEntries>>shift, 1)
+	}
+	if maxBytes == 0 {
+		maxBytes = applicationBatchBytesUnderMemoryPressure
+	}
+	maxBytes = max(maxBytes>>shift, 1)
+	return maxEntries, maxBytes
+}
+
+// underMemoryPressure returns whether the usage of the KV memory monitor is
+// close to its limit.
+func (s *Store) underMemoryPressure() bool {
+	m := s.cfg.KVMemoryMonitor
+	if m == nil {
+		return false
+	}
+	limit := m.Limit()
+	return limit > 0 && float64(m.AllocBytes()) > applicationBatchMemoryPressure*float64(limit)
+}
+
+// applicationBatchLimits returns the maximum number of entries and the
+// maximum total size of the entries of the raft application batches of the
+// replicas of the store. A limit of 0 means no limit.
+func (s *Store) applicationBatchLimits() (maxEntries int, maxBytes int64) {
+	shift := s.appBatchSizer.maybeResize(timeutil.Now(), s.underMemoryPressure)
+	s.metrics.RaftApplyBatchShrinkFactor.Update(int64(1) << shift)
+
+	sv := &s.ClusterSettings().SV
+	entries, bytes := s.appBatchSizer.limits(
+		maxApplicationBatchEntries.Get(sv), maxApplicationBatchBytes.Get(sv))
+	if n := s.TestingKnobs().MaxApplicationBatchSize; n > 0 {
+		entries = int64(n)
+	}
+	return int(entries), bytes
+}
@@ -0,0 +1,70 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplicationBatchSizer(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var b applicationBatchSizer
+	var pressure bool
+	underPressure := func() bool { return pressure }
+	now := time.Unix(1000, 0)
+
+	// Without memory pressure, the configured limits apply.
+	require.Equal(t, int32(0), b.maybeResize(now, underPressure))
+	entries, bytes := b.limits(100, 0)
+	require.Equal(t, int64(100), entries)
+	require.Equal(t, int64(0), bytes)
+
+	// Under memory pressure, the limits are halved at most once per interval,
+	// and an unlimited byte limit is shrunk from a baseline.
+	pressure = true
+	now = now.Add(applicationBatchResizeInterval)
+	require.Equal(t, int32(1), b.maybeResize(now, underPressure))
+	require.Equal(t, int32(1), b.maybeResize(now.Add(time.Millisecond), underPressure))
+	entries, bytes = b.limits(100, 0)
+	require.Equal(t, int64(50), entries)
+	require.Equal(t, int64(applicationBatchBytesUnderMemoryPressure/2), bytes)
+
+	// The shrinking is bounded, and the limits never drop to 0 (no limit).
+	for i := 0; i < 2*applicationBatchMaxShrinkShift; i++ {
+		now = now.Add(applicationBatchResizeInterval)
+		b.maybeResize(now, underPressure)
+	}
+	require.Equal(t, int32(applicationBatchMaxShrinkShift), b.shift.Load())
+	entries, bytes = b.limits(10, 8)
+	require.Equal(t, int64(1), entries)
+	require.Equal(t, int64(1), bytes)
+	entries, _ = b.limits(0, 0)
+	require.Equal(t, int64(0), entries)
+
+	// Once the pressure is gone, the limits grow back.
+	pressure = false
+	now = now.Add(applicationBatchResizeInterval)
+	require.Equal(t, int32(applicationBatchMaxShrinkShift-1), b.maybeResize(now, underPressure))
+	for i := 0; i < applicationBatchMaxShrinkShift; i++ {
+		now = now.Add(applicationBatchResizeInterval)
+		b.maybeResize(now, underPressure)
+	}
+	entries, bytes = b.limits(100, 1000)
+	require.Equal(t, int64(100), entries)
+	require.Equal(t, int64(1000), bytes)
+}
@@ -12,9 +12,11 @@ package kvserver
 
 import (
 	"context"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/batcheval/result"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/raftlog"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
@@ -45,6 +47,9 @@ type replicatedCmd struct {
 	// proposal is populated on the proposing Replica only and comes from the
 	// Replica's proposal map.
 	proposal *ProposalData
+	// proposedAt is the time at which the command was proposed. It is only
+	// populated on the proposing Replica, along with proposal.
+	proposedAt time.Time
 
 	// ctx is a non-cancelable context used to apply the command.
 	ctx context.Context
@@ -69,6 +74,15 @@ type replicatedCmd struct {
 	response    proposalResult
 }
 
+// seedID returns the ID of the first proposal of the command, which is shared
+// by all its copies, including the reproposals under new lease indexes.
+func (c *replicatedCmd) seedID() kvserverbase.CmdIDKey {
+	if c.Cmd.SeedID != "" {
+		return kvserverbase.CmdIDKey(c.Cmd.SeedID)
+	}
+	return c.ID
+}
+
 // IsLocal implements apply.Command.
 func (c *replicatedCmd) IsLocal() bool {
 	return c.proposal != nil
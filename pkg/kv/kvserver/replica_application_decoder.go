@@ -112,6 +112,7 @@ func (d *replicaDecoder) retrieveLocalProposals() (anyLocal bool) {
 				cmd.proposal = nil
 			} else {
 				cmd.proposal.v2SeenDuringApplication = true
+				cmd.proposedAt = cmd.proposal.ec.replicatingSince
 				anyLocal = true
 				delete(d.r.mu.proposals, cmd.ID)
 				if d.r.mu.proposalQuota != nil {
@@ -0,0 +1,99 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"runtime/pprof"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+)
+
+// applicationProfilerLabelGranularity controls the profiler labels with which
+// the application of raft commands is tagged.
+type applicationProfilerLabelGranularity int64
+
+const (
+	// applicationProfilerLabelsOff disables the profiler labels of the
+	// application of raft commands.
+	applicationProfilerLabelsOff applicationProfilerLabelGranularity = iota
+	// applicationProfilerLabelsTenant labels the application of raft commands
+	// with the tenant of the range.
+	applicationProfilerLabelsTenant
+	// applicationProfilerLabelsRange labels the application of raft commands
+	// with the tenant and the ID of the range. The number of distinct labels is
+	// the number of ranges, which can make CPU profiles large.
+	applicationProfilerLabelsRange
+)
+
+// applicationProfilerLabels is the granularity of the profiler labels with
+// which the application of raft commands is tagged, while a CPU profile with
+// labels is being recorded.
+var applicationProfilerLabels = settings.RegisterEnumSetting(
+	settings.SystemOnly,
+	"kv.raft.apply.profiler_labels",
+	"the granularity of the profiler labels of the application of raft commands, "+
+		"which attribute the CPU time of the application to tenants or ranges in CPU "+
+		"profiles with labels; labelling ranges can make profiles large on stores with "+
+		"many ranges",
+	"tenant",
+	map[int64]string{
+		int64(applicationProfilerLabelsOff):    "off",
+		int64(applicationProfilerLabelsTenant): "tenant",
+		int64(applicationProfilerLabelsRange):  "range",
+	},
+)
+
+// makeApplicationProfilerLabels returns the profiler labels of the application
+// of the raft commands of the given range, at the given granularity.
+func makeApplicationProfilerLabels(
+	granularity applicationProfilerLabelGranularity,
+	rangeID roachpb.RangeID,
+	tenantID roachpb.TenantID,
+	tenantOK bool,
+) []string {
+	if granularity == applicationProfilerLabelsOff {
+		return nil
+	}
+	labels := []string{"raft_apply", "true"}
+	if tenantOK {
+		labels = append(labels, "tenant_id", tenantID.String())
+	}
+	if granularity == applicationProfilerLabelsRange {
+		labels = append(labels, "range_id", rangeID.String())
+	}
+	return labels
+}
+
+// setApplicationProfilerLabels tags the goroutine with the profiler labels of
+// the application of the raft commands of the replica, if a CPU profile with
+// labels is being recorded. The returned function restores the labels of the
+// given context, and must be called once the commands are applied.
+func (r *Replica) setApplicationProfilerLabels(ctx context.Context) (context.Context, func()) {
+	st := r.store.cfg.Settings
+	if st.CPUProfileType() != cluster.CPUProfileWithLabels {
+		return ctx, func() {}
+	}
+	tenantID, ok := r.TenantID()
+	labels := makeApplicationProfilerLabels(
+		applicationProfilerLabelGranularity(applicationProfilerLabels.Get(&st.SV)),
+		r.RangeID, tenantID, ok,
+	)
+	if len(labels) == 0 {
+		return ctx, func() {}
+	}
+	prevCtx := ctx
+	ctx = pprof.WithLabels(ctx, pprof.Labels(labels...))
+	pprof.SetGoroutineLabels(ctx)
+	return ctx, func() { pprof.SetGoroutineLabels(prevCtx) }
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeApplicationProfilerLabels(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	tenantID := roachpb.MustMakeTenantID(5)
+	for _, tc := range []struct {
+		name        string
+		granularity applicationProfilerLabelGranularity
+		tenantOK    bool
+		exp         []string
+	}{
+		{
+			name:        "off",
+			granularity: applicationProfilerLabelsOff,
+			tenantOK:    true,
+		},
+		{
+			name:        "tenant",
+			granularity: applicationProfilerLabelsTenant,
+			tenantOK:    true,
+			exp:         []string{"raft_apply", "true", "tenant_id", "5"},
+		},
+		{
+			name:        "range",
+			granularity: applicationProfilerLabelsRange,
+			tenantOK:    true,
+			exp:         []string{"raft_apply", "true", "tenant_id", "5", "range_id", "7"},
+		},
+		{
+			name:        "uninitialized",
+			granularity: applicationProfilerLabelsRange,
+			exp:         []string{"raft_apply", "true", "range_id", "7"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp,
+				makeApplicationProfilerLabels(tc.granularity, 7, tenantID, tc.tenantOK))
+		})
+	}
+}
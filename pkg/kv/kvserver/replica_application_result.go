@@ -169,11 +169,39 @@ func (r *Replica) prepareLocalResult(ctx context.Context, cmd *replicatedCmd) {
 			// These many possible worlds are a major source of complexity, a
 			// reduction of which is postponed.
 			pErr = nil
+			if r.raftMu.appliedCmds.contains(cmd.seedID()) {
+				// Another copy of the command has already applied, but wasn't
+				// matched to the proposal (see appliedCmdCache). Acknowledge the
+				// proposal instead of reproposing it, which would apply it twice.
+				log.VEventf(ctx, 2, "command %x already applied, acknowledging it", cmd.ID)
+				r.store.metrics.RaftCommandsDeduplicated.Inc(1)
+				cmd.response.Err = nil
+				cmd.response.Reply = cmd.proposal.Local.Reply
+				break
+			}
 			if fn := r.store.TestingKnobs().InjectReproposalError; fn != nil {
 				if err := fn(cmd.proposal); err != nil {
 					pErr = kvpb.NewError(err)
 				}
 			}
+			if pErr == nil {
+				// Record the rejection, and give up on the command if it failed the
+				// check too many times. No other copy of the command can apply, so
+				// the error is unambiguous.
+				r.mu.RLock()
+				rej := kvpb.LeaseIndexRejection{
+					MaxLeaseIndex:         cmd.Cmd.MaxLeaseIndex,
+					LeaseAppliedIndex:     r.mu.state.LeaseAppliedIndex,
+					ProposerLeaseSequence: cmd.Cmd.ProposerLeaseSequence,
+					LeaseSequence:         r.mu.state.Lease.Sequence,
+				}
+				r.mu.RUnlock()
+				maxAttempts := leaseIndexReproposalMaxAttempts.Get(&r.store.cfg.Settings.SV)
+				if err := r.recordLeaseIndexRejection(cmd.proposal, rej, maxAttempts); err != nil {
+					log.VEventf(ctx, 2, "not reproposing command %x: %v", cmd.ID, err)
+					pErr = kvpb.NewError(err)
+				}
+			}
 			if pErr == nil { // since we might have injected an error
 				pErr = kvpb.NewError(r.tryReproposeWithNewLeaseIndex(ctx, cmd))
 				if pErr == nil {
@@ -296,6 +324,7 @@ func (r *Replica) makeReproposal(origP *ProposalData) (reproposal *ProposalData,
 		WriteBatch:            origP.command.WriteBatch,
 		LogicalOpLog:          origP.command.LogicalOpLog,
 		TraceData:             origP.command.TraceData,
+		SeedID:                string(seedP.idKey),
 
 		MaxLeaseIndex:       0,   // assigned on flush
 		ClosedTimestamp:     nil, // assigned on flush
@@ -406,14 +435,16 @@ func (r *Replica) tryReproposeWithNewLeaseIndex(ctx context.Context, origCmd *re
 	if newProposal.Request.AppliesTimestampCache() && newProposal.Request.WriteTimestamp().LessEq(minTS) {
 		// The tracker wants us to forward the request timestamp, but we can't
 		// do that without re-evaluating, so give up. The error returned here
-		// will go to back to DistSender, so send something it can digest.
+		// will go to back to DistSender, so send something it can digest. It is
+		// marked so that the batch can instead be re-evaluated above Raft, see
+		// handleReproposalBelowClosedTimestampError.
 		err := kvpb.NewNotLeaseHolderError(
 			*r.mu.state.Lease,
 			r.store.StoreID(),
 			r.mu.state.Desc,
 			"reproposal failed due to closed timestamp",
 		)
-		return err
+		return errors.Mark(err, errReproposalBelowClosedTimestamp)
 	}
 	// Some tests check for this log message in the trace.
 	log.VEventf(ctx, 2, "retry: proposalIllegalLeaseIndex")
@@ -455,6 +486,13 @@ func (r *Replica) handleMergeResult(ctx context.Context, merge *kvserverpb.Merge
 		// Our in-memory state has diverged from the on-disk state.
 		log.Fatalf(ctx, "failed to update store after merging range: %s", err)
 	}
+	if r.store.cfg.ApplyTriggers != nil {
+		r.store.cfg.ApplyTriggers.NotifySplitMerge(ctx, kvserverbase.SplitMergeEvent{
+			Merge:           true,
+			Ranges:          []roachpb.RangeInfo{r.GetRangeInfo(ctx)},
+			SubsumedRangeID: merge.RightDesc.RangeID,
+		})
+	}
 }
 
 func (r *Replica) handleDescResult(ctx context.Context, desc *roachpb.RangeDescriptor) {
@@ -465,12 +503,14 @@ func (r *Replica) handleLeaseResult(
 	ctx context.Context, lease *roachpb.Lease, priorReadSum *rspb.ReadSummary,
 ) {
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	prevLease := r.mu.state.Lease
 	r.leasePostApplyLocked(ctx,
-		r.mu.state.Lease, /* prevLease */
-		lease,            /* newLease */
+		prevLease, /* prevLease */
+		lease,     /* newLease */
 		priorReadSum,
 		assertNoLeaseJump)
+	r.mu.Unlock()
+	r.notifyRangeStateChange(ctx, RangeStateLease, protoOrNil(prevLease), lease)
 }
 
 func (r *Replica) handleTruncatedStateResult(
@@ -518,14 +558,53 @@ func (r *Replica) handleGCThresholdResult(ctx context.Context, thresh *hlc.Times
 		return
 	}
 	r.mu.Lock()
+	prevThresh := r.mu.state.GCThreshold
 	r.mu.state.GCThreshold = thresh
 	r.mu.Unlock()
+	r.notifyRangeStateChange(ctx, RangeStateGCThreshold, protoOrNil(prevThresh), thresh)
 }
 
 func (r *Replica) handleGCHintResult(ctx context.Context, hint *roachpb.GCHint) {
 	r.mu.Lock()
+	prevHint := r.mu.state.GCHint
 	r.mu.state.GCHint = hint
 	r.mu.Unlock()
+	r.notifyRangeStateChange(ctx, RangeStateGCHint, protoOrNil(prevHint), hint)
+}
+
+func (r *Replica) handleWriteFencesResult(ctx context.Context, fences *roachpb.WriteFences) {
+	r.mu.Lock()
+	prevFences := r.mu.state.WriteFences
+	r.mu.state.WriteFences = fences
+	r.mu.Unlock()
+	r.notifyRangeStateChange(ctx, RangeStateWriteFences, protoOrNil(prevFences), protoOrNil(fences))
+}
+
+func (r *Replica) handleReplicaPinResult(ctx context.Context, pin *roachpb.ReplicaPin) {
+	r.mu.Lock()
+	prevPin := r.mu.state.ReplicaPin
+	r.mu.state.ReplicaPin = pin
+	r.mu.Unlock()
+	r.notifyRangeStateChange(ctx, RangeStateReplicaPin, protoOrNil(prevPin), protoOrNil(pin))
+}
+
+func (r *Replica) handleColdCompactionResult(ctx context.Context, cs *roachpb.ColdCompaction) {
+	r.mu.Lock()
+	prev := r.mu.state.ColdCompaction
+	r.mu.state.ColdCompaction = cs
+	desc := r.mu.state.Desc
+	r.mu.Unlock()
+	// The range was compacted by its leaseholder, compact it here too.
+	if prev == nil || prev.CompactedAt.Less(cs.CompactedAt) {
+		r.store.queueColdCompaction(ctx, desc.KeySpan().AsRawSpanWithNoLocals())
+	}
+}
+
+func (r *Replica) handleHotStandbyResult(ctx context.Context, hs *roachpb.HotStandby) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.state.HotStandby = hs
+	r.updateHotStandbyLocked(ctx)
 }
 
 func (r *Replica) handleVersionResult(ctx context.Context, version *roachpb.Version) {
@@ -533,8 +612,10 @@ func (r *Replica) handleVersionResult(ctx context.Context, version *roachpb.Vers
 		log.Fatal(ctx, "not expecting empty replica version downstream of raft")
 	}
 	r.mu.Lock()
+	prevVersion := r.mu.state.Version
 	r.mu.state.Version = version
 	r.mu.Unlock()
+	r.notifyRangeStateChange(ctx, RangeStateVersion, protoOrNil(prevVersion), version)
 }
 
 func (r *Replica) handleComputeChecksumResult(ctx context.Context, cc *kvserverpb.ComputeChecksum) {
@@ -548,6 +629,8 @@ func (r *Replica) handleComputeChecksumResult(ctx context.Context, cc *kvserverp
 func (r *Replica) handleChangeReplicasResult(
 	ctx context.Context, chng *kvserverpb.ChangeReplicas,
 ) (changeRemovedReplica bool) {
+	r.maybeNotifyRemovedReplicas(ctx, chng.Removed())
+
 	// If this command removes us then we would have set the destroy status
 	// to destroyReasonRemoved which we detect here.
 	//
@@ -586,9 +669,11 @@ func (r *Replica) handleChangeReplicasResult(
 	// NB: postDestroyRaftMuLocked requires that the batch which removed the data
 	// be durably synced to disk, which we have.
 	// See replicaAppBatch.ApplyToStateMachine().
-	if err := r.postDestroyRaftMuLocked(ctx, r.GetMVCCStats()); err != nil {
+	ms := r.GetMVCCStats()
+	if err := r.postDestroyRaftMuLocked(ctx, ms); err != nil {
 		log.Fatalf(ctx, "failed to run Replica postDestroy: %v", err)
 	}
+	r.store.hintCompaction(ctx, r.Desc().KeySpan().AsRawSpanWithNoLocals(), ms.Total())
 
 	return true
 }
@@ -40,6 +40,7 @@ func makeProposalData() *ProposalData {
 		AdmissionPriority:     1,
 		AdmissionCreateTime:   1,
 		AdmissionOriginNode:   1,
+		SeedID:                "deadbeef",
 	}
 
 	return &ProposalData{
@@ -78,7 +79,7 @@ func TestProposalDataAndRaftCommandAreConsideredWhenAddingFields(t *testing.T) {
 	// NB: we can't use zerofields for two reasons: First, we have unexported fields
 	// here, and second, we don't want to check for recursively populated structs (but
 	// only for the top level fields).
-	require.Equal(t, 10, reflect.TypeOf(*prop.command).NumField())
+	require.Equal(t, 11, reflect.TypeOf(*prop.command).NumField())
 	require.Equal(t, 19, reflect.TypeOf(*prop).NumField())
 }
 
@@ -100,9 +101,10 @@ func TestReplicaMakeReproposalChaininig(t *testing.T) {
 		} else {
 			require.Nil(t, seed.lastReproposal)
 		}
-		// All reproposals must point at the seed proposal.
+		// All reproposals must point at the seed proposal, and carry its ID.
 		for _, reproposal := range proposals[1:] {
 			require.Equal(t, seed, reproposal.seedProposal)
+			require.Equal(t, string(seed.idKey), reproposal.command.SeedID)
 			require.Nil(t, reproposal.lastReproposal)
 		}
 		// Only the latest reproposal must use the seed context.
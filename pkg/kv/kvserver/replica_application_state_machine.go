@@ -153,6 +153,7 @@ func (sm *replicaStateMachine) NewBatch() apply.Batch {
 	*b.state.Stats = *r.mu.state.Stats
 	b.closedTimestampSetter = r.mu.closedTimestampSetter
 	r.mu.RUnlock()
+	b.checkLevel = applyCheckLevel(applyCheckLevelSetting.Get(&r.ClusterSettings().SV))
 	b.start = timeutil.Now()
 	return b
 }
@@ -191,6 +192,7 @@ func (sm *replicaStateMachine) ApplySideEffects(
 	// before notifying a potentially waiting client.
 	clearTrivialReplicatedEvalResultFields(cmd.ReplicatedResult())
 	if !cmd.IsTrivial() {
+		log.Event(ctx, "applying non-trivial side effects")
 		shouldAssert, isRemoved := sm.handleNonTrivialReplicatedEvalResult(ctx, cmd.ReplicatedResult())
 		if isRemoved {
 			// The proposal must not have been local, because we don't allow a
@@ -318,6 +320,26 @@ func (sm *replicaStateMachine) handleNonTrivialReplicatedEvalResult(
 			rResult.State.GCHint = nil
 		}
 
+		if rResult.State.WriteFences != nil {
+			sm.r.handleWriteFencesResult(ctx, rResult.State.WriteFences)
+			rResult.State.WriteFences = nil
+		}
+
+		if rResult.State.ReplicaPin != nil {
+			sm.r.handleReplicaPinResult(ctx, rResult.State.ReplicaPin)
+			rResult.State.ReplicaPin = nil
+		}
+
+		if rResult.State.ColdCompaction != nil {
+			sm.r.handleColdCompactionResult(ctx, rResult.State.ColdCompaction)
+			rResult.State.ColdCompaction = nil
+		}
+
+		if rResult.State.HotStandby != nil {
+			sm.r.handleHotStandbyResult(ctx, rResult.State.HotStandby)
+			rResult.State.HotStandby = nil
+		}
+
 		if (*rResult.State == kvserverpb.ReplicaState{}) {
 			rResult.State = nil
 		}
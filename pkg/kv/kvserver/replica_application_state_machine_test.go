@@ -479,3 +479,86 @@ func TestReplicaStateMachineEphemeralAppBatchRejection(t *testing.T) {
 	b.Close()
 	require.Equal(t, []bool{false, true}, rejs)
 }
+
+// TestReplicaStateMachineRejectedCommandCounts verifies that the commands
+// rejected below raft are counted by reason, on the replica and on its store.
+func TestReplicaStateMachineRejectedCommandCounts(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	tc := testContext{}
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	tc.Start(ctx, t, stopper)
+
+	// Lock the replica for the entire test.
+	r := tc.repl
+	r.raftMu.Lock()
+	defer r.raftMu.Unlock()
+	// Avoid additional raft processing after we're done with this replica because
+	// we've applied entries that aren't in the log.
+	defer r.mu.destroyStatus.Set(errors.New("boom"), destroyReasonRemoved)
+
+	sm := r.getStateMachine()
+
+	r.mu.Lock()
+	raftAppliedIndex := r.mu.state.RaftAppliedIndex
+	leaseSeq := r.mu.state.Lease.Sequence
+	lai := r.mu.state.LeaseAppliedIndex
+	r.mu.Unlock()
+
+	m := tc.store.Metrics()
+	before := r.State(ctx).RejectedCommands
+	metricsBefore := []int64{
+		m.RaftCommandsRejectedLeaseMismatch.Count(),
+		m.RaftCommandsRejectedLeaseIndex.Count(),
+		m.RaftCommandsRejectedGCThreshold.Count(),
+		m.RaftCommandsRejectedProposalRetry.Count(),
+	}
+
+	// The first command was proposed under a previous lease, and the second
+	// one at a lease index which was already consumed.
+	cmds := []kvserverpb.RaftCommand{
+		{ProposerLeaseSequence: leaseSeq - 1, MaxLeaseIndex: lai + 1},
+		{ProposerLeaseSequence: leaseSeq, MaxLeaseIndex: lai},
+	}
+	b := sm.NewBatch()
+	defer b.Close()
+	for i, raftCmd := range cmds {
+		var ba kvpb.BatchRequest
+		ba.Add(kvpb.NewPut(keys.LocalMax, roachpb.MakeValueFromString("hello")))
+		cmd := &replicatedCmd{
+			ctx: ctx,
+			ReplicatedCmd: raftlog.ReplicatedCmd{Entry: &raftlog.Entry{
+				Entry: raftpb.Entry{
+					Index: uint64(raftAppliedIndex) + uint64(i) + 1,
+					Type:  raftpb.EntryNormal,
+				},
+				ID:  raftlog.MakeCmdIDKey(),
+				Cmd: raftCmd,
+			}},
+			proposal: &ProposalData{Request: &ba},
+		}
+		checkedCmd, err := b.Stage(cmd.ctx, cmd)
+		require.NoError(t, err)
+		require.True(t, checkedCmd.Rejected())
+	}
+
+	require.Equal(t, kvserverpb.RejectedCommandCounts{
+		LeaseMismatch: before.LeaseMismatch + 1,
+		LeaseIndex:    before.LeaseIndex + 1,
+		GCThreshold:   before.GCThreshold,
+		ProposalRetry: before.ProposalRetry + 1,
+	}, r.State(ctx).RejectedCommands)
+	require.Equal(t, []int64{
+		metricsBefore[0] + 1,
+		metricsBefore[1] + 1,
+		metricsBefore[2],
+		metricsBefore[3] + 1,
+	}, []int64{
+		m.RaftCommandsRejectedLeaseMismatch.Count(),
+		m.RaftCommandsRejectedLeaseIndex.Count(),
+		m.RaftCommandsRejectedGCThreshold.Count(),
+		m.RaftCommandsRejectedProposalRetry.Count(),
+	})
+}
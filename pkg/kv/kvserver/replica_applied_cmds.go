@@ -0,0 +1,55 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import "github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
+
+// appliedCmdCacheSize is the number of command IDs retained by the
+// appliedCmdCache of a replica.
+const appliedCmdCacheSize = 64
+
+// appliedCmdCache is a bounded cache of the seed IDs of the commands most
+// recently applied to a replica (see replicatedCmd.seedID). It is used to
+// recognize a local proposal whose copy has already applied without being
+// matched to the proposal, e.g. because the copy applied while the proposal was
+// not in the proposals map, or because it was a copy from before a reproposal
+// under a new lease index, which assigned the command a new ID. Such a proposal
+// is rejected by the lease applied index check when another copy comes up for
+// application and would otherwise be reproposed under a new lease index, or
+// fail, while its effects are already part of the state machine.
+//
+// The cache is allocated lazily and grows up to appliedCmdCacheSize entries,
+// after which the oldest entries are evicted. It is only accessed during
+// application, under Replica.raftMu.
+type appliedCmdCache struct {
+	ids []kvserverbase.CmdIDKey
+	// next is the index of ids at which the next ID is written, once the cache
+	// is full.
+	next int
+}
+
+func (c *appliedCmdCache) add(id kvserverbase.CmdIDKey) {
+	if len(c.ids) < appliedCmdCacheSize {
+		c.ids = append(c.ids, id)
+		return
+	}
+	c.ids[c.next] = id
+	c.next = (c.next + 1) % len(c.ids)
+}
+
+func (c *appliedCmdCache) contains(id kvserverbase.CmdIDKey) bool {
+	for _, cached := range c.ids {
+		if cached == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/batcheval/result"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/raftlog"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppliedCmdCache(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var c appliedCmdCache
+	ids := make([]kvserverbase.CmdIDKey, appliedCmdCacheSize+10)
+	for i := range ids {
+		ids[i] = raftlog.MakeCmdIDKey()
+		require.False(t, c.contains(ids[i]))
+		c.add(ids[i])
+		require.True(t, c.contains(ids[i]))
+	}
+	require.Len(t, c.ids, appliedCmdCacheSize)
+
+	// The oldest IDs have been evicted.
+	for i, id := range ids {
+		require.Equal(t, i >= 10, c.contains(id), "id #%d", i)
+	}
+}
+
+// TestPrepareLocalResultDeduplicatesReproposals verifies that a local command
+// rejected by the lease applied index check is acknowledged instead of being
+// reproposed if another copy of it already applied, including a copy from
+// before a reproposal under a new lease index, which has a different ID.
+func TestPrepareLocalResultDeduplicatesReproposals(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	ctx := context.Background()
+	defer stopper.Stop(ctx)
+	tc.Start(ctx, t, stopper)
+	r := tc.repl
+
+	seedID := raftlog.MakeCmdIDKey()
+	reply := &kvpb.BatchResponse{}
+
+	// The copy of the seed proposal applies, e.g. while a reproposal was
+	// pending, without being matched to the proposal.
+	applied := &replicatedCmd{ReplicatedCmd: raftlog.ReplicatedCmd{Entry: &raftlog.Entry{ID: seedID}}}
+	r.raftMu.Lock()
+	r.raftMu.appliedCmds.add(applied.seedID())
+	r.raftMu.Unlock()
+
+	// A copy of the reproposal, which was assigned a new ID, is then rejected.
+	// It is acknowledged with the reply of the proposal.
+	before := r.store.metrics.RaftCommandsDeduplicated.Count()
+	cmd := &replicatedCmd{
+		ReplicatedCmd: raftlog.ReplicatedCmd{Entry: &raftlog.Entry{ID: raftlog.MakeCmdIDKey()}},
+		proposal: &ProposalData{
+			Local:   &result.LocalResult{Reply: reply},
+			Request: &kvpb.BatchRequest{},
+		},
+	}
+	cmd.Cmd.SeedID = string(seedID)
+	cmd.Rejection = kvserverbase.ProposalRejectionIllegalLeaseIndex
+	cmd.ForcedError = kvpb.NewErrorf("illegal lease index")
+	r.raftMu.Lock()
+	r.prepareLocalResult(ctx, cmd)
+	r.raftMu.Unlock()
+	require.Nil(t, cmd.response.Err)
+	require.Equal(t, reply, cmd.response.Reply)
+	require.Equal(t, before+1, r.store.metrics.RaftCommandsDeduplicated.Count())
+}
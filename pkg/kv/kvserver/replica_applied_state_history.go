@@ -0,0 +1,183 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// appliedStateHistorySize is the number of applied states retained by each
+// replica, to locate the divergence of the stats of replicas.
+var appliedStateHistorySize = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"kv.raft.applied_state_history.size",
+	"the number of applied states (index, term and checksum of the MVCC stats) retained "+
+		"in memory by each replica after each application batch, to locate the index at "+
+		"which the stats of two replicas diverged (0 disables)",
+	16,
+	settings.NonNegativeInt,
+)
+
+// AppliedStateRecord is the applied state of a replica after an application
+// batch.
+type AppliedStateRecord struct {
+	// Index and Term are the raft index and term of the last command of the
+	// batch.
+	Index kvpb.RaftIndex
+	Term  kvpb.RaftTerm
+	// LeaseAppliedIndex is the lease applied index after the batch.
+	LeaseAppliedIndex kvpb.LeaseAppliedIndex
+	// StatsChecksum is a checksum of the MVCC stats after the batch.
+	StatsChecksum uint64
+}
+
+// mvccStatsChecksum returns a checksum of the given MVCC stats. The stats are
+// part of the replicated state, so the checksums of two replicas which applied
+// the same index are equal unless their stats diverged.
+func mvccStatsChecksum(ms *enginepb.MVCCStats) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, v := range [...]int64{
+		ms.ContainsEstimates, ms.LastUpdateNanos, ms.LockAge, ms.GCBytesAge,
+		ms.LiveBytes, ms.LiveCount, ms.KeyBytes, ms.KeyCount, ms.ValBytes, ms.ValCount,
+		ms.IntentBytes, ms.IntentCount, ms.LockBytes, ms.LockCount,
+		ms.RangeKeyCount, ms.RangeKeyBytes, ms.RangeValCount, ms.RangeValBytes,
+		ms.SysBytes, ms.SysCount, ms.AbortSpanBytes,
+	} {
+		binary.LittleEndian.PutUint64(buf[:], uint64(v))
+		_, _ = h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// replicaAppliedStateHistory retains the last applied states of a replica in a
+// ring.
+type replicaAppliedStateHistory struct {
+	syncutil.Mutex
+	ring []AppliedStateRecord
+	// next is the position of the next record in the ring, and n the number of
+	// records in it.
+	next, n int
+}
+
+// record adds a record to the history, retaining the last size records.
+func (h *replicaAppliedStateHistory) record(rec AppliedStateRecord, size int) {
+	h.Lock()
+	defer h.Unlock()
+	if size != len(h.ring) {
+		// The size changed, keep the most recent records which fit.
+		old := h.recordsLocked()
+		if len(old) > size {
+			old = old[len(old)-size:]
+		}
+		h.ring = make([]AppliedStateRecord, size)
+		h.n = copy(h.ring, old)
+		h.next = 0
+		if size > 0 {
+			h.next = h.n % size
+		}
+	}
+	if size == 0 {
+		return
+	}
+	h.ring[h.next] = rec
+	h.next = (h.next + 1) % size
+	if h.n < size {
+		h.n++
+	}
+}
+
+// recordsLocked returns a copy of the records, in the order they were added.
+func (h *replicaAppliedStateHistory) recordsLocked() []AppliedStateRecord {
+	res := make([]AppliedStateRecord, 0, h.n)
+	start := h.next - h.n
+	if start < 0 {
+		start += len(h.ring)
+	}
+	for i := 0; i < h.n; i++ {
+		res = append(res, h.ring[(start+i)%len(h.ring)])
+	}
+	return res
+}
+
+// AppliedStateHistory returns the last applied states of the replica, by
+// increasing index. See kv.raft.applied_state_history.size.
+func (r *Replica) AppliedStateHistory() []AppliedStateRecord {
+	r.appliedStateHistory.Lock()
+	defer r.appliedStateHistory.Unlock()
+	return r.appliedStateHistory.recordsLocked()
+}
+
+// recordAppliedState records the applied state after an application batch.
+func (b *replicaAppBatch) recordAppliedState() {
+	size := int(appliedStateHistorySize.Get(&b.r.store.cfg.Settings.SV))
+	b.r.appliedStateHistory.record(AppliedStateRecord{
+		Index:             b.state.RaftAppliedIndex,
+		Term:              b.state.RaftAppliedIndexTerm,
+		LeaseAppliedIndex: b.state.LeaseAppliedIndex,
+		StatsChecksum:     mvccStatsChecksum(b.state.Stats),
+	}, size)
+}
+
+// StatsDivergence locates the divergence of the MVCC stats of two replicas.
+type StatsDivergence struct {
+	// LastMatch is the last index at which the stats of both replicas were
+	// recorded and equal, or zero if they already differed at the first index
+	// recorded by both.
+	LastMatch kvpb.RaftIndex
+	// FirstMismatch is the first index at which the stats of both replicas were
+	// recorded and differed. The divergence was introduced by a command in
+	// (LastMatch, FirstMismatch].
+	FirstMismatch kvpb.RaftIndex
+}
+
+// BisectStatsDivergence locates the divergence of the MVCC stats of two
+// replicas of a range, given their applied state histories. The histories are
+// only comparable at the indexes recorded by both replicas, i.e. at the ends
+// of application batches shared by both. Once diverged, the stats are assumed
+// to remain divergent, so the first mismatch is found by bisection. Returns
+// false if the stats are equal at all the common indexes, or if there is none.
+func BisectStatsDivergence(a, b []AppliedStateRecord) (StatsDivergence, bool) {
+	type pair struct{ a, b AppliedStateRecord }
+	var common []pair
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].Index < b[j].Index:
+			i++
+		case a[i].Index > b[j].Index:
+			j++
+		default:
+			common = append(common, pair{a[i], b[j]})
+			i++
+			j++
+		}
+	}
+	mismatch := func(p pair) bool {
+		return p.a.Term != p.b.Term || p.a.LeaseAppliedIndex != p.b.LeaseAppliedIndex ||
+			p.a.StatsChecksum != p.b.StatsChecksum
+	}
+	i := sort.Search(len(common), func(i int) bool { return mismatch(common[i]) })
+	if i == len(common) {
+		return StatsDivergence{}, false
+	}
+	div := StatsDivergence{FirstMismatch: common[i].a.Index}
+	if i > 0 {
+		div.LastMatch = common[i-1].a.Index
+	}
+	return div, true
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaAppliedStateHistory(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rec := func(idx kvpb.RaftIndex) AppliedStateRecord { return AppliedStateRecord{Index: idx} }
+	records := func(h *replicaAppliedStateHistory) []kvpb.RaftIndex {
+		var res []kvpb.RaftIndex
+		for _, r := range h.recordsLocked() {
+			res = append(res, r.Index)
+		}
+		return res
+	}
+
+	var h replicaAppliedStateHistory
+	for idx := kvpb.RaftIndex(1); idx <= 5; idx++ {
+		h.record(rec(idx), 3)
+	}
+	require.Equal(t, []kvpb.RaftIndex{3, 4, 5}, records(&h))
+
+	// Growing the ring retains the records.
+	h.record(rec(6), 5)
+	h.record(rec(7), 5)
+	require.Equal(t, []kvpb.RaftIndex{3, 4, 5, 6, 7}, records(&h))
+
+	// Shrinking it retains the most recent ones.
+	h.record(rec(8), 2)
+	require.Equal(t, []kvpb.RaftIndex{7, 8}, records(&h))
+
+	// A zero size disables the history.
+	h.record(rec(9), 0)
+	require.Empty(t, records(&h))
+}
+
+func TestBisectStatsDivergence(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	stats := enginepb.MVCCStats{LiveBytes: 10, LiveCount: 1}
+	diverged := stats
+	diverged.LiveBytes++
+	require.NotEqual(t, mvccStatsChecksum(&stats), mvccStatsChecksum(&diverged))
+
+	rec := func(idx kvpb.RaftIndex, ms enginepb.MVCCStats) AppliedStateRecord {
+		return AppliedStateRecord{Index: idx, Term: 1, StatsChecksum: mvccStatsChecksum(&ms)}
+	}
+	// The replicas applied different batches: only 10, 20, 30 and 40 are
+	// recorded by both. The stats diverged in (20, 30].
+	a := []AppliedStateRecord{
+		rec(10, stats), rec(15, stats), rec(20, stats), rec(30, diverged), rec(40, diverged),
+	}
+	b := []AppliedStateRecord{
+		rec(10, stats), rec(20, stats), rec(25, stats), rec(30, stats), rec(40, stats),
+	}
+	div, ok := BisectStatsDivergence(a, b)
+	require.True(t, ok)
+	require.Equal(t, StatsDivergence{LastMatch: 20, FirstMismatch: 30}, div)
+
+	// The stats differ at the first common index.
+	div, ok = BisectStatsDivergence(a[3:], b)
+	require.True(t, ok)
+	require.Equal(t, StatsDivergence{FirstMismatch: 30}, div)
+
+	// No divergence, or no common index.
+	_, ok = BisectStatsDivergence(a[:3], b)
+	require.False(t, ok)
+	_, ok = BisectStatsDivergence(a[1:2], b)
+	require.False(t, ok)
+}
@@ -0,0 +1,162 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/log/logcrash"
+	"github.com/cockroachdb/errors"
+)
+
+// applyCheckLevel is the level of the sanity checks performed during the
+// application of raft commands. Each level includes the checks of the lower
+// levels.
+type applyCheckLevel int64
+
+const (
+	// applyCheckLevelFast only performs the checks which are constant-time per
+	// command or per application batch.
+	applyCheckLevelFast applyCheckLevel = iota
+	// applyCheckLevelStrict additionally performs the checks which inspect the
+	// requests of the local commands.
+	applyCheckLevelStrict
+	// applyCheckLevelParanoid additionally checks invariants of the replica
+	// state after each application batch.
+	applyCheckLevelParanoid
+)
+
+// applyCheckLevelSetting is the level of the sanity checks performed during the
+// application of raft commands.
+var applyCheckLevelSetting = settings.RegisterEnumSetting(
+	settings.SystemOnly,
+	"kv.raft.apply.sanity_checks",
+	"the level of the sanity checks of the application of raft commands: fast only "+
+		"performs constant-time checks, strict also checks the writes of local commands "+
+		"against the closed timestamp, and paranoid also checks the invariants of the "+
+		"replica state, e.g. the non-negativity of its MVCC stats, after each batch",
+	"strict",
+	map[int64]string{
+		int64(applyCheckLevelFast):     "fast",
+		int64(applyCheckLevelStrict):   "strict",
+		int64(applyCheckLevelParanoid): "paranoid",
+	},
+)
+
+// applyCheck identifies a sanity check of the application of raft commands.
+type applyCheck int
+
+const (
+	// applyCheckAppliedIndex checks that the raft index of each command follows
+	// the applied index. Its failure fails the application.
+	applyCheckAppliedIndex applyCheck = iota
+	// applyCheckCmdClosedTimestamp checks that the closed timestamp carried by
+	// each command doesn't regress.
+	applyCheckCmdClosedTimestamp
+	// applyCheckBatchClosedTimestamp checks that the closed timestamp of the
+	// replica doesn't regress with each application batch.
+	applyCheckBatchClosedTimestamp
+	// applyCheckWriteBelowClosedTimestamp checks that the local commands don't
+	// write below the closed timestamp.
+	applyCheckWriteBelowClosedTimestamp
+	// applyCheckLeaseAppliedIndex checks that the lease applied index of the
+	// replica doesn't regress with each application batch.
+	applyCheckLeaseAppliedIndex
+	// applyCheckStatsNonNegative checks that the MVCC stats of the replica
+	// aren't negative after each application batch, unless they are estimates.
+	applyCheckStatsNonNegative
+
+	numApplyChecks
+)
+
+// applyCheckLevels is the lowest level at which each check is performed.
+var applyCheckLevels = [numApplyChecks]applyCheckLevel{
+	applyCheckAppliedIndex:              applyCheckLevelFast,
+	applyCheckCmdClosedTimestamp:        applyCheckLevelFast,
+	applyCheckBatchClosedTimestamp:      applyCheckLevelFast,
+	applyCheckWriteBelowClosedTimestamp: applyCheckLevelStrict,
+	applyCheckLeaseAppliedIndex:         applyCheckLevelParanoid,
+	applyCheckStatsNonNegative:          applyCheckLevelParanoid,
+}
+
+// shouldCheck returns whether the given check is performed on the batch.
+func (b *replicaAppBatch) shouldCheck(c applyCheck) bool {
+	return b.checkLevel >= applyCheckLevels[c]
+}
+
+// checkFailed counts the failure of the given check, and reports the error,
+// which panics in test builds.
+func (b *replicaAppBatch) checkFailed(ctx context.Context, c applyCheck, err error) {
+	b.r.store.metrics.RaftApplyCheckFailures[c].Inc(1)
+	logcrash.ReportOrPanic(ctx, &b.r.ClusterSettings().SV, "%v", err)
+}
+
+// checkReplicaStateInvariants performs the paranoid checks of the replica
+// state once the batch is committed, given the lease applied index of the
+// replica before the batch.
+func (b *replicaAppBatch) checkReplicaStateInvariants(
+	ctx context.Context, prevLeaseAppliedIndex kvpb.LeaseAppliedIndex,
+) {
+	if b.shouldCheck(applyCheckLeaseAppliedIndex) &&
+		b.state.LeaseAppliedIndex < prevLeaseAppliedIndex {
+		b.checkFailed(ctx, applyCheckLeaseAppliedIndex, errors.AssertionFailedf(
+			"lease applied index regression; replica has: %d, new batch has: %d",
+			prevLeaseAppliedIndex, b.state.LeaseAppliedIndex))
+	}
+	if b.shouldCheck(applyCheckStatsNonNegative) {
+		if field, ok := negativeMVCCStatsField(*b.state.Stats); ok {
+			b.checkFailed(ctx, applyCheckStatsNonNegative, errors.AssertionFailedf(
+				"negative %s in MVCC stats after applying batch at index %d: %+v",
+				field, b.state.RaftAppliedIndex, *b.state.Stats))
+		}
+	}
+}
+
+// negativeMVCCStatsField returns the name of a negative field of the given
+// stats, if any. Stats containing estimates aren't checked, as they can
+// legitimately be negative.
+func negativeMVCCStatsField(ms enginepb.MVCCStats) (string, bool) {
+	if ms.ContainsEstimates != 0 {
+		return "", false
+	}
+	for _, f := range []struct {
+		name string
+		val  int64
+	}{
+		{"lock_age", ms.LockAge},
+		{"gc_bytes_age", ms.GCBytesAge},
+		{"live_bytes", ms.LiveBytes},
+		{"live_count", ms.LiveCount},
+		{"key_bytes", ms.KeyBytes},
+		{"key_count", ms.KeyCount},
+		{"val_bytes", ms.ValBytes},
+		{"val_count", ms.ValCount},
+		{"intent_bytes", ms.IntentBytes},
+		{"intent_count", ms.IntentCount},
+		{"lock_bytes", ms.LockBytes},
+		{"lock_count", ms.LockCount},
+		{"range_key_count", ms.RangeKeyCount},
+		{"range_key_bytes", ms.RangeKeyBytes},
+		{"range_val_count", ms.RangeValCount},
+		{"range_val_bytes", ms.RangeValBytes},
+		{"sys_bytes", ms.SysBytes},
+		{"sys_count", ms.SysCount},
+		{"abort_span_bytes", ms.AbortSpanBytes},
+	} {
+		if f.val < 0 {
+			return f.name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyCheckLevels(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Each level performs the checks of the lower levels.
+	for _, tc := range []struct {
+		level     applyCheckLevel
+		expChecks []applyCheck
+	}{
+		{applyCheckLevelFast, []applyCheck{
+			applyCheckAppliedIndex, applyCheckCmdClosedTimestamp, applyCheckBatchClosedTimestamp,
+		}},
+		{applyCheckLevelStrict, []applyCheck{
+			applyCheckAppliedIndex, applyCheckCmdClosedTimestamp, applyCheckBatchClosedTimestamp,
+			applyCheckWriteBelowClosedTimestamp,
+		}},
+		{applyCheckLevelParanoid, []applyCheck{
+			applyCheckAppliedIndex, applyCheckCmdClosedTimestamp, applyCheckBatchClosedTimestamp,
+			applyCheckWriteBelowClosedTimestamp, applyCheckLeaseAppliedIndex,
+			applyCheckStatsNonNegative,
+		}},
+	} {
+		b := replicaAppBatch{checkLevel: tc.level}
+		var checks []applyCheck
+		for c := applyCheck(0); c < numApplyChecks; c++ {
+			if b.shouldCheck(c) {
+				checks = append(checks, c)
+			}
+		}
+		require.Equal(t, tc.expChecks, checks, "level %d", tc.level)
+	}
+}
+
+func TestNegativeMVCCStatsField(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ms := enginepb.MVCCStats{LiveBytes: 10, LiveCount: 1, KeyBytes: 5, KeyCount: 1}
+	_, ok := negativeMVCCStatsField(ms)
+	require.False(t, ok)
+
+	ms.SysCount = -1
+	field, ok := negativeMVCCStatsField(ms)
+	require.True(t, ok)
+	require.Equal(t, "sys_count", field)
+
+	// Estimates can be negative.
+	ms.ContainsEstimates = 1
+	_, ok = negativeMVCCStatsField(ms)
+	require.False(t, ok)
+}
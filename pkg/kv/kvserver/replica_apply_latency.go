@@ -0,0 +1,179 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/log/eventpb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// proposalToApplyLatencyAlertThreshold is the proposal-to-apply latency above
+// which a command is reported by a structured event.
+var proposalToApplyLatencyAlertThreshold = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.replication.proposal_to_apply_latency.alert_threshold",
+	"if non-zero, a structured event is logged to the HEALTH channel when a command "+
+		"takes longer than this duration from its proposal to its application on the "+
+		"proposer, at most once per minute per range",
+	0,
+	settings.NonNegativeDuration,
+)
+
+const (
+	// applyLatencyWindow is the duration of each of the two windows the
+	// percentiles of the proposal-to-apply latency of a range are computed
+	// over: the percentiles cover between one and two windows of history.
+	applyLatencyWindow = time.Minute
+	// applyLatencyAlertInterval is the minimum interval between two structured
+	// events reporting the proposal-to-apply latency of a range.
+	applyLatencyAlertInterval = time.Minute
+	// numApplyLatencyBuckets is the number of buckets of the proposal-to-apply
+	// latency histogram of a range. Bucket i counts the latencies up to
+	// 2^i milliseconds, and the last bucket also counts all larger latencies.
+	numApplyLatencyBuckets = 17
+)
+
+// applyLatencyHistogram is a histogram of proposal-to-apply latencies, with
+// exponential buckets. It is small enough to be kept for every replica.
+type applyLatencyHistogram struct {
+	buckets [numApplyLatencyBuckets]int64
+	count   int64
+}
+
+func applyLatencyBucket(d time.Duration) int {
+	for i := 0; i < numApplyLatencyBuckets-1; i++ {
+		if d <= applyLatencyBucketBound(i) {
+			return i
+		}
+	}
+	return numApplyLatencyBuckets - 1
+}
+
+func applyLatencyBucketBound(i int) time.Duration {
+	return time.Millisecond << i
+}
+
+func (h *applyLatencyHistogram) record(d time.Duration) {
+	h.buckets[applyLatencyBucket(d)]++
+	h.count++
+}
+
+// replicaApplyLatency tracks the latency from the proposal of the commands
+// proposed by a replica to their application on the replica, over a sliding
+// window.
+type replicaApplyLatency struct {
+	syncutil.Mutex
+	// cur and prev are the histograms of the current and previous windows.
+	cur, prev applyLatencyHistogram
+	curStart  time.Time
+	// lastAlert is the time of the last structured event reporting the latency
+	// of the range.
+	lastAlert time.Time
+}
+
+// rotateLocked moves on to a new window if the current one is over.
+func (l *replicaApplyLatency) rotateLocked(now time.Time) {
+	if now.Sub(l.curStart) < applyLatencyWindow {
+		return
+	}
+	if now.Sub(l.curStart) < 2*applyLatencyWindow {
+		l.prev = l.cur
+	} else {
+		l.prev = applyLatencyHistogram{}
+	}
+	l.cur = applyLatencyHistogram{}
+	l.curStart = now
+}
+
+// record records the latencies of the commands proposed at the given times and
+// applied at now. It returns the maximum latency, and whether it should be
+// reported by a structured event given the threshold, in which case the time
+// of the last alert is updated.
+func (l *replicaApplyLatency) record(
+	now time.Time, proposedAt []time.Time, threshold time.Duration,
+) (maxLatency time.Duration, alert bool) {
+	l.Lock()
+	defer l.Unlock()
+	l.rotateLocked(now)
+	for _, t := range proposedAt {
+		d := now.Sub(t)
+		l.cur.record(d)
+		if d > maxLatency {
+			maxLatency = d
+		}
+	}
+	if threshold > 0 && maxLatency > threshold && now.Sub(l.lastAlert) >= applyLatencyAlertInterval {
+		l.lastAlert = now
+		return maxLatency, true
+	}
+	return maxLatency, false
+}
+
+// quantile returns an upper bound of the given quantile of the latencies
+// recorded over the last one to two windows, or zero if none was recorded.
+func (l *replicaApplyLatency) quantile(now time.Time, q float64) time.Duration {
+	l.Lock()
+	defer l.Unlock()
+	l.rotateLocked(now)
+	count := l.cur.count + l.prev.count
+	if count == 0 {
+		return 0
+	}
+	// rank is the number of latencies at or below the quantile.
+	rank := int64(q * float64(count))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i := 0; i < numApplyLatencyBuckets; i++ {
+		seen += l.cur.buckets[i] + l.prev.buckets[i]
+		if seen >= rank {
+			return applyLatencyBucketBound(i)
+		}
+	}
+	return applyLatencyBucketBound(numApplyLatencyBuckets - 1)
+}
+
+// ProposalToApplyLatencyP99 returns an upper bound of the 99th percentile of
+// the latency from the proposal of the commands proposed by the replica to
+// their application, over the last one to two minutes. It is zero if the
+// replica didn't propose any command over that period.
+func (r *Replica) ProposalToApplyLatencyP99() time.Duration {
+	return r.applyLatency.quantile(timeutil.Now(), 0.99)
+}
+
+// recordProposalToApplyLatencies records the proposal-to-apply latencies of
+// the commands proposed by the replica at the given times and applied at now,
+// and reports them if they exceed
+// kv.replication.proposal_to_apply_latency.alert_threshold.
+func (r *Replica) recordProposalToApplyLatencies(
+	ctx context.Context, now time.Time, proposedAt []time.Time,
+) {
+	threshold := proposalToApplyLatencyAlertThreshold.Get(&r.store.cfg.Settings.SV)
+	maxLatency, alert := r.applyLatency.record(now, proposedAt, threshold)
+	if !alert {
+		return
+	}
+	r.store.metrics.RaftReplicationLagAlerts.Inc(1)
+	log.StructuredEvent(ctx, &eventpb.RangeReplicationLagExceeded{
+		StoreID:        int32(r.store.StoreID()),
+		RangeID:        int64(r.RangeID),
+		LatencyNanos:   maxLatency.Nanoseconds(),
+		ThresholdNanos: threshold.Nanoseconds(),
+		P99Nanos:       r.applyLatency.quantile(now, 0.99).Nanoseconds(),
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaApplyLatency(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var l replicaApplyLatency
+	start := time.Unix(1000, 0)
+	// proposed returns the proposal times of commands applied at now with the
+	// given latencies.
+	proposed := func(now time.Time, latencies ...time.Duration) []time.Time {
+		var ts []time.Time
+		for _, d := range latencies {
+			ts = append(ts, now.Add(-d))
+		}
+		return ts
+	}
+
+	require.Zero(t, l.quantile(start, 0.99))
+
+	// The quantiles are upper bounds, rounded up to a power of two
+	// milliseconds.
+	now := start
+	var latencies []time.Duration
+	for i := 0; i < 98; i++ {
+		latencies = append(latencies, 3*time.Millisecond)
+	}
+	latencies = append(latencies, 100*time.Millisecond, time.Hour)
+	maxLatency, alert := l.record(now, proposed(now, latencies...), 0)
+	require.Equal(t, time.Hour, maxLatency)
+	require.False(t, alert)
+	require.Equal(t, 4*time.Millisecond, l.quantile(now, 0.5))
+	require.Equal(t, 128*time.Millisecond, l.quantile(now, 0.99))
+	require.Equal(t, applyLatencyBucketBound(numApplyLatencyBuckets-1), l.quantile(now, 1))
+
+	// The latencies of the previous window are still accounted for, but not
+	// the ones of older windows.
+	now = now.Add(applyLatencyWindow)
+	_, _ = l.record(now, proposed(now, time.Millisecond), 0)
+	require.Equal(t, 4*time.Millisecond, l.quantile(now, 0.5))
+	now = now.Add(applyLatencyWindow)
+	require.Equal(t, time.Millisecond, l.quantile(now, 0.5))
+	now = now.Add(2 * applyLatencyWindow)
+	require.Zero(t, l.quantile(now, 0.5))
+
+	// Latencies over the threshold are reported at most once per interval.
+	threshold := 500 * time.Millisecond
+	_, alert = l.record(now, proposed(now, 100*time.Millisecond), threshold)
+	require.False(t, alert)
+	maxLatency, alert = l.record(now, proposed(now, 100*time.Millisecond, time.Second), threshold)
+	require.Equal(t, time.Second, maxLatency)
+	require.True(t, alert)
+	_, alert = l.record(now.Add(time.Second), proposed(now, time.Second), threshold)
+	require.False(t, alert)
+	now = now.Add(applyLatencyAlertInterval)
+	_, alert = l.record(now, proposed(now, time.Second), threshold)
+	require.True(t, alert)
+}
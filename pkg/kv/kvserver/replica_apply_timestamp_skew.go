@@ -0,0 +1,147 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/closedts"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/log/eventpb"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// timestampSkewCheckMode is the action taken when a raft command is applied
+// with a write timestamp too far ahead of the local clock.
+type timestampSkewCheckMode int64
+
+const (
+	// timestampSkewCheckOff disables the check.
+	timestampSkewCheckOff timestampSkewCheckMode = iota
+	// timestampSkewCheckReport increments a metric, and logs a structured event
+	// at most once per minute per range.
+	timestampSkewCheckReport
+	// timestampSkewCheckFatal reports the command like timestampSkewCheckReport,
+	// then crashes the node.
+	timestampSkewCheckFatal
+)
+
+// timestampSkewCheck controls the check, at application time, that the write
+// timestamps of raft commands aren't too far ahead of the local clock.
+var timestampSkewCheck = settings.RegisterEnumSetting(
+	settings.SystemOnly,
+	"kv.raft.apply.timestamp_skew_check.mode",
+	"the action taken when a raft command is applied with a write timestamp further ahead "+
+		"of the local clock than the maximum clock offset, the lead of the ranges serving "+
+		"global reads, and kv.raft.apply.timestamp_skew_check.tolerance: `off` disables the "+
+		"check, `report` logs a structured event to the HEALTH channel, and `fatal` also "+
+		"crashes the node",
+	"report",
+	map[int64]string{
+		int64(timestampSkewCheckOff):    "off",
+		int64(timestampSkewCheckReport): "report",
+		int64(timestampSkewCheckFatal):  "fatal",
+	},
+)
+
+// timestampSkewTolerance is the skew allowed above the maximum clock offset
+// and the lead of the ranges serving global reads.
+var timestampSkewTolerance = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.raft.apply.timestamp_skew_check.tolerance",
+	"the skew of the write timestamp of an applied raft command ahead of the local clock "+
+		"tolerated on top of the maximum clock offset and the lead of the ranges serving "+
+		"global reads, see kv.raft.apply.timestamp_skew_check.mode",
+	time.Second,
+	settings.NonNegativeDuration,
+)
+
+// timestampSkewReportInterval is the minimum interval between two structured
+// events reporting the timestamp skew of the commands of a range.
+const timestampSkewReportInterval = time.Minute
+
+// maxTimestampSkew returns the maximum skew of a write timestamp ahead of the
+// given physical time of the local clock.
+//
+// The write timestamp of a command evaluated by the leaseholder is at most the
+// leaseholder's clock, which is at most the maximum clock offset ahead of the
+// local clock. Transactions writing to the ranges serving global reads commit
+// in the future, at the lead of their closed timestamps, and write there to
+// any range. Above that, the write timestamp of a command can only come from a
+// clock which isn't synchronized with the local clock, and would break the
+// closed timestamp invariants if the local replica was to serve reads.
+func maxTimestampSkew(
+	sv *settings.Values, clock *hlc.Clock, physicalNow int64,
+) (maxSkew time.Duration) {
+	maxSkew = clock.MaxOffset()
+	globalReadsTarget := closedts.TargetForPolicy(
+		hlc.ClockTimestamp{WallTime: physicalNow},
+		clock.MaxOffset(),
+		closedts.TargetDuration.Get(sv),
+		closedts.LeadForGlobalReadsOverride.Get(sv),
+		closedts.SideTransportCloseInterval.Get(sv),
+		roachpb.LEAD_FOR_GLOBAL_READS,
+	)
+	if lead := time.Duration(globalReadsTarget.WallTime - physicalNow); lead > maxSkew {
+		maxSkew = lead
+	}
+	return maxSkew + timestampSkewTolerance.Get(sv)
+}
+
+// checkTimestampSkew reports the command if its write timestamp is further
+// ahead of the local clock than allowed by maxTimestampSkew, as configured
+// by kv.raft.apply.timestamp_skew_check.mode. The check doesn't affect the
+// application of the command, which must be deterministic.
+func (b *replicaAppBatch) checkTimestampSkew(ctx context.Context, cmd *replicatedCmd) {
+	sv := &b.r.ClusterSettings().SV
+	mode := timestampSkewCheckMode(timestampSkewCheck.Get(sv))
+	if mode == timestampSkewCheckOff || cmd.Rejected() {
+		return
+	}
+	wts := cmd.Cmd.ReplicatedEvalResult.WriteTimestamp
+	if wts.IsEmpty() {
+		return
+	}
+	clock := b.r.Clock()
+	physicalNow := clock.PhysicalNow()
+	skew := time.Duration(wts.WallTime - physicalNow)
+	// Avoid computing the maximum skew in the common case.
+	if skew <= clock.MaxOffset() {
+		return
+	}
+	maxSkew := maxTimestampSkew(sv, clock, physicalNow)
+	if skew <= maxSkew {
+		return
+	}
+	b.r.store.metrics.RaftApplyTimestampSkew.Inc(1)
+	if mode != timestampSkewCheckFatal &&
+		!b.r.timestampSkewReportThrottle.ShouldProcess(timeutil.Now()) {
+		return
+	}
+	log.StructuredEvent(ctx, &eventpb.RaftCommandTimestampSkewExceeded{
+		StoreID:        int32(b.r.store.StoreID()),
+		RangeID:        int64(b.r.RangeID),
+		CommandID:      cmd.ID.String(),
+		WriteTimestamp: wts.String(),
+		SkewNanos:      skew.Nanoseconds(),
+		MaxSkewNanos:   maxSkew.Nanoseconds(),
+	})
+	if mode == timestampSkewCheckFatal {
+		log.Fatalf(ctx, "command %x applied with write timestamp %s, %s ahead of the local "+
+			"clock, above the maximum skew of %s", cmd.ID, wts, skew, maxSkew)
+	}
+	log.Warningf(ctx, "command %x applied with write timestamp %s, %s ahead of the local "+
+		"clock, above the maximum skew of %s", cmd.ID, wts, skew, maxSkew)
+}
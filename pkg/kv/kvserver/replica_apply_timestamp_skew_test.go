@@ -0,0 +1,49 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/closedts"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxTimestampSkew(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	manual := timeutil.NewManualTime(timeutil.Unix(0, 123))
+	clock := hlc.NewClock(manual, 500*time.Millisecond, 400*time.Millisecond)
+	now := clock.PhysicalNow()
+
+	// The lead of the ranges serving global reads exceeds the maximum clock
+	// offset, and is tolerated along with kv.raft.apply.timestamp_skew_check.tolerance.
+	timestampSkewTolerance.Override(ctx, &st.SV, 0)
+	lead := maxTimestampSkew(&st.SV, clock, now)
+	require.Greater(t, lead, clock.MaxOffset())
+	timestampSkewTolerance.Override(ctx, &st.SV, time.Second)
+	require.Equal(t, lead+time.Second, maxTimestampSkew(&st.SV, clock, now))
+
+	// The maximum clock offset is tolerated even if the lead is overridden
+	// below it.
+	closedts.LeadForGlobalReadsOverride.Override(ctx, &st.SV, time.Millisecond)
+	require.Equal(t, clock.MaxOffset()+time.Second, maxTimestampSkew(&st.SV, clock, now))
+}
@@ -0,0 +1,211 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/allstacks"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/log/eventpb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/petermattis/goid"
+)
+
+// slowApplyThreshold is the duration a raft command can spend in a single
+// phase of its application before the apply watchdog reports it.
+var slowApplyThreshold = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.raft.apply.slow_command_threshold",
+	"if non-zero, the stack of the goroutine applying a raft command is logged, and a "+
+		"structured event is logged to the HEALTH channel, when the command spends longer "+
+		"than this duration staging or committing its write batch",
+	10*time.Second,
+	settings.NonNegativeDuration,
+)
+
+// slowApplyStacksLimiter limits the rate at which the stacks of slow
+// applications are dumped, since collecting them stops the world.
+var slowApplyStacksLimiter = log.Every(10 * time.Second)
+
+// maxSlowApplyCmdIDs is the maximum number of command IDs reported by a slow
+// application event.
+const maxSlowApplyCmdIDs = 16
+
+const (
+	applyPhaseStage = "stage"
+	applyPhaseApply = "apply"
+)
+
+// applyWatchdog detects raft commands which spend longer than
+// kv.raft.apply.slow_command_threshold in a single phase of their application,
+// e.g. because they are stuck acquiring the split or merge lock, or committing
+// the engine batch. It logs the stack of the goroutine applying the commands,
+// and records a SlowRaftCommandApplication event.
+//
+// Each phase is watched with a timer which is reused across phases, so that
+// watching doesn't allocate on the apply path.
+type applyWatchdog struct {
+	r  *Replica
+	mu struct {
+		syncutil.Mutex
+		timer *time.Timer
+		// watching is set between start and stop.
+		watching bool
+		// reported is set once the current phase has been reported.
+		reported    bool
+		started     time.Time
+		threshold   time.Duration
+		goroutineID int64
+		phase       string
+		cmdIDs      []kvserverbase.CmdIDKey
+		triggers    []string
+	}
+}
+
+// start starts watching a phase of the application of the given commands. It
+// is a no-op if the watchdog is disabled.
+func (w *applyWatchdog) start(phase string, cmds ...*replicatedCmd) {
+	threshold := slowApplyThreshold.Get(&w.r.ClusterSettings().SV)
+	if threshold == 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.mu.watching = true
+	w.mu.reported = false
+	w.mu.started = timeutil.Now()
+	w.mu.threshold = threshold
+	w.mu.goroutineID = goid.Get()
+	w.mu.phase = phase
+	w.mu.cmdIDs = w.mu.cmdIDs[:0]
+	w.mu.triggers = w.mu.triggers[:0]
+	for _, cmd := range cmds {
+		w.mu.cmdIDs = append(w.mu.cmdIDs, cmd.ID)
+		if !cmd.IsTrivial() {
+			w.mu.triggers = append(w.mu.triggers, commandTriggers(cmd.ReplicatedResult())...)
+		}
+	}
+	if w.mu.timer == nil {
+		w.mu.timer = time.AfterFunc(threshold, w.fire)
+	} else {
+		w.mu.timer.Reset(threshold)
+	}
+}
+
+// stop stops watching the current phase.
+func (w *applyWatchdog) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.mu.watching {
+		return
+	}
+	w.mu.watching = false
+	w.mu.timer.Stop()
+}
+
+// fire is called by the timer once the threshold has passed. The timer may
+// fire after the phase it was started for has completed, in which case it
+// ignores the phase which is watched now, unless it is overdue as well.
+func (w *applyWatchdog) fire() {
+	w.mu.Lock()
+	if !w.mu.watching || w.mu.reported {
+		w.mu.Unlock()
+		return
+	}
+	elapsed := timeutil.Since(w.mu.started)
+	if elapsed < w.mu.threshold {
+		w.mu.timer.Reset(w.mu.threshold - elapsed)
+		w.mu.Unlock()
+		return
+	}
+	w.mu.reported = true
+	goroutineID := w.mu.goroutineID
+	ev := &eventpb.SlowRaftCommandApplication{
+		StoreID:        int32(w.r.store.StoreID()),
+		RangeID:        int64(w.r.RangeID),
+		Phase:          w.mu.phase,
+		Triggers:       append([]string(nil), w.mu.triggers...),
+		ThresholdNanos: w.mu.threshold.Nanoseconds(),
+	}
+	for i, id := range w.mu.cmdIDs {
+		if i == maxSlowApplyCmdIDs {
+			break
+		}
+		ev.CommandIDs = append(ev.CommandIDs, id.String())
+	}
+	w.mu.Unlock()
+
+	ctx := w.r.AnnotateCtx(context.Background())
+	if slowApplyStacksLimiter.ShouldLog() {
+		stack := goroutineStack(allstacks.Get(), goroutineID)
+		log.Warningf(ctx, "%s of raft commands %v (triggers %v) has been running for %s:\n%s",
+			ev.Phase, ev.CommandIDs, ev.Triggers, elapsed, stack)
+	} else {
+		log.Warningf(ctx, "%s of raft commands %v (triggers %v) has been running for %s",
+			ev.Phase, ev.CommandIDs, ev.Triggers, elapsed)
+	}
+	log.StructuredEvent(ctx, ev)
+}
+
+// goroutineStack extracts the stack of the goroutine with the given ID from a
+// dump of the stacks of all goroutines. It returns the entire dump if the
+// goroutine isn't found.
+func goroutineStack(stacks []byte, goroutineID int64) []byte {
+	header := []byte(fmt.Sprintf("goroutine %d [", goroutineID))
+	start := bytes.Index(stacks, header)
+	if start < 0 {
+		return stacks
+	}
+	stack := stacks[start:]
+	if end := bytes.Index(stack, []byte("\n\n")); end >= 0 {
+		stack = stack[:end]
+	}
+	return stack
+}
+
+// commandTriggers returns the types of the non-trivial triggers of a
+// command, as reported by the apply watchdog.
+func commandTriggers(res *kvserverpb.ReplicatedEvalResult) []string {
+	var triggers []string
+	if res.Split != nil {
+		triggers = append(triggers, "split")
+	}
+	if res.Merge != nil {
+		triggers = append(triggers, "merge")
+	}
+	if res.ChangeReplicas != nil {
+		triggers = append(triggers, "change_replicas")
+	}
+	if res.AddSSTable != nil {
+		triggers = append(triggers, "add_sstable")
+	}
+	if res.ComputeChecksum != nil {
+		triggers = append(triggers, "compute_checksum")
+	}
+	if res.IsLeaseRequest {
+		triggers = append(triggers, "lease")
+	}
+	if res.State != nil && res.State.TruncatedState != nil {
+		triggers = append(triggers, "truncate_log")
+	}
+	if res.MVCCHistoryMutation != nil {
+		triggers = append(triggers, "mvcc_history_mutation")
+	}
+	return triggers
+}
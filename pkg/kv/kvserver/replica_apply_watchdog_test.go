@@ -0,0 +1,74 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/util/allstacks"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/petermattis/goid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoroutineStack(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	stacks := []byte(`goroutine 1 [running]:
+main.main()
+	main.go:10
+
+goroutine 12 [semacquire]:
+sync.(*Mutex).Lock()
+	mutex.go:80
+kvserver.(*Replica).maybeAcquireSplitMergeLock()
+	replica_app_batch.go:240
+
+goroutine 123 [select]:
+main.worker()
+	worker.go:5
+`)
+	require.Equal(t, `goroutine 12 [semacquire]:
+sync.(*Mutex).Lock()
+	mutex.go:80
+kvserver.(*Replica).maybeAcquireSplitMergeLock()
+	replica_app_batch.go:240`, string(goroutineStack(stacks, 12)))
+	require.Equal(t, `goroutine 123 [select]:
+main.worker()
+	worker.go:5
+`, string(goroutineStack(stacks, 123)))
+	// An unknown goroutine returns all the stacks.
+	require.Equal(t, stacks, goroutineStack(stacks, 7))
+
+	// The stack of the current goroutine contains this test.
+	stack := goroutineStack(allstacks.Get(), goid.Get())
+	require.Contains(t, string(stack), "TestGoroutineStack")
+}
+
+func TestCommandTriggers(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	require.Empty(t, commandTriggers(&kvserverpb.ReplicatedEvalResult{}))
+	require.Equal(t, []string{"split"}, commandTriggers(&kvserverpb.ReplicatedEvalResult{
+		Split: &kvserverpb.Split{},
+	}))
+	require.Equal(t, []string{"merge", "lease"}, commandTriggers(&kvserverpb.ReplicatedEvalResult{
+		Merge:          &kvserverpb.Merge{},
+		IsLeaseRequest: true,
+	}))
+	require.Equal(t, []string{"truncate_log"}, commandTriggers(&kvserverpb.ReplicatedEvalResult{
+		State: &kvserverpb.ReplicaState{TruncatedState: &kvserverpb.RaftTruncatedState{}},
+	}))
+}
@@ -133,7 +133,9 @@ func (r *Replica) shouldBackpressureWrites() bool {
 
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	exceeded, bytesOver := r.exceedsMultipleOfSplitSizeRLocked(mult)
+	// Backpressure is based on the MVCC stats rather than the size estimate,
+	// see rangeSizeTracker.
+	exceeded, bytesOver := r.exceedsMultipleOfSplitSizeRLocked(mult, r.mu.state.Stats.Total())
 	if !exceeded {
 		return false
 	}
@@ -174,6 +174,78 @@ func maybeStripInFlightWrites(ba *kvpb.BatchRequest) (*kvpb.BatchRequest, error)
 	return ba, nil
 }
 
+// maybeConvertFullRangeDeleteRange marks the point DeleteRange requests in the
+// batch that cover the entire range for conversion to an MVCC range tombstone,
+// if kv.delete_range.full_range_conversion.enabled is set, and unmarks those
+// that don't qualify. The conversion requires wider latches, so the decision is
+// made once, before the request's spans are collected, rather than at
+// evaluation time.
+func (r *Replica) maybeConvertFullRangeDeleteRange(
+	ctx context.Context, ba *kvpb.BatchRequest,
+) *kvpb.BatchRequest {
+	sv := &r.ClusterSettings().SV
+	enabled := batcheval.ConvertFullRangeDeleteRange.Get(sv)
+	desc := r.Desc()
+	copied := false
+	for i, union := range ba.Requests {
+		args, ok := union.GetInner().(*kvpb.DeleteRangeRequest)
+		if !ok {
+			continue
+		}
+		convert := enabled && batcheval.MayConvertToRangeTombstone(&ba.Header, args) &&
+			args.Key.Equal(desc.StartKey.AsRawKey()) && args.EndKey.Equal(desc.EndKey.AsRawKey())
+		var clearHistoryAt hlc.Timestamp
+		if convert && batcheval.ClearHistoryOnFullRangeDeleteRange.Get(sv) &&
+			r.canClearHistoryBelow(ctx, ba.Timestamp) {
+			clearHistoryAt = ba.Timestamp
+		}
+		if args.ConvertToRangeTombstone == convert && args.ClearHistoryAt == clearHistoryAt {
+			continue
+		}
+		if !copied {
+			ba = ba.ShallowCopy()
+			ba.Requests = append([]kvpb.RequestUnion(nil), ba.Requests...)
+			copied = true
+		}
+		argsCopy := *args // shallow copy
+		argsCopy.ConvertToRangeTombstone = convert
+		argsCopy.ClearHistoryAt = clearHistoryAt
+		ba.Requests[i].MustSetInner(&argsCopy)
+	}
+	return ba
+}
+
+// canClearHistoryBelow returns whether the MVCC history of the range below the
+// given timestamp may be cleared right away, i.e. whether the GC threshold may
+// be raised to just below it as far as the protected timestamps known to the
+// replica are concerned. Like for the MVCC GC queue, reads of the cleared
+// history which are only protected by a record that isn't known yet fail
+// instead of observing the missing history.
+func (r *Replica) canClearHistoryBelow(ctx context.Context, ts hlc.Timestamp) bool {
+	readAt, earliestProtection, ok := func() (_, _ hlc.Timestamp, ok bool) {
+		var read cachedProtectedTimestampState
+		defer r.maybeUpdateCachedProtectedTS(&read)
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		defer read.clearIfNotNewer(r.mu.cachedProtectedTS)
+
+		var err error
+		read, err = r.readProtectedTimestampsRLocked(ctx)
+		if err != nil {
+			log.VEventf(ctx, 2, "not clearing history: %v", err)
+			return hlc.Timestamp{}, hlc.Timestamp{}, false
+		}
+		// The protected timestamp information must be available and recent, see
+		// checkProtectedTimestampsForGC.
+		ok = !read.readAt.IsEmpty() && !read.readAt.Less(r.mu.state.Lease.Start.ToTimestamp())
+		return read.readAt, read.earliestProtectionTimestamp, ok
+	}()
+	if !ok || (!earliestProtection.IsEmpty() && earliestProtection.Less(ts)) {
+		return false
+	}
+	return r.markPendingGC(readAt, ts.Prev()) == nil
+}
+
 // maybeBumpReadTimestampToWriteTimestamp bumps the batch's read timestamp to
 // the write timestamp for transactional batches where these timestamp have
 // diverged and where bumping is possible. When possible, this allows the
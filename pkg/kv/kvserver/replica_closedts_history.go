@@ -0,0 +1,174 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// closedTimestampHistorySize is the number of samples retained in the closed
+// timestamp history of a replica. With the default sample interval, the
+// history covers the last 5 minutes of activity of the range.
+const closedTimestampHistorySize = 30
+
+// closedTimestampHistorySampleInterval is the interval between two samples of a
+// replica's closed timestamp history.
+var closedTimestampHistorySampleInterval = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.closed_timestamp.history.sample_interval",
+	"the interval at which the closed timestamp of each replica is sampled "+
+		"into its closed timestamp history (0 disables the history)",
+	10*time.Second,
+	settings.NonNegativeDuration,
+)
+
+// ClosedTimestampSample is a sample of the closed timestamp of a replica, taken
+// periodically by the store (see Store.startClosedTimestampSampler).
+type ClosedTimestampSample struct {
+	// At is the time at which the sample was taken.
+	At time.Time
+	// Policy is the closed timestamp policy of the range.
+	Policy roachpb.RangeClosedTimestampPolicy
+	// Target is the timestamp the range would have liked to close according to
+	// its policy.
+	Target hlc.Timestamp
+	// Closed is the closed timestamp of the replica, the maximum of the closed
+	// timestamps carried by the Raft log and by the side transport. It is what
+	// follower reads are checked against.
+	Closed hlc.Timestamp
+	// TrackerLag is the amount by which requests evaluating on the leaseholder
+	// held back the closed timestamp of the last proposal, compared to the
+	// target. Long-running writes are the usual culprit. Only set on the
+	// leaseholder.
+	TrackerLag time.Duration
+}
+
+// ClosedLag returns how far the closed timestamp trailed the time at which the
+// sample was taken. Follower reads at timestamps within the lag fail to be
+// served by followers.
+func (s ClosedTimestampSample) ClosedLag() time.Duration {
+	if s.Closed.IsEmpty() {
+		return 0
+	}
+	return s.At.Sub(s.Closed.GoTime())
+}
+
+// closedTimestampHistory is a ring buffer of the latest closed timestamp
+// samples of a replica. It is allocated with the first sample, and grows up to
+// closedTimestampHistorySize samples.
+type closedTimestampHistory struct {
+	samples []ClosedTimestampSample
+	// next is the index of samples at which the next sample is written, once
+	// the buffer is full.
+	next int
+}
+
+func (h *closedTimestampHistory) add(s ClosedTimestampSample) {
+	if len(h.samples) < closedTimestampHistorySize {
+		h.samples = append(h.samples, s)
+		return
+	}
+	h.samples[h.next] = s
+	h.next = (h.next + 1) % len(h.samples)
+}
+
+// get returns the samples taken at or after the given time, from the oldest
+// to the latest.
+func (h *closedTimestampHistory) get(since time.Time) []ClosedTimestampSample {
+	if h == nil {
+		return nil
+	}
+	var res []ClosedTimestampSample
+	for i := range h.samples {
+		s := h.samples[(h.next+i)%len(h.samples)]
+		if s.At.Before(since) {
+			continue
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+// sampleClosedTimestamp records a sample of the replica's closed timestamp in
+// its history.
+func (r *Replica) sampleClosedTimestamp(ctx context.Context, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mu.closedTSHistory == nil {
+		r.mu.closedTSHistory = &closedTimestampHistory{}
+	}
+	r.mu.closedTSHistory.add(ClosedTimestampSample{
+		At:         now,
+		Policy:     r.closedTimestampPolicyRLocked(),
+		Target:     r.closedTimestampTargetRLocked(),
+		Closed:     r.getCurrentClosedTimestampLocked(ctx, hlc.Timestamp{} /* sufficient */),
+		TrackerLag: r.mu.proposalBuf.trackerLag,
+	})
+}
+
+// startClosedTimestampSampler starts the periodic sampling of the closed
+// timestamp of the replicas of the store into their history. The replicas are
+// sampled on a timer rather than when commands apply, since the closed
+// timestamp of idle ranges is only advanced by the side transport.
+func (s *Store) startClosedTimestampSampler(ctx context.Context) {
+	_ /* err */ = s.stopper.RunAsyncTaskEx(ctx, stop.TaskOpts{
+		TaskName: "closed-timestamp-sampler",
+		SpanOpt:  stop.SterileRootSpan,
+	}, func(ctx context.Context) {
+		ctx, cancel := s.stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+
+		timer := timeutil.NewTimer()
+		defer timer.Stop()
+		timer.Reset(closedTimestampHistorySampleInterval.Get(&s.ClusterSettings().SV))
+		for {
+			select {
+			case <-timer.C:
+				timer.Read = true
+				interval := closedTimestampHistorySampleInterval.Get(&s.ClusterSettings().SV)
+				if interval == 0 {
+					// Check again later whether the history has been enabled.
+					timer.Reset(time.Minute)
+					continue
+				}
+				s.sampleClosedTimestamps(ctx)
+				timer.Reset(interval)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// sampleClosedTimestamps samples the closed timestamp of the replicas of the
+// store.
+func (s *Store) sampleClosedTimestamps(ctx context.Context) {
+	now := s.Clock().PhysicalTime()
+	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+		r.sampleClosedTimestamp(ctx, now)
+		return ctx.Err() == nil
+	})
+}
+
+// ClosedTimestampHistory returns the samples of the replica's closed timestamp
+// history taken at or after the given time, from the oldest to the latest.
+func (r *Replica) ClosedTimestampHistory(since time.Time) []ClosedTimestampSample {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mu.closedTSHistory.get(since)
+}
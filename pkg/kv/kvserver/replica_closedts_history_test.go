@@ -0,0 +1,87 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClosedTimestampHistory(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var h *closedTimestampHistory
+	require.Empty(t, h.get(time.Time{}))
+
+	start := time.Unix(1000, 0)
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Second) }
+	h = &closedTimestampHistory{}
+	for i := 0; i < closedTimestampHistorySize+5; i++ {
+		h.add(ClosedTimestampSample{
+			At:     at(i),
+			Closed: hlc.Timestamp{WallTime: at(i).Add(-3 * time.Second).UnixNano()},
+		})
+	}
+
+	// Only the latest samples are retained, from the oldest to the latest.
+	samples := h.get(time.Time{})
+	require.Len(t, samples, closedTimestampHistorySize)
+	for i, s := range samples {
+		require.Equal(t, at(i+5), s.At)
+		require.Equal(t, 3*time.Second, s.ClosedLag())
+	}
+
+	// The samples can be filtered by time.
+	samples = h.get(at(closedTimestampHistorySize + 3))
+	require.Len(t, samples, 2)
+	require.Equal(t, at(closedTimestampHistorySize+3), samples[0].At)
+
+	require.Zero(t, ClosedTimestampSample{At: start}.ClosedLag())
+}
+
+// TestClosedTimestampHistorySample verifies that the samples of a replica's
+// closed timestamp account for the closed timestamps communicated by the side
+// transport, which alone advance the closed timestamp of idle ranges.
+func TestClosedTimestampHistorySample(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	ctx := context.Background()
+	defer stopper.Stop(ctx)
+	tc.Start(ctx, t, stopper)
+	r := tc.repl
+
+	start := time.Unix(1000, 0)
+	r.mu.RLock()
+	raftClosed := r.mu.state.RaftClosedTimestamp
+	lai := r.mu.state.LeaseAppliedIndex
+	r.mu.RUnlock()
+	r.sampleClosedTimestamp(ctx, start)
+
+	sideClosed := raftClosed.Add(time.Second.Nanoseconds(), 0)
+	r.ForwardSideTransportClosedTimestamp(ctx, sideClosed, lai)
+	r.sampleClosedTimestamp(ctx, start.Add(time.Second))
+
+	samples := r.ClosedTimestampHistory(time.Time{})
+	require.Len(t, samples, 2)
+	require.Equal(t, raftClosed, samples[0].Closed)
+	require.Equal(t, sideClosed, samples[1].Closed)
+	require.Len(t, r.ClosedTimestampHistory(start.Add(time.Second)), 1)
+}
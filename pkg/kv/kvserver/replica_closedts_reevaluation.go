@@ -0,0 +1,102 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/batcheval"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// closedTimestampReevaluationEnabled controls whether the local writes whose
+// reproposal was rejected because of the closed timestamp are re-evaluated on
+// the leaseholder at a higher timestamp.
+var closedTimestampReevaluationEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.raft.closed_timestamp_reevaluation.enabled",
+	"if enabled, writes which fail the lease applied index check below Raft and can't be "+
+		"reproposed because the closed timestamp advanced past their write timestamp are "+
+		"re-evaluated at a higher timestamp on the leaseholder, when the batch permits it, "+
+		"instead of being redirected to the client",
+	true,
+)
+
+// closedTimestampReevaluationMaxAttempts bounds the number of times a batch is
+// re-evaluated because its reproposal was rejected by the closed timestamp.
+const closedTimestampReevaluationMaxAttempts = 3
+
+// errReproposalBelowClosedTimestamp marks the errors of the local proposals
+// which failed the lease applied index check, and couldn't be reproposed with a
+// new lease index because the closed timestamp had advanced past their write
+// timestamp. No other copy of the command can apply, so the batch can be
+// evaluated again.
+var errReproposalBelowClosedTimestamp = errors.New("reproposal below the closed timestamp")
+
+// isReproposalBelowClosedTimestampError returns whether the error was returned
+// by a proposal which couldn't be reproposed because of the closed timestamp.
+func isReproposalBelowClosedTimestampError(pErr *kvpb.Error) bool {
+	return errors.Is(pErr.GoError(), errReproposalBelowClosedTimestamp)
+}
+
+// handleReproposalBelowClosedTimestampError attempts to bump the timestamp of
+// a batch whose proposal was rejected below Raft because of the closed
+// timestamp, so that it can be evaluated again without a round-trip to the
+// client. Returns the original error, a NotLeaseHolderError which the
+// DistSender retries, if the batch can't be re-evaluated.
+func (r *Replica) handleReproposalBelowClosedTimestampError(
+	ctx context.Context, ba *kvpb.BatchRequest, pErr *kvpb.Error, attempts int,
+) (*kvpb.BatchRequest, *kvpb.Error) {
+	if !closedTimestampReevaluationEnabled.Get(&r.store.cfg.Settings.SV) ||
+		attempts >= closedTimestampReevaluationMaxAttempts {
+		return nil, pErr
+	}
+	if !bumpBatchAboveClosedTimestamp(ctx, ba, r.GetCurrentClosedTimestamp(ctx)) {
+		return nil, pErr
+	}
+	r.store.metrics.RaftCommandsReevaluated.Inc(1)
+	log.VEventf(ctx, 2, "re-evaluating batch rejected below the closed timestamp at %s", ba.Timestamp)
+	return ba, nil
+}
+
+// bumpBatchAboveClosedTimestamp bumps the timestamp of the batch above the
+// given closed timestamp, subject to the same constraints as the server-side
+// refreshes of canDoServersideRetry: a transactional batch must be able to
+// forward its read timestamp, and must not exceed the deadline of its EndTxn
+// request nor its commit timestamp floor. Returns false if the timestamp could
+// not be bumped, in which case the batch is unchanged.
+func bumpBatchAboveClosedTimestamp(
+	ctx context.Context, ba *kvpb.BatchRequest, closedTS hlc.Timestamp,
+) bool {
+	ts := closedTS.Next()
+	ts.Forward(ba.Timestamp)
+	if ba.Txn != nil {
+		if !ba.CanForwardReadTimestamp {
+			return false
+		}
+		ts.Forward(ba.Txn.WriteTimestamp)
+		if floor := ba.CommitTimestampFloor; !floor.IsEmpty() && floor.Less(ts) {
+			return false
+		}
+		if etArg, ok := ba.GetArg(kvpb.EndTxn); ok {
+			if batcheval.IsEndTxnExceedingDeadline(ts, etArg.(*kvpb.EndTxnRequest).Deadline) {
+				return false
+			}
+		}
+	}
+	// The latches were released with the proposal, they are re-acquired at the
+	// new timestamp.
+	return tryBumpBatchTimestamp(ctx, ba, nil /* g */, ts)
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReproposalBelowClosedTimestampError(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The marked error remains a NotLeaseHolderError for the DistSender.
+	nlhe := kvpb.NewNotLeaseHolderError(roachpb.Lease{}, 1, &roachpb.RangeDescriptor{},
+		"reproposal failed due to closed timestamp")
+	pErr := kvpb.NewError(errors.Mark(nlhe, errReproposalBelowClosedTimestamp))
+	require.True(t, isReproposalBelowClosedTimestampError(pErr))
+	require.IsType(t, &kvpb.NotLeaseHolderError{}, pErr.GetDetail())
+	require.False(t, isReproposalBelowClosedTimestampError(kvpb.NewError(nlhe)))
+}
+
+func TestBumpBatchAboveClosedTimestamp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	ts := func(wallTime int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wallTime} }
+	closedTS := ts(10)
+	makeTxn := func(read, write hlc.Timestamp) *roachpb.Transaction {
+		return &roachpb.Transaction{
+			TxnMeta:       enginepb.TxnMeta{WriteTimestamp: write},
+			ReadTimestamp: read,
+		}
+	}
+	put := &kvpb.PutRequest{RequestHeader: kvpb.RequestHeader{Key: roachpb.Key("a")}}
+
+	testCases := []struct {
+		name     string
+		txn      *roachpb.Transaction
+		canFwd   bool
+		floor    hlc.Timestamp
+		deadline hlc.Timestamp
+		expTS    hlc.Timestamp // empty if the batch can't be bumped
+	}{
+		{name: "non-txn", expTS: closedTS.Next()},
+		{name: "txn", txn: makeTxn(ts(5), ts(5)), canFwd: true, expTS: closedTS.Next()},
+		{name: "txn pushed write", txn: makeTxn(ts(5), ts(20)), canFwd: true, expTS: ts(20)},
+		{name: "txn with reads", txn: makeTxn(ts(5), ts(5))},
+		{name: "txn floor", txn: makeTxn(ts(5), ts(5)), canFwd: true, floor: ts(8)},
+		{name: "txn deadline", txn: makeTxn(ts(5), ts(5)), canFwd: true, deadline: ts(10)},
+		{
+			name: "txn before deadline", txn: makeTxn(ts(5), ts(5)), canFwd: true,
+			deadline: ts(11), expTS: closedTS.Next(),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ba := &kvpb.BatchRequest{}
+			ba.Timestamp = ts(5)
+			ba.Txn = tc.txn
+			ba.CanForwardReadTimestamp = tc.canFwd
+			ba.CommitTimestampFloor = tc.floor
+			ba.Add(put)
+			if !tc.deadline.IsEmpty() {
+				ba.Add(&kvpb.EndTxnRequest{Commit: true, Deadline: tc.deadline})
+			}
+			bumped := bumpBatchAboveClosedTimestamp(ctx, ba, closedTS)
+			require.Equal(t, !tc.expTS.IsEmpty(), bumped)
+			if !bumped {
+				require.Equal(t, ts(5), ba.Timestamp)
+				return
+			}
+			require.Equal(t, tc.expTS, ba.Timestamp)
+			if tc.txn != nil {
+				require.Equal(t, tc.expTS, ba.Txn.ReadTimestamp)
+				require.Equal(t, tc.expTS, ba.Txn.WriteTimestamp)
+				require.Equal(t, ts(5), tc.txn.ReadTimestamp, "txn must be cloned")
+			}
+		})
+	}
+}
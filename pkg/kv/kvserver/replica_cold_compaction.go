@@ -0,0 +1,213 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// coldCompactionScanInterval is the interval at which each store looks for the
+// cold ranges it holds the lease of, and updates its cold compaction metrics.
+var coldCompactionScanInterval = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.cold_compaction.scan_interval",
+	"the interval at which each store compacts the cold ranges it is the leaseholder "+
+		"of, according to their cold compaction policies, into the bottom levels of "+
+		"the engines (0 disables)",
+	10*time.Minute,
+	settings.NonNegativeDuration,
+)
+
+// ColdCompaction returns the cold compaction policy of the range, set with
+// DB.SetColdCompactionPolicy, or nil if the range has no policy.
+//
+// Once a range with a policy is cold, each of its replicas compacts the range
+// into the bottom levels of its engine. This is all the policy does: Pebble
+// only places data by level, for the whole engine, so the data of a single
+// range can't be moved to object storage. On a store whose bottom levels are
+// on shared storage, the compaction does hasten the move of the range there,
+// which SharedStorageBytes observes, but the data of the other ranges gets
+// there too as it's compacted, and the data of a compacted range moves back up
+// to the local levels when it's written again (see roachpb.ColdCompaction).
+func (r *Replica) ColdCompaction() *roachpb.ColdCompaction {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cs := r.mu.state.ColdCompaction
+	if cs == nil || cs.Threshold.IsEmpty() {
+		return nil
+	}
+	return cs
+}
+
+// SharedStorageBytes returns the approximate on-disk size of the data of the
+// range that is on shared storage and on local storage. All of it is local if
+// the engine has no shared storage.
+func (r *Replica) SharedStorageBytes() (shared, local uint64, _ error) {
+	span := r.Desc().KeySpan().AsRawSpanWithNoLocals()
+	total, remote, _, err := r.store.TODOEngine().ApproximateDiskBytes(span.Key, span.EndKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	if remote > total {
+		remote = total
+	}
+	return remote, total - remote, nil
+}
+
+// shouldCompactColdData returns true if the range is cold under its cold
+// compaction policy and was written since it was last compacted.
+//
+// The range is cold if its MVCC stats were last updated before the threshold.
+// This is an approximation, made for the range as a whole rather than for each
+// of its sstables: the stats are updated by every write to the range, but also
+// by the garbage collection of old versions, and a single recent write keeps
+// the whole range hot.
+func shouldCompactColdData(cs *roachpb.ColdCompaction, ms enginepb.MVCCStats) bool {
+	if cs == nil || cs.Threshold.IsEmpty() {
+		return false
+	}
+	if ms.LastUpdateNanos >= cs.Threshold.WallTime {
+		return false
+	}
+	return cs.CompactedAt.IsEmpty() || cs.CompactedAt.WallTime <= ms.LastUpdateNanos
+}
+
+// coldCompactions holds the spans of the cold ranges pending compaction.
+type coldCompactions struct {
+	// notify is signaled when a span is queued.
+	notify chan struct{}
+	mu     struct {
+		syncutil.Mutex
+		spans []roachpb.Span
+	}
+}
+
+func makeColdCompactions() coldCompactions {
+	return coldCompactions{notify: make(chan struct{}, 1)}
+}
+
+// queueColdCompaction queues the compaction of the span of a cold range, which
+// rewrites the data of the span into the bottom levels of the engine. The span
+// is not tracked beyond that.
+func (s *Store) queueColdCompaction(ctx context.Context, span roachpb.Span) {
+	c := &s.coldCompactions
+	c.mu.Lock()
+	c.mu.spans = append(c.mu.spans, span)
+	c.mu.Unlock()
+	log.VEventf(ctx, 2, "queued cold compaction of span %s", span)
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// startColdCompactor starts a worker which compacts the spans queued by
+// queueColdCompaction, one at a time, and periodically compacts the cold
+// ranges the store is the leaseholder of.
+func (s *Store) startColdCompactor(ctx context.Context) {
+	_ /* err */ = s.stopper.RunAsyncTaskEx(ctx, stop.TaskOpts{
+		TaskName: "cold-compactor",
+		SpanOpt:  stop.SterileRootSpan,
+	}, func(ctx context.Context) {
+		ctx, cancel := s.stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+
+		c := &s.coldCompactions
+		timer := timeutil.NewTimer()
+		defer timer.Stop()
+		timer.Reset(time.Minute)
+		for {
+			select {
+			case <-c.notify:
+				c.mu.Lock()
+				spans := c.mu.spans
+				c.mu.spans = nil
+				c.mu.Unlock()
+				s.compactColdData(ctx, spans)
+			case <-timer.C:
+				timer.Read = true
+				interval := coldCompactionScanInterval.Get(&s.ClusterSettings().SV)
+				if interval == 0 {
+					// Check again later whether the scan has been enabled.
+					timer.Reset(time.Minute)
+					continue
+				}
+				s.scanColdCompaction(ctx)
+				timer.Reset(interval)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// compactColdData compacts the given spans of cold ranges.
+func (s *Store) compactColdData(ctx context.Context, spans []roachpb.Span) {
+	spans, _ = roachpb.MergeSpans(&spans)
+	for _, sp := range spans {
+		if ctx.Err() != nil {
+			return
+		}
+		start := timeutil.Now()
+		if err := s.TODOEngine().CompactRange(sp.Key, sp.EndKey); err != nil {
+			s.metrics.ColdCompactionsFailed.Inc(1)
+			log.Warningf(ctx, "unable to compact cold span %s: %v", sp, err)
+			continue
+		}
+		s.metrics.ColdCompactions.Inc(1)
+		log.VEventf(ctx, 2, "compacted cold span %s in %s", sp, timeutil.Since(start))
+	}
+}
+
+// scanColdCompaction runs a single pass over the replicas of the store with a
+// cold compaction policy. It updates the cold compaction metrics of the store,
+// and compacts the cold ranges the store is the leaseholder of.
+func (s *Store) scanColdCompaction(ctx context.Context) {
+	var sharedBytes, localBytes uint64
+	var toCompact []roachpb.Key
+	now := s.Clock().NowAsClockTimestamp()
+	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+		cs := r.ColdCompaction()
+		if cs == nil {
+			return true
+		}
+		shared, local, err := r.SharedStorageBytes()
+		if err != nil {
+			log.Warningf(ctx, "r%d: unable to compute shared storage bytes: %v", r.RangeID, err)
+		}
+		sharedBytes += shared
+		localBytes += local
+		if shouldCompactColdData(cs, r.GetMVCCStats()) && r.OwnsValidLease(ctx, now) {
+			toCompact = append(toCompact, r.Desc().StartKey.AsRawKey())
+		}
+		return ctx.Err() == nil
+	})
+	s.metrics.ColdCompactionSharedBytes.Update(int64(sharedBytes))
+	s.metrics.ColdCompactionLocalBytes.Update(int64(localBytes))
+
+	for _, key := range toCompact {
+		if err := s.db.CompactColdData(ctx, key); err != nil {
+			log.Warningf(ctx, "unable to compact the cold range containing %s: %v", key, err)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
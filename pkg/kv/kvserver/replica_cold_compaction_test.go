@@ -0,0 +1,77 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldCompactColdData(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ts := func(wallTime int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wallTime} }
+	for _, tc := range []struct {
+		name        string
+		cs          *roachpb.ColdCompaction
+		lastUpdated int64
+		exp         bool
+	}{
+		{
+			name:        "no policy",
+			lastUpdated: 10,
+		},
+		{
+			name:        "empty policy",
+			cs:          &roachpb.ColdCompaction{},
+			lastUpdated: 10,
+		},
+		{
+			name:        "cold",
+			cs:          &roachpb.ColdCompaction{Threshold: ts(20)},
+			lastUpdated: 10,
+			exp:         true,
+		},
+		{
+			name:        "written at threshold",
+			cs:          &roachpb.ColdCompaction{Threshold: ts(20)},
+			lastUpdated: 20,
+		},
+		{
+			name:        "hot",
+			cs:          &roachpb.ColdCompaction{Threshold: ts(20)},
+			lastUpdated: 30,
+		},
+		{
+			name:        "compacted",
+			cs:          &roachpb.ColdCompaction{Threshold: ts(20), CompactedAt: ts(15)},
+			lastUpdated: 10,
+		},
+		{
+			name:        "written since compaction",
+			cs:          &roachpb.ColdCompaction{Threshold: ts(20), CompactedAt: ts(5)},
+			lastUpdated: 10,
+			exp:         true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ms := enginepb.MVCCStats{LastUpdateNanos: tc.lastUpdated}
+			require.Equal(t, tc.exp, shouldCompactColdData(tc.cs, ms))
+		})
+	}
+}
@@ -333,6 +333,9 @@ func (r *Replica) adminSplitWithDescriptor(
 				// No suitable split key could be found.
 				return reply, unsplittableRangeError{}
 			}
+			// Split at a nearby span config boundary instead, if any, to avoid
+			// having to split there later on.
+			foundSplitKey = r.maybeSnapSplitKeyToSpanConfigBoundary(ctx, desc, foundSplitKey)
 		} else {
 			// If the key that routed this request to this range is now out of this
 			// range's bounds, return an error for the client to try again on the
@@ -1032,6 +1035,13 @@ func (r *Replica) changeReplicasImpl(
 	if err := validateReplicationChanges(desc, chgs); err != nil {
 		return nil, errors.Mark(err, errMarkInvalidReplicationChange)
 	}
+	for _, chg := range chgs {
+		if chg.ChangeType == roachpb.ADD_VOTER && r.store.isNonVotingStore(chg.Target.StoreID) {
+			return nil, errors.Mark(
+				errors.Errorf("s%d is in non-voting mode and can't hold voters", chg.Target.StoreID),
+				errMarkInvalidReplicationChange)
+		}
+	}
 	targets := SynthesizeTargetsByChangeType(chgs)
 
 	// NB: As of the time of this writing,`AdminRelocateRange` will only execute
@@ -1094,10 +1104,16 @@ func (r *Replica) changeReplicasImpl(
 	}
 
 	if len(targets.VoterAdditions)+len(targets.VoterRemovals) > 0 {
-		desc, err = r.execReplicationChangesForVoters(
-			ctx, desc, reason, details,
-			targets.VoterAdditions, targets.VoterRemovals,
-		)
+		// Don't promote the learners to voters while their stores are IO
+		// overloaded. If they don't recover in time, the learners are rolled back
+		// below.
+		err = r.waitForHealthyLearnerPromotionTargets(ctx, targets.VoterAdditions)
+		if err == nil {
+			desc, err = r.execReplicationChangesForVoters(
+				ctx, desc, reason, details,
+				targets.VoterAdditions, targets.VoterRemovals,
+			)
+		}
 		if err != nil {
 			// If the error occurred while transitioning out of an atomic replication
 			// change, try again here with a fresh descriptor; this is a noop otherwise.
@@ -2539,6 +2555,27 @@ func recordRangeEventsInLog(
 	return nil
 }
 
+// excludeDrainingSnapshotSenders filters out the candidate snapshot senders
+// whose stores are draining. Draining stores are busy shedding their replicas
+// and leases, and adding snapshot send load to them slows down the drain. If
+// every candidate is draining, the candidates are returned unmodified since a
+// draining sender is still preferable to no sender at all.
+func excludeDrainingSnapshotSenders(
+	candidates []roachpb.ReplicaDescriptor, isDraining func(roachpb.StoreID) (bool, error),
+) []roachpb.ReplicaDescriptor {
+	var nonDraining []roachpb.ReplicaDescriptor
+	for _, c := range candidates {
+		if draining, err := isDraining(c.StoreID); err == nil && draining {
+			continue
+		}
+		nonDraining = append(nonDraining, c)
+	}
+	if len(nonDraining) == 0 {
+		return candidates
+	}
+	return nonDraining
+}
+
 // getSenderReplicas returns an ordered list of replica descriptor for a
 // follower replica to act as the sender for delegated snapshots. The replicas
 // should be tried in order, and typically the coordinator is the last entry on
@@ -2583,7 +2620,8 @@ func (r *Replica) getSenderReplicas(
 			return rDesc.ReplicaID != recipient.ReplicaID && storePool.IsStoreHealthy(rDesc.StoreID)
 		},
 	)
-	candidates := nonRecipientReplicas.VoterAndNonVoterDescriptors()
+	candidates := excludeDrainingSnapshotSenders(
+		nonRecipientReplicas.VoterAndNonVoterDescriptors(), storePool.IsDraining)
 	if len(candidates) == 0 {
 		// Not clear when the coordinator would be considered dead, but if it does
 		// happen, just return the coordinator.
@@ -2878,6 +2916,9 @@ func (r *Replica) sendSnapshotUsingDelegate(
 					return err
 				}
 				if resp.MsgAppResp != nil {
+					// If the replica was removed or hibernated in the meantime, it
+					// tracks no progress for the recipient and the response is
+					// dropped.
 					_ = r.withRaftGroup(func(rn *raft.RawNode) (unquiesceAndWakeLeader bool, _ error) {
 						msg := *resp.MsgAppResp
 						// With a delegated snapshot, the recipient received the snapshot
@@ -3211,6 +3252,7 @@ func (r *Replica) followerSendSnapshot(
 			resp, err := r.store.cfg.Transport.SendSnapshot(
 				ctx,
 				r.store.ClusterID(),
+				r.store.StoreID(),
 				r.store.cfg.StorePool,
 				header,
 				snap,
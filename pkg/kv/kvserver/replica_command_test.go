@@ -23,6 +23,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -608,3 +609,66 @@ func TestSynthesizeTargetsByChangeType(t *testing.T) {
 		})
 	}
 }
+
+func TestExcludeDrainingSnapshotSenders(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	mkReplicas := func(storeIDs ...roachpb.StoreID) []roachpb.ReplicaDescriptor {
+		var res []roachpb.ReplicaDescriptor
+		for _, id := range storeIDs {
+			res = append(res, roachpb.ReplicaDescriptor{
+				NodeID: roachpb.NodeID(id), StoreID: id, ReplicaID: roachpb.ReplicaID(id),
+			})
+		}
+		return res
+	}
+	mkIsDraining := func(draining ...roachpb.StoreID) func(roachpb.StoreID) (bool, error) {
+		return func(storeID roachpb.StoreID) (bool, error) {
+			if storeID == 4 {
+				return false, errors.Errorf("store %d was not found", storeID)
+			}
+			for _, id := range draining {
+				if id == storeID {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+	}
+
+	tests := []struct {
+		name       string
+		candidates []roachpb.ReplicaDescriptor
+		draining   []roachpb.StoreID
+		exp        []roachpb.ReplicaDescriptor
+	}{
+		{
+			name:       "none draining",
+			candidates: mkReplicas(1, 2, 3),
+			exp:        mkReplicas(1, 2, 3),
+		},
+		{
+			name:       "some draining",
+			candidates: mkReplicas(1, 2, 3),
+			draining:   []roachpb.StoreID{2},
+			exp:        mkReplicas(1, 3),
+		},
+		{
+			name:       "all draining",
+			candidates: mkReplicas(1, 2),
+			draining:   []roachpb.StoreID{1, 2},
+			exp:        mkReplicas(1, 2),
+		},
+		{
+			name:       "unknown store is kept",
+			candidates: mkReplicas(1, 4),
+			draining:   []roachpb.StoreID{1},
+			exp:        mkReplicas(4),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, excludeDrainingSnapshotSenders(tc.candidates, mkIsDraining(tc.draining...)))
+		})
+	}
+}
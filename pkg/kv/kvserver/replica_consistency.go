@@ -96,30 +96,7 @@ func (r *Replica) checkConsistencyImpl(
 	}
 
 	res := kvpb.CheckConsistencyResponse_Result{RangeID: r.RangeID}
-
-	shaToIdxs := map[string][]int{}
-	var missing []ConsistencyCheckResult
-	for i, result := range results {
-		if result.Err != nil {
-			missing = append(missing, result)
-			continue
-		}
-		s := string(result.Response.Checksum)
-		shaToIdxs[s] = append(shaToIdxs[s], i)
-	}
-
-	// When replicas diverge, anecdotally often the minority (usually of size
-	// one) is in the wrong. If there's more than one smallest minority (for
-	// example, if three replicas all return different hashes) we pick any of
-	// them.
-	var minoritySHA string
-	if len(shaToIdxs) > 1 {
-		for sha, idxs := range shaToIdxs {
-			if minoritySHA == "" || len(shaToIdxs[minoritySHA]) > len(idxs) {
-				minoritySHA = sha
-			}
-		}
-	}
+	shaToIdxs, missing, minoritySHA := groupConsistencyCheckResults(results)
 
 	// There is an inconsistency if and only if there is a minority SHA.
 
@@ -167,33 +144,14 @@ func (r *Replica) checkConsistencyImpl(
 	//
 	// TODO(pavelkalinnikov): Compare deltas to assert this assumption anyway.
 	delta := enginepb.MVCCStats(results[0].Response.Delta)
-	var haveDelta bool
-	{
-		d2 := delta
-		d2.AgeTo(0)
-		haveDelta = d2 != enginepb.MVCCStats{}
-	}
+	haveDelta := haveStatsDelta(delta)
 
 	res.StartKey = []byte(args.Key)
-	res.Status = kvpb.CheckConsistencyResponse_RANGE_CONSISTENT
-	if minoritySHA != "" {
-		res.Status = kvpb.CheckConsistencyResponse_RANGE_INCONSISTENT
-	} else if args.Mode != kvpb.ChecksumMode_CHECK_STATS && haveDelta {
-		if delta.ContainsEstimates > 0 {
-			// When ContainsEstimates is set, it's generally expected that we'll get a different
-			// result when we recompute from scratch.
-			res.Status = kvpb.CheckConsistencyResponse_RANGE_CONSISTENT_STATS_ESTIMATED
-		} else {
-			// When ContainsEstimates is unset, we expect the recomputation to agree with the stored stats.
-			// If that's not the case, that's a problem: it could be a bug in the stats computation
-			// or stats maintenance, but it could also hint at the replica having diverged from its peers.
-			res.Status = kvpb.CheckConsistencyResponse_RANGE_CONSISTENT_STATS_INCORRECT
-		}
+	res.Status = consistencyCheckStatus(args.Mode, minoritySHA != "", len(missing) > 0, delta)
+	if res.Status == kvpb.CheckConsistencyResponse_RANGE_CONSISTENT_STATS_ESTIMATED ||
+		res.Status == kvpb.CheckConsistencyResponse_RANGE_CONSISTENT_STATS_INCORRECT {
 		res.Detail += fmt.Sprintf("delta (stats-computed): %+v\n",
 			enginepb.MVCCStats(results[0].Response.Delta))
-	} else if len(missing) > 0 {
-		// No inconsistency was detected, but we didn't manage to inspect all replicas.
-		res.Status = kvpb.CheckConsistencyResponse_RANGE_INDETERMINATE
 	}
 	var resp kvpb.CheckConsistencyResponse
 	resp.Result = append(resp.Result, res)
@@ -235,7 +193,8 @@ func (r *Replica) checkConsistencyImpl(
 	if args.Checkpoint {
 		// A checkpoint/termination request has already been sent. Return because
 		// all the code below will do is request another consistency check, with
-		// instructions to make a checkpoint and to terminate the minority nodes.
+		// instructions to make a checkpoint and to terminate or rebuild the
+		// minority.
 		log.Errorf(ctx, "consistency check failed")
 		return resp, nil
 	}
@@ -244,18 +203,42 @@ func (r *Replica) checkConsistencyImpl(
 	// checkpoints and termination of suspicious nodes. Note that this recursive
 	// call will be terminated in the `args.Checkpoint` branch above.
 	args.Checkpoint = true
+	var divergent []roachpb.ReplicaDescriptor
 	for _, idxs := range shaToIdxs[minoritySHA] {
-		args.Terminate = append(args.Terminate, results[idxs].Replica)
+		divergent = append(divergent, results[idxs].Replica)
+	}
+	// If enabled, and a quorum of consistent voters is left to rebuild them
+	// from, the suspicious replicas are rebuilt instead of terminating their
+	// nodes.
+	var rebuild bool
+	if consistencyCheckRebuildDivergentReplicas.Get(&r.ClusterSettings().SV) {
+		if err := checkDivergentReplicasRebuildable(
+			r.Desc(), divergent, len(shaToIdxs), len(missing),
+		); err != nil {
+			log.Errorf(ctx, "unable to rebuild divergent replicas: %v", err)
+		} else if !r.rebuildingDivergentReplicas.Get() {
+			rebuild = true
+		} else {
+			// The replicas found to diverge by a previous check are being
+			// rebuilt, and are expected to be found again until they are.
+			log.Warningf(ctx, "consistency check failed while rebuilding divergent replicas")
+			return resp, nil
+		}
 	}
-	// args.Terminate is a slice of properly redactable values, but
+	if !rebuild {
+		args.Terminate = divergent
+	}
+	// divergent is a slice of properly redactable values, but
 	// with %v `redact` will not realize that and will redact the
 	// whole thing. Wrap it as a ReplicaSet which is a SafeFormatter
 	// and will get the job done.
 	//
 	// TODO(knz): clean up after https://github.com/cockroachdb/redact/issues/5.
-	{
-		var tmp redact.SafeFormatter = roachpb.MakeReplicaSet(args.Terminate)
-		log.Errorf(ctx, "consistency check failed; fetching details and shutting down minority %v", tmp)
+	var divergentSet redact.SafeFormatter = roachpb.MakeReplicaSet(divergent)
+	if rebuild {
+		log.Errorf(ctx, "consistency check failed; fetching details and rebuilding minority %v", divergentSet)
+	} else {
+		log.Errorf(ctx, "consistency check failed; fetching details and shutting down minority %v", divergentSet)
 	}
 
 	// We've noticed in practice that if the snapshot diff is large, the
@@ -271,9 +254,184 @@ func (r *Replica) checkConsistencyImpl(
 		log.Errorf(ctx, "replica inconsistency detected; second round failed: %s", pErr)
 	}
 
+	if rebuild {
+		// The checkpoints taken by the second round are retained for analysis.
+		r.rebuildDivergentReplicasAsync(ctx, args, divergent)
+	}
+
 	return resp, nil
 }
 
+// groupConsistencyCheckResults groups the successful results of a consistency
+// check by checksum, as indexes into the results, and returns the failed
+// results. If the checksums don't all match, it also returns the checksum of
+// the smallest group, which is suspected to be in the wrong.
+func groupConsistencyCheckResults(
+	results []ConsistencyCheckResult,
+) (shaToIdxs map[string][]int, missing []ConsistencyCheckResult, minoritySHA string) {
+	shaToIdxs = map[string][]int{}
+	for i, result := range results {
+		if result.Err != nil {
+			missing = append(missing, result)
+			continue
+		}
+		s := string(result.Response.Checksum)
+		shaToIdxs[s] = append(shaToIdxs[s], i)
+	}
+
+	// When replicas diverge, anecdotally often the minority (usually of size
+	// one) is in the wrong. If there's more than one smallest minority (for
+	// example, if three replicas all return different hashes) we pick any of
+	// them.
+	if len(shaToIdxs) > 1 {
+		for sha, idxs := range shaToIdxs {
+			if minoritySHA == "" || len(shaToIdxs[minoritySHA]) > len(idxs) {
+				minoritySHA = sha
+			}
+		}
+	}
+	return shaToIdxs, missing, minoritySHA
+}
+
+// haveStatsDelta returns whether the stats recomputed by a consistency check
+// differ from the persisted ones, ignoring their age.
+func haveStatsDelta(delta enginepb.MVCCStats) bool {
+	delta.AgeTo(0)
+	return delta != enginepb.MVCCStats{}
+}
+
+// consistencyCheckStatus returns the status of a consistency check run in the
+// given mode. Both Persisted and Delta stats are computed deterministically
+// from the data fed into the checksum, so if there is no inconsistency, the
+// delta of an arbitrary successful replica is representative of all of them.
+func consistencyCheckStatus(
+	mode kvpb.ChecksumMode, inconsistent, incomplete bool, delta enginepb.MVCCStats,
+) kvpb.CheckConsistencyResponse_Status {
+	if inconsistent {
+		return kvpb.CheckConsistencyResponse_RANGE_INCONSISTENT
+	}
+	if mode != kvpb.ChecksumMode_CHECK_STATS && haveStatsDelta(delta) {
+		if delta.ContainsEstimates > 0 {
+			// When ContainsEstimates is set, it's generally expected that we'll get a different
+			// result when we recompute from scratch.
+			return kvpb.CheckConsistencyResponse_RANGE_CONSISTENT_STATS_ESTIMATED
+		}
+		// When ContainsEstimates is unset, we expect the recomputation to agree with the stored stats.
+		// If that's not the case, that's a problem: it could be a bug in the stats computation
+		// or stats maintenance, but it could also hint at the replica having diverged from its peers.
+		return kvpb.CheckConsistencyResponse_RANGE_CONSISTENT_STATS_INCORRECT
+	}
+	if incomplete {
+		// No inconsistency was detected, but we didn't manage to inspect all replicas.
+		return kvpb.CheckConsistencyResponse_RANGE_INDETERMINATE
+	}
+	return kvpb.CheckConsistencyResponse_RANGE_CONSISTENT
+}
+
+// ConsistencyReport is the structured outcome of an on-demand consistency
+// check of a range. See Replica.CheckConsistencyReport.
+type ConsistencyReport struct {
+	RangeID roachpb.RangeID
+	Status  kvpb.CheckConsistencyResponse_Status
+	// Replicas holds the result of every replica of the range.
+	Replicas []ConsistencyReportReplica
+	// KeyDiffSkipped is set if the range was too large for the KVs of its
+	// replicas to be collected, in which case no KeyDiff is reported.
+	KeyDiffSkipped bool
+}
+
+// ConsistencyReportReplica is the result of a replica in a ConsistencyReport.
+type ConsistencyReportReplica struct {
+	Replica  roachpb.ReplicaDescriptor
+	Checksum []byte
+	// Minority is set if the checksum of the replica is the one suspected to be
+	// in the wrong.
+	Minority bool
+	// Persisted are the persisted stats of the replica.
+	Persisted enginepb.MVCCStats
+	// Delta is the difference between the persisted stats and the ones
+	// recomputed from the data of the replica.
+	Delta enginepb.MVCCStats
+	// Diff is the difference between the persisted stats of the replica and
+	// those of the replicas in the majority. It's only set for replicas in the
+	// minority.
+	Diff enginepb.MVCCStats
+	// KeyDiff is the KVs which differ between the replica and a replica in the
+	// majority. It's only set for replicas in the minority.
+	KeyDiff []ReplicaSnapshotDiff
+	// Err is set if the checksum of the replica couldn't be collected.
+	Err error
+}
+
+// consistencyReportMaxSnapshotBytes is the size of the largest range whose
+// replicas collect their KVs in a CheckConsistencyReport, for the KVs of
+// diverging replicas to be compared. The KVs of all the replicas are held in
+// memory by the node running the check.
+const consistencyReportMaxSnapshotBytes = 32 << 20 // 32 MiB
+
+// CheckConsistencyReport runs a full consistency check of the range, and
+// returns a structured report of the results of its replicas, including the
+// KVs on which the replicas in the minority differ from the majority if the
+// range is small enough. Unlike checks run by the consistency queue, an
+// inconsistency neither terminates the replicas in the minority nor is logged,
+// and incorrect stats aren't recomputed.
+func (r *Replica) CheckConsistencyReport(ctx context.Context) (ConsistencyReport, error) {
+	ms := r.GetMVCCStats()
+	snapshot := ms.Total() <= consistencyReportMaxSnapshotBytes
+	results, err := r.runConsistencyCheck(ctx, kvpb.ComputeChecksumRequest{
+		RequestHeader: kvpb.RequestHeader{Key: r.Desc().StartKey.AsRawKey()},
+		Version:       batcheval.ReplicaChecksumVersion,
+		Mode:          kvpb.ChecksumMode_CHECK_FULL,
+		Snapshot:      snapshot,
+	})
+	if err != nil {
+		return ConsistencyReport{}, err
+	}
+	report := makeConsistencyReport(r.RangeID, results)
+	report.KeyDiffSkipped = !snapshot
+	return report, nil
+}
+
+// makeConsistencyReport assembles the report of a full consistency check from
+// the results of the replicas, the first of which is successful.
+func makeConsistencyReport(
+	rangeID roachpb.RangeID, results []ConsistencyCheckResult,
+) ConsistencyReport {
+	shaToIdxs, missing, minoritySHA := groupConsistencyCheckResults(results)
+	report := ConsistencyReport{
+		RangeID: rangeID,
+		Status: consistencyCheckStatus(kvpb.ChecksumMode_CHECK_FULL, minoritySHA != "",
+			len(missing) > 0, enginepb.MVCCStats(results[0].Response.Delta)),
+	}
+	// The majority is the largest group of replicas outside of the minority.
+	var majority *CollectChecksumResponse
+	var majoritySize int
+	for sha, idxs := range shaToIdxs {
+		if sha != minoritySHA && len(idxs) > majoritySize {
+			majority, majoritySize = &results[idxs[0]].Response, len(idxs)
+		}
+	}
+	for _, result := range results {
+		replica := ConsistencyReportReplica{
+			Replica: result.Replica,
+			Err:     result.Err,
+		}
+		if result.Err == nil {
+			replica.Checksum = result.Response.Checksum
+			replica.Persisted = result.Response.Persisted
+			replica.Delta = enginepb.MVCCStats(result.Response.Delta)
+			replica.Minority = minoritySHA != "" && string(result.Response.Checksum) == minoritySHA
+		}
+		if replica.Minority && majority != nil {
+			replica.Diff = replica.Persisted
+			replica.Diff.Subtract(majority.Persisted)
+			replica.KeyDiff = diffRange(majority.Snapshot, result.Response.Snapshot)
+		}
+		report.Replicas = append(report.Replicas, replica)
+	}
+	return report
+}
+
 // A ConsistencyCheckResult contains the outcome of a CollectChecksum call.
 type ConsistencyCheckResult struct {
 	Replica  roachpb.ReplicaDescriptor
@@ -465,6 +623,7 @@ func (*Replica) computeChecksumDone(rc *replicaChecksum, result *ReplicaDigest)
 		delta.Subtract(result.RecomputedMS)
 		c.Delta = enginepb.MVCCStatsDelta(delta)
 		c.Persisted = result.PersistedMS
+		c.Snapshot = result.Snapshot
 	}
 
 	// Sending succeeds because the channel is buffered, and there is at most one
@@ -479,6 +638,8 @@ type ReplicaDigest struct {
 	SHA512       [sha512.Size]byte
 	PersistedMS  enginepb.MVCCStats
 	RecomputedMS enginepb.MVCCStats
+	// Snapshot holds the replicated KVs, if requested.
+	Snapshot *ReplicaSnapshotData
 }
 
 // CalcReplicaDigest computes the SHA512 hash and MVCC stats of the replica data
@@ -491,8 +652,27 @@ func CalcReplicaDigest(
 	mode kvpb.ChecksumMode,
 	limiter *quotapool.RateLimiter,
 	settings *cluster.Settings,
+) (*ReplicaDigest, error) {
+	return calcReplicaDigest(ctx, desc, snap, mode, limiter, settings, false /* collectSnapshot */)
+}
+
+// calcReplicaDigest is CalcReplicaDigest, additionally collecting the
+// replicated KVs into the Snapshot of the digest if requested and the mode
+// considers the full replicated state.
+func calcReplicaDigest(
+	ctx context.Context,
+	desc roachpb.RangeDescriptor,
+	snap storage.Reader,
+	mode kvpb.ChecksumMode,
+	limiter *quotapool.RateLimiter,
+	settings *cluster.Settings,
+	collectSnapshot bool,
 ) (*ReplicaDigest, error) {
 	statsOnly := mode == kvpb.ChecksumMode_CHECK_STATS
+	var snapshot *ReplicaSnapshotData
+	if collectSnapshot && !statsOnly {
+		snapshot = &ReplicaSnapshotData{}
+	}
 
 	// Iterate over all the data in the range.
 	var intBuf [8]byte
@@ -568,6 +748,13 @@ func CalcReplicaDigest(
 		if _, err := hasher.Write(timestampBuf); err != nil {
 			return err
 		}
+		if snapshot != nil {
+			snapshot.KVs = append(snapshot.KVs, ReplicaSnapshotData_KeyValue{
+				Key:       unsafeKey.Key.Clone(),
+				Timestamp: unsafeKey.Timestamp,
+				Value:     append([]byte(nil), unsafeValue...),
+			})
+		}
 		// Encode the value.
 		_, err := hasher.Write(unsafeValue)
 		return err
@@ -612,6 +799,14 @@ func CalcReplicaDigest(
 		if _, err := hasher.Write(timestampBuf); err != nil {
 			return err
 		}
+		if snapshot != nil {
+			snapshot.RangeKVs = append(snapshot.RangeKVs, ReplicaSnapshotData_RangeKeyValue{
+				StartKey:  rangeKV.RangeKey.StartKey.Clone(),
+				EndKey:    rangeKV.RangeKey.EndKey.Clone(),
+				Timestamp: rangeKV.RangeKey.Timestamp,
+				Value:     append([]byte(nil), rangeKV.Value...),
+			})
+		}
 		// Encode the value.
 		_, err = hasher.Write(rangeKV.Value)
 		return err
@@ -651,6 +846,13 @@ func CalcReplicaDigest(
 		if _, err := hasher.Write(uuidBuf[:]); err != nil {
 			return err
 		}
+		if snapshot != nil {
+			engineKey, _ := unsafeKey.ToEngineKey(nil)
+			snapshot.KVs = append(snapshot.KVs, ReplicaSnapshotData_KeyValue{
+				Key:   engineKey.Encode(),
+				Value: append([]byte(nil), unsafeValue...),
+			})
+		}
 		// Encode the value.
 		_, err := hasher.Write(unsafeValue)
 		return err
@@ -658,7 +860,7 @@ func CalcReplicaDigest(
 
 	// In statsOnly mode, we hash only the RangeAppliedState. In regular mode, hash
 	// all of the replicated key space.
-	var result ReplicaDigest
+	result := ReplicaDigest{Snapshot: snapshot}
 	if !statsOnly {
 		ms, err := rditer.ComputeStatsForRangeWithVisitors(
 			ctx, &desc, snap, 0 /* nowNanos */, visitors)
@@ -791,7 +993,8 @@ func (r *Replica) computeChecksumPostApply(
 		); err != nil {
 			log.Errorf(ctx, "checksum collection did not join: %v", err)
 		} else {
-			result, err := CalcReplicaDigest(ctx, desc, snap, cc.Mode, r.store.consistencyLimiter, r.ClusterSettings())
+			result, err := calcReplicaDigest(ctx, desc, snap, cc.Mode, r.store.consistencyLimiter,
+				r.ClusterSettings(), cc.Snapshot)
 			if err != nil {
 				log.Errorf(ctx, "checksum computation failed: %v", err)
 				result = nil
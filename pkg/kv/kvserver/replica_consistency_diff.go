@@ -0,0 +1,91 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+)
+
+// ReplicaSnapshotDiff is a KV found on only one of two replicas, or with a
+// different value on each of them.
+type ReplicaSnapshotDiff struct {
+	// Majority is set if the KV is the one of the replica in the majority, and
+	// unset if it's the one of the replica in the minority.
+	Majority bool
+	Key      roachpb.Key
+	// EndKey is only set for MVCC range keys.
+	EndKey    roachpb.Key
+	Timestamp hlc.Timestamp
+	Value     []byte
+}
+
+// diffRange returns the KVs which differ between the snapshots of a replica in
+// the majority and a replica in the minority, ordered by key. A KV with a
+// different value on each replica yields both, the one of the majority first.
+func diffRange(majority, minority *ReplicaSnapshotData) []ReplicaSnapshotDiff {
+	if majority == nil || minority == nil {
+		return nil
+	}
+	type diffKey struct {
+		key, endKey string
+		ts          hlc.Timestamp
+	}
+	index := func(data *ReplicaSnapshotData, isMajority bool) map[diffKey]ReplicaSnapshotDiff {
+		m := make(map[diffKey]ReplicaSnapshotDiff, len(data.KVs)+len(data.RangeKVs))
+		for _, kv := range data.KVs {
+			m[diffKey{key: string(kv.Key), ts: kv.Timestamp}] = ReplicaSnapshotDiff{
+				Majority: isMajority, Key: kv.Key, Timestamp: kv.Timestamp, Value: kv.Value,
+			}
+		}
+		for _, kv := range data.RangeKVs {
+			m[diffKey{key: string(kv.StartKey), endKey: string(kv.EndKey), ts: kv.Timestamp}] =
+				ReplicaSnapshotDiff{
+					Majority: isMajority, Key: kv.StartKey, EndKey: kv.EndKey, Timestamp: kv.Timestamp,
+					Value: kv.Value,
+				}
+		}
+		return m
+	}
+	majKVs, minKVs := index(majority, true), index(minority, false)
+
+	var diff []ReplicaSnapshotDiff
+	for k, d := range majKVs {
+		if o, ok := minKVs[k]; !ok {
+			diff = append(diff, d)
+		} else if !bytes.Equal(o.Value, d.Value) {
+			diff = append(diff, d, o)
+		}
+	}
+	for k, d := range minKVs {
+		if _, ok := majKVs[k]; !ok {
+			diff = append(diff, d)
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool {
+		a, b := diff[i], diff[j]
+		if c := a.Key.Compare(b.Key); c != 0 {
+			return c < 0
+		}
+		if c := a.EndKey.Compare(b.EndKey); c != 0 {
+			return c < 0
+		}
+		if a.Timestamp != b.Timestamp {
+			// The newest versions first, as in the MVCC key order.
+			return b.Timestamp.Less(a.Timestamp)
+		}
+		return a.Majority && !b.Majority
+	})
+	return diff
+}
@@ -149,6 +149,9 @@ func (r *Replica) destroyRaftMuLocked(ctx context.Context, nextReplicaID roachpb
 	if err := r.postDestroyRaftMuLocked(ctx, ms); err != nil {
 		return err
 	}
+	if inited {
+		r.store.hintCompaction(ctx, desc.KeySpan().AsRawSpanWithNoLocals(), ms.Total())
+	}
 	if r.IsInitialized() {
 		log.Infof(ctx, "removed %d (%d+%d) keys in %0.0fms [clear=%0.0fms commit=%0.0fms]",
 			ms.KeyCount+ms.SysCount, ms.KeyCount, ms.SysCount,
@@ -191,4 +194,5 @@ func (r *Replica) disconnectReplicationRaftMuLocked(ctx context.Context) {
 		log.Fatalf(ctx, "removing raft group before destroying replica %s", r)
 	}
 	r.mu.internalRaftGroup = nil
+	r.mu.hibernated = false
 }
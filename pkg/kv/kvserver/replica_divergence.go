@@ -0,0 +1,221 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+var consistencyCheckRebuildDivergentReplicas = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"server.consistency_check.rebuild_divergent_replicas.enabled",
+	"if enabled, the replicas which the consistency checker finds to diverge from a quorum "+
+		"of consistent voters are removed and re-added via snapshot, instead of terminating "+
+		"the nodes hosting them; the checkpoints of the inconsistent range are retained",
+	false,
+)
+
+// checkDivergentReplicasRebuildable returns an error unless the divergent
+// replicas of the range can safely be rebuilt from the other replicas. This
+// requires the other replicas to agree on a single checksum, and to include a
+// quorum of the voters.
+//
+// numChecksums is the number of distinct checksums found by the consistency
+// check, and numMissing the number of replicas whose checksum couldn't be
+// collected.
+func checkDivergentReplicasRebuildable(
+	desc *roachpb.RangeDescriptor,
+	divergent []roachpb.ReplicaDescriptor,
+	numChecksums, numMissing int,
+) error {
+	if numMissing > 0 {
+		return errors.Errorf("the checksums of %d replicas couldn't be collected", numMissing)
+	}
+	if numChecksums != 2 {
+		return errors.Errorf("the replicas have %d distinct checksums, no majority agrees", numChecksums)
+	}
+	if desc.Replicas().InAtomicReplicationChange() {
+		return errors.Errorf("the range is in a joint configuration")
+	}
+	voters := desc.Replicas().VoterDescriptors()
+	consistentVoters := len(voters)
+	for _, d := range divergent {
+		rd, ok := desc.GetReplicaDescriptorByID(d.ReplicaID)
+		if !ok {
+			return errors.Errorf("divergent replica %s is no longer in the range descriptor", d)
+		}
+		if rd.IsVoterNewConfig() {
+			consistentVoters--
+		}
+	}
+	if 2*consistentVoters <= len(voters) {
+		return errors.Errorf("only %d of %d voters are consistent", consistentVoters, len(voters))
+	}
+	return nil
+}
+
+// rebuildDivergentReplicasAsync rebuilds the divergent replicas of the range
+// in an async task, so that the consistency queue isn't held up by the lease
+// transfer and snapshots. If the rebuild fails, the nodes of the divergent
+// replicas which are still part of the range are terminated, using the args
+// of the consistency check which found them.
+func (r *Replica) rebuildDivergentReplicasAsync(
+	ctx context.Context, args kvpb.ComputeChecksumRequest, divergent []roachpb.ReplicaDescriptor,
+) {
+	if r.rebuildingDivergentReplicas.Swap(true) {
+		log.Warningf(ctx, "divergent replicas are already being rebuilt")
+		return
+	}
+	knobs := r.store.cfg.TestingKnobs.ConsistencyTestingKnobs
+	taskCtx := r.AnnotateCtx(context.Background())
+	if err := r.store.stopper.RunAsyncTask(taskCtx, "rebuild-divergent", func(ctx context.Context) {
+		defer r.rebuildingDivergentReplicas.Set(false)
+		ctx, cancel := r.store.stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+
+		divergentSet := roachpb.MakeReplicaSet(divergent)
+		err := r.rebuildDivergentReplicas(ctx, divergent)
+		if err == nil {
+			log.Warningf(ctx, "rebuilt minority %v from a snapshot of the majority", divergentSet)
+		} else if ctx.Err() == nil {
+			// Fall back to terminating the nodes of the divergent replicas, as if
+			// rebuilding them was disabled. Some of them may have been rebuilt, or
+			// removed from the range in the meantime, so the descriptor is read
+			// again to only terminate those still part of it.
+			log.Errorf(ctx, "failed to rebuild minority %v, shutting it down: %v", divergentSet, err)
+			remaining, descErr := r.remainingDivergentReplicas(ctx, divergent)
+			if descErr != nil {
+				log.Errorf(ctx, "unable to shut down minority %v: %v", divergentSet, descErr)
+			} else if len(remaining) > 0 {
+				args.Terminate = remaining
+				if _, pErr := r.checkConsistencyImpl(ctx, args); pErr != nil {
+					log.Errorf(ctx, "replica inconsistency detected; termination round failed: %s", pErr)
+				}
+			}
+		}
+		if fn := knobs.AfterRebuildDivergentReplicas; fn != nil {
+			fn(err)
+		}
+	}); err != nil {
+		r.rebuildingDivergentReplicas.Set(false)
+		log.Errorf(ctx, "unable to rebuild divergent replicas: %v", err)
+	}
+}
+
+// remainingDivergentReplicas returns the divergent replicas which are still
+// part of the range, according to its descriptor read from KV.
+func (r *Replica) remainingDivergentReplicas(
+	ctx context.Context, divergent []roachpb.ReplicaDescriptor,
+) ([]roachpb.ReplicaDescriptor, error) {
+	descKey := keys.RangeDescriptorKey(r.Desc().StartKey)
+	var desc roachpb.RangeDescriptor
+	if err := r.store.DB().GetProto(ctx, descKey, &desc); err != nil {
+		return nil, err
+	}
+	var remaining []roachpb.ReplicaDescriptor
+	for _, d := range divergent {
+		if _, ok := desc.GetReplicaDescriptorByID(d.ReplicaID); ok {
+			remaining = append(remaining, d)
+		}
+	}
+	return remaining, nil
+}
+
+// rebuildDivergentReplicas removes the divergent replicas of the range, and
+// then re-adds them on the same stores, which receive a snapshot of the data of
+// the consistent replicas. If the leaseholder is divergent, the lease is first
+// transferred to a consistent voter, so that it doesn't send the snapshots.
+// The divergent replicas which are no longer part of the range are skipped.
+func (r *Replica) rebuildDivergentReplicas(
+	ctx context.Context, divergent []roachpb.ReplicaDescriptor,
+) error {
+	knobs := r.store.cfg.TestingKnobs.ConsistencyTestingKnobs
+	if fn := knobs.BeforeRebuildDivergentReplicas; fn != nil {
+		if err := fn(divergent); err != nil {
+			return err
+		}
+	}
+	startKey := r.Desc().StartKey
+	key := startKey.AsRawKey()
+	divergentStores := map[roachpb.StoreID]struct{}{}
+	for _, d := range divergent {
+		divergentStores[d.StoreID] = struct{}{}
+	}
+	if _, ok := divergentStores[r.StoreID()]; ok {
+		var target roachpb.StoreID
+		for _, v := range r.Desc().Replicas().VoterDescriptors() {
+			if _, ok := divergentStores[v.StoreID]; !ok {
+				target = v.StoreID
+				break
+			}
+		}
+		if target == 0 {
+			return errors.New("no consistent voter to transfer the lease to")
+		}
+		if err := r.store.DB().AdminTransferLease(ctx, key, target); err != nil {
+			return errors.Wrapf(err, "transferring the lease to s%d", target)
+		}
+	}
+
+	// Remove every divergent replica before re-adding any of them. Otherwise,
+	// a divergent replica still in the range could be picked as the delegate
+	// sending the snapshot to a re-added one, seeding it with divergent data.
+	var removed []roachpb.ReplicaDescriptor
+	for _, d := range divergent {
+		var desc roachpb.RangeDescriptor
+		if err := r.store.DB().GetProto(ctx, keys.RangeDescriptorKey(startKey), &desc); err != nil {
+			return err
+		}
+		if _, ok := desc.GetReplicaDescriptorByID(d.ReplicaID); !ok {
+			continue
+		}
+		removeType := roachpb.REMOVE_VOTER
+		if d.Type == roachpb.NON_VOTER {
+			removeType = roachpb.REMOVE_NON_VOTER
+		}
+		target := roachpb.ReplicationTarget{NodeID: d.NodeID, StoreID: d.StoreID}
+		if _, err := r.store.DB().AdminChangeReplicas(
+			ctx, key, desc, kvpb.MakeReplicationChanges(removeType, target),
+		); err != nil {
+			return errors.Wrapf(err, "removing divergent replica %s", d)
+		}
+		removed = append(removed, d)
+	}
+
+	// The stale replicas left on the stores, if they weren't garbage collected
+	// yet, are destroyed when the stores receive the snapshots of the new ones.
+	// If a re-addition fails, the range is left under-replicated, which the
+	// replicate queue repairs.
+	for _, d := range removed {
+		addType := roachpb.ADD_VOTER
+		if d.Type == roachpb.NON_VOTER {
+			addType = roachpb.ADD_NON_VOTER
+		}
+		target := roachpb.ReplicationTarget{NodeID: d.NodeID, StoreID: d.StoreID}
+		var desc roachpb.RangeDescriptor
+		if err := r.store.DB().GetProto(ctx, keys.RangeDescriptorKey(startKey), &desc); err != nil {
+			return err
+		}
+		if _, err := r.store.DB().AdminChangeReplicas(
+			ctx, key, desc, kvpb.MakeReplicationChanges(addType, target),
+		); err != nil {
+			return errors.Wrapf(err, "re-adding divergent replica %s", d)
+		}
+	}
+	return nil
+}
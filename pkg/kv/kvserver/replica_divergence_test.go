@@ -0,0 +1,63 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDivergentReplicasRebuildable(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := &roachpb.RangeDescriptor{RangeID: 1}
+	for i := 1; i <= 5; i++ {
+		typ := roachpb.VOTER_FULL
+		if i > 3 {
+			typ = roachpb.NON_VOTER
+		}
+		desc.AddReplica(roachpb.NodeID(i), roachpb.StoreID(i), typ)
+	}
+	replica := func(id roachpb.ReplicaID) roachpb.ReplicaDescriptor {
+		rd, ok := desc.GetReplicaDescriptorByID(id)
+		require.True(t, ok)
+		return rd
+	}
+
+	// A divergent voter or non-voters can be rebuilt from the other voters.
+	require.NoError(t, checkDivergentReplicasRebuildable(
+		desc, []roachpb.ReplicaDescriptor{replica(1)}, 2 /* numChecksums */, 0 /* numMissing */))
+	require.NoError(t, checkDivergentReplicasRebuildable(
+		desc, []roachpb.ReplicaDescriptor{replica(4), replica(5)}, 2, 0))
+
+	// Two of three voters don't leave a quorum of consistent voters, even if
+	// they're a minority of the replicas.
+	require.ErrorContains(t, checkDivergentReplicasRebuildable(
+		desc, []roachpb.ReplicaDescriptor{replica(1), replica(2)}, 2, 0),
+		"only 1 of 3 voters are consistent")
+
+	// The other replicas must agree, and all replicas must have responded.
+	require.ErrorContains(t, checkDivergentReplicasRebuildable(
+		desc, []roachpb.ReplicaDescriptor{replica(1)}, 3, 0), "3 distinct checksums")
+	require.ErrorContains(t, checkDivergentReplicasRebuildable(
+		desc, []roachpb.ReplicaDescriptor{replica(1)}, 2, 1), "couldn't be collected")
+
+	// The replica must still be part of the range.
+	gone := replica(1)
+	gone.ReplicaID = 10
+	require.ErrorContains(t, checkDivergentReplicasRebuildable(
+		desc, []roachpb.ReplicaDescriptor{gone}, 2, 0), "no longer in the range descriptor")
+}
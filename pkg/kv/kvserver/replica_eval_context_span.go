@@ -277,6 +277,13 @@ func (rec *SpanSetReplicaEvalContext) GetApproximateDiskBytes(
 	return rec.i.GetApproximateDiskBytes(from, to)
 }
 
+// HasIngestedSSTable implements the batcheval.EvalContext interface.
+func (rec *SpanSetReplicaEvalContext) HasIngestedSSTable(
+	span roachpb.Span, ts hlc.Timestamp, contentHash []byte,
+) bool {
+	return rec.i.HasIngestedSSTable(span, ts, contentHash)
+}
+
 // AdmissionHeader implements the batcheval.EvalContext interface.
 func (rec *SpanSetReplicaEvalContext) AdmissionHeader() kvpb.AdmissionHeader {
 	return rec.i.AdmissionHeader()
@@ -235,6 +235,12 @@ func evaluateBatch(
 	// retries.
 	var deferredWriteTooOldErr *kvpb.WriteTooOldError
 
+	// Only collect the commit latency breakdown if it was requested.
+	var commitLatency *kvpb.CommitLatencyBreakdown
+	if ba.ReturnCommitLatencyBreakdown {
+		commitLatency = br.EnsureCommitLatencyBreakdown()
+	}
+
 	// Only collect the scan stats if the tracing is enabled.
 	var ss *kvpb.ScanStats
 	if sp := tracing.SpanFromContext(ctx); sp.RecordingType() != tracingpb.RecordingOff {
@@ -303,7 +309,8 @@ func evaluateBatch(
 		// may carry a response transaction and in the case of WriteTooOldError
 		// (which is sometimes deferred) it is fully populated.
 		curResult, err := evaluateCommand(
-			ctx, readWriter, rec, ms, ss, baHeader, args, reply, g, st, ui, evalPath, omitInRangefeeds,
+			ctx, readWriter, rec, ms, ss, commitLatency, baHeader, args, reply, g, st, ui, evalPath,
+			omitInRangefeeds,
 		)
 
 		if filter := rec.EvalKnobs().TestingPostEvalFilter; filter != nil {
@@ -454,6 +461,7 @@ func evaluateCommand(
 	rec batcheval.EvalContext,
 	ms *enginepb.MVCCStats,
 	ss *kvpb.ScanStats,
+	commitLatency *kvpb.CommitLatencyBreakdown,
 	h kvpb.Header,
 	args kvpb.Request,
 	reply kvpb.Response,
@@ -478,6 +486,7 @@ func evaluateCommand(
 			Now:                   now,
 			Stats:                 ms,
 			ScanStats:             ss,
+			CommitLatency:         commitLatency,
 			Concurrency:           g,
 			Uncertainty:           ui,
 			DontInterleaveIntents: evalPath == readOnlyWithoutInterleavedIntents,
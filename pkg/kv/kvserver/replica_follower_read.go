@@ -15,8 +15,11 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/kv/kvbase"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/stateloader"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/buildutil"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/redact"
@@ -34,6 +37,19 @@ var FollowerReadsEnabled = settings.RegisterBoolSetting(
 	settings.WithName("kv.closed_timestamp.follower_reads.enabled"),
 	settings.WithPublic)
 
+// verifyFollowerReadsEnabled controls whether follower reads check, once they
+// have been evaluated, that the closed timestamp of the engine state they were
+// evaluated on covers the read. A failed verification indicates a bug in the
+// closed timestamp machinery; it is logged and counted, but the response is
+// still returned to the client.
+var verifyFollowerReadsEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.closed_timestamp.follower_reads.verification.enabled",
+	"if enabled, follower reads verify at response time that the closed timestamp of the engine "+
+		"state they were evaluated on covers the read",
+	buildutil.CrdbTestBuild,
+)
+
 // BatchCanBeEvaluatedOnFollower determines if a batch consists exclusively of
 // requests that can be evaluated on a follower replica, given a sufficiently
 // advanced closed timestamp.
@@ -129,6 +145,50 @@ func (r *Replica) canServeFollowerReadRLocked(ctx context.Context, ba *kvpb.Batc
 	return true
 }
 
+// followerReadClosedTimestamp returns the closed timestamp of the replica,
+// along with the lease applied index as of which it is closed. It is called
+// once a batch was admitted as a follower read, before the state of the
+// storage engine is pinned for its evaluation, for verifyFollowerRead.
+func (r *Replica) followerReadClosedTimestamp(
+	ctx context.Context, ba *kvpb.BatchRequest,
+) closedTimestamp {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return closedTimestamp{
+		ts:  r.getCurrentClosedTimestampLocked(ctx, ba.RequiredFrontier() /* sufficient */),
+		lai: r.mu.state.LeaseAppliedIndex,
+	}
+}
+
+// verifyFollowerRead verifies that the engine state a follower read was
+// evaluated on covers the batch's required frontier. The closed timestamp of
+// that state is the raft closed timestamp it applied, forwarded by the given
+// closed timestamp if the state applied the lease applied index it is closed
+// as of. The reader is the storage engine snapshot the batch was evaluated on.
+// A failure here means that the read may have missed writes and points at a
+// bug in the closed timestamp plumbing, or in the pinning of the engine state.
+func (r *Replica) verifyFollowerRead(
+	ctx context.Context, ba *kvpb.BatchRequest, closed closedTimestamp, reader storage.Reader,
+) {
+	as, err := stateloader.Make(r.RangeID).LoadRangeAppliedState(ctx, reader)
+	if err != nil {
+		log.Warningf(ctx, "unable to verify follower read: %v", err)
+		return
+	}
+	requiredFrontier := ba.RequiredFrontier()
+	evalClosed := as.RaftClosedTimestamp
+	if closed.lai <= as.LeaseAppliedIndex {
+		evalClosed.Forward(closed.ts)
+	}
+	if requiredFrontier.LessEq(evalClosed) {
+		return
+	}
+	r.store.metrics.FollowerReadsVerificationFailures.Inc(1)
+	log.Errorf(ctx, "follower read verification failed; closed timestamp %s of the evaluated "+
+		"state at lease applied index %d below required frontier %s",
+		evalClosed, as.LeaseAppliedIndex, requiredFrontier)
+}
+
 // getCurrentClosedTimestampRLocked is like GetCurrentClosedTimestamp, except
 // that it requires r.mu to be RLocked. It also optionally takes a hint: if
 // sufficient is not empty, getClosedTimestampRLocked might return a timestamp
@@ -20,11 +20,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/closedts"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/isolation"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -191,3 +193,73 @@ func TestCheckExecutionCanProceedAllowsFollowerReadWithInvalidLease(t *testing.T
 	ls = r.CurrentLeaseStatus(ctx)
 	require.False(t, ls.IsValid())
 }
+
+// Test that follower read verification flags reads evaluated on an engine
+// state whose closed timestamp doesn't cover their required frontier, by
+// injecting a regression: evaluating against an engine snapshot taken before
+// the closed timestamp that allowed the read applied.
+func TestVerifyFollowerRead(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	manual := timeutil.NewManualTime(timeutil.Unix(5, 0))
+	clock := hlc.NewClockForTesting(manual)
+	tsc := TestStoreConfig(clock)
+	const closedTimestampLag = time.Second
+	closedts.TargetDuration.Override(ctx, &tsc.Settings.SV, closedTimestampLag)
+
+	tc := testContext{manualClock: manual}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	tc.StartWithStoreConfig(ctx, t, stopper, tsc)
+	r := tc.repl
+	eng := r.store.TODOEngine()
+
+	// The range starts with no closed timestamp, which this snapshot of the
+	// engine predates.
+	staleSnap := eng.NewSnapshot()
+	defer staleSnap.Close()
+
+	// Perform a write in order to close a timestamp.
+	key := roachpb.Key("a")
+	{
+		write := putArgs(key, []byte("foo"))
+		_, pErr := tc.SendWrapped(&write)
+		require.NoError(t, pErr.GoError())
+	}
+
+	nowNs := clock.Now().WallTime
+	tsBelowClosedTimestamp := hlc.Timestamp{
+		WallTime: nowNs - closedTimestampLag.Nanoseconds() - clock.MaxOffset().Nanoseconds(),
+	}
+	gArgs := getArgs(key)
+	ba := &kvpb.BatchRequest{}
+	ba.Header = kvpb.Header{Timestamp: tsBelowClosedTimestamp}
+	ba.Add(&gArgs)
+	// The write may be acknowledged before it applied.
+	var closed closedTimestamp
+	testutils.SucceedsSoon(t, func() error {
+		closed = r.followerReadClosedTimestamp(ctx, ba)
+		if !ba.RequiredFrontier().LessEq(closed.ts) {
+			return errors.Errorf("closed timestamp %s below %s", closed.ts, ba.RequiredFrontier())
+		}
+		return nil
+	})
+
+	failures := r.store.metrics.FollowerReadsVerificationFailures
+	snap := eng.NewSnapshot()
+	defer snap.Close()
+	r.verifyFollowerRead(ctx, ba, closed, snap)
+	require.Zero(t, failures.Count())
+
+	// The stale snapshot applied neither the closed timestamp nor the lease
+	// applied index it is closed as of.
+	r.verifyFollowerRead(ctx, ba, closed, staleSnap)
+	require.Equal(t, int64(1), failures.Count())
+
+	// A read above the closed timestamp isn't covered either.
+	ba.Timestamp = clock.Now()
+	r.verifyFollowerRead(ctx, ba, closed, snap)
+	require.Equal(t, int64(2), failures.Count())
+}
@@ -18,11 +18,15 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/rpc"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/spanconfig"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/log/logcrash"
 	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
 	"go.etcd.io/raft/v3"
 )
@@ -57,6 +61,22 @@ const (
 	replicaGCPriorityRemoved = 2.0
 )
 
+// notifyRemovedReplicasEnabled controls whether the raft leader of a range,
+// upon applying a replication change that removes replicas, notifies the stores
+// of the removed replicas so that they are garbage collected right away instead
+// of waiting for the replica scanner to get to them.
+var notifyRemovedReplicasEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.replica_gc_queue.notify_removed_replicas.enabled",
+	"if enabled, stores of replicas removed by a replication change are notified "+
+		"to garbage collect them immediately",
+	true,
+)
+
+// notifyRemovedReplicaTimeout bounds the duration of a single best-effort
+// EnqueueReplicaGC RPC.
+const notifyRemovedReplicaTimeout = 5 * time.Second
+
 var (
 	metaReplicaGCQueueRemoveReplicaCount = metric.Metadata{
 		Name:        "queue.replicagc.removereplica",
@@ -64,16 +84,24 @@ var (
 		Measurement: "Replica Removals",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaReplicaGCQueueNotifiedCount = metric.Metadata{
+		Name:        "queue.replicagc.notified",
+		Help:        "Number of replicas enqueued into the replica GC queue after their removal was reported by the range's leader",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
 )
 
 // ReplicaGCQueueMetrics is the set of metrics for the replica GC queue.
 type ReplicaGCQueueMetrics struct {
 	RemoveReplicaCount *metric.Counter
+	NotifiedCount      *metric.Counter
 }
 
 func makeReplicaGCQueueMetrics() ReplicaGCQueueMetrics {
 	return ReplicaGCQueueMetrics{
 		RemoveReplicaCount: metric.NewCounter(metaReplicaGCQueueRemoveReplicaCount),
+		NotifiedCount:      metric.NewCounter(metaReplicaGCQueueNotifiedCount),
 	}
 }
 
@@ -391,3 +419,51 @@ func (*replicaGCQueue) purgatoryChan() <-chan time.Time {
 func (*replicaGCQueue) updateChan() <-chan time.Time {
 	return nil
 }
+
+// maybeNotifyRemovedReplicas is called when a replication change that removes
+// replicas is applied. If the local replica is the raft leader, it notifies the
+// stores of the removed replicas (best effort and asynchronously) so that they
+// enqueue them into their replica GC queues. Without this, a removed replica
+// that did not learn about its removal lingers, consuming disk and responding
+// to stray messages, until the replica scanner gets around to it.
+func (r *Replica) maybeNotifyRemovedReplicas(
+	ctx context.Context, removed []roachpb.ReplicaDescriptor,
+) {
+	if len(removed) == 0 || r.store.cfg.NodeDialer == nil ||
+		!notifyRemovedReplicasEnabled.Get(&r.store.ClusterSettings().SV) {
+		return
+	}
+	r.mu.RLock()
+	isLeader := r.isRaftLeaderRLocked()
+	r.mu.RUnlock()
+	if !isLeader {
+		return
+	}
+	for _, rDesc := range removed {
+		if rDesc.StoreID == r.store.StoreID() {
+			continue
+		}
+		rDesc := rDesc // copy for goroutine
+		if err := r.store.stopper.RunAsyncTask(
+			r.AnnotateCtx(context.Background()), "notify-removed-replica",
+			func(ctx context.Context) {
+				if err := timeutil.RunWithTimeout(ctx, "notify removed replica", notifyRemovedReplicaTimeout,
+					func(ctx context.Context) error {
+						conn, err := r.store.cfg.NodeDialer.Dial(ctx, rDesc.NodeID, rpc.DefaultClass)
+						if err != nil {
+							return errors.Wrapf(err, "could not dial n%d", rDesc.NodeID)
+						}
+						_, err = NewPerReplicaClient(conn).EnqueueReplicaGC(ctx, &EnqueueReplicaGCRequest{
+							StoreRequestHeader: StoreRequestHeader{NodeID: rDesc.NodeID, StoreID: rDesc.StoreID},
+							RangeID:            r.RangeID,
+							ReplicaID:          rDesc.ReplicaID,
+						})
+						return err
+					}); err != nil {
+					log.VEventf(ctx, 2, "unable to notify removed replica %s: %v", rDesc, err)
+				}
+			}); err != nil && !errors.Is(err, stop.ErrUnavailable) {
+			log.VEventf(ctx, 2, "unable to notify removed replica %s: %v", rDesc, err)
+		}
+	}
+}
@@ -119,7 +119,7 @@ func (r *Replica) MaybeGossipNodeLivenessRaftMuLocked(
 		key := gossip.MakeNodeLivenessKey(kvLiveness.NodeID)
 		// Look up liveness from gossip; skip gossiping anew if unchanged.
 		if err := r.store.Gossip().GetInfoProto(key, &gossipLiveness); err == nil {
-			if gossipLiveness == kvLiveness && r.store.Gossip().InfoOriginatedHere(key) {
+			if gossipLiveness.Equal(kvLiveness) && r.store.Gossip().InfoOriginatedHere(key) {
 				continue
 			}
 		}
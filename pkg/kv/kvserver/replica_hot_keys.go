@@ -0,0 +1,120 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/load"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/pebble"
+)
+
+// replicaHotKeysEnabled controls whether the replicas sample their most
+// frequently accessed keys.
+var replicaHotKeysEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.replica_hot_keys.enabled",
+	"if enabled, each replica estimates its most frequently read and written keys, "+
+		"reported in crdb_internal.node_hot_keys; this takes ~4 KiB of memory per "+
+		"replica receiving traffic",
+	false,
+)
+
+// hotKeySamplerIfEnabled returns the hot key sampler of the replica, allocating
+// it if needed, or nil if the sampling of hot keys is disabled, in which case
+// the sampler is released.
+func (r *Replica) hotKeySamplerIfEnabled() *load.HotKeySampler {
+	if !replicaHotKeysEnabled.Get(&r.store.cfg.Settings.SV) {
+		if r.hotKeySampler.Load() != nil {
+			r.hotKeySampler.Store(nil)
+		}
+		return nil
+	}
+	if s := r.hotKeySampler.Load(); s != nil {
+		return s
+	}
+	r.hotKeySampler.CompareAndSwap(nil, load.NewHotKeySampler(r.store.Clock()))
+	return r.hotKeySampler.Load()
+}
+
+// recordHotReadKeys records the keys read by the point reads of the batch in
+// the hot key sampler of the replica. The keys read by scans aren't recorded,
+// as their cost is dominated by the scan rather than by any individual key.
+func (r *Replica) recordHotReadKeys(ba *kvpb.BatchRequest) {
+	s := r.hotKeySamplerIfEnabled()
+	if s == nil {
+		return
+	}
+	for _, union := range ba.Requests {
+		h := union.GetInner().Header()
+		if len(h.EndKey) == 0 {
+			s.Record(h.Key)
+		}
+	}
+}
+
+// recordHotWriteKeys records the global keys written by the given write batch
+// in the hot key sampler of the replica.
+func (r *Replica) recordHotWriteKeys(ctx context.Context, repr []byte) {
+	s := r.hotKeySamplerIfEnabled()
+	if s == nil {
+		return
+	}
+	reader, err := storage.NewBatchReader(repr)
+	if err != nil {
+		log.Errorf(ctx, "unable to read committed WriteBatch: %+v", err)
+		return
+	}
+	for reader.Next() {
+		switch reader.KeyKind() {
+		case pebble.InternalKeyKindSet, pebble.InternalKeyKindSetWithDelete,
+			pebble.InternalKeyKindDelete, pebble.InternalKeyKindSingleDelete:
+		default:
+			// Range deletions and range keys don't write individual keys.
+			continue
+		}
+		key, err := reader.MVCCKey()
+		if err != nil {
+			// Lock table keys aren't MVCC keys.
+			continue
+		}
+		if !keys.IsLocal(key.Key) {
+			s.Record(key.Key)
+		}
+	}
+}
+
+// hotKeys returns the hot keys sampled by the replica, from the most to the
+// least accessed.
+func (r *Replica) hotKeys() []kvserverpb.HotKey {
+	s := r.hotKeySampler.Load()
+	if s == nil {
+		return nil
+	}
+	sampled := s.HotKeys()
+	res := make([]kvserverpb.HotKey, len(sampled))
+	for i, hk := range sampled {
+		res[i] = kvserverpb.HotKey{Key: hk.Key, Count: hk.Count}
+	}
+	return res
+}
+
+// resetHotKeys drops the hot keys sampled by the replica, e.g. once its bounds
+// change.
+func (r *Replica) resetHotKeys() {
+	r.hotKeySampler.Store(nil)
+}
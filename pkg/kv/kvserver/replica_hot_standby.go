@@ -0,0 +1,309 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"go.etcd.io/raft/v3"
+)
+
+// hotStandbyPrewarmMaxBytes bounds the amount of data read from each hot
+// standby replica to prewarm the block cache of its store.
+var hotStandbyPrewarmMaxBytes = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.hot_standby.prewarm.max_bytes",
+	"the maximum amount of data read from each hot standby replica to load it into "+
+		"the block cache of its store (0 disables prewarming)",
+	256<<20, // 256 MiB
+)
+
+// hotStandbyPrewarmInterval is the interval at which each store prewarms the
+// block cache with the data of the ranges it is the hot standby of.
+var hotStandbyPrewarmInterval = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.hot_standby.prewarm.interval",
+	"the interval at which each store reads the data of the ranges it is the hot "+
+		"standby of, to keep it in the block cache",
+	5*time.Minute,
+	settings.PositiveDuration,
+)
+
+// HotStandby returns the hot standby designation of the range, set with
+// DB.SetHotStandby, or nil if the range has no hot standby.
+//
+// The hot standby is a voter which is kept ready to take over the lease: its
+// store gives the range priority in the Raft scheduler so that it applies the
+// log with minimal lag, and keeps the data of the range in its block cache.
+// When the lease expires, or is shed by a draining leaseholder, the standby is
+// preferred as the next leaseholder.
+func (r *Replica) HotStandby() *roachpb.HotStandby {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	hs := r.mu.state.HotStandby
+	if hs == nil || hs.StoreID == 0 {
+		return nil
+	}
+	return hs
+}
+
+// hotStandbyReplica returns the replica designated as the hot standby of the
+// range, if the designation is set and the replica on its store is a voter.
+// Designations of stores which no longer hold a voter are ignored until they
+// do again.
+func hotStandbyReplica(
+	hs *roachpb.HotStandby, desc *roachpb.RangeDescriptor,
+) (roachpb.ReplicaDescriptor, bool) {
+	if hs == nil || hs.StoreID == 0 || desc == nil {
+		return roachpb.ReplicaDescriptor{}, false
+	}
+	repl, ok := desc.GetReplicaDescriptor(hs.StoreID)
+	if !ok || !repl.IsVoterNewConfig() {
+		return roachpb.ReplicaDescriptor{}, false
+	}
+	return repl, true
+}
+
+// hotStandbys holds the ranges whose hot standby is on the store.
+type hotStandbys struct {
+	// notify is signaled when a range is queued for prewarming.
+	notify chan struct{}
+	mu     struct {
+		syncutil.Mutex
+		rangeIDs map[roachpb.RangeID]struct{}
+		// prewarm holds the ranges whose standby was moved to the store since
+		// the last prewarm.
+		prewarm []roachpb.RangeID
+	}
+}
+
+func makeHotStandbys() hotStandbys {
+	h := hotStandbys{notify: make(chan struct{}, 1)}
+	h.mu.rangeIDs = map[roachpb.RangeID]struct{}{}
+	return h
+}
+
+// update records whether the store holds the hot standby of the given range,
+// returning true if this changed.
+func (h *hotStandbys) update(rangeID roachpb.RangeID, isStandby bool) (changed bool, n int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, wasStandby := h.mu.rangeIDs[rangeID]
+	if isStandby == wasStandby {
+		return false, len(h.mu.rangeIDs)
+	}
+	if isStandby {
+		h.mu.rangeIDs[rangeID] = struct{}{}
+		h.mu.prewarm = append(h.mu.prewarm, rangeID)
+	} else {
+		delete(h.mu.rangeIDs, rangeID)
+	}
+	return true, len(h.mu.rangeIDs)
+}
+
+// rangeIDsToPrewarm returns the queued ranges, or all the ranges if all is
+// set, and clears the queue.
+func (h *hotStandbys) rangeIDsToPrewarm(all bool) []roachpb.RangeID {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rangeIDs := h.mu.prewarm
+	h.mu.prewarm = nil
+	if all {
+		rangeIDs = rangeIDs[:0]
+		for rangeID := range h.mu.rangeIDs {
+			rangeIDs = append(rangeIDs, rangeID)
+		}
+	}
+	return rangeIDs
+}
+
+// updateHotStandbyLocked updates the Raft scheduler priority of the replica,
+// and the set of hot standbys of the store, after a change to the descriptor
+// or to the hot standby designation of the range. The replica's mutex must be
+// held.
+func (r *Replica) updateHotStandbyLocked(ctx context.Context) {
+	desc := r.mu.state.Desc
+	repl, ok := hotStandbyReplica(r.mu.state.HotStandby, desc)
+	isStandby := ok && repl.StoreID == r.store.StoreID()
+	changed, n := r.store.hotStandbys.update(desc.RangeID, isStandby)
+	if !changed {
+		return
+	}
+	r.store.metrics.HotStandbyReplicas.Update(int64(n))
+	if !isStandby {
+		log.VEventf(ctx, 1, "no longer the hot standby of the range")
+		if !hasSystemRaftPriority(ctx, desc) {
+			r.store.scheduler.RemovePriorityID(desc.RangeID)
+		}
+		return
+	}
+	log.VEventf(ctx, 1, "became the hot standby of the range")
+	r.store.scheduler.AddPriorityID(desc.RangeID)
+	select {
+	case r.store.hotStandbys.notify <- struct{}{}:
+	default:
+	}
+}
+
+// maybeTransferRaftLeadershipToHotStandbyLocked transfers the raft leadership
+// to the hot standby of the range if the lease has expired, e.g. because the
+// leaseholder failed, and this replica is the raft leader but not the standby.
+// Since only the raft leader can acquire an expired lease, the standby then
+// acquires it rather than this replica. Returns true if the leadership is
+// being transferred, in which case this replica must not acquire the lease
+// itself.
+//
+// The leadership is only transferred if the standby is caught up on the log,
+// so that it doesn't delay the failover.
+func (r *Replica) maybeTransferRaftLeadershipToHotStandbyLocked(
+	ctx context.Context, status kvserverpb.LeaseStatus, livenessMap livenesspb.IsLiveMap,
+) bool {
+	if r.store.TestingKnobs().DisableLeaderFollowsLeaseholder {
+		return false
+	}
+	if status.State != kvserverpb.LeaseState_EXPIRED || !r.isRaftLeaderRLocked() {
+		return false
+	}
+	standby, ok := hotStandbyReplica(r.mu.state.HotStandby, r.mu.state.Desc)
+	if !ok || standby.ReplicaID == r.replicaID {
+		return false
+	}
+	if entry, ok := livenessMap[standby.NodeID]; !ok || !entry.IsLive {
+		return false
+	}
+	raftStatus := r.raftSparseStatusRLocked()
+	if raftStatus == nil || raftStatus.RaftState != raft.StateLeader {
+		return false
+	}
+	progress, ok := raftStatus.Progress[uint64(standby.ReplicaID)]
+	if !ok || progress.Match < raftStatus.Commit {
+		return false
+	}
+	log.VEventf(ctx, 1, "transferring raft leadership to hot standby replica ID %v", standby.ReplicaID)
+	r.store.metrics.RangeRaftLeaderTransfers.Inc(1)
+	r.store.metrics.HotStandbyFailovers.Inc(1)
+	r.mu.internalRaftGroup.TransferLeader(uint64(standby.ReplicaID))
+	return true
+}
+
+// hotStandbyLeaseTarget returns the hot standby of the range as the target of
+// a lease transfer away from this replica, if the standby is on another store
+// and its node is live.
+func (r *Replica) hotStandbyLeaseTarget() (roachpb.ReplicaDescriptor, bool) {
+	r.mu.RLock()
+	standby, ok := hotStandbyReplica(r.mu.state.HotStandby, r.mu.state.Desc)
+	r.mu.RUnlock()
+	if !ok || standby.StoreID == r.store.StoreID() {
+		return roachpb.ReplicaDescriptor{}, false
+	}
+	livenessMap, _ := r.store.livenessMap.Load().(livenesspb.IsLiveMap)
+	if entry, ok := livenessMap[standby.NodeID]; !ok || !entry.IsLive || entry.Draining {
+		return roachpb.ReplicaDescriptor{}, false
+	}
+	return standby, true
+}
+
+// startHotStandbyPrewarmer starts a worker which loads the data of the ranges
+// the store is the hot standby of into the block cache: as soon as the store
+// becomes the standby of a range, and periodically afterwards, since the
+// block cache evicts data the store doesn't read.
+func (s *Store) startHotStandbyPrewarmer(ctx context.Context) {
+	_ /* err */ = s.stopper.RunAsyncTaskEx(ctx, stop.TaskOpts{
+		TaskName: "hot-standby-prewarmer",
+		SpanOpt:  stop.SterileRootSpan,
+	}, func(ctx context.Context) {
+		ctx, cancel := s.stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+
+		h := &s.hotStandbys
+		timer := timeutil.NewTimer()
+		defer timer.Stop()
+		timer.Reset(hotStandbyPrewarmInterval.Get(&s.ClusterSettings().SV))
+		for {
+			select {
+			case <-h.notify:
+				s.prewarmHotStandbys(ctx, h.rangeIDsToPrewarm(false /* all */))
+			case <-timer.C:
+				timer.Read = true
+				s.prewarmHotStandbys(ctx, h.rangeIDsToPrewarm(true /* all */))
+				timer.Reset(hotStandbyPrewarmInterval.Get(&s.ClusterSettings().SV))
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// prewarmHotStandbys loads the data of the given ranges into the block cache.
+func (s *Store) prewarmHotStandbys(ctx context.Context, rangeIDs []roachpb.RangeID) {
+	maxBytes := hotStandbyPrewarmMaxBytes.Get(&s.ClusterSettings().SV)
+	if maxBytes == 0 {
+		return
+	}
+	for _, rangeID := range rangeIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		r := s.GetReplicaIfExists(rangeID)
+		if r == nil || !r.IsInitialized() {
+			continue
+		}
+		span := r.Desc().KeySpan().AsRawSpanWithNoLocals()
+		n, err := prewarmSpan(ctx, s.TODOEngine(), span, maxBytes)
+		s.metrics.HotStandbyPrewarmedBytes.Inc(n)
+		if err != nil {
+			log.Warningf(ctx, "r%d: unable to prewarm the block cache: %v", rangeID, err)
+			continue
+		}
+		log.VEventf(ctx, 2, "r%d: prewarmed %d bytes of the block cache", rangeID, n)
+	}
+}
+
+// prewarmSpan reads the point keys of the given span, up to maxBytes of keys
+// and values, which loads the blocks containing them into the block cache.
+// Returns the number of bytes read.
+func prewarmSpan(
+	ctx context.Context, reader storage.Reader, span roachpb.Span, maxBytes int64,
+) (int64, error) {
+	iter, err := reader.NewMVCCIterator(ctx, storage.MVCCKeyIterKind, storage.IterOptions{
+		LowerBound:   span.Key,
+		UpperBound:   span.EndKey,
+		ReadCategory: storage.ReplicationReadCategory,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+	var n int64
+	for iter.SeekGE(storage.MVCCKey{Key: span.Key}); n < maxBytes; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			return n, err
+		} else if !ok {
+			break
+		}
+		v, err := iter.UnsafeValue()
+		if err != nil {
+			return n, err
+		}
+		n += int64(len(iter.UnsafeKey().Key) + len(v))
+	}
+	return n, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHotStandbyReplica(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	learner := roachpb.LEARNER
+	desc := &roachpb.RangeDescriptor{
+		RangeID: 1,
+		InternalReplicas: []roachpb.ReplicaDescriptor{
+			{NodeID: 1, StoreID: 1, ReplicaID: 1},
+			{NodeID: 2, StoreID: 2, ReplicaID: 2},
+			{NodeID: 3, StoreID: 3, ReplicaID: 3, Type: learner},
+		},
+	}
+
+	repl, ok := hotStandbyReplica(&roachpb.HotStandby{StoreID: 2}, desc)
+	require.True(t, ok)
+	require.Equal(t, roachpb.ReplicaID(2), repl.ReplicaID)
+
+	// Learners and stores without a replica are ignored, as are empty
+	// designations.
+	for _, hs := range []*roachpb.HotStandby{nil, {}, {StoreID: 3}, {StoreID: 4}} {
+		_, ok := hotStandbyReplica(hs, desc)
+		require.False(t, ok, "%v", hs)
+	}
+}
+
+func TestHotStandbys(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	h := makeHotStandbys()
+	changed, n := h.update(1, true /* isStandby */)
+	require.True(t, changed)
+	require.Equal(t, 1, n)
+	changed, _ = h.update(1, true /* isStandby */)
+	require.False(t, changed)
+	changed, n = h.update(2, true /* isStandby */)
+	require.True(t, changed)
+	require.Equal(t, 2, n)
+
+	// New standbys are queued for prewarming once.
+	require.Equal(t, []roachpb.RangeID{1, 2}, h.rangeIDsToPrewarm(false /* all */))
+	require.Empty(t, h.rangeIDsToPrewarm(false /* all */))
+
+	changed, n = h.update(1, false /* isStandby */)
+	require.True(t, changed)
+	require.Equal(t, 1, n)
+	require.Equal(t, []roachpb.RangeID{2}, h.rangeIDsToPrewarm(true /* all */))
+}
+
+func TestPrewarmSpan(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+
+	for _, k := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, eng.PutMVCC(storage.MVCCKey{
+			Key: roachpb.Key(k), Timestamp: hlc.Timestamp{WallTime: 1},
+		}, storage.MVCCValue{Value: roachpb.MakeValueFromString(k)}))
+	}
+
+	span := roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("d")}
+	all, err := prewarmSpan(ctx, eng, span, 1<<20)
+	require.NoError(t, err)
+	require.NotZero(t, all)
+
+	// The read stops once the limit is reached.
+	n, err := prewarmSpan(ctx, eng, span, 1)
+	require.NoError(t, err)
+	require.Less(t, n, all)
+}
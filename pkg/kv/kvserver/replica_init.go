@@ -22,6 +22,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvstorage"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/load"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/logstore"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/replicastats"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/split"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/stateloader"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -160,6 +161,7 @@ func newUninitializedReplicaWithoutRaftGroup(
 
 	if store.cfg.StorePool != nil {
 		r.loadStats = load.NewReplicaLoad(store.Clock(), store.cfg.StorePool.GetNodeLocalityString)
+		r.loadStats.SetDecayHalfLife(replicastats.DecayHalfLife.Get(&store.cfg.Settings.SV))
 		split.Init(
 			&r.loadBasedSplitter,
 			newReplicaSplitConfig(store.ClusterSettings()),
@@ -189,6 +191,9 @@ func newUninitializedReplicaWithoutRaftGroup(
 
 	r.splitQueueThrottle = util.Every(splitQueueThrottleDuration)
 	r.mergeQueueThrottle = util.Every(mergeQueueThrottleDuration)
+	r.timestampSkewReportThrottle = util.Every(timestampSkewReportInterval)
+	r.applyWatchdog.r = r
+	r.intentResolutionCoalescer.r = r
 
 	onTrip := func() {
 		telemetry.Inc(telemetryTripAsync)
@@ -402,14 +407,25 @@ func (r *Replica) setDescLockedRaftMuLocked(ctx context.Context, desc *roachpb.R
 	r.mu.replicaFlowControlIntegration.onDescChanged(ctx)
 
 	// Give the liveness and meta ranges high priority in the Raft scheduler, to
-	// avoid head-of-line blocking and high scheduling latency.
+	// avoid head-of-line blocking and high scheduling latency. Hot standbys are
+	// given priority too, see updateHotStandbyLocked.
+	if hasSystemRaftPriority(ctx, desc) {
+		r.store.scheduler.AddPriorityID(desc.RangeID)
+	}
+	r.updateHotStandbyLocked(ctx)
+}
+
+// hasSystemRaftPriority returns true if the range overlaps the liveness or meta
+// ranges, which have high priority in the Raft scheduler.
+func hasSystemRaftPriority(ctx context.Context, desc *roachpb.RangeDescriptor) bool {
 	for _, span := range []roachpb.Span{keys.NodeLivenessSpan, keys.MetaSpan} {
 		rspan, err := keys.SpanAddr(span)
 		if err != nil {
 			log.Fatalf(ctx, "can't resolve system span %s: %s", span, err)
 		}
 		if _, err := desc.RSpan().Intersect(rspan); err == nil {
-			r.store.scheduler.AddPriorityID(desc.RangeID)
+			return true
 		}
 	}
+	return false
 }
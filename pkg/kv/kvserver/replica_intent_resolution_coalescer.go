@@ -0,0 +1,224 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvadmission"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// coalesceIntentResolutionEnabled controls whether the intent resolution
+// batches received by a leaseholder while another one is being proposed are
+// coalesced into a single batch, and thus a single raft proposal.
+var coalesceIntentResolutionEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.intent_resolution.coalesce.enabled",
+	"if enabled, the intent resolution batches received by a range while another "+
+		"one is in flight are coalesced into a single raft proposal",
+	false,
+)
+
+// coalesceIntentResolutionMaxBytes is the maximum size of the batches
+// coalesced into a single intent resolution batch.
+var coalesceIntentResolutionMaxBytes = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.intent_resolution.coalesce.max_batch_size",
+	"the maximum size of the intent resolution batches coalesced into a single raft proposal",
+	4<<20, /* 4 MiB */
+	settings.PositiveInt,
+)
+
+// canCoalesceIntentResolution returns whether the batch only resolves
+// intents, and can be merged with other such batches. Batches with limits
+// aren't coalesced, since the limits would apply to the merged batch.
+func canCoalesceIntentResolution(ba *kvpb.BatchRequest) bool {
+	if ba.Txn != nil || ba.MaxSpanRequestKeys != 0 || ba.TargetBytes != 0 ||
+		ba.ReadConsistency != kvpb.CONSISTENT || len(ba.Requests) == 0 {
+		return false
+	}
+	for _, ru := range ba.Requests {
+		switch ru.GetInner().Method() {
+		case kvpb.ResolveIntent, kvpb.ResolveIntentRange:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// intentResolutionBatchFn executes an intent resolution batch.
+type intentResolutionBatchFn func(
+	context.Context, *kvpb.BatchRequest,
+) (*kvpb.BatchResponse, *kvadmission.StoreWriteBytes, *kvpb.Error)
+
+// intentResolutionCoalescer coalesces the intent resolution batches of a
+// range, which tend to arrive in bursts after large transactions commit, to
+// reduce the number of raft entries they result in.
+//
+// Coalescing doesn't add latency: a batch is executed right away if no other
+// batch is in flight. Otherwise, it is queued until the batch in flight
+// completes, and all the queued batches are then executed as one. The merged
+// batch acquires latches on the union of the keys of its batches, and is
+// proposed as a single write batch. Each batch was admitted individually
+// before reaching the replica, so the merged batch is not admitted again.
+type intentResolutionCoalescer struct {
+	r  *Replica
+	mu struct {
+		syncutil.Mutex
+		// inFlight is set while a batch is executed, or while the queued batches
+		// are drained.
+		inFlight     bool
+		pending      []*pendingIntentResolution
+		pendingBytes int64
+	}
+}
+
+// pendingIntentResolution is an intent resolution batch waiting to be
+// coalesced with others.
+type pendingIntentResolution struct {
+	ba *kvpb.BatchRequest
+	// done receives the result of the batch. It is buffered, so that the
+	// batch can be abandoned by its caller.
+	done chan intentResolutionResult
+}
+
+type intentResolutionResult struct {
+	br   *kvpb.BatchResponse
+	pErr *kvpb.Error
+}
+
+// send executes the intent resolution batch, possibly coalesced with others.
+func (c *intentResolutionCoalescer) send(
+	ctx context.Context, ba *kvpb.BatchRequest, fn intentResolutionBatchFn,
+) (*kvpb.BatchResponse, *kvadmission.StoreWriteBytes, *kvpb.Error) {
+	size := int64(ba.Size())
+	maxBytes := coalesceIntentResolutionMaxBytes.Get(&c.r.ClusterSettings().SV)
+	c.mu.Lock()
+	if !c.mu.inFlight {
+		c.mu.inFlight = true
+		c.mu.Unlock()
+		br, writeBytes, pErr := fn(ctx, ba)
+		c.drain(ctx, fn)
+		return br, writeBytes, pErr
+	}
+	if c.mu.pendingBytes+size > maxBytes {
+		// Don't hold up the batch if it doesn't fit.
+		c.mu.Unlock()
+		return fn(ctx, ba)
+	}
+	p := &pendingIntentResolution{ba: ba, done: make(chan intentResolutionResult, 1)}
+	c.mu.pending = append(c.mu.pending, p)
+	c.mu.pendingBytes += size
+	c.mu.Unlock()
+
+	log.Event(ctx, "waiting to coalesce intent resolution batch")
+	select {
+	case res := <-p.done:
+		return res.br, nil, res.pErr
+	case <-ctx.Done():
+		return nil, nil, kvpb.NewError(ctx.Err())
+	}
+}
+
+// drain executes the queued batches in the background, until there are none
+// left.
+func (c *intentResolutionCoalescer) drain(ctx context.Context, fn intentResolutionBatchFn) {
+	c.mu.Lock()
+	if len(c.mu.pending) == 0 {
+		c.mu.inFlight = false
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+
+	// The queued batches don't belong to the caller, so they are executed in
+	// an async task rather than on the caller's goroutine and context.
+	ctx = c.r.AnnotateCtx(context.Background())
+	if err := c.r.store.stopper.RunAsyncTask(ctx, "coalesce-intent-resolution", func(ctx context.Context) {
+		for {
+			c.mu.Lock()
+			group := c.mu.pending
+			c.mu.pending, c.mu.pendingBytes = nil, 0
+			if len(group) == 0 {
+				c.mu.inFlight = false
+				c.mu.Unlock()
+				return
+			}
+			c.mu.Unlock()
+			c.sendGroup(ctx, group, fn)
+		}
+	}); err != nil {
+		c.mu.Lock()
+		group := c.mu.pending
+		c.mu.pending, c.mu.pendingBytes = nil, 0
+		c.mu.inFlight = false
+		c.mu.Unlock()
+		for _, p := range group {
+			p.done <- intentResolutionResult{pErr: kvpb.NewError(err)}
+		}
+	}
+}
+
+// sendGroup executes a group of queued batches as a single batch. If the
+// merged batch fails, the batches are executed individually, so that the
+// failure of one of them doesn't fail the others.
+func (c *intentResolutionCoalescer) sendGroup(
+	ctx context.Context, group []*pendingIntentResolution, fn intentResolutionBatchFn,
+) {
+	sendAlone := func(p *pendingIntentResolution) {
+		br, writeBytes, pErr := fn(ctx, p.ba)
+		writeBytes.Release()
+		p.done <- intentResolutionResult{br: br, pErr: pErr}
+	}
+	if len(group) == 1 {
+		sendAlone(group[0])
+		return
+	}
+
+	merged := group[0].ba.ShallowCopy()
+	var numReqs int
+	for _, p := range group {
+		numReqs += len(p.ba.Requests)
+	}
+	merged.Requests = make([]kvpb.RequestUnion, 0, numReqs)
+	for _, p := range group {
+		merged.Requests = append(merged.Requests, p.ba.Requests...)
+		merged.Timestamp.Forward(p.ba.Timestamp)
+	}
+	br, writeBytes, pErr := fn(ctx, merged)
+	writeBytes.Release()
+	if pErr != nil {
+		log.VEventf(ctx, 2, "coalesced intent resolution of %d batches failed, "+
+			"resolving them individually: %s", len(group), pErr)
+		for _, p := range group {
+			sendAlone(p)
+		}
+		return
+	}
+	c.r.store.metrics.IntentResolutionCoalescedBatches.Inc(int64(len(group)))
+
+	var i int
+	for _, p := range group {
+		n := len(p.ba.Requests)
+		pbr := &kvpb.BatchResponse{BatchResponse_Header: br.BatchResponse_Header}
+		// The spans of the merged batch were recorded by the async task, not by
+		// the callers.
+		pbr.CollectedSpans = nil
+		pbr.Responses = br.Responses[i : i+n : i+n]
+		i += n
+		p.done <- intentResolutionResult{br: pbr}
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvadmission"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanCoalesceIntentResolution(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	resolve := &kvpb.ResolveIntentRequest{RequestHeader: kvpb.RequestHeader{Key: roachpb.Key("a")}}
+	resolveRange := &kvpb.ResolveIntentRangeRequest{
+		RequestHeader: kvpb.RequestHeader{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")},
+	}
+	put := &kvpb.PutRequest{RequestHeader: kvpb.RequestHeader{Key: roachpb.Key("a")}}
+
+	ba := &kvpb.BatchRequest{}
+	require.False(t, canCoalesceIntentResolution(ba))
+	ba.Add(resolve, resolveRange)
+	require.True(t, canCoalesceIntentResolution(ba))
+
+	limited := ba.ShallowCopy()
+	limited.MaxSpanRequestKeys = 10
+	require.False(t, canCoalesceIntentResolution(limited))
+
+	txn := ba.ShallowCopy()
+	txn.Txn = &roachpb.Transaction{}
+	require.False(t, canCoalesceIntentResolution(txn))
+
+	mixed := &kvpb.BatchRequest{}
+	mixed.Add(resolve, put)
+	require.False(t, canCoalesceIntentResolution(mixed))
+}
+
+func TestIntentResolutionCoalescer(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	st := cluster.MakeTestingClusterSettings()
+	r := &Replica{
+		AmbientContext: log.MakeTestingAmbientCtxWithNewTracer(),
+		RangeID:        1,
+		store: &Store{
+			cfg:     StoreConfig{Settings: st},
+			stopper: stopper,
+			metrics: newStoreMetrics(time.Minute),
+		},
+	}
+	r.intentResolutionCoalescer.r = r
+	c := &r.intentResolutionCoalescer
+
+	makeBatch := func(ts int64, keys ...string) *kvpb.BatchRequest {
+		ba := &kvpb.BatchRequest{}
+		ba.Timestamp = hlc.Timestamp{WallTime: ts}
+		for _, k := range keys {
+			ba.Add(&kvpb.ResolveIntentRequest{RequestHeader: kvpb.RequestHeader{Key: roachpb.Key(k)}})
+		}
+		return ba
+	}
+
+	// The first batch is executed right away, and blocks until unblocked. The
+	// batches sent in the meantime are coalesced once it completes.
+	unblock := make(chan struct{})
+	executed := make(chan *kvpb.BatchRequest, 10)
+	var failMerged bool
+	fn := func(
+		ctx context.Context, ba *kvpb.BatchRequest,
+	) (*kvpb.BatchResponse, *kvadmission.StoreWriteBytes, *kvpb.Error) {
+		executed <- ba
+		if ba.Requests[0].GetResolveIntent().Key.Equal(roachpb.Key("a")) {
+			<-unblock
+		}
+		if failMerged && len(ba.Requests) > 2 {
+			return nil, nil, kvpb.NewError(errors.New("boom"))
+		}
+		br := &kvpb.BatchResponse{}
+		br.Timestamp = ba.Timestamp
+		for range ba.Requests {
+			br.Add(&kvpb.ResolveIntentResponse{})
+		}
+		return br, nil, nil
+	}
+
+	run := func() {
+		type result struct {
+			br   *kvpb.BatchResponse
+			pErr *kvpb.Error
+		}
+		results := make(chan result, 3)
+		for i, ba := range []*kvpb.BatchRequest{
+			makeBatch(1, "a"), makeBatch(2, "b", "c"), makeBatch(3, "d"),
+		} {
+			ba := ba
+			go func() {
+				br, _, pErr := c.send(ctx, ba, fn)
+				results <- result{br, pErr}
+			}()
+			if i == 0 {
+				require.Len(t, (<-executed).Requests, 1)
+			}
+		}
+		require.Eventually(t, func() bool {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return len(c.mu.pending) == 2
+		}, 10*time.Second, time.Millisecond)
+		close(unblock)
+
+		merged := <-executed
+		require.Len(t, merged.Requests, 3)
+		require.Equal(t, hlc.Timestamp{WallTime: 3}, merged.Timestamp)
+		if failMerged {
+			// The batches are retried individually.
+			require.Len(t, (<-executed).Requests, 2)
+			require.Len(t, (<-executed).Requests, 1)
+		}
+		var numResponses int
+		for i := 0; i < 3; i++ {
+			res := <-results
+			require.Nil(t, res.pErr)
+			numResponses += len(res.br.Responses)
+		}
+		require.Equal(t, 4, numResponses)
+		require.Eventually(t, func() bool {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return !c.mu.inFlight
+		}, 10*time.Second, time.Millisecond)
+	}
+
+	run()
+	require.EqualValues(t, 2, r.store.metrics.IntentResolutionCoalescedBatches.Count())
+
+	unblock = make(chan struct{})
+	failMerged = true
+	run()
+	require.EqualValues(t, 2, r.store.metrics.IntentResolutionCoalescedBatches.Count())
+}
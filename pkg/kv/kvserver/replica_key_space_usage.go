@@ -0,0 +1,361 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/pebble"
+)
+
+// replicaKeySpaceUsageEnabled controls whether the replicas account the size
+// of their keys per SQL index.
+var replicaKeySpaceUsageEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.replica_key_space_usage.enabled",
+	"if enabled, each replica accounts the logical size of its keys per SQL index, "+
+		"reported in crdb_internal.node_key_space_usage; the usage of a replica is "+
+		"computed by scanning it the first time it's reported, and is then maintained "+
+		"as commands are applied",
+	false,
+)
+
+// replicaKeySpaceUsageMaxBatchEntries is the maximum number of entries of the
+// write batches whose writes are accounted for as they are applied.
+var replicaKeySpaceUsageMaxBatchEntries = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"kv.replica_key_space_usage.max_batch_entries",
+	"the maximum number of entries of an applied write batch for its writes to be "+
+		"accounted for in the key space usage of the replica; applying larger batches "+
+		"drops the usage of the replica, which is recomputed the next time it's reported",
+	1024,
+	settings.PositiveInt,
+)
+
+// keySpaceBucket identifies the SQL index the usage of a key is accounted to.
+type keySpaceBucket struct {
+	tenantID         uint64
+	tableID, indexID uint32
+}
+
+// keySpaceBucketOf returns the bucket of the given key, or false if the key
+// doesn't belong to a SQL index.
+func keySpaceBucketOf(key roachpb.Key) (keySpaceBucket, bool) {
+	if keys.IsLocal(key) {
+		return keySpaceBucket{}, false
+	}
+	rest, tenantID, err := keys.DecodeTenantPrefix(key)
+	if err != nil {
+		return keySpaceBucket{}, false
+	}
+	_, tableID, indexID, err := keys.SystemSQLCodec.DecodeIndexPrefix(rest)
+	if err != nil {
+		return keySpaceBucket{}, false
+	}
+	return keySpaceBucket{tenantID: tenantID.ToUint64(), tableID: tableID, indexID: indexID}, true
+}
+
+// keySpaceUsageDelta is a change of the usage of the buckets.
+type keySpaceUsageDelta map[keySpaceBucket]*kvserverpb.KeySpaceUsage
+
+// add accounts for the addition (sign 1) or removal (sign -1) of a version of
+// a key with a value of the given length.
+func (d keySpaceUsageDelta) add(b keySpaceBucket, key storage.MVCCKey, valLen int, sign int64) {
+	u, ok := d[b]
+	if !ok {
+		u = &kvserverpb.KeySpaceUsage{TenantID: b.tenantID, TableID: b.tableID, IndexID: b.indexID}
+		d[b] = u
+	}
+	u.KeyCount += sign
+	u.KeyBytes += sign * int64(key.EncodedSize())
+	u.ValBytes += sign * int64(valLen)
+}
+
+// keySpaceUsageTracker tracks the usage of the buckets of a replica.
+type keySpaceUsageTracker struct {
+	mu struct {
+		syncutil.Mutex
+		usage keySpaceUsageDelta
+		// computed is set once the usage of the replica at the time the tracker
+		// was created has been added to usage.
+		computed bool
+	}
+}
+
+func newKeySpaceUsageTracker() *keySpaceUsageTracker {
+	t := &keySpaceUsageTracker{}
+	t.mu.usage = keySpaceUsageDelta{}
+	return t
+}
+
+func (t *keySpaceUsageTracker) apply(delta keySpaceUsageDelta, computed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for b, d := range delta {
+		u, ok := t.mu.usage[b]
+		if !ok {
+			u = &kvserverpb.KeySpaceUsage{TenantID: b.tenantID, TableID: b.tableID, IndexID: b.indexID}
+			t.mu.usage[b] = u
+		}
+		u.KeyCount += d.KeyCount
+		u.KeyBytes += d.KeyBytes
+		u.ValBytes += d.ValBytes
+		if u.KeyCount == 0 {
+			delete(t.mu.usage, b)
+		}
+	}
+	t.mu.computed = t.mu.computed || computed
+}
+
+// get returns the usage of the buckets, ordered by bucket, or false if the
+// usage isn't computed yet.
+func (t *keySpaceUsageTracker) get() ([]kvserverpb.KeySpaceUsage, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.mu.computed {
+		return nil, false
+	}
+	res := make([]kvserverpb.KeySpaceUsage, 0, len(t.mu.usage))
+	for _, u := range t.mu.usage {
+		res = append(res, *u)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.TenantID != b.TenantID {
+			return a.TenantID < b.TenantID
+		}
+		if a.TableID != b.TableID {
+			return a.TableID < b.TableID
+		}
+		return a.IndexID < b.IndexID
+	})
+	return res, true
+}
+
+// computeKeySpaceUsage scans the versions of the keys in the span to compute
+// their usage.
+func computeKeySpaceUsage(
+	ctx context.Context, reader storage.Reader, span roachpb.Span,
+) (keySpaceUsageDelta, error) {
+	iter, err := reader.NewMVCCIterator(ctx, storage.MVCCKeyIterKind, storage.IterOptions{
+		LowerBound: span.Key,
+		UpperBound: span.EndKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	delta := keySpaceUsageDelta{}
+	for iter.SeekGE(storage.MVCCKey{Key: span.Key}); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			return nil, err
+		} else if !ok {
+			return delta, nil
+		}
+		key := iter.UnsafeKey()
+		if !key.IsValue() {
+			continue
+		}
+		if b, ok := keySpaceBucketOf(key.Key); ok {
+			delta.add(b, key, iter.ValueLen(), 1)
+		}
+	}
+}
+
+// keySpaceUsageWrite is a write of a version of a key by a write batch.
+type keySpaceUsageWrite struct {
+	key     storage.MVCCKey
+	bucket  keySpaceBucket
+	valLen  int
+	deleted bool
+}
+
+// computeKeySpaceUsageDelta computes the change of the usage resulting from
+// applying the write batch on top of the reader. Returns false if the batch
+// has more than maxEntries entries, or clears ranges of keys, in which case the
+// change can't be computed cheaply.
+//
+// The versions overwritten or deleted by the batch are read with a single pass
+// of an iterator over the keys written by the batch, sorted.
+func computeKeySpaceUsageDelta(
+	ctx context.Context, reader storage.Reader, repr []byte, maxEntries int,
+) (keySpaceUsageDelta, bool, error) {
+	r, err := storage.NewBatchReader(repr)
+	if err != nil {
+		return nil, false, err
+	}
+	if r.Count() > maxEntries {
+		return nil, false, nil
+	}
+	var writes []keySpaceUsageWrite
+	for r.Next() {
+		var w keySpaceUsageWrite
+		switch r.KeyKind() {
+		case pebble.InternalKeyKindSet, pebble.InternalKeyKindSetWithDelete:
+			w.valLen = len(r.Value())
+		case pebble.InternalKeyKindDelete, pebble.InternalKeyKindSingleDelete:
+			w.deleted = true
+		case pebble.InternalKeyKindRangeDelete:
+			return nil, false, nil
+		default:
+			// Range keys (i.e. MVCC range tombstones) don't change the versions of
+			// point keys.
+			continue
+		}
+		key, err := r.MVCCKey()
+		if err != nil || !key.IsValue() {
+			// Lock table keys aren't MVCC keys, and unversioned keys aren't
+			// accounted for.
+			continue
+		}
+		var ok bool
+		if w.bucket, ok = keySpaceBucketOf(key.Key); !ok {
+			continue
+		}
+		w.key = key.Clone()
+		writes = append(writes, w)
+	}
+	if err := r.Error(); err != nil {
+		return nil, false, err
+	}
+	delta := keySpaceUsageDelta{}
+	if len(writes) == 0 {
+		return delta, true, nil
+	}
+
+	// Only the last write of each version determines its size once the batch
+	// is applied.
+	sort.SliceStable(writes, func(i, j int) bool { return writes[i].key.Less(writes[j].key) })
+	last := writes[:0]
+	for i, w := range writes {
+		if i+1 < len(writes) && writes[i+1].key.Equal(w.key) {
+			continue
+		}
+		last = append(last, w)
+	}
+	writes = last
+
+	iter, err := reader.NewMVCCIterator(ctx, storage.MVCCKeyIterKind, storage.IterOptions{
+		LowerBound: writes[0].key.Key,
+		UpperBound: writes[len(writes)-1].key.Key.Next(),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	defer iter.Close()
+	for _, w := range writes {
+		iter.SeekGE(w.key)
+		ok, err := iter.Valid()
+		if err != nil {
+			return nil, false, err
+		}
+		if ok && iter.UnsafeKey().Equal(w.key) {
+			delta.add(w.bucket, w.key, iter.ValueLen(), -1)
+		}
+		if !w.deleted {
+			delta.add(w.bucket, w.key, w.valLen, 1)
+		}
+	}
+	return delta, true, nil
+}
+
+// recordKeySpaceUsage accounts for the writes of the given write batch in the
+// key space usage of the replica, before the batch is applied on top of the
+// reader. If the writes can't be accounted for cheaply, the usage is dropped,
+// to be recomputed the next time it's reported.
+func (r *Replica) recordKeySpaceUsage(
+	ctx context.Context, reader storage.Reader, cmd *replicatedCmd,
+) {
+	t := r.keySpaceUsageTracker.Load()
+	if t == nil {
+		return
+	}
+	sv := &r.store.cfg.Settings.SV
+	if !replicaKeySpaceUsageEnabled.Get(sv) || cmd.ReplicatedResult().AddSSTable != nil {
+		r.resetKeySpaceUsage()
+		return
+	}
+	wb := cmd.Cmd.WriteBatch
+	if wb == nil {
+		return
+	}
+	delta, ok, err := computeKeySpaceUsageDelta(
+		ctx, reader, wb.Data, int(replicaKeySpaceUsageMaxBatchEntries.Get(sv)))
+	if err != nil {
+		log.Errorf(ctx, "unable to account for the key space usage of committed WriteBatch: %+v", err)
+	}
+	if !ok {
+		r.resetKeySpaceUsage()
+		return
+	}
+	t.apply(delta, false /* computed */)
+}
+
+// keySpaceUsage returns the key space usage of the replica, ordered by SQL
+// index, or nil if it isn't computed.
+func (r *Replica) keySpaceUsage() []kvserverpb.KeySpaceUsage {
+	t := r.keySpaceUsageTracker.Load()
+	if t == nil {
+		return nil
+	}
+	usage, _ := t.get()
+	return usage
+}
+
+// computeKeySpaceUsageIfNeeded returns the key space usage of the replica,
+// computing it if needed. Returns nil if the accounting of the key space usage
+// is disabled.
+func (r *Replica) computeKeySpaceUsageIfNeeded(
+	ctx context.Context,
+) ([]kvserverpb.KeySpaceUsage, error) {
+	if !replicaKeySpaceUsageEnabled.Get(&r.store.cfg.Settings.SV) {
+		r.resetKeySpaceUsage()
+		return nil, nil
+	}
+	if t := r.keySpaceUsageTracker.Load(); t != nil {
+		if usage, ok := t.get(); ok {
+			return usage, nil
+		}
+	}
+
+	// While holding raftMu, no command is being applied, so the writes of the
+	// commands applied after the snapshot is taken are accounted for by the
+	// new tracker, on top of the usage computed from the snapshot.
+	r.raftMu.Lock()
+	snap := r.store.TODOEngine().NewSnapshot()
+	span := r.Desc().KeySpan().AsRawSpanWithNoLocals()
+	t := newKeySpaceUsageTracker()
+	r.keySpaceUsageTracker.Store(t)
+	r.raftMu.Unlock()
+	defer snap.Close()
+
+	delta, err := computeKeySpaceUsage(ctx, snap, span)
+	if err != nil {
+		return nil, err
+	}
+	t.apply(delta, true /* computed */)
+	usage, _ := t.get()
+	return usage, nil
+}
+
+// resetKeySpaceUsage drops the key space usage of the replica, e.g. once its
+// bounds change.
+func (r *Replica) resetKeySpaceUsage() {
+	r.keySpaceUsageTracker.Store(nil)
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySpaceUsageDelta(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+
+	tenantCodec := keys.MakeSQLCodec(roachpb.MustMakeTenantID(5))
+	key := func(codec keys.SQLCodec, tableID, indexID uint32, s string) roachpb.Key {
+		return append(codec.IndexPrefix(tableID, indexID), s...)
+	}
+	version := func(k roachpb.Key, wallTime int64) storage.MVCCKey {
+		return storage.MVCCKey{Key: k, Timestamp: hlc.Timestamp{WallTime: wallTime}}
+	}
+	value := func(n int) storage.MVCCValue {
+		return storage.MVCCValue{Value: roachpb.MakeValueFromString(strings.Repeat("v", n))}
+	}
+	a, b := key(keys.SystemSQLCodec, 104, 1, "a"), key(keys.SystemSQLCodec, 104, 1, "b")
+	c := key(keys.SystemSQLCodec, 104, 2, "c")
+	d := key(tenantCodec, 104, 1, "d")
+	nonSQL := keys.NodeLivenessKey(1)
+
+	// Write some versions of keys of several indexes, in the system tenant and
+	// a secondary tenant, along with a key which doesn't belong to an index.
+	for _, kv := range []struct {
+		key    storage.MVCCKey
+		valLen int
+	}{
+		{version(a, 1), 10}, {version(a, 2), 20}, {version(b, 1), 30},
+		{version(c, 1), 40}, {version(d, 1), 50}, {version(nonSQL, 1), 60},
+	} {
+		require.NoError(t, eng.PutMVCC(kv.key, value(kv.valLen)))
+	}
+	span := roachpb.Span{Key: keys.LocalMax, EndKey: roachpb.KeyMax}
+	before, err := computeKeySpaceUsage(ctx, eng, span)
+	require.NoError(t, err)
+	require.Len(t, before, 3)
+	systemIndex1 := before[keySpaceBucket{tenantID: 1, tableID: 104, indexID: 1}]
+	require.Equal(t, int64(3), systemIndex1.KeyCount)
+	require.Equal(t, int64(version(a, 1).EncodedSize()+version(a, 2).EncodedSize()+
+		version(b, 1).EncodedSize()), systemIndex1.KeyBytes)
+	require.Contains(t, before, keySpaceBucket{tenantID: 5, tableID: 104, indexID: 1})
+
+	// Overwrite, delete and add versions, including the same version several
+	// times in the batch.
+	wb := eng.NewWriteBatch()
+	defer wb.Close()
+	require.NoError(t, wb.PutMVCC(version(a, 2), value(25)))
+	require.NoError(t, wb.ClearMVCC(version(b, 1), storage.ClearOptions{}))
+	require.NoError(t, wb.PutMVCC(version(c, 2), value(5)))
+	require.NoError(t, wb.ClearMVCC(version(c, 2), storage.ClearOptions{}))
+	require.NoError(t, wb.PutMVCC(version(c, 2), value(7)))
+	require.NoError(t, wb.ClearMVCC(version(d, 1), storage.ClearOptions{}))
+	require.NoError(t, wb.PutMVCC(version(nonSQL, 2), value(70)))
+	repr := wb.Repr()
+
+	delta, ok, err := computeKeySpaceUsageDelta(ctx, eng, repr, 100 /* maxEntries */)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The delta applied to the usage before the batch matches the usage
+	// computed after applying it.
+	tracker := newKeySpaceUsageTracker()
+	tracker.apply(before, true /* computed */)
+	tracker.apply(delta, false /* computed */)
+	require.NoError(t, eng.ApplyBatchRepr(repr, false /* sync */))
+	after, err := computeKeySpaceUsage(ctx, eng, span)
+	require.NoError(t, err)
+	expected := newKeySpaceUsageTracker()
+	expected.apply(after, true /* computed */)
+	got, ok := tracker.get()
+	require.True(t, ok)
+	exp, ok := expected.get()
+	require.True(t, ok)
+	require.Equal(t, exp, got)
+	// The index of the secondary tenant has no keys left.
+	require.Len(t, got, 2)
+	for i, indexID := range []uint32{1, 2} {
+		require.Equal(t, keySpaceBucket{tenantID: 1, tableID: 104, indexID: indexID},
+			keySpaceBucket{tenantID: got[i].TenantID, tableID: got[i].TableID, indexID: got[i].IndexID})
+	}
+
+	// Batches which are too large, or clear ranges of keys, aren't accounted
+	// for.
+	_, ok, err = computeKeySpaceUsageDelta(ctx, eng, repr, 6 /* maxEntries */)
+	require.NoError(t, err)
+	require.False(t, ok)
+	wb = eng.NewWriteBatch()
+	defer wb.Close()
+	require.NoError(t, wb.ClearRawRange(a, c, true /* pointKeys */, false /* rangeKeys */))
+	_, ok, err = computeKeySpaceUsageDelta(ctx, eng, wb.Repr(), 100 /* maxEntries */)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// The usage isn't reported until it's computed.
+	_, ok = newKeySpaceUsageTracker().get()
+	require.False(t, ok)
+}
@@ -0,0 +1,123 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/redact"
+)
+
+// learnerPromotionIOOverloadThreshold is the IO overload score of a store at
+// or above which the promotion of a learner on the store to a voter is
+// deferred.
+var learnerPromotionIOOverloadThreshold = settings.RegisterFloatSetting(
+	settings.SystemOnly,
+	"kv.replication.learner_promotion.io_overload_threshold",
+	"the IO overload score of a store at or above which the promotion of learners "+
+		"on the store to voters is deferred (0 disables the check)",
+	1.0,
+	settings.NonNegativeFloat,
+)
+
+// learnerPromotionMaxDeferral is the maximum duration for which the promotion
+// of a learner is deferred, after which the change is aborted and the learner
+// is rolled back.
+var learnerPromotionMaxDeferral = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.replication.learner_promotion.max_deferral",
+	"the maximum duration for which the promotion of learners on IO overloaded "+
+		"stores is deferred, before the replication change is aborted",
+	10*time.Second,
+	settings.NonNegativeDuration,
+)
+
+// learnerPromotionUnhealthyReason returns a non-empty reason if the LSM of the
+// store, as described by its IO threshold, is too unhealthy for a learner on
+// the store to be promoted to a voter. A voter on an IO overloaded store
+// takes part in the quorum of the range, so promoting it would slow down the
+// writes to the range, and add to the overload of the store.
+func learnerPromotionUnhealthyReason(
+	iot admissionpb.IOThreshold, threshold float64,
+) redact.RedactableString {
+	if threshold <= 0 {
+		return ""
+	}
+	score, _ := iot.Score()
+	if score < threshold {
+		return ""
+	}
+	return redact.Sprintf("io overload %s (l0 sublevels %d/%d, l0 files %d/%d) exceeds threshold %.2f",
+		&iot, iot.L0NumSubLevels, iot.L0NumSubLevelsThreshold,
+		iot.L0NumFiles, iot.L0NumFilesThreshold, redact.SafeFloat(threshold))
+}
+
+// waitForHealthyLearnerPromotionTargets waits until the stores of the given
+// learners, which are about to be promoted to voters, are healthy enough to
+// take part in the quorum of the range. The stores whose health is unknown
+// are considered healthy. The reasons for which the promotion is deferred are
+// recorded in the trace of the change (e.g. the replicate queue's trace). It
+// returns an error if the stores remain unhealthy after
+// kv.replication.learner_promotion.max_deferral, in which case the caller rolls
+// back the learners.
+func (r *Replica) waitForHealthyLearnerPromotionTargets(
+	ctx context.Context, targets []roachpb.ReplicationTarget,
+) error {
+	sv := &r.store.ClusterSettings().SV
+	storePool := r.store.cfg.StorePool
+	if storePool == nil || learnerPromotionIOOverloadThreshold.Get(sv) <= 0 {
+		return nil
+	}
+	unhealthy := func() (roachpb.StoreID, redact.RedactableString) {
+		threshold := learnerPromotionIOOverloadThreshold.Get(sv)
+		for _, target := range targets {
+			desc, ok := storePool.GetStoreDescriptor(target.StoreID)
+			if !ok {
+				continue
+			}
+			if reason := learnerPromotionUnhealthyReason(desc.Capacity.IOThreshold, threshold); reason != "" {
+				return target.StoreID, reason
+			}
+		}
+		return 0, ""
+	}
+
+	start := timeutil.Now()
+	retryOpts := retry.Options{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+	var storeID roachpb.StoreID
+	var reason redact.RedactableString
+	for re := retry.StartWithCtx(ctx, retryOpts); re.Next(); {
+		if storeID, reason = unhealthy(); reason == "" {
+			return nil
+		}
+		log.KvDistribution.VEventf(ctx, 2, "deferring promotion of learner on s%d: %s", storeID, reason)
+		if timeutil.Since(start) >= learnerPromotionMaxDeferral.Get(sv) {
+			break
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.Errorf("not promoting learner on s%d after %s: %s",
+		storeID, timeutil.Since(start).Round(time.Millisecond), reason)
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLearnerPromotionUnhealthyReason(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	iot := func(subLevels, files int64) admissionpb.IOThreshold {
+		return admissionpb.IOThreshold{
+			L0NumSubLevels:          subLevels,
+			L0NumSubLevelsThreshold: 20,
+			L0NumFiles:              files,
+			L0NumFilesThreshold:     1000,
+		}
+	}
+	for _, tc := range []struct {
+		name      string
+		iot       admissionpb.IOThreshold
+		threshold float64
+		exp       string
+	}{
+		{
+			name:      "unknown",
+			threshold: 1,
+		},
+		{
+			name:      "healthy",
+			iot:       iot(5, 100),
+			threshold: 1,
+		},
+		{
+			name:      "sublevels overloaded",
+			iot:       iot(30, 100),
+			threshold: 1,
+			exp:       "io overload 1.500[L0-overload] (l0 sublevels 30/20, l0 files 100/1000) exceeds threshold 1.00",
+		},
+		{
+			name:      "files overloaded",
+			iot:       iot(5, 1000),
+			threshold: 1,
+			exp:       "io overload 1.000 (l0 sublevels 5/20, l0 files 1000/1000) exceeds threshold 1.00",
+		},
+		{
+			name:      "lower threshold",
+			iot:       iot(10, 100),
+			threshold: 0.5,
+			exp:       "io overload 0.500 (l0 sublevels 10/20, l0 files 100/1000) exceeds threshold 0.50",
+		},
+		{
+			name:      "disabled",
+			iot:       iot(30, 100),
+			threshold: 0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reason := learnerPromotionUnhealthyReason(tc.iot, tc.threshold)
+			require.Equal(t, tc.exp, reason.StripMarkers())
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+)
+
+// leaseIndexReproposalMaxAttempts bounds the number of times a command is
+// proposed when it keeps failing the lease applied index check below Raft.
+var leaseIndexReproposalMaxAttempts = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"kv.raft.lease_index_reproposal.max_attempts",
+	"the maximum number of times a command which keeps failing the lease applied index "+
+		"check is proposed, after which it is rejected with a structured error describing "+
+		"the rejections, which the client may retry (0 disables the limit)",
+	0,
+	settings.NonNegativeInt,
+)
+
+// recordLeaseIndexRejection records the given rejection of the proposal by the
+// lease applied index check on the seed of its chain of (re-)proposals. It
+// returns a LeaseIndexReproposalsExhaustedError if the command was rejected
+// maxAttempts times, in which case it must not be reproposed again.
+func (r *Replica) recordLeaseIndexRejection(
+	p *ProposalData, rej kvpb.LeaseIndexRejection, maxAttempts int64,
+) error {
+	seedP := p.seedProposal
+	if seedP == nil {
+		seedP = p
+	}
+	seedP.leaseIndexRejections = append(seedP.leaseIndexRejections, rej)
+	if maxAttempts <= 0 || int64(len(seedP.leaseIndexRejections)) < maxAttempts {
+		return nil
+	}
+	return kvpb.NewLeaseIndexReproposalsExhaustedError(r.RangeID, seedP.leaseIndexRejections)
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLeaseIndexRejection(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	r := &Replica{RangeID: 7}
+	seed := &ProposalData{}
+	reproposal := &ProposalData{seedProposal: seed}
+	rej := func(maxLAI, lai kvpb.LeaseAppliedIndex, leaseSeq roachpb.LeaseSequence) kvpb.LeaseIndexRejection {
+		return kvpb.LeaseIndexRejection{
+			MaxLeaseIndex:         maxLAI,
+			LeaseAppliedIndex:     lai,
+			ProposerLeaseSequence: 3,
+			LeaseSequence:         leaseSeq,
+		}
+	}
+
+	// The rejections of the reproposals are recorded on the seed proposal.
+	require.NoError(t, r.recordLeaseIndexRejection(seed, rej(10, 12, 3), 3))
+	require.NoError(t, r.recordLeaseIndexRejection(reproposal, rej(14, 15, 3), 3))
+	require.Len(t, seed.leaseIndexRejections, 2)
+	require.Empty(t, reproposal.leaseIndexRejections)
+
+	err := r.recordLeaseIndexRejection(reproposal, rej(16, 18, 4), 3)
+	require.True(t, kvpb.IsLeaseIndexReproposalsExhaustedError(err))
+	var exhausted *kvpb.LeaseIndexReproposalsExhaustedError
+	require.True(t, errors.As(err, &exhausted))
+	require.Equal(t, roachpb.RangeID(7), exhausted.RangeID)
+	require.Equal(t, []kvpb.LeaseIndexRejection{
+		rej(10, 12, 3), rej(14, 15, 3), rej(16, 18, 4),
+	}, exhausted.Rejections)
+	require.True(t, exhausted.LeaseChanged())
+
+	// Without a limit, commands are reproposed indefinitely.
+	seed = &ProposalData{}
+	for i := 0; i < 10; i++ {
+		require.NoError(t, r.recordLeaseIndexRejection(seed, rej(10, 12, 3), 0))
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// leasePrewarmMaxBytes bounds the amount of data read from a replica which
+// acquired the lease from another store to prewarm the block cache of its
+// store.
+var leasePrewarmMaxBytes = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.lease.prewarm.max_bytes",
+	"the maximum amount of data read from a replica which acquired the lease from another "+
+		"store, starting at its recently hot keys, to load it into the block cache of its "+
+		"store (0 disables prewarming); the hot keys are only known if "+
+		"kv.replica_hot_keys.enabled is set",
+	0,
+)
+
+// leasePrewarmMaxQueued bounds the number of ranges queued for prewarming,
+// e.g. when a store acquires many leases at once after a failover. The ranges
+// acquired beyond it aren't prewarmed.
+const leasePrewarmMaxQueued = 1024
+
+// leasePrewarmQueue holds the ranges whose lease was acquired by the store,
+// which are pending prewarming.
+type leasePrewarmQueue struct {
+	// notify is signaled when a range is queued.
+	notify chan struct{}
+	mu     struct {
+		syncutil.Mutex
+		rangeIDs []roachpb.RangeID
+	}
+}
+
+func makeLeasePrewarmQueue() leasePrewarmQueue {
+	return leasePrewarmQueue{notify: make(chan struct{}, 1)}
+}
+
+// add queues the given range, returning false if the queue is full.
+func (q *leasePrewarmQueue) add(rangeID roachpb.RangeID) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.mu.rangeIDs) >= leasePrewarmMaxQueued {
+		return false
+	}
+	q.mu.rangeIDs = append(q.mu.rangeIDs, rangeID)
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// drain returns the queued ranges, and clears the queue.
+func (q *leasePrewarmQueue) drain() []roachpb.RangeID {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	rangeIDs := q.mu.rangeIDs
+	q.mu.rangeIDs = nil
+	return rangeIDs
+}
+
+// maybeQueueLeasePrewarm queues the range for prewarming after the replica
+// acquired the lease from another store, e.g. through a lease transfer or
+// after the failure of the previous leaseholder. The block cache of the store
+// likely doesn't hold the data read by the previous leaseholder, which would
+// otherwise be loaded from disk by the first requests served by the replica.
+func (r *Replica) maybeQueueLeasePrewarm(ctx context.Context) {
+	if leasePrewarmMaxBytes.Get(&r.store.cfg.Settings.SV) == 0 {
+		return
+	}
+	if !r.store.leasePrewarmQueue.add(r.RangeID) {
+		log.VEventf(ctx, 2, "not prewarming the block cache, too many ranges queued")
+	}
+}
+
+// startLeasePrewarmer starts a worker which prewarms the block cache with the
+// data of the ranges whose lease was acquired by the store.
+func (s *Store) startLeasePrewarmer(ctx context.Context) {
+	_ /* err */ = s.stopper.RunAsyncTaskEx(ctx, stop.TaskOpts{
+		TaskName: "lease-prewarmer",
+		SpanOpt:  stop.SterileRootSpan,
+	}, func(ctx context.Context) {
+		ctx, cancel := s.stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+
+		q := &s.leasePrewarmQueue
+		for {
+			select {
+			case <-q.notify:
+				s.prewarmLeases(ctx, q.drain())
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// prewarmLeases loads the data around the recently hot keys of the given
+// ranges into the block cache, if the store still holds their lease.
+func (s *Store) prewarmLeases(ctx context.Context, rangeIDs []roachpb.RangeID) {
+	maxBytes := leasePrewarmMaxBytes.Get(&s.ClusterSettings().SV)
+	if maxBytes == 0 {
+		return
+	}
+	for _, rangeID := range rangeIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		r := s.GetReplicaIfExists(rangeID)
+		if r == nil || !r.IsInitialized() {
+			continue
+		}
+		if !r.OwnsValidLease(ctx, s.Clock().NowAsClockTimestamp()) {
+			continue
+		}
+		desc := r.Desc()
+		hotKeys := r.leasePrewarmKeys(ctx, desc)
+		if len(hotKeys) == 0 {
+			continue
+		}
+		n, err := prewarmHotSpans(ctx, s.TODOEngine(), hotKeys, desc.EndKey.AsRawKey(), maxBytes)
+		s.metrics.LeasePrewarmedBytes.Inc(n)
+		if err != nil {
+			log.Warningf(ctx, "r%d: unable to prewarm the block cache: %v", rangeID, err)
+			continue
+		}
+		log.VEventf(ctx, 2, "r%d: prewarmed %d bytes of the block cache from %d hot keys",
+			rangeID, n, len(hotKeys))
+	}
+}
+
+// leasePrewarmKeys returns the recently hot keys of the replica within the
+// bounds of the given descriptor: the keys sampled since the replica was
+// created, followed by the keys persisted with the summary of its load, see
+// persistLoadStats. The persisted keys carry the hot keys across restarts.
+func (r *Replica) leasePrewarmKeys(
+	ctx context.Context, desc *roachpb.RangeDescriptor,
+) []roachpb.Key {
+	var res []roachpb.Key
+	seen := make(map[string]struct{})
+	add := func(key roachpb.Key) {
+		if _, ok := seen[string(key)]; ok || !desc.ContainsKey(roachpb.RKey(key)) {
+			return
+		}
+		seen[string(key)] = struct{}{}
+		res = append(res, key)
+	}
+	for _, hk := range r.hotKeys() {
+		add(hk.Key)
+	}
+	var msg kvserverpb.RangeLoadStats
+	if found, err := r.loadPersistedLoadStats(ctx, &msg); err != nil {
+		log.Warningf(ctx, "unable to load persisted load stats: %v", err)
+	} else if found {
+		for _, key := range msg.HotKeys {
+			add(key)
+		}
+	}
+	return res
+}
+
+// prewarmHotSpans reads the spans starting at each of the given hot keys and
+// ending at endKey, sharing maxBytes of keys and values among them, which
+// loads the blocks containing them into the block cache. Returns the number of
+// bytes read.
+func prewarmHotSpans(
+	ctx context.Context,
+	reader storage.Reader,
+	hotKeys []roachpb.Key,
+	endKey roachpb.Key,
+	maxBytes int64,
+) (int64, error) {
+	perKey := maxBytes / int64(len(hotKeys))
+	var n int64
+	for _, key := range hotKeys {
+		read, err := prewarmSpan(ctx, reader, roachpb.Span{Key: key, EndKey: endKey}, perKey)
+		n += read
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeasePrewarmQueue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	q := makeLeasePrewarmQueue()
+	require.True(t, q.add(1))
+	require.True(t, q.add(2))
+	<-q.notify
+	require.Equal(t, []roachpb.RangeID{1, 2}, q.drain())
+	require.Empty(t, q.drain())
+
+	// Ranges beyond the bound aren't queued.
+	for i := 0; i < leasePrewarmMaxQueued; i++ {
+		require.True(t, q.add(roachpb.RangeID(i)))
+	}
+	require.False(t, q.add(leasePrewarmMaxQueued))
+	require.Len(t, q.drain(), leasePrewarmMaxQueued)
+	require.True(t, q.add(1))
+}
+
+func TestPrewarmHotSpans(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+
+	for _, k := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, eng.PutMVCC(storage.MVCCKey{
+			Key: roachpb.Key(k), Timestamp: hlc.Timestamp{WallTime: 1},
+		}, storage.MVCCValue{Value: roachpb.MakeValueFromString(k)}))
+	}
+
+	// The spans starting at the hot keys are read up to the end key.
+	hotKeys := []roachpb.Key{roachpb.Key("c"), roachpb.Key("a")}
+	endKey := roachpb.Key("d")
+	all, err := prewarmHotSpans(ctx, eng, hotKeys, endKey, 1<<20)
+	require.NoError(t, err)
+	a, err := prewarmSpan(ctx, eng, roachpb.Span{Key: roachpb.Key("a"), EndKey: endKey}, 1<<20)
+	require.NoError(t, err)
+	c, err := prewarmSpan(ctx, eng, roachpb.Span{Key: roachpb.Key("c"), EndKey: endKey}, 1<<20)
+	require.NoError(t, err)
+	require.Equal(t, a+c, all)
+
+	// The hot keys share the limit.
+	n, err := prewarmHotSpans(ctx, eng, hotKeys, endKey, c)
+	require.NoError(t, err)
+	require.Less(t, n, all)
+}
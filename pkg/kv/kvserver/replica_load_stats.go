@@ -0,0 +1,166 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/load"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// loadStatsPersistInterval is the interval at which each store persists the
+// load of its replicas.
+var loadStatsPersistInterval = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.replica_stats.persist_interval",
+	"the interval at which each store persists a summary of the load of its replicas, "+
+		"which is restored when the store restarts to inform load-based splitting and "+
+		"rebalancing without waiting for new measurements (0 disables)",
+	5*time.Minute,
+	settings.NonNegativeDuration,
+)
+
+// loadStatsMaxRestoreAge is the maximum age of a persisted load summary which
+// is restored on startup. Older summaries are unlikely to reflect the current
+// load of the replica.
+const loadStatsMaxRestoreAge = 30 * time.Minute
+
+// persistLoadStats persists a summary of the load of the replica. It is a
+// no-op if the load hasn't been measured for long enough.
+func (r *Replica) persistLoadStats(ctx context.Context) error {
+	if r.loadStats == nil {
+		return nil
+	}
+	stats, dur := r.loadStats.Snapshot()
+	if dur == 0 {
+		return nil
+	}
+	msg := kvserverpb.RangeLoadStats{
+		PersistedAt:              r.store.Clock().Now(),
+		Duration:                 dur,
+		QueriesPerSecond:         stats.QueriesPerSecond,
+		RequestsPerSecond:        stats.RequestsPerSecond,
+		WriteKeysPerSecond:       stats.WriteKeysPerSecond,
+		ReadKeysPerSecond:        stats.ReadKeysPerSecond,
+		WriteBytesPerSecond:      stats.WriteBytesPerSecond,
+		ReadBytesPerSecond:       stats.ReadBytesPerSecond,
+		RaftCPUNanosPerSecond:    stats.RaftCPUNanosPerSecond,
+		RequestCPUNanosPerSecond: stats.RequestCPUNanosPerSecond,
+	}
+	for _, hk := range r.hotKeys() {
+		msg.HotKeys = append(msg.HotKeys, hk.Key)
+	}
+
+	// Hold raftMu so that the key isn't written after the replica's data was
+	// cleared by its destruction.
+	r.raftMu.Lock()
+	defer r.raftMu.Unlock()
+	if _, err := r.IsDestroyed(); err != nil {
+		return nil //nolint:returnerrcheck
+	}
+	return storage.MVCCPutProto(ctx, r.store.TODOEngine(), keys.RangeLoadStatsKey(r.RangeID),
+		hlc.Timestamp{}, &msg, storage.MVCCWriteOptions{})
+}
+
+// restoreLoadStats restores the load of the replica from its persisted
+// summary, unless the summary is older than loadStatsMaxRestoreAge.
+func (r *Replica) restoreLoadStats(ctx context.Context) error {
+	if r.loadStats == nil {
+		return nil
+	}
+	var msg kvserverpb.RangeLoadStats
+	if found, err := r.loadPersistedLoadStats(ctx, &msg); err != nil || !found {
+		return err
+	}
+	r.loadStats.Restore(load.ReplicaLoadStats{
+		QueriesPerSecond:         msg.QueriesPerSecond,
+		RequestsPerSecond:        msg.RequestsPerSecond,
+		WriteKeysPerSecond:       msg.WriteKeysPerSecond,
+		ReadKeysPerSecond:        msg.ReadKeysPerSecond,
+		WriteBytesPerSecond:      msg.WriteBytesPerSecond,
+		ReadBytesPerSecond:       msg.ReadBytesPerSecond,
+		RaftCPUNanosPerSecond:    msg.RaftCPUNanosPerSecond,
+		RequestCPUNanosPerSecond: msg.RequestCPUNanosPerSecond,
+	}, msg.Duration)
+	return nil
+}
+
+// loadPersistedLoadStats loads the persisted summary of the load of the
+// replica, returning false if there is none or if it is older than
+// loadStatsMaxRestoreAge.
+func (r *Replica) loadPersistedLoadStats(
+	ctx context.Context, msg *kvserverpb.RangeLoadStats,
+) (bool, error) {
+	found, err := storage.MVCCGetProto(ctx, r.store.TODOEngine(), keys.RangeLoadStatsKey(r.RangeID),
+		hlc.Timestamp{}, msg, storage.MVCCGetOptions{})
+	if err != nil {
+		return false, errors.Wrap(err, "loading persisted load stats")
+	}
+	if !found {
+		return false, nil
+	}
+	if age := r.store.Clock().Now().GoTime().Sub(msg.PersistedAt.GoTime()); age > loadStatsMaxRestoreAge {
+		return false, nil
+	}
+	return true, nil
+}
+
+// startLoadStatsPersister starts a worker which periodically persists the
+// load of the replicas of the store.
+func (s *Store) startLoadStatsPersister(ctx context.Context) {
+	_ /* err */ = s.stopper.RunAsyncTaskEx(ctx, stop.TaskOpts{
+		TaskName: "load-stats-persister",
+		SpanOpt:  stop.SterileRootSpan,
+	}, func(ctx context.Context) {
+		ctx, cancel := s.stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+
+		timer := timeutil.NewTimer()
+		defer timer.Stop()
+		timer.Reset(time.Minute)
+		for {
+			select {
+			case <-timer.C:
+				timer.Read = true
+				interval := loadStatsPersistInterval.Get(&s.ClusterSettings().SV)
+				if interval == 0 {
+					// Check again later whether persistence has been enabled.
+					timer.Reset(time.Minute)
+					continue
+				}
+				s.persistLoadStats(ctx)
+				timer.Reset(interval)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// persistLoadStats persists the load of the replicas of the store.
+func (s *Store) persistLoadStats(ctx context.Context) {
+	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+		if err := r.persistLoadStats(ctx); err != nil {
+			log.Warningf(ctx, "r%d: unable to persist load stats: %v", r.RangeID, err)
+		}
+		return ctx.Err() == nil
+	})
+}
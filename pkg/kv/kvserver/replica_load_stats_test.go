@@ -0,0 +1,61 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/load"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/replicastats"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaLoadStatsPersistence(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	tc.Start(ctx, t, stopper)
+	repl := tc.repl
+
+	// Nothing is persisted or restored until the load has been measured.
+	repl.loadStats = load.NewReplicaLoad(tc.Clock(), nil)
+	require.NoError(t, repl.persistLoadStats(ctx))
+	require.NoError(t, repl.restoreLoadStats(ctx))
+	require.Zero(t, repl.loadStats.TestingGetSum(load.Queries))
+
+	repl.loadStats.TestingSetStat(load.Queries, 10)
+	repl.loadStats.TestingSetStat(load.Requests, 20)
+	repl.loadStats.TestingSetStat(load.WriteBytes, 1000)
+	require.NoError(t, repl.persistLoadStats(ctx))
+
+	// The persisted load is restored as if it was measured over the same
+	// duration.
+	repl.loadStats = load.NewReplicaLoad(tc.Clock(), nil)
+	require.NoError(t, repl.restoreLoadStats(ctx))
+	dur := replicastats.MinStatsDuration.Seconds()
+	require.InDelta(t, 10*dur, repl.loadStats.TestingGetSum(load.Queries), 1e-6)
+	require.InDelta(t, 20*dur, repl.loadStats.TestingGetSum(load.Requests), 1e-6)
+	require.InDelta(t, 1000*dur, repl.loadStats.TestingGetSum(load.WriteBytes), 1e-6)
+	require.Zero(t, repl.loadStats.TestingGetSum(load.ReadBytes))
+
+	// Stale summaries aren't restored.
+	tc.manualClock.Advance(2 * loadStatsMaxRestoreAge)
+	repl.loadStats = load.NewReplicaLoad(tc.Clock(), nil)
+	require.NoError(t, repl.restoreLoadStats(ctx))
+	require.Zero(t, repl.loadStats.TestingGetSum(load.Queries))
+}
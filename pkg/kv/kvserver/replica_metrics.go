@@ -42,14 +42,20 @@ type ReplicaMetrics struct {
 	// Ticking indicates whether the store is ticking the replica. It should be
 	// the opposite of Quiescent.
 	Ticking bool
+	// Hibernated indicates whether the replica released its raft group.
+	Hibernated bool
 
 	// RangeCounter is true if the current replica is responsible for range-level
 	// metrics (generally the leaseholder, if live, otherwise the first replica in the
 	// range descriptor).
-	RangeCounter          bool
-	Unavailable           bool
-	Underreplicated       bool
-	Overreplicated        bool
+	RangeCounter    bool
+	Unavailable     bool
+	Underreplicated bool
+	Overreplicated  bool
+	// Pinned is set if the voters of the range are pinned to stores, and
+	// PinViolated if they are not on these stores.
+	Pinned                bool
+	PinViolated           bool
 	RaftLogTooLarge       bool
 	BehindCount           int64
 	PausedFollowerCount   int64
@@ -103,6 +109,7 @@ func (r *Replica) Metrics(
 		nodeLocality:          nodeLocality,
 		quiescent:             r.mu.quiescent,
 		ticking:               ticking,
+		hibernated:            r.mu.hibernated,
 		latchMetrics:          latchMetrics,
 		lockTableMetrics:      lockTableMetrics,
 		raftLogSize:           r.mu.raftLogSize,
@@ -111,6 +118,7 @@ func (r *Replica) Metrics(
 		qpCapacity:            qpCap,
 		paused:                r.mu.pausedFollowers,
 		slowRaftProposalCount: r.mu.slowProposalCount,
+		pin:                   r.mu.state.ReplicaPin,
 	}
 
 	r.mu.RUnlock()
@@ -131,6 +139,7 @@ type calcReplicaMetricsInput struct {
 	nodeLocality          roachpb.Locality
 	quiescent             bool
 	ticking               bool
+	hibernated            bool
 	latchMetrics          concurrency.LatchMetrics
 	lockTableMetrics      concurrency.LockTableMetrics
 	raftLogSize           int64
@@ -138,6 +147,7 @@ type calcReplicaMetricsInput struct {
 	qpUsed, qpCapacity    int64 // quota pool used and capacity bytes
 	paused                map[roachpb.ReplicaID]struct{}
 	slowRaftProposalCount int64
+	pin                   *roachpb.ReplicaPin
 }
 
 func calcReplicaMetrics(d calcReplicaMetricsInput) ReplicaMetrics {
@@ -163,6 +173,9 @@ func calcReplicaMetrics(d calcReplicaMetricsInput) ReplicaMetrics {
 	rangeCounter, unavailable, underreplicated, overreplicated := calcRangeCounter(
 		d.storeID, d.desc, d.leaseStatus, d.vitalityMap, d.conf.GetNumVoters(), d.conf.NumReplicas, d.clusterNodes)
 
+	pinned := d.pin != nil && len(d.pin.VoterStoreIDs) > 0
+	pinViolated := pinned && !replicaPinSatisfied(d.desc, d.pin)
+
 	// The raft leader computes the number of raft entries that replicas are
 	// behind.
 	leader := d.raftStatus != nil && d.raftStatus.RaftState == raft.StateLeader
@@ -184,10 +197,13 @@ func calcReplicaMetrics(d calcReplicaMetricsInput) ReplicaMetrics {
 		LessPreferredLease:        lessPreferredLease,
 		Quiescent:                 d.quiescent,
 		Ticking:                   d.ticking,
+		Hibernated:                d.hibernated,
 		RangeCounter:              rangeCounter,
 		Unavailable:               unavailable,
 		Underreplicated:           underreplicated,
 		Overreplicated:            overreplicated,
+		Pinned:                    pinned,
+		PinViolated:               pinViolated,
 		RaftLogTooLarge: d.raftLogSizeTrusted &&
 			d.raftLogSize > raftLogTooLargeMultiple*d.raftCfg.RaftLogTruncationThreshold,
 		BehindCount:           leaderBehindCount,
@@ -322,12 +338,12 @@ func (r *Replica) LoadStats() load.ReplicaLoadStats {
 }
 
 func (r *Replica) needsSplitBySizeRLocked() bool {
-	exceeded, _ := r.exceedsMultipleOfSplitSizeRLocked(1)
+	exceeded, _ := r.exceedsMultipleOfSplitSizeRLocked(1, r.sizeEstimateRLocked().Bytes)
 	return exceeded
 }
 
 func (r *Replica) needsMergeBySizeRLocked() bool {
-	return r.mu.state.Stats.Total() < r.mu.conf.RangeMinBytes
+	return r.sizeEstimateRLocked().Bytes < r.mu.conf.RangeMinBytes
 }
 
 func (r *Replica) needsRaftLogTruncationLocked() bool {
@@ -346,18 +362,19 @@ func (r *Replica) needsRaftLogTruncationLocked() bool {
 	return checkRaftLog
 }
 
-// exceedsMultipleOfSplitSizeRLocked returns whether the current size of the
+// exceedsMultipleOfSplitSizeRLocked returns whether the given size of the
 // range exceeds the max size times mult. If so, the bytes overage is also
 // returned. Note that the max size is determined by either the current maximum
 // size as dictated by the span config or a previous max size indicating that
 // the max size has changed relatively recently and thus we should not
 // backpressure for being over.
-func (r *Replica) exceedsMultipleOfSplitSizeRLocked(mult float64) (exceeded bool, bytesOver int64) {
+func (r *Replica) exceedsMultipleOfSplitSizeRLocked(
+	mult float64, size int64,
+) (exceeded bool, bytesOver int64) {
 	maxBytes := r.mu.conf.RangeMaxBytes
-	if r.mu.largestPreviousMaxRangeSizeBytes > maxBytes {
-		maxBytes = r.mu.largestPreviousMaxRangeSizeBytes
+	if r.mu.sizeTracker.largestPreviousMaxRangeSizeBytes > maxBytes {
+		maxBytes = r.mu.sizeTracker.largestPreviousMaxRangeSizeBytes
 	}
-	size := r.mu.state.Stats.Total()
 	maxSize := int64(float64(maxBytes)*mult) + 1
 	if maxBytes <= 0 || size <= maxSize {
 		return false, 0
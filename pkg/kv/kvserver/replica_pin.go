@@ -0,0 +1,143 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator/allocatorimpl"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// ReplicaPin returns the replica pin of the range, set with DB.PinReplicas, or
+// nil if the range is not pinned.
+//
+// The voters of a pinned range are placed on the pinned stores, regardless of
+// the zone configuration and of the allocator: the replicate queue enforces
+// the pin before consulting the allocator, and the store rebalancer leaves the
+// range alone.
+func (r *Replica) ReplicaPin() *roachpb.ReplicaPin {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	pin := r.mu.state.ReplicaPin
+	if pin == nil || len(pin.VoterStoreIDs) == 0 {
+		return nil
+	}
+	return pin
+}
+
+// replicaPinSatisfied returns true if the voters of the range are exactly
+// full voters on the pinned stores. A range with learners or in an atomic
+// replication change does not satisfy its pin until the change is done.
+func replicaPinSatisfied(desc *roachpb.RangeDescriptor, pin *roachpb.ReplicaPin) bool {
+	replicas := desc.Replicas()
+	if replicas.InAtomicReplicationChange() || len(replicas.LearnerDescriptors()) > 0 {
+		return false
+	}
+	if len(replicas.VoterDescriptors()) != len(pin.VoterStoreIDs) {
+		return false
+	}
+	for _, storeID := range pin.VoterStoreIDs {
+		rd, ok := desc.GetReplicaDescriptor(storeID)
+		if !ok || rd.Type != roachpb.VOTER_FULL {
+			return false
+		}
+	}
+	return true
+}
+
+// deadPinnedStores returns the pinned stores which are dead.
+func (rq *replicateQueue) deadPinnedStores(pin *roachpb.ReplicaPin) []roachpb.StoreID {
+	repls := make([]roachpb.ReplicaDescriptor, len(pin.VoterStoreIDs))
+	for i, storeID := range pin.VoterStoreIDs {
+		repls[i] = roachpb.ReplicaDescriptor{StoreID: storeID}
+	}
+	_, dead := rq.storePool.LiveAndDeadReplicas(repls, true /* includeSuspectAndDrainingStores */)
+	storeIDs := make([]roachpb.StoreID, len(dead))
+	for i, rd := range dead {
+		storeIDs[i] = rd.StoreID
+	}
+	return storeIDs
+}
+
+// shouldQueuePinned is the replicate queue's shouldQueue for a pinned range.
+// The range is queued if a pinned store is dead, so that the pin is dropped,
+// or if its voters don't match the pin.
+func (rq *replicateQueue) shouldQueuePinned(
+	desc *roachpb.RangeDescriptor, pin *roachpb.ReplicaPin,
+) (shouldQueue bool, priority float64) {
+	if len(rq.deadPinnedStores(pin)) > 0 {
+		return true, allocatorimpl.AllocatorReplaceDeadVoter.Priority()
+	}
+	if !replicaPinSatisfied(desc, pin) {
+		return true, allocatorimpl.AllocatorReplaceDecommissioningVoter.Priority()
+	}
+	return false, 0
+}
+
+// enforceReplicaPin is the replicate queue's processing of a pinned range. It
+// relocates the voters of the range onto the pinned stores, keeping the
+// non-voters which are not on a pinned store.
+//
+// If a pinned store is dead, the pin can't be satisfied: it is removed and the
+// range requeued, so that the allocator restores its availability. The pin is
+// not reinstated once the store is back.
+func (rq *replicateQueue) enforceReplicaPin(
+	ctx context.Context, desc *roachpb.RangeDescriptor, pin *roachpb.ReplicaPin, dryRun bool,
+) (requeue bool, _ error) {
+	if dead := rq.deadPinnedStores(pin); len(dead) > 0 {
+		if dryRun {
+			return false, nil
+		}
+		log.KvDistribution.Warningf(ctx,
+			"removing replica pin %v of r%d: pinned stores %v are dead", pin.VoterStoreIDs, desc.RangeID, dead)
+		if err := rq.store.DB().UnpinReplicas(ctx, desc.StartKey.AsRawKey()); err != nil {
+			return false, errors.Wrapf(err, "removing replica pin of r%d", desc.RangeID)
+		}
+		rq.metrics.PinRemovedDeadStoreCount.Inc(1)
+		return true, nil
+	}
+	if replicaPinSatisfied(desc, pin) {
+		return false, nil
+	}
+
+	voters := make([]roachpb.ReplicationTarget, 0, len(pin.VoterStoreIDs))
+	for _, storeID := range pin.VoterStoreIDs {
+		store, ok := rq.storePool.GetStoreDescriptor(storeID)
+		if !ok {
+			return false, errors.Errorf("r%d is pinned to unknown store s%d", desc.RangeID, storeID)
+		}
+		voters = append(voters, roachpb.ReplicationTarget{NodeID: store.Node.NodeID, StoreID: storeID})
+	}
+	pinned := make(map[roachpb.StoreID]struct{}, len(pin.VoterStoreIDs))
+	for _, storeID := range pin.VoterStoreIDs {
+		pinned[storeID] = struct{}{}
+	}
+	var nonVoters []roachpb.ReplicationTarget
+	for _, rd := range desc.Replicas().NonVoterDescriptors() {
+		if _, ok := pinned[rd.StoreID]; ok {
+			continue
+		}
+		nonVoters = append(nonVoters, roachpb.ReplicationTarget{NodeID: rd.NodeID, StoreID: rd.StoreID})
+	}
+	if dryRun {
+		return false, nil
+	}
+	log.KvDistribution.Infof(ctx, "relocating r%d onto its pinned stores %v", desc.RangeID, pin.VoterStoreIDs)
+	if err := rq.RelocateRange(
+		ctx, desc.StartKey.AsRawKey(), voters, nonVoters, false, /* transferLeaseToFirstVoter */
+	); err != nil {
+		return false, errors.Wrapf(err, "relocating r%d onto its pinned stores", desc.RangeID)
+	}
+	return false, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaPinSatisfied(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	replica := func(storeID roachpb.StoreID, typ roachpb.ReplicaType) roachpb.ReplicaDescriptor {
+		return roachpb.ReplicaDescriptor{
+			NodeID:    roachpb.NodeID(storeID),
+			StoreID:   storeID,
+			ReplicaID: roachpb.ReplicaID(storeID),
+			Type:      typ,
+		}
+	}
+	pin := &roachpb.ReplicaPin{VoterStoreIDs: []roachpb.StoreID{1, 2, 3}}
+
+	for _, tc := range []struct {
+		name     string
+		replicas []roachpb.ReplicaDescriptor
+		exp      bool
+	}{
+		{
+			name: "satisfied",
+			replicas: []roachpb.ReplicaDescriptor{
+				replica(3, roachpb.VOTER_FULL), replica(1, roachpb.VOTER_FULL), replica(2, roachpb.VOTER_FULL),
+			},
+			exp: true,
+		},
+		{
+			name: "satisfied with non-voter",
+			replicas: []roachpb.ReplicaDescriptor{
+				replica(1, roachpb.VOTER_FULL), replica(2, roachpb.VOTER_FULL), replica(3, roachpb.VOTER_FULL),
+				replica(4, roachpb.NON_VOTER),
+			},
+			exp: true,
+		},
+		{
+			name: "missing voter",
+			replicas: []roachpb.ReplicaDescriptor{
+				replica(1, roachpb.VOTER_FULL), replica(2, roachpb.VOTER_FULL),
+			},
+		},
+		{
+			name: "voter on unpinned store",
+			replicas: []roachpb.ReplicaDescriptor{
+				replica(1, roachpb.VOTER_FULL), replica(2, roachpb.VOTER_FULL), replica(4, roachpb.VOTER_FULL),
+			},
+		},
+		{
+			name: "extra voter",
+			replicas: []roachpb.ReplicaDescriptor{
+				replica(1, roachpb.VOTER_FULL), replica(2, roachpb.VOTER_FULL), replica(3, roachpb.VOTER_FULL),
+				replica(4, roachpb.VOTER_FULL),
+			},
+		},
+		{
+			name: "non-voter on pinned store",
+			replicas: []roachpb.ReplicaDescriptor{
+				replica(1, roachpb.VOTER_FULL), replica(2, roachpb.VOTER_FULL), replica(3, roachpb.NON_VOTER),
+			},
+		},
+		{
+			name: "learner",
+			replicas: []roachpb.ReplicaDescriptor{
+				replica(1, roachpb.VOTER_FULL), replica(2, roachpb.VOTER_FULL), replica(3, roachpb.VOTER_FULL),
+				replica(4, roachpb.LEARNER),
+			},
+		},
+		{
+			name: "joint config",
+			replicas: []roachpb.ReplicaDescriptor{
+				replica(1, roachpb.VOTER_FULL), replica(2, roachpb.VOTER_FULL), replica(3, roachpb.VOTER_INCOMING),
+				replica(4, roachpb.VOTER_OUTGOING),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			desc := roachpb.NewRangeDescriptor(1, roachpb.RKeyMin, roachpb.RKeyMax,
+				roachpb.MakeReplicaSet(tc.replicas))
+			require.Equal(t, tc.exp, replicaPinSatisfied(desc, pin))
+		})
+	}
+}
@@ -138,6 +138,13 @@ type ProposalData struct {
 	// *first* proposed.
 	createdAtTicks int
 
+	// reproposals is the number of times this command was reproposed unchanged
+	// because it failed to replicate, and reproposalBackoffTicks the number of
+	// ticks after its last (re-)proposal at which it is reproposed again. See
+	// refreshProposalsLocked.
+	reproposals            int
+	reproposalBackoffTicks int
+
 	// command is the log entry that is encoded into encodedCommand and proposed
 	// to raft. Never mutated.
 	command *kvserverpb.RaftCommand
@@ -217,6 +224,10 @@ type ProposalData struct {
 	// such a way that the "common" parts of the (re-)proposals are shared and
 	// chaining isn't necessary.
 	lastReproposal *ProposalData
+	// leaseIndexRejections records the rejections of the chain of
+	// (re-)proposals by the lease applied index check, in order. This field is
+	// set only on the seed proposal. See recordLeaseIndexRejection.
+	leaseIndexRejections []kvpb.LeaseIndexRejection
 }
 
 // useReplicationAdmissionControl indicates whether this raft command should
@@ -466,6 +477,9 @@ func (r *Replica) leasePostApplyLocked(
 	// Log the lease acquisition, if appropriate.
 	if leaseChangingHands && iAmTheLeaseHolder {
 		r.maybeLogLeaseAcquisition(ctx, now, prevLease, newLease)
+		if prevLease.Replica.StoreID != newLease.Replica.StoreID {
+			r.maybeQueueLeasePrewarm(ctx)
+		}
 	}
 
 	st := r.leaseStatusAtRLocked(ctx, now)
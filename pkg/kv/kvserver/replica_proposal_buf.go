@@ -96,6 +96,11 @@ type propBuf struct {
 	// This field can be read under the proposer's read lock, and written to under
 	// the write lock.
 	assignedClosedTimestamp hlc.Timestamp
+	// trackerLag is the amount by which currently-evaluating requests held back
+	// the closed timestamp assigned to the last proposal, below the target of
+	// the range's closed timestamp policy. It is recorded in the range's closed
+	// timestamp history.
+	trackerLag time.Duration
 
 	// Buffer used to avoid allocations.
 	scratchFooter kvserverpb.RaftCommandFooter
@@ -926,11 +931,15 @@ func (b *propBuf) allocateLAIAndClosedTimestampLocked(
 	// See https://github.com/cockroachdb/cockroach/issues/72428#issuecomment-976428551.
 
 	lb := b.evalTracker.LowerBound(ctx)
+	b.trackerLag = 0
 	if !lb.IsEmpty() {
 		// If the tracker told us that requests are currently evaluating at
 		// timestamps >= lb, then we can close up to lb.Prev(). We use FloorPrev()
 		// to get rid of the logical ticks; we try to not publish closed ts with
 		// logical ticks when there's no good reason for them.
+		if held := lb.FloorPrev(); held.Less(closedTSTarget) {
+			b.trackerLag = closedTSTarget.GoTime().Sub(held.GoTime())
+		}
 		closedTSTarget.Backward(lb.FloorPrev())
 	}
 	// We can't close timestamps above the current lease's expiration.
@@ -1325,6 +1334,12 @@ func (rp *replicaProposer) closedTimestampTarget() hlc.Timestamp {
 }
 
 func (rp *replicaProposer) withGroupLocked(fn func(raftGroup proposerRaft) error) error {
+	if rp.mu.hibernated {
+		// The raft group can't be woken without raftMu. Flush the proposals
+		// without it, which only registers them: the Ready handling scheduled
+		// by the proposer wakes the raft group, and proposes them.
+		return fn(nil)
+	}
 	return (*Replica)(rp).withRaftGroupLocked(func(raftGroup *raft.RawNode) (bool, error) {
 		// We're proposing a command here so there is no need to wake the leader
 		// if we were quiesced. However, we should make sure we are unquiesced.
@@ -1462,6 +1477,7 @@ func (rp *replicaProposer) rejectProposalWithLeaseTransferRejectedLocked(
 	reason raftutil.ReplicaNeedsSnapshotStatus,
 ) {
 	rp.store.metrics.LeaseTransferErrorCount.Inc(1)
+	rp.store.metrics.LeaseTransferRejectedMayNeedSnapshotCount.Inc(1)
 	log.VEventf(ctx, 2, "not proposing lease transfer because the target %s may "+
 		"need a snapshot: %s", lease.Replica, reason)
 	err := NewLeaseTransferRejectedBecauseTargetMayNeedSnapshotError(lease.Replica, reason)
@@ -13,6 +13,7 @@ package kvserver
 import (
 	"bytes"
 	"context"
+	"math"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
@@ -21,6 +22,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
 	"go.etcd.io/raft/v3"
@@ -82,6 +84,72 @@ func quotaPoolEnabledForRange(desc roachpb.RangeDescriptor) bool {
 	return !bytes.HasPrefix(desc.StartKey, keys.NodeLivenessPrefix)
 }
 
+// proposalQuotaCapacityLocked returns the capacity of the proposal quota pool
+// of the range: the override set through the SetProposalQuota RPC until it
+// expires, or the store's default otherwise.
+func (r *Replica) proposalQuotaCapacityLocked(now time.Time) uint64 {
+	if o := r.mu.proposalQuotaOverride; o.capacity != 0 && now.Before(o.expiration) {
+		return o.capacity
+	}
+	return uint64(r.store.cfg.RaftProposalQuota)
+}
+
+// SetProposalQuota sets the capacity of the proposal quota pool of the range
+// for the given duration, after which it reverts to the store's default. This
+// lets an operator temporarily raise the quota of a range during controlled
+// bulk writes. A zero capacity clears any previous override. The capacity
+// only applies while the replica is the raft leader, and is picked up on the
+// next raft ready iteration.
+func (r *Replica) SetProposalQuota(capacity uint64, duration time.Duration) error {
+	if capacity > math.MaxInt64 {
+		return errors.Errorf("proposal quota %d exceeds the maximum of %d", capacity, math.MaxInt64)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.proposalQuotaOverride.capacity = capacity
+	r.mu.proposalQuotaOverride.expiration = timeutil.Now().Add(duration)
+	return nil
+}
+
+// proposalQuotaWaiters tracks the proposals waiting for proposal quota, to
+// report how long the longest-waiting one has been waiting. Proposals which
+// acquire quota without waiting aren't tracked.
+type proposalQuotaWaiters struct {
+	mu syncutil.Mutex
+	// waiting maps the quota requests of the waiting proposals to the time at
+	// which they started to wait.
+	waiting map[quotapool.Request]time.Time
+}
+
+func (w *proposalQuotaWaiters) onWaitStart(_ context.Context, _ string, req quotapool.Request) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.waiting == nil {
+		w.waiting = make(map[quotapool.Request]time.Time)
+	}
+	w.waiting[req] = timeutil.Now()
+}
+
+func (w *proposalQuotaWaiters) onWaitFinish(
+	_ context.Context, _ string, req quotapool.Request, _ time.Time,
+) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.waiting, req)
+}
+
+// longestWait returns how long the longest-waiting proposal has been waiting
+// as of now, or zero if no proposal is waiting.
+func (w *proposalQuotaWaiters) longestWait(now time.Time) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var longest time.Duration
+	for _, start := range w.waiting {
+		longest = max(longest, now.Sub(start))
+	}
+	return longest
+}
+
 var logSlowRaftProposalQuotaAcquisition = quotapool.OnSlowAcquisition(
 	base.SlowRequestThreshold, quotapool.LogSlowAcquisition,
 )
@@ -115,8 +183,10 @@ func (r *Replica) updateProposalQuotaRaftMuLocked(
 			// hands.
 			r.mu.proposalQuota = quotapool.NewIntPool(
 				"raft proposal",
-				uint64(r.store.cfg.RaftProposalQuota),
+				r.proposalQuotaCapacityLocked(timeutil.Now()),
 				logSlowRaftProposalQuotaAcquisition,
+				quotapool.OnWaitStart(r.proposalQuotaWaiters.onWaitStart),
+				quotapool.OnWaitFinish(r.proposalQuotaWaiters.onWaitFinish),
 			)
 			r.mu.lastUpdateTimes = make(map[roachpb.ReplicaID]time.Time)
 			r.mu.lastUpdateTimes.updateOnBecomeLeader(r.mu.state.Desc.Replicas().Descriptors(), timeutil.Now())
@@ -146,6 +216,11 @@ func (r *Replica) updateProposalQuotaRaftMuLocked(
 
 	// Find the minimum index that active followers have acknowledged.
 	now := timeutil.Now()
+	// Apply any change of the capacity of the pool, including the expiration of
+	// an override.
+	if c := r.proposalQuotaCapacityLocked(now); c != r.mu.proposalQuota.Capacity() {
+		r.mu.proposalQuota.UpdateCapacity(c)
+	}
 	// commitIndex is used to determine whether a newly added replica has fully
 	// caught up.
 	commitIndex := kvpb.RaftIndex(status.Commit)
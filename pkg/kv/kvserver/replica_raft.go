@@ -571,9 +571,15 @@ var errRemoved = errors.New("replica removed")
 
 // stepRaftGroup calls Step on the replica's RawNode with the provided request's
 // message. Before doing so, it assures that the replica is unquiesced and ready
-// to handle the request.
+// to handle the request. Requires that raftMu is held, to wake the raft group
+// if the replica is hibernated.
 func (r *Replica) stepRaftGroup(req *kvserverpb.RaftMessageRequest) error {
-	return r.withRaftGroup(func(raftGroup *raft.RawNode) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.maybeWakeRaftGroupRaftMuLockedReplicaMuLocked(); err != nil {
+		return err
+	}
+	return r.withRaftGroupLocked(func(raftGroup *raft.RawNode) (bool, error) {
 		// We're processing an incoming raft message (from a batch that may
 		// include MsgVotes), so don't campaign if we wake up our raft
 		// group.
@@ -785,6 +791,21 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 	}
 	leaderID := r.mu.leaderID
 	lastLeaderID := leaderID
+	if r.mu.hibernated {
+		// Wake the raft group if proposals are waiting for it. Otherwise, there's
+		// no Ready to handle.
+		if r.mu.proposalBuf.AllocatedIdx() == 0 && len(r.mu.proposals) == 0 {
+			r.mu.Unlock()
+			return stats, nil
+		}
+		if err := r.maybeWakeRaftGroupRaftMuLockedReplicaMuLocked(); err != nil {
+			r.mu.Unlock()
+			if errors.Is(err, errRemoved) {
+				return stats, nil
+			}
+			return stats, err
+		}
+	}
 	err := r.withRaftGroupLocked(func(raftGroup *raft.RawNode) (bool, error) {
 		r.deliverLocalRaftMsgsRaftMuLockedReplicaMuLocked(ctx, raftGroup)
 
@@ -889,7 +910,9 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 	var appTask apply.Task
 	if hasMsg(msgStorageApply) {
 		appTask = apply.MakeTask(sm, dec)
-		appTask.SetMaxBatchSize(r.store.TestingKnobs().MaxApplicationBatchSize)
+		maxEntries, maxBytes := r.store.applicationBatchLimits()
+		appTask.SetMaxBatchSize(maxEntries)
+		appTask.SetMaxBatchBytes(maxBytes)
 		defer appTask.Close()
 		if err := appTask.Decode(ctx, msgStorageApply.Entries); err != nil {
 			return stats, err
@@ -1056,7 +1079,9 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 	if hasMsg(msgStorageApply) {
 		r.traceEntries(msgStorageApply.Entries, "committed, before applying any entries")
 
-		err := appTask.ApplyCommittedEntries(ctx)
+		appCtx, resetLabels := r.setApplicationProfilerLabels(ctx)
+		err := appTask.ApplyCommittedEntries(appCtx)
+		resetLabels()
 		stats.apply = sm.moveStats()
 		if err != nil {
 			// NB: this branch will be hit when the replica has been removed,
@@ -1068,6 +1093,9 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 			return stats, err
 		}
 
+		if fwb := stats.apply.followerStoreWriteBytes; fwb.NumEntries > 0 {
+			r.store.health.recordFollowerWriteBytes(fwb.WriteBytes + fwb.IngestedBytes)
+		}
 		if r.store.cfg.KVAdmissionController != nil &&
 			stats.apply.followerStoreWriteBytes.NumEntries > 0 {
 			r.store.cfg.KVAdmissionController.FollowerStoreWriteBytes(
@@ -1275,11 +1303,14 @@ func (r *Replica) tick(
 	}
 
 	r.maybeTransferRaftLeadershipToLeaseholderLocked(ctx, leaseStatus)
+	// If the lease expired, let the hot standby acquire it rather than this
+	// replica.
+	toHotStandby := r.maybeTransferRaftLeadershipToHotStandbyLocked(ctx, leaseStatus, livenessMap)
 
 	// Eagerly acquire or extend leases. This only works for unquiesced ranges. We
 	// never quiesce expiration leases, but for epoch leases we fall back to the
 	// replicate queue which will do this within 10 minutes.
-	if !r.store.cfg.TestingKnobs.DisableAutomaticLeaseRenewal {
+	if !r.store.cfg.TestingKnobs.DisableAutomaticLeaseRenewal && !toHotStandby {
 		if shouldRequest, isExtension := r.shouldRequestLeaseRLocked(leaseStatus); shouldRequest {
 			if _, requestPending := r.mu.pendingLeaseRequest.RequestPending(); !requestPending {
 				var limiter *quotapool.IntPool
@@ -1436,6 +1467,11 @@ func (r *Replica) refreshProposalsLocked(
 
 		case reasonTicks:
 			if p.proposedAtTicks <= r.mu.ticks-refreshAtDelta {
+				if p.proposedAtTicks > r.mu.ticks-p.reproposalBackoffTicks {
+					// The command is backing off after its previous reproposals.
+					r.store.metrics.RaftCommandsReproposalsDeferred.Inc(1)
+					continue
+				}
 				// The command was proposed a while ago and may have been dropped. Try it again.
 				reproposals = append(reproposals, p)
 			}
@@ -1491,7 +1527,22 @@ func (r *Replica) refreshProposalsLocked(
 	// that they can make it in the right place. Reproposing in order is
 	// definitely required, however.
 	sort.Sort(reproposals)
-	for _, p := range reproposals {
+	budget := raftReproposalBudget.Get(&r.store.cfg.Settings.SV)
+	maxBackoffTicks := reproposalMaxBackoffTicks(&r.store.cfg.Settings.SV, r.store.cfg.RaftTickInterval)
+	for i, p := range reproposals {
+		if budget > 0 && int64(i) >= budget {
+			// Reject the commands in excess of the budget. These are the ones with
+			// the highest lease indexes, which the commands reproposed ahead of them
+			// don't depend on. Lease requests, which have none, are reproposed
+			// first. The commands may still apply, so the result is ambiguous.
+			log.Eventf(p.ctx, "rejecting command %x in excess of the reproposal budget: %s",
+				p.idKey, reason)
+			r.cleanupFailedProposalLocked(p)
+			p.finishApplication(ctx, makeProposalResultErr(kvpb.NewAmbiguousResultError(
+				kvpb.NewReproposalBudgetExceededError(r.RangeID, budget, int64(len(reproposals))))))
+			r.store.metrics.RaftCommandsReproposalsRejected.Inc(1)
+			continue
+		}
 		log.Eventf(p.ctx, "re-submitting command %x (MLI %d, CT %s): %s",
 			p.idKey, p.command.MaxLeaseIndex, p.command.ClosedTimestamp, reason)
 		if err := r.mu.proposalBuf.ReinsertLocked(ctx, p); err != nil {
@@ -1500,6 +1551,11 @@ func (r *Replica) refreshProposalsLocked(
 				kvpb.NewAmbiguousResultError(err)))
 			continue
 		}
+		p.reproposals++
+		if maxBackoffTicks > 0 {
+			p.reproposalBackoffTicks = reproposalBackoffTicks(
+				r.store.cfg.RaftReproposalTimeoutTicks, p.reproposals, maxBackoffTicks, rand.Float64())
+		}
 		r.store.metrics.RaftCommandsReproposed.Inc(1)
 	}
 }
@@ -1864,10 +1920,15 @@ func (r *Replica) reportSnapshotStatus(ctx context.Context, to roachpb.ReplicaID
 	// the follower will generate an MsgAppResp reflecting the applied snapshot
 	// which typically moves the follower to StateReplicate when (if) received
 	// by the leader, which as of #106793 we do synchronously.
+	//
+	// The snapshot may have been sent for minutes, during which this replica
+	// could have lost leadership, quiesced, and hibernated. A hibernated raft
+	// group tracks no progress for the recipient, so there's nothing to report,
+	// just as when the replica was removed.
 	if err := r.withRaftGroup(func(raftGroup *raft.RawNode) (bool, error) {
 		raftGroup.ReportSnapshot(uint64(to), snapStatus)
 		return true, nil
-	}); err != nil && !errors.Is(err, errRemoved) {
+	}); err != nil && !errors.Is(err, errRemoved) && !errors.Is(err, errRaftGroupHibernated) {
 		log.Fatalf(ctx, "%v", err)
 	}
 }
@@ -2008,7 +2069,7 @@ func (s pendingCmdSlice) Less(i, j int) bool {
 // Requires that Replica.mu is held.
 //
 // If this Replica is in the process of being removed this method will return
-// errRemoved.
+// errRemoved, and if it is hibernated errRaftGroupHibernated.
 func (r *Replica) withRaftGroupLocked(
 	f func(r *raft.RawNode) (unquiesceAndWakeLeader bool, _ error),
 ) error {
@@ -2017,7 +2078,14 @@ func (r *Replica) withRaftGroupLocked(
 		return errRemoved
 	}
 
-	// INVARIANT: !r.mu.destroyStatus.Removed() → internalRaftGroup != nil
+	if r.mu.hibernated {
+		// The raft group can't be woken without raftMu, see
+		// maybeWakeRaftGroupRaftMuLockedReplicaMuLocked.
+		return errRaftGroupHibernated
+	}
+
+	// INVARIANT: !r.mu.destroyStatus.Removed() && !r.mu.hibernated →
+	// internalRaftGroup != nil
 
 	unquiesce, err := f(r.mu.internalRaftGroup)
 	if r.mu.internalRaftGroup.BasicStatus().Lead == 0 {
@@ -2050,7 +2118,7 @@ func (r *Replica) withRaftGroupLocked(
 // by the supplied function).
 //
 // If this Replica is in the process of being removed this method will return
-// errRemoved.
+// errRemoved, and if it is hibernated errRaftGroupHibernated.
 func (r *Replica) withRaftGroup(
 	f func(r *raft.RawNode) (unquiesceAndWakeLeader bool, _ error),
 ) error {
@@ -2174,6 +2242,9 @@ func (r *Replica) maybeCampaignOnWakeLocked(ctx context.Context) {
 // TODO(erikgrinaker): The above cases are only relevant with epoch leases and
 // 23.1 compatibility. Consider removing this when no longer needed.
 func (r *Replica) maybeForgetLeaderOnVoteRequestLocked() {
+	if r.mu.internalRaftGroup == nil {
+		return
+	}
 	raftStatus := r.mu.internalRaftGroup.BasicStatus()
 	livenessMap, _ := r.store.livenessMap.Load().(livenesspb.IsLiveMap)
 	now := r.store.Clock().Now()
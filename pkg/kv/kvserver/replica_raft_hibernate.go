@@ -0,0 +1,162 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"go.etcd.io/raft/v3"
+)
+
+// raftHibernationIdleDuration is the duration after which a quiesced follower
+// releases its in-memory raft state.
+var raftHibernationIdleDuration = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.raft.hibernation.idle_duration",
+	"the duration after which the quiesced follower replicas which don't hold the lease "+
+		"release their in-memory raft state, which is reconstructed from storage on the "+
+		"next raft operation; reduces the memory usage of stores with many idle ranges "+
+		"(0 disables)",
+	0,
+	settings.NonNegativeDuration,
+)
+
+// raftHibernationScanInterval is the interval at which each store looks for
+// replicas to hibernate.
+const raftHibernationScanInterval = time.Minute
+
+// canHibernateRLocked returns whether the replica can release its raft group.
+// Only initialized followers which were quiesced for the given duration and
+// have no raft work in flight hibernate. Leaders and leaseholders never do,
+// since they would be woken right away.
+func (r *Replica) canHibernateRLocked(now time.Time, idle time.Duration) bool {
+	if r.mu.hibernated || r.mu.internalRaftGroup == nil || r.mu.destroyStatus.Removed() {
+		return false
+	}
+	if !r.IsInitialized() || !r.mu.quiescent || now.Sub(r.mu.quiescedAt) < idle {
+		return false
+	}
+	if r.mu.internalRaftGroup.BasicStatus().RaftState != raft.StateFollower ||
+		r.mu.state.Lease.OwnedBy(r.store.StoreID()) {
+		return false
+	}
+	return len(r.mu.proposals) == 0 && r.mu.proposalBuf.AllocatedIdx() == 0 &&
+		!r.mu.internalRaftGroup.HasReady()
+}
+
+// maybeHibernate releases the raft group of the replica if it has been idle
+// for the given duration, returning true in that case.
+func (r *Replica) maybeHibernate(ctx context.Context, now time.Time, idle time.Duration) bool {
+	// Holding raftMu ensures that no Ready is being handled.
+	r.raftMu.Lock()
+	defer r.raftMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.canHibernateRLocked(now, idle) {
+		return false
+	}
+	r.mu.internalRaftGroup = nil
+	r.mu.hibernated = true
+	if log.V(3) {
+		log.Infof(ctx, "hibernating r%d", r.RangeID)
+	}
+	return true
+}
+
+// errRaftGroupHibernated is returned by withRaftGroupLocked for a hibernated
+// replica, whose raft group can only be woken with raftMu held.
+var errRaftGroupHibernated = errors.New("raft group hibernated")
+
+// maybeWakeRaftGroupRaftMuLockedReplicaMuLocked reconstructs the raft group of
+// a hibernated replica from storage. The reconstructed group doesn't know the
+// leader, so the replica unquiesces and learns it again from the leader's next
+// message.
+//
+// The raft group is only woken by the paths holding raftMu: stepping a raft
+// message, and handling a Ready when proposals are pending. The other paths
+// find the raft group of a hibernated replica nil: withRaftGroupLocked returns
+// errRaftGroupHibernated, maybeUnquiesceLocked doesn't unquiesce, and the
+// proposal buffer only registers the proposals, which are flushed once the
+// Ready handling it schedules wakes the raft group.
+func (r *Replica) maybeWakeRaftGroupRaftMuLockedReplicaMuLocked() error {
+	r.raftMu.AssertHeld()
+	if !r.mu.hibernated {
+		return nil
+	}
+	if r.mu.destroyStatus.Removed() {
+		return errRemoved
+	}
+	start := timeutil.Now()
+	if err := r.initRaftGroupRaftMuLockedReplicaMuLocked(); err != nil {
+		return errors.Wrap(err, "waking hibernated raft group")
+	}
+	r.mu.hibernated = false
+	r.store.metrics.RaftHibernationWakes.Inc(1)
+	r.store.metrics.RaftHibernationWakeLatency.RecordValue(timeutil.Since(start).Nanoseconds())
+	return nil
+}
+
+// IsHibernated returns whether the replica released its raft group.
+func (r *Replica) IsHibernated() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mu.hibernated
+}
+
+// startRaftHibernation starts a worker which periodically hibernates the idle
+// replicas of the store.
+func (s *Store) startRaftHibernation(ctx context.Context) {
+	_ /* err */ = s.stopper.RunAsyncTaskEx(ctx, stop.TaskOpts{
+		TaskName: "raft-hibernation",
+		SpanOpt:  stop.SterileRootSpan,
+	}, func(ctx context.Context) {
+		ctx, cancel := s.stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+
+		timer := timeutil.NewTimer()
+		defer timer.Stop()
+		timer.Reset(raftHibernationScanInterval)
+		for {
+			select {
+			case <-timer.C:
+				timer.Read = true
+				if idle := raftHibernationIdleDuration.Get(&s.ClusterSettings().SV); idle > 0 {
+					s.hibernateIdleReplicas(ctx, idle)
+				}
+				timer.Reset(raftHibernationScanInterval)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// hibernateIdleReplicas hibernates the replicas of the store which have been
+// idle for the given duration.
+func (s *Store) hibernateIdleReplicas(ctx context.Context, idle time.Duration) {
+	now := timeutil.Now()
+	var n int
+	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+		if r.maybeHibernate(ctx, now, idle) {
+			n++
+		}
+		return ctx.Err() == nil
+	})
+	if n > 0 {
+		log.VEventf(ctx, 2, "hibernated %d replicas", n)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/raft/v3"
+)
+
+func TestReplicaRaftHibernation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	tc.Start(ctx, t, stopper)
+	repl := tc.repl
+
+	// The replica is the leader and leaseholder, so it doesn't hibernate.
+	require.False(t, repl.maybeHibernate(ctx, timeutil.Now(), 0))
+	require.False(t, repl.IsHibernated())
+
+	// Release the raft group as if the replica hibernated.
+	hibernate := func() {
+		repl.raftMu.Lock()
+		defer repl.raftMu.Unlock()
+		repl.mu.Lock()
+		defer repl.mu.Unlock()
+		repl.mu.internalRaftGroup = nil
+		repl.mu.hibernated = true
+		repl.mu.quiescent = true
+	}
+	hibernate()
+
+	// Without raftMu, the raft group isn't woken.
+	require.ErrorIs(t, repl.withRaftGroup(func(rg *raft.RawNode) (bool, error) {
+		t.Fatal("unexpected call with a hibernated raft group")
+		return false, nil
+	}), errRaftGroupHibernated)
+	require.False(t, repl.maybeUnquiesce(ctx, true /* wakeLeader */, true /* mayCampaign */))
+	require.True(t, repl.IsHibernated())
+
+	// With raftMu, the raft group is reconstructed.
+	func() {
+		repl.raftMu.Lock()
+		defer repl.raftMu.Unlock()
+		repl.mu.Lock()
+		defer repl.mu.Unlock()
+		require.NoError(t, repl.maybeWakeRaftGroupRaftMuLockedReplicaMuLocked())
+	}()
+	require.False(t, repl.IsHibernated())
+	require.NoError(t, repl.withRaftGroup(func(rg *raft.RawNode) (bool, error) {
+		require.NotNil(t, rg)
+		return false, nil
+	}))
+	require.NotNil(t, repl.RaftStatus())
+	require.GreaterOrEqual(t, tc.store.Metrics().RaftHibernationWakes.Count(), int64(1))
+
+	// A proposal to a hibernated replica wakes its raft group when the Ready
+	// handling it schedules runs.
+	hibernate()
+	key := roachpb.Key("a")
+	pArgs := putArgs(key, []byte("value"))
+	_, pErr := tc.SendWrapped(&pArgs)
+	require.NoError(t, pErr.GoError())
+	require.False(t, repl.IsHibernated())
+}
+
+// TestReplicaRaftHibernationDuringSnapshot verifies that a snapshot whose send
+// completes after the sending replica hibernated is reported without waking or
+// crashing the replica: the hibernated raft group tracks no progress for the
+// recipient.
+func TestReplicaRaftHibernationDuringSnapshot(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	tc.Start(ctx, t, stopper)
+	repl := tc.repl
+
+	// Start a snapshot send, which reports its status when done.
+	sent := make(chan error)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		repl.reportSnapshotStatus(ctx, 2 /* to */, <-sent)
+	}()
+
+	// While the snapshot is in flight, the replica loses leadership, quiesces
+	// and hibernates.
+	func() {
+		repl.raftMu.Lock()
+		defer repl.raftMu.Unlock()
+		repl.mu.Lock()
+		defer repl.mu.Unlock()
+		repl.mu.internalRaftGroup = nil
+		repl.mu.hibernated = true
+		repl.mu.quiescent = true
+	}()
+
+	// Completing the send, successfully or not, leaves the replica hibernated.
+	sent <- nil
+	<-done
+	require.True(t, repl.IsHibernated())
+	repl.reportSnapshotStatus(ctx, 2 /* to */, errors.New("boom"))
+	require.True(t, repl.IsHibernated())
+}
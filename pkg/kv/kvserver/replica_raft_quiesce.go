@@ -43,6 +43,7 @@ func (r *Replica) quiesceLocked(ctx context.Context, lagging laggingReplicaSet)
 			log.Infof(ctx, "quiescing r%d", r.RangeID)
 		}
 		r.mu.quiescent = true
+		r.mu.quiescedAt = timeutil.Now()
 		r.mu.laggingFollowersOnQuiesce = lagging
 		r.store.unquiescedReplicas.Lock()
 		delete(r.store.unquiescedReplicas.m, r.RangeID)
@@ -138,7 +139,8 @@ func (r *Replica) canUnquiesceRLocked() bool {
 		// letting them unquiesce and tick every 500ms indefinitely avoids a
 		// meaningful amount of periodic work for each uninitialized replica.
 		r.IsInitialized() &&
-		// Destroyed replicas have no Raft group, and can't unquiesce.
+		// Destroyed and hibernated replicas have no Raft group, and can't
+		// unquiesce. Hibernated ones are unquiesced once their group is woken.
 		r.mu.internalRaftGroup != nil
 }
 
@@ -703,6 +703,23 @@ func (r *Replica) applySnapshot(
 			r.store.StoreID(), ingestStats, writeBytes)
 	}
 	stats.ingestion = timeutil.Now()
+	r.activity.record(stats.ingestion, replicaActivityCounts{snapshotBytes: inSnap.SSTSize})
+
+	// The user keys of subsumed replicas which extend beyond the snapshot have
+	// been cleared. Compact them to reclaim their disk space.
+	for _, sr := range subsumedRepls {
+		srDesc := sr.Desc()
+		if srDesc.EndKey.Compare(desc.EndKey) <= 0 {
+			continue
+		}
+		startKey := srDesc.StartKey
+		if startKey.Less(desc.EndKey) {
+			startKey = desc.EndKey
+		}
+		r.store.hintCompaction(ctx, roachpb.Span{
+			Key: startKey.AsRawKey(), EndKey: srDesc.EndKey.AsRawKey(),
+		}, sr.GetMVCCStats().Total())
+	}
 
 	// The on-disk state is now committed, but the corresponding in-memory state
 	// has not yet been updated. Any errors past this point must therefore be
@@ -794,11 +811,14 @@ func (r *Replica) applySnapshot(
 	r.store.metrics.subtractMVCCStats(ctx, r.tenantMetricsRef, *r.mu.state.Stats)
 	r.store.metrics.addMVCCStats(ctx, r.tenantMetricsRef, *state.Stats)
 	lastKnownLease := r.mu.state.Lease
+	prevState := r.mu.state
 	// Update the rest of the Raft state. Changes to r.mu.state.Desc must be
 	// managed by r.setDescRaftMuLocked and changes to r.mu.state.Lease must be handled
 	// by r.leasePostApply, but we called those above, so now it's safe to
 	// wholesale replace r.mu.state.
 	r.mu.state = state
+	// The snapshot may have changed the hot standby of the range.
+	r.updateHotStandbyLocked(ctx)
 	// Snapshots typically have fewer log entries than the leaseholder. The next
 	// time we hold the lease, recompute the log size before making decisions.
 	r.mu.raftLogSizeTrusted = false
@@ -819,6 +839,8 @@ func (r *Replica) applySnapshot(
 
 	// Inform the concurrency manager that this replica just applied a snapshot.
 	r.concMgr.OnReplicaSnapshotApplied()
+	// The snapshot replaced the keys whose usage the replica accounted for.
+	r.resetKeySpaceUsage()
 
 	if fn := r.store.cfg.TestingKnobs.AfterSnapshotApplication; fn != nil {
 		desc, _ := r.getReplicaDescriptorRLocked()
@@ -827,6 +849,9 @@ func (r *Replica) applySnapshot(
 
 	r.mu.Unlock()
 
+	// The snapshot may have changed any of the range-local state.
+	r.notifyRangeStateReplaced(ctx, &prevState, &state)
+
 	// Assert that the in-memory and on-disk states of the Replica are congruent
 	// after the application of the snapshot. Do so under a read lock, as this
 	// operation can be expensive. This is safe, as we hold the Replica.raftMu
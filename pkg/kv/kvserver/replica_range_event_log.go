@@ -0,0 +1,126 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/errors"
+)
+
+// rangeEventLogMaxEntries bounds the number of events retained in the range
+// event log of a replica. The oldest events are removed as new ones are
+// recorded.
+const rangeEventLogMaxEntries = 64
+
+// makeRangeEvent returns the event to record in the range event log for a
+// command applied at the given index, given its replicated result and the
+// lease in effect before it applies, if the command carries one. Splits and
+// merges are only recorded by the left-hand side, which survives them. The
+// commands carrying these events carry no other.
+func makeRangeEvent(
+	index kvpb.RaftIndex, res *kvserverpb.ReplicatedEvalResult, prevLease *roachpb.Lease,
+) (kvserverpb.RangeEvent, bool) {
+	ev := kvserverpb.RangeEvent{RaftIndex: index, Timestamp: res.WriteTimestamp}
+	switch {
+	case res.Split != nil:
+		left, right := res.Split.LeftDesc, res.Split.RightDesc
+		ev.Type, ev.Desc, ev.OtherDesc = kvserverpb.RangeEvent_SPLIT, &left, &right
+	case res.Merge != nil:
+		left, right := res.Merge.LeftDesc, res.Merge.RightDesc
+		ev.Type, ev.Desc, ev.OtherDesc = kvserverpb.RangeEvent_MERGE, &left, &right
+	case res.ChangeReplicas != nil && res.ChangeReplicas.Desc != nil:
+		desc := *res.ChangeReplicas.Desc
+		ev.Type, ev.Desc = kvserverpb.RangeEvent_CHANGE_REPLICAS, &desc
+	case res.State != nil && res.State.Lease != nil:
+		lease := *res.State.Lease
+		if prevLease != nil && prevLease.Sequence == lease.Sequence {
+			// An extension of the current lease.
+			return kvserverpb.RangeEvent{}, false
+		}
+		ev.Type, ev.Timestamp, ev.Lease = kvserverpb.RangeEvent_LEASE, lease.Start.ToTimestamp(), &lease
+	default:
+		return kvserverpb.RangeEvent{}, false
+	}
+	return ev, true
+}
+
+// writeRangeEvent records the given event in the range event log of the
+// replica with the given range ID, and removes the oldest events in excess of
+// rangeEventLogMaxEntries.
+//
+// The log is unreplicated: it is written by each replica as it applies the
+// commands carrying the events, does not count towards the MVCC stats of the
+// range, and is not transferred in snapshots. A replica initialized by a
+// snapshot starts with an empty log.
+func writeRangeEvent(
+	ctx context.Context, rw storage.ReadWriter, rangeID roachpb.RangeID, ev *kvserverpb.RangeEvent,
+) error {
+	buf := keys.MakeRangeIDPrefixBuf(rangeID)
+	if err := storage.MVCCBlindPutProto(
+		ctx, rw, buf.RangeEventLogKey(ev.RaftIndex), hlc.Timestamp{}, ev, storage.MVCCWriteOptions{},
+	); err != nil {
+		return errors.Wrapf(err, "unable to record range event at index %d", ev.RaftIndex)
+	}
+
+	prefix := buf.RangeEventLogPrefix()
+	var eventKeys []roachpb.Key
+	if _, err := storage.MVCCIterate(ctx, rw, prefix, prefix.PrefixEnd(), hlc.Timestamp{},
+		storage.MVCCScanOptions{}, func(kv roachpb.KeyValue) error {
+			eventKeys = append(eventKeys, kv.Key)
+			return nil
+		}); err != nil {
+		return errors.Wrap(err, "unable to scan range event log")
+	}
+	for len(eventKeys) > rangeEventLogMaxEntries {
+		if err := rw.ClearUnversioned(eventKeys[0], storage.ClearOptions{}); err != nil {
+			return errors.Wrap(err, "unable to remove range event")
+		}
+		eventKeys = eventKeys[1:]
+	}
+	return nil
+}
+
+// readRangeEvents returns the events in the range event log of the replica
+// with the given range ID, from the oldest to the newest.
+func readRangeEvents(
+	ctx context.Context, reader storage.Reader, rangeID roachpb.RangeID,
+) ([]kvserverpb.RangeEvent, error) {
+	prefix := keys.RangeEventLogPrefix(rangeID)
+	var events []kvserverpb.RangeEvent
+	if _, err := storage.MVCCIterate(ctx, reader, prefix, prefix.PrefixEnd(), hlc.Timestamp{},
+		storage.MVCCScanOptions{}, func(kv roachpb.KeyValue) error {
+			var ev kvserverpb.RangeEvent
+			if err := kv.Value.GetProto(&ev); err != nil {
+				return err
+			}
+			events = append(events, ev)
+			return nil
+		}); err != nil {
+		return nil, errors.Wrap(err, "unable to read range event log")
+	}
+	return events, nil
+}
+
+// RangeEventLog returns the events recorded in the range event log of the
+// replica, from the oldest to the newest. Unlike the system.rangelog table,
+// the log is always up to date with the applied state of the replica, but
+// only retains its latest events, and starts empty on replicas initialized by
+// a snapshot.
+func (r *Replica) RangeEventLog(ctx context.Context) ([]kvserverpb.RangeEvent, error) {
+	return readRangeEvents(ctx, r.store.StateEngine(), r.RangeID)
+}
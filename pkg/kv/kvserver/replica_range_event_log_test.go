@@ -0,0 +1,115 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeRangeEvent(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ts := hlc.Timestamp{WallTime: 100}
+	left := roachpb.RangeDescriptor{RangeID: 1, StartKey: roachpb.RKey("a"), EndKey: roachpb.RKey("b")}
+	right := roachpb.RangeDescriptor{RangeID: 2, StartKey: roachpb.RKey("b"), EndKey: roachpb.RKey("c")}
+	prevLease := &roachpb.Lease{Sequence: 4}
+
+	for _, tc := range []struct {
+		name     string
+		res      kvserverpb.ReplicatedEvalResult
+		expected kvserverpb.RangeEvent_Type
+	}{
+		{"none", kvserverpb.ReplicatedEvalResult{}, kvserverpb.RangeEvent_UNKNOWN},
+		{"split", kvserverpb.ReplicatedEvalResult{
+			Split: &kvserverpb.Split{SplitTrigger: roachpb.SplitTrigger{LeftDesc: left, RightDesc: right}},
+		}, kvserverpb.RangeEvent_SPLIT},
+		{"merge", kvserverpb.ReplicatedEvalResult{
+			Merge: &kvserverpb.Merge{MergeTrigger: roachpb.MergeTrigger{LeftDesc: left, RightDesc: right}},
+		}, kvserverpb.RangeEvent_MERGE},
+		{"change replicas", kvserverpb.ReplicatedEvalResult{
+			ChangeReplicas: &kvserverpb.ChangeReplicas{ChangeReplicasTrigger: roachpb.ChangeReplicasTrigger{Desc: &left}},
+		}, kvserverpb.RangeEvent_CHANGE_REPLICAS},
+		{"new lease", kvserverpb.ReplicatedEvalResult{
+			State: &kvserverpb.ReplicaState{Lease: &roachpb.Lease{Sequence: 5}},
+		}, kvserverpb.RangeEvent_LEASE},
+		{"lease extension", kvserverpb.ReplicatedEvalResult{
+			State: &kvserverpb.ReplicaState{Lease: &roachpb.Lease{Sequence: 4}},
+		}, kvserverpb.RangeEvent_UNKNOWN},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.res.WriteTimestamp = ts
+			ev, ok := makeRangeEvent(10, &tc.res, prevLease)
+			require.Equal(t, tc.expected != kvserverpb.RangeEvent_UNKNOWN, ok)
+			if !ok {
+				return
+			}
+			require.Equal(t, tc.expected, ev.Type)
+			require.Equal(t, kvpb.RaftIndex(10), ev.RaftIndex)
+			switch tc.expected {
+			case kvserverpb.RangeEvent_SPLIT, kvserverpb.RangeEvent_MERGE:
+				require.Equal(t, left, *ev.Desc)
+				require.Equal(t, right, *ev.OtherDesc)
+			case kvserverpb.RangeEvent_CHANGE_REPLICAS:
+				require.Equal(t, left, *ev.Desc)
+			case kvserverpb.RangeEvent_LEASE:
+				require.Equal(t, *tc.res.State.Lease, *ev.Lease)
+			}
+		})
+	}
+}
+
+func TestRangeEventLogIsBounded(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+
+	const rangeID = roachpb.RangeID(7)
+	const numEvents = rangeEventLogMaxEntries + 10
+	for i := 1; i <= numEvents; i++ {
+		ev := kvserverpb.RangeEvent{
+			Type:      kvserverpb.RangeEvent_LEASE,
+			RaftIndex: kvpb.RaftIndex(i),
+			Lease:     &roachpb.Lease{Sequence: roachpb.LeaseSequence(i)},
+		}
+		require.NoError(t, writeRangeEvent(ctx, eng, rangeID, &ev))
+	}
+	// The event log of another range is unaffected.
+	require.NoError(t, writeRangeEvent(ctx, eng, rangeID+1, &kvserverpb.RangeEvent{
+		Type: kvserverpb.RangeEvent_SPLIT, RaftIndex: 1,
+	}))
+
+	events, err := readRangeEvents(ctx, eng, rangeID)
+	require.NoError(t, err)
+	require.Len(t, events, rangeEventLogMaxEntries)
+	for i, ev := range events {
+		// Only the newest events are retained, in order.
+		require.Equal(t, kvpb.RaftIndex(numEvents-rangeEventLogMaxEntries+i+1), ev.RaftIndex)
+	}
+
+	events, err = readRangeEvents(ctx, eng, rangeID+1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, kvserverpb.RangeEvent_SPLIT, events[0].Type)
+}
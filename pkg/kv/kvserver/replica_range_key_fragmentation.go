@@ -0,0 +1,204 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/pebble"
+)
+
+// rangeKeyDefragmentationThreshold is the number of MVCC range key fragments
+// which, once observed overlapping a single applied range key write, get the
+// span of the replica compacted.
+var rangeKeyDefragmentationThreshold = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"kv.range_key_defragmentation.fragments_threshold",
+	"the number of MVCC range key fragments overlapping the span of an applied range "+
+		"key write above which the replica is compacted to defragment its range keys, "+
+		"which otherwise slow down scans (0 disables)",
+	100,
+	settings.NonNegativeInt,
+)
+
+// rangeKeyDefragmentationInterval is the interval at which each store compacts
+// its replicas with fragmented MVCC range keys.
+var rangeKeyDefragmentationInterval = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.range_key_defragmentation.interval",
+	"the interval at which each store compacts its replicas with fragmented MVCC "+
+		"range keys",
+	10*time.Minute,
+	settings.PositiveDuration,
+)
+
+// maxRangeKeyFragmentsCounted bounds the number of fragments counted for each
+// applied range key write, bounding the cost of the count on the apply path.
+const maxRangeKeyFragmentsCounted = 1024
+
+// rangeKeyFragmentation tracks the fragmentation of the MVCC range keys of a
+// replica. Pebble fragments range keys at the bounds of the range keys they
+// overlap, so e.g. a series of DeleteRange tombstones with staggered bounds
+// leaves many small fragments, each of which scans have to step over.
+// Compacting the span into the bottom of the LSM coalesces the fragments whose
+// range keys have since been removed.
+type rangeKeyFragmentation struct {
+	// fragments is the largest number of fragments observed overlapping the
+	// span of an applied range key write since the replica was last
+	// defragmented.
+	fragments atomic.Int64
+}
+
+// observe records the number of fragments overlapping an applied range key
+// write.
+func (f *rangeKeyFragmentation) observe(n int64) {
+	for {
+		cur := f.fragments.Load()
+		if n <= cur || f.fragments.CompareAndSwap(cur, n) {
+			return
+		}
+	}
+}
+
+// rangeKeyWriteSpans returns the spans of the MVCC range keys set, unset or
+// deleted by the given write batch.
+func rangeKeyWriteSpans(repr []byte) ([]roachpb.Span, error) {
+	reader, err := storage.NewBatchReader(repr)
+	if err != nil {
+		return nil, err
+	}
+	var spans []roachpb.Span
+	for reader.Next() {
+		switch reader.KeyKind() {
+		case pebble.InternalKeyKindRangeKeySet, pebble.InternalKeyKindRangeKeyUnset,
+			pebble.InternalKeyKindRangeKeyDelete:
+		default:
+			continue
+		}
+		start, err := reader.EngineKey()
+		if err != nil {
+			return nil, err
+		}
+		end, err := reader.EngineEndKey()
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, roachpb.Span{Key: start.Key, EndKey: end.Key})
+	}
+	return spans, reader.Error()
+}
+
+// countRangeKeyFragments returns the number of MVCC range key fragments of the
+// reader which overlap the given span, up to the given limit.
+func countRangeKeyFragments(
+	ctx context.Context, reader storage.Reader, span roachpb.Span, limit int64,
+) (int64, error) {
+	iter, err := reader.NewMVCCIterator(ctx, storage.MVCCKeyIterKind, storage.IterOptions{
+		KeyTypes:     storage.IterKeyTypeRangesOnly,
+		LowerBound:   span.Key,
+		UpperBound:   span.EndKey,
+		ReadCategory: storage.ReplicationReadCategory,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+	var n int64
+	for iter.SeekGE(storage.MVCCKey{Key: span.Key}); n < limit; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			return 0, err
+		} else if !ok {
+			break
+		}
+		n++
+	}
+	return n, nil
+}
+
+// recordRangeKeyFragmentation accounts for the MVCC range keys written by the
+// given write batch, and for the fragmentation of the range keys they overlap
+// in the given reader, in which the write batch must already be staged.
+func (r *Replica) recordRangeKeyFragmentation(
+	ctx context.Context, reader storage.Reader, repr []byte,
+) {
+	spans, err := rangeKeyWriteSpans(repr)
+	if err != nil {
+		log.Errorf(ctx, "unable to read committed WriteBatch: %+v", err)
+		return
+	}
+	if len(spans) == 0 {
+		return
+	}
+	r.store.metrics.RangeKeyWritesApplied.Inc(int64(len(spans)))
+	for _, sp := range spans {
+		n, err := countRangeKeyFragments(ctx, reader, sp, maxRangeKeyFragmentsCounted)
+		if err != nil {
+			log.Errorf(ctx, "unable to count range key fragments in %s: %+v", sp, err)
+			return
+		}
+		r.store.metrics.RangeKeyOverlappingFragments.RecordValue(n)
+		r.rangeKeyFragmentation.observe(n)
+	}
+}
+
+// startRangeKeyDefragmenter starts a worker which periodically queues the
+// compaction of the replicas of the store whose MVCC range keys are
+// fragmented, see rangeKeyFragmentation.
+func (s *Store) startRangeKeyDefragmenter(ctx context.Context) {
+	_ /* err */ = s.stopper.RunAsyncTaskEx(ctx, stop.TaskOpts{
+		TaskName: "range-key-defragmenter",
+		SpanOpt:  stop.SterileRootSpan,
+	}, func(ctx context.Context) {
+		ctx, cancel := s.stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+
+		timer := timeutil.NewTimer()
+		defer timer.Stop()
+		for {
+			timer.Reset(rangeKeyDefragmentationInterval.Get(&s.ClusterSettings().SV))
+			select {
+			case <-timer.C:
+				timer.Read = true
+				s.defragmentRangeKeys(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// defragmentRangeKeys runs a single pass over the replicas of the store,
+// queueing the compaction of those whose MVCC range keys are fragmented.
+func (s *Store) defragmentRangeKeys(ctx context.Context) {
+	threshold := rangeKeyDefragmentationThreshold.Get(&s.ClusterSettings().SV)
+	if threshold == 0 {
+		return
+	}
+	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+		if r.rangeKeyFragmentation.fragments.Load() < threshold {
+			return true
+		}
+		r.rangeKeyFragmentation.fragments.Store(0)
+		span := r.Desc().KeySpan().AsRawSpanWithNoLocals()
+		log.VEventf(ctx, 2, "r%d: queueing compaction to defragment range keys", r.RangeID)
+		s.metrics.RangeKeyDefragmentationsQueued.Inc(1)
+		s.queueCompactionHint(ctx, span, 0 /* bytes */)
+		return ctx.Err() == nil
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeKeyFragmentation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+
+	span := func(start, end string) roachpb.Span {
+		return roachpb.Span{Key: roachpb.Key(start), EndKey: roachpb.Key(end)}
+	}
+	putRangeKey := func(w storage.Writer, sp roachpb.Span, wallTime int64) {
+		require.NoError(t, w.PutMVCCRangeKey(storage.MVCCRangeKey{
+			StartKey:  sp.Key,
+			EndKey:    sp.EndKey,
+			Timestamp: hlc.Timestamp{WallTime: wallTime},
+		}, storage.MVCCValue{}))
+	}
+
+	// Range keys with staggered bounds fragment each other:
+	// [a,b) [b,c) [c,d) [d,e).
+	putRangeKey(eng, span("a", "c"), 1)
+	putRangeKey(eng, span("b", "d"), 2)
+	putRangeKey(eng, span("c", "e"), 3)
+
+	batch := eng.NewBatch()
+	defer batch.Close()
+	putRangeKey(batch, span("a", "e"), 4)
+	require.NoError(t, batch.PutMVCC(storage.MVCCKey{
+		Key: roachpb.Key("f"), Timestamp: hlc.Timestamp{WallTime: 4},
+	}, storage.MVCCValue{Value: roachpb.MakeValueFromString("f")}))
+
+	// Only the range key write is returned.
+	spans, err := rangeKeyWriteSpans(batch.Repr())
+	require.NoError(t, err)
+	require.Equal(t, []roachpb.Span{span("a", "e")}, spans)
+
+	// The range key write is fragmented by those it overlaps.
+	n, err := countRangeKeyFragments(ctx, batch, span("a", "e"), maxRangeKeyFragmentsCounted)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), n)
+	n, err = countRangeKeyFragments(ctx, batch, span("a", "e"), 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+	n, err = countRangeKeyFragments(ctx, batch, span("e", "z"), maxRangeKeyFragmentsCounted)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	// The largest fragmentation observed is retained.
+	var f rangeKeyFragmentation
+	f.observe(4)
+	f.observe(2)
+	require.Equal(t, int64(4), f.fragments.Load())
+	f.observe(7)
+	require.Equal(t, int64(7), f.fragments.Load())
+}
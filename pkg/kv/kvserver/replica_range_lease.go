@@ -124,6 +124,19 @@ var LeaseCheckPreferencesOnAcquisitionEnabled = settings.RegisterBoolSetting(
 	true,
 )
 
+// leaseTransferMaxTargetLag bounds the number of log entries applied by the
+// leaseholder which the target of a cooperative lease transfer can be missing.
+// A target further behind would take over the lease, but be unable to serve
+// requests until it caught up on its log.
+var leaseTransferMaxTargetLag = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"kv.lease_transfer.max_target_lag",
+	"the maximum number of log entries applied by the leaseholder which the target "+
+		"of a lease transfer can be missing, beyond which the transfer is refused (0 disables)",
+	0,
+	settings.NonNegativeInt,
+)
+
 var leaseStatusLogLimiter = func() *log.EveryN {
 	e := log.Every(15 * time.Second)
 	e.ShouldLog() // waste the first shot
@@ -324,6 +337,7 @@ func (p *pendingLeaseRequest) InitOrJoinRequest(
 			return llHandle
 		}
 		reqLease.Epoch = l.Epoch
+		reqLease.StoreEpoch = l.StoreEpoch(nextLeaseHolder.StoreID)
 	}
 
 	var leaseReq kvpb.Request
@@ -354,7 +368,7 @@ func (p *pendingLeaseRequest) InitOrJoinRequest(
 		}
 	}
 
-	err := p.requestLeaseAsync(ctx, nextLeaseHolder, status, leaseReq, limiter)
+	err := p.requestLeaseAsync(ctx, nextLeaseHolder, status, leaseReq, acquisition, limiter)
 	if err != nil {
 		if errors.Is(err, stop.ErrThrottled) {
 			llHandle.resolve(kvpb.NewError(err))
@@ -381,7 +395,8 @@ func (p *pendingLeaseRequest) InitOrJoinRequest(
 // requestLeaseAsync sends a transfer lease or lease request to the specified
 // replica. The request is sent in an async task. If limiter is non-nil, it is
 // used to bound the number of goroutines spawned, returning ErrThrottled when
-// exceeded.
+// exceeded. Acquisitions of a lease from another store additionally wait for
+// their turn in the store's leaseAcquisitionLimiter.
 //
 // The status argument is used as the expected value for liveness operations.
 // leaseReq must be consistent with the LeaseStatus.
@@ -390,8 +405,15 @@ func (p *pendingLeaseRequest) requestLeaseAsync(
 	nextLeaseHolder roachpb.ReplicaDescriptor,
 	status kvserverpb.LeaseStatus,
 	leaseReq kvpb.Request,
+	acquisition bool,
 	limiter *quotapool.IntPool,
 ) error {
+	var acquisitionPriority float64
+	if acquisition {
+		acquisitionPriority = leaseAcquisitionPriority(
+			p.repl.mu.state.Desc, p.repl.loadStats.Stats().RequestsPerSecond)
+	}
+
 	// Create a new context. We run the request to completion even if all callers
 	// go away, to ensure leases can be acquired e.g. in the face of IO delays
 	// which may trigger client timeouts).
@@ -424,7 +446,18 @@ func (p *pendingLeaseRequest) requestLeaseAsync(
 			// RPC, but here we submit the request directly to the local replica.
 			growstack.Grow()
 
-			err := p.requestLease(ctx, nextLeaseHolder, status, leaseReq)
+			var err error
+			if acquisition {
+				var release func()
+				release, err = p.repl.store.leaseAcquisitionLimiter.acquire(
+					ctx, p.repl.store.Stopper(), acquisitionPriority)
+				if err == nil {
+					defer release()
+				}
+			}
+			if err == nil {
+				err = p.requestLease(ctx, nextLeaseHolder, status, leaseReq)
+			}
 			// Error will be handled below.
 
 			// We reset our state below regardless of whether we've gotten an error or
@@ -485,6 +518,13 @@ func (p *pendingLeaseRequest) requestLease(
 			if err = p.repl.store.cfg.NodeLiveness.Heartbeat(ctx, status.Liveness); err != nil && logFailedHeartbeatOwnLiveness.ShouldLog() {
 				log.Errorf(ctx, "failed to heartbeat own liveness record: %s", err)
 			}
+		} else if status.Liveness.StoreEpoch(status.Lease.Replica.StoreID) > status.Lease.StoreEpoch {
+			// The lease expired because the epoch of the previous leaseholder's
+			// store was incremented. Its expiration is capped for good by the
+			// increment, so it can't become valid again when the node heartbeats,
+			// and there's no need to increment the epoch of the node.
+			log.VEventf(ctx, 2, "not incrementing epoch on n%d because the epoch of s%d was incremented",
+				status.Liveness.NodeID, status.Lease.Replica.StoreID)
 		} else if status.Liveness.Epoch == status.Lease.Epoch {
 			// If not owner, increment epoch if necessary to invalidate lease.
 			// However, we only do so in the event that the next leaseholder is
@@ -725,7 +765,9 @@ func (r *Replica) leaseStatus(
 	} else {
 		l, ok := r.store.cfg.NodeLiveness.GetLiveness(lease.Replica.NodeID)
 		status.Liveness = l.Liveness
-		if !ok || status.Liveness.Epoch < lease.Epoch {
+		storeEpoch := status.Liveness.StoreEpoch(lease.Replica.StoreID)
+		if !ok || status.Liveness.Epoch < lease.Epoch ||
+			(status.Liveness.Epoch == lease.Epoch && storeEpoch < lease.StoreEpoch) {
 			// If lease validity can't be determined (e.g. gossip is down
 			// and liveness info isn't available for owner), we can neither
 			// use the lease nor do we want to attempt to acquire it.
@@ -748,7 +790,11 @@ func (r *Replica) leaseStatus(
 			status.State = kvserverpb.LeaseState_EXPIRED
 			return status
 		}
-		expiration = status.Liveness.Expiration.ToTimestamp()
+		// If the epoch of the leaseholder's store was incremented after its disk
+		// failed, the lease expires with the liveness record that the increment
+		// replaced instead of the current one. This is the latest expiration that
+		// the leaseholder may have used to serve requests under the lease.
+		expiration = status.Expiration()
 	}
 	maxOffset := r.store.Clock().MaxOffset()
 	stasis := expiration.Add(-int64(maxOffset), 0)
@@ -939,6 +985,11 @@ func (r *Replica) AdminTransferLease(
 		if nextLeaseHolder, ok = desc.GetReplicaDescriptor(target); !ok {
 			return nil, nil, roachpb.ErrReplicaNotFound
 		}
+		// Stores in non-voting mode don't hold leases, which only voters left
+		// over from before the store's mode was changed could acquire.
+		if !bypassSafetyChecks && r.store.isNonVotingStore(target) {
+			return nil, nil, errors.Errorf("can't transfer the lease to s%d, which is in non-voting mode", target)
+		}
 
 		if nextLease, ok := r.mu.pendingLeaseRequest.RequestPending(); ok &&
 			nextLease.Replica != nextLeaseHolder {
@@ -969,11 +1020,28 @@ func (r *Replica) AdminTransferLease(
 		snapStatus := raftutil.ReplicaMayNeedSnapshot(raftStatus, raftFirstIndex, nextLeaseHolder.ReplicaID)
 		if snapStatus != raftutil.NoSnapshotNeeded && !bypassSafetyChecks && !r.store.cfg.TestingKnobs.DisableAboveRaftLeaseTransferSafetyChecks {
 			r.store.metrics.LeaseTransferErrorCount.Inc(1)
+			r.store.metrics.LeaseTransferRejectedMayNeedSnapshotCount.Inc(1)
 			log.VEventf(ctx, 2, "not initiating lease transfer because the target %s may "+
 				"need a snapshot: %s", nextLeaseHolder, snapStatus)
 			err := NewLeaseTransferRejectedBecauseTargetMayNeedSnapshotError(nextLeaseHolder, snapStatus)
 			return nil, nil, err
 		}
+		// Verify that the target isn't so far behind on its log that it would
+		// take over the lease without being able to serve requests until it
+		// catches up. The target's applied index isn't known to the leader, but
+		// it can't be past the target's match index.
+		maxLag := uint64(leaseTransferMaxTargetLag.Get(&r.store.ClusterSettings().SV))
+		if maxLag > 0 && !bypassSafetyChecks {
+			lag, ok := raftutil.ReplicaLag(raftStatus, r.mu.state.RaftAppliedIndex, nextLeaseHolder.ReplicaID)
+			if ok && lag > maxLag {
+				r.store.metrics.LeaseTransferErrorCount.Inc(1)
+				r.store.metrics.LeaseTransferRejectedTargetLagCount.Inc(1)
+				log.VEventf(ctx, 2, "not initiating lease transfer because the target %s is "+
+					"%d entries behind the applied index", nextLeaseHolder, lag)
+				err := NewLeaseTransferRejectedBecauseTargetIsBehindError(nextLeaseHolder, lag, maxLag)
+				return nil, nil, err
+			}
+		}
 
 		transfer = r.mu.pendingLeaseRequest.InitOrJoinRequest(ctx, nextLeaseHolder, status,
 			desc.StartKey.AsRawKey(), true /* transfer */, bypassSafetyChecks, nil /* limiter */)
@@ -1005,11 +1073,13 @@ func (r *Replica) AdminTransferLease(
 		// If there isn't, request a transfer.
 		extension, transfer, err := initTransferHelper()
 		if err != nil {
-			if IsLeaseTransferRejectedBecauseTargetMayNeedSnapshotError(err) && transferRejectedRetry.Next() {
+			if (IsLeaseTransferRejectedBecauseTargetMayNeedSnapshotError(err) ||
+				IsLeaseTransferRejectedBecauseTargetIsBehindError(err)) && transferRejectedRetry.Next() {
 				// If the lease transfer was rejected because the target may need a
 				// snapshot, try again. After the backoff, we may have become the Raft
 				// leader (through maybeTransferRaftLeadershipToLeaseholderLocked) or
 				// may have learned more about the state of the lease target's log.
+				// Similarly, a target which was behind may have caught up.
 				log.VEventf(ctx, 2, "retrying lease transfer to store %d after rejection", target)
 				continue
 			}
@@ -1078,6 +1148,18 @@ func NewLeaseTransferRejectedBecauseTargetMayNeedSnapshotError(
 	return errors.Mark(err, errMarkLeaseTransferRejectedBecauseTargetMayNeedSnapshot)
 }
 
+// NewLeaseTransferRejectedBecauseTargetIsBehindError returns an error
+// indicating that a lease transfer failed because the lease transfer target
+// was missing more log entries applied by the current leaseholder than allowed
+// by kv.lease_transfer.max_target_lag.
+func NewLeaseTransferRejectedBecauseTargetIsBehindError(
+	target roachpb.ReplicaDescriptor, lag, maxLag uint64,
+) error {
+	err := errors.Errorf("refusing to transfer lease to %d because target is %d entries "+
+		"behind the applied index (max %d)", target, lag, maxLag)
+	return errors.Mark(err, errMarkLeaseTransferRejectedBecauseTargetIsBehind)
+}
+
 // checkRequestTimeRLocked checks that the provided request timestamp is not
 // too far in the future. We define "too far" as a time that would require a
 // lease extension even if we were perfectly proactive about extending our
@@ -0,0 +1,108 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/multiqueue"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+)
+
+// maxConcurrentLeaseAcquisitions bounds the number of lease acquisitions a
+// store has in flight. When a region fails, the ranges whose leaseholders were
+// in it all acquire new leases as requests arrive: without a limit, the
+// liveness epoch increments and lease proposals of thousands of ranges compete
+// with each other and with the foreground traffic, and the store can collapse
+// under the load right after the failover.
+var maxConcurrentLeaseAcquisitions = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"kv.lease.max_concurrent_acquisitions",
+	"maximum number of lease acquisitions a store has in flight; the other ones "+
+		"wait their turn, system ranges first and then by decreasing load; 0 disables the limit",
+	256,
+	settings.NonNegativeInt,
+)
+
+// leaseAcquisitionLimiter bounds the number of lease acquisitions of a store in
+// flight, see kv.lease.max_concurrent_acquisitions. Lease extensions and
+// transfers aren't limited: they are cheap, and delaying them could cause the
+// leases to expire.
+type leaseAcquisitionLimiter struct {
+	st      *cluster.Settings
+	queue   *multiqueue.MultiQueue
+	metrics *StoreMetrics
+}
+
+func newLeaseAcquisitionLimiter(
+	st *cluster.Settings, metrics *StoreMetrics,
+) *leaseAcquisitionLimiter {
+	l := &leaseAcquisitionLimiter{
+		st:      st,
+		queue:   multiqueue.NewMultiQueue(int(maxConcurrentLeaseAcquisitions.Get(&st.SV))),
+		metrics: metrics,
+	}
+	maxConcurrentLeaseAcquisitions.SetOnChange(&st.SV, func(context.Context) {
+		if limit := maxConcurrentLeaseAcquisitions.Get(&st.SV); limit > 0 {
+			l.queue.UpdateConcurrencyLimit(int(limit))
+		}
+	})
+	return l
+}
+
+// leaseAcquisitionPriority returns the priority of the lease acquisition of a
+// range. System ranges come first, since all the other ranges depend on them,
+// and then ranges by decreasing load, so that the ranges serving most of the
+// traffic recover first.
+func leaseAcquisitionPriority(desc *roachpb.RangeDescriptor, requestsPerSecond float64) float64 {
+	if desc.StartKey.Less(roachpb.RKey(keys.SystemSQLCodec.TablePrefix(keys.MaxReservedDescID + 1))) {
+		return math.MaxFloat64
+	}
+	return requestsPerSecond
+}
+
+// acquire waits for the turn of a lease acquisition with the given priority.
+// The returned function must be called once the acquisition is done.
+func (l *leaseAcquisitionLimiter) acquire(
+	ctx context.Context, stopper *stop.Stopper, priority float64,
+) (release func(), _ error) {
+	if maxConcurrentLeaseAcquisitions.Get(&l.st.SV) == 0 {
+		return func() {}, nil
+	}
+	task, err := l.queue.Add(0 /* queueType */, priority, -1 /* maxQueueLength */)
+	if err != nil {
+		return nil, err
+	}
+	var permit *multiqueue.Permit
+	select {
+	case permit = <-task.GetWaitChan():
+	default:
+		l.metrics.LeaseAcquisitionsWaited.Inc(1)
+		l.metrics.LeaseAcquisitionsQueued.Inc(1)
+		log.VEventf(ctx, 2, "waiting for the turn of the lease acquisition")
+		select {
+		case permit = <-task.GetWaitChan():
+		case <-stopper.ShouldQuiesce():
+		}
+		l.metrics.LeaseAcquisitionsQueued.Dec(1)
+		if permit == nil {
+			l.queue.Cancel(task)
+			return nil, stop.ErrUnavailable
+		}
+	}
+	return func() { l.queue.Release(permit) }, nil
+}
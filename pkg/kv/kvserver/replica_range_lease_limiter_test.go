@@ -0,0 +1,91 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaseAcquisitionPriority(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := func(startKey roachpb.Key) *roachpb.RangeDescriptor {
+		return &roachpb.RangeDescriptor{StartKey: roachpb.RKey(startKey)}
+	}
+	systemPriority := leaseAcquisitionPriority(desc(keys.NodeLivenessPrefix), 0)
+	require.Equal(t, systemPriority,
+		leaseAcquisitionPriority(desc(keys.SystemSQLCodec.TablePrefix(keys.DescriptorTableID)), 0))
+
+	userDesc := desc(keys.SystemSQLCodec.TablePrefix(keys.MaxReservedDescID + 1))
+	hot, cold := leaseAcquisitionPriority(userDesc, 1000), leaseAcquisitionPriority(userDesc, 1)
+	require.Greater(t, systemPriority, hot)
+	require.Greater(t, hot, cold)
+}
+
+func TestLeaseAcquisitionLimiter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	maxConcurrentLeaseAcquisitions.Override(ctx, &st.SV, 1)
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	metrics := newStoreMetrics(time.Minute)
+	l := newLeaseAcquisitionLimiter(st, metrics)
+
+	release, err := l.acquire(ctx, stopper, 0)
+	require.NoError(t, err)
+
+	// The queued acquisitions run by decreasing priority.
+	order := make(chan float64, 2)
+	for i, priority := range []float64{1, 2} {
+		priority := priority
+		go func() {
+			release, err := l.acquire(ctx, stopper, priority)
+			if err != nil {
+				order <- -1
+				return
+			}
+			order <- priority
+			release()
+		}()
+		require.Eventually(t, func() bool {
+			return metrics.LeaseAcquisitionsQueued.Value() == int64(i+1)
+		}, 10*time.Second, time.Millisecond)
+	}
+	release()
+	require.Equal(t, 2.0, <-order)
+	require.Equal(t, 1.0, <-order)
+	require.EqualValues(t, 2, metrics.LeaseAcquisitionsWaited.Count())
+	require.Zero(t, metrics.LeaseAcquisitionsQueued.Value())
+
+	// Without a limit, acquisitions don't wait.
+	maxConcurrentLeaseAcquisitions.Override(ctx, &st.SV, 0)
+	release1, err := l.acquire(ctx, stopper, 0)
+	require.NoError(t, err)
+	release2, err := l.acquire(ctx, stopper, 0)
+	require.NoError(t, err)
+	release1()
+	release2()
+	require.EqualValues(t, 2, metrics.LeaseAcquisitionsWaited.Count())
+}
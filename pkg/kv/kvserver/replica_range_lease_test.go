@@ -66,6 +66,8 @@ func TestReplicaLeaseStatus(t *testing.T) {
 		Epoch:      5,
 		ProposedTS: expLease.ProposedTS,
 	}
+	storeEpoLease := epoLease
+	storeEpoLease.StoreEpoch = 1
 
 	oldLiveness := livenesspb.Liveness{
 		NodeID: 1, Epoch: 4, Expiration: hlc.LegacyTimestamp{WallTime: ts[1].WallTime},
@@ -76,6 +78,14 @@ func TestReplicaLeaseStatus(t *testing.T) {
 	expLiveness := livenesspb.Liveness{
 		NodeID: 1, Epoch: 6, Expiration: hlc.LegacyTimestamp{WallTime: ts[6].WallTime},
 	}
+	// The liveness of the node after the epoch of s1 was incremented once and
+	// twice, respectively. The leases from the previous store epoch expire at
+	// storeExp, before the liveness record does.
+	storeExp := hlc.LegacyTimestamp{WallTime: 2500}
+	curStoreLiveness := curLiveness
+	curStoreLiveness.StoreEpochs = []livenesspb.StoreEpoch{{StoreID: 1, Epoch: 1, Expiration: storeExp}}
+	incStoreLiveness := curLiveness
+	incStoreLiveness.StoreEpochs = []livenesspb.StoreEpoch{{StoreID: 1, Epoch: 2, Expiration: storeExp}}
 
 	for _, tc := range []struct {
 		lease         roachpb.Lease
@@ -116,11 +126,25 @@ func TestReplicaLeaseStatus(t *testing.T) {
 		// Epoch-based lease, VALID.
 		{lease: epoLease, now: ts[2], liveness: curLiveness, want: kvserverpb.LeaseState_VALID},
 
+		// Epoch-based lease with a store epoch, EXPIRED.
+		{lease: epoLease, now: ts[3], liveness: curStoreLiveness, want: kvserverpb.LeaseState_EXPIRED},
+		{lease: storeEpoLease, now: ts[3], liveness: incStoreLiveness, want: kvserverpb.LeaseState_EXPIRED},
+		{lease: epoLease, now: ts[2], liveness: incStoreLiveness, want: kvserverpb.LeaseState_EXPIRED},
+		// Epoch-based lease with a store epoch, UNUSABLE.
+		{lease: epoLease, now: ts[2], liveness: curStoreLiveness,
+			reqTS: hlc.Timestamp{WallTime: storeExp.WallTime - 50}, want: kvserverpb.LeaseState_UNUSABLE},
+		// Epoch-based lease with a store epoch, VALID.
+		{lease: epoLease, now: ts[2], liveness: curStoreLiveness, want: kvserverpb.LeaseState_VALID},
+		{lease: storeEpoLease, now: ts[2], liveness: incStoreLiveness, want: kvserverpb.LeaseState_VALID},
+		{lease: storeEpoLease, now: ts[3], liveness: curStoreLiveness, want: kvserverpb.LeaseState_VALID},
+
 		// Epoch-based lease, ERROR.
 		{lease: epoLease, now: ts[2], want: kvserverpb.LeaseState_ERROR,
 			wantErr: "liveness record not found"},
 		{lease: epoLease, now: ts[2], liveness: oldLiveness, want: kvserverpb.LeaseState_ERROR,
 			wantErr: "node liveness info for n1 is stale"},
+		{lease: storeEpoLease, now: ts[2], liveness: curLiveness, want: kvserverpb.LeaseState_ERROR,
+			wantErr: "node liveness info for n1 is stale"},
 	} {
 		t.Run("", func(t *testing.T) {
 			cache :=
@@ -139,7 +163,7 @@ func TestReplicaLeaseStatus(t *testing.T) {
 				cfg:   StoreConfig{Clock: clock, NodeLiveness: l},
 			}}
 			var empty livenesspb.Liveness
-			if maybeLiveness := tc.liveness; maybeLiveness != empty {
+			if maybeLiveness := tc.liveness; !maybeLiveness.Equal(empty) {
 				l.TestingMaybeUpdate(ctx, liveness.Record{Liveness: maybeLiveness})
 			}
 
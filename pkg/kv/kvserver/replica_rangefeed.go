@@ -77,6 +77,27 @@ var RangeFeedSmearInterval = settings.RegisterDurationSetting(
 	settings.NonNegativeDuration,
 )
 
+// rangefeedCatchUpScanParallelism is the number of iterators the catch-up scan
+// of a large range uses concurrently.
+var rangefeedCatchUpScanParallelism = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"kv.rangefeed.catchup_scan.parallelism",
+	"the number of sub-spans the catch-up scan of a range larger than "+
+		"kv.rangefeed.catchup_scan.parallelism_min_range_size scans concurrently",
+	1,
+	settings.PositiveInt,
+)
+
+// rangefeedCatchUpScanParallelismMinRangeSize is the size of the ranges above
+// which catch-up scans are parallelized.
+var rangefeedCatchUpScanParallelismMinRangeSize = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.rangefeed.catchup_scan.parallelism_min_range_size",
+	"the minimum size of a range whose catch-up scans are split into "+
+		"kv.rangefeed.catchup_scan.parallelism sub-spans",
+	512<<20,
+)
+
 func init() {
 	// Inject into kvserverbase to allow usage from kvcoord.
 	kvserverbase.RangeFeedRefreshInterval = RangeFeedRefreshInterval
@@ -242,6 +263,15 @@ func (r *Replica) RangeFeed(
 		}
 	}
 
+	// Split large ranges into sub-spans which the catch-up scan scans
+	// concurrently. The split keys are estimated before locking raftMu, since
+	// they don't need to be consistent with the state captured by the
+	// iterators.
+	var catchUpSplitKeys []roachpb.Key
+	if usingCatchUpIter {
+		catchUpSplitKeys = r.catchUpScanSplitKeys(ctx, rSpan.AsRawSpanWithNoLocals())
+	}
+
 	// Lock the raftMu, then register the stream as a new rangefeed registration.
 	// raftMu is held so that the catch-up iterator is captured in the same
 	// critical-section as the registration is established. This ensures that
@@ -258,9 +288,9 @@ func (r *Replica) RangeFeed(
 	if usingCatchUpIter {
 		// Pass context.Background() since the context where the iter will be used
 		// is different.
-		catchUpIter, err = rangefeed.NewCatchUpIterator(
+		catchUpIter, err = rangefeed.NewParallelCatchUpIterator(
 			context.Background(), r.store.TODOEngine(), rSpan.AsRawSpanWithNoLocals(),
-			args.Timestamp, iterSemRelease, pacer)
+			catchUpSplitKeys, args.Timestamp, iterSemRelease, pacer)
 		if err != nil {
 			r.raftMu.Unlock()
 			iterSemRelease()
@@ -272,7 +302,8 @@ func (r *Replica) RangeFeed(
 	}
 	var done future.ErrorFuture
 	p := r.registerWithRangefeedRaftMuLocked(
-		ctx, rSpan, args.Timestamp, catchUpIter, args.WithDiff, args.WithFiltering, lockedStream, &done,
+		ctx, rSpan, args.Timestamp, catchUpIter, args.WithDiff, args.WithFiltering,
+		args.ResolvedTSInterval, args.OverflowPolicy, args.MVCCHistoryMutationToken, lockedStream, &done,
 	)
 	r.raftMu.Unlock()
 
@@ -284,6 +315,29 @@ func (r *Replica) RangeFeed(
 	return &done
 }
 
+// catchUpScanSplitKeys returns the keys which split the given span of the
+// replica into the sub-spans of a parallel catch-up scan, if the range is large
+// enough to use one.
+func (r *Replica) catchUpScanSplitKeys(ctx context.Context, span roachpb.Span) []roachpb.Key {
+	sv := &r.ClusterSettings().SV
+	parallelism := int(rangefeedCatchUpScanParallelism.Get(sv))
+	if parallelism <= 1 ||
+		r.GetMVCCStats().Total() < rangefeedCatchUpScanParallelismMinRangeSize.Get(sv) {
+		return nil
+	}
+	splitKeys, err := rangefeed.CatchUpScanSplitKeys(span, parallelism,
+		func(from, to roachpb.Key) (uint64, error) {
+			total, _, _, err := r.store.TODOEngine().ApproximateDiskBytes(from, to)
+			return total, err
+		})
+	if err != nil {
+		// The catch-up scan can proceed with a single iterator.
+		log.Warningf(ctx, "unable to split catch-up scan of %s: %v", span, err)
+		return nil
+	}
+	return splitKeys
+}
+
 func (r *Replica) getRangefeedProcessorAndFilter() (rangefeed.Processor, *rangefeed.Filter) {
 	r.rangefeedMu.RLock()
 	defer r.rangefeedMu.RUnlock()
@@ -369,6 +423,9 @@ func (r *Replica) registerWithRangefeedRaftMuLocked(
 	catchUpIter *rangefeed.CatchUpIterator,
 	withDiff bool,
 	withFiltering bool,
+	resolvedTSInterval time.Duration,
+	overflowPolicy kvpb.RangeFeedRequest_OverflowPolicy,
+	mvccHistoryMutationToken []byte,
 	stream rangefeed.Stream,
 	done *future.ErrorFuture,
 ) rangefeed.Processor {
@@ -391,7 +448,8 @@ func (r *Replica) registerWithRangefeedRaftMuLocked(
 
 	if p != nil {
 		reg, filter := p.Register(span, startTS, catchUpIter, withDiff, withFiltering,
-			stream, func() { r.maybeDisconnectEmptyRangefeed(p) }, done)
+			resolvedTSInterval, overflowPolicy, mvccHistoryMutationToken, stream,
+			func() { r.maybeDisconnectEmptyRangefeed(p) }, done)
 		if reg {
 			// Registered successfully with an existing processor.
 			// Update the rangefeed filter to avoid filtering ops
@@ -473,7 +531,8 @@ func (r *Replica) registerWithRangefeedRaftMuLocked(
 	// this ensures that the only time the registration fails is during
 	// server shutdown.
 	reg, filter := p.Register(span, startTS, catchUpIter, withDiff,
-		withFiltering, stream, func() { r.maybeDisconnectEmptyRangefeed(p) }, done)
+		withFiltering, resolvedTSInterval, overflowPolicy, mvccHistoryMutationToken, stream,
+		func() { r.maybeDisconnectEmptyRangefeed(p) }, done)
 	if !reg {
 		select {
 		case <-r.store.Stopper().ShouldQuiesce():
@@ -533,6 +592,34 @@ func (r *Replica) disconnectRangefeedSpanWithErr(span roachpb.Span, pErr *kvpb.E
 	r.maybeDisconnectEmptyRangefeed(p)
 }
 
+// handleMVCCHistoryMutationRaftMuLocked informs the rangefeed processor, if
+// any, of an MVCC history mutation across the given span. Registrations that
+// coordinated the mutation using the given token receive an MVCC history
+// mutation event, all other overlapping registrations are disconnected with a
+// non-retriable MVCCHistoryMutationError.
+func (r *Replica) handleMVCCHistoryMutationRaftMuLocked(
+	ctx context.Context, span roachpb.Span, token []byte,
+) {
+	pErr := kvpb.NewError(&kvpb.MVCCHistoryMutationError{Span: span})
+	if len(token) == 0 {
+		r.disconnectRangefeedSpanWithErr(span, pErr)
+		return
+	}
+	p := r.getRangefeedProcessor()
+	if p == nil {
+		return
+	}
+	// Disconnect uncoordinated registrations up front, so that they're
+	// disconnected with a non-retriable error even if the processor fails to
+	// consume the mutation below. Otherwise, they could reconnect and silently
+	// miss it.
+	p.DisconnectUncoordinatedSpanWithErr(span, token, pErr)
+	if !p.ConsumeMVCCHistoryMutation(ctx, span, token) {
+		r.unsetRangefeedProcessor(p)
+	}
+	r.maybeDisconnectEmptyRangefeed(p)
+}
+
 // disconnectRangefeedWithReason broadcasts the provided rangefeed retry reason
 // to all rangefeed registrations and tears down the active rangefeed Processor.
 // No-op if a rangefeed is not active.
@@ -559,13 +646,29 @@ func (r *Replica) numRangefeedRegistrations() int {
 // log with previous values read from the reader, which is expected to reflect
 // the state of the Replica before the operations in the logical op log are
 // applied. No-op if a rangefeed is not active. Requires raftMu to be locked.
+//
+// Previous values are only read for operations that overlap a registration
+// which requested them (see kvpb.RangeFeedRequest.WithDiff), each with its own
+// point read. The number of bytes read is recorded in the provided metrics, if
+// any.
 func populatePrevValsInLogicalOpLog(
 	ctx context.Context,
 	filter *rangefeed.Filter,
 	ops *kvserverpb.LogicalOpLog,
 	prevReader storage.Reader,
+	metrics *rangefeed.Metrics,
 ) error {
-	// Read from the Reader to populate the PrevValue fields.
+	// Fast path: no registration requested previous values.
+	if !filter.NeedPrevVals() {
+		return nil
+	}
+
+	var readBytes int64
+	defer func() {
+		if metrics != nil && readBytes > 0 {
+			metrics.RangeFeedPrevValueReadBytes.Inc(readBytes)
+		}
+	}()
 	for _, op := range ops.Ops {
 		var key []byte
 		var ts hlc.Timestamp
@@ -599,10 +702,11 @@ func populatePrevValsInLogicalOpLog(
 				Tombstones: true, Inconsistent: true, ReadCategory: storage.RangefeedReadCategory},
 		)
 		if err != nil {
-			return errors.Wrapf(err, "consuming %T for key %v @ ts %v", op, key, ts)
+			return errors.Wrapf(err, "consuming %T for key %v @ ts %v", op.GetValue(), key, ts)
 		}
 		if prevValRes.Value != nil {
 			*prevValPtr = prevValRes.Value.RawBytes
+			readBytes += int64(len(prevValRes.Value.RawBytes))
 		} else {
 			*prevValPtr = nil
 		}
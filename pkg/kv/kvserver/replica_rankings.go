@@ -55,6 +55,9 @@ type CandidateReplica interface {
 	RangeUsageInfo() allocator.RangeUsageInfo
 	// AdminTransferLease transfers the LeaderLease to another replica.
 	AdminTransferLease(ctx context.Context, target roachpb.StoreID, bypassSafetyChecks bool) error
+	// ReplicaPin returns the replica pin of the range, or nil if the range is
+	// not pinned. The replicas of pinned ranges are not rebalanced.
+	ReplicaPin() *roachpb.ReplicaPin
 	// Repl returns the underlying replica for this CandidateReplica. It is
 	// only used for determining timeouts in production code and not the
 	// simulator.
@@ -67,6 +67,17 @@ func (r *Replica) executeReadOnlyBatch(
 	// timestamps, which can help avoid uncertainty restarts.
 	ui := uncertainty.ComputeInterval(&ba.Header, st, r.Clock().MaxOffset())
 
+	// A consistent read evaluated without a valid lease status is served as a
+	// follower read. Record the closed timestamp which allowed it before the
+	// engine state is pinned, so that it can be verified against the engine
+	// state once evaluated.
+	verifyFollowerRead := !st.IsValid() && ba.ReadConsistency == kvpb.CONSISTENT &&
+		verifyFollowerReadsEnabled.Get(&r.store.cfg.Settings.SV)
+	var followerReadClosed closedTimestamp
+	if verifyFollowerRead {
+		followerReadClosed = r.followerReadClosedTimestamp(ctx, ba)
+	}
+
 	// Evaluate read-only batch command.
 	rec := NewReplicaEvalContext(
 		ctx, r, g.LatchSpans(), ba.RequiresClosedTSOlderThanStorageSnapshot(), ba.AdmissionHeader)
@@ -96,13 +107,24 @@ func (r *Replica) executeReadOnlyBatch(
 	if err := rw.PinEngineStateForIterators(readCategory); err != nil {
 		return nil, g, nil, kvpb.NewError(err)
 	}
+	// The unwrapped reader reads the range-local applied state, which isn't
+	// declared by the batch.
+	evalReader := storage.Reader(rw)
 	if util.RaceEnabled {
 		rw = spanset.NewReadWriterAt(rw, g.LatchSpans(), ba.Timestamp)
 	}
 	defer rw.Close()
 
+	var truncatedBelow hlc.Timestamp
 	if err := r.checkExecutionCanProceedAfterStorageSnapshot(ctx, ba, st); err != nil {
-		return nil, g, nil, kvpb.NewError(err)
+		// Batches which asked for it read below the GC threshold, and report
+		// the threshold in their response.
+		var gcErr *kvpb.BatchTimestampBeforeGCError
+		if !ba.AllowReadBelowGCThreshold || !errors.As(err, &gcErr) {
+			return nil, g, nil, kvpb.NewError(err)
+		}
+		log.VEventf(ctx, 2, "reading below the GC threshold %s", gcErr.Threshold)
+		truncatedBelow = gcErr.Threshold
 	}
 	ok, stillNeedsInterleavedIntents, pErr := r.canDropLatchesBeforeEval(ctx, rw, ba, g, st)
 	if pErr != nil {
@@ -231,9 +253,14 @@ func (r *Replica) executeReadOnlyBatch(
 	if pErr != nil {
 		log.VErrEventf(ctx, 3, "%v", pErr.String())
 	} else {
+		if verifyFollowerRead {
+			r.verifyFollowerRead(ctx, ba, followerReadClosed, evalReader)
+		}
+		br.TruncatedBelowGCThreshold = truncatedBelow
 		keysRead, bytesRead := getBatchResponseReadStats(br)
 		r.loadStats.RecordReadKeys(keysRead)
 		r.loadStats.RecordReadBytes(bytesRead)
+		r.recordHotReadKeys(ba)
 		log.Event(ctx, "read completed")
 	}
 	return br, nil, nil, pErr
@@ -0,0 +1,61 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"sync/atomic"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+)
+
+// rejectedCommandCounts counts the commands rejected below raft by a replica,
+// by reason. The counts are read by Replica.State without holding raftMu.
+type rejectedCommandCounts struct {
+	leaseMismatch atomic.Int64
+	leaseIndex    atomic.Int64
+	gcThreshold   atomic.Int64
+	proposalRetry atomic.Int64
+}
+
+func (c *rejectedCommandCounts) get() kvserverpb.RejectedCommandCounts {
+	return kvserverpb.RejectedCommandCounts{
+		LeaseMismatch: c.leaseMismatch.Load(),
+		LeaseIndex:    c.leaseIndex.Load(),
+		GCThreshold:   c.gcThreshold.Load(),
+		ProposalRetry: c.proposalRetry.Load(),
+	}
+}
+
+// recordRejectedCommand accounts for the outcome of the below-raft checks of
+// a command, in the counters of the replica and of its store. Commands with
+// nothing to apply, i.e. empty entries and probes, aren't counted.
+func (r *Replica) recordRejectedCommand(fr kvserverbase.ForcedErrResult) {
+	if fr.ForcedError == nil {
+		return
+	}
+	m := r.store.metrics
+	switch fr.Reason {
+	case kvserverbase.ForcedErrReasonLeaseMismatch:
+		r.rejectedCmds.leaseMismatch.Add(1)
+		m.RaftCommandsRejectedLeaseMismatch.Inc(1)
+	case kvserverbase.ForcedErrReasonLeaseIndex:
+		r.rejectedCmds.leaseIndex.Add(1)
+		m.RaftCommandsRejectedLeaseIndex.Inc(1)
+		if fr.Rejection == kvserverbase.ProposalRejectionIllegalLeaseIndex {
+			r.rejectedCmds.proposalRetry.Add(1)
+			m.RaftCommandsRejectedProposalRetry.Inc(1)
+		}
+	case kvserverbase.ForcedErrReasonGCThreshold:
+		r.rejectedCmds.gcThreshold.Add(1)
+		m.RaftCommandsRejectedGCThreshold.Inc(1)
+	}
+}
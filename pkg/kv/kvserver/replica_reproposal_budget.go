@@ -0,0 +1,72 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+)
+
+// raftReproposalBudget bounds the number of pending commands a replica
+// reproposes at once. Without it, a replica reproposes all of its pending
+// commands after a leadership change, or when they fail to replicate in time,
+// which amplifies the load of an already overloaded range.
+var raftReproposalBudget = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"kv.raft.reproposal.budget",
+	"the maximum number of pending commands a replica reproposes at once, e.g. after a "+
+		"leadership change; the excess commands are rejected with an ambiguous result "+
+		"instead (0 disables the limit)",
+	0,
+	settings.NonNegativeInt,
+)
+
+// raftReproposalMaxBackoff bounds the backoff between the reproposals of a
+// command which fails to replicate. See reproposalBackoffTicks.
+var raftReproposalMaxBackoff = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.raft.reproposal.max_backoff",
+	"the maximum interval between the reproposals of a command which fails to replicate; "+
+		"the interval doubles with each reproposal, with jitter, up to this value "+
+		"(0 disables the backoff)",
+	0,
+	settings.NonNegativeDuration,
+)
+
+// reproposalBackoffTicks returns the number of ticks after which a command
+// which was already reproposed the given number of times since it was first
+// proposed is reproposed again when it fails to replicate. The interval starts
+// at baseTicks and doubles with each reproposal, up to maxTicks. The jitter,
+// in [0, 1), spreads it out across the upper half of that interval, so that
+// the commands stuck together don't keep being reproposed together. It's
+// never shorter than baseTicks.
+func reproposalBackoffTicks(baseTicks, reproposals, maxTicks int, jitter float64) int {
+	if reproposals == 0 || maxTicks <= baseTicks {
+		return baseTicks
+	}
+	ticks := maxTicks
+	if reproposals < 31 && baseTicks<<reproposals < maxTicks {
+		ticks = baseTicks << reproposals
+	}
+	ticks = ticks/2 + int(jitter*float64(ticks-ticks/2))
+	return max(ticks, baseTicks)
+}
+
+// reproposalMaxBackoffTicks returns the kv.raft.reproposal.max_backoff
+// setting in ticks of the given interval.
+func reproposalMaxBackoffTicks(sv *settings.Values, tickInterval time.Duration) int {
+	maxBackoff := raftReproposalMaxBackoff.Get(sv)
+	if maxBackoff <= 0 || tickInterval <= 0 {
+		return 0
+	}
+	return int(maxBackoff / tickInterval)
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/uncertainty"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReproposalBackoffTicks(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	for _, tc := range []struct {
+		reproposals, maxTicks int
+		jitter                float64
+		expected              int
+	}{
+		{0, 100, 0.5, 5},    // first reproposal
+		{1, 0, 0.5, 5},      // backoff disabled
+		{1, 100, 0, 5},      // lowest jitter
+		{1, 100, 0.99, 9},   // highest jitter
+		{3, 100, 0.5, 30},   // 40 ticks, jittered
+		{5, 100, 0.5, 75},   // capped at 100 ticks
+		{100, 100, 0.5, 75}, // no overflow
+		{1, 3, 0.5, 5},      // never below the base interval
+	} {
+		t.Run(fmt.Sprintf("%d/%d/%.2f", tc.reproposals, tc.maxTicks, tc.jitter), func(t *testing.T) {
+			require.Equal(t, tc.expected, reproposalBackoffTicks(5, tc.reproposals, tc.maxTicks, tc.jitter))
+		})
+	}
+}
+
+// TestReplicaReproposalBudget verifies that a replica rejects the pending
+// commands in excess of its reproposal budget, with an ambiguous result.
+func TestReplicaReproposalBudget(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var tc testContext
+	cfg := TestStoreConfig(nil)
+	// Disable reasonNewLeader and reasonNewLeaderOrConfigChange proposal
+	// refreshes so that our proposals don't risk being reproposed due to
+	// Raft leadership instability.
+	cfg.TestingKnobs.DisableRefreshReasonNewLeader = true
+	cfg.TestingKnobs.DisableRefreshReasonNewLeaderOrConfigChange = true
+	cfg.TestingKnobs.DisableCanAckBeforeApplication = true
+	ctx := context.Background()
+	const budget = 3
+	raftReproposalBudget.Override(ctx, &cfg.Settings.SV, budget)
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	tc.StartWithStoreConfig(ctx, t, stopper, cfg)
+
+	dropAll := int32(1)
+	tc.repl.mu.Lock()
+	tc.repl.mu.proposalBuf.testing.submitProposalFilter = func(p *ProposalData) (drop bool, _ error) {
+		return atomic.LoadInt32(&dropAll) == 1, nil
+	}
+	tc.repl.mu.Unlock()
+
+	// The commands are assigned increasing lease indexes in the order they are
+	// proposed.
+	const num = 10
+	chs := make([]chan proposalResult, 0, num)
+	for i := 0; i < num; i++ {
+		ba := &kvpb.BatchRequest{}
+		ba.Timestamp = tc.Clock().Now()
+		ba.Add(&kvpb.PutRequest{
+			RequestHeader: kvpb.RequestHeader{
+				Key: roachpb.Key(fmt.Sprintf("k%d", i)),
+			},
+		})
+		_, tok := tc.repl.mu.proposalBuf.TrackEvaluatingRequest(ctx, hlc.MinTimestamp)
+		st := tc.repl.CurrentLeaseStatus(ctx)
+		ch, _, _, _, err := tc.repl.evalAndPropose(ctx, ba, allSpansGuard(), &st, uncertainty.Interval{}, tok.Move(ctx))
+		require.NoError(t, err.GoError())
+		chs = append(chs, ch)
+	}
+
+	reproposed := tc.store.metrics.RaftCommandsReproposed.Count()
+	tc.repl.raftMu.Lock()
+	tc.repl.mu.Lock()
+	require.NoError(t, tc.repl.mu.proposalBuf.flushLocked(ctx))
+	atomic.StoreInt32(&dropAll, 0)
+	tc.repl.refreshProposalsLocked(ctx, 0 /* refreshAtDelta */, reasonTicks)
+	require.NoError(t, tc.repl.mu.proposalBuf.flushLocked(ctx))
+	tc.repl.mu.Unlock()
+	tc.repl.raftMu.Unlock()
+
+	for i, ch := range chs {
+		pErr := (<-ch).Err
+		if i < budget {
+			require.Nil(t, pErr)
+			continue
+		}
+		require.NotNil(t, pErr)
+		err := pErr.GoError()
+		require.True(t, errors.HasType(err, (*kvpb.AmbiguousResultError)(nil)), "%+v", err)
+		require.True(t, kvpb.IsReproposalBudgetExceededError(err), "%+v", err)
+	}
+	require.Equal(t, int64(num-budget), tc.store.metrics.RaftCommandsReproposalsRejected.Count())
+	require.Equal(t, reproposed+budget, tc.store.metrics.RaftCommandsReproposed.Count())
+}
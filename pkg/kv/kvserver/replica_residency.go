@@ -0,0 +1,294 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator/storepool"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/errors"
+)
+
+// residencyVerificationRetryOptions are the retry options of the verification
+// of the residency of a range, which has to wait for the replicas of the
+// range to catch up after it was relocated.
+var residencyVerificationRetryOptions = retry.Options{
+	InitialBackoff: 50 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+	MaxRetries:     15,
+}
+
+// adminRelocateToLocality moves the replicas of the range onto stores matching
+// the locality, and verifies that its data is present on them. See the
+// comment on AdminRelocateToLocalityRequest for details.
+func (r *Replica) adminRelocateToLocality(
+	ctx context.Context, args kvpb.AdminRelocateToLocalityRequest,
+) (kvpb.AdminRelocateToLocalityResponse, error) {
+	var reply kvpb.AdminRelocateToLocalityResponse
+	if !r.store.ClusterSettings().Version.IsActive(ctx, clusterversion.V24_1_RelocateToLocality) {
+		return reply, errors.Newf(
+			"relocating ranges to a locality requires cluster version %s",
+			clusterversion.V24_1_RelocateToLocality)
+	}
+	if args.Locality.Empty() {
+		return reply, errors.New("no locality to relocate the range to")
+	}
+	storePool := r.store.cfg.StorePool
+	if storePool == nil {
+		return reply, errors.New("relocating ranges to a locality requires a store pool")
+	}
+
+	desc := r.Desc()
+	storeList, _, _ := storePool.GetStoreList(storepool.StoreFilterNone)
+	voters, nonVoters, err := residencyTargets(desc, storeList.Stores, args.Locality)
+	if err != nil {
+		return reply, errors.Wrapf(err, "relocating r%d to locality %s", desc.RangeID, args.Locality)
+	}
+	voterStoreIDs := make([]roachpb.StoreID, len(voters))
+	for i, target := range voters {
+		voterStoreIDs[i] = target.StoreID
+	}
+
+	// Pin the voters first, so that the replicate queue doesn't move them out
+	// of the locality once they're relocated.
+	key := desc.StartKey.AsRawKey()
+	if err := r.store.DB().PinReplicas(ctx, key, voterStoreIDs); err != nil {
+		return reply, errors.Wrapf(err, "pinning r%d to %v", desc.RangeID, voterStoreIDs)
+	}
+	log.KvDistribution.Infof(ctx, "relocating r%d to locality %s: voters %v, non-voters %v",
+		desc.RangeID, args.Locality, voters, nonVoters)
+	if err := r.AdminRelocateRange(
+		ctx, *desc, voters, nonVoters, false, /* transferLeaseToFirstVoter */
+	); err != nil {
+		return reply, errors.Wrapf(err, "relocating r%d to locality %s", desc.RangeID, args.Locality)
+	}
+
+	// The range may have lost its lease, or this replica may have been removed
+	// altogether, so the verification doesn't rely on this replica.
+	report, err := r.store.verifyResidency(ctx, desc.StartKey, args.Locality)
+	if err != nil {
+		return reply, err
+	}
+	if reply.Report, err = protoutil.Marshal(&report); err != nil {
+		return reply, err
+	}
+	if err := r.store.signResidencyReport(&reply); err != nil {
+		return reply, errors.Wrap(err, "signing the residency report")
+	}
+	return reply, nil
+}
+
+// residencyTargets returns the stores to relocate the replicas of the range
+// to, so that they all match the locality. The range keeps its number of
+// voters, which are placed on matching stores of distinct nodes, preferring
+// the stores already holding a replica of the range, and then the stores
+// holding the fewest ranges. The non-voters on matching stores are kept, and
+// the others dropped.
+func residencyTargets(
+	desc *roachpb.RangeDescriptor, stores []roachpb.StoreDescriptor, locality roachpb.Locality,
+) (voters, nonVoters []roachpb.ReplicationTarget, _ error) {
+	var candidates []roachpb.StoreDescriptor
+	for _, store := range stores {
+		if ok, _ := store.Node.Locality.Matches(locality); ok {
+			candidates = append(candidates, store)
+		}
+	}
+	// rank orders voters before non-voters, before stores without a replica.
+	rank := func(storeID roachpb.StoreID) int {
+		rd, ok := desc.GetReplicaDescriptor(storeID)
+		switch {
+		case !ok:
+			return 2
+		case rd.Type == roachpb.NON_VOTER:
+			return 1
+		default:
+			return 0
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if ra, rb := rank(a.StoreID), rank(b.StoreID); ra != rb {
+			return ra < rb
+		}
+		if a.Capacity.RangeCount != b.Capacity.RangeCount {
+			return a.Capacity.RangeCount < b.Capacity.RangeCount
+		}
+		return a.StoreID < b.StoreID
+	})
+
+	numVoters := len(desc.Replicas().VoterDescriptors())
+	nodes := make(map[roachpb.NodeID]struct{}, numVoters)
+	for _, store := range candidates {
+		if len(voters) == numVoters {
+			break
+		}
+		if _, ok := nodes[store.Node.NodeID]; ok {
+			continue
+		}
+		nodes[store.Node.NodeID] = struct{}{}
+		voters = append(voters, roachpb.ReplicationTarget{NodeID: store.Node.NodeID, StoreID: store.StoreID})
+	}
+	if len(voters) < numVoters {
+		return nil, nil, errors.Errorf(
+			"%d live nodes have stores matching the locality, %d voters needed", len(voters), numVoters)
+	}
+
+	matching := make(map[roachpb.StoreID]struct{}, len(candidates))
+	for _, store := range candidates {
+		matching[store.StoreID] = struct{}{}
+	}
+	for _, target := range voters {
+		delete(matching, target.StoreID)
+	}
+	for _, rd := range desc.Replicas().NonVoterDescriptors() {
+		if _, ok := matching[rd.StoreID]; ok {
+			nonVoters = append(nonVoters, roachpb.ReplicationTarget{NodeID: rd.NodeID, StoreID: rd.StoreID})
+		}
+	}
+	return voters, nonVoters, nil
+}
+
+// verifyResidency verifies that the data of the range starting at the key is
+// only present on stores matching the locality, and that all of its replicas
+// are up to date. It retries until the replicas of the range have caught up
+// after a relocation.
+func (s *Store) verifyResidency(
+	ctx context.Context, key roachpb.RKey, locality roachpb.Locality,
+) (kvpb.ResidencyReport, error) {
+	var lastErr error
+	for re := retry.StartWithCtx(ctx, residencyVerificationRetryOptions); re.Next(); {
+		report, err := s.tryVerifyResidency(ctx, key, locality)
+		if err == nil {
+			return report, nil
+		}
+		lastErr = err
+		log.VEventf(ctx, 2, "verifying the residency of the range at %s: %v", key, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return kvpb.ResidencyReport{}, err
+	}
+	return kvpb.ResidencyReport{}, errors.Wrapf(lastErr,
+		"verifying the residency of the range at %s in locality %s", key, locality)
+}
+
+// tryVerifyResidency makes a single attempt at verifying the residency of the
+// range starting at the key: the replicas of the descriptor of the range must
+// all be on stores matching the locality, and must pass a consistency check.
+// The consistency check has all the replicas compute a checksum of their data
+// at the same log position, so it only succeeds once all of them have applied
+// their snapshot and caught up with the log. The descriptor is read again
+// after the check, to make sure that the replicas which were checked are
+// those of the descriptor.
+func (s *Store) tryVerifyResidency(
+	ctx context.Context, key roachpb.RKey, locality roachpb.Locality,
+) (kvpb.ResidencyReport, error) {
+	report := kvpb.ResidencyReport{
+		Locality: locality,
+		NodeID:   s.NodeID(),
+	}
+	if err := s.DB().GetProto(ctx, keys.RangeDescriptorKey(key), &report.Desc); err != nil {
+		return kvpb.ResidencyReport{}, err
+	}
+	desc := &report.Desc
+	if !desc.IsInitialized() || !desc.StartKey.Equal(key) {
+		return kvpb.ResidencyReport{}, errors.Errorf("no range starts at %s anymore", key)
+	}
+	replicas := desc.Replicas()
+	if replicas.InAtomicReplicationChange() || len(replicas.LearnerDescriptors()) > 0 {
+		return kvpb.ResidencyReport{}, errors.Errorf("r%d is undergoing a replication change", desc.RangeID)
+	}
+	for _, rd := range replicas.Descriptors() {
+		store, ok := s.cfg.StorePool.GetStoreDescriptor(rd.StoreID)
+		if !ok {
+			return kvpb.ResidencyReport{}, errors.Errorf("unknown store s%d of replica %s", rd.StoreID, rd)
+		}
+		if ok, tier := store.Node.Locality.Matches(locality); !ok {
+			return kvpb.ResidencyReport{}, errors.Errorf(
+				"replica %s is on s%d, whose locality %s doesn't match %s",
+				rd, rd.StoreID, store.Node.Locality, tier)
+		}
+		report.Replicas = append(report.Replicas, kvpb.ResidencyReport_Replica{
+			Replica:  rd,
+			Locality: store.Node.Locality,
+		})
+	}
+
+	var b kv.Batch
+	b.AddRawRequest(&kvpb.CheckConsistencyRequest{
+		RequestHeader: kvpb.RequestHeaderFromSpan(desc.KeySpan().AsRawSpanWithNoLocals()),
+		Mode:          kvpb.ChecksumMode_CHECK_STATS,
+	})
+	if err := s.DB().Run(ctx, &b); err != nil {
+		return kvpb.ResidencyReport{}, err
+	}
+	results := b.RawResponse().Responses[0].GetCheckConsistency().Result
+	if len(results) != 1 || results[0].RangeID != desc.RangeID {
+		return kvpb.ResidencyReport{}, errors.Errorf("r%d changed during its consistency check", desc.RangeID)
+	}
+	switch report.Status = results[0].Status; report.Status {
+	case kvpb.CheckConsistencyResponse_RANGE_CONSISTENT,
+		kvpb.CheckConsistencyResponse_RANGE_CONSISTENT_STATS_ESTIMATED:
+	default:
+		return kvpb.ResidencyReport{}, errors.Errorf(
+			"consistency check of r%d: %s: %s", desc.RangeID, report.Status, results[0].Detail)
+	}
+
+	var after roachpb.RangeDescriptor
+	if err := s.DB().GetProto(ctx, keys.RangeDescriptorKey(key), &after); err != nil {
+		return kvpb.ResidencyReport{}, err
+	}
+	if !desc.Equal(&after) {
+		return kvpb.ResidencyReport{}, errors.Errorf("r%d changed during its consistency check", desc.RangeID)
+	}
+	report.VerifiedAt = s.Clock().Now()
+	return report, nil
+}
+
+// signResidencyReport signs the report of the response with the key of the
+// node certificate. The report is left unsigned in insecure clusters.
+func (s *Store) signResidencyReport(reply *kvpb.AdminRelocateToLocalityResponse) error {
+	rpcCtx := s.cfg.RPCContext
+	if rpcCtx == nil || rpcCtx.ContextOptions.Insecure {
+		return nil
+	}
+	cm, err := rpcCtx.SecurityContext.GetCertificateManager()
+	if err != nil {
+		return err
+	}
+	cert := cm.NodeCert()
+	if cert == nil {
+		return errors.New("no node certificate")
+	}
+	if cert.Error != nil {
+		return cert.Error
+	}
+	key, err := security.PEMToPrivateKey(cert.KeyFileContents)
+	if err != nil {
+		return err
+	}
+	if reply.Signature, err = kvpb.SignResidencyReport(key, reply.Report); err != nil {
+		return err
+	}
+	reply.Certificate = cert.FileContents
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResidencyTargets(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Stores 1-3 are in us-east, on nodes 1-3. Stores 4-7 are in eu-west, with
+	// stores 4 and 5 on node 4.
+	store := func(storeID roachpb.StoreID, nodeID roachpb.NodeID, region string, ranges int32) roachpb.StoreDescriptor {
+		return roachpb.StoreDescriptor{
+			StoreID: storeID,
+			Node: roachpb.NodeDescriptor{
+				NodeID: nodeID,
+				Locality: roachpb.Locality{Tiers: []roachpb.Tier{
+					{Key: "region", Value: region}, {Key: "zone", Value: region + "-a"},
+				}},
+			},
+			Capacity: roachpb.StoreCapacity{RangeCount: ranges},
+		}
+	}
+	stores := []roachpb.StoreDescriptor{
+		store(1, 1, "us-east", 10), store(2, 2, "us-east", 10), store(3, 3, "us-east", 10),
+		store(4, 4, "eu-west", 5), store(5, 4, "eu-west", 1), store(6, 5, "eu-west", 30),
+		store(7, 6, "eu-west", 20),
+	}
+	target := func(storeID roachpb.StoreID, nodeID roachpb.NodeID) roachpb.ReplicationTarget {
+		return roachpb.ReplicationTarget{NodeID: nodeID, StoreID: storeID}
+	}
+	euWest := roachpb.Locality{Tiers: []roachpb.Tier{{Key: "region", Value: "eu-west"}}}
+
+	// The range has voters in us-east and on s6, and non-voters on s3 and s7.
+	desc := &roachpb.RangeDescriptor{RangeID: 1}
+	desc.AddReplica(1, 1, roachpb.VOTER_FULL)
+	desc.AddReplica(2, 2, roachpb.VOTER_FULL)
+	desc.AddReplica(5, 6, roachpb.VOTER_FULL)
+	desc.AddReplica(3, 3, roachpb.NON_VOTER)
+	desc.AddReplica(6, 7, roachpb.NON_VOTER)
+
+	// The voter on s6 stays, the non-voter on s7 is promoted, and the last voter
+	// goes to the node 4 store with the fewest ranges. The non-voter in us-east
+	// is dropped.
+	voters, nonVoters, err := residencyTargets(desc, stores, euWest)
+	require.NoError(t, err)
+	require.Equal(t, []roachpb.ReplicationTarget{target(6, 5), target(7, 6), target(5, 4)}, voters)
+	require.Empty(t, nonVoters)
+
+	// The non-voters on matching stores are kept.
+	desc.RemoveReplica(3, 3)
+	desc.AddReplica(4, 4, roachpb.NON_VOTER)
+	desc.RemoveReplica(1, 1)
+	desc.RemoveReplica(2, 2)
+	voters, nonVoters, err = residencyTargets(desc, stores, euWest)
+	require.NoError(t, err)
+	require.Equal(t, []roachpb.ReplicationTarget{target(6, 5)}, voters)
+	require.ElementsMatch(t, []roachpb.ReplicationTarget{target(4, 4), target(7, 6)}, nonVoters)
+
+	// A locality without enough nodes for the voters can't be relocated to.
+	desc.AddReplica(1, 1, roachpb.VOTER_FULL)
+	desc.AddReplica(2, 2, roachpb.VOTER_FULL)
+	desc.AddReplica(3, 3, roachpb.VOTER_FULL)
+	desc.AddReplica(8, 8, roachpb.VOTER_FULL)
+	_, _, err = residencyTargets(desc, stores, euWest)
+	require.ErrorContains(t, err, "3 live nodes have stores matching the locality, 5 voters needed")
+	_, _, err = residencyTargets(desc, stores,
+		roachpb.Locality{Tiers: []roachpb.Tier{{Key: "region", Value: "ap-south"}}})
+	require.ErrorContains(t, err, "0 live nodes")
+}
@@ -168,6 +168,7 @@ func (r *Replica) SendWithWriteBytes(
 	if err != nil {
 		return nil, nil, kvpb.NewError(err)
 	}
+	ba = r.maybeConvertFullRangeDeleteRange(ctx, ba)
 
 	if filter := r.store.cfg.TestingKnobs.TestingRequestFilter; filter != nil {
 		if pErr := filter(ctx, ba); pErr != nil {
@@ -186,7 +187,17 @@ func (r *Replica) SendWithWriteBytes(
 	} else if ba.IsWrite() {
 		log.Event(ctx, "read-write path")
 		fn := (*Replica).executeWriteBatch
-		br, writeBytes, pErr = r.executeBatchWithConcurrencyRetries(ctx, ba, fn)
+		if coalesceIntentResolutionEnabled.Get(&r.ClusterSettings().SV) &&
+			canCoalesceIntentResolution(ba) {
+			br, writeBytes, pErr = r.intentResolutionCoalescer.send(ctx, ba,
+				func(
+					ctx context.Context, ba *kvpb.BatchRequest,
+				) (*kvpb.BatchResponse, *kvadmission.StoreWriteBytes, *kvpb.Error) {
+					return r.executeBatchWithConcurrencyRetries(ctx, ba, fn)
+				})
+		} else {
+			br, writeBytes, pErr = r.executeBatchWithConcurrencyRetries(ctx, ba, fn)
+		}
 	} else if ba.IsAdmin() {
 		log.Event(ctx, "admin path")
 		br, pErr = r.executeAdminBatch(ctx, ba)
@@ -212,6 +223,9 @@ func (r *Replica) SendWithWriteBytes(
 		// and a pErr here. Also, some errors (e.g. NotLeaseholderError) have custom
 		// ways of returning range info.
 		r.maybeAddRangeInfoToResponse(ctx, ba, br)
+		if ba.ReturnRangePlacement {
+			br.RangePlacements = []kvpb.RangePlacement{r.rangePlacement()}
+		}
 		// Handle load-based splitting, if necessary.
 		r.recordBatchForLoadBasedSplitting(ctx, ba, br, int(grunning.Difference(startCPU, grunning.Time())))
 	}
@@ -305,6 +319,29 @@ func (r *Replica) maybeCommitWaitBeforeCommitTrigger(
 	return nil
 }
 
+// rangePlacement returns the placement of the range, for clients which
+// request it through the ReturnRangePlacement header.
+func (r *Replica) rangePlacement() kvpb.RangePlacement {
+	r.mu.RLock()
+	desc := r.mu.state.Desc
+	lease := *r.mu.state.Lease
+	replicationFactor := r.mu.conf.NumReplicas
+	r.mu.RUnlock()
+
+	sp := r.store.cfg.StorePool
+	voters := desc.Replicas().VoterDescriptors()
+	placement := kvpb.RangePlacement{
+		RangeID:           desc.RangeID,
+		ReplicationFactor: replicationFactor,
+		VoterLocalities:   make([]roachpb.Locality, 0, len(voters)),
+		LeaseLocality:     sp.GetNodeLocality(lease.Replica.NodeID),
+	}
+	for _, rd := range voters {
+		placement.VoterLocalities = append(placement.VoterLocalities, sp.GetNodeLocality(rd.NodeID))
+	}
+	return placement
+}
+
 // maybeAddRangeInfoToResponse populates br.RangeInfo if the client doesn't
 // have up-to-date info about the range's descriptor and lease.
 func (r *Replica) maybeAddRangeInfoToResponse(
@@ -434,6 +471,12 @@ func (r *Replica) executeBatchWithConcurrencyRetries(
 			r.concMgr.FinishReq(g)
 		}
 	}()
+	// latchWait is the time spent sequencing the request, across retries, if
+	// the batch requested a commit latency breakdown.
+	var latchWait time.Duration
+	// reevaluations is the number of times the batch was evaluated again after
+	// its proposal was rejected because of the closed timestamp.
+	var reevaluations int
 	pp := poison.Policy_Error
 	if r.signallerForBatch(ba).C() == nil {
 		// The request wishes to ignore the circuit breaker, i.e. attempt to propose
@@ -465,6 +508,10 @@ func (r *Replica) executeBatchWithConcurrencyRetries(
 		// to ensure that the request has full isolation during evaluation. This
 		// returns a request guard that must be eventually released.
 		var resp []kvpb.ResponseUnion
+		var sequenceStart time.Time
+		if ba.ReturnCommitLatencyBreakdown {
+			sequenceStart = timeutil.Now()
+		}
 		g, resp, pErr = r.concMgr.SequenceReq(ctx, g, concurrency.Request{
 			Txn:             ba.Txn,
 			Timestamp:       ba.Timestamp,
@@ -478,6 +525,9 @@ func (r *Replica) executeBatchWithConcurrencyRetries(
 			LatchSpans:      latchSpans, // nil if g != nil
 			LockSpans:       lockSpans,  // nil if g != nil
 		}, requestEvalKind)
+		if ba.ReturnCommitLatencyBreakdown {
+			latchWait += timeutil.Since(sequenceStart)
+		}
 		if pErr != nil {
 			if poisonErr := (*poison.PoisonedError)(nil); errors.As(pErr.GoError(), &poisonErr) {
 				// It's possible that intent resolution accessed txn info anchored on a
@@ -511,7 +561,23 @@ func (r *Replica) executeBatchWithConcurrencyRetries(
 		br, g, writeBytes, pErr = fn(r, ctx, ba, g)
 		if pErr == nil {
 			// Success.
+			if ba.ReturnCommitLatencyBreakdown {
+				br.EnsureCommitLatencyBreakdown().LatchWait = latchWait
+			}
 			return br, writeBytes, nil
+		} else if isReproposalBelowClosedTimestampError(pErr) {
+			// The proposal was rejected below Raft and couldn't be reproposed
+			// because the closed timestamp advanced past its write timestamp. Its
+			// latches were released with the proposal, so the guard was consumed.
+			// Attempt to bump the batch's timestamp and evaluate it again, instead
+			// of redirecting the client.
+			if ba, pErr = r.handleReproposalBelowClosedTimestampError(
+				ctx, ba, pErr, reevaluations,
+			); pErr != nil {
+				return nil, nil, pErr
+			}
+			reevaluations++
+			continue
 		} else if !isConcurrencyRetryError(pErr) {
 			// Propagate error.
 			return nil, nil, pErr
@@ -1005,6 +1071,11 @@ func (r *Replica) executeAdminBatch(
 		pErr = kvpb.NewError(err)
 		resp = &reply
 
+	case *kvpb.AdminRelocateToLocalityRequest:
+		reply, err := r.adminRelocateToLocality(ctx, *tArgs)
+		pErr = kvpb.NewError(err)
+		resp = &reply
+
 	default:
 		return nil, kvpb.NewErrorf("unrecognized admin command: %T", args)
 	}
@@ -1352,7 +1423,13 @@ func (ec *endCmds) done(
 	}
 
 	if ts := ec.replicatingSince; !ts.IsZero() {
-		ec.repl.store.metrics.RaftReplicationLatency.RecordValue(timeutil.Since(ts).Nanoseconds())
+		replicationLatency := timeutil.Since(ts)
+		ec.repl.store.metrics.RaftReplicationLatency.RecordValue(replicationLatency.Nanoseconds())
+		// NB: the response of a batch which performed consensus asynchronously
+		// was already returned to the client.
+		if ba.ReturnCommitLatencyBreakdown && br != nil && !ba.AsyncConsensus {
+			br.EnsureCommitLatencyBreakdown().Replication = replicationLatency
+		}
 	}
 
 	// Release the latches acquired by the request and exit lock wait-queues. Must
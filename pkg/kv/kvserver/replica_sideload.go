@@ -0,0 +1,127 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/logstore"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// sideloadedStorageReconcileInterval is the interval at which the store
+// removes sideloaded files that are not referenced by the raft log of their
+// replica.
+var sideloadedStorageReconcileInterval = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.raft.sideloaded_storage.reconcile_interval",
+	"the interval at which sideloaded raft log payloads that are not referenced "+
+		"by the raft log are removed (0 disables)",
+	10*time.Minute,
+	settings.NonNegativeDuration,
+)
+
+// reconcileSideloadedStorage removes the files in the replica's sideloaded
+// storage which are not referenced by its raft log. See
+// logstore.SideloadStorage.Reconcile.
+func (r *Replica) reconcileSideloadedStorage(
+	ctx context.Context,
+) (logstore.SideloadReconcileStats, error) {
+	// Holding raftMu prevents concurrent log appends and truncations, which
+	// would otherwise race with the listing of the sideloaded files.
+	r.raftMu.Lock()
+	defer r.raftMu.Unlock()
+
+	r.mu.RLock()
+	skip := r.mu.destroyStatus.Removed() || !r.IsInitialized()
+	first, last := r.raftFirstIndexRLocked(), r.raftLastIndexRLocked()
+	r.mu.RUnlock()
+	if skip {
+		return logstore.SideloadReconcileStats{}, nil
+	}
+
+	return r.raftMu.sideloaded.Reconcile(ctx, first, last,
+		func(index kvpb.RaftIndex) (kvpb.RaftTerm, error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			return r.raftTermLocked(index)
+		})
+}
+
+// startSideloadedStorageReconciler starts a worker which periodically removes
+// the sideloaded files not referenced by the raft log of any replica on the
+// store, and updates the store's sideloaded storage metrics. Such orphaned
+// files can be left behind by crashes or races with log truncation, and would
+// otherwise never be removed.
+func (s *Store) startSideloadedStorageReconciler(ctx context.Context) {
+	_ /* err */ = s.stopper.RunAsyncTaskEx(ctx, stop.TaskOpts{
+		TaskName: "sideloaded-storage-reconciler",
+		SpanOpt:  stop.SterileRootSpan,
+	}, func(ctx context.Context) {
+		ctx, cancel := s.stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+
+		// Run a pass shortly after startup, which is when files left behind by a
+		// crash are most likely to be found.
+		timer := timeutil.NewTimer()
+		defer timer.Stop()
+		timer.Reset(time.Minute)
+		for {
+			select {
+			case <-timer.C:
+				timer.Read = true
+			case <-ctx.Done():
+				return
+			}
+			interval := sideloadedStorageReconcileInterval.Get(&s.ClusterSettings().SV)
+			if interval == 0 {
+				// Check again later whether the reconciler has been enabled.
+				timer.Reset(time.Minute)
+				continue
+			}
+			s.reconcileSideloadedStorage(ctx)
+			timer.Reset(interval)
+		}
+	})
+}
+
+// reconcileSideloadedStorage runs a single reconciliation pass over all the
+// replicas on the store.
+func (s *Store) reconcileSideloadedStorage(ctx context.Context) {
+	var total logstore.SideloadReconcileStats
+	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+		stats, err := r.reconcileSideloadedStorage(ctx)
+		if err != nil {
+			log.Warningf(ctx, "r%d: unable to reconcile sideloaded storage: %v", r.RangeID, err)
+			return ctx.Err() == nil
+		}
+		total.Files += stats.Files
+		total.Bytes += stats.Bytes
+		total.OrphanedFiles += stats.OrphanedFiles
+		total.OrphanedBytes += stats.OrphanedBytes
+		return ctx.Err() == nil
+	})
+	s.metrics.RaftLogSideloadedOrphanedFiles.Inc(int64(total.OrphanedFiles))
+	s.metrics.RaftLogSideloadedOrphanedBytes.Inc(total.OrphanedBytes)
+	if ctx.Err() == nil {
+		// Only a complete pass accounts for all the sideloaded bytes.
+		s.metrics.RaftLogSideloadedBytes.Update(total.Bytes)
+	}
+	if total.OrphanedFiles > 0 {
+		log.Infof(ctx, "removed %d orphaned sideloaded files (%d bytes)",
+			total.OrphanedFiles, total.OrphanedBytes)
+	}
+}
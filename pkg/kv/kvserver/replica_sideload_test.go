@@ -24,13 +24,17 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/logstore"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/raftlog"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
 // TODO(pavelkalinnikov): refactor and move tests from here to SideloadStorage
@@ -283,3 +287,40 @@ func TestRaftSSTableSideloadingTruncation(t *testing.T) {
 		}
 	})
 }
+
+func TestVerifySideloadedFiles(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+	ss := logstore.NewDiskSideloadStorage(cluster.MakeTestingClusterSettings(), 1,
+		eng.GetAuxiliaryDir(), rate.NewLimiter(rate.Inf, math.MaxInt64), eng)
+
+	intact, corrupt := []byte("intact payload"), []byte("corrupt payload")
+	require.NoError(t, ss.Put(ctx, 10, 1, intact))
+	require.NoError(t, ss.Put(ctx, 11, 1, corrupt))
+	refs := []sideloadedFileRef{
+		{index: 10, term: 1, crc32: util.CRC32(intact)},
+		{index: 11, term: 1, crc32: util.CRC32(corrupt) + 1},
+		// Truncated since the raft log was scanned.
+		{index: 12, term: 1, crc32: 1},
+	}
+
+	var paced int64
+	pace := func(_ context.Context, n int64) error {
+		paced += n
+		return nil
+	}
+	var corrupted []kvpb.RaftIndex
+	verified, err := verifySideloadedFiles(ctx, ss, refs, pace,
+		func(ref sideloadedFileRef, checksum uint32) {
+			require.Equal(t, util.CRC32(corrupt), checksum)
+			corrupted = append(corrupted, ref.index)
+		})
+	require.NoError(t, err)
+	require.Equal(t, int64(len(intact)+len(corrupt)), verified)
+	require.Equal(t, verified, paced)
+	require.Equal(t, []kvpb.RaftIndex{11}, corrupted)
+}
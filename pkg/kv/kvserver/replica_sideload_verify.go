@@ -0,0 +1,209 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/logstore"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/raftlog"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// sideloadedStorageVerifyInterval is the interval at which the store verifies
+// the checksums of the sideloaded files referenced by the raft logs of its
+// replicas.
+var sideloadedStorageVerifyInterval = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.raft.sideloaded_storage.verify_interval",
+	"the interval at which the checksums of the sideloaded raft log payloads are "+
+		"verified in the background (0 disables)",
+	time.Hour,
+	settings.NonNegativeDuration,
+)
+
+// sideloadedStorageVerifyRate bounds the rate at which the sideloaded files
+// are read by the background verification.
+var sideloadedStorageVerifyRate = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.raft.sideloaded_storage.verify_rate",
+	"the rate limit (bytes/sec) at which sideloaded raft log payloads are read to "+
+		"verify their checksums",
+	8<<20, // 8 MiB/s
+	settings.PositiveInt,
+)
+
+// sideloadedFileRef references a sideloaded file of a replica, along with the
+// checksum of its contents recorded in the raft log at proposal time.
+type sideloadedFileRef struct {
+	index kvpb.RaftIndex
+	term  kvpb.RaftTerm
+	crc32 uint32
+}
+
+// sideloadedFilesToVerify returns the sideloaded files referenced by the raft
+// log of the replica, and the storage they are in.
+func (r *Replica) sideloadedFilesToVerify(
+	ctx context.Context,
+) ([]sideloadedFileRef, logstore.SideloadStorage, error) {
+	// Holding raftMu prevents concurrent log appends and truncations while the
+	// log is scanned. The files are read afterwards, without it: a file removed
+	// by a truncation in the meantime is skipped.
+	r.raftMu.Lock()
+	defer r.raftMu.Unlock()
+
+	r.mu.RLock()
+	skip := r.mu.destroyStatus.Removed() || !r.IsInitialized()
+	first, last := r.raftFirstIndexRLocked(), r.raftLastIndexRLocked()
+	r.mu.RUnlock()
+	if skip {
+		return nil, nil, nil
+	}
+
+	var refs []sideloadedFileRef
+	if err := raftlog.Visit(ctx, r.store.LogEngine(), r.RangeID, first, last+1,
+		func(ent raftpb.Entry) error {
+			typ, err := raftlog.EncodingOf(ent)
+			if err != nil || !typ.IsSideloaded() {
+				return err
+			}
+			e, err := raftlog.NewEntry(ent)
+			if err != nil {
+				return err
+			}
+			defer e.Release()
+			// Sstables ingested from remote storage have no sideloaded payload.
+			if sst := e.Cmd.ReplicatedEvalResult.AddSSTable; sst != nil && sst.RemoteFilePath == "" {
+				refs = append(refs, sideloadedFileRef{
+					index: kvpb.RaftIndex(ent.Index),
+					term:  kvpb.RaftTerm(ent.Term),
+					crc32: sst.CRC32,
+				})
+			}
+			return nil
+		}); err != nil {
+		return nil, nil, err
+	}
+	return refs, r.raftMu.sideloaded, nil
+}
+
+// verifySideloadedFiles reads the given sideloaded files, pacing the reads
+// with the given function, and compares the checksums of their contents to
+// the ones recorded in the raft log. The files found corrupted are passed to
+// the given function. Returns the total size of the files verified.
+func verifySideloadedFiles(
+	ctx context.Context,
+	ss logstore.SideloadStorage,
+	refs []sideloadedFileRef,
+	pace func(context.Context, int64) error,
+	corrupted func(ref sideloadedFileRef, checksum uint32),
+) (verifiedBytes int64, _ error) {
+	for _, ref := range refs {
+		data, err := ss.Get(ctx, ref.index, ref.term)
+		if err != nil {
+			if logstore.IsSideloadedFileNotFound(err) {
+				// Truncated since the raft log was scanned.
+				continue
+			}
+			return verifiedBytes, err
+		}
+		if err := pace(ctx, int64(len(data))); err != nil {
+			return verifiedBytes, err
+		}
+		if checksum := util.CRC32(data); checksum != ref.crc32 {
+			corrupted(ref, checksum)
+		}
+		verifiedBytes += int64(len(data))
+	}
+	return verifiedBytes, nil
+}
+
+// startSideloadedStorageVerifier starts a worker which periodically verifies,
+// at a bounded rate, the checksums of the sideloaded files referenced by the
+// raft logs of the replicas on the store. The sideloaded files of applied
+// AddSSTable commands are hard links to the sstables ingested into the
+// engine, so this also covers the recently ingested sstables until their
+// raft log entries are truncated. A corruption is reported before it is hit
+// when the file is ingested, or sent to a follower.
+func (s *Store) startSideloadedStorageVerifier(ctx context.Context) {
+	_ /* err */ = s.stopper.RunAsyncTaskEx(ctx, stop.TaskOpts{
+		TaskName: "sideloaded-storage-verifier",
+		SpanOpt:  stop.SterileRootSpan,
+	}, func(ctx context.Context) {
+		ctx, cancel := s.stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+
+		limiter := quotapool.NewRateLimiter("sideloaded-storage-verifier", quotapool.Inf(), 0)
+		timer := timeutil.NewTimer()
+		defer timer.Stop()
+		timer.Reset(sideloadedStorageVerifyInterval.Get(&s.ClusterSettings().SV))
+		for {
+			select {
+			case <-timer.C:
+				timer.Read = true
+			case <-ctx.Done():
+				return
+			}
+			interval := sideloadedStorageVerifyInterval.Get(&s.ClusterSettings().SV)
+			if interval == 0 {
+				// Check again later whether the verifier has been enabled.
+				timer.Reset(time.Minute)
+				continue
+			}
+			s.verifySideloadedStorage(ctx, limiter)
+			timer.Reset(interval)
+		}
+	})
+}
+
+// verifySideloadedStorage runs a single verification pass over all the
+// replicas on the store.
+func (s *Store) verifySideloadedStorage(ctx context.Context, limiter *quotapool.RateLimiter) {
+	pace := func(ctx context.Context, n int64) error {
+		r := sideloadedStorageVerifyRate.Get(&s.ClusterSettings().SV)
+		limiter.UpdateLimit(quotapool.Limit(r), r)
+		// Acquire the bytes in chunks no larger than the burst.
+		for n > 0 {
+			chunk := min(n, r)
+			if err := limiter.WaitN(ctx, chunk); err != nil {
+				return err
+			}
+			n -= chunk
+		}
+		return nil
+	}
+	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+		refs, ss, err := r.sideloadedFilesToVerify(ctx)
+		if err == nil && len(refs) > 0 {
+			var verified int64
+			verified, err = verifySideloadedFiles(ctx, ss, refs, pace,
+				func(ref sideloadedFileRef, checksum uint32) {
+					s.metrics.RaftLogSideloadedCorruptFiles.Inc(1)
+					log.Errorf(ctx, "r%d: checksum of sideloaded payload at index %d, term %d "+
+						"does not match: at proposal time %x, now %x",
+						r.RangeID, ref.index, ref.term, ref.crc32, checksum)
+				})
+			s.metrics.RaftLogSideloadedVerifiedBytes.Inc(verified)
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Warningf(ctx, "r%d: unable to verify sideloaded storage: %v", r.RangeID, err)
+		}
+		return ctx.Err() == nil
+	})
+}
@@ -0,0 +1,227 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// rangeSizeRecalibrationInterval is the minimum interval between two
+// recalibrations of a replica's size estimate against the storage engine.
+var rangeSizeRecalibrationInterval = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.range_size_estimate.recalibration_interval",
+	"the minimum interval at which the size estimate of a range with estimated "+
+		"MVCC stats is recalibrated against the approximate on-disk size of the range "+
+		"(0 disables recalibration)",
+	10*time.Minute,
+	settings.NonNegativeDuration,
+)
+
+// rangeSizeEstimate is an estimate of the logical size of a range, i.e. of the
+// MVCCStats.Total() that a recomputation of its MVCC stats would return.
+type rangeSizeEstimate struct {
+	// Bytes is the estimated size of the range.
+	Bytes int64
+	// ErrorBytes bounds the error of Bytes. It is zero if the estimate is
+	// derived from exact MVCC stats.
+	ErrorBytes int64
+}
+
+// rangeSizeTracker maintains the size estimate of a replica, which informs
+// size-based splits and merges. Write backpressure is deliberately based on the
+// MVCC stats alone, since it blocks foreground traffic and shouldn't kick in on
+// the strength of an estimate. The tracker is protected by Replica.mu.
+//
+// The size of a range is derived from its MVCC stats. These are exact unless
+// they contain estimates (see MVCCStats.ContainsEstimates), e.g. after
+// AddSSTable requests or splits which estimate their stats, in which case the
+// stats can drift arbitrarily far from the true size of the range until they
+// are recomputed, which requires a full scan of the range. To bound the drift
+// cheaply, the tracker is opportunistically recalibrated against the
+// approximate on-disk size of the range, which the storage engine derives from
+// the table properties of its sstables. The on-disk size at calibration,
+// carried forward by the logical changes to the stats since, gives a second
+// opinion on the size of the range, and the disagreement between the two
+// bounds the error of the estimate.
+//
+// The tracker also remembers a previous, larger RangeMaxBytes after the span
+// config of the range reduced it, to avoid backpressuring writes to the range
+// until it has been split below the new limit. Like backpressure, this is based
+// on the MVCC stats.
+type rangeSizeTracker struct {
+	// largestPreviousMaxRangeSizeBytes tracks a previous conf.RangeMaxBytes
+	// which exceeded the current conf.RangeMaxBytes to help defeat the range
+	// backpressure mechanism in cases where a user reduces the configured range
+	// size. It is set when the span config changes to a smaller value and the
+	// current range size exceeds the new value. It is cleared after the range's
+	// size drops below its current conf.MaxRangeBytes or if the
+	// conf.MaxRangeBytes increases to surpass the current value.
+	largestPreviousMaxRangeSizeBytes int64
+
+	// calibration is the state of the last recalibration. It only applies to
+	// the range descriptor generation it was taken at, since splits and merges
+	// change the span of the range.
+	calibration struct {
+		at         time.Time
+		generation roachpb.RangeGeneration
+		// statsBytes is the MVCCStats.Total() of the range at calibration.
+		statsBytes int64
+		// diskBytes is the approximate on-disk size of the range at
+		// calibration.
+		diskBytes int64
+	}
+}
+
+// estimate returns the size estimate of a range with the given descriptor
+// generation and MVCC stats.
+func (t *rangeSizeTracker) estimate(
+	generation roachpb.RangeGeneration, ms *enginepb.MVCCStats,
+) rangeSizeEstimate {
+	total := ms.Total()
+	if ms.ContainsEstimates == 0 {
+		return rangeSizeEstimate{Bytes: total}
+	}
+	if t.calibration.at.IsZero() || t.calibration.generation != generation {
+		// Without a calibration, there is nothing to bound the drift of the
+		// stats with. Conservatively assume that they are off by their own size.
+		return rangeSizeEstimate{Bytes: total, ErrorBytes: total}
+	}
+	diskDerived := t.calibration.diskBytes + (total - t.calibration.statsBytes)
+	if diskDerived < 0 {
+		diskDerived = 0
+	}
+	// The on-disk size is compressed, so the disk-derived size is a lower bound
+	// of the logical size of the range rather than an estimate of it. If even
+	// the lower bound exceeds the stats, the stats have drifted low, e.g. due to
+	// ingestions with underestimated stats, and the lower bound is the better
+	// estimate. Otherwise, the stats are kept.
+	est := rangeSizeEstimate{Bytes: total, ErrorBytes: diskDerived - total}
+	if diskDerived > total {
+		est.Bytes = diskDerived
+	} else {
+		est.ErrorBytes = -est.ErrorBytes
+	}
+	return est
+}
+
+// needsRecalibration returns whether the tracker should be recalibrated for a
+// range with the given descriptor generation and MVCC stats.
+func (t *rangeSizeTracker) needsRecalibration(
+	now time.Time,
+	interval time.Duration,
+	generation roachpb.RangeGeneration,
+	ms *enginepb.MVCCStats,
+) bool {
+	if interval == 0 || ms.ContainsEstimates == 0 {
+		return false
+	}
+	return t.calibration.generation != generation || now.Sub(t.calibration.at) >= interval
+}
+
+// recalibrate records a calibration of the tracker for a range with the given
+// descriptor generation and MVCC stats, and the given on-disk size.
+func (t *rangeSizeTracker) recalibrate(
+	now time.Time, generation roachpb.RangeGeneration, ms *enginepb.MVCCStats, diskBytes int64,
+) {
+	t.calibration.at = now
+	t.calibration.generation = generation
+	t.calibration.statsBytes = ms.Total()
+	t.calibration.diskBytes = diskBytes
+}
+
+// onSpanConfigChange updates the tracker for a change of the range's span
+// config from oldMaxBytes to newMaxBytes, given the current MVCCStats.Total() of
+// the range. explicit indicates whether the old span config was explicitly set.
+func (t *rangeSizeTracker) onSpanConfigChange(
+	statsBytes, oldMaxBytes, newMaxBytes int64, explicit bool,
+) {
+	// Set largestPreviousMaxRangeSizeBytes if the current range size is
+	// greater than the new limit, if the limit has decreased from what we
+	// last remember, and we don't already have a larger value.
+	if statsBytes > newMaxBytes && newMaxBytes < oldMaxBytes &&
+		t.largestPreviousMaxRangeSizeBytes < oldMaxBytes &&
+		// We also want to make sure that we're replacing a real span config.
+		// If we didn't have this check, the default value would prevent
+		// backpressure until the range got larger than it.
+		explicit {
+		t.largestPreviousMaxRangeSizeBytes = oldMaxBytes
+	} else if t.largestPreviousMaxRangeSizeBytes > 0 &&
+		t.largestPreviousMaxRangeSizeBytes < newMaxBytes {
+		// Reset it if the new limit is larger than the largest we were
+		// aware of.
+		t.largestPreviousMaxRangeSizeBytes = 0
+	}
+}
+
+// onSizeChange updates the tracker for a change of the range's
+// MVCCStats.Total(), given the range's current RangeMaxBytes.
+func (t *rangeSizeTracker) onSizeChange(statsBytes, maxBytes int64) {
+	// If the range is now less than its RangeMaxBytes, clear the history of its
+	// largest previous max bytes.
+	if t.largestPreviousMaxRangeSizeBytes > 0 && statsBytes < maxBytes {
+		t.largestPreviousMaxRangeSizeBytes = 0
+	}
+}
+
+// sizeEstimateRLocked returns the replica's current size estimate. Requires
+// that r.mu is held for reading.
+func (r *Replica) sizeEstimateRLocked() rangeSizeEstimate {
+	return r.mu.sizeTracker.estimate(r.mu.state.Desc.Generation, r.mu.state.Stats)
+}
+
+// getSizeEstimate returns the replica's current size estimate, recalibrating it
+// against the storage engine first if it is due. See rangeSizeTracker.
+func (r *Replica) getSizeEstimate(ctx context.Context) rangeSizeEstimate {
+	r.maybeRecalibrateSizeEstimate(ctx)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sizeEstimateRLocked()
+}
+
+// maybeRecalibrateSizeEstimate recalibrates the replica's size estimate against
+// the approximate on-disk size of the range, if the range has estimated MVCC
+// stats and it has not been recalibrated recently.
+func (r *Replica) maybeRecalibrateSizeEstimate(ctx context.Context) {
+	interval := rangeSizeRecalibrationInterval.Get(&r.store.cfg.Settings.SV)
+	now := r.Clock().PhysicalTime()
+	r.mu.RLock()
+	desc := r.mu.state.Desc
+	needed := r.IsInitialized() &&
+		r.mu.sizeTracker.needsRecalibration(now, interval, desc.Generation, r.mu.state.Stats)
+	r.mu.RUnlock()
+	if !needed {
+		return
+	}
+
+	// The on-disk size and the stats are not read atomically, so writes in
+	// between skew the calibration. This is fine for an estimate.
+	span := desc.KeySpan().AsRawSpanWithNoLocals()
+	diskBytes, err := r.GetApproximateDiskBytes(span.Key, span.EndKey)
+	if err != nil {
+		log.VEventf(ctx, 2, "unable to recalibrate range size estimate: %v", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mu.state.Desc.Generation != desc.Generation {
+		// The range has split or merged in the meantime.
+		return
+	}
+	r.mu.sizeTracker.recalibrate(now, desc.Generation, r.mu.state.Stats, int64(diskBytes))
+}
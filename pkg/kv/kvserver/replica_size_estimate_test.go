@@ -0,0 +1,69 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeSizeTracker(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var tr rangeSizeTracker
+	now := time.Unix(1000, 0)
+	const interval = time.Minute
+
+	// Exact stats are used as is, and never need recalibration.
+	exact := enginepb.MVCCStats{KeyBytes: 100, ValBytes: 900}
+	require.Equal(t, rangeSizeEstimate{Bytes: 1000}, tr.estimate(1, &exact))
+	require.False(t, tr.needsRecalibration(now, interval, 1, &exact))
+
+	// Estimated stats have an unknown error until calibrated.
+	estimated := enginepb.MVCCStats{ContainsEstimates: 1, KeyBytes: 100, ValBytes: 900}
+	require.Equal(t, rangeSizeEstimate{Bytes: 1000, ErrorBytes: 1000}, tr.estimate(1, &estimated))
+	require.True(t, tr.needsRecalibration(now, interval, 1, &estimated))
+	require.False(t, tr.needsRecalibration(now, 0 /* interval */, 1, &estimated))
+
+	// The on-disk size exceeds the stats, which have drifted low. The estimate
+	// follows the on-disk size, carried forward by later changes to the stats.
+	tr.recalibrate(now, 1, &estimated, 5000)
+	require.Equal(t, rangeSizeEstimate{Bytes: 5000, ErrorBytes: 4000}, tr.estimate(1, &estimated))
+	estimated.ValBytes += 1000
+	require.Equal(t, rangeSizeEstimate{Bytes: 6000, ErrorBytes: 4000}, tr.estimate(1, &estimated))
+	require.False(t, tr.needsRecalibration(now.Add(interval/2), interval, 1, &estimated))
+	require.True(t, tr.needsRecalibration(now.Add(interval), interval, 1, &estimated))
+
+	// The on-disk size is below the stats. The stats are used, and the
+	// disagreement bounds their error.
+	tr.recalibrate(now, 1, &estimated, 1500)
+	require.Equal(t, rangeSizeEstimate{Bytes: 2000, ErrorBytes: 500}, tr.estimate(1, &estimated))
+
+	// A calibration does not apply to a later descriptor generation.
+	require.Equal(t, rangeSizeEstimate{Bytes: 2000, ErrorBytes: 2000}, tr.estimate(2, &estimated))
+	require.True(t, tr.needsRecalibration(now, interval, 2, &estimated))
+
+	// The largest previous max bytes is remembered after a reduction of the
+	// range max bytes below the size of the range, and reset once the range
+	// shrinks below the new limit.
+	tr.onSpanConfigChange(1000, 2000, 500, true /* explicit */)
+	require.Equal(t, int64(2000), tr.largestPreviousMaxRangeSizeBytes)
+	tr.onSizeChange(600, 500)
+	require.Equal(t, int64(2000), tr.largestPreviousMaxRangeSizeBytes)
+	tr.onSizeChange(400, 500)
+	require.Zero(t, tr.largestPreviousMaxRangeSizeBytes)
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"bytes"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// maxIngestedSSTables is the number of recently ingested sstables a replica
+// remembers for the deduplication of AddSSTable requests.
+const maxIngestedSSTables = 32
+
+// ingestedSSTable is an sstable ingested by a replica at its write timestamp.
+type ingestedSSTable struct {
+	span        roachpb.Span
+	ts          hlc.Timestamp
+	contentHash []byte
+}
+
+// ingestedSSTables remembers the sstables recently ingested by a replica, so
+// that the AddSSTable requests which ingest an identical sstable into the
+// same span at the same timestamp can be skipped during evaluation, see
+// kv.bulk_io_write.sst_deduplication.enabled.
+//
+// It is maintained by every replica as the AddSSTable commands are applied,
+// so that it carries over lease transfers. It is not persisted, nor sent in
+// snapshots: a replica which doesn't remember an sstable simply ingests it
+// again, as if deduplication was disabled.
+type ingestedSSTables struct {
+	mu struct {
+		syncutil.Mutex
+		// ssts is ordered by ingestion, oldest first.
+		ssts []ingestedSSTable
+	}
+}
+
+// record remembers an sstable ingested into the given span at the given
+// timestamp. The ingestion may have overwritten the versions written by the
+// sstables previously ingested into overlapping spans, which are forgotten.
+func (s *ingestedSSTables) record(span roachpb.Span, ts hlc.Timestamp, contentHash []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forgetOverlappingLocked(span)
+	if len(s.mu.ssts) == maxIngestedSSTables {
+		s.mu.ssts[0] = ingestedSSTable{}
+		s.mu.ssts = s.mu.ssts[1:]
+	}
+	s.mu.ssts = append(s.mu.ssts, ingestedSSTable{
+		span:        span,
+		ts:          ts,
+		contentHash: append([]byte(nil), contentHash...),
+	})
+}
+
+// forget forgets the sstables ingested into spans overlapping the given span,
+// which is mutated in a way which makes their ingestion no longer idempotent,
+// e.g. by an MVCC history mutation.
+func (s *ingestedSSTables) forget(span roachpb.Span) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forgetOverlappingLocked(span)
+}
+
+func (s *ingestedSSTables) forgetOverlappingLocked(span roachpb.Span) {
+	ssts := s.mu.ssts[:0]
+	for _, sst := range s.mu.ssts {
+		if !sst.span.Overlaps(span) {
+			ssts = append(ssts, sst)
+		}
+	}
+	for i := len(ssts); i < len(s.mu.ssts); i++ {
+		s.mu.ssts[i] = ingestedSSTable{}
+	}
+	s.mu.ssts = ssts
+}
+
+// contains returns whether an sstable with the given content hash was
+// ingested into the given span at the given timestamp, and wasn't forgotten
+// since.
+func (s *ingestedSSTables) contains(
+	span roachpb.Span, ts hlc.Timestamp, contentHash []byte,
+) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sst := range s.mu.ssts {
+		if sst.ts == ts && sst.span.Equal(span) && bytes.Equal(sst.contentHash, contentHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasIngestedSSTable implements the batcheval.EvalContext interface.
+func (r *Replica) HasIngestedSSTable(
+	span roachpb.Span, ts hlc.Timestamp, contentHash []byte,
+) bool {
+	return r.ingestedSSTs.contains(span, ts, contentHash)
+}
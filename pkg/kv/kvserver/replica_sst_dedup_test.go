@@ -0,0 +1,70 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestedSSTables(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	span := func(key, endKey string) roachpb.Span {
+		return roachpb.Span{Key: roachpb.Key(key), EndKey: roachpb.Key(endKey)}
+	}
+	ts := hlc.Timestamp{WallTime: 10}
+	hashA, hashB := []byte("a"), []byte("b")
+
+	var s ingestedSSTables
+	require.False(t, s.contains(span("a", "c"), ts, hashA))
+
+	// Only the same sstable, in the same span at the same timestamp, is
+	// reported as ingested.
+	s.record(span("a", "c"), ts, hashA)
+	require.True(t, s.contains(span("a", "c"), ts, hashA))
+	require.False(t, s.contains(span("a", "c"), ts, hashB))
+	require.False(t, s.contains(span("a", "d"), ts, hashA))
+	require.False(t, s.contains(span("a", "c"), ts.Next(), hashA))
+
+	// Ingesting into an overlapping span forgets the sstable, while ingesting
+	// into an adjacent span doesn't.
+	s.record(span("c", "e"), ts, hashB)
+	require.True(t, s.contains(span("a", "c"), ts, hashA))
+	s.record(span("b", "d"), ts, hashB)
+	require.False(t, s.contains(span("a", "c"), ts, hashA))
+	require.False(t, s.contains(span("c", "e"), ts, hashB))
+	require.True(t, s.contains(span("b", "d"), ts, hashB))
+
+	// MVCC history mutations forget the sstables they overlap.
+	s.forget(span("a", "b"))
+	require.True(t, s.contains(span("b", "d"), ts, hashB))
+	s.forget(span("c", "z"))
+	require.False(t, s.contains(span("b", "d"), ts, hashB))
+
+	// Only the most recent sstables are remembered.
+	for i := 0; i <= maxIngestedSSTables; i++ {
+		key := fmt.Sprintf("k%03d", i)
+		s.record(span(key, key+"0"), ts, hashA)
+	}
+	require.False(t, s.contains(span("k000", "k0000"), ts, hashA))
+	require.True(t, s.contains(span("k001", "k0010"), ts, hashA))
+	last := fmt.Sprintf("k%03d", maxIngestedSSTables)
+	require.True(t, s.contains(span(last, last+"0"), ts, hashA))
+	require.Len(t, s.mu.ssts, maxIngestedSSTables)
+}
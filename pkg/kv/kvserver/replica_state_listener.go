@@ -0,0 +1,202 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/gogo/protobuf/proto"
+)
+
+// RangeStateKey identifies a piece of the replicated range-local state whose
+// changes can be listened to with Store.RegisterRangeStateListener.
+type RangeStateKey int8
+
+const (
+	// RangeStateGCThreshold is the GC threshold of the range. The values of its
+	// changes are *hlc.Timestamp.
+	RangeStateGCThreshold RangeStateKey = iota
+	// RangeStateGCHint is the GC hint of the range. The values of its changes
+	// are *roachpb.GCHint.
+	RangeStateGCHint
+	// RangeStateLease is the lease of the range. The values of its changes are
+	// *roachpb.Lease.
+	RangeStateLease
+	// RangeStateVersion is the replica version of the range. The values of its
+	// changes are *roachpb.Version.
+	RangeStateVersion
+	// RangeStateWriteFences is the set of write fences of the range. The values
+	// of its changes are *roachpb.WriteFences.
+	RangeStateWriteFences
+	// RangeStateReplicaPin is the replica pin of the range. The values of its
+	// changes are *roachpb.ReplicaPin.
+	RangeStateReplicaPin
+
+	numRangeStateKeys
+)
+
+func (k RangeStateKey) String() string {
+	switch k {
+	case RangeStateGCThreshold:
+		return "gc-threshold"
+	case RangeStateGCHint:
+		return "gc-hint"
+	case RangeStateLease:
+		return "lease"
+	case RangeStateVersion:
+		return "version"
+	case RangeStateWriteFences:
+		return "write-fences"
+	case RangeStateReplicaPin:
+		return "replica-pin"
+	default:
+		return "unknown"
+	}
+}
+
+// RangeStateChange is a change to the range-local state of a replica.
+type RangeStateChange struct {
+	RangeID roachpb.RangeID
+	Key     RangeStateKey
+	// Before is the value before the change. It can be nil if the state was
+	// never set.
+	Before protoutil.Message
+	// After is the value after the change. It can be nil if the state was
+	// cleared.
+	After protoutil.Message
+}
+
+// RangeStateListener is notified of the changes to the range-local state of
+// the replicas of a store.
+//
+// Listeners are invoked synchronously on the goroutine applying the change,
+// once the in-memory state of the replica reflects it. Besides the commands
+// changing the state, this covers the snapshots replacing it and the splits
+// creating the right-hand side of the range, whose initial state is notified
+// as changed from nil. Merges change the state of the left-hand side through
+// the merge command itself. The GC threshold is
+// bumped before the command carrying it is applied to the state machine (see
+// replicaAppBatch.runPostAddTriggersReplicaOnly), and the other keys after.
+// Listeners are thus on the critical path of Raft application and must not
+// block, nor call back into the replica: the ones with work to do should hand
+// it off to another goroutine. The values must not be modified.
+type RangeStateListener func(ctx context.Context, change RangeStateChange)
+
+// rangeStateListeners holds the listeners registered on a store.
+type rangeStateListeners struct {
+	mu struct {
+		syncutil.RWMutex
+		nextID int
+		byKey  [numRangeStateKeys]map[int]RangeStateListener
+	}
+}
+
+// RegisterRangeStateListener registers a listener notified of the changes to
+// the given keys of the range-local state of all the replicas of the store. It
+// is the sanctioned way for other subsystems to follow these changes, instead
+// of polling the replicas. The returned function unregisters the listener.
+func (s *Store) RegisterRangeStateListener(
+	fn RangeStateListener, keys ...RangeStateKey,
+) (unregister func()) {
+	l := &s.rangeStateListeners
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	id := l.mu.nextID
+	l.mu.nextID++
+	for _, k := range keys {
+		if l.mu.byKey[k] == nil {
+			l.mu.byKey[k] = map[int]RangeStateListener{}
+		}
+		l.mu.byKey[k][id] = fn
+	}
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for _, k := range keys {
+			delete(l.mu.byKey[k], id)
+		}
+	}
+}
+
+// notify notifies the listeners registered for the key of the given change.
+// It must not be called with the replica's mutex held.
+func (l *rangeStateListeners) notify(ctx context.Context, change RangeStateChange) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, fn := range l.mu.byKey[change.Key] {
+		fn(ctx, change)
+	}
+}
+
+// notifyRangeStateChange notifies the listeners of the store of a change to the
+// replica's range-local state.
+func (r *Replica) notifyRangeStateChange(
+	ctx context.Context, key RangeStateKey, before, after protoutil.Message,
+) {
+	r.store.rangeStateListeners.notify(ctx, RangeStateChange{
+		RangeID: r.RangeID,
+		Key:     key,
+		Before:  before,
+		After:   after,
+	})
+}
+
+// notifyRangeStateReplaced notifies the listeners of the store of the changes
+// to the replica's range-local state from a wholesale replacement of the state,
+// e.g. by a snapshot.
+func (r *Replica) notifyRangeStateReplaced(
+	ctx context.Context, before, after *kvserverpb.ReplicaState,
+) {
+	for k := RangeStateKey(0); k < numRangeStateKeys; k++ {
+		b, a := rangeStateValue(before, k), rangeStateValue(after, k)
+		if b == nil && a == nil || b != nil && a != nil && proto.Equal(b, a) {
+			continue
+		}
+		r.notifyRangeStateChange(ctx, k, b, a)
+	}
+}
+
+// rangeStateValue returns the value of the given key in the replica state, or
+// nil if it's not set.
+func rangeStateValue(s *kvserverpb.ReplicaState, key RangeStateKey) protoutil.Message {
+	switch key {
+	case RangeStateGCThreshold:
+		return protoOrNil(s.GCThreshold)
+	case RangeStateGCHint:
+		return protoOrNil(s.GCHint)
+	case RangeStateLease:
+		return protoOrNil(s.Lease)
+	case RangeStateVersion:
+		return protoOrNil(s.Version)
+	case RangeStateWriteFences:
+		return protoOrNil(s.WriteFences)
+	case RangeStateReplicaPin:
+		return protoOrNil(s.ReplicaPin)
+	default:
+		return nil
+	}
+}
+
+// protoOrNil returns the given message, or an untyped nil if it's a nil
+// pointer, so that listeners can compare RangeStateChange.Before to nil.
+func protoOrNil[T any, P interface {
+	*T
+	protoutil.Message
+}](p P) protoutil.Message {
+	if p == nil {
+		return nil
+	}
+	return p
+}
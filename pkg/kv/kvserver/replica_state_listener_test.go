@@ -0,0 +1,115 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeStateListener(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	ctx := context.Background()
+	defer stopper.Stop(ctx)
+	tc.Start(ctx, t, stopper)
+
+	var changes []RangeStateChange
+	unregister := tc.store.RegisterRangeStateListener(func(_ context.Context, c RangeStateChange) {
+		changes = append(changes, c)
+	}, RangeStateGCThreshold, RangeStateGCHint, RangeStateWriteFences, RangeStateReplicaPin)
+
+	tc.repl.mu.RLock()
+	prevThresh := tc.repl.mu.state.GCThreshold
+	tc.repl.mu.RUnlock()
+	thresh := hlc.Timestamp{WallTime: 100}
+	tc.repl.handleGCThresholdResult(ctx, &thresh)
+	require.Len(t, changes, 1)
+	require.Equal(t, tc.repl.RangeID, changes[0].RangeID)
+	require.Equal(t, RangeStateGCThreshold, changes[0].Key)
+	require.Equal(t, protoOrNil(prevThresh), changes[0].Before)
+	require.Equal(t, &thresh, changes[0].After)
+
+	// A state which was never set has no previous value.
+	tc.repl.mu.Lock()
+	tc.repl.mu.state.GCHint = nil
+	tc.repl.mu.Unlock()
+	hint := &roachpb.GCHint{LatestRangeDeleteTimestamp: hlc.Timestamp{WallTime: 1}}
+	tc.repl.handleGCHintResult(ctx, hint)
+	require.Len(t, changes, 2)
+	require.Equal(t, RangeStateGCHint, changes[1].Key)
+	require.Nil(t, changes[1].Before)
+	require.Equal(t, hint, changes[1].After)
+
+	// Keys which are not listened to are not notified.
+	tc.repl.handleVersionResult(ctx, &roachpb.Version{Major: 1000})
+	require.Len(t, changes, 2)
+
+	// Write fences are notified, including when cleared.
+	tc.repl.mu.Lock()
+	tc.repl.mu.state.WriteFences = nil
+	tc.repl.mu.Unlock()
+	fences := &roachpb.WriteFences{}
+	tc.repl.handleWriteFencesResult(ctx, fences)
+	require.Len(t, changes, 3)
+	require.Equal(t, RangeStateWriteFences, changes[2].Key)
+	require.Nil(t, changes[2].Before)
+	require.Equal(t, fences, changes[2].After)
+	tc.repl.handleWriteFencesResult(ctx, nil)
+	require.Len(t, changes, 4)
+	require.Equal(t, fences, changes[3].Before)
+	require.Nil(t, changes[3].After)
+	tc.repl.handleWriteFencesResult(ctx, fences)
+	require.Len(t, changes, 5)
+
+	// So are replica pins.
+	tc.repl.mu.Lock()
+	tc.repl.mu.state.ReplicaPin = nil
+	tc.repl.mu.Unlock()
+	pin := &roachpb.ReplicaPin{VoterStoreIDs: []roachpb.StoreID{1}}
+	tc.repl.handleReplicaPinResult(ctx, pin)
+	require.Len(t, changes, 6)
+	require.Equal(t, RangeStateReplicaPin, changes[5].Key)
+	require.Nil(t, changes[5].Before)
+	require.Equal(t, pin, changes[5].After)
+
+	// A wholesale replacement of the state, e.g. by a snapshot, notifies the
+	// keys whose values changed.
+	tc.repl.mu.RLock()
+	before := tc.repl.mu.state
+	tc.repl.mu.RUnlock()
+	after := before
+	after.GCHint = &roachpb.GCHint{LatestRangeDeleteTimestamp: hlc.Timestamp{WallTime: 2}}
+	after.WriteFences = nil
+	after.ReplicaPin = nil
+	tc.repl.notifyRangeStateReplaced(ctx, &before, &after)
+	require.Len(t, changes, 9)
+	changed := map[RangeStateKey]bool{}
+	for _, c := range changes[6:] {
+		changed[c.Key] = true
+	}
+	require.Equal(t, map[RangeStateKey]bool{
+		RangeStateGCHint: true, RangeStateWriteFences: true, RangeStateReplicaPin: true,
+	}, changed)
+
+	unregister()
+	tc.repl.handleGCThresholdResult(ctx, &hlc.Timestamp{WallTime: 200})
+	require.Len(t, changes, 9)
+}
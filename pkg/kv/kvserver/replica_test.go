@@ -2796,6 +2796,112 @@ func TestReplicaLatchingSplitDeclaresWrites(t *testing.T) {
 	}
 }
 
+// TestReplicaWriteFenceLatching verifies that write fences serialize with the
+// writes to the fenced span through latching alone, which is why the fences
+// are only checked before evaluation and not below raft: latches are only
+// released once a command applied (even when its success was acknowledged
+// before application), so a fence and a conflicting write can never be
+// evaluated against the applied fence state while the other one is in flight.
+func TestReplicaWriteFenceLatching(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testutils.RunTrueAndFalse(t, "block-fence", func(t *testing.T, blockFence bool) {
+		ctx := context.Background()
+		key := roachpb.Key("a")
+
+		// Block the application of the first command of the given method, after
+		// its evaluation.
+		blockMethod := kvpb.Put
+		if blockFence {
+			blockMethod = kvpb.WriteFence
+		}
+		var blocked int32
+		blockingApply := make(chan struct{})
+		unblockApply := make(chan struct{})
+		cfg := TestStoreConfig(nil)
+		cfg.TestingKnobs.TestingApplyCalledTwiceFilter =
+			func(args kvserverbase.ApplyFilterArgs) (int, *kvpb.Error) {
+				if args.Ephemeral || args.Req == nil {
+					return 0, nil
+				}
+				if _, ok := args.Req.GetArg(blockMethod); !ok {
+					return 0, nil
+				}
+				if atomic.CompareAndSwapInt32(&blocked, 0, 1) {
+					close(blockingApply)
+					<-unblockApply
+				}
+				return 0, nil
+			}
+		tc := testContext{}
+		stopper := stop.NewStopper()
+		defer stopper.Stop(ctx)
+		tc.StartWithStoreConfig(ctx, t, stopper, cfg)
+		belowTS := tc.Clock().Now()
+		fenceTS := belowTS.Next()
+		aboveTS := fenceTS.Next()
+
+		fence := func() *kvpb.Error {
+			_, pErr := tc.SendWrapped(&kvpb.WriteFenceRequest{
+				RequestHeader:  kvpb.RequestHeader{Key: key, EndKey: key.Next()},
+				Action:         kvpb.WriteFenceRequest_INSTALL,
+				FenceTimestamp: fenceTS,
+			})
+			return pErr
+		}
+		put := func(ts hlc.Timestamp) *kvpb.Error {
+			pArgs := putArgs(key, []byte("value"))
+			_, pErr := tc.SendWrappedWith(kvpb.Header{Timestamp: ts}, &pArgs)
+			return pErr
+		}
+
+		// Evaluate the first command, and block its application.
+		firstDone := make(chan *kvpb.Error, 1)
+		go func() {
+			if blockFence {
+				firstDone <- fence()
+			} else {
+				firstDone <- put(belowTS)
+			}
+		}()
+		<-blockingApply
+
+		// The second command waits for the latches of the first one, even if
+		// the first one was acknowledged already.
+		secondDone := make(chan *kvpb.Error, 1)
+		go func() {
+			if blockFence {
+				secondDone <- put(belowTS)
+			} else {
+				secondDone <- fence()
+			}
+		}()
+		select {
+		case pErr := <-secondDone:
+			t.Fatalf("second command evaluated while the first one is in flight: %v", pErr)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(unblockApply)
+		require.Nil(t, <-firstDone)
+		pErr := <-secondDone
+		if blockFence {
+			// The write was evaluated against the applied fence.
+			require.IsType(t, &kvpb.WriteFenceError{}, pErr.GetDetail())
+		} else {
+			// The write applied before the fence was installed.
+			require.Nil(t, pErr)
+		}
+
+		// Once the fence is installed, writes at or below it are rejected, and
+		// writes above it are not.
+		require.IsType(t, &kvpb.WriteFenceError{}, put(belowTS).GetDetail())
+		require.IsType(t, &kvpb.WriteFenceError{}, put(fenceTS).GetDetail())
+		require.Nil(t, put(aboveTS))
+	})
+}
+
 // TestReplicaLatchingOptimisticEvaluationKeyLimit verifies that limited scans
 // evaluate optimistically without waiting for latches to be acquired. In some
 // cases, this allows them to avoid waiting on writes that their
@@ -7264,6 +7370,70 @@ func TestQuotaPoolReleasedOnFailedProposal(t *testing.T) {
 	}
 }
 
+// TestSetProposalQuota verifies that the capacity of the proposal quota pool of
+// a range can be overridden temporarily, and that it's reported in the range's
+// status.
+func TestSetProposalQuota(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	tc.Start(ctx, t, stopper)
+
+	// Flush a write all the way through the Raft proposal pipeline to ensure
+	// that the replica becomes the Raft leader and sets up its quota pool.
+	write := func() {
+		iArgs := incrementArgs([]byte("a"), 1)
+		_, pErr := tc.SendWrapped(iArgs)
+		require.NoError(t, pErr.GoError())
+	}
+	write()
+	defaultCapacity := tc.store.cfg.RaftProposalQuota
+	require.Equal(t, defaultCapacity, tc.repl.State(ctx).ProposalQuotaCapacity)
+
+	require.NoError(t, tc.repl.SetProposalQuota(uint64(2*defaultCapacity), time.Hour))
+	write()
+	ri := tc.repl.State(ctx)
+	require.Equal(t, 2*defaultCapacity, ri.ProposalQuotaCapacity)
+	require.Equal(t, 2*defaultCapacity, ri.ProposalQuotaOverride)
+	require.True(t, ri.ProposalQuotaOverrideExpiration.After(timeutil.Now()))
+
+	// Clearing the override reverts to the default capacity.
+	require.NoError(t, tc.repl.SetProposalQuota(0, 0))
+	write()
+	ri = tc.repl.State(ctx)
+	require.Equal(t, defaultCapacity, ri.ProposalQuotaCapacity)
+	require.Zero(t, ri.ProposalQuotaOverride)
+}
+
+func TestProposalQuotaWaitersLongestWait(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	var w proposalQuotaWaiters
+	require.Zero(t, w.longestWait(timeutil.Now()))
+
+	// Any distinct pointers do as quota requests.
+	type request struct{ quotapool.Request }
+	r1, r2 := &request{}, &request{}
+	w.onWaitStart(ctx, "test", r1)
+	w.onWaitStart(ctx, "test", r2)
+	w.mu.Lock()
+	start := timeutil.Now()
+	w.waiting[r1] = start.Add(-time.Minute)
+	w.waiting[r2] = start.Add(-time.Second)
+	w.mu.Unlock()
+	require.Equal(t, time.Minute, w.longestWait(start))
+
+	w.onWaitFinish(ctx, "test", r1, start)
+	require.Equal(t, time.Second, w.longestWait(start))
+	w.onWaitFinish(ctx, "test", r2, start)
+	require.Zero(t, w.longestWait(start))
+}
+
 // TestQuotaPoolAccessOnDestroyedReplica tests the occurrence of #17303 where
 // following a leader replica getting destroyed, the scheduling of
 // handleRaftReady twice on the replica would cause a panic when
@@ -8255,6 +8425,36 @@ func TestFailureToProcessCommandClearsLocalResult(t *testing.T) {
 	}
 }
 
+// TestApplyEventsInProposerTrace verifies that the application of a locally
+// proposed command is recorded in the trace of the request which proposed it.
+func TestApplyEventsInProposerTrace(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	var tc testContext
+	cfg := TestStoreConfig(nil)
+	// Don't acknowledge the command before it is applied, which would finish
+	// the trace before the application.
+	cfg.TestingKnobs.DisableCanAckBeforeApplication = true
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	tc.StartWithStoreConfig(ctx, t, stopper, cfg)
+
+	tr := tc.store.cfg.AmbientCtx.Tracer
+	opCtx, getRecAndFinish := tracing.ContextWithRecordingSpan(ctx, tr, "test-recording")
+	defer getRecAndFinish()
+
+	put := putArgs(roachpb.Key("a"), []byte("value"))
+	if _, pErr := kv.SendWrapped(opCtx, tc.Sender(), &put); pErr != nil {
+		t.Fatal(pErr)
+	}
+	require.NoError(t, testutils.MatchInOrder(getRecAndFinish().String(),
+		"staging command at raft index",
+		"ran triggers and staged command in application batch",
+		"committed application batch of .* entries",
+	))
+}
+
 // TestMVCCStatsGCCommutesWithWrites tests that the MVCCStats updates
 // corresponding to writes and GCs are commutative.
 //
@@ -8408,6 +8608,64 @@ func TestBatchTimestampBelowGCThreshold(t *testing.T) {
 	}
 }
 
+// TestReadBelowGCThreshold verifies that reads which allow it read below the
+// replica GC threshold, and report the threshold in their response.
+func TestReadBelowGCThreshold(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	tc.Start(ctx, t, stopper)
+
+	now := tc.Clock().Now()
+	ts1 := now.Add(1, 0)
+	ts2 := now.Add(2, 0)
+	ts3 := now.Add(3, 0)
+
+	keyA, keyB := roachpb.Key("a"), roachpb.Key("b")
+	for _, w := range []struct {
+		key roachpb.Key
+		ts  hlc.Timestamp
+	}{{keyA, ts1}, {keyA, ts2}, {keyB, ts1}} {
+		pArgs := putArgs(w.key, []byte("v"))
+		_, pErr := tc.SendWrappedWith(kvpb.Header{Timestamp: w.ts}, &pArgs)
+		require.Nil(t, pErr)
+	}
+
+	// GC the version of a@ts1, which is shadowed by a@ts2.
+	gcReq := gcArgs(keyA, keyB.Next(), gcKey(keyA, ts1))
+	gcReq.Threshold = ts2
+	_, pErr := tc.SendWrappedWith(kvpb.Header{RangeID: 1}, &gcReq)
+	require.Nil(t, pErr)
+
+	scan := func(ts hlc.Timestamp, allowBelowGCThreshold bool) (*kvpb.BatchResponse, *kvpb.Error) {
+		ba := &kvpb.BatchRequest{}
+		ba.Timestamp = ts
+		ba.AllowReadBelowGCThreshold = allowBelowGCThreshold
+		ba.Add(scanArgs(keyA, keyB.Next()))
+		return tc.Sender().Send(ctx, ba)
+	}
+
+	// A read below the threshold fails, unless it allows it.
+	_, pErr = scan(ts1, false /* allowBelowGCThreshold */)
+	require.True(t, testutils.IsPError(pErr, `must be after replica GC threshold`), "%v", pErr)
+	br, pErr := scan(ts1, true /* allowBelowGCThreshold */)
+	require.Nil(t, pErr)
+	require.Equal(t, ts2, br.TruncatedBelowGCThreshold)
+	// The GC'ed version is missing from the results.
+	rows := br.Responses[0].GetScan().Rows
+	require.Len(t, rows, 1)
+	require.Equal(t, keyB, rows[0].Key)
+
+	// A read above the threshold isn't truncated.
+	br, pErr = scan(ts3, true /* allowBelowGCThreshold */)
+	require.Nil(t, pErr)
+	require.True(t, br.TruncatedBelowGCThreshold.IsEmpty())
+	require.Len(t, br.Responses[0].GetScan().Rows, 2)
+}
+
 // TestRefreshFromBelowGCThreshold verifies that refresh requests that need to
 // see MVCC history below the replica GC threshold fail.
 func TestRefreshFromBelowGCThreshold(t *testing.T) {
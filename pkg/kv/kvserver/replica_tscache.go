@@ -235,6 +235,13 @@ func (r *Replica) updateTimestampCache(
 			if _, ok := pErr.GetDetail().(*kvpb.ConditionFailedError); ok {
 				addToTSCache(start, end, ts, txnID)
 			}
+		case *kvpb.CheckAndMutateRequest:
+			// CheckAndMutate only reads the keys of its mutations, so the
+			// timestamp cache is updated on these keys instead of the request
+			// span, which may cover many more keys.
+			for i := range t.Mutations {
+				addToTSCache(t.Mutations[i].Key, nil, ts, txnID)
+			}
 		case *kvpb.GetRequest:
 			if !beforeEval && resp.(*kvpb.GetResponse).ResumeSpan != nil {
 				// The request did not evaluate. Ignore it.
@@ -141,6 +141,13 @@ func (r *Replica) executeWriteBatch(
 	}
 	defer tok.DoneIfNotMoved(ctx)
 
+	// A batch with a commit timestamp floor must not be forced above the floor
+	// by the closed timestamp, see kvpb.Header.CommitTimestampFloor.
+	if floor := ba.CommitTimestampFloor; !floor.IsEmpty() && floor.Less(minTS) {
+		return nil, g, nil, kvpb.NewError(
+			kvpb.NewCommitTimestampFloorClosedError(r.RangeID, floor, minTS.Prev()))
+	}
+
 	// Examine the timestamp cache for preceding commands which require this
 	// command to move its timestamp forward. Or, in the case of a transactional
 	// write, the txn timestamp and possible write-too-old bool.
@@ -284,6 +291,11 @@ func (r *Replica) executeWriteBatch(
 					})
 				propResult.Err = kvpb.NewError(applicationErr)
 			}
+			if ba.Requests[0].GetBarrier() != nil && propResult.Err == nil {
+				// A Barrier may want the commands it waited for to be applied on all
+				// voters, which we achieve via waitForApplication like for Migrate.
+				propResult.Err = kvpb.NewError(r.waitForBarrierApplication(ctx, propResult.Reply))
+			}
 			if propResult.Err != nil && ba.IsSingleProbeRequest() && errors.Is(
 				propResult.Err.GoError(), kvserverbase.NoopOnProbeCommandErr.GoError(),
 			) {
@@ -353,6 +365,30 @@ func (r *Replica) executeWriteBatch(
 
 // canAttempt1PCEvaluation looks at the batch and decides whether it can be
 // executed as 1PC.
+// waitForBarrierApplication waits until all voters of the range applied the
+// lease applied index returned by the barriers of the batch which wait for all
+// voters. The descriptor is read after the barrier was evaluated, so it
+// includes any voter which could have missed the commands the barrier waited
+// for.
+func (r *Replica) waitForBarrierApplication(ctx context.Context, br *kvpb.BatchResponse) error {
+	for _, union := range br.Responses {
+		resp, ok := union.GetInner().(*kvpb.BarrierResponse)
+		if !ok {
+			continue
+		}
+		for _, ai := range resp.AppliedIndexes {
+			desc := r.Desc()
+			if err := waitForApplication(
+				ctx, r.store.cfg.NodeDialer, desc.RangeID, desc.Replicas().VoterDescriptors(),
+				ai.LeaseAppliedIndex,
+			); err != nil {
+				return errors.Wrap(err, "waiting for voters to apply barrier")
+			}
+		}
+	}
+	return nil
+}
+
 func (r *Replica) canAttempt1PCEvaluation(
 	ctx context.Context, ba *kvpb.BatchRequest, g *concurrency.Guard,
 ) bool {
@@ -48,6 +48,23 @@ var AddSSTableRequestSizeFactor = settings.RegisterIntSetting(
 	50000,
 	settings.WithPublic)
 
+// DefaultDecayHalfLife is the half-life of the decay of the per-locality
+// request rates, with which the weight of a window decays by decayFactor at
+// every rotation.
+var DefaultDecayHalfLife = time.Duration(
+	float64(replStatsRotateInterval) * math.Log(0.5) / math.Log(decayFactor))
+
+// DecayHalfLife wraps "kv.replica_stats.decay_half_life".
+var DecayHalfLife = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.replica_stats.decay_half_life",
+	"the half-life of the exponential decay of the per-locality request rates of the "+
+		"replicas, which inform follow-the-workload lease transfers; a shorter half-life "+
+		"reacts faster to changes of the workload",
+	DefaultDecayHalfLife,
+	settings.DurationInRange(time.Minute, 24*time.Hour),
+)
+
 // LocalityOracle provides a mapping between a node ID and it's corresponding
 // locality.
 type LocalityOracle func(roachpb.NodeID) string
@@ -84,6 +101,10 @@ type ReplicaStats struct {
 	lastRotate time.Time
 	lastReset  time.Time
 
+	// decayFactor is the factor by which the weight of a window decays at every
+	// rotation. See SetDecayHalfLife.
+	decayFactor float64
+
 	// Testing only.
 	avgRateForTesting float64
 }
@@ -192,6 +213,7 @@ func (rsr *replicaStatsRecord) split(other *replicaStatsRecord) {
 func NewReplicaStats(now time.Time, getNodeLocality LocalityOracle) *ReplicaStats {
 	rs := &ReplicaStats{
 		getNodeLocality: getNodeLocality,
+		decayFactor:     decayFactor,
 	}
 
 	rs.lastRotate = now
@@ -314,7 +336,7 @@ func (rs *ReplicaStats) PerLocalityDecayingRate(now time.Time) PerLocalityCounts
 		// negative result from the modulus operation when rs.idx is small.
 		requestsIdx := (rs.idx + len(rs.records) - i) % len(rs.records)
 		if cur := rs.records[requestsIdx]; cur.active {
-			decay := math.Pow(decayFactor, float64(i)+fractionOfRotation)
+			decay := math.Pow(rs.decayFactor, float64(i)+fractionOfRotation)
 			if i == 0 {
 				duration += time.Duration(float64(timeSinceRotate) * decay)
 			} else {
@@ -386,6 +408,26 @@ func (rs *ReplicaStats) ResetRequestCounts(now time.Time) {
 	rs.lastReset = rs.lastRotate
 }
 
+// SetDecayHalfLife sets the half-life of the decay of the per-locality rates.
+func (rs *ReplicaStats) SetDecayHalfLife(halfLife time.Duration) {
+	if halfLife <= 0 {
+		return
+	}
+	rs.decayFactor = math.Pow(0.5, float64(replStatsRotateInterval)/float64(halfLife))
+}
+
+// RestoreRate resets the stats to those of a rate measured over the given
+// duration, e.g. before a restart. The restored counts are weighted as if they
+// were recorded in the current window, which spans at most one rotation
+// interval.
+func (rs *ReplicaStats) RestoreRate(now time.Time, rate float64, duration time.Duration) {
+	if duration > replStatsRotateInterval {
+		duration = replStatsRotateInterval
+	}
+	rs.ResetRequestCounts(now.Add(-duration))
+	rs.records[rs.idx].sum = rate * duration.Seconds()
+}
+
 // SnapshotRatedSummary returns a RatedSummary representing a snapshot of the
 // current replica stats state, summarized by arithmetic mean count,
 // per-locality count and duration recorded over.
@@ -658,3 +658,54 @@ func TestReplicaStatsRecordAggregate(t *testing.T) {
 
 	require.Equal(t, expectedStatsRecord, rs.records[rs.idx])
 }
+
+func TestReplicaStatsDecayHalfLife(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	now := testingStartTime()
+	rs := NewReplicaStats(now, func(roachpb.NodeID) string { return "a" })
+
+	// The default half-life preserves the legacy decay factor.
+	rs.SetDecayHalfLife(DefaultDecayHalfLife)
+	require.InDelta(t, decayFactor, rs.decayFactor, 1e-9)
+
+	// With a half-life of one rotation, a window's weight halves at every
+	// rotation.
+	rs.SetDecayHalfLife(replStatsRotateInterval)
+	rs.RecordCount(now, 10, 1)
+	now = now.Add(replStatsRotateInterval)
+	rs.RecordCount(now, 10, 1)
+	now = now.Add(replStatsRotateInterval / 2)
+	counts := rs.PerLocalityDecayingRate(now)
+	weight := math.Pow(0.5, 0.5)
+	expected := (10*weight + 10*weight/2) /
+		(replStatsRotateInterval.Seconds()/2*weight + replStatsRotateInterval.Seconds()*weight/2)
+	require.InDelta(t, expected, counts["a"], 1e-9)
+}
+
+func TestReplicaStatsRestoreRate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	now := testingStartTime()
+	rs := NewReplicaStats(now, nil)
+	rs.RecordCount(now, 100, 0)
+
+	// The restored rate replaces the recorded history.
+	rs.RestoreRate(now, 2, time.Minute)
+	rate, dur := rs.AverageRatePerSecond(now)
+	require.InDelta(t, 2, rate, 1e-9)
+	require.Equal(t, time.Minute, dur)
+
+	// New counts are averaged with the restored rate.
+	rs.RecordCount(now, 120, 0)
+	rate, _ = rs.AverageRatePerSecond(now)
+	require.InDelta(t, 4, rate, 1e-9)
+
+	// Rates measured over longer durations are restored over one window.
+	rs.RestoreRate(now, 3, time.Hour)
+	rate, dur = rs.AverageRatePerSecond(now)
+	require.InDelta(t, 3, rate, 1e-9)
+	require.Equal(t, replStatsRotateInterval, dur)
+}
@@ -228,6 +228,12 @@ var (
 		Measurement: "Demotions of Voters to Non Voters",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaReplicateQueuePinRemovedDeadStoreCount = metric.Metadata{
+		Name:        "queue.replicate.pin.removed_dead_store",
+		Help:        "Number of replica pins removed by the replicate queue because a pinned store was dead",
+		Measurement: "Replica Pins",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaReplicateQueueAddReplicaSuccessCount = metric.Metadata{
 		Name:        "queue.replicate.addreplica.success",
 		Help:        "Number of successful replica additions processed by the replicate queue",
@@ -342,6 +348,7 @@ type ReplicateQueueMetrics struct {
 	TransferLeaseCount                        *metric.Counter
 	NonVoterPromotionsCount                   *metric.Counter
 	VoterDemotionsCount                       *metric.Counter
+	PinRemovedDeadStoreCount                  *metric.Counter
 
 	// Success/error counts by allocator action.
 	RemoveReplicaSuccessCount                 *metric.Counter
@@ -382,6 +389,7 @@ func makeReplicateQueueMetrics() ReplicateQueueMetrics {
 		TransferLeaseCount:                        metric.NewCounter(metaReplicateQueueTransferLeaseCount),
 		NonVoterPromotionsCount:                   metric.NewCounter(metaReplicateQueueNonVoterPromotionsCount),
 		VoterDemotionsCount:                       metric.NewCounter(metaReplicateQueueVoterDemotionsCount),
+		PinRemovedDeadStoreCount:                  metric.NewCounter(metaReplicateQueuePinRemovedDeadStoreCount),
 
 		RemoveReplicaSuccessCount:                 metric.NewCounter(metaReplicateQueueRemoveReplicaSuccessCount),
 		RemoveReplicaErrorCount:                   metric.NewCounter(metaReplicateQueueRemoveReplicaErrorCount),
@@ -635,6 +643,9 @@ func (rq *replicateQueue) shouldQueue(
 		return false, 0
 	}
 	desc := repl.Desc()
+	if pin := repl.ReplicaPin(); pin != nil {
+		return rq.shouldQueuePinned(desc, pin)
+	}
 	return rq.planner.ShouldPlanChange(
 		ctx,
 		now,
@@ -881,6 +892,11 @@ func (rq *replicateQueue) processOneChange(
 		return false, err
 	}
 
+	// The voters of a pinned range are placed by its pin, not the allocator.
+	if pin := repl.ReplicaPin(); pin != nil {
+		return rq.enforceReplicaPin(ctx, desc, pin, dryRun)
+	}
+
 	change, err := rq.planner.PlanOneChange(ctx, repl, desc, conf, canTransferLeaseFrom, scatter)
 	// When there is an error planning a change, return the error immediately
 	// and do not requeue. It is unlikely that the range or storepool state
@@ -997,6 +1013,11 @@ func (rq *replicateQueue) shedLease(
 	opts allocator.TransferLeaseOptions,
 ) (allocator.LeaseTransferOutcome, error) {
 	rangeUsageInfo := repl.RangeUsageInfo()
+	// The hot standby of the range, which is kept ready to take over the lease,
+	// breaks the ties between the targets the allocator considers equally good.
+	if standby, ok := repl.hotStandbyLeaseTarget(); ok {
+		opts.PreferredTarget = standby.StoreID
+	}
 	// Learner replicas aren't allowed to become the leaseholder or raft leader,
 	// so only consider the `VoterDescriptors` replicas.
 	target := rq.allocator.TransferLeaseTarget(
@@ -46,6 +46,11 @@ type replicaQueue interface {
 	NeedsLease() bool
 	// SetDisabled turns queue processing off or on as directed.
 	SetDisabled(disabled bool)
+	// SetPaused pauses or resumes the processing of the given range, or of all
+	// ranges if rangeID is zero, as requested by an operator.
+	SetPaused(rangeID roachpb.RangeID, paused bool, reason string, now time.Time) bool
+	// Pauses returns the pauses of the queue, ordered by range ID.
+	Pauses() []QueuePause
 }
 
 // A replicaSet provides access to a sequence of replicas to consider
@@ -119,6 +119,14 @@ func (tq *testQueue) SetDisabled(d bool) {
 	tq.disabled = d
 }
 
+func (tq *testQueue) SetPaused(roachpb.RangeID, bool, string, time.Time) bool {
+	return false
+}
+
+func (tq *testQueue) Pauses() []QueuePause {
+	return nil
+}
+
 func (tq *testQueue) Start(stopper *stop.Stopper) {
 	done := func() {
 		tq.Lock()
@@ -352,6 +352,18 @@ func (s *raftScheduler) PriorityIDs() []roachpb.RangeID {
 	return priorityIDs
 }
 
+// QueueLen returns the number of ranges waiting to be processed, across all
+// the shards of the scheduler.
+func (s *raftScheduler) QueueLen() int {
+	var n int
+	for _, ss := range s.shards {
+		ss.Lock()
+		n += ss.queue.Len()
+		ss.Unlock()
+	}
+	return n
+}
+
 func (ss *raftSchedulerShard) worker(
 	ctx context.Context, processor raftProcessor, metrics *StoreMetrics,
 ) {
@@ -0,0 +1,160 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// snapshotNodeSendRate bounds the bandwidth of the snapshots sent by all the
+// stores of a node, on top of the per-snapshot kv.snapshot_rebalance.max_rate.
+var snapshotNodeSendRate = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.snapshot.node_send_rate",
+	"the rate limit (bytes/sec) of the snapshots sent by all the stores of a node, "+
+		"shared fairly among the stores; 0 disables the limit",
+	0,
+	settings.NonNegativeInt,
+)
+
+// snapshotNodeRecvRate bounds the bandwidth of the snapshots received by all
+// the stores of a node.
+var snapshotNodeRecvRate = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.snapshot.node_recv_rate",
+	"the rate limit (bytes/sec) of the snapshots received by all the stores of a "+
+		"node, shared fairly among the stores; 0 disables the limit",
+	0,
+	settings.NonNegativeInt,
+)
+
+// snapshotBandwidth coordinates the snapshot bandwidth of the stores of a node
+// in one direction. The stores share a token bucket, refilled at the rate of
+// the node, which serves its waiters in order. Each store has at most one
+// request waiting in the bucket at a time, so that the stores take turns
+// regardless of the number of snapshots each of them has in flight.
+type snapshotBandwidth struct {
+	st       *cluster.Settings
+	rate     *settings.ByteSizeSetting
+	limiter  *quotapool.RateLimiter
+	waitTime *metric.Counter
+	mu       struct {
+		syncutil.Mutex
+		// turns holds, for each store, a semaphore admitting a single request of
+		// the store into limiter.
+		turns map[roachpb.StoreID]chan struct{}
+	}
+}
+
+func newSnapshotBandwidth(
+	name string, st *cluster.Settings, rate *settings.ByteSizeSetting, waitTime *metric.Counter,
+) *snapshotBandwidth {
+	b := &snapshotBandwidth{
+		st:       st,
+		rate:     rate,
+		limiter:  quotapool.NewRateLimiter(name, quotapool.Inf(), 0),
+		waitTime: waitTime,
+	}
+	b.mu.turns = map[roachpb.StoreID]chan struct{}{}
+	update := func(context.Context) {
+		if r := rate.Get(&st.SV); r > 0 {
+			// The burst allows for a second worth of data.
+			b.limiter.UpdateLimit(quotapool.Limit(r), r)
+		} else {
+			b.limiter.UpdateLimit(quotapool.Inf(), 0)
+		}
+	}
+	rate.SetOnChange(&st.SV, update)
+	update(context.Background())
+	return b
+}
+
+func (b *snapshotBandwidth) turn(storeID roachpb.StoreID) chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	turn, ok := b.mu.turns[storeID]
+	if !ok {
+		turn = make(chan struct{}, 1)
+		b.mu.turns[storeID] = turn
+	}
+	return turn
+}
+
+// WaitN waits until the node's bandwidth allows the store to send or receive n
+// bytes of snapshot data.
+func (b *snapshotBandwidth) WaitN(ctx context.Context, storeID roachpb.StoreID, n int64) error {
+	if b.rate.Get(&b.st.SV) == 0 {
+		return nil
+	}
+	start := timeutil.Now()
+	defer func() { b.waitTime.Inc(timeutil.Since(start).Nanoseconds()) }()
+	turn := b.turn(storeID)
+	select {
+	case turn <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-turn }()
+	return b.limiter.WaitN(ctx, n)
+}
+
+// snapshotBandwidthSendStream is an outgoingSnapshotStream whose batches are
+// sent within the bandwidth of the node.
+type snapshotBandwidthSendStream struct {
+	outgoingSnapshotStream
+	ctx       context.Context
+	bandwidth *snapshotBandwidth
+	storeID   roachpb.StoreID
+}
+
+// Send implements the outgoingSnapshotStream interface.
+func (s *snapshotBandwidthSendStream) Send(req *kvserverpb.SnapshotRequest) error {
+	if n := len(req.KVBatch); n > 0 {
+		if err := s.bandwidth.WaitN(s.ctx, s.storeID, int64(n)); err != nil {
+			return err
+		}
+	}
+	return s.outgoingSnapshotStream.Send(req)
+}
+
+// snapshotBandwidthRecvStream is a SnapshotResponseStream whose batches are
+// received within the bandwidth of the node: the next batch isn't received
+// until the previous one fits in the bandwidth, which pushes back on the
+// sender through the flow control of the stream.
+type snapshotBandwidthRecvStream struct {
+	SnapshotResponseStream
+	ctx       context.Context
+	bandwidth *snapshotBandwidth
+	storeID   roachpb.StoreID
+}
+
+// Recv implements the SnapshotResponseStream interface.
+func (s *snapshotBandwidthRecvStream) Recv() (*kvserverpb.SnapshotRequest, error) {
+	req, err := s.SnapshotResponseStream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if n := len(req.KVBatch); n > 0 {
+		if err := s.bandwidth.WaitN(s.ctx, s.storeID, int64(n)); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotBandwidth(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	waitTime := metric.NewCounter(metric.Metadata{})
+	b := newSnapshotBandwidth("test", st, snapshotNodeSendRate, waitTime)
+
+	// The bandwidth isn't limited by default.
+	require.NoError(t, b.WaitN(ctx, 1, 1<<30))
+	require.Zero(t, waitTime.Count())
+
+	// With a limit, the bucket initially holds a second worth of data.
+	snapshotNodeSendRate.Override(ctx, &st.SV, 100)
+	require.NoError(t, b.WaitN(ctx, 1, 100))
+
+	// The first store waits for the bucket to refill, and holds its turn in the
+	// meantime: its other requests wait behind it, outside of the bucket.
+	errCh := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errCh <- b.WaitN(ctx, 1, 10) }()
+	}
+	require.Eventually(t, func() bool {
+		return len(b.turn(1)) == 1
+	}, 10*time.Second, time.Millisecond)
+
+	// The requests of another store have their own turn, which is released when
+	// they give up.
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, b.WaitN(cancelCtx, 2, 10), context.Canceled)
+	require.Empty(t, b.turn(2))
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, <-errCh)
+	}
+	require.Empty(t, b.turn(1))
+	require.NotZero(t, waitTime.Count())
+}
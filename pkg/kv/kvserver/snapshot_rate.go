@@ -0,0 +1,108 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// snapshotDynamicRateEnabled controls whether the receivers of snapshots
+// advertise the rate at which they can ingest them, given the health of their
+// LSM, which the senders adopt when it is below
+// kv.snapshot_rebalance.max_rate.
+var snapshotDynamicRateEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.snapshot_receiver.dynamic_rate.enabled",
+	"if enabled, the receiver of a snapshot lowers the rate at which it is sent below "+
+		"kv.snapshot_rebalance.max_rate as its L0 sublevels and compaction debt grow",
+	true,
+)
+
+const (
+	// snapshotRateL0SublevelsLow and snapshotRateL0SublevelsHigh are the
+	// numbers of L0 sublevels of a store between which the rate of the
+	// snapshots it receives is lowered from kv.snapshot_rebalance.max_rate
+	// down to minDynamicSnapshotRate. The high mark matches the default L0
+	// sublevel threshold of admission control.
+	snapshotRateL0SublevelsLow  = 5
+	snapshotRateL0SublevelsHigh = 20
+	// snapshotRateCompactionDebtLow and snapshotRateCompactionDebtHigh are
+	// the estimated compaction debts of a store between which the rate of the
+	// snapshots it receives is lowered down to minDynamicSnapshotRate.
+	snapshotRateCompactionDebtLow  = 16 << 30 // 16 GiB
+	snapshotRateCompactionDebtHigh = 64 << 30 // 64 GiB
+	// snapshotRateMaxReduction bounds the lowering of the rate of snapshots to
+	// a fraction of kv.snapshot_rebalance.max_rate, well within the slowdown
+	// permitted by the timeouts of the queues sending snapshots, which are
+	// derived from that setting (see makeRateLimitedTimeoutFunc).
+	snapshotRateMaxReduction = 4
+)
+
+// minDynamicSnapshotRate returns the lowest rate (bytes/sec) at which a
+// snapshot is sent when its receiver asks for a lower rate than maxRate.
+func minDynamicSnapshotRate(maxRate int64) int64 {
+	return min(maxRate, max(maxRate/snapshotRateMaxReduction, minSnapshotRate))
+}
+
+// snapshotIngestRate returns the rate (bytes/sec) at which a store with the
+// given number of L0 sublevels and compaction debt can ingest snapshots, out
+// of maxRate. The rate is lowered linearly between the low and high marks of
+// either signal, whichever is the unhealthiest, but never below
+// minDynamicSnapshotRate, so that the snapshots still complete in time.
+func snapshotIngestRate(maxRate, l0Sublevels, compactionDebt int64) int64 {
+	health := func(v, low, high int64) float64 {
+		switch {
+		case v <= low:
+			return 1
+		case v >= high:
+			return 0
+		default:
+			return float64(high-v) / float64(high-low)
+		}
+	}
+	h := min(
+		health(l0Sublevels, snapshotRateL0SublevelsLow, snapshotRateL0SublevelsHigh),
+		health(compactionDebt, snapshotRateCompactionDebtLow, snapshotRateCompactionDebtHigh),
+	)
+	minRate := minDynamicSnapshotRate(maxRate)
+	return minRate + int64(h*float64(maxRate-minRate))
+}
+
+// advertisedSnapshotRate returns the rate (bytes/sec) which the store
+// advertises to the sender of a snapshot it accepts, or 0 if it doesn't
+// advertise one, in which case the sender uses kv.snapshot_rebalance.max_rate.
+func (s *Store) advertisedSnapshotRate() int64 {
+	sv := &s.ClusterSettings().SV
+	if !snapshotDynamicRateEnabled.Get(sv) {
+		return 0
+	}
+	maxRate := rebalanceSnapshotRate.Get(sv)
+	rate := snapshotIngestRate(
+		maxRate,
+		int64(syncutil.LoadFloat64(&s.metrics.l0SublevelsWindowedMax)),
+		s.metrics.RdbPendingCompaction.Value(),
+	)
+	s.metrics.RangeSnapshotRecvAdvertisedRate.Update(rate)
+	if rate < maxRate {
+		s.metrics.RangeSnapshotRecvRateLimited.Inc(1)
+	}
+	return rate
+}
+
+// negotiatedSnapshotRate returns the rate (bytes/sec) at which a snapshot is
+// sent, given the rate advertised by its receiver, if any.
+func negotiatedSnapshotRate(maxRate, advertisedRate int64) int64 {
+	if advertisedRate <= 0 {
+		return maxRate
+	}
+	return max(min(maxRate, advertisedRate), minDynamicSnapshotRate(maxRate))
+}
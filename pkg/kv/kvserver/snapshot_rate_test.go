@@ -0,0 +1,64 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotIngestRate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const maxRate = 32 << 20
+	const minRate = maxRate / snapshotRateMaxReduction
+	for _, tc := range []struct {
+		name           string
+		l0Sublevels    int64
+		compactionDebt int64
+		expected       int64
+	}{
+		{"healthy", 0, 0, maxRate},
+		{"low marks", snapshotRateL0SublevelsLow, snapshotRateCompactionDebtLow, maxRate},
+		{"l0 overload", snapshotRateL0SublevelsHigh, 0, minRate},
+		{"compaction debt overload", 0, 2 * snapshotRateCompactionDebtHigh, minRate},
+		{"compaction debt halfway", 0, 40 << 30, minRate + (maxRate-minRate)/2},
+		{"unhealthiest signal", 17, 40 << 30, minRate + (maxRate-minRate)/5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, snapshotIngestRate(maxRate, tc.l0Sublevels, tc.compactionDebt))
+		})
+	}
+
+	// The rate is never lowered below minSnapshotRate.
+	require.Equal(t, int64(minSnapshotRate),
+		snapshotIngestRate(2*minSnapshotRate, snapshotRateL0SublevelsHigh, 0))
+	require.Equal(t, int64(minSnapshotRate),
+		snapshotIngestRate(minSnapshotRate, snapshotRateL0SublevelsHigh, 0))
+}
+
+func TestNegotiatedSnapshotRate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const maxRate = 32 << 20
+	// Receivers which don't advertise a rate get the configured one.
+	require.Equal(t, int64(maxRate), negotiatedSnapshotRate(maxRate, 0))
+	require.Equal(t, int64(20<<20), negotiatedSnapshotRate(maxRate, 20<<20))
+	// The advertised rate can't exceed the configured one, nor lower it beyond
+	// the bound of the timeouts of the senders.
+	require.Equal(t, int64(maxRate), negotiatedSnapshotRate(maxRate, 64<<20))
+	require.Equal(t, int64(maxRate/snapshotRateMaxReduction), negotiatedSnapshotRate(maxRate, 1))
+}
@@ -20,7 +20,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/spanconfig"
-	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -65,6 +64,12 @@ var (
 		Measurement: "Range Splits",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaHashShardBasedSplitCount = metric.Metadata{
+		Name:        "queue.split.hash_shard_based",
+		Help:        "Number of range splits at the boundaries of hash-sharded spans and their shards",
+		Measurement: "Range Splits",
+		Unit:        metric.Unit_COUNT,
+	}
 )
 
 // SplitQueueMetrics is the set of metrics for the split queue.
@@ -72,6 +77,7 @@ type SplitQueueMetrics struct {
 	SizeBasedSplitCount       *metric.Counter
 	LoadBasedSplitCount       *metric.Counter
 	SpanConfigBasedSplitCount *metric.Counter
+	HashShardBasedSplitCount  *metric.Counter
 }
 
 func makeSplitQueueMetrics() SplitQueueMetrics {
@@ -79,6 +85,7 @@ func makeSplitQueueMetrics() SplitQueueMetrics {
 		SizeBasedSplitCount:       metric.NewCounter(metaSizeBasedSplitCount),
 		LoadBasedSplitCount:       metric.NewCounter(metaLoadBasedSplitCount),
 		SpanConfigBasedSplitCount: metric.NewCounter(metaSpanConfigBasedSplitCount),
+		HashShardBasedSplitCount:  metric.NewCounter(metaHashShardBasedSplitCount),
 	}
 }
 
@@ -136,7 +143,7 @@ func newSplitQueue(store *Store, db *kv.DB) *splitQueue {
 func shouldSplitRange(
 	ctx context.Context,
 	desc *roachpb.RangeDescriptor,
-	ms enginepb.MVCCStats,
+	size int64,
 	maxBytes int64,
 	shouldBackpressureWrites bool,
 	confReader spanconfig.StoreReader,
@@ -154,7 +161,7 @@ func shouldSplitRange(
 
 	// Add priority based on the size of range compared to the max
 	// size for the zone it's in.
-	if ratio := float64(ms.Total()) / float64(maxBytes); ratio > 1 {
+	if ratio := float64(size) / float64(maxBytes); ratio > 1 {
 		priority += ratio
 		shouldQ = true
 	}
@@ -185,9 +192,18 @@ func shouldSplitRange(
 func (sq *splitQueue) shouldQueue(
 	ctx context.Context, now hlc.ClockTimestamp, repl *Replica, confReader spanconfig.StoreReader,
 ) (shouldQ bool, priority float64) {
-	shouldQ, priority = shouldSplitRange(ctx, repl.Desc(), repl.GetMVCCStats(),
+	shouldQ, priority = shouldSplitRange(ctx, repl.Desc(), repl.getSizeEstimate(ctx).Bytes,
 		repl.GetMaxBytes(ctx), repl.shouldBackpressureWrites(), confReader)
 
+	if !shouldQ {
+		desc := repl.Desc()
+		hashSharded := sq.store.cfg.HashShardedSpans.Get()
+		if splitKey := hashSharded.SplitKey(desc.StartKey, desc.EndKey); splitKey != nil {
+			// Same priority as the splits at span config boundaries.
+			shouldQ, priority = true, 1.0
+		}
+	}
+
 	if !shouldQ && repl.SplitByLoadEnabled() {
 		if splitKey := repl.loadSplitKey(ctx, repl.Clock().PhysicalTime()); splitKey != nil {
 			shouldQ, priority = true, 1.0 // default priority
@@ -254,10 +270,34 @@ func (sq *splitQueue) processAttempt(
 		return true, nil
 	}
 
+	// Next handle the boundaries of the hash-sharded spans and their shards,
+	// which keep each shard in its own ranges.
+	hashSharded := sq.store.cfg.HashShardedSpans.Get()
+	if splitKey := hashSharded.SplitKey(desc.StartKey, desc.EndKey); splitKey != nil {
+		if _, err := r.adminSplitWithDescriptor(
+			ctx,
+			kvpb.AdminSplitRequest{
+				RequestHeader: kvpb.RequestHeader{
+					Key: splitKey.AsRawKey(),
+				},
+				SplitKey:       splitKey.AsRawKey(),
+				ExpirationTime: hlc.Timestamp{},
+			},
+			desc,
+			false, /* delayable */
+			"hash-sharded span",
+			false, /* findFirstSafeSplitKey */
+		); err != nil {
+			return false, errors.Wrapf(err, "unable to split %s at key %q", r, splitKey)
+		}
+		sq.metrics.HashShardBasedSplitCount.Inc(1)
+		return true, nil
+	}
+
 	// Next handle case of splitting due to size. Note that we don't perform
 	// size-based splitting if maxBytes is 0 (happens in certain test
 	// situations).
-	size := r.GetMVCCStats().Total()
+	size := r.getSizeEstimate(ctx).Bytes
 	maxBytes := r.GetMaxBytes(ctx)
 	if maxBytes > 0 && size > maxBytes {
 		reason := redact.Sprintf(
@@ -0,0 +1,122 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/spanconfig"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// splitSpanConfigBoundarySnapFraction controls how far from the key picked to
+// split a range by size the range is split at a span config boundary instead.
+// The boundaries considered include the ones between adjacent span configs
+// which are coalesced into a single range because they are identical: these
+// call for a split as soon as the configs diverge, which would otherwise
+// carve a small range out of one of the halves shortly after the size-based
+// split.
+var splitSpanConfigBoundarySnapFraction = settings.RegisterFloatSetting(
+	settings.SystemOnly,
+	"kv.range_split.span_config_boundary_snap_fraction",
+	"the maximum distance, as a fraction of the size of a range, between the key at which "+
+		"the range is split by size and a span config boundary within it, for the range to "+
+		"be split at the boundary instead (0 disables)",
+	0.1,
+	settings.FloatInRange(0, 0.5),
+)
+
+// maxSplitSpanConfigBoundaries bounds the number of span config boundaries
+// considered when picking the key to split a range by size at.
+const maxSplitSpanConfigBoundaries = 32
+
+// maybeSnapSplitKeyToSpanConfigBoundary returns the span config boundary
+// within the range nearest to the given size-based split key, if it's within
+// kv.range_split.span_config_boundary_snap_fraction of the size of the range
+// from it, or the split key otherwise.
+func (r *Replica) maybeSnapSplitKeyToSpanConfigBoundary(
+	ctx context.Context, desc *roachpb.RangeDescriptor, splitKey roachpb.Key,
+) roachpb.Key {
+	fraction := splitSpanConfigBoundarySnapFraction.Get(&r.store.cfg.Settings.SV)
+	if fraction == 0 {
+		return splitKey
+	}
+	confReader, err := r.store.GetConfReader(ctx)
+	if err != nil {
+		return splitKey
+	}
+	boundaryReader, ok := confReader.(spanconfig.BoundaryReader)
+	if !ok {
+		// The system config span doesn't track coalesced span configs.
+		return splitKey
+	}
+	boundaries, err := boundaryReader.ComputeBoundaries(
+		ctx, desc.StartKey, desc.EndKey, maxSplitSpanConfigBoundaries)
+	if err != nil || len(boundaries) == 0 {
+		return splitKey
+	}
+	eng := r.store.TODOEngine()
+	distance := func(from, to roachpb.Key) (uint64, error) {
+		total, _, _, err := eng.ApproximateDiskBytes(from, to)
+		return total, err
+	}
+	rangeBytes, err := distance(desc.StartKey.AsRawKey(), desc.EndKey.AsRawKey())
+	if err != nil {
+		return splitKey
+	}
+	boundary, err := nearestSpanConfigBoundary(
+		splitKey, boundaries, uint64(fraction*float64(rangeBytes)), distance)
+	if err != nil {
+		log.VEventf(ctx, 2, "unable to consider span config boundaries for split: %v", err)
+		return splitKey
+	}
+	if boundary == nil {
+		return splitKey
+	}
+	log.VEventf(ctx, 2, "splitting at span config boundary %s instead of %s", boundary, splitKey)
+	return boundary
+}
+
+// nearestSpanConfigBoundary returns the valid split key among the given span
+// config boundaries which is the nearest to the given split key, given the
+// distance function, if it's no further than maxDistance from it. It returns
+// nil if there is none.
+func nearestSpanConfigBoundary(
+	splitKey roachpb.Key,
+	boundaries []roachpb.RKey,
+	maxDistance uint64,
+	distance func(from, to roachpb.Key) (uint64, error),
+) (roachpb.Key, error) {
+	var nearest roachpb.Key
+	nearestDistance := uint64(math.MaxUint64)
+	for _, b := range boundaries {
+		key := b.AsRawKey()
+		if !storage.IsValidSplitKey(key) {
+			continue
+		}
+		from, to := splitKey, key
+		if to.Compare(from) < 0 {
+			from, to = to, from
+		}
+		d, err := distance(from, to)
+		if err != nil {
+			return nil, err
+		}
+		if d <= maxDistance && d < nearestDistance {
+			nearest, nearestDistance = key, d
+		}
+	}
+	return nearest, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNearestSpanConfigBoundary(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Every letter of the keys below holds 10 bytes.
+	distance := func(from, to roachpb.Key) (uint64, error) {
+		require.True(t, from.Compare(to) <= 0)
+		return uint64(to[0]-from[0]) * 10, nil
+	}
+	boundaries := func(ks ...string) []roachpb.RKey {
+		var rks []roachpb.RKey
+		for _, k := range ks {
+			rks = append(rks, roachpb.RKey(k))
+		}
+		return rks
+	}
+	for _, tc := range []struct {
+		name        string
+		splitKey    string
+		boundaries  []roachpb.RKey
+		maxDistance uint64
+		expected    roachpb.Key
+	}{
+		{"no boundaries", "m", nil, 50, nil},
+		{"boundary too far", "m", boundaries("c", "x"), 50, nil},
+		{"boundary before", "m", boundaries("c", "j"), 50, roachpb.Key("j")},
+		{"boundary after", "m", boundaries("c", "p", "x"), 50, roachpb.Key("p")},
+		{"nearest boundary", "m", boundaries("j", "n", "p"), 50, roachpb.Key("n")},
+		{"boundary at split key", "m", boundaries("j", "m"), 50, roachpb.Key("m")},
+		{"invalid boundary", "m", []roachpb.RKey{roachpb.RKey(keys.Meta2KeyMax)}, math.MaxUint64, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			boundary, err := nearestSpanConfigBoundary(
+				roachpb.Key(tc.splitKey), tc.boundaries, tc.maxDistance, distance)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, boundary)
+		})
+	}
+}
@@ -73,6 +73,22 @@ func (rsl StateLoader) Load(
 		return kvserverpb.ReplicaState{}, err
 	}
 
+	if s.WriteFences, err = rsl.LoadWriteFences(ctx, reader); err != nil {
+		return kvserverpb.ReplicaState{}, err
+	}
+
+	if s.ReplicaPin, err = rsl.LoadReplicaPin(ctx, reader); err != nil {
+		return kvserverpb.ReplicaState{}, err
+	}
+
+	if s.ColdCompaction, err = rsl.LoadColdCompaction(ctx, reader); err != nil {
+		return kvserverpb.ReplicaState{}, err
+	}
+
+	if s.HotStandby, err = rsl.LoadHotStandby(ctx, reader); err != nil {
+		return kvserverpb.ReplicaState{}, err
+	}
+
 	as, err := rsl.LoadRangeAppliedState(ctx, reader)
 	if err != nil {
 		return kvserverpb.ReplicaState{}, err
@@ -335,6 +351,139 @@ func (rsl StateLoader) SetGCHint(
 		hlc.Timestamp{}, hint, storage.MVCCWriteOptions{Stats: ms})
 }
 
+// LoadWriteFences loads the write fences.
+func (rsl StateLoader) LoadWriteFences(
+	ctx context.Context, reader storage.Reader,
+) (*roachpb.WriteFences, error) {
+	var f roachpb.WriteFences
+	_, err := storage.MVCCGetProto(ctx, reader, rsl.RangeWriteFencesKey(),
+		hlc.Timestamp{}, &f, storage.MVCCGetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// SetWriteFences writes the write fences. The key is cleared if no fences are
+// installed, so that unfenced ranges don't carry it.
+func (rsl StateLoader) SetWriteFences(
+	ctx context.Context,
+	readWriter storage.ReadWriter,
+	ms *enginepb.MVCCStats,
+	fences *roachpb.WriteFences,
+) error {
+	if fences == nil {
+		return errors.New("cannot persist nil WriteFences")
+	}
+	if fences.IsEmpty() {
+		_, _, err := storage.MVCCDelete(ctx, readWriter, rsl.RangeWriteFencesKey(),
+			hlc.Timestamp{}, storage.MVCCWriteOptions{Stats: ms})
+		return err
+	}
+	return storage.MVCCPutProto(ctx, readWriter, rsl.RangeWriteFencesKey(),
+		hlc.Timestamp{}, fences, storage.MVCCWriteOptions{Stats: ms})
+}
+
+// LoadReplicaPin loads the replica pin.
+func (rsl StateLoader) LoadReplicaPin(
+	ctx context.Context, reader storage.Reader,
+) (*roachpb.ReplicaPin, error) {
+	var p roachpb.ReplicaPin
+	_, err := storage.MVCCGetProto(ctx, reader, rsl.RangeReplicaPinKey(),
+		hlc.Timestamp{}, &p, storage.MVCCGetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SetReplicaPin writes the replica pin. The key is cleared if the pin is empty,
+// so that unpinned ranges don't carry it.
+func (rsl StateLoader) SetReplicaPin(
+	ctx context.Context,
+	readWriter storage.ReadWriter,
+	ms *enginepb.MVCCStats,
+	pin *roachpb.ReplicaPin,
+) error {
+	if pin == nil {
+		return errors.New("cannot persist nil ReplicaPin")
+	}
+	if len(pin.VoterStoreIDs) == 0 {
+		_, _, err := storage.MVCCDelete(ctx, readWriter, rsl.RangeReplicaPinKey(),
+			hlc.Timestamp{}, storage.MVCCWriteOptions{Stats: ms})
+		return err
+	}
+	return storage.MVCCPutProto(ctx, readWriter, rsl.RangeReplicaPinKey(),
+		hlc.Timestamp{}, pin, storage.MVCCWriteOptions{Stats: ms})
+}
+
+// LoadColdCompaction loads the cold compaction policy.
+func (rsl StateLoader) LoadColdCompaction(
+	ctx context.Context, reader storage.Reader,
+) (*roachpb.ColdCompaction, error) {
+	var cs roachpb.ColdCompaction
+	_, err := storage.MVCCGetProto(ctx, reader, rsl.RangeColdCompactionKey(),
+		hlc.Timestamp{}, &cs, storage.MVCCGetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &cs, nil
+}
+
+// SetColdCompaction writes the cold compaction policy. The key is cleared if
+// the policy has no threshold, so that ranges without a policy don't carry it.
+func (rsl StateLoader) SetColdCompaction(
+	ctx context.Context,
+	readWriter storage.ReadWriter,
+	ms *enginepb.MVCCStats,
+	cs *roachpb.ColdCompaction,
+) error {
+	if cs == nil {
+		return errors.New("cannot persist nil ColdCompaction")
+	}
+	if cs.Threshold.IsEmpty() {
+		_, _, err := storage.MVCCDelete(ctx, readWriter, rsl.RangeColdCompactionKey(),
+			hlc.Timestamp{}, storage.MVCCWriteOptions{Stats: ms})
+		return err
+	}
+	return storage.MVCCPutProto(ctx, readWriter, rsl.RangeColdCompactionKey(),
+		hlc.Timestamp{}, cs, storage.MVCCWriteOptions{Stats: ms})
+}
+
+// LoadHotStandby loads the hot standby designation.
+func (rsl StateLoader) LoadHotStandby(
+	ctx context.Context, reader storage.Reader,
+) (*roachpb.HotStandby, error) {
+	var hs roachpb.HotStandby
+	_, err := storage.MVCCGetProto(ctx, reader, rsl.RangeHotStandbyKey(),
+		hlc.Timestamp{}, &hs, storage.MVCCGetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &hs, nil
+}
+
+// SetHotStandby writes the hot standby designation. The key is cleared if the
+// designation has no store, so that ranges without a hot standby don't carry
+// it.
+func (rsl StateLoader) SetHotStandby(
+	ctx context.Context,
+	readWriter storage.ReadWriter,
+	ms *enginepb.MVCCStats,
+	hs *roachpb.HotStandby,
+) error {
+	if hs == nil {
+		return errors.New("cannot persist nil HotStandby")
+	}
+	if hs.StoreID == 0 {
+		_, _, err := storage.MVCCDelete(ctx, readWriter, rsl.RangeHotStandbyKey(),
+			hlc.Timestamp{}, storage.MVCCWriteOptions{Stats: ms})
+		return err
+	}
+	return storage.MVCCPutProto(ctx, readWriter, rsl.RangeHotStandbyKey(),
+		hlc.Timestamp{}, hs, storage.MVCCWriteOptions{Stats: ms})
+}
+
 // LoadVersion loads the replica version.
 func (rsl StateLoader) LoadVersion(
 	ctx context.Context, reader storage.Reader,
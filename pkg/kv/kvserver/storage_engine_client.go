@@ -12,6 +12,7 @@ package kvserver
 
 import (
 	"context"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/rpc"
@@ -50,6 +51,28 @@ func (c *StorageEngineClient) CompactEngineSpan(
 	return err
 }
 
+// SetProposalQuota is a tree.SetProposalQuotaFunc.
+func (c *StorageEngineClient) SetProposalQuota(
+	ctx context.Context, nodeID, storeID int32, rangeID int64, capacity uint64, duration time.Duration,
+) error {
+	conn, err := c.nd.Dial(ctx, roachpb.NodeID(nodeID), rpc.DefaultClass)
+	if err != nil {
+		return errors.Wrapf(err, "could not dial node ID %d", nodeID)
+	}
+	client := NewPerReplicaClient(conn)
+	req := &SetProposalQuotaRequest{
+		StoreRequestHeader: StoreRequestHeader{
+			NodeID:  roachpb.NodeID(nodeID),
+			StoreID: roachpb.StoreID(storeID),
+		},
+		RangeID:  roachpb.RangeID(rangeID),
+		Capacity: capacity,
+		Duration: duration,
+	}
+	_, err = client.SetProposalQuota(ctx, req)
+	return err
+}
+
 // GetTableMetrics is a tree.GetTableMetricsFunc.
 func (c *StorageEngineClient) GetTableMetrics(
 	ctx context.Context, nodeID, storeID int32, startKey, endKey []byte,
@@ -76,6 +99,42 @@ func (c *StorageEngineClient) GetTableMetrics(
 	return resp.TableMetrics, nil
 }
 
+// GetSpanEncryptionStats is a tree.GetSpanEncryptionStatsFunc.
+func (c *StorageEngineClient) GetSpanEncryptionStats(
+	ctx context.Context, nodeID int32, storeSpans map[int32][]roachpb.Span, forceReencryption bool,
+) (map[int32][]enginepb.SpanEncryptionStats, error) {
+	conn, err := c.nd.Dial(ctx, roachpb.NodeID(nodeID), rpc.DefaultClass)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not dial node ID %d", nodeID)
+	}
+	client := NewPerStoreClient(conn)
+	req := &GetSpanEncryptionStatsRequest{ForceReencryption: forceReencryption}
+	for storeID, spans := range storeSpans {
+		req.Stores = append(req.Stores, GetSpanEncryptionStatsRequest_StoreSpans{
+			StoreRequestHeader: StoreRequestHeader{
+				NodeID:  roachpb.NodeID(nodeID),
+				StoreID: roachpb.StoreID(storeID),
+			},
+			Spans: spans,
+		})
+	}
+	resp, err := client.GetSpanEncryptionStats(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int32][]enginepb.SpanEncryptionStats, len(req.Stores))
+	stats := resp.Stats
+	for _, s := range req.Stores {
+		if len(stats) < len(s.Spans) {
+			return nil, errors.AssertionFailedf(
+				"expected stats for %d spans of store %d, got %d", len(s.Spans), s.StoreID, len(stats))
+		}
+		res[int32(s.StoreID)] = stats[:len(s.Spans):len(s.Spans)]
+		stats = stats[len(s.Spans):]
+	}
+	return res, nil
+}
+
 // ScanStorageInternalKeys is a tree.ScanStorageInternalKeys
 func (c *StorageEngineClient) ScanStorageInternalKeys(
 	ctx context.Context, nodeID, storeID int32, startKey, endKey []byte, megabytesPerSecond int64,
@@ -54,6 +54,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/raftentry"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/rangefeed"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/rditer"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/replicastats"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/tenantrate"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/tscache"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/txnrecovery"
@@ -859,6 +860,7 @@ type Store struct {
 	scanner             *replicaScanner             // Replica scanner
 	consistencyQueue    *consistencyQueue           // Replica consistency check queue
 	consistencyLimiter  *quotapool.RateLimiter      // Rate limits consistency checks
+	maintenanceBudget   *queueBudget                // Processing budget of maintenance queues
 	metrics             *StoreMetrics
 	intentResolver      *intentresolver.IntentResolver
 	recoveryMgr         txnrecovery.Manager
@@ -896,6 +898,10 @@ type Store struct {
 	// Queue to limit concurrent non-empty snapshot sending.
 	snapshotSendQueue *multiqueue.MultiQueue
 
+	// leaseAcquisitionLimiter bounds the number of concurrent lease
+	// acquisitions.
+	leaseAcquisitionLimiter *leaseAcquisitionLimiter
+
 	// draining holds a bool which indicates whether this store is draining. See
 	// SetDraining() for a more detailed explanation of behavior changes.
 	//
@@ -1030,6 +1036,27 @@ type Store struct {
 		m map[roachpb.RangeID]struct{}
 	}
 
+	// compactionHints holds the spans cleared by replica destruction, or with
+	// fragmented MVCC range keys, which are pending a manual compaction.
+	compactionHints compactionHints
+
+	// coldCompactions holds the spans of the cold ranges which are pending a
+	// compaction into the bottom levels of the engine under their cold
+	// compaction policy.
+	coldCompactions coldCompactions
+
+	// hotStandbys holds the ranges whose hot standby is on the store, which are
+	// periodically loaded into the block cache.
+	hotStandbys hotStandbys
+
+	// leasePrewarmQueue holds the ranges whose lease was acquired by the store,
+	// which are pending prewarming of the block cache.
+	leasePrewarmQueue leasePrewarmQueue
+
+	// rangeStateListeners are notified of the changes to the range-local state
+	// of the replicas as they apply.
+	rangeStateListeners rangeStateListeners
+
 	// The subset of replicas with active rangefeeds.
 	rangefeedReplicas struct {
 		syncutil.Mutex
@@ -1063,6 +1090,13 @@ type Store struct {
 		t *admissionpb.IOThreshold // never nil
 	}
 
+	// health tracks the composed health of the store, see Store.Health.
+	health storeHealth
+
+	// appBatchSizer shrinks the raft application batches of the store's
+	// replicas under memory pressure, see applicationBatchLimits.
+	appBatchSizer applicationBatchSizer
+
 	counts struct {
 		// Number of placeholders removed due to error. Not a good fit for meaningful
 		// metrics, as snapshots to initialized ranges don't get a placeholder.
@@ -1220,6 +1254,9 @@ type StoreConfig struct {
 	// Used to subscribe to span configuration changes, keeping up-to-date a
 	// data structure useful for retrieving span configs.
 	SpanConfigSubscriber spanconfig.KVSubscriber
+	// HashShardedSpans provides the hash-sharded spans, whose ranges are split
+	// at the prefixes of their shards.
+	HashShardedSpans *kvserverbase.HashShardedSpansWatcher
 	// SharedStorageEnabled stores whether this store is configured with a
 	// shared.Storage instance and can accept shared snapshots.
 	SharedStorageEnabled bool
@@ -1249,6 +1286,10 @@ type StoreConfig struct {
 	// RangeLogWriter is used to write entries to the system.rangelog table.
 	RangeLogWriter RangeLogWriter
 
+	// ApplyTriggers is notified of the split and merge triggers applied by the
+	// replicas of the store. May be nil.
+	ApplyTriggers *kvserverbase.ApplyTriggerRegistry
+
 	// RangeFeedSchedulerConcurrency specifies number of rangefeed scheduler
 	// workers for the store.
 	RangeFeedSchedulerConcurrency int
@@ -1282,6 +1323,14 @@ type ConsistencyTestingKnobs struct {
 	// If non-nil, OnBadChecksumFatal is called on a replica with a mismatching
 	// checksum, instead of log.Fatal.
 	OnBadChecksumFatal func(roachpb.StoreIdent)
+	// If non-nil, BeforeRebuildDivergentReplicas is called before the divergent
+	// replicas of a range are rebuilt. If it returns an error, the rebuild fails
+	// with it.
+	BeforeRebuildDivergentReplicas func(divergent []roachpb.ReplicaDescriptor) error
+	// If non-nil, AfterRebuildDivergentReplicas is called once the async task
+	// rebuilding the divergent replicas of a range is done, with the error of
+	// the rebuild.
+	AfterRebuildDivergentReplicas func(err error)
 
 	ConsistencyQueueResultHook func(response kvpb.CheckConsistencyResponse)
 }
@@ -1508,6 +1557,11 @@ func NewStore(
 	s.rangefeedReplicas.m = map[roachpb.RangeID]int64{}
 	s.rangefeedReplicas.Unlock()
 
+	s.compactionHints = makeCompactionHints()
+	s.coldCompactions = makeColdCompactions()
+	s.hotStandbys = makeHotStandbys()
+	s.leasePrewarmQueue = makeLeasePrewarmQueue()
+
 	s.tsCache = tscache.New(cfg.Clock)
 	s.metrics.registry.AddMetricStruct(s.tsCache.Metrics())
 
@@ -1515,6 +1569,7 @@ func NewStore(
 	s.metrics.registry.AddMetricStruct(s.txnWaitMetrics)
 	s.snapshotApplyQueue = multiqueue.NewMultiQueue(int(cfg.SnapshotApplyLimit))
 	s.snapshotSendQueue = multiqueue.NewMultiQueue(int(cfg.SnapshotSendLimit))
+	s.leaseAcquisitionLimiter = newLeaseAcquisitionLimiter(cfg.Settings, s.metrics)
 
 	s.consistencyLimiter = quotapool.NewRateLimiter(
 		"ConsistencyQueue",
@@ -1527,6 +1582,18 @@ func NewStore(
 		s.consistencyLimiter.UpdateLimit(quotapool.Limit(rate), rate*consistencyCheckRateBurstFactor)
 	})
 
+	replicastats.DecayHalfLife.SetOnChange(&cfg.Settings.SV, func(ctx context.Context) {
+		halfLife := replicastats.DecayHalfLife.Get(&cfg.Settings.SV)
+		newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+			if r.loadStats != nil {
+				r.loadStats.SetDecayHalfLife(halfLife)
+			}
+			return true
+		})
+	})
+
+	s.maintenanceBudget = newMaintenanceQueueBudget(cfg.Settings)
+
 	s.limiters.BulkIOWriteRate = rate.NewLimiter(rate.Limit(bulkIOWriteLimit.Get(&cfg.Settings.SV)), kvserverbase.BulkIOWriteBurst)
 	bulkIOWriteLimit.SetOnChange(&cfg.Settings.SV, func(ctx context.Context) {
 		s.limiters.BulkIOWriteRate.SetLimit(rate.Limit(bulkIOWriteLimit.Get(&cfg.Settings.SV)))
@@ -2144,6 +2211,9 @@ func (s *Store) Start(ctx context.Context, stopper *stop.Stopper) error {
 		if err != nil {
 			return err
 		}
+		if err := rep.restoreLoadStats(ctx); err != nil {
+			log.Warningf(ctx, "r%d: unable to restore load stats: %v", rep.RangeID, err)
+		}
 
 		// We can't lock s.mu across NewReplica due to the lock ordering
 		// constraint (*Replica).raftMu < (*Store).mu. See the comment on
@@ -2250,6 +2320,28 @@ func (s *Store) Start(ctx context.Context, stopper *stop.Stopper) error {
 
 	s.startRangefeedTxnPushNotifier(ctx)
 
+	s.startSideloadedStorageReconciler(ctx)
+
+	s.startRangeIDLocalGC(ctx)
+
+	s.startSideloadedStorageVerifier(ctx)
+
+	s.startCompactionHintProcessor(ctx)
+
+	s.startRangeKeyDefragmenter(ctx)
+
+	s.startColdCompactor(ctx)
+
+	s.startHotStandbyPrewarmer(ctx)
+
+	s.startLeasePrewarmer(ctx)
+
+	s.startRaftHibernation(ctx)
+
+	s.startLoadStatsPersister(ctx)
+
+	s.startClosedTimestampSampler(ctx)
+
 	if s.replicateQueue != nil {
 		s.storeRebalancer = NewStoreRebalancer(
 			s.cfg.AmbientCtx, s.cfg.Settings, s.replicateQueue, s.replRankings, s.rebalanceObjManager)
@@ -2839,6 +2931,20 @@ func (s *Store) Properties() roachpb.StoreProperties {
 	return s.TODOEngine().Properties()
 }
 
+// isNonVotingStore returns whether the given store is in non-voting mode, in
+// which it only holds non-voting replicas and no leases. The mode of other
+// stores is learned through their gossiped store descriptors.
+func (s *Store) isNonVotingStore(storeID roachpb.StoreID) bool {
+	if storeID == s.StoreID() {
+		return s.Properties().NonVoting
+	}
+	if s.cfg.StorePool == nil {
+		return false
+	}
+	desc, ok := s.cfg.StorePool.GetStoreDescriptor(storeID)
+	return ok && desc.Properties.NonVoting
+}
+
 // Capacity returns the capacity of the underlying storage engine. Note that
 // this does not include reservations.
 // Note that Capacity() has the side effect of updating some of the store's
@@ -2864,6 +2970,7 @@ func (s *Store) Capacity(ctx context.Context, useCached bool) (roachpb.StoreCapa
 	var totalQueriesPerSecond float64
 	var totalWritesPerSecond float64
 	var totalStoreCPUTimePerSecond float64
+	var applyBacklog int64
 	replicaCount := s.metrics.ReplicaCount.Value()
 	bytesPerReplica := make([]float64, 0, replicaCount)
 	writesPerReplica := make([]float64, 0, replicaCount)
@@ -2900,6 +3007,7 @@ func (s *Store) Capacity(ctx context.Context, useCached bool) (roachpb.StoreCapa
 		}
 		rankingsAccumulator.AddReplica(cr)
 		rankingsByTenantAccumulator.AddReplica(cr)
+		applyBacklog += r.applyBacklog()
 		return true
 	})
 
@@ -2927,6 +3035,15 @@ func (s *Store) Capacity(ctx context.Context, useCached bool) (roachpb.StoreCapa
 		capacity.IOThreshold = *s.ioThreshold.t
 		s.ioThreshold.Unlock()
 	}
+	ioOverload, _ := capacity.IOThreshold.Score()
+	capacity.Health = s.health.update(
+		timeutil.Now(), ioOverload, applyBacklog, int64(s.scheduler.QueueLen()),
+		makeStoreHealthThresholds(&s.ClusterSettings().SV),
+	)
+	s.metrics.StoreHealthScore.Update(capacity.Health.Score)
+	s.metrics.StoreHealthApplyBacklog.Update(capacity.Health.ApplyBacklog)
+	s.metrics.StoreHealthRaftSchedulerQueueDepth.Update(capacity.Health.RaftSchedulerQueueDepth)
+	s.metrics.StoreHealthFollowerWriteBytes.Update(capacity.Health.FollowerWriteBytesPerSecond)
 	capacity.BytesPerReplica = roachpb.PercentilesFromData(bytesPerReplica)
 	capacity.WritesPerReplica = roachpb.PercentilesFromData(writesPerReplica)
 	s.storeGossip.RecordNewPerSecondStats(totalQueriesPerSecond, totalWritesPerSecond)
@@ -3034,6 +3151,7 @@ func (s *Store) updateReplicationGauges(ctx context.Context) error {
 		raftLeaderNotLeaseHolderCount  int64
 		raftLeaderInvalidLeaseCount    int64
 		quiescentCount                 int64
+		hibernatedCount                int64
 		uninitializedCount             int64
 		averageQueriesPerSecond        float64
 		averageRequestsPerSecond       float64
@@ -3043,14 +3161,16 @@ func (s *Store) updateReplicationGauges(ctx context.Context) error {
 		averageWriteBytesPerSecond     float64
 		averageCPUNanosPerSecond       float64
 
-		rangeCount                int64
-		unavailableRangeCount     int64
-		underreplicatedRangeCount int64
-		overreplicatedRangeCount  int64
-		behindCount               int64
-		pausedFollowerCount       int64
-		ioOverload                float64
-		slowRaftProposalCount     int64
+		rangeCount                   int64
+		unavailableRangeCount        int64
+		underreplicatedRangeCount    int64
+		overreplicatedRangeCount     int64
+		pinnedRangeCount             int64
+		pinnedNonCompliantRangeCount int64
+		behindCount                  int64
+		pausedFollowerCount          int64
+		ioOverload                   float64
+		slowRaftProposalCount        int64
 
 		locks                          int64
 		totalLockHoldDurationNanos     int64
@@ -3115,6 +3235,9 @@ func (s *Store) updateReplicationGauges(ctx context.Context) error {
 		if metrics.Quiescent {
 			quiescentCount++
 		}
+		if metrics.Hibernated {
+			hibernatedCount++
+		}
 		if metrics.RangeCounter {
 			rangeCount++
 			if metrics.Unavailable {
@@ -3126,10 +3249,20 @@ func (s *Store) updateReplicationGauges(ctx context.Context) error {
 			if metrics.Overreplicated {
 				overreplicatedRangeCount++
 			}
+			if metrics.Pinned {
+				pinnedRangeCount++
+			}
+			if metrics.PinViolated {
+				pinnedNonCompliantRangeCount++
+			}
 		}
 		pausedFollowerCount += metrics.PausedFollowerCount
 		slowRaftProposalCount += metrics.SlowRaftProposalCount
 		behindCount += metrics.BehindCount
+		// Guarantee ranges with lagging followers a share of the raft entry
+		// cache, so that the followers can be caught up without reading the
+		// log from storage.
+		s.raftEntryCache.SetProtected(rep.RangeID, metrics.BehindCount > 0)
 		loadStats := rep.loadStats.Stats()
 		averageQueriesPerSecond += loadStats.QueriesPerSecond
 		averageRequestsPerSecond += loadStats.RequestsPerSecond
@@ -3173,6 +3306,7 @@ func (s *Store) updateReplicationGauges(ctx context.Context) error {
 	s.metrics.LeaseLessPreferredCount.Update(leaseLessPreferredCount)
 	s.metrics.LeaseLivenessCount.Update(leaseLivenessCount)
 	s.metrics.QuiescentCount.Update(quiescentCount)
+	s.metrics.HibernatedCount.Update(hibernatedCount)
 	s.metrics.UninitializedCount.Update(uninitializedCount)
 	s.metrics.AverageQueriesPerSecond.Update(averageQueriesPerSecond)
 	s.metrics.AverageRequestsPerSecond.Update(averageRequestsPerSecond)
@@ -3187,6 +3321,8 @@ func (s *Store) updateReplicationGauges(ctx context.Context) error {
 	s.metrics.UnavailableRangeCount.Update(unavailableRangeCount)
 	s.metrics.UnderReplicatedRangeCount.Update(underreplicatedRangeCount)
 	s.metrics.OverReplicatedRangeCount.Update(overreplicatedRangeCount)
+	s.metrics.PinnedRangeCount.Update(pinnedRangeCount)
+	s.metrics.PinnedNonCompliantRangeCount.Update(pinnedNonCompliantRangeCount)
 	s.metrics.RaftLogFollowerBehindCount.Update(behindCount)
 	s.metrics.RaftPausedFollowerCount.Update(pausedFollowerCount)
 	s.metrics.IOOverload.Update(ioOverload)
@@ -3690,6 +3826,40 @@ func (s *Store) Enqueue(
 	return collectAndFinish(), processErr, nil
 }
 
+// SetQueuePaused pauses or resumes the named queue on the store, for the given
+// range or, if rangeID is zero, for all ranges. Paused replicas are not
+// processed by the queue until resumed, though they can still be run through
+// it manually using Enqueue. Returns false if the queue was already in the
+// requested state. See baseQueue.SetPaused.
+func (s *Store) SetQueuePaused(
+	ctx context.Context, queueName string, rangeID roachpb.RangeID, paused bool, reason string,
+) (bool, error) {
+	for _, q := range s.scanner.queues {
+		if !strings.EqualFold(q.Name(), queueName) {
+			continue
+		}
+		changed := q.SetPaused(rangeID, paused, reason, s.Clock().PhysicalTime())
+		if changed && rangeID == 0 {
+			log.Infof(ctx, "set paused=%t for all ranges in the %s queue: %s",
+				paused, redact.Safe(q.Name()), reason)
+		} else if changed {
+			log.Infof(ctx, "set paused=%t for r%d in the %s queue: %s",
+				paused, rangeID, redact.Safe(q.Name()), reason)
+		}
+		return changed, nil
+	}
+	return false, errors.Errorf("unknown queue type %q", queueName)
+}
+
+// QueuePauses returns the pauses of the queues on the store.
+func (s *Store) QueuePauses() []QueuePause {
+	var pauses []QueuePause
+	for _, q := range s.scanner.queues {
+		pauses = append(pauses, q.Pauses()...)
+	}
+	return pauses
+}
+
 // PurgeOutdatedReplicas purges all replicas with a version less than the one
 // specified. This entails clearing out replicas in the replica GC queue that
 // fit the bill.
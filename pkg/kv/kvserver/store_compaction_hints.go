@@ -0,0 +1,128 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// compactionHintMinBytes is the minimum logical size of the data cleared by a
+// replica destruction for which a manual compaction of the cleared span is
+// scheduled.
+var compactionHintMinBytes = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.replica_destroy.compaction_hint.min_bytes",
+	"the minimum size of the data cleared by the destruction of a replica for which "+
+		"the cleared span is compacted to reclaim disk space promptly (0 disables)",
+	64<<20, /* 64 MiB */
+)
+
+// compactionHints holds the spans pending a manual compaction. These are the
+// spans cleared by replica destruction, for which the disk space of the cleared
+// data is otherwise only reclaimed once regular compactions reach the span,
+// which can take a long time for spans that see no new writes, e.g. after
+// rebalancing a replica away from the store. They are also the spans of
+// replicas whose MVCC range keys are fragmented, see rangeKeyFragmentation.
+type compactionHints struct {
+	// notify is signaled when a span is queued.
+	notify chan struct{}
+	mu     struct {
+		syncutil.Mutex
+		spans []roachpb.Span
+		bytes int64
+	}
+}
+
+func makeCompactionHints() compactionHints {
+	return compactionHints{notify: make(chan struct{}, 1)}
+}
+
+// hintCompaction schedules a manual compaction of the given span, whose data
+// has been cleared, if the logical size of the cleared data is large enough to
+// warrant one. Requires that the clearing has been committed.
+func (s *Store) hintCompaction(ctx context.Context, span roachpb.Span, bytes int64) {
+	minBytes := compactionHintMinBytes.Get(&s.ClusterSettings().SV)
+	if minBytes == 0 || bytes < minBytes {
+		return
+	}
+	s.queueCompactionHint(ctx, span, bytes)
+}
+
+// queueCompactionHint queues a manual compaction of the given span. The bytes
+// are the logical size of the data cleared from the span, if any.
+func (s *Store) queueCompactionHint(ctx context.Context, span roachpb.Span, bytes int64) {
+	if !span.Valid() {
+		return
+	}
+	h := &s.compactionHints
+	h.mu.Lock()
+	h.mu.spans = append(h.mu.spans, span)
+	h.mu.bytes += bytes
+	s.metrics.CompactionHintsQueued.Inc(1)
+	s.metrics.CompactionHintsPendingBytes.Update(h.mu.bytes)
+	h.mu.Unlock()
+	log.VEventf(ctx, 2, "queued compaction of span %s (%d bytes cleared)", span, bytes)
+	select {
+	case h.notify <- struct{}{}:
+	default:
+	}
+}
+
+// startCompactionHintProcessor starts a worker which compacts the spans queued
+// by queueCompactionHint. Compactions are run one at a time, to bound their impact
+// on foreground traffic, and adjacent or overlapping spans queued in the
+// meantime are compacted together.
+func (s *Store) startCompactionHintProcessor(ctx context.Context) {
+	_ /* err */ = s.stopper.RunAsyncTaskEx(ctx, stop.TaskOpts{
+		TaskName: "compaction-hint-processor",
+		SpanOpt:  stop.SterileRootSpan,
+	}, func(ctx context.Context) {
+		ctx, cancel := s.stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+
+		h := &s.compactionHints
+		for {
+			select {
+			case <-h.notify:
+			case <-ctx.Done():
+				return
+			}
+			h.mu.Lock()
+			spans := h.mu.spans
+			h.mu.spans = nil
+			h.mu.bytes = 0
+			s.metrics.CompactionHintsPendingBytes.Update(0)
+			h.mu.Unlock()
+
+			spans, _ = roachpb.MergeSpans(&spans)
+			for _, sp := range spans {
+				if ctx.Err() != nil {
+					return
+				}
+				start := timeutil.Now()
+				if err := s.TODOEngine().CompactRange(sp.Key, sp.EndKey); err != nil {
+					s.metrics.CompactionHintsFailed.Inc(1)
+					log.Warningf(ctx, "unable to compact span %s: %v", sp, err)
+					continue
+				}
+				s.metrics.CompactionHintsCompacted.Inc(1)
+				log.VEventf(ctx, 2, "compacted span %s in %s", sp, timeutil.Since(start))
+			}
+		}
+	})
+}
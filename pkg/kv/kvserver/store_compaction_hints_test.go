@@ -0,0 +1,58 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreHintCompaction(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	s := &Store{
+		cfg:             StoreConfig{Settings: st},
+		metrics:         newStoreMetrics(metric.TestSampleInterval),
+		compactionHints: makeCompactionHints(),
+	}
+	compactionHintMinBytes.Override(ctx, &st.SV, 100)
+	span := func(start, end string) roachpb.Span {
+		return roachpb.Span{Key: roachpb.Key(start), EndKey: roachpb.Key(end)}
+	}
+
+	// Small spans are not worth a compaction.
+	s.hintCompaction(ctx, span("a", "b"), 99)
+	require.Empty(t, s.compactionHints.mu.spans)
+	require.Len(t, s.compactionHints.notify, 0)
+
+	s.hintCompaction(ctx, span("a", "b"), 100)
+	s.hintCompaction(ctx, span("b", "c"), 200)
+	require.Equal(t, []roachpb.Span{span("a", "b"), span("b", "c")}, s.compactionHints.mu.spans)
+	require.Equal(t, int64(2), s.metrics.CompactionHintsQueued.Count())
+	require.Equal(t, int64(300), s.metrics.CompactionHintsPendingBytes.Value())
+	// The processor is notified once.
+	require.Len(t, s.compactionHints.notify, 1)
+
+	// Compaction hints can be disabled.
+	compactionHintMinBytes.Override(ctx, &st.SV, 0)
+	s.hintCompaction(ctx, span("c", "d"), 1000)
+	require.Len(t, s.compactionHints.mu.spans, 2)
+}
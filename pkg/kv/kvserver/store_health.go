@@ -0,0 +1,172 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// storeHealthApplyBacklogThreshold is the apply backlog of a store at which
+// its apply backlog component of the store health score reaches 1.
+var storeHealthApplyBacklogThreshold = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"kv.store_health.apply_backlog_threshold",
+	"the number of committed but unapplied raft entries on a store at which the store "+
+		"is considered overloaded by its health score (0 ignores the apply backlog)",
+	10000,
+	settings.NonNegativeInt,
+)
+
+// storeHealthRaftSchedulerQueueThreshold is the raft scheduler queue depth
+// of a store at which its component of the store health score reaches 1.
+var storeHealthRaftSchedulerQueueThreshold = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"kv.store_health.raft_scheduler_queue_threshold",
+	"the number of ranges waiting in the raft scheduler of a store at which the store "+
+		"is considered overloaded by its health score (0 ignores the raft scheduler)",
+	1000,
+	settings.NonNegativeInt,
+)
+
+// storeHealthFollowerWriteBytesThreshold is the follower write rate of a
+// store at which its component of the store health score reaches 1.
+var storeHealthFollowerWriteBytesThreshold = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"kv.store_health.follower_write_bytes_threshold",
+	"the rate, in bytes per second, at which a store writes the commands of the ranges "+
+		"it is a follower of at which the store is considered overloaded by its health "+
+		"score (0 ignores follower writes)",
+	256<<20,
+)
+
+// storeHealthThresholds are the thresholds by which the components of the
+// store health score are normalized. A zero threshold ignores its component.
+type storeHealthThresholds struct {
+	applyBacklog            int64
+	raftSchedulerQueueDepth int64
+	followerWriteBytes      int64
+}
+
+func makeStoreHealthThresholds(sv *settings.Values) storeHealthThresholds {
+	return storeHealthThresholds{
+		applyBacklog:            storeHealthApplyBacklogThreshold.Get(sv),
+		raftSchedulerQueueDepth: storeHealthRaftSchedulerQueueThreshold.Get(sv),
+		followerWriteBytes:      storeHealthFollowerWriteBytesThreshold.Get(sv),
+	}
+}
+
+// storeHealthScore composes the components of the given store health into a
+// single score: the maximum of the components, each normalized by its
+// threshold. The IO overload score is already normalized.
+func storeHealthScore(h roachpb.StoreHealth, t storeHealthThresholds) float64 {
+	score := h.IOOverload
+	normalized := func(v, threshold int64) float64 {
+		if threshold <= 0 {
+			return 0
+		}
+		return float64(v) / float64(threshold)
+	}
+	score = math.Max(score, normalized(h.ApplyBacklog, t.applyBacklog))
+	score = math.Max(score, normalized(h.RaftSchedulerQueueDepth, t.raftSchedulerQueueDepth))
+	score = math.Max(score, normalized(h.FollowerWriteBytesPerSecond, t.followerWriteBytes))
+	return score
+}
+
+// storeHealth tracks the health of a store, which is recomputed whenever the
+// capacity of the store is (i.e. at least as often as it is gossiped).
+type storeHealth struct {
+	// followerWriteBytes is the number of bytes written by the application of
+	// the commands of the ranges the store is a follower of, since the store
+	// started.
+	followerWriteBytes atomic.Int64
+
+	mu struct {
+		syncutil.Mutex
+		health roachpb.StoreHealth
+		// lastFollowerWriteBytes and lastUpdate are the value of
+		// followerWriteBytes and the time as of the previous update.
+		lastFollowerWriteBytes int64
+		lastUpdate             time.Time
+	}
+}
+
+// recordFollowerWriteBytes records bytes written by the application of the
+// commands of the ranges the store is a follower of.
+func (h *storeHealth) recordFollowerWriteBytes(bytes int64) {
+	h.followerWriteBytes.Add(bytes)
+}
+
+// update recomputes the health of the store from the given components, and
+// returns it.
+func (h *storeHealth) update(
+	now time.Time,
+	ioOverload float64,
+	applyBacklog, raftSchedulerQueueDepth int64,
+	thresholds storeHealthThresholds,
+) roachpb.StoreHealth {
+	followerWriteBytes := h.followerWriteBytes.Load()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	health := roachpb.StoreHealth{
+		IOOverload:              ioOverload,
+		ApplyBacklog:            applyBacklog,
+		RaftSchedulerQueueDepth: raftSchedulerQueueDepth,
+		// The rate is carried over until it can be computed over a meaningful
+		// interval.
+		FollowerWriteBytesPerSecond: h.mu.health.FollowerWriteBytesPerSecond,
+	}
+	if h.mu.lastUpdate.IsZero() {
+		h.mu.lastUpdate = now
+		h.mu.lastFollowerWriteBytes = followerWriteBytes
+	} else if elapsed := now.Sub(h.mu.lastUpdate); elapsed >= time.Second {
+		health.FollowerWriteBytesPerSecond = int64(
+			float64(followerWriteBytes-h.mu.lastFollowerWriteBytes) / elapsed.Seconds())
+		h.mu.lastUpdate = now
+		h.mu.lastFollowerWriteBytes = followerWriteBytes
+	}
+	health.Score = storeHealthScore(health, thresholds)
+	h.mu.health = health
+	return health
+}
+
+// get returns the health of the store as of its last update.
+func (h *storeHealth) get() roachpb.StoreHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.mu.health
+}
+
+// applyBacklog returns the number of committed raft entries of the replica
+// which are not yet applied.
+func (r *Replica) applyBacklog() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	status := r.raftBasicStatusRLocked()
+	if status.Commit <= status.Applied {
+		return 0
+	}
+	return int64(status.Commit - status.Applied)
+}
+
+// Health returns the health of the store, as of the last computation of its
+// capacity. The health is also gossiped as part of the capacity of the store,
+// see roachpb.StoreCapacity.Health, so that the health of remote stores can be
+// inspected through their store descriptors.
+func (s *Store) Health() roachpb.StoreHealth {
+	return s.health.get()
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreHealthScore(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	thresholds := storeHealthThresholds{
+		applyBacklog:            100,
+		raftSchedulerQueueDepth: 10,
+		followerWriteBytes:      1000,
+	}
+	for _, tc := range []struct {
+		name string
+		h    roachpb.StoreHealth
+		exp  float64
+	}{
+		{name: "idle"},
+		{name: "io overload", h: roachpb.StoreHealth{IOOverload: 1.5, ApplyBacklog: 10}, exp: 1.5},
+		{name: "apply backlog", h: roachpb.StoreHealth{IOOverload: 0.1, ApplyBacklog: 50}, exp: 0.5},
+		{name: "raft scheduler", h: roachpb.StoreHealth{RaftSchedulerQueueDepth: 20}, exp: 2},
+		{name: "follower writes", h: roachpb.StoreHealth{FollowerWriteBytesPerSecond: 250}, exp: 0.25},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, storeHealthScore(tc.h, thresholds))
+		})
+	}
+
+	// A zero threshold ignores its component.
+	require.Equal(t, 0.0, storeHealthScore(
+		roachpb.StoreHealth{ApplyBacklog: 50}, storeHealthThresholds{}))
+}
+
+func TestStoreHealthUpdate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	thresholds := storeHealthThresholds{followerWriteBytes: 1000}
+	var h storeHealth
+	now := time.Unix(100, 0)
+
+	// The follower write rate is unknown until the second update.
+	h.recordFollowerWriteBytes(5000)
+	health := h.update(now, 0.2, 3, 4, thresholds)
+	require.Equal(t, roachpb.StoreHealth{
+		Score:                   0.2,
+		IOOverload:              0.2,
+		ApplyBacklog:            3,
+		RaftSchedulerQueueDepth: 4,
+	}, health)
+	require.Equal(t, health, h.get())
+
+	h.recordFollowerWriteBytes(2000)
+	health = h.update(now.Add(2*time.Second), 0.2, 0, 0, thresholds)
+	require.Equal(t, int64(1000), health.FollowerWriteBytesPerSecond)
+	require.Equal(t, 1.0, health.Score)
+
+	// The rate is carried over by updates too close to the previous one.
+	h.recordFollowerWriteBytes(2000)
+	health = h.update(now.Add(2500*time.Millisecond), 0.2, 0, 0, thresholds)
+	require.Equal(t, int64(1000), health.FollowerWriteBytesPerSecond)
+	health = h.update(now.Add(4*time.Second), 0.2, 0, 0, thresholds)
+	require.Equal(t, int64(1000), health.FollowerWriteBytesPerSecond)
+	health = h.update(now.Add(6*time.Second), 0.2, 0, 0, thresholds)
+	require.Equal(t, int64(0), health.FollowerWriteBytesPerSecond)
+}
@@ -146,6 +146,9 @@ func (s *Store) MergeRange(
 	}
 
 	leftRepl.loadStats.Merge(rightRepl.loadStats)
+	// The counts of the hot keys sampled by either side can't be combined.
+	leftRepl.resetHotKeys()
+	leftRepl.resetKeySpaceUsage()
 
 	// Clear the concurrency manager's lock and txn wait-queues to redirect the
 	// queued transactions to the left-hand replica, if necessary.
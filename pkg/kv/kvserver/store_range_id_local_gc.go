@@ -0,0 +1,239 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvstorage"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// rangeIDLocalGCInterval is the interval at which the store removes the
+// range-ID local data of the ranges it has no replica of.
+var rangeIDLocalGCInterval = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"kv.range_id_local_gc.interval",
+	"the interval at which each store removes the orphaned range-ID local data of the "+
+		"ranges it has no replica of, e.g. left behind by removed or merged replicas (0 disables)",
+	time.Hour,
+	settings.NonNegativeDuration,
+)
+
+// rangeIDLocalGCRate bounds the number of ranges whose orphaned range-ID local
+// data is removed per second, to pace the deletions after mass merges.
+var rangeIDLocalGCRate = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"kv.range_id_local_gc.ranges_per_second",
+	"the maximum number of ranges per second whose orphaned range-ID local data is removed "+
+		"by each store, see kv.range_id_local_gc.interval",
+	10,
+	settings.PositiveInt,
+)
+
+// orphanedRangeIDData is the range-ID local data of a range which has no
+// replica on the store.
+type orphanedRangeIDData struct {
+	rangeID roachpb.RangeID
+	// keys is the number of keys of the range, excluding its tombstone.
+	keys int64
+}
+
+// findOrphanedRangeIDData scans the range-ID local keyspace of the reader for
+// the ranges which have no replica according to hasReplica, but have keys
+// other than their RangeTombstone, which outlives the replica to prevent the
+// reuse of its replica ID.
+func findOrphanedRangeIDData(
+	ctx context.Context, reader storage.Reader, hasReplica func(roachpb.RangeID) bool,
+) ([]orphanedRangeIDData, error) {
+	iter, err := reader.NewMVCCIterator(ctx, storage.MVCCKeyIterKind, storage.IterOptions{
+		LowerBound: keys.LocalRangeIDPrefix.AsRawKey(),
+		UpperBound: keys.LocalRangeIDPrefix.PrefixEnd().AsRawKey(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var res []orphanedRangeIDData
+	for iter.SeekGE(storage.MVCCKey{Key: keys.LocalRangeIDPrefix.AsRawKey()}); ; {
+		if ok, err := iter.Valid(); err != nil {
+			return nil, err
+		} else if !ok {
+			return res, nil
+		}
+		rangeID, _, _, _, err := keys.DecodeRangeIDKey(iter.UnsafeKey().Key)
+		if err != nil {
+			return nil, err
+		}
+		if !hasReplica(rangeID) {
+			// Count the keys of the range other than its tombstone.
+			tombstoneKey := keys.RangeTombstoneKey(rangeID)
+			endKey := keys.MakeRangeIDPrefix(rangeID).PrefixEnd()
+			orphan := orphanedRangeIDData{rangeID: rangeID}
+			for ; ; iter.Next() {
+				if ok, err := iter.Valid(); err != nil {
+					return nil, err
+				} else if !ok || !iter.UnsafeKey().Key.Less(endKey) {
+					break
+				}
+				if !iter.UnsafeKey().Key.Equal(tombstoneKey) {
+					orphan.keys++
+				}
+			}
+			if orphan.keys > 0 {
+				res = append(res, orphan)
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		iter.SeekGE(storage.MVCCKey{Key: keys.MakeRangeIDPrefix(rangeID + 1)})
+	}
+}
+
+// clearOrphanedRangeIDData clears the range-ID local data of the given range,
+// except for its RangeTombstone.
+func clearOrphanedRangeIDData(
+	ctx context.Context, reader storage.Reader, writer storage.Writer, rangeID roachpb.RangeID,
+) error {
+	replicatedPrefix := keys.MakeRangeIDReplicatedPrefix(rangeID)
+	unreplicatedPrefix := keys.MakeRangeIDUnreplicatedPrefix(rangeID)
+	tombstoneKey := keys.RangeTombstoneKey(rangeID)
+	for _, span := range []roachpb.Span{
+		{Key: replicatedPrefix, EndKey: replicatedPrefix.PrefixEnd()},
+		{Key: unreplicatedPrefix, EndKey: tombstoneKey},
+		{Key: tombstoneKey.Next(), EndKey: unreplicatedPrefix.PrefixEnd()},
+	} {
+		if err := storage.ClearRangeWithHeuristic(
+			ctx, reader, writer, span.Key, span.EndKey,
+			kvstorage.ClearRangeThresholdPointKeys, kvstorage.ClearRangeThresholdRangeKeys,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startRangeIDLocalGC starts a worker which periodically removes the orphaned
+// range-ID local data of the store. The data of a replica is removed with it,
+// but crashes, bugs, or older versions could leave some behind; after mass
+// merges, e.g. once many tables were dropped, this adds up to a significant
+// amount of garbage which would otherwise never be removed.
+func (s *Store) startRangeIDLocalGC(ctx context.Context) {
+	_ /* err */ = s.stopper.RunAsyncTaskEx(ctx, stop.TaskOpts{
+		TaskName: "range-id-local-gc",
+		SpanOpt:  stop.SterileRootSpan,
+	}, func(ctx context.Context) {
+		ctx, cancel := s.stopper.WithCancelOnQuiesce(ctx)
+		defer cancel()
+
+		timer := timeutil.NewTimer()
+		defer timer.Stop()
+		timer.Reset(10 * time.Minute)
+		for {
+			select {
+			case <-timer.C:
+				timer.Read = true
+			case <-ctx.Done():
+				return
+			}
+			interval := rangeIDLocalGCInterval.Get(&s.ClusterSettings().SV)
+			if interval == 0 {
+				// Check again later whether the GC has been enabled.
+				timer.Reset(time.Minute)
+				continue
+			}
+			if err := s.gcOrphanedRangeIDData(ctx); err != nil && ctx.Err() == nil {
+				log.Warningf(ctx, "unable to remove orphaned range-ID local data: %v", err)
+			}
+			timer.Reset(interval)
+		}
+	})
+}
+
+// gcOrphanedRangeIDData runs a single pass removing the orphaned range-ID
+// local data of the store, paced by kv.range_id_local_gc.ranges_per_second.
+func (s *Store) gcOrphanedRangeIDData(ctx context.Context) error {
+	eng := s.TODOEngine()
+	orphans, err := findOrphanedRangeIDData(ctx, eng, func(rangeID roachpb.RangeID) bool {
+		return s.GetReplicaIfExists(rangeID) != nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(orphans) == 0 {
+		return nil
+	}
+	rate := rangeIDLocalGCRate.Get(&s.ClusterSettings().SV)
+	limiter := quotapool.NewRateLimiter("range-id-local-gc", quotapool.Limit(rate), 1)
+	var ranges, removedKeys int64
+	for _, orphan := range orphans {
+		if err := limiter.WaitN(ctx, 1); err != nil {
+			return err
+		}
+		removed, err := s.removeOrphanedRangeIDData(ctx, orphan.rangeID)
+		if err != nil {
+			return errors.Wrapf(err, "r%d", orphan.rangeID)
+		}
+		if removed {
+			ranges++
+			removedKeys += orphan.keys
+			s.metrics.RangeIDLocalOrphanedRanges.Inc(1)
+			s.metrics.RangeIDLocalOrphanedKeys.Inc(orphan.keys)
+		}
+	}
+	if ranges > 0 {
+		log.Infof(ctx, "removed the orphaned range-ID local data of %d ranges (%d keys)",
+			ranges, removedKeys)
+	}
+	return nil
+}
+
+// removeOrphanedRangeIDData removes the range-ID local data of the given range,
+// unless the store has a replica of it. Returns false if the data wasn't
+// removed because the store has a replica, or is creating one.
+func (s *Store) removeOrphanedRangeIDData(
+	ctx context.Context, rangeID roachpb.RangeID,
+) (bool, error) {
+	// Prevent the creation of a replica while the data is removed, the same
+	// way racing creations of the replica are prevented. See
+	// tryGetOrCreateReplica.
+	s.mu.Lock()
+	if _, ok := s.mu.creatingReplicas[rangeID]; ok || s.GetReplicaIfExists(rangeID) != nil {
+		s.mu.Unlock()
+		return false, nil
+	}
+	s.mu.creatingReplicas[rangeID] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.mu.creatingReplicas, rangeID)
+		s.mu.Unlock()
+	}()
+
+	eng := s.TODOEngine()
+	batch := eng.NewWriteBatch()
+	defer batch.Close()
+	if err := clearOrphanedRangeIDData(ctx, eng, batch, rangeID); err != nil {
+		return false, err
+	}
+	return true, batch.Commit(false /* sync */)
+}
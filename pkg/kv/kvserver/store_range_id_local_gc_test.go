@@ -0,0 +1,75 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/stateloader"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrphanedRangeIDData(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	eng := storage.NewDefaultInMemForTesting()
+	defer eng.Close()
+
+	// r1 has a replica, r2 only has a tombstone, and r3 and r4 have no replica
+	// but have leftover replicated and unreplicated data.
+	for rangeID := roachpb.RangeID(1); rangeID <= 4; rangeID++ {
+		sl := stateloader.Make(rangeID)
+		if rangeID != 2 {
+			require.NoError(t, sl.SetRaftReplicaID(ctx, eng, 1))
+			require.NoError(t, sl.SetGCThreshold(ctx, eng, nil, &hlc.Timestamp{WallTime: 1}))
+		}
+		if rangeID != 1 {
+			require.NoError(t, storage.MVCCBlindPutProto(ctx, eng, keys.RangeTombstoneKey(rangeID),
+				hlc.Timestamp{}, &kvserverpb.RangeTombstone{NextReplicaID: 2},
+				storage.MVCCWriteOptions{}))
+		}
+	}
+	hasReplica := func(rangeID roachpb.RangeID) bool { return rangeID == 1 }
+
+	orphans, err := findOrphanedRangeIDData(ctx, eng, hasReplica)
+	require.NoError(t, err)
+	require.Equal(t, []orphanedRangeIDData{
+		{rangeID: 3, keys: 2},
+		{rangeID: 4, keys: 2},
+	}, orphans)
+
+	// Clearing the data of the orphans keeps their tombstones.
+	for _, orphan := range orphans {
+		require.NoError(t, clearOrphanedRangeIDData(ctx, eng, eng, orphan.rangeID))
+		found, err := storage.MVCCGetProto(ctx, eng, keys.RangeTombstoneKey(orphan.rangeID),
+			hlc.Timestamp{}, &kvserverpb.RangeTombstone{}, storage.MVCCGetOptions{})
+		require.NoError(t, err)
+		require.True(t, found)
+	}
+	orphans, err = findOrphanedRangeIDData(ctx, eng, hasReplica)
+	require.NoError(t, err)
+	require.Empty(t, orphans)
+
+	// The data of r1 is untouched.
+	replicaID, err := stateloader.Make(1).LoadRaftReplicaID(ctx, eng)
+	require.NoError(t, err)
+	require.Equal(t, roachpb.ReplicaID(1), replicaID.ReplicaID)
+}
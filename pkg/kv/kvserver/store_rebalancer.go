@@ -910,6 +910,12 @@ func (sr *StoreRebalancer) chooseRangeToRebalance(
 			continue
 		}
 
+		// The replicas of pinned ranges are placed by the replicate queue.
+		if candidateReplica.ReplicaPin() != nil {
+			log.KvDistribution.VEventf(ctx, 3, "r%d is pinned; ignoring", candidateReplica.GetRangeID())
+			continue
+		}
+
 		rangeDesc := candidateReplica.Desc()
 		conf, err := candidateReplica.LoadSpanConfig(ctx)
 		if err != nil {
@@ -310,6 +310,9 @@ func (s *Store) unlinkReplicaByRangeIDLocked(ctx context.Context, rangeID roachp
 	s.unregisterLeaseholderByID(ctx, rangeID)
 	s.raftRecvQueues.Delete(rangeID)
 	s.scheduler.RemovePriorityID(rangeID)
+	if changed, n := s.hotStandbys.update(rangeID, false /* isStandby */); changed {
+		s.metrics.HotStandbyReplicas.Update(int64(n))
+	}
 }
 
 // removePlaceholder removes a placeholder for the specified range.
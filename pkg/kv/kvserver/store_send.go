@@ -256,6 +256,14 @@ func (s *Store) SendWithWriteBytes(
 				ris = nil // just to be safe
 			}
 
+			// The store doesn't have replicas for all the ranges of the span. Fill
+			// in the gaps with the node's range cache, so that the client can
+			// repopulate its own cache for the whole span at once instead of
+			// looking up the missing ranges one by one. These are appended first,
+			// since they may be stale.
+			t.AppendRangeInfo(ctx, s.cachedRangeInfosForGaps(
+				ctx, roachpb.RSpan{Key: startKey, EndKey: endKey}, ri, ris)...)
+
 			// Update the suggested ranges, if returned from the replica. Note here that newer ranges are
 			// always appended, so that the oldest RangeInfo is processed by the Client's RangeCache first,
 			// which is then invalidated on conflict by newer data (LILO).
@@ -309,6 +317,50 @@ func (s *Store) SendWithWriteBytes(
 	return nil, nil, pErr
 }
 
+// cachedRangeInfosForGaps returns the infos of the ranges overlapping the
+// given span, and none of the given ranges of this store's replicas, from the
+// node's range cache. The infos may be stale: they are only hints for the
+// client's range cache, which doesn't let them replace newer entries.
+func (s *Store) cachedRangeInfosForGaps(
+	ctx context.Context, span roachpb.RSpan, mismatched roachpb.RangeInfo, local []roachpb.RangeInfo,
+) []roachpb.RangeInfo {
+	if s.cfg.RangeDescriptorCache == nil {
+		return nil
+	}
+	overlapsLocal := func(desc *roachpb.RangeDescriptor) bool {
+		overlaps := func(o *roachpb.RangeDescriptor) bool {
+			return desc.StartKey.Less(o.EndKey) && o.StartKey.Less(desc.EndKey)
+		}
+		if overlaps(&mismatched.Desc) {
+			return true
+		}
+		for i := range local {
+			if overlaps(&local[i].Desc) {
+				return true
+			}
+		}
+		return false
+	}
+	var ris []roachpb.RangeInfo
+	for _, e := range s.cfg.RangeDescriptorCache.GetCachedOverlapping(ctx, span) {
+		desc := e.Desc()
+		if e.DescSpeculative() || overlapsLocal(desc) {
+			continue
+		}
+		ri := roachpb.RangeInfo{
+			Desc:                  *desc,
+			ClosedTimestampPolicy: e.ClosedTimestampPolicy(),
+		}
+		if l := e.Lease(); l != nil {
+			if _, ok := desc.GetReplicaDescriptorByID(l.Replica.ReplicaID); ok {
+				ri.Lease = *l
+			}
+		}
+		ris = append(ris, ri)
+	}
+	return ris
+}
+
 // maybeThrottleBatch inspects the provided batch and determines whether
 // throttling should be applied to avoid overloading the Store. If so, the
 // method blocks and returns a reservation that must be released after the
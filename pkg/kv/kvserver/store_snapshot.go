@@ -126,7 +126,11 @@ type kvBatchSnapshotStrategy struct {
 	// before flushing to disk. Only used on the receiver side.
 	sstChunkSize int64
 	// Only used on the receiver side.
-	scratch   *SSTSnapshotStorageScratch
+	scratch *SSTSnapshotStorageScratch
+	// local is set, on the receiver side, for the snapshots sent by a store on
+	// the same node, whose data is read from the sender's engine snapshot
+	// instead of the stream.
+	local     *localSnapshot
 	st        *cluster.Settings
 	clusterID uuid.UUID
 }
@@ -535,7 +539,12 @@ func (kvSS *kvBatchSnapshotStrategy) Receive(
 
 	for {
 		timingTag.start("recv")
-		req, err := stream.Recv()
+		var req *kvserverpb.SnapshotRequest
+		if kvSS.local != nil {
+			req, err = kvSS.receiveLocal(ctx, msstw, recordBytesReceived)
+		} else {
+			req, err = stream.Recv()
+		}
 		timingTag.stop("recv")
 		if err != nil {
 			return noSnap, err
@@ -1286,9 +1295,19 @@ func (s *Store) getLocalityComparison(
 	return comparisonResult
 }
 
+// isLearnerSnapshot returns true if the snapshot described by the header is
+// targeting a LEARNER replica on the given store.
+func isLearnerSnapshot(header *kvserverpb.SnapshotRequest_Header, storeID roachpb.StoreID) bool {
+	repDesc, ok := header.State.Desc.GetReplicaDescriptor(storeID)
+	return ok && repDesc.Type == roachpb.LEARNER
+}
+
 // receiveSnapshot receives an incoming snapshot via a pre-opened GRPC stream.
 func (s *Store) receiveSnapshot(
-	ctx context.Context, header *kvserverpb.SnapshotRequest_Header, stream incomingSnapshotStream,
+	ctx context.Context,
+	header *kvserverpb.SnapshotRequest_Header,
+	stream incomingSnapshotStream,
+	local *localSnapshot,
 ) error {
 	// Draining nodes will generally not be rebalanced to (see the filtering that
 	// happens in getStoreListFromIDsLocked()), but in case they are, they should
@@ -1304,6 +1323,19 @@ func (s *Store) receiveSnapshot(
 			if header.SenderQueuePriority == 0 {
 				return sendSnapshotError(ctx, s, stream, errors.New(storeDrainingMsg))
 			}
+			// Recovery snapshots that would initialize a new learner on this store
+			// are rejected as well (if configured). The learner would only have to
+			// be moved off again before the drain can complete, and the drain
+			// waits for it. Rejecting the snapshot fails the learner addition, and
+			// the replicate queue rolls the learner back. It then picks a new
+			// target, and the store pool no longer offers this store once the
+			// drain is gossiped. Snapshots for existing replicas are still
+			// accepted, so recovery of replicas we already hold is unaffected.
+			if rejectLearnerSnapshotsWhenDraining.Get(&s.ClusterSettings().SV) &&
+				isLearnerSnapshot(header, s.StoreID()) {
+				s.metrics.RangeSnapshotRecvDrainingRejected.Inc(1)
+				return sendSnapshotError(ctx, s, stream, errors.New(storeDrainingMsg))
+			}
 		case kvserverpb.SnapshotRequest_OTHER:
 			return sendSnapshotError(ctx, s, stream, errors.New(storeDrainingMsg))
 		default:
@@ -1378,12 +1410,16 @@ func (s *Store) receiveSnapshot(
 	ss := &kvBatchSnapshotStrategy{
 		scratch:      s.sstSnapshotStorage.NewScratchSpace(header.State.Desc.RangeID, snapUUID),
 		sstChunkSize: snapshotSSTWriteSyncRate.Get(&s.cfg.Settings.SV),
+		local:        local,
 		st:           s.ClusterSettings(),
 		clusterID:    s.ClusterID(),
 	}
 	defer ss.Close(ctx)
 
-	if err := stream.Send(&kvserverpb.SnapshotResponse{Status: kvserverpb.SnapshotResponse_ACCEPTED}); err != nil {
+	if err := stream.Send(&kvserverpb.SnapshotResponse{
+		Status:  kvserverpb.SnapshotResponse_ACCEPTED,
+		MaxRate: s.advertisedSnapshotRate(),
+	}); err != nil {
 		return err
 	}
 	if log.V(2) {
@@ -1618,6 +1654,23 @@ var snapshotReservationQueueTimeoutFraction = settings.RegisterFloatSetting(
 	settings.FloatInRange(0.25, 1.0),
 )
 
+// rejectLearnerSnapshotsWhenDraining controls whether a draining store
+// declines recovery snapshots from the replicate queue that would initialize a
+// new learner replica on it. Rebalancing snapshots are always declined by
+// draining stores.
+//
+// The setting only governs the receiving side. Picking a delegated snapshot
+// sender (see excludeDrainingSnapshotSenders) avoids draining stores whether
+// or not it is set. A draining store is never the sender of a snapshot it
+// receives, so the two checks cover different stores of the same snapshot.
+var rejectLearnerSnapshotsWhenDraining = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.snapshot_receiver.reject_learner_snapshots_when_draining.enabled",
+	"if enabled, draining stores decline snapshots for new learner replicas, "+
+		"including those sent to recover under-replicated ranges",
+	true,
+)
+
 // snapshotSSTWriteSyncRate is the size of chunks to write before fsync-ing.
 // The default of 2 MiB was chosen to be in line with the behavior in bulk-io.
 // See sstWriteSyncRate.
@@ -1834,8 +1887,9 @@ func sendSnapshot(
 	durQueued := timeutil.Since(start)
 	start = timeutil.Now()
 
-	// Consult cluster settings to determine rate limits and batch sizes.
-	targetRate := rate.Limit(rebalanceSnapshotRate.Get(&st.SV))
+	// Consult cluster settings to determine rate limits and batch sizes. The
+	// receiver may ask for a lower rate, given the health of its LSM.
+	targetRate := rate.Limit(negotiatedSnapshotRate(rebalanceSnapshotRate.Get(&st.SV), resp.MaxRate))
 	batchSize := snapshotSenderBatchSize.Get(&st.SV)
 
 	// Convert the bytes/sec rate limit to batches/sec.
@@ -0,0 +1,166 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/rditer"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// localSnapshotsEnabled controls whether snapshots sent to a store on the same
+// node take the local fast path.
+var localSnapshotsEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"kv.snapshot.local_fast_path.enabled",
+	"if enabled, snapshots sent to a store on the same node are written straight "+
+		"into the SSTs ingested by the recipient, instead of being streamed over the "+
+		"network and rate limited",
+	true,
+)
+
+// localSnapshot is a snapshot sent by a store to another store on the same
+// node. Such a snapshot isn't streamed: the recipient reads the data of the
+// range from the sender's engine snapshot and writes it into the SSTs it
+// ingests, which are hard-linked into its engine (or copied, if its scratch
+// space is on another filesystem). The raft state is then spliced in as for
+// any other snapshot.
+type localSnapshot struct {
+	snap            *OutgoingSnapshot
+	sent            func()
+	recordBytesSent snapshotRecordMetrics
+}
+
+// localSnapshotStream is the incomingSnapshotStream of a localSnapshot. It
+// only collects the responses of the recipient, since the snapshot data isn't
+// streamed.
+type localSnapshotStream struct {
+	resps []*kvserverpb.SnapshotResponse
+}
+
+var _ incomingSnapshotStream = (*localSnapshotStream)(nil)
+
+// Send implements the incomingSnapshotStream interface.
+func (s *localSnapshotStream) Send(resp *kvserverpb.SnapshotResponse) error {
+	s.resps = append(s.resps, resp)
+	return nil
+}
+
+// Recv implements the incomingSnapshotStream interface.
+func (s *localSnapshotStream) Recv() (*kvserverpb.SnapshotRequest, error) {
+	return nil, errors.AssertionFailedf("local snapshots are not streamed")
+}
+
+// receiveLocalSnapshot receives a snapshot from a store on the same node. It
+// goes through the same admission and application as a streamed snapshot, but
+// skips the network and the snapshot rate limit.
+func (s *Store) receiveLocalSnapshot(
+	ctx context.Context,
+	header *kvserverpb.SnapshotRequest_Header,
+	snap *OutgoingSnapshot,
+	sent func(),
+	recordBytesSent snapshotRecordMetrics,
+) (*kvserverpb.SnapshotResponse, error) {
+	if recordBytesSent == nil {
+		recordBytesSent = func(inc int64) {}
+	}
+	to := header.RaftMessageRequest.ToReplica
+	stream := &localSnapshotStream{}
+	local := &localSnapshot{snap: snap, sent: sent, recordBytesSent: recordBytesSent}
+	if err := s.receiveSnapshot(ctx, header, stream, local); err != nil {
+		return nil, err
+	}
+	if len(stream.resps) == 0 {
+		return nil, errors.AssertionFailedf("%s: no response to local snapshot", to)
+	}
+	resp := stream.resps[len(stream.resps)-1]
+	switch resp.Status {
+	case kvserverpb.SnapshotResponse_ERROR:
+		return nil, errors.Wrapf(
+			maybeHandleDeprecatedSnapErr(resp.Error()), "%s: local store failed to apply snapshot", to,
+		)
+	case kvserverpb.SnapshotResponse_APPLIED:
+		s.metrics.RangeSnapshotsLocalRcvd.Inc(1)
+		log.KvDistribution.Infof(ctx, "applied %s to %s through the local fast path", snap, to)
+		return resp, nil
+	default:
+		return nil, errors.Errorf("%s: local store sent an invalid status: %s", to, resp.Status)
+	}
+}
+
+// receiveLocal writes the data of a local snapshot into the SSTs of the
+// recipient, straight from the sender's engine snapshot. It returns the final
+// request of the snapshot, as if the data had been streamed.
+func (kvSS *kvBatchSnapshotStrategy) receiveLocal(
+	ctx context.Context, msstw *multiSSTWriter, recordBytesReceived snapshotRecordMetrics,
+) (*kvserverpb.SnapshotRequest, error) {
+	snap := kvSS.local.snap
+	var bytes int64
+	var kvs, rangeKVs int
+	err := rditer.IterateReplicaKeySpans(ctx, snap.State.Desc, snap.EngineSnap, true, /* replicatedOnly */
+		rditer.ReplicatedSpansAll,
+		func(iter storage.EngineIterator, _ roachpb.Span, keyType storage.IterKeyType) error {
+			var err error
+			switch keyType {
+			case storage.IterKeyTypePointsOnly:
+				for ok := true; ok && err == nil; ok, err = iter.NextEngineKey() {
+					kvs++
+					key, err := iter.UnsafeEngineKey()
+					if err != nil {
+						return err
+					}
+					v, err := iter.UnsafeValue()
+					if err != nil {
+						return err
+					}
+					if err := msstw.Put(ctx, key, v); err != nil {
+						return errors.Wrapf(err, "writing sst for local snapshot")
+					}
+					bytes += int64(key.EncodedLen() + len(v))
+				}
+
+			case storage.IterKeyTypeRangesOnly:
+				for ok := true; ok && err == nil; ok, err = iter.NextEngineKey() {
+					bounds, err := iter.EngineRangeBounds()
+					if err != nil {
+						return err
+					}
+					for _, rkv := range iter.EngineRangeKeys() {
+						rangeKVs++
+						if err := msstw.PutRangeKey(ctx, bounds.Key, bounds.EndKey, rkv.Version, rkv.Value); err != nil {
+							return errors.Wrapf(err, "writing sst for local snapshot")
+						}
+						bytes += int64(len(bounds.Key) + len(bounds.EndKey) + len(rkv.Version) + len(rkv.Value))
+					}
+				}
+
+			default:
+				return errors.AssertionFailedf("unexpected key type %v", keyType)
+			}
+			return err
+		})
+	if err != nil {
+		return nil, err
+	}
+	log.Eventf(ctx, "copied %d kvs and %d range kvs from local snapshot", kvs, rangeKVs)
+	recordBytesReceived(bytes)
+	kvSS.local.recordBytesSent(bytes)
+	if kvSS.local.sent != nil {
+		kvSS.local.sent()
+	}
+	return &kvserverpb.SnapshotRequest{Final: true}, nil
+}
@@ -14,6 +14,8 @@ import (
 	"bytes"
 	"context"
 
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvstorage"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/load"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/stateloader"
@@ -189,6 +191,11 @@ func splitPostApply(
 	// Update store stats with difference in stats before and after split.
 	if rightReplOrNil != nil {
 		rightReplOrNil.store.metrics.addMVCCStats(ctx, rightReplOrNil.tenantMetricsRef, deltaMS)
+		// The range-local state of the RHS was just initialized.
+		rightReplOrNil.mu.RLock()
+		rightState := rightReplOrNil.mu.state
+		rightReplOrNil.mu.RUnlock()
+		rightReplOrNil.notifyRangeStateReplaced(ctx, &kvserverpb.ReplicaState{}, &rightState)
 	}
 
 	now := r.store.Clock().NowAsClockTimestamp()
@@ -214,6 +221,16 @@ func splitPostApply(
 			r.store.enqueueRaftUpdateCheck(rightReplOrNil.RangeID)
 		}
 	}
+
+	if r.store.cfg.ApplyTriggers != nil {
+		rightInfo := roachpb.RangeInfo{Desc: split.RightDesc}
+		if rightReplOrNil != nil {
+			rightInfo = rightReplOrNil.GetRangeInfo(ctx)
+		}
+		r.store.cfg.ApplyTriggers.NotifySplitMerge(ctx, kvserverbase.SplitMergeEvent{
+			Ranges: []roachpb.RangeInfo{r.GetRangeInfo(ctx), rightInfo},
+		})
+	}
 }
 
 // prepareRightReplicaForSplit a helper for splitPostApply.
@@ -315,6 +332,11 @@ func (s *Store) SplitRange(
 	// clear them.
 	leftRepl.concMgr.OnRangeSplit()
 
+	// The hot keys sampled by the LHS may now belong to the RHS, and so may
+	// the keys whose usage it accounted for.
+	leftRepl.resetHotKeys()
+	leftRepl.resetKeySpaceUsage()
+
 	if rightReplOrNil == nil {
 		// There is no RHS replica, so (heuristically) halve the load stats for the
 		// LHS, instead of splitting it between LHS and RHS.
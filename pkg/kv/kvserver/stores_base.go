@@ -16,6 +16,7 @@ import (
 	"unsafe"
 
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing/tracingpb"
@@ -56,6 +57,12 @@ func (s *baseStore) StoreID() roachpb.StoreID {
 	return store.StoreID()
 }
 
+// Health is part of kvserverbase.Store.
+func (s *baseStore) Health() roachpb.StoreHealth {
+	store := (*Store)(s)
+	return store.Health()
+}
+
 // Enqueue is part of kvserverbase.Store.
 func (s *baseStore) Enqueue(
 	ctx context.Context, queue string, rangeID roachpb.RangeID, skipShouldQueue bool,
@@ -95,6 +102,45 @@ func (s *baseStore) SetQueueActive(active bool, queue string) error {
 	return nil
 }
 
+// VisitHotKeys is part of kvserverbase.Store.
+func (s *baseStore) VisitHotKeys(
+	visitor func(rangeID roachpb.RangeID, key roachpb.Key, count int64) error,
+) error {
+	store := (*Store)(s)
+	var err error
+	newStoreReplicaVisitor(store).InOrder().Visit(func(r *Replica) bool {
+		for _, hk := range r.hotKeys() {
+			if err = visitor(r.RangeID, hk.Key, hk.Count); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+	return err
+}
+
+// VisitKeySpaceUsage is part of kvserverbase.Store.
+func (s *baseStore) VisitKeySpaceUsage(
+	ctx context.Context,
+	visitor func(rangeID roachpb.RangeID, usage kvserverpb.KeySpaceUsage) error,
+) error {
+	store := (*Store)(s)
+	var err error
+	newStoreReplicaVisitor(store).InOrder().Visit(func(r *Replica) bool {
+		var usage []kvserverpb.KeySpaceUsage
+		if usage, err = r.computeKeySpaceUsageIfNeeded(ctx); err != nil {
+			return false
+		}
+		for _, u := range usage {
+			if err = visitor(r.RangeID, u); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+	return err
+}
+
 // GetReplicaMutexForTesting is part of kvserverbase.Store.
 func (s *baseStore) GetReplicaMutexForTesting(rangeID roachpb.RangeID) *syncutil.RWMutex {
 	store := (*Store)(s)
@@ -140,6 +140,71 @@ func (is Server) WaitForReplicaInit(
 	return resp, err
 }
 
+// EnqueueReplicaGC implements PerReplicaServer. It adds the replica to the
+// store's replica GC queue if it is still the replica that was removed. The
+// request does not wait for the replica to be processed by the queue.
+func (is Server) EnqueueReplicaGC(
+	ctx context.Context, req *EnqueueReplicaGCRequest,
+) (*EnqueueReplicaGCResponse, error) {
+	resp := &EnqueueReplicaGCResponse{}
+	err := is.execStoreCommand(ctx, req.StoreRequestHeader, func(ctx context.Context, s *Store) error {
+		repl, err := s.GetReplica(req.RangeID)
+		if err != nil {
+			// The replica may already have been removed, which is fine.
+			return nil // nolint:returnerrcheck
+		}
+		if repl.ReplicaID() != req.ReplicaID {
+			return nil
+		}
+		bq := s.replicaGCQueue.baseQueue
+		bq.Async(ctx, "Add", true /* wait */, func(ctx context.Context, _ queueHelper) {
+			// Only count the notification if it actually enqueued the replica. It
+			// may be dropped if the queue is disabled or full, or if the replica is
+			// already queued or in purgatory.
+			added, err := bq.addInternal(ctx, repl.Desc(), repl.ReplicaID(), replicaGCPriorityRemoved)
+			if err != nil && log.V(1) {
+				log.Infof(ctx, "during Add: %s", err)
+			}
+			if added {
+				s.replicaGCQueue.metrics.NotifiedCount.Inc(1)
+			}
+		})
+		return nil
+	})
+	return resp, err
+}
+
+// SetProposalQuota implements PerReplicaServer.
+func (is Server) SetProposalQuota(
+	ctx context.Context, req *SetProposalQuotaRequest,
+) (*SetProposalQuotaResponse, error) {
+	resp := &SetProposalQuotaResponse{}
+	err := is.execStoreCommand(ctx, req.StoreRequestHeader, func(ctx context.Context, s *Store) error {
+		repl, err := s.GetReplica(req.RangeID)
+		if err != nil {
+			return err
+		}
+		return repl.SetProposalQuota(req.Capacity, req.Duration)
+	})
+	return resp, err
+}
+
+// GetRangeEventLog implements PerReplicaServer.
+func (is Server) GetRangeEventLog(
+	ctx context.Context, req *GetRangeEventLogRequest,
+) (*GetRangeEventLogResponse, error) {
+	resp := &GetRangeEventLogResponse{}
+	err := is.execStoreCommand(ctx, req.StoreRequestHeader, func(ctx context.Context, s *Store) error {
+		repl, err := s.GetReplica(req.RangeID)
+		if err != nil {
+			return err
+		}
+		resp.Events, err = repl.RangeEventLog(ctx)
+		return err
+	})
+	return resp, err
+}
+
 // CompactEngineSpan implements PerStoreServer. It blocks until the compaction
 // is done, so it can be a long-lived RPC.
 func (is Server) CompactEngineSpan(
@@ -173,6 +238,37 @@ func (is Server) GetTableMetrics(
 	return resp, err
 }
 
+// GetSpanEncryptionStats implements PerStoreServer. It retrieves the progress
+// of the rotation of the data key of the stores of the node for the given
+// spans, compacting them first if re-encryption is forced.
+func (is Server) GetSpanEncryptionStats(
+	ctx context.Context, req *GetSpanEncryptionStatsRequest,
+) (*GetSpanEncryptionStatsResponse, error) {
+	resp := &GetSpanEncryptionStatsResponse{}
+	for _, storeSpans := range req.Stores {
+		err := is.execStoreCommand(ctx, storeSpans.StoreRequestHeader,
+			func(ctx context.Context, s *Store) error {
+				for _, sp := range storeSpans.Spans {
+					if req.ForceReencryption {
+						if err := s.TODOEngine().CompactRange(sp.Key, sp.EndKey); err != nil {
+							return err
+						}
+					}
+					stats, err := s.TODOEngine().GetSpanEncryptionStats(sp.Key, sp.EndKey)
+					if err != nil {
+						return err
+					}
+					resp.Stats = append(resp.Stats, stats)
+				}
+				return nil
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
 func (is Server) ScanStorageInternalKeys(
 	ctx context.Context, req *ScanStorageInternalKeysRequest,
 ) (*ScanStorageInternalKeysResponse, error) {
@@ -121,6 +121,7 @@ func newTimeSeriesMaintenanceQueue(
 			pending:              store.metrics.TimeSeriesMaintenanceQueuePending,
 			processingNanos:      store.metrics.TimeSeriesMaintenanceQueueProcessingNanos,
 			disabledConfig:       kvserverbase.TimeSeriesMaintenanceQueueEnabled,
+			priorityClass:        queuePriorityClassMaintenance,
 		},
 	)
 
@@ -153,6 +154,12 @@ func (q *timeSeriesMaintenanceQueue) process(
 	desc := repl.Desc()
 	eng := repl.store.StateEngine()
 	now := repl.store.Clock().Now()
+	// Time series maintenance scans the entire range. The scan is charged for
+	// up front, since it isn't paced from within.
+	ms := repl.GetMVCCStats()
+	if err := chargeQueueScan(ctx, ms.Total()); err != nil {
+		return false, err
+	}
 	if err := q.tsData.MaintainTimeSeries(
 		ctx, eng, desc.StartKey, desc.EndKey, q.db, q.mem, TimeSeriesMaintenanceMemoryBudget, now,
 	); err != nil {
@@ -230,6 +230,9 @@ func (m *manager) resolveIndeterminateCommitForTxnProbe(
 	for len(queryIntentReqs) > 0 {
 		var b kv.Batch
 		b.Header.Timestamp = m.batchTimestamp(txn)
+		// The in-flight writes of the transaction record hold the physical keys
+		// of the hash-sharded spans, see kvserverbase.HashShardedSpan.
+		b.Header.HashShardedKeysTranslated = true
 		b.AddRawRequest(&queryTxnReq)
 		for i := 0; i < defaultBatchSize && len(queryIntentReqs) > 0; i++ {
 			b.AddRawRequest(&queryIntentReqs[0])
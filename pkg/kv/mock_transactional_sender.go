@@ -151,6 +151,16 @@ func (m *MockTransactionalSender) SetFixedTimestamp(_ context.Context, ts hlc.Ti
 	return nil
 }
 
+// SetCommitTimestampFloor is part of the TxnSender interface.
+func (m *MockTransactionalSender) SetCommitTimestampFloor(
+	_ context.Context, ts hlc.Timestamp,
+) error {
+	m.txn.ReadTimestamp.Forward(ts)
+	m.txn.WriteTimestamp.Forward(ts)
+	m.txn.GlobalUncertaintyLimit.Forward(ts)
+	return nil
+}
+
 // RequiredFrontier is part of the TxnSender interface.
 func (m *MockTransactionalSender) RequiredFrontier() hlc.Timestamp {
 	return m.txn.RequiredFrontier()
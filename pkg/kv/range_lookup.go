@@ -17,6 +17,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/retry"
 	"github.com/cockroachdb/errors"
@@ -170,6 +171,49 @@ func RangeLookup(
 	rc kvpb.ReadConsistencyType,
 	prefetchNum int64,
 	prefetchReverse bool,
+) (rs, preRs []roachpb.RangeDescriptor, err error) {
+	return rangeLookup(ctx, sender, key, rc, hlc.Timestamp{}, prefetchNum, prefetchReverse)
+}
+
+// RangeLookupAsOf is like RangeLookup, but it looks up the RangeDescriptors as
+// of the given timestamp, by reading the range metadata at that timestamp. The
+// lookup is performed at the READ_UNCOMMITTED read consistency, like the
+// lookups of the range descriptor cache, so it may return the descriptors
+// written by transactions which were in progress at the timestamp.
+//
+// The returned RangeDescriptors describe the ranges as they were at the
+// timestamp, and the ranges may have since been split, merged or moved. They
+// can't be used to route requests, including historical reads: the data of a
+// range, including its history, is only served by the current replicas of the
+// range, which are described by its current RangeDescriptor. Instead, they
+// describe how the keyspace was split at the timestamp, e.g. to interpret the
+// range IDs reported by historical traces or events.
+//
+// The timestamp must be above the GC threshold of the range metadata.
+func RangeLookupAsOf(
+	ctx context.Context,
+	sender Sender,
+	key roachpb.Key,
+	ts hlc.Timestamp,
+	prefetchNum int64,
+	prefetchReverse bool,
+) (rs, preRs []roachpb.RangeDescriptor, err error) {
+	if ts.IsEmpty() {
+		return nil, nil, errors.AssertionFailedf("range lookup timestamp must be set")
+	}
+	return rangeLookup(ctx, sender, key, kvpb.READ_UNCOMMITTED, ts, prefetchNum, prefetchReverse)
+}
+
+// rangeLookup implements RangeLookup and RangeLookupAsOf. If ts is empty, the
+// lookup reads the latest range metadata.
+func rangeLookup(
+	ctx context.Context,
+	sender Sender,
+	key roachpb.Key,
+	rc kvpb.ReadConsistencyType,
+	ts hlc.Timestamp,
+	prefetchNum int64,
+	prefetchReverse bool,
 ) (rs, preRs []roachpb.RangeDescriptor, err error) {
 	// RangeLookup scans can span multiple ranges, as discussed above.
 	// Traditionally, in order to see a fully-consistent snapshot of multiple
@@ -203,12 +247,12 @@ func RangeLookup(
 			return nil, nil, err
 		}
 
-		descs, intentDescs, err := lookupRangeFwdScan(ctx, sender, rkey, rc, prefetchNum, prefetchReverse)
+		descs, intentDescs, err := lookupRangeFwdScan(ctx, sender, rkey, rc, ts, prefetchNum, prefetchReverse)
 		if err != nil {
 			return nil, nil, err
 		}
 		if prefetchReverse {
-			descs, intentDescs, err = lookupRangeRevScan(ctx, sender, rkey, rc, prefetchNum,
+			descs, intentDescs, err = lookupRangeRevScan(ctx, sender, rkey, rc, ts, prefetchNum,
 				prefetchReverse, descs, intentDescs)
 			if err != nil {
 				return nil, nil, err
@@ -270,6 +314,7 @@ func lookupRangeFwdScan(
 	sender Sender,
 	key roachpb.RKey,
 	rc kvpb.ReadConsistencyType,
+	ts hlc.Timestamp,
 	prefetchNum int64,
 	prefetchReverse bool,
 ) (rs, preRs []roachpb.RangeDescriptor, err error) {
@@ -291,6 +336,7 @@ func lookupRangeFwdScan(
 
 	ba := &kvpb.BatchRequest{}
 	ba.ReadConsistency = rc
+	ba.Timestamp = ts
 	// If the caller is asking for a potentially stale result, we want to route
 	// the request to the nearest replica rather than the leaseholder.
 	if rc == kvpb.INCONSISTENT {
@@ -363,6 +409,7 @@ func lookupRangeRevScan(
 	sender Sender,
 	key roachpb.RKey,
 	rc kvpb.ReadConsistencyType,
+	ts hlc.Timestamp,
 	prefetchNum int64,
 	prefetchReverse bool,
 	fwdDescs, fwdIntentDescs []roachpb.RangeDescriptor,
@@ -389,6 +436,7 @@ func lookupRangeRevScan(
 
 	ba := &kvpb.BatchRequest{}
 	ba.ReadConsistency = rc
+	ba.Timestamp = ts
 	// If the caller is asking for a potentially stale result, we want to route
 	// the request to the nearest replica rather than the leaseholder.
 	if rc == kvpb.INCONSISTENT {
@@ -19,6 +19,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 )
@@ -176,6 +177,52 @@ func TestRangeLookupRaceSplits(t *testing.T) {
 	})
 }
 
+// TestRangeLookupAsOf tests that RangeLookupAsOf reads the range metadata at
+// the requested timestamp.
+func TestRangeLookupAsOf(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	desc := roachpb.RangeDescriptor{
+		RangeID:  1,
+		StartKey: roachpb.RKey("j"),
+		EndKey:   roachpb.RKey("p"),
+	}
+	ts := hlc.Timestamp{WallTime: 100}
+	sender := SenderFunc(func(_ context.Context, ba *kvpb.BatchRequest) (*kvpb.BatchResponse, *kvpb.Error) {
+		if !TestingIsRangeLookup(ba) {
+			t.Fatalf("expected range lookup, found %v", ba)
+		}
+		if ba.Timestamp != ts {
+			t.Fatalf("expected range lookup at %s, found %s", ts, ba.Timestamp)
+		}
+		if ba.ReadConsistency != kvpb.READ_UNCOMMITTED {
+			t.Fatalf("expected READ_UNCOMMITTED range lookup, found %s", ba.ReadConsistency)
+		}
+		return newScanRespFromRangeDescriptors(&desc), nil
+	})
+
+	rs, preRs, err := RangeLookupAsOf(
+		ctx, sender, roachpb.Key("k"), ts, 0 /* prefetchNum */, false, /* prefetchReverse */
+	)
+	if err != nil {
+		t.Fatalf("RangeLookupAsOf returned error: %v", err)
+	}
+	if len(preRs) != 0 {
+		t.Fatalf("RangeLookupAsOf returned unexpected prefetched descriptors: %v", preRs)
+	}
+	if expRs := []roachpb.RangeDescriptor{desc}; !reflect.DeepEqual(expRs, rs) {
+		t.Fatalf("expected RangeLookupAsOf to return %v, found %v", expRs, rs)
+	}
+
+	if _, _, err := RangeLookupAsOf(
+		ctx, sender, roachpb.Key("k"), hlc.Timestamp{}, 0 /* prefetchNum */, false, /* prefetchReverse */
+	); err == nil {
+		t.Fatal("expected RangeLookupAsOf without a timestamp to fail")
+	}
+}
+
 func newScanRespFromRangeDescriptors(descs ...*roachpb.RangeDescriptor) *kvpb.BatchResponse {
 	br := &kvpb.BatchResponse{}
 	r := &kvpb.ScanResponse{}
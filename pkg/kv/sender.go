@@ -189,6 +189,18 @@ type TxnSender interface {
 	// transaction has been used in the current epoch to read or write.
 	SetFixedTimestamp(ctx context.Context, ts hlc.Timestamp) error
 
+	// SetCommitTimestampFloor sets a timestamp, sourced from an external HLC,
+	// below which the transaction must not commit. The transaction's read and
+	// write timestamps are forwarded to it, and the local clock is updated
+	// with it. The writes of the transaction are rejected with a
+	// CommitTimestampFloorClosedError by ranges which already closed the floor.
+	//
+	// This is used to order transactions by timestamps established by another
+	// cluster, e.g. when applying replicated writes. Like SetFixedTimestamp,
+	// the method must not be called after the transaction has been used in the
+	// current epoch to read or write.
+	SetCommitTimestampFloor(ctx context.Context, ts hlc.Timestamp) error
+
 	// GenerateForcedRetryableErr constructs, handles, and returns a retryable
 	// error that will cause the transaction to be (partially or fully) retried.
 	//
@@ -586,6 +586,21 @@ func (txn *Txn) CPut(ctx context.Context, key, value interface{}, expValue []byt
 	return getOneErr(txn.Run(ctx, b), b)
 }
 
+// CheckAndMutate atomically applies the given conditional puts and deletes,
+// whose keys must belong to a single range. It returns the mutations whose
+// conditions didn't hold, in which case none of the mutations was applied.
+// See CheckAndMutateRequest for details.
+func (txn *Txn) CheckAndMutate(
+	ctx context.Context, mutations ...kvpb.CheckAndMutateRequest_Mutation,
+) ([]kvpb.CheckAndMutateResponse_Failure, error) {
+	b := txn.NewBatch()
+	b.CheckAndMutate(mutations...)
+	if err := getOneErr(txn.Run(ctx, b), b); err != nil {
+		return nil, err
+	}
+	return b.RawResponse().Responses[0].GetCheckAndMutate().Failures, nil
+}
+
 // InitPut sets the first value for a key to value. An error is reported if a
 // value already exists for the key and it's not equal to the value passed in.
 // If failOnTombstones is set to true, tombstones count as mismatched values
@@ -821,6 +836,7 @@ func (txn *Txn) CommitInBatch(ctx context.Context, b *Batch) error {
 		return errors.Errorf("a batch b can only be committed by b.txn")
 	}
 	et := endTxnReq(true, txn.deadline())
+	et.req.DisableSplitOnRetry = b.DisableCommitSplitOnRetry
 	b.growReqs(1)
 	b.reqs[len(b.reqs)-1].Value = &et.union
 	b.initResult(1 /* calls */, 0, b.raw, nil)
@@ -1546,6 +1562,29 @@ func (txn *Txn) SetFixedTimestamp(ctx context.Context, ts hlc.Timestamp) error {
 	return txn.mu.sender.SetFixedTimestamp(ctx, ts)
 }
 
+// SetCommitTimestampFloor sets a timestamp, sourced from an external HLC,
+// below which the transaction must not commit. The writes of the transaction
+// are rejected with a CommitTimestampFloorClosedError by ranges which have
+// already closed the floor, since they would have to be written above it.
+//
+// This is used by cluster-to-cluster replication to order the application of
+// replicated writes by their upstream commit timestamps. This method must be
+// called on every transaction retry, before the transaction reads or writes.
+func (txn *Txn) SetCommitTimestampFloor(ctx context.Context, ts hlc.Timestamp) error {
+	if txn.typ != RootTxn {
+		return errors.WithContextTags(errors.AssertionFailedf(
+			"SetCommitTimestampFloor() called on leaf txn"), ctx)
+	}
+
+	if ts.IsEmpty() {
+		return errors.WithContextTags(errors.AssertionFailedf(
+			"empty timestamp is invalid for SetCommitTimestampFloor()"), ctx)
+	}
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+	return txn.mu.sender.SetCommitTimestampFloor(ctx, ts)
+}
+
 // GenerateForcedRetryableErr returns a TransactionRetryWithProtoRefreshError
 // that will cause the txn to be retried.
 //
@@ -170,6 +170,7 @@ var reqMethodToCap = map[kvpb.Method]tenantcapabilities.ID{
 	// The following requests are authorized for all workloads.
 	kvpb.AddSSTable:         noCapCheckNeeded,
 	kvpb.Barrier:            noCapCheckNeeded,
+	kvpb.CheckAndMutate:     noCapCheckNeeded,
 	kvpb.ClearRange:         noCapCheckNeeded,
 	kvpb.ConditionalPut:     noCapCheckNeeded,
 	kvpb.Delete:             noCapCheckNeeded,
@@ -209,11 +210,15 @@ var reqMethodToCap = map[kvpb.Method]tenantcapabilities.ID{
 	// TODO(knz,arul): Verify with the relevant teams whether secondary
 	// tenants have legitimate access to any of those.
 	kvpb.AdminMerge:                    onlySystemTenant,
+	kvpb.AdminRelocateToLocality:       onlySystemTenant,
 	kvpb.AdminVerifyProtectedTimestamp: onlySystemTenant,
+	kvpb.ColdCompaction:                onlySystemTenant,
 	kvpb.ComputeChecksum:               onlySystemTenant,
 	kvpb.GC:                            onlySystemTenant,
+	kvpb.HotStandby:                    onlySystemTenant,
 	kvpb.Merge:                         onlySystemTenant,
 	kvpb.Migrate:                       onlySystemTenant,
+	kvpb.PinReplicas:                   onlySystemTenant,
 	kvpb.Probe:                         onlySystemTenant,
 	kvpb.QueryResolvedTimestamp:        onlySystemTenant,
 	kvpb.RecomputeStats:                onlySystemTenant,
@@ -222,6 +227,7 @@ var reqMethodToCap = map[kvpb.Method]tenantcapabilities.ID{
 	kvpb.TransferLease:                 onlySystemTenant,
 	kvpb.TruncateLog:                   onlySystemTenant,
 	kvpb.WriteBatch:                    onlySystemTenant,
+	kvpb.WriteFence:                    onlySystemTenant,
 }
 
 const (
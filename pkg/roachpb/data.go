@@ -1827,6 +1827,9 @@ func (l Lease) SafeFormat(w redact.SafePrinter, _ rune) {
 		w.Printf("repl=%s seq=%d start=%s exp=%s", l.Replica, l.Sequence, l.Start, l.Expiration)
 	} else {
 		w.Printf("repl=%s seq=%d start=%s epo=%d", l.Replica, l.Sequence, l.Start, l.Epoch)
+		if l.StoreEpoch != 0 {
+			w.Printf(" store-epo=%d", l.StoreEpoch)
+		}
 	}
 	if l.ProposedTS != nil {
 		w.Printf(" pro=%s", l.ProposedTS)
@@ -1916,6 +1919,7 @@ func (l Lease) Equivalent(newL Lease) bool {
 		// See the comment above, though this field's nullability wasn't
 		// changed. We nil it out for completeness only.
 		l.Epoch, newL.Epoch = 0, 0
+		l.StoreEpoch, newL.StoreEpoch = 0, 0
 
 		// For expiration-based leases, extensions are considered equivalent.
 		// This is the one case where Equivalent is not commutative and, as
@@ -1957,7 +1961,8 @@ func equivalentTimestamps(a, b *hlc.Timestamp) bool {
 // Equal implements the gogoproto Equal interface. This implementation is
 // forked from the gogoproto generated code to allow l.Expiration == nil and
 // l.Expiration == &hlc.Timestamp{} to compare equal. Ditto for
-// DeprecatedStartStasis.
+// DeprecatedStartStasis. Fields added to Lease must be compared here as the
+// generated code would, which TestLeaseEqual verifies.
 func (l *Lease) Equal(that interface{}) bool {
 	if that == nil {
 		return l == nil
@@ -1999,6 +2004,9 @@ func (l *Lease) Equal(that interface{}) bool {
 	if l.Sequence != that1.Sequence {
 		return false
 	}
+	if l.StoreEpoch != that1.StoreEpoch {
+		return false
+	}
 	return true
 }
 
@@ -1045,6 +1045,7 @@ func TestLeaseEquivalence(t *testing.T) {
 	expire1 := Lease{Replica: r1, Start: ts1, Expiration: ts2.ToTimestamp().Clone()}
 	expire2 := Lease{Replica: r1, Start: ts1, Expiration: ts3.ToTimestamp().Clone()}
 	epoch2TS2 := Lease{Replica: r2, Start: ts2, Epoch: 2}
+	epoch1StoreEpoch1 := Lease{Replica: r1, Start: ts1, Epoch: 1, StoreEpoch: 1}
 	expire2TS2 := Lease{Replica: r2, Start: ts2, Expiration: ts3.ToTimestamp().Clone()}
 
 	proposed1 := Lease{Replica: r1, Start: ts1, Epoch: 1, ProposedTS: &ts1}
@@ -1068,6 +1069,7 @@ func TestLeaseEquivalence(t *testing.T) {
 		{expire1, expire1, true},           // same expiration lease
 		{epoch1, epoch2, false},            // different epoch leases
 		{epoch1, epoch2TS2, false},         // different epoch leases
+		{epoch1, epoch1StoreEpoch1, false}, // different store epochs
 		{expire1, expire2TS2, false},       // different expiration leases
 		{expire1, expire2, true},           // same expiration lease, extended
 		{expire2, expire1, false},          // same expiration lease, extended but backwards
@@ -1121,6 +1123,7 @@ func TestLeaseEqual(t *testing.T) {
 		Epoch                 int64
 		Sequence              LeaseSequence
 		AcquisitionType       LeaseAcquisitionType
+		StoreEpoch            int64
 	}
 	// Verify that the lease structure does not change unexpectedly. If a compile
 	// error occurs on the following line of code, update the expectedLease
@@ -1167,6 +1170,7 @@ func TestLeaseEqual(t *testing.T) {
 		{ProposedTS: &clockTS},
 		{Epoch: 1},
 		{Sequence: 1},
+		{StoreEpoch: 1},
 	}
 	for _, c := range testCases {
 		t.Run("", func(t *testing.T) {
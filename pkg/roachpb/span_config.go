@@ -40,6 +40,10 @@ func MatchesConstraint(storeAttrs, nodeAttrs Attributes, nodeLocality Locality,
 	return false
 }
 
+// MaxHashShards is the maximum value of SpanConfig.HashShards. The shard
+// prefixes are encoded on a single byte up to this number.
+const MaxHashShards = 64
+
 var emptySpanConfig = &SpanConfig{}
 
 // IsEmpty returns true if s is an empty SpanConfig.
@@ -79,9 +83,15 @@ func (s *SpanConfig) ValidateSystemTargetSpanConfig() error {
 	if s.GCPolicy.IgnoreStrictEnforcement {
 		return errors.AssertionFailedf("IgnoreStrictEnforcement set on system span config")
 	}
+	if s.GCPolicy.MaxVersions != 0 {
+		return errors.AssertionFailedf("MaxVersions set on system span config")
+	}
 	if s.GlobalReads {
 		return errors.AssertionFailedf("GlobalReads set on system span config")
 	}
+	if s.HashShards != 0 {
+		return errors.AssertionFailedf("HashShards set on system span config")
+	}
 	if s.NumReplicas != 0 {
 		return errors.AssertionFailedf("NumReplicas set on system span config")
 	}
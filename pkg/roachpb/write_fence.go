@@ -0,0 +1,113 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package roachpb
+
+import (
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+)
+
+// Rejects returns true if the fence rejects a write to the given span at the
+// given timestamp.
+func (f WriteFence) Rejects(span Span, ts hlc.Timestamp) bool {
+	return ts.LessEq(f.Timestamp) && f.Span.Overlaps(span)
+}
+
+// IsEmpty returns true if no write fences are installed.
+func (f *WriteFences) IsEmpty() bool {
+	return f == nil || len(f.Fences) == 0
+}
+
+// Conflicting returns the first installed fence which rejects a write to the
+// given span at the given timestamp, if any.
+func (f *WriteFences) Conflicting(span Span, ts hlc.Timestamp) (WriteFence, bool) {
+	if f == nil {
+		return WriteFence{}, false
+	}
+	for _, fence := range f.Fences {
+		if fence.Rejects(span, ts) {
+			return fence, true
+		}
+	}
+	return WriteFence{}, false
+}
+
+// Install installs a fence on the given span at the given timestamp. If a
+// fence on an identical span is already installed, its timestamp is forwarded
+// instead, so fences never move backwards. Returns true iff the receiver was
+// updated.
+func (f *WriteFences) Install(span Span, ts hlc.Timestamp) bool {
+	for i := range f.Fences {
+		if f.Fences[i].Span.Equal(span) {
+			return f.Fences[i].Timestamp.Forward(ts)
+		}
+	}
+	f.Fences = append(f.Fences, WriteFence{Span: span, Timestamp: ts})
+	f.sort()
+	return true
+}
+
+// Remove removes all fences which, restricted to the given range bounds, are
+// contained in the given span. Fences which no longer overlap the range bounds
+// at all, which can be the case for the left-hand side of a split, are dropped
+// as well. Returns true iff the receiver was updated.
+func (f *WriteFences) Remove(span, bounds Span) bool {
+	var kept []WriteFence
+	for _, fence := range f.Fences {
+		clipped := fence.Span.Intersect(bounds)
+		if !clipped.Valid() || span.Contains(clipped) {
+			continue
+		}
+		kept = append(kept, fence)
+	}
+	if len(kept) == len(f.Fences) {
+		return false
+	}
+	f.Fences = kept
+	return true
+}
+
+// Clip returns the fences overlapping the given range bounds, with their spans
+// restricted to the bounds.
+func (f *WriteFences) Clip(bounds Span) []WriteFence {
+	var clipped []WriteFence
+	for _, fence := range f.Fences {
+		if sp := fence.Span.Intersect(bounds); sp.Valid() {
+			clipped = append(clipped, WriteFence{Span: sp, Timestamp: fence.Timestamp})
+		}
+	}
+	return clipped
+}
+
+// Merge combines the write fences of two adjacent ranges. Updates the receiver
+// to contain the union of both sets of fences, so that it can be carried by
+// the merged range. Returns true iff the receiver was updated.
+//
+// Splits copy the fences overlapping the right-hand side verbatim, so merging
+// the two halves back together deduplicates identical fences.
+func (f *WriteFences) Merge(rhs *WriteFences) bool {
+	var updated bool
+	for _, fence := range rhs.Fences {
+		updated = f.Install(fence.Span, fence.Timestamp) || updated
+	}
+	return updated
+}
+
+func (f *WriteFences) sort() {
+	sort.Slice(f.Fences, func(i, j int) bool {
+		a, b := f.Fences[i].Span, f.Fences[j].Span
+		if c := a.Key.Compare(b.Key); c != 0 {
+			return c < 0
+		}
+		return a.EndKey.Compare(b.EndKey) < 0
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package roachpb
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFences(t *testing.T) {
+	ts := func(w int64) hlc.Timestamp { return hlc.Timestamp{WallTime: w} }
+
+	var f WriteFences
+	require.True(t, f.IsEmpty())
+	require.True(t, f.Install(makeSpan("c-e"), ts(10)))
+	require.True(t, f.Install(makeSpan("a-b"), ts(5)))
+	require.False(t, f.IsEmpty())
+	// Fences are kept sorted by span.
+	require.Equal(t, []WriteFence{
+		{Span: makeSpan("a-b"), Timestamp: ts(5)},
+		{Span: makeSpan("c-e"), Timestamp: ts(10)},
+	}, f.Fences)
+
+	// Re-installing a fence on the same span forwards its timestamp, but never
+	// moves it backwards.
+	require.False(t, f.Install(makeSpan("c-e"), ts(7)))
+	require.True(t, f.Install(makeSpan("c-e"), ts(20)))
+	require.Equal(t, ts(20), f.Fences[1].Timestamp)
+
+	// Writes at or below the fence timestamp are rejected.
+	for _, tc := range []struct {
+		span   Span
+		ts     hlc.Timestamp
+		reject bool
+	}{
+		{makeSpan("d"), ts(20), true},
+		{makeSpan("d"), ts(1), true},
+		{makeSpan("d"), ts(21), false},
+		{makeSpan("b-d"), ts(20), true},
+		{makeSpan("b-c"), ts(1), false},
+		{makeSpan("e-f"), ts(1), false},
+		{makeSpan("a"), ts(5), true},
+		{makeSpan("a"), ts(6), false},
+	} {
+		_, reject := f.Conflicting(tc.span, tc.ts)
+		require.Equal(t, tc.reject, reject, "%s@%s", tc.span, tc.ts)
+	}
+
+	// Clipping restricts the fences to the range bounds.
+	require.Equal(t, []WriteFence{
+		{Span: makeSpan("d-e"), Timestamp: ts(20)},
+	}, f.Clip(makeSpan("d-z")))
+
+	// Merging deduplicates identical fences.
+	rhs := WriteFences{}
+	rhs.Install(makeSpan("c-e"), ts(20))
+	require.False(t, f.Merge(&rhs))
+	rhs.Install(makeSpan("x-z"), ts(3))
+	require.True(t, f.Merge(&rhs))
+	require.Len(t, f.Fences, 3)
+
+	// Removal considers fences restricted to the range bounds, and drops fences
+	// which no longer overlap the bounds at all.
+	bounds := makeSpan("d-y")
+	require.True(t, f.Remove(makeSpan("e-f"), bounds))
+	require.Equal(t, []WriteFence{
+		{Span: makeSpan("c-e"), Timestamp: ts(20)},
+		{Span: makeSpan("x-z"), Timestamp: ts(3)},
+	}, f.Fences)
+	require.False(t, f.Remove(makeSpan("e-f"), bounds))
+	require.True(t, f.Remove(makeSpan("d-e"), bounds))
+	require.Equal(t, []WriteFence{
+		{Span: makeSpan("x-z"), Timestamp: ts(3)},
+	}, f.Fences)
+	require.True(t, f.Remove(makeSpan("x-y"), bounds))
+	require.True(t, f.IsEmpty())
+}
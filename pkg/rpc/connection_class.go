@@ -38,6 +38,10 @@ const (
 	SystemClass
 	// RangefeedClass is the ConnectionClass used for rangefeeds.
 	RangefeedClass
+	// BulkClass is the ConnectionClass used for bulk requests, such as
+	// AddSSTable and Export, whose large payloads would otherwise hold up the
+	// foreground traffic sharing their connection.
+	BulkClass
 
 	// NumConnectionClasses is the number of valid ConnectionClass values.
 	NumConnectionClasses int = iota
@@ -48,6 +52,7 @@ var connectionClassName = map[ConnectionClass]string{
 	DefaultClass:   "default",
 	SystemClass:    "system",
 	RangefeedClass: "rangefeed",
+	BulkClass:      "bulk",
 }
 
 // String implements the fmt.Stringer interface.
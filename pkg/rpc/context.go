@@ -1720,7 +1720,7 @@ func (rpcCtx *Context) dialOptsNetwork(
 
 	// Ensure the TCP link remains active so that overzealous firewalls
 	// don't shut it down.
-	dialOpts = append(dialOpts, grpc.WithKeepaliveParams(clientKeepalive))
+	dialOpts = append(dialOpts, grpc.WithKeepaliveParams(clientKeepaliveForClass(class)))
 
 	// Append a testing stream interceptor, if so configured.
 	//
@@ -1786,9 +1786,12 @@ func (rpcCtx *Context) dialOptsCommon(
 
 	// Configure the window sizes with optional env var overrides.
 	dialOpts = append(dialOpts, grpc.WithInitialConnWindowSize(rpcCtx.initialConnWindowSize(ctx)))
-	if class == RangefeedClass {
+	switch class {
+	case RangefeedClass:
 		dialOpts = append(dialOpts, grpc.WithInitialWindowSize(rpcCtx.rangefeedInitialWindowSize(ctx)))
-	} else {
+	case BulkClass:
+		dialOpts = append(dialOpts, grpc.WithInitialWindowSize(rpcCtx.bulkInitialWindowSize(ctx)))
+	default:
 		dialOpts = append(dialOpts, grpc.WithInitialWindowSize(rpcCtx.initialWindowSize(ctx)))
 	}
 	unaryInterceptors := rpcCtx.clientUnaryInterceptors
@@ -71,6 +71,28 @@ var clientKeepalive = keepalive.ClientParameters{
 	// Do the pings even when there are no ongoing RPCs.
 	PermitWithoutStream: true,
 }
+
+// streamingClientKeepaliveTimeout is the keepalive ping timeout of the
+// connections of the RangefeedClass and the BulkClass. These connections carry
+// large, sustained streams of data, behind which the acknowledgement of a ping
+// can be held up by flow control for longer than on the connections of the
+// foreground traffic, without the connection being unhealthy.
+var streamingClientKeepaliveTimeout = envutil.EnvOrDefaultDuration(
+	"COCKROACH_RPC_STREAMING_CLIENT_KEEPALIVE_TIMEOUT", 3*minimumClientKeepaliveInterval)
+
+// clientKeepaliveForClass returns the client keepalive parameters of the
+// connections of the given class.
+func clientKeepaliveForClass(class ConnectionClass) keepalive.ClientParameters {
+	switch class {
+	case RangefeedClass, BulkClass:
+		params := clientKeepalive
+		params.Timeout = streamingClientKeepaliveTimeout
+		return params
+	default:
+		return clientKeepalive
+	}
+}
+
 var serverKeepalive = keepalive.ServerParameters{
 	// Send periodic pings on the connection when there is no other traffic.
 	Time: serverKeepaliveInterval,
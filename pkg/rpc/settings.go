@@ -83,6 +83,9 @@ type windowSizeSettings struct {
 		initialConnWindowSize int32
 		// rangefeedInitialWindowSize is the initial window size for a RangeFeed RPC.
 		rangefeedInitialWindowSize int32
+		// bulkInitialWindowSize is the initial window size for an RPC of the
+		// BulkClass.
+		bulkInitialWindowSize int32
 	}
 }
 
@@ -96,6 +99,8 @@ func (s *windowSizeSettings) maybeInit(ctx context.Context) {
 		}
 		s.values.rangefeedInitialWindowSize = getWindowSize(ctx,
 			"COCKROACH_RANGEFEED_RPC_INITIAL_WINDOW_SIZE", RangefeedClass, 2*defaultWindowSize /* 128KB */)
+		s.values.bulkInitialWindowSize = getWindowSize(ctx,
+			"COCKROACH_BULK_RPC_INITIAL_WINDOW_SIZE", BulkClass, defaultInitialWindowSize)
 	})
 }
 
@@ -117,6 +122,12 @@ func (s *windowSizeSettings) rangefeedInitialWindowSize(ctx context.Context) int
 	return s.values.rangefeedInitialWindowSize
 }
 
+// For an RPC of the BulkClass.
+func (s *windowSizeSettings) bulkInitialWindowSize(ctx context.Context) int32 {
+	s.maybeInit(ctx)
+	return s.values.bulkInitialWindowSize
+}
+
 // sourceAddr is the environment-provided local address for outgoing
 // connections.
 var sourceAddr = func() net.Addr {
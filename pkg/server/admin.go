@@ -16,6 +16,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"sort"
 	"strconv"
@@ -3331,6 +3332,408 @@ func (s *systemAdminServer) enqueueRangeLocal(
 	return response, nil
 }
 
+// SetQueuePaused pauses or resumes the specified queue on the specified
+// store(s), for a single range or for all ranges, recording the change in the
+// structured event log.
+func (s *systemAdminServer) SetQueuePaused(
+	ctx context.Context, req *serverpb.SetQueuePausedRequest,
+) (*serverpb.SetQueuePausedResponse, error) {
+	ctx = authserver.ForwardSQLIdentityThroughRPCCalls(ctx)
+	ctx = s.AnnotateCtx(ctx)
+
+	if err := s.privilegeChecker.RequireRepairClusterMetadataPermission(ctx); err != nil {
+		// NB: not using srverrors.ServerError() here since the priv checker
+		// already returns a proper gRPC error status.
+		return nil, err
+	}
+
+	if req.NodeID < 0 {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "node_id must be non-negative; got %d", req.NodeID)
+	}
+	if req.StoreID < 0 {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "store_id must be non-negative; got %d", req.StoreID)
+	}
+	if req.StoreID != 0 && req.NodeID == 0 {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "node_id must be specified along with store_id")
+	}
+	if req.Queue == "" {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "queue name must be non-empty")
+	}
+	if req.RangeID < 0 {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "range_id must be non-negative; got %d", req.RangeID)
+	}
+	if req.Paused && req.Reason == "" {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "a reason must be given when pausing a queue")
+	}
+
+	// If the request is targeted at this node, serve it directly. Otherwise,
+	// forward it to the appropriate node(s). If no node was specified, forward
+	// it to all nodes.
+	if req.NodeID == roachpb.NodeID(s.serverIterator.getID()) {
+		response, err := s.setQueuePausedLocal(ctx, req)
+		if err != nil {
+			return nil, srverrors.ServerError(ctx, err)
+		}
+		return response, nil
+	} else if req.NodeID != 0 {
+		admin, err := s.dialNode(ctx, req.NodeID)
+		if err != nil {
+			return nil, srverrors.ServerError(ctx, err)
+		}
+		return admin.SetQueuePaused(ctx, req)
+	}
+
+	response := &serverpb.SetQueuePausedResponse{}
+
+	dialFn := func(ctx context.Context, nodeID roachpb.NodeID) (interface{}, error) {
+		client, err := s.dialNode(ctx, nodeID)
+		return client, err
+	}
+	nodeFn := func(ctx context.Context, client interface{}, nodeID roachpb.NodeID) (interface{}, error) {
+		admin := client.(serverpb.AdminClient)
+		req := *req
+		req.NodeID = nodeID
+		return admin.SetQueuePaused(ctx, &req)
+	}
+	responseFn := func(_ roachpb.NodeID, nodeResp interface{}) {
+		nodeDetails := nodeResp.(*serverpb.SetQueuePausedResponse)
+		response.Details = append(response.Details, nodeDetails.Details...)
+	}
+	errorFn := func(nodeID roachpb.NodeID, err error) {
+		errDetail := &serverpb.SetQueuePausedResponse_Details{
+			NodeID: nodeID,
+			Error:  err.Error(),
+		}
+		response.Details = append(response.Details, errDetail)
+	}
+
+	if err := timeutil.RunWithTimeout(ctx, "set queue paused", time.Minute, func(ctx context.Context) error {
+		return iterateNodes(
+			ctx, s.serverIterator, s.server.stopper, fmt.Sprintf("set paused=%t in queue %s", req.Paused, req.Queue),
+			noTimeout,
+			dialFn, nodeFn, responseFn, errorFn,
+		)
+	}); err != nil {
+		if len(response.Details) == 0 {
+			return nil, srverrors.ServerError(ctx, err)
+		}
+		response.Details = append(response.Details, &serverpb.SetQueuePausedResponse_Details{
+			Error: err.Error(),
+		})
+	}
+
+	return response, nil
+}
+
+// setQueuePausedLocal pauses or resumes the requested queue on the requested
+// local store, or on all local stores if none was requested, emitting a
+// structured event for every store on which the state of the queue changed.
+//
+// Note that the function returns plain errors, and it is the caller's
+// responsibility to convert them to srverrors.ServerErrors.
+func (s *systemAdminServer) setQueuePausedLocal(
+	ctx context.Context, req *serverpb.SetQueuePausedRequest,
+) (*serverpb.SetQueuePausedResponse, error) {
+	user, err := authserver.UserFromIncomingRPCContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeID := roachpb.NodeID(s.serverIterator.getID())
+	response := &serverpb.SetQueuePausedResponse{}
+	if err := s.server.node.stores.VisitStores(func(store *kvserver.Store) error {
+		if req.StoreID != 0 && req.StoreID != store.StoreID() {
+			return nil
+		}
+		details := &serverpb.SetQueuePausedResponse_Details{
+			NodeID:  nodeID,
+			StoreID: store.StoreID(),
+		}
+		response.Details = append(response.Details, details)
+
+		changed, err := store.SetQueuePaused(ctx, req.Queue, req.RangeID, req.Paused, req.Reason)
+		if err != nil {
+			details.Error = err.Error()
+			return nil
+		}
+		details.Changed = changed
+		if changed {
+			log.StructuredEvent(ctx, &eventpb.DebugSetQueuePaused{
+				CommonEventDetails: logpb.CommonEventDetails{
+					Timestamp: timeutil.Now().UnixNano(),
+				},
+				CommonDebugEventDetails: eventpb.CommonDebugEventDetails{
+					NodeID: int32(nodeID),
+					User:   user.Normalized(),
+				},
+				StoreID: int64(store.StoreID()),
+				Queue:   req.Queue,
+				RangeID: int64(req.RangeID),
+				Paused:  req.Paused,
+				Reason:  req.Reason,
+			})
+		}
+		for _, p := range store.QueuePauses() {
+			if !strings.EqualFold(p.Queue, req.Queue) {
+				continue
+			}
+			details.Pauses = append(details.Pauses, serverpb.SetQueuePausedResponse_QueuePause{
+				Queue:   p.Queue,
+				RangeID: p.RangeID,
+				Since:   p.Since,
+				Reason:  p.Reason,
+			})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if req.StoreID != 0 && len(response.Details) == 0 {
+		response.Details = append(response.Details, &serverpb.SetQueuePausedResponse_Details{
+			NodeID:  nodeID,
+			StoreID: req.StoreID,
+			Error:   fmt.Sprintf("n%d has no store s%d", nodeID, req.StoreID),
+		})
+	}
+	return response, nil
+}
+
+// SetConsistencyCheckPriority sets the priority with which the consistency
+// checker checks the ranges of a database on the specified node(s). The
+// priorities aren't persisted: they're lost when a node restarts.
+func (s *systemAdminServer) SetConsistencyCheckPriority(
+	ctx context.Context, req *serverpb.SetConsistencyCheckPriorityRequest,
+) (*serverpb.SetConsistencyCheckPriorityResponse, error) {
+	ctx = authserver.ForwardSQLIdentityThroughRPCCalls(ctx)
+	ctx = s.AnnotateCtx(ctx)
+
+	if err := s.privilegeChecker.RequireRepairClusterMetadataPermission(ctx); err != nil {
+		// NB: not using srverrors.ServerError() here since the priv checker
+		// already returns a proper gRPC error status.
+		return nil, err
+	}
+
+	if req.NodeID < 0 {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "node_id must be non-negative; got %d", req.NodeID)
+	}
+	if req.Database == "" {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "database name must be non-empty")
+	}
+	if req.Priority < 0 || math.IsNaN(req.Priority) || math.IsInf(req.Priority, 0) {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "priority must be a non-negative number; got %f", req.Priority)
+	}
+
+	// If the request is targeted at this node, serve it directly. Otherwise,
+	// forward it to the appropriate node(s). If no node was specified, forward
+	// it to all nodes.
+	if req.NodeID == roachpb.NodeID(s.serverIterator.getID()) {
+		response, err := s.setConsistencyCheckPriorityLocal(ctx, req)
+		if err != nil {
+			return nil, srverrors.ServerError(ctx, err)
+		}
+		return response, nil
+	} else if req.NodeID != 0 {
+		admin, err := s.dialNode(ctx, req.NodeID)
+		if err != nil {
+			return nil, srverrors.ServerError(ctx, err)
+		}
+		return admin.SetConsistencyCheckPriority(ctx, req)
+	}
+
+	response := &serverpb.SetConsistencyCheckPriorityResponse{}
+
+	dialFn := func(ctx context.Context, nodeID roachpb.NodeID) (interface{}, error) {
+		client, err := s.dialNode(ctx, nodeID)
+		return client, err
+	}
+	nodeFn := func(ctx context.Context, client interface{}, nodeID roachpb.NodeID) (interface{}, error) {
+		admin := client.(serverpb.AdminClient)
+		req := *req
+		req.NodeID = nodeID
+		return admin.SetConsistencyCheckPriority(ctx, &req)
+	}
+	responseFn := func(_ roachpb.NodeID, nodeResp interface{}) {
+		nodeDetails := nodeResp.(*serverpb.SetConsistencyCheckPriorityResponse)
+		response.Details = append(response.Details, nodeDetails.Details...)
+	}
+	errorFn := func(nodeID roachpb.NodeID, err error) {
+		errDetail := &serverpb.SetConsistencyCheckPriorityResponse_Details{
+			NodeID: nodeID,
+			Error:  err.Error(),
+		}
+		response.Details = append(response.Details, errDetail)
+	}
+
+	if err := timeutil.RunWithTimeout(ctx, "set consistency check priority", time.Minute, func(ctx context.Context) error {
+		return iterateNodes(
+			ctx, s.serverIterator, s.server.stopper,
+			fmt.Sprintf("set consistency check priority of database %s", req.Database),
+			noTimeout,
+			dialFn, nodeFn, responseFn, errorFn,
+		)
+	}); err != nil {
+		if len(response.Details) == 0 {
+			return nil, srverrors.ServerError(ctx, err)
+		}
+		response.Details = append(response.Details, &serverpb.SetConsistencyCheckPriorityResponse_Details{
+			Error: err.Error(),
+		})
+	}
+
+	return response, nil
+}
+
+// setConsistencyCheckPriorityLocal sets the priority of the requested database
+// in the consistency checker of all local stores.
+//
+// Note that the function returns plain errors, and it is the caller's
+// responsibility to convert them to srverrors.ServerErrors.
+func (s *systemAdminServer) setConsistencyCheckPriorityLocal(
+	ctx context.Context, req *serverpb.SetConsistencyCheckPriorityRequest,
+) (*serverpb.SetConsistencyCheckPriorityResponse, error) {
+	user, err := authserver.UserFromIncomingRPCContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	priority := kvserver.ConsistencyCheckPriority{Name: req.Database, Priority: req.Priority}
+	// A priority being removed doesn't need the spans, and the database may
+	// have been dropped already.
+	if req.Priority != 0 {
+		if priority.Spans, err = s.queryDatabaseSpans(ctx, user, req.Database); err != nil {
+			return nil, err
+		}
+	}
+
+	nodeID := roachpb.NodeID(s.serverIterator.getID())
+	response := &serverpb.SetConsistencyCheckPriorityResponse{}
+	if err := s.server.node.stores.VisitStores(func(store *kvserver.Store) error {
+		store.SetConsistencyCheckPriority(ctx, priority)
+		details := &serverpb.SetConsistencyCheckPriorityResponse_Details{
+			NodeID:  nodeID,
+			StoreID: store.StoreID(),
+		}
+		for _, p := range store.ConsistencyCheckPriorities() {
+			details.Priorities = append(details.Priorities, serverpb.SetConsistencyCheckPriorityResponse_Priority{
+				Database: p.Name,
+				Priority: p.Priority,
+			})
+		}
+		response.Details = append(response.Details, details)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// queryDatabaseSpans returns the spans of the tables of the database.
+//
+// Note that the function returns plain errors, and it is the caller's
+// responsibility to convert them to srverrors.ServerErrors.
+func (s *adminServer) queryDatabaseSpans(
+	ctx context.Context, userName username.SQLUsername, database string,
+) (roachpb.Spans, error) {
+	rows, err := s.internalExecutor.QueryBufferedEx(
+		ctx, "admin-database-spans", nil, /* txn */
+		sessiondata.InternalExecutorOverride{User: userName},
+		`SELECT table_id FROM crdb_internal.tables WHERE database_name = $1 AND drop_time IS NULL`,
+		database,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.Newf("database %q does not exist or has no tables", database)
+	}
+	spans := make(roachpb.Spans, 0, len(rows))
+	for _, row := range rows {
+		tableID := descpb.ID(tree.MustBeDInt(row[0]))
+		spans = append(spans, generateTableSpan(tableID, s.sqlServer.execCfg.Codec))
+	}
+	return spans, nil
+}
+
+// CheckRangeConsistency runs a full consistency check of the specified range
+// on the specified node, returning a structured report of the results.
+func (s *systemAdminServer) CheckRangeConsistency(
+	ctx context.Context, req *serverpb.CheckRangeConsistencyRequest,
+) (*serverpb.CheckRangeConsistencyResponse, error) {
+	ctx = authserver.ForwardSQLIdentityThroughRPCCalls(ctx)
+	ctx = s.AnnotateCtx(ctx)
+
+	if err := s.privilegeChecker.RequireRepairClusterMetadataPermission(ctx); err != nil {
+		// NB: not using srverrors.ServerError() here since the priv checker
+		// already returns a proper gRPC error status.
+		return nil, err
+	}
+
+	if req.NodeID < 0 {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "node_id must be non-negative; got %d", req.NodeID)
+	}
+	if req.RangeID <= 0 {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "range_id must be positive; got %d", req.RangeID)
+	}
+
+	if req.NodeID != 0 && req.NodeID != roachpb.NodeID(s.serverIterator.getID()) {
+		admin, err := s.dialNode(ctx, req.NodeID)
+		if err != nil {
+			return nil, srverrors.ServerError(ctx, err)
+		}
+		return admin.CheckRangeConsistency(ctx, req)
+	}
+
+	nodeID := roachpb.NodeID(s.serverIterator.getID())
+	var repl *kvserver.Replica
+	if err := s.server.node.stores.VisitStores(func(store *kvserver.Store) error {
+		if r := store.GetReplicaIfExists(req.RangeID); r != nil {
+			repl = r
+		}
+		return nil
+	}); err != nil {
+		return nil, srverrors.ServerError(ctx, err)
+	}
+	if repl == nil {
+		return nil, grpcstatus.Errorf(codes.NotFound, "n%d has no replica for r%d", nodeID, req.RangeID)
+	}
+
+	report, err := repl.CheckConsistencyReport(ctx)
+	if err != nil {
+		return nil, srverrors.ServerError(ctx, err)
+	}
+	response := &serverpb.CheckRangeConsistencyResponse{
+		NodeID:         nodeID,
+		RangeID:        report.RangeID,
+		Status:         report.Status,
+		KeyDiffSkipped: report.KeyDiffSkipped,
+	}
+	for _, r := range report.Replicas {
+		replica := serverpb.CheckRangeConsistencyResponse_Replica{
+			Replica:   r.Replica,
+			Checksum:  r.Checksum,
+			Minority:  r.Minority,
+			Persisted: r.Persisted,
+			Delta:     r.Delta,
+			Diff:      r.Diff,
+		}
+		for _, d := range r.KeyDiff {
+			replica.KeyDiff = append(replica.KeyDiff, serverpb.CheckRangeConsistencyResponse_KeyDiff{
+				Majority:  d.Majority,
+				Key:       d.Key,
+				EndKey:    d.EndKey,
+				Timestamp: d.Timestamp,
+				Value:     d.Value,
+			})
+		}
+		if r.Err != nil {
+			replica.Error = r.Err.Error()
+		}
+		response.Replicas = append(response.Replicas, replica)
+	}
+	return response, nil
+}
+
 // SendKVBatch proxies the given BatchRequest into KV, returning the
 // response. It is for use by the CLI `debug send-kv-batch` command.
 func (s *systemAdminServer) SendKVBatch(
@@ -3463,6 +3866,70 @@ func (s *systemAdminServer) RecoveryVerify(
 	return s.server.recoveryServer.Verify(ctx, request, s.nodeLiveness, s.db)
 }
 
+// RangesInfo streams the descriptor, lease, lease applied index and MVCC stats
+// of every range overlapping the requested span. Ranges are streamed in pages
+// of at most the requested page size; the stats for each page are collected
+// using a single batch of RangeStats requests.
+func (s *systemAdminServer) RangesInfo(
+	req *serverpb.RangesInfoRequest, stream serverpb.Admin_RangesInfoServer,
+) error {
+	ctx := stream.Context()
+	ctx = s.server.AnnotateCtx(ctx)
+	err := s.privilegeChecker.RequireViewClusterMetadataPermission(ctx)
+	if err != nil {
+		return err
+	}
+
+	span := roachpb.Span{Key: req.StartKey, EndKey: req.EndKey}
+	if len(span.EndKey) == 0 {
+		span.EndKey = roachpb.KeyMax
+	}
+	if !span.Valid() {
+		return grpcstatus.Errorf(codes.InvalidArgument, "invalid span %s", span)
+	}
+	pageSize := int(req.PageSize)
+	if pageSize <= 0 {
+		pageSize = int(RangeDescPageSize.Get(&s.st.SV))
+	}
+
+	// The scanner retries its transaction on failure, replaying pages which may
+	// already have been streamed. Skip ranges which end at or before the last
+	// range sent.
+	var sent roachpb.RKey
+	fetcher := s.server.sqlServer.execCfg.RangeStatsFetcher
+	scanner := rangedesc.NewScanner(s.db)
+	return scanner.Scan(ctx, pageSize, func() {}, span, func(descs ...roachpb.RangeDescriptor) error {
+		startKeys := make([]roachpb.Key, 0, len(descs))
+		for i := range descs {
+			if sent != nil && descs[i].EndKey.Compare(sent) <= 0 {
+				continue
+			}
+			startKeys = append(startKeys, descs[i].StartKey.AsRawKey())
+		}
+		if len(startKeys) == 0 {
+			return nil
+		}
+		stats, err := fetcher.RangeStats(ctx, startKeys...)
+		if err != nil {
+			return err
+		}
+		resp := &serverpb.RangesInfoResponse{
+			Ranges: make([]serverpb.RangesInfoResponse_Range, 0, len(stats)),
+		}
+		for _, rs := range stats {
+			resp.Ranges = append(resp.Ranges, serverpb.RangesInfoResponse_Range{
+				Desc:              rs.RangeInfo.Desc,
+				Lease:             rs.RangeInfo.Lease,
+				LeaseAppliedIndex: rs.LeaseAppliedIndex,
+				Stats:             rs.MVCCStats,
+			})
+		}
+		sent = descs[len(descs)-1].EndKey
+		resp.ResumeKey = sent.AsRawKey()
+		return stream.Send(resp)
+	})
+}
+
 // resultScanner scans columns from sql.ResultRow instances into variables,
 // performing the appropriate casting and error detection along the way.
 type resultScanner struct {
@@ -759,6 +759,7 @@ func (cfg *Config) CreateEngines(ctx context.Context) (Engines, error) {
 		storageConfigOpts := []storage.ConfigOption{
 			storage.Attributes(spec.Attributes),
 			storage.EncryptionAtRest(spec.EncryptionOptions),
+			storage.NonVoting(spec.NonVoting),
 			storage.If(storeKnobs.SmallEngineBlocks, storage.BlockSize(1)),
 		}
 		if len(storeKnobs.EngineKnobs) > 0 {
@@ -406,6 +406,9 @@ type Node struct {
 	// Used to collect samples for the key visualizer.
 	spanStatsCollector *spanstatscollector.SpanStatsCollector
 
+	// Used to isolate the range lookups of tenants from each other.
+	tenantRangeLookups *tenantRangeLookups
+
 	// versionUpdateMu is used by the TenantSettings endpoint
 	// to inform tenant servers of storage version changes.
 	versionUpdateMu struct {
@@ -582,6 +585,7 @@ func NewNode(
 		spanConfigReporter:    spanConfigReporter,
 		testingErrorEvent:     cfg.TestingKnobs.TestingResponseErrorEvent,
 		spanStatsCollector:    spanstatscollector.New(cfg.Settings),
+		tenantRangeLookups:    newTenantRangeLookups(cfg.Settings, stopper, reg),
 	}
 	n.versionUpdateMu.updateCh = make(chan struct{})
 	n.perReplicaServer = kvserver.MakeServer(&n.Descriptor, n.stores)
@@ -1679,23 +1683,32 @@ func (n *Node) RangeLookup(
 	// cache in order to avoid serving the same stale descriptor over and over
 	// again. Because of that, using our own cache doesn't seem worth it, at
 	// least for now.
-	sender := n.storeCfg.DB.NonTransactionalSender()
-	rs, preRs, err := kv.RangeLookup(
-		ctx,
-		sender,
-		req.Key.AsRawKey(),
-		req.ReadConsistency,
-		req.PrefetchNum,
-		req.PrefetchReverse,
-	)
-	resp := new(kvpb.RangeLookupResponse)
-	if err != nil {
-		resp.Error = kvpb.NewError(err)
-	} else {
-		resp.Descriptors = rs
-		resp.PrefetchedDescriptors = filterRangeLookupResponseForTenant(ctx, preRs)
+	lookup := func(ctx context.Context) (*kvpb.RangeLookupResponse, error) {
+		sender := n.storeCfg.DB.NonTransactionalSender()
+		rs, preRs, err := kv.RangeLookup(
+			ctx,
+			sender,
+			req.Key.AsRawKey(),
+			req.ReadConsistency,
+			req.PrefetchNum,
+			req.PrefetchReverse,
+		)
+		resp := new(kvpb.RangeLookupResponse)
+		if err != nil {
+			resp.Error = kvpb.NewError(err)
+		} else {
+			resp.Descriptors = rs
+			resp.PrefetchedDescriptors = filterRangeLookupResponseForTenant(ctx, preRs)
+		}
+		return resp, nil
+	}
+	// The lookups of secondary tenants are isolated from each other, so that a
+	// tenant can't block the lookups of the others served by this node.
+	tenID, ok := roachpb.ClientTenantFromContext(ctx)
+	if !ok || tenID.IsSystem() {
+		return lookup(ctx)
 	}
-	return resp, nil
+	return n.tenantRangeLookups.do(ctx, tenID, req, lookup)
 }
 
 // RangeFeed implements the roachpb.InternalServer interface.
@@ -67,6 +67,7 @@ func nodeStatusToResp(n *statuspb.NodeStatus, hasViewClusterMetadata bool) serve
 				Properties: roachpb.StoreProperties{
 					ReadOnly:  ss.Desc.Properties.ReadOnly,
 					Encrypted: ss.Desc.Properties.Encrypted,
+					NonVoting: ss.Desc.Properties.NonVoting,
 				},
 			},
 			Metrics: ss.Metrics,
@@ -36,6 +36,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/keyvisualizer/spanstatskvaccessor"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvcoord"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/nodedescs"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/rangefeed"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/rangestats"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
@@ -192,6 +193,11 @@ type topLevelServer struct {
 	kvProber       *kvprober.Prober
 	inspectzServer *inspectz.Server
 
+	// nodeDescs serves node and store descriptors to the DistSender, either
+	// from gossip or from a rangefeed over the node status records.
+	nodeDescs        *nodedescs.FallbackStore
+	rangeFeedFactory *rangefeed.Factory
+
 	replicationReporter *reports.Reporter
 	protectedtsProvider protectedts.Provider
 
@@ -447,17 +453,26 @@ func NewServer(cfg Config, stopper *stop.Stopper) (serverctl.ServerStartupInterf
 		retryOpts = base.DefaultRetryOptions()
 	}
 	retryOpts.Closer = stopper.ShouldQuiesce()
+	// The rangefeed-backed descriptor source is started in PreStart, once KV
+	// is available; until then (and for any descriptors it has not yet
+	// observed) lookups fall back to gossip.
+	nodeDescs := nodedescs.NewFallbackStore(st, nodedescs.NewStore(clock), g)
+	appRegistry.AddMetricStruct(nodeDescs.Metrics())
+	// The hash-sharded spans are maintained from the span configs, once the
+	// KVSubscriber is created below.
+	hashShardedSpans := kvserverbase.NewHashShardedSpansWatcher()
 	distSenderCfg := kvcoord.DistSenderConfig{
 		AmbientCtx:         cfg.AmbientCtx,
 		Settings:           st,
 		Clock:              clock,
-		NodeDescs:          g,
+		NodeDescs:          nodeDescs,
 		Stopper:            stopper,
 		LatencyFunc:        rpcContext.RemoteClocks.Latency,
 		RPCRetryOptions:    &retryOpts,
 		TransportFactory:   kvcoord.GRPCTransportFactory(kvNodeDialer),
 		FirstRangeProvider: g,
 		Locality:           cfg.Locality,
+		HashShardedSpans:   hashShardedSpans,
 		TestingKnobs:       clientTestingKnobs,
 		HealthFunc: func(id roachpb.NodeID) bool {
 			return livenessCache.GetNodeVitality(id).IsLive(livenesspb.DistSender)
@@ -686,6 +701,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (serverctl.ServerStartupInterf
 		histogramWindowInterval: cfg.HistogramWindowInterval(),
 		settings:                cfg.Settings,
 	})
+	distSender.SetMemoryMonitor(sqlMonitorAndMetrics.rootSQLMemoryMonitor)
 	kvMemoryMonitor := mon.NewMonitorInheritWithLimit(
 		"kv-mem", 0 /* limit */, sqlMonitorAndMetrics.rootSQLMemoryMonitor)
 	kvMemoryMonitor.StartNoReserved(ctx, sqlMonitorAndMetrics.rootSQLMemoryMonitor)
@@ -789,6 +805,9 @@ func NewServer(cfg Config, stopper *stop.Stopper) (serverctl.ServerStartupInterf
 			nodeRegistry,
 		)
 	}
+	if src, ok := spanConfig.subscriber.(kvserverbase.HashShardedSpansSource); ok {
+		hashShardedSpans.Start(src)
+	}
 
 	scKVAccessor := spanconfigkvaccessor.New(
 		db, internalExecutor, cfg.Settings, clock,
@@ -829,6 +848,16 @@ func NewServer(cfg Config, stopper *stop.Stopper) (serverctl.ServerStartupInterf
 			uint64(kvserver.EagerLeaseAcquisitionConcurrency.Get(&cfg.Settings.SV)))
 	})
 
+	// Keep the range cache of the node, which the DistSender and the SQL layer
+	// use to plan requests, up to date with the splits and merges applied by
+	// the replicas of the node, rather than waiting for requests to run into
+	// stale descriptors.
+	applyTriggers := kvserverbase.NewApplyTriggerRegistry()
+	applyTriggers.RegisterSplitMergeObserver(
+		func(ctx context.Context, ev kvserverbase.SplitMergeEvent) {
+			distSender.RangeDescriptorCache().Insert(ctx, ev.Ranges...)
+		})
+
 	storeCfg := kvserver.StoreConfig{
 		DefaultSpanConfig:            cfg.DefaultZoneConfig.AsSpanConfig(),
 		Settings:                     st,
@@ -858,9 +887,11 @@ func NewServer(cfg Config, stopper *stop.Stopper) (serverctl.ServerStartupInterf
 		SharedStorageEnabled:         cfg.SharedStorage != "",
 		SystemConfigProvider:         systemConfigWatcher,
 		SpanConfigSubscriber:         spanConfig.subscriber,
+		HashShardedSpans:             hashShardedSpans,
 		SnapshotApplyLimit:           cfg.SnapshotApplyLimit,
 		SnapshotSendLimit:            cfg.SnapshotSendLimit,
 		RangeLogWriter:               rangeLogWriter,
+		ApplyTriggers:                applyTriggers,
 		KVAdmissionController:        admissionControl.kvAdmissionController,
 		KVFlowController:             admissionControl.kvflowController,
 		KVFlowHandles:                admissionControl.storesFlowControl,
@@ -1100,6 +1131,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (serverctl.ServerStartupInterf
 		cfg.BaseConfig.AmbientCtx,
 		node.storeCfg.KVFlowHandles,
 		node.storeCfg.KVFlowController,
+		stores,
 	)
 
 	// Instantiate the SQL server proper.
@@ -1127,7 +1159,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (serverctl.ServerStartupInterf
 		clock:                    clock,
 		runtime:                  runtimeSampler,
 		rpcContext:               rpcContext,
-		nodeDescs:                g,
+		nodeDescs:                nodeDescs,
 		systemConfigWatcher:      systemConfigWatcher,
 		spanConfigAccessor:       spanConfig.kvAccessor,
 		keyVisServerAccessor:     keyVisServerAccessor,
@@ -1295,6 +1327,8 @@ func NewServer(cfg Config, stopper *stop.Stopper) (serverctl.ServerStartupInterf
 		stopTrigger:               stopTrigger,
 		debug:                     debugServer,
 		kvProber:                  kvProber,
+		nodeDescs:                 nodeDescs,
+		rangeFeedFactory:          rangeFeedFactory,
 		replicationReporter:       replicationReporter,
 		protectedtsProvider:       protectedtsProvider,
 		spanConfigSubscriber:      spanConfig.subscriber,
@@ -2172,6 +2206,10 @@ func (s *topLevelServer) PreStart(ctx context.Context) error {
 		return errors.Wrapf(err, "failed to start KV prober")
 	}
 
+	if err := s.nodeDescs.Rangefeed().Start(workersCtx, s.stopper, s.rangeFeedFactory); err != nil {
+		return errors.Wrapf(err, "failed to start node descriptor rangefeed")
+	}
+
 	// Perform loss of quorum recovery cleanup if any actions were scheduled.
 	// Cleanup actions rely on node being connected to the cluster and hopefully
 	// in a healthy or healthier stats to update node liveness records.
@@ -982,7 +982,9 @@ func newSQLServer(ctx context.Context, cfg sqlServerArgs) (*SQLServer, error) {
 		TestingKnobs:                sqlExecutorTestingKnobs,
 		CompactEngineSpanFunc:       storageEngineClient.CompactEngineSpan,
 		CompactionConcurrencyFunc:   storageEngineClient.SetCompactionConcurrency,
+		SetProposalQuotaFunc:        storageEngineClient.SetProposalQuota,
 		GetTableMetricsFunc:         storageEngineClient.GetTableMetrics,
+		GetSpanEncryptionStatsFunc:  storageEngineClient.GetSpanEncryptionStats,
 		ScanStorageInternalKeysFunc: storageEngineClient.ScanStorageInternalKeys,
 		TraceCollector:              traceCollector,
 		TenantUsageServer:           cfg.tenantUsageServer,
@@ -1032,6 +1034,7 @@ func newSQLServer(ctx context.Context, cfg sqlServerArgs) (*SQLServer, error) {
 		RangeProber:                rangeprober.NewRangeProber(cfg.db),
 		DescIDGenerator:            descidgen.NewGenerator(cfg.Settings, codec, cfg.db),
 		RangeStatsFetcher:          rangeStatsFetcher,
+		OnePhaseCommitStats:        sql.NewOnePhaseCommitStats(),
 		EventsExporter:             cfg.eventsExporter,
 		NodeDescs:                  cfg.nodeDescs,
 		TenantCapabilitiesReader:   cfg.tenantCapabilitiesReader,
@@ -2322,6 +2322,10 @@ func (s *systemStatusServer) rangesHelper(
 		}
 
 		loadStats := rep.LoadStats()
+		sharedBytes, localBytes, err := rep.SharedStorageBytes()
+		if err != nil {
+			log.Warningf(ctx, "r%d: unable to compute shared storage bytes: %v", rep.RangeID, err)
+		}
 		locality := serverpb.Locality{}
 		for _, tier := range rep.GetNodeLocality().Tiers {
 			locality.Tiers = append(locality.Tiers, serverpb.Tier{
@@ -2344,6 +2348,10 @@ func (s *systemStatusServer) rangesHelper(
 				WriteBytesPerSecond: loadStats.WriteBytesPerSecond,
 				ReadBytesPerSecond:  loadStats.ReadBytesPerSecond,
 				CPUTimePerSecond:    loadStats.RaftCPUNanosPerSecond + loadStats.RequestCPUNanosPerSecond,
+
+				ProposalToApplyLatencyP99Nanos: rep.ProposalToApplyLatencyP99().Nanoseconds(),
+				SharedBytes:                    sharedBytes,
+				LocalBytes:                     localBytes,
 			},
 			Problems: serverpb.RangeProblems{
 				Unavailable:            metrics.Unavailable,
@@ -3693,6 +3701,64 @@ func (s *systemStatusServer) Stores(
 	return resp, nil
 }
 
+// ClosedTimestampHistory returns the closed timestamp history of the replicas
+// of a range on a node.
+func (s *systemStatusServer) ClosedTimestampHistory(
+	ctx context.Context, req *serverpb.ClosedTimestampHistoryRequest,
+) (*serverpb.ClosedTimestampHistoryResponse, error) {
+	ctx = authserver.ForwardSQLIdentityThroughRPCCalls(ctx)
+	ctx = s.AnnotateCtx(ctx)
+
+	if err := s.privilegeChecker.RequireViewClusterMetadataPermission(ctx); err != nil {
+		// NB: not using srverrors.ServerError() here since the priv checker
+		// already returns a proper gRPC error status.
+		return nil, err
+	}
+
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+	if req.RangeID <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid range ID %d", req.RangeID)
+	}
+
+	if !local {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, srverrors.ServerError(ctx, err)
+		}
+		return status.ClosedTimestampHistory(ctx, req)
+	}
+
+	resp := &serverpb.ClosedTimestampHistoryResponse{
+		NodeID:  nodeID,
+		RangeID: req.RangeID,
+	}
+	if err := s.stores.VisitStores(func(store *kvserver.Store) error {
+		repl := store.GetReplicaIfExists(req.RangeID)
+		if repl == nil {
+			return nil
+		}
+		replica := serverpb.ClosedTimestampHistoryResponse_Replica{StoreID: store.StoreID()}
+		for _, sample := range repl.ClosedTimestampHistory(req.Since) {
+			replica.Samples = append(replica.Samples, serverpb.ClosedTimestampHistoryResponse_Sample{
+				Time:       sample.At,
+				Policy:     sample.Policy,
+				Target:     sample.Target,
+				Closed:     sample.Closed,
+				ClosedLag:  sample.ClosedLag(),
+				TrackerLag: sample.TrackerLag,
+			})
+		}
+		resp.Replicas = append(resp.Replicas, replica)
+		return nil
+	}); err != nil {
+		return nil, srverrors.ServerError(ctx, err)
+	}
+	return resp, nil
+}
+
 // jsonWrapper provides a wrapper on any slice data type being
 // marshaled to JSON. This prevents a security vulnerability
 // where a phishing attack can trick a user's browser into
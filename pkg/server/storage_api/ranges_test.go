@@ -12,6 +12,7 @@ package storage_api_test
 
 import (
 	"context"
+	"io"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
@@ -193,3 +194,47 @@ func TestRangeResponse(t *testing.T) {
 		t.Error("expected at least one lease history entry")
 	}
 }
+
+func TestRangesInfo(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	srv := serverutils.StartServerOnly(t, base.TestServerArgs{
+		DefaultTestTenant: base.TestIsSpecificToStorageLayerAndNeedsASystemTenant,
+	})
+	defer srv.Stopper().Stop(ctx)
+	ts := srv.SystemLayer()
+
+	const pageSize = 5
+	stream, err := ts.GetAdminClient(t).RangesInfo(ctx, &serverpb.RangesInfoRequest{
+		PageSize: pageSize,
+	})
+	require.NoError(t, err)
+
+	var ranges []serverpb.RangesInfoResponse_Range
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.Ranges)
+		require.LessOrEqual(t, len(resp.Ranges), pageSize)
+		last := resp.Ranges[len(resp.Ranges)-1]
+		require.Equal(t, last.Desc.EndKey.AsRawKey(), resp.ResumeKey)
+		ranges = append(ranges, resp.Ranges...)
+	}
+	require.Greater(t, len(ranges), pageSize)
+
+	// The ranges cover the keyspace without gaps, and each was reported by its
+	// leaseholder.
+	require.Equal(t, roachpb.RKeyMin, ranges[0].Desc.StartKey)
+	require.Equal(t, roachpb.RKeyMax, ranges[len(ranges)-1].Desc.EndKey)
+	for i, r := range ranges {
+		if i > 0 {
+			require.Equal(t, ranges[i-1].Desc.EndKey, r.Desc.StartKey)
+		}
+		require.False(t, r.Lease.Empty())
+		require.NotZero(t, r.LeaseAppliedIndex)
+	}
+}
@@ -1298,6 +1298,7 @@ func makeTenantSQLServerArgs(
 		histogramWindowInterval: baseCfg.HistogramWindowInterval(),
 		settings:                baseCfg.Settings,
 	})
+	ds.SetMemoryMonitor(monitorAndMetrics.rootSQLMemoryMonitor)
 	remoteFlowRunnerAcc := monitorAndMetrics.rootSQLMemoryMonitor.MakeBoundAccount()
 	remoteFlowRunner := flowinfra.NewRemoteFlowRunner(baseCfg.AmbientCtx, stopper, &remoteFlowRunnerAcc)
 
@@ -0,0 +1,171 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/limit"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil/singleflight"
+)
+
+// tenantRangeLookupConcurrency bounds the number of range lookups of each
+// tenant which a node serves concurrently.
+var tenantRangeLookupConcurrency = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"server.tenant_range_lookup.max_concurrency",
+	"maximum number of range lookups of each tenant which a node serves "+
+		"concurrently (0 disables the limit)",
+	64,
+	settings.NonNegativeInt,
+)
+
+var (
+	metaTenantRangeLookupsCoalesced = metric.Metadata{
+		Name:        "rpc.range_lookup.tenant.coalesced",
+		Help:        `Number of tenant range lookups served by the result of an identical lookup of the same tenant`,
+		Measurement: "Lookups",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaTenantRangeLookupsThrottled = metric.Metadata{
+		Name:        "rpc.range_lookup.tenant.throttled",
+		Help:        `Number of tenant range lookups which waited for the concurrency limit of their tenant`,
+		Measurement: "Lookups",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// tenantRangeLookupMetrics are the metrics of the tenant range lookups served
+// by a node.
+type tenantRangeLookupMetrics struct {
+	Coalesced *metric.Counter
+	Throttled *metric.Counter
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (tenantRangeLookupMetrics) MetricStruct() {}
+
+// tenantRangeLookups isolates the range lookups that tenants proxy through a
+// node from each other. Each tenant has its own singleflight group, which
+// coalesces its identical lookups, and its own concurrency limit, so that the
+// meta lookup storm of a tenant, e.g. after a cold start of its range cache,
+// can't starve the lookups of the other tenants served by the same node.
+type tenantRangeLookups struct {
+	st      *cluster.Settings
+	stopper *stop.Stopper
+	metrics tenantRangeLookupMetrics
+	mu      struct {
+		syncutil.Mutex
+		tenants map[roachpb.TenantID]*tenantRangeLookupState
+	}
+}
+
+type tenantRangeLookupState struct {
+	lookups *singleflight.Group
+	limiter limit.ConcurrentRequestLimiter
+}
+
+func newTenantRangeLookups(
+	st *cluster.Settings, stopper *stop.Stopper, reg *metric.Registry,
+) *tenantRangeLookups {
+	l := &tenantRangeLookups{
+		st:      st,
+		stopper: stopper,
+		metrics: tenantRangeLookupMetrics{
+			Coalesced: metric.NewCounter(metaTenantRangeLookupsCoalesced),
+			Throttled: metric.NewCounter(metaTenantRangeLookupsThrottled),
+		},
+	}
+	l.mu.tenants = make(map[roachpb.TenantID]*tenantRangeLookupState)
+	reg.AddMetricStruct(l.metrics)
+	tenantRangeLookupConcurrency.SetOnChange(&st.SV, func(ctx context.Context) {
+		limit := int(tenantRangeLookupConcurrency.Get(&st.SV))
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for _, ts := range l.mu.tenants {
+			ts.limiter.SetLimit(limit)
+		}
+	})
+	return l
+}
+
+func (l *tenantRangeLookups) getState(tenID roachpb.TenantID) *tenantRangeLookupState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ts, ok := l.mu.tenants[tenID]
+	if !ok {
+		ts = &tenantRangeLookupState{
+			lookups: singleflight.NewGroup("tenant range lookup", "lookup"),
+			limiter: limit.MakeConcurrentRequestLimiter(
+				"tenantRangeLookupLimiter", int(tenantRangeLookupConcurrency.Get(&l.st.SV)),
+			),
+		}
+		l.mu.tenants[tenID] = ts
+	}
+	return ts
+}
+
+// do runs the given range lookup of the given tenant. The lookup is coalesced
+// with an identical in-flight lookup of the tenant, if any, and otherwise waits
+// for the concurrency limit of the tenant.
+func (l *tenantRangeLookups) do(
+	ctx context.Context,
+	tenID roachpb.TenantID,
+	req *kvpb.RangeLookupRequest,
+	lookup func(context.Context) (*kvpb.RangeLookupResponse, error),
+) (*kvpb.RangeLookupResponse, error) {
+	ts := l.getState(tenID)
+	key := fmt.Sprintf("%s/%s/%d/%t",
+		req.Key, req.ReadConsistency, req.PrefetchNum, req.PrefetchReverse)
+	future, leader := ts.lookups.DoChan(ctx, key,
+		singleflight.DoOpts{
+			Stop:               l.stopper,
+			InheritCancelation: false,
+		},
+		func(ctx context.Context) (interface{}, error) {
+			// The flight doesn't inherit the values of the caller's ctx.
+			ctx = roachpb.ContextWithClientTenant(ctx, tenID)
+			if tenantRangeLookupConcurrency.Get(&l.st.SV) > 0 {
+				if ts.limiter.Available() == 0 {
+					l.metrics.Throttled.Inc(1)
+				}
+				alloc, err := ts.limiter.Begin(ctx)
+				if err != nil {
+					return nil, err
+				}
+				defer alloc.Release()
+			}
+			return lookup(ctx)
+		})
+	if !leader {
+		l.metrics.Coalesced.Inc(1)
+	}
+	select {
+	case <-future.C():
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	res := future.WaitForResult(ctx)
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	// The response is shared by the callers of the flight, which must not
+	// modify it.
+	return res.Val.(*kvpb.RangeLookupResponse), nil
+}
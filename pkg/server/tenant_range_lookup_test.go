@@ -15,9 +15,15 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -116,3 +122,75 @@ func TestFilterRangeLookupResponseForTenant(t *testing.T) {
 		require.Equal(t, tc.descs[:tc.exp], got)
 	}
 }
+
+// TestTenantRangeLookupsIsolation tests that the range lookups of a tenant are
+// coalesced and limited independently of the lookups of other tenants.
+func TestTenantRangeLookupsIsolation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	st := cluster.MakeTestingClusterSettings()
+	tenantRangeLookupConcurrency.Override(ctx, &st.SV, 1)
+	l := newTenantRangeLookups(st, stopper, metric.NewRegistry())
+
+	tenant2, tenant3 := roachpb.MustMakeTenantID(2), roachpb.MustMakeTenantID(3)
+	mkReq := func(key string) *kvpb.RangeLookupRequest {
+		return &kvpb.RangeLookupRequest{Key: roachpb.RKey(key)}
+	}
+	unblock := make(chan struct{})
+	blockingLookup := func(ctx context.Context) (*kvpb.RangeLookupResponse, error) {
+		select {
+		case <-unblock:
+			return &kvpb.RangeLookupResponse{}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	type result struct {
+		resp *kvpb.RangeLookupResponse
+		err  error
+	}
+	doAsync := func(tenID roachpb.TenantID, key string) chan result {
+		ch := make(chan result, 1)
+		go func() {
+			resp, err := l.do(ctx, tenID, mkReq(key), blockingLookup)
+			ch <- result{resp, err}
+		}()
+		return ch
+	}
+
+	// The first lookup of tenant 2 takes its only slot, the second one is
+	// coalesced with it, and the third one waits for the slot.
+	first := doAsync(tenant2, "a")
+	testutils.SucceedsSoon(t, func() error {
+		if l.getState(tenant2).limiter.Available() != 0 {
+			return errors.New("first lookup hasn't started")
+		}
+		return nil
+	})
+	second := doAsync(tenant2, "a")
+	third := doAsync(tenant2, "b")
+	testutils.SucceedsSoon(t, func() error {
+		if l.metrics.Coalesced.Count() != 1 || l.metrics.Throttled.Count() != 1 {
+			return errors.Newf("coalesced %d, throttled %d",
+				l.metrics.Coalesced.Count(), l.metrics.Throttled.Count())
+		}
+		return nil
+	})
+
+	// The lookups of tenant 3 aren't blocked by the ones of tenant 2.
+	resp, err := l.do(ctx, tenant3, mkReq("a"), func(context.Context) (*kvpb.RangeLookupResponse, error) {
+		return &kvpb.RangeLookupResponse{}, nil
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+
+	close(unblock)
+	for _, ch := range []chan result{first, second, third} {
+		res := <-ch
+		require.NoError(t, res.err)
+		require.NotNil(t, res.resp)
+	}
+}
@@ -105,7 +105,9 @@ type TestingKnobs struct {
 	// matically to the StoreSpecs used.
 	StickyVFSRegistry StickyVFSRegistry
 	// WallClock is used to inject a custom clock for testing the server. It is
-	// typically either an hlc.HybridManualClock or hlc.ManualClock.
+	// typically either an hlc.HybridManualClock or hlc.ManualClock. An
+	// hlc.OffsetClock per node can be used to simulate clock skew between the
+	// nodes of a TestCluster.
 	WallClock hlc.WallClock
 
 	// ImportTimeseriesFile, if set, is a file created via `DumpRaw` that written
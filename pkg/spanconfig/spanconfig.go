@@ -285,6 +285,17 @@ type StoreReader interface {
 	GetSpanConfigForKey(ctx context.Context, key roachpb.RKey) (roachpb.SpanConfig, error)
 }
 
+// BoundaryReader is implemented by the StoreReaders which can list the
+// boundaries between the span configs in a span. Unlike the split keys
+// returned by ComputeSplitKey, these include the boundaries between adjacent
+// span configs which are coalesced into a single range because they're
+// identical, which call for a split as soon as either of them changes.
+type BoundaryReader interface {
+	// ComputeBoundaries returns the start keys of the span configs strictly
+	// within the given span, in ascending order, up to the given limit.
+	ComputeBoundaries(ctx context.Context, start, end roachpb.RKey, limit int) ([]roachpb.RKey, error)
+}
+
 // Limiter is used to limit the number of span configs installed by secondary
 // tenants. It takes in a delta (typically the difference in span configs
 // between the committed and uncommitted state in the txn), uses it to maintain
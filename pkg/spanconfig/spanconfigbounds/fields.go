@@ -50,6 +50,8 @@ var fields = [config.NumFields]Field{
 	numVoters,
 	numReplicas,
 	gcTTLSeconds,
+	gcMaxVersions,
+	hashShards,
 	constraints,
 	voterConstraints,
 	leasePreferences,
@@ -62,6 +64,8 @@ const (
 	numReplicas      = int32Field(config.NumReplicas)
 	numVoters        = int32Field(config.NumVoters)
 	gcTTLSeconds     = int32Field(config.GCTTL)
+	gcMaxVersions    = int32Field(config.GCMaxVersions)
+	hashShards       = int32Field(config.HashShards)
 	constraints      = constraintsConjunctionField(config.Constraints)
 	voterConstraints = constraintsConjunctionField(config.VoterConstraints)
 	leasePreferences = leasePreferencesField(config.LeasePreferences)
@@ -37,6 +37,13 @@ func (f int32Field) FieldBound(b *Bounds) ValueBounds {
 			return b.NumVoters
 		case gcTTLSeconds:
 			return b.GCTTLSeconds
+		case gcMaxVersions:
+			// The number of versions retained by the tenant's own ranges isn't
+			// bounded.
+			return nil
+		case hashShards:
+			// The layout of the data of the tenant's own spans isn't bounded.
+			return nil
 		default:
 			// This is safe because we test that all the fields in the proto have
 			// a corresponding field, and we call this for each of them, and the user
@@ -62,6 +69,10 @@ func (f int32Field) fieldValue(c *roachpb.SpanConfig) *int32 {
 		return &c.NumVoters
 	case gcTTLSeconds:
 		return &c.GCPolicy.TTLSeconds
+	case gcMaxVersions:
+		return &c.GCPolicy.MaxVersions
+	case hashShards:
+		return &c.HashShards
 	default:
 		// This is safe because we test that all the fields in the proto have
 		// a corresponding field, and we call this for each of them, and the user
@@ -145,6 +145,7 @@ type KVSubscriber struct {
 }
 
 var _ spanconfig.KVSubscriber = &KVSubscriber{}
+var _ spanconfig.BoundaryReader = &KVSubscriber{}
 
 // Metrics are the Metrics associated with an instance of the
 // KVSubscriber.
@@ -354,6 +355,16 @@ func (s *KVSubscriber) ComputeSplitKey(
 	return s.mu.internal.ComputeSplitKey(ctx, start, end)
 }
 
+// ComputeBoundaries is part of the spanconfig.BoundaryReader interface.
+func (s *KVSubscriber) ComputeBoundaries(
+	ctx context.Context, start, end roachpb.RKey, limit int,
+) ([]roachpb.RKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.mu.internal.ComputeBoundaries(ctx, start, end, limit)
+}
+
 // GetSpanConfigForKey is part of the spanconfig.KVSubscriber interface.
 func (s *KVSubscriber) GetSpanConfigForKey(
 	ctx context.Context, key roachpb.RKey,
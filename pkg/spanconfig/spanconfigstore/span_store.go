@@ -107,6 +107,29 @@ func (s *spanConfigStore) forEachOverlapping(
 	return nil
 }
 
+// computeBoundaries returns the start keys of the span config entries
+// strictly within the given span, up to the given limit, regardless of
+// whether adjacent entries are coalesced.
+func (s *spanConfigStore) computeBoundaries(
+	start, end roachpb.RKey, limit int,
+) ([]roachpb.RKey, error) {
+	var boundaries []roachpb.RKey
+	sp := roachpb.Span{Key: start.AsRawKey(), EndKey: end.AsRawKey()}
+	if err := s.forEachOverlapping(sp, func(span roachpb.Span, _ roachpb.SpanConfig) error {
+		if span.Key.Compare(sp.Key) <= 0 {
+			return nil // more
+		}
+		boundaries = append(boundaries, roachpb.RKey(span.Key))
+		if len(boundaries) >= limit {
+			return iterutil.StopIteration()
+		}
+		return nil // more
+	}); err != nil {
+		return nil, err
+	}
+	return boundaries, nil
+}
+
 // computeSplitKey returns the first key we should split on because of the
 // presence a span config given a start and end key pair.
 func (s *spanConfigStore) computeSplitKey(
@@ -78,6 +78,7 @@ type Store struct {
 }
 
 var _ spanconfig.Store = &Store{}
+var _ spanconfig.BoundaryReader = &Store{}
 
 // New instantiates a span config store with the given fallback.
 func New(
@@ -120,6 +121,16 @@ func (s *Store) ComputeSplitKey(
 	return s.mu.spanConfigStore.computeSplitKey(ctx, start, end)
 }
 
+// ComputeBoundaries is part of the spanconfig.BoundaryReader interface.
+func (s *Store) ComputeBoundaries(
+	ctx context.Context, start, end roachpb.RKey, limit int,
+) ([]roachpb.RKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.mu.spanConfigStore.computeBoundaries(start, end, limit)
+}
+
 // GetSpanConfigForKey is part of the spanconfig.StoreReader interface.
 func (s *Store) GetSpanConfigForKey(
 	ctx context.Context, key roachpb.RKey,
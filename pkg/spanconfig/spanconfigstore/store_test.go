@@ -98,6 +98,10 @@ func (s *spanConfigStore) TestingSplitKeys(
 //	----
 //	key=c
 //
+//	boundaries span=[b,h) limit=10
+//	----
+//	key=c
+//
 //	overlapping span=[b,h)
 //	----
 //	[b,d):A
@@ -195,6 +199,23 @@ func TestDataDriven(t *testing.T) {
 				}
 				return b.String()
 
+			case "boundaries":
+				d.ScanArgs(t, "span", &spanStr)
+				span := spanconfigtestutils.ParseSpan(t, spanStr)
+				limit := 100
+				if d.HasArg("limit") {
+					d.ScanArgs(t, "limit", &limit)
+				}
+
+				start, end := roachpb.RKey(span.Key), roachpb.RKey(span.EndKey)
+				boundaries, err := store.ComputeBoundaries(ctx, start, end, limit)
+				require.NoError(t, err)
+				var b strings.Builder
+				for _, boundary := range boundaries {
+					b.WriteString(fmt.Sprintf("key=%s\n", string(boundary)))
+				}
+				return b.String()
+
 			case "overlapping":
 				d.ScanArgs(t, "span", &spanStr)
 				span := spanconfigtestutils.ParseSpan(t, spanStr)
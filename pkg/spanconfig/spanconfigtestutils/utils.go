@@ -631,9 +631,15 @@ func PrintSpanConfigDiffedAgainstDefaults(conf roachpb.SpanConfig) string {
 	if conf.GCPolicy.IgnoreStrictEnforcement != defaultConf.GCPolicy.IgnoreStrictEnforcement {
 		diffs = append(diffs, fmt.Sprintf("ignore_strict_gc=%t", conf.GCPolicy.IgnoreStrictEnforcement))
 	}
+	if conf.GCPolicy.MaxVersions != defaultConf.GCPolicy.MaxVersions {
+		diffs = append(diffs, fmt.Sprintf("gc_max_versions=%d", conf.GCPolicy.MaxVersions))
+	}
 	if conf.GlobalReads != defaultConf.GlobalReads {
 		diffs = append(diffs, fmt.Sprintf("global_reads=%v", conf.GlobalReads))
 	}
+	if conf.HashShards != defaultConf.HashShards {
+		diffs = append(diffs, fmt.Sprintf("hash_shards=%d", conf.HashShards))
+	}
 	if conf.NumReplicas != defaultConf.NumReplicas {
 		diffs = append(diffs, fmt.Sprintf("num_replicas=%d", conf.NumReplicas))
 	}
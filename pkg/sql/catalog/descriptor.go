@@ -716,6 +716,10 @@ type TableDescriptor interface {
 	// GetExcludeDataFromBackup returns true if the table's row data is configured
 	// to be excluded during backup.
 	GetExcludeDataFromBackup() bool
+	// GetDisableCommitSplitOnRetry returns true if the commit of the implicit
+	// transactions writing to the table is kept in the batch of their writes
+	// when they restart.
+	GetDisableCommitSplitOnRetry() bool
 	// GetStorageParams returns a list of storage parameters for the table.
 	GetStorageParams(spaceBetweenEqual bool) []string
 	// NoAutoStatsSettingsOverrides is true if no auto stats related settings are
@@ -2358,6 +2358,11 @@ func (desc *wrapper) GetExcludeDataFromBackup() bool {
 	return desc.ExcludeDataFromBackup
 }
 
+// GetDisableCommitSplitOnRetry implements the TableDescriptor interface.
+func (desc *wrapper) GetDisableCommitSplitOnRetry() bool {
+	return desc.DisableCommitSplitOnRetry
+}
+
 // GetStorageParams implements the TableDescriptor interface.
 func (desc *wrapper) GetStorageParams(spaceBetweenEqual bool) []string {
 	var storageParams []string
@@ -2435,6 +2440,9 @@ func (desc *wrapper) GetStorageParams(spaceBetweenEqual bool) []string {
 	if desc.IsSchemaLocked() {
 		appendStorageParam(`schema_locked`, `true`)
 	}
+	if desc.GetDisableCommitSplitOnRetry() {
+		appendStorageParam(`disable_commit_split_on_retry`, `true`)
+	}
 	return storageParams
 }
 
@@ -181,6 +181,7 @@ func (v *vectorInserter) Next() coldata.Batch {
 		log.VEventf(ctx, 2, "copy running batch, autocommit: %v, final: %v, numrows: %d", v.autoCommit, end == b.Length(), end-start)
 		var err error
 		if v.autoCommit && end == b.Length() {
+			kvba.Batch.DisableCommitSplitOnRetry = v.desc.GetDisableCommitSplitOnRetry()
 			err = v.flowCtx.Txn.CommitInBatch(ctx, kvba.Batch)
 		} else {
 			err = v.flowCtx.Txn.Run(ctx, kvba.Batch)
@@ -35,6 +35,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvcoord"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvflowcontrol/kvflowinspectpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/protectedts/ptpb"
 	"github.com/cockroachdb/cockroach/pkg/multitenant/tenantcapabilities"
@@ -220,6 +222,11 @@ var crdbInternal = virtualSchema{
 		catconstants.CrdbInternalRepairableCatalogCorruptionsViewID: crdbInternalRepairableCatalogCorruptions,
 		catconstants.CrdbInternalKVProtectedTS:                      crdbInternalKVProtectedTSTable,
 		catconstants.CrdbInternalKVSessionBasedLeases:               crdbInternalSessionBasedLeases,
+		catconstants.CrdbInternalRangesWithOldIntentsViewID:         crdbInternalRangesWithOldIntentsView,
+		catconstants.CrdbInternalRangeEncryptionStatusTableID:       crdbInternalRangeEncryptionStatusTable,
+		catconstants.CrdbInternalNodeOnePhaseCommitStatsTableID:     crdbInternalNodeOnePhaseCommitStatsTable,
+		catconstants.CrdbInternalNodeHotKeysTableID:                 crdbInternalNodeHotKeysTable,
+		catconstants.CrdbInternalNodeKeySpaceUsageTableID:           crdbInternalNodeKeySpaceUsageTable,
 	},
 	validWithNoDatabaseContext: true,
 }
@@ -2145,6 +2152,107 @@ CREATE TABLE crdb_internal.node_txn_stats (
 	},
 }
 
+// crdbInternalNodeOnePhaseCommitStatsTable exposes, for each table, the number
+// of implicit transactions which attempted to commit in one phase along with
+// their writes to the table, and the number of them which succeeded.
+var crdbInternalNodeOnePhaseCommitStatsTable = virtualSchemaTable{
+	comment: `per-table one phase commit attempts of implicit transactions ` +
+		`(in-memory, not durable; local node only)`,
+	schema: `
+CREATE TABLE crdb_internal.node_one_phase_commit_stats (
+  table_id   INT NOT NULL,
+  attempts   INT NOT NULL,
+  successes  INT NOT NULL
+)`,
+	populate: func(ctx context.Context, p *planner, _ catalog.DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		hasViewActivityOrViewActivityRedacted, _, err := p.HasViewActivityOrViewActivityRedactedRole(ctx)
+		if err != nil {
+			return err
+		}
+		if !hasViewActivityOrViewActivityRedacted {
+			return noViewActivityOrViewActivityRedactedRoleError(p.User())
+		}
+		return p.execCfg.OnePhaseCommitStats.forEach(func(tableID descpb.ID, attempts, successes int64) error {
+			return addRow(
+				tree.NewDInt(tree.DInt(tableID)),
+				tree.NewDInt(tree.DInt(attempts)),
+				tree.NewDInt(tree.DInt(successes)),
+			)
+		})
+	},
+}
+
+// crdbInternalNodeHotKeysTable exposes the hot keys sampled by the replicas of
+// the local stores, see kv.replica_hot_keys.enabled.
+var crdbInternalNodeHotKeysTable = virtualSchemaTable{
+	comment: `estimated most frequently accessed keys of each replica ` +
+		`(in-memory, not durable; local node only)`,
+	schema: `
+CREATE TABLE crdb_internal.node_hot_keys (
+  store_id    INT NOT NULL,
+  range_id    INT NOT NULL,
+  key         BYTES NOT NULL,
+  pretty_key  STRING NOT NULL,
+  count       INT NOT NULL
+)`,
+	populate: func(ctx context.Context, p *planner, _ catalog.DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		if err := p.CheckPrivilege(ctx, syntheticprivilege.GlobalPrivilegeObject, privilege.VIEWCLUSTERMETADATA); err != nil {
+			return err
+		}
+		return p.EvalContext().KVStoresIterator.ForEachStore(func(store kvserverbase.Store) error {
+			storeID := tree.NewDInt(tree.DInt(store.StoreID()))
+			return store.VisitHotKeys(func(rangeID roachpb.RangeID, key roachpb.Key, count int64) error {
+				return addRow(
+					storeID,
+					tree.NewDInt(tree.DInt(rangeID)),
+					tree.NewDBytes(tree.DBytes(key)),
+					tree.NewDString(keys.PrettyPrint(nil /* valDirs */, key)),
+					tree.NewDInt(tree.DInt(count)),
+				)
+			})
+		})
+	},
+}
+
+// crdbInternalNodeKeySpaceUsageTable exposes the size of the keys of the
+// replicas of the local stores per SQL index, see
+// kv.replica_key_space_usage.enabled.
+var crdbInternalNodeKeySpaceUsageTable = virtualSchemaTable{
+	comment: `logical size of the keys of each replica per SQL index ` +
+		`(in-memory, not durable; local node only)`,
+	schema: `
+CREATE TABLE crdb_internal.node_key_space_usage (
+  store_id    INT NOT NULL,
+  range_id    INT NOT NULL,
+  tenant_id   INT NOT NULL,
+  table_id    INT NOT NULL,
+  index_id    INT NOT NULL,
+  key_count   INT NOT NULL,
+  key_bytes   INT NOT NULL,
+  val_bytes   INT NOT NULL
+)`,
+	populate: func(ctx context.Context, p *planner, _ catalog.DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		if err := p.CheckPrivilege(ctx, syntheticprivilege.GlobalPrivilegeObject, privilege.VIEWCLUSTERMETADATA); err != nil {
+			return err
+		}
+		return p.EvalContext().KVStoresIterator.ForEachStore(func(store kvserverbase.Store) error {
+			storeID := tree.NewDInt(tree.DInt(store.StoreID()))
+			return store.VisitKeySpaceUsage(ctx, func(rangeID roachpb.RangeID, u kvserverpb.KeySpaceUsage) error {
+				return addRow(
+					storeID,
+					tree.NewDInt(tree.DInt(rangeID)),
+					tree.NewDInt(tree.DInt(u.TenantID)),
+					tree.NewDInt(tree.DInt(u.TableID)),
+					tree.NewDInt(tree.DInt(u.IndexID)),
+					tree.NewDInt(tree.DInt(u.KeyCount)),
+					tree.NewDInt(tree.DInt(u.KeyBytes)),
+					tree.NewDInt(tree.DInt(u.ValBytes)),
+				)
+			})
+		})
+	},
+}
+
 // crdbInternalSessionTraceTable exposes the latest trace collected on this
 // session (via SET TRACING={ON/OFF})
 //
@@ -4344,6 +4452,185 @@ CREATE VIEW crdb_internal.ranges AS SELECT ` +
 	comment:       "ranges is a view which queries ranges_no_leases for system ranges",
 }
 
+// crdbInternalRangesWithOldIntentsView exposes the ranges with outstanding
+// intents, along with the average age of their locks, oldest first. Intents
+// which are much older than their transactions should live are likely
+// abandoned, and slow down the reads which encounter them until they are
+// cleaned up by the MVCC GC queue, which prioritizes these ranges.
+var crdbInternalRangesWithOldIntentsView = virtualSchemaView{
+	schema: `
+CREATE VIEW crdb_internal.ranges_with_old_intents AS
+  SELECT
+    range_id,
+    start_key,
+    start_pretty,
+    end_key,
+    end_pretty,
+    intent_count,
+    lock_count,
+    ((lock_age + lock_count::FLOAT * (extract(epoch FROM now()) - last_update_nanos / 1e9))
+      / lock_count::FLOAT) * '1 second'::INTERVAL AS avg_lock_age
+  FROM
+    (
+      SELECT
+        range_id,
+        start_key,
+        start_pretty,
+        end_key,
+        end_pretty,
+        coalesce((stats->>'intent_count')::INT, 0) AS intent_count,
+        coalesce((stats->>'lock_count')::INT, 0) AS lock_count,
+        coalesce((stats->>'lock_age')::FLOAT, 0) AS lock_age,
+        coalesce((stats->>'last_update_nanos')::FLOAT, 0) AS last_update_nanos
+      FROM
+        (
+          SELECT
+            range_id, start_key, start_pretty, end_key, end_pretty,
+            crdb_internal.range_stats(start_key) AS stats
+          FROM
+            crdb_internal.ranges_no_leases
+        ) AS ranges
+    ) AS ranges_with_stats
+  WHERE
+    intent_count > 0 AND lock_count > 0
+  ORDER BY
+    avg_lock_age DESC
+`,
+	resultColumns: colinfo.ResultColumns{
+		{Name: "range_id", Typ: types.Int},
+		{Name: "start_key", Typ: types.Bytes},
+		{Name: "start_pretty", Typ: types.String},
+		{Name: "end_key", Typ: types.Bytes},
+		{Name: "end_pretty", Typ: types.String},
+		{Name: "intent_count", Typ: types.Int},
+		{Name: "lock_count", Typ: types.Int},
+		{Name: "avg_lock_age", Typ: types.Interval},
+	},
+	comment: "ranges with outstanding intents, ordered by the average age of their locks",
+}
+
+// crdbInternalRangeEncryptionStatusTable exposes, for each replica of each
+// range, the progress of the rotation of the data key of the encryption-at-rest
+// of its store: the fraction of the data of the range which is encrypted with
+// the active data key, and the amount of data encrypted with each data key.
+// The stats of all the replicas of a node are retrieved in a single RPC.
+//
+// The data of a range is re-encrypted as compactions rewrite the sstables
+// containing it; crdb_internal.reencrypt_engine_span can be used to force
+// these compactions, e.g.:
+//
+//	SELECT crdb_internal.reencrypt_engine_span(node_id, store_id, start_key, end_key)
+//	FROM crdb_internal.range_encryption_status WHERE reencrypted_fraction < 1
+var crdbInternalRangeEncryptionStatusTable = virtualSchemaTable{
+	comment: `progress of the re-encryption of the replicas of ranges with the active data key of their store (KV join; expensive!)`,
+	schema: `
+CREATE TABLE crdb_internal.range_encryption_status (
+  range_id             INT NOT NULL,
+  node_id              INT NOT NULL,
+  store_id             INT NOT NULL,
+  start_key            BYTES NOT NULL,
+  start_pretty         STRING NOT NULL,
+  end_key              BYTES NOT NULL,
+  end_pretty           STRING NOT NULL,
+  active_key_id        STRING NOT NULL,
+  total_bytes          INT NOT NULL,
+  active_key_bytes     INT NOT NULL,
+  reencrypted_fraction FLOAT NOT NULL,
+  bytes_by_key_id      JSONB NOT NULL
+)
+`,
+	populate: func(ctx context.Context, p *planner, _ catalog.DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		if isAdmin, err := p.HasAdminRole(ctx); err != nil {
+			return err
+		} else if !isAdmin {
+			return pgerror.Newf(pgcode.InsufficientPrivilege,
+				"only users with the admin role can read crdb_internal.range_encryption_status")
+		}
+		execCfg := p.ExecCfg()
+		rangeDescIterator, err := execCfg.RangeDescIteratorFactory.NewIterator(ctx, execCfg.Codec.TenantSpan())
+		if err != nil {
+			return err
+		}
+
+		// Group the replicas of the ranges by node and store, so that the stats
+		// of all the replicas of a node are retrieved in a single round trip.
+		type storeKey struct {
+			nodeID  roachpb.NodeID
+			storeID roachpb.StoreID
+		}
+		descsByStore := make(map[storeKey][]roachpb.RangeDescriptor)
+		for ; rangeDescIterator.Valid(); rangeDescIterator.Next() {
+			desc := rangeDescIterator.CurRangeDescriptor()
+			for _, rd := range desc.Replicas().VoterAndNonVoterDescriptors() {
+				k := storeKey{nodeID: rd.NodeID, storeID: rd.StoreID}
+				descsByStore[k] = append(descsByStore[k], desc)
+			}
+		}
+		storeKeys := make([]storeKey, 0, len(descsByStore))
+		for k := range descsByStore {
+			storeKeys = append(storeKeys, k)
+		}
+		sort.Slice(storeKeys, func(i, j int) bool {
+			if storeKeys[i].nodeID != storeKeys[j].nodeID {
+				return storeKeys[i].nodeID < storeKeys[j].nodeID
+			}
+			return storeKeys[i].storeID < storeKeys[j].storeID
+		})
+
+		for len(storeKeys) > 0 {
+			nodeID := storeKeys[0].nodeID
+			storeSpans := make(map[int32][]roachpb.Span)
+			var nodeStores []storeKey
+			for len(storeKeys) > 0 && storeKeys[0].nodeID == nodeID {
+				k := storeKeys[0]
+				storeKeys = storeKeys[1:]
+				nodeStores = append(nodeStores, k)
+				spans := make([]roachpb.Span, 0, len(descsByStore[k]))
+				for _, desc := range descsByStore[k] {
+					spans = append(spans, desc.RSpan().AsRawSpanWithNoLocals())
+				}
+				storeSpans[int32(k.storeID)] = spans
+			}
+			statsByStore, err := p.EvalContext().GetSpanEncryptionStats(
+				ctx, int32(nodeID), storeSpans, false /* forceReencryption */)
+			if err != nil {
+				return err
+			}
+			for _, k := range nodeStores {
+				stats := statsByStore[int32(k.storeID)]
+				for i, desc := range descsByStore[k] {
+					s := stats[i]
+					fraction := 1.0
+					if s.TotalBytes > 0 {
+						fraction = float64(s.ActiveKeyBytes) / float64(s.TotalBytes)
+					}
+					bytesByKeyID := json.NewObjectBuilder(len(s.BytesByKeyID))
+					for keyID, n := range s.BytesByKeyID {
+						bytesByKeyID.Add(keyID, json.FromInt64(int64(n)))
+					}
+					if err := addRow(
+						tree.NewDInt(tree.DInt(desc.RangeID)),
+						tree.NewDInt(tree.DInt(k.nodeID)),
+						tree.NewDInt(tree.DInt(k.storeID)),
+						tree.NewDBytes(tree.DBytes(desc.StartKey)),
+						tree.NewDString(keys.PrettyPrint(nil /* valDirs */, desc.StartKey.AsRawKey())),
+						tree.NewDBytes(tree.DBytes(desc.EndKey)),
+						tree.NewDString(keys.PrettyPrint(nil /* valDirs */, desc.EndKey.AsRawKey())),
+						tree.NewDString(s.ActiveKeyID),
+						tree.NewDInt(tree.DInt(s.TotalBytes)),
+						tree.NewDInt(tree.DInt(s.ActiveKeyBytes)),
+						tree.NewDFloat(tree.DFloat(fraction)),
+						tree.NewDJSON(bytesByKeyID.Build()),
+					); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	},
+}
+
 // descriptorsByType is a utility function that iterates through a slice of
 // descriptors and, using the provided privilege checker function, categorizes
 // the privileged descriptors for easy lookup of their human-readable names by IDs.
@@ -7776,6 +8063,10 @@ CREATE TABLE crdb_internal.cluster_locks (
 	generator: genClusterLocksGenerator(clusterLocksFilters{}),
 }
 
+// clusterLocksRangeConcurrency is the number of ranges whose lock tables are
+// queried concurrently to populate crdb_internal.cluster_locks.
+const clusterLocksRangeConcurrency = 16
+
 type clusterLocksFilters struct {
 	tableID      *int64
 	databaseName *string
@@ -7840,80 +8131,24 @@ func genClusterLocksGenerator(
 			}
 		}
 
-		spanIdx := 0
-		spansRemain := func() bool {
-			return spanIdx < len(spansToQuery)
+		scannerOpts := kvcoord.LockTableScannerOptions{
+			IncludeUncontended:    filters.contended == nil || !*filters.contended,
+			MaxLocksPerRequest:    int64(rowinfra.ProductionKVBatchSize),
+			TargetBytesPerRequest: int64(rowinfra.GetDefaultBatchBytesLimit(p.extendedEvalCtx.TestingKnobs.ForceProductionValues)),
+			Concurrency:           clusterLocksRangeConcurrency,
 		}
-		getNextSpan := func() *roachpb.Span {
-			if !spansRemain() {
-				return nil
-			}
-
-			nextSpan := spansToQuery[spanIdx]
-			spanIdx++
-			return &nextSpan
-		}
-
-		var resp *kvpb.QueryLocksResponse
+		scanner := kvcoord.NewLockTableScanner(p.execCfg.DistSender, spansToQuery, scannerOpts)
 		var locks []roachpb.LockStateInfo
-		var resumeSpan *roachpb.Span
-
-		fetchLocks := func(key, endKey roachpb.Key) error {
-			b := p.Txn().NewBatch()
-			queryLocksRequest := &kvpb.QueryLocksRequest{
-				RequestHeader: kvpb.RequestHeader{
-					Key:    key,
-					EndKey: endKey,
-				},
-				IncludeUncontended: true,
-			}
-			if filters.contended != nil && *filters.contended {
-				queryLocksRequest.IncludeUncontended = false
-			}
-
-			b.AddRawRequest(queryLocksRequest)
-
-			b.Header.MaxSpanRequestKeys = int64(rowinfra.ProductionKVBatchSize)
-			b.Header.TargetBytes = int64(rowinfra.GetDefaultBatchBytesLimit(p.extendedEvalCtx.TestingKnobs.ForceProductionValues))
-
-			err := p.txn.Run(ctx, b)
-			if err != nil {
-				return err
-			}
-
-			if len(b.RawResponse().Responses) != 1 {
-				return errors.AssertionFailedf(
-					"unexpected response length of %d for QueryLocksRequest", len(b.RawResponse().Responses),
-				)
-			}
-
-			resp = b.RawResponse().Responses[0].GetQueryLocks()
-			locks = resp.Locks
-			resumeSpan = resp.ResumeSpan
-			return nil
-		}
-
 		lockIdx := 0
 		getNextLock := func() (*roachpb.LockStateInfo, error) {
-			// If we don't yet have a response or the current response is exhausted,
-			// look for a span to query. This may be a ResumeSpan, in the case we
-			// need to fetch the next page of results for the current span, or it
-			// may be the next span in our list.
-			for lockIdx >= len(locks) && (spansRemain() || resumeSpan != nil) {
-				var spanToQuery *roachpb.Span
-				if resumeSpan != nil {
-					spanToQuery = resumeSpan
-				} else {
-					spanToQuery = getNextSpan()
-				}
-
-				if spanToQuery != nil {
-					err := fetchLocks(spanToQuery.Key, spanToQuery.EndKey)
-					if err != nil {
-						return nil, err
-					}
-					lockIdx = 0
+			// If we don't yet have a page of locks or the current page is
+			// exhausted, fetch the next page.
+			if lockIdx >= len(locks) {
+				var err error
+				if locks, err = scanner.NextPage(ctx); err != nil {
+					return nil, err
 				}
+				lockIdx = 0
 			}
 
 			if lockIdx < len(locks) {
@@ -135,6 +135,7 @@ func (d *deleteNode) BatchedNext(params runParams) (bool, error) {
 
 	if lastBatch {
 		d.run.td.setRowsWrittenLimit(params.extendedEvalCtx.SessionData())
+		d.run.td.setOnePhaseCommitStats(params.ExecCfg().OnePhaseCommitStats)
 		if err := d.run.td.finalize(params.ctx); err != nil {
 			return false, err
 		}
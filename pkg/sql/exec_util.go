@@ -1376,10 +1376,18 @@ type ExecutorConfig struct {
 	// compaction concurrency.
 	CompactionConcurrencyFunc eval.SetCompactionConcurrencyFunc
 
+	// SetProposalQuotaFunc is used to set the capacity of the proposal quota
+	// pool of a range for a specified node and store.
+	SetProposalQuotaFunc eval.SetProposalQuotaFunc
+
 	// GetTableMetricsFunc is used to gather information about sstables that
 	// overlap with a key range for a specified node and store.
 	GetTableMetricsFunc eval.GetTableMetricsFunc
 
+	// GetSpanEncryptionStatsFunc is used to gather the progress of the
+	// rotation of the data key of a specified node and store on a key range.
+	GetSpanEncryptionStatsFunc eval.GetSpanEncryptionStatsFunc
+
 	// ScanStorageInternalKeys is used to gather information about the types of
 	// keys (including snapshot pinned keys) at each level of a node store.
 	ScanStorageInternalKeysFunc eval.ScanStorageInternalKeysFunc
@@ -1448,6 +1456,10 @@ type ExecutorConfig struct {
 	// RangeStatsFetcher is used to fetch RangeStats.
 	RangeStatsFetcher eval.RangeStatsFetcher
 
+	// OnePhaseCommitStats counts the one phase commit attempts of implicit
+	// transactions per table.
+	OnePhaseCommitStats *OnePhaseCommitStats
+
 	// EventsExporter is the client for the Observability Service.
 	EventsExporter obs.EventsExporterInterface
 
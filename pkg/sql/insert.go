@@ -336,6 +336,7 @@ func (n *insertNode) BatchedNext(params runParams) (bool, error) {
 
 	if lastBatch {
 		n.run.ti.setRowsWrittenLimit(params.extendedEvalCtx.SessionData())
+		n.run.ti.setOnePhaseCommitStats(params.ExecCfg().OnePhaseCommitStats)
 		if err := n.run.ti.finalize(params.ctx); err != nil {
 			return false, err
 		}
@@ -505,6 +505,7 @@ func (n *insertFastPathNode) BatchedNext(params runParams) (bool, error) {
 		}
 	}
 	n.run.ti.setRowsWrittenLimit(params.extendedEvalCtx.SessionData())
+	n.run.ti.setOnePhaseCommitStats(params.ExecCfg().OnePhaseCommitStats)
 	if err := n.run.ti.finalize(params.ctx); err != nil {
 		return false, err
 	}
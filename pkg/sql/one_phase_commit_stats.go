@@ -0,0 +1,98 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// OnePhaseCommitStats counts, for each table, the implicit transactions which
+// were committed in the same KV batch as their writes to the table, i.e. which
+// attempted to commit in one phase, and the ones which succeeded to. The
+// counts are local to the node, and reset when it restarts. They are exposed
+// in crdb_internal.node_one_phase_commit_stats.
+type OnePhaseCommitStats struct {
+	mu struct {
+		syncutil.Mutex
+		byTable map[descpb.ID]*onePhaseCommitTableStats
+	}
+}
+
+type onePhaseCommitTableStats struct {
+	attempts, successes int64
+}
+
+// NewOnePhaseCommitStats creates an empty OnePhaseCommitStats.
+func NewOnePhaseCommitStats() *OnePhaseCommitStats {
+	s := &OnePhaseCommitStats{}
+	s.mu.byTable = make(map[descpb.ID]*onePhaseCommitTableStats)
+	return s
+}
+
+// record records an attempt to commit a transaction in one phase along with
+// its writes to the given table. It is a no-op on a nil OnePhaseCommitStats.
+func (s *OnePhaseCommitStats) record(tableID descpb.ID, success bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ts, ok := s.mu.byTable[tableID]
+	if !ok {
+		ts = &onePhaseCommitTableStats{}
+		s.mu.byTable[tableID] = ts
+	}
+	ts.attempts++
+	if success {
+		ts.successes++
+	}
+}
+
+// forEach calls fn with the counts of each table, in the order of their IDs.
+func (s *OnePhaseCommitStats) forEach(
+	fn func(tableID descpb.ID, attempts, successes int64) error,
+) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	ids := make([]descpb.ID, 0, len(s.mu.byTable))
+	stats := make(map[descpb.ID]onePhaseCommitTableStats, len(s.mu.byTable))
+	for id, ts := range s.mu.byTable {
+		ids = append(ids, id)
+		stats[id] = *ts
+	}
+	s.mu.Unlock()
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		if err := fn(id, stats[id].attempts, stats[id].successes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// committedInOnePhase returns true if the transaction committed with
+// Txn.CommitInBatch along with the given batch committed in one phase.
+func committedInOnePhase(b *kv.Batch) bool {
+	br := b.RawResponse()
+	if br == nil || len(br.Responses) == 0 {
+		return false
+	}
+	et, ok := br.Responses[len(br.Responses)-1].GetInner().(*kvpb.EndTxnResponse)
+	return ok && et.OnePhaseCommit
+}
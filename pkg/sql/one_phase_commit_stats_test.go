@@ -0,0 +1,73 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOnePhaseCommitStats tests that the implicit transactions writing to a
+// table are counted in crdb_internal.node_one_phase_commit_stats, and that
+// they carry the disable_commit_split_on_retry storage parameter of the table
+// to their EndTxn requests.
+func TestOnePhaseCommitStats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var disabledSplits int64
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{
+		Knobs: base.TestingKnobs{
+			Store: &kvserver.StoreTestingKnobs{
+				TestingRequestFilter: func(_ context.Context, ba *kvpb.BatchRequest) *kvpb.Error {
+					if et, ok := ba.GetArg(kvpb.EndTxn); ok && et.(*kvpb.EndTxnRequest).DisableSplitOnRetry {
+						atomic.AddInt64(&disabledSplits, 1)
+					}
+					return nil
+				},
+			},
+		},
+	})
+	defer s.Stopper().Stop(context.Background())
+	sqlDB := sqlutils.MakeSQLRunner(db)
+
+	sqlDB.Exec(t, `CREATE TABLE t (k INT PRIMARY KEY, v INT)`)
+	tableID := sqlutils.QueryTableID(t, db, "defaultdb", "public", "t")
+	getStats := func() [][]string {
+		return sqlDB.QueryStr(t,
+			`SELECT attempts, successes FROM crdb_internal.node_one_phase_commit_stats WHERE table_id = $1`,
+			tableID)
+	}
+
+	sqlDB.Exec(t, `INSERT INTO t VALUES (1, 1)`)
+	sqlDB.Exec(t, `UPDATE t SET v = 2 WHERE k = 1`)
+	require.Equal(t, [][]string{{"2", "2"}}, getStats())
+	require.Zero(t, atomic.LoadInt64(&disabledSplits))
+
+	// Explicit transactions don't commit with their writes.
+	sqlDB.Exec(t, `BEGIN; INSERT INTO t VALUES (2, 2); COMMIT`)
+	require.Equal(t, [][]string{{"2", "2"}}, getStats())
+
+	sqlDB.Exec(t, `ALTER TABLE t SET (disable_commit_split_on_retry = true)`)
+	sqlDB.Exec(t, `UPSERT INTO t VALUES (1, 3)`)
+	require.Equal(t, [][]string{{"3", "3"}}, getStats())
+	require.Equal(t, int64(1), atomic.LoadInt64(&disabledSplits))
+}
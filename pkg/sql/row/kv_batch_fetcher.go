@@ -84,6 +84,18 @@ var internalLowPriReadElasticControlEnabled = settings.RegisterBoolSetting(
 	true,
 )
 
+// adaptiveTargetBytesInitial is the response size budget of the first range
+// read by the first batch of byte-limited fetches, see
+// kvpb.Header.AdaptiveTargetBytes.
+var adaptiveTargetBytesInitial = settings.RegisterByteSizeSetting(
+	settings.ApplicationLevel,
+	"sql.fetcher.adaptive_target_bytes.initial_size",
+	"if positive, the response size budget of the first range read by byte-limited "+
+		"fetches, which grows with the rows returned; smaller budgets return the first rows "+
+		"sooner. Set to 0 to disable adaptive sizing",
+	0,
+)
+
 // sendFunc is the function used to execute a KV batch; normally
 // wraps (*kv.Txn).Send.
 type sendFunc func(
@@ -192,6 +204,9 @@ type txnKVFetcher struct {
 	alreadyFetched bool
 	batchIdx       int
 	reqsScratch    []kvpb.RequestUnion
+	// adaptiveTargetBytes is the AdaptiveTargetBytes of the next batch, as
+	// hinted by the response to the previous one.
+	adaptiveTargetBytes int64
 
 	responses           []kvpb.ResponseUnion
 	kvPairsRead         int64
@@ -216,6 +231,9 @@ type txnKVFetcher struct {
 	requestAdmissionHeader kvpb.AdmissionHeader
 	responseAdmissionQ     *admission.WorkQueue
 	admissionPacer         *admission.Pacer
+
+	// sv is nil in tests.
+	sv *settings.Values
 }
 
 var _ KVBatchFetcher = &txnKVFetcher{}
@@ -323,6 +341,7 @@ func newTxnKVFetcherInternal(args newTxnKVFetcherArgs) *txnKVFetcher {
 		forceProductionKVBatchSize: args.forceProductionKVBatchSize,
 		requestAdmissionHeader:     args.admission.requestHeader,
 		responseAdmissionQ:         args.admission.responseQ,
+		sv:                         args.admission.settingsValues,
 	}
 
 	f.maybeInitAdmissionPacer(
@@ -340,6 +359,7 @@ func (f *txnKVFetcher) setTxnAndSendFn(txn *kv.Txn, sendFn sendFunc) {
 	f.sendFn = sendFn
 	f.requestAdmissionHeader = txn.AdmissionHeader()
 	f.responseAdmissionQ = txn.DB().SQLKVResponseAdmissionQ
+	f.sv = txn.DB().SettingsValues
 
 	f.admissionPacer.Close()
 	f.maybeInitAdmissionPacer(txn.AdmissionHeader(), txn.DB().AdmissionPacerFactory, txn.DB().SettingsValues)
@@ -540,6 +560,12 @@ func (f *txnKVFetcher) fetch(ctx context.Context) error {
 	ba.Header.LockTimeout = f.lockTimeout
 	ba.Header.TargetBytes = int64(f.batchBytesLimit)
 	ba.Header.MaxSpanRequestKeys = int64(f.getBatchKeyLimit())
+	if f.batchBytesLimit != 0 && f.sv != nil {
+		if f.batchIdx == 0 {
+			f.adaptiveTargetBytes = adaptiveTargetBytesInitial.Get(f.sv)
+		}
+		ba.Header.AdaptiveTargetBytes = f.adaptiveTargetBytes
+	}
 	if buildutil.CrdbTestBuild {
 		if f.scanFormat == kvpb.COL_BATCH_RESPONSE && f.indexFetchSpec == nil {
 			return errors.AssertionFailedf("IndexFetchSpec not provided with COL_BATCH_RESPONSE scan format")
@@ -586,6 +612,9 @@ func (f *txnKVFetcher) fetch(ctx context.Context) error {
 	}
 	if br != nil {
 		f.responses = br.Responses
+		if br.AdaptiveTargetBytesHint > 0 {
+			f.adaptiveTargetBytes = br.AdaptiveTargetBytesHint
+		}
 		f.kvPairsRead = 0
 		for i := range f.responses {
 			f.kvPairsRead += f.responses[i].GetInner().Header().NumKeys
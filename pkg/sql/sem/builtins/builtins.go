@@ -6924,6 +6924,105 @@ Parameters:` + randgencfg.ConfigDoc,
 		},
 	),
 
+	"crdb_internal.set_range_proposal_quota": makeBuiltin(
+		tree.FunctionProperties{
+			Category:         builtinconstants.CategorySystemRepair,
+			DistsqlBlocklist: true,
+			Undocumented:     true,
+		},
+		tree.Overload{
+			Types: tree.ParamTypes{
+				{Name: "node_id", Typ: types.Int},
+				{Name: "store_id", Typ: types.Int},
+				{Name: "range_id", Typ: types.Int},
+				{Name: "quota_bytes", Typ: types.Int},
+				{Name: "duration", Typ: types.Interval},
+			},
+			ReturnType: tree.FixedReturnType(types.Bool),
+			Fn: func(ctx context.Context, evalCtx *eval.Context, args tree.Datums) (tree.Datum, error) {
+				isAdmin, err := evalCtx.SessionAccessor.HasAdminRole(ctx)
+				if err != nil {
+					return nil, err
+				}
+				if !isAdmin {
+					return nil, errInsufficientPriv
+				}
+				nodeID := int32(tree.MustBeDInt(args[0]))
+				storeID := int32(tree.MustBeDInt(args[1]))
+				rangeID := int64(tree.MustBeDInt(args[2]))
+				quota := int64(tree.MustBeDInt(args[3]))
+				if quota < 0 {
+					return nil, pgerror.New(pgcode.InvalidParameterValue, "quota_bytes must not be negative")
+				}
+				duration := time.Duration(tree.MustBeDInterval(args[4]).Nanos())
+				if err := evalCtx.SetProposalQuota(
+					ctx, nodeID, storeID, rangeID, uint64(quota), duration); err != nil {
+					return nil, err
+				}
+				return tree.DBoolTrue, nil
+			},
+			Info: "This function is used only by CockroachDB's developers for controlled bulk writes. " +
+				"It sets the capacity of the Raft proposal quota pool of the given range on the given " +
+				"node and store for the given duration, after which it reverts to the default. The " +
+				"capacity is only in effect while the replica on the store is the Raft leader, so " +
+				"it should be set on all the voting replicas of the range. A quota of 0 reverts to " +
+				"the default immediately.",
+			Volatility: volatility.Volatile,
+		},
+	),
+
+	"crdb_internal.span_encryption_stats": makeBuiltin(
+		tree.FunctionProperties{
+			Category:         builtinconstants.CategorySystemInfo,
+			DistsqlBlocklist: true,
+			Undocumented:     true,
+		},
+		tree.Overload{
+			Types: tree.ParamTypes{
+				{Name: "node_id", Typ: types.Int},
+				{Name: "store_id", Typ: types.Int},
+				{Name: "start_key", Typ: types.Bytes},
+				{Name: "end_key", Typ: types.Bytes},
+			},
+			ReturnType: tree.FixedReturnType(types.Jsonb),
+			Fn:         makeSpanEncryptionStatsFn(false /* forceReencryption */),
+			Info: "This function is used to report the progress of the rotation of the data key " +
+				"of the encryption-at-rest of the given node and store on a span of the engine. " +
+				"It returns the ID of the active data key, the approximate sizes of the data " +
+				"of the span in the store and in the sstables encrypted with the active key, and " +
+				"the approximate size of the data of the span by data key ID. To report on all " +
+				"the ranges, use crdb_internal.range_encryption_status. The data of a span can be " +
+				"re-encrypted with the active key with crdb_internal.reencrypt_engine_span.",
+			Volatility: volatility.Volatile,
+		},
+	),
+
+	"crdb_internal.reencrypt_engine_span": makeBuiltin(
+		tree.FunctionProperties{
+			Category:         builtinconstants.CategorySystemInfo,
+			DistsqlBlocklist: true,
+			Undocumented:     true,
+		},
+		tree.Overload{
+			Types: tree.ParamTypes{
+				{Name: "node_id", Typ: types.Int},
+				{Name: "store_id", Typ: types.Int},
+				{Name: "start_key", Typ: types.Bytes},
+				{Name: "end_key", Typ: types.Bytes},
+			},
+			ReturnType: tree.FixedReturnType(types.Jsonb),
+			Fn:         makeSpanEncryptionStatsFn(true /* forceReencryption */),
+			Info: "This function is used to force the re-encryption of a span of the engine of " +
+				"the given node and store with the active data key of its encryption-at-rest, " +
+				"by compacting the span. It blocks until the compaction is done, and returns the " +
+				"encryption stats of the span, as crdb_internal.span_encryption_stats. The " +
+				"sstables of the span which are only in the bottommost level of the LSM aren't " +
+				"rewritten by the compaction; they are reported under their data key in the " +
+				"returned stats, and re-encrypted when they are next compacted.",
+			Volatility: volatility.Volatile,
+		},
+	),
+
 	"crdb_internal.increment_feature_counter": makeBuiltin(
 		tree.FunctionProperties{
 			Category:     builtinconstants.CategorySystemInfo,
@@ -9342,6 +9441,46 @@ var jsonExtractPathTextImpl = tree.Overload{
 	Volatility: volatility.Immutable,
 }
 
+// makeSpanEncryptionStatsFn returns the implementation of the builtins
+// retrieving the encryption stats of a span of the engine of a store, which
+// is compacted first if forceReencryption is set.
+func makeSpanEncryptionStatsFn(forceReencryption bool) eval.FnOverload {
+	return func(ctx context.Context, evalCtx *eval.Context, args tree.Datums) (tree.Datum, error) {
+		isAdmin, err := evalCtx.SessionAccessor.HasAdminRole(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !isAdmin {
+			return nil, errInsufficientPriv
+		}
+		nodeID := int32(tree.MustBeDInt(args[0]))
+		storeID := int32(tree.MustBeDInt(args[1]))
+		span := roachpb.Span{
+			Key:    roachpb.Key(tree.MustBeDBytes(args[2])),
+			EndKey: roachpb.Key(tree.MustBeDBytes(args[3])),
+		}
+		res, err := evalCtx.GetSpanEncryptionStats(
+			ctx, nodeID, map[int32][]roachpb.Span{storeID: {span}}, forceReencryption)
+		if err != nil {
+			return nil, err
+		}
+		if len(res[storeID]) != 1 {
+			return nil, errors.AssertionFailedf("expected the stats of a single span")
+		}
+		stats := res[storeID][0]
+		bytesByKeyID := json.NewObjectBuilder(len(stats.BytesByKeyID))
+		for keyID, n := range stats.BytesByKeyID {
+			bytesByKeyID.Add(keyID, json.FromInt64(int64(n)))
+		}
+		builder := json.NewObjectBuilder(4)
+		builder.Add("active_key_id", json.FromString(stats.ActiveKeyID))
+		builder.Add("total_bytes", json.FromInt64(int64(stats.TotalBytes)))
+		builder.Add("active_key_bytes", json.FromInt64(int64(stats.ActiveKeyBytes)))
+		builder.Add("bytes_by_key_id", bytesByKeyID.Build())
+		return tree.NewDJSON(builder.Build()), nil
+	}
+}
+
 func jsonExtractPathHelper(args tree.Datums) (json.JSON, error) {
 	j := tree.MustBeDJSON(args[0])
 	path := make([]string, len(args)-1)
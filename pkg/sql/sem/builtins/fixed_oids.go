@@ -2538,6 +2538,9 @@ var builtinOidsArray = []string{
 	2570: `array_position(array: refcursor[], elem: refcursor, start: int) -> int`,
 	2571: `bit_count(val: bytes) -> int`,
 	2572: `bit_count(val: varbit) -> int`,
+	2573: `crdb_internal.span_encryption_stats(node_id: int, store_id: int, start_key: bytes, end_key: bytes) -> jsonb`,
+	2574: `crdb_internal.set_range_proposal_quota(node_id: int, store_id: int, range_id: int, quota_bytes: int, duration: interval) -> bool`,
+	2575: `crdb_internal.reencrypt_engine_span(node_id: int, store_id: int, start_key: bytes, end_key: bytes) -> jsonb`,
 }
 
 var builtinOidsBySignature map[string]oid.Oid
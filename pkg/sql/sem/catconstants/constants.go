@@ -205,6 +205,11 @@ const (
 	CrdbInternalRepairableCatalogCorruptionsViewID
 	CrdbInternalKVProtectedTS
 	CrdbInternalKVSessionBasedLeases
+	CrdbInternalRangesWithOldIntentsViewID
+	CrdbInternalRangeEncryptionStatusTableID
+	CrdbInternalNodeOnePhaseCommitStatsTableID
+	CrdbInternalNodeHotKeysTableID
+	CrdbInternalNodeKeySpaceUsageTableID
 	InformationSchemaID
 	InformationSchemaAdministrableRoleAuthorizationsID
 	InformationSchemaApplicableRolesID
@@ -225,9 +225,15 @@ type Context struct {
 	// CompactEngineSpan is used to force compaction of a span in a store.
 	CompactEngineSpan CompactEngineSpanFunc
 
+	// SetProposalQuota is used in crdb_internal.set_range_proposal_quota.
+	SetProposalQuota SetProposalQuotaFunc
+
 	// GetTableMetrics is used in crdb_internal.sstable_metrics.
 	GetTableMetrics GetTableMetricsFunc
 
+	// GetSpanEncryptionStats is used in crdb_internal.span_encryption_stats.
+	GetSpanEncryptionStats GetSpanEncryptionStatsFunc
+
 	// ScanStorageInternalKeys is used in crdb_internal.scan_storage_internal_keys.
 	ScanStorageInternalKeys ScanStorageInternalKeysFunc
 
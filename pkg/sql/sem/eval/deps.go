@@ -467,12 +467,26 @@ type CompactEngineSpanFunc func(
 	ctx context.Context, nodeID, storeID int32, startKey, endKey []byte,
 ) error
 
+// SetProposalQuotaFunc is used to set the capacity of the proposal quota pool
+// of the given range at the given (nodeID, storeID) for the given duration.
+type SetProposalQuotaFunc func(
+	ctx context.Context, nodeID, storeID int32, rangeID int64, capacity uint64, duration time.Duration,
+) error
+
 // GetTableMetrics is used to retrieve sstable metrics on a key span
 // (end-exclusive) at the given (nodeID, storeID).
 type GetTableMetricsFunc func(
 	ctx context.Context, nodeID, storeID int32, startKey, endKey []byte,
 ) ([]enginepb.SSTableMetricsInfo, error)
 
+// GetSpanEncryptionStatsFunc is used to retrieve the progress of the rotation
+// of the data key of the stores of the given node on key spans
+// (end-exclusive), keyed by store ID, in a single round trip. If
+// forceReencryption is set, the spans are compacted first.
+type GetSpanEncryptionStatsFunc func(
+	ctx context.Context, nodeID int32, storeSpans map[int32][]roachpb.Span, forceReencryption bool,
+) (map[int32][]enginepb.SpanEncryptionStats, error)
+
 // ScanStorageInternalKeysFunc is used to retrieve pebble metrics on a key span
 // (end-exclusive) at the given (nodeID, storeID).
 // megabytesPerSecond is used to specify the maximmum number of bytes read per second.
@@ -17,6 +17,7 @@ import (
 	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/config"
 	"github.com/cockroachdb/cockroach/pkg/config/zonepb"
 	"github.com/cockroachdb/cockroach/pkg/keys"
@@ -119,7 +120,24 @@ func init() {
 			field:        config.GCTTL,
 			requiredType: types.Int,
 			setter: func(c *zonepb.ZoneConfig, d tree.Datum) {
-				c.GC = &zonepb.GCPolicy{TTLSeconds: int32(tree.MustBeDInt(d))}
+				gc := zonepb.GCPolicy{}
+				if c.GC != nil {
+					gc = *c.GC
+				}
+				gc.TTLSeconds = int32(tree.MustBeDInt(d))
+				c.GC = &gc
+			},
+		},
+		{
+			field:        config.GCMaxVersions,
+			requiredType: types.Int,
+			setter: func(c *zonepb.ZoneConfig, d tree.Datum) {
+				gc := zonepb.GCPolicy{}
+				if c.GC != nil {
+					gc = *c.GC
+				}
+				gc.MaxVersions = int32(tree.MustBeDInt(d))
+				c.GC = &gc
 			},
 		},
 		{
@@ -156,6 +174,22 @@ func init() {
 				c.InheritedLeasePreferences = false
 			},
 		},
+		{
+			field:        config.HashShards,
+			requiredType: types.Int,
+			setter:       func(c *zonepb.ZoneConfig, d tree.Datum) { c.HashShards = proto.Int32(int32(tree.MustBeDInt(d))) },
+			checkAllowed: func(ctx context.Context, execCfg *ExecutorConfig, _ tree.Datum) error {
+				if !execCfg.Codec.ForSystemTenant() {
+					return pgerror.Newf(pgcode.FeatureNotSupported,
+						"hash_shards can only be set by the system tenant")
+				}
+				if !execCfg.Settings.Version.IsActive(ctx, clusterversion.V24_1_HashShardedSpans) {
+					return pgerror.Newf(pgcode.FeatureNotSupported,
+						"hash_shards requires the cluster to be fully upgraded")
+				}
+				return nil
+			},
+		},
 	}
 	supportedZoneConfigOptions = make(map[tree.Name]zoneConfigOption, len(opts))
 	zoneOptionKeys = make([]string, len(opts))
@@ -587,6 +621,10 @@ func (n *setZoneConfigNode) startExec(params runParams) error {
 				partialSubzone = &zonepb.Subzone{Config: *zonepb.NewZoneConfig()}
 			}
 		}
+		oldHashShards := partialZone.GetHashShards()
+		if index != nil {
+			oldHashShards = partialSubzone.Config.GetHashShards()
+		}
 
 		// Retrieve the zone configuration.
 		//
@@ -658,6 +696,10 @@ func (n *setZoneConfigNode) startExec(params runParams) error {
 		}
 
 		if deleteZone {
+			if oldHashShards != 0 {
+				return pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+					"cannot remove a zone configuration with hash_shards set")
+			}
 			if index != nil {
 				didDelete := completeZone.DeleteSubzone(uint32(index.GetID()), partition)
 				_ = partialZone.DeleteSubzone(uint32(index.GetID()), partition)
@@ -718,6 +760,7 @@ func (n *setZoneConfigNode) startExec(params runParams) error {
 			// settings, (e.g. because the query specified CONFIGURE ZONE = or
 			// USING DEFAULT), the setter slice will be empty and this will be
 			// a no-op. This is innocuous.
+			inheritsGC := finalZone.GC == nil
 			for _, setter := range setters {
 				// A setter may fail with an error-via-panic. Catch those.
 				if err := func() (err error) {
@@ -740,6 +783,18 @@ func (n *setZoneConfigNode) startExec(params runParams) error {
 					return err
 				}
 			}
+			if err := validateHashShards(
+				params, table, index, partition, partialZone, oldHashShards, finalZone.GetHashShards(),
+			); err != nil {
+				return err
+			}
+
+			// The GC policy is inherited as a whole, so the partial zone retains
+			// the inherited values of the GC fields which weren't set.
+			if inheritsGC && finalZone.GC != nil && newZone.GC != nil {
+				gc := *newZone.GC
+				finalZone.GC = &gc
+			}
 
 			// Validate that there are no conflicts in the zone setup.
 			if err := validateNoRepeatKeysInZone(&newZone); err != nil {
@@ -968,6 +1023,52 @@ func validateNoRepeatKeysInConstraints(constraints []zonepb.Constraint) error {
 	return nil
 }
 
+// validateHashShards checks that a zone config change keeps the on-disk layout
+// implied by hash_shards intact. The field may only be set on a table without
+// subzones or on an index, only while the span it covers is empty, and never
+// changed afterwards. Subzones may not be added below a hash-sharded table.
+func validateHashShards(
+	params runParams,
+	table catalog.TableDescriptor,
+	index catalog.Index,
+	partition string,
+	tableZone *zonepb.ZoneConfig,
+	oldHashShards, newHashShards int32,
+) error {
+	if index != nil && tableZone.GetHashShards() != 0 {
+		return pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+			"cannot configure a subzone of table %q, which has hash_shards set", table.GetName())
+	}
+	if oldHashShards == newHashShards {
+		return nil
+	}
+	if oldHashShards != 0 {
+		return pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+			"hash_shards cannot be changed once set")
+	}
+	if table == nil || partition != "" {
+		return pgerror.Newf(pgcode.InvalidParameterValue,
+			"hash_shards can only be set on a table or an index")
+	}
+	codec := params.ExecCfg().Codec
+	prefix := codec.TablePrefix(uint32(table.GetID()))
+	if index != nil {
+		prefix = codec.IndexPrefix(uint32(table.GetID()), uint32(index.GetID()))
+	} else if len(tableZone.Subzones) > 0 {
+		return pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+			"hash_shards cannot be set on table %q, which has subzones", table.GetName())
+	}
+	kvs, err := params.p.txn.Scan(params.ctx, prefix, prefix.PrefixEnd(), 1)
+	if err != nil {
+		return err
+	}
+	if len(kvs) > 0 {
+		return pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+			"hash_shards can only be set while %q is empty", table.GetName())
+	}
+	return nil
+}
+
 // accumulateNewUniqueConstraints returns a list of unique constraints in the
 // given newZone config proto that are not in the currentZone
 func accumulateNewUniqueConstraints(currentZone, newZone *zonepb.ZoneConfig) []zonepb.Constraint {
@@ -207,6 +207,10 @@ func zoneConfigToSQL(zs *tree.ZoneSpecifier, zone *zonepb.ZoneConfig) (string, e
 	if zone.GC != nil {
 		maybeWriteComma(f)
 		f.Printf("\tgc.ttlseconds = %d", zone.GC.TTLSeconds)
+		if zone.GC.MaxVersions != 0 {
+			maybeWriteComma(f)
+			f.Printf("\tgc.max_versions = %d", zone.GC.MaxVersions)
+		}
 	}
 	if zone.GlobalReads != nil {
 		maybeWriteComma(f)
@@ -220,6 +224,10 @@ func zoneConfigToSQL(zs *tree.ZoneSpecifier, zone *zonepb.ZoneConfig) (string, e
 		maybeWriteComma(f)
 		f.Printf("\tnum_voters = %d", *zone.NumVoters)
 	}
+	if zone.HashShards != nil {
+		maybeWriteComma(f)
+		f.Printf("\thash_shards = %d", *zone.HashShards)
+	}
 	if !zone.InheritedConstraints {
 		maybeWriteComma(f)
 		f.Printf("\tconstraints = %s", lexbase.EscapeSQLString(constraints))
@@ -551,6 +551,20 @@ var tableParams = map[string]tableParam{
 			return nil
 		},
 	},
+	`disable_commit_split_on_retry`: {
+		onSet: func(ctx context.Context, po *Setter, semaCtx *tree.SemaContext, evalCtx *eval.Context, key string, datum tree.Datum) error {
+			boolVal, err := boolFromDatum(ctx, evalCtx, key, datum)
+			if err != nil {
+				return err
+			}
+			po.TableDesc.DisableCommitSplitOnRetry = boolVal
+			return nil
+		},
+		onReset: func(ctx context.Context, po *Setter, evalCtx *eval.Context, key string) error {
+			po.TableDesc.DisableCommitSplitOnRetry = false
+			return nil
+		},
+	},
 	`schema_locked`: {
 		onSet: func(ctx context.Context, po *Setter, semaCtx *tree.SemaContext, evalCtx *eval.Context, key string, datum tree.Datum) error {
 			boolVal, err := boolFromDatum(ctx, evalCtx, key, datum)
@@ -138,6 +138,9 @@ type tableWriterBase struct {
 	// finalize() before deciding whether it is safe to auto commit (if auto
 	// commit is enabled).
 	rowsWrittenLimit int64
+	// onePCStats, if set, records the attempts of the auto commit to commit in
+	// one phase.
+	onePCStats *OnePhaseCommitStats
 	// rows contains the accumulated result rows if rowsNeeded is set on the
 	// corresponding tableWriter.
 	rows *rowcontainer.RowContainer
@@ -192,6 +195,12 @@ func (tb *tableWriterBase) setRowsWrittenLimit(sd *sessiondata.SessionData) {
 	}
 }
 
+// setOnePhaseCommitStats should be called before finalize to record whether
+// the auto commit, if enabled, commits in one phase.
+func (tb *tableWriterBase) setOnePhaseCommitStats(stats *OnePhaseCommitStats) {
+	tb.onePCStats = stats
+}
+
 // flushAndStartNewBatch shares the common flushAndStartNewBatch() code between
 // tableWriters.
 func (tb *tableWriterBase) flushAndStartNewBatch(ctx context.Context) error {
@@ -227,7 +236,9 @@ func (tb *tableWriterBase) finalize(ctx context.Context) (err error) {
 		// An auto-txn can commit the transaction with the batch. This is an
 		// optimization to avoid an extra round-trip to the transaction
 		// coordinator.
+		tb.b.DisableCommitSplitOnRetry = tb.desc.GetDisableCommitSplitOnRetry()
 		err = tb.txn.CommitInBatch(ctx, tb.b)
+		tb.onePCStats.record(tb.desc.GetID(), err == nil && committedInOnePhase(tb.b))
 	} else {
 		err = tb.txn.Run(ctx, tb.b)
 	}
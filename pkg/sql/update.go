@@ -198,6 +198,7 @@ func (u *updateNode) BatchedNext(params runParams) (bool, error) {
 
 	if lastBatch {
 		u.run.tu.setRowsWrittenLimit(params.extendedEvalCtx.SessionData())
+		u.run.tu.setOnePhaseCommitStats(params.ExecCfg().OnePhaseCommitStats)
 		if err := u.run.tu.finalize(params.ctx); err != nil {
 			return false, err
 		}
@@ -122,6 +122,7 @@ func (n *upsertNode) BatchedNext(params runParams) (bool, error) {
 
 	if lastBatch {
 		n.run.tw.setRowsWrittenLimit(params.extendedEvalCtx.SessionData())
+		n.run.tw.setOnePhaseCommitStats(params.ExecCfg().OnePhaseCommitStats)
 		if err := n.run.tw.finalize(params.ctx); err != nil {
 			return false, err
 		}
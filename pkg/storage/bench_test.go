@@ -1509,7 +1509,7 @@ func runMVCCGarbageCollect(
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		batch := eng.NewBatch()
-		if err := MVCCGarbageCollect(ctx, batch, ms, gcKeys, now); err != nil {
+		if err := MVCCGarbageCollect(ctx, batch, ms, gcKeys, now, hlc.Timestamp{}); err != nil {
 			b.Fatal(err)
 		}
 		batch.Close()
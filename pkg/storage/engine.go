@@ -945,6 +945,10 @@ type Engine interface {
 	// GetEnvStats retrieves stats about the engine's environment
 	// For RocksDB, this includes details of at-rest encryption.
 	GetEnvStats() (*EnvStats, error)
+	// GetSpanEncryptionStats returns the approximate size of the data of the
+	// key span [start, end) in the local sstables, in total and by the data key
+	// they are encrypted with.
+	GetSpanEncryptionStats(start, end roachpb.Key) (enginepb.SpanEncryptionStats, error)
 	// GetAuxiliaryDir returns a path under which files can be stored
 	// persistently, and from which data can be ingested by the engine.
 	//
@@ -31,6 +31,7 @@ func populatedMVCCValueHeader() MVCCValueHeader {
 	allFieldsSet := MVCCValueHeader{
 		LocalTimestamp:   hlc.ClockTimestamp{WallTime: 1, Logical: 1, Synthetic: true},
 		OmitInRangefeeds: true,
+		Expiration:       hlc.Timestamp{WallTime: 2},
 	}
 	allFieldsSet.KVNemesisSeq.Set(123)
 	return allFieldsSet
@@ -45,6 +46,7 @@ func TestMVCCValueHeader_IsEmpty(t *testing.T) {
 	require.False(t, allFieldsSet.IsEmpty())
 	require.False(t, MVCCValueHeader{LocalTimestamp: allFieldsSet.LocalTimestamp}.IsEmpty())
 	require.False(t, MVCCValueHeader{OmitInRangefeeds: allFieldsSet.OmitInRangefeeds}.IsEmpty())
+	require.False(t, MVCCValueHeader{Expiration: allFieldsSet.Expiration}.IsEmpty())
 }
 
 func TestMVCCValueHeader_MarshalUnmarshal(t *testing.T) {
@@ -1045,6 +1045,37 @@ func updateStatsOnGC(
 	return ms
 }
 
+// updateStatsOnGCLive returns the stats delta of garbage collecting the meta
+// key or version of a latest value which expired and is thus still accounted
+// as live. Since its live bytes are removed along with its key and value bytes,
+// the delta has no GCBytes and doesn't affect GCBytesAge.
+func updateStatsOnGCLive(
+	key roachpb.Key, keySize, valSize int64, metaKey bool,
+) enginepb.MVCCStats {
+	ms := updateStatsOnGC(key, keySize, valSize, metaKey, 0 /* nonLiveMS */)
+	if isSysLocal(key) {
+		return ms
+	}
+	ms.LiveBytes -= keySize + valSize
+	if metaKey {
+		ms.LiveCount--
+	}
+	return ms
+}
+
+// mvccValueExpiredAt returns whether the value at the current position of the
+// iterator expired at or below the given timestamp.
+func mvccValueExpiredAt(iter MVCCIterator, ts hlc.Timestamp) (bool, error) {
+	if ts.IsEmpty() {
+		return false, nil
+	}
+	v, err := DecodeMVCCValueAndErr(iter.UnsafeValue())
+	if err != nil {
+		return false, err
+	}
+	return v.IsExpired(ts), nil
+}
+
 // MVCCGetProto fetches the value at the specified key and unmarshals it into
 // msg if msg is non-nil. Returns true on success or false if the key was not
 // found.
@@ -1266,6 +1297,9 @@ func (opts *MVCCGetOptions) validate() error {
 	if opts.DontInterleaveIntents && opts.SkipLocked {
 		return errors.Errorf("cannot disable interleaved intents with skip locked option")
 	}
+	if opts.Filter != nil && opts.WholeRowsOfSize > 1 {
+		return errors.Errorf("cannot filter the results of a scan returning whole rows")
+	}
 	return nil
 }
 
@@ -2650,6 +2684,9 @@ func mvccPutInternal(
 	versionValue.Value = value
 	versionValue.LocalTimestamp = opts.LocalTimestamp
 	versionValue.OmitInRangefeeds = opts.OmitInRangefeeds
+	if !versionValue.IsTombstone() {
+		versionValue.Expiration = opts.Expiration
+	}
 
 	if buildutil.CrdbTestBuild {
 		if seq, seqOK := kvnemesisutil.FromContext(ctx); seqOK {
@@ -4401,6 +4438,7 @@ func mvccScanInit(
 		targetBytes:      opts.TargetBytes,
 		allowEmpty:       opts.AllowEmpty,
 		wholeRows:        opts.WholeRowsOfSize > 1, // single-KV rows don't need processing
+		filter:           opts.Filter,
 		maxLockConflicts: opts.MaxLockConflicts,
 		inconsistent:     opts.Inconsistent,
 		skipLocked:       opts.SkipLocked,
@@ -4562,6 +4600,10 @@ type MVCCWriteOptions struct {
 	MaxLockConflicts int64
 	// Category is used for writes that need to do a read.
 	Category ReadCategory
+	// Expiration, if set, is the timestamp at which a written value expires,
+	// after which it is seen as deleted by reads and removed by GC. It is
+	// ignored for deletion tombstones.
+	Expiration hlc.Timestamp
 }
 
 func (opts *MVCCWriteOptions) validate() error {
@@ -4610,6 +4652,12 @@ type MVCCScanOptions struct {
 	// and AllowEmpty is false, in which case the remaining KV pairs of the row
 	// will be fetched and returned too.
 	WholeRowsOfSize int32
+	// Filter, if set, restricts the result to the kv pairs for which it returns
+	// true, given their key and the RawBytes of their roachpb.Value. The kv
+	// pairs filtered out don't count against MaxKeys or TargetBytes. It can't be
+	// combined with WholeRowsOfSize, since it may drop some of the kv pairs of a
+	// row.
+	Filter func(key roachpb.Key, rawValue []byte) bool
 	// MaxLockConflicts is a maximum number of locks (intents) collected by
 	// scanner in consistent mode before returning LockConflictError.
 	//
@@ -6068,7 +6116,9 @@ func mvccReleaseLockInternal(
 // it iterates through the keys listed for garbage collection by the
 // keys slice. The iterator is seeked in turn to each listed
 // key, clearing all values with timestamps <= to expiration. The
-// timestamp parameter is used to compute the intent age on GC.
+// timestamp parameter is used to compute the intent age on GC. The latest
+// value of a key is only cleared if it is a deletion tombstone or if it
+// expired at or below gcThreshold.
 //
 // Note that this method will be sorting the keys.
 //
@@ -6082,6 +6132,7 @@ func MVCCGarbageCollect(
 	ms *enginepb.MVCCStats,
 	keys []kvpb.GCRequest_GCKey,
 	timestamp hlc.Timestamp,
+	gcThreshold hlc.Timestamp,
 ) error {
 
 	var count int64
@@ -6156,6 +6207,10 @@ func MVCCGarbageCollect(
 
 		unsafeKey := iter.UnsafeKey()
 		implicitMeta := unsafeKey.IsValue()
+		// latestExpired is set if the latest value of the key is live but expired
+		// at or below the GC threshold, in which case it's removed along with all
+		// older versions.
+		var latestExpired bool
 		// Note that we naively can't terminate GC'ing keys loop early if we
 		// enter any of branches below, as it will update the stats under the
 		// provision that the (implicit or explicit) meta key (and thus all
@@ -6184,9 +6239,17 @@ func MVCCGarbageCollect(
 			// For version keys, don't allow GC'ing the meta key if it's
 			// not marked deleted. However, for inline values we allow it;
 			// they are internal and GCing them directly saves the extra
-			// deletion step.
+			// deletion step. Values which expired at or below the GC threshold
+			// are treated as deleted.
 			if !meta.Deleted && !inlinedValue {
-				return errors.Errorf("request to GC non-deleted, latest value of %q", gcKey.Key)
+				if implicitMeta {
+					if latestExpired, err = mvccValueExpiredAt(iter, gcThreshold); err != nil {
+						return err
+					}
+				}
+				if !latestExpired {
+					return errors.Errorf("request to GC non-deleted, latest value of %q", gcKey.Key)
+				}
 			}
 			if meta.Txn != nil {
 				return errors.Errorf("request to GC intent at %q", gcKey.Key)
@@ -6195,6 +6258,8 @@ func MVCCGarbageCollect(
 				if inlinedValue {
 					updateStatsForInline(ms, gcKey.Key, metaKeySize, metaValSize, 0, 0)
 					ms.AgeTo(timestamp.WallTime)
+				} else if latestExpired {
+					ms.Add(updateStatsOnGCLive(gcKey.Key, metaKeySize, metaValSize, true /* metaKey */))
 				} else {
 					ms.Add(updateStatsOnGC(gcKey.Key, metaKeySize, metaValSize, true /* metaKey */, meta.Timestamp.WallTime))
 				}
@@ -6345,21 +6410,25 @@ func MVCCGarbageCollect(
 
 				// A non-deletion becomes non-live when its newer neighbor shows up.
 				// A deletion tombstone becomes non-live right when it is created.
-				fromNS := prevNanos
-				if valIsTombstone {
-					fromNS = unsafeIterKey.Timestamp.WallTime
-				} else if !rangeTombstones.IsEmpty() {
-					// For non deletions, we need to find if we had a range tombstone
-					// between this and next value (prevNanos) to use its timestamp for
-					// computing GCBytesAge.
-					if kv, ok := rangeTombstones.FirstAtOrAbove(unsafeIterKey.Timestamp); ok {
-						if kv.Timestamp.WallTime < fromNS {
-							fromNS = kv.Timestamp.WallTime
+				// The expired latest value is still live.
+				if latestExpired && unsafeIterKey.Timestamp.Equal(meta.Timestamp.ToTimestamp()) {
+					ms.Add(updateStatsOnGCLive(gcKey.Key, keySize, valSize, false /* metaKey */))
+				} else {
+					fromNS := prevNanos
+					if valIsTombstone {
+						fromNS = unsafeIterKey.Timestamp.WallTime
+					} else if !rangeTombstones.IsEmpty() {
+						// For non deletions, we need to find if we had a range tombstone
+						// between this and next value (prevNanos) to use its timestamp for
+						// computing GCBytesAge.
+						if kv, ok := rangeTombstones.FirstAtOrAbove(unsafeIterKey.Timestamp); ok {
+							if kv.Timestamp.WallTime < fromNS {
+								fromNS = kv.Timestamp.WallTime
+							}
 						}
 					}
+					ms.Add(updateStatsOnGC(gcKey.Key, keySize, valSize, false /* metaKey */, fromNS))
 				}
-
-				ms.Add(updateStatsOnGC(gcKey.Key, keySize, valSize, false /* metaKey */, fromNS))
 			}
 			count++
 			if err := rw.ClearMVCC(unsafeIterKey, clearOpts); err != nil {
@@ -6763,7 +6832,17 @@ func MVCCGarbageCollectPointsWithClearRange(
 			}
 		}
 
-		if isGarbage := !coveredBy.IsEmpty() && coveredBy.LessEq(gcThreshold) || isTombstone; !isGarbage {
+		// The latest value of a key is still live if it expired, since its
+		// expiration isn't reflected in the stats.
+		var isLiveExpired bool
+		if !isTombstone && coveredBy.IsEmpty() {
+			if isLiveExpired, err = mvccValueExpiredAt(iter, gcThreshold); err != nil {
+				return err
+			}
+		}
+
+		if isGarbage := !coveredBy.IsEmpty() && coveredBy.LessEq(gcThreshold) || isTombstone ||
+			isLiveExpired; !isGarbage {
 			// Current version is below threshold and is not a tombstone, but
 			// preceding one is above so it is visible and can't be cleared.
 			return errors.Errorf("attempt to GC data %s still visible at GC threshold %s with clear range",
@@ -6775,7 +6854,12 @@ func MVCCGarbageCollectPointsWithClearRange(
 			validTill = unsafeKey.Timestamp
 		}
 
-		if ms != nil {
+		if ms != nil && isLiveExpired {
+			ms.Add(updateStatsOnGCLive(unsafeKey.Key, int64(EncodedMVCCKeyPrefixLength(unsafeKey.Key)), 0,
+				true /* metaKey */))
+			ms.Add(updateStatsOnGCLive(unsafeKey.Key, MVCCVersionTimestampSize, int64(valueLen),
+				false /* metaKey */))
+		} else if ms != nil {
 			if newKey {
 				ms.Add(updateStatsOnGC(unsafeKey.Key, int64(EncodedMVCCKeyPrefixLength(unsafeKey.Key)), 0,
 					true /* metaKey */, validTill.WallTime))
@@ -1016,6 +1016,7 @@ func TestMVCCStatsDelDelGC(t *testing.T) {
 			Timestamp: ts2,
 		}},
 		ts2,
+		hlc.Timestamp{},
 	); err != nil {
 		t.Fatal(err)
 	}
@@ -1211,7 +1212,7 @@ func TestMVCCStatsPutWaitDeleteGC(t *testing.T) {
 	if err := MVCCGarbageCollect(ctx, engine, aggMS, []kvpb.GCRequest_GCKey{{
 		Key:       key,
 		Timestamp: ts1,
-	}}, ts2); err != nil {
+	}}, ts2, hlc.Timestamp{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -2150,6 +2151,7 @@ func TestMVCCStatsRandomized(t *testing.T) {
 				Timestamp: gcTS,
 			}},
 			s.TS,
+			hlc.Timestamp{},
 		); err != nil {
 			return false, err.Error()
 		}
@@ -4938,7 +4938,7 @@ func TestMVCCGarbageCollect(t *testing.T) {
 		{Key: roachpb.Key("t"), Timestamp: ts4},
 	}
 	if err := MVCCGarbageCollect(
-		context.Background(), engine, ms, gcKeys, gcTime,
+		context.Background(), engine, ms, gcKeys, gcTime, hlc.Timestamp{},
 	); err != nil {
 		t.Fatal(err)
 	}
@@ -5025,7 +5025,7 @@ func TestMVCCGarbageCollectNonDeleted(t *testing.T) {
 		keys := []kvpb.GCRequest_GCKey{
 			{Key: test.key, Timestamp: ts2},
 		}
-		err := MVCCGarbageCollect(ctx, engine, nil, keys, ts2)
+		err := MVCCGarbageCollect(ctx, engine, nil, keys, ts2, hlc.Timestamp{})
 		if !testutils.IsError(err, test.expError) {
 			t.Fatalf("expected error %q when garbage collecting a non-deleted live value, found %v",
 				test.expError, err)
@@ -5066,7 +5066,7 @@ func TestMVCCGarbageCollectIntent(t *testing.T) {
 	keys := []kvpb.GCRequest_GCKey{
 		{Key: key, Timestamp: ts2},
 	}
-	if err := MVCCGarbageCollect(ctx, engine, nil, keys, ts2); err == nil {
+	if err := MVCCGarbageCollect(ctx, engine, nil, keys, ts2, hlc.Timestamp{}); err == nil {
 		t.Fatal("expected error garbage collecting an intent")
 	}
 	// Compact the engine; the ForTesting() config option will assert that all
@@ -5074,6 +5074,62 @@ func TestMVCCGarbageCollectIntent(t *testing.T) {
 	require.NoError(t, engine.Compact())
 }
 
+// TestMVCCValueExpiration verifies that a value written with an expiration is
+// seen as deleted by reads at or above the expiration, and that it can be
+// garbage collected along with its older versions once the GC threshold
+// reaches the expiration.
+func TestMVCCValueExpiration(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	engine := NewDefaultInMemForTesting()
+	defer engine.Close()
+
+	ms := &enginepb.MVCCStats{}
+	ts1 := hlc.Timestamp{WallTime: 1e9}
+	ts2 := hlc.Timestamp{WallTime: 2e9}
+	ts3 := hlc.Timestamp{WallTime: 3e9}
+	keyA, keyB := roachpb.Key("a"), roachpb.Key("b")
+
+	put := func(key roachpb.Key, ts hlc.Timestamp, val string, expiration hlc.Timestamp) {
+		_, err := MVCCPut(ctx, engine, key, ts, roachpb.MakeValueFromString(val),
+			MVCCWriteOptions{Stats: ms, Expiration: expiration})
+		require.NoError(t, err)
+	}
+	put(keyA, ts1, "old", hlc.Timestamp{})
+	put(keyA, ts2, "new", ts3)
+	put(keyB, ts1, "b", hlc.Timestamp{})
+
+	// Reads below the expiration see the value, reads at or above it don't.
+	res, err := MVCCGet(ctx, engine, keyA, ts2, MVCCGetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, res.Value)
+	res, err = MVCCGet(ctx, engine, keyA, ts3, MVCCGetOptions{})
+	require.NoError(t, err)
+	require.Nil(t, res.Value)
+	scanRes, err := MVCCScan(ctx, engine, keyA, keyMax, ts3, MVCCScanOptions{})
+	require.NoError(t, err)
+	require.Len(t, scanRes.KVs, 1)
+	require.Equal(t, keyB, scanRes.KVs[0].Key)
+
+	// The value can't be garbage collected below its expiration.
+	keys := []kvpb.GCRequest_GCKey{{Key: keyA, Timestamp: ts2}}
+	require.ErrorContains(t, MVCCGarbageCollect(ctx, engine, nil, keys, ts3, ts2),
+		`request to GC non-deleted, latest value of "a"`)
+
+	// Once the GC threshold reaches the expiration, all versions of the key are
+	// removed, and the stats remain accurate.
+	require.NoError(t, MVCCGarbageCollect(ctx, engine, ms, keys, ts3, ts3))
+	kvs, err := Scan(ctx, engine, localMax, keyMax, 0)
+	require.NoError(t, err)
+	require.Len(t, kvs, 1)
+	require.Equal(t, mvccVersionKey(keyB, ts1), kvs[0].Key)
+	expMS, err := ComputeStats(ctx, engine, localMax, keyMax, ts3.WallTime)
+	require.NoError(t, err)
+	assertEq(t, engine, "after GC", ms, &expMS)
+}
+
 // TestMVCCGarbageCollectPanicsWithMixOfLocalAndGlobalKeys verifies that
 // MVCCGarbageCollect panics when presented with a mix of local and global
 // keys.
@@ -5092,7 +5148,7 @@ func TestMVCCGarbageCollectPanicsWithMixOfLocalAndGlobalKeys(t *testing.T) {
 			{Key: k, Timestamp: ts},
 			{Key: keys.RangeDescriptorKey(roachpb.RKey(k))},
 		}
-		if err := MVCCGarbageCollect(ctx, engine, nil, keys, ts); err != nil {
+		if err := MVCCGarbageCollect(ctx, engine, nil, keys, ts, hlc.Timestamp{}); err != nil {
 			panic(err)
 		}
 	})
@@ -5174,7 +5230,7 @@ func TestMVCCGarbageCollectUsesSeekLTAppropriately(t *testing.T) {
 		defer batch.Close()
 		rw := readWriterReturningSeekLTTrackingIterator{ReadWriter: batch}
 
-		require.NoError(t, MVCCGarbageCollect(ctx, &rw, ms, keys, toHLC(10)))
+		require.NoError(t, MVCCGarbageCollect(ctx, &rw, ms, keys, toHLC(10), hlc.Timestamp{}))
 		require.Equal(t, expectedSeekLTs, rw.it.seekLTCalled)
 	}
 	cases := []testCase{
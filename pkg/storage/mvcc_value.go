@@ -81,6 +81,12 @@ func (v MVCCValue) IsTombstone() bool {
 	return len(v.Value.RawBytes) == 0
 }
 
+// IsExpired returns whether the MVCCValue has an expiration at or below the
+// given timestamp, in which case reads at that timestamp see it as deleted.
+func (v MVCCValue) IsExpired(ts hlc.Timestamp) bool {
+	return !v.Expiration.IsEmpty() && v.Expiration.LessEq(ts)
+}
+
 // LocalTimestampNeeded returns whether the MVCCValue's local timestamp is
 // needed, or whether it can be implied by (i.e. set to the same value as)
 // its key's version timestamp.
@@ -133,6 +139,12 @@ func (v MVCCValue) SafeFormat(w redact.SafePrinter, _ rune) {
 		if !v.LocalTimestamp.IsEmpty() {
 			w.Printf("localTs=%s", v.LocalTimestamp)
 		}
+		if !v.Expiration.IsEmpty() {
+			if !v.LocalTimestamp.IsEmpty() {
+				w.Printf(",")
+			}
+			w.Printf("expiration=%s", v.Expiration)
+		}
 		w.Printf("}")
 	}
 	w.Print(v.Value.PrettyPrint())
@@ -89,6 +89,8 @@ func TestMVCCValueFormat(t *testing.T) {
 
 	valHeader := enginepb.MVCCValueHeader{}
 	valHeader.LocalTimestamp = hlc.ClockTimestamp{WallTime: 9}
+	expHeader := valHeader
+	expHeader.Expiration = hlc.Timestamp{WallTime: 10}
 
 	testcases := map[string]struct {
 		val    MVCCValue
@@ -100,6 +102,7 @@ func TestMVCCValueFormat(t *testing.T) {
 		"header+tombstone": {val: MVCCValue{MVCCValueHeader: valHeader}, expect: "{localTs=0.000000009,0}/<empty>"},
 		"header+bytes":     {val: MVCCValue{MVCCValueHeader: valHeader, Value: strVal}, expect: "{localTs=0.000000009,0}/BYTES/foo"},
 		"header+int":       {val: MVCCValue{MVCCValueHeader: valHeader, Value: intVal}, expect: "{localTs=0.000000009,0}/INT/17"},
+		"expiration+bytes": {val: MVCCValue{MVCCValueHeader: expHeader, Value: strVal}, expect: "{localTs=0.000000009,0,expiration=0.000000010,0}/BYTES/foo"},
 	}
 	for name, tc := range testcases {
 		t.Run(name, func(t *testing.T) {
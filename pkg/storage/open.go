@@ -89,6 +89,15 @@ func Attributes(attrs roachpb.Attributes) ConfigOption {
 	}
 }
 
+// NonVoting configures whether the store is in non-voting mode, in which it
+// only holds non-voting replicas and no leases.
+func NonVoting(nonVoting bool) ConfigOption {
+	return func(cfg *engineConfig) error {
+		cfg.NonVoting = nonVoting
+		return nil
+	}
+}
+
 // MaxSize sets the intended maximum store size. MaxSize is used for
 // calculating free space and making rebalancing decisions.
 func MaxSize(size int64) ConfigOption {
@@ -1251,7 +1251,8 @@ func NewPebble(ctx context.Context, cfg PebbleConfig) (p *Pebble, err error) {
 		}
 	}
 
-	storeProps := computeStoreProperties(ctx, cfg.Dir, opts.ReadOnly, encryptionEnv != nil /* encryptionEnabled */)
+	storeProps := computeStoreProperties(ctx, cfg.Dir, opts.ReadOnly,
+		encryptionEnv != nil /* encryptionEnabled */, cfg.NonVoting)
 
 	p = &Pebble{
 		FS:                opts.FS,
@@ -2232,6 +2233,74 @@ func (p *Pebble) GetEnvStats() (*EnvStats, error) {
 	return stats, nil
 }
 
+// GetSpanEncryptionStats implements the Engine interface.
+func (p *Pebble) GetSpanEncryptionStats(
+	start, end roachpb.Key,
+) (enginepb.SpanEncryptionStats, error) {
+	const plainKeyID = "plain"
+	stats := enginepb.SpanEncryptionStats{
+		ActiveKeyID:  plainKeyID,
+		BytesByKeyID: make(map[string]uint64),
+	}
+	// sstKeyIDs holds the ID of the data key each sstable is encrypted with.
+	// The sstables which aren't in the file registry are in plain text.
+	var sstKeyIDs map[pebble.FileNum]string
+	if p.encryption != nil {
+		var err error
+		stats.ActiveKeyID, err = p.encryption.StatsHandler.GetActiveDataKeyID()
+		if err != nil {
+			return enginepb.SpanEncryptionStats{}, err
+		}
+		sstKeyIDs = make(map[pebble.FileNum]string)
+		for filePath, entry := range p.fileRegistry.getRegistryCopy().Files {
+			filename := p.FS.PathBase(filePath)
+			numStr := strings.TrimSuffix(filename, ".sst")
+			if len(numStr) == len(filename) {
+				continue // not a sstable
+			}
+			u, err := strconv.ParseUint(numStr, 10, 64)
+			if err != nil {
+				return enginepb.SpanEncryptionStats{}, errors.Wrapf(err, "parsing filename %q", errors.Safe(filename))
+			}
+			keyID, err := p.encryption.StatsHandler.GetKeyIDFromSettings(entry.EncryptionSettings)
+			if err != nil {
+				return enginepb.SpanEncryptionStats{}, err
+			}
+			sstKeyIDs[pebble.FileNum(u)] = keyID
+		}
+	}
+
+	tableInfo, err := p.db.SSTables(
+		pebble.WithKeyRangeFilter(EngineKey{Key: start}.Encode(), EngineKey{Key: end}.Encode()),
+		pebble.WithProperties(), pebble.WithApproximateSpanBytes())
+	if err != nil {
+		return enginepb.SpanEncryptionStats{}, err
+	}
+	for _, sstableInfos := range tableInfo {
+		for _, sstableInfo := range sstableInfos {
+			if sstableInfo.BackingType != pebble.BackingTypeLocal {
+				// Remote sstables aren't encrypted by the store.
+				continue
+			}
+			spanBytes, err := strconv.ParseUint(
+				sstableInfo.Properties.UserProperties["approximate-span-bytes"], 10, 64)
+			if err != nil {
+				return enginepb.SpanEncryptionStats{}, err
+			}
+			keyID := plainKeyID
+			if id := sstKeyIDs[sstableInfo.BackingSSTNum]; id != "" {
+				keyID = id
+			}
+			stats.TotalBytes += spanBytes
+			stats.BytesByKeyID[keyID] += spanBytes
+			if keyID == stats.ActiveKeyID {
+				stats.ActiveKeyBytes += spanBytes
+			}
+		}
+	}
+	return stats, nil
+}
+
 // GetAuxiliaryDir implements the Engine interface.
 func (p *Pebble) GetAuxiliaryDir() string {
 	return p.auxDir
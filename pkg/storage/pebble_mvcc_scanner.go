@@ -408,6 +408,9 @@ type pebbleMVCCScanner struct {
 	// allowEmpty is false, and the partial row is the first row in the result,
 	// the row will instead be completed by fetching additional KV pairs.
 	wholeRows bool
+	// If set, only the kv pairs for which filter returns true are added to the
+	// results. See MVCCScanOptions.Filter.
+	filter func(key roachpb.Key, rawValue []byte) bool
 	// Stop adding intents and abort scan once maxLockConflicts threshold is
 	// reached. This limit is only applicable to consistent scans since they
 	// return intents as an error.
@@ -825,7 +828,7 @@ func (p *pebbleMVCCScanner) getOne(ctx context.Context) (ok, added bool) {
 		if p.curUnsafeKey.Timestamp.Less(p.ts) {
 			// 1. Fast path: there is no intent and our read timestamp is newer
 			// than the most recent version's timestamp.
-			return p.add(ctx, p.curUnsafeKey.Key, p.curRawKey, p.curUnsafeValue.Value.RawBytes)
+			return p.add(ctx, p.curUnsafeKey.Key, p.curRawKey, p.curUnsafeValueRawBytes())
 		}
 
 		// ts == read_ts
@@ -859,7 +862,7 @@ func (p *pebbleMVCCScanner) getOne(ctx context.Context) (ok, added bool) {
 
 			// 3. There is no intent and our read timestamp is equal to the most
 			// recent version's timestamp.
-			return p.add(ctx, p.curUnsafeKey.Key, p.curRawKey, p.curUnsafeValue.Value.RawBytes)
+			return p.add(ctx, p.curUnsafeKey.Key, p.curRawKey, p.curUnsafeValueRawBytes())
 		}
 
 		// ts > read_ts
@@ -1044,7 +1047,7 @@ func (p *pebbleMVCCScanner) getOne(ctx context.Context) (ok, added bool) {
 			if p.err != nil {
 				return false, false
 			}
-			return p.add(ctx, p.curUnsafeKey.Key, p.keyBuf, p.curUnsafeValue.Value.RawBytes)
+			return p.add(ctx, p.curUnsafeKey.Key, p.keyBuf, p.curUnsafeValueRawBytes())
 		}
 		// 14. If no value in the intent history has a sequence number equal to
 		// or less than the read, we must ignore the intents laid down by the
@@ -1228,6 +1231,11 @@ func (p *pebbleMVCCScanner) add(
 		return true /* ok */, false
 	}
 
+	// Don't include the kv pairs filtered out by the scan filter.
+	if p.filter != nil && !p.filter(key, rawValue) {
+		return true /* ok */, false
+	}
+
 	// If the scanner has been configured with the skipLocked option, don't
 	// include locked keys in the result set. Consult the in-memory lock table to
 	// determine whether this is locked with an unreplicated lock. Replicated
@@ -1387,7 +1395,7 @@ func (p *pebbleMVCCScanner) seekVersion(
 				if rkv, ok := p.coveredByRangeKey(p.curUnsafeKey.Timestamp); ok {
 					return p.addSynthetic(ctx, p.curUnsafeKey.Key, rkv)
 				}
-				return p.add(ctx, p.curUnsafeKey.Key, p.curRawKey, p.curUnsafeValue.Value.RawBytes)
+				return p.add(ctx, p.curUnsafeKey.Key, p.curRawKey, p.curUnsafeValueRawBytes())
 			}
 			// Iterate through uncertainty interval. Though we found a value in
 			// the interval, it may not be uncertainty. This is because seekTS
@@ -1429,7 +1437,7 @@ func (p *pebbleMVCCScanner) seekVersion(
 			if rkv, ok := p.coveredByRangeKey(p.curUnsafeKey.Timestamp); ok {
 				return p.addSynthetic(ctx, p.curUnsafeKey.Key, rkv)
 			}
-			return p.add(ctx, p.curUnsafeKey.Key, p.curRawKey, p.curUnsafeValue.Value.RawBytes)
+			return p.add(ctx, p.curUnsafeKey.Key, p.curRawKey, p.curUnsafeValueRawBytes())
 		}
 		// Iterate through uncertainty interval. See the comment above about why
 		// a value in this interval is not necessarily cause for an uncertainty
@@ -1620,6 +1628,16 @@ func (p *pebbleMVCCScanner) decodeCurrentMetadata() bool {
 	return true
 }
 
+// curUnsafeValueRawBytes returns the raw bytes of the current decoded value,
+// or nil, i.e. a deletion tombstone, if the value expired at the read
+// timestamp.
+func (p *pebbleMVCCScanner) curUnsafeValueRawBytes() []byte {
+	if p.curUnsafeValue.IsExpired(p.ts) {
+		return nil
+	}
+	return p.curUnsafeValue.Value.RawBytes
+}
+
 //gcassert:inline
 func (p *pebbleMVCCScanner) tryDecodeCurrentValueSimple(v []byte) (extended, valid bool) {
 	var simple bool
@@ -20,11 +20,12 @@ import (
 )
 
 func computeStoreProperties(
-	ctx context.Context, dir string, readonly bool, encryptionEnabled bool,
+	ctx context.Context, dir string, readonly bool, encryptionEnabled bool, nonVoting bool,
 ) roachpb.StoreProperties {
 	props := roachpb.StoreProperties{
 		ReadOnly:  readonly,
 		Encrypted: encryptionEnabled,
+		NonVoting: nonVoting,
 	}
 
 	// In-memory store?
@@ -58,3 +58,12 @@ func (p *Pacer) Close() {
 	p.wq.AdmittedWorkDone(p.cur)
 	p.cur = nil
 }
+
+// Fork returns a new Pacer for work which is done concurrently with the work
+// paced by p, on behalf of the same request. It is nil if p is nil.
+func (p *Pacer) Fork() *Pacer {
+	if p == nil {
+		return nil
+	}
+	return &Pacer{unit: p.unit, wi: p.wi, wq: p.wq}
+}
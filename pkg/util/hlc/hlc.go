@@ -184,6 +184,46 @@ func (m *HybridManualClock) Resume() {
 	m.mu.Unlock()
 }
 
+// OffsetClock is a WallClock which reads another WallClock shifted by an
+// adjustable offset. It can be used to simulate the clock skew of a node in a
+// multi-node test cluster, by passing a different OffsetClock to each node,
+// e.g. through server.TestingKnobs.WallClock.
+//
+// OffsetClock is thread safe.
+type OffsetClock struct {
+	wallClock WallClock
+	// offsetNanos is accessed atomically.
+	offsetNanos int64
+}
+
+// NewOffsetClock returns a new OffsetClock reading the given WallClock, or the
+// system clock if nil, with no offset.
+func NewOffsetClock(wallClock WallClock) *OffsetClock {
+	if wallClock == nil {
+		wallClock = timeutil.DefaultTimeSource{}
+	}
+	return &OffsetClock{wallClock: wallClock}
+}
+
+var _ WallClock = &OffsetClock{}
+
+// Now implements the WallClock interface.
+func (c *OffsetClock) Now() time.Time {
+	return c.wallClock.Now().Add(c.Offset())
+}
+
+// SetOffset sets the offset of the clock. A negative offset makes the clock
+// lag behind the underlying clock. Note that changing the offset can make the
+// clock go backwards.
+func (c *OffsetClock) SetOffset(offset time.Duration) {
+	atomic.StoreInt64(&c.offsetNanos, int64(offset))
+}
+
+// Offset returns the offset of the clock.
+func (c *OffsetClock) Offset() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.offsetNanos))
+}
+
 // NewClockWithSystemTimeSource creates a Clock that reads the system time. This
 // is equivalent to NewClock(timeutil.SystemTimeSource, maxOffset, toleratedOffset).
 func NewClockWithSystemTimeSource(maxOffset, toleratedOffset time.Duration) *Clock {
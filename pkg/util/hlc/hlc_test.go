@@ -405,6 +405,27 @@ func TestHybridManualClockPause(t *testing.T) {
 	require.Less(t, trueNow+inc, c.Now().WallTime)
 }
 
+func TestOffsetClock(t *testing.T) {
+	m := timeutil.NewManualTime(timeutil.Unix(0, 1000))
+	o := NewOffsetClock(m)
+	require.Equal(t, m.Now(), o.Now())
+	o.SetOffset(500 * time.Nanosecond)
+	require.Equal(t, 500*time.Nanosecond, o.Offset())
+	require.Equal(t, int64(1500), o.Now().UnixNano())
+	m.Advance(100)
+	require.Equal(t, int64(1600), o.Now().UnixNano())
+	o.SetOffset(-500 * time.Nanosecond)
+	require.Equal(t, int64(600), o.Now().UnixNano())
+
+	// Clocks with different offsets diverge, as the clocks of different nodes
+	// would.
+	c1 := NewClock(NewOffsetClock(m), 0 /* maxOffset */, 0 /* toleratedOffset */)
+	skewed := NewOffsetClock(m)
+	skewed.SetOffset(time.Second)
+	c2 := NewClock(skewed, 0 /* maxOffset */, 0 /* toleratedOffset */)
+	require.Equal(t, time.Second.Nanoseconds(), c2.Now().WallTime-c1.Now().WallTime)
+}
+
 func TestHLCMonotonicityCheck(t *testing.T) {
 	m := timeutil.NewManualTime(timeutil.Unix(0, 100000))
 	c := NewClock(m, 100*time.Nanosecond, 100*time.Nanosecond)